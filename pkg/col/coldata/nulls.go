@@ -10,6 +10,8 @@
 
 package coldata
 
+import "math/bits"
+
 // zeroedNulls is a zeroed out slice representing a bitmap of size MaxBatchSize.
 // This is copied to efficiently set all nulls.
 var zeroedNulls [(MaxBatchSize-1)/8 + 1]byte
@@ -181,6 +183,25 @@ func (n *Nulls) NullAt(i int) bool {
 	return n.nulls[i>>3]&bitMask[i&7] == 0
 }
 
+// NullCount returns the number of null values among the first length values
+// of the column.
+func (n *Nulls) NullCount(length int) int {
+	if !n.maybeHasNulls || length == 0 {
+		return 0
+	}
+	// The bits are set for the non-null values, so we count those in the full
+	// bytes of the bitmap and then in the remaining bits of the last byte.
+	numFullBytes := length >> 3
+	nonNulls := 0
+	for _, b := range n.nulls[:numFullBytes] {
+		nonNulls += bits.OnesCount8(b)
+	}
+	if rem := length & 7; rem != 0 {
+		nonNulls += bits.OnesCount8(n.nulls[numFullBytes] & (bitMask[rem] - 1))
+	}
+	return length - nonNulls
+}
+
 // SetNull sets the ith value of the column to null.
 func (n *Nulls) SetNull(i int) {
 	n.maybeHasNulls = true
@@ -370,6 +391,30 @@ func (n *Nulls) Or(n2 *Nulls) *Nulls {
 	return res
 }
 
+// SetNullsFrom sets all values in [0, length) that are null in other to null
+// in n. The values that are not null in other as well as the values past
+// length are left unchanged. Unlike Or, it updates n in place a byte at a time.
+// It is assumed that both n and other have enough capacity to store length
+// number of elements.
+func (n *Nulls) SetNullsFrom(other *Nulls, length int) {
+	if !other.maybeHasNulls || length == 0 {
+		return
+	}
+	n.maybeHasNulls = true
+	numFullBytes := length / 8
+	dst := n.nulls[:numFullBytes]
+	src := other.nulls[:len(dst)]
+	for i := range dst {
+		//gcassert:bce
+		dst[i] &= src[i]
+	}
+	if endBits := length % 8; endBits != 0 {
+		// Only the first endBits bits of the final byte are within the range,
+		// so we treat the remaining bits of other as valid.
+		n.nulls[numFullBytes] &= other.nulls[numFullBytes] | (onesMask << endBits)
+	}
+}
+
 // makeCopy returns a copy of n which can be modified independently.
 func (n *Nulls) makeCopy() Nulls {
 	c := Nulls{
@@ -73,6 +73,26 @@ func TestNullAt(t *testing.T) {
 	}
 }
 
+func TestNullCount(t *testing.T) {
+	for _, length := range pos {
+		expected3, expected5 := 0, 0
+		for i := 0; i < length; i++ {
+			if i%3 == 0 {
+				expected3++
+			}
+			if i%5 == 0 {
+				expected5++
+			}
+		}
+		require.Equal(t, expected3, nulls3.NullCount(length), "length=%d", length)
+		require.Equal(t, expected5, nulls5.NullCount(length), "length=%d", length)
+		require.Equal(t, 0, noNulls.NullCount(length), "length=%d", length)
+		n := NewNulls(BatchSize())
+		n.SetNulls()
+		require.Equal(t, length, n.NullCount(length), "length=%d", length)
+	}
+}
+
 func TestSetNullRange(t *testing.T) {
 	for _, start := range pos {
 		for _, end := range pos {
@@ -255,3 +275,28 @@ func TestNullsOr(t *testing.T) {
 		}
 	}
 }
+
+func TestNullsSetNullsFrom(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	randomNulls := NewNulls(BatchSize())
+	for i := 0; i < BatchSize(); i++ {
+		if rng.Float64() < 0.5 {
+			randomNulls.SetNull(i)
+		}
+	}
+	nullsToChooseFrom := []Nulls{noNulls, nulls3, nulls5, nulls10, randomNulls}
+
+	for _, length := range append([]int{rng.Intn(BatchSize() + 1)}, pos...) {
+		dst := nullsToChooseFrom[rng.Intn(len(nullsToChooseFrom))].makeCopy()
+		orig := dst.makeCopy()
+		other := nullsToChooseFrom[rng.Intn(len(nullsToChooseFrom))]
+		dst.SetNullsFrom(&other, length)
+		for i := 0; i < BatchSize(); i++ {
+			expected := orig.NullAt(i) || (i < length && other.NullAt(i))
+			require.Equal(t, expected, dst.NullAt(i), "length=%d, i=%d", length, i)
+		}
+		if expected := orig.maybeHasNulls || (length > 0 && other.maybeHasNulls); expected {
+			require.True(t, dst.maybeHasNulls)
+		}
+	}
+}
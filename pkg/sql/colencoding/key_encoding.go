@@ -15,6 +15,7 @@ import (
 
 	"github.com/cockroachdb/apd/v2"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -24,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/errors"
 )
@@ -304,6 +306,81 @@ func decodeTableKeyToCol(
 	return rkey, false, err
 }
 
+// EncodeTableKeyFromCol appends the key encoding of the value at the idx'th
+// slot of the input colexec.Vec to b and returns the resulting buffer. The
+// encoding is the same as the one produced by EncodeTableKey, so the result
+// can be decoded by DecodeKeyValsToCols.
+// See the analog, EncodeTableKey, in sqlbase/column_type_encoding.go.
+func EncodeTableKeyFromCol(
+	b []byte, vec coldata.Vec, idx int, valType *types.T, dir encoding.Direction,
+) ([]byte, error) {
+	if (dir != encoding.Ascending) && (dir != encoding.Descending) {
+		return nil, errors.AssertionFailedf("invalid direction: %d", log.Safe(dir))
+	}
+	if vec.MaybeHasNulls() && vec.Nulls().NullAt(idx) {
+		if dir == encoding.Ascending {
+			return encoding.EncodeNullAscending(b), nil
+		}
+		return encoding.EncodeNullDescending(b), nil
+	}
+
+	switch valType.Family() {
+	case types.BoolFamily:
+		var i int64
+		if vec.Bool()[idx] {
+			i = 1
+		}
+		if dir == encoding.Ascending {
+			return encoding.EncodeVarintAscending(b, i), nil
+		}
+		return encoding.EncodeVarintDescending(b, i), nil
+	case types.IntFamily, types.DateFamily:
+		var i int64
+		switch valType.Width() {
+		case 16:
+			i = int64(vec.Int16()[idx])
+		case 32:
+			i = int64(vec.Int32()[idx])
+		case 0, 64:
+			i = vec.Int64()[idx]
+		}
+		if dir == encoding.Ascending {
+			return encoding.EncodeVarintAscending(b, i), nil
+		}
+		return encoding.EncodeVarintDescending(b, i), nil
+	case types.FloatFamily:
+		if dir == encoding.Ascending {
+			return encoding.EncodeFloatAscending(b, vec.Float64()[idx]), nil
+		}
+		return encoding.EncodeFloatDescending(b, vec.Float64()[idx]), nil
+	case types.DecimalFamily:
+		if dir == encoding.Ascending {
+			return encoding.EncodeDecimalAscending(b, &vec.Decimal()[idx]), nil
+		}
+		return encoding.EncodeDecimalDescending(b, &vec.Decimal()[idx]), nil
+	case types.BytesFamily, types.StringFamily, types.UuidFamily:
+		if dir == encoding.Ascending {
+			return encoding.EncodeBytesAscending(b, vec.Bytes().Get(idx)), nil
+		}
+		return encoding.EncodeBytesDescending(b, vec.Bytes().Get(idx)), nil
+	case types.TimestampFamily, types.TimestampTZFamily:
+		if dir == encoding.Ascending {
+			return encoding.EncodeTimeAscending(b, vec.Timestamp()[idx]), nil
+		}
+		return encoding.EncodeTimeDescending(b, vec.Timestamp()[idx]), nil
+	case types.IntervalFamily:
+		if dir == encoding.Ascending {
+			return encoding.EncodeDurationAscending(b, vec.Interval()[idx])
+		}
+		return encoding.EncodeDurationDescending(b, vec.Interval()[idx])
+	case types.JsonFamily:
+		return nil, unimplemented.NewWithIssue(35706, "unable to encode JSON as a table key")
+	default:
+		d := vec.Datum().Get(idx).(*coldataext.Datum).Datum
+		return rowenc.EncodeTableKey(b, d, dir)
+	}
+}
+
 // UnmarshalColumnValueToCol decodes the value from a roachpb.Value using the
 // type expected by the column, writing into the input Vec at the given row
 // idx. An error is returned if the value's type does not match the column's
@@ -13,8 +13,10 @@ package colexec
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/apd/v2"
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
@@ -447,6 +449,92 @@ var aggregatorsTestCases = []aggregatorTestCase{
 			{8, nil, nil},
 		},
 	},
+	{
+		// This test case exercises the short-circuiting of bool_and and
+		// bool_or when the result of the group is determined before all its
+		// tuples have been seen.
+		name: "BoolAndOrShortCircuit",
+		typs: []*types.T{types.Int, types.Bool},
+		input: colexectestutils.Tuples{
+			{0, false},
+			{0, true},
+			{0, nil},
+			{0, true},
+			{1, true},
+			{1, false},
+			{1, nil},
+			{1, false},
+			{2, nil},
+			{2, false},
+			{2, true},
+			{2, nil},
+			{3, nil},
+			{3, true},
+			{3, nil},
+			{4, nil},
+			{4, nil},
+			{4, nil},
+			{5, nil},
+			{5, false},
+			{5, false},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.BoolAnd,
+			execinfrapb.BoolOr,
+		},
+		expected: colexectestutils.Tuples{
+			{0, false, true},
+			{1, false, true},
+			{2, false, true},
+			{3, true, true},
+			{4, nil, nil},
+			{5, false, false},
+		},
+	},
+	{
+		// Groups 1 and 2 consist of a single value, so the results match the
+		// value only if the folds start out from the identity values (all ones
+		// for bit_and and zero for bit_or and bit_xor).
+		name: "BitAndOrXor",
+		typs: []*types.T{types.Int, types.Int2, types.Int4, types.Int},
+		input: colexectestutils.Tuples{
+			{0, 12, 12, 12},
+			{0, 10, 10, 10},
+			{0, nil, nil, nil},
+			{0, 7, 7, 7},
+			{1, -1, -1, -1},
+			{2, 0, 0, 0},
+			{3, nil, nil, nil},
+			{3, nil, nil, nil},
+			{4, 5, 5, 5},
+			{4, nil, nil, nil},
+			{4, 5, 5, 5},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}, {1}, {2}, {2}, {2}, {3}, {3}, {3}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.BitAnd,
+			execinfrapb.BitOr,
+			execinfrapb.BitXor,
+			execinfrapb.BitAnd,
+			execinfrapb.BitOr,
+			execinfrapb.BitXor,
+			execinfrapb.BitAnd,
+			execinfrapb.BitOr,
+			execinfrapb.BitXor,
+		},
+		expected: colexectestutils.Tuples{
+			{0, 0, 15, 1, 0, 15, 1, 0, 15, 1},
+			{1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+			{2, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			{3, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+			{4, 5, 5, 0, 5, 5, 0, 5, 5, 0},
+		},
+	},
 	{
 		name: "MultiGroupColsWithPointerTypes",
 		typs: []*types.T{types.Int, types.Decimal, types.Bytes, types.Decimal},
@@ -522,6 +610,134 @@ var aggregatorsTestCases = []aggregatorTestCase{
 			{0, 3.0, 3.0, 3.0, 3.0, 3.0, duration.MakeDuration(3, 3, 3)},
 		},
 	},
+	{
+		// The components of the intervals are summed up separately without
+		// being normalized (i.e. 25 hours don't become a day and an hour),
+		// and the average spills the fractions of the months and the days
+		// into the smaller components, same as in the row engine.
+		name: "SUM and AVG on intervals",
+		typs: []*types.T{types.Int, types.Interval},
+		input: colexectestutils.Tuples{
+			{0, duration.MakeDuration(23*time.Hour.Nanoseconds(), 0, 0)},
+			{0, nil},
+			{0, duration.MakeDuration(2*time.Hour.Nanoseconds(), 20, 11)},
+			{0, duration.MakeDuration(0, 15, 2)},
+			{1, nil},
+			{1, nil},
+			{2, nil},
+			{2, duration.MakeDuration(-time.Hour.Nanoseconds(), 1, -1)},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.Sum,
+			execinfrapb.Avg,
+		},
+		expected: colexectestutils.Tuples{
+			{0, duration.MakeDuration(25*time.Hour.Nanoseconds(), 35, 13), duration.MakeDuration(25*time.Hour.Nanoseconds(), 35, 13).Div(3)},
+			{1, nil, nil},
+			{2, duration.MakeDuration(-time.Hour.Nanoseconds(), 1, -1), duration.MakeDuration(-time.Hour.Nanoseconds(), 1, -1)},
+		},
+	},
+	{
+		name: "VarianceStddevFloats",
+		typs: []*types.T{types.Int, types.Float},
+		input: colexectestutils.Tuples{
+			{0, 1.0},
+			{0, nil},
+			{0, 2.0},
+			{0, 4.0},
+			{1, 5.0},
+			{1, nil},
+			{2, nil},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}, {1}, {1}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.Variance,
+			execinfrapb.VarPop,
+			execinfrapb.Stddev,
+			execinfrapb.StddevPop,
+		},
+		expected: colexectestutils.Tuples{
+			{0, 7.0 / 3, 14.0 / 9, math.Sqrt(7.0 / 3), math.Sqrt(14.0 / 9)},
+			{1, nil, 0.0, nil, 0.0},
+			{2, nil, nil, nil, nil},
+		},
+	},
+	{
+		name: "VarianceStddevDecimals",
+		typs: []*types.T{types.Int, types.Decimal},
+		input: colexectestutils.Tuples{
+			{0, 1.0},
+			{0, nil},
+			{0, 3.0},
+			{1, 5.0},
+			{2, nil},
+			{3, 2.0},
+			{3, 4.0},
+			{3, 6.0},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}, {1}, {1}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.Variance,
+			execinfrapb.VarPop,
+			execinfrapb.Stddev,
+			execinfrapb.StddevPop,
+		},
+		expected: colexectestutils.Tuples{
+			{0, "2", "1", "1.4142135623730950488", "1"},
+			{1, nil, "0", nil, "0"},
+			{2, nil, nil, nil, nil},
+			{3, "4", "2.6666666666666666667", "2", "1.6329931618554520655"},
+		},
+		convToDecimal: true,
+	},
+	{
+		// Group 0 follows the linear relationship y = 2x + 1, group 1 has a
+		// single pair of non-NULL values, and group 3 has a constant y.
+		name: "RegressionAggregates",
+		typs: []*types.T{types.Int, types.Float, types.Int},
+		input: colexectestutils.Tuples{
+			{0, 3.0, 1},
+			{0, 5.0, 2},
+			{0, nil, 5},
+			{0, 7.0, 3},
+			{0, 9.0, 4},
+			{1, 7.0, 3},
+			{1, 1.0, nil},
+			{2, nil, nil},
+			{3, 5.0, 1},
+			{3, 5.0, 2},
+			{3, 5.0, 3},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}, {1, 2}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.Corr,
+			execinfrapb.CovarPop,
+			execinfrapb.CovarSamp,
+			execinfrapb.RegrAvgx,
+			execinfrapb.RegrAvgy,
+			execinfrapb.RegrIntercept,
+			execinfrapb.RegrR2,
+			execinfrapb.RegrSlope,
+			execinfrapb.RegrSxx,
+			execinfrapb.RegrSxy,
+			execinfrapb.RegrSyy,
+		},
+		expected: colexectestutils.Tuples{
+			{0, 1.0, 2.5, 10.0 / 3, 2.5, 6.0, 1.0, 1.0, 2.0, 5.0, 10.0, 20.0},
+			{1, nil, 0.0, nil, 3.0, 7.0, nil, nil, nil, 0.0, 0.0, 0.0},
+			{2, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+			{3, nil, 0.0, 0.0, 2.0, 5.0, 5.0, 1.0, 0.0, 2.0, 0.0, 0.0},
+		},
+	},
 	{
 		name: "ConcatAgg",
 		typs: []*types.T{types.Int, types.Bytes},
@@ -551,6 +767,115 @@ var aggregatorsTestCases = []aggregatorTestCase{
 			{4, nil},
 		},
 	},
+	{
+		name: "JSONAgg",
+		typs: []*types.T{types.Int, types.Int, types.String},
+		input: colexectestutils.Tuples{
+			{1, 1, "a"},
+			{1, nil, "b"},
+			{1, 3, nil},
+			{2, nil, nil},
+			{2, nil, nil},
+			{3, 5, "c"},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {2}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.JSONAgg,
+			execinfrapb.JSONBAgg,
+		},
+		expected: colexectestutils.Tuples{
+			{1, `[1, null, 3]`, `["a", "b", null]`},
+			{2, `[null, null]`, `[null, null]`},
+			{3, `[5]`, `["c"]`},
+		},
+	},
+	{
+		name: "ApproxCountDistinct",
+		typs: []*types.T{types.Int, types.Bytes, types.Decimal},
+		input: colexectestutils.Tuples{
+			{1, "a", "1.0"},
+			{1, "b", "1.00"},
+			{1, "a", "2"},
+			{2, nil, nil},
+			{2, "a", nil},
+			{3, nil, nil},
+			{4, "c", "3"},
+			{4, "d", "3.0"},
+			{4, "e", "-3"},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {2}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.ApproxCountDistinct,
+			execinfrapb.ApproxCountDistinct,
+		},
+		expected: colexectestutils.Tuples{
+			{1, 2, 2},
+			{2, 1, 0},
+			{3, 0, 0},
+			{4, 3, 2},
+		},
+		convToDecimal: true,
+	},
+	{
+		name: "ApproxPercentile",
+		typs: []*types.T{types.Int, types.Float, types.Float},
+		input: colexectestutils.Tuples{
+			{1, 3.0, 0.5},
+			{1, 1.0, 0.5},
+			{1, 4.0, 0.5},
+			{1, 2.0, 0.5},
+			{2, nil, 0.5},
+			{3, 30.0, nil},
+			{3, 20.0, 0.0},
+			{3, 10.0, 0.0},
+			{4, 5.0, 1.0},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1, 2}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.ApproxPercentile,
+		},
+		expected: colexectestutils.Tuples{
+			{1, 2.5},
+			{2, nil},
+			{3, 10.0},
+			{4, 5.0},
+		},
+	},
+	{
+		name: "Mode",
+		typs: []*types.T{types.Int, types.Int, types.Bytes, types.Decimal},
+		input: colexectestutils.Tuples{
+			{1, 3, "b", "1.0"},
+			{1, 2, "a", "2"},
+			{1, 3, "a", "1.00"},
+			{1, 2, "b", "2.0"},
+			{2, nil, nil, nil},
+			{2, 5, "c", nil},
+			{2, 7, "d", "3"},
+			{2, 7, "c", "3.0"},
+			{3, nil, nil, nil},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {2}, {3}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.ModeImpl,
+			execinfrapb.ModeImpl,
+			execinfrapb.ModeImpl,
+		},
+		expected: colexectestutils.Tuples{
+			{1, 3, "b", "1.0"},
+			{2, 7, "c", "3"},
+			{3, nil, nil, nil},
+		},
+		convToDecimal: true,
+	},
 	{
 		name: "All",
 		typs: []*types.T{types.Int, types.Decimal, types.Int, types.Bool, types.Bytes},
@@ -736,6 +1061,37 @@ var aggregatorsTestCases = []aggregatorTestCase{
 		aggDistinct: []bool{false, false, true, true, false, true, true},
 		aggFilter:   []int{tree.NoColumnIdx, 2, tree.NoColumnIdx, 2, 2, tree.NoColumnIdx, 2},
 	},
+	{
+		name: "MultipleFilterColumns",
+		typs: []*types.T{types.Int, types.Int, types.Bool, types.Bool},
+		input: colexectestutils.Tuples{
+			{0, 1, true, false},
+			{0, 2, true, true},
+			{0, 2, false, true},
+			{0, nil, true, nil},
+			{0, 3, nil, true},
+			{1, 1, true, true},
+			{1, 1, false, nil},
+			{1, 4, true, false},
+		},
+		groupCols: []uint32{0},
+		aggCols:   [][]uint32{{0}, {1}, {1}, {}, {1}, {1}, {1}},
+		aggFns: []execinfrapb.AggregatorSpec_Func{
+			execinfrapb.AnyNotNull,
+			execinfrapb.SumInt,
+			execinfrapb.SumInt,
+			execinfrapb.CountRows,
+			execinfrapb.Count,
+			execinfrapb.SumInt,
+			execinfrapb.Count,
+		},
+		expected: colexectestutils.Tuples{
+			{0, 3, 7, 3, 2, 8, 2},
+			{1, 5, 1, 2, 1, 6, 2},
+		},
+		aggDistinct: []bool{false, false, false, false, true, false, true},
+		aggFilter:   []int{tree.NoColumnIdx, 2, 3, 2, 3, tree.NoColumnIdx, 2},
+	},
 }
 
 func init() {
@@ -791,6 +1147,111 @@ func TestAggregators(t *testing.T) {
 	}
 }
 
+// TestJSONAggregates verifies the JSON aggregates on groups that span multiple
+// batches as well as the handling of NULL keys by json_object_agg. It doesn't
+// use RunTests since the injected NULL keys would result in an error.
+func TestJSONAggregates(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	ctx := context.Background()
+	newAggregator := func(
+		agg aggType, input colexecop.Operator, typs []*types.T, aggCols [][]uint32, aggFns []execinfrapb.AggregatorSpec_Func,
+	) colexecop.Operator {
+		tc := aggregatorTestCase{typs: typs, groupCols: []uint32{0}, aggCols: aggCols, aggFns: aggFns}
+		require.NoError(t, tc.init())
+		constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+			&evalCtx, nil /* semaCtx */, tc.spec.Aggregations, tc.typs,
+		)
+		require.NoError(t, err)
+		op, err := agg.new(&colexecagg.NewAggregatorArgs{
+			Allocator:      testAllocator,
+			MemAccount:     testMemAcc,
+			Input:          input,
+			InputTypes:     tc.typs,
+			Spec:           tc.spec,
+			EvalCtx:        &evalCtx,
+			Constructors:   constructors,
+			ConstArguments: constArguments,
+			OutputTypes:    outputTypes,
+		})
+		require.NoError(t, err)
+		return op
+	}
+
+	typs := []*types.T{types.Int, types.String, types.Int}
+	aggCols := [][]uint32{{0}, {2}, {1, 2}}
+	aggFns := []execinfrapb.AggregatorSpec_Func{
+		execinfrapb.AnyNotNull,
+		execinfrapb.JSONAgg,
+		execinfrapb.JSONObjectAgg,
+	}
+
+	t.Run("multi-batch groups", func(t *testing.T) {
+		// The first group spans multiple batches.
+		numRows := 2*coldata.BatchSize() + 1
+		var input colexectestutils.Tuples
+		var expArray, expObject strings.Builder
+		expArray.WriteString("[")
+		expObject.WriteString("{")
+		for i := 0; i < numRows; i++ {
+			// The keys are zero-padded so that their order in the object is
+			// the same as the input order.
+			key := fmt.Sprintf("k%05d", i)
+			input = append(input, colexectestutils.Tuple{0, key, i})
+			if i > 0 {
+				expArray.WriteString(", ")
+				expObject.WriteString(", ")
+			}
+			fmt.Fprintf(&expArray, "%d", i)
+			fmt.Fprintf(&expObject, "%q: %d", key, i)
+		}
+		expArray.WriteString("]")
+		expObject.WriteString("}")
+		input = append(input,
+			colexectestutils.Tuple{1, "b", nil},
+			colexectestutils.Tuple{1, "a", 1},
+			colexectestutils.Tuple{2, "c", nil},
+		)
+		expected := colexectestutils.Tuples{
+			{0, expArray.String(), expObject.String()},
+			{1, `[null, 1]`, `{"a": 1, "b": null}`},
+			{2, `[null]`, `{"c": null}`},
+		}
+		for _, agg := range aggTypes {
+			t.Run(agg.name, func(t *testing.T) {
+				colexectestutils.RunTestsWithFn(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs},
+					func(t *testing.T, inputs []colexecop.Operator) {
+						op := newAggregator(agg, inputs[0], typs, aggCols, aggFns)
+						require.NoError(t, colexectestutils.NewOpTestOutput(op, expected).Verify())
+					})
+			})
+		}
+	})
+
+	t.Run("NULL key", func(t *testing.T) {
+		input := colexectestutils.Tuples{
+			{0, "a", 1},
+			{1, "b", 2},
+			{1, nil, 3},
+		}
+		for _, agg := range aggTypes {
+			t.Run(agg.name, func(t *testing.T) {
+				op := newAggregator(agg, colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), input, typs), typs, aggCols, aggFns)
+				op.Init(ctx)
+				err := colexecerror.CatchVectorizedRuntimeError(func() {
+					for b := op.Next(); b.Length() > 0; b = op.Next() {
+					}
+				})
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "field name must not be null")
+			})
+		}
+	})
+}
+
 func TestAggregatorRandom(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -1127,6 +1588,15 @@ func BenchmarkAllOptimizedAggregateFunctions(b *testing.B) {
 				aggInputTypes = []*types.T{types.Bool}
 			case execinfrapb.ConcatAgg:
 				aggInputTypes = []*types.T{types.Bytes}
+			case execinfrapb.Variance, execinfrapb.VarPop, execinfrapb.Stddev, execinfrapb.StddevPop:
+				aggInputTypes = []*types.T{types.Float}
+			case execinfrapb.Corr, execinfrapb.CovarPop, execinfrapb.CovarSamp,
+				execinfrapb.RegrAvgx, execinfrapb.RegrAvgy, execinfrapb.RegrIntercept,
+				execinfrapb.RegrR2, execinfrapb.RegrSlope, execinfrapb.RegrSxx,
+				execinfrapb.RegrSxy, execinfrapb.RegrSyy:
+				aggInputTypes = []*types.T{types.Float, types.Float}
+			case execinfrapb.JSONObjectAgg, execinfrapb.JSONBObjectAgg:
+				aggInputTypes = []*types.T{types.String, types.Int}
 			case execinfrapb.CountRows:
 			default:
 				aggInputTypes = []*types.T{types.Int}
@@ -81,9 +81,25 @@ func newAggregatorHelper(
 		}
 		return newDistinctOrderedAggregatorHelper(args, datumAlloc, maxBatchSize)
 	}
-	filters := make([]*filteringSingleFunctionHashHelper, len(args.Spec.Aggregations))
+	// Group the aggregate functions by their FILTER clauses so that each
+	// filter is applied only once, regardless of how many functions use it.
+	var filters []aggFnsWithFilter
 	for i, filterIdx := range aggFilter {
-		filters[i] = newFilteringHashAggHelper(args, filterIdx, maxBatchSize)
+		found := false
+		for j := range filters {
+			if filters[j].filterIdx == filterIdx {
+				filters[j].fnIdxs = append(filters[j].fnIdxs, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			filters = append(filters, aggFnsWithFilter{
+				filter:    newFilteringHashAggHelper(args, filterIdx, maxBatchSize),
+				filterIdx: filterIdx,
+				fnIdxs:    []int{i},
+			})
+		}
 	}
 	if !hasDistinct {
 		return newFilteringHashAggregatorHelper(args.Spec, filters, maxBatchSize)
@@ -196,19 +212,30 @@ func (h *filteringSingleFunctionHashHelper) applyFilter(
 	return newBatch.ColVecs(), newBatch.Length(), newBatch.Selection(), true
 }
 
+// aggFnsWithFilter describes all aggregate functions that have the same FILTER
+// clause (or that don't have one). The filter is applied once, and then all of
+// these functions are computed on the same selection of tuples.
+type aggFnsWithFilter struct {
+	filter *filteringSingleFunctionHashHelper
+	// filterIdx is the index of the filtering column or tree.NoColumnIdx.
+	filterIdx int
+	// fnIdxs are the indices of the aggregate functions in the spec.
+	fnIdxs []int
+}
+
 // filteringHashAggregatorHelper is an aggregatorHelper that handles the
 // aggregate functions which have at least one FILTER clause but no DISTINCT
 // clauses for the hash aggregation.
 type filteringHashAggregatorHelper struct {
 	*aggregatorHelperBase
 
-	filters []*filteringSingleFunctionHashHelper
+	filters []aggFnsWithFilter
 }
 
 var _ aggregatorHelper = &filteringHashAggregatorHelper{}
 
 func newFilteringHashAggregatorHelper(
-	spec *execinfrapb.AggregatorSpec, filters []*filteringSingleFunctionHashHelper, maxBatchSize int,
+	spec *execinfrapb.AggregatorSpec, filters []aggFnsWithFilter, maxBatchSize int,
 ) aggregatorHelper {
 	h := &filteringHashAggregatorHelper{
 		aggregatorHelperBase: newAggregatorHelperBase(spec, maxBatchSize),
@@ -225,13 +252,15 @@ func (h *filteringHashAggregatorHelper) performAggregation(
 	ctx context.Context, vecs []coldata.Vec, inputLen int, sel []int, bucket *aggBucket, _ []bool,
 ) {
 	h.saveState(vecs, inputLen, sel)
-	for fnIdx, fn := range bucket.fns {
+	for _, f := range h.filters {
 		var maybeModified bool
-		vecs, inputLen, sel, maybeModified = h.filters[fnIdx].applyFilter(ctx, vecs, inputLen, sel)
+		vecs, inputLen, sel, maybeModified = f.filter.applyFilter(ctx, vecs, inputLen, sel)
 		if inputLen > 0 {
 			// It is possible that all tuples to aggregate have been filtered
 			// out, so we need to check the length.
-			fn.Compute(vecs, h.spec.Aggregations[fnIdx].ColIdx, inputLen, sel)
+			for _, fnIdx := range f.fnIdxs {
+				bucket.fns[fnIdx].Compute(vecs, h.spec.Aggregations[fnIdx].ColIdx, inputLen, sel)
+			}
 		}
 		if maybeModified {
 			// Restore the state so that the next iteration sees the input with
@@ -388,14 +417,14 @@ func (b *distinctAggregatorHelperBase) selectDistinctTuples(
 type filteringDistinctHashAggregatorHelper struct {
 	*distinctAggregatorHelperBase
 
-	filters []*filteringSingleFunctionHashHelper
+	filters []aggFnsWithFilter
 }
 
 var _ aggregatorHelper = &filteringDistinctHashAggregatorHelper{}
 
 func newFilteringDistinctHashAggregatorHelper(
 	args *colexecagg.NewAggregatorArgs,
-	filters []*filteringSingleFunctionHashHelper,
+	filters []aggFnsWithFilter,
 	datumAlloc *rowenc.DatumAlloc,
 	maxBatchSize int,
 ) aggregatorHelper {
@@ -416,30 +445,36 @@ func newFilteringDistinctHashAggregatorHelper(
 // 1. Store the input state because we will be modifying some of it.
 // 2. Convert all aggregate columns of functions that perform DISTINCT
 //    aggregation.
-// 3. For every function:
+// 3. For every set of functions with the same FILTER clause:
 //    1) Apply the filter to the selection vector of the input.
-//    2) Update the (possibly updated) selection vector to include only tuples
-//       we haven't yet seen making sure to remember that new tuples we have
-//       just seen.
-//    3) Execute Compute on the updated state.
-//    4) Restore the state to the original state (if it might have been
+//    2) For every function in the set:
+//       - if the function performs DISTINCT aggregation, update the filtered
+//         selection vector to include only tuples we haven't yet seen making
+//         sure to remember that new tuples we have just seen.
+//       - Execute Compute on the updated state.
+//    3) Restore the state to the original state (if it might have been
 //       modified).
 func (h *filteringDistinctHashAggregatorHelper) performAggregation(
 	ctx context.Context, vecs []coldata.Vec, inputLen int, sel []int, bucket *aggBucket, _ []bool,
 ) {
 	h.saveState(vecs, inputLen, sel)
 	h.aggColsConverter.ConvertVecs(vecs, inputLen, sel)
-	for aggFnIdx, aggFn := range h.spec.Aggregations {
+	for _, f := range h.filters {
 		var maybeModified bool
-		vecs, inputLen, sel, maybeModified = h.filters[aggFnIdx].applyFilter(ctx, vecs, inputLen, sel)
-		if inputLen > 0 && aggFn.Distinct {
-			inputLen, sel = h.selectDistinctTuples(
-				ctx, inputLen, sel, aggFn.ColIdx, bucket.seen[aggFnIdx], nil, /* groups */
-			)
-			maybeModified = true
-		}
+		vecs, inputLen, sel, maybeModified = f.filter.applyFilter(ctx, vecs, inputLen, sel)
 		if inputLen > 0 {
-			bucket.fns[aggFnIdx].Compute(vecs, aggFn.ColIdx, inputLen, sel)
+			for _, aggFnIdx := range f.fnIdxs {
+				aggFn := &h.spec.Aggregations[aggFnIdx]
+				fnLen, fnSel := inputLen, sel
+				if aggFn.Distinct {
+					fnLen, fnSel = h.selectDistinctTuples(
+						ctx, inputLen, sel, aggFn.ColIdx, bucket.seen[aggFnIdx], nil, /* groups */
+					)
+				}
+				if fnLen > 0 {
+					bucket.fns[aggFnIdx].Compute(vecs, aggFn.ColIdx, fnLen, fnSel)
+				}
+			}
 		}
 		if maybeModified {
 			vecs, inputLen, sel = h.restoreState()
@@ -589,3 +624,14 @@ func (a *aggBucketAlloc) newAggBucket() *aggBucket {
 	a.buf = a.buf[1:]
 	return ret
 }
+
+// estimateMemSize returns the memory that will be registered with the
+// allocator when numBuckets new aggBuckets are requested.
+func (a *aggBucketAlloc) estimateMemSize(numBuckets int) int64 {
+	numBuckets -= len(a.buf)
+	if numBuckets <= 0 {
+		return 0
+	}
+	numAllocs := int64((numBuckets-1)/hashAggregatorAllocSize + 1)
+	return numAllocs * (aggBucketSliceOverhead + hashAggregatorAllocSize*sizeOfAggBucket)
+}
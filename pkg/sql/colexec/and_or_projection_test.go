@@ -212,7 +212,7 @@ func TestAndOrOps(t *testing.T) {
 						}
 						// We will project out the first two columns in order
 						// to have test cases be less verbose.
-						return colexecbase.NewSimpleProjectOp(projOp, 3 /* numInputCols */, []uint32{2}), nil
+						return colexecbase.NewSimpleProjectOp(projOp, []*types.T{types.Bool, types.Bool, types.Bool}, []uint32{2}), nil
 					})
 			}
 		})
@@ -0,0 +1,99 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/errors"
+)
+
+// andSelOp is an operator that selects the tuples for which all of the
+// predicates are true. Each predicate is represented by a chain of selection
+// operators that reads from the buffer.
+//
+// The predicates are evaluated in order on the same batch, and each predicate
+// narrows down the selection vector of the batch in place, so every predicate
+// only sees the tuples that have been selected by all of the previous ones.
+// Once some predicate selects no tuples, the remaining predicates are not
+// evaluated at all, and the operator moves onto the next batch.
+type andSelOp struct {
+	colexecop.InitHelper
+
+	buffer    *bufferOp
+	conjuncts []colexecop.Operator
+}
+
+var _ colexecop.Operator = &andSelOp{}
+
+// NewAndSelOp returns an operator that selects the tuples that satisfy all of
+// the predicates.
+// - buffer is a bufferOp that will return the input batch repeatedly.
+// - conjuncts are the chains of selection operators (connected to buffer) that
+// evaluate the predicates. They are evaluated in the given order.
+func NewAndSelOp(buffer colexecop.Operator, conjuncts []colexecop.Operator) colexecop.Operator {
+	return &andSelOp{
+		buffer:    buffer.(*bufferOp),
+		conjuncts: conjuncts,
+	}
+}
+
+func (a *andSelOp) ChildCount(verbose bool) int {
+	return 1 + len(a.conjuncts)
+}
+
+func (a *andSelOp) Child(nth int, verbose bool) execinfra.OpNode {
+	if nth == 0 {
+		return a.buffer
+	}
+	if nth <= len(a.conjuncts) {
+		return a.conjuncts[nth-1]
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("invalid idx %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (a *andSelOp) Init(ctx context.Context) {
+	if !a.InitHelper.Init(ctx) {
+		return
+	}
+	for _, conjunct := range a.conjuncts {
+		conjunct.Init(a.Ctx)
+	}
+}
+
+func (a *andSelOp) Next() coldata.Batch {
+	for {
+		a.buffer.advance()
+		batch := a.buffer.batch
+		if batch.Length() == 0 {
+			return coldata.ZeroBatch
+		}
+		for _, conjunct := range a.conjuncts {
+			// Every predicate modifies the selection vector of the buffered
+			// batch in place, so by rewinding the buffer we make only the
+			// tuples selected so far available to the next predicate.
+			a.buffer.rewind()
+			batch = conjunct.Next()
+			if batch.Length() == 0 {
+				break
+			}
+		}
+		if batch.Length() > 0 {
+			return batch
+		}
+	}
+}
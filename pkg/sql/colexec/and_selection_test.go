@@ -0,0 +1,150 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecsel"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAndSelOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	typs := []*types.T{types.Int, types.Int}
+	tuples := colexectestutils.Tuples{
+		{0, 0}, {1, 5}, {6, 1}, {7, 9}, {nil, 2}, {3, nil}, {nil, nil}, {10, 10}, {-1, 4},
+	}
+	for _, tc := range []struct {
+		filter   string
+		expected colexectestutils.Tuples
+	}{
+		{
+			filter:   "@1 > 5 AND @2 < 3",
+			expected: colexectestutils.Tuples{{6, 1}},
+		},
+		{
+			// The right side must not be evaluated on the tuples for which
+			// the left side is not true, so there is no division by zero.
+			filter:   "@1 <> 0 AND 10 / @1 > 2",
+			expected: colexectestutils.Tuples{{1, 5}, {3, nil}},
+		},
+		{
+			// The first conjunct doesn't select anything from most of the
+			// batches when the input is split into small batches.
+			filter:   "@1 = 7 AND @2 = 9",
+			expected: colexectestutils.Tuples{{7, 9}},
+		},
+		{
+			filter:   "@1 IS NOT NULL AND @2 IS NOT NULL AND @1 < @2 AND @2 < 10",
+			expected: colexectestutils.Tuples{{1, 5}, {7, 9}, {-1, 4}},
+		},
+		{
+			// Some conjuncts append columns to the batch.
+			filter:   "@1 + 1 > 1 AND @2 * 2 < 12 AND @1 - @2 < 0",
+			expected: colexectestutils.Tuples{{1, 5}},
+		},
+		{
+			filter:   "@1 > 0 AND (@2 < 3 OR @2 > 9) AND @1 < 10",
+			expected: colexectestutils.Tuples{{6, 1}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.filter)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return createTestFilterer(ctx, flowCtx, input[0], typs, tc.filter)
+			})
+	}
+}
+
+// rowCountingOp is a utility operator that counts the number of tuples it
+// returns.
+type rowCountingOp struct {
+	colexecop.OneInputHelper
+	numRows int
+}
+
+func (r *rowCountingOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	r.numRows += batch.Length()
+	return batch
+}
+
+func BenchmarkAndSelOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Int}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for _, vec := range batch.ColVecs() {
+		col := vec.Int64()
+		for i := 0; i < coldata.BatchSize(); i++ {
+			col[i] = rng.Int63n(1000)
+		}
+	}
+	batch.SetLength(coldata.BatchSize())
+	// The first predicate is '@1 < selectivity', the second one is '@2 < 500'.
+	for _, selectivity := range []int{10, 500, 990} {
+		b.Run(fmt.Sprintf("firstSelectivity=%d/1000", selectivity), func(b *testing.B) {
+			source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+			buffer := NewBufferOp(source)
+			first, err := colexecsel.GetSelectionConstOperator(
+				tree.LT, buffer, typs, 0 /* colIdx */, tree.NewDInt(tree.DInt(selectivity)), &evalCtx, nil, /* cmpExpr */
+			)
+			require.NoError(b, err)
+			// We count the number of tuples that the second predicate is
+			// evaluated on.
+			counter := &rowCountingOp{OneInputHelper: colexecop.MakeOneInputHelper(buffer)}
+			second, err := colexecsel.GetSelectionConstOperator(
+				tree.LT, counter, typs, 1 /* colIdx */, tree.NewDInt(500), &evalCtx, nil, /* cmpExpr */
+			)
+			require.NoError(b, err)
+			op := NewAndSelOp(buffer, []colexecop.Operator{first, second})
+			op.Init(ctx)
+			b.SetBytes(int64(len(typs) * 8 * coldata.BatchSize()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op.Next()
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(counter.numRows)/float64(b.N), "second-evaluated-rows/op")
+			b.ReportMetric(float64(coldata.BatchSize()), "input-rows/op")
+		})
+	}
+}
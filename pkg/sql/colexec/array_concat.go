@@ -0,0 +1,230 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// arrayConcatKind describes which of the array concatenation overloads is
+// evaluated.
+type arrayConcatKind int
+
+const (
+	// arrayConcatArrays concatenates two arrays.
+	arrayConcatArrays arrayConcatKind = iota
+	// arrayConcatAppend appends an element to the end of an array.
+	arrayConcatAppend
+	// arrayConcatPrepend prepends an element to the beginning of an array.
+	arrayConcatPrepend
+)
+
+// GetArrayConcatProjectionOperator returns an operator that projects the
+// result of the concatenation (||) of the arguments into the column at
+// position resultIdx. At least one of the arguments must be an array, and the
+// other one must be either an array or an element of the same type. The left
+// argument is the constant constLeft if it is non-nil or the column at
+// position leftIdx, and similarly for the right argument.
+func GetArrayConcatProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	leftType, rightType, outputType *types.T,
+	leftIdx, rightIdx int,
+	constLeft, constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	if outputType.Family() != types.ArrayFamily {
+		return nil, errors.Errorf("unsupported array concatenation output type %s", outputType)
+	}
+	var kind arrayConcatKind
+	elemType := outputType.ArrayContents()
+	switch {
+	case outputType.Equivalent(leftType) && outputType.Equivalent(rightType):
+		kind = arrayConcatArrays
+	case outputType.Equivalent(leftType) && elemType.Equivalent(rightType):
+		kind = arrayConcatAppend
+	case elemType.Equivalent(leftType) && outputType.Equivalent(rightType):
+		kind = arrayConcatPrepend
+	default:
+		// Note that this also covers the untyped NULL arguments.
+		return nil, errors.Errorf(
+			"unsupported array concatenation of %s and %s into %s", leftType, rightType, outputType,
+		)
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, resultIdx)
+	return &arrayConcatProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		kind:           kind,
+		elemType:       elemType,
+		left:           arrayConcatArg{colIdx: leftIdx, constArg: constLeft},
+		right:          arrayConcatArg{colIdx: rightIdx, constArg: constRight},
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// arrayConcatArg is one of the arguments of the array concatenation.
+type arrayConcatArg struct {
+	// colIdx is only used if constArg is nil.
+	colIdx   int
+	constArg tree.Datum
+	// converted is the scratch space for the datum representation of the
+	// argument column. It is reused across batches.
+	converted []tree.Datum
+}
+
+// convert prepares the datum representation of the argument for the current
+// batch. It must be called before get.
+func (a *arrayConcatArg) convert(batch coldata.Batch, n int, da *rowenc.DatumAlloc) {
+	if a.constArg != nil {
+		return
+	}
+	// ColVecToDatum doesn't perform the deselection, so we need the space
+	// up to the last selected row.
+	size := n
+	if sel := batch.Selection(); sel != nil {
+		size = sel[n-1] + 1
+	}
+	if cap(a.converted) < size {
+		a.converted = make([]tree.Datum, size)
+	} else {
+		a.converted = a.converted[:size]
+	}
+	colconv.ColVecToDatum(a.converted, batch.ColVec(a.colIdx), n, batch.Selection(), da)
+}
+
+func (a *arrayConcatArg) get(rowIdx int) tree.Datum {
+	if a.constArg != nil {
+		return a.constArg
+	}
+	return a.converted[rowIdx]
+}
+
+// arrayLen returns the number of elements in d which is expected to be either
+// an array or NULL.
+func arrayLen(d tree.Datum) int {
+	if d == tree.DNull {
+		return 0
+	}
+	return tree.MustBeDArray(d).Len()
+}
+
+// arrayConcatProjOp is an operator that evaluates the array concatenation the
+// same way as the row engine does. Note that a NULL array is treated as an
+// empty one, so the result is NULL only if both arrays are NULL, and that a
+// NULL element is concatenated as is.
+type arrayConcatProjOp struct {
+	colexecop.OneInputHelper
+	allocator   *colmem.Allocator
+	kind        arrayConcatKind
+	elemType    *types.T
+	left, right arrayConcatArg
+	outputIdx   int
+	da          rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &arrayConcatProjOp{}
+
+func (o *arrayConcatProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.left.convert(batch, n, &o.da)
+	o.right.convert(batch, n, &o.da)
+	// All result arrays of this batch share a single buffer which is sized
+	// upfront, so we allocate the elements only once per batch. The buffer
+	// cannot be reused across batches since the results are referenced by the
+	// output vector.
+	var bufSize int
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		bufSize += o.resultLen(o.left.get(rowIdx), o.right.get(rowIdx))
+	}
+	buf := make(tree.Datums, bufSize)
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Datum()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			left, right := o.left.get(rowIdx), o.right.get(rowIdx)
+			if o.kind == arrayConcatArrays && left == tree.DNull && right == tree.DNull {
+				outputVec.Nulls().SetNull(rowIdx)
+				continue
+			}
+			resultLen := o.resultLen(left, right)
+			res := tree.NewDArray(o.elemType)
+			// Limit the capacity so that the Append calls never write past
+			// the part of the buffer reserved for this row.
+			res.Array = buf[:0:resultLen]
+			buf = buf[resultLen:]
+			o.appendArg(res, left, o.kind != arrayConcatPrepend /* isArray */)
+			o.appendArg(res, right, o.kind != arrayConcatAppend /* isArray */)
+			outputCol.Set(rowIdx, res)
+		}
+	})
+	return batch
+}
+
+// resultLen returns the number of elements in the result of the concatenation
+// of left and right.
+func (o *arrayConcatProjOp) resultLen(left, right tree.Datum) int {
+	switch o.kind {
+	case arrayConcatAppend:
+		return arrayLen(left) + 1
+	case arrayConcatPrepend:
+		return 1 + arrayLen(right)
+	default:
+		return arrayLen(left) + arrayLen(right)
+	}
+}
+
+// appendArg appends d to res. If isArray is true, then d is either an array
+// whose elements are appended or NULL which is ignored; otherwise, d itself is
+// appended.
+func (o *arrayConcatProjOp) appendArg(res *tree.DArray, d tree.Datum, isArray bool) {
+	if !isArray {
+		if err := res.Append(d); err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		return
+	}
+	if d == tree.DNull {
+		return
+	}
+	for _, e := range tree.MustBeDArray(d).Array {
+		if err := res.Append(e); err != nil {
+			colexecerror.ExpectedError(err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayConcat(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "arrays",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[3]"},
+				{"ARRAY[1,NULL]", "ARRAY[NULL,2]"},
+				{"ARRAY[]:::INT[]", "ARRAY[1]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				// A NULL array is treated as an empty one unless both arrays
+				// are NULL.
+				{nil, "ARRAY[1]"},
+				{"ARRAY[1]", nil},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{intArray, intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[3]", "ARRAY[1,2,3]"},
+				{"ARRAY[1,NULL]", "ARRAY[NULL,2]", "ARRAY[1,NULL,NULL,2]"},
+				{"ARRAY[]:::INT[]", "ARRAY[1]", "ARRAY[1]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				{nil, "ARRAY[1]", "ARRAY[1]"},
+				{"ARRAY[1]", nil, "ARRAY[1]"},
+				{nil, nil, nil},
+			},
+		},
+		{
+			desc: "append element",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3},
+				{"ARRAY[]:::INT[]", 1},
+				{"ARRAY[1]", nil},
+				{nil, 1},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3, "ARRAY[1,2,3]"},
+				{"ARRAY[]:::INT[]", 1, "ARRAY[1]"},
+				{"ARRAY[1]", nil, "ARRAY[1,NULL]"},
+				{nil, 1, "ARRAY[1]"},
+				{nil, nil, "ARRAY[NULL]:::INT[]"},
+			},
+		},
+		{
+			desc: "prepend element",
+			expr: "@2 || @1",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3},
+				{"ARRAY[]:::INT[]", 1},
+				{"ARRAY[1]", nil},
+				{nil, 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3, "ARRAY[3,1,2]"},
+				{"ARRAY[]:::INT[]", 1, "ARRAY[1]"},
+				{"ARRAY[1]", nil, "ARRAY[NULL,1]"},
+				{nil, 1, "ARRAY[1]"},
+			},
+		},
+		{
+			desc: "constants",
+			expr: "0 || (@1 || 3) || '{4,5}'",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]"}, {"ARRAY[]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[0,1,2,3,4,5]"},
+				{"ARRAY[]:::INT[]", "ARRAY[0,3,4,5]"},
+				{nil, "ARRAY[0,3,4,5]"},
+			},
+		},
+		{
+			desc: "narrow ints",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1:::INT2]", 2},
+			},
+			inputTypes: []*types.T{types.MakeArray(types.Int2), types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1:::INT2]", 2, "ARRAY[1:::INT2,2:::INT2]"},
+			},
+		},
+		{
+			desc: "strings",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY['a']", "b"},
+				{"ARRAY['a']", ""},
+			},
+			inputTypes: []*types.T{types.StringArray, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY['a']", "b", "ARRAY['a','b']"},
+				{"ARRAY['a']", "", "ARRAY['a','']"},
+			},
+		},
+		{
+			desc: "array_cat",
+			expr: "array_cat(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[3]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				{nil, "ARRAY[1]"},
+				{"ARRAY[1]", nil},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{intArray, intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[3]", "ARRAY[1,2,3]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				{nil, "ARRAY[1]", "ARRAY[1]"},
+				{"ARRAY[1]", nil, "ARRAY[1]"},
+				{nil, nil, nil},
+			},
+		},
+		{
+			desc: "array_append and array_prepend",
+			expr: "array_prepend(@2, array_append(@1, @2))",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3},
+				{"ARRAY[]:::INT[]", 1},
+				{"ARRAY[1]", nil},
+				{nil, 1},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 3, "ARRAY[3,1,2,3]"},
+				{"ARRAY[]:::INT[]", 1, "ARRAY[1,1]"},
+				{"ARRAY[1]", nil, "ARRAY[NULL,1,NULL]"},
+				{nil, 1, "ARRAY[1,1]"},
+				{nil, nil, "ARRAY[NULL,NULL]:::INT[]"},
+			},
+		},
+		{
+			desc: "builtins with constants",
+			expr: "array_cat(array_append(@1, NULL), array_prepend('x', '{}'))",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY['a']"}, {"ARRAY[]:::STRING[]"}, {nil},
+			},
+			inputTypes: []*types.T{types.StringArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY['a']", "ARRAY['a',NULL,'x']"},
+				{"ARRAY[]:::STRING[]", "ARRAY[NULL,'x']:::STRING[]"},
+				{nil, "ARRAY[NULL,'x']:::STRING[]"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
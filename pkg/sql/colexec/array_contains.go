@@ -0,0 +1,227 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// arrayContainsBase evaluates the array containment (either @> or <@) of the
+// array column at position leftIdx and either the array column at position
+// rightIdx or the constant array constRight.
+type arrayContainsBase struct {
+	evalCtx *tree.EvalContext
+	leftIdx int
+	// rightIdx is only used if constRight is nil.
+	rightIdx   int
+	constRight *tree.DArray
+	// leftIsHaystack indicates whether the left argument is the array that
+	// must contain all elements of the right argument (i.e. @> is used).
+	leftIsHaystack bool
+}
+
+func makeArrayContainsBase(
+	evalCtx *tree.EvalContext,
+	cmpOp tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (arrayContainsBase, error) {
+	if cmpOp != tree.Contains && cmpOp != tree.ContainedBy {
+		return arrayContainsBase{}, errors.AssertionFailedf("unexpected array containment operator %s", cmpOp)
+	}
+	b := arrayContainsBase{
+		evalCtx:        evalCtx,
+		leftIdx:        leftIdx,
+		rightIdx:       rightIdx,
+		leftIsHaystack: cmpOp == tree.Contains,
+	}
+	if constRight != nil {
+		arr, ok := constRight.(*tree.DArray)
+		if !ok {
+			return arrayContainsBase{}, errors.Errorf("unsupported array containment argument %s", constRight)
+		}
+		b.constRight = arr
+	}
+	return b, nil
+}
+
+// eval returns the result of the array containment on the row at position
+// rowIdx. The result is NULL if either of the arrays is NULL.
+func (b *arrayContainsBase) eval(leftVec, rightVec coldata.Vec, rowIdx int) (res bool, isNull bool) {
+	if leftVec.Nulls().NullAt(rowIdx) {
+		return false, true
+	}
+	left := tree.MustBeDArray(leftVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum)
+	right := b.constRight
+	if right == nil {
+		if rightVec.Nulls().NullAt(rowIdx) {
+			return false, true
+		}
+		right = tree.MustBeDArray(rightVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum)
+	}
+	haystack, needles := left, right
+	if !b.leftIsHaystack {
+		haystack, needles = right, left
+	}
+	contains, err := tree.ArrayContains(b.evalCtx, haystack, needles)
+	if err != nil {
+		colexecerror.ExpectedError(err)
+	}
+	return bool(*contains), false
+}
+
+// vecs returns the vectors of the arguments. The right vector is nil if the
+// right argument is constant.
+func (b *arrayContainsBase) vecs(batch coldata.Batch) (leftVec, rightVec coldata.Vec) {
+	leftVec = batch.ColVec(b.leftIdx)
+	if b.constRight == nil {
+		rightVec = batch.ColVec(b.rightIdx)
+	}
+	return leftVec, rightVec
+}
+
+// GetArrayContainsProjectionOperator returns an operator that projects the
+// result of the array containment operator cmpOp (either @> or <@) into the
+// Bool column at position resultIdx. The left argument is the array column at
+// position leftIdx, and the right argument is either the constant constRight
+// if it is non-nil or the array column at position rightIdx.
+func GetArrayContainsProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	cmpOp tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeArrayContainsBase(evalCtx, cmpOp, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &arrayContainsProjOp{
+		OneInputHelper:    colexecop.MakeOneInputHelper(input),
+		arrayContainsBase: base,
+		allocator:         allocator,
+		outputIdx:         resultIdx,
+	}, nil
+}
+
+// GetArrayContainsOperator returns an operator that selects the tuples for
+// which the array containment operator cmpOp (either @> or <@) evaluates to
+// true. The arguments are the same as in GetArrayContainsProjectionOperator.
+func GetArrayContainsOperator(
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	cmpOp tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeArrayContainsBase(evalCtx, cmpOp, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	return &arrayContainsSelOp{
+		OneInputHelper:    colexecop.MakeOneInputHelper(input),
+		arrayContainsBase: base,
+	}, nil
+}
+
+type arrayContainsProjOp struct {
+	colexecop.OneInputHelper
+	arrayContainsBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &arrayContainsProjOp{}
+
+func (o *arrayContainsProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	leftVec, rightVec := o.vecs(batch)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(leftVec, rightVec, rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type arrayContainsSelOp struct {
+	colexecop.OneInputHelper
+	arrayContainsBase
+}
+
+var _ colexecop.Operator = &arrayContainsSelOp{}
+
+func (o *arrayContainsSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		leftVec, rightVec := o.vecs(batch)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
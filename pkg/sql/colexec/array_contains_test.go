@@ -0,0 +1,213 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayContains(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "contains column",
+			expr: "@1 @> @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", "ARRAY[3,1]"},
+				{"ARRAY[1,2,3]", "ARRAY[1,4]"},
+				{"ARRAY[1,1]", "ARRAY[1,1,1]"},
+				{"ARRAY[1,2]", "ARRAY[]:::INT[]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				{"ARRAY[]:::INT[]", "ARRAY[1]"},
+				{"ARRAY[1,NULL]", "ARRAY[1]"},
+				{"ARRAY[1,NULL]", "ARRAY[NULL]:::INT[]"},
+				{nil, "ARRAY[1]"},
+				{"ARRAY[1]", nil},
+			},
+			inputTypes: []*types.T{intArray, intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", "ARRAY[3,1]", true},
+				{"ARRAY[1,2,3]", "ARRAY[1,4]", false},
+				{"ARRAY[1,1]", "ARRAY[1,1,1]", true},
+				{"ARRAY[1,2]", "ARRAY[]:::INT[]", true},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]", true},
+				{"ARRAY[]:::INT[]", "ARRAY[1]", false},
+				{"ARRAY[1,NULL]", "ARRAY[1]", true},
+				// NULL elements never match each other.
+				{"ARRAY[1,NULL]", "ARRAY[NULL]:::INT[]", false},
+				{nil, "ARRAY[1]", nil},
+				{"ARRAY[1]", nil, nil},
+			},
+		},
+		{
+			desc: "contained by column",
+			expr: "@1 <@ @2",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[3,1]", "ARRAY[1,2,3]"},
+				{"ARRAY[1,2,3]", "ARRAY[3,1]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]"},
+				{"ARRAY[NULL]:::INT[]", "ARRAY[NULL]:::INT[]"},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{intArray, intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[3,1]", "ARRAY[1,2,3]", true},
+				{"ARRAY[1,2,3]", "ARRAY[3,1]", false},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::INT[]", true},
+				{"ARRAY[NULL]:::INT[]", "ARRAY[NULL]:::INT[]", false},
+				{nil, nil, nil},
+			},
+		},
+		{
+			desc: "contains constant",
+			expr: "@1 @> '{2,1}'",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"}, {"ARRAY[1]"}, {"ARRAY[]:::INT[]"}, {"ARRAY[NULL,1,2]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", true}, {"ARRAY[1]", false}, {"ARRAY[]:::INT[]", false}, {"ARRAY[NULL,1,2]", true}, {nil, nil},
+			},
+		},
+		{
+			desc: "contained by constant",
+			expr: "@1 <@ '{2,1}'",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"}, {"ARRAY[1]"}, {"ARRAY[]:::INT[]"}, {"ARRAY[NULL]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", false}, {"ARRAY[1]", true}, {"ARRAY[]:::INT[]", true}, {"ARRAY[NULL]:::INT[]", false}, {nil, nil},
+			},
+		},
+		{
+			desc: "contains empty constant",
+			expr: "@1 @> '{}'",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1]"}, {"ARRAY[]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1]", true}, {"ARRAY[]:::INT[]", true}, {nil, nil},
+			},
+		},
+		{
+			desc: "strings",
+			expr: "@1 @> '{b}'",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY['a','b']"}, {"ARRAY['a']"},
+			},
+			inputTypes: []*types.T{types.StringArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY['a','b']", true}, {"ARRAY['a']", false},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+func TestArrayContainsSel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	intArray := types.MakeArray(types.Int)
+	typs := []*types.T{intArray, intArray}
+	inputTuples := colexectestutils.Tuples{
+		{"ARRAY[1,2]", "ARRAY[1]"},
+		{"ARRAY[1]", "ARRAY[1,2]"},
+		{"ARRAY[1]", "ARRAY[]:::INT[]"},
+		{"ARRAY[NULL]:::INT[]", "ARRAY[NULL]:::INT[]"},
+		{nil, "ARRAY[1]"},
+		{"ARRAY[1]", nil},
+	}
+	for _, tc := range []struct {
+		cmpOp        tree.ComparisonOperator
+		constRight   tree.Datum
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			cmpOp: tree.Contains,
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", "ARRAY[1]"},
+				{"ARRAY[1]", "ARRAY[]:::INT[]"},
+			},
+		},
+		{
+			cmpOp: tree.ContainedBy,
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1]", "ARRAY[1,2]"},
+			},
+		},
+		{
+			cmpOp: tree.ContainedBy,
+			constRight: &tree.DArray{
+				ParamTyp: types.Int,
+				Array:    tree.Datums{tree.NewDInt(1)},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1]", "ARRAY[1,2]"},
+				{"ARRAY[1]", "ARRAY[]:::INT[]"},
+				{"ARRAY[1]", nil},
+			},
+		},
+	} {
+		log.Infof(ctx, "%s/const=%t", tc.cmpOp, tc.constRight != nil)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetArrayContainsOperator(
+					&evalCtx, input[0], tc.cmpOp, 0 /* leftIdx */, 1 /* rightIdx */, tc.constRight,
+				)
+			})
+	}
+}
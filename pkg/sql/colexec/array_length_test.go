@@ -0,0 +1,149 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayLength(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	nestedIntArray := types.MakeArray(intArray)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "array_length constant dimension",
+			expr: "array_length(@1, 1)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"}, {"ARRAY[NULL]:::INT[]"}, {"ARRAY[]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", 3}, {"ARRAY[NULL]:::INT[]", 1}, {"ARRAY[]:::INT[]", nil}, {nil, nil},
+			},
+		},
+		{
+			desc: "array_length out-of-range constant dimension",
+			expr: "array_length(@1, 0) IS NULL AND array_length(@1, -1) IS NULL AND array_length(@1, 2) IS NULL",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", true},
+			},
+		},
+		{
+			desc: "array_length dimension column",
+			expr: "array_length(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 1},
+				{"ARRAY[1,2]", 2},
+				{"ARRAY[1,2]", 0},
+				{"ARRAY[1,2]", nil},
+				{"ARRAY[]:::INT[]", 1},
+				{nil, 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 1, 2},
+				{"ARRAY[1,2]", 2, nil},
+				{"ARRAY[1,2]", 0, nil},
+				{"ARRAY[1,2]", nil, nil},
+				{"ARRAY[]:::INT[]", 1, nil},
+				{nil, 1, nil},
+			},
+		},
+		{
+			desc: "array_length narrow dimension column",
+			expr: "array_length(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", 1, 2},
+			},
+		},
+		{
+			desc: "array_length multi-dimensional",
+			expr: "array_length(@1, 1) + array_length(@1, 2) * 10",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[ARRAY[1,2,3],ARRAY[4,5,6]]"},
+			},
+			inputTypes: []*types.T{nestedIntArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[ARRAY[1,2,3],ARRAY[4,5,6]]", 32},
+			},
+		},
+		{
+			desc: "cardinality",
+			expr: "cardinality(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"}, {"ARRAY[NULL,NULL]:::INT[]"}, {"ARRAY[]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", 3}, {"ARRAY[NULL,NULL]:::INT[]", 2}, {"ARRAY[]:::INT[]", 0}, {nil, nil},
+			},
+		},
+		{
+			desc: "cardinality multi-dimensional",
+			expr: "cardinality(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[ARRAY[1,2,3],ARRAY[4,5,6]]"},
+			},
+			inputTypes: []*types.T{nestedIntArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[ARRAY[1,2,3],ARRAY[4,5,6]]", 6},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for array_length.eg.go. It's formatted in
+// a special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _DIM_WIDTH is the template variable.
+const _DIM_WIDTH = 0
+
+// */}}
+
+// newArrayLengthOperator returns an operator that evaluates array_length()
+// builtin. The arguments are expected at positions argumentCols: the array
+// column and the dimension Int column. If dim is non-nil, then the dimension
+// is constant and its column is not read.
+func newArrayLengthOperator(
+	allocator *colmem.Allocator,
+	typs []*types.T,
+	argumentCols []int,
+	dim *int64,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := arrayLengthOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		arrayIdx:       argumentCols[0],
+		outputIdx:      outputIdx,
+	}
+	if dim != nil {
+		return &arrayLengthConstOp{arrayLengthOpBase: base, dim: *dim}
+	}
+	dimIdx := argumentCols[1]
+	dimType := typs[dimIdx]
+	if dimType.Family() != types.IntFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("non-int dimension argument type %s", dimType))
+	}
+	switch dimType.Width() {
+	// {{range .}}
+	case _DIM_WIDTH:
+		return &arrayLength_DimTypeOp{arrayLengthOpBase: base, dimIdx: dimIdx}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported array_length argument type: %s", dimType))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+// newCardinalityOperator returns an operator that evaluates cardinality()
+// builtin on the array column at position arrayIdx.
+func newCardinalityOperator(
+	allocator *colmem.Allocator, arrayIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &cardinalityOp{
+		arrayLengthOpBase: arrayLengthOpBase{
+			OneInputHelper: colexecop.MakeOneInputHelper(input),
+			allocator:      allocator,
+			arrayIdx:       arrayIdx,
+			outputIdx:      outputIdx,
+		},
+	}
+}
+
+type arrayLengthOpBase struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	arrayIdx  int
+	outputIdx int
+}
+
+// arrayLength returns the length of arr on the dimension dim and whether it is
+// defined. It matches array_length() builtin of the row engine, so the length
+// is undefined for an empty array and for an out-of-range dimension.
+func arrayLength(arr *tree.DArray, dim int64) (_ int64, ok bool) {
+	for ; ; dim-- {
+		if arr.Len() == 0 || dim < 1 {
+			return 0, false
+		}
+		if dim == 1 {
+			return int64(arr.Len()), true
+		}
+		if arr, ok = tree.AsDArray(arr.Array[0]); !ok {
+			return 0, false
+		}
+	}
+}
+
+// arrayCardinality returns the total number of elements in arr. It matches
+// cardinality() builtin of the row engine.
+func arrayCardinality(arr *tree.DArray) int64 {
+	if arr.ParamTyp.Family() != types.ArrayFamily {
+		return int64(arr.Len())
+	}
+	var n int64
+	for _, e := range arr.Array {
+		if a, ok := tree.AsDArray(e); ok {
+			n += arrayCardinality(a)
+		}
+	}
+	return n
+}
+
+// {{range .}}
+
+// arrayLength_DimTypeOp is an operator that evaluates array_length() builtin
+// with the dimension read from a column.
+type arrayLength_DimTypeOp struct {
+	arrayLengthOpBase
+	dimIdx int
+}
+
+var _ colexecop.Operator = &arrayLength_DimTypeOp{}
+
+func (a *arrayLength_DimTypeOp) Next() coldata.Batch {
+	batch := a.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	arrayVec := batch.ColVec(a.arrayIdx)
+	arrayNulls, arrayCol := arrayVec.Nulls(), arrayVec.Datum()
+	dimVec := batch.ColVec(a.dimIdx)
+	dimNulls, dimCol := dimVec.Nulls(), dimVec._DimType()
+	outputVec := batch.ColVec(a.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	a.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if arrayNulls.NullAt(rowIdx) || dimNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				arr := tree.MustBeDArray(arrayCol.Get(rowIdx).(*coldataext.Datum).Datum)
+				length, ok := arrayLength(arr, int64(dimCol[rowIdx]))
+				if !ok {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol[rowIdx] = length
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
+
+// arrayLengthConstOp is an operator that evaluates array_length() builtin
+// with a constant dimension.
+type arrayLengthConstOp struct {
+	arrayLengthOpBase
+	dim int64
+}
+
+var _ colexecop.Operator = &arrayLengthConstOp{}
+
+func (a *arrayLengthConstOp) Next() coldata.Batch {
+	batch := a.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	arrayVec := batch.ColVec(a.arrayIdx)
+	arrayNulls, arrayCol := arrayVec.Nulls(), arrayVec.Datum()
+	outputVec := batch.ColVec(a.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	a.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if arrayNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				arr := tree.MustBeDArray(arrayCol.Get(rowIdx).(*coldataext.Datum).Datum)
+				length, ok := arrayLength(arr, a.dim)
+				if !ok {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol[rowIdx] = length
+			}
+		},
+	)
+	return batch
+}
+
+// cardinalityOp is an operator that evaluates cardinality() builtin.
+type cardinalityOp struct {
+	arrayLengthOpBase
+}
+
+var _ colexecop.Operator = &cardinalityOp{}
+
+func (c *cardinalityOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	arrayVec := batch.ColVec(c.arrayIdx)
+	arrayNulls, arrayCol := arrayVec.Nulls(), arrayVec.Datum()
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	c.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if arrayNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				arr := tree.MustBeDArray(arrayCol.Get(rowIdx).(*coldataext.Datum).Datum)
+				outputCol[rowIdx] = arrayCardinality(arr)
+			}
+		},
+	)
+	return batch
+}
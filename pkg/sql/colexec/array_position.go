@@ -0,0 +1,143 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// newArrayPositionOperator returns an operator that evaluates either
+// array_position() (if all is false) or array_positions() (if all is true)
+// builtin. The first argument is the array and the second one is the element
+// to search for; each of them is either the constant from funcExpr or the
+// column from argumentCols.
+func newArrayPositionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	funcExpr *tree.FuncExpr,
+	argumentCols []int,
+	all bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	var args [2]arrayConcatArg
+	for i := range args {
+		args[i].colIdx = argumentCols[i]
+		if d, ok := funcExpr.Exprs[i].(tree.Datum); ok {
+			args[i].constArg = d
+		}
+	}
+	return &arrayPositionOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		evalCtx:        evalCtx,
+		array:          args[0],
+		elem:           args[1],
+		all:            all,
+		outputIdx:      outputIdx,
+	}
+}
+
+// arrayPositionOp is an operator that evaluates array_position() and
+// array_positions() builtins the same way as the row engine does. The result
+// is NULL if the array is NULL. The elements are compared using IS NOT
+// DISTINCT FROM semantics, so a NULL element matches the NULL elements of the
+// array. If there are no matching elements, array_position() returns NULL
+// whereas array_positions() returns an empty array.
+type arrayPositionOp struct {
+	colexecop.OneInputHelper
+	allocator   *colmem.Allocator
+	evalCtx     *tree.EvalContext
+	array, elem arrayConcatArg
+	// all indicates whether the positions of all matching elements are
+	// returned (i.e. whether array_positions() is evaluated).
+	all       bool
+	outputIdx int
+	da        rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &arrayPositionOp{}
+
+func (o *arrayPositionOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.array.convert(batch, n, &o.da)
+	o.elem.convert(batch, n, &o.da)
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		if o.all {
+			outputCol := outputVec.Datum()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				arr := o.array.get(rowIdx)
+				if arr == tree.DNull {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				elem := o.elem.get(rowIdx)
+				res := tree.NewDArray(types.Int)
+				for j, e := range tree.MustBeDArray(arr).Array {
+					if e.Compare(o.evalCtx, elem) == 0 {
+						if err := res.Append(o.da.NewDInt(tree.DInt(j + 1))); err != nil {
+							colexecerror.ExpectedError(err)
+						}
+					}
+				}
+				outputCol.Set(rowIdx, res)
+			}
+			return
+		}
+		outputCol := outputVec.Int64()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			arr := o.array.get(rowIdx)
+			if arr == tree.DNull {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			elem := o.elem.get(rowIdx)
+			found := false
+			for j, e := range tree.MustBeDArray(arr).Array {
+				if e.Compare(o.evalCtx, elem) == 0 {
+					outputCol[rowIdx] = int64(j + 1)
+					found = true
+					break
+				}
+			}
+			if !found {
+				outputNulls.SetNull(rowIdx)
+			}
+		}
+	})
+	return batch
+}
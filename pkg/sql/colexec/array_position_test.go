@@ -0,0 +1,144 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayPosition(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	stringArray := types.MakeArray(types.String)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "array_position element column",
+			expr: "array_position(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2},
+				{"ARRAY[1,2,3]", 4},
+				{"ARRAY[]:::INT[]", 1},
+				{nil, 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2, 2},
+				{"ARRAY[1,2,3]", 4, nil},
+				{"ARRAY[]:::INT[]", 1, nil},
+				{nil, 1, nil},
+			},
+		},
+		{
+			desc: "array_position NULL element",
+			expr: "array_position(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3,NULL]", nil},
+				{"ARRAY[1,2]", nil},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3,NULL]", nil, 2},
+				{"ARRAY[1,2]", nil, nil},
+			},
+		},
+		{
+			desc: "array_position constant element",
+			expr: "array_position(@1, 'b')",
+			inputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`}, {`ARRAY['c']`}, {nil},
+			},
+			inputTypes: []*types.T{stringArray},
+			outputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`, 2}, {`ARRAY['c']`, nil}, {nil, nil},
+			},
+		},
+		{
+			desc: "array_positions element column",
+			expr: "array_positions(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2},
+				{"ARRAY[1,2,3]", 4},
+				{"ARRAY[]:::INT[]", 1},
+				{nil, 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2, "ARRAY[2,4]"},
+				{"ARRAY[1,2,3]", 4, "ARRAY[]:::INT[]"},
+				{"ARRAY[]:::INT[]", 1, "ARRAY[]:::INT[]"},
+				{nil, 1, nil},
+			},
+		},
+		{
+			desc: "array_positions NULL element",
+			expr: "array_positions(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[NULL,1,NULL]", nil},
+				{"ARRAY[1,2]", nil},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[NULL,1,NULL]", nil, "ARRAY[1,3]"},
+				{"ARRAY[1,2]", nil, "ARRAY[]:::INT[]"},
+			},
+		},
+		{
+			desc: "array_positions constant element",
+			expr: "array_positions(@1, 'b')",
+			inputTuples: colexectestutils.Tuples{
+				{`ARRAY['b','a','b']`}, {`ARRAY['c']`}, {nil},
+			},
+			inputTypes: []*types.T{stringArray},
+			outputTuples: colexectestutils.Tuples{
+				{`ARRAY['b','a','b']`, "ARRAY[1,3]"}, {`ARRAY['c']`, "ARRAY[]:::INT[]"}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
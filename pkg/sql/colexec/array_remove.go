@@ -0,0 +1,132 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// newArrayRemoveOperator returns an operator that evaluates either
+// array_remove() (if replace is false) or array_replace() (if replace is true)
+// builtin. The first argument is the array, the second one is the element to
+// search for, and the third one (only for array_replace()) is the replacement;
+// each of them is either the constant from funcExpr or the column from
+// argumentCols.
+func newArrayRemoveOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	funcExpr *tree.FuncExpr,
+	argumentCols []int,
+	replace bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	var args [3]arrayConcatArg
+	for i := range argumentCols {
+		args[i].colIdx = argumentCols[i]
+		if d, ok := funcExpr.Exprs[i].(tree.Datum); ok {
+			args[i].constArg = d
+		}
+	}
+	return &arrayRemoveOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		evalCtx:        evalCtx,
+		elemType:       funcExpr.ResolvedType().ArrayContents(),
+		array:          args[0],
+		elem:           args[1],
+		replacement:    args[2],
+		replace:        replace,
+		outputIdx:      outputIdx,
+	}
+}
+
+// arrayRemoveOp is an operator that evaluates array_remove() and
+// array_replace() builtins the same way as the row engine does. The result is
+// NULL if the array is NULL. The elements are compared using IS NOT DISTINCT
+// FROM semantics, so a NULL element matches the NULL elements of the array.
+// The matching elements are either omitted (for array_remove()) or replaced
+// (for array_replace()) in the resulting array.
+type arrayRemoveOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	evalCtx   *tree.EvalContext
+	elemType  *types.T
+	array     arrayConcatArg
+	elem      arrayConcatArg
+	// replacement is only used if replace is true.
+	replacement arrayConcatArg
+	replace     bool
+	outputIdx   int
+	da          rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &arrayRemoveOp{}
+
+func (o *arrayRemoveOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.array.convert(batch, n, &o.da)
+	o.elem.convert(batch, n, &o.da)
+	if o.replace {
+		o.replacement.convert(batch, n, &o.da)
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	outputCol := outputVec.Datum()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			arr := o.array.get(rowIdx)
+			if arr == tree.DNull {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			elem := o.elem.get(rowIdx)
+			var replacement tree.Datum
+			if o.replace {
+				replacement = o.replacement.get(rowIdx)
+			}
+			res := tree.NewDArray(o.elemType)
+			for _, e := range tree.MustBeDArray(arr).Array {
+				if e.Compare(o.evalCtx, elem) == 0 {
+					if !o.replace {
+						continue
+					}
+					e = replacement
+				}
+				if err := res.Append(e); err != nil {
+					colexecerror.ExpectedError(err)
+				}
+			}
+			outputCol.Set(rowIdx, res)
+		}
+	})
+	return batch
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayRemoveReplace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	stringArray := types.MakeArray(types.String)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "array_remove element column",
+			expr: "array_remove(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2},
+				{"ARRAY[1,2,3]", 4},
+				{"ARRAY[2,2]", 2},
+				{"ARRAY[]:::INT[]", 1},
+				{nil, 1},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2, "ARRAY[1,3]"},
+				// No elements match.
+				{"ARRAY[1,2,3]", 4, "ARRAY[1,2,3]"},
+				// All elements match.
+				{"ARRAY[2,2]", 2, "ARRAY[]:::INT[]"},
+				{"ARRAY[]:::INT[]", 1, "ARRAY[]:::INT[]"},
+				{nil, 1, nil},
+			},
+		},
+		{
+			desc: "array_remove NULL element",
+			expr: "array_remove(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3,NULL]", nil},
+				{"ARRAY[1,NULL]", 1},
+				{"ARRAY[1,2]", nil},
+			},
+			inputTypes: []*types.T{intArray, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				// The NULL element matches the NULL elements of the array.
+				{"ARRAY[1,NULL,3,NULL]", nil, "ARRAY[1,3]"},
+				{"ARRAY[1,NULL]", 1, "ARRAY[NULL]:::INT[]"},
+				{"ARRAY[1,2]", nil, "ARRAY[1,2]"},
+			},
+		},
+		{
+			desc: "array_remove constant element",
+			expr: "array_remove(@1, 'b')",
+			inputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`}, {`ARRAY['c']`}, {`ARRAY['b']`}, {nil},
+			},
+			inputTypes: []*types.T{stringArray},
+			outputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`, `ARRAY['a']`}, {`ARRAY['c']`, `ARRAY['c']`},
+				{`ARRAY['b']`, `ARRAY[]:::STRING[]`}, {nil, nil},
+			},
+		},
+		{
+			desc: "array_replace element columns",
+			expr: "array_replace(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2, 5},
+				{"ARRAY[1,2,3]", 4, 5},
+				{"ARRAY[2,2]", 2, 1},
+				{"ARRAY[]:::INT[]", 1, 2},
+				{nil, 1, 2},
+			},
+			inputTypes: []*types.T{intArray, types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3,2]", 2, 5, "ARRAY[1,5,3,5]"},
+				{"ARRAY[1,2,3]", 4, 5, "ARRAY[1,2,3]"},
+				{"ARRAY[2,2]", 2, 1, "ARRAY[1,1]"},
+				{"ARRAY[]:::INT[]", 1, 2, "ARRAY[]:::INT[]"},
+				{nil, 1, 2, nil},
+			},
+		},
+		{
+			desc: "array_replace NULL elements",
+			expr: "array_replace(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3,NULL]", nil, 0},
+				{"ARRAY[1,NULL,1]", 1, nil},
+				{"ARRAY[1,2]", nil, 0},
+			},
+			inputTypes: []*types.T{intArray, types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3,NULL]", nil, 0, "ARRAY[1,0,3,0]"},
+				{"ARRAY[1,NULL,1]", 1, nil, "ARRAY[NULL,NULL,NULL]:::INT[]"},
+				{"ARRAY[1,2]", nil, 0, "ARRAY[1,2]"},
+			},
+		},
+		{
+			desc: "array_replace constant elements",
+			expr: "array_replace(@1, 'b', 'x')",
+			inputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`}, {`ARRAY['c']`}, {nil},
+			},
+			inputTypes: []*types.T{stringArray},
+			outputTuples: colexectestutils.Tuples{
+				{`ARRAY['a','b','b']`, `ARRAY['a','x','x']`}, {`ARRAY['c']`, `ARRAY['c']`}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// newArrayToStringOperator returns an operator that evaluates array_to_string()
+// builtin. The array is either the constant from funcExpr or the column at
+// position argumentCols[0]. The delimiter and the string that replaces the
+// NULL elements (the latter only if withNullStr is true) are either the
+// constant strings or NULLs from funcExpr, or the String columns from
+// argumentCols.
+func newArrayToStringOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	argumentCols []int,
+	withNullStr bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &arrayToStringOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		array:          arrayConcatArg{colIdx: argumentCols[0]},
+		delimIdx:       -1,
+		nullStrIdx:     -1,
+		outputIdx:      outputIdx,
+		fmtCtx:         tree.NewFmtCtx(tree.FmtArrayToString),
+	}
+	if d, ok := funcExpr.Exprs[0].(tree.Datum); ok {
+		op.array.constArg = d
+	}
+	var ok bool
+	if op.delim, ok = constStringOrNull(funcExpr.Exprs[1]); !ok {
+		op.delimIdx = argumentCols[1]
+	}
+	if withNullStr {
+		if op.nullStr, ok = constStringOrNull(funcExpr.Exprs[2]); !ok {
+			op.nullStrIdx = argumentCols[2]
+		}
+	}
+	return op
+}
+
+// arrayToStringOp is an operator that joins the text representations of the
+// array elements using the delimiter the same way as the row engine does. The
+// result is NULL if either the array or the delimiter is NULL. The NULL
+// elements are replaced with the null string if it is non-NULL and are
+// omitted otherwise.
+type arrayToStringOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	array     arrayConcatArg
+	// delim is the constant delimiter (nil if it is NULL), and delimIdx is the
+	// index of the column with the delimiter, -1 if the delimiter is constant.
+	delim    *string
+	delimIdx int
+	// nullStr is the constant null string (nil if it is NULL or not given),
+	// and nullStrIdx is the index of the column with the null string, -1 if
+	// the null string is constant or not given.
+	nullStr    *string
+	nullStrIdx int
+	outputIdx  int
+	// fmtCtx is reused for formatting the result of every row.
+	fmtCtx *tree.FmtCtx
+	da     rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &arrayToStringOp{}
+
+func (o *arrayToStringOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.array.convert(batch, n, &o.da)
+	var constDelim, constNullStr []byte
+	if o.delim != nil {
+		constDelim = []byte(*o.delim)
+	}
+	if o.nullStr != nil {
+		constNullStr = []byte(*o.nullStr)
+	}
+	var delimNulls, nullStrNulls *coldata.Nulls
+	var delimCol, nullStrCol *coldata.Bytes
+	if o.delimIdx >= 0 {
+		delimVec := batch.ColVec(o.delimIdx)
+		delimNulls, delimCol = delimVec.Nulls(), delimVec.Bytes()
+	}
+	if o.nullStrIdx >= 0 {
+		nullStrVec := batch.ColVec(o.nullStrIdx)
+		nullStrNulls, nullStrCol = nullStrVec.Nulls(), nullStrVec.Bytes()
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			arr := o.array.get(rowIdx)
+			if arr == tree.DNull {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			var delim []byte
+			if delimCol != nil {
+				if delimNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				delim = delimCol.Get(rowIdx)
+			} else if o.delim == nil {
+				outputNulls.SetNull(rowIdx)
+				continue
+			} else {
+				delim = constDelim
+			}
+			var nullStr []byte
+			hasNullStr := false
+			if nullStrCol != nil {
+				if !nullStrNulls.NullAt(rowIdx) {
+					nullStr, hasNullStr = nullStrCol.Get(rowIdx), true
+				}
+			} else if o.nullStr != nil {
+				nullStr, hasNullStr = constNullStr, true
+			}
+			o.fmtCtx.Reset()
+			elems := tree.MustBeDArray(arr).Array
+			for j, e := range elems {
+				// Note that the delimiter is not written after an omitted NULL
+				// element, yet it is written after the element preceding it, so
+				// a trailing NULL element leaves a trailing delimiter. This
+				// matches the row engine.
+				if e == tree.DNull {
+					if !hasNullStr {
+						continue
+					}
+					o.fmtCtx.Write(nullStr)
+				} else {
+					o.fmtCtx.FormatNode(e)
+				}
+				if j < len(elems)-1 {
+					o.fmtCtx.Write(delim)
+				}
+			}
+			outputCol.Set(rowIdx, o.fmtCtx.Bytes())
+		}
+	})
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
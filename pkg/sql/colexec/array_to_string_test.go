@@ -0,0 +1,142 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestArrayToString(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intArray := types.MakeArray(types.Int)
+	stringArray := types.MakeArray(types.String)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			// Note that a trailing NULL element leaves a trailing delimiter
+			// whereas a leading one doesn't leave a leading delimiter, same as
+			// in the row engine.
+			desc: "constant delimiter",
+			expr: "array_to_string(@1, ',')",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]"},
+				{"ARRAY[1,NULL,3]"},
+				{"ARRAY[NULL,2]"},
+				{"ARRAY[1,2,NULL]"},
+				{"ARRAY[NULL,NULL]:::INT[]"},
+				{"ARRAY[]:::INT[]"},
+				{nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2,3]", "1,2,3"},
+				{"ARRAY[1,NULL,3]", "1,3"},
+				{"ARRAY[NULL,2]", "2"},
+				{"ARRAY[1,2,NULL]", "1,2,"},
+				{"ARRAY[NULL,NULL]:::INT[]", ""},
+				{"ARRAY[]:::INT[]", ""},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "constant delimiter and null string",
+			expr: "array_to_string(@1, ', ', '*')",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]"}, {"ARRAY[1,2,NULL]"}, {"ARRAY[NULL]:::INT[]"}, {"ARRAY[]:::INT[]"}, {nil},
+			},
+			inputTypes: []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]", "1, *, 3"}, {"ARRAY[1,2,NULL]", "1, 2, *"}, {"ARRAY[NULL]:::INT[]", "*"},
+				{"ARRAY[]:::INT[]", ""}, {nil, nil},
+			},
+		},
+		{
+			// The strings are neither quoted nor escaped.
+			desc: "string elements",
+			expr: "array_to_string(@1, '|', NULL)",
+			inputTuples: colexectestutils.Tuples{
+				{`ARRAY['a b','c"d',NULL,'e|f']`}, {`ARRAY['']`},
+			},
+			inputTypes: []*types.T{stringArray},
+			outputTuples: colexectestutils.Tuples{
+				{`ARRAY['a b','c"d',NULL,'e|f']`, `a b|c"d|e|f`}, {`ARRAY['']`, ""},
+			},
+		},
+		{
+			desc:        "NULL delimiter",
+			expr:        "array_to_string(@1, NULL, '*')",
+			inputTuples: colexectestutils.Tuples{{"ARRAY[1,2]"}, {nil}},
+			inputTypes:  []*types.T{intArray},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,2]", nil}, {nil, nil},
+			},
+		},
+		{
+			desc: "delimiter and null string columns",
+			expr: "array_to_string(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]", "-", "x"},
+				{"ARRAY[1,NULL,3]", "", "x"},
+				{"ARRAY[1,NULL,3]", "-", nil},
+				{"ARRAY[1,NULL,3]", nil, "x"},
+				{"ARRAY[]:::INT[]", "-", "x"},
+				{nil, "-", "x"},
+			},
+			inputTypes: []*types.T{intArray, types.String, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]", "-", "x", "1-x-3"},
+				{"ARRAY[1,NULL,3]", "", "x", "1x3"},
+				{"ARRAY[1,NULL,3]", "-", nil, "1-3"},
+				{"ARRAY[1,NULL,3]", nil, "x", nil},
+				{"ARRAY[]:::INT[]", "-", "x", ""},
+				{nil, "-", "x", nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
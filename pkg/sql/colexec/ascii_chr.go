@@ -0,0 +1,180 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// The errors below match the ones returned by the row engine.
+var (
+	errASCIIEmptyInputString = pgerror.New(pgcode.InvalidParameterValue, "the input string must not be empty")
+	errChrValueTooSmall      = pgerror.New(pgcode.InvalidParameterValue, "input value must be >= 0")
+	errChrValueTooLarge      = pgerror.Newf(pgcode.InvalidParameterValue,
+		"input value must be <= %d (maximum Unicode code point)", utf8.MaxRune)
+)
+
+// newASCIIOperator returns an operator that evaluates ascii() builtin on the
+// String column at position inputIdx.
+func newASCIIOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &asciiOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// asciiOp is an operator that returns the code point of the first character of
+// each string. Same as in the row engine, an empty string results in an error.
+type asciiOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &asciiOp{}
+
+func (a *asciiOp) Next() coldata.Batch {
+	batch := a.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(a.inputIdx)
+	nulls, col := vec.Nulls(), vec.Bytes()
+	outputVec := batch.ColVec(a.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	a.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				s := col.Get(rowIdx)
+				if len(s) == 0 {
+					colexecerror.ExpectedError(a.funcExpr.MaybeWrapError(errASCIIEmptyInputString))
+				}
+				r, _ := utf8.DecodeRune(s)
+				outputCol[rowIdx] = int64(r)
+			}
+		},
+	)
+	return batch
+}
+
+// newChrOperator returns an operator that evaluates chr() builtin on the Int
+// column at position inputIdx.
+func newChrOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &chrOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// chrOp is an operator that returns the string consisting of the single
+// character with the given code point. The code points outside of the Unicode
+// range result in an error, and the surrogate code points are replaced with
+// utf8.RuneError, same as in the row engine.
+type chrOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	inputIdx  int
+	outputIdx int
+	buf       [utf8.UTFMax]byte
+}
+
+var _ colexecop.Operator = &chrOp{}
+
+func (c *chrOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(c.inputIdx)
+	nulls, col := vec.Nulls(), vec.Int64()
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	c.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				x := col[rowIdx]
+				switch {
+				case x < 0:
+					colexecerror.ExpectedError(c.funcExpr.MaybeWrapError(errChrValueTooSmall))
+				case x > utf8.MaxRune:
+					colexecerror.ExpectedError(c.funcExpr.MaybeWrapError(errChrValueTooLarge))
+				}
+				size := utf8.EncodeRune(c.buf[:], rune(x))
+				outputCol.Set(rowIdx, c.buf[:size])
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
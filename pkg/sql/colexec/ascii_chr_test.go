@@ -0,0 +1,140 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestASCIIAndChr(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "ascii",
+			expr: "ascii(@1)",
+			// The first characters are encoded with one, two, three, and four
+			// bytes.
+			inputTuples: colexectestutils.Tuples{
+				{"a"}, {"\x00b"}, {"é"}, {"禅定"}, {"😀a"}, {"\U0010FFFF"}, {nil},
+			},
+			inputTypes: []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"a", 97}, {"\x00b", 0}, {"é", 233}, {"禅定", 31109}, {"😀a", 128512},
+				{"\U0010FFFF", utf8.MaxRune}, {nil, nil},
+			},
+		},
+		{
+			desc: "chr",
+			expr: "chr(@1)",
+			// The code points at the boundaries of the encodings with one, two,
+			// three, and four bytes as well as a surrogate code point which is
+			// replaced with utf8.RuneError.
+			inputTuples: colexectestutils.Tuples{
+				{0}, {0x7F}, {0x80}, {0x7FF}, {0x800}, {0xD800}, {0xFFFF}, {0x10000},
+				{utf8.MaxRune}, {nil},
+			},
+			inputTypes: []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{0, "\x00"}, {0x7F, "\x7F"}, {0x80, "\u0080"}, {0x7FF, "߿"},
+				{0x800, "ࠀ"}, {0xD800, "�"}, {0xFFFF, "￿"},
+				{0x10000, "\U00010000"}, {utf8.MaxRune, "\U0010FFFF"}, {nil, nil},
+			},
+		},
+		{
+			desc: "chr of INT2",
+			expr: "chr(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{0x41}, {0x7FF}, {nil},
+			},
+			inputTypes: []*types.T{types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{0x41, "A"}, {0x7FF, "߿"}, {nil, nil},
+			},
+		},
+		{
+			desc: "chr of ascii",
+			expr: "chr(ascii(@1))",
+			inputTuples: colexectestutils.Tuples{
+				{"abc"}, {"禅定"}, {"😀"}, {nil},
+			},
+			inputTypes: []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"abc", "a"}, {"禅定", "禅"}, {"😀", "😀"}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The invalid arguments result in the same errors as in the row engine.
+	for _, tc := range []struct {
+		expr        string
+		input       interface{}
+		inputType   *types.T
+		expectedErr string
+	}{
+		{expr: "ascii(@1)", input: "", inputType: types.String, expectedErr: "ascii(): the input string must not be empty"},
+		{expr: "chr(@1)", input: -1, inputType: types.Int, expectedErr: "chr(): input value must be >= 0"},
+		{expr: "chr(@1)", input: utf8.MaxRune + 1, inputType: types.Int, expectedErr: "chr(): input value must be <= 1114111 (maximum Unicode code point)"},
+	} {
+		typs := []*types.T{tc.inputType}
+		input := colexectestutils.NewOpTestInput(
+			testAllocator, 1, colexectestutils.Tuples{{tc.input}}, typs,
+		)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.expectedErr)
+	}
+}
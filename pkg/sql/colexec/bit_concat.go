@@ -0,0 +1,153 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/errors"
+)
+
+// GetBitConcatProjectionOperator returns an operator that projects the result
+// of the concatenation (||) of two bit strings into the column at position
+// resultIdx. The left argument is the constant constLeft if it is non-nil or
+// the column at position leftIdx, and similarly for the right argument.
+func GetBitConcatProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	leftType, rightType, outputType *types.T,
+	leftIdx, rightIdx int,
+	constLeft, constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	if outputType.Family() != types.BitFamily ||
+		leftType.Family() != types.BitFamily || rightType.Family() != types.BitFamily {
+		// Note that this also covers the untyped NULL arguments.
+		return nil, errors.Errorf(
+			"unsupported bit string concatenation of %s and %s into %s", leftType, rightType, outputType,
+		)
+	}
+	left, err := makeBitConcatArg(leftIdx, constLeft)
+	if err != nil {
+		return nil, err
+	}
+	right, err := makeBitConcatArg(rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, resultIdx)
+	return &bitConcatProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		left:           left,
+		right:          right,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// bitConcatArg is one of the arguments of the bit string concatenation.
+type bitConcatArg struct {
+	// colIdx is only used if constArg is nil.
+	colIdx   int
+	constArg *tree.DBitArray
+	nulls    *coldata.Nulls
+	col      coldata.DatumVec
+}
+
+func makeBitConcatArg(colIdx int, constArg tree.Datum) (bitConcatArg, error) {
+	if constArg == nil {
+		return bitConcatArg{colIdx: colIdx}, nil
+	}
+	d, ok := constArg.(*tree.DBitArray)
+	if !ok {
+		return bitConcatArg{}, errors.Errorf("unsupported bit string concatenation argument %s", constArg)
+	}
+	return bitConcatArg{constArg: d}, nil
+}
+
+// init prepares the argument for the current batch. It must be called before
+// get.
+func (a *bitConcatArg) init(batch coldata.Batch) {
+	if a.constArg != nil {
+		return
+	}
+	vec := batch.ColVec(a.colIdx)
+	a.nulls, a.col = vec.Nulls(), vec.Datum()
+}
+
+// get returns the bit string at position rowIdx as well as whether it is
+// non-NULL.
+func (a *bitConcatArg) get(rowIdx int) (bitarray.BitArray, bool) {
+	if a.constArg != nil {
+		return a.constArg.BitArray, true
+	}
+	if a.nulls.NullAt(rowIdx) {
+		return bitarray.BitArray{}, false
+	}
+	return a.col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DBitArray).BitArray, true
+}
+
+// bitConcatProjOp is an operator that concatenates two bit strings the same
+// way as the row engine does. The result is NULL if either of the arguments is
+// NULL, and the bit length of the result is the sum of the bit lengths of the
+// arguments (the width of a BIT(n) argument is not preserved).
+type bitConcatProjOp struct {
+	colexecop.OneInputHelper
+	allocator   *colmem.Allocator
+	left, right bitConcatArg
+	outputIdx   int
+}
+
+var _ colexecop.Operator = &bitConcatProjOp{}
+
+func (o *bitConcatProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.left.init(batch)
+	o.right.init(batch)
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Datum()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			left, ok := o.left.get(rowIdx)
+			if !ok {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			right, ok := o.right.get(rowIdx)
+			if !ok {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			outputCol.Set(rowIdx, &tree.DBitArray{BitArray: bitarray.Concat(left, right)})
+		}
+	})
+	return batch
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestBitConcat(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The bit strings of various lengths (including the empty ones and the
+	// ones that span several words) are concatenated via their string
+	// representation to get the expected results.
+	rng, _ := randutil.NewPseudoRand()
+	bitLens := []uint{0, 1, 7, 63, 64, 65, 128, 130}
+	var randInput, randOutput colexectestutils.Tuples
+	for _, leftLen := range bitLens {
+		for _, rightLen := range bitLens {
+			left := &tree.DBitArray{BitArray: bitarray.Rand(rng, leftLen)}
+			right := &tree.DBitArray{BitArray: bitarray.Rand(rng, rightLen)}
+			expected, err := tree.ParseDBitArray(left.BitArray.String() + right.BitArray.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			randInput = append(randInput, colexectestutils.Tuple{left, right})
+			randOutput = append(randOutput, colexectestutils.Tuple{left, right, expected})
+		}
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:         "random",
+			expr:         "@1 || @2",
+			inputTuples:  randInput,
+			inputTypes:   []*types.T{types.VarBit, types.VarBit},
+			outputTuples: randOutput,
+		},
+		{
+			desc: "nulls",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"B'101'", "B''"},
+				{"B'101'", nil},
+				{nil, "B'1'"},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{types.VarBit, types.VarBit},
+			outputTuples: colexectestutils.Tuples{
+				{"B'101'", "B''", "B'101'"},
+				{"B'101'", nil, nil},
+				{nil, "B'1'", nil},
+				{nil, nil, nil},
+			},
+		},
+		{
+			// The result is a VARBIT, so the widths of the BIT(n) arguments
+			// add up.
+			desc: "fixed width",
+			expr: "@1 || @2",
+			inputTuples: colexectestutils.Tuples{
+				{"B'101'", "B'01'"},
+				{"B'000'", "B'11'"},
+			},
+			inputTypes: []*types.T{types.MakeBit(3), types.MakeBit(2)},
+			outputTuples: colexectestutils.Tuples{
+				{"B'101'", "B'01'", "B'10101'"},
+				{"B'000'", "B'11'", "B'00011'"},
+			},
+		},
+		{
+			desc: "constants",
+			expr: "B'1' || (@1 || B'00')",
+			inputTuples: colexectestutils.Tuples{
+				{"B'101'"}, {"B''"}, {nil},
+			},
+			inputTypes: []*types.T{types.VarBit},
+			outputTuples: colexectestutils.Tuples{
+				{"B'101'", "B'110100'"},
+				{"B''", "B'100'"},
+				{nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"math/bits"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// newBitCountOperator returns an operator that evaluates bit_count() builtin
+// on the column of inputType type at position inputIdx. The column must be
+// either of an Int or of a bit string type.
+func newBitCountOperator(
+	allocator *colmem.Allocator,
+	inputType *types.T,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &bitCountOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputType:      inputType,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// bitCountOp is an operator that returns the number of bits set to 1 in the
+// values. Same as in the row engine, the integers of all widths are always
+// treated as 64-bit unsigned ones, so the negative values are counted in
+// two's complement (e.g. the result for -1 is 64).
+type bitCountOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	inputType *types.T
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &bitCountOp{}
+
+func (b *bitCountOp) Next() coldata.Batch {
+	batch := b.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(b.inputIdx)
+	nulls := vec.Nulls()
+	outputVec := batch.ColVec(b.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	b.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			switch typeconv.TypeFamilyToCanonicalTypeFamily(b.inputType.Family()) {
+			case types.IntFamily:
+				switch b.inputType.Width() {
+				case 16:
+					col := vec.Int16()
+					for i := 0; i < n; i++ {
+						rowIdx := i
+						if sel != nil {
+							rowIdx = sel[i]
+						}
+						if nulls.NullAt(rowIdx) {
+							outputNulls.SetNull(rowIdx)
+							continue
+						}
+						// The conversion to int64 extends the sign bit.
+						outputCol[rowIdx] = int64(bits.OnesCount64(uint64(int64(col[rowIdx]))))
+					}
+				case 32:
+					col := vec.Int32()
+					for i := 0; i < n; i++ {
+						rowIdx := i
+						if sel != nil {
+							rowIdx = sel[i]
+						}
+						if nulls.NullAt(rowIdx) {
+							outputNulls.SetNull(rowIdx)
+							continue
+						}
+						// The conversion to int64 extends the sign bit.
+						outputCol[rowIdx] = int64(bits.OnesCount64(uint64(int64(col[rowIdx]))))
+					}
+				case 0, 64:
+					col := vec.Int64()
+					for i := 0; i < n; i++ {
+						rowIdx := i
+						if sel != nil {
+							rowIdx = sel[i]
+						}
+						if nulls.NullAt(rowIdx) {
+							outputNulls.SetNull(rowIdx)
+							continue
+						}
+						outputCol[rowIdx] = int64(bits.OnesCount64(uint64(col[rowIdx])))
+					}
+				default:
+					colexecerror.InternalError(errors.AssertionFailedf("unsupported int width %d", b.inputType.Width()))
+				}
+			case typeconv.DatumVecCanonicalTypeFamily:
+				col := vec.Datum()
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					if nulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					d := col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DBitArray)
+					outputCol[rowIdx] = int64(d.BitArray.OnesCount())
+				}
+			default:
+				colexecerror.InternalError(errors.AssertionFailedf("unsupported type %s", b.inputType))
+			}
+		},
+	)
+	return batch
+}
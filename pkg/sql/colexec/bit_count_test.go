@@ -0,0 +1,137 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitCount(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The bit strings of various lengths (including the empty ones and the
+	// ones that span several words) are counted bit by bit to get the
+	// expected results.
+	rng, _ := randutil.NewPseudoRand()
+	var bitsInput, bitsOutput colexectestutils.Tuples
+	for _, bitLen := range []uint{0, 1, 7, 63, 64, 65, 128, 130} {
+		for i := 0; i < 3; i++ {
+			d := &tree.DBitArray{BitArray: bitarray.Rand(rng, bitLen)}
+			count := 0
+			for j := 0; j < int(bitLen); j++ {
+				bit, err := d.GetBitAtIndex(j)
+				require.NoError(t, err)
+				count += bit
+			}
+			bitsInput = append(bitsInput, colexectestutils.Tuple{d})
+			bitsOutput = append(bitsOutput, colexectestutils.Tuple{d, count})
+		}
+	}
+	bitsInput = append(bitsInput, colexectestutils.Tuple{nil})
+	bitsOutput = append(bitsOutput, colexectestutils.Tuple{nil, nil})
+
+	testCases := []struct {
+		desc         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "int64",
+			// The negative values are counted in two's complement as 64-bit
+			// integers.
+			inputTuples: colexectestutils.Tuples{
+				{0}, {1}, {255}, {-1}, {-256},
+				{math.MaxInt64}, {math.MinInt64}, {nil},
+			},
+			inputTypes: []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{0, 0}, {1, 1}, {255, 8}, {-1, 64}, {-256, 56},
+				{math.MaxInt64, 63}, {math.MinInt64, 1}, {nil, nil},
+			},
+		},
+		{
+			// Same as in the row engine, the narrower integers are sign
+			// extended to 64 bits, so all negative values have the upper bits
+			// set.
+			desc: "int16",
+			inputTuples: colexectestutils.Tuples{
+				{int16(0)}, {int16(-1)}, {int16(math.MaxInt16)}, {int16(math.MinInt16)}, {nil},
+			},
+			inputTypes: []*types.T{types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{int16(0), 0}, {int16(-1), 64}, {int16(math.MaxInt16), 15}, {int16(math.MinInt16), 49}, {nil, nil},
+			},
+		},
+		{
+			desc: "int32",
+			inputTuples: colexectestutils.Tuples{
+				{int32(0)}, {int32(-1)}, {int32(math.MaxInt32)}, {int32(math.MinInt32)}, {nil},
+			},
+			inputTypes: []*types.T{types.Int4},
+			outputTuples: colexectestutils.Tuples{
+				{int32(0), 0}, {int32(-1), 64}, {int32(math.MaxInt32), 31}, {int32(math.MinInt32), 33}, {nil, nil},
+			},
+		},
+		{
+			desc: "bit",
+			inputTuples: colexectestutils.Tuples{
+				{"B'0000'"}, {"B'1010'"}, {"B'1111'"}, {nil},
+			},
+			inputTypes: []*types.T{types.MakeBit(4)},
+			outputTuples: colexectestutils.Tuples{
+				{"B'0000'", 0}, {"B'1010'", 2}, {"B'1111'", 4}, {nil, nil},
+			},
+		},
+		{
+			desc:         "varbit",
+			inputTuples:  bitsInput,
+			inputTypes:   []*types.T{types.VarBit},
+			outputTuples: bitsOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					"bit_count(@1)", false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/errors"
+)
+
+// newBitStringFuncOperator returns an operator that evaluates one of the
+// get_bit(), set_bit() and substring() builtins on the bit string column at
+// position argumentCols[0]. All other arguments must be Int64 columns.
+func newBitStringFuncOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	specializedBuiltin tree.SpecializedVectorizedBuiltin,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &bitStringFuncOp{
+		OneInputHelper:     colexecop.MakeOneInputHelper(input),
+		allocator:          allocator,
+		funcExpr:           funcExpr,
+		specializedBuiltin: specializedBuiltin,
+		argumentCols:       argumentCols,
+		outputIdx:          outputIdx,
+		intNulls:           make([]*coldata.Nulls, len(argumentCols)-1),
+		intCols:            make([]coldata.Int64s, len(argumentCols)-1),
+	}
+}
+
+// bitStringFuncOp is an operator that evaluates the builtins which operate on
+// the individual bits of the bit strings. Same as in the row engine, the bit
+// positions of get_bit() and set_bit() are 0-based and must be within the bit
+// string, whereas the positions of substring() are 1-based and are clamped to
+// the bit string. The result is NULL if any of the arguments is NULL.
+type bitStringFuncOp struct {
+	colexecop.OneInputHelper
+	allocator          *colmem.Allocator
+	funcExpr           *tree.FuncExpr
+	specializedBuiltin tree.SpecializedVectorizedBuiltin
+	argumentCols       []int
+	outputIdx          int
+	// intNulls and intCols are the scratch space for the integer arguments.
+	intNulls []*coldata.Nulls
+	intCols  []coldata.Int64s
+}
+
+var _ colexecop.Operator = &bitStringFuncOp{}
+
+func (o *bitStringFuncOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.argumentCols[0])
+	nulls, col := vec.Nulls(), vec.Datum()
+	for i, colIdx := range o.argumentCols[1:] {
+		intVec := batch.ColVec(colIdx)
+		o.intNulls[i], o.intCols[i] = intVec.Nulls(), intVec.Int64()
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	getBit := o.specializedBuiltin == tree.GetBitVarBitInt
+	var outputInts coldata.Int64s
+	var outputDatums coldata.DatumVec
+	if getBit {
+		outputInts = outputVec.Int64()
+	} else {
+		outputDatums = outputVec.Datum()
+	}
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) || o.anyIntNull(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				d := col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DBitArray)
+				if getBit {
+					bit, err := d.GetBitAtIndex(int(o.intCols[0][rowIdx]))
+					if err != nil {
+						colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+					}
+					outputInts[rowIdx] = int64(bit)
+					continue
+				}
+				res, err := o.eval(d.BitArray, rowIdx)
+				if err != nil {
+					colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+				}
+				outputDatums.Set(rowIdx, &tree.DBitArray{BitArray: res})
+			}
+		},
+	)
+	return batch
+}
+
+// anyIntNull returns whether any of the integer arguments is NULL in the row
+// at position rowIdx.
+func (o *bitStringFuncOp) anyIntNull(rowIdx int) bool {
+	for _, nulls := range o.intNulls {
+		if nulls.NullAt(rowIdx) {
+			return true
+		}
+	}
+	return false
+}
+
+// eval evaluates set_bit() or substring() on the bit string d with the integer
+// arguments from the row at position rowIdx.
+func (o *bitStringFuncOp) eval(d bitarray.BitArray, rowIdx int) (bitarray.BitArray, error) {
+	switch o.specializedBuiltin {
+	case tree.SetBitVarBitIntInt:
+		index, toSet := int(o.intCols[0][rowIdx]), int(o.intCols[1][rowIdx])
+		// Value of bit can only be set to 1 or 0.
+		if toSet != 0 && toSet != 1 {
+			return bitarray.BitArray{}, pgerror.Newf(pgcode.InvalidParameterValue,
+				"new bit must be 0 or 1.")
+		}
+		return d.SetBitAtIndex(index, toSet)
+	case tree.SubstringVarBitInt:
+		// SQL strings are 1-indexed.
+		start := int(o.intCols[0][rowIdx]) - 1
+		return bitSubstring(d, start, int(d.BitLen())), nil
+	case tree.SubstringVarBitIntInt:
+		start, length := int(o.intCols[0][rowIdx])-1, int(o.intCols[1][rowIdx])
+		if length < 0 {
+			return bitarray.BitArray{}, pgerror.Newf(pgcode.InvalidParameterValue,
+				"negative bit subarray length %d not allowed", length)
+		}
+		end := start + length
+		// Check for integer overflow.
+		if end < start {
+			end = int(d.BitLen())
+		}
+		return bitSubstring(d, start, end), nil
+	default:
+		return bitarray.BitArray{}, errors.AssertionFailedf(
+			"unsupported bit string builtin %d", o.specializedBuiltin,
+		)
+	}
+}
+
+// bitSubstring returns the bits of d in the range [start, end) where the
+// 0-based bounds are clamped to d, so the result can be empty.
+func bitSubstring(d bitarray.BitArray, start, end int) bitarray.BitArray {
+	bitLen := int(d.BitLen())
+	if end < 0 {
+		end = 0
+	} else if end > bitLen {
+		end = bitLen
+	}
+	if start < 0 {
+		start = 0
+	} else if start > bitLen {
+		start = bitLen
+	}
+	if start >= end {
+		return bitarray.BitArray{}
+	}
+	return d.LeftShiftAny(int64(start)).ToWidth(uint(end - start))
+}
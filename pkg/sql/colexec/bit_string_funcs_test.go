@@ -0,0 +1,230 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitStringFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The bit strings of various lengths (including the ones that span
+	// several words) are processed at the boundary positions, and the
+	// expected results are computed via the string representation.
+	rng, _ := randutil.NewPseudoRand()
+	mustParse := func(s string) *tree.DBitArray {
+		d, err := tree.ParseDBitArray(s)
+		require.NoError(t, err)
+		return d
+	}
+	var getBitInput, getBitOutput, setBitInput, setBitOutput colexectestutils.Tuples
+	var substringInput, substringOutput colexectestutils.Tuples
+	for _, bitLen := range []int{1, 7, 63, 64, 65, 128, 130} {
+		d := &tree.DBitArray{BitArray: bitarray.Rand(rng, uint(bitLen))}
+		s := d.BitArray.String()
+		for _, pos := range []int{0, 1, bitLen / 2, 63, 64, bitLen - 1} {
+			if pos >= bitLen {
+				continue
+			}
+			bit := int(s[pos] - '0')
+			getBitInput = append(getBitInput, colexectestutils.Tuple{d, pos})
+			getBitOutput = append(getBitOutput, colexectestutils.Tuple{d, pos, bit})
+			flipped := s[:pos] + string(rune('1'-bit)) + s[pos+1:]
+			setBitInput = append(setBitInput, colexectestutils.Tuple{d, pos, 1 - bit})
+			setBitOutput = append(setBitOutput, colexectestutils.Tuple{d, pos, 1 - bit, mustParse(flipped)})
+			for _, length := range []int{0, 1, 64, bitLen} {
+				end := pos + length
+				if end > bitLen {
+					end = bitLen
+				}
+				// The positions of substring() are 1-based.
+				substringInput = append(substringInput, colexectestutils.Tuple{d, pos + 1, length})
+				substringOutput = append(substringOutput, colexectestutils.Tuple{d, pos + 1, length, mustParse(s[pos:end])})
+			}
+		}
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:         "get_bit",
+			expr:         "get_bit(@1, @2)",
+			inputTuples:  getBitInput,
+			inputTypes:   []*types.T{types.VarBit, types.Int},
+			outputTuples: getBitOutput,
+		},
+		{
+			desc:         "set_bit",
+			expr:         "set_bit(@1, @2, @3)",
+			inputTuples:  setBitInput,
+			inputTypes:   []*types.T{types.VarBit, types.Int, types.Int},
+			outputTuples: setBitOutput,
+		},
+		{
+			desc:         "substring",
+			expr:         "substring(@1, @2, @3)",
+			inputTuples:  substringInput,
+			inputTypes:   []*types.T{types.VarBit, types.Int, types.Int},
+			outputTuples: substringOutput,
+		},
+		{
+			desc: "get_bit fixed width",
+			expr: "get_bit(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"B'0100'", 0}, {"B'0100'", 1}, {"B'0001'", 3}, {nil, 0}, {"B'1111'", nil},
+			},
+			inputTypes: []*types.T{types.MakeBit(4), types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"B'0100'", 0, 0}, {"B'0100'", 1, 1}, {"B'0001'", 3, 1}, {nil, 0, nil}, {"B'1111'", nil, nil},
+			},
+		},
+		{
+			desc: "set_bit nulls",
+			expr: "set_bit(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"B'0000'", 3, 1}, {"B'1111'", 0, 1}, {nil, 0, 1}, {"B'1'", nil, 1}, {"B'1'", 0, nil},
+			},
+			inputTypes: []*types.T{types.VarBit, types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"B'0000'", 3, 1, "B'0001'"}, {"B'1111'", 0, 1, "B'1111'"},
+				{nil, 0, 1, nil}, {"B'1'", nil, 1, nil}, {"B'1'", 0, nil, nil},
+			},
+		},
+		{
+			// The bounds of substring() are clamped to the bit string.
+			desc: "substring bounds",
+			expr: "substring(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"B'10110'", 0, 3}, {"B'10110'", -2, 5}, {"B'10110'", -10, 3},
+				{"B'10110'", 4, 10}, {"B'10110'", 6, 1}, {"B'10110'", 100, 1},
+				{"B'10110'", 2, math.MaxInt64}, {"B'10110'", math.MinInt64, 3},
+				{"B''", 1, 1}, {nil, 1, 1}, {"B'1'", nil, 1}, {"B'1'", 1, nil},
+			},
+			inputTypes: []*types.T{types.VarBit, types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"B'10110'", 0, 3, "B'10'"}, {"B'10110'", -2, 5, "B'10'"}, {"B'10110'", -10, 3, "B''"},
+				{"B'10110'", 4, 10, "B'10'"}, {"B'10110'", 6, 1, "B''"}, {"B'10110'", 100, 1, "B''"},
+				{"B'10110'", 2, math.MaxInt64, "B'0110'"}, {"B'10110'", math.MinInt64, 3, "B''"},
+				{"B''", 1, 1, "B''"}, {nil, 1, 1, nil}, {"B'1'", nil, 1, nil}, {"B'1'", 1, nil, nil},
+			},
+		},
+		{
+			desc: "substring without length",
+			expr: "substring(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"B'10110'", 1}, {"B'10110'", 3}, {"B'10110'", 5}, {"B'10110'", 6},
+				{"B'10110'", -3}, {"B'10110'", math.MinInt64}, {nil, 1}, {"B'1'", nil},
+			},
+			inputTypes: []*types.T{types.VarBit, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"B'10110'", 1, "B'10110'"}, {"B'10110'", 3, "B'110'"}, {"B'10110'", 5, "B'0'"},
+				{"B'10110'", 6, "B''"}, {"B'10110'", -3, "B'10110'"}, {"B'10110'", math.MinInt64, "B''"},
+				{nil, 1, nil}, {"B'1'", nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	for _, tc := range []struct {
+		expr        string
+		inputTuple  colexectestutils.Tuple
+		inputTypes  []*types.T
+		expectedErr string
+	}{
+		{
+			expr:        "get_bit(@1, @2)",
+			inputTuple:  colexectestutils.Tuple{"B'1010'", 4},
+			inputTypes:  []*types.T{types.VarBit, types.Int},
+			expectedErr: "get_bit(): GetBitAtIndex: bit index 4 out of valid range (0..3)",
+		},
+		{
+			expr:        "get_bit(@1, @2)",
+			inputTuple:  colexectestutils.Tuple{"B'1010'", -1},
+			inputTypes:  []*types.T{types.VarBit, types.Int},
+			expectedErr: "get_bit(): GetBitAtIndex: bit index -1 out of valid range (0..3)",
+		},
+		{
+			expr:        "get_bit(@1, @2)",
+			inputTuple:  colexectestutils.Tuple{"B''", 0},
+			inputTypes:  []*types.T{types.VarBit, types.Int},
+			expectedErr: "get_bit(): GetBitAtIndex: bit index 0 out of valid range (0..-1)",
+		},
+		{
+			expr:        "set_bit(@1, @2, @3)",
+			inputTuple:  colexectestutils.Tuple{"B'1010'", 4, 1},
+			inputTypes:  []*types.T{types.VarBit, types.Int, types.Int},
+			expectedErr: "set_bit(): SetBitAtIndex: bit index 4 out of valid range (0..3)",
+		},
+		{
+			expr:        "set_bit(@1, @2, @3)",
+			inputTuple:  colexectestutils.Tuple{"B'1010'", 0, 2},
+			inputTypes:  []*types.T{types.VarBit, types.Int, types.Int},
+			expectedErr: "set_bit(): new bit must be 0 or 1.",
+		},
+		{
+			expr:        "substring(@1, @2, @3)",
+			inputTuple:  colexectestutils.Tuple{"B'1010'", 1, -1},
+			inputTypes:  []*types.T{types.VarBit, types.Int, types.Int},
+			expectedErr: "substring(): negative bit subarray length -1 not allowed",
+		},
+	} {
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{tc.inputTuple}, tc.inputTypes)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, tc.inputTypes, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.expectedErr)
+	}
+}
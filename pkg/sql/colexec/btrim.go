@@ -0,0 +1,187 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+)
+
+// newBTrimOperator returns an operator that evaluates btrim(input, trim_chars)
+// builtin. The input is the Bytes column at position argumentCols[0]. If
+// trimChars is non-nil, then it is the constant set of the characters to be
+// trimmed; otherwise, the set is read from the Bytes column at position
+// argumentCols[1].
+func newBTrimOperator(
+	allocator *colmem.Allocator,
+	trimChars *string,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &btrimOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputIdx:       argumentCols[0],
+		charsIdx:       -1,
+		outputIdx:      outputIdx,
+	}
+	if trimChars != nil {
+		op.charSet.reset([]byte(*trimChars))
+	} else {
+		op.charsIdx = argumentCols[1]
+	}
+	return op
+}
+
+// btrimOp is an operator that removes the longest prefix and the longest
+// suffix consisting only of the characters from the given set.
+type btrimOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	inputIdx  int
+	// charsIdx is the index of the column with the trim characters, -1 if the
+	// trim characters are constant.
+	charsIdx  int
+	outputIdx int
+
+	// charSet is the membership set of the trim characters. If the trim
+	// characters are constant, it is built once in the constructor, otherwise
+	// it is rebuilt only when the trim characters differ from the ones of the
+	// previous row (stored in lastChars). Note that the zero value of the set
+	// is empty, so it matches the initially empty lastChars.
+	charSet   trimCharSet
+	lastChars []byte
+}
+
+var _ colexecop.Operator = &btrimOp{}
+
+func (t *btrimOp) Next() coldata.Batch {
+	batch := t.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(t.inputIdx)
+	inputNulls, inputCol := inputVec.Nulls(), inputVec.Bytes()
+	var charsNulls *coldata.Nulls
+	var charsCol *coldata.Bytes
+	if t.charsIdx >= 0 {
+		charsVec := batch.ColVec(t.charsIdx)
+		charsNulls, charsCol = charsVec.Nulls(), charsVec.Bytes()
+	}
+	outputVec := batch.ColVec(t.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	t.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if inputNulls.NullAt(rowIdx) || (charsNulls != nil && charsNulls.NullAt(rowIdx)) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				if charsCol != nil {
+					if chars := charsCol.Get(rowIdx); !bytes.Equal(chars, t.lastChars) {
+						// The trim characters are copied since the memory of
+						// the input vector can be reused by the next batch.
+						t.lastChars = append(t.lastChars[:0], chars...)
+						t.charSet.reset(t.lastChars)
+					}
+				}
+				// Note that trimming only reslices the input value, so the only
+				// copy happens when the result is set into the output vector.
+				outputCol.Set(rowIdx, t.charSet.trim(inputCol.Get(rowIdx)))
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// trimCharSet is a membership set of the characters to be trimmed.
+type trimCharSet struct {
+	// ascii indicates whether each of the ASCII characters is in the set.
+	ascii [utf8.RuneSelf]bool
+	// multibyte contains all other characters in the set. The sets of the trim
+	// characters are usually tiny, so the linear search is good enough.
+	multibyte []rune
+}
+
+// reset makes s contain exactly the characters of the UTF-8 encoded chars. As
+// in strings.Trim, an invalid UTF-8 sequence is treated as utf8.RuneError.
+func (s *trimCharSet) reset(chars []byte) {
+	s.ascii = [utf8.RuneSelf]bool{}
+	s.multibyte = s.multibyte[:0]
+	for len(chars) > 0 {
+		r, size := rune(chars[0]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(chars)
+			s.multibyte = append(s.multibyte, r)
+		} else {
+			s.ascii[r] = true
+		}
+		chars = chars[size:]
+	}
+}
+
+func (s *trimCharSet) contains(r rune) bool {
+	if r < utf8.RuneSelf {
+		return s.ascii[r]
+	}
+	for _, c := range s.multibyte {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+// trim returns the subslice of b with all leading and trailing characters
+// contained in s removed.
+func (s *trimCharSet) trim(b []byte) []byte {
+	for len(b) > 0 {
+		r, size := rune(b[0]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(b)
+		}
+		if !s.contains(r) {
+			break
+		}
+		b = b[size:]
+	}
+	for len(b) > 0 {
+		r, size := rune(b[len(b)-1]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeLastRune(b)
+		}
+		if !s.contains(r) {
+			break
+		}
+		b = b[:len(b)-size]
+	}
+	return b
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestBTrim(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The random test case uses a small alphabet with multibyte characters so
+	// that the trim characters often occur at both ends of the input.
+	rng, _ := randutil.NewPseudoRand()
+	alphabet := []string{"a", "b", " ", "é", "日", "😀"}
+	randString := func(maxLen int) string {
+		var sb strings.Builder
+		for i := rng.Intn(maxLen + 1); i > 0; i-- {
+			sb.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+	const numRandomRows = 200
+	randomInput := make(colexectestutils.Tuples, numRandomRows)
+	randomExpected := make(colexectestutils.Tuples, numRandomRows)
+	for i := range randomInput {
+		s, chars := randString(8), randString(3)
+		randomInput[i] = colexectestutils.Tuple{s, chars}
+		randomExpected[i] = colexectestutils.Tuple{s, chars, strings.Trim(s, chars)}
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:        "constant multibyte",
+			expr:        "btrim(@1, 'é日😀')",
+			inputTuples: colexectestutils.Tuples{{"日é本😀日"}, {"éx"}, {"ee"}, {"本"}, {nil}},
+			inputTypes:  []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"日é本😀日", "本"}, {"éx", "x"}, {"ee", "ee"}, {"本", "本"}, {nil, nil},
+			},
+		},
+		{
+			desc:        "constant full trim",
+			expr:        "btrim(@1, 'ab日')",
+			inputTuples: colexectestutils.Tuples{{"abba"}, {"日a日"}, {""}, {"a"}},
+			inputTypes:  []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"abba", ""}, {"日a日", ""}, {"", ""}, {"a", ""},
+			},
+		},
+		{
+			desc:        "constant empty trim characters",
+			expr:        "btrim(@1, '')",
+			inputTuples: colexectestutils.Tuples{{" abc "}, {""}, {nil}},
+			inputTypes:  []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{" abc ", " abc "}, {"", ""}, {nil, nil},
+			},
+		},
+		{
+			// The trim characters change from row to row, including the
+			// repeated and the empty sets.
+			desc: "column",
+			expr: "btrim(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"xyaxy", "xy"}, {"xbx", "xy"}, {"xbx", ""}, {"日本日", "日"}, {"日本日", "本日"},
+				{"éaé", "é"}, {"abc", nil}, {nil, "a"}, {"aéa", "a"}, {"", "a"},
+			},
+			inputTypes: []*types.T{types.String, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"xyaxy", "xy", "a"}, {"xbx", "xy", "b"}, {"xbx", "", "xbx"}, {"日本日", "日", "本"},
+				{"日本日", "本日", ""}, {"éaé", "é", "a"}, {"abc", nil, nil}, {nil, "a", nil},
+				{"aéa", "a", "é"}, {"", "a", ""},
+			},
+		},
+		{
+			desc:         "column random",
+			expr:         "btrim(@1, @2)",
+			inputTuples:  randomInput,
+			inputTypes:   []*types.T{types.String, types.String},
+			outputTuples: randomExpected,
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 type defaultBuiltinFuncOperator struct {
@@ -118,27 +119,382 @@ func NewBuiltinFunctionOperator(
 	outputIdx int,
 	input colexecop.Operator,
 ) (colexecop.Operator, error) {
-	switch funcExpr.ResolvedOverload().SpecializedVecBuiltin {
+	switch specializedBuiltin := funcExpr.ResolvedOverload().SpecializedVecBuiltin; specializedBuiltin {
+	case tree.AbsDecimal:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Decimal, outputIdx)
+		return newAbsOperator(allocator, argumentCols[0], outputIdx, input), nil
+	case tree.AgeTimestampTZTimestampTZ:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Interval, outputIdx)
+		return newAgeOperator(allocator, argumentCols[0], argumentCols[1], outputIdx, input), nil
+	case tree.ArrayAppend, tree.ArrayCat, tree.ArrayPrepend:
+		// These builtins have the same semantics as the corresponding overloads
+		// of the concatenation operator. We use the argument types of the
+		// overload (rather than of the expressions) so that the untyped NULL
+		// constants are supported too.
+		argTypes := funcExpr.ResolvedOverload().Types.(tree.ArgTypes)
+		var constArgs [2]tree.Datum
+		for i := range constArgs {
+			if d, ok := funcExpr.Exprs[i].(tree.Datum); ok {
+				constArgs[i] = d
+			}
+		}
+		return GetArrayConcatProjectionOperator(
+			allocator, input, argTypes[0].Typ, argTypes[1].Typ, funcExpr.ResolvedType(),
+			argumentCols[0], argumentCols[1], constArgs[0], constArgs[1], outputIdx,
+		)
+	case tree.ArrayLength:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+		var dim *int64
+		if d, ok := funcExpr.Exprs[1].(*tree.DInt); ok {
+			dim = (*int64)(d)
+		}
+		return newArrayLengthOperator(
+			allocator, columnTypes, argumentCols, dim, outputIdx, input,
+		), nil
+	case tree.ArrayPosition, tree.ArrayPositions:
+		all := specializedBuiltin == tree.ArrayPositions
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+		return newArrayPositionOperator(
+			allocator, evalCtx, funcExpr, argumentCols, all, outputIdx, input,
+		), nil
+	case tree.ArrayRemove, tree.ArrayReplace:
+		replace := specializedBuiltin == tree.ArrayReplace
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+		return newArrayRemoveOperator(
+			allocator, evalCtx, funcExpr, argumentCols, replace, outputIdx, input,
+		), nil
+	case tree.ArrayToStringString, tree.ArrayToStringStringString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		withNullStr := specializedBuiltin == tree.ArrayToStringStringString
+		return newArrayToStringOperator(
+			allocator, funcExpr, argumentCols, withNullStr, outputIdx, input,
+		), nil
+	case tree.ASCIIString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+		return newASCIIOperator(allocator, funcExpr, argumentCols[0], outputIdx, input), nil
+	case tree.Cardinality:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+		return newCardinalityOperator(allocator, argumentCols[0], outputIdx, input), nil
+	case tree.ChrInt:
+		// Only the Int64 argument is supported natively, so we fall back to
+		// the default builtin operator otherwise.
+		switch columnTypes[argumentCols[0]].Width() {
+		case 0, 64:
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+			return newChrOperator(allocator, funcExpr, argumentCols[0], outputIdx, input), nil
+		}
+	case tree.ConcatWS:
+		// Only the String arguments are supported natively (for example, an
+		// argument might be an untyped NULL), so we fall back to the default
+		// builtin operator otherwise.
+		supported := len(argumentCols) > 0
+		for _, colIdx := range argumentCols {
+			supported = supported && columnTypes[colIdx].Family() == types.StringFamily
+		}
+		if supported {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+			var sep *string
+			if s, ok := funcExpr.Exprs[0].(*tree.DString); ok {
+				sep = (*string)(s)
+			}
+			return newConcatWSOperator(allocator, sep, argumentCols, outputIdx, input), nil
+		}
+	case tree.CRC32C, tree.CRC32IEEE, tree.FNV32, tree.FNV32a, tree.FNV64, tree.FNV64a,
+		tree.MD5, tree.SHA1, tree.SHA224, tree.SHA256, tree.SHA384, tree.SHA512:
+		// Only the String and Bytes arguments are supported natively (for
+		// example, an argument might be an untyped NULL), so we fall back to
+		// the default builtin operator otherwise.
+		supported := len(argumentCols) > 0
+		for _, colIdx := range argumentCols {
+			family := columnTypes[colIdx].Family()
+			supported = supported && (family == types.StringFamily || family == types.BytesFamily)
+		}
+		if supported {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+			return newHashFuncOperator(allocator, specializedBuiltin, argumentCols, outputIdx, input), nil
+		}
+	case tree.ExtractStringTime, tree.ExtractStringTimeTZ:
+		// Only the constant fields supported by the type are handled natively,
+		// so we fall back to the default builtin operator otherwise (which
+		// returns an error for the unsupported fields).
+		if timeSpan, ok := funcExpr.Exprs[0].(*tree.DString); ok {
+			isTimeTZ := specializedBuiltin == tree.ExtractStringTimeTZ
+			if op, ok := newTimeExtractOperator(
+				allocator, string(*timeSpan), isTimeTZ, argumentCols[1], outputIdx,
+				colexecutils.NewVectorTypeEnforcer(allocator, input, types.Float, outputIdx),
+			); ok {
+				return op, nil
+			}
+		}
+	case tree.JSONBuildArray, tree.JSONBuildObject:
+		// Only some keys of the object are supported natively (for example, a
+		// key might be an integer which is formatted specially), so we fall
+		// back to the default builtin operator otherwise.
+		isObject := specializedBuiltin == tree.JSONBuildObject
+		if !isObject || jsonBuildObjectSupported(funcExpr, columnTypes, argumentCols) {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Jsonb, outputIdx)
+			return newJSONBuildOperator(
+				allocator, evalCtx, funcExpr, columnTypes, argumentCols, isObject, outputIdx, input,
+			), nil
+		}
+	case tree.AcosFloat, tree.AsinFloat, tree.Atan2FloatFloat, tree.AtanFloat, tree.CosFloat,
+		tree.ExpFloat, tree.LnFloat, tree.LogFloat, tree.LogFloatFloat, tree.SinFloat,
+		tree.SqrtFloat, tree.TanFloat:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Float, outputIdx)
+		return newFloatFuncOperator(
+			allocator, funcExpr, specializedBuiltin, argumentCols, outputIdx, input,
+		), nil
+	case tree.GCDIntInt, tree.LCMIntInt:
+		// Only the INT8 arguments are supported natively, so we fall back to
+		// the default builtin operator otherwise.
+		isInt64 := func(t *types.T) bool { return t.Width() == 0 || t.Width() == 64 }
+		if isInt64(columnTypes[argumentCols[0]]) && isInt64(columnTypes[argumentCols[1]]) {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+			return newIntGCDOperator(
+				allocator, funcExpr, specializedBuiltin == tree.LCMIntInt, argumentCols, outputIdx, input,
+			), nil
+		}
+	case tree.GenRandomUUID, tree.Random, tree.UUIDV4:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+		return newRandomOperator(
+			allocator, specializedBuiltin != tree.Random, randutil.NewPseudoSeed(), outputIdx, input,
+		), nil
+	case tree.JSONInsert, tree.JSONInsertWithInsertAfter, tree.JSONSet, tree.JSONSetWithCreateMissing:
+		// Only the constant path and the arguments of the JSON and the Bool
+		// types are supported natively (for example, the new value might be
+		// an untyped NULL), so we fall back to the default builtin operator
+		// otherwise.
+		path, supported := funcExpr.Exprs[1].(*tree.DArray)
+		supported = supported &&
+			columnTypes[argumentCols[0]].Family() == types.JsonFamily &&
+			columnTypes[argumentCols[2]].Family() == types.JsonFamily
+		if len(argumentCols) == 4 {
+			supported = supported && columnTypes[argumentCols[3]].Family() == types.BoolFamily
+		}
+		if supported {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Jsonb, outputIdx)
+			insert := specializedBuiltin == tree.JSONInsert || specializedBuiltin == tree.JSONInsertWithInsertAfter
+			return newJSONSetOperator(allocator, path, insert, argumentCols, outputIdx, input), nil
+		}
+	case tree.JSONStripNulls:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Jsonb, outputIdx)
+		return newJSONStripNullsOperator(allocator, argumentCols[0], outputIdx, input), nil
+	case tree.JSONTypeOf:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newJSONTypeOfOperator(allocator, argumentCols[0], outputIdx, input), nil
+	case tree.HostINet, tree.MaskLenINet:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+		return newINetFuncOperator(
+			allocator, specializedBuiltin == tree.HostINet, argumentCols[0], outputIdx, input,
+		), nil
+	case tree.InitcapString, tree.LowerString, tree.UpperString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newCaseConversionOperator(
+			allocator, specializedBuiltin, argumentCols[0], outputIdx, input,
+		), nil
+	case tree.BitCountBits, tree.BitCountInt:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+		return newBitCountOperator(
+			allocator, columnTypes[argumentCols[0]], argumentCols[0], outputIdx, input,
+		), nil
+	case tree.GetBitVarBitInt, tree.SetBitVarBitIntInt, tree.SubstringVarBitInt,
+		tree.SubstringVarBitIntInt:
+		// Only the bit string column and the Int64 columns for all other
+		// arguments are supported natively (for example, an argument might be
+		// an untyped NULL), so we fall back to the default builtin operator
+		// otherwise.
+		supported := columnTypes[argumentCols[0]].Family() == types.BitFamily
+		for _, colIdx := range argumentCols[1:] {
+			t := columnTypes[colIdx]
+			supported = supported && t.Family() == types.IntFamily && (t.Width() == 0 || t.Width() == 64)
+		}
+		if supported {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, funcExpr.ResolvedType(), outputIdx)
+			return newBitStringFuncOperator(
+				allocator, funcExpr, specializedBuiltin, argumentCols, outputIdx, input,
+			), nil
+		}
+	case tree.CharLengthString, tree.OctetLengthBytes, tree.OctetLengthString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+		return newLengthOperator(
+			allocator, specializedBuiltin, argumentCols[0], outputIdx, input,
+		), nil
+	case tree.BTrimString, tree.LTrimString, tree.RTrimString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newTrimOperator(
+			allocator, specializedBuiltin, nil /* trimChars */, argumentCols[0], outputIdx, input,
+		), nil
+	case tree.BTrimStringString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		var trimChars *string
+		if c, ok := funcExpr.Exprs[1].(*tree.DString); ok {
+			chars := string(*c)
+			trimChars = &chars
+		}
+		return newBTrimOperator(allocator, trimChars, argumentCols, outputIdx, input), nil
+	case tree.LTrimStringString, tree.RTrimStringString:
+		// Only the constant set of the trim characters is supported natively,
+		// so we fall back to the default builtin operator otherwise.
+		if trimChars, ok := funcExpr.Exprs[1].(*tree.DString); ok {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+			chars := string(*trimChars)
+			return newTrimOperator(
+				allocator, specializedBuiltin, &chars, argumentCols[0], outputIdx, input,
+			), nil
+		}
+	case tree.ModDecimalDecimal:
+		// The decimal context must be the same as the one used by the row
+		// engine, otherwise the results could differ in the last digits.
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Decimal, outputIdx)
+		return newDecimalModOperator(
+			allocator, funcExpr, tree.HighPrecisionCtx, argumentCols, outputIdx, input,
+		), nil
+	case tree.OverlayStringStringInt, tree.OverlayStringStringIntInt:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newOverlayOperator(
+			allocator, columnTypes, argumentCols, outputIdx, input,
+		), nil
+	case tree.RegexpSplitToArrayStringString, tree.RegexpSplitToArrayStringStringString:
+		// Only the constant pattern and flags are supported natively, so we
+		// fall back to the default builtin operator otherwise.
+		pattern, ok := funcExpr.Exprs[1].(*tree.DString)
+		flags := new(tree.DString)
+		if specializedBuiltin == tree.RegexpSplitToArrayStringStringString {
+			var flagsOk bool
+			flags, flagsOk = funcExpr.Exprs[2].(*tree.DString)
+			ok = ok && flagsOk
+		}
+		if ok {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.StringArray, outputIdx)
+			return newRegexpSplitToArrayOperator(
+				allocator, evalCtx, funcExpr, string(*pattern), string(*flags),
+				argumentCols[0], outputIdx, input,
+			), nil
+		}
+	case tree.StringToArrayStringString, tree.StringToArrayStringStringString:
+		// Only the constant delimiter and the constant string to be considered
+		// NULL (either of which can be NULL) are supported natively, so we fall
+		// back to the default builtin operator otherwise.
+		delim, ok := constStringOrNull(funcExpr.Exprs[1])
+		var nullStr *string
+		if specializedBuiltin == tree.StringToArrayStringStringString {
+			var nullStrOk bool
+			nullStr, nullStrOk = constStringOrNull(funcExpr.Exprs[2])
+			ok = ok && nullStrOk
+		}
+		if ok {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.StringArray, outputIdx)
+			return newStringToArrayOperator(
+				allocator, delim, nullStr, argumentCols[0], outputIdx, input,
+			), nil
+		}
+	case tree.LeftBytesInt, tree.LeftStringInt, tree.RightBytesInt, tree.RightStringInt:
+		// Only the Int64 count argument is supported natively, so we fall back
+		// to the default builtin operator otherwise.
+		switch columnTypes[argumentCols[1]].Width() {
+		case 0, 64:
+			outputType := funcExpr.ResolvedType()
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputIdx)
+			left := specializedBuiltin == tree.LeftBytesInt || specializedBuiltin == tree.LeftStringInt
+			onBytes := outputType.Family() == types.BytesFamily
+			return newLeftRightOperator(allocator, argumentCols, left, onBytes, outputIdx, input), nil
+		}
+	case tree.LPadStringInt, tree.LPadStringIntString, tree.RPadStringInt, tree.RPadStringIntString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		left := specializedBuiltin == tree.LPadStringInt || specializedBuiltin == tree.LPadStringIntString
+		return newPadOperator(
+			allocator, columnTypes, argumentCols, left, outputIdx, input,
+		), nil
+	case tree.ReplaceStringStringString:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newReplaceOperator(allocator, argumentCols, outputIdx, input), nil
+	case tree.RoundDecimal, tree.RoundDecimalInt, tree.TruncDecimal:
+		// Only the Int64 scale argument is supported natively, so we fall back
+		// to the default builtin operator otherwise.
+		if specializedBuiltin == tree.RoundDecimalInt {
+			if width := columnTypes[argumentCols[1]].Width(); width != 0 && width != 64 {
+				break
+			}
+		}
+		// Same as for mod(), the decimal context must match the row engine.
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Decimal, outputIdx)
+		return newDecimalRoundOperator(
+			allocator, funcExpr, tree.HighPrecisionCtx, specializedBuiltin == tree.TruncDecimal,
+			argumentCols, outputIdx, input,
+		), nil
+	case tree.SplitPartStringStringInt:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+		return newSplitPartOperator(
+			allocator, columnTypes, argumentCols, outputIdx, input,
+		), nil
+	case tree.StrftimeDate, tree.StrftimeTimestamp, tree.StrftimeTimestampTZ:
+		// Only the constant format is supported natively, so we fall back to
+		// the default builtin operator otherwise.
+		if format, ok := funcExpr.Exprs[1].(*tree.DString); ok {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+			return newStrftimeOperator(
+				allocator, funcExpr, string(*format), specializedBuiltin == tree.StrftimeDate,
+				argumentCols[0], outputIdx, input,
+			), nil
+		}
+	case tree.StrptimeStringString:
+		// Only the constant format is supported natively, so we fall back to
+		// the default builtin operator otherwise.
+		if format, ok := funcExpr.Exprs[1].(*tree.DString); ok {
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.TimestampTZ, outputIdx)
+			return newStrptimeOperator(
+				allocator, funcExpr, string(*format), argumentCols[0], outputIdx, input,
+			), nil
+		}
 	case tree.SubstringStringIntInt:
 		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
 		return newSubstringOperator(
 			allocator, columnTypes, argumentCols, outputIdx, input,
 		), nil
-	default:
-		outputType := funcExpr.ResolvedType()
-		input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputIdx)
-		return &defaultBuiltinFuncOperator{
-			OneInputHelper:      colexecop.MakeOneInputHelper(input),
-			allocator:           allocator,
-			evalCtx:             evalCtx,
-			funcExpr:            funcExpr,
-			outputIdx:           outputIdx,
-			columnTypes:         columnTypes,
-			outputType:          outputType,
-			toDatumConverter:    colconv.NewVecToDatumConverter(len(columnTypes), argumentCols, true /* willRelease */),
-			datumToVecConverter: colconv.GetDatumToPhysicalFn(outputType),
-			row:                 make(tree.Datums, len(argumentCols)),
-			argumentCols:        argumentCols,
-		}, nil
+	case tree.TimezoneStringTimestamp, tree.TimezoneStringTimestampTZ:
+		// Only the constant string or the column of the time zones is supported
+		// natively (for example, the time zone might be a NULL constant), so we
+		// fall back to the default builtin operator otherwise.
+		zone, isConstString := funcExpr.Exprs[0].(*tree.DString)
+		if _, isConst := funcExpr.Exprs[0].(tree.Datum); isConstString || !isConst {
+			toTimestampTZ := specializedBuiltin == tree.TimezoneStringTimestamp
+			outputType := types.Timestamp
+			if toTimestampTZ {
+				outputType = types.TimestampTZ
+			}
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputIdx)
+			return newTimezoneOperator(
+				allocator, funcExpr, (*string)(zone), argumentCols, toTimestampTZ, outputIdx, input,
+			), nil
+		}
+	case tree.ToHexInt:
+		// Only the Int64 argument is supported natively, so we fall back to
+		// the default builtin operator otherwise.
+		switch columnTypes[argumentCols[0]].Width() {
+		case 0, 64:
+			input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.String, outputIdx)
+			return newToHexOperator(allocator, argumentCols[0], outputIdx, input), nil
+		}
+	case tree.ToTimestampDecimal, tree.ToTimestampFloat:
+		input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.TimestampTZ, outputIdx)
+		return newToTimestampOperator(
+			allocator, funcExpr, specializedBuiltin == tree.ToTimestampDecimal,
+			argumentCols[0], outputIdx, input,
+		), nil
 	}
+	outputType := funcExpr.ResolvedType()
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputIdx)
+	return &defaultBuiltinFuncOperator{
+		OneInputHelper:      colexecop.MakeOneInputHelper(input),
+		allocator:           allocator,
+		evalCtx:             evalCtx,
+		funcExpr:            funcExpr,
+		outputIdx:           outputIdx,
+		columnTypes:         columnTypes,
+		outputType:          outputType,
+		toDatumConverter:    colconv.NewVecToDatumConverter(len(columnTypes), argumentCols, true /* willRelease */),
+		datumToVecConverter: colconv.GetDatumToPhysicalFn(outputType),
+		row:                 make(tree.Datums, len(argumentCols)),
+		argumentCols:        argumentCols,
+	}, nil
 }
@@ -47,6 +47,10 @@ type caseOp struct {
 	// by the current case arm (those present in the "previous" sel and not
 	// present in the "current" sel).
 	prevSel []int
+	// matchedSel is a buffer used to keep track of all tuples of the dense
+	// batch when a case arm matched all of them and, thus, dropped the
+	// selection vector.
+	matchedSel []int
 }
 
 var _ colexecop.Operator = &caseOp{}
@@ -165,6 +169,16 @@ func (c *caseOp) Next() coldata.Batch {
 			// 3 3
 			// 4   4
 			toSubtract := batch.Selection()
+			if toSubtract == nil {
+				// The selection operators drop the selection vector when all
+				// tuples of the dense batch are selected, so all of them have
+				// matched the current case arm.
+				c.matchedSel = colexecutils.EnsureSelectionVectorLength(c.matchedSel, batch.Length())
+				for j := range c.matchedSel {
+					c.matchedSel[j] = j
+				}
+				toSubtract = c.matchedSel
+			}
 			toSubtract = toSubtract[:batch.Length()]
 			// toSubtract is now a selection vector containing all matched tuples of the
 			// current case arm.
@@ -0,0 +1,112 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// caseConversionTestStrings contains the strings with non-ASCII characters
+// (accented letters, the Turkish dotted and dotless i, letters that change
+// their length in bytes when case-converted) as well as invalid UTF-8.
+var caseConversionTestStrings = []string{
+	"",
+	"hello world",
+	"HELLO World",
+	"élan VITAL à la carte",
+	"ÀÉÎÕÜ àéîõü",
+	"İstanbul ıi Iİ",
+	"ǅungla ǆ Ǆ",
+	"straße STRASSE",
+	"ΣΊΣΥΦΟΣ σίσυφος",
+	"hello_world foo-bar 1st 2ND",
+	"日本語 テキスト",
+	"ⱥ Ⱥ ɐ",
+	"a\xffb \xe6\x97 C",
+}
+
+// TestCaseConversionParity verifies that the case conversion functions used
+// by the vectorized operators produce the same results as the functions used
+// by the row engine.
+func TestCaseConversionParity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	inputs := append([]string(nil), caseConversionTestStrings...)
+	// Generate random strings out of the "interesting" pieces.
+	pieces := []string{"a", "B", " ", "İ", "ı", "_", "ß", "é", "\xff", "ǅ", "-", "Σ", "1"}
+	for i := 0; i < 100; i++ {
+		var b strings.Builder
+		for j := rng.Intn(20); j > 0; j-- {
+			b.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		inputs = append(inputs, b.String())
+	}
+	for _, s := range inputs {
+		require.Equal(t, strings.ToLower(s), string(appendLower(nil, []byte(s))), "lower(%q)", s)
+		require.Equal(t, strings.ToUpper(s), string(appendUpper(nil, []byte(s))), "upper(%q)", s)
+		require.Equal(t, strings.Title(strings.ToLower(s)), string(appendInitcap(nil, []byte(s))), "initcap(%q)", s)
+	}
+}
+
+func TestCaseConversion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		builtin string
+		rowFn   func(string) string
+	}{
+		{builtin: "lower", rowFn: strings.ToLower},
+		{builtin: "upper", rowFn: strings.ToUpper},
+		{builtin: "initcap", rowFn: func(s string) string { return strings.Title(strings.ToLower(s)) }},
+	} {
+		log.Infof(ctx, "%s", tc.builtin)
+		inputTuples := colexectestutils.Tuples{{nil}}
+		expected := colexectestutils.Tuples{{nil, nil}}
+		for _, s := range caseConversionTestStrings {
+			inputTuples = append(inputTuples, colexectestutils.Tuple{s})
+			expected = append(expected, colexectestutils.Tuple{s, tc.rowFn(s)})
+		}
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{inputTuples}, expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], []*types.T{types.String},
+					fmt.Sprintf("%s(@1)", tc.builtin), false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
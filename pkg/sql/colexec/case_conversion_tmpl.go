@@ -0,0 +1,248 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for case_conversion.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _CONVERT is the template function for appending the case-converted second
+// argument to the first argument.
+func _CONVERT(_, _ []byte) []byte {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// newCaseConversionOperator returns an operator that converts the case of the
+// values in the Bytes column at position colIdx according to the case
+// conversion builtin and writes the result into the column at position
+// outputIdx.
+func newCaseConversionOperator(
+	allocator *colmem.Allocator,
+	builtin tree.SpecializedVectorizedBuiltin,
+	colIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := caseConversionOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	switch builtin {
+	case tree.InitcapString:
+		return &initcapOp{caseConversionOpBase: base}
+	case tree.LowerString:
+		return &lowerOp{caseConversionOpBase: base}
+	case tree.UpperString:
+		return &upperOp{caseConversionOpBase: base}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported case conversion builtin %d", builtin))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type caseConversionOpBase struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+	// scratch is the buffer the converted values are written into before
+	// being set into the output vector. It is reused across rows and batches.
+	scratch []byte
+}
+
+// appendRune appends the UTF-8 encoding of r to dst.
+func appendRune(dst []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(dst, buf[:n]...)
+}
+
+// The functions below append the case-converted src to dst and return the
+// result. They produce the same output as the corresponding functions from
+// the strings package used by the row engine (including the replacement of
+// invalid UTF-8 bytes with utf8.RuneError) while not allocating.
+
+// appendLower appends the lower-case equivalent of src to dst. It matches
+// strings.ToLower.
+func appendLower(dst, src []byte) []byte {
+	for i := 0; i < len(src); {
+		if c := src[i]; c < utf8.RuneSelf {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, width := utf8.DecodeRune(src[i:])
+		dst = appendRune(dst, unicode.ToLower(r))
+		i += width
+	}
+	return dst
+}
+
+// appendUpper appends the upper-case equivalent of src to dst. It matches
+// strings.ToUpper.
+func appendUpper(dst, src []byte) []byte {
+	for i := 0; i < len(src); {
+		if c := src[i]; c < utf8.RuneSelf {
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, width := utf8.DecodeRune(src[i:])
+		dst = appendRune(dst, unicode.ToUpper(r))
+		i += width
+	}
+	return dst
+}
+
+// appendInitcap appends src to dst with the first letter of each word in the
+// title case and all other letters in the lower case. It matches
+// strings.Title(strings.ToLower(s)).
+func appendInitcap(dst, src []byte) []byte {
+	// prev is the previous (lower-cased) rune. It starts as a separator so
+	// that the very first letter is capitalized.
+	prev := ' '
+	for i := 0; i < len(src); {
+		r, width := rune(src[i]), 1
+		if r >= utf8.RuneSelf {
+			r, width = utf8.DecodeRune(src[i:])
+		}
+		r = unicode.ToLower(r)
+		if isWordSeparator(prev) {
+			dst = appendRune(dst, unicode.ToTitle(r))
+		} else {
+			dst = appendRune(dst, r)
+		}
+		prev = r
+		i += width
+	}
+	return dst
+}
+
+// isWordSeparator reports whether the rune could mark a word boundary. It is
+// the same as the unexported isSeparator from the strings package which is
+// used by strings.Title.
+func isWordSeparator(r rune) bool {
+	// ASCII alphanumerics and underscore are not separators.
+	if r <= 0x7F {
+		switch {
+		case '0' <= r && r <= '9':
+			return false
+		case 'a' <= r && r <= 'z':
+			return false
+		case 'A' <= r && r <= 'Z':
+			return false
+		case r == '_':
+			return false
+		}
+		return true
+	}
+	// Letters and digits are not separators.
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return false
+	}
+	// Otherwise, all we can do for now is treat spaces as separators.
+	return unicode.IsSpace(r)
+}
+
+// {{range .}}
+
+// _OP_NAMEOp is an operator that evaluates _OP_NAME() builtin on a Bytes
+// column.
+type _OP_NAMEOp struct {
+	caseConversionOpBase
+}
+
+var _ colexecop.Operator = &_OP_NAMEOp{}
+
+func (c *_OP_NAMEOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(c.colIdx)
+	inputCol := inputVec.Bytes()
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	c.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if inputVec.MaybeHasNulls() {
+				inputNulls := inputVec.Nulls()
+				outputNulls := outputVec.Nulls()
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					if inputNulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					v := inputCol.Get(rowIdx)
+					c.scratch = _CONVERT(c.scratch[:0], v)
+					outputCol.Set(rowIdx, c.scratch)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					v := inputCol.Get(rowIdx)
+					c.scratch = _CONVERT(c.scratch[:0], v)
+					outputCol.Set(rowIdx, c.scratch)
+				}
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{end}}
@@ -44,6 +44,7 @@ func TestCaseOp(t *testing.T) {
 		renderExpr string
 		expected   colexectestutils.Tuples
 		inputTypes []*types.T
+		outputType *types.T
 	}{
 		{
 			// Basic test.
@@ -51,6 +52,7 @@ func TestCaseOp(t *testing.T) {
 			renderExpr: "CASE WHEN @1 = 2 THEN 1 ELSE 0 END",
 			expected:   colexectestutils.Tuples{{0}, {1}, {0}, {0}},
 			inputTypes: []*types.T{types.Int},
+			outputType: types.Int,
 		},
 		{
 			// Test "reordered when's."
@@ -58,6 +60,7 @@ func TestCaseOp(t *testing.T) {
 			renderExpr: "CASE WHEN @1 + @2 > 3 THEN 0 WHEN @1 = 2 THEN 1 ELSE 2 END",
 			expected:   colexectestutils.Tuples{{2}, {1}, {2}, {0}},
 			inputTypes: []*types.T{types.Int, types.Int},
+			outputType: types.Int,
 		},
 		{
 			// Test the short-circuiting behavior.
@@ -65,6 +68,16 @@ func TestCaseOp(t *testing.T) {
 			renderExpr: "CASE WHEN @1 = 2 THEN 0::FLOAT WHEN @1 / @2 = 1 THEN 1::FLOAT END",
 			expected:   colexectestutils.Tuples{{nil}, {0.0}, {nil}, {1.0}},
 			inputTypes: []*types.T{types.Int, types.Int},
+			outputType: types.Float,
+		},
+		{
+			// Test the case when the first arm matches all tuples, so the
+			// selection vector is dropped by the WHEN operator.
+			tuples:     colexectestutils.Tuples{{1}, {2}, {3}},
+			renderExpr: "CASE WHEN @1 > 0 THEN @1 + 10 WHEN @1 > 1 THEN 0 ELSE -1 END",
+			expected:   colexectestutils.Tuples{{11}, {12}, {13}},
+			inputTypes: []*types.T{types.Int},
+			outputType: types.Int,
 		},
 	} {
 		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier, func(inputs []colexecop.Operator) (colexecop.Operator, error) {
@@ -77,7 +90,8 @@ func TestCaseOp(t *testing.T) {
 			}
 			// We will project out the input columns in order to have test
 			// cases be less verbose.
-			return colexecbase.NewSimpleProjectOp(caseOp, len(tc.inputTypes)+1, []uint32{uint32(len(tc.inputTypes))}), nil
+			outputTypes := append(tc.inputTypes[:len(tc.inputTypes):len(tc.inputTypes)], tc.outputType)
+			return colexecbase.NewSimpleProjectOp(caseOp, outputTypes, []uint32{uint32(len(tc.inputTypes))}), nil
 		})
 	}
 }
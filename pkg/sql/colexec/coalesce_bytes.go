@@ -0,0 +1,153 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewCoalesceBytesOp returns an operator that projects COALESCE of the
+// columns at indices colIdxs into outputIdx. All columns must be of type t
+// which must have the Bytes canonical type family.
+//
+// When all rows of a batch resolve to the same source column (i.e. that column
+// is the first non-NULL one for every row, or all columns are NULL and it is
+// the last one), the source vector is aliased as the output vector instead of
+// copying the values one by one. Otherwise, the values are copied row by row.
+func NewCoalesceBytesOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	t *types.T,
+	colIdxs []int,
+	outputIdx int,
+) colexecop.Operator {
+	if typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) != types.BytesFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("unexpected type %s for coalesce bytes", t))
+	}
+	if len(colIdxs) == 0 {
+		colexecerror.InternalError(errors.AssertionFailedf("no arguments for coalesce bytes"))
+	}
+	return &coalesceBytesOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		typ:            t,
+		colIdxs:        colIdxs,
+		outputIdx:      outputIdx,
+		nulls:          make([]*coldata.Nulls, len(colIdxs)),
+	}
+}
+
+type coalesceBytesOp struct {
+	colexecop.OneInputHelper
+
+	allocator *colmem.Allocator
+	typ       *types.T
+	colIdxs   []int
+	outputIdx int
+
+	// outputVec is the vector owned by the operator. It is put back into the
+	// batch at the beginning of every call to Next since the output column
+	// might have been aliased to one of the input columns.
+	outputVec coldata.Vec
+	// nulls is a scratch slice for the null bitmaps of the input columns.
+	nulls []*coldata.Nulls
+}
+
+var _ colexecop.Operator = &coalesceBytesOp{}
+
+func (c *coalesceBytesOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	if c.outputVec != nil && c.outputIdx < batch.Width() {
+		// The output column of the previous batch might have been aliased to
+		// one of the input columns, so we need to put our own vector back
+		// before writing into it.
+		batch.ReplaceCol(c.outputVec, c.outputIdx)
+	}
+	c.allocator.MaybeAppendColumn(batch, c.typ, c.outputIdx)
+	c.outputVec = batch.ColVec(c.outputIdx)
+	for i, colIdx := range c.colIdxs {
+		c.nulls[i] = batch.ColVec(colIdx).Nulls()
+	}
+	sel := batch.Selection()
+	if src := c.commonSource(n, sel); src >= 0 {
+		// All rows resolve to the same source, so we can use the source
+		// vector as is.
+		batch.ReplaceCol(batch.ColVec(c.colIdxs[src]), c.outputIdx)
+		return batch
+	}
+	outputVec := c.outputVec
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	c.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			src := c.firstNonNull(rowIdx)
+			if c.nulls[src].NullAt(rowIdx) {
+				outputNulls.SetNull(rowIdx)
+			} else {
+				outputCol.Set(rowIdx, batch.ColVec(c.colIdxs[src]).Bytes().Get(rowIdx))
+			}
+		}
+	})
+	return batch
+}
+
+// firstNonNull returns the position of the source column that the row at
+// rowIdx resolves to. If all columns are NULL, the last one is returned.
+func (c *coalesceBytesOp) firstNonNull(rowIdx int) int {
+	for i := 0; i < len(c.nulls)-1; i++ {
+		if !c.nulls[i].NullAt(rowIdx) {
+			return i
+		}
+	}
+	return len(c.nulls) - 1
+}
+
+// commonSource returns the position of the source column that all n rows of
+// the batch resolve to, or -1 if there is no such column.
+func (c *coalesceBytesOp) commonSource(n int, sel []int) int {
+	if !c.nulls[0].MaybeHasNulls() {
+		// The first column has no NULLs, so all rows resolve to it.
+		return 0
+	}
+	rowIdx := 0
+	if sel != nil {
+		rowIdx = sel[0]
+	}
+	src := c.firstNonNull(rowIdx)
+	for i := 1; i < n; i++ {
+		rowIdx = i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		if c.firstNonNull(rowIdx) != src {
+			return -1
+		}
+	}
+	return src
+}
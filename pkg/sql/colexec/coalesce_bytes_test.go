@@ -0,0 +1,182 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+func TestCoalesceBytesOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	typs := []*types.T{types.String, types.String, types.String}
+	for _, tc := range []struct {
+		desc     string
+		tuples   colexectestutils.Tuples
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc:   "first source",
+			tuples: colexectestutils.Tuples{{"a", "b", "c"}, {"d", nil, "e"}, {"f", "g", nil}},
+			expected: colexectestutils.Tuples{
+				{"a", "b", "c", "a"}, {"d", nil, "e", "d"}, {"f", "g", nil, "f"},
+			},
+		},
+		{
+			desc:   "second source",
+			tuples: colexectestutils.Tuples{{nil, "b", "c"}, {nil, "d", nil}},
+			expected: colexectestutils.Tuples{
+				{nil, "b", "c", "b"}, {nil, "d", nil, "d"},
+			},
+		},
+		{
+			desc:   "all nulls",
+			tuples: colexectestutils.Tuples{{nil, nil, nil}, {nil, nil, nil}},
+			expected: colexectestutils.Tuples{
+				{nil, nil, nil, nil}, {nil, nil, nil, nil},
+			},
+		},
+		{
+			desc:   "last source",
+			tuples: colexectestutils.Tuples{{nil, nil, "a"}, {nil, nil, nil}, {nil, nil, "b"}},
+			expected: colexectestutils.Tuples{
+				{nil, nil, "a", "a"}, {nil, nil, nil, nil}, {nil, nil, "b", "b"},
+			},
+		},
+		{
+			desc: "mixed sources",
+			tuples: colexectestutils.Tuples{
+				{"a", "b", "c"}, {nil, "d", "e"}, {nil, nil, "f"}, {nil, nil, nil}, {"g", nil, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{"a", "b", "c", "a"}, {nil, "d", "e", "d"}, {nil, nil, "f", "f"},
+				{nil, nil, nil, nil}, {"g", nil, nil, "g"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			// Note that the input columns are included into the expected output
+			// in order to check that they are not modified when the output
+			// column is aliased to one of them.
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewCoalesceBytesOp(
+						testAllocator, inputs[0], types.String, []int{0, 1, 2}, len(typs),
+					), nil
+				})
+		})
+	}
+}
+
+func TestCoalesceBytesPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		tuples     colexectestutils.Tuples
+		renderExpr string
+		inputTypes []*types.T
+		expected   colexectestutils.Tuples
+	}{
+		{
+			tuples:     colexectestutils.Tuples{{"a", "b"}, {nil, "c"}, {nil, nil}},
+			renderExpr: "COALESCE(@1, @2)",
+			inputTypes: []*types.T{types.String, types.String},
+			expected:   colexectestutils.Tuples{{"a"}, {"c"}, {nil}},
+		},
+		{
+			tuples:     colexectestutils.Tuples{{nil, "a", "b"}, {"c", nil, "d"}},
+			renderExpr: "COALESCE(@2, @1, @3)",
+			inputTypes: []*types.T{types.Bytes, types.Bytes, types.Bytes},
+			expected:   colexectestutils.Tuples{{"a"}, {"c"}},
+		},
+	} {
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{tc.inputTypes}, tc.expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				op, err := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, inputs[0], tc.inputTypes, tc.renderExpr,
+					false /* canFallbackToRowexec */, testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				// COALESCE is of the type of its arguments.
+				outputTypes := append(tc.inputTypes[:len(tc.inputTypes):len(tc.inputTypes)], tc.inputTypes[0])
+				return colexecbase.NewSimpleProjectOp(op, outputTypes, []uint32{uint32(len(tc.inputTypes))}), nil
+			})
+	}
+}
+
+func BenchmarkCoalesceBytes(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+
+	const numSources = 3
+	typs := []*types.T{types.Bytes, types.Bytes, types.Bytes}
+	for _, dominantSource := range []bool{true, false} {
+		batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+		for i := 0; i < coldata.BatchSize(); i++ {
+			// When a single source dominates, the second column is the first
+			// non-NULL one for all rows. Otherwise, the first non-NULL column is
+			// chosen randomly for every row.
+			src := 1
+			if !dominantSource {
+				src = rng.Intn(numSources)
+			}
+			for j := 0; j < numSources; j++ {
+				if j < src {
+					batch.ColVec(j).Nulls().SetNull(i)
+				} else {
+					batch.ColVec(j).Bytes().Set(i, randutil.RandBytes(rng, 16))
+				}
+			}
+		}
+		batch.SetLength(coldata.BatchSize())
+		b.Run(fmt.Sprintf("dominantSource=%t", dominantSource), func(b *testing.B) {
+			source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+			op := NewCoalesceBytesOp(testAllocator, source, types.Bytes, []int{0, 1, 2}, numSources)
+			op.Init(ctx)
+			b.SetBytes(int64(16 * coldata.BatchSize()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op.Next()
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
@@ -45,6 +46,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
 )
 
 func checkNumIn(inputs []colexecargs.OpWithMetaInfo, numIn int) error {
@@ -150,6 +152,191 @@ func needHashAggregator(aggSpec *execinfrapb.AggregatorSpec) (bool, error) {
 	return false, nil
 }
 
+// isDistinctAggregation returns whether the aggregation doesn't compute any
+// actual aggregates and only outputs the grouping columns (which is the case
+// for queries like SELECT a, b FROM t GROUP BY a, b). Such aggregation is
+// equivalent to the distinct over the grouping columns.
+func isDistinctAggregation(aggSpec *execinfrapb.AggregatorSpec) bool {
+	if aggSpec.IsScalar() || len(aggSpec.GroupCols) == 0 {
+		return false
+	}
+	var groupCols util.FastIntSet
+	for _, col := range aggSpec.GroupCols {
+		groupCols.Add(int(col))
+	}
+	for _, agg := range aggSpec.Aggregations {
+		if agg.Func != execinfrapb.AnyNotNull || agg.Distinct || agg.FilterColIdx != nil ||
+			len(agg.ColIdx) != 1 || !groupCols.Contains(int(agg.ColIdx[0])) {
+			return false
+		}
+	}
+	return true
+}
+
+// foldFinalAvgs finds the render expressions that divide the result of a sum
+// aggregation by the result of a sum_int aggregation, which is how the final
+// stage of the distributed avg is planned (see
+// physicalplan.DistAggregationTable), and replaces each such pair of the
+// aggregations with a single avg aggregation over two arguments (the partial
+// sums and the partial counts) that emits the final result directly. This way
+// the aggregator doesn't have to materialize both intermediate columns only
+// for them to be projected away after the division. The aggregations are
+// folded only if their results aren't used anywhere else.
+//
+// aggArgs is updated in place to use the new aggregator spec, and the updated
+// post-processing spec is returned (post itself is returned if there is
+// nothing to fold). Neither the original aggregator spec nor post is modified.
+func foldFinalAvgs(
+	flowCtx *execinfra.FlowCtx,
+	evalCtx *tree.EvalContext,
+	args *colexecargs.NewColOperatorArgs,
+	aggArgs *colexecagg.NewAggregatorArgs,
+	post *execinfrapb.PostProcessSpec,
+) *execinfrapb.PostProcessSpec {
+	if post.Projection || len(post.RenderExprs) == 0 {
+		return post
+	}
+	aggregations := aggArgs.Spec.Aggregations
+	semaCtx := flowCtx.TypeResolverFactory.NewSemaContext(evalCtx.Txn)
+	renderExprs := make([]tree.TypedExpr, len(post.RenderExprs))
+	numUses := make([]int, len(aggregations))
+	for i := range post.RenderExprs {
+		expr, err := args.ExprHelper.ProcessExpr(post.RenderExprs[i], semaCtx, evalCtx, aggArgs.OutputTypes)
+		if err != nil {
+			// The error will be handled when planning the post-processing
+			// spec.
+			return post
+		}
+		renderExprs[i] = expr
+		_, _ = tree.WalkExpr(ivarCounter(numUses), expr)
+	}
+	isFoldable := func(aggIdx int, fn execinfrapb.AggregatorSpec_Func) bool {
+		agg := &aggregations[aggIdx]
+		return agg.Func == fn && !agg.Distinct && agg.FilterColIdx == nil &&
+			len(agg.ColIdx) == 1 && numUses[aggIdx] == 1
+	}
+	// countIdxs maps the index of each folded sum aggregation to the index of
+	// the corresponding sum_int aggregation.
+	countIdxs := make(map[int]int)
+	var removed util.FastIntSet
+	for i, expr := range renderExprs {
+		sumIdx, countIdx, ok := getFinalAvgArgs(expr)
+		if !ok || !isFoldable(sumIdx, execinfrapb.Sum) || !isFoldable(countIdx, execinfrapb.SumInt) {
+			continue
+		}
+		// The sum in the final stage of the distributed avg is computed over
+		// the partial sums, which are never integers.
+		switch aggArgs.InputTypes[aggregations[sumIdx].ColIdx[0]].Family() {
+		case types.DecimalFamily, types.FloatFamily, types.IntervalFamily:
+		default:
+			continue
+		}
+		countIdxs[sumIdx] = countIdx
+		removed.Add(countIdx)
+		renderExprs[i] = tree.NewTypedOrdinalReference(sumIdx, expr.ResolvedType())
+	}
+	if len(countIdxs) == 0 {
+		return post
+	}
+
+	newSpec := *aggArgs.Spec
+	newSpec.Aggregations = make([]execinfrapb.AggregatorSpec_Aggregation, 0, len(aggregations)-len(countIdxs))
+	var constructors []execinfrapb.AggregateConstructor
+	var constArguments []tree.Datums
+	var outputTypes []*types.T
+	// indexMap maps the index of each remaining aggregation to its new index.
+	indexMap := make([]int, len(aggregations))
+	for i, agg := range aggregations {
+		if removed.Contains(i) {
+			indexMap[i] = -1
+			continue
+		}
+		if countIdx, ok := countIdxs[i]; ok {
+			agg = execinfrapb.AggregatorSpec_Aggregation{
+				Func:   execinfrapb.Avg,
+				ColIdx: []uint32{agg.ColIdx[0], aggregations[countIdx].ColIdx[0]},
+			}
+		}
+		indexMap[i] = len(newSpec.Aggregations)
+		newSpec.Aggregations = append(newSpec.Aggregations, agg)
+		constructors = append(constructors, aggArgs.Constructors[i])
+		constArguments = append(constArguments, aggArgs.ConstArguments[i])
+		outputTypes = append(outputTypes, aggArgs.OutputTypes[i])
+	}
+	aggArgs.Spec = &newSpec
+	aggArgs.Constructors, aggArgs.ConstArguments, aggArgs.OutputTypes = constructors, constArguments, outputTypes
+
+	newPost := *post
+	newPost.RenderExprs = make([]execinfrapb.Expression, len(renderExprs))
+	for i, expr := range renderExprs {
+		newExpr, _ := tree.WalkExpr(ivarRemapper(indexMap), expr)
+		newPost.RenderExprs[i] = execinfrapb.Expression{LocalExpr: newExpr.(tree.TypedExpr)}
+	}
+	return &newPost
+}
+
+// getFinalAvgArgs returns the indices of the sum and the count columns if expr
+// is the final rendering of the distributed avg, i.e. either @sum / @count or
+// @sum / @count::FLOAT.
+func getFinalAvgArgs(expr tree.TypedExpr) (sumIdx, countIdx int, ok bool) {
+	div, ok := expr.(*tree.BinaryExpr)
+	if !ok || div.Operator != tree.Div {
+		return 0, 0, false
+	}
+	sum, ok := div.Left.(*tree.IndexedVar)
+	if !ok {
+		return 0, 0, false
+	}
+	right := div.Right
+	if cast, ok := right.(*tree.CastExpr); ok && cast.ResolvedType().Family() == types.FloatFamily {
+		right = cast.Expr
+	}
+	count, ok := right.(*tree.IndexedVar)
+	if !ok || count.Idx == sum.Idx {
+		return 0, 0, false
+	}
+	return sum.Idx, count.Idx, true
+}
+
+// ivarCounter is a tree.Visitor that counts the number of references to each
+// of the columns.
+type ivarCounter []int
+
+var _ tree.Visitor = ivarCounter(nil)
+
+// VisitPre is a part of tree.Visitor interface.
+func (c ivarCounter) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if ivar, ok := expr.(*tree.IndexedVar); ok {
+		if ivar.Idx < len(c) {
+			c[ivar.Idx]++
+		}
+		return false, expr
+	}
+	return true, expr
+}
+
+// VisitPost is a part of tree.Visitor interface.
+func (ivarCounter) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+// ivarRemapper is a tree.Visitor that remaps the indices of the columns
+// referenced by the expression.
+type ivarRemapper []int
+
+var _ tree.Visitor = ivarRemapper(nil)
+
+// VisitPre is a part of tree.Visitor interface.
+func (m ivarRemapper) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if ivar, ok := expr.(*tree.IndexedVar); ok {
+		// Note that a new unbound variable is created since the original one
+		// might be bound to a container that formats it by its old index.
+		return false, tree.NewTypedOrdinalReference(m[ivar.Idx], ivar.ResolvedType())
+	}
+	return true, expr
+}
+
+// VisitPost is a part of tree.Visitor interface.
+func (ivarRemapper) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
 // IsSupported returns an error if the given spec is not supported by the
 // vectorized engine (neither natively nor by wrapping the corresponding row
 // execution processor).
@@ -205,6 +392,11 @@ func supportedNatively(spec *execinfrapb.ProcessorSpec) error {
 	case spec.Core.Ordinality != nil:
 		return nil
 
+	case spec.Core.ProjectSet != nil:
+		// Only some of the set-returning functions are supported natively,
+		// and we fall back to wrapping the processor otherwise.
+		return nil
+
 	case spec.Core.HashJoiner != nil:
 		if !spec.Core.HashJoiner.OnExpr.Empty() && spec.Core.HashJoiner.Type != descpb.InnerJoin {
 			return errors.Newf("can't plan vectorized non-inner hash joins with ON expressions")
@@ -221,7 +413,26 @@ func supportedNatively(spec *execinfrapb.ProcessorSpec) error {
 		return nil
 
 	case spec.Core.Windower != nil:
-		for _, wf := range spec.Core.Windower.WindowFns {
+		for i := range spec.Core.Windower.WindowFns {
+			wf := &spec.Core.Windower.WindowFns[i]
+			if wf.FilterColIdx != tree.NoColumnIdx {
+				return errors.Newf("window functions with FILTER clause are not supported")
+			}
+			if wf.Func.AggregateFunc != nil {
+				if _, ok := colexecwindow.MovingAggOffset(wf, spec.Input[0].ColumnTypes); ok {
+					continue
+				}
+				if colexecwindow.IsWholePartitionArrayAgg(wf) {
+					continue
+				}
+				if colexecwindow.IsRunningSum(wf, spec.Input[0].ColumnTypes) {
+					continue
+				}
+				if _, ok := colexecwindow.RangeMinMaxOffset(wf, spec.Input[0].ColumnTypes); ok {
+					continue
+				}
+				return errors.Newf("aggregate functions used as window functions are not supported")
+			}
 			if wf.Frame != nil {
 				frame, err := wf.Frame.ConvertToAST()
 				if err != nil {
@@ -231,12 +442,6 @@ func supportedNatively(spec *execinfrapb.ProcessorSpec) error {
 					return errors.Newf("window functions with non-default window frames are not supported")
 				}
 			}
-			if wf.FilterColIdx != tree.NoColumnIdx {
-				return errors.Newf("window functions with FILTER clause are not supported")
-			}
-			if wf.Func.AggregateFunc != nil {
-				return errors.Newf("aggregate functions used as window functions are not supported")
-			}
 
 			if _, supported := colexecwindow.SupportedWindowFns[*wf.Func.WindowFunc]; !supported {
 				return errors.Newf("window function %s is not supported", wf.String())
@@ -334,7 +539,9 @@ func canWrap(mode sessiondatapb.VectorizeExecMode, spec *execinfrapb.ProcessorSp
 // creation of this operator. It is used only to distinguish memory monitors.
 // - post describes the post-processing spec of the processor. It will be used
 // to determine whether top K sort can be planned. If you want the general sort
-// operator, then pass in empty struct.
+// operator, then pass in empty struct. If the top K sort is planned, then the
+// returned operator skips post.Offset rows itself, and post.Offset is reset to
+// zero so that the offset is not applied again.
 func (r opResult) createDiskBackedSort(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
@@ -354,7 +561,10 @@ func (r opResult) createDiskBackedSort(
 	var (
 		sorterMemMonitorName string
 		inMemorySorter       colexecop.Operator
-		err                  error
+		// offset is the number of rows skipped by the top K sorter that the
+		// external sorter needs to skip too.
+		offset uint64
+		err    error
 	)
 	if len(ordering.Columns) == int(matchLen) {
 		// The input is already fully ordered, so there is nothing to sort.
@@ -393,11 +603,11 @@ func (r opResult) createDiskBackedSort(
 				ctx, flowCtx, opNamePrefix+"topk-sort", processorID,
 			)
 		}
-		k := post.Limit + post.Offset
 		inMemorySorter = colexec.NewTopKSorter(
 			colmem.NewAllocator(ctx, topKSorterMemAccount, factory), input, inputTypes,
-			ordering.Columns, k,
+			ordering.Columns, post.Limit, post.Offset,
 		)
+		offset, post.Offset = post.Offset, 0
 	} else {
 		// No optimizations possible. Default to the standard sort operator.
 		var sorterMemAccount *mon.BoundAccount
@@ -409,7 +619,7 @@ func (r opResult) createDiskBackedSort(
 			)
 		}
 		inMemorySorter, err = colexec.NewSorter(
-			colmem.NewAllocator(ctx, sorterMemAccount, factory), input, inputTypes, ordering.Columns,
+			colmem.NewAllocatorWithBatchPool(ctx, sorterMemAccount, factory, args.BatchPool), input, inputTypes, ordering.Columns,
 		)
 	}
 	if err != nil {
@@ -427,7 +637,7 @@ func (r opResult) createDiskBackedSort(
 	// sorter regardless of which sorter variant we have instantiated (i.e.
 	// we don't take advantage of the limits and of partial ordering). We
 	// could improve this.
-	return colexec.NewOneInputDiskSpiller(
+	diskSpiller := colexec.NewOneInputDiskSpiller(
 		input, inMemorySorter.(colexecop.BufferingInMemoryOperator),
 		sorterMemMonitorName,
 		func(input colexecop.Operator) colexecop.Operator {
@@ -462,10 +672,61 @@ func (r opResult) createDiskBackedSort(
 				diskAccount,
 			)
 			r.ToClose = append(r.ToClose, es.(colexecop.Closer))
+			if offset != 0 {
+				return colexec.NewOffsetOp(es, offset)
+			}
 			return es
 		},
 		args.TestingKnobs.SpillingCallbackFn,
-	), nil
+	)
+	// The disk spiller needs to be closed so that the in-memory sorter could
+	// release its output batch.
+	r.ToClose = append(r.ToClose, diskSpiller.(colexecop.Closer))
+	return diskSpiller, nil
+}
+
+// windowPartitionOrdering returns the ordering on the partitionBy columns
+// that the input to the window functions is sorted on. Since the partitions
+// only need to be contiguous, the order of the partitionBy columns as well as
+// their directions are chosen so that the ordering shares the longest possible
+// prefix with the inputOrdering (the remaining columns are sorted ascending).
+func windowPartitionOrdering(
+	partitionBy []uint32, inputOrdering []execinfrapb.Ordering_Column,
+) []execinfrapb.Ordering_Column {
+	var partitionCols, orderedCols util.FastIntSet
+	for _, idx := range partitionBy {
+		partitionCols.Add(int(idx))
+	}
+	partitionOrdering := make([]execinfrapb.Ordering_Column, 0, len(partitionBy))
+	for _, ord := range inputOrdering {
+		if !partitionCols.Contains(int(ord.ColIdx)) {
+			break
+		}
+		if !orderedCols.Contains(int(ord.ColIdx)) {
+			orderedCols.Add(int(ord.ColIdx))
+			partitionOrdering = append(partitionOrdering, ord)
+		}
+	}
+	for _, idx := range partitionBy {
+		if !orderedCols.Contains(int(idx)) {
+			orderedCols.Add(int(idx))
+			partitionOrdering = append(partitionOrdering, execinfrapb.Ordering_Column{ColIdx: idx})
+		}
+	}
+	return partitionOrdering
+}
+
+// orderingMatchLen returns the length of the longest prefix of the required
+// ordering that is already provided by the existing ordering.
+func orderingMatchLen(required, existing []execinfrapb.Ordering_Column) uint32 {
+	var matchLen uint32
+	for i := 0; i < len(required) && i < len(existing); i++ {
+		if required[i] != existing[i] {
+			break
+		}
+		matchLen++
+	}
+	return matchLen
 }
 
 // makeDistBackedSorterConstructors creates a DiskBackedSorterConstructor that
@@ -505,6 +766,70 @@ func (r opResult) makeDiskBackedSorterConstructor(
 	}
 }
 
+// planDistinct creates an operator that outputs the distinct tuples of the
+// input according to distinctCols. If the input is ordered on all of
+// distinctCols, the streaming ordered distinct is planned; otherwise, the
+// disk-backed unordered distinct is used, and outputOrdering specifies the
+// ordering the output of that operator must have if it spills to disk. It is
+// shared by the distinct processor core and the aggregations that only output
+// the grouping columns (see isDistinctAggregation).
+func (r opResult) planDistinct(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	args *colexecargs.NewColOperatorArgs,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	distinctCols, orderedCols []uint32,
+	outputOrdering execinfrapb.Ordering,
+	processorID int32,
+	factory coldata.ColumnFactory,
+) (colexecop.Operator, error) {
+	if len(orderedCols) == len(distinctCols) {
+		return colexecbase.NewOrderedDistinct(input, orderedCols, inputTypes)
+	}
+	// We have separate unit tests that instantiate in-memory distinct
+	// operators, so we don't need to look at
+	// args.TestingKnobs.DiskSpillingDisabled and always instantiate a
+	// disk-backed one here.
+	distinctMemAccount, distinctMemMonitorName := r.createMemAccountForSpillStrategy(
+		ctx, flowCtx, "distinct" /* opName */, processorID,
+	)
+	// TODO(yuzefovich): we have an implementation of partially ordered
+	// distinct, and we should plan it when we have non-empty ordered columns
+	// and we think that the probability of distinct tuples in the input is
+	// about 0.01 or less.
+	allocator := colmem.NewAllocator(ctx, distinctMemAccount, factory)
+	inMemoryUnorderedDistinct := colexec.NewUnorderedDistinct(
+		allocator, input, distinctCols, inputTypes,
+	)
+	edOpName := "external-distinct"
+	diskAccount := r.createDiskAccount(ctx, flowCtx, edOpName, processorID)
+	diskSpiller := colexec.NewOneInputDiskSpiller(
+		input, inMemoryUnorderedDistinct.(colexecop.BufferingInMemoryOperator),
+		distinctMemMonitorName,
+		func(input colexecop.Operator) colexecop.Operator {
+			unlimitedAllocator := colmem.NewAllocator(
+				ctx, r.createBufferingUnlimitedMemAccount(ctx, flowCtx, edOpName, processorID), factory,
+			)
+			return colexec.NewExternalDistinct(
+				unlimitedAllocator,
+				flowCtx,
+				args,
+				input,
+				inputTypes,
+				distinctCols,
+				outputOrdering,
+				r.makeDiskBackedSorterConstructor(ctx, flowCtx, args, edOpName, factory),
+				inMemoryUnorderedDistinct,
+				diskAccount,
+			)
+		},
+		args.TestingKnobs.SpillingCallbackFn,
+	)
+	r.ToClose = append(r.ToClose, diskSpiller.(colexecop.Closer))
+	return diskSpiller, nil
+}
+
 // TODO(yuzefovich): introduce some way to unit test that the meta info tracking
 // works correctly. See #64256 for more details.
 
@@ -802,6 +1127,36 @@ func NewColOperator(
 				result.ColumnTypes = []*types.T{types.Int}
 				break
 			}
+			if isDistinctAggregation(aggSpec) {
+				// The aggregation only outputs the grouping columns, so we plan
+				// the distinct over them, which is cheaper than the aggregator
+				// since it doesn't have to materialize the groups, and then
+				// project out the columns the aggregations refer to.
+				inputTypes := spec.Input[0].ColumnTypes
+				// The output ordering refers to the output columns of the
+				// aggregator, so we need to remap it onto the input columns.
+				var outputOrdering execinfrapb.Ordering
+				for _, col := range aggSpec.OutputOrdering.Columns {
+					col.ColIdx = aggSpec.Aggregations[col.ColIdx].ColIdx[0]
+					outputOrdering.Columns = append(outputOrdering.Columns, col)
+				}
+				result.Root, err = result.planDistinct(
+					ctx, flowCtx, args, inputs[0].Root, inputTypes,
+					aggSpec.GroupCols, aggSpec.OrderedGroupCols, outputOrdering,
+					spec.ProcessorID, factory,
+				)
+				if err != nil {
+					return r, err
+				}
+				projection := make([]uint32, len(aggSpec.Aggregations))
+				result.ColumnTypes = make([]*types.T, len(aggSpec.Aggregations))
+				for i, agg := range aggSpec.Aggregations {
+					projection[i] = agg.ColIdx[0]
+					result.ColumnTypes[i] = inputTypes[agg.ColIdx[0]]
+				}
+				result.Root = colexecbase.NewSimpleProjectOp(result.Root, inputTypes, projection)
+				break
+			}
 
 			var needHash bool
 			needHash, err = needHashAggregator(aggSpec)
@@ -823,6 +1178,7 @@ func NewColOperator(
 			if err != nil {
 				return r, err
 			}
+			post = foldFinalAvgs(flowCtx, evalCtx, args, newAggArgs, post)
 			result.ColumnTypes = newAggArgs.OutputTypes
 
 			if needHash {
@@ -865,7 +1221,7 @@ func NewColOperator(
 					spillingQueueCfg := args.DiskQueueCfg
 					spillingQueueCfg.CacheMode = colcontainer.DiskQueueCacheModeReuseCache
 					spillingQueueCfg.SetDefaultBufferSizeBytesForCacheMode()
-					newAggArgs.Allocator = colmem.NewAllocator(ctx, hashAggregatorMemAccount, factory)
+					newAggArgs.Allocator = colmem.NewAllocatorWithBatchPool(ctx, hashAggregatorMemAccount, factory, args.BatchPool)
 					newAggArgs.MemAccount = hashAggregatorMemAccount
 					var inMemoryHashAggregator colexecop.Operator
 					inMemoryHashAggregator, err = colexec.NewHashAggregator(
@@ -928,48 +1284,11 @@ func NewColOperator(
 			}
 			result.ColumnTypes = make([]*types.T, len(spec.Input[0].ColumnTypes))
 			copy(result.ColumnTypes, spec.Input[0].ColumnTypes)
-			if len(core.Distinct.OrderedColumns) == len(core.Distinct.DistinctColumns) {
-				result.Root, err = colexecbase.NewOrderedDistinct(inputs[0].Root, core.Distinct.OrderedColumns, result.ColumnTypes)
-			} else {
-				// We have separate unit tests that instantiate in-memory
-				// distinct operators, so we don't need to look at
-				// args.TestingKnobs.DiskSpillingDisabled and always instantiate
-				// a disk-backed one here.
-				distinctMemAccount, distinctMemMonitorName := result.createMemAccountForSpillStrategy(
-					ctx, flowCtx, "distinct" /* opName */, spec.ProcessorID,
-				)
-				// TODO(yuzefovich): we have an implementation of partially
-				// ordered distinct, and we should plan it when we have
-				// non-empty ordered columns and we think that the probability
-				// of distinct tuples in the input is about 0.01 or less.
-				allocator := colmem.NewAllocator(ctx, distinctMemAccount, factory)
-				inMemoryUnorderedDistinct := colexec.NewUnorderedDistinct(
-					allocator, inputs[0].Root, core.Distinct.DistinctColumns, result.ColumnTypes,
-				)
-				edOpName := "external-distinct"
-				diskAccount := result.createDiskAccount(ctx, flowCtx, edOpName, spec.ProcessorID)
-				result.Root = colexec.NewOneInputDiskSpiller(
-					inputs[0].Root, inMemoryUnorderedDistinct.(colexecop.BufferingInMemoryOperator),
-					distinctMemMonitorName,
-					func(input colexecop.Operator) colexecop.Operator {
-						unlimitedAllocator := colmem.NewAllocator(
-							ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, edOpName, spec.ProcessorID), factory,
-						)
-						return colexec.NewExternalDistinct(
-							unlimitedAllocator,
-							flowCtx,
-							args,
-							input,
-							result.ColumnTypes,
-							result.makeDiskBackedSorterConstructor(ctx, flowCtx, args, edOpName, factory),
-							inMemoryUnorderedDistinct,
-							diskAccount,
-						)
-					},
-					args.TestingKnobs.SpillingCallbackFn,
-				)
-				result.ToClose = append(result.ToClose, result.Root.(colexecop.Closer))
-			}
+			result.Root, err = result.planDistinct(
+				ctx, flowCtx, args, inputs[0].Root, result.ColumnTypes,
+				core.Distinct.DistinctColumns, core.Distinct.OrderedColumns,
+				core.Distinct.OutputOrdering, spec.ProcessorID, factory,
+			)
 
 		case core.Ordinality != nil:
 			if err := checkNumIn(inputs, 1); err != nil {
@@ -979,6 +1298,19 @@ func NewColOperator(
 			result.Root = colexecbase.NewOrdinalityOp(streamingAllocator, inputs[0].Root, outputIdx)
 			result.ColumnTypes = appendOneType(spec.Input[0].ColumnTypes, types.Int)
 
+		case core.ProjectSet != nil:
+			if err := checkNumIn(inputs, 1); err != nil {
+				return r, err
+			}
+			result.ColumnTypes = make([]*types.T, len(spec.Input[0].ColumnTypes))
+			copy(result.ColumnTypes, spec.Input[0].ColumnTypes)
+			result.Root = inputs[0].Root
+			if err := result.planAndMaybeWrapProjectSet(
+				ctx, flowCtx, evalCtx, args, spec.ProcessorID, core.ProjectSet, streamingAllocator, factory,
+			); err != nil {
+				return r, err
+			}
+
 		case core.HashJoiner != nil:
 			if err := checkNumIn(inputs, 2); err != nil {
 				return r, err
@@ -987,8 +1319,24 @@ func NewColOperator(
 			copy(leftTypes, spec.Input[0].ColumnTypes)
 			rightTypes := make([]*types.T, len(spec.Input[1].ColumnTypes))
 			copy(rightTypes, spec.Input[1].ColumnTypes)
+			if core.HashJoiner.Type.IsSetOpJoin() {
+				// The set operations compare the equality columns of both
+				// inputs, so we need to make sure that their types match.
+				opName := "INTERSECT"
+				if core.HashJoiner.Type == descpb.ExceptAllJoin {
+					opName = "EXCEPT"
+				}
+				inputs[0].Root, inputs[1].Root, leftTypes, rightTypes, err = colexecbase.ReconcileEqualityColumns(
+					streamingAllocator, inputs[0].Root, inputs[1].Root, leftTypes, rightTypes,
+					core.HashJoiner.LeftEqColumns, core.HashJoiner.RightEqColumns, opName,
+				)
+				if err != nil {
+					return r, err
+				}
+			}
 
 			memoryLimit := execinfra.GetWorkMemLimit(flowCtx)
+			onExpr := core.HashJoiner.OnExpr
 			if len(core.HashJoiner.LeftEqColumns) == 0 {
 				// We are performing a cross-join, so we need to plan a
 				// specialized operator.
@@ -1019,8 +1367,8 @@ func NewColOperator(
 					hashJoinerMemAccount, hashJoinerMemMonitorName = result.createMemAccountForSpillStrategy(
 						ctx, flowCtx, opName, spec.ProcessorID,
 					)
-					hashJoinerUnlimitedAllocator = colmem.NewAllocator(
-						ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, opName, spec.ProcessorID), factory,
+					hashJoinerUnlimitedAllocator = colmem.NewAllocatorWithBatchPool(
+						ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, opName, spec.ProcessorID), factory, args.BatchPool,
 					)
 				}
 				hjSpec := colexecjoin.MakeHashJoinerSpec(
@@ -1031,6 +1379,11 @@ func NewColOperator(
 					rightTypes,
 					core.HashJoiner.RightEqColumnsAreKey,
 				)
+				if !onExpr.Empty() && core.HashJoiner.Type == descpb.InnerJoin {
+					hjSpec.ProbeFilter, onExpr = result.planProbeFilter(
+						ctx, flowCtx, evalCtx, args, onExpr, leftTypes, rightTypes, factory,
+					)
+				}
 
 				inMemoryHashJoiner := colexecjoin.NewHashJoiner(
 					colmem.NewAllocator(ctx, hashJoinerMemAccount, factory),
@@ -1066,14 +1419,17 @@ func NewColOperator(
 						},
 						args.TestingKnobs.SpillingCallbackFn,
 					)
+					// The disk spiller needs to be closed so that the in-memory
+					// hash joiner could release its output batch.
+					result.ToClose = append(result.ToClose, result.Root.(colexecop.Closer))
 				}
 			}
 
 			result.ColumnTypes = core.HashJoiner.Type.MakeOutputTypes(leftTypes, rightTypes)
 
-			if !core.HashJoiner.OnExpr.Empty() && core.HashJoiner.Type == descpb.InnerJoin {
+			if !onExpr.Empty() && core.HashJoiner.Type == descpb.InnerJoin {
 				if err = result.planAndMaybeWrapFilter(
-					ctx, flowCtx, evalCtx, args, spec.ProcessorID, core.HashJoiner.OnExpr, factory,
+					ctx, flowCtx, evalCtx, args, spec.ProcessorID, onExpr, factory,
 				); err != nil {
 					return r, err
 				}
@@ -1110,12 +1466,20 @@ func NewColOperator(
 					ctx, flowCtx, opName, spec.ProcessorID,
 				), factory)
 			diskAccount := result.createDiskAccount(ctx, flowCtx, opName, spec.ProcessorID)
-			mj, err := colexecjoin.NewMergeJoinOp(
+			// If there is a limit and no ON expression, we know exactly how
+			// many tuples the merge joiner needs to emit, so it can stop
+			// consuming its inputs once that number is reached. The last part
+			// of the condition is making sure there is no overflow.
+			var limit uint64
+			if onExpr == nil && post.Limit != 0 && post.Limit < math.MaxUint64-post.Offset {
+				limit = post.Limit + post.Offset
+			}
+			mj, err := colexecjoin.NewMergeJoinOpWithLimit(
 				unlimitedAllocator, execinfra.GetWorkMemLimit(flowCtx),
 				args.DiskQueueCfg, args.FDSemaphore,
 				joinType, inputs[0].Root, inputs[1].Root, leftTypes, rightTypes,
 				core.MergeJoiner.LeftOrdering.Columns, core.MergeJoiner.RightOrdering.Columns,
-				diskAccount,
+				diskAccount, limit,
 			)
 			if err != nil {
 				return r, err
@@ -1142,6 +1506,11 @@ func NewColOperator(
 			copy(result.ColumnTypes, spec.Input[0].ColumnTypes)
 			ordering := core.Sorter.OutputOrdering
 			matchLen := core.Sorter.OrderingMatchLen
+			// The sorter might apply the offset itself, so we give it a copy
+			// of the post-processing spec which it is allowed to modify.
+			sorterPost := *post
+			post = &sorterPost
+			input, result.ColumnTypes, ordering = pruneSortInput(input, result.ColumnTypes, ordering, post)
 			result.Root, err = result.createDiskBackedSort(
 				ctx, flowCtx, args, input, result.ColumnTypes, ordering, matchLen, 0, /* maxNumberPartitions */
 				spec.ProcessorID, post, "" /* opNamePrefix */, factory,
@@ -1155,110 +1524,223 @@ func NewColOperator(
 			input := inputs[0].Root
 			result.ColumnTypes = make([]*types.T, len(spec.Input[0].ColumnTypes))
 			copy(result.ColumnTypes, spec.Input[0].ColumnTypes)
-			for _, wf := range core.Windower.WindowFns {
-				// We allocate the capacity for two extra types because of the
-				// temporary columns that can be appended below.
-				typs := make([]*types.T, len(result.ColumnTypes), len(result.ColumnTypes)+2)
-				copy(typs, result.ColumnTypes)
-				tempColOffset, partitionColIdx := uint32(0), tree.NoColumnIdx
-				peersColIdx := tree.NoColumnIdx
-				windowFn := *wf.Func.WindowFunc
-				if len(core.Windower.PartitionBy) > 0 {
-					// TODO(yuzefovich): add support for hashing partitioner
-					// (probably by leveraging hash routers once we can
-					// distribute). The decision about which kind of partitioner
-					// to use should come from the optimizer.
-					partitionColIdx = int(wf.OutputColIdx)
-					input, err = colexecwindow.NewWindowSortingPartitioner(
-						streamingAllocator, input, typs,
-						core.Windower.PartitionBy, wf.Ordering.Columns, int(wf.OutputColIdx),
-						func(input colexecop.Operator, inputTypes []*types.T, orderingCols []execinfrapb.Ordering_Column) (colexecop.Operator, error) {
-							return result.createDiskBackedSort(
-								ctx, flowCtx, args, input, inputTypes,
-								execinfrapb.Ordering{Columns: orderingCols}, 0, /* matchLen */
-								0 /* maxNumberPartitions */, spec.ProcessorID,
-								&execinfrapb.PostProcessSpec{}, opNamePrefix, factory)
-						},
-					)
-					// Window partitioner will append a boolean column.
-					tempColOffset++
-					typs = typs[:len(typs)+1]
-					typs[len(typs)-1] = types.Bool
-				} else {
-					if len(wf.Ordering.Columns) > 0 {
-						input, err = result.createDiskBackedSort(
-							ctx, flowCtx, args, input, typs,
-							wf.Ordering, 0 /* matchLen */, 0, /* maxNumberPartitions */
-							spec.ProcessorID, &execinfrapb.PostProcessSpec{}, opNamePrefix, factory,
+			// inputOrdering is the ordering of the input to the current window
+			// function. All window operators preserve the order of the tuples
+			// and only append the output columns, so the ordering established
+			// for one window function still holds for the next one.
+			inputOrdering := spec.Input[0].Ordering.Columns
+			var partitionOrdering []execinfrapb.Ordering_Column
+			if len(core.Windower.PartitionBy) > 0 {
+				partitionOrdering = windowPartitionOrdering(core.Windower.PartitionBy, inputOrdering)
+			}
+			// The consecutive window functions with the same ordering (the
+			// partitioning is shared by all window functions of the windower)
+			// are computed in one pass over the partitioned input: the
+			// temporary partition and peers columns are appended once and are
+			// reused by all such window functions, and they are projected out
+			// only after the last one of them. typs is the type schema of the
+			// input to the current window function including the temporary
+			// columns which are tracked by tempCols.
+			typs := make([]*types.T, len(result.ColumnTypes))
+			copy(typs, result.ColumnTypes)
+			var tempCols util.FastIntSet
+			partitionColIdx, sharedPeersColIdx := tree.NoColumnIdx, tree.NoColumnIdx
+			windowFns := core.Windower.WindowFns
+			for wfIdx, wf := range windowFns {
+				if wfIdx == 0 || !wf.Ordering.Equal(windowFns[wfIdx-1].Ordering) {
+					requiredOrdering := make([]execinfrapb.Ordering_Column, 0, len(partitionOrdering)+len(wf.Ordering.Columns))
+					requiredOrdering = append(requiredOrdering, partitionOrdering...)
+					requiredOrdering = append(requiredOrdering, wf.Ordering.Columns...)
+					matchLen := orderingMatchLen(requiredOrdering, inputOrdering)
+					if len(core.Windower.PartitionBy) > 0 {
+						// TODO(yuzefovich): add support for hashing partitioner
+						// (probably by leveraging hash routers once we can
+						// distribute). The decision about which kind of
+						// partitioner to use should come from the optimizer.
+						partitionColIdx = len(typs)
+						input, err = colexecwindow.NewWindowSortingPartitioner(
+							streamingAllocator, input, typs,
+							partitionOrdering, wf.Ordering.Columns, partitionColIdx,
+							func(input colexecop.Operator, inputTypes []*types.T, orderingCols []execinfrapb.Ordering_Column) (colexecop.Operator, error) {
+								return result.createDiskBackedSort(
+									ctx, flowCtx, args, input, inputTypes,
+									execinfrapb.Ordering{Columns: orderingCols}, matchLen,
+									0 /* maxNumberPartitions */, spec.ProcessorID,
+									&execinfrapb.PostProcessSpec{}, opNamePrefix, factory)
+							},
 						)
+						// Window partitioner will append a boolean column.
+						tempCols.Add(partitionColIdx)
+						typs = appendOneType(typs, types.Bool)
+					} else {
+						if len(wf.Ordering.Columns) > 0 {
+							input, err = result.createDiskBackedSort(
+								ctx, flowCtx, args, input, typs,
+								wf.Ordering, matchLen, 0, /* maxNumberPartitions */
+								spec.ProcessorID, &execinfrapb.PostProcessSpec{}, opNamePrefix, factory,
+							)
+						}
+					}
+					if err != nil {
+						return r, err
+					}
+					if int(matchLen) < len(requiredOrdering) {
+						// The input has just been sorted.
+						inputOrdering = requiredOrdering
 					}
 				}
-				if err != nil {
-					return r, err
+				rangeOffset, isRangeMinMax := colexecwindow.RangeMinMaxOffset(&wf, typs)
+				needsPeersInfo := wf.Func.WindowFunc != nil && colexecwindow.WindowFnNeedsPeersInfo(*wf.Func.WindowFunc)
+				if wf.Func.AggregateFunc != nil {
+					// In RANGE mode, the peers of the current row share its
+					// window frame.
+					needsPeersInfo = colexecwindow.MovingAggNeedsPeersInfo(&wf) || isRangeMinMax
 				}
-				if colexecwindow.WindowFnNeedsPeersInfo(*wf.Func.WindowFunc) {
-					peersColIdx = int(wf.OutputColIdx + tempColOffset)
-					input, err = colexecwindow.NewWindowPeerGrouper(
-						streamingAllocator, input, typs, wf.Ordering.Columns,
-						partitionColIdx, peersColIdx,
-					)
-					// Window peer grouper will append a boolean column.
-					tempColOffset++
-					typs = typs[:len(typs)+1]
-					typs[len(typs)-1] = types.Bool
+				peersColIdx := tree.NoColumnIdx
+				if needsPeersInfo {
+					if sharedPeersColIdx == tree.NoColumnIdx {
+						sharedPeersColIdx = len(typs)
+						input, err = colexecwindow.NewWindowPeerGrouper(
+							streamingAllocator, input, typs, wf.Ordering.Columns,
+							partitionColIdx, sharedPeersColIdx,
+						)
+						if err != nil {
+							return r, err
+						}
+						// Window peer grouper will append a boolean column.
+						tempCols.Add(sharedPeersColIdx)
+						typs = appendOneType(typs, types.Bool)
+					}
+					peersColIdx = sharedPeersColIdx
 				}
 
-				outputIdx := int(wf.OutputColIdx + tempColOffset)
-				switch windowFn {
-				case execinfrapb.WindowerSpec_ROW_NUMBER:
-					result.Root = colexecwindow.NewRowNumberOperator(streamingAllocator, input, outputIdx, partitionColIdx)
-				case execinfrapb.WindowerSpec_RANK, execinfrapb.WindowerSpec_DENSE_RANK:
-					result.Root, err = colexecwindow.NewRankOperator(
-						streamingAllocator, input, windowFn, wf.Ordering.Columns,
-						outputIdx, partitionColIdx, peersColIdx,
+				outputIdx := len(typs)
+				if colexecwindow.IsWholePartitionArrayAgg(&wf) {
+					// We are using an unlimited memory monitor here because
+					// the array_agg operator itself is responsible for making
+					// sure that we stay within the memory limit, and it will
+					// fall back to disk if necessary.
+					opName := opNamePrefix + "array-agg"
+					unlimitedAllocator := colmem.NewAllocator(
+						ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, opName, spec.ProcessorID), factory,
+					)
+					diskAcc := result.createDiskAccount(ctx, flowCtx, opName, spec.ProcessorID)
+					result.Root = colexecwindow.NewArrayAggOperator(
+						unlimitedAllocator, execinfra.GetWorkMemLimit(flowCtx), args.DiskQueueCfg,
+						args.FDSemaphore, input, typs, int(wf.ArgsIdxs[0]), outputIdx,
+						partitionColIdx, diskAcc,
 					)
-				case execinfrapb.WindowerSpec_PERCENT_RANK, execinfrapb.WindowerSpec_CUME_DIST:
+					result.ToClose = append(result.ToClose, result.Root.(colexecop.Closer))
+				} else if colexecwindow.IsRunningSum(&wf, typs) {
+					result.Root, err = colexecwindow.NewRunningSumOperator(
+						streamingAllocator, input, typs, int(wf.ArgsIdxs[0]), outputIdx, partitionColIdx,
+					)
+				} else if isRangeMinMax {
 					// We are using an unlimited memory monitor here because
-					// relative rank operators themselves are responsible for
-					// making sure that we stay within the memory limit, and
-					// they will fall back to disk if necessary.
-					opName := opNamePrefix + "relative-rank"
+					// the range min max operator itself is responsible for
+					// making sure that we stay within the memory limit when
+					// buffering the tuples, and it will fall back to disk if
+					// necessary.
+					opName := opNamePrefix + "range-min-max"
 					unlimitedAllocator := colmem.NewAllocator(
 						ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, opName, spec.ProcessorID), factory,
 					)
 					diskAcc := result.createDiskAccount(ctx, flowCtx, opName, spec.ProcessorID)
-					result.Root, err = colexecwindow.NewRelativeRankOperator(
+					var location *time.Location
+					if typs[wf.Ordering.Columns[0].ColIdx].Family() == types.TimestampTZFamily {
+						location = evalCtx.GetLocation()
+					}
+					result.Root, err = colexecwindow.NewRangeMinMaxOperator(
 						unlimitedAllocator, execinfra.GetWorkMemLimit(flowCtx), args.DiskQueueCfg,
-						args.FDSemaphore, input, typs, windowFn, wf.Ordering.Columns,
-						outputIdx, partitionColIdx, peersColIdx, diskAcc,
+						args.FDSemaphore, input, typs, *wf.Func.AggregateFunc, int(wf.ArgsIdxs[0]),
+						wf.Ordering.Columns[0], rangeOffset, location, outputIdx, partitionColIdx,
+						peersColIdx, diskAcc,
 					)
-					// NewRelativeRankOperator sometimes returns a constOp when
-					// there are no ordering columns, so we check that the
-					// returned operator is a Closer.
-					if c, ok := result.Root.(colexecop.Closer); ok {
-						result.ToClose = append(result.ToClose, c)
+					if err == nil {
+						result.ToClose = append(result.ToClose, result.Root.(colexecop.Closer))
 					}
-				default:
-					return r, errors.AssertionFailedf("window function %s is not supported", wf.String())
-				}
-
-				if tempColOffset > 0 {
-					// We want to project out temporary columns (which have
-					// indices in the range [wf.OutputColIdx,
-					// wf.OutputColIdx+tempColOffset)).
-					projection := make([]uint32, 0, wf.OutputColIdx+tempColOffset)
-					for i := uint32(0); i < wf.OutputColIdx; i++ {
-						projection = append(projection, i)
+				} else if wf.Func.AggregateFunc != nil {
+					// The only other aggregate functions used as window
+					// functions that we support are the moving aggregates
+					// (this has been checked in supportedNatively).
+					offset, ok := colexecwindow.MovingAggOffset(&wf, typs)
+					if !ok {
+						return r, errors.AssertionFailedf("window function %s is not supported", wf.String())
+					}
+					switch aggFn := *wf.Func.AggregateFunc; aggFn {
+					case execinfrapb.Min, execinfrapb.Max:
+						result.Root, err = colexecwindow.NewMovingMinMaxOperator(
+							streamingAllocator, input, typs, aggFn, int(wf.ArgsIdxs[0]),
+							outputIdx, partitionColIdx, offset,
+						)
+					default:
+						result.Root, err = colexecwindow.NewMovingAggOperator(
+							streamingAllocator, input, typs, aggFn,
+							int(wf.ArgsIdxs[0]), outputIdx, partitionColIdx, peersColIdx,
+							offset, wf.Frame.Exclusion,
+						)
+					}
+				} else {
+					switch windowFn := *wf.Func.WindowFunc; windowFn {
+					case execinfrapb.WindowerSpec_ROW_NUMBER:
+						result.Root = colexecwindow.NewRowNumberOperator(streamingAllocator, input, outputIdx, partitionColIdx)
+					case execinfrapb.WindowerSpec_RANK, execinfrapb.WindowerSpec_DENSE_RANK:
+						result.Root, err = colexecwindow.NewRankOperator(
+							streamingAllocator, input, windowFn, wf.Ordering.Columns,
+							outputIdx, partitionColIdx, peersColIdx,
+						)
+					case execinfrapb.WindowerSpec_PERCENT_RANK, execinfrapb.WindowerSpec_CUME_DIST:
+						// We are using an unlimited memory monitor here because
+						// relative rank operators themselves are responsible for
+						// making sure that we stay within the memory limit, and
+						// they will fall back to disk if necessary.
+						opName := opNamePrefix + "relative-rank"
+						unlimitedAllocator := colmem.NewAllocator(
+							ctx, result.createBufferingUnlimitedMemAccount(ctx, flowCtx, opName, spec.ProcessorID), factory,
+						)
+						diskAcc := result.createDiskAccount(ctx, flowCtx, opName, spec.ProcessorID)
+						result.Root, err = colexecwindow.NewRelativeRankOperator(
+							unlimitedAllocator, execinfra.GetWorkMemLimit(flowCtx), args.DiskQueueCfg,
+							args.FDSemaphore, input, typs, windowFn, wf.Ordering.Columns,
+							outputIdx, partitionColIdx, peersColIdx, diskAcc,
+						)
+						// NewRelativeRankOperator sometimes returns a constOp when
+						// there are no ordering columns, so we check that the
+						// returned operator is a Closer.
+						if c, ok := result.Root.(colexecop.Closer); ok {
+							result.ToClose = append(result.ToClose, c)
+						}
+					default:
+						return r, errors.AssertionFailedf("window function %s is not supported", wf.String())
 					}
-					projection = append(projection, wf.OutputColIdx+tempColOffset)
-					result.Root = colexecbase.NewSimpleProjectOp(result.Root, int(wf.OutputColIdx+tempColOffset), projection)
 				}
 
-				_, returnType, err := execinfrapb.GetWindowFunctionInfo(wf.Func, []*types.T{}...)
+				argTypes := make([]*types.T, len(wf.ArgsIdxs))
+				for i, idx := range wf.ArgsIdxs {
+					argTypes[i] = typs[idx]
+				}
+				_, returnType, err := execinfrapb.GetWindowFunctionInfo(wf.Func, argTypes...)
 				if err != nil {
 					return r, err
 				}
 				result.ColumnTypes = appendOneType(result.ColumnTypes, returnType)
+				typs = appendOneType(typs, returnType)
+				if wfIdx == len(windowFns)-1 || !wf.Ordering.Equal(windowFns[wfIdx+1].Ordering) {
+					if !tempCols.Empty() {
+						// We want to project out the temporary columns.
+						projection := make([]uint32, 0, len(typs)-tempCols.Len())
+						for i := range typs {
+							if !tempCols.Contains(i) {
+								projection = append(projection, uint32(i))
+							}
+						}
+						result.Root = colexecbase.NewSimpleProjectOp(result.Root, typs, projection)
+						typs = make([]*types.T, len(result.ColumnTypes))
+						copy(typs, result.ColumnTypes)
+						tempCols = util.FastIntSet{}
+					}
+					partitionColIdx, sharedPeersColIdx = tree.NoColumnIdx, tree.NoColumnIdx
+				}
 				input = result.Root
 			}
 
@@ -1410,6 +1892,135 @@ func (r opResult) planAndMaybeWrapFilter(
 	return nil
 }
 
+// planProbeFilter extracts the conjuncts of the ON expression of an inner hash
+// join that only reference the columns of the left input. Such conjuncts are
+// evaluated by the hash joiner on the probe input, so the tuples that don't
+// pass them are never probed. The constructor of the probe filter (nil if
+// there are no such conjuncts or if they cannot be planned natively) is
+// returned along with the remaining part of the ON expression (which is empty
+// if all conjuncts have been extracted).
+func (r opResult) planProbeFilter(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	evalCtx *tree.EvalContext,
+	args *colexecargs.NewColOperatorArgs,
+	onExpr execinfrapb.Expression,
+	leftTypes, rightTypes []*types.T,
+	factory coldata.ColumnFactory,
+) (func(colexecop.Operator) colexecop.Operator, execinfrapb.Expression) {
+	outputTypes := make([]*types.T, 0, len(leftTypes)+len(rightTypes))
+	outputTypes = append(outputTypes, leftTypes...)
+	outputTypes = append(outputTypes, rightTypes...)
+	semaCtx := flowCtx.TypeResolverFactory.NewSemaContext(evalCtx.Txn)
+	expr, err := args.ExprHelper.ProcessExpr(onExpr, semaCtx, evalCtx, outputTypes)
+	if err != nil {
+		// The error will be handled when planning the ON expression on top
+		// of the join.
+		return nil, onExpr
+	}
+	var probeConjuncts, otherConjuncts []tree.TypedExpr
+	for _, conjunct := range flattenAndExpr(expr, nil /* conjuncts */) {
+		refs := make(ivarCounter, len(outputTypes))
+		tree.WalkExprConst(refs, conjunct)
+		onlyLeft := true
+		for _, cnt := range refs[len(leftTypes):] {
+			if cnt > 0 {
+				onlyLeft = false
+				break
+			}
+		}
+		if onlyLeft {
+			probeConjuncts = append(probeConjuncts, conjunct)
+		} else {
+			otherConjuncts = append(otherConjuncts, conjunct)
+		}
+	}
+	if len(probeConjuncts) == 0 {
+		return nil, onExpr
+	}
+	probeExpr := execinfrapb.Expression{LocalExpr: makeConjunction(probeConjuncts)}
+	makeProbeFilter := func(input colexecop.Operator) (colexecop.Operator, error) {
+		return planFilterExpr(
+			ctx, flowCtx, evalCtx, input, leftTypes, probeExpr, args.StreamingMemAccount,
+			factory, args.ExprHelper, &r.Releasables,
+		)
+	}
+	if _, err = makeProbeFilter(colexecop.NewFeedOperator()); err != nil {
+		// The probe filter cannot be planned natively, so we leave the ON
+		// expression as is.
+		return nil, onExpr
+	}
+	var remaining execinfrapb.Expression
+	if len(otherConjuncts) > 0 {
+		remaining.LocalExpr = makeConjunction(otherConjuncts)
+	}
+	// Note that the probe filter is planned anew every time a hash joiner is
+	// created (including the ones created by the external hash joiner after
+	// spilling to disk).
+	return func(input colexecop.Operator) colexecop.Operator {
+		op, err := makeProbeFilter(input)
+		if err != nil {
+			colexecerror.InternalError(err)
+		}
+		return op
+	}, remaining
+}
+
+// makeConjunction returns the AND of all of the given expressions.
+func makeConjunction(conjuncts []tree.TypedExpr) tree.TypedExpr {
+	expr := conjuncts[0]
+	for _, conjunct := range conjuncts[1:] {
+		expr = tree.NewTypedAndExpr(expr, conjunct)
+	}
+	return expr
+}
+
+// planAndMaybeWrapProjectSet plans a project set processor. If the
+// expressions are unsupported, it is planned as a wrapped project set
+// processor.
+func (r opResult) planAndMaybeWrapProjectSet(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	evalCtx *tree.EvalContext,
+	args *colexecargs.NewColOperatorArgs,
+	processorID int32,
+	projectSetSpec *execinfrapb.ProjectSetSpec,
+	allocator *colmem.Allocator,
+	factory coldata.ColumnFactory,
+) error {
+	op, outputTypes, err := planProjectSetExprs(
+		ctx, flowCtx, evalCtx, r.Root, r.ColumnTypes, projectSetSpec, allocator,
+		args.StreamingMemAccount, factory, args.ExprHelper, &r.Releasables,
+	)
+	if err != nil {
+		// Project set planning failed. Fall back to planning the project set
+		// using row execution.
+		if log.V(2) {
+			log.Infof(
+				ctx,
+				"project set exprs %v planning failed with error, attempting to wrap as a row source: %v",
+				projectSetSpec.Exprs, err,
+			)
+		}
+
+		wrappedSpec := &execinfrapb.ProcessorSpec{
+			Core: execinfrapb.ProcessorCoreUnion{
+				ProjectSet: projectSetSpec,
+			},
+			ProcessorID: processorID,
+			ResultTypes: args.Spec.ResultTypes,
+		}
+		inputToMaterializer := colexecargs.OpWithMetaInfo{Root: r.Root}
+		takeOverMetaInfo(&inputToMaterializer, args.Inputs)
+		return r.createAndWrapRowSource(
+			ctx, flowCtx, args, []colexecargs.OpWithMetaInfo{inputToMaterializer},
+			[][]*types.T{r.ColumnTypes}, wrappedSpec, factory, err,
+		)
+	}
+	r.Root, r.ColumnTypes = op, outputTypes
+	return nil
+}
+
 // wrapPostProcessSpec plans the given post process spec by wrapping a noop
 // processor with that output spec. This is used to fall back to row execution
 // when encountering unsupported post processing specs. An error is returned
@@ -1458,11 +2069,27 @@ func (r *postProcessResult) planPostProcessSpec(
 		}
 		semaCtx := flowCtx.TypeResolverFactory.NewSemaContext(evalCtx.Txn)
 		var renderedCols []uint32
+		// The constants among the render expressions (for example, the default
+		// values of the columns not provided by an INSERT) are filled in by
+		// the column mapping operator together with the projection, so we
+		// remember their positions and plan only the other expressions.
+		var constTypes []*types.T
+		var constVals []interface{}
+		var constRenderIdxs []int
 		for _, renderExpr := range post.RenderExprs {
 			expr, err := args.ExprHelper.ProcessExpr(renderExpr, semaCtx, evalCtx, r.ColumnTypes)
 			if err != nil {
 				return err
 			}
+			if constType, constVal, ok := getRenderConstant(expr); ok {
+				constRenderIdxs = append(constRenderIdxs, len(renderedCols))
+				constTypes = append(constTypes, constType)
+				constVals = append(constVals, constVal)
+				// The index of the constant column is set below, once all
+				// of the other expressions have been planned.
+				renderedCols = append(renderedCols, 0)
+				continue
+			}
 			var outputIdx int
 			r.Op, outputIdx, r.ColumnTypes, err = planProjectionOperators(
 				ctx, evalCtx, expr, r.ColumnTypes, r.Op, args.StreamingMemAccount, factory, releasables,
@@ -1475,7 +2102,19 @@ func (r *postProcessResult) planPostProcessSpec(
 			}
 			renderedCols = append(renderedCols, uint32(outputIdx))
 		}
-		r.Op = colexecbase.NewSimpleProjectOp(r.Op, len(r.ColumnTypes), renderedCols)
+		if len(constTypes) > 0 {
+			numInputCols := len(r.ColumnTypes)
+			for i, renderIdx := range constRenderIdxs {
+				renderedCols[renderIdx] = uint32(numInputCols + i)
+			}
+			r.Op = colexecbase.NewColumnMappingOp(
+				colmem.NewAllocator(ctx, args.StreamingMemAccount, factory),
+				r.Op, numInputCols, constTypes, constVals, renderedCols,
+			)
+			r.ColumnTypes = append(r.ColumnTypes[:numInputCols:numInputCols], constTypes...)
+		} else {
+			r.Op = colexecbase.NewSimpleProjectOp(r.Op, r.ColumnTypes, renderedCols)
+		}
 		newTypes := make([]*types.T, len(renderedCols))
 		for i, j := range renderedCols {
 			newTypes[i] = r.ColumnTypes[j]
@@ -1491,6 +2130,24 @@ func (r *postProcessResult) planPostProcessSpec(
 	return nil
 }
 
+// getRenderConstant returns the type and the physical representation of the
+// constant value of the render expression if it is a constant (nil is returned
+// as the value of NULL constants, including the typed ones).
+func getRenderConstant(expr tree.TypedExpr) (_ *types.T, _ interface{}, ok bool) {
+	switch t := expr.(type) {
+	case tree.Datum:
+		if t == tree.DNull {
+			return t.ResolvedType(), nil, true
+		}
+		return t.ResolvedType(), colconv.GetDatumToPhysicalFn(t.ResolvedType())(t), true
+	case *tree.CastExpr:
+		if t.Expr == tree.DNull {
+			return t.ResolvedType(), nil, true
+		}
+	}
+	return nil, nil, false
+}
+
 // getMemMonitorName returns a unique (for this opResult) memory monitor name.
 func (r opResult) getMemMonitorName(opName string, processorID int32, suffix string) string {
 	return fmt.Sprintf("%s-%d-%s-%d", opName, processorID, suffix, len(r.OpMonitors))
@@ -1606,12 +2263,31 @@ func planFilterExpr(
 		// we put a zero operator.
 		return colexecutils.NewZeroOp(input), nil
 	}
+	var projection []uint32
+	if projInput, projInputTypes, proj, ok := colexecbase.UnwrapSimpleProjectOp(input); ok {
+		// The input is a simple projection, so all columns referenced by the
+		// filter are also available before it. We plan the filter on the
+		// input of the projection (with the column indices remapped
+		// accordingly) and then re-apply the projection on top. This way the
+		// projected out columns are not touched by the filter, and the columns
+		// appended while evaluating the filter are removed by the same
+		// projection.
+		indexMap := make(ivarRemapper, len(proj))
+		for i, col := range proj {
+			indexMap[i] = int(col)
+		}
+		newExpr, _ := tree.WalkExpr(indexMap, expr)
+		expr, input, columnTypes, projection = newExpr.(tree.TypedExpr), projInput, projInputTypes, proj
+	}
 	op, _, filterColumnTypes, err := planSelectionOperators(
 		ctx, evalCtx, expr, columnTypes, input, acc, factory, releasables,
 	)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to columnarize filter expression %q", filter)
 	}
+	if projection != nil {
+		return colexecbase.NewSimpleProjectOp(op, filterColumnTypes, projection), nil
+	}
 	if len(filterColumnTypes) > len(columnTypes) {
 		// Additional columns were appended to store projections while
 		// evaluating the filter. Project them away.
@@ -1619,11 +2295,116 @@ func planFilterExpr(
 		for i := range columnTypes {
 			outputColumns = append(outputColumns, uint32(i))
 		}
-		op = colexecbase.NewSimpleProjectOp(op, len(filterColumnTypes), outputColumns)
+		op = colexecbase.NewSimpleProjectOp(op, filterColumnTypes, outputColumns)
 	}
 	return op, nil
 }
 
+// planProjectSetExprs creates all operators to implement the set-returning
+// functions of the project set processor and returns the resulting operator
+// along with its output types. Currently, only a single JSON expanding
+// function (like jsonb_array_elements or jsonb_each), generate_subscripts,
+// unnest of a single array, information_schema._pg_expandarray, or
+// regexp_matches with the constant pattern and flags is supported.
+func planProjectSetExprs(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	columnTypes []*types.T,
+	projectSetSpec *execinfrapb.ProjectSetSpec,
+	allocator *colmem.Allocator,
+	acc *mon.BoundAccount,
+	factory coldata.ColumnFactory,
+	helper *colexecargs.ExprHelper,
+	releasables *[]execinfra.Releasable,
+) (colexecop.Operator, []*types.T, error) {
+	if len(projectSetSpec.Exprs) != 1 {
+		return nil, nil, errors.Newf("project set with %d expressions is not supported", len(projectSetSpec.Exprs))
+	}
+	semaCtx := flowCtx.TypeResolverFactory.NewSemaContext(evalCtx.Txn)
+	expr, err := helper.ProcessExpr(projectSetSpec.Exprs[0], semaCtx, evalCtx, columnTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	funcExpr, ok := expr.(*tree.FuncExpr)
+	if !ok || !funcExpr.IsGeneratorApplication() {
+		return nil, nil, errors.Newf("expression %s is not a set-returning function", expr)
+	}
+	var fn colexec.JSONExpandFunc
+	// funcArgs are the arguments of the function that are planned as the
+	// projections on the input.
+	funcArgs := funcExpr.Exprs
+	specializedBuiltin := funcExpr.ResolvedOverload().SpecializedVecBuiltin
+	switch specializedBuiltin {
+	case tree.GenerateSubscripts, tree.PGExpandArray, tree.Unnest:
+		// These functions have their own operators which are planned below.
+	case tree.RegexpMatchesStringString, tree.RegexpMatchesStringStringString:
+		// The pattern is compiled only once, so only the constant pattern and
+		// flags are supported.
+		for _, e := range funcExpr.Exprs[1:] {
+			if _, ok := e.(*tree.DString); !ok {
+				return nil, nil, errors.Newf("regexp_matches with non-constant argument %s is not supported", e)
+			}
+		}
+		funcArgs = funcExpr.Exprs[:1]
+	case tree.JSONArrayElements:
+		fn = colexec.JSONArrayElements
+	case tree.JSONArrayElementsText:
+		fn = colexec.JSONArrayElementsText
+	case tree.JSONEach:
+		fn = colexec.JSONEach
+	case tree.JSONEachText:
+		fn = colexec.JSONEachText
+	case tree.JSONObjectKeys:
+		fn = colexec.JSONObjectKeys
+	default:
+		return nil, nil, errors.Newf("set-returning function %s is not supported", funcExpr.Func)
+	}
+	op, typs := input, columnTypes
+	argumentCols := make([]int, len(funcArgs))
+	for i, e := range funcArgs {
+		op, argumentCols[i], typs, err = planProjectionOperators(
+			ctx, evalCtx, e.(tree.TypedExpr), typs, op, acc, factory, releasables,
+		)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to columnarize set-returning function argument %q", e)
+		}
+	}
+	switch specializedBuiltin {
+	case tree.GenerateSubscripts:
+		op, err = colexec.NewGenerateSubscriptsOp(
+			allocator, op, typs, len(columnTypes), argumentCols, execinfra.GetWorkMemLimit(flowCtx),
+		)
+	case tree.PGExpandArray, tree.Unnest:
+		// information_schema._pg_expandarray is the same as unnest with the
+		// ordinality of each element within its array.
+		withOrdinality := specializedBuiltin == tree.PGExpandArray
+		op, err = colexec.NewUnnestOp(
+			allocator, op, typs, len(columnTypes), argumentCols[0], withOrdinality, execinfra.GetWorkMemLimit(flowCtx),
+		)
+	case tree.RegexpMatchesStringString, tree.RegexpMatchesStringStringString:
+		var flags string
+		if specializedBuiltin == tree.RegexpMatchesStringStringString {
+			flags = string(*funcExpr.Exprs[2].(*tree.DString))
+		}
+		op, err = colexec.NewRegexpMatchesOp(
+			allocator, evalCtx, op, typs, len(columnTypes), argumentCols[0],
+			string(*funcExpr.Exprs[1].(*tree.DString)), flags, execinfra.GetWorkMemLimit(flowCtx),
+		)
+	default:
+		op, err = colexec.NewJSONExpandOp(
+			allocator, op, typs, len(columnTypes), argumentCols[0], fn, execinfra.GetWorkMemLimit(flowCtx),
+		)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	outputTypes := make([]*types.T, len(columnTypes), len(columnTypes)+len(projectSetSpec.GeneratedColumns))
+	copy(outputTypes, columnTypes)
+	return op, append(outputTypes, projectSetSpec.GeneratedColumns...), nil
+}
+
 // addProjection adds a simple projection on top of op according to projection
 // and returns the updated operator and type schema.
 func addProjection(
@@ -1633,7 +2414,281 @@ func addProjection(
 	for i, j := range projection {
 		newTypes[i] = typs[j]
 	}
-	return colexecbase.NewSimpleProjectOp(op, len(typs), projection), newTypes
+	return colexecbase.NewSimpleProjectOp(op, typs, projection), newTypes
+}
+
+// pruneSortInput adds a simple projection on top of the sorter's input that
+// keeps only the columns surviving the projection of post and the ordering
+// columns so that the sorter doesn't materialize and reorder the columns that
+// are discarded right after the sort. It returns the updated input, its type
+// schema and the ordering remapped onto the kept columns; the output columns of
+// post (which must be owned by the caller) are remapped too.
+func pruneSortInput(
+	input colexecop.Operator,
+	typs []*types.T,
+	ordering execinfrapb.Ordering,
+	post *execinfrapb.PostProcessSpec,
+) (colexecop.Operator, []*types.T, execinfrapb.Ordering) {
+	if !post.Projection {
+		return input, typs, ordering
+	}
+	var neededCols util.FastIntSet
+	for _, col := range post.OutputColumns {
+		neededCols.Add(int(col))
+	}
+	for _, col := range ordering.Columns {
+		neededCols.Add(int(col.ColIdx))
+	}
+	if neededCols.Len() == len(typs) {
+		return input, typs, ordering
+	}
+	projection := make([]uint32, 0, neededCols.Len())
+	// newColIdx maps the ordinal of each kept column in the input to its
+	// ordinal after the pruning.
+	newColIdx := make([]uint32, len(typs))
+	neededCols.ForEach(func(col int) {
+		newColIdx[col] = uint32(len(projection))
+		projection = append(projection, uint32(col))
+	})
+	prunedOrdering := execinfrapb.Ordering{
+		Columns: make([]execinfrapb.Ordering_Column, len(ordering.Columns)),
+	}
+	for i, col := range ordering.Columns {
+		col.ColIdx = newColIdx[col.ColIdx]
+		prunedOrdering.Columns[i] = col
+	}
+	outputColumns := make([]uint32, len(post.OutputColumns))
+	for i, col := range post.OutputColumns {
+		outputColumns[i] = newColIdx[col]
+	}
+	post.OutputColumns = outputColumns
+	input, typs = addProjection(input, typs, projection)
+	return input, typs, prunedOrdering
+}
+
+// flattenAndExpr appends all conjuncts of the (possibly nested) AND
+// expression to conjuncts in the order in which they appear in the expression.
+func flattenAndExpr(expr tree.TypedExpr, conjuncts []tree.TypedExpr) []tree.TypedExpr {
+	if andExpr, ok := expr.(*tree.AndExpr); ok {
+		conjuncts = flattenAndExpr(andExpr.TypedLeft(), conjuncts)
+		return flattenAndExpr(andExpr.TypedRight(), conjuncts)
+	}
+	return append(conjuncts, expr)
+}
+
+// addNullRejectedColumns adds to cols the ordinals of the columns (which are
+// not tuples) that expr directly compares if expr is a comparison that
+// evaluates to NULL whenever any of its arguments is NULL, so that such a
+// predicate never selects the tuples with NULL in those columns.
+func addNullRejectedColumns(expr tree.TypedExpr, columnTypes []*types.T, cols *util.FastIntSet) {
+	cmpExpr, ok := expr.(*tree.ComparisonExpr)
+	if !ok {
+		return
+	}
+	switch cmpExpr.Operator {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+	default:
+		return
+	}
+	for _, arg := range []tree.Expr{cmpExpr.Left, cmpExpr.Right} {
+		// The comparison of tuples might not be NULL when some of the
+		// elements are NULL.
+		if col, ok := arg.(*tree.IndexedVar); ok && columnTypes[col.Idx].Family() != types.TupleFamily {
+			cols.Add(col.Idx)
+		}
+	}
+}
+
+// dropRedundantIsNotNullConjuncts removes the conjuncts of the form
+// `col IS NOT NULL` for which another conjunct already rejects the NULL
+// values of the same column (like `col IS NOT NULL AND col > 5`). Only the
+// top-level conjuncts are considered, so the IS NOT NULL checks under OR
+// expressions are preserved. The remaining conjuncts keep their order.
+func dropRedundantIsNotNullConjuncts(
+	conjuncts []tree.TypedExpr, columnTypes []*types.T,
+) []tree.TypedExpr {
+	var nullRejected util.FastIntSet
+	for _, conjunct := range conjuncts {
+		addNullRejectedColumns(conjunct, columnTypes, &nullRejected)
+	}
+	if nullRejected.Empty() {
+		return conjuncts
+	}
+	res := conjuncts[:0]
+	for _, conjunct := range conjuncts {
+		if isNotNull, ok := conjunct.(*tree.IsNotNullExpr); ok {
+			if col, ok := isNotNull.TypedInnerExpr().(*tree.IndexedVar); ok && nullRejected.Contains(col.Idx) {
+				continue
+			}
+		}
+		res = append(res, conjunct)
+	}
+	return res
+}
+
+// rangeBound describes a comparison of a column with a non-NULL constant of
+// the same type that bounds the values of the column from one side.
+type rangeBound struct {
+	colIdx    int
+	bound     tree.Datum
+	inclusive bool
+	isLower   bool
+}
+
+// getRangeBound returns the rangeBound that expr describes if it is a
+// comparison of a column with a constant that can be evaluated by a range
+// selection operator.
+func getRangeBound(expr tree.TypedExpr, columnTypes []*types.T) (rangeBound, bool) {
+	cmpExpr, ok := expr.(*tree.ComparisonExpr)
+	if !ok {
+		return rangeBound{}, false
+	}
+	col, ok := cmpExpr.Left.(*tree.IndexedVar)
+	if !ok {
+		return rangeBound{}, false
+	}
+	bound, ok := cmpExpr.Right.(tree.Datum)
+	if !ok || bound == tree.DNull {
+		return rangeBound{}, false
+	}
+	b := rangeBound{colIdx: col.Idx, bound: bound}
+	switch cmpExpr.Operator {
+	case tree.GT:
+		b.isLower = true
+	case tree.GE:
+		b.isLower, b.inclusive = true, true
+	case tree.LT:
+	case tree.LE:
+		b.inclusive = true
+	default:
+		return rangeBound{}, false
+	}
+	colType, boundType := columnTypes[b.colIdx], bound.ResolvedType()
+	if colType.Family() != boundType.Family() {
+		// Comparisons of different types (like TIMESTAMP and TIMESTAMPTZ)
+		// have special semantics.
+		return rangeBound{}, false
+	}
+	switch colType.Family() {
+	case types.TupleFamily, types.EnumFamily:
+		// Tuple comparisons have special NULL-handling semantics, and enums
+		// are compared by their logical order.
+		return rangeBound{}, false
+	case types.IntFamily:
+		if colType.Width() != boundType.Width() {
+			// The constant might not fit into the physical representation
+			// of the column.
+			return rangeBound{}, false
+		}
+	}
+	if typeconv.TypeFamilyToCanonicalTypeFamily(colType.Family()) == typeconv.DatumVecCanonicalTypeFamily &&
+		!colType.Identical(boundType) {
+		return rangeBound{}, false
+	}
+	return b, true
+}
+
+// fuseRangeConjuncts finds the pairs of conjuncts which compare the same column
+// with the lower and the upper bounds and can be fused into a single range
+// selection. fusedWith[i] is the ordinal of the conjunct that the ith
+// conjunct is fused with or -1 if it isn't fused, and numFused is the number
+// of the fused pairs.
+func fuseRangeConjuncts(
+	conjuncts []tree.TypedExpr, columnTypes []*types.T,
+) (fusedWith []int, numFused int) {
+	fusedWith = make([]int, len(conjuncts))
+	bounds := make([]rangeBound, len(conjuncts))
+	isBound := make([]bool, len(conjuncts))
+	for i, conjunct := range conjuncts {
+		fusedWith[i] = -1
+		bounds[i], isBound[i] = getRangeBound(conjunct, columnTypes)
+	}
+	for i := range conjuncts {
+		if !isBound[i] || fusedWith[i] != -1 {
+			continue
+		}
+		for j := i + 1; j < len(conjuncts); j++ {
+			if isBound[j] && fusedWith[j] == -1 && bounds[i].colIdx == bounds[j].colIdx &&
+				bounds[i].isLower != bounds[j].isLower {
+				fusedWith[i], fusedWith[j] = j, i
+				numFused++
+				break
+			}
+		}
+	}
+	return fusedWith, numFused
+}
+
+// isEmptyRange returns whether the range given by two bounds fused by
+// fuseRangeConjuncts cannot contain any values (like x > 10 AND x < 5).
+func isEmptyRange(
+	evalCtx *tree.EvalContext, expr1, expr2 tree.TypedExpr, columnTypes []*types.T,
+) bool {
+	lower, _ := getRangeBound(expr1, columnTypes)
+	upper, _ := getRangeBound(expr2, columnTypes)
+	if !lower.isLower {
+		lower, upper = upper, lower
+	}
+	cmp := lower.bound.Compare(evalCtx, upper.bound)
+	return cmp > 0 || (cmp == 0 && !(lower.inclusive && upper.inclusive))
+}
+
+// planRangeSelectionOperator plans a single range selection operator for two
+// bounds fused by fuseRangeConjuncts.
+func planRangeSelectionOperator(
+	expr1, expr2 tree.TypedExpr, columnTypes []*types.T, input colexecop.Operator,
+) (colexecop.Operator, error) {
+	lower, _ := getRangeBound(expr1, columnTypes)
+	upper, _ := getRangeBound(expr2, columnTypes)
+	if !lower.isLower {
+		lower, upper = upper, lower
+	}
+	return colexecsel.GetSelectionRangeOperator(
+		input, columnTypes[lower.colIdx], lower.colIdx,
+		lower.bound, lower.inclusive, upper.bound, upper.inclusive,
+	)
+}
+
+// isPlainSelection returns whether the predicate is planned as a single
+// selection operator directly on top of the input, without any projections.
+func isPlainSelection(expr tree.TypedExpr) bool {
+	switch t := expr.(type) {
+	case *tree.IndexedVar:
+		return true
+	case *tree.IsNullExpr:
+		_, ok := t.TypedInnerExpr().(*tree.IndexedVar)
+		return ok
+	case *tree.IsNotNullExpr:
+		_, ok := t.TypedInnerExpr().(*tree.IndexedVar)
+		return ok
+	case *tree.ComparisonExpr:
+		if _, ok := t.Left.(*tree.IndexedVar); !ok {
+			return false
+		}
+		switch t.Right.(type) {
+		case *tree.IndexedVar, tree.Datum:
+			return true
+		}
+	}
+	return false
+}
+
+// isNeverNullPredicate returns whether the predicate is known to evaluate to
+// either true or false (but never NULL) on every tuple.
+func isNeverNullPredicate(expr tree.TypedExpr) bool {
+	switch t := expr.(type) {
+	case *tree.IsNullExpr, *tree.IsNotNullExpr:
+		return true
+	case *tree.ComparisonExpr:
+		return t.Operator == tree.IsDistinctFrom || t.Operator == tree.IsNotDistinctFrom
+	case *tree.AndExpr:
+		return isNeverNullPredicate(t.TypedLeft()) && isNeverNullPredicate(t.TypedRight())
+	case *tree.OrExpr:
+		return isNeverNullPredicate(t.TypedLeft()) && isNeverNullPredicate(t.TypedRight())
+	case *tree.NotExpr:
+		return isNeverNullPredicate(t.TypedInnerExpr())
+	}
+	return false
 }
 
 func planSelectionOperators(
@@ -1652,57 +2707,145 @@ func planSelectionOperators(
 		return op, -1, columnTypes, err
 	case *tree.AndExpr:
 		// AND expressions are handled by an implicit AND'ing of selection
-		// vectors. First we select out the tuples that are true on the left
-		// side, and then, only among the matched tuples, we select out the
-		// tuples that are true on the right side.
+		// vectors. All nested AND expressions are flattened into a list of
+		// conjuncts which are evaluated in order, and each of them only sees
+		// the tuples that have been selected by all of the previous ones.
+		conjunctExprs := flattenAndExpr(t, nil /* conjuncts */)
+		conjunctExprs = dropRedundantIsNotNullConjuncts(conjunctExprs, columnTypes)
+		if len(conjunctExprs) == 1 {
+			// All other conjuncts were redundant.
+			return planSelectionOperators(
+				ctx, evalCtx, conjunctExprs[0], columnTypes, input, acc, factory, releasables,
+			)
+		}
+		// Pairs of the comparisons of the same column with the lower and the
+		// upper bounds are fused into a single range selection.
+		fusedWith, numFused := fuseRangeConjuncts(conjunctExprs, columnTypes)
+		for i, j := range fusedWith {
+			if j > i && isEmptyRange(evalCtx, conjunctExprs[i], conjunctExprs[j], columnTypes) {
+				// The bounds contradict each other, so no tuples can be
+				// selected.
+				return colexecutils.NewZeroOp(input), -1, columnTypes, nil
+			}
+		}
+		// Plain selection operators narrow down the selection vector of the
+		// batch in place, so simply chaining them already evaluates every
+		// conjunct only on the tuples selected by the previous ones. The
+		// fused AND operator (which requires buffering the input) is only
+		// planned when some conjunct needs projections to be evaluated.
+		fuse := false
+		for _, conjunctExpr := range conjunctExprs {
+			if !isPlainSelection(conjunctExpr) {
+				fuse = true
+				break
+			}
+		}
+		var buffer colexecop.Operator
+		conjunctInput := input
+		if fuse {
+			buffer = colexec.NewBufferOp(input)
+			conjunctInput = buffer
+		}
+		conjuncts := make([]colexecop.Operator, 0, len(conjunctExprs)-numFused)
+		typs = columnTypes
+		for i, conjunctExpr := range conjunctExprs {
+			var conjunct colexecop.Operator
+			switch j := fusedWith[i]; {
+			case j == -1:
+				conjunct, resultIdx, typs, err = planSelectionOperators(
+					ctx, evalCtx, conjunctExpr, typs, conjunctInput, acc, factory, releasables,
+				)
+			case j > i:
+				conjunct, err = planRangeSelectionOperator(
+					conjunctExpr, conjunctExprs[j], typs, conjunctInput,
+				)
+				resultIdx = -1
+			default:
+				// This conjunct has already been fused with an earlier one.
+				continue
+			}
+			if err != nil {
+				return nil, resultIdx, typs, err
+			}
+			if !fuse {
+				conjunctInput = conjunct
+			}
+			conjuncts = append(conjuncts, conjunct)
+		}
+		if !fuse {
+			return conjunctInput, resultIdx, typs, nil
+		}
+		op = colexec.NewAndSelOp(buffer, conjuncts)
+		return op, resultIdx, typs, nil
+	case *tree.OrExpr:
+		// OR expressions are handled by a union of selection vectors. First we
+		// select out the tuples that are true on the left side, and then, only
+		// among the tuples that didn't match, we select out the tuples that are
+		// true on the right side. This way the right side is not evaluated on
+		// the tuples for which the left side is true.
+		allocator := colmem.NewAllocator(ctx, acc, factory)
+		// We don't know the schema yet and will update it below, right before
+		// instantiating the OR operator.
+		schemaEnforcer := colexecutils.NewBatchSchemaSubsetEnforcer(
+			allocator, input, nil /* typs */, len(columnTypes), -1, /* subsetEndIdx */
+		)
+		buffer := colexec.NewBufferOp(schemaEnforcer)
 		var leftOp, rightOp colexecop.Operator
 		leftOp, _, typs, err = planSelectionOperators(
-			ctx, evalCtx, t.TypedLeft(), columnTypes, input, acc, factory, releasables,
+			ctx, evalCtx, t.TypedLeft(), columnTypes, buffer, acc, factory, releasables,
 		)
 		if err != nil {
 			return nil, resultIdx, typs, err
 		}
 		rightOp, resultIdx, typs, err = planSelectionOperators(
-			ctx, evalCtx, t.TypedRight(), typs, leftOp, acc, factory, releasables,
+			ctx, evalCtx, t.TypedRight(), typs, buffer, acc, factory, releasables,
 		)
-		return rightOp, resultIdx, typs, err
-	case *tree.OrExpr:
-		// OR expressions are handled by converting them to an equivalent CASE
-		// statement. Since CASE statements don't have a selection form, plan a
-		// projection and then convert the resulting boolean to a selection
-		// vector.
-		//
-		// Rewrite the OR expression as an equivalent CASE expression. "a OR b"
-		// becomes "CASE WHEN a THEN true WHEN b THEN true ELSE false END". This
-		// way we can take advantage of the short-circuiting logic built into
-		// the CASE operator. (b should not be evaluated if a is true.)
-		caseExpr, err := tree.NewTypedCaseExpr(
-			nil, /* expr */
-			[]*tree.When{
-				{Cond: t.Left, Val: tree.DBoolTrue},
-				{Cond: t.Right, Val: tree.DBoolTrue},
-			},
-			tree.DBoolFalse,
-			types.Bool)
 		if err != nil {
 			return nil, resultIdx, typs, err
 		}
+		// Both sides might have appended columns to the batch, and since not
+		// all tuples are necessarily evaluated on each side, the schema
+		// enforcer needs to make sure that all of those columns are present.
+		schemaEnforcer.SetTypes(typs)
+		op = colexec.NewOrSelOp(allocator, buffer, leftOp, rightOp)
+		return op, resultIdx, typs, nil
+	case *tree.CaseExpr:
 		op, resultIdx, typs, err = planProjectionOperators(
-			ctx, evalCtx, caseExpr, columnTypes, input, acc, factory, releasables,
+			ctx, evalCtx, expr, columnTypes, input, acc, factory, releasables,
 		)
 		if err != nil {
-			return nil, resultIdx, typs, err
+			return op, resultIdx, typs, err
 		}
 		op, err = colexecutils.BoolOrUnknownToSelOp(op, typs, resultIdx)
 		return op, resultIdx, typs, err
-	case *tree.CaseExpr:
+	case *tree.NotExpr:
+		if isNeverNullPredicate(t.TypedInnerExpr()) {
+			// If the inner predicate is never NULL, its negation selects
+			// exactly the tuples that weren't selected by the predicate, so we
+			// plan the predicate as selection operators on top of the buffer
+			// and take the complement of the selected tuples.
+			buffer := colexec.NewBufferOp(input)
+			var innerOp colexecop.Operator
+			innerOp, resultIdx, typs, err = planSelectionOperators(
+				ctx, evalCtx, t.TypedInnerExpr(), columnTypes, buffer, acc, factory, releasables,
+			)
+			if err != nil {
+				return nil, resultIdx, typs, err
+			}
+			op = colexec.NewNotSelOp(colmem.NewAllocator(ctx, acc, factory), buffer, innerOp)
+			return op, resultIdx, typs, nil
+		}
+		// Otherwise, the tuples for which the predicate is NULL must not be
+		// selected by the negation either (same as the tuples for which the
+		// predicate is true), so we project the predicate and select the
+		// tuples with false values.
 		op, resultIdx, typs, err = planProjectionOperators(
-			ctx, evalCtx, expr, columnTypes, input, acc, factory, releasables,
+			ctx, evalCtx, t.TypedInnerExpr(), columnTypes, input, acc, factory, releasables,
 		)
 		if err != nil {
 			return op, resultIdx, typs, err
 		}
-		op, err = colexecutils.BoolOrUnknownToSelOp(op, typs, resultIdx)
+		op, err = colexecutils.NotBoolOrUnknownToSelOp(op, typs, resultIdx)
 		return op, resultIdx, typs, err
 	case *tree.IsNullExpr:
 		op, resultIdx, typs, err = planProjectionOperators(
@@ -1742,6 +2885,15 @@ func planSelectionOperators(
 				op, err = colexecsel.GetLikeOperator(
 					evalCtx, leftOp, leftIdx, string(tree.MustBeDString(constArg)), negate,
 				)
+			case tree.SimilarTo, tree.NotSimilarTo:
+				pattern, ok := tree.AsDString(constArg)
+				if !ok {
+					break
+				}
+				negate := cmpOp == tree.NotSimilarTo
+				op, err = colexecsel.GetSimilarToOperator(
+					evalCtx, leftOp, leftIdx, string(pattern), negate,
+				)
 			case tree.In, tree.NotIn:
 				negate := cmpOp == tree.NotIn
 				datumTuple, ok := tree.AsDTuple(constArg)
@@ -1753,6 +2905,29 @@ func planSelectionOperators(
 					break
 				}
 				op, err = colexec.GetInOperator(lTyp, leftOp, leftIdx, datumTuple, negate)
+			case tree.Any, tree.Some, tree.All:
+				if isLikeOperator(t.SubOperator) {
+					op, err = colexec.GetLikeAnyOperator(
+						evalCtx, leftOp, leftIdx, lTyp, cmpOp, t.SubOperator, constArg,
+					)
+					break
+				}
+				if array, ok := tree.AsDArray(constArg); ok && cmpOp == tree.Any && t.SubOperator == tree.EQ {
+					op, err = colexec.GetInArrayOperator(
+						colmem.NewAllocator(ctx, acc, factory), evalCtx, lTyp, leftOp, leftIdx, array,
+					)
+					break
+				}
+				// = ANY with a tuple on the right side is what IN subqueries
+				// are planned as, and the tuple contains the buffered
+				// results of the subquery, so we use a hash set for it.
+				datumTuple, ok := tree.AsDTuple(constArg)
+				if !ok || cmpOp != tree.Any || t.SubOperator != tree.EQ || !colexec.CanUseInHashSet(lTyp, datumTuple) {
+					break
+				}
+				op, err = colexec.GetInHashOperator(
+					colmem.NewAllocator(ctx, acc, factory), lTyp, leftOp, leftIdx, datumTuple, false, /* negate */
+				)
 			case tree.IsDistinctFrom, tree.IsNotDistinctFrom:
 				if constArg != tree.DNull {
 					// Optimized IsDistinctFrom and IsNotDistinctFrom are
@@ -1766,10 +2941,45 @@ func planSelectionOperators(
 				// FROM NULL.
 				negate := cmpOp == tree.IsDistinctFrom
 				op = colexec.NewIsNullSelOp(leftOp, leftIdx, negate, false /* isTupleNull */)
+			case tree.Contains, tree.ContainedBy:
+				switch lTyp.Family() {
+				case types.ArrayFamily:
+					op, err = colexec.GetArrayContainsOperator(
+						evalCtx, leftOp, cmpOp, leftIdx, -1 /* rightIdx */, constArg,
+					)
+				case types.JsonFamily:
+					op, err = colexec.GetJSONContainsOperator(
+						leftOp, cmpOp, leftIdx, -1 /* rightIdx */, constArg,
+					)
+				}
+			case tree.JSONExists, tree.JSONSomeExists, tree.JSONAllExists:
+				op, err = colexec.GetJSONExistsOperator(leftOp, cmpOp, leftIdx, constArg)
+			case tree.Overlaps:
+				if !isINetContainment(cmpOp, t.TypedLeft(), t.TypedRight()) {
+					break
+				}
+				op, err = colexec.GetINetContainsOperator(
+					leftOp, cmpOp, leftIdx, -1 /* rightIdx */, constArg,
+				)
+			case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+				if isEnumComparison(cmpOp, t.TypedLeft(), t.TypedRight()) {
+					op, err = colexec.GetEnumCmpOperator(
+						leftOp, cmpOp, leftIdx, -1 /* rightIdx */, constArg,
+					)
+				} else if isTimeComparison(cmpOp, t.TypedLeft(), t.TypedRight()) {
+					op, err = colexec.GetTimeCmpOperator(
+						evalCtx, leftOp, cmpOp, lTyp, t.TypedRight().ResolvedType(),
+						leftIdx, -1 /* rightIdx */, constArg,
+					)
+				}
 			}
 			if op == nil || err != nil {
 				// op hasn't been created yet, so let's try the constructor for
-				// all other selection operators.
+				// all other selection operators. Note that the specialized
+				// constructors above (as well as the ones of the projection
+				// operators) return an error when they don't support the
+				// constant argument (e.g. the constant NULL), in which case we
+				// fall back to the default operator too.
 				op, err = colexecsel.GetSelectionConstOperator(
 					cmpOp, leftOp, ct, leftIdx, constArg, evalCtx, t,
 				)
@@ -1785,9 +2995,41 @@ func planSelectionOperators(
 		if err != nil {
 			return nil, resultIdx, ct, err
 		}
-		op, err = colexecsel.GetSelectionOperator(
-			cmpOp, rightOp, ct, leftIdx, rightIdx, evalCtx, t,
-		)
+		switch cmpOp {
+		case tree.Contains, tree.ContainedBy:
+			switch lTyp.Family() {
+			case types.ArrayFamily:
+				op, err = colexec.GetArrayContainsOperator(
+					evalCtx, rightOp, cmpOp, leftIdx, rightIdx, nil, /* constRight */
+				)
+			case types.JsonFamily:
+				op, err = colexec.GetJSONContainsOperator(
+					rightOp, cmpOp, leftIdx, rightIdx, nil, /* constRight */
+				)
+			}
+		case tree.Overlaps:
+			if !isINetContainment(cmpOp, t.TypedLeft(), t.TypedRight()) {
+				break
+			}
+			op, err = colexec.GetINetContainsOperator(
+				rightOp, cmpOp, leftIdx, rightIdx, nil, /* constRight */
+			)
+		case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+			if isEnumComparison(cmpOp, t.TypedLeft(), t.TypedRight()) {
+				op, err = colexec.GetEnumCmpOperator(
+					rightOp, cmpOp, leftIdx, rightIdx, nil, /* constRight */
+				)
+			} else if isTimeComparison(cmpOp, t.TypedLeft(), t.TypedRight()) {
+				op, err = colexec.GetTimeCmpOperator(
+					evalCtx, rightOp, cmpOp, lTyp, ct[rightIdx], leftIdx, rightIdx, nil, /* constRight */
+				)
+			}
+		}
+		if op == nil || err != nil {
+			op, err = colexecsel.GetSelectionOperator(
+				cmpOp, rightOp, ct, leftIdx, rightIdx, evalCtx, t,
+			)
+		}
 		if r, ok := op.(execinfra.Releasable); ok {
 			*releasables = append(*releasables, r)
 		}
@@ -1800,6 +3042,11 @@ func planSelectionOperators(
 // planCastOperator plans a CAST operator that casts the column at index
 // 'inputIdx' coming from input of type 'fromType' into a column of type
 // 'toType' that will be output at index 'resultIdx'.
+//
+// If the types are identical, then the cast is a no-op and is elided: the
+// input is returned unchanged and 'resultIdx' is 'inputIdx'. Note that the
+// types must be identical and not merely equivalent since, for example, a cast
+// between decimals with different scales rounds the values.
 func planCastOperator(
 	ctx context.Context,
 	acc *mon.BoundAccount,
@@ -1810,12 +3057,64 @@ func planCastOperator(
 	toType *types.T,
 	factory coldata.ColumnFactory,
 ) (op colexecop.Operator, resultIdx int, typs []*types.T, err error) {
+	if fromType.Identical(toType) {
+		return input, inputIdx, columnTypes, nil
+	}
 	outputIdx := len(columnTypes)
-	op, err = colexecbase.GetCastOperator(colmem.NewAllocator(ctx, acc, factory), input, inputIdx, outputIdx, fromType, toType)
 	typs = appendOneType(columnTypes, toType)
+	allocator := colmem.NewAllocator(ctx, acc, factory)
+	if fromType.Family() == types.ArrayFamily && toType.Family() == types.ArrayFamily {
+		// The arrays are cast element by element if the cast between the
+		// element types is supported natively; otherwise, we fall back to
+		// casting the whole datums below.
+		if op, err = colexecbase.GetArrayCastOperator(allocator, input, inputIdx, outputIdx, fromType, toType); err == nil {
+			return op, outputIdx, typs, nil
+		}
+	}
+	if fromType.Family() == types.EnumFamily || toType.Family() == types.EnumFamily {
+		// The enums are mapped to and from their labels using the enum
+		// metadata if the type is hydrated; otherwise, we fall back to the
+		// default cast operator below.
+		if op, err = colexecbase.GetEnumCastOperator(allocator, input, inputIdx, outputIdx, fromType, toType); err == nil {
+			return op, outputIdx, typs, nil
+		}
+	}
+	op, err = colexecbase.GetCastOperator(allocator, input, inputIdx, outputIdx, fromType, toType)
 	return op, outputIdx, typs, err
 }
 
+// isRedundantIntermediateCastType returns whether casting from fromType into
+// viaType and then into toType always has the same result as casting from
+// fromType into toType directly. This is the case when viaType is identical to
+// one of the other types (since such cast is a no-op) or when viaType can
+// represent all values of fromType without any loss. Note that the casts
+// through a narrower type are not redundant since they can truncate the value
+// or result in an out of range error.
+func isRedundantIntermediateCastType(fromType, viaType, toType *types.T) bool {
+	if viaType.Identical(fromType) || viaType.Identical(toType) {
+		return true
+	}
+	if fromType.Family() != viaType.Family() || viaType.Family() != toType.Family() {
+		return false
+	}
+	switch viaType.Family() {
+	case types.IntFamily:
+		return viaType.Width() >= fromType.Width()
+	case types.StringFamily:
+		// Only the casts between STRING and VARCHAR types are considered since
+		// the other types in the family (like CHAR or NAME) can modify the
+		// value beyond the truncation to the width of the type.
+		for _, typ := range []*types.T{fromType, viaType, toType} {
+			if typ.Oid() != oid.T_text && typ.Oid() != oid.T_varchar {
+				return false
+			}
+		}
+		return viaType.Width() == 0 || (fromType.Width() != 0 && viaType.Width() >= fromType.Width())
+	default:
+		return false
+	}
+}
+
 // planProjectionOperators plans a chain of operators to execute the provided
 // expression. It returns the tail of the chain, as well as the column index
 // of the expression's result (if any, otherwise -1) and the column types of the
@@ -1854,9 +3153,21 @@ func planProjectionOperators(
 			columnTypes, input, acc, factory, nil /* binFn */, t, releasables,
 		)
 	case *tree.BinaryExpr:
-		if err = checkSupportedBinaryExpr(t.TypedLeft(), t.TypedRight(), t.ResolvedType()); err != nil {
+		if err = checkSupportedBinaryExpr(t.Operator, t.TypedLeft(), t.TypedRight(), t.ResolvedType()); err != nil {
 			return op, resultIdx, typs, err
 		}
+		if fusedExpr, numOps, ok := makeFusedArithExpr(t, t.ResolvedType(), columnTypes, 1 /* depth */); ok && numOps > 1 {
+			// Evaluate the nested arithmetic expression with a single
+			// operator without materializing the intermediate results.
+			resultIdx = len(columnTypes)
+			op, err = colexecproj.NewFusedArithmeticOp(
+				colmem.NewAllocator(ctx, acc, factory), input, t.ResolvedType(), fusedExpr, resultIdx,
+			)
+			if err != nil {
+				return nil, resultIdx, nil, err
+			}
+			return op, resultIdx, appendOneType(columnTypes, t.ResolvedType()), nil
+		}
 		return planProjectionExpr(
 			ctx, evalCtx, t.Operator, t.ResolvedType(), t.TypedLeft(), t.TypedRight(),
 			columnTypes, input, acc, factory, t.Fn.Fn, nil /* cmpExpr */, releasables,
@@ -1867,6 +3178,19 @@ func planProjectionOperators(
 		return planIsNullProjectionOp(ctx, evalCtx, t.ResolvedType(), t.TypedInnerExpr(), columnTypes, input, acc, true /* negate */, factory, releasables)
 	case *tree.CastExpr:
 		expr := t.Expr.(tree.TypedExpr)
+		// Collapse the chain of casts into a single cast as long as the
+		// intermediate types don't change the result.
+		for {
+			inner, ok := expr.(*tree.CastExpr)
+			if !ok {
+				break
+			}
+			innerExpr := inner.Expr.(tree.TypedExpr)
+			if !isRedundantIntermediateCastType(innerExpr.ResolvedType(), inner.ResolvedType(), t.ResolvedType()) {
+				break
+			}
+			expr = innerExpr
+		}
 		op, resultIdx, typs, err = planProjectionOperators(
 			ctx, evalCtx, expr, columnTypes, input, acc, factory, releasables,
 		)
@@ -1875,6 +3199,32 @@ func planProjectionOperators(
 		}
 		op, resultIdx, typs, err = planCastOperator(ctx, acc, typs, op, resultIdx, expr.ResolvedType(), t.ResolvedType(), factory)
 		return op, resultIdx, typs, err
+	case *tree.UnaryExpr:
+		var newUnaryOperator func(*colmem.Allocator, *types.T, int, int, colexecop.Operator) (colexecop.Operator, error)
+		switch t.Operator {
+		case tree.UnaryMinus:
+			newUnaryOperator = colexec.NewNegOperator
+		case tree.UnaryComplement:
+			newUnaryOperator = colexec.NewComplementOperator
+		default:
+			return nil, resultIdx, nil, errors.Errorf("unhandled unary operator: %s", t.Operator)
+		}
+		inputExpr := t.Expr.(tree.TypedExpr)
+		op, resultIdx, typs, err = planProjectionOperators(
+			ctx, evalCtx, inputExpr, columnTypes, input, acc, factory, releasables,
+		)
+		if err != nil {
+			return nil, resultIdx, nil, err
+		}
+		outputIdx := len(typs)
+		op, err = newUnaryOperator(
+			colmem.NewAllocator(ctx, acc, factory), inputExpr.ResolvedType(), resultIdx, outputIdx, op,
+		)
+		if err != nil {
+			return nil, resultIdx, nil, err
+		}
+		typs = appendOneType(typs, t.ResolvedType())
+		return op, outputIdx, typs, nil
 	case *tree.FuncExpr:
 		var inputCols []int
 		typs = make([]*types.T, len(columnTypes))
@@ -2060,6 +3410,73 @@ func planProjectionOperators(
 		schemaEnforcer.SetTypes(typs)
 		op := colexec.NewCaseOp(allocator, buffer, caseOps, elseOp, thenIdxs, caseOutputIdx, caseOutputType)
 		return op, caseOutputIdx, typs, err
+	case *tree.CoalesceExpr:
+		outputType := t.ResolvedType()
+		if colIdxs, ok := coalesceBytesColIdxs(t, outputType); ok {
+			// COALESCE of Bytes columns is supported natively since evaluating
+			// a column reference has no side effects.
+			outputIdx := len(columnTypes)
+			op = colexec.NewCoalesceBytesOp(
+				colmem.NewAllocator(ctx, acc, factory), input, outputType, colIdxs, outputIdx,
+			)
+			typs = appendOneType(columnTypes, outputType)
+			return op, outputIdx, typs, nil
+		}
+		// Otherwise, only COALESCE(x, <default>) with a non-NULL constant
+		// default is supported natively because the other arguments must not be
+		// evaluated on the rows where the earlier ones are non-NULL.
+		if len(t.Exprs) != 2 {
+			return nil, resultIdx, typs, errors.Newf("unsupported number of arguments in COALESCE: %d", len(t.Exprs))
+		}
+		defaultVal, ok := t.Exprs[1].(tree.Datum)
+		if !ok || defaultVal == tree.DNull || !defaultVal.ResolvedType().Identical(outputType) ||
+			!t.TypedExprAt(0).ResolvedType().Identical(outputType) {
+			return nil, resultIdx, typs, errors.Newf("unsupported COALESCE expression: %s", t)
+		}
+		op, resultIdx, typs, err = planProjectionOperators(
+			ctx, evalCtx, t.TypedExprAt(0), columnTypes, input, acc, factory, releasables,
+		)
+		if err != nil {
+			return nil, resultIdx, typs, err
+		}
+		outputIdx := len(typs)
+		op, err = colexec.NewDefaultOnNullOp(
+			colmem.NewAllocator(ctx, acc, factory), op, outputType, resultIdx,
+			-1 /* defaultIdx */, colconv.GetDatumToPhysicalFn(outputType)(defaultVal), outputIdx,
+		)
+		if err != nil {
+			return nil, resultIdx, typs, err
+		}
+		typs = appendOneType(typs, outputType)
+		return op, outputIdx, typs, nil
+	case *tree.NullIfExpr:
+		// Only NULLIF(x, <sentinel>) with a non-NULL constant sentinel of the
+		// same type is supported natively.
+		outputType := t.ResolvedType()
+		sentinel, ok := t.Expr2.(tree.Datum)
+		if !ok || sentinel == tree.DNull || !sentinel.ResolvedType().Identical(outputType) {
+			return nil, resultIdx, typs, errors.Newf("unsupported NULLIF expression: %s", t)
+		}
+		inputExpr := t.Expr1.(tree.TypedExpr)
+		if !inputExpr.ResolvedType().Identical(outputType) {
+			return nil, resultIdx, typs, errors.Newf("unsupported NULLIF expression: %s", t)
+		}
+		op, resultIdx, typs, err = planProjectionOperators(
+			ctx, evalCtx, inputExpr, columnTypes, input, acc, factory, releasables,
+		)
+		if err != nil {
+			return nil, resultIdx, typs, err
+		}
+		outputIdx := len(typs)
+		op, err = colexec.NewNullIfOp(
+			colmem.NewAllocator(ctx, acc, factory), op, outputType, resultIdx,
+			colconv.GetDatumToPhysicalFn(outputType)(sentinel), outputIdx,
+		)
+		if err != nil {
+			return nil, resultIdx, typs, err
+		}
+		typs = appendOneType(typs, outputType)
+		return op, outputIdx, typs, nil
 	case *tree.AndExpr, *tree.OrExpr:
 		return planLogicalProjectionOp(ctx, evalCtx, expr, columnTypes, input, acc, factory, releasables)
 	default:
@@ -2067,10 +3484,28 @@ func planProjectionOperators(
 	}
 }
 
-func checkSupportedProjectionExpr(left, right tree.TypedExpr) error {
+// coalesceBytesColIdxs returns the indices of the columns referenced by the
+// COALESCE expression if all of its arguments are references to columns of
+// the output type which has the Bytes canonical type family.
+func coalesceBytesColIdxs(t *tree.CoalesceExpr, outputType *types.T) ([]int, bool) {
+	if typeconv.TypeFamilyToCanonicalTypeFamily(outputType.Family()) != types.BytesFamily {
+		return nil, false
+	}
+	colIdxs := make([]int, len(t.Exprs))
+	for i, e := range t.Exprs {
+		iv, ok := e.(*tree.IndexedVar)
+		if !ok || !iv.ResolvedType().Identical(outputType) {
+			return nil, false
+		}
+		colIdxs[i] = iv.Idx
+	}
+	return colIdxs, true
+}
+
+func checkSupportedProjectionExpr(op tree.Operator, left, right tree.TypedExpr) error {
 	leftTyp := left.ResolvedType()
 	rightTyp := right.ResolvedType()
-	if leftTyp.Equivalent(rightTyp) {
+	if leftTyp.Equivalent(rightTyp) || isDateTimeArith(op, left, right) {
 		return nil
 	}
 
@@ -2085,11 +3520,17 @@ func checkSupportedProjectionExpr(left, right tree.TypedExpr) error {
 	return nil
 }
 
-func checkSupportedBinaryExpr(left, right tree.TypedExpr, outputType *types.T) error {
+func checkSupportedBinaryExpr(
+	binOp tree.BinaryOperator, left, right tree.TypedExpr, outputType *types.T,
+) error {
 	leftDatumBacked := typeconv.TypeFamilyToCanonicalTypeFamily(left.ResolvedType().Family()) == typeconv.DatumVecCanonicalTypeFamily
 	rightDatumBacked := typeconv.TypeFamilyToCanonicalTypeFamily(right.ResolvedType().Family()) == typeconv.DatumVecCanonicalTypeFamily
 	outputDatumBacked := typeconv.TypeFamilyToCanonicalTypeFamily(outputType.Family()) == typeconv.DatumVecCanonicalTypeFamily
-	if (leftDatumBacked && rightDatumBacked) && !outputDatumBacked {
+	// The INet containment is handled by the special operator which supports
+	// the Bool output, and the subtraction of two times is handled by the
+	// date and time arithmetic operator.
+	if (leftDatumBacked && rightDatumBacked) && !outputDatumBacked &&
+		!isINetContainment(binOp, left, right) && !isDateTimeArith(binOp, left, right) {
 		return errors.New("datum-backed arguments on both sides and not datum-backed " +
 			"output of a binary expression is currently not supported")
 	}
@@ -2110,7 +3551,7 @@ func planProjectionExpr(
 	cmpExpr *tree.ComparisonExpr,
 	releasables *[]execinfra.Releasable,
 ) (op colexecop.Operator, resultIdx int, typs []*types.T, err error) {
-	if err := checkSupportedProjectionExpr(left, right); err != nil {
+	if err := checkSupportedProjectionExpr(projOp, left, right); err != nil {
 		return nil, resultIdx, typs, err
 	}
 	allocator := colmem.NewAllocator(ctx, acc, factory)
@@ -2133,10 +3574,70 @@ func planProjectionExpr(
 		resultIdx = len(typs)
 		// The projection result will be outputted to a new column which is
 		// appended to the input batch.
-		op, err = colexecproj.GetProjectionLConstOperator(
-			allocator, typs, left.ResolvedType(), outputType, projOp, input,
-			rightIdx, lConstArg, resultIdx, evalCtx, binFn, cmpExpr,
-		)
+		if projOp == tree.Concat && outputType.Family() == types.ArrayFamily {
+			op, err = colexec.GetArrayConcatProjectionOperator(
+				allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+				-1 /* leftIdx */, rightIdx, lConstArg, nil /* constRight */, resultIdx,
+			)
+		} else if projOp == tree.Concat && outputType.Family() == types.BitFamily {
+			op, err = colexec.GetBitConcatProjectionOperator(
+				allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+				-1 /* leftIdx */, rightIdx, lConstArg, nil /* constRight */, resultIdx,
+			)
+		} else if isINetContainment(projOp, left, right) {
+			// Only the constant on the right is supported, so we swap the
+			// arguments which turns << into >> and vice versa (&& is
+			// symmetric).
+			commutedOp := projOp
+			switch projOp {
+			case tree.LShift:
+				commutedOp = tree.RShift
+			case tree.RShift:
+				commutedOp = tree.LShift
+			}
+			op, err = colexec.GetINetContainsProjectionOperator(
+				allocator, input, commutedOp, rightIdx, -1 /* rightIdx */, lConstArg, resultIdx,
+			)
+		} else if isEnumComparison(projOp, left, right) || isTimeComparison(projOp, left, right) {
+			// Only the constant on the right is supported, so we swap the
+			// arguments which reverses the ordering comparisons.
+			commutedOp := projOp.(tree.ComparisonOperator)
+			switch commutedOp {
+			case tree.LT:
+				commutedOp = tree.GT
+			case tree.LE:
+				commutedOp = tree.GE
+			case tree.GT:
+				commutedOp = tree.LT
+			case tree.GE:
+				commutedOp = tree.LE
+			}
+			if isEnumComparison(projOp, left, right) {
+				op, err = colexec.GetEnumCmpProjectionOperator(
+					allocator, input, commutedOp, rightIdx, -1 /* rightIdx */, lConstArg, resultIdx,
+				)
+			} else {
+				op, err = colexec.GetTimeCmpProjectionOperator(
+					allocator, evalCtx, input, commutedOp, right.ResolvedType(), left.ResolvedType(),
+					rightIdx, -1 /* rightIdx */, lConstArg, resultIdx,
+				)
+			}
+		} else if projOp == tree.Minus && isDateMinusDate(left, right) {
+			op, err = colexec.GetDateMinusProjectionOperator(
+				allocator, input, -1 /* leftIdx */, rightIdx, lConstArg, nil /* constRight */, resultIdx,
+			)
+		} else if isDateTimeArith(projOp, left, right) {
+			op, err = colexec.GetDateTimeArithProjectionOperator(
+				allocator, evalCtx, input, projOp, left.ResolvedType(), right.ResolvedType(), outputType,
+				-1 /* leftIdx */, rightIdx, lConstArg, nil /* constRight */, resultIdx,
+			)
+		}
+		if op == nil || err != nil {
+			op, err = colexecproj.GetProjectionLConstOperator(
+				allocator, typs, left.ResolvedType(), outputType, projOp, input,
+				rightIdx, lConstArg, resultIdx, evalCtx, binFn, cmpExpr,
+			)
+		}
 	} else {
 		var leftIdx int
 		input, leftIdx, typs, err = planProjectionOperators(
@@ -2184,6 +3685,15 @@ func planProjectionExpr(
 					allocator, evalCtx, input, leftIdx, resultIdx,
 					string(tree.MustBeDString(rConstArg)), negate,
 				)
+			case tree.SimilarTo, tree.NotSimilarTo:
+				pattern, ok := tree.AsDString(rConstArg)
+				if !ok {
+					break
+				}
+				negate := projOp == tree.NotSimilarTo
+				op, err = colexecproj.GetSimilarToProjectionOperator(
+					allocator, evalCtx, input, leftIdx, resultIdx, string(pattern), negate,
+				)
 			case tree.In, tree.NotIn:
 				negate := projOp == tree.NotIn
 				datumTuple, ok := tree.AsDTuple(rConstArg)
@@ -2197,6 +3707,30 @@ func planProjectionExpr(
 				op, err = colexec.GetInProjectionOperator(
 					allocator, typs[leftIdx], input, leftIdx, resultIdx, datumTuple, negate,
 				)
+			case tree.Any, tree.Some, tree.All:
+				if isLikeOperator(cmpExpr.SubOperator) {
+					op, err = colexec.GetLikeAnyProjectionOperator(
+						allocator, evalCtx, input, leftIdx, typs[leftIdx],
+						projOp.(tree.ComparisonOperator), cmpExpr.SubOperator, rConstArg, resultIdx,
+					)
+					break
+				}
+				if array, ok := tree.AsDArray(rConstArg); ok && projOp == tree.Any && cmpExpr.SubOperator == tree.EQ {
+					op, err = colexec.GetInArrayProjectionOperator(
+						allocator, evalCtx, typs[leftIdx], input, leftIdx, resultIdx, array,
+					)
+					break
+				}
+				// = ANY with a tuple on the right side is what IN subqueries
+				// are planned as, and the tuple contains the buffered
+				// results of the subquery, so we use a hash set for it.
+				datumTuple, ok := tree.AsDTuple(rConstArg)
+				if !ok || projOp != tree.Any || cmpExpr.SubOperator != tree.EQ || !colexec.CanUseInHashSet(typs[leftIdx], datumTuple) {
+					break
+				}
+				op, err = colexec.GetInHashProjectionOperator(
+					allocator, typs[leftIdx], input, leftIdx, resultIdx, datumTuple, false, /* negate */
+				)
 			case tree.IsDistinctFrom, tree.IsNotDistinctFrom:
 				if right != tree.DNull {
 					// Optimized IsDistinctFrom and IsNotDistinctFrom are
@@ -2210,6 +3744,69 @@ func planProjectionExpr(
 				op = colexec.NewIsNullProjOp(
 					allocator, input, leftIdx, resultIdx, negate, false, /* isTupleNull */
 				)
+			case tree.Contains, tree.ContainedBy:
+				switch typs[leftIdx].Family() {
+				case types.ArrayFamily:
+					op, err = colexec.GetArrayContainsProjectionOperator(
+						allocator, evalCtx, input, projOp.(tree.ComparisonOperator),
+						leftIdx, -1 /* rightIdx */, rConstArg, resultIdx,
+					)
+				case types.JsonFamily:
+					op, err = colexec.GetJSONContainsProjectionOperator(
+						allocator, input, projOp.(tree.ComparisonOperator),
+						leftIdx, -1 /* rightIdx */, rConstArg, resultIdx,
+					)
+				}
+			case tree.JSONExists, tree.JSONSomeExists, tree.JSONAllExists:
+				op, err = colexec.GetJSONExistsProjectionOperator(
+					allocator, input, projOp.(tree.ComparisonOperator), leftIdx, rConstArg, resultIdx,
+				)
+			case tree.JSONFetchValPath, tree.JSONFetchTextPath:
+				op, err = colexec.GetJSONFetchPathProjectionOperator(
+					allocator, input, projOp.(tree.BinaryOperator), leftIdx, rConstArg, resultIdx,
+				)
+			case tree.LShift, tree.RShift, tree.Overlaps:
+				if !isINetContainment(projOp, left, right) {
+					break
+				}
+				op, err = colexec.GetINetContainsProjectionOperator(
+					allocator, input, projOp, leftIdx, -1 /* rightIdx */, rConstArg, resultIdx,
+				)
+			case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+				if isEnumComparison(projOp, left, right) {
+					op, err = colexec.GetEnumCmpProjectionOperator(
+						allocator, input, projOp.(tree.ComparisonOperator), leftIdx, -1 /* rightIdx */, rConstArg, resultIdx,
+					)
+				} else if isTimeComparison(projOp, left, right) {
+					op, err = colexec.GetTimeCmpProjectionOperator(
+						allocator, evalCtx, input, projOp.(tree.ComparisonOperator),
+						left.ResolvedType(), right.ResolvedType(), leftIdx, -1 /* rightIdx */, rConstArg, resultIdx,
+					)
+				}
+			case tree.Concat:
+				switch outputType.Family() {
+				case types.ArrayFamily:
+					op, err = colexec.GetArrayConcatProjectionOperator(
+						allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, -1 /* rightIdx */, nil /* constLeft */, rConstArg, resultIdx,
+					)
+				case types.BitFamily:
+					op, err = colexec.GetBitConcatProjectionOperator(
+						allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, -1 /* rightIdx */, nil /* constLeft */, rConstArg, resultIdx,
+					)
+				}
+			case tree.Plus, tree.Minus:
+				if isDateTimeArith(projOp, left, right) {
+					op, err = colexec.GetDateTimeArithProjectionOperator(
+						allocator, evalCtx, input, projOp, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, -1 /* rightIdx */, nil /* constLeft */, rConstArg, resultIdx,
+					)
+				} else if projOp == tree.Minus && isDateMinusDate(left, right) {
+					op, err = colexec.GetDateMinusProjectionOperator(
+						allocator, input, leftIdx, -1 /* rightIdx */, nil /* constLeft */, rConstArg, resultIdx,
+					)
+				}
 			}
 			if op == nil || err != nil {
 				// op hasn't been created yet, so let's try the constructor for
@@ -2229,10 +3826,69 @@ func planProjectionExpr(
 				return nil, resultIdx, nil, err
 			}
 			resultIdx = len(typs)
-			op, err = colexecproj.GetProjectionOperator(
-				allocator, typs, outputType, projOp, input, leftIdx, rightIdx,
-				resultIdx, evalCtx, binFn, cmpExpr,
-			)
+			switch projOp {
+			case tree.Contains, tree.ContainedBy:
+				switch typs[leftIdx].Family() {
+				case types.ArrayFamily:
+					op, err = colexec.GetArrayContainsProjectionOperator(
+						allocator, evalCtx, input, projOp.(tree.ComparisonOperator),
+						leftIdx, rightIdx, nil /* constRight */, resultIdx,
+					)
+				case types.JsonFamily:
+					op, err = colexec.GetJSONContainsProjectionOperator(
+						allocator, input, projOp.(tree.ComparisonOperator),
+						leftIdx, rightIdx, nil /* constRight */, resultIdx,
+					)
+				}
+			case tree.LShift, tree.RShift, tree.Overlaps:
+				if !isINetContainment(projOp, left, right) {
+					break
+				}
+				op, err = colexec.GetINetContainsProjectionOperator(
+					allocator, input, projOp, leftIdx, rightIdx, nil /* constRight */, resultIdx,
+				)
+			case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+				if isEnumComparison(projOp, left, right) {
+					op, err = colexec.GetEnumCmpProjectionOperator(
+						allocator, input, projOp.(tree.ComparisonOperator), leftIdx, rightIdx, nil /* constRight */, resultIdx,
+					)
+				} else if isTimeComparison(projOp, left, right) {
+					op, err = colexec.GetTimeCmpProjectionOperator(
+						allocator, evalCtx, input, projOp.(tree.ComparisonOperator),
+						left.ResolvedType(), right.ResolvedType(), leftIdx, rightIdx, nil /* constRight */, resultIdx,
+					)
+				}
+			case tree.Concat:
+				switch outputType.Family() {
+				case types.ArrayFamily:
+					op, err = colexec.GetArrayConcatProjectionOperator(
+						allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, rightIdx, nil /* constLeft */, nil /* constRight */, resultIdx,
+					)
+				case types.BitFamily:
+					op, err = colexec.GetBitConcatProjectionOperator(
+						allocator, input, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, rightIdx, nil /* constLeft */, nil /* constRight */, resultIdx,
+					)
+				}
+			case tree.Plus, tree.Minus:
+				if isDateTimeArith(projOp, left, right) {
+					op, err = colexec.GetDateTimeArithProjectionOperator(
+						allocator, evalCtx, input, projOp, left.ResolvedType(), right.ResolvedType(), outputType,
+						leftIdx, rightIdx, nil /* constLeft */, nil /* constRight */, resultIdx,
+					)
+				} else if projOp == tree.Minus && isDateMinusDate(left, right) {
+					op, err = colexec.GetDateMinusProjectionOperator(
+						allocator, input, leftIdx, rightIdx, nil /* constLeft */, nil /* constRight */, resultIdx,
+					)
+				}
+			}
+			if op == nil || err != nil {
+				op, err = colexecproj.GetProjectionOperator(
+					allocator, typs, outputType, projOp, input, leftIdx, rightIdx,
+					resultIdx, evalCtx, binFn, cmpExpr,
+				)
+			}
 		}
 	}
 	if err != nil {
@@ -2245,6 +3901,103 @@ func planProjectionExpr(
 	return op, resultIdx, typs, err
 }
 
+// fusedArithMaxDepth is the maximum depth of the tree of binary arithmetic
+// operations that is evaluated by a single fused arithmetic operator.
+const fusedArithMaxDepth = 3
+
+// makeFusedArithExpr returns the tree of the binary arithmetic operations
+// rooted at expr to be evaluated by the fused arithmetic operator along with
+// the number of operations in it. false is returned if expr (of the given
+// depth) is not supported by the fused operator, which is the case unless
+// all of the nodes are +, -, or * operations of type typ (Int or Float),
+// columns of type typ, or non-NULL constants.
+func makeFusedArithExpr(
+	expr tree.TypedExpr, typ *types.T, columnTypes []*types.T, depth int,
+) (_ *colexecproj.FusedArithExpr, numOps int, ok bool) {
+	if !typ.Identical(types.Int) && !typ.Identical(types.Float) {
+		return nil, 0, false
+	}
+	if !expr.ResolvedType().Identical(typ) {
+		return nil, 0, false
+	}
+	switch t := expr.(type) {
+	case *tree.BinaryExpr:
+		if depth > fusedArithMaxDepth {
+			return nil, 0, false
+		}
+		if t.Operator != tree.Plus && t.Operator != tree.Minus && t.Operator != tree.Mult {
+			return nil, 0, false
+		}
+		left, numLeftOps, ok := makeFusedArithExpr(t.TypedLeft(), typ, columnTypes, depth+1)
+		if !ok {
+			return nil, 0, false
+		}
+		right, numRightOps, ok := makeFusedArithExpr(t.TypedRight(), typ, columnTypes, depth+1)
+		if !ok {
+			return nil, 0, false
+		}
+		return &colexecproj.FusedArithExpr{Op: t.Operator, Left: left, Right: right}, numLeftOps + numRightOps + 1, true
+	case *tree.IndexedVar:
+		if !columnTypes[t.Idx].Identical(typ) {
+			return nil, 0, false
+		}
+		return &colexecproj.FusedArithExpr{ColIdx: t.Idx}, 0, true
+	case *tree.DInt:
+		return &colexecproj.FusedArithExpr{ColIdx: -1, Const: int64(*t)}, 0, true
+	case *tree.DFloat:
+		return &colexecproj.FusedArithExpr{ColIdx: -1, Const: float64(*t)}, 0, true
+	}
+	return nil, 0, false
+}
+
+// isDateMinusDate returns whether the subtraction of right from left is the
+// subtraction of two dates which needs to be handled by the special operator
+// (the dates are represented as integers, so the generic operator would
+// silently produce wrong results for infinite dates).
+func isDateMinusDate(left, right tree.TypedExpr) bool {
+	return left.ResolvedType().Family() == types.DateFamily &&
+		right.ResolvedType().Family() == types.DateFamily
+}
+
+// isDateTimeArith returns whether op on left and right is the date and time
+// arithmetic which needs to be handled by the special operator (the generic
+// operators only see the physical representation of the arguments, so they
+// would silently produce wrong results, for example, for the dates which are
+// represented as integers).
+func isDateTimeArith(op tree.Operator, left, right tree.TypedExpr) bool {
+	return colexec.IsDateTimeArith(op, left.ResolvedType(), right.ResolvedType())
+}
+
+// isINetContainment returns whether op is one of the INet containment
+// operators (<<, >> and &&) on two INet arguments which are handled by the
+// special operator.
+func isINetContainment(op tree.Operator, left, right tree.TypedExpr) bool {
+	if op != tree.LShift && op != tree.RShift && op != tree.Overlaps {
+		return false
+	}
+	return left.ResolvedType().Family() == types.INetFamily &&
+		right.ResolvedType().Family() == types.INetFamily
+}
+
+// isEnumComparison returns whether op is one of the comparison operators (=,
+// <>, <, <=, > and >=) on two enum arguments which are handled by the special
+// operator.
+func isEnumComparison(op tree.Operator, left, right tree.TypedExpr) bool {
+	switch op {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+	default:
+		return false
+	}
+	return left.ResolvedType().Family() == types.EnumFamily &&
+		right.ResolvedType().Family() == types.EnumFamily
+}
+
+// isTimeComparison returns whether op is one of the comparison operators (=,
+// <>, <, <=, > and >=) on the TIME or TIMETZ arguments.
+func isTimeComparison(op tree.Operator, left, right tree.TypedExpr) bool {
+	return colexec.IsTimeCmp(op, left.ResolvedType(), right.ResolvedType())
+}
+
 // planLogicalProjectionOp plans all the needed operators for a projection of
 // a logical operation (either AND or OR).
 func planLogicalProjectionOp(
@@ -2352,6 +4105,16 @@ func appendOneType(typs []*types.T, t *types.T) []*types.T {
 	return newTyps
 }
 
+// isLikeOperator returns whether cmpOp is one of the LIKE operators that are
+// supported as sub-operators of ANY and ALL by the vectorized engine.
+func isLikeOperator(cmpOp tree.ComparisonOperator) bool {
+	switch cmpOp {
+	case tree.Like, tree.NotLike, tree.ILike, tree.NotILike:
+		return true
+	}
+	return false
+}
+
 func tupleContainsTuples(tuple *tree.DTuple) bool {
 	for _, typ := range tuple.ResolvedType().TupleContents() {
 		if typ.Family() == types.TupleFamily {
@@ -12,15 +12,25 @@ package colbuilder
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecagg"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
@@ -30,6 +40,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -144,3 +156,1606 @@ func TestNewColOperatorExpectedTypeSchema(t *testing.T) {
 	}
 	require.Equal(t, numRows, rowIdx)
 }
+
+func TestIsRedundantIntermediateCastType(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		from, via, to *types.T
+		redundant     bool
+	}{
+		{from: types.Int2, via: types.Int, to: types.Int4, redundant: true},
+		{from: types.Int4, via: types.Int4, to: types.Int2, redundant: true},
+		{from: types.Float, via: types.Int, to: types.Int, redundant: true},
+		{from: types.Int, via: types.Int2, to: types.Int, redundant: false},
+		{from: types.Int, via: types.Int4, to: types.Int2, redundant: false},
+		{from: types.Float, via: types.Int, to: types.Float, redundant: false},
+		{from: types.Int, via: types.Float, to: types.Int, redundant: false},
+		{from: types.Int, via: types.String, to: types.Int, redundant: false},
+		{from: types.MakeVarChar(3), via: types.String, to: types.MakeVarChar(2), redundant: true},
+		{from: types.MakeVarChar(3), via: types.MakeVarChar(5), to: types.String, redundant: true},
+		{from: types.String, via: types.MakeVarChar(2), to: types.String, redundant: false},
+		{from: types.MakeVarChar(5), via: types.MakeVarChar(3), to: types.String, redundant: false},
+		{from: types.String, via: types.MakeChar(5), to: types.String, redundant: false},
+		{from: types.Decimal, via: types.MakeDecimal(10, 2), to: types.Decimal, redundant: false},
+	} {
+		require.Equal(
+			t, tc.redundant, isRedundantIntermediateCastType(tc.from, tc.via, tc.to),
+			"%s -> %s -> %s", tc.from.SQLString(), tc.via.SQLString(), tc.to.SQLString(),
+		)
+	}
+}
+
+// TestCastChainPlanning verifies that the chains of casts with redundant
+// intermediate types are planned as a single cast operator.
+func TestCastChainPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+
+	for _, tc := range []struct {
+		typs     []*types.T
+		numCasts int
+	}{
+		{typs: []*types.T{types.Int2, types.Int, types.Int4}, numCasts: 1},
+		// The chain collapses into a no-op cast which is elided.
+		{typs: []*types.T{types.Int2, types.Int4, types.Int, types.Int2}, numCasts: 0},
+		{typs: []*types.T{types.Int, types.Int2, types.Int2}, numCasts: 1},
+		{typs: []*types.T{types.Int, types.Int2, types.Int}, numCasts: 2},
+		{typs: []*types.T{types.Int2, types.Int, types.Bool, types.Int4}, numCasts: 3},
+		{typs: []*types.T{types.Int2, types.Int2, types.Bool, types.Int4}, numCasts: 2},
+	} {
+		// Build the chain of casts of the first column into the remaining types
+		// in order.
+		var expr tree.TypedExpr = tree.NewTypedOrdinalReference(0, tc.typs[0])
+		for _, typ := range tc.typs[1:] {
+			expr = tree.NewTypedCastExpr(expr, typ)
+		}
+		columnTypes := tc.typs[:1]
+		_, resultIdx, typs, err := planProjectionOperators(
+			ctx, &evalCtx, expr, columnTypes, colexecop.NewFeedOperator(), &acc,
+			coldataext.NewExtendedColumnFactory(&evalCtx), nil, /* releasables */
+		)
+		require.NoError(t, err, expr.String())
+		// Each cast operator appends a single column.
+		require.Equal(t, tc.numCasts, len(typs)-len(columnTypes), expr.String())
+		require.True(t, typs[resultIdx].Identical(tc.typs[len(tc.typs)-1]), expr.String())
+	}
+}
+
+// TestNoopCastElision verifies that the casts into the identical type are
+// elided while the casts that can change the values (like the ones between
+// the decimals with different scales) are planned.
+func TestNoopCastElision(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+
+	for _, tc := range []struct {
+		from, to *types.T
+		elided   bool
+	}{
+		{from: types.Int, to: types.Int, elided: true},
+		{from: types.Int2, to: types.Int2, elided: true},
+		{from: types.String, to: types.String, elided: true},
+		{from: types.MakeVarChar(3), to: types.MakeVarChar(3), elided: true},
+		{from: types.Decimal, to: types.Decimal, elided: true},
+		{from: types.MakeDecimal(10, 2), to: types.MakeDecimal(10, 2), elided: true},
+		{from: types.TimestampTZ, to: types.TimestampTZ, elided: true},
+		{from: types.IntArray, to: types.IntArray, elided: true},
+		{from: types.Int, to: types.Int2, elided: false},
+		{from: types.Int4, to: types.Int, elided: false},
+		{from: types.Decimal, to: types.MakeDecimal(10, 2), elided: false},
+		{from: types.MakeDecimal(10, 4), to: types.MakeDecimal(10, 2), elided: false},
+		{from: types.MakeDecimal(10, 2), to: types.MakeDecimal(12, 2), elided: false},
+	} {
+		expr := tree.NewTypedCastExpr(tree.NewTypedOrdinalReference(0, tc.from), tc.to)
+		columnTypes := []*types.T{tc.from}
+		input := colexecop.NewFeedOperator()
+		op, resultIdx, typs, err := planProjectionOperators(
+			ctx, &evalCtx, expr, columnTypes, input, &acc,
+			coldataext.NewExtendedColumnFactory(&evalCtx), nil, /* releasables */
+		)
+		require.NoError(t, err, expr.String())
+		require.True(t, typs[resultIdx].Identical(tc.to), expr.String())
+		if tc.elided {
+			require.Same(t, input, op, expr.String())
+			require.Equal(t, 0, resultIdx, expr.String())
+			require.Equal(t, columnTypes, typs, expr.String())
+		} else {
+			require.NotSame(t, input, op, expr.String())
+			require.Equal(t, 1, resultIdx, expr.String())
+		}
+	}
+}
+
+func TestFusedArithmeticPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+
+	col := func(idx int, typ *types.T) tree.TypedExpr {
+		return tree.NewTypedOrdinalReference(idx, typ)
+	}
+	bin := func(op tree.BinaryOperator, left, right tree.TypedExpr) tree.TypedExpr {
+		return tree.NewTypedBinaryExpr(op, left, right, left.ResolvedType())
+	}
+	for _, tc := range []struct {
+		columnTypes []*types.T
+		expr        tree.TypedExpr
+		// numCols is the number of the columns appended by the projection
+		// operators.
+		numCols int
+	}{
+		{
+			// A single operation is not fused.
+			columnTypes: []*types.T{types.Int, types.Int},
+			expr:        bin(tree.Plus, col(0, types.Int), col(1, types.Int)),
+			numCols:     1,
+		},
+		{
+			columnTypes: []*types.T{types.Int, types.Int, types.Int, types.Int},
+			expr: bin(tree.Mult,
+				bin(tree.Plus, col(0, types.Int), col(1, types.Int)),
+				bin(tree.Minus, col(2, types.Int), col(3, types.Int)),
+			),
+			numCols: 1,
+		},
+		{
+			columnTypes: []*types.T{types.Float, types.Float},
+			expr: bin(tree.Minus,
+				bin(tree.Mult, col(0, types.Float), tree.NewDFloat(2)),
+				col(1, types.Float),
+			),
+			numCols: 1,
+		},
+		{
+			// The nesting is deeper than the maximum depth, so only the top
+			// three levels are fused.
+			columnTypes: []*types.T{types.Int, types.Int},
+			expr: bin(tree.Plus,
+				bin(tree.Plus,
+					bin(tree.Plus,
+						bin(tree.Plus, col(0, types.Int), col(1, types.Int)),
+						col(1, types.Int),
+					),
+					col(1, types.Int),
+				),
+				col(1, types.Int),
+			),
+			numCols: 2,
+		},
+		{
+			// Division is not fused.
+			columnTypes: []*types.T{types.Int, types.Int},
+			expr: bin(tree.Plus,
+				tree.NewTypedBinaryExpr(tree.FloorDiv, col(0, types.Int), col(1, types.Int), types.Int),
+				col(1, types.Int),
+			),
+			numCols: 2,
+		},
+	} {
+		_, resultIdx, typs, err := planProjectionOperators(
+			ctx, &evalCtx, tc.expr, tc.columnTypes, colexecop.NewFeedOperator(), &acc,
+			coldataext.NewExtendedColumnFactory(&evalCtx), nil, /* releasables */
+		)
+		require.NoError(t, err, tc.expr.String())
+		require.Equal(t, tc.numCols, len(typs)-len(tc.columnTypes), tc.expr.String())
+		require.True(t, typs[resultIdx].Identical(tc.expr.ResolvedType()), tc.expr.String())
+	}
+}
+
+// TestWindowPartitionOrdering verifies that the ordering on the PARTITION BY
+// columns is chosen to reuse the existing ordering of the input as much as
+// possible.
+func TestWindowPartitionOrdering(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	asc := func(idx uint32) execinfrapb.Ordering_Column {
+		return execinfrapb.Ordering_Column{ColIdx: idx, Direction: execinfrapb.Ordering_Column_ASC}
+	}
+	desc := func(idx uint32) execinfrapb.Ordering_Column {
+		return execinfrapb.Ordering_Column{ColIdx: idx, Direction: execinfrapb.Ordering_Column_DESC}
+	}
+	for _, tc := range []struct {
+		partitionBy   []uint32
+		inputOrdering []execinfrapb.Ordering_Column
+		expected      []execinfrapb.Ordering_Column
+		matchLen      uint32
+	}{
+		{
+			partitionBy: []uint32{1, 0},
+			expected:    []execinfrapb.Ordering_Column{asc(1), asc(0)},
+		},
+		{
+			partitionBy:   []uint32{1, 0},
+			inputOrdering: []execinfrapb.Ordering_Column{desc(0), asc(1), asc(2)},
+			expected:      []execinfrapb.Ordering_Column{desc(0), asc(1)},
+			matchLen:      2,
+		},
+		{
+			partitionBy:   []uint32{0, 1, 2},
+			inputOrdering: []execinfrapb.Ordering_Column{desc(2), asc(3), asc(1)},
+			expected:      []execinfrapb.Ordering_Column{desc(2), asc(0), asc(1)},
+			matchLen:      1,
+		},
+		{
+			partitionBy:   []uint32{0},
+			inputOrdering: []execinfrapb.Ordering_Column{asc(1), asc(0)},
+			expected:      []execinfrapb.Ordering_Column{asc(0)},
+		},
+		{
+			partitionBy:   []uint32{0, 1},
+			inputOrdering: []execinfrapb.Ordering_Column{desc(1), desc(1), asc(0)},
+			expected:      []execinfrapb.Ordering_Column{desc(1), asc(0)},
+			matchLen:      1,
+		},
+	} {
+		partitionOrdering := windowPartitionOrdering(tc.partitionBy, tc.inputOrdering)
+		require.Equal(t, tc.expected, partitionOrdering)
+		require.Equal(t, tc.matchLen, orderingMatchLen(partitionOrdering, tc.inputOrdering))
+	}
+
+	// The directions of the ORDER BY columns must match too.
+	required := []execinfrapb.Ordering_Column{asc(0), asc(1)}
+	require.Equal(t, uint32(2), orderingMatchLen(required, []execinfrapb.Ordering_Column{asc(0), asc(1), asc(2)}))
+	require.Equal(t, uint32(1), orderingMatchLen(required, []execinfrapb.Ordering_Column{asc(0), desc(1)}))
+	require.Equal(t, uint32(0), orderingMatchLen(required, []execinfrapb.Ordering_Column{asc(1), asc(0)}))
+}
+
+// TestSharedWindowSpec verifies that the window functions with the same
+// window spec share the partitioning and the peer grouping and that they
+// produce the expected output.
+func TestSharedWindowSpec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &acc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	// The peers are identical in all columns so that the output of row_number
+	// doesn't depend on the order in which the sort returns them.
+	inputTypes := []*types.T{types.Int, types.Int, types.Int}
+	inputTuples := colexectestutils.Tuples{
+		{1, 1, 4},
+		{1, 2, 5},
+		{2, 3, 2},
+		{1, nil, 0},
+		{2, 3, 2},
+		{1, 2, 5},
+		{nil, 5, 1},
+		{2, 0, 7},
+	}
+	expected := colexectestutils.Tuples{
+		{1, nil, 0, 1, 1, 1, 4},
+		{1, 1, 4, 2, 2, 2, 3},
+		{1, 2, 5, 3, 3, 3, 1},
+		{1, 2, 5, 4, 3, 3, 1},
+		{2, 0, 7, 1, 1, 1, 1},
+		{2, 3, 2, 2, 2, 2, 2},
+		{2, 3, 2, 3, 2, 2, 2},
+		{nil, 5, 1, 1, 1, 1, 1},
+	}
+	windowFn := func(
+		fn execinfrapb.WindowerSpec_WindowFunc, ordering execinfrapb.Ordering_Column, outputColIdx uint32,
+	) execinfrapb.WindowerSpec_WindowFn {
+		return execinfrapb.WindowerSpec_WindowFn{
+			Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &fn},
+			Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{ordering}},
+			FilterColIdx: tree.NoColumnIdx,
+			OutputColIdx: outputColIdx,
+		}
+	}
+	orderByO := execinfrapb.Ordering_Column{ColIdx: 1, Direction: execinfrapb.Ordering_Column_ASC}
+	orderByO2Desc := execinfrapb.Ordering_Column{ColIdx: 2, Direction: execinfrapb.Ordering_Column_DESC}
+	// The first three window functions share the window spec, and the last
+	// one has a different ordering.
+	windowerSpec := &execinfrapb.WindowerSpec{
+		PartitionBy: []uint32{0},
+		WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+			windowFn(execinfrapb.WindowerSpec_ROW_NUMBER, orderByO, 3),
+			windowFn(execinfrapb.WindowerSpec_RANK, orderByO, 4),
+			windowFn(execinfrapb.WindowerSpec_DENSE_RANK, orderByO, 5),
+			windowFn(execinfrapb.WindowerSpec_RANK, orderByO2Desc, 6),
+		},
+	}
+	var monitors []*mon.BytesMonitor
+	var accounts []*mon.BoundAccount
+	defer func() {
+		for _, acc := range accounts {
+			acc.Close(ctx)
+		}
+		for _, m := range monitors {
+			m.Stop(ctx)
+		}
+	}()
+	planWindower := func(input colexecop.Operator) (colexecop.Operator, error) {
+		args := &colexecargs.NewColOperatorArgs{
+			Spec: &execinfrapb.ProcessorSpec{
+				Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core:        execinfrapb.ProcessorCoreUnion{Windower: windowerSpec},
+				ResultTypes: []*types.T{types.Int, types.Int, types.Int, types.Int, types.Int, types.Int, types.Int},
+			},
+			Inputs:              []colexecargs.OpWithMetaInfo{{Root: input}},
+			StreamingMemAccount: &acc,
+		}
+		args.TestingKnobs.DiskSpillingDisabled = true
+		r, err := NewColOperator(ctx, flowCtx, args)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, r.OpMonitors...)
+		accounts = append(accounts, r.OpAccounts...)
+		return r.Root, nil
+	}
+	op, err := planWindower(colexecop.NewFeedOperator())
+	require.NoError(t, err)
+	// The input is partitioned and the peers are grouped once for each of the
+	// two distinct window specs.
+	require.Equal(t, 2, countOps(op, "windowSortingPartitioner"))
+	require.Equal(t, 2, countOps(op, "PeerGrouperWithPartitionOp"))
+
+	colexectestutils.RunTestsWithTyps(
+		t, allocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+		expected, colexectestutils.UnorderedVerifier,
+		func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+			return planWindower(inputs[0])
+		},
+	)
+}
+
+// countOps returns the number of the operators in the tree rooted at op with
+// the type name ending with the suffix.
+func countOps(op execinfra.OpNode, suffix string) int {
+	var res int
+	if strings.HasSuffix(fmt.Sprintf("%T", op), suffix) {
+		res++
+	}
+	for i := 0; i < op.ChildCount(true /* verbose */); i++ {
+		res += countOps(op.Child(i, true /* verbose */), suffix)
+	}
+	return res
+}
+
+// TestRangeSelectionPlanning verifies that the comparisons of the same column
+// with the lower and the upper bounds are fused into a single range selection
+// operator and that the contradictory bounds result in no tuples being
+// selected.
+func TestRangeSelectionPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+
+	columnTypes := []*types.T{types.Int, types.Int, types.Int4}
+	cmp := func(op tree.ComparisonOperator, colIdx int, val int) tree.TypedExpr {
+		return tree.NewTypedComparisonExpr(
+			op, tree.NewTypedOrdinalReference(colIdx, columnTypes[colIdx]), tree.NewDInt(tree.DInt(val)),
+		)
+	}
+	and := func(exprs ...tree.TypedExpr) tree.TypedExpr {
+		res := exprs[0]
+		for _, expr := range exprs[1:] {
+			res = tree.NewTypedAndExpr(res, expr)
+		}
+		return res
+	}
+	for _, tc := range []struct {
+		expr tree.TypedExpr
+		// empty indicates that the bounds are contradictory.
+		empty         bool
+		numRangeOps   int
+		numSelConstOp int
+	}{
+		{
+			expr:        and(cmp(tree.GT, 0, 5), cmp(tree.LT, 0, 10)),
+			numRangeOps: 1,
+		},
+		{
+			expr:        and(cmp(tree.LE, 0, 10), cmp(tree.GE, 0, 5)),
+			numRangeOps: 1,
+		},
+		{
+			// The bounds are the same but both are inclusive.
+			expr:        and(cmp(tree.GE, 0, 5), cmp(tree.LE, 0, 5)),
+			numRangeOps: 1,
+		},
+		{
+			// The conjuncts on the same column don't have to be adjacent.
+			expr:          and(cmp(tree.GE, 0, 5), cmp(tree.LT, 1, 3), cmp(tree.LE, 0, 10)),
+			numRangeOps:   1,
+			numSelConstOp: 1,
+		},
+		{
+			expr: and(
+				cmp(tree.GT, 0, 5), cmp(tree.GT, 1, 3), cmp(tree.LT, 1, 7), cmp(tree.LT, 0, 10),
+			),
+			numRangeOps: 2,
+		},
+		{
+			// Only a single pair of the bounds is fused for each column.
+			expr:          and(cmp(tree.GT, 0, 5), cmp(tree.LT, 0, 10), cmp(tree.GT, 0, 7)),
+			numRangeOps:   1,
+			numSelConstOp: 1,
+		},
+		{
+			// Both bounds are lower ones.
+			expr:          and(cmp(tree.GT, 0, 5), cmp(tree.GT, 0, 7)),
+			numSelConstOp: 2,
+		},
+		{
+			// Different columns.
+			expr:          and(cmp(tree.GT, 0, 5), cmp(tree.LT, 1, 10)),
+			numSelConstOp: 2,
+		},
+		{
+			// The constant has a different width than the column.
+			expr:          and(cmp(tree.GT, 2, 5), cmp(tree.LT, 2, 10)),
+			numSelConstOp: 2,
+		},
+		{
+			expr:  and(cmp(tree.GT, 0, 10), cmp(tree.LT, 0, 5)),
+			empty: true,
+		},
+		{
+			expr:  and(cmp(tree.GT, 1, 3), cmp(tree.LT, 0, 5), cmp(tree.GE, 0, 5)),
+			empty: true,
+		},
+		{
+			expr:  and(cmp(tree.GE, 0, 5), cmp(tree.LT, 0, 5)),
+			empty: true,
+		},
+	} {
+		op, _, _, err := planSelectionOperators(
+			ctx, &evalCtx, tc.expr, columnTypes, colexecop.NewFeedOperator(), &acc,
+			coldataext.NewExtendedColumnFactory(&evalCtx), nil, /* releasables */
+		)
+		require.NoError(t, err, tc.expr.String())
+		if tc.empty {
+			require.Equal(t, "*colexecutils.zeroOperator", fmt.Sprintf("%T", op), tc.expr.String())
+			continue
+		}
+		if tc.numRangeOps == 1 && tc.numSelConstOp == 0 {
+			// A single range selection doesn't need the buffering.
+			require.Equal(t, "*colexecsel.selRangeInt64Op", fmt.Sprintf("%T", op), tc.expr.String())
+		}
+		require.Equal(t, tc.numRangeOps, countOps(op, "selRangeInt64Op"), tc.expr.String())
+		require.Equal(t, tc.numSelConstOp, countOps(op, "ConstOp"), tc.expr.String())
+	}
+}
+
+// TestFilterBelowSimpleProject verifies that the filter on top of a simple
+// projection is planned below it with the column indices remapped and that it
+// produces the same results.
+func TestFilterBelowSimpleProject(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	factory := coldataext.NewExtendedColumnFactory(&evalCtx)
+	allocator := colmem.NewAllocator(ctx, &acc, factory)
+
+	inputTypes := []*types.T{types.Int, types.Int, types.String, types.Int}
+	inputTuples := colexectestutils.Tuples{
+		{1, 2, "a", 3},
+		{4, nil, "b", 4},
+		{2, 2, nil, 2},
+		{nil, 1, "c", 5},
+		{0, 0, "d", nil},
+		{3, 1, "e", 1},
+	}
+	// The string column is projected out, and the remaining ones are
+	// reordered, so the filters below refer to the input columns 3, 0, 1.
+	projection := []uint32{3, 0, 1}
+	projectedTypes := []*types.T{types.Int, types.Int, types.Int}
+	for _, tc := range []struct {
+		filter   string
+		expected colexectestutils.Tuples
+	}{
+		{
+			filter:   "@1 > 2",
+			expected: colexectestutils.Tuples{{3, 1, 2}, {4, 4, nil}, {5, nil, 1}},
+		},
+		{
+			// The sum is appended as a temporary column.
+			filter:   "@2 + @3 < 5",
+			expected: colexectestutils.Tuples{{3, 1, 2}, {2, 2, 2}, {nil, 0, 0}, {1, 3, 1}},
+		},
+		{
+			filter:   "@1 = @2 OR @3 IS NULL",
+			expected: colexectestutils.Tuples{{4, 4, nil}, {2, 2, 2}},
+		},
+		{
+			filter:   "@1 > @3 AND @2 < 4",
+			expected: colexectestutils.Tuples{{3, 1, 2}},
+		},
+	} {
+		t.Run(tc.filter, func(t *testing.T) {
+			planFilter := func(input colexecop.Operator) (colexecop.Operator, error) {
+				return planFilterExpr(
+					ctx, flowCtx, &evalCtx, colexecbase.NewSimpleProjectOp(input, inputTypes, projection),
+					projectedTypes, execinfrapb.Expression{Expr: tc.filter}, &acc, factory,
+					colexecargs.NewExprHelper(), nil, /* releasables */
+				)
+			}
+			// The filter must be planned below the projection, so the root of
+			// the operator tree must be the same projection.
+			op, err := planFilter(colexecop.NewFeedOperator())
+			require.NoError(t, err)
+			_, rootInputTypes, rootProjection, ok := colexecbase.UnwrapSimpleProjectOp(op)
+			require.True(t, ok, "unexpected root %T", op)
+			require.Equal(t, projection, rootProjection)
+			// The filter is planned with the actual types of the input
+			// columns, including the projected out ones.
+			require.Equal(t, inputTypes, rootInputTypes[:len(inputTypes)])
+
+			colexectestutils.RunTestsWithTyps(
+				t, allocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return planFilter(inputs[0])
+				},
+			)
+		})
+	}
+}
+
+// TestFilterOnWindowFunctionOutput is a regression test for planning a filter
+// on the output of a window function that uses temporary columns. The filter
+// is planned below the projection that removes the temporary columns, so that
+// projection must be created with the correct types of its input.
+func TestFilterOnWindowFunctionOutput(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &acc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	// The rows within each partition are identical so that the output doesn't
+	// depend on the order in which the sort returns them.
+	inputTypes := []*types.T{types.Int, types.Int}
+	outputTypes := []*types.T{types.Int, types.Int, types.Int}
+	inputTuples := colexectestutils.Tuples{{1, 10}, {2, 20}, {1, 10}, {3, 30}, {2, 20}, {1, 10}}
+	expected := colexectestutils.Tuples{{1, 10, 1}, {2, 20, 1}, {3, 30, 1}}
+	rowNumber := execinfrapb.WindowerSpec_ROW_NUMBER
+	// The partitioning column is a temporary column that is projected out
+	// after computing row_number.
+	windowerSpec := &execinfrapb.WindowerSpec{
+		PartitionBy: []uint32{0},
+		WindowFns: []execinfrapb.WindowerSpec_WindowFn{{
+			Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &rowNumber},
+			FilterColIdx: tree.NoColumnIdx,
+			OutputColIdx: 2,
+		}},
+	}
+	var results []*colexecargs.NewColOperatorResult
+	defer func() {
+		for _, r := range results {
+			for _, acc := range r.OpAccounts {
+				acc.Close(ctx)
+			}
+			for _, m := range r.OpMonitors {
+				m.Stop(ctx)
+			}
+		}
+	}()
+	plan := func(spec *execinfrapb.ProcessorSpec, input colexecop.Operator) (colexecop.Operator, error) {
+		args := &colexecargs.NewColOperatorArgs{
+			Spec:                spec,
+			Inputs:              []colexecargs.OpWithMetaInfo{{Root: input}},
+			StreamingMemAccount: &acc,
+		}
+		args.TestingKnobs.DiskSpillingDisabled = true
+		r, err := NewColOperator(ctx, flowCtx, args)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+		return r.Root, nil
+	}
+	colexectestutils.RunTestsWithTyps(
+		t, allocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+		expected, colexectestutils.UnorderedVerifier,
+		func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+			windower, err := plan(&execinfrapb.ProcessorSpec{
+				Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+				Core:        execinfrapb.ProcessorCoreUnion{Windower: windowerSpec},
+				ResultTypes: outputTypes,
+			}, inputs[0])
+			if err != nil {
+				return nil, err
+			}
+			return plan(&execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: outputTypes}},
+				Core: execinfrapb.ProcessorCoreUnion{Filterer: &execinfrapb.FiltererSpec{
+					Filter: execinfrapb.Expression{Expr: "@3 = 1"},
+				}},
+				ResultTypes: outputTypes,
+			}, windower)
+		},
+	)
+}
+
+// TestRedundantIsNotNullPlanning verifies that the IS NOT NULL conjuncts are
+// not planned when another conjunct already rejects the NULL values of the
+// same column, that the IS NOT NULL checks under OR expressions are kept, and
+// that the filters produce the expected results.
+func TestRedundantIsNotNullPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	factory := coldataext.NewExtendedColumnFactory(&evalCtx)
+	allocator := colmem.NewAllocator(ctx, &acc, factory)
+
+	typs := []*types.T{types.Int, types.Int}
+	inputTuples := colexectestutils.Tuples{{0, 1}, {nil, 2}, {2, nil}, {3, 3}, {nil, nil}}
+	for _, tc := range []struct {
+		filter string
+		// numIsNullOps is the number of the IS NOT NULL selections that
+		// must be planned.
+		numIsNullOps int
+		expected     colexectestutils.Tuples
+	}{
+		{
+			filter:   "@1 IS NOT NULL AND @1 > 1",
+			expected: colexectestutils.Tuples{{2, nil}, {3, 3}},
+		},
+		{
+			filter:   "@1 < 3 AND @1 IS NOT NULL",
+			expected: colexectestutils.Tuples{{0, 1}, {2, nil}},
+		},
+		{
+			filter:   "@1 IS NOT NULL AND @2 IS NOT NULL AND @2 = @1",
+			expected: colexectestutils.Tuples{{3, 3}},
+		},
+		{
+			// Only the check of the second column is redundant.
+			filter:       "@1 IS NOT NULL AND @2 > 0 AND @2 IS NOT NULL",
+			numIsNullOps: 1,
+			expected:     colexectestutils.Tuples{{0, 1}, {3, 3}},
+		},
+		{
+			// Only the direct comparisons of the columns are considered.
+			filter:       "@1 IS NOT NULL AND @1 + 1 > 3",
+			numIsNullOps: 1,
+			expected:     colexectestutils.Tuples{{3, 3}},
+		},
+		{
+			// The check isn't redundant in the OR context.
+			filter:       "@1 IS NOT NULL OR @1 > 1",
+			numIsNullOps: 1,
+			expected:     colexectestutils.Tuples{{0, 1}, {2, nil}, {3, 3}},
+		},
+		{
+			filter:       "(@1 IS NOT NULL OR @2 = 2) AND @1 < 3",
+			numIsNullOps: 1,
+			expected:     colexectestutils.Tuples{{0, 1}, {2, nil}},
+		},
+		{
+			filter:       "@1 IS NOT NULL AND (@1 > 2 OR @2 = 2)",
+			numIsNullOps: 1,
+			expected:     colexectestutils.Tuples{{3, 3}},
+		},
+	} {
+		t.Run(tc.filter, func(t *testing.T) {
+			planFilter := func(input colexecop.Operator) (colexecop.Operator, error) {
+				return planFilterExpr(
+					ctx, flowCtx, &evalCtx, input, typs, execinfrapb.Expression{Expr: tc.filter},
+					&acc, factory, colexecargs.NewExprHelper(), nil, /* releasables */
+				)
+			}
+			op, err := planFilter(colexecop.NewFeedOperator())
+			require.NoError(t, err)
+			require.Equal(t, tc.numIsNullOps, countOps(op, "isNullSelOp"))
+
+			colexectestutils.RunTestsWithTyps(
+				t, allocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return planFilter(inputs[0])
+				},
+			)
+		})
+	}
+}
+
+func TestIsDistinctAggregation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	anyNotNull := func(col uint32) execinfrapb.AggregatorSpec_Aggregation {
+		return execinfrapb.AggregatorSpec_Aggregation{Func: execinfrapb.AnyNotNull, ColIdx: []uint32{col}}
+	}
+	filterColIdx := uint32(2)
+	for _, tc := range []struct {
+		desc     string
+		spec     execinfrapb.AggregatorSpec
+		expected bool
+	}{
+		{
+			desc: "all grouping columns",
+			spec: execinfrapb.AggregatorSpec{
+				GroupCols:    []uint32{0, 1},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{anyNotNull(0), anyNotNull(1)},
+			},
+			expected: true,
+		},
+		{
+			desc: "some grouping columns reordered and repeated",
+			spec: execinfrapb.AggregatorSpec{
+				Type:         execinfrapb.AggregatorSpec_NON_SCALAR,
+				GroupCols:    []uint32{0, 1, 2},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{anyNotNull(2), anyNotNull(0), anyNotNull(2)},
+			},
+			expected: true,
+		},
+		{
+			desc: "scalar",
+			spec: execinfrapb.AggregatorSpec{
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{anyNotNull(0)},
+			},
+		},
+		{
+			desc: "non-grouping column",
+			spec: execinfrapb.AggregatorSpec{
+				GroupCols:    []uint32{0},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{anyNotNull(0), anyNotNull(1)},
+			},
+		},
+		{
+			desc: "actual aggregate",
+			spec: execinfrapb.AggregatorSpec{
+				GroupCols: []uint32{0},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+					anyNotNull(0), {Func: execinfrapb.Min, ColIdx: []uint32{0}},
+				},
+			},
+		},
+		{
+			desc: "filter",
+			spec: execinfrapb.AggregatorSpec{
+				GroupCols: []uint32{0},
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+					{Func: execinfrapb.AnyNotNull, ColIdx: []uint32{0}, FilterColIdx: &filterColIdx},
+				},
+			},
+		},
+	} {
+		require.Equal(t, tc.expected, isDistinctAggregation(&tc.spec), tc.desc)
+	}
+}
+
+// TestSingleGroupAggregation verifies that the aggregations with no grouping
+// columns are planned with the ordered aggregator rather than the hash one and
+// that the HAVING filter on top of them emits the single group only if the
+// predicate passes.
+func TestSingleGroupAggregation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &acc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	inputTypes := []*types.T{types.Int}
+	outputTypes := []*types.T{types.Int, types.Int}
+	inputTuples := colexectestutils.Tuples{{1}, {3}, {2}}
+	for _, tc := range []struct {
+		desc     string
+		aggType  execinfrapb.AggregatorSpec_Type
+		input    colexectestutils.Tuples
+		having   string
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc:     "scalar having passes",
+			aggType:  execinfrapb.AggregatorSpec_SCALAR,
+			input:    inputTuples,
+			having:   "@2 > 2",
+			expected: colexectestutils.Tuples{{3, 3}},
+		},
+		{
+			desc:     "scalar having filters out",
+			aggType:  execinfrapb.AggregatorSpec_SCALAR,
+			input:    inputTuples,
+			having:   "@2 > 3",
+			expected: colexectestutils.Tuples{},
+		},
+		{
+			desc:     "scalar empty input having passes",
+			aggType:  execinfrapb.AggregatorSpec_SCALAR,
+			input:    colexectestutils.Tuples{},
+			having:   "@1 = 0",
+			expected: colexectestutils.Tuples{{0, nil}},
+		},
+		{
+			desc:     "scalar empty input having filters out",
+			aggType:  execinfrapb.AggregatorSpec_SCALAR,
+			input:    colexectestutils.Tuples{},
+			having:   "@2 IS NOT NULL",
+			expected: colexectestutils.Tuples{},
+		},
+		{
+			// This is the case of grouping by a constant, with the grouping
+			// column removed by the optimizer.
+			desc:     "non-scalar having passes",
+			aggType:  execinfrapb.AggregatorSpec_NON_SCALAR,
+			input:    inputTuples,
+			having:   "@1 = 3",
+			expected: colexectestutils.Tuples{{3, 3}},
+		},
+		{
+			desc:     "non-scalar having filters out",
+			aggType:  execinfrapb.AggregatorSpec_NON_SCALAR,
+			input:    inputTuples,
+			having:   "@1 < 3",
+			expected: colexectestutils.Tuples{},
+		},
+		{
+			desc:     "non-scalar empty input",
+			aggType:  execinfrapb.AggregatorSpec_NON_SCALAR,
+			input:    colexectestutils.Tuples{},
+			having:   "@1 = 0",
+			expected: colexectestutils.Tuples{},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			aggSpec := &execinfrapb.AggregatorSpec{
+				Type: tc.aggType,
+				Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+					{Func: execinfrapb.CountRows},
+					{Func: execinfrapb.Max, ColIdx: []uint32{0}},
+				},
+			}
+			planAggWithHaving := func(input colexecop.Operator) (colexecop.Operator, error) {
+				r, err := NewColOperator(ctx, flowCtx, &colexecargs.NewColOperatorArgs{
+					Spec: &execinfrapb.ProcessorSpec{
+						Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+						Core:        execinfrapb.ProcessorCoreUnion{Aggregator: aggSpec},
+						ResultTypes: outputTypes,
+					},
+					Inputs:              []colexecargs.OpWithMetaInfo{{Root: input}},
+					StreamingMemAccount: &acc,
+				})
+				if err != nil {
+					return nil, err
+				}
+				r, err = NewColOperator(ctx, flowCtx, &colexecargs.NewColOperatorArgs{
+					Spec: &execinfrapb.ProcessorSpec{
+						Input: []execinfrapb.InputSyncSpec{{ColumnTypes: outputTypes}},
+						Core: execinfrapb.ProcessorCoreUnion{
+							Filterer: &execinfrapb.FiltererSpec{Filter: execinfrapb.Expression{Expr: tc.having}},
+						},
+						ResultTypes: outputTypes,
+					},
+					Inputs:              []colexecargs.OpWithMetaInfo{{Root: r.Root}},
+					StreamingMemAccount: &acc,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return r.Root, nil
+			}
+			op, err := planAggWithHaving(colexecop.NewFeedOperator())
+			require.NoError(t, err)
+			require.Equal(t, 1, countOps(op, "orderedAggregator"))
+			require.Equal(t, 0, countOps(op, "hashAggregator"))
+
+			// The HAVING filters above either reject or accept the single
+			// group regardless of whether the input values are NULL, so the
+			// all nulls injection doesn't necessarily change the output.
+			colexectestutils.RunTestsWithoutAllNullsInjection(
+				t, allocator, []colexectestutils.Tuples{tc.input}, [][]*types.T{inputTypes},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return planAggWithHaving(inputs[0])
+				},
+			)
+		})
+	}
+
+	// The ordered grouping columns must be a subset of the grouping columns
+	// even when there are none of the latter.
+	_, err := needHashAggregator(&execinfrapb.AggregatorSpec{OrderedGroupCols: []uint32{0}})
+	require.Error(t, err)
+}
+
+// TestFoldFinalAvgs verifies that the render expressions dividing the results
+// of the sum and sum_int aggregations are folded into the final avg
+// aggregations only when the intermediate results aren't used otherwise.
+func TestFoldFinalAvgs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx}
+	args := &colexecargs.NewColOperatorArgs{ExprHelper: colexecargs.NewExprHelper()}
+
+	inputTypes := []*types.T{types.Int, types.Decimal, types.Float, types.Int, types.Int}
+	agg := func(fn execinfrapb.AggregatorSpec_Func, colIdx ...uint32) execinfrapb.AggregatorSpec_Aggregation {
+		return execinfrapb.AggregatorSpec_Aggregation{Func: fn, ColIdx: colIdx}
+	}
+	distinct := func(agg execinfrapb.AggregatorSpec_Aggregation) execinfrapb.AggregatorSpec_Aggregation {
+		agg.Distinct = true
+		return agg
+	}
+	for _, tc := range []struct {
+		aggregations []execinfrapb.AggregatorSpec_Aggregation
+		renderExprs  []string
+		// If expectedAggregations is nil, nothing should be folded.
+		expectedAggregations []execinfrapb.AggregatorSpec_Aggregation
+		expectedRenderExprs  []string
+	}{
+		{
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.AnyNotNull, 0), agg(execinfrapb.Sum, 1), agg(execinfrapb.SumInt, 3),
+			},
+			renderExprs: []string{"@1", "@2 / @3"},
+			expectedAggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.AnyNotNull, 0), agg(execinfrapb.Avg, 1, 3),
+			},
+			expectedRenderExprs: []string{"@1", "@2"},
+		},
+		{
+			// The count precedes the sum, and the count of the floats is cast.
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.SumInt, 4), agg(execinfrapb.AnyNotNull, 0), agg(execinfrapb.Sum, 2),
+			},
+			renderExprs: []string{"@3 / @1::FLOAT8", "@2 + 1"},
+			expectedAggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.AnyNotNull, 0), agg(execinfrapb.Avg, 2, 4),
+			},
+			expectedRenderExprs: []string{"@2", "@1 + 1"},
+		},
+		{
+			// Two averages.
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Sum, 1), agg(execinfrapb.SumInt, 3), agg(execinfrapb.Sum, 2), agg(execinfrapb.SumInt, 4),
+			},
+			renderExprs: []string{"@3 / @4::FLOAT8", "@1 / @2"},
+			expectedAggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Avg, 1, 3), agg(execinfrapb.Avg, 2, 4),
+			},
+			expectedRenderExprs: []string{"@2", "@1"},
+		},
+		{
+			// The sum is also rendered on its own.
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Sum, 1), agg(execinfrapb.SumInt, 3),
+			},
+			renderExprs: []string{"@1 / @2", "@1"},
+		},
+		{
+			// The count is also used in another expression.
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Sum, 1), agg(execinfrapb.SumInt, 3),
+			},
+			renderExprs: []string{"@1 / @2", "@2 + 1"},
+		},
+		{
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				distinct(agg(execinfrapb.Sum, 1)), agg(execinfrapb.SumInt, 3),
+			},
+			renderExprs: []string{"@1 / @2"},
+		},
+		{
+			// The sum of integers is not the final stage of avg.
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Sum, 0), agg(execinfrapb.SumInt, 3),
+			},
+			renderExprs: []string{"@1 / @2"},
+		},
+		{
+			aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				agg(execinfrapb.Sum, 1), agg(execinfrapb.Count, 3),
+			},
+			renderExprs: []string{"@1 / @2"},
+		},
+	} {
+		spec := &execinfrapb.AggregatorSpec{Aggregations: tc.aggregations}
+		aggArgs := &colexecagg.NewAggregatorArgs{InputTypes: inputTypes, Spec: spec, EvalCtx: &evalCtx}
+		var err error
+		aggArgs.Constructors, aggArgs.ConstArguments, aggArgs.OutputTypes, err = colexecagg.ProcessAggregations(
+			&evalCtx, nil /* semaCtx */, spec.Aggregations, inputTypes,
+		)
+		require.NoError(t, err)
+		post := &execinfrapb.PostProcessSpec{}
+		for _, expr := range tc.renderExprs {
+			post.RenderExprs = append(post.RenderExprs, execinfrapb.Expression{Expr: expr})
+		}
+		newPost := foldFinalAvgs(flowCtx, &evalCtx, args, aggArgs, post)
+		if tc.expectedAggregations == nil {
+			require.Equal(t, post, newPost, tc.renderExprs)
+			require.Equal(t, spec, aggArgs.Spec, tc.renderExprs)
+			continue
+		}
+		require.Equal(t, tc.expectedAggregations, aggArgs.Spec.Aggregations, tc.renderExprs)
+		require.Equal(t, len(tc.expectedAggregations), len(aggArgs.OutputTypes), tc.renderExprs)
+		for i, expr := range newPost.RenderExprs {
+			require.Equal(t, tc.expectedRenderExprs[i], expr.LocalExpr.String(), tc.renderExprs)
+		}
+		// The original specs must not be modified.
+		require.Equal(t, tc.aggregations, spec.Aggregations, tc.renderExprs)
+		require.Equal(t, tc.renderExprs[0], post.RenderExprs[0].Expr, tc.renderExprs)
+	}
+}
+
+// BenchmarkFinalAvg compares the final stage of the distributed avg when the
+// division is folded into the aggregator against the plan in which the sum and
+// the count are materialized and divided by a separate projection.
+func BenchmarkFinalAvg(b *testing.B) {
+	defer log.Scope(b).Close(b)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+	defer streamingMemAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	rng, _ := randutil.NewPseudoRand()
+	numInputRows := 64 * coldata.BatchSize()
+	const groupSize = 8
+	for _, sumType := range []*types.T{types.Decimal, types.Float} {
+		typs := []*types.T{types.Int, sumType, types.Int}
+		cols := make([]coldata.Vec, len(typs))
+		for i := range typs {
+			cols[i] = allocator.NewMemColumn(typs[i], numInputRows)
+		}
+		groups, counts := cols[0].Int64(), cols[2].Int64()
+		for i := 0; i < numInputRows; i++ {
+			groups[i] = int64(i / groupSize)
+			counts[i] = int64(1 + rng.Intn(groupSize))
+			// Use the partial sums of reasonable magnitude since the division
+			// of arbitrary decimals would dominate the benchmark.
+			partialSum := rng.Int63n(1000) * counts[i]
+			if sumType.Family() == types.DecimalFamily {
+				cols[1].Decimal()[i].SetInt64(partialSum)
+			} else {
+				cols[1].Float64()[i] = float64(partialSum)
+			}
+		}
+		source := colexectestutils.NewChunkingBatchSource(allocator, typs, cols, numInputRows)
+		renderExpr := "@2 / @3"
+		if sumType.Family() == types.FloatFamily {
+			renderExpr = "@2 / @3::FLOAT8"
+		}
+		aggSpec := &execinfrapb.AggregatorSpec{
+			Type:             execinfrapb.AggregatorSpec_NON_SCALAR,
+			GroupCols:        []uint32{0},
+			OrderedGroupCols: []uint32{0},
+			Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+				{Func: execinfrapb.AnyNotNull, ColIdx: []uint32{0}},
+				{Func: execinfrapb.Sum, ColIdx: []uint32{1}},
+				{Func: execinfrapb.SumInt, ColIdx: []uint32{2}},
+			},
+		}
+		post := execinfrapb.PostProcessSpec{
+			RenderExprs: []execinfrapb.Expression{{Expr: "@1"}, {Expr: renderExpr}},
+		}
+		for _, folded := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/folded=%t", sumType, folded), func(b *testing.B) {
+				b.SetBytes(int64(numInputRows * 8 * len(typs)))
+				for i := 0; i < b.N; i++ {
+					source.(colexecop.Resetter).Reset(ctx)
+					args := &colexecargs.NewColOperatorArgs{
+						Spec: &execinfrapb.ProcessorSpec{
+							Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+							Core:        execinfrapb.ProcessorCoreUnion{Aggregator: aggSpec},
+							ResultTypes: []*types.T{types.Int, sumType},
+						},
+						Inputs:              []colexecargs.OpWithMetaInfo{{Root: source}},
+						StreamingMemAccount: &streamingMemAcc,
+					}
+					if folded {
+						args.Spec.Post = post
+					} else {
+						// Plan the aggregator and the rendering separately so
+						// that the division isn't folded.
+						args.Spec.ResultTypes = []*types.T{types.Int, sumType, types.Int}
+						r, err := NewColOperator(ctx, flowCtx, args)
+						require.NoError(b, err)
+						args = &colexecargs.NewColOperatorArgs{
+							Spec: &execinfrapb.ProcessorSpec{
+								Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: args.Spec.ResultTypes}},
+								Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+								Post:        post,
+								ResultTypes: []*types.T{types.Int, sumType},
+							},
+							Inputs:              []colexecargs.OpWithMetaInfo{{Root: r.Root}},
+							StreamingMemAccount: &streamingMemAcc,
+						}
+					}
+					r, err := NewColOperator(ctx, flowCtx, args)
+					require.NoError(b, err)
+					r.Root.Init(ctx)
+					for r.Root.Next().Length() != 0 {
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestSortInputPruning verifies that the columns of the sorter's input that
+// are neither projected out nor used by the ordering are pruned before the
+// sort and that the sorter still produces the expected output.
+func TestSortInputPruning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &acc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	inputTypes := []*types.T{types.Int, types.String, types.Int, types.Bytes, types.Float}
+	inputTuples := colexectestutils.Tuples{
+		{1, "a", 3, "x", 0.5},
+		{2, "b", 1, "y", 1.5},
+		{3, nil, 2, nil, nil},
+		{4, "d", 1, "z", 2.5},
+		{nil, "e", nil, "w", 3.5},
+	}
+	asc := func(col uint32) execinfrapb.Ordering_Column {
+		return execinfrapb.Ordering_Column{ColIdx: col, Direction: execinfrapb.Ordering_Column_ASC}
+	}
+	desc := func(col uint32) execinfrapb.Ordering_Column {
+		return execinfrapb.Ordering_Column{ColIdx: col, Direction: execinfrapb.Ordering_Column_DESC}
+	}
+	for _, tc := range []struct {
+		desc     string
+		ordering []execinfrapb.Ordering_Column
+		post     execinfrapb.PostProcessSpec
+		// If prunedProjection is nil, the input shouldn't be pruned.
+		prunedProjection []uint32
+		prunedOrdering   []execinfrapb.Ordering_Column
+		prunedOutput     []uint32
+		expected         colexectestutils.Tuples
+	}{
+		{
+			desc:             "ordering column projected out",
+			ordering:         []execinfrapb.Ordering_Column{asc(2), desc(0)},
+			post:             execinfrapb.PostProcessSpec{Projection: true, OutputColumns: []uint32{4}},
+			prunedProjection: []uint32{0, 2, 4},
+			prunedOrdering:   []execinfrapb.Ordering_Column{asc(1), desc(0)},
+			prunedOutput:     []uint32{2},
+			expected:         colexectestutils.Tuples{{3.5}, {2.5}, {1.5}, {nil}, {0.5}},
+		},
+		{
+			desc:             "reordered and repeated output columns",
+			ordering:         []execinfrapb.Ordering_Column{desc(0)},
+			post:             execinfrapb.PostProcessSpec{Projection: true, OutputColumns: []uint32{3, 0, 3}},
+			prunedProjection: []uint32{0, 3},
+			prunedOrdering:   []execinfrapb.Ordering_Column{desc(0)},
+			prunedOutput:     []uint32{1, 0, 1},
+			expected: colexectestutils.Tuples{
+				{"z", 4, "z"}, {nil, 3, nil}, {"y", 2, "y"}, {"x", 1, "x"}, {"w", nil, "w"},
+			},
+		},
+		{
+			desc:     "all columns needed",
+			ordering: []execinfrapb.Ordering_Column{asc(1), asc(2)},
+			post:     execinfrapb.PostProcessSpec{Projection: true, OutputColumns: []uint32{4, 3, 0}},
+			expected: colexectestutils.Tuples{
+				{nil, nil, 3}, {0.5, "x", 1}, {1.5, "y", 2}, {2.5, "z", 4}, {3.5, "w", nil},
+			},
+		},
+		{
+			desc:     "no projection",
+			ordering: []execinfrapb.Ordering_Column{desc(4)},
+			post:     execinfrapb.PostProcessSpec{RenderExprs: []execinfrapb.Expression{{Expr: "@1"}}},
+			expected: colexectestutils.Tuples{{nil}, {4}, {2}, {1}, {3}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			// The post-processing spec must not be modified when the input is
+			// pruned.
+			post := tc.post
+			input := colexecop.NewFeedOperator()
+			op, typs, ordering := pruneSortInput(input, inputTypes, execinfrapb.Ordering{Columns: tc.ordering}, &post)
+			if tc.prunedProjection == nil {
+				require.Equal(t, input, op)
+				require.Equal(t, inputTypes, typs)
+				require.Equal(t, tc.ordering, ordering.Columns)
+				require.Equal(t, tc.post, post)
+			} else {
+				_, _, projection, ok := colexecbase.UnwrapSimpleProjectOp(op)
+				require.True(t, ok, "unexpected root %T", op)
+				require.Equal(t, tc.prunedProjection, projection)
+				require.Equal(t, len(tc.prunedProjection), len(typs))
+				require.Equal(t, tc.prunedOrdering, ordering.Columns)
+				require.Equal(t, tc.prunedOutput, post.OutputColumns)
+				require.NotEqual(t, tc.post.OutputColumns, post.OutputColumns)
+			}
+
+			outputTypes := make([]*types.T, 0, len(tc.post.OutputColumns))
+			for _, col := range tc.post.OutputColumns {
+				outputTypes = append(outputTypes, inputTypes[col])
+			}
+			if !tc.post.Projection {
+				outputTypes = []*types.T{types.Int}
+			}
+			var monitors []*mon.BytesMonitor
+			var accounts []*mon.BoundAccount
+			defer func() {
+				for _, acc := range accounts {
+					acc.Close(ctx)
+				}
+				for _, m := range monitors {
+					m.Stop(ctx)
+				}
+			}()
+			colexectestutils.RunTestsWithTyps(
+				t, allocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					args := &colexecargs.NewColOperatorArgs{
+						Spec: &execinfrapb.ProcessorSpec{
+							Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+							Core: execinfrapb.ProcessorCoreUnion{
+								Sorter: &execinfrapb.SorterSpec{
+									OutputOrdering: execinfrapb.Ordering{Columns: tc.ordering},
+								},
+							},
+							Post:        tc.post,
+							ResultTypes: outputTypes,
+						},
+						Inputs:              []colexecargs.OpWithMetaInfo{{Root: inputs[0]}},
+						StreamingMemAccount: &acc,
+					}
+					args.TestingKnobs.DiskSpillingDisabled = true
+					r, err := NewColOperator(ctx, flowCtx, args)
+					if err != nil {
+						return nil, err
+					}
+					monitors = append(monitors, r.OpMonitors...)
+					accounts = append(accounts, r.OpAccounts...)
+					return r.Root, nil
+				},
+			)
+		})
+	}
+}
+
+// TestSorterReleasesOutputBatch verifies that the in-memory sorter gives its
+// output batch back to the batch pool of the flow when it is closed and that
+// the batch is handed out to the sorter planned next.
+func TestSorterReleasesOutputBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	poolAcc := evalCtx.Mon.MakeBoundAccount()
+	defer poolAcc.Close(ctx)
+	pool := colmem.NewBatchPool(&poolAcc, math.MaxInt64)
+	defer pool.Close(ctx)
+	diskMonitor := execinfra.NewTestDiskMonitor(ctx, st)
+	defer diskMonitor.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx:     &evalCtx,
+		Cfg:         &execinfra.ServerConfig{Settings: st},
+		DiskMonitor: diskMonitor,
+	}
+	acc := evalCtx.Mon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &acc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	typs := []*types.T{types.Int}
+	tuples := make(colexectestutils.Tuples, coldata.BatchSize())
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{len(tuples) - i}
+	}
+	var monitors []*mon.BytesMonitor
+	var accounts []*mon.BoundAccount
+	defer func() {
+		for _, acc := range accounts {
+			acc.Close(ctx)
+		}
+		for _, m := range monitors {
+			m.Stop(ctx)
+		}
+	}()
+	opMemUsage := func(r *colexecargs.NewColOperatorResult) int64 {
+		var used int64
+		for _, acc := range r.OpAccounts {
+			used += acc.Used()
+		}
+		return used
+	}
+	// runSorter plans the sorter (which will be wrapped into the disk
+	// spiller), runs it to completion and returns the last output batch.
+	runSorter := func() (*colexecargs.NewColOperatorResult, coldata.Batch) {
+		args := &colexecargs.NewColOperatorArgs{
+			Spec: &execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+				Core: execinfrapb.ProcessorCoreUnion{
+					Sorter: &execinfrapb.SorterSpec{
+						OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+					},
+				},
+				ResultTypes: typs,
+			},
+			Inputs: []colexecargs.OpWithMetaInfo{{
+				Root: colexectestutils.NewOpTestInput(allocator, coldata.BatchSize(), tuples, typs),
+			}},
+			StreamingMemAccount: &acc,
+			FDSemaphore:         colexecop.NewTestingSemaphore(256 /* limit */),
+			BatchPool:           pool,
+		}
+		r, err := NewColOperator(ctx, flowCtx, args)
+		require.NoError(t, err)
+		monitors = append(monitors, r.OpMonitors...)
+		accounts = append(accounts, r.OpAccounts...)
+		r.Root.Init(ctx)
+		var output coldata.Batch
+		for b := r.Root.Next(); b.Length() > 0; b = r.Root.Next() {
+			output = b
+		}
+		require.NotNil(t, output)
+		return r, output
+	}
+
+	r, output := runSorter()
+	usedBeforeClose := opMemUsage(r)
+	require.NoError(t, r.ToClose.Close(ctx))
+	// The memory of the output batch is moved from the sorter's account to the
+	// pool's account.
+	outputMemSize := colmem.GetBatchMemSize(output)
+	require.Equal(t, outputMemSize, usedBeforeClose-opMemUsage(r))
+	require.Equal(t, outputMemSize, poolAcc.Used())
+
+	r, reusedOutput := runSorter()
+	defer func() { require.NoError(t, r.ToClose.Close(ctx)) }()
+	require.True(t, reusedOutput == output)
+	require.Zero(t, poolAcc.Used())
+}
+
+// BenchmarkSortInputPruning compares the sort of a wide input followed by a
+// narrow projection when the projected out columns are pruned before the sort
+// against the plan in which the sorter materializes all of them.
+func BenchmarkSortInputPruning(b *testing.B) {
+	defer log.Scope(b).Close(b)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+	defer streamingMemAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	rng, _ := randutil.NewPseudoRand()
+	numInputRows := 16 * coldata.BatchSize()
+	const numCols = 16
+	typs := make([]*types.T, numCols)
+	cols := make([]coldata.Vec, numCols)
+	for i := range typs {
+		typs[i] = types.Int
+		cols[i] = allocator.NewMemColumn(typs[i], numInputRows)
+		vals := cols[i].Int64()
+		for j := range vals[:numInputRows] {
+			vals[j] = rng.Int63()
+		}
+	}
+	source := colexectestutils.NewChunkingBatchSource(allocator, typs, cols, numInputRows)
+	sorterSpec := &execinfrapb.SorterSpec{
+		OutputOrdering: execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+	}
+	post := execinfrapb.PostProcessSpec{Projection: true, OutputColumns: []uint32{0, 1}}
+	for _, pruned := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pruned=%t", pruned), func(b *testing.B) {
+			b.SetBytes(int64(numInputRows * 8 * numCols))
+			for i := 0; i < b.N; i++ {
+				source.(colexecop.Resetter).Reset(ctx)
+				args := &colexecargs.NewColOperatorArgs{
+					Spec: &execinfrapb.ProcessorSpec{
+						Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+						Core:        execinfrapb.ProcessorCoreUnion{Sorter: sorterSpec},
+						ResultTypes: typs[:2],
+					},
+					Inputs:              []colexecargs.OpWithMetaInfo{{Root: source}},
+					StreamingMemAccount: &streamingMemAcc,
+				}
+				args.TestingKnobs.DiskSpillingDisabled = true
+				var sorterResult *colexecargs.NewColOperatorResult
+				if pruned {
+					args.Spec.Post = post
+				} else {
+					// Plan the sorter and the projection separately so that
+					// the sorter doesn't know about the projection.
+					args.Spec.ResultTypes = typs
+					var err error
+					sorterResult, err = NewColOperator(ctx, flowCtx, args)
+					require.NoError(b, err)
+					args = &colexecargs.NewColOperatorArgs{
+						Spec: &execinfrapb.ProcessorSpec{
+							Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+							Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+							Post:        post,
+							ResultTypes: typs[:2],
+						},
+						Inputs:              []colexecargs.OpWithMetaInfo{{Root: sorterResult.Root}},
+						StreamingMemAccount: &streamingMemAcc,
+					}
+				}
+				r, err := NewColOperator(ctx, flowCtx, args)
+				require.NoError(b, err)
+				r.Root.Init(ctx)
+				for r.Root.Next().Length() != 0 {
+				}
+				if sorterResult != nil {
+					r.OpAccounts = append(r.OpAccounts, sorterResult.OpAccounts...)
+					r.OpMonitors = append(r.OpMonitors, sorterResult.OpMonitors...)
+				}
+				for _, acc := range r.OpAccounts {
+					acc.Close(ctx)
+				}
+				for _, m := range r.OpMonitors {
+					m.Stop(ctx)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSharedWindowSpec compares row_number and rank sharing the window
+// spec computed in one pass over the partitioned input against the plan in
+// which each window function is computed by a separate windower.
+func BenchmarkSharedWindowSpec(b *testing.B) {
+	defer log.Scope(b).Close(b)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{EvalCtx: &evalCtx, Cfg: &execinfra.ServerConfig{Settings: st}}
+	streamingMemAcc := evalCtx.Mon.MakeBoundAccount()
+	defer streamingMemAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &streamingMemAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	rng, _ := randutil.NewPseudoRand()
+	numInputRows := 64 * coldata.BatchSize()
+	const partitionSize = 64
+	// The input is already ordered on the partitioning and the ordering
+	// columns so that the sort doesn't dominate the benchmark.
+	typs := []*types.T{types.Int, types.Int}
+	cols := []coldata.Vec{
+		allocator.NewMemColumn(typs[0], numInputRows), allocator.NewMemColumn(typs[1], numInputRows),
+	}
+	partitionCol, orderCol := cols[0].Int64(), cols[1].Int64()
+	for i := 0; i < numInputRows; i++ {
+		partitionCol[i] = int64(i / partitionSize)
+		if i%partitionSize == 0 {
+			orderCol[i] = 0
+		} else {
+			orderCol[i] = orderCol[i-1] + int64(rng.Intn(2))
+		}
+	}
+	source := colexectestutils.NewChunkingBatchSource(allocator, typs, cols, numInputRows)
+	inputOrdering := execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}, {ColIdx: 1}}}
+	windowFn := func(fn execinfrapb.WindowerSpec_WindowFunc, outputColIdx uint32) execinfrapb.WindowerSpec_WindowFn {
+		return execinfrapb.WindowerSpec_WindowFn{
+			Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &fn},
+			Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}}},
+			FilterColIdx: tree.NoColumnIdx,
+			OutputColIdx: outputColIdx,
+		}
+	}
+	rowNumber := windowFn(execinfrapb.WindowerSpec_ROW_NUMBER, 2)
+	rank := windowFn(execinfrapb.WindowerSpec_RANK, 3)
+	outputTypes := []*types.T{types.Int, types.Int, types.Int, types.Int}
+	for _, shared := range []bool{false, true} {
+		b.Run(fmt.Sprintf("shared=%t", shared), func(b *testing.B) {
+			b.SetBytes(int64(numInputRows * 8 * len(typs)))
+			for i := 0; i < b.N; i++ {
+				source.(colexecop.Resetter).Reset(ctx)
+				planWindower := func(
+					input colexecop.Operator, inputTypes []*types.T, windowFns ...execinfrapb.WindowerSpec_WindowFn,
+				) *colexecargs.NewColOperatorResult {
+					resultTypes := outputTypes[:len(inputTypes)+len(windowFns)]
+					r, err := NewColOperator(ctx, flowCtx, &colexecargs.NewColOperatorArgs{
+						Spec: &execinfrapb.ProcessorSpec{
+							Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes, Ordering: inputOrdering}},
+							Core: execinfrapb.ProcessorCoreUnion{
+								Windower: &execinfrapb.WindowerSpec{PartitionBy: []uint32{0}, WindowFns: windowFns},
+							},
+							ResultTypes: resultTypes,
+						},
+						Inputs:              []colexecargs.OpWithMetaInfo{{Root: input}},
+						StreamingMemAccount: &streamingMemAcc,
+					})
+					require.NoError(b, err)
+					return r
+				}
+				var results []*colexecargs.NewColOperatorResult
+				if shared {
+					results = append(results, planWindower(source, typs, rowNumber, rank))
+				} else {
+					r := planWindower(source, typs, rowNumber)
+					results = append(results, r, planWindower(r.Root, outputTypes[:3], rank))
+				}
+				root := results[len(results)-1].Root
+				root.Init(ctx)
+				for root.Next().Length() != 0 {
+				}
+				for _, r := range results {
+					for _, acc := range r.OpAccounts {
+						acc.Close(ctx)
+					}
+					for _, m := range r.OpMonitors {
+						m.Stop(ctx)
+					}
+				}
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/tdigest"
 	"github.com/cockroachdb/errors"
 )
 
@@ -30,22 +31,156 @@ import (
 func IsAggOptimized(aggFn execinfrapb.AggregatorSpec_Func) bool {
 	switch aggFn {
 	case execinfrapb.AnyNotNull,
+		execinfrapb.ApproxCountDistinct,
+		execinfrapb.ApproxPercentile,
 		execinfrapb.Avg,
 		execinfrapb.Sum,
 		execinfrapb.SumInt,
 		execinfrapb.ConcatAgg,
 		execinfrapb.CountRows,
 		execinfrapb.Count,
+		execinfrapb.JSONAgg,
+		execinfrapb.JSONBAgg,
+		execinfrapb.JSONObjectAgg,
+		execinfrapb.JSONBObjectAgg,
 		execinfrapb.Min,
 		execinfrapb.Max,
+		execinfrapb.ModeImpl,
 		execinfrapb.BoolAnd,
-		execinfrapb.BoolOr:
+		execinfrapb.BoolOr,
+		execinfrapb.BitAnd,
+		execinfrapb.BitOr,
+		execinfrapb.BitXor,
+		execinfrapb.Variance,
+		execinfrapb.VarPop,
+		execinfrapb.Stddev,
+		execinfrapb.StddevPop,
+		execinfrapb.Corr,
+		execinfrapb.CovarPop,
+		execinfrapb.CovarSamp,
+		execinfrapb.RegrAvgx,
+		execinfrapb.RegrAvgy,
+		execinfrapb.RegrIntercept,
+		execinfrapb.RegrR2,
+		execinfrapb.RegrSlope,
+		execinfrapb.RegrSxx,
+		execinfrapb.RegrSxy,
+		execinfrapb.RegrSyy:
 		return true
 	default:
 		return false
 	}
 }
 
+// isAggOptimizedForInput returns whether aggFn has an optimized implementation
+// for the given input types. It differs from IsAggOptimized only for the
+// bitwise aggregates which are optimized for integers but not for bit arrays,
+// for the variance and standard deviation aggregates which are optimized for
+// floats and decimals but not for integers, for the statistical aggregates
+// over two arguments which are optimized only for floats and 64-bit integers,
+// and for the JSON object aggregates which are optimized only for string keys
+// (the latter cases are handled by the default aggregate function).
+func isAggOptimizedForInput(
+	aggFn execinfrapb.AggregatorSpec_Aggregation, inputTypes []*types.T,
+) bool {
+	switch aggFn.Func {
+	case execinfrapb.BitAnd, execinfrapb.BitOr, execinfrapb.BitXor:
+		return inputTypes[aggFn.ColIdx[0]].Family() == types.IntFamily
+	case execinfrapb.Variance, execinfrapb.VarPop, execinfrapb.Stddev, execinfrapb.StddevPop:
+		switch inputTypes[aggFn.ColIdx[0]].Family() {
+		case types.FloatFamily, types.DecimalFamily:
+			return true
+		}
+		return false
+	case execinfrapb.Corr, execinfrapb.CovarPop, execinfrapb.CovarSamp,
+		execinfrapb.RegrAvgx, execinfrapb.RegrAvgy, execinfrapb.RegrIntercept,
+		execinfrapb.RegrR2, execinfrapb.RegrSlope, execinfrapb.RegrSxx,
+		execinfrapb.RegrSxy, execinfrapb.RegrSyy:
+		return isRegressionInputSupported(inputTypes[aggFn.ColIdx[0]]) &&
+			isRegressionInputSupported(inputTypes[aggFn.ColIdx[1]])
+	case execinfrapb.JSONObjectAgg, execinfrapb.JSONBObjectAgg:
+		return inputTypes[aggFn.ColIdx[0]].Family() == types.StringFamily
+	}
+	return IsAggOptimized(aggFn.Func)
+}
+
+// isRegressionInputSupported returns whether the statistical aggregates over
+// two arguments (like corr and regr_slope) have an optimized implementation
+// for the argument of type t.
+func isRegressionInputSupported(t *types.T) bool {
+	switch t.Family() {
+	case types.FloatFamily:
+		return true
+	case types.IntFamily:
+		return t.Width() == 64
+	}
+	return false
+}
+
+// datumArgColIdxs returns the indices of the columns with the arguments of
+// aggFn that need to be converted to datums. These are all of the arguments
+// of the default aggregate functions and the values of the JSON aggregates.
+func datumArgColIdxs(
+	aggFn execinfrapb.AggregatorSpec_Aggregation, inputTypes []*types.T,
+) []uint32 {
+	if !isAggOptimizedForInput(aggFn, inputTypes) {
+		return aggFn.ColIdx
+	}
+	switch aggFn.Func {
+	case execinfrapb.JSONAgg, execinfrapb.JSONBAgg:
+		return aggFn.ColIdx[:1]
+	case execinfrapb.JSONObjectAgg, execinfrapb.JSONBObjectAgg:
+		return aggFn.ColIdx[1:2]
+	}
+	return nil
+}
+
+// newBitAggAlloc returns the allocator of the bitwise aggregate function
+// aggFn over integers of type t.
+func newBitAggAlloc(
+	allocator *colmem.Allocator,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	t *types.T,
+	allocSize int64,
+	isHashAgg bool,
+) (aggregateFuncAlloc, error) {
+	switch aggFn {
+	case execinfrapb.BitAnd:
+		if isHashAgg {
+			return newBitAndHashAggAlloc(allocator, t, allocSize)
+		}
+		return newBitAndOrderedAggAlloc(allocator, t, allocSize)
+	case execinfrapb.BitOr:
+		if isHashAgg {
+			return newBitOrHashAggAlloc(allocator, t, allocSize)
+		}
+		return newBitOrOrderedAggAlloc(allocator, t, allocSize)
+	case execinfrapb.BitXor:
+		if isHashAgg {
+			return newBitXorHashAggAlloc(allocator, t, allocSize)
+		}
+		return newBitXorOrderedAggAlloc(allocator, t, allocSize)
+	}
+	return nil, errors.AssertionFailedf("unexpected bitwise aggregate function %s", aggFn)
+}
+
+// newVarianceAggAlloc returns the allocator of the variance or the standard
+// deviation aggregate function aggFn over the values of type t.
+func newVarianceAggAlloc(
+	allocator *colmem.Allocator,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	t *types.T,
+	allocSize int64,
+	isHashAgg bool,
+) (aggregateFuncAlloc, error) {
+	pop := aggFn == execinfrapb.VarPop || aggFn == execinfrapb.StddevPop
+	sqrt := aggFn == execinfrapb.Stddev || aggFn == execinfrapb.StddevPop
+	if isHashAgg {
+		return newVarianceHashAggAlloc(allocator, t, allocSize, pop, sqrt)
+	}
+	return newVarianceOrderedAggAlloc(allocator, t, allocSize, pop, sqrt)
+}
+
 // AggregateFunc is an aggregate function that performs computation on a batch
 // when Compute(batch) is called and writes the output to the Vec passed in
 // in SetOutput. The AggregateFunc performs an aggregation per group and outputs
@@ -206,27 +341,37 @@ func NewAggregateFuncsAlloc(
 	var toClose colexecop.Closers
 	var vecIdxsToConvert []int
 	for _, aggFn := range args.Spec.Aggregations {
-		if !IsAggOptimized(aggFn.Func) {
-			for _, vecIdx := range aggFn.ColIdx {
-				found := false
-				for i := range vecIdxsToConvert {
-					if vecIdxsToConvert[i] == int(vecIdx) {
-						found = true
-						break
-					}
-				}
-				if !found {
-					vecIdxsToConvert = append(vecIdxsToConvert, int(vecIdx))
+		for _, vecIdx := range datumArgColIdxs(aggFn, args.InputTypes) {
+			found := false
+			for i := range vecIdxsToConvert {
+				if vecIdxsToConvert[i] == int(vecIdx) {
+					found = true
+					break
 				}
 			}
+			if !found {
+				vecIdxsToConvert = append(vecIdxsToConvert, int(vecIdx))
+			}
 		}
 	}
 	var inputArgsConverter *colconv.VecToDatumConverter
 	if len(vecIdxsToConvert) > 0 {
 		// Only create the converter if we actually need to convert some vectors
-		// for the default aggregate functions.
+		// for the default or the JSON aggregate functions.
 		inputArgsConverter = colconv.NewVecToDatumConverter(len(args.InputTypes), vecIdxsToConvert, false /* willRelease */)
 	}
+	newDefaultAggAlloc := func(i int, aggFn execinfrapb.AggregatorSpec_Aggregation) aggregateFuncAlloc {
+		if isHashAgg {
+			return newDefaultHashAggAlloc(
+				args.Allocator, args.Constructors[i], args.EvalCtx, inputArgsConverter,
+				len(aggFn.ColIdx), args.ConstArguments[i], args.OutputTypes[i], allocSize,
+			)
+		}
+		return newDefaultOrderedAggAlloc(
+			args.Allocator, args.Constructors[i], args.EvalCtx, inputArgsConverter,
+			len(aggFn.ColIdx), args.ConstArguments[i], args.OutputTypes[i], allocSize,
+		)
+	}
 	for i, aggFn := range args.Spec.Aggregations {
 		var err error
 		switch aggFn.Func {
@@ -236,8 +381,34 @@ func NewAggregateFuncsAlloc(
 			} else {
 				funcAllocs[i], err = newAnyNotNullOrderedAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
 			}
-		case execinfrapb.Avg:
+		case execinfrapb.ApproxCountDistinct:
 			if isHashAgg {
+				funcAllocs[i], err = newApproxCountDistinctHashAggAlloc(
+					args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize, DefaultApproxCountDistinctPrecision,
+				)
+			} else {
+				funcAllocs[i], err = newApproxCountDistinctOrderedAggAlloc(
+					args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize, DefaultApproxCountDistinctPrecision,
+				)
+			}
+		case execinfrapb.ApproxPercentile:
+			if isHashAgg {
+				funcAllocs[i] = newApproxPercentileHashAggAlloc(args.Allocator, allocSize, tdigest.DefaultCompression)
+			} else {
+				funcAllocs[i] = newApproxPercentileOrderedAggAlloc(args.Allocator, allocSize, tdigest.DefaultCompression)
+			}
+		case execinfrapb.Avg:
+			if len(aggFn.ColIdx) == 2 {
+				// This is the final avg that divides the sum of the first
+				// argument by the sum of the second one. colbuilder plans it
+				// for the final stage of the distributed avg instead of the
+				// sum and sum_int aggregations followed by the division.
+				if isHashAgg {
+					funcAllocs[i], err = newAvgFinalHashAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
+				} else {
+					funcAllocs[i], err = newAvgFinalOrderedAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
+				}
+			} else if isHashAgg {
 				funcAllocs[i], err = newAvgHashAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
 			} else {
 				funcAllocs[i], err = newAvgOrderedAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
@@ -272,6 +443,23 @@ func NewAggregateFuncsAlloc(
 			} else {
 				funcAllocs[i] = newCountOrderedAggAlloc(args.Allocator, allocSize)
 			}
+		case execinfrapb.JSONAgg, execinfrapb.JSONBAgg:
+			if isHashAgg {
+				funcAllocs[i] = newJSONArrayHashAggAlloc(args.Allocator, inputArgsConverter, args.EvalCtx.GetLocation(), allocSize)
+			} else {
+				funcAllocs[i] = newJSONArrayOrderedAggAlloc(args.Allocator, inputArgsConverter, args.EvalCtx.GetLocation(), allocSize)
+			}
+		case execinfrapb.JSONObjectAgg, execinfrapb.JSONBObjectAgg:
+			if !isAggOptimizedForInput(aggFn, args.InputTypes) {
+				funcAllocs[i] = newDefaultAggAlloc(i, aggFn)
+				toClose = append(toClose, funcAllocs[i].(colexecop.Closer))
+				break
+			}
+			if isHashAgg {
+				funcAllocs[i] = newJSONObjectHashAggAlloc(args.Allocator, inputArgsConverter, args.EvalCtx.GetLocation(), allocSize)
+			} else {
+				funcAllocs[i] = newJSONObjectOrderedAggAlloc(args.Allocator, inputArgsConverter, args.EvalCtx.GetLocation(), allocSize)
+			}
 		case execinfrapb.Min:
 			if isHashAgg {
 				funcAllocs[i] = newMinHashAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
@@ -284,6 +472,12 @@ func NewAggregateFuncsAlloc(
 			} else {
 				funcAllocs[i] = newMaxOrderedAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
 			}
+		case execinfrapb.ModeImpl:
+			if isHashAgg {
+				funcAllocs[i], err = newModeHashAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
+			} else {
+				funcAllocs[i], err = newModeOrderedAggAlloc(args.Allocator, args.InputTypes[aggFn.ColIdx[0]], allocSize)
+			}
 		case execinfrapb.BoolAnd:
 			if isHashAgg {
 				funcAllocs[i] = newBoolAndHashAggAlloc(args.Allocator, allocSize)
@@ -296,21 +490,44 @@ func NewAggregateFuncsAlloc(
 			} else {
 				funcAllocs[i] = newBoolOrOrderedAggAlloc(args.Allocator, allocSize)
 			}
+		case execinfrapb.BitAnd, execinfrapb.BitOr, execinfrapb.BitXor:
+			if !isAggOptimizedForInput(aggFn, args.InputTypes) {
+				funcAllocs[i] = newDefaultAggAlloc(i, aggFn)
+				toClose = append(toClose, funcAllocs[i].(colexecop.Closer))
+				break
+			}
+			funcAllocs[i], err = newBitAggAlloc(
+				args.Allocator, aggFn.Func, args.InputTypes[aggFn.ColIdx[0]], allocSize, isHashAgg,
+			)
+		case execinfrapb.Variance, execinfrapb.VarPop, execinfrapb.Stddev, execinfrapb.StddevPop:
+			if !isAggOptimizedForInput(aggFn, args.InputTypes) {
+				funcAllocs[i] = newDefaultAggAlloc(i, aggFn)
+				toClose = append(toClose, funcAllocs[i].(colexecop.Closer))
+				break
+			}
+			funcAllocs[i], err = newVarianceAggAlloc(
+				args.Allocator, aggFn.Func, args.InputTypes[aggFn.ColIdx[0]], allocSize, isHashAgg,
+			)
+		case execinfrapb.Corr, execinfrapb.CovarPop, execinfrapb.CovarSamp,
+			execinfrapb.RegrAvgx, execinfrapb.RegrAvgy, execinfrapb.RegrIntercept,
+			execinfrapb.RegrR2, execinfrapb.RegrSlope, execinfrapb.RegrSxx,
+			execinfrapb.RegrSxy, execinfrapb.RegrSyy:
+			if !isAggOptimizedForInput(aggFn, args.InputTypes) {
+				funcAllocs[i] = newDefaultAggAlloc(i, aggFn)
+				toClose = append(toClose, funcAllocs[i].(colexecop.Closer))
+				break
+			}
+			yType, xType := args.InputTypes[aggFn.ColIdx[0]], args.InputTypes[aggFn.ColIdx[1]]
+			if isHashAgg {
+				funcAllocs[i], err = newRegressionHashAggAlloc(args.Allocator, aggFn.Func, yType, xType, allocSize)
+			} else {
+				funcAllocs[i], err = newRegressionOrderedAggAlloc(args.Allocator, aggFn.Func, yType, xType, allocSize)
+			}
 		// NOTE: if you're adding an implementation of a new aggregate
 		// function, make sure to account for the memory under that struct in
 		// its constructor.
 		default:
-			if isHashAgg {
-				funcAllocs[i] = newDefaultHashAggAlloc(
-					args.Allocator, args.Constructors[i], args.EvalCtx, inputArgsConverter,
-					len(aggFn.ColIdx), args.ConstArguments[i], args.OutputTypes[i], allocSize,
-				)
-			} else {
-				funcAllocs[i] = newDefaultOrderedAggAlloc(
-					args.Allocator, args.Constructors[i], args.EvalCtx, inputArgsConverter,
-					len(aggFn.ColIdx), args.ConstArguments[i], args.OutputTypes[i], allocSize,
-				)
-			}
+			funcAllocs[i] = newDefaultAggAlloc(i, aggFn)
 			toClose = append(toClose, funcAllocs[i].(colexecop.Closer))
 		}
 
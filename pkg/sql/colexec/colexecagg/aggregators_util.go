@@ -11,12 +11,24 @@
 package colexecagg
 
 import (
+	"math"
+	"unsafe"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colencoding"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
 )
 
 // NewAggregatorArgs encompasses all arguments necessary to instantiate either
@@ -34,3 +46,211 @@ type NewAggregatorArgs struct {
 	ConstArguments []tree.Datums
 	OutputTypes    []*types.T
 }
+
+// DefaultApproxCountDistinctPrecision is the precision of the HyperLogLog
+// sketches used by approx_count_distinct aggregate. It matches the precision
+// used by the row-by-row implementation of the aggregate.
+const DefaultApproxCountDistinctPrecision = 14
+
+// checkApproxCountDistinctPrecision returns an error if the HyperLogLog
+// sketches of the given precision are not supported.
+func checkApproxCountDistinctPrecision(precision uint8) error {
+	if precision != 14 && precision != 16 {
+		return errors.AssertionFailedf("unsupported approx_count_distinct precision %d", precision)
+	}
+	return nil
+}
+
+// newApproxCountDistinctSketch returns a new empty HyperLogLog sketch of the
+// given precision.
+func newApproxCountDistinctSketch(precision uint8) *hyperloglog.Sketch {
+	if precision == 16 {
+		return hyperloglog.New16()
+	}
+	return hyperloglog.New14()
+}
+
+// approxCountDistinctSketchSize returns the upper bound on the memory used by
+// a HyperLogLog sketch of the given precision.
+func approxCountDistinctSketchSize(precision uint8) int64 {
+	return int64(1) << precision
+}
+
+// aggFingerprint writes into buf the fingerprint of the value at position idx
+// of vec (of type typ), and it returns the updated buf and scratch. The values
+// that are equal in SQL have the same fingerprints. The fingerprints are the
+// same as the ones used by the row-by-row implementations of the aggregates
+// (the ascending key encoding or, for JSON, the value encoding), so both
+// engines produce the same results.
+func aggFingerprint(
+	buf, scratch []byte, vec coldata.Vec, idx int, typ *types.T,
+) ([]byte, []byte) {
+	var err error
+	if typ.Family() == types.JsonFamily {
+		scratch, err = json.EncodeJSON(scratch[:0], vec.JSON().Get(idx))
+		if err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		return encoding.EncodeJSONValue(buf[:0], encoding.NoColumnID, scratch), scratch
+	}
+	buf, err = colencoding.EncodeTableKeyFromCol(buf[:0], vec, idx, typ, encoding.Ascending)
+	if err != nil {
+		colexecerror.ExpectedError(err)
+	}
+	return buf, scratch
+}
+
+// modeEntry describes a distinct value seen by the mode aggregate function
+// within a group.
+type modeEntry struct {
+	// count is the number of times the value has been seen.
+	count int
+	// ordinal is the position of the value among the distinct values of the
+	// group in the order in which they have been first seen.
+	ordinal int
+}
+
+// modeEntryOverhead is the memory footprint of a single entry in the map of
+// the mode aggregate function, without the size of the key. The map overhead
+// is a guess, the same as the one used by the hash aggregator of the
+// row-by-row engine.
+const modeEntryOverhead = int64(unsafe.Sizeof(modeEntry{})) + int64(unsafe.Sizeof(&modeEntry{})) + 64
+
+// modeFloatKey returns the key of the float in the map of the mode aggregate
+// function. The floats that are equal in SQL (all NaNs as well as the positive
+// and the negative zeros) have the same keys.
+func modeFloatKey(f float64) uint64 {
+	switch {
+	case math.IsNaN(f):
+		return math.Float64bits(math.NaN())
+	case f == 0:
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// checkApproxPercentileFraction panics with an expected error if the fraction
+// of the percentile estimated by approx_percentile aggregate is not within
+// [0, 1]. The error is the same as the one of the row-by-row implementation.
+func checkApproxPercentileFraction(fraction float64) {
+	if fraction < 0 || fraction > 1.0 {
+		colexecerror.ExpectedError(pgerror.Newf(pgcode.NumericValueOutOfRange,
+			"percentile value %f is not between 0 and 1", fraction))
+	}
+}
+
+// regressionAccumulator contains the transition values of the statistical
+// aggregate functions over two arguments (like corr and regr_slope): the
+// number of rows and the co-moments of the dependent (Y) and the independent
+// (X) variables. The values are updated using the Youngs-Cramer algorithm in
+// exactly the same way as in the row-by-row implementation.
+type regressionAccumulator struct {
+	n   float64
+	sx  float64
+	sxx float64
+	sy  float64
+	syy float64
+	sxy float64
+}
+
+// add incorporates the pair (y, x) into the transition values. It panics with
+// an expected error if finite inputs lead to infinite results.
+func (a *regressionAccumulator) add(y, x float64) {
+	n, sx, sxx, sy, syy, sxy := a.n+1, a.sx+x, a.sxx, a.sy+y, a.syy, a.sxy
+	if a.n > 0 {
+		tmpX := x*n - sx
+		tmpY := y*n - sy
+		scale := 1.0 / (n * a.n)
+		sxx += tmpX * tmpX * scale
+		syy += tmpY * tmpY * scale
+		sxy += tmpX * tmpY * scale
+		if math.IsInf(sx, 0) || math.IsInf(sxx, 0) || math.IsInf(sy, 0) || math.IsInf(syy, 0) || math.IsInf(sxy, 0) {
+			if ((math.IsInf(sx, 0) || math.IsInf(sxx, 0)) &&
+				!math.IsInf(a.sx, 0) && !math.IsInf(x, 0)) ||
+				((math.IsInf(sy, 0) || math.IsInf(syy, 0)) &&
+					!math.IsInf(a.sy, 0) && !math.IsInf(y, 0)) ||
+				(math.IsInf(sxy, 0) &&
+					!math.IsInf(a.sx, 0) && !math.IsInf(x, 0) &&
+					!math.IsInf(a.sy, 0) && !math.IsInf(y, 0)) {
+				colexecerror.ExpectedError(tree.ErrFloatOutOfRange)
+			}
+			// The dependent sums should be NaN if any of the relevant inputs
+			// are infinite.
+			if math.IsInf(sxx, 0) {
+				sxx = math.NaN()
+			}
+			if math.IsInf(syy, 0) {
+				syy = math.NaN()
+			}
+			if math.IsInf(sxy, 0) {
+				sxy = math.NaN()
+			}
+		}
+	} else {
+		// If the first input is Inf or NaN, the dependent sums are forced to
+		// NaN; otherwise we would falsely report zero variance when there are
+		// no more inputs.
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			sxx = math.NaN()
+			sxy = math.NaN()
+		}
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			syy = math.NaN()
+			sxy = math.NaN()
+		}
+	}
+	*a = regressionAccumulator{n: n, sx: sx, sxx: sxx, sy: sy, syy: syy, sxy: sxy}
+}
+
+// result returns the value of the statistical aggregate function aggFn
+// computed from the transition values. ok is false if the result is NULL.
+func (a *regressionAccumulator) result(aggFn execinfrapb.AggregatorSpec_Func) (_ float64, ok bool) {
+	if a.n < 1 {
+		return 0, false
+	}
+	switch aggFn {
+	case execinfrapb.Corr:
+		if a.sxx == 0 || a.syy == 0 {
+			return 0, false
+		}
+		return a.sxy / math.Sqrt(a.sxx*a.syy), true
+	case execinfrapb.CovarPop:
+		return a.sxy / a.n, true
+	case execinfrapb.CovarSamp:
+		if a.n < 2 {
+			return 0, false
+		}
+		return a.sxy / (a.n - 1), true
+	case execinfrapb.RegrAvgx:
+		return a.sx / a.n, true
+	case execinfrapb.RegrAvgy:
+		return a.sy / a.n, true
+	case execinfrapb.RegrIntercept:
+		if a.sxx == 0 {
+			return 0, false
+		}
+		return (a.sy - a.sx*a.sxy/a.sxx) / a.n, true
+	case execinfrapb.RegrR2:
+		if a.sxx == 0 {
+			return 0, false
+		}
+		if a.syy == 0 {
+			return 1, true
+		}
+		return (a.sxy * a.sxy) / (a.sxx * a.syy), true
+	case execinfrapb.RegrSlope:
+		if a.sxx == 0 {
+			return 0, false
+		}
+		return a.sxy / a.sxx, true
+	case execinfrapb.RegrSxx:
+		return a.sxx, true
+	case execinfrapb.RegrSxy:
+		return a.sxy, true
+	case execinfrapb.RegrSyy:
+		return a.syy, true
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unexpected statistical aggregate function %s", aggFn))
+	// This code is unreachable, but the compiler cannot infer that.
+	return 0, false
+}
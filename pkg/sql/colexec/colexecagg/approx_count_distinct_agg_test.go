@@ -0,0 +1,116 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecagg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApproxCountDistinctErrorBound verifies that the estimates of the
+// approx_count_distinct aggregate functions on the inputs with known
+// cardinalities are within the error bound of the HyperLogLog sketches.
+func TestApproxCountDistinctErrorBound(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	defer testMemMonitor.Stop(ctx)
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	testAllocator := colmem.NewAllocator(ctx, &memAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	_, err := newApproxCountDistinctHashAggAlloc(testAllocator, types.Int, 1 /* allocSize */, 10 /* precision */)
+	require.Error(t, err)
+
+	for _, precision := range []uint8{14, 16} {
+		// The standard error of the HyperLogLog estimates is 1.04/sqrt(m)
+		// where m is the number of registers, and we allow for three standard
+		// errors (the estimates are deterministic, so the test isn't flaky).
+		maxRelativeError := 3 * 1.04 / math.Sqrt(float64(int(1)<<precision))
+		for _, typ := range []*types.T{types.Int, types.String} {
+			for _, cardinality := range []int{1, 10, 1000, 10000, 100000} {
+				// Every value is included twice, and there are some NULLs.
+				nRows := 3 * cardinality
+				vec := testAllocator.NewMemColumn(typ, nRows)
+				for i := 0; i < nRows; i++ {
+					switch {
+					case i%3 == 2:
+						vec.Nulls().SetNull(i)
+					case typ.Family() == types.IntFamily:
+						vec.Int64()[i] = int64(i / 3)
+					default:
+						vec.Bytes().Set(i, []byte(fmt.Sprintf("value%d", i/3)))
+					}
+				}
+				for _, isHashAgg := range []bool{false, true} {
+					var alloc aggregateFuncAlloc
+					if isHashAgg {
+						alloc, err = newApproxCountDistinctHashAggAlloc(testAllocator, typ, 1 /* allocSize */, precision)
+					} else {
+						alloc, err = newApproxCountDistinctOrderedAggAlloc(testAllocator, typ, 1 /* allocSize */, precision)
+					}
+					require.NoError(t, err)
+					f := alloc.newAggFunc()
+					output := testAllocator.NewMemColumn(types.Int, 1)
+					f.SetOutput(output)
+					groups := make([]bool, coldata.BatchSize())
+					groups[0] = true
+					f.Init(groups)
+					sel := make([]int, coldata.BatchSize())
+					for batchStart := 0; batchStart < nRows; batchStart += coldata.BatchSize() {
+						inputLen := nRows - batchStart
+						if inputLen > coldata.BatchSize() {
+							inputLen = coldata.BatchSize()
+						}
+						window := vec.Window(batchStart, batchStart+inputLen)
+						if isHashAgg {
+							// The hash aggregator always uses the selection
+							// vector.
+							for i := 0; i < inputLen; i++ {
+								sel[i] = i
+							}
+							f.Compute([]coldata.Vec{window}, []uint32{0}, inputLen, sel)
+						} else {
+							f.Compute([]coldata.Vec{window}, []uint32{0}, inputLen, nil /* sel */)
+							groups[0] = false
+						}
+					}
+					f.Flush(0 /* outputIdx */)
+					estimate := output.Int64()[0]
+					relativeError := math.Abs(float64(estimate)-float64(cardinality)) / float64(cardinality)
+					require.LessOrEqualf(
+						t, relativeError, maxRelativeError,
+						"precision=%d/type=%s/cardinality=%d/hash=%t: estimated %d",
+						precision, typ, cardinality, isHashAgg, estimate,
+					)
+				}
+			}
+		}
+	}
+}
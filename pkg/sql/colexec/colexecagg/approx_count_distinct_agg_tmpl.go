@@ -0,0 +1,215 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for approx_count_distinct_agg.eg.go. It's
+// formatted in a special way, so it's both valid Go and a valid text/template
+// input. This permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"unsafe"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+func newApproxCountDistinct_AGGKINDAggAlloc(
+	allocator *colmem.Allocator, inputType *types.T, allocSize int64, precision uint8,
+) (aggregateFuncAlloc, error) {
+	if err := checkApproxCountDistinctPrecision(precision); err != nil {
+		return nil, err
+	}
+	return &approxCountDistinct_AGGKINDAggAlloc{
+		aggAllocBase: aggAllocBase{
+			allocator: allocator,
+			allocSize: allocSize,
+		},
+		inputType: inputType,
+		precision: precision,
+	}, nil
+}
+
+// approxCountDistinct_AGGKINDAgg estimates the number of distinct non-NULL
+// values in each group using a HyperLogLog sketch.
+type approxCountDistinct_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	inputType *types.T
+	precision uint8
+	// sketch is the HyperLogLog sketch of the group that is currently being
+	// aggregated.
+	sketch *hyperloglog.Sketch
+	// col points to the output vector we are updating.
+	col []int64
+	// buf and scratch are reused when computing the fingerprints of the
+	// values.
+	buf, scratch []byte
+}
+
+var _ AggregateFunc = &approxCountDistinct_AGGKINDAgg{}
+
+func (a *approxCountDistinct_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec.Int64()
+}
+
+func (a *approxCountDistinct_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	vec := vecs[inputIdxs[0]]
+	nulls := vec.Nulls()
+	// Note that the sketches are accounted for upfront, so we don't need to
+	// adjust the memory usage here.
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			if nulls.MaybeHasNulls() {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_APPROX_COUNT_DISTINCT(a, vec, nulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_APPROX_COUNT_DISTINCT(a, vec, nulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if nulls.MaybeHasNulls() {
+				for _, i := range sel {
+					_ACCUMULATE_APPROX_COUNT_DISTINCT(a, vec, nulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_APPROX_COUNT_DISTINCT(a, vec, nulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+}
+
+func (a *approxCountDistinct_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	a.col[outputIdx] = int64(a.sketch.Estimate())
+}
+
+// {{if eq "_AGGKIND" "Ordered"}}
+func (a *approxCountDistinct_AGGKINDAgg) HandleEmptyInputScalar() {
+	// Similar to COUNT aggregates, approx_count_distinct returns zero in case
+	// of an empty input in the scalar context.
+	a.col[0] = 0
+}
+
+// {{end}}
+
+func (a *approxCountDistinct_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.sketch = newApproxCountDistinctSketch(a.precision)
+}
+
+type approxCountDistinct_AGGKINDAggAlloc struct {
+	aggAllocBase
+	inputType *types.T
+	precision uint8
+	aggFuncs  []approxCountDistinct_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &approxCountDistinct_AGGKINDAggAlloc{}
+
+const sizeOfApproxCountDistinct_AGGKINDAgg = int64(unsafe.Sizeof(approxCountDistinct_AGGKINDAgg{}))
+const approxCountDistinct_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]approxCountDistinct_AGGKINDAgg{}))
+
+func (a *approxCountDistinct_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(approxCountDistinct_AGGKINDAggSliceOverhead + sizeOfApproxCountDistinct_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]approxCountDistinct_AGGKINDAgg, a.allocSize)
+	}
+	// Every function holds on to a single sketch at any point in time, so we
+	// account for its (maximum) size once, when the function is created.
+	a.allocator.AdjustMemoryUsage(approxCountDistinctSketchSize(a.precision))
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.inputType = a.inputType
+	f.precision = a.precision
+	f.sketch = newApproxCountDistinctSketch(a.precision)
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{/*
+func _ACCUMULATE_APPROX_COUNT_DISTINCT(
+	a *approxCountDistinct_AGGKINDAgg,
+	vec coldata.Vec,
+	nulls *coldata.Nulls,
+	i int,
+	_HAS_NULLS bool,
+	_HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateApproxCountDistinct"}}
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			a.col[a.curIdx] = int64(a.sketch.Estimate())
+			a.curIdx++
+			a.sketch = newApproxCountDistinctSketch(a.precision)
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = nulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		a.buf, a.scratch = aggFingerprint(a.buf, a.scratch, vec, i, a.inputType)
+		a.sketch.Insert(a.buf)
+	}
+	// {{end}}
+	// {{/*
+} // */}}
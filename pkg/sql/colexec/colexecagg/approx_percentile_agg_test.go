@@ -0,0 +1,229 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecagg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tdigest"
+	"github.com/stretchr/testify/require"
+)
+
+// computeApproxPercentile feeds all rows of the value and fraction vectors,
+// batch by batch, into the aggregate function f. Every row of the input
+// starts a new group if newGroupEvery is positive, and f must be an ordered
+// aggregate function in that case.
+func computeApproxPercentile(
+	f AggregateFunc, isHashAgg bool, values, fractions coldata.Vec, nRows int, newGroupEvery int,
+) {
+	groups := make([]bool, coldata.BatchSize())
+	f.Init(groups)
+	sel := make([]int, coldata.BatchSize())
+	for batchStart := 0; batchStart < nRows; batchStart += coldata.BatchSize() {
+		inputLen := nRows - batchStart
+		if inputLen > coldata.BatchSize() {
+			inputLen = coldata.BatchSize()
+		}
+		for i := 0; i < inputLen; i++ {
+			groups[i] = batchStart+i == 0 || (newGroupEvery > 0 && (batchStart+i)%newGroupEvery == 0)
+		}
+		vecs := []coldata.Vec{
+			values.Window(batchStart, batchStart+inputLen),
+			fractions.Window(batchStart, batchStart+inputLen),
+		}
+		if isHashAgg {
+			// The hash aggregator always uses the selection vector.
+			for i := 0; i < inputLen; i++ {
+				sel[i] = i
+			}
+			f.Compute(vecs, []uint32{0, 1}, inputLen, sel)
+		} else {
+			f.Compute(vecs, []uint32{0, 1}, inputLen, nil /* sel */)
+		}
+	}
+	f.Flush(0 /* outputIdx */)
+}
+
+func newApproxPercentileTestAllocator() (*colmem.Allocator, func()) {
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	memAcc := testMemMonitor.MakeBoundAccount()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	allocator := colmem.NewAllocator(ctx, &memAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+	return allocator, func() {
+		evalCtx.Stop(ctx)
+		memAcc.Close(ctx)
+		testMemMonitor.Stop(ctx)
+	}
+}
+
+// TestApproxPercentileErrorBound verifies that the estimates of the
+// approx_percentile aggregate functions on a known distribution are within the
+// error tolerance of the exact percentiles.
+func TestApproxPercentileErrorBound(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testAllocator, cleanup := newApproxPercentileTestAllocator()
+	defer cleanup()
+	rng, _ := randutil.NewPseudoRand()
+
+	// The input is a random permutation of integers in [0, n) interleaved
+	// with some NULLs, so the exact q-percentile is q * (n - 1).
+	const n = 100000
+	nRows := n + n/10
+	values := testAllocator.NewMemColumn(types.Float, nRows)
+	perm := rng.Perm(n)
+	for i, j := 0, 0; i < nRows; i++ {
+		if i%11 == 10 {
+			values.Nulls().SetNull(i)
+			continue
+		}
+		values.Float64()[i] = float64(perm[j])
+		j++
+	}
+	for _, fraction := range []float64{0, 0.001, 0.01, 0.25, 0.5, 0.9, 0.99, 0.999, 1} {
+		fractions := testAllocator.NewMemColumn(types.Float, nRows)
+		for i := 0; i < nRows; i++ {
+			fractions.Float64()[i] = fraction
+		}
+		for _, isHashAgg := range []bool{false, true} {
+			var alloc aggregateFuncAlloc
+			if isHashAgg {
+				alloc = newApproxPercentileHashAggAlloc(testAllocator, 1 /* allocSize */, tdigest.DefaultCompression)
+			} else {
+				alloc = newApproxPercentileOrderedAggAlloc(testAllocator, 1 /* allocSize */, tdigest.DefaultCompression)
+			}
+			f := alloc.newAggFunc()
+			output := testAllocator.NewMemColumn(types.Float, 1)
+			f.SetOutput(output)
+			computeApproxPercentile(f, isHashAgg, values, fractions, nRows, 0 /* newGroupEvery */)
+			require.False(t, output.Nulls().NullAt(0))
+			estimate, exact := output.Float64()[0], fraction*(n-1)
+			// The values are the same as their ranks, so we allow for the
+			// error of 0.5% of the ranks.
+			require.LessOrEqualf(
+				t, math.Abs(estimate-exact), 0.005*n,
+				"fraction=%f/hash=%t: estimated %f", fraction, isHashAgg, estimate,
+			)
+		}
+	}
+}
+
+// TestApproxPercentileMemoryIsBounded verifies that the memory used by the
+// approx_percentile aggregate functions is bounded regardless of the number of
+// distinct values in a group and that it is proportional to the number of
+// values when the groups are small.
+func TestApproxPercentileMemoryIsBounded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testAllocator, cleanup := newApproxPercentileTestAllocator()
+	defer cleanup()
+	rng, _ := randutil.NewPseudoRand()
+
+	const nRows = 1000000
+	values := testAllocator.NewMemColumn(types.Float, nRows)
+	fractions := testAllocator.NewMemColumn(types.Float, nRows)
+	for i := 0; i < nRows; i++ {
+		values.Float64()[i] = rng.Float64()
+		fractions.Float64()[i] = 0.5
+	}
+	output := testAllocator.NewMemColumn(types.Float, nRows)
+	maxDigestMemUsage := tdigest.MaxMemUsage(tdigest.DefaultCompression)
+
+	for _, tc := range []struct {
+		isHashAgg     bool
+		newGroupEvery int
+	}{
+		// A single group with all distinct values.
+		{isHashAgg: false},
+		{isHashAgg: true},
+		// A lot of groups, each with all distinct values. The ordered
+		// aggregate function reuses the same digest for all groups.
+		{isHashAgg: false, newGroupEvery: 1000},
+		{isHashAgg: false, newGroupEvery: 3},
+	} {
+		t.Run(fmt.Sprintf("hash=%t/newGroupEvery=%d", tc.isHashAgg, tc.newGroupEvery), func(t *testing.T) {
+			var alloc aggregateFuncAlloc
+			// The bound includes the memory of the aggregate function itself.
+			maxMemUsage := maxDigestMemUsage
+			if tc.isHashAgg {
+				alloc = newApproxPercentileHashAggAlloc(testAllocator, 1 /* allocSize */, tdigest.DefaultCompression)
+				maxMemUsage += approxPercentileHashAggSliceOverhead + sizeOfApproxPercentileHashAgg
+			} else {
+				alloc = newApproxPercentileOrderedAggAlloc(testAllocator, 1 /* allocSize */, tdigest.DefaultCompression)
+				maxMemUsage += approxPercentileOrderedAggSliceOverhead + sizeOfApproxPercentileOrderedAgg
+			}
+			before := testAllocator.Used()
+			f := alloc.newAggFunc()
+			f.SetOutput(output)
+			computeApproxPercentile(f, tc.isHashAgg, values, fractions, nRows, tc.newGroupEvery)
+			require.LessOrEqual(t, testAllocator.Used()-before, maxMemUsage)
+			testAllocator.ReleaseMemory(testAllocator.Used() - before)
+		})
+	}
+
+	t.Run("hash aggregation with small groups", func(t *testing.T) {
+		// Every group has only a few values, so its digest must be small.
+		const numGroups, groupSize = 10000, 3
+		alloc := newApproxPercentileHashAggAlloc(testAllocator, 128 /* allocSize */, tdigest.DefaultCompression)
+		before := testAllocator.Used()
+		for g := 0; g < numGroups; g++ {
+			f := alloc.newAggFunc()
+			f.SetOutput(output)
+			computeApproxPercentile(f, true /* isHashAgg */, values.Window(g*groupSize, (g+1)*groupSize),
+				fractions.Window(g*groupSize, (g+1)*groupSize), groupSize, 0, /* newGroupEvery */
+			)
+		}
+		perGroup := (testAllocator.Used() - before) / numGroups
+		require.Less(t, perGroup, maxDigestMemUsage/10)
+		testAllocator.ReleaseMemory(testAllocator.Used() - before)
+	})
+}
+
+func TestApproxPercentileInvalidFraction(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testAllocator, cleanup := newApproxPercentileTestAllocator()
+	defer cleanup()
+
+	values := testAllocator.NewMemColumn(types.Float, 2)
+	fractions := testAllocator.NewMemColumn(types.Float, 2)
+	// The fraction in a row with NULL value is ignored.
+	values.Nulls().SetNull(0)
+	fractions.Float64()[0] = 0.5
+	fractions.Float64()[1] = 1.5
+	alloc := newApproxPercentileOrderedAggAlloc(testAllocator, 1 /* allocSize */, tdigest.DefaultCompression)
+	f := alloc.newAggFunc()
+	f.SetOutput(testAllocator.NewMemColumn(types.Float, 1))
+	err := colexecerror.CatchVectorizedRuntimeError(func() {
+		computeApproxPercentile(f, false /* isHashAgg */, values, fractions, 2 /* nRows */, 0 /* newGroupEvery */)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "percentile value 1.500000 is not between 0 and 1")
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for approx_percentile_agg.eg.go. It's
+// formatted in a special way, so it's both valid Go and a valid text/template
+// input. This permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/util/tdigest"
+)
+
+func newApproxPercentile_AGGKINDAggAlloc(
+	allocator *colmem.Allocator, allocSize int64, compression float64,
+) aggregateFuncAlloc {
+	return &approxPercentile_AGGKINDAggAlloc{
+		aggAllocBase: aggAllocBase{
+			allocator: allocator,
+			allocSize: allocSize,
+		},
+		compression: compression,
+	}
+}
+
+// approxPercentile_AGGKINDAgg estimates the percentile of the values in each
+// group using a t-digest. The first argument is the value, the second is the
+// fraction of the percentile which is read from the first row of the group in
+// which neither argument is NULL.
+type approxPercentile_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	// digest is the t-digest of the group that is currently being aggregated.
+	digest *tdigest.TDigest
+	// accounted is the memory of the digest that has already been registered
+	// with the allocator. The memory of the digest grows lazily, and it is
+	// kept for reuse when the digest is reset.
+	accounted   int64
+	fraction    float64
+	hasFraction bool
+	// col points to the output vector we are updating.
+	col []float64
+}
+
+var _ AggregateFunc = &approxPercentile_AGGKINDAgg{}
+
+func (a *approxPercentile_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec.Float64()
+}
+
+func (a *approxPercentile_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	valueVec, fractionVec := vecs[inputIdxs[0]], vecs[inputIdxs[1]]
+	valueCol, fractionCol := valueVec.Float64(), fractionVec.Float64()
+	valueNulls, fractionNulls := valueVec.Nulls(), fractionVec.Nulls()
+	hasNulls := valueNulls.MaybeHasNulls() || fractionNulls.MaybeHasNulls()
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			if hasNulls {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_APPROX_PERCENTILE(a, valueCol, fractionCol, valueNulls, fractionNulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_APPROX_PERCENTILE(a, valueCol, fractionCol, valueNulls, fractionNulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if hasNulls {
+				for _, i := range sel {
+					_ACCUMULATE_APPROX_PERCENTILE(a, valueCol, fractionCol, valueNulls, fractionNulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_APPROX_PERCENTILE(a, valueCol, fractionCol, valueNulls, fractionNulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+	if memUsage := a.digest.MemUsage(); memUsage > a.accounted {
+		a.allocator.AdjustMemoryUsage(memUsage - a.accounted)
+		a.accounted = memUsage
+	}
+}
+
+// setOutput writes the estimate of the current group into the output vector
+// at position outputIdx.
+func (a *approxPercentile_AGGKINDAgg) setOutput(outputIdx int) {
+	if a.digest.Count() == 0 {
+		a.nulls.SetNull(outputIdx)
+	} else {
+		a.col[outputIdx] = a.digest.Quantile(a.fraction)
+	}
+}
+
+func (a *approxPercentile_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	a.setOutput(outputIdx)
+}
+
+func (a *approxPercentile_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.digest.Reset()
+	a.hasFraction = false
+}
+
+type approxPercentile_AGGKINDAggAlloc struct {
+	aggAllocBase
+	compression float64
+	aggFuncs    []approxPercentile_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &approxPercentile_AGGKINDAggAlloc{}
+
+const sizeOfApproxPercentile_AGGKINDAgg = int64(unsafe.Sizeof(approxPercentile_AGGKINDAgg{}))
+const approxPercentile_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]approxPercentile_AGGKINDAgg{}))
+
+func (a *approxPercentile_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(approxPercentile_AGGKINDAggSliceOverhead + sizeOfApproxPercentile_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]approxPercentile_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.digest = tdigest.New(a.compression)
+	f.accounted = f.digest.MemUsage()
+	a.allocator.AdjustMemoryUsage(f.accounted)
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{/*
+func _ACCUMULATE_APPROX_PERCENTILE(
+	a *approxPercentile_AGGKINDAgg,
+	valueCol coldata.Float64s,
+	fractionCol coldata.Float64s,
+	valueNulls *coldata.Nulls,
+	fractionNulls *coldata.Nulls,
+	i int,
+	_HAS_NULLS bool,
+	_HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateApproxPercentile"}}
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			a.setOutput(a.curIdx)
+			a.curIdx++
+			a.digest.Reset()
+			a.hasFraction = false
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = valueNulls.NullAt(i) || fractionNulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		if !a.hasFraction {
+			a.fraction = fractionCol.Get(i)
+			checkApproxPercentileFraction(a.fraction)
+			a.hasFraction = true
+		}
+		a.digest.Add(valueCol.Get(i))
+	}
+	// {{end}}
+	// {{/*
+} // */}}
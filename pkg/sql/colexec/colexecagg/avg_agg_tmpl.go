@@ -11,7 +11,8 @@
 // {{/*
 // +build execgen_template
 //
-// This file is the execgen template for avg_agg.eg.go. It's formatted in a
+// This file is the execgen template for avg_agg.eg.go and
+// final_avg_agg.eg.go. It's formatted in a
 // special way, so it's both valid Go and a valid text/template input. This
 // permits editing this file with editor support.
 //
@@ -59,7 +60,7 @@ func _ASSIGN_ADD(_, _, _, _, _, _ string) {
 
 // */}}
 
-func newAvg_AGGKINDAggAlloc(
+func newAvg_AVGKIND_AGGKINDAggAlloc(
 	allocator *colmem.Allocator, t *types.T, allocSize int64,
 ) (aggregateFuncAlloc, error) {
 	allocBase := aggAllocBase{allocator: allocator, allocSize: allocSize}
@@ -70,7 +71,7 @@ func newAvg_AGGKINDAggAlloc(
 		// {{range .WidthOverloads}}
 		case _TYPE_WIDTH:
 			// {{with .Overload}}
-			return &avg_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase}, nil
+			return &avg_AVGKIND_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase}, nil
 			// {{end}}
 			// {{end}}
 		}
@@ -83,7 +84,7 @@ func newAvg_AGGKINDAggAlloc(
 // {{range .WidthOverloads}}
 // {{with .Overload}}
 
-type avg_TYPE_AGGKINDAgg struct {
+type avg_AVGKIND_TYPE_AGGKINDAgg struct {
 	// {{if eq "_AGGKIND" "Ordered"}}
 	orderedAggregateFuncBase
 	// {{else}}
@@ -101,6 +102,13 @@ type avg_TYPE_AGGKINDAgg struct {
 	// foundNonNullForCurrentGroup tracks if we have seen any non-null values
 	// for the group that is currently being aggregated.
 	foundNonNullForCurrentGroup bool
+	// {{if eq "_AVGKIND" "Final"}}
+	// foundNonNullCountForCurrentGroup tracks if we have seen any non-null
+	// counts for the group that is currently being aggregated. The partial
+	// sums and counts are tracked separately so that the result is exactly
+	// the same as of the division of the sum by the sum_int aggregations.
+	foundNonNullCountForCurrentGroup bool
+	// {{end}}
 	// {{if .NeedsHelper}}
 	// {{/*
 	// overloadHelper is used only when we perform the summation of integers
@@ -111,9 +119,9 @@ type avg_TYPE_AGGKINDAgg struct {
 	// {{end}}
 }
 
-var _ AggregateFunc = &avg_TYPE_AGGKINDAgg{}
+var _ AggregateFunc = &avg_AVGKIND_TYPE_AGGKINDAgg{}
 
-func (a *avg_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+func (a *avg_AVGKIND_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
 	// {{if eq "_AGGKIND" "Ordered"}}
 	a.orderedAggregateFuncBase.SetOutput(vec)
 	// {{else}}
@@ -122,7 +130,7 @@ func (a *avg_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
 	a.col = vec._RET_TYPE()
 }
 
-func (a *avg_TYPE_AGGKINDAgg) Compute(
+func (a *avg_AVGKIND_TYPE_AGGKINDAgg) Compute(
 	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
 ) {
 	// {{if .NeedsHelper}}
@@ -138,12 +146,20 @@ func (a *avg_TYPE_AGGKINDAgg) Compute(
 	execgen.SETVARIABLESIZE(oldCurSumSize, a.curSum)
 	vec := vecs[inputIdxs[0]]
 	col, nulls := vec.TemplateType(), vec.Nulls()
+	// {{if eq "_AVGKIND" "Final"}}
+	// The second argument contains the partial counts.
+	countVec := vecs[inputIdxs[1]]
+	countCol, countNulls := countVec.Int64(), countVec.Nulls()
+	// {{end}}
 	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
 		// {{if eq "_AGGKIND" "Ordered"}}
 		// Capture groups and col to force bounds check to work. See
 		// https://github.com/golang/go/issues/39756
 		groups := a.groups
 		col := col
+		// {{if eq "_AVGKIND" "Final"}}
+		countCol := countCol
+		// {{end}}
 		// {{/*
 		// We don't need to check whether sel is non-nil when performing
 		// hash aggregation because the hash aggregator always uses non-nil
@@ -152,6 +168,9 @@ func (a *avg_TYPE_AGGKINDAgg) Compute(
 		if sel == nil {
 			_ = groups[inputLen-1]
 			_ = col.Get(inputLen - 1)
+			// {{if eq "_AVGKIND" "Final"}}
+			_ = countCol.Get(inputLen - 1)
+			// {{end}}
 			if nulls.MaybeHasNulls() {
 				for i := 0; i < inputLen; i++ {
 					_ACCUMULATE_AVG(a, nulls, i, true, false)
@@ -183,7 +202,7 @@ func (a *avg_TYPE_AGGKINDAgg) Compute(
 	}
 }
 
-func (a *avg_TYPE_AGGKINDAgg) Flush(outputIdx int) {
+func (a *avg_AVGKIND_TYPE_AGGKINDAgg) Flush(outputIdx int) {
 	// The aggregation is finished. Flush the last value. If we haven't found
 	// any non-nulls for this group so far, the output for this group should be
 	// NULL.
@@ -193,6 +212,9 @@ func (a *avg_TYPE_AGGKINDAgg) Flush(outputIdx int) {
 	outputIdx = a.curIdx
 	a.curIdx++
 	// {{end}}
+	// {{if eq "_AVGKIND" "Final"}}
+	_FINALIZE_COUNT(a)
+	// {{end}}
 	if !a.foundNonNullForCurrentGroup {
 		a.nulls.SetNull(outputIdx)
 	} else {
@@ -200,29 +222,32 @@ func (a *avg_TYPE_AGGKINDAgg) Flush(outputIdx int) {
 	}
 }
 
-func (a *avg_TYPE_AGGKINDAgg) Reset() {
+func (a *avg_AVGKIND_TYPE_AGGKINDAgg) Reset() {
 	// {{if eq "_AGGKIND" "Ordered"}}
 	a.orderedAggregateFuncBase.Reset()
 	// {{end}}
 	a.curSum = zero_RET_TYPEValue
 	a.curCount = 0
 	a.foundNonNullForCurrentGroup = false
+	// {{if eq "_AVGKIND" "Final"}}
+	a.foundNonNullCountForCurrentGroup = false
+	// {{end}}
 }
 
-type avg_TYPE_AGGKINDAggAlloc struct {
+type avg_AVGKIND_TYPE_AGGKINDAggAlloc struct {
 	aggAllocBase
-	aggFuncs []avg_TYPE_AGGKINDAgg
+	aggFuncs []avg_AVGKIND_TYPE_AGGKINDAgg
 }
 
-var _ aggregateFuncAlloc = &avg_TYPE_AGGKINDAggAlloc{}
+var _ aggregateFuncAlloc = &avg_AVGKIND_TYPE_AGGKINDAggAlloc{}
 
-const sizeOfAvg_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(avg_TYPE_AGGKINDAgg{}))
-const avg_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]avg_TYPE_AGGKINDAgg{}))
+const sizeOfAvg_AVGKIND_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(avg_AVGKIND_TYPE_AGGKINDAgg{}))
+const avg_AVGKIND_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]avg_AVGKIND_TYPE_AGGKINDAgg{}))
 
-func (a *avg_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+func (a *avg_AVGKIND_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
 	if len(a.aggFuncs) == 0 {
-		a.allocator.AdjustMemoryUsage(avg_TYPE_AGGKINDAggSliceOverhead + sizeOfAvg_TYPE_AGGKINDAgg*a.allocSize)
-		a.aggFuncs = make([]avg_TYPE_AGGKINDAgg, a.allocSize)
+		a.allocator.AdjustMemoryUsage(avg_AVGKIND_TYPE_AGGKINDAggSliceOverhead + sizeOfAvg_AVGKIND_TYPE_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]avg_AVGKIND_TYPE_AGGKINDAgg, a.allocSize)
 	}
 	f := &a.aggFuncs[0]
 	f.allocator = a.allocator
@@ -234,6 +259,23 @@ func (a *avg_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
 // {{end}}
 // {{end}}
 
+// {{/*
+// _FINALIZE_COUNT makes sure that the result of the final avg for the current
+// group is NULL if no non-null counts have been found and returns the division
+// by zero error if the sum is non-NULL but the count is zero, the same way as
+// the division of the sum by the sum_int aggregations does.
+func _FINALIZE_COUNT(a *_AGG_TYPE_AGGKINDAgg) { // */}}
+	// {{define "finalizeCount"}}
+	if !a.foundNonNullCountForCurrentGroup {
+		a.foundNonNullForCurrentGroup = false
+	} else if a.foundNonNullForCurrentGroup && a.curCount == 0 {
+		colexecerror.ExpectedError(tree.ErrDivByZero)
+	}
+	// {{end}}
+
+	// {{/*
+} // */}}
+
 // {{/*
 // _ACCUMULATE_AVG updates the total sum/count for current group using the value
 // of the ith row. If this is the first row of a new group, then the average is
@@ -252,6 +294,9 @@ func _ACCUMULATE_AVG(
 		if !a.isFirstGroup {
 			// If we encounter a new group, and we haven't found any non-nulls for the
 			// current group, the output for this group should be null.
+			// {{if eq "_AVGKIND" "Final"}}
+			_FINALIZE_COUNT(a)
+			// {{end}}
 			if !a.foundNonNullForCurrentGroup {
 				a.nulls.SetNull(a.curIdx)
 			} else {
@@ -264,6 +309,9 @@ func _ACCUMULATE_AVG(
 			a.curSum = zero_RET_TYPEValue
 			// {{end}}
 			a.curCount = 0
+			// {{if eq "_AVGKIND" "Final"}}
+			a.foundNonNullCountForCurrentGroup = false
+			// {{end}}
 
 			// {{/*
 			// We only need to reset this flag if there are nulls. If there are no
@@ -289,9 +337,25 @@ func _ACCUMULATE_AVG(
 		// {{end}}
 		v := col.Get(i)
 		_ASSIGN_ADD(a.curSum, a.curSum, v, _, _, col)
+		// {{if ne "_AVGKIND" "Final"}}
 		a.curCount++
+		// {{end}}
 		a.foundNonNullForCurrentGroup = true
 	}
+	// {{if eq "_AVGKIND" "Final"}}
+	if !countNulls.NullAt(i) {
+		// {{if not .HasSel}}
+		//gcassert:bce
+		// {{end}}
+		c := countCol.Get(i)
+		newCount := a.curCount + c
+		if (newCount < a.curCount) != (c < 0) {
+			colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+		}
+		a.curCount = newCount
+		a.foundNonNullCountForCurrentGroup = true
+	}
+	// {{end}}
 	// {{end}}
 
 	// {{/*
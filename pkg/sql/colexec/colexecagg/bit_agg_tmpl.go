@@ -0,0 +1,242 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for bit_agg.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Remove unused warning.
+var _ = colexecerror.InternalError
+
+// {{/*
+
+// _ASSIGN_BIT_OP is the template bitwise operation function for assigning the
+// first input to the result of a bitwise operation of the second and the third
+// inputs.
+func _ASSIGN_BIT_OP(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// {{range .}}
+
+func newBit_OP_TYPE_AGGKINDAggAlloc(
+	allocator *colmem.Allocator, t *types.T, allocSize int64,
+) (aggregateFuncAlloc, error) {
+	allocBase := aggAllocBase{allocator: allocator, allocSize: allocSize}
+	switch t.Family() {
+	case types.IntFamily:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			// {{with .Overload}}
+			return &bit_OP_TYPE_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase}, nil
+			// {{end}}
+			// {{end}}
+		}
+	}
+	return nil, errors.Errorf("unsupported bit_OP_TYPE agg type %s", t.Name())
+}
+
+// {{range .WidthOverloads}}
+// {{with .Overload}}
+
+type bit_OP_TYPE_TYPE_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	col []int64
+	// curAgg holds the running bitwise fold of the current group. It starts
+	// out as the identity value of the operation.
+	curAgg int64
+	// foundNonNullForCurrentGroup tracks if we have seen any non-null values
+	// for the group that is currently being aggregated.
+	foundNonNullForCurrentGroup bool
+}
+
+var _ AggregateFunc = &bit_OP_TYPE_TYPE_AGGKINDAgg{}
+
+func (a *bit_OP_TYPE_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec.Int64()
+}
+
+func (a *bit_OP_TYPE_TYPE_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	vec := vecs[inputIdxs[0]]
+	col, nulls := vec._TYPE(), vec.Nulls()
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups and col to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		col := col
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			_ = col.Get(inputLen - 1)
+			if nulls.MaybeHasNulls() {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_BITWISE(a, nulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_BITWISE(a, nulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if nulls.MaybeHasNulls() {
+				for _, i := range sel {
+					_ACCUMULATE_BITWISE(a, nulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_BITWISE(a, nulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+}
+
+func (a *bit_OP_TYPE_TYPE_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	if !a.foundNonNullForCurrentGroup {
+		a.nulls.SetNull(outputIdx)
+	} else {
+		a.col[outputIdx] = a.curAgg
+	}
+}
+
+func (a *bit_OP_TYPE_TYPE_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	// {{/*
+	// _DEFAULT_VAL is the identity value of the bitwise operation: all ones
+	// for bit_and and zero for bit_or and bit_xor.
+	// */}}
+	a.curAgg = _DEFAULT_VAL
+	a.foundNonNullForCurrentGroup = false
+}
+
+type bit_OP_TYPE_TYPE_AGGKINDAggAlloc struct {
+	aggAllocBase
+	aggFuncs []bit_OP_TYPE_TYPE_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &bit_OP_TYPE_TYPE_AGGKINDAggAlloc{}
+
+const sizeOfBit_OP_TYPE_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(bit_OP_TYPE_TYPE_AGGKINDAgg{}))
+const bit_OP_TYPE_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]bit_OP_TYPE_TYPE_AGGKINDAgg{}))
+
+func (a *bit_OP_TYPE_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(bit_OP_TYPE_TYPE_AGGKINDAggSliceOverhead + sizeOfBit_OP_TYPE_TYPE_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]bit_OP_TYPE_TYPE_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.Reset()
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _ACCUMULATE_BITWISE folds the integer value at index i into the bitwise
+// aggregate.
+func _ACCUMULATE_BITWISE(
+	a *bit_OP_TYPE_TYPE_AGGKINDAgg, nulls *coldata.Nulls, i int, _HAS_NULLS bool, _HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateBitwise" -}}
+
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			if !a.foundNonNullForCurrentGroup {
+				a.nulls.SetNull(a.curIdx)
+			} else {
+				a.col[a.curIdx] = a.curAgg
+			}
+			a.curIdx++
+			// {{with .Global}}
+			a.curAgg = _DEFAULT_VAL
+			// {{end}}
+			a.foundNonNullForCurrentGroup = false
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = nulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		// {{if not .HasSel}}
+		//gcassert:bce
+		// {{end}}
+		v := col.Get(i)
+		// {{with .Global}}
+		_ASSIGN_BIT_OP(a.curAgg, a.curAgg, v)
+		// {{end}}
+		a.foundNonNullForCurrentGroup = true
+	}
+
+	// {{end}}
+
+	// {{/*
+} // */}}
@@ -81,6 +81,14 @@ func (a *bool_OP_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
 func (a *bool_OP_TYPE_AGGKINDAgg) Compute(
 	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
 ) {
+	// {{if eq "_AGGKIND" "Hash"}}
+	if a.foundNonNullForCurrentGroup && _RESULT_DETERMINED {
+		// The result for the group has already been determined (we have seen
+		// false for bool_and or true for bool_or), so there is no need to
+		// look at the remaining tuples.
+		return
+	}
+	// {{end}}
 	execgen.SETVARIABLESIZE(oldCurAggSize, a.curAgg)
 	vec := vecs[inputIdxs[0]]
 	col, nulls := vec.Bool(), vec.Nulls()
@@ -222,6 +230,15 @@ func _ACCUMULATE_BOOLEAN(
 		_ASSIGN_BOOL_OP(a.curAgg, a.curAgg, col[i])
 		// {{end}}
 		a.foundNonNullForCurrentGroup = true
+		// {{if eq "_AGGKIND" "Hash"}}
+		// All tuples belong to the same group, so once the result is
+		// determined, we can skip the rest of them.
+		// {{with .Global}}
+		if _RESULT_DETERMINED {
+			break
+		}
+		// {{end}}
+		// {{end}}
 	}
 
 	// {{end}}
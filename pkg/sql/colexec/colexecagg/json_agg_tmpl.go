@@ -0,0 +1,287 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for json_agg.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ = pgcode.InvalidParameterValue
+	_ = pgerror.New
+	_ = errors.AssertionFailedf
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// _BUILDER_TYPE is the template variable.
+type _BUILDER_TYPE = json.ArrayBuilderWithCounter
+
+// _NEW_BUILDER is the template function for creating a new builder of the
+// JSON value of a group.
+func _NEW_BUILDER() *_BUILDER_TYPE {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// {{range .}}
+
+func newJSON_JSON_KIND_AGGKINDAggAlloc(
+	allocator *colmem.Allocator,
+	inputArgsConverter *colconv.VecToDatumConverter,
+	loc *time.Location,
+	allocSize int64,
+) aggregateFuncAlloc {
+	return &json_JSON_KIND_AGGKINDAggAlloc{
+		aggAllocBase: aggAllocBase{
+			allocator: allocator,
+			allocSize: allocSize,
+		},
+		inputArgsConverter: inputArgsConverter,
+		loc:                loc,
+	}
+}
+
+// {{if .IsObject}}
+// json_JSON_KIND_AGGKINDAgg builds a JSON object out of the key/value pairs of
+// each group (json_object_agg and jsonb_object_agg). The values are added in
+// the order in which they are seen, NULL values are added as JSON nulls, and
+// NULL keys result in an error.
+// {{else}}
+// json_JSON_KIND_AGGKINDAgg builds a JSON array out of the values of each group
+// (json_agg and jsonb_agg). The values are added in the order in which they
+// are seen, and NULL values are added as JSON nulls.
+// {{end}}
+type json_JSON_KIND_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	// col points to the output vector we are updating.
+	col *coldata.JSONs
+	// inputArgsConverter is managed by the aggregator, and this function can
+	// simply call GetDatumColumn to get the values.
+	inputArgsConverter *colconv.VecToDatumConverter
+	loc                *time.Location
+	// builder accumulates the JSON value of the group that is currently being
+	// aggregated.
+	builder *_BUILDER_TYPE
+	// builderMemUsage is the memory usage of builder that has been accounted
+	// for.
+	builderMemUsage int64
+	// foundRowForCurrentGroup tracks if we have seen any rows for the group
+	// that is currently being aggregated (note that unlike most aggregate
+	// functions NULL values aren't skipped).
+	foundRowForCurrentGroup bool
+}
+
+var _ AggregateFunc = &json_JSON_KIND_AGGKINDAgg{}
+
+func (a *json_JSON_KIND_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec.JSON()
+}
+
+func (a *json_JSON_KIND_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	// {{if .IsObject}}
+	keyVec := vecs[inputIdxs[0]]
+	keys, keyNulls := keyVec.Bytes(), keyVec.Nulls()
+	// Both aggregators convert the batch "sparsely" - without deselection -
+	// so converted values are at the same positions as the original ones.
+	vals := a.inputArgsConverter.GetDatumColumn(int(inputIdxs[1]))
+	// {{else}}
+	// Both aggregators convert the batch "sparsely" - without deselection -
+	// so converted values are at the same positions as the original ones.
+	vals := a.inputArgsConverter.GetDatumColumn(int(inputIdxs[0]))
+	// {{end}}
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			for i := 0; i < inputLen; i++ {
+				_ACCUMULATE_JSON(a, groups, i, false)
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			for _, i := range sel {
+				_ACCUMULATE_JSON(a, groups, i, true)
+			}
+		}
+	},
+	)
+	a.updateMemUsage()
+}
+
+func (a *json_JSON_KIND_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	a.setResult(outputIdx)
+	// Release the builder eagerly.
+	a.releaseBuilder()
+	a.builder = nil
+}
+
+func (a *json_JSON_KIND_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.releaseBuilder()
+	a.builder = _NEW_BUILDER()
+	a.foundRowForCurrentGroup = false
+}
+
+// setResult sets the JSON value of the current group in position outputIdx
+// of the output. If we haven't found any rows for the group, the output for
+// this group should be null.
+func (a *json_JSON_KIND_AGGKINDAgg) setResult(outputIdx int) {
+	if !a.foundRowForCurrentGroup {
+		a.nulls.SetNull(outputIdx)
+	} else {
+		a.col.Set(outputIdx, a.builder.Build())
+	}
+}
+
+// updateMemUsage accounts for the change in the memory usage of the builder.
+func (a *json_JSON_KIND_AGGKINDAgg) updateMemUsage() {
+	if memUsage := int64(a.builder.Size()); memUsage != a.builderMemUsage {
+		a.allocator.AdjustMemoryUsage(memUsage - a.builderMemUsage)
+		a.builderMemUsage = memUsage
+	}
+}
+
+// releaseBuilder releases the memory of the builder of the current group.
+func (a *json_JSON_KIND_AGGKINDAgg) releaseBuilder() {
+	a.allocator.AdjustMemoryUsage(-a.builderMemUsage)
+	a.builderMemUsage = 0
+}
+
+type json_JSON_KIND_AGGKINDAggAlloc struct {
+	aggAllocBase
+	inputArgsConverter *colconv.VecToDatumConverter
+	loc                *time.Location
+	aggFuncs           []json_JSON_KIND_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &json_JSON_KIND_AGGKINDAggAlloc{}
+
+const sizeOfJSON_JSON_KIND_AGGKINDAgg = int64(unsafe.Sizeof(json_JSON_KIND_AGGKINDAgg{}))
+const json_JSON_KIND_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]json_JSON_KIND_AGGKINDAgg{}))
+
+func (a *json_JSON_KIND_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(json_JSON_KIND_AGGKINDAggSliceOverhead + sizeOfJSON_JSON_KIND_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]json_JSON_KIND_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.inputArgsConverter = a.inputArgsConverter
+	f.loc = a.loc
+	f.builder = _NEW_BUILDER()
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{end}}
+
+// {{/*
+// _ACCUMULATE_JSON adds the value (or the key/value pair) of the ith row to
+// the JSON value of the current group. If this is the first row of a new
+// group, then the output for the previous group is set.
+func _ACCUMULATE_JSON(a *json_JSON_KIND_AGGKINDAgg, groups []bool, i int, _HAS_SEL bool) { // */}}
+	// {{define "accumulateJSON"}}
+
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			a.setResult(a.curIdx)
+			a.curIdx++
+			a.releaseBuilder()
+			// {{with .Global}}
+			a.builder = _NEW_BUILDER()
+			// {{end}}
+			a.foundRowForCurrentGroup = false
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	// {{with .Global}}
+	// {{if .IsObject}}
+	if keyNulls.NullAt(i) {
+		colexecerror.ExpectedError(pgerror.New(pgcode.InvalidParameterValue, "field name must not be null"))
+	}
+	// {{end}}
+	// {{end}}
+	val, err := tree.AsJSON(vals[i], a.loc)
+	if err != nil {
+		colexecerror.ExpectedError(err)
+	}
+	// {{with .Global}}
+	// {{if .IsObject}}
+	a.builder.Add(string(keys.Get(i)), val)
+	// {{else}}
+	a.builder.Add(val)
+	// {{end}}
+	// {{end}}
+	a.foundRowForCurrentGroup = true
+	// {{end}}
+
+	// {{/*
+} // */}}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecagg
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestModeFloats verifies that the mode aggregate functions treat all NaNs as
+// well as the positive and the negative zeros as equal values, and that the
+// memory of the counts is released once the aggregation is finished.
+func TestModeFloats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	defer testMemMonitor.Stop(ctx)
+	memAcc := testMemMonitor.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	testAllocator := colmem.NewAllocator(ctx, &memAcc, coldataext.NewExtendedColumnFactory(&evalCtx))
+
+	otherNaN := math.Float64frombits(math.Float64bits(math.NaN()) + 1)
+	require.True(t, math.IsNaN(otherNaN))
+	for _, tc := range []struct {
+		input    []float64
+		expected float64
+	}{
+		{
+			input:    []float64{1.5, math.NaN(), 1.5, otherNaN, otherNaN},
+			expected: math.NaN(),
+		},
+		{
+			input:    []float64{0, 1.5, math.Copysign(0, -1), 1.5},
+			expected: 0,
+		},
+		{
+			input:    []float64{math.Copysign(0, -1), 1.5, 0, 1.5, 0},
+			expected: math.Copysign(0, -1),
+		},
+	} {
+		for _, isHashAgg := range []bool{false, true} {
+			vec := testAllocator.NewMemColumn(types.Float, len(tc.input))
+			copy(vec.Float64(), tc.input)
+			var alloc aggregateFuncAlloc
+			var err error
+			if isHashAgg {
+				alloc, err = newModeHashAggAlloc(testAllocator, types.Float, 1 /* allocSize */)
+			} else {
+				alloc, err = newModeOrderedAggAlloc(testAllocator, types.Float, 1 /* allocSize */)
+			}
+			require.NoError(t, err)
+			f := alloc.newAggFunc()
+			output := testAllocator.NewMemColumn(types.Float, 1)
+			f.SetOutput(output)
+			groups := make([]bool, len(tc.input))
+			groups[0] = true
+			f.Init(groups)
+			memUsage := testAllocator.Used()
+			if isHashAgg {
+				// The hash aggregator always uses the selection vector.
+				sel := make([]int, len(tc.input))
+				for i := range sel {
+					sel[i] = i
+				}
+				f.Compute([]coldata.Vec{vec}, []uint32{0}, len(tc.input), sel)
+			} else {
+				f.Compute([]coldata.Vec{vec}, []uint32{0}, len(tc.input), nil /* sel */)
+			}
+			require.Greater(t, testAllocator.Used(), memUsage)
+			f.Flush(0 /* outputIdx */)
+			require.Equal(t, memUsage, testAllocator.Used())
+			actual := output.Float64()[0]
+			require.False(t, output.Nulls().NullAt(0))
+			if math.IsNaN(tc.expected) {
+				require.True(t, math.IsNaN(actual))
+			} else {
+				require.Equal(t, tc.expected, actual)
+				require.Equal(t, math.Signbit(tc.expected), math.Signbit(actual))
+			}
+		}
+	}
+}
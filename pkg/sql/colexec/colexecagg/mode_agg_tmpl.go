@@ -0,0 +1,345 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for mode_agg.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ tree.AggType
+	_ apd.Context
+	_ duration.Duration
+	_ json.JSON
+	_ colexecerror.StorageError
+	_ coldataext.Datum
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// _GOTYPESLICE is the template variable.
+type _GOTYPESLICE interface{}
+
+// _GOTYPE is the template variable.
+type _GOTYPE interface{}
+
+// _KEYTYPE is the template variable.
+type _KEYTYPE interface{}
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+// _MODE_PREPARE_KEY is the template function for computing the fingerprint of
+// the value at position i of vec if the fingerprints are used as the keys.
+func _MODE_PREPARE_KEY(_ *mode_TYPE_AGGKINDAgg, _ coldata.Vec, _ int) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _MODE_KEY is the template function for the key of the value in the map of
+// the distinct values.
+func _MODE_KEY(_ *mode_TYPE_AGGKINDAgg, _ _GOTYPE) _KEYTYPE {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _MODE_KEY_SIZE is the template function for the memory footprint of the
+// key.
+func _MODE_KEY_SIZE(_ _KEYTYPE) int64 {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+func newMode_AGGKINDAggAlloc(
+	allocator *colmem.Allocator, t *types.T, allocSize int64,
+) (aggregateFuncAlloc, error) {
+	allocBase := aggAllocBase{allocator: allocator, allocSize: allocSize}
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			return &mode_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase, inputType: t}, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported mode agg type %s", t.Name())
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+// mode_TYPE_AGGKINDAgg returns the most frequent non-NULL value in each group.
+// The distinct values of the group are counted in a hash map. If there are
+// multiple equally-frequent values, the one seen first wins, which is the
+// first one in the ordering since the input of mode is sorted according to
+// its WITHIN GROUP clause.
+type mode_TYPE_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	// col points to the output vector we are updating.
+	col       _GOTYPESLICE
+	inputType *types.T
+	// counts maps the keys of the distinct values of the group that is
+	// currently being aggregated to their entries.
+	counts map[_KEYTYPE]*modeEntry
+	// countsMemUsage is the memory usage of counts that has been accounted
+	// for.
+	countsMemUsage int64
+	// curAgg holds the most frequent value of the current group so far, and
+	// curEntry is its entry in counts.
+	// NOTE: if curEntry is nil, curAgg is undefined.
+	curAgg   _GOTYPE
+	curEntry *modeEntry
+	// buf and scratch are reused when computing the fingerprints of the
+	// values (if the fingerprints are used as the keys).
+	buf, scratch []byte
+}
+
+var _ AggregateFunc = &mode_TYPE_AGGKINDAgg{}
+
+func (a *mode_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec._TYPE()
+}
+
+func (a *mode_TYPE_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	execgen.SETVARIABLESIZE(oldCurAggSize, a.curAgg)
+	vec := vecs[inputIdxs[0]]
+	col, nulls := vec._TYPE(), vec.Nulls()
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups and col to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		col := col
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			_ = col.Get(inputLen - 1)
+			if nulls.MaybeHasNulls() {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_MODE(a, vec, nulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_MODE(a, vec, nulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if nulls.MaybeHasNulls() {
+				for _, i := range sel {
+					_ACCUMULATE_MODE(a, vec, nulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_MODE(a, vec, nulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+	execgen.SETVARIABLESIZE(newCurAggSize, a.curAgg)
+	if newCurAggSize != oldCurAggSize {
+		a.allocator.AdjustMemoryUsage(int64(newCurAggSize - oldCurAggSize))
+	}
+}
+
+func (a *mode_TYPE_AGGKINDAgg) Flush(outputIdx int) {
+	// The aggregation is finished. Flush the last value. If we haven't found
+	// any non-nulls for this group so far, the output for this group should
+	// be null.
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	if a.curEntry == nil {
+		a.nulls.SetNull(outputIdx)
+	} else {
+		execgen.SET(a.col, outputIdx, a.curAgg)
+	}
+	// {{if or (.IsBytesLike) (eq .VecMethod "Datum")}}
+	execgen.SETVARIABLESIZE(oldCurAggSize, a.curAgg)
+	// Release the reference to curAgg eagerly.
+	a.allocator.AdjustMemoryUsage(-int64(oldCurAggSize))
+	a.curAgg = nil
+	// {{end}}
+	// Release the counts eagerly.
+	a.releaseCounts()
+	a.counts = nil
+}
+
+func (a *mode_TYPE_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.releaseCounts()
+	a.counts = make(map[_KEYTYPE]*modeEntry)
+	a.curEntry = nil
+}
+
+// releaseCounts releases the memory of the counts of the current group.
+func (a *mode_TYPE_AGGKINDAgg) releaseCounts() {
+	a.allocator.AdjustMemoryUsage(-a.countsMemUsage)
+	a.countsMemUsage = 0
+}
+
+type mode_TYPE_AGGKINDAggAlloc struct {
+	aggAllocBase
+	inputType *types.T
+	aggFuncs  []mode_TYPE_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &mode_TYPE_AGGKINDAggAlloc{}
+
+const sizeOfMode_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(mode_TYPE_AGGKINDAgg{}))
+const mode_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]mode_TYPE_AGGKINDAgg{}))
+
+func (a *mode_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(mode_TYPE_AGGKINDAggSliceOverhead + sizeOfMode_TYPE_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]mode_TYPE_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.inputType = a.inputType
+	f.counts = make(map[_KEYTYPE]*modeEntry)
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{end}}
+// {{end}}
+
+// {{/*
+// _ACCUMULATE_MODE counts the value of the ith row and updates the most
+// frequent value of the current group. If this is the first row of a new
+// group, then the output for the previous group is set.
+func _ACCUMULATE_MODE(
+	a *mode_TYPE_AGGKINDAgg,
+	vec coldata.Vec,
+	nulls *coldata.Nulls,
+	i int,
+	_HAS_NULLS bool,
+	_HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateMode"}}
+
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			// If we encounter a new group, and we haven't found any non-nulls for the
+			// current group, the output for this group should be null.
+			if a.curEntry == nil {
+				a.nulls.SetNull(a.curIdx)
+			} else {
+				// {{with .Global}}
+				execgen.SET(a.col, a.curIdx, a.curAgg)
+				// {{end}}
+			}
+			a.curIdx++
+			a.releaseCounts()
+			// {{with .Global}}
+			a.counts = make(map[_KEYTYPE]*modeEntry)
+			// {{end}}
+			a.curEntry = nil
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = nulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		// {{if and (.Sliceable) (not .HasSel)}}
+		//gcassert:bce
+		// {{end}}
+		val := col.Get(i)
+		// {{with .Global}}
+		_MODE_PREPARE_KEY(a, vec, i)
+		e := a.counts[_MODE_KEY(a, val)]
+		if e == nil {
+			key := _MODE_KEY(a, val)
+			e = &modeEntry{ordinal: len(a.counts)}
+			a.counts[key] = e
+			memUsage := modeEntryOverhead + _MODE_KEY_SIZE(key)
+			a.allocator.AdjustMemoryUsage(memUsage)
+			a.countsMemUsage += memUsage
+		}
+		e.count++
+		if e != a.curEntry {
+			if a.curEntry == nil || e.count > a.curEntry.count ||
+				(e.count == a.curEntry.count && e.ordinal < a.curEntry.ordinal) {
+				execgen.COPYVAL(a.curAgg, val)
+				a.curEntry = e
+			}
+		}
+		// {{end}}
+	}
+	// {{end}}
+
+	// {{/*
+} // */}}
@@ -0,0 +1,242 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for regression_agg.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+// Declarations to make the template compile properly.
+
+// _Y_TYPE_FAMILY is the template variable.
+const _Y_TYPE_FAMILY = types.UnknownFamily
+
+// _X_TYPE_FAMILY is the template variable.
+const _X_TYPE_FAMILY = types.UnknownFamily
+
+// */}}
+
+// newRegression_AGGKINDAggAlloc returns the allocator of the statistical
+// aggregate function aggFn (like corr or regr_slope) over the dependent
+// variable of type yType and the independent variable of type xType. Only
+// floats and 64-bit integers are supported.
+func newRegression_AGGKINDAggAlloc(
+	allocator *colmem.Allocator,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	yType *types.T,
+	xType *types.T,
+	allocSize int64,
+) (aggregateFuncAlloc, error) {
+	allocBase := aggAllocBase{allocator: allocator, allocSize: allocSize}
+	if isRegressionInputSupported(yType) && isRegressionInputSupported(xType) {
+		switch yType.Family() {
+		// {{range .}}
+		case _Y_TYPE_FAMILY:
+			switch xType.Family() {
+			// {{range .XOverloads}}
+			case _X_TYPE_FAMILY:
+				return &regression_Y_TYPE_X_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase, aggFn: aggFn}, nil
+				// {{end}}
+			}
+			// {{end}}
+		}
+	}
+	return nil, errors.Errorf("unsupported %s agg types %s and %s", aggFn, yType.Name(), xType.Name())
+}
+
+// {{range .}}
+// {{range .XOverloads}}
+
+// regression_Y_TYPE_X_TYPE_AGGKINDAgg computes the statistical aggregate
+// function over the pairs of the dependent (the first argument) and the
+// independent (the second argument) variables in each group. Only the
+// transition values are maintained for the current group, and the result is
+// computed when the group is flushed. The rows in which either argument is
+// NULL are skipped.
+type regression_Y_TYPE_X_TYPE_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	regressionAccumulator
+	aggFn execinfrapb.AggregatorSpec_Func
+	// col points to the output vector we are updating.
+	col []float64
+}
+
+var _ AggregateFunc = &regression_Y_TYPE_X_TYPE_AGGKINDAgg{}
+
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec.Float64()
+}
+
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	yVec, xVec := vecs[inputIdxs[0]], vecs[inputIdxs[1]]
+	yCol, xCol := yVec._Y_TYPE(), xVec._X_TYPE()
+	yNulls, xNulls := yVec.Nulls(), xVec.Nulls()
+	hasNulls := yNulls.MaybeHasNulls() || xNulls.MaybeHasNulls()
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			if hasNulls {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_REGRESSION(a, yCol, xCol, yNulls, xNulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_REGRESSION(a, yCol, xCol, yNulls, xNulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if hasNulls {
+				for _, i := range sel {
+					_ACCUMULATE_REGRESSION(a, yCol, xCol, yNulls, xNulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_REGRESSION(a, yCol, xCol, yNulls, xNulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+}
+
+// setOutput writes the result of the current group into the output vector at
+// position outputIdx.
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAgg) setOutput(outputIdx int) {
+	if res, ok := a.result(a.aggFn); ok {
+		a.col[outputIdx] = res
+	} else {
+		a.nulls.SetNull(outputIdx)
+	}
+}
+
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	a.setOutput(outputIdx)
+}
+
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.regressionAccumulator = regressionAccumulator{}
+}
+
+type regression_Y_TYPE_X_TYPE_AGGKINDAggAlloc struct {
+	aggAllocBase
+	aggFn    execinfrapb.AggregatorSpec_Func
+	aggFuncs []regression_Y_TYPE_X_TYPE_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &regression_Y_TYPE_X_TYPE_AGGKINDAggAlloc{}
+
+const sizeOfRegression_Y_TYPE_X_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(regression_Y_TYPE_X_TYPE_AGGKINDAgg{}))
+const regression_Y_TYPE_X_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]regression_Y_TYPE_X_TYPE_AGGKINDAgg{}))
+
+func (a *regression_Y_TYPE_X_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(regression_Y_TYPE_X_TYPE_AGGKINDAggSliceOverhead + sizeOfRegression_Y_TYPE_X_TYPE_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]regression_Y_TYPE_X_TYPE_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.aggFn = a.aggFn
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{end}}
+// {{end}}
+
+// {{/*
+// _ACCUMULATE_REGRESSION updates the transition values of the current group
+// using the values of the ith row. If this is the first row of a new group,
+// then the result is computed for the current group.
+func _ACCUMULATE_REGRESSION(
+	a *regression_Y_TYPE_X_TYPE_AGGKINDAgg,
+	yCol coldata.Float64s,
+	xCol coldata.Float64s,
+	yNulls *coldata.Nulls,
+	xNulls *coldata.Nulls,
+	i int,
+	_HAS_NULLS bool,
+	_HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateRegression"}}
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			a.setOutput(a.curIdx)
+			a.curIdx++
+			a.regressionAccumulator = regressionAccumulator{}
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = yNulls.NullAt(i) || xNulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		a.add(float64(yCol.Get(i)), float64(xCol.Get(i)))
+	}
+	// {{end}}
+	// {{/*
+} // */}}
@@ -0,0 +1,300 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for variance_agg.eg.go. It's formatted in
+// a special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecagg
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ tree.AggType
+	_ apd.Context
+	_ = math.Sqrt
+)
+
+// {{/*
+// Declarations to make the template compile properly
+
+// _ASSIGN_WELFORD_UPDATE is the template function for updating the running
+// count, mean and sum of squared differences from the mean of the aggregate
+// function (the first input) with the value (the second input).
+func _ASSIGN_WELFORD_UPDATE(_, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _ASSIGN_VARIANCE is the template function for assigning the first input to
+// the variance (or the standard deviation) computed from the running state of
+// the aggregate function (the second input).
+func _ASSIGN_VARIANCE(_, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// newVariance_AGGKINDAggAlloc returns the allocator of the aggregate function
+// that computes the variance of the values of type t. If pop is true, then the
+// population variance is computed, otherwise the sample one is. If sqrt is
+// true, then the standard deviation is returned instead of the variance.
+func newVariance_AGGKINDAggAlloc(
+	allocator *colmem.Allocator, t *types.T, allocSize int64, pop bool, sqrt bool,
+) (aggregateFuncAlloc, error) {
+	allocBase := aggAllocBase{allocator: allocator, allocSize: allocSize}
+	switch t.Family() {
+	// {{range .}}
+	case _TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			// {{with .Overload}}
+			return &variance_TYPE_AGGKINDAggAlloc{aggAllocBase: allocBase, pop: pop, sqrt: sqrt}, nil
+			// {{end}}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported variance agg type %s", t.Name())
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+// {{with .Overload}}
+
+// variance_TYPE_AGGKINDAgg computes the variance (or the standard deviation)
+// of the values in each group. It uses the Welford's online algorithm, same as
+// the row engine, so only the running count, mean and sum of squared
+// differences from the mean are kept for the current group, and the final
+// value is computed when the group is flushed.
+type variance_TYPE_AGGKINDAgg struct {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	orderedAggregateFuncBase
+	// {{else}}
+	hashAggregateFuncBase
+	// {{end}}
+	// pop indicates whether the population (rather than the sample) variance
+	// is computed.
+	pop bool
+	// sqrt indicates whether the square root of the variance (i.e. the
+	// standard deviation) is returned.
+	sqrt bool
+	// curCount is the number of non-null values of the current group.
+	curCount int64
+	// curMean is the running mean of the current group.
+	curMean _GOTYPE
+	// curSqrDiff is the running sum of squared differences from the mean of
+	// the current group.
+	curSqrDiff _GOTYPE
+	// {{if .IsDecimal}}
+	// ed performs the intermediate computations with the extra precision.
+	ed apd.ErrDecimal
+	// count, delta and tmp are the scratch space used within iterations.
+	count apd.Decimal
+	delta apd.Decimal
+	tmp   apd.Decimal
+	// {{end}}
+	// col points to the statically-typed output vector.
+	col []_GOTYPE
+}
+
+var _ AggregateFunc = &variance_TYPE_AGGKINDAgg{}
+
+func (a *variance_TYPE_AGGKINDAgg) SetOutput(vec coldata.Vec) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.SetOutput(vec)
+	// {{else}}
+	a.hashAggregateFuncBase.SetOutput(vec)
+	// {{end}}
+	a.col = vec._TYPE()
+}
+
+func (a *variance_TYPE_AGGKINDAgg) Compute(
+	vecs []coldata.Vec, inputIdxs []uint32, inputLen int, sel []int,
+) {
+	execgen.SETVARIABLESIZE(oldCurMeanSize, a.curMean)
+	execgen.SETVARIABLESIZE(oldCurSqrDiffSize, a.curSqrDiff)
+	vec := vecs[inputIdxs[0]]
+	col, nulls := vec._TYPE(), vec.Nulls()
+	a.allocator.PerformOperation([]coldata.Vec{a.vec}, func() {
+		// {{if eq "_AGGKIND" "Ordered"}}
+		// Capture groups and col to force bounds check to work. See
+		// https://github.com/golang/go/issues/39756
+		groups := a.groups
+		col := col
+		// {{/*
+		// We don't need to check whether sel is non-nil when performing
+		// hash aggregation because the hash aggregator always uses non-nil
+		// sel to specify the tuples to be aggregated.
+		// */}}
+		if sel == nil {
+			_ = groups[inputLen-1]
+			_ = col.Get(inputLen - 1)
+			if nulls.MaybeHasNulls() {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_VARIANCE(a, nulls, i, true, false)
+				}
+			} else {
+				for i := 0; i < inputLen; i++ {
+					_ACCUMULATE_VARIANCE(a, nulls, i, false, false)
+				}
+			}
+		} else
+		// {{end}}
+		{
+			sel = sel[:inputLen]
+			if nulls.MaybeHasNulls() {
+				for _, i := range sel {
+					_ACCUMULATE_VARIANCE(a, nulls, i, true, true)
+				}
+			} else {
+				for _, i := range sel {
+					_ACCUMULATE_VARIANCE(a, nulls, i, false, true)
+				}
+			}
+		}
+	},
+	)
+	execgen.SETVARIABLESIZE(newCurMeanSize, a.curMean)
+	execgen.SETVARIABLESIZE(newCurSqrDiffSize, a.curSqrDiff)
+	if newCurMeanSize != oldCurMeanSize || newCurSqrDiffSize != oldCurSqrDiffSize {
+		a.allocator.AdjustMemoryUsage(
+			int64(newCurMeanSize+newCurSqrDiffSize) - int64(oldCurMeanSize+oldCurSqrDiffSize),
+		)
+	}
+}
+
+// setOutput writes the result of the current group into the output vector at
+// position outputIdx. The result is NULL if there are no non-null values in
+// the group or, for the sample variance, if there is only one.
+func (a *variance_TYPE_AGGKINDAgg) setOutput(outputIdx int) {
+	if a.curCount == 0 || (!a.pop && a.curCount == 1) {
+		a.nulls.SetNull(outputIdx)
+	} else {
+		_ASSIGN_VARIANCE(a.col[outputIdx], a)
+	}
+}
+
+func (a *variance_TYPE_AGGKINDAgg) Flush(outputIdx int) {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// Go around "argument overwritten before first use" linter error.
+	_ = outputIdx
+	outputIdx = a.curIdx
+	a.curIdx++
+	// {{end}}
+	a.setOutput(outputIdx)
+}
+
+func (a *variance_TYPE_AGGKINDAgg) Reset() {
+	// {{if eq "_AGGKIND" "Ordered"}}
+	a.orderedAggregateFuncBase.Reset()
+	// {{end}}
+	a.curCount = 0
+	a.curMean = zero_TYPEValue
+	a.curSqrDiff = zero_TYPEValue
+}
+
+type variance_TYPE_AGGKINDAggAlloc struct {
+	aggAllocBase
+	pop      bool
+	sqrt     bool
+	aggFuncs []variance_TYPE_AGGKINDAgg
+}
+
+var _ aggregateFuncAlloc = &variance_TYPE_AGGKINDAggAlloc{}
+
+const sizeOfVariance_TYPE_AGGKINDAgg = int64(unsafe.Sizeof(variance_TYPE_AGGKINDAgg{}))
+const variance_TYPE_AGGKINDAggSliceOverhead = int64(unsafe.Sizeof([]variance_TYPE_AGGKINDAgg{}))
+
+func (a *variance_TYPE_AGGKINDAggAlloc) newAggFunc() AggregateFunc {
+	if len(a.aggFuncs) == 0 {
+		a.allocator.AdjustMemoryUsage(variance_TYPE_AGGKINDAggSliceOverhead + sizeOfVariance_TYPE_AGGKINDAgg*a.allocSize)
+		a.aggFuncs = make([]variance_TYPE_AGGKINDAgg, a.allocSize)
+	}
+	f := &a.aggFuncs[0]
+	f.allocator = a.allocator
+	f.pop = a.pop
+	f.sqrt = a.sqrt
+	// {{if .IsDecimal}}
+	f.ed = apd.MakeErrDecimal(tree.IntermediateCtx)
+	// {{end}}
+	a.aggFuncs = a.aggFuncs[1:]
+	return f
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _ACCUMULATE_VARIANCE updates the running state of the current group using
+// the value of the ith row. If this is the first row of a new group, then the
+// result is computed for the current group.
+func _ACCUMULATE_VARIANCE(
+	a *variance_TYPE_AGGKINDAgg, nulls *coldata.Nulls, i int, _HAS_NULLS bool, _HAS_SEL bool,
+) { // */}}
+	// {{define "accumulateVariance"}}
+
+	// {{if eq "_AGGKIND" "Ordered"}}
+	// {{if not .HasSel}}
+	//gcassert:bce
+	// {{end}}
+	if groups[i] {
+		if !a.isFirstGroup {
+			a.setOutput(a.curIdx)
+			a.curIdx++
+			a.curCount = 0
+			// {{with .Global}}
+			a.curMean = zero_TYPEValue
+			a.curSqrDiff = zero_TYPEValue
+			// {{end}}
+		}
+		a.isFirstGroup = false
+	}
+	// {{end}}
+
+	var isNull bool
+	// {{if .HasNulls}}
+	isNull = nulls.NullAt(i)
+	// {{else}}
+	isNull = false
+	// {{end}}
+	if !isNull {
+		// {{if not .HasSel}}
+		//gcassert:bce
+		// {{end}}
+		v := col.Get(i)
+		// {{with .Global}}
+		_ASSIGN_WELFORD_UPDATE(a, v)
+		// {{end}}
+	}
+	// {{end}}
+
+	// {{/*
+} // */}}
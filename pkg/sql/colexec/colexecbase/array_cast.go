@@ -0,0 +1,184 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// GetArrayCastOperator returns an operator that casts the array column at
+// position colIdx into the column of the array type toType at position
+// resultIdx. The elements of the arrays are cast by the cast operator between
+// the element types, so an error is returned if that cast is not supported.
+func GetArrayCastOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	fromType *types.T,
+	toType *types.T,
+) (colexecop.Operator, error) {
+	if fromType.Family() != types.ArrayFamily || toType.Family() != types.ArrayFamily {
+		return nil, errors.Errorf("unhandled array cast %s -> %s", fromType, toType)
+	}
+	fromElemType, toElemType := fromType.ArrayContents(), toType.ArrayContents()
+	// The elements are written into the first column of the scratch batch,
+	// and the element cast operator projects the result into the second one.
+	elemInput := colexecop.NewFeedOperator()
+	elemCast, err := GetCastOperator(allocator, elemInput, 0 /* colIdx */, 1 /* resultIdx */, fromElemType, toElemType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unhandled array cast %s -> %s", fromType, toType)
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, toType, resultIdx)
+	return &arrayCastOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      resultIdx,
+		toElemType:     toElemType,
+		elemInput:      elemInput,
+		elemCast:       elemCast,
+		scratchTypes:   []*types.T{fromElemType},
+		toPhysical:     colconv.GetDatumToPhysicalFn(fromElemType),
+	}, nil
+}
+
+// arrayCastOp casts the arrays by flattening the elements of all arrays of
+// the batch into a scratch batch, casting them with the element cast
+// operator, and building the resulting arrays from the cast elements. A NULL
+// array is cast to NULL, and the NULL elements stay NULL.
+type arrayCastOp struct {
+	colexecop.OneInputHelper
+
+	allocator  *colmem.Allocator
+	colIdx     int
+	outputIdx  int
+	toElemType *types.T
+
+	elemInput *colexecop.FeedOperator
+	elemCast  colexecop.Operator
+	// scratch is the batch with the elements to be cast. It is reused across
+	// batches, and its capacity might be smaller than the total number of
+	// elements, in which case the elements are cast in several chunks.
+	scratch      coldata.Batch
+	scratchTypes []*types.T
+	toPhysical   func(tree.Datum) interface{}
+	// elems is the scratch space for the elements of all arrays of the current
+	// batch.
+	elems tree.Datums
+	da    rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &arrayCastOp{}
+
+func (c *arrayCastOp) Init(ctx context.Context) {
+	if !c.InitHelper.Init(ctx) {
+		return
+	}
+	c.Input.Init(c.Ctx)
+	c.elemCast.Init(c.Ctx)
+}
+
+func (c *arrayCastOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(c.colIdx)
+	nulls := vec.Nulls()
+	arrays := vec.Datum()
+	c.elems = c.elems[:0]
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		if nulls.MaybeHasNulls() && nulls.NullAt(rowIdx) {
+			continue
+		}
+		c.elems = append(c.elems, tree.MustBeDArray(arrays.Get(rowIdx).(*coldataext.Datum).Datum).Array...)
+	}
+	// The cast elements are referenced by the output vector, so they cannot
+	// be reused across batches.
+	castElems := make(tree.Datums, len(c.elems))
+	for start := 0; start < len(c.elems); {
+		const maxBatchMemSize = math.MaxInt64
+		c.scratch, _ = c.allocator.ResetMaybeReallocate(
+			c.scratchTypes, c.scratch, len(c.elems)-start, maxBatchMemSize,
+		)
+		end := start + c.scratch.Capacity()
+		if end > len(c.elems) {
+			end = len(c.elems)
+		}
+		elemVec := c.scratch.ColVec(0)
+		c.allocator.PerformOperation([]coldata.Vec{elemVec}, func() {
+			for i, e := range c.elems[start:end] {
+				if e == tree.DNull {
+					elemVec.Nulls().SetNull(i)
+				} else {
+					coldata.SetValueAt(elemVec, c.toPhysical(e), i)
+				}
+			}
+		})
+		c.scratch.SetLength(end - start)
+		c.elemInput.SetBatch(c.scratch)
+		castBatch := c.elemCast.Next()
+		colconv.ColVecToDatum(castElems[start:end], castBatch.ColVec(1), end-start, nil /* sel */, &c.da)
+		start = end
+	}
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	outputCol := outputVec.Datum()
+	c.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if nulls.MaybeHasNulls() && nulls.NullAt(rowIdx) {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			arrayLen := tree.MustBeDArray(arrays.Get(rowIdx).(*coldataext.Datum).Datum).Len()
+			res := tree.NewDArray(c.toElemType)
+			res.Array = castElems[:arrayLen:arrayLen]
+			castElems = castElems[arrayLen:]
+			for _, e := range res.Array {
+				if e == tree.DNull {
+					res.HasNulls = true
+				} else {
+					res.HasNonNulls = true
+				}
+			}
+			outputCol.Set(rowIdx, res)
+		}
+	})
+	return batch
+}
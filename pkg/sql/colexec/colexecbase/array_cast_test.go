@@ -0,0 +1,150 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayCast(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// makeArray returns the array with the integers in [0, n) in the
+	// representation accepted by the test harness.
+	makeArray := func(n int, typ string) string {
+		elems := make([]string, n)
+		for i := range elems {
+			elems[i] = fmt.Sprint(i)
+		}
+		return fmt.Sprintf("ARRAY[%s]:::%s[]", strings.Join(elems, ","), typ)
+	}
+	// The array is larger than the scratch batch of the operator, so its
+	// elements are cast in several chunks.
+	largeArrayLen := 2*coldata.BatchSize() + 1
+
+	intArray := types.MakeArray(types.Int)
+	testCases := []struct {
+		desc         string
+		inputTuples  colexectestutils.Tuples
+		fromType     *types.T
+		toType       *types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "int to decimal",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]"}, {"ARRAY[NULL]:::INT[]"}, {"ARRAY[]:::INT[]"}, {nil}, {"ARRAY[-2]"},
+			},
+			fromType: intArray,
+			toType:   types.MakeArray(types.Decimal),
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1,NULL,3]", "ARRAY[1,NULL,3]:::DECIMAL[]"},
+				{"ARRAY[NULL]:::INT[]", "ARRAY[NULL]:::DECIMAL[]"},
+				{"ARRAY[]:::INT[]", "ARRAY[]:::DECIMAL[]"},
+				{nil, nil},
+				{"ARRAY[-2]", "ARRAY[-2]:::DECIMAL[]"},
+			},
+		},
+		{
+			desc: "float to int",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[1.4:::FLOAT,NULL,2.5:::FLOAT,3.5:::FLOAT]"}, {nil},
+			},
+			fromType: types.MakeArray(types.Float),
+			toType:   intArray,
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[1.4:::FLOAT,NULL,2.5:::FLOAT,3.5:::FLOAT]", "ARRAY[1,NULL,2,3]"}, {nil, nil},
+			},
+		},
+		{
+			desc: "int to bool",
+			inputTuples: colexectestutils.Tuples{
+				{"ARRAY[0,NULL,7]"},
+			},
+			fromType: intArray,
+			toType:   types.BoolArray,
+			outputTuples: colexectestutils.Tuples{
+				{"ARRAY[0,NULL,7]", "ARRAY[false,NULL,true]"},
+			},
+		},
+		{
+			desc: "large arrays",
+			inputTuples: colexectestutils.Tuples{
+				{makeArray(largeArrayLen, "INT")}, {makeArray(3, "INT")},
+			},
+			fromType: intArray,
+			toType:   types.MakeArray(types.Float),
+			outputTuples: colexectestutils.Tuples{
+				{makeArray(largeArrayLen, "INT"), makeArray(largeArrayLen, "FLOAT")},
+				{makeArray(3, "INT"), makeArray(3, "FLOAT")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		typs := []*types.T{tc.fromType}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexecbase.GetArrayCastOperator(testAllocator, input[0], 0 /* colIdx */, 1 /* resultIdx */, tc.fromType, tc.toType)
+			})
+		// The cast expression is planned using the same operator.
+		expr := fmt.Sprintf("@1::%s", tc.toType.SQLString())
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs, expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The numeric-to-text element casts aren't supported by the cast operator
+	// (the whole datums are cast instead when planning the cast expression).
+	_, err := colexecbase.GetArrayCastOperator(
+		testAllocator, colexecop.NewFeedOperator(), 0 /* colIdx */, 1 /* resultIdx */, intArray, types.StringArray,
+	)
+	require.Error(t, err)
+	colexectestutils.RunTestsWithTyps(t, testAllocator,
+		[]colexectestutils.Tuples{{{"ARRAY[1,NULL,3]"}, {nil}}}, [][]*types.T{{intArray}},
+		colexectestutils.Tuples{{"ARRAY[1,NULL,3]", "ARRAY['1',NULL,'3']"}, {nil, nil}},
+		colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return colexectestutils.CreateTestProjectingOperator(
+				ctx, flowCtx, input[0], []*types.T{intArray}, "@1::STRING[]", false /* canFallbackToRowexec */, testMemAcc,
+			)
+		})
+}
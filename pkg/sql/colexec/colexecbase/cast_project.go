@@ -0,0 +1,151 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// NewCastProjectOp returns an operator that applies a simple projection to
+// its input and casts some of the projected columns in a single pass. The
+// i-th output column is the input column projection[i] cast to
+// outputTypes[i]; the columns that already have the desired type are not
+// copied, and the input vectors are used as is. It replaces the chain of the
+// cast operators followed by the simple project operator, so the casted
+// columns aren't appended to the input batches and the intermediate
+// projecting batch is not needed.
+//
+// An error is returned if some of the casts are not supported.
+func NewCastProjectOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	projection []uint32,
+	outputTypes []*types.T,
+) (colexecop.Operator, error) {
+	c := &castProjectOp{
+		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
+		projection:               make([]uint32, len(projection)),
+		castFeed:                 colexecop.NewFeedOperator(),
+		output:                   coldata.NewMemBatchNoCols(outputTypes, coldata.BatchSize()),
+	}
+	// We make a copy of projection to be safe.
+	copy(c.projection, projection)
+	// The casts are planned on top of the feed operator that returns the
+	// batch with the source columns of the casts, and each cast appends its
+	// result after those.
+	var castSrcTypes []*types.T
+	for i, colIdx := range projection {
+		if !inputTypes[colIdx].Identical(outputTypes[i]) {
+			c.castOutputIdxs = append(c.castOutputIdxs, i)
+			castSrcTypes = append(castSrcTypes, inputTypes[colIdx])
+		}
+	}
+	c.castBatch = coldata.NewMemBatchNoCols(castSrcTypes, coldata.BatchSize())
+	c.casts = c.castFeed
+	for i, outputIdx := range c.castOutputIdxs {
+		var err error
+		c.casts, err = GetCastOperator(
+			allocator, c.casts, i, len(castSrcTypes)+i, castSrcTypes[i], outputTypes[outputIdx],
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+type castProjectOp struct {
+	colexecop.OneInputInitCloserHelper
+
+	projection []uint32
+	// castOutputIdxs contains the indices of the output columns that need to
+	// be cast, in the increasing order.
+	castOutputIdxs []int
+	// castBatch contains the source columns of the casts (the i-th column is
+	// the source of the cast to the output column castOutputIdxs[i]) followed
+	// by the results of the casts (in the same order) that are appended by
+	// the cast operators on the first batch.
+	castBatch coldata.Batch
+	castFeed  *colexecop.FeedOperator
+	// casts is the chain of the cast operators planned on top of castFeed.
+	casts  colexecop.Operator
+	output coldata.Batch
+}
+
+var _ colexecop.ClosableOperator = &castProjectOp{}
+var _ colexecop.ResettableOperator = &castProjectOp{}
+
+func (c *castProjectOp) Init(ctx context.Context) {
+	if !c.InitHelper.Init(ctx) {
+		return
+	}
+	c.Input.Init(c.Ctx)
+	c.casts.Init(c.Ctx)
+}
+
+func (c *castProjectOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	if len(c.castOutputIdxs) > 0 {
+		for i, outputIdx := range c.castOutputIdxs {
+			replaceColIfChanged(c.castBatch, batch.ColVec(int(c.projection[outputIdx])), i)
+		}
+		setLengthAndSelection(c.castBatch, n, sel)
+		c.castFeed.SetBatch(c.castBatch)
+		c.casts.Next()
+	}
+	castIdx := 0
+	for i, colIdx := range c.projection {
+		if castIdx < len(c.castOutputIdxs) && c.castOutputIdxs[castIdx] == i {
+			replaceColIfChanged(c.output, c.castBatch.ColVec(len(c.castOutputIdxs)+castIdx), i)
+			castIdx++
+		} else {
+			replaceColIfChanged(c.output, batch.ColVec(int(colIdx)), i)
+		}
+	}
+	setLengthAndSelection(c.output, n, sel)
+	return c.output
+}
+
+// replaceColIfChanged replaces the column colIdx of the batch with vec unless
+// it is already in place. The input usually returns the same batch every time,
+// so this allows us to skip the type check performed by ReplaceCol.
+func replaceColIfChanged(batch coldata.Batch, vec coldata.Vec, colIdx int) {
+	if batch.ColVec(colIdx) != vec {
+		batch.ReplaceCol(vec, colIdx)
+	}
+}
+
+// setLengthAndSelection sets the length of the batch to n and copies the
+// selection vector sel (if non-nil) into the batch.
+func setLengthAndSelection(batch coldata.Batch, n int, sel []int) {
+	batch.SetSelection(sel != nil)
+	if sel != nil {
+		copy(batch.Selection()[:n], sel[:n])
+	}
+	batch.SetLength(n)
+}
+
+func (c *castProjectOp) Reset(ctx context.Context) {
+	if r, ok := c.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+}
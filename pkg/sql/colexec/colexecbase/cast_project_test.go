@@ -0,0 +1,189 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// planCastsAndProject plans the chain of the cast operators followed by the
+// simple project operator which is equivalent to the cast project operator.
+func planCastsAndProject(
+	input colexecop.Operator, inputTypes []*types.T, projection []uint32, outputTypes []*types.T,
+) (colexecop.Operator, error) {
+	typs := inputTypes[:len(inputTypes):len(inputTypes)]
+	finalProjection := make([]uint32, len(projection))
+	for i, colIdx := range projection {
+		finalProjection[i] = colIdx
+		if inputTypes[colIdx].Identical(outputTypes[i]) {
+			continue
+		}
+		var err error
+		input, err = colexecbase.GetCastOperator(
+			testAllocator, input, int(colIdx), len(typs), inputTypes[colIdx], outputTypes[i],
+		)
+		if err != nil {
+			return nil, err
+		}
+		finalProjection[i] = uint32(len(typs))
+		typs = append(typs, outputTypes[i])
+	}
+	return colexecbase.NewSimpleProjectOp(input, typs, finalProjection), nil
+}
+
+func TestCastProjectOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	decimal := func(s string) apd.Decimal {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		return *d
+	}
+	inputTypes := []*types.T{types.Int2, types.String, types.Int, types.Float}
+	inputTuples := colexectestutils.Tuples{
+		{1, "a", 10, 1.5},
+		{nil, "b", 20, nil},
+		{3, nil, nil, 3.5},
+		{-4, "d", -40, -4.25},
+	}
+	for _, tc := range []struct {
+		desc        string
+		projection  []uint32
+		outputTypes []*types.T
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc:        "reorder without casts",
+			projection:  []uint32{3, 1, 0},
+			outputTypes: []*types.T{types.Float, types.String, types.Int2},
+			expected: colexectestutils.Tuples{
+				{1.5, "a", 1},
+				{nil, "b", nil},
+				{3.5, nil, 3},
+				{-4.25, "d", -4},
+			},
+		},
+		{
+			desc:        "cast without reorder",
+			projection:  []uint32{0, 1, 2, 3},
+			outputTypes: []*types.T{types.Int, types.String, types.Decimal, types.Float},
+			expected: colexectestutils.Tuples{
+				{1, "a", decimal("10"), 1.5},
+				{nil, "b", decimal("20"), nil},
+				{3, nil, nil, 3.5},
+				{-4, "d", decimal("-40"), -4.25},
+			},
+		},
+		{
+			desc:        "reorder and cast",
+			projection:  []uint32{2, 3, 0, 1},
+			outputTypes: []*types.T{types.Float, types.Decimal, types.Int, types.String},
+			expected: colexectestutils.Tuples{
+				{10.0, decimal("1.5"), 1, "a"},
+				{20.0, nil, nil, "b"},
+				{nil, decimal("3.5"), 3, nil},
+				{-40.0, decimal("-4.25"), -4, "d"},
+			},
+		},
+		{
+			desc:        "same column with and without cast",
+			projection:  []uint32{0, 0, 2},
+			outputTypes: []*types.T{types.Int2, types.Float, types.Int},
+			expected: colexectestutils.Tuples{
+				{1, 1.0, 10},
+				{nil, nil, 20},
+				{3, 3.0, nil},
+				{-4, -4.0, -40},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return colexecbase.NewCastProjectOp(testAllocator, inputs[0], inputTypes, tc.projection, tc.outputTypes)
+				},
+			)
+			// Also check that the general path (casts followed by the simple
+			// projection) produces the same result.
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{inputTypes},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return planCastsAndProject(inputs[0], inputTypes, tc.projection, tc.outputTypes)
+				},
+			)
+		})
+	}
+}
+
+func BenchmarkCastProjectOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	// The columns are reversed, and every other one is cast.
+	const numCols = 8
+	inputTypes := make([]*types.T, numCols)
+	outputTypes := make([]*types.T, numCols)
+	projection := make([]uint32, numCols)
+	for i := range inputTypes {
+		inputTypes[i] = types.Int
+		projection[i] = uint32(numCols - i - 1)
+		outputTypes[i] = types.Int
+		if i%2 == 0 {
+			outputTypes[i] = types.Float
+		}
+	}
+	for _, useSel := range []bool{false, true} {
+		selectivity := 0.0
+		if useSel {
+			selectivity = 0.5
+		}
+		batch := coldatatestutils.RandomBatchWithSel(
+			testAllocator, rng, inputTypes, coldata.BatchSize(), 0.1 /* nullProbability */, selectivity,
+		)
+		for _, fused := range []bool{false, true} {
+			b.Run(fmt.Sprintf("useSel=%t/fused=%t", useSel, fused), func(b *testing.B) {
+				source := colexecop.NewRepeatableBatchSource(testAllocator, batch, inputTypes)
+				var op colexecop.Operator
+				var err error
+				if fused {
+					op, err = colexecbase.NewCastProjectOp(testAllocator, source, inputTypes, projection, outputTypes)
+				} else {
+					op, err = planCastsAndProject(source, inputTypes, projection, outputTypes)
+				}
+				require.NoError(b, err)
+				op.Init(ctx)
+				b.SetBytes(int64(8 * numCols * coldata.BatchSize()))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					op.Next()
+				}
+			})
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
@@ -157,6 +158,87 @@ func TestRandomizedCast(t *testing.T) {
 	}
 }
 
+// TestCastChain verifies that the chains of casts produce the same results
+// regardless of whether they are collapsed into a single cast.
+func TestCastChain(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		expr         string
+		typ          *types.T
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			// The widening intermediate type is redundant.
+			expr:         "@1::INT8::INT4",
+			typ:          types.Int2,
+			inputTuples:  colexectestutils.Tuples{{1}, {-3}, {nil}},
+			outputTuples: colexectestutils.Tuples{{1, 1}, {-3, -3}, {nil, nil}},
+		},
+		{
+			expr:         "@1::INT4::INT8::INT2",
+			typ:          types.Int2,
+			inputTuples:  colexectestutils.Tuples{{32767}, {-32768}, {nil}},
+			outputTuples: colexectestutils.Tuples{{32767, 32767}, {-32768, -32768}, {nil, nil}},
+		},
+		{
+			// The intermediate type is identical to the target type.
+			expr:         "@1::INT2::INT2",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{5}, {nil}},
+			outputTuples: colexectestutils.Tuples{{5, 5}, {nil, nil}},
+		},
+		{
+			// The narrowing intermediate type is significant, but all values
+			// are in its range.
+			expr:         "@1::INT2::INT8",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{5}, {-7}, {nil}},
+			outputTuples: colexectestutils.Tuples{{5, 5}, {-7, -7}, {nil, nil}},
+		},
+		{
+			// The round trip through BOOL is significant.
+			expr:         "@1::BOOL::INT8",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{5}, {0}, {nil}},
+			outputTuples: colexectestutils.Tuples{{5, 1}, {0, 0}, {nil, nil}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		typs := []*types.T{tc.typ}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The cast through the narrower type must not be collapsed since the value
+	// is out of its range.
+	typs := []*types.T{types.Int}
+	input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{100000}}, typs)
+	op, err := colexectestutils.CreateTestProjectingOperator(
+		ctx, flowCtx, input, typs, "@1::INT2::INT8", false /* canFallbackToRowexec */, testMemAcc,
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+	require.EqualError(t, err, tree.ErrInt2OutOfRange.Error())
+}
+
 func BenchmarkCastOp(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
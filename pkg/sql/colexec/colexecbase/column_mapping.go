@@ -0,0 +1,145 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// NewColumnMappingOp returns an operator that fills in the constant columns
+// and applies a simple projection to the resulting batch in a single pass. It
+// is used for the render expressions that consist only of the references to
+// the input columns and of the constants, the most common example of which is
+// the projection planned for an INSERT that maps the input columns to the
+// columns of the table and fills in the default values of the columns that
+// weren't provided. (The computed columns are evaluated by the projection
+// operators planned on top of the input, so their results are input columns
+// too.) The operator replaces the chain of the constant operators followed by
+// the simple project operator.
+//
+// The constants are placed at the columns [numInputCols,
+// numInputCols+len(constTypes)), in order, and the projection can refer to
+// both the input columns and the constants. constVals contains the physical
+// representations of the constants with nil values used for NULLs.
+func NewColumnMappingOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	numInputCols int,
+	constTypes []*types.T,
+	constVals []interface{},
+	projection []uint32,
+) colexecop.Operator {
+	return &columnMappingOp{
+		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
+		batchProjector:           makeBatchProjector(projection),
+		allocator:                allocator,
+		numInputCols:             numInputCols,
+		constTypes:               constTypes,
+		constVals:                constVals,
+		filledVecs:               make([]coldata.Vec, len(constTypes)),
+		filledLens:               make([]int, len(constTypes)),
+	}
+}
+
+type columnMappingOp struct {
+	colexecop.OneInputInitCloserHelper
+	batchProjector
+
+	allocator    *colmem.Allocator
+	numInputCols int
+	constTypes   []*types.T
+	constVals    []interface{}
+	// constBatch contains a column for each constant that has all values set
+	// to the constant, so that the constant columns of each batch can be
+	// filled in with a single copy. The batch is lazily allocated and
+	// reallocated if the input batch requires larger capacity.
+	constBatch coldata.Batch
+	// filledVecs and filledLens track the vector that each constant was last
+	// copied into and the number of values copied. The input usually reuses
+	// the same batch, and resetting the batch doesn't modify the values of
+	// the fixed-width vectors, so such a vector doesn't need to be filled in
+	// again as long as the batch doesn't get longer. The bytes-like vectors
+	// are reset by the batch, so they are always filled in.
+	filledVecs []coldata.Vec
+	filledLens []int
+}
+
+var _ colexecop.ClosableOperator = &columnMappingOp{}
+
+func (c *columnMappingOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	// fillLen is the number of values in the constant columns that can be
+	// accessed by the batch.
+	fillLen := n
+	if sel := batch.Selection(); sel != nil {
+		// Note that here we rely on the fact that selection vectors are
+		// increasing sequences.
+		fillLen = sel[n-1] + 1
+	}
+	c.maybeAllocateConstBatch(fillLen)
+	for i, typ := range c.constTypes {
+		c.allocator.MaybeAppendColumn(batch, typ, c.numInputCols+i)
+	}
+	constCols := batch.ColVecs()[c.numInputCols : c.numInputCols+len(c.constTypes)]
+	c.allocator.PerformOperation(constCols, func() {
+		for i, vec := range constCols {
+			if c.constVals[i] == nil {
+				vec.Nulls().SetNulls()
+				continue
+			}
+			if !vec.IsBytesLike() && vec == c.filledVecs[i] && fillLen <= c.filledLens[i] {
+				continue
+			}
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:       c.constBatch.ColVec(i),
+						SrcEndIdx: fillLen,
+					},
+				},
+			)
+			c.filledVecs[i], c.filledLens[i] = vec, fillLen
+		}
+	})
+	return c.project(c.Ctx, batch)
+}
+
+// maybeAllocateConstBatch makes sure that constBatch has at least capacity
+// values set to the constants.
+func (c *columnMappingOp) maybeAllocateConstBatch(capacity int) {
+	if c.constBatch != nil && c.constBatch.Capacity() >= capacity {
+		return
+	}
+	if c.constBatch != nil {
+		c.allocator.ReleaseMemory(colmem.GetBatchMemSize(c.constBatch))
+	}
+	if capacity < coldata.BatchSize() {
+		capacity = coldata.BatchSize()
+	}
+	c.constBatch = c.allocator.NewMemBatchWithFixedCapacity(c.constTypes, capacity)
+	c.allocator.PerformOperation(c.constBatch.ColVecs(), func() {
+		for i, vec := range c.constBatch.ColVecs() {
+			if c.constVals[i] == nil {
+				continue
+			}
+			for j := 0; j < capacity; j++ {
+				coldata.SetValueAt(vec, c.constVals[i], j)
+			}
+		}
+	})
+}
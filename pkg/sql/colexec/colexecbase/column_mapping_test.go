@@ -0,0 +1,203 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnMappingOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tuples := colexectestutils.Tuples{
+		{1, "a"},
+		{2, nil},
+		{nil, "c"},
+	}
+	typs := []*types.T{types.Int, types.Bytes}
+	for _, tc := range []struct {
+		desc       string
+		constTypes []*types.T
+		constVals  []interface{}
+		projection []uint32
+		expected   colexectestutils.Tuples
+	}{
+		{
+			desc:       "constants between input columns",
+			constTypes: []*types.T{types.Int, types.Bytes},
+			constVals:  []interface{}{int64(10), []byte("x")},
+			projection: []uint32{1, 2, 0, 3},
+			expected: colexectestutils.Tuples{
+				{"a", 10, 1, "x"},
+				{nil, 10, 2, "x"},
+				{"c", 10, nil, "x"},
+			},
+		},
+		{
+			desc:       "null constants",
+			constTypes: []*types.T{types.Int, types.Unknown},
+			constVals:  []interface{}{nil, nil},
+			projection: []uint32{0, 2, 3},
+			expected: colexectestutils.Tuples{
+				{1, nil, nil},
+				{2, nil, nil},
+				{nil, nil, nil},
+			},
+		},
+		{
+			desc:       "constant used twice and input column dropped",
+			constTypes: []*types.T{types.Float},
+			constVals:  []interface{}{1.5},
+			projection: []uint32{2, 0, 2},
+			expected: colexectestutils.Tuples{
+				{1.5, 1, 1.5},
+				{1.5, 2, 1.5},
+				{1.5, nil, 1.5},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return colexecbase.NewColumnMappingOp(
+						testAllocator, input[0], len(typs), tc.constTypes, tc.constVals, tc.projection,
+					), nil
+				})
+		})
+	}
+}
+
+// TestColumnMappingPlanning verifies that the render expressions of the
+// projection that the optimizer plans for an INSERT into a table with the
+// default values and a computed column are planned with the column mapping
+// operator. The table is
+//   CREATE TABLE t (
+//     a INT, b INT DEFAULT 10, c INT AS (a + b) STORED, d STRING DEFAULT 'x',
+//     e INT, f INT
+//   )
+// and the statement is INSERT INTO t (e, a) SELECT v, k FROM src.
+func TestColumnMappingPlanning(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	inputTypes := []*types.T{types.Int, types.Int}
+	renderExprs := []execinfrapb.Expression{
+		{Expr: "@1"}, {Expr: "10"}, {Expr: "@1 + 10"}, {Expr: "'x'"}, {Expr: "@2"}, {Expr: "NULL::INT8"},
+	}
+	resultTypes := []*types.T{types.Int, types.Int, types.Int, types.String, types.Int, types.Int}
+	tuples := colexectestutils.Tuples{{1, 2}, {3, nil}, {nil, 4}}
+	expected := colexectestutils.Tuples{
+		{1, 10, 11, "x", 2, nil},
+		{3, 10, 13, "x", nil, nil},
+		{nil, 10, nil, "x", 4, nil},
+	}
+	colexectestutils.RunTestsWithTyps(
+		t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{inputTypes},
+		expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			args := &colexecargs.NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input:       []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+					Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+					Post:        execinfrapb.PostProcessSpec{RenderExprs: renderExprs},
+					ResultTypes: resultTypes,
+				},
+				Inputs:              []colexecargs.OpWithMetaInfo{{Root: input[0]}},
+				StreamingMemAccount: testMemAcc,
+			}
+			result, err := colexecargs.TestNewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			require.Equal(t, "*colexecbase.columnMappingOp", fmt.Sprintf("%T", result.Root))
+			return result.Root, nil
+		})
+}
+
+// BenchmarkColumnMappingOp compares the column mapping operator that fills in
+// several constant columns against the chain of the constant operators (one
+// per constant) followed by the simple project operator that was planned for
+// the same render expressions before, for a query like
+//   SELECT 1, 'a', true, 3.14 FROM t
+func BenchmarkColumnMappingOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int}
+	constTypes := []*types.T{types.Int, types.Bytes, types.Bool, types.Decimal}
+	var dec apd.Decimal
+	_, _, err := dec.SetString("3.14")
+	require.NoError(b, err)
+	constVals := []interface{}{int64(1), []byte("a"), true, dec}
+	projection := []uint32{1, 2, 3, 4}
+	for _, useSel := range []bool{false, true} {
+		selectivity := 0.0
+		if useSel {
+			selectivity = 0.5
+		}
+		batch := coldatatestutils.RandomBatchWithSel(
+			testAllocator, rng, typs, coldata.BatchSize(), 0 /* nullProbability */, selectivity,
+		)
+		for _, fused := range []bool{false, true} {
+			b.Run(fmt.Sprintf("useSel=%t/fused=%t", useSel, fused), func(b *testing.B) {
+				var op colexecop.Operator = colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+				if fused {
+					op = colexecbase.NewColumnMappingOp(
+						testAllocator, op, len(typs), constTypes, constVals, projection,
+					)
+				} else {
+					for i, typ := range constTypes {
+						op, err = colexecbase.NewConstOp(testAllocator, op, typ, constVals[i], len(typs)+i)
+						require.NoError(b, err)
+					}
+					op = colexecbase.NewSimpleProjectOp(op, append(typs[:len(typs):len(typs)], constTypes...), projection)
+				}
+				op.Init(ctx)
+				b.SetBytes(int64(len(constTypes) * 8 * coldata.BatchSize()))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					op.Next()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+)
+
+// GetEnumCastOperator returns an operator that casts the column at position
+// colIdx into the column of type toType at position resultIdx. Either an enum
+// is cast to its label (fromType is an enum and toType is a STRING type), or a
+// label is cast to the enum (fromType is a STRING type and toType is an enum).
+// The enum metadata of the type is used to perform the mapping, so an error is
+// returned if the enum type is not hydrated.
+func GetEnumCastOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	fromType *types.T,
+	toType *types.T,
+) (colexecop.Operator, error) {
+	var enumType *types.T
+	switch {
+	case fromType.Family() == types.EnumFamily && toType.Family() == types.StringFamily:
+		enumType = fromType
+	case fromType.Family() == types.StringFamily && toType.Family() == types.EnumFamily:
+		enumType = toType
+	default:
+		return nil, errors.Errorf("unhandled enum cast %s -> %s", fromType, toType)
+	}
+	if enumType.TypeMeta.EnumData == nil {
+		return nil, errors.Errorf("unhydrated enum type %s", enumType)
+	}
+	op := &enumCastOp{
+		allocator: allocator,
+		colIdx:    colIdx,
+		outputIdx: resultIdx,
+		toType:    toType,
+	}
+	if toType.Family() == types.EnumFamily {
+		logicalReps := toType.TypeMeta.EnumData.LogicalRepresentations
+		op.labelToIdx = make(map[string]int, len(logicalReps))
+		for i, label := range logicalReps {
+			op.labelToIdx[label] = i
+		}
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, toType, resultIdx)
+	op.OneInputHelper = colexecop.MakeOneInputHelper(input)
+	return op, nil
+}
+
+// enumCastOp casts either the enums to their labels or the labels to the enums
+// of toType. Same as in the row engine, the labels are truncated to the width
+// of the STRING type, and casting an unknown label or the label of a member
+// that is not yet public results in an error.
+type enumCastOp struct {
+	colexecop.OneInputHelper
+
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+	toType    *types.T
+	// labelToIdx maps the labels of toType to their positions in the enum
+	// metadata. It is only set when casting to an enum.
+	labelToIdx map[string]int
+}
+
+var _ colexecop.Operator = &enumCastOp{}
+
+func (c *enumCastOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(c.colIdx)
+	nulls := vec.Nulls()
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	c.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		if c.labelToIdx == nil {
+			col, outputCol := vec.Datum(), outputVec.Bytes()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol.Set(rowIdx, []byte(c.enumToLabel(col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DEnum))))
+			}
+			return
+		}
+		col, outputCol := vec.Bytes(), outputVec.Datum()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if nulls.NullAt(rowIdx) {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			outputCol.Set(rowIdx, c.labelToEnum(string(col.Get(rowIdx))))
+		}
+	})
+	return batch
+}
+
+// enumToLabel returns the label of the enum d as a value of toType.
+func (c *enumCastOp) enumToLabel(d *tree.DEnum) string {
+	s := d.LogicalRep
+	if c.toType.Oid() == oid.T_bpchar {
+		s = strings.TrimRight(s, " ")
+	}
+	if c.toType.Width() > 0 {
+		s = util.TruncateString(s, int(c.toType.Width()))
+	}
+	return s
+}
+
+// labelToEnum returns the member of toType with the given label.
+func (c *enumCastOp) labelToEnum(label string) *tree.DEnum {
+	enumData := c.toType.TypeMeta.EnumData
+	idx, ok := c.labelToIdx[label]
+	if !ok || enumData.IsMemberReadOnly[idx] {
+		// The row engine is used in order to get the same error.
+		_, err := tree.MakeDEnumFromLogicalRepresentation(c.toType, label)
+		if err == nil {
+			err = errors.AssertionFailedf("unexpectedly cast %q to enum %s", label, c.toType)
+		}
+		colexecerror.ExpectedError(err)
+	}
+	return &tree.DEnum{
+		EnumTyp:     c.toType,
+		PhysicalRep: enumData.PhysicalRepresentations[idx],
+		LogicalRep:  enumData.LogicalRepresentations[idx],
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumCast(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	enumType := colexectestutils.MakeTestEnumType("small", "medium", "large")
+	mustMake := func(label string) *tree.DEnum {
+		d, err := tree.MakeDEnumFromLogicalRepresentation(enumType, label)
+		require.NoError(t, err)
+		return d
+	}
+	small, medium, large := mustMake("small"), mustMake("medium"), mustMake("large")
+
+	for _, tc := range []struct {
+		desc         string
+		inputTuples  colexectestutils.Tuples
+		fromType     *types.T
+		toType       *types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:        "enum to string",
+			inputTuples: colexectestutils.Tuples{{small}, {large}, {nil}, {medium}},
+			fromType:    enumType,
+			toType:      types.String,
+			outputTuples: colexectestutils.Tuples{
+				{small, "small"}, {large, "large"}, {nil, nil}, {medium, "medium"},
+			},
+		},
+		{
+			desc:        "enum to varchar",
+			inputTuples: colexectestutils.Tuples{{small}, {large}, {nil}, {medium}},
+			fromType:    enumType,
+			toType:      types.MakeVarChar(4),
+			outputTuples: colexectestutils.Tuples{
+				{small, "smal"}, {large, "larg"}, {nil, nil}, {medium, "medi"},
+			},
+		},
+		{
+			desc:        "string to enum",
+			inputTuples: colexectestutils.Tuples{{"medium"}, {nil}, {"small"}, {"large"}},
+			fromType:    types.String,
+			toType:      enumType,
+			outputTuples: colexectestutils.Tuples{
+				{"medium", medium}, {nil, nil}, {"small", small}, {"large", large},
+			},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		typs := []*types.T{tc.fromType}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexecbase.GetEnumCastOperator(testAllocator, input[0], 0 /* colIdx */, 1 /* resultIdx */, tc.fromType, tc.toType)
+			})
+		if tc.fromType.Family() == types.EnumFamily {
+			// The cast expression is planned using the same operator (the
+			// enum type can't be spelled out in the expression, so only the
+			// casts from the enums are checked).
+			expr := "@1::" + tc.toType.SQLString()
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], typs, expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+				})
+		}
+	}
+
+	// Casting an unknown label results in the same error as in the row
+	// engine.
+	input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"huge"}}, []*types.T{types.String})
+	op, err := colexecbase.GetEnumCastOperator(testAllocator, input, 0 /* colIdx */, 1 /* resultIdx */, types.String, enumType)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+	require.EqualError(t, err, `invalid input value for enum test_enum: "huge"`)
+
+	// The casts between the enums and other types aren't supported.
+	_, err = colexecbase.GetEnumCastOperator(
+		testAllocator, colexecop.NewFeedOperator(), 0 /* colIdx */, 1 /* resultIdx */, enumType, types.Int,
+	)
+	require.Error(t, err)
+}
@@ -19,7 +19,8 @@ import (
 )
 
 // ordinalityOp is an operator that implements WITH ORDINALITY, which adds
-// an additional column to the result with an ordinal number.
+// an additional column to the result with an ordinal number. It is also used
+// to add a sequence of numbers with the configurable start and step.
 type ordinalityOp struct {
 	colexecop.OneInputHelper
 
@@ -27,8 +28,10 @@ type ordinalityOp struct {
 	// outputIdx is the index of the column in which ordinalityOp will write the
 	// ordinal number.
 	outputIdx int
-	// counter is the number of tuples seen so far.
+	// counter is the number that will be written for the next tuple.
 	counter int64
+	// step is the difference between the numbers of the consecutive tuples.
+	step int64
 }
 
 var _ colexecop.Operator = &ordinalityOp{}
@@ -36,13 +39,26 @@ var _ colexecop.Operator = &ordinalityOp{}
 // NewOrdinalityOp returns a new WITH ORDINALITY operator.
 func NewOrdinalityOp(
 	allocator *colmem.Allocator, input colexecop.Operator, outputIdx int,
+) colexecop.Operator {
+	return NewSequenceOp(allocator, input, outputIdx, 1 /* start */, 1 /* step */)
+}
+
+// NewSequenceOp returns a new operator that writes the numbers start,
+// start+step, start+2*step, and so on into the Int column at position
+// outputIdx. The numbers are assigned to the tuples in the order in which they
+// are emitted by the input (i.e. only the selected tuples are numbered), and
+// the sequence continues across the batches, so it doesn't depend on the
+// batch boundaries.
+func NewSequenceOp(
+	allocator *colmem.Allocator, input colexecop.Operator, outputIdx int, start, step int64,
 ) colexecop.Operator {
 	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
 	c := &ordinalityOp{
 		OneInputHelper: colexecop.MakeOneInputHelper(input),
 		allocator:      allocator,
 		outputIdx:      outputIdx,
-		counter:        1,
+		counter:        start,
+		step:           step,
 	}
 	return c
 }
@@ -66,14 +82,14 @@ func (c *ordinalityOp) Next() coldata.Batch {
 		// Bounds check elimination.
 		for _, i := range sel[:bat.Length()] {
 			col[i] = c.counter
-			c.counter++
+			c.counter += c.step
 		}
 	} else {
 		// Bounds check elimination.
 		col = col[:bat.Length()]
 		for i := range col {
 			col[i] = c.counter
-			c.counter++
+			c.counter += c.step
 		}
 	}
 
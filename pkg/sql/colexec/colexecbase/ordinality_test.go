@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -76,6 +77,65 @@ func TestOrdinality(t *testing.T) {
 	}
 }
 
+func TestSequence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The input spans multiple batches, and the sequence must continue across
+	// the batch boundaries.
+	const start, step = 10, 3
+	numTuples := 3*coldata.BatchSize() + 5
+	tuples := make(colexectestutils.Tuples, numTuples)
+	expected := make(colexectestutils.Tuples, numTuples)
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{i}
+		expected[i] = colexectestutils.Tuple{i, start + step*i}
+	}
+	colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tuples}, expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return colexecbase.NewSequenceOp(testAllocator, input[0], 1 /* outputIdx */, start, step), nil
+		})
+
+	// Only the tuples that are selected by the filter (which sets the
+	// selection vector on the batches) are numbered.
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	typs := []*types.T{types.Int}
+	expected = expected[:0]
+	for i := range tuples {
+		if i%3 == 0 {
+			expected = append(expected, colexectestutils.Tuple{i, -5 + 2*len(expected)})
+		}
+	}
+	colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tuples}, expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			args := &colexecargs.NewColOperatorArgs{
+				Spec: &execinfrapb.ProcessorSpec{
+					Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+					Core: execinfrapb.ProcessorCoreUnion{
+						Filterer: &execinfrapb.FiltererSpec{Filter: execinfrapb.Expression{Expr: "@1 % 3 = 0"}},
+					},
+					ResultTypes: typs,
+				},
+				Inputs:              []colexecargs.OpWithMetaInfo{{Root: input[0]}},
+				StreamingMemAccount: testMemAcc,
+			}
+			result, err := colexecargs.TestNewColOperator(ctx, flowCtx, args)
+			if err != nil {
+				return nil, err
+			}
+			return colexecbase.NewSequenceOp(testAllocator, result.Root, 1 /* outputIdx */, -5 /* start */, 2 /* step */), nil
+		})
+}
+
 func BenchmarkOrdinality(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
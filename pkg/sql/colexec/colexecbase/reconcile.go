@@ -0,0 +1,178 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// numericTypeRank returns the position of t in the hierarchy of the valid
+// implicit numeric casts
+//   INT2 -> INT4 -> INT8 -> FLOAT -> DECIMAL
+// (a value can be cast to any type that follows its type in this chain
+// without losing information) or -1 if t is not numeric.
+func numericTypeRank(t *types.T) int {
+	switch t.Family() {
+	case types.IntFamily:
+		switch t.Width() {
+		case 16:
+			return 0
+		case 32:
+			return 1
+		default:
+			return 2
+		}
+	case types.FloatFamily:
+		return 3
+	case types.DecimalFamily:
+		return 4
+	}
+	return -1
+}
+
+// CommonSupertype returns the type that values of both left and right types
+// can be cast to, or nil if there is no such type. Only the identical types,
+// the numeric types from the INT2 -> INT4 -> INT8 -> FLOAT -> DECIMAL chain
+// and the unknown (NULL) type are reconciled.
+func CommonSupertype(left, right *types.T) *types.T {
+	if left.Identical(right) {
+		return left
+	}
+	if left.Family() == types.UnknownFamily {
+		return right
+	}
+	if right.Family() == types.UnknownFamily {
+		return left
+	}
+	leftRank, rightRank := numericTypeRank(left), numericTypeRank(right)
+	if leftRank == -1 || rightRank == -1 {
+		return nil
+	}
+	switch {
+	case leftRank > rightRank:
+		return left
+	case leftRank < rightRank:
+		return right
+	case left.Family() == types.FloatFamily:
+		// The types differ only in width (FLOAT4 and FLOAT8).
+		return types.Float
+	default:
+		// The types are decimals that differ in precision or scale.
+		return types.Decimal
+	}
+}
+
+// ReconcileSchemas plans the cast operators on top of left and right inputs so
+// that both of them produce the same schema, which is returned along with the
+// new inputs. For every pair of columns with different types, the column of
+// the narrower type is cast to the common supertype (see CommonSupertype). An
+// error is returned at plan time if the inputs have a different number of
+// columns or if some types cannot be matched. opName is used in the error
+// message (e.g. "UNION" or "INTERSECT").
+func ReconcileSchemas(
+	allocator *colmem.Allocator,
+	left, right colexecop.Operator,
+	leftTypes, rightTypes []*types.T,
+	opName string,
+) (_, _ colexecop.Operator, commonTypes []*types.T, _ error) {
+	if len(leftTypes) != len(rightTypes) {
+		return nil, nil, nil, pgerror.Newf(
+			pgcode.Syntax, "each %s query must have the same number of columns: %d vs %d",
+			opName, len(leftTypes), len(rightTypes),
+		)
+	}
+	commonTypes = make([]*types.T, len(leftTypes))
+	for i := range leftTypes {
+		commonTypes[i] = CommonSupertype(leftTypes[i], rightTypes[i])
+		if commonTypes[i] == nil {
+			return nil, nil, nil, pgerror.Newf(
+				pgcode.DatatypeMismatch, "%s types %s and %s cannot be matched",
+				opName, leftTypes[i], rightTypes[i],
+			)
+		}
+	}
+	var err error
+	if left, err = castToSchema(allocator, left, leftTypes, commonTypes); err != nil {
+		return nil, nil, nil, err
+	}
+	if right, err = castToSchema(allocator, right, rightTypes, commonTypes); err != nil {
+		return nil, nil, nil, err
+	}
+	return left, right, commonTypes, nil
+}
+
+// ReconcileEqualityColumns is similar to ReconcileSchemas, but it only
+// reconciles the types of the corresponding equality columns of the inputs
+// (leftEqCols[i] and rightEqCols[i]), which can be positioned differently in
+// each input, and all other columns are left intact. The columns of the same
+// type family aren't reconciled since they can be compared without the casts
+// (e.g. INT2 and INT8). The new schemas of the inputs are returned along with
+// the new inputs.
+func ReconcileEqualityColumns(
+	allocator *colmem.Allocator,
+	left, right colexecop.Operator,
+	leftTypes, rightTypes []*types.T,
+	leftEqCols, rightEqCols []uint32,
+	opName string,
+) (_, _ colexecop.Operator, newLeftTypes, newRightTypes []*types.T, _ error) {
+	newLeftTypes = append([]*types.T(nil), leftTypes...)
+	newRightTypes = append([]*types.T(nil), rightTypes...)
+	for i := range leftEqCols {
+		leftIdx, rightIdx := leftEqCols[i], rightEqCols[i]
+		leftType, rightType := leftTypes[leftIdx], rightTypes[rightIdx]
+		if leftType.Family() == rightType.Family() {
+			continue
+		}
+		commonType := CommonSupertype(leftType, rightType)
+		if commonType == nil {
+			return nil, nil, nil, nil, pgerror.Newf(
+				pgcode.DatatypeMismatch, "%s types %s and %s cannot be matched",
+				opName, leftType, rightType,
+			)
+		}
+		newLeftTypes[leftIdx], newRightTypes[rightIdx] = commonType, commonType
+	}
+	var err error
+	if left, err = castToSchema(allocator, left, leftTypes, newLeftTypes); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if right, err = castToSchema(allocator, right, rightTypes, newRightTypes); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return left, right, newLeftTypes, newRightTypes, nil
+}
+
+// castToSchema plans the casts of all columns of input whose types differ from
+// the corresponding ones in targetTypes. input is returned unchanged if no
+// casts are needed.
+func castToSchema(
+	allocator *colmem.Allocator, input colexecop.Operator, inputTypes, targetTypes []*types.T,
+) (colexecop.Operator, error) {
+	needsCast := false
+	for i := range inputTypes {
+		if !inputTypes[i].Identical(targetTypes[i]) {
+			needsCast = true
+			break
+		}
+	}
+	if !needsCast {
+		return input, nil
+	}
+	projection := make([]uint32, len(inputTypes))
+	for i := range projection {
+		projection[i] = uint32(i)
+	}
+	return NewCastProjectOp(allocator, input, inputTypes, projection, targetTypes)
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecbase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileSchemas(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		desc                  string
+		leftTypes, rightTypes []*types.T
+		left, right           colexectestutils.Tuples
+		expectedTypes         []*types.T
+		// expectedLeft and expectedRight are the outputs of the reconciled
+		// inputs. If unset, the inputs are expected to be unchanged.
+		expectedLeft, expectedRight colexectestutils.Tuples
+	}{
+		{
+			desc:          "identical schemas",
+			leftTypes:     []*types.T{types.Int, types.String},
+			rightTypes:    []*types.T{types.Int, types.String},
+			left:          colexectestutils.Tuples{{1, "a"}, {nil, "b"}},
+			right:         colexectestutils.Tuples{{2, nil}},
+			expectedTypes: []*types.T{types.Int, types.String},
+		},
+		{
+			desc:          "ints of different widths",
+			leftTypes:     []*types.T{types.Int2, types.Int},
+			rightTypes:    []*types.T{types.Int4, types.Int2},
+			left:          colexectestutils.Tuples{{1, 2}, {nil, 3}},
+			right:         colexectestutils.Tuples{{4, 5}, {6, nil}},
+			expectedTypes: []*types.T{types.Int4, types.Int},
+		},
+		{
+			desc:          "int and float",
+			leftTypes:     []*types.T{types.String, types.Float},
+			rightTypes:    []*types.T{types.String, types.Int},
+			left:          colexectestutils.Tuples{{"a", 1.5}},
+			right:         colexectestutils.Tuples{{"b", 2}, {"c", nil}},
+			expectedTypes: []*types.T{types.String, types.Float},
+			expectedRight: colexectestutils.Tuples{{"b", 2.0}, {"c", nil}},
+		},
+		{
+			desc:          "float4 and float8",
+			leftTypes:     []*types.T{types.Float4},
+			rightTypes:    []*types.T{types.Float},
+			left:          colexectestutils.Tuples{{0.5}},
+			right:         colexectestutils.Tuples{{1.25}},
+			expectedTypes: []*types.T{types.Float},
+		},
+		{
+			desc:          "unknown and int",
+			leftTypes:     []*types.T{types.Int, types.Unknown},
+			rightTypes:    []*types.T{types.Unknown, types.Int},
+			left:          colexectestutils.Tuples{{1, nil}},
+			right:         colexectestutils.Tuples{{nil, 2}},
+			expectedTypes: []*types.T{types.Int, types.Int},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			left := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, tc.left, tc.leftTypes)
+			right := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, tc.right, tc.rightTypes)
+			newLeft, newRight, typs, err := colexecbase.ReconcileSchemas(
+				testAllocator, left, right, tc.leftTypes, tc.rightTypes, "UNION",
+			)
+			require.NoError(t, err)
+			require.Len(t, typs, len(tc.expectedTypes))
+			for i := range typs {
+				require.Truef(t, typs[i].Identical(tc.expectedTypes[i]), "expected %s, got %s", tc.expectedTypes[i], typs[i])
+			}
+			expectedLeft, expectedRight := tc.expectedLeft, tc.expectedRight
+			if expectedLeft == nil {
+				expectedLeft = tc.left
+			}
+			if expectedRight == nil {
+				expectedRight = tc.right
+			}
+			newLeft.Init(ctx)
+			require.NoError(t, colexectestutils.NewOpTestOutput(newLeft, expectedLeft).Verify())
+			newRight.Init(ctx)
+			require.NoError(t, colexectestutils.NewOpTestOutput(newRight, expectedRight).Verify())
+		})
+	}
+
+	// Incompatible schemas result in an error at plan time.
+	for _, tc := range []struct {
+		leftTypes, rightTypes []*types.T
+		expectedCode          pgcode.Code
+		expectedErr           string
+	}{
+		{
+			leftTypes:    []*types.T{types.Int, types.Int},
+			rightTypes:   []*types.T{types.Int, types.String},
+			expectedCode: pgcode.DatatypeMismatch,
+			expectedErr:  "UNION types int and string cannot be matched",
+		},
+		{
+			leftTypes:    []*types.T{types.Decimal},
+			rightTypes:   []*types.T{types.Interval},
+			expectedCode: pgcode.DatatypeMismatch,
+			expectedErr:  "UNION types decimal and interval cannot be matched",
+		},
+		{
+			leftTypes:    []*types.T{types.Int},
+			rightTypes:   []*types.T{types.Int, types.Int},
+			expectedCode: pgcode.Syntax,
+			expectedErr:  "each UNION query must have the same number of columns: 1 vs 2",
+		},
+	} {
+		left := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, nil /* tuples */, tc.leftTypes)
+		right := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, nil /* tuples */, tc.rightTypes)
+		_, _, _, err := colexecbase.ReconcileSchemas(
+			testAllocator, left, right, tc.leftTypes, tc.rightTypes, "UNION",
+		)
+		require.EqualError(t, err, tc.expectedErr)
+		require.Equal(t, tc.expectedCode, pgerror.GetPGCode(err))
+	}
+}
+
+func TestReconcileEqualityColumns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	// The equality columns are positioned differently in the inputs, and the
+	// other columns can't be reconciled but must be left intact.
+	leftTypes := []*types.T{types.Int2, types.Bool, types.Int}
+	rightTypes := []*types.T{types.Float, types.Int, types.Uuid}
+	leftEqCols, rightEqCols := []uint32{0, 2}, []uint32{1, 0}
+	left := colexectestutils.NewOpTestInput(
+		testAllocator, 1 /* batchSize */, colexectestutils.Tuples{{1, true, 2}, {3, nil, nil}}, leftTypes,
+	)
+	right := colexectestutils.NewOpTestInput(
+		testAllocator, 1 /* batchSize */, colexectestutils.Tuples{{0.5, 4, nil}}, rightTypes,
+	)
+	newLeft, newRight, newLeftTypes, newRightTypes, err := colexecbase.ReconcileEqualityColumns(
+		testAllocator, left, right, leftTypes, rightTypes, leftEqCols, rightEqCols, "INTERSECT",
+	)
+	require.NoError(t, err)
+	// INT2 and INT8 are of the same type family, so only the INT8 column is
+	// cast to FLOAT.
+	require.Equal(t, []*types.T{types.Int2, types.Bool, types.Float}, newLeftTypes)
+	require.Equal(t, rightTypes, newRightTypes)
+	newLeft.Init(ctx)
+	require.NoError(t, colexectestutils.NewOpTestOutput(
+		newLeft, colexectestutils.Tuples{{1, true, 2.0}, {3, nil, nil}},
+	).Verify())
+	newRight.Init(ctx)
+	require.NoError(t, colexectestutils.NewOpTestOutput(
+		newRight, colexectestutils.Tuples{{0.5, 4, nil}},
+	).Verify())
+
+	// Incompatible equality columns result in an error at plan time.
+	_, _, _, _, err = colexecbase.ReconcileEqualityColumns(
+		testAllocator, left, right, leftTypes, rightTypes, []uint32{1}, []uint32{2}, "EXCEPT",
+	)
+	require.EqualError(t, err, "EXCEPT types bool and uuid cannot be matched")
+	require.Equal(t, pgcode.DatatypeMismatch, pgerror.GetPGCode(err))
+}
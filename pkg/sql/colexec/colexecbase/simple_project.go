@@ -15,6 +15,8 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
@@ -23,13 +25,10 @@ import (
 type simpleProjectOp struct {
 	colexecop.OneInputInitCloserHelper
 	colexecop.NonExplainable
-
-	projection []uint32
-	batches    map[coldata.Batch]*projectingBatch
-	// numBatchesLoggingThreshold is the threshold on the number of items in
-	// 'batches' map at which we will log a message when a new projectingBatch
-	// is created. It is growing exponentially.
-	numBatchesLoggingThreshold int
+	batchProjector
+	// inputTypes are the types of the columns of the batches returned by the
+	// input.
+	inputTypes []*types.T
 }
 
 var _ colexecop.ClosableOperator = &simpleProjectOp{}
@@ -86,15 +85,83 @@ func (b *projectingBatch) ReplaceCol(col coldata.Vec, idx int) {
 	b.Batch.ReplaceCol(col, int(b.projection[idx]))
 }
 
+// batchProjector applies a simple projection to the batches by wrapping them
+// into projectingBatches. A separate projectingBatch is kept for each distinct
+// batch, so that the projections of the batches that are still being
+// referenced by the callers are not modified.
+type batchProjector struct {
+	projection []uint32
+	batches    map[coldata.Batch]*projectingBatch
+	// lastBatch and lastProjBatch are the batch that was projected most
+	// recently and its projectingBatch. Most inputs return the same batch on
+	// every call, so this allows us to skip the lookup in 'batches' map which
+	// is a noticeable part of the cost of the projection when the batches are
+	// projected to a single (or a few) column(s).
+	lastBatch     coldata.Batch
+	lastProjBatch *projectingBatch
+	// numBatchesLoggingThreshold is the threshold on the number of items in
+	// 'batches' map at which we will log a message when a new projectingBatch
+	// is created. It is growing exponentially.
+	numBatchesLoggingThreshold int
+}
+
+func makeBatchProjector(projection []uint32) batchProjector {
+	p := batchProjector{
+		projection:                 make([]uint32, len(projection)),
+		batches:                    make(map[coldata.Batch]*projectingBatch),
+		numBatchesLoggingThreshold: 128,
+	}
+	// We make a copy of projection to be safe.
+	copy(p.projection, projection)
+	return p
+}
+
+// project returns the projectingBatch that applies the projection to the
+// non-zero length batch.
+func (p *batchProjector) project(ctx context.Context, batch coldata.Batch) coldata.Batch {
+	if batch == p.lastBatch {
+		p.lastProjBatch.Batch = batch
+		return p.lastProjBatch
+	}
+	projBatch, found := p.batches[batch]
+	if !found {
+		projBatch = newProjectionBatch(p.projection)
+		p.batches[batch] = projBatch
+		if len(p.batches) == p.numBatchesLoggingThreshold {
+			if log.V(1) {
+				log.Infof(ctx, "size of 'batches' map of the projecting operator = %d", len(p.batches))
+			}
+			p.numBatchesLoggingThreshold = p.numBatchesLoggingThreshold * 2
+		}
+	}
+	p.lastBatch, p.lastProjBatch = batch, projBatch
+	projBatch.Batch = batch
+	return projBatch
+}
+
+// MaybeHasNulls returns false only if none of the columns in batch have nulls.
+// For the batches produced by the simple project operator only the projected
+// columns are checked, and the vectors are accessed without populating the
+// ColVecs() slice.
+func MaybeHasNulls(batch coldata.Batch) bool {
+	for i, width := 0, batch.Width(); i < width; i++ {
+		if batch.ColVec(i).MaybeHasNulls() {
+			return true
+		}
+	}
+	return false
+}
+
 // NewSimpleProjectOp returns a new simpleProjectOp that applies a simple
 // projection on the columns in its input batch, returning a new batch with
 // only the columns in the projection slice, in order. In a degenerate case
 // when input already outputs batches that satisfy the projection, a
-// simpleProjectOp is not planned and input is returned.
+// simpleProjectOp is not planned and input is returned. inputTypes are the
+// types of the columns returned by input.
 func NewSimpleProjectOp(
-	input colexecop.Operator, numInputCols int, projection []uint32,
+	input colexecop.Operator, inputTypes []*types.T, projection []uint32,
 ) colexecop.Operator {
-	if numInputCols == len(projection) {
+	if len(inputTypes) == len(projection) {
 		projectionIsRedundant := true
 		for i := range projection {
 			if projection[i] != uint32(i) {
@@ -105,15 +172,54 @@ func NewSimpleProjectOp(
 			return input
 		}
 	}
-	s := &simpleProjectOp{
-		OneInputInitCloserHelper:   colexecop.MakeOneInputInitCloserHelper(input),
-		projection:                 make([]uint32, len(projection)),
-		batches:                    make(map[coldata.Batch]*projectingBatch),
-		numBatchesLoggingThreshold: 128,
+	return &simpleProjectOp{
+		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
+		batchProjector:           makeBatchProjector(projection),
+		inputTypes:               inputTypes,
 	}
-	// We make a copy of projection to be safe.
-	copy(s.projection, projection)
-	return s
+}
+
+// IsPermutation returns whether projection is a permutation of the
+// numInputCols input columns, i.e. every input column is projected exactly
+// once and only the order of the columns is changed. Such projections don't
+// drop any columns, so the callers can operate on all columns of the batches
+// underlying the projecting ones and only reorder the results.
+func IsPermutation(numInputCols int, projection []uint32) bool {
+	if numInputCols != len(projection) {
+		return false
+	}
+	var seen util.FastIntSet
+	for _, colIdx := range projection {
+		if int(colIdx) >= numInputCols || seen.Contains(int(colIdx)) {
+			return false
+		}
+		seen.Add(int(colIdx))
+	}
+	return true
+}
+
+// UnwrapSimpleProjectOp returns the input of op, the types of the columns
+// returned by that input, and the projection applied on top of them if op is
+// a simple project operator. This allows the callers to plan other operators
+// below the projection. The returned slices must not be modified.
+func UnwrapSimpleProjectOp(
+	op colexecop.Operator,
+) (input colexecop.Operator, inputTypes []*types.T, projection []uint32, ok bool) {
+	if d, ok := op.(*simpleProjectOp); ok {
+		return d.Input, d.inputTypes, d.projection, true
+	}
+	return nil, nil, nil, false
+}
+
+// UnwrapProjectingBatch returns the batch underlying the batch returned by a
+// simple project operator, so that the callers that know the projection (see
+// UnwrapSimpleProjectOp) can access the projected columns directly. Other
+// batches are returned as is.
+func UnwrapProjectingBatch(batch coldata.Batch) coldata.Batch {
+	if b, ok := batch.(*projectingBatch); ok {
+		return b.Batch
+	}
+	return batch
 }
 
 func (d *simpleProjectOp) Next() coldata.Batch {
@@ -121,19 +227,7 @@ func (d *simpleProjectOp) Next() coldata.Batch {
 	if batch.Length() == 0 {
 		return coldata.ZeroBatch
 	}
-	projBatch, found := d.batches[batch]
-	if !found {
-		projBatch = newProjectionBatch(d.projection)
-		d.batches[batch] = projBatch
-		if len(d.batches) == d.numBatchesLoggingThreshold {
-			if log.V(1) {
-				log.Infof(d.Ctx, "simpleProjectOp: size of 'batches' map = %d", len(d.batches))
-			}
-			d.numBatchesLoggingThreshold = d.numBatchesLoggingThreshold * 2
-		}
-	}
-	projBatch.Batch = batch
-	return projBatch
+	return d.project(d.Ctx, batch)
 }
 
 func (d *simpleProjectOp) Reset(ctx context.Context) {
@@ -11,9 +11,12 @@
 package colexecbase_test
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
@@ -67,9 +70,11 @@ func TestSimpleProjectOp(t *testing.T) {
 			},
 		},
 	}
+	// The types of the input tuples are deduced to be integers.
+	inputTypes := []*types.T{types.Int, types.Int, types.Int}
 	for _, tc := range tcs {
 		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
-			return colexecbase.NewSimpleProjectOp(input[0], len(tc.tuples[0]), tc.colsToKeep), nil
+			return colexecbase.NewSimpleProjectOp(input[0], inputTypes, tc.colsToKeep), nil
 		})
 	}
 
@@ -77,13 +82,13 @@ func TestSimpleProjectOp(t *testing.T) {
 	// nothing.
 	colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{{{1, 2, 3}, {1, 2, 3}}}, nil, colexectestutils.Tuples{{}, {}}, colexectestutils.OrderedVerifier,
 		func(input []colexecop.Operator) (colexecop.Operator, error) {
-			return colexecbase.NewSimpleProjectOp(input[0], 3 /* numInputCols */, nil), nil
+			return colexecbase.NewSimpleProjectOp(input[0], inputTypes, nil), nil
 		})
 
 	t.Run("RedundantProjectionIsNotPlanned", func(t *testing.T) {
 		typs := []*types.T{types.Int, types.Int}
 		input := colexectestutils.NewFiniteBatchSource(testAllocator, testAllocator.NewMemBatchWithMaxCapacity(typs), typs, 1)
-		projectOp := colexecbase.NewSimpleProjectOp(input, len(typs), []uint32{0, 1})
+		projectOp := colexecbase.NewSimpleProjectOp(input, typs, []uint32{0, 1})
 		require.IsType(t, input, projectOp)
 	})
 }
@@ -122,8 +127,187 @@ func TestSimpleProjectOpWithUnorderedSynchronizer(t *testing.T) {
 				parallelUnorderedSynchronizerInputs[i].Root = inputs[i]
 			}
 			input = colexec.NewParallelUnorderedSynchronizer(parallelUnorderedSynchronizerInputs, &wg)
-			input = colexecbase.NewSimpleProjectOp(input, len(inputTypes), []uint32{0})
+			input = colexecbase.NewSimpleProjectOp(input, inputTypes, []uint32{0})
 			return colexecbase.NewConstOp(testAllocator, input, types.Int, constVal, 1)
 		})
 	wg.Wait()
 }
+
+// TestSimpleProjectOpMaybeHasNulls verifies that MaybeHasNulls returns true
+// for the projected batches whenever any of the projected columns has nulls
+// and returns false when only the columns that are projected out have nulls.
+func TestSimpleProjectOpMaybeHasNulls(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	typs := []*types.T{types.Int, types.Int, types.Int}
+	// Only the second column has nulls.
+	tuples := colexectestutils.Tuples{{1, 2, 3}, {1, nil, 3}, {1, 2, 3}, {1, 2, 3}}
+	for _, tc := range []struct {
+		projection []uint32
+		hasNulls   bool
+	}{
+		{projection: []uint32{0, 2}, hasNulls: false},
+		{projection: []uint32{2}, hasNulls: false},
+		{projection: []uint32{1}, hasNulls: true},
+		{projection: []uint32{2, 1}, hasNulls: true},
+		{projection: []uint32{}, hasNulls: false},
+	} {
+		// Every tuple is put into a separate batch, so only one of the
+		// batches has nulls (if the null column is projected).
+		input := colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, tuples, typs)
+		op := colexecbase.NewSimpleProjectOp(input, typs, tc.projection)
+		op.Init(ctx)
+		sawNulls := false
+		for b := op.Next(); b.Length() > 0; b = op.Next() {
+			actualHasNulls := false
+			for _, vec := range b.ColVecs() {
+				for i := 0; i < b.Length(); i++ {
+					rowIdx := i
+					if sel := b.Selection(); sel != nil {
+						rowIdx = sel[i]
+					}
+					actualHasNulls = actualHasNulls || vec.Nulls().NullAt(rowIdx)
+				}
+			}
+			sawNulls = sawNulls || actualHasNulls
+			require.Equal(t, actualHasNulls, colexecbase.MaybeHasNulls(b), "projection %v", tc.projection)
+
+			// The nulls set directly on the projected vectors must be
+			// reflected.
+			if b.Width() > 0 && !actualHasNulls {
+				b.ColVec(0).Nulls().SetNull(0)
+				require.True(t, colexecbase.MaybeHasNulls(b), "projection %v", tc.projection)
+				b.ColVec(0).Nulls().UnsetNulls()
+			}
+
+			// Appending a column with nulls must be reflected too.
+			vec := testAllocator.NewMemColumn(types.Int, b.Length())
+			vec.Nulls().SetNull(0)
+			b.AppendCol(vec)
+			require.True(t, colexecbase.MaybeHasNulls(b), "projection %v", tc.projection)
+		}
+		require.Equal(t, tc.hasNulls, sawNulls, "projection %v", tc.projection)
+	}
+}
+
+// TestSimpleProjectOpAlternatingBatches verifies that the projection is
+// applied correctly when the input alternates between several batches, in
+// which case the projectingBatch of the batch that was projected most
+// recently can't be reused, and that the projected batches that have been
+// returned earlier aren't modified.
+func TestSimpleProjectOpAlternatingBatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	typs := []*types.T{types.Int, types.Int, types.Int, types.Int}
+	batches := make([]coldata.Batch, 3)
+	for i := range batches {
+		batches[i] = testAllocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */)
+		for j := range typs {
+			batches[i].ColVec(j).Int64()[0] = int64(10*i + j)
+		}
+		batches[i].SetLength(1)
+	}
+	for _, projection := range [][]uint32{{2}, {3, 1}, {0, 1, 2}} {
+		input := colexecop.NewFeedOperator()
+		op := colexecbase.NewSimpleProjectOp(input, typs, projection)
+		op.Init(ctx)
+		projected := make(map[int]coldata.Batch)
+		for _, batchIdx := range []int{0, 0, 1, 0, 2, 2, 1, 0} {
+			input.SetBatch(batches[batchIdx])
+			b := op.Next()
+			if prev, ok := projected[batchIdx]; ok {
+				require.True(t, prev == b, "projection %v", projection)
+			}
+			projected[batchIdx] = b
+			for i, projBatch := range projected {
+				require.Equal(t, len(projection), projBatch.Width(), "projection %v", projection)
+				for j, colIdx := range projection {
+					require.Equal(t, int64(10*i+int(colIdx)), projBatch.ColVec(j).Int64()[0], "projection %v", projection)
+				}
+			}
+		}
+	}
+}
+
+// TestSimpleProjectOpPermutation verifies that the projections that only
+// reorder all of the input columns are recognized as permutations and that
+// the projected columns are returned correctly both by ColVec and ColVecs.
+func TestSimpleProjectOpPermutation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	typs := []*types.T{types.Int, types.Int, types.Int, types.Int}
+	batch := testAllocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */)
+	for i := range typs {
+		batch.ColVec(i).Int64()[0] = int64(i)
+	}
+	batch.SetLength(1)
+	for _, tc := range []struct {
+		projection    []uint32
+		isPermutation bool
+	}{
+		{projection: []uint32{3, 2, 1, 0}, isPermutation: true},
+		{projection: []uint32{2, 0, 1, 3}, isPermutation: true},
+		{projection: []uint32{1, 0, 3, 2}, isPermutation: true},
+		{projection: []uint32{0, 1, 3, 2}, isPermutation: true},
+		// Some columns are projected several times or not at all.
+		{projection: []uint32{3, 2, 1}, isPermutation: false},
+		{projection: []uint32{3, 2, 1, 1}, isPermutation: false},
+		{projection: []uint32{3, 2, 1, 0, 0}, isPermutation: false},
+	} {
+		require.Equal(t, tc.isPermutation, colexecbase.IsPermutation(len(typs), tc.projection), "projection %v", tc.projection)
+		input := colexecop.NewFeedOperator()
+		op := colexecbase.NewSimpleProjectOp(input, typs, tc.projection)
+		op.Init(ctx)
+		input.SetBatch(batch)
+		b := op.Next()
+		require.Equal(t, len(tc.projection), b.Width(), "projection %v", tc.projection)
+		vecs := b.ColVecs()
+		require.Equal(t, len(tc.projection), len(vecs), "projection %v", tc.projection)
+		for i, colIdx := range tc.projection {
+			require.Equal(t, int64(colIdx), b.ColVec(i).Int64()[0], "projection %v", tc.projection)
+			require.Equal(t, int64(colIdx), vecs[i].Int64()[0], "projection %v", tc.projection)
+		}
+	}
+	// The identity projection is a permutation too, but it isn't planned.
+	require.True(t, colexecbase.IsPermutation(len(typs), []uint32{0, 1, 2, 3}))
+}
+
+func BenchmarkSimpleProjectOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	const numCols = 64
+	typs := make([]*types.T, numCols)
+	for i := range typs {
+		typs[i] = types.Int
+	}
+	batches := []coldata.Batch{
+		testAllocator.NewMemBatchWithMaxCapacity(typs),
+		testAllocator.NewMemBatchWithMaxCapacity(typs),
+	}
+	for _, batch := range batches {
+		batch.SetLength(coldata.BatchSize())
+	}
+	// The wide batches are projected to a single column. When the input
+	// alternates between the batches, the projectingBatch has to be looked up
+	// for every batch.
+	for _, alternate := range []bool{false, true} {
+		b.Run(fmt.Sprintf("alternate=%t", alternate), func(b *testing.B) {
+			input := colexecop.NewFeedOperator()
+			op := colexecbase.NewSimpleProjectOp(input, typs, []uint32{numCols / 2})
+			op.Init(ctx)
+			b.SetBytes(int64(8 * coldata.BatchSize()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if alternate {
+					input.SetBatch(batches[i%2])
+				} else {
+					input.SetBatch(batches[0])
+				}
+				_ = op.Next().ColVec(0)
+			}
+		})
+	}
+}
@@ -136,12 +136,15 @@ func (d *TupleHashDistributor) Init(ctx context.Context) {
 	d.cancelChecker.Init(ctx)
 }
 
-// Distribute populates selection vectors to route each of the tuples in b to
-// one of the numOutputs outputs according to the computed on hashCols hash
-// values.
+// ComputeOutputIdxs returns the indices of the outputs (among numOutputs) to
+// which the tuples in b are routed according to the computed on hashCols hash
+// values. The returned slice has the length of b, and its i-th element
+// corresponds to the i-th tuple of b after the selection vector is applied.
+// NULL values don't change the hash value, so all NULLs hash the same way.
+// The slice is only valid until the next call on d.
 // NOTE: b is assumed to be non-zero batch.
 // NOTE: the distributor *must* be initialized before the first use.
-func (d *TupleHashDistributor) Distribute(b coldata.Batch, hashCols []uint32) [][]int {
+func (d *TupleHashDistributor) ComputeOutputIdxs(b coldata.Batch, hashCols []uint32) []uint64 {
 	n := b.Length()
 	if cap(d.buckets) < n {
 		d.buckets = make([]uint64, n)
@@ -161,6 +164,17 @@ func (d *TupleHashDistributor) Distribute(b coldata.Batch, hashCols []uint32) []
 	}
 
 	finalizeHash(d.buckets, n, uint64(len(d.selections)))
+	return d.buckets
+}
+
+// Distribute populates selection vectors to route each of the tuples in b to
+// one of the numOutputs outputs according to the computed on hashCols hash
+// values.
+// NOTE: b is assumed to be non-zero batch.
+// NOTE: the distributor *must* be initialized before the first use.
+func (d *TupleHashDistributor) Distribute(b coldata.Batch, hashCols []uint32) [][]int {
+	n := b.Length()
+	d.ComputeOutputIdxs(b, hashCols)
 
 	// Reset selections.
 	for i := 0; i < len(d.selections); i++ {
@@ -203,3 +217,46 @@ func (d *TupleHashDistributor) ResetNumOutputs(numOutputs int) {
 		d.selections[i] = oldSelections[i]
 	}
 }
+
+// VecHasher is a helper struct that computes the hash buckets of the values of
+// a single vector. Values that are equal according to the SQL equality are
+// assigned the same bucket, so VecHasher can be used to implement hash sets of
+// values of any type.
+type VecHasher struct {
+	buckets []uint64
+	// cancelChecker is used during the hashing of the values to check for
+	// query cancellation.
+	cancelChecker  colexecutils.CancelChecker
+	overloadHelper execgen.OverloadHelper
+	datumAlloc     rowenc.DatumAlloc
+}
+
+// Init initializes the VecHasher. Second, third, etc calls are noops.
+func (h *VecHasher) Init(ctx context.Context) {
+	h.cancelChecker.Init(ctx)
+}
+
+// ComputeBuckets returns the buckets (among numBuckets) of the first n values
+// of vec according to sel. The i-th element of the returned slice corresponds
+// to the i-th value of vec after sel is applied, and the buckets of NULL values
+// are unspecified. The slice is only valid until the next call on h.
+// NOTE: n is assumed to be positive.
+// NOTE: the hasher *must* be initialized before the first use.
+func (h *VecHasher) ComputeBuckets(vec coldata.Vec, n int, sel []int, numBuckets uint64) []uint64 {
+	if cap(h.buckets) < n {
+		h.buckets = make([]uint64, n)
+	} else {
+		h.buckets = h.buckets[:n]
+	}
+	initHash(h.buckets, n, DefaultInitHashValue)
+
+	// Check if we received more values than the current allocation size and
+	// increase it if so.
+	if n > h.datumAlloc.AllocSize {
+		h.datumAlloc.AllocSize = n
+	}
+
+	rehash(h.buckets, vec, n, sel, h.cancelChecker, &h.overloadHelper, &h.datumAlloc)
+	finalizeHash(h.buckets, n, numBuckets)
+	return h.buckets
+}
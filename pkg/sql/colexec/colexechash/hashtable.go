@@ -100,6 +100,11 @@ type hashTableProbeBuffer struct {
 	// key at any given index.
 	HeadID []uint64
 
+	// tailID stores the last build table keyID that has been appended to the
+	// Same chain starting at HeadID at any given index. It is only used when
+	// the HashTable contains non-distinct keys.
+	tailID []uint64
+
 	// differs stores whether the key at any index differs with the build table
 	// key.
 	differs []bool
@@ -158,7 +163,9 @@ type HashTable struct {
 	// Same is a densely-packed list that stores the keyID of the next key in the
 	// hash table that has the same value as the current key. The HeadID of the key
 	// is the first key of that value found in the next linked list. This field
-	// will be lazily populated by the prober.
+	// will be lazily populated by the prober. The keyIDs within each chain are
+	// in ascending order, so build tuples with the same key that are adjacent
+	// in Vals form contiguous runs.
 	Same []uint64
 	// Visited represents whether each of the corresponding keys have been touched
 	// by the prober.
@@ -313,12 +320,17 @@ func (p *hashTableProbeBuffer) accountForLimitedSlices(allocator *colmem.Allocat
 	if p.limitedSlicesAreAccountedFor {
 		return
 	}
-	const sizeOfBool = int64(unsafe.Sizeof(true))
-	internalMemMaxUsed := sizeOfUint64*int64(5*coldata.BatchSize()) + sizeOfBool*int64(2*coldata.BatchSize())
-	allocator.AdjustMemoryUsage(internalMemMaxUsed)
+	allocator.AdjustMemoryUsage(limitedSlicesMemSize())
 	p.limitedSlicesAreAccountedFor = true
 }
 
+// limitedSlicesMemSize returns the maximum memory footprint of the slices of
+// hashTableProbeBuffer that are limited by coldata.BatchSize() in size.
+func limitedSlicesMemSize() int64 {
+	const sizeOfBool = int64(unsafe.Sizeof(true))
+	return sizeOfUint64*int64(6*coldata.BatchSize()) + sizeOfBool*int64(2*coldata.BatchSize())
+}
+
 // buildFromBufferedTuples builds the hash table from already buffered tuples
 // in ht.Vals. It'll determine the appropriate number of buckets that satisfy
 // the target load factor.
@@ -493,6 +505,54 @@ func (ht *HashTable) AppendAllDistinct(batch coldata.Batch) {
 	}
 }
 
+// EstimateAppendAllDistinctMemSize returns an estimate of the memory that will
+// be registered with the allocator when numTuples tuples from batch are
+// appended to the hash table via AppendAllDistinct. This allows the caller to
+// check the estimate against the memory limit before any of the tuples are
+// inserted. The sizes of the variable-width values are estimated based on the
+// first numTuples tuples in batch.
+func (ht *HashTable) EstimateAppendAllDistinctMemSize(batch coldata.Batch, numTuples int) int64 {
+	if numTuples == 0 {
+		return 0
+	}
+	newLength := ht.Vals.Length() + numTuples
+	var size int64
+	for _, keyCol := range ht.keyCols {
+		src := batch.ColVec(int(keyCol))
+		if src.IsBytesLike() {
+			size += int64(coldata.ProportionalSize(src, int64(numTuples)))
+			continue
+		}
+		// The memory footprint of the fixed-width vectors is determined by
+		// their capacity which at most doubles when the values are appended.
+		dst := ht.Vals.ColVec(int(keyCol))
+		if capacity := dst.Capacity(); newLength > capacity {
+			newCapacity := 2 * capacity
+			if newCapacity < newLength {
+				newCapacity = newLength
+			}
+			size += int64(colmem.EstimateBatchSizeBytes([]*types.T{dst.Type()}, newCapacity-capacity))
+		}
+	}
+	// The auxiliary slices are only accounted for when the hash table is
+	// resized (see buildFromBufferedTuples).
+	newNumBuckets := ht.numBuckets
+	for float64(newLength)/float64(newNumBuckets) > ht.loadFactor {
+		newNumBuckets *= 2
+	}
+	if newNumBuckets != ht.numBuckets {
+		newUint64Count := int64(newNumBuckets) + int64(newLength+1)
+		if ht.ProbeScratch.First != nil {
+			newUint64Count += int64(newNumBuckets)
+		}
+		size += sizeOfUint64 * (newUint64Count - ht.unlimitedSlicesNumUint64AccountedFor)
+		if !ht.ProbeScratch.limitedSlicesAreAccountedFor {
+			size += limitedSlicesMemSize()
+		}
+	}
+	return size
+}
+
 // MaybeRepairAfterDistinctBuild checks whether the hash table built via
 // DistinctBuild is in an inconsistent state and repairs it if so.
 func (ht *HashTable) MaybeRepairAfterDistinctBuild() {
@@ -605,8 +665,15 @@ func (p *hashTableProbeBuffer) SetupLimitedSlices(length int, buildMode HashTabl
 	if buildMode == HashTableDistinctBuildMode {
 		p.distinct = colexecutils.MaybeAllocateLimitedBoolArray(p.distinct, length)
 	}
-	// Note that we don't use maybeAllocate* methods below because GroupID and
-	// ToCheck don't need to be zeroed out when reused.
+	// Note that we don't use maybeAllocate* methods below because tailID,
+	// GroupID, and ToCheck don't need to be zeroed out when reused.
+	if buildMode == HashTableFullBuildMode {
+		if cap(p.tailID) < length {
+			p.tailID = make([]uint64, length)
+		} else {
+			p.tailID = p.tailID[:length]
+		}
+	}
 	if cap(p.GroupID) < length {
 		p.GroupID = make([]uint64, length)
 	} else {
@@ -425,9 +425,11 @@ func _CHECK_BODY(_SELECT_SAME_TUPLES bool, _DELETING_PROBE_MODE bool) { // */}}
 			// {{else}}
 			if ht.ProbeScratch.HeadID[toCheck] == 0 {
 				ht.ProbeScratch.HeadID[toCheck] = keyID
+				// {{if .SelectSameTuples}}
+				ht.ProbeScratch.tailID[toCheck] = keyID
+				// {{end}}
 			}
 			// {{if .SelectSameTuples}}
-			firstID := ht.ProbeScratch.HeadID[toCheck]
 			if !ht.Visited[keyID] {
 				// We can then add this keyID into the same array at the end of the
 				// corresponding linked list and mark this ID as visited. Since there
@@ -437,9 +439,14 @@ func _CHECK_BODY(_SELECT_SAME_TUPLES bool, _DELETING_PROBE_MODE bool) { // */}}
 				// chain.
 				ht.ProbeScratch.differs[toCheck] = true
 				ht.Visited[keyID] = true
-				if firstID != keyID {
-					ht.Same[keyID] = ht.Same[firstID]
-					ht.Same[firstID] = keyID
+				// The keyIDs are visited in ascending order along the next
+				// chain, so appending them to the end of the linked list keeps
+				// the list sorted.
+				tailID := ht.ProbeScratch.tailID[toCheck]
+				if tailID != keyID {
+					ht.Same[keyID] = ht.Same[tailID]
+					ht.Same[tailID] = keyID
+					ht.ProbeScratch.tailID[toCheck] = keyID
 				}
 			}
 			// {{end}}
@@ -0,0 +1,298 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ApplyParamsOp is the leaf of the right side of the apply join. Every time
+// the right side is evaluated for a left tuple, the operator emits a single
+// batch with one tuple that contains the values of that left tuple. This
+// allows the right side to refer to the outer columns as if they were the
+// columns of its own input (for example, in the projections and the
+// selections).
+type ApplyParamsOp struct {
+	colexecop.ZeroInputNode
+	colexecop.NonExplainable
+
+	allocator *colmem.Allocator
+	numParams int
+	batch     coldata.Batch
+	done      bool
+}
+
+var _ colexecop.ResettableOperator = &ApplyParamsOp{}
+
+// NewApplyParamsOp returns a new ApplyParamsOp that emits the tuples of the
+// left input of the apply join which has the given types.
+func NewApplyParamsOp(allocator *colmem.Allocator, leftTypes []*types.T) *ApplyParamsOp {
+	return &ApplyParamsOp{
+		allocator: allocator,
+		numParams: len(leftTypes),
+		batch:     allocator.NewMemBatchWithFixedCapacity(leftTypes, 1 /* capacity */),
+	}
+}
+
+// Init implements the colexecop.Operator interface.
+func (o *ApplyParamsOp) Init(context.Context) {}
+
+// Next implements the colexecop.Operator interface.
+func (o *ApplyParamsOp) Next() coldata.Batch {
+	if o.done {
+		return coldata.ZeroBatch
+	}
+	o.done = true
+	return o.batch
+}
+
+// Reset implements the colexecop.Resetter interface.
+func (o *ApplyParamsOp) Reset(context.Context) {
+	o.done = false
+}
+
+// bind sets the parameters to the values of the tuple at position rowIdx of
+// the batch (after the selection vector has been applied).
+func (o *ApplyParamsOp) bind(batch coldata.Batch, rowIdx int) {
+	// Note that the operators of the right side might have modified the
+	// batch (for example, by setting the selection vector or by appending
+	// the projection columns), so we need to reset it first.
+	o.batch.ResetInternalBatch()
+	o.allocator.PerformOperation(o.batch.ColVecs()[:o.numParams], func() {
+		for i := 0; i < o.numParams; i++ {
+			o.batch.ColVec(i).Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         batch.ColVec(i),
+						SrcStartIdx: rowIdx,
+						SrcEndIdx:   rowIdx + 1,
+					},
+				},
+			)
+		}
+	})
+	o.batch.SetLength(1)
+}
+
+// NewApplyJoinOp returns an operator that performs the apply join (i.e. the
+// LATERAL join with a correlated right side). For each tuple of the left
+// input, the parameters are bound to its values, the right side is reset and
+// is fully evaluated, and every tuple produced by the right side is combined
+// with the left tuple. The output contains all of the left columns followed
+// by the right columns.
+//
+// right must be the root of the operator chain which has params as its leaf,
+// and all operators in the chain that have state must be resettable. Only
+// INNER and LEFT OUTER join types are supported. In the latter case, the left
+// tuples for which the right side didn't produce anything are emitted with
+// NULLs in the right columns.
+func NewApplyJoinOp(
+	allocator *colmem.Allocator,
+	memoryLimit int64,
+	joinType descpb.JoinType,
+	left colexecop.Operator,
+	leftTypes []*types.T,
+	params *ApplyParamsOp,
+	right colexecop.ResettableOperator,
+	rightTypes []*types.T,
+) (colexecop.Operator, error) {
+	if joinType != descpb.InnerJoin && joinType != descpb.LeftOuterJoin {
+		return nil, errors.Errorf("unsupported apply join type %s", joinType)
+	}
+	return &applyJoinOp{
+		joinHelper:            newJoinHelper(left, right),
+		allocator:             allocator,
+		joinType:              joinType,
+		params:                params,
+		right:                 right,
+		numLeftCols:           len(leftTypes),
+		numRightCols:          len(rightTypes),
+		outputTypes:           joinType.MakeOutputTypes(leftTypes, rightTypes),
+		maxOutputBatchMemSize: memoryLimit,
+	}, nil
+}
+
+type applyJoinOp struct {
+	*joinHelper
+
+	allocator             *colmem.Allocator
+	joinType              descpb.JoinType
+	params                *ApplyParamsOp
+	right                 colexecop.ResettableOperator
+	numLeftCols           int
+	numRightCols          int
+	outputTypes           []*types.T
+	maxOutputBatchMemSize int64
+
+	// leftBatch is the current batch from the left input, and leftIdx is the
+	// position of the next tuple in it (before applying the selection vector)
+	// for which the right side is to be evaluated.
+	leftBatch coldata.Batch
+	leftIdx   int
+	// leftRowIdx is the position of the left tuple for which the right side
+	// is currently being evaluated.
+	leftRowIdx int
+	// leftSel is a selection vector that repeats leftRowIdx and is used to
+	// copy the values of the current left tuple into the output.
+	leftSel []int
+	// rightBatch is the current batch of the right side and rightIdx is the
+	// position of the next tuple in it (before applying the selection vector)
+	// to be emitted. rightBatch is nil when the right side for the current
+	// left tuple has been fully emitted.
+	rightBatch coldata.Batch
+	rightIdx   int
+	// rightMatched indicates whether the right side has produced at least one
+	// tuple for the current left tuple.
+	rightMatched bool
+
+	output coldata.Batch
+}
+
+var _ colexecop.Operator = &applyJoinOp{}
+
+func (a *applyJoinOp) Init(ctx context.Context) {
+	a.init(ctx)
+}
+
+func (a *applyJoinOp) Next() coldata.Batch {
+	a.output, _ = a.allocator.ResetMaybeReallocate(
+		a.outputTypes, a.output, coldata.BatchSize(), a.maxOutputBatchMemSize,
+	)
+	outputCapacity := a.output.Capacity()
+	if cap(a.leftSel) < outputCapacity {
+		a.leftSel = make([]int, outputCapacity)
+	}
+	var outputIdx int
+	a.allocator.PerformOperation(a.output.ColVecs(), func() {
+		for outputIdx < outputCapacity {
+			if a.rightBatch == nil && !a.startNextLeftTuple() {
+				// The left input has been exhausted.
+				return
+			}
+			n := a.rightBatch.Length()
+			if n == 0 {
+				// The right side has been exhausted for the current left
+				// tuple.
+				if !a.rightMatched && a.joinType == descpb.LeftOuterJoin {
+					a.emitUnmatched(outputIdx)
+					outputIdx++
+				}
+				a.rightBatch = nil
+				continue
+			}
+			if a.rightIdx == n {
+				a.rightBatch = a.right.Next()
+				a.rightIdx = 0
+				continue
+			}
+			toEmit := n - a.rightIdx
+			if toEmit > outputCapacity-outputIdx {
+				toEmit = outputCapacity - outputIdx
+			}
+			a.emitMatched(outputIdx, toEmit)
+			a.rightMatched = true
+			a.rightIdx += toEmit
+			outputIdx += toEmit
+		}
+	})
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	a.output.SetLength(outputIdx)
+	return a.output
+}
+
+// startNextLeftTuple binds the parameters to the next left tuple and starts
+// the evaluation of the right side for it. false is returned if the left input
+// has been exhausted.
+func (a *applyJoinOp) startNextLeftTuple() bool {
+	for a.leftBatch == nil || a.leftIdx == a.leftBatch.Length() {
+		if a.leftBatch != nil && a.leftBatch.Length() == 0 {
+			return false
+		}
+		a.leftBatch = a.inputOne.Next()
+		a.leftIdx = 0
+	}
+	a.leftRowIdx = a.leftIdx
+	if sel := a.leftBatch.Selection(); sel != nil {
+		a.leftRowIdx = sel[a.leftIdx]
+	}
+	a.leftIdx++
+	a.params.bind(a.leftBatch, a.leftRowIdx)
+	a.right.Reset(a.Ctx)
+	a.rightBatch = a.right.Next()
+	a.rightIdx = 0
+	a.rightMatched = false
+	return true
+}
+
+// emitMatched copies toEmit tuples of the right side, starting from rightIdx,
+// combined with the current left tuple into the output starting at position
+// outputIdx.
+func (a *applyJoinOp) emitMatched(outputIdx, toEmit int) {
+	leftSel := a.leftSel[:toEmit]
+	for i := range leftSel {
+		leftSel[i] = a.leftRowIdx
+	}
+	for i := 0; i < a.numLeftCols; i++ {
+		a.output.ColVec(i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:       a.leftBatch.ColVec(i),
+					Sel:       leftSel,
+					DestIdx:   outputIdx,
+					SrcEndIdx: toEmit,
+				},
+			},
+		)
+	}
+	rightSel := a.rightBatch.Selection()
+	for i := 0; i < a.numRightCols; i++ {
+		a.output.ColVec(a.numLeftCols + i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         a.rightBatch.ColVec(i),
+					Sel:         rightSel,
+					DestIdx:     outputIdx,
+					SrcStartIdx: a.rightIdx,
+					SrcEndIdx:   a.rightIdx + toEmit,
+				},
+			},
+		)
+	}
+}
+
+// emitUnmatched copies the current left tuple with NULLs in the right columns
+// into the output at position outputIdx.
+func (a *applyJoinOp) emitUnmatched(outputIdx int) {
+	for i := 0; i < a.numLeftCols; i++ {
+		a.output.ColVec(i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         a.leftBatch.ColVec(i),
+					DestIdx:     outputIdx,
+					SrcStartIdx: a.leftRowIdx,
+					SrcEndIdx:   a.leftRowIdx + 1,
+				},
+			},
+		)
+	}
+	for i := 0; i < a.numRightCols; i++ {
+		a.output.ColVec(a.numLeftCols + i).Nulls().SetNull(outputIdx)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// resettableTestInput emits the tuples from the beginning every time it is
+// reset.
+type resettableTestInput struct {
+	colexecop.ZeroInputNode
+	allocator *colmem.Allocator
+	tuples    colexectestutils.Tuples
+	typs      []*types.T
+	input     colexecop.Operator
+}
+
+var _ colexecop.ResettableOperator = &resettableTestInput{}
+
+func (r *resettableTestInput) Init(ctx context.Context) {
+	r.Reset(ctx)
+}
+
+func (r *resettableTestInput) Next() coldata.Batch {
+	return r.input.Next()
+}
+
+func (r *resettableTestInput) Reset(ctx context.Context) {
+	r.input = colexectestutils.NewOpTestInput(r.allocator, coldata.BatchSize(), r.tuples, r.typs)
+	r.input.Init(ctx)
+}
+
+func TestApplyJoin(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The right side for every left tuple is
+	//   SELECT r.v FROM r WHERE r.k = l.a
+	// which is planned as the hash join of the parameters with r followed by
+	// the projection.
+	leftTypes := []*types.T{types.Int}
+	tableTypes := []*types.T{types.Int, types.String}
+	rightTypes := []*types.T{types.String}
+	makeApplyJoin := func(
+		joinType descpb.JoinType, left colexecop.Operator, table colexectestutils.Tuples,
+	) (colexecop.Operator, error) {
+		params := NewApplyParamsOp(testAllocator, leftTypes)
+		tableInput := &resettableTestInput{allocator: testAllocator, tuples: table, typs: tableTypes}
+		spec := MakeHashJoinerSpec(
+			descpb.InnerJoin, []uint32{0}, []uint32{0}, leftTypes, tableTypes, false, /* rightDistinct */
+		)
+		hj := NewHashJoiner(
+			testAllocator, testAllocator, spec, params, tableInput,
+			HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+		)
+		hjTypes := append(leftTypes[:len(leftTypes):len(leftTypes)], tableTypes...)
+		right := colexecbase.NewSimpleProjectOp(hj, hjTypes, []uint32{2})
+		return NewApplyJoinOp(
+			testAllocator, execinfra.DefaultMemoryLimit, joinType, left, leftTypes,
+			params, right.(colexecop.ResettableOperator), rightTypes,
+		)
+	}
+
+	// manyRows is the number of the right tuples that a single left tuple
+	// matches in the "many" test cases. It is such that the output for a single
+	// left tuple spans multiple batches.
+	manyRows := 2*coldata.BatchSize() + 1
+	var manyTable, manyExpected colexectestutils.Tuples
+	for i := 0; i < manyRows; i++ {
+		v := fmt.Sprintf("v%d", i)
+		manyTable = append(manyTable, colexectestutils.Tuple{1, v})
+		manyExpected = append(manyExpected, colexectestutils.Tuple{1, v})
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		joinType descpb.JoinType
+		left     colexectestutils.Tuples
+		table    colexectestutils.Tuples
+		expected colexectestutils.Tuples
+		// skipAllNullsInjection is set when the output doesn't change when
+		// the left input consists only of NULLs.
+		skipAllNullsInjection bool
+	}{
+		{
+			// 1 has one match, 2 has none, and 3 has many. NULL never
+			// matches.
+			desc:     "inner",
+			joinType: descpb.InnerJoin,
+			left:     colexectestutils.Tuples{{1}, {2}, {3}, {nil}},
+			table:    colexectestutils.Tuples{{1, "a"}, {3, "b"}, {3, "c"}, {3, "d"}, {4, "e"}},
+			expected: colexectestutils.Tuples{{1, "a"}, {3, "b"}, {3, "c"}, {3, "d"}},
+		},
+		{
+			desc:     "left outer",
+			joinType: descpb.LeftOuterJoin,
+			left:     colexectestutils.Tuples{{1}, {2}, {3}, {nil}},
+			table:    colexectestutils.Tuples{{1, "a"}, {3, "b"}, {3, "c"}, {3, "d"}, {4, "e"}},
+			expected: colexectestutils.Tuples{{1, "a"}, {2, nil}, {3, "b"}, {3, "c"}, {3, "d"}, {nil, nil}},
+		},
+		{
+			desc:     "inner empty right",
+			joinType: descpb.InnerJoin,
+			left:     colexectestutils.Tuples{{1}, {2}},
+			table:    colexectestutils.Tuples{},
+			expected: colexectestutils.Tuples{},
+			// The output is empty regardless of the left tuples.
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:     "left outer empty right",
+			joinType: descpb.LeftOuterJoin,
+			left:     colexectestutils.Tuples{{1}, {2}},
+			table:    colexectestutils.Tuples{},
+			expected: colexectestutils.Tuples{{1, nil}, {2, nil}},
+		},
+		{
+			desc:     "inner many",
+			joinType: descpb.InnerJoin,
+			left:     colexectestutils.Tuples{{2}, {1}, {2}},
+			table:    manyTable,
+			expected: manyExpected,
+		},
+		{
+			desc:     "left outer many",
+			joinType: descpb.LeftOuterJoin,
+			left:     colexectestutils.Tuples{{2}, {1}},
+			table:    manyTable,
+			expected: append(colexectestutils.Tuples{{2, nil}}, manyExpected...),
+		},
+	} {
+		log.Infof(context.Background(), "%s", tc.desc)
+		runTests := colexectestutils.RunTestsWithTyps
+		if tc.skipAllNullsInjection {
+			runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+		}
+		runTests(
+			t, testAllocator, []colexectestutils.Tuples{tc.left}, [][]*types.T{leftTypes},
+			tc.expected, colexectestutils.UnorderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				return makeApplyJoin(tc.joinType, inputs[0], tc.table)
+			},
+		)
+	}
+
+	_, err := NewApplyJoinOp(
+		testAllocator, execinfra.DefaultMemoryLimit, descpb.FullOuterJoin, nil /* left */, leftTypes,
+		nil /* params */, nil /* right */, rightTypes,
+	)
+	require.Error(t, err)
+}
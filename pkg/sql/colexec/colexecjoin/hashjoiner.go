@@ -12,6 +12,7 @@ package colexecjoin
 
 import (
 	"context"
+	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -61,6 +62,15 @@ type HashJoinerSpec struct {
 	Left  hashJoinerSourceSpec
 	Right hashJoinerSourceSpec
 
+	// ProbeFilter, if non-nil, returns the selection operator chain that
+	// evaluates a filter on the columns of the left (probe) input on top of
+	// input. The filter has the same semantics as a filter on the left columns
+	// of the output of the join (meaning that it is also evaluated on the
+	// tuples that are NULL-padded on the left side), but it is applied by the
+	// hash joiner itself. It is only supported with the join types that
+	// include the left columns into the output.
+	ProbeFilter func(input colexecop.Operator) colexecop.Operator
+
 	// trackBuildMatches indicates whether or not we need to track if a row
 	// from the build table had a match (this is needed with RIGHT/FULL OUTER,
 	// RIGHT SEMI, and RIGHT ANTI joins).
@@ -69,6 +79,12 @@ type HashJoinerSpec struct {
 	// rightDistinct indicates whether or not the build table equality column
 	// tuples are distinct. If they are distinct, performance can be optimized.
 	rightDistinct bool
+
+	// buildDistinct indicates whether only a single build tuple per distinct
+	// key is stored in the hash table (this is the case for LEFT SEMI and
+	// LEFT ANTI joins which only care whether a probe tuple has a match). In
+	// such case the hash table only stores the equality columns.
+	buildDistinct bool
 }
 
 type hashJoinerSourceSpec struct {
@@ -167,6 +183,7 @@ type hashJoinerSourceSpec struct {
 // with NULL values on the probe side.
 type hashJoiner struct {
 	*joinHelper
+	colexecop.CloserHelper
 
 	// buildSideAllocator should be used when building the hash table from the
 	// right input.
@@ -186,6 +203,22 @@ type hashJoiner struct {
 	// ht holds the HashTable that is populated during the build phase and used
 	// during the probe phase.
 	ht *colexechash.HashTable
+	// runtimeFilter, if non-nil, is populated from the equality columns of
+	// the build side once the hash table is built and is used by probeInput
+	// to discard the probe tuples that cannot have a match.
+	runtimeFilter *runtimeFilter
+	// probeInput is the operator from which the probe batches are read. It
+	// is the left input possibly wrapped with the nullKeysFilterOp, the probe
+	// filter, and the runtimeFilterOp.
+	probeInput colexecop.Operator
+	// buildInput is the operator from which the hash table is built. It is
+	// the right input possibly wrapped with the nullKeysFilterOp.
+	buildInput colexecop.Operator
+	// outputFilter, if non-nil, is the probe filter that is applied to the
+	// left columns of the output (fed by outputFilterInput). It is used when
+	// the filter cannot be applied to the probe input.
+	outputFilter      colexecop.Operator
+	outputFilterInput *probeFilterFeeder
 	// memoryLimit is the total amount of RAM available for the hash joiner.
 	// This limits the output batches (and is also the same limit for the size
 	// of the hash table).
@@ -207,11 +240,21 @@ type hashJoiner struct {
 		// be NULL on the build table. This indicates that the probe table row
 		// did not match any build table rows.
 		probeRowUnmatched []bool
+		// unmatchedNulls is the null bitmap corresponding to probeRowUnmatched
+		// (the unmatched rows are null). It is built once per output batch and
+		// is then applied to all build table columns at once.
+		unmatchedNulls coldata.Nulls
 		// buildRowMatched is used in the case that spec.trackBuildMatches is true. This
 		// means that an outer join is performed on the build side and buildRowMatched
 		// marks all the build table rows that have been matched already. The rows
-		// that were unmatched are emitted during the hjEmittingRight phase.
-		buildRowMatched []bool
+		// that were unmatched are emitted during the hjEmittingRight phase. Note
+		// that the build rows with duplicate keys are tracked separately, so each
+		// of them is emitted exactly once.
+		buildRowMatched matchedBitmap
+		// probeRuns and buildRuns are used to copy the matched rows of the
+		// probe and the build sides, respectively, into the output in bulk
+		// when they form contiguous runs.
+		probeRuns, buildRuns contiguousRuns
 
 		// buckets is used to store the computed hash value of each key in a single
 		// probe batch.
@@ -233,11 +276,16 @@ type hashJoiner struct {
 	exportBufferedState struct {
 		rightExported      int
 		rightWindowedBatch coldata.Batch
+		// nonKeyColsSet indicates whether the all-NULL vectors have been set
+		// in rightWindowedBatch for the right non-equality columns (which are
+		// not stored by the hash table when spec.buildDistinct is true).
+		nonKeyColsSet bool
 	}
 }
 
 var _ colexecop.BufferingInMemoryOperator = &hashJoiner{}
 var _ colexecop.Resetter = &hashJoiner{}
+var _ colexecop.Closer = &hashJoiner{}
 
 // HashJoinerInitialNumBuckets is the number of the hash buckets initially
 // allocated by the hash table that is used by the in-memory hash joiner.
@@ -249,12 +297,21 @@ func (hj *hashJoiner) Init(ctx context.Context) {
 	if !hj.init(ctx) {
 		return
 	}
+	hj.probeInput.Init(hj.Ctx)
+	hj.buildInput.Init(hj.Ctx)
+	if hj.outputFilter != nil {
+		hj.outputFilter.Init(hj.Ctx)
+	}
 
 	allowNullEquality, probeMode := false, colexechash.HashTableDefaultProbeMode
 	if hj.spec.JoinType.IsSetOpJoin() {
 		allowNullEquality = true
 		probeMode = colexechash.HashTableDeletingProbeMode
 	}
+	buildMode := colexechash.HashTableFullBuildMode
+	if hj.spec.buildDistinct {
+		buildMode = colexechash.HashTableDistinctBuildMode
+	}
 	// This number was chosen after running the micro-benchmarks and relevant
 	// TPCH queries using tpchvec/bench.
 	const hashTableLoadFactor = 1.0
@@ -266,7 +323,7 @@ func (hj *hashJoiner) Init(ctx context.Context) {
 		hj.spec.Right.SourceTypes,
 		hj.spec.Right.EqCols,
 		allowNullEquality,
-		colexechash.HashTableFullBuildMode,
+		buildMode,
 		probeMode,
 	)
 
@@ -299,6 +356,9 @@ func (hj *hashJoiner) Next() coldata.Batch {
 				}
 				continue
 			}
+			if hj.maybeFilterOutput() == 0 {
+				continue
+			}
 			return output
 		case hjEmittingRight:
 			if hj.emittingRightState.rowIdx == hj.ht.Vals.Length() {
@@ -306,6 +366,9 @@ func (hj *hashJoiner) Next() coldata.Batch {
 				continue
 			}
 			hj.emitRight(hj.spec.JoinType == descpb.RightSemiJoin /* matched */)
+			if hj.maybeFilterOutput() == 0 {
+				continue
+			}
 			return hj.output
 		case hjDone:
 			return coldata.ZeroBatch
@@ -318,7 +381,20 @@ func (hj *hashJoiner) Next() coldata.Batch {
 }
 
 func (hj *hashJoiner) build() {
-	hj.ht.FullBuild(hj.inputTwo)
+	if hj.spec.buildDistinct {
+		for {
+			batch := hj.buildInput.Next()
+			if batch.Length() == 0 {
+				break
+			}
+			hj.ht.DistinctBuild(batch)
+		}
+	} else {
+		hj.ht.FullBuild(hj.buildInput)
+	}
+	if hj.runtimeFilter != nil {
+		hj.runtimeFilter.build(hj.ht.Vals)
+	}
 
 	// We might have duplicates in the hash table, so we need to set up
 	// same and visited slices for the prober.
@@ -337,18 +413,21 @@ func (hj *hashJoiner) build() {
 	}
 
 	if hj.spec.trackBuildMatches {
-		if cap(hj.probeState.buildRowMatched) < hj.ht.Vals.Length() {
-			hj.probeState.buildRowMatched = make([]bool, hj.ht.Vals.Length())
-		} else {
-			hj.probeState.buildRowMatched = hj.probeState.buildRowMatched[:hj.ht.Vals.Length()]
-			for n := 0; n < hj.ht.Vals.Length(); n += copy(hj.probeState.buildRowMatched[n:], colexecutils.ZeroBoolColumn) {
-			}
-		}
+		hj.probeState.buildRowMatched.reset(hj.ht.Vals.Length())
 	}
 
 	hj.state = hjProbing
 }
 
+// maybeFilterOutput applies the output filter, if set, to hj.output and
+// returns the number of tuples that remain in it.
+func (hj *hashJoiner) maybeFilterOutput() int {
+	if hj.outputFilter == nil {
+		return hj.output.Length()
+	}
+	return hj.outputFilterInput.apply(hj.outputFilter, hj.output)
+}
+
 // emitRight populates the output batch to emit tuples from the right side that
 // didn't get a match when matched==false (right/full outer and right anti
 // joins) or did get a match when matched==true (right semi joins).
@@ -366,10 +445,22 @@ func (hj *hashJoiner) emitRight(matched bool) {
 	}
 
 	// Find the next batch of tuples that have the requested 'matched' value.
+	// The full words of the bitmap in which none of the tuples have the
+	// requested value are skipped at once.
+	skipWord := uint64(0)
+	if !matched {
+		skipWord = math.MaxUint64
+	}
+	numBuildRows := hj.ht.Vals.Length()
 	nResults := 0
-	for nResults < coldata.BatchSize() && hj.emittingRightState.rowIdx < hj.ht.Vals.Length() {
-		if hj.probeState.buildRowMatched[hj.emittingRightState.rowIdx] == matched {
-			hj.probeState.buildIdx[nResults] = hj.emittingRightState.rowIdx
+	for nResults < coldata.BatchSize() && hj.emittingRightState.rowIdx < numBuildRows {
+		rowIdx := hj.emittingRightState.rowIdx
+		if rowIdx%64 == 0 && rowIdx+64 <= numBuildRows && hj.probeState.buildRowMatched.word(rowIdx) == skipWord {
+			hj.emittingRightState.rowIdx += 64
+			continue
+		}
+		if hj.probeState.buildRowMatched.isSet(rowIdx) == matched {
+			hj.probeState.buildIdx[nResults] = rowIdx
 			nResults++
 		}
 		hj.emittingRightState.rowIdx++
@@ -442,6 +533,9 @@ func (hj *hashJoiner) prepareForCollecting(batchSize int) {
 		} else {
 			hj.probeState.probeRowUnmatched = hj.probeState.probeRowUnmatched[:batchSize]
 		}
+		if len(hj.probeState.unmatchedNulls.NullBitmap())*8 < batchSize {
+			hj.probeState.unmatchedNulls = coldata.NewNulls(batchSize)
+		}
 	}
 	if cap(hj.probeState.buildIdx) < batchSize {
 		hj.probeState.buildIdx = make([]int, batchSize)
@@ -476,7 +570,7 @@ func (hj *hashJoiner) exec() coldata.Batch {
 		// There were no matches in that batch, so we move on to the next one.
 	}
 	for {
-		batch := hj.inputOne.Next()
+		batch := hj.probeInput.Next()
 		batchSize := batch.Length()
 
 		if batchSize == 0 {
@@ -570,6 +664,22 @@ func (hj *hashJoiner) exec() coldata.Batch {
 	return hj.output
 }
 
+// buildUnmatchedNulls builds the null bitmap of the unmatched rows among the
+// first nResults collected rows in hj.probeState.unmatchedNulls and returns
+// the number of those rows.
+func (hj *hashJoiner) buildUnmatchedNulls(nResults int) int {
+	unmatchedNulls := &hj.probeState.unmatchedNulls
+	unmatchedNulls.UnsetNulls()
+	numUnmatched := 0
+	for i, isNull := range hj.probeState.probeRowUnmatched[:nResults] {
+		if isNull {
+			unmatchedNulls.SetNull(i)
+			numUnmatched++
+		}
+	}
+	return numUnmatched
+}
+
 // congregate uses the probeIdx and buildIdx pairs to stitch together the
 // resulting join rows and add them to the output batch with the left table
 // columns preceding the right table columns.
@@ -583,54 +693,44 @@ func (hj *hashJoiner) congregate(nResults int, batch coldata.Batch) {
 	hj.outputUnlimitedAllocator.PerformOperation(hj.output.ColVecs(), func() {
 		if hj.spec.JoinType.ShouldIncludeLeftColsInOutput() {
 			outCols := hj.output.ColVecs()[:len(hj.spec.Left.SourceTypes)]
+			probeIdx := hj.probeState.probeIdx[:nResults]
+			hj.probeState.probeRuns.init(probeIdx)
 			for i := range hj.spec.Left.SourceTypes {
-				outCol := outCols[i]
-				valCol := batch.ColVec(i)
-				outCol.Copy(
-					coldata.CopySliceArgs{
-						SliceArgs: coldata.SliceArgs{
-							Src:       valCol,
-							Sel:       hj.probeState.probeIdx,
-							SrcEndIdx: nResults,
-						},
-					},
-				)
+				hj.probeState.probeRuns.copy(outCols[i], batch.ColVec(i), probeIdx)
 			}
 		}
 
 		if hj.spec.JoinType.ShouldIncludeRightColsInOutput() {
 			rightColOffset := len(hj.spec.Left.SourceTypes)
-			// If the hash table is empty, then there is nothing to copy. The nulls
-			// will be set below.
-			if hj.ht.Vals.Length() > 0 {
+			numUnmatched := 0
+			if hj.spec.JoinType.IsLeftOuterOrFullOuter() {
+				numUnmatched = hj.buildUnmatchedNulls(nResults)
+			}
+			// If the hash table is empty or none of the rows have a match, then
+			// there is nothing to copy. The nulls will be set below.
+			if hj.ht.Vals.Length() > 0 && numUnmatched < nResults {
 				outCols := hj.output.ColVecs()[rightColOffset : rightColOffset+len(hj.spec.Right.SourceTypes)]
+				// Note that if for some index i, probeRowUnmatched[i] is true, then
+				// hj.buildIdx[i] == 0 which will copy the garbage zeroth row of the
+				// hash table, but we will set the NULL value below.
+				buildIdx := hj.probeState.buildIdx[:nResults]
+				hj.probeState.buildRuns.init(buildIdx)
 				for i := range hj.spec.Right.SourceTypes {
-					outCol := outCols[i]
-					valCol := hj.ht.Vals.ColVec(i)
-					// Note that if for some index i, probeRowUnmatched[i] is true, then
-					// hj.buildIdx[i] == 0 which will copy the garbage zeroth row of the
-					// hash table, but we will set the NULL value below.
-					outCol.Copy(
-						coldata.CopySliceArgs{
-							SliceArgs: coldata.SliceArgs{
-								Src:       valCol,
-								SrcEndIdx: nResults,
-								Sel:       hj.probeState.buildIdx,
-							},
-						},
-					)
+					hj.probeState.buildRuns.copy(outCols[i], hj.ht.Vals.ColVec(i), buildIdx)
 				}
 			}
-			if hj.spec.JoinType.IsLeftOuterOrFullOuter() {
-				// Add in the nulls we needed to set for the outer join.
+			// Add in the nulls we needed to set for the outer join.
+			if numUnmatched == nResults {
+				// None of the rows have a match (which is always the case when
+				// the hash table is empty), so all values are null.
 				for i := range hj.spec.Right.SourceTypes {
-					outCol := hj.output.ColVec(i + rightColOffset)
-					nulls := outCol.Nulls()
-					for i, isNull := range hj.probeState.probeRowUnmatched[:nResults] {
-						if isNull {
-							nulls.SetNull(i)
-						}
-					}
+					hj.output.ColVec(i + rightColOffset).Nulls().SetNullRange(0 /* startIdx */, nResults)
+				}
+			} else if numUnmatched > 0 {
+				// We merge the null bitmap of the unmatched rows into the nulls
+				// of each column a byte at a time.
+				for i := range hj.spec.Right.SourceTypes {
+					hj.output.ColVec(i + rightColOffset).Nulls().SetNullsFrom(&hj.probeState.unmatchedNulls, nResults)
 				}
 			}
 		}
@@ -648,14 +748,14 @@ func (hj *hashJoiner) congregate(nResults int, batch coldata.Batch) {
 					if !probeRowUnmatched[i] {
 						//gcassert:bce
 						bIdx := buildIdx[i]
-						hj.probeState.buildRowMatched[bIdx] = true
+						hj.probeState.buildRowMatched.set(bIdx)
 					}
 				}
 			} else {
 				for i := 0; i < nResults; i++ {
 					//gcassert:bce
 					bIdx := buildIdx[i]
-					hj.probeState.buildRowMatched[bIdx] = true
+					hj.probeState.buildRowMatched.set(bIdx)
 				}
 			}
 		}
@@ -680,9 +780,26 @@ func (hj *hashJoiner) ExportBuffered(input colexecop.Operator) coldata.Batch {
 		}
 		startIdx, endIdx := hj.exportBufferedState.rightExported, newRightExported
 		b := hj.exportBufferedState.rightWindowedBatch
+		if hj.spec.buildDistinct && !hj.exportBufferedState.nonKeyColsSet {
+			// The values of the non-equality columns are not needed for the
+			// join types with the distinct build, so we export NULLs in those
+			// columns. Note that we're spilling to disk at this point, so we
+			// have to use the unlimited allocator.
+			for i, t := range hj.spec.Right.SourceTypes {
+				if !hj.isRightEqCol(i) {
+					vec := hj.outputUnlimitedAllocator.NewMemColumn(t, coldata.BatchSize())
+					vec.Nulls().SetNulls()
+					b.ReplaceCol(vec, i)
+				}
+			}
+			hj.exportBufferedState.nonKeyColsSet = true
+		}
 		// We don't need to worry about selection vectors on hj.ht.Vals because the
 		// tuples have been already selected during building of the hash table.
 		for i := range hj.spec.Right.SourceTypes {
+			if hj.spec.buildDistinct && !hj.isRightEqCol(i) {
+				continue
+			}
 			window := hj.ht.Vals.ColVec(i).Window(startIdx, endIdx)
 			b.ReplaceCol(window, i)
 		}
@@ -698,6 +815,17 @@ func (hj *hashJoiner) ExportBuffered(input colexecop.Operator) coldata.Batch {
 	}
 }
 
+// isRightEqCol returns whether the right column with the given index is one of
+// the equality columns.
+func (hj *hashJoiner) isRightEqCol(colIdx int) bool {
+	for _, eqCol := range hj.spec.Right.EqCols {
+		if int(eqCol) == colIdx {
+			return true
+		}
+	}
+	return false
+}
+
 func (hj *hashJoiner) resetOutput(nResults int) {
 	minCapacity := nResults
 	if minCapacity < 1 {
@@ -730,6 +858,18 @@ func (hj *hashJoiner) Reset(ctx context.Context) {
 	hj.exportBufferedState.rightExported = 0
 }
 
+// Close implements the colexecop.Closer interface.
+func (hj *hashJoiner) Close(context.Context) error {
+	if !hj.CloserHelper.Close() {
+		return nil
+	}
+	// The output batch is no longer needed, so we give it back to the
+	// allocator in order for the batch to be reused by other operators.
+	hj.outputUnlimitedAllocator.ReleaseBatch(hj.output)
+	hj.output = nil
+	return nil
+}
+
 // MakeHashJoinerSpec creates a specification for columnar hash join operator.
 // leftEqCols and rightEqCols specify the equality columns while leftOutCols
 // and rightOutCols specifies the output columns. leftTypes and rightTypes
@@ -743,6 +883,12 @@ func MakeHashJoinerSpec(
 	rightTypes []*types.T,
 	rightDistinct bool,
 ) HashJoinerSpec {
+	// LEFT SEMI and LEFT ANTI joins only need to know whether there is at
+	// least one build tuple with the same key as a probe tuple, so there is no
+	// point in storing the build tuples with duplicate keys. Deduplicating
+	// the build tuples is not free though, so we don't do it if the keys are
+	// known to be distinct already.
+	buildDistinct := (joinType == descpb.LeftSemiJoin || joinType == descpb.LeftAntiJoin) && !rightDistinct
 	switch joinType {
 	case descpb.LeftSemiJoin:
 		// In a left semi join, we don't need to store anything but a single row per
@@ -790,6 +936,7 @@ func MakeHashJoinerSpec(
 		Right:             right,
 		trackBuildMatches: trackBuildMatches,
 		rightDistinct:     rightDistinct,
+		buildDistinct:     buildDistinct,
 	}
 }
 
@@ -806,13 +953,177 @@ func NewHashJoiner(
 	initialNumBuckets uint64,
 	memoryLimit int64,
 ) colexecop.ResettableOperator {
-	return &hashJoiner{
+	hj := &hashJoiner{
 		joinHelper:                 newJoinHelper(leftSource, rightSource),
 		buildSideAllocator:         buildSideAllocator,
 		outputUnlimitedAllocator:   outputUnlimitedAllocator,
 		spec:                       spec,
+		runtimeFilter:              newRuntimeFilter(spec),
+		probeInput:                 leftSource,
+		buildInput:                 rightSource,
 		memoryLimit:                memoryLimit,
 		outputTypes:                spec.JoinType.MakeOutputTypes(spec.Left.SourceTypes, spec.Right.SourceTypes),
 		hashTableInitialNumBuckets: initialNumBuckets,
 	}
+	if canDiscardNullProbeKeys(spec.JoinType) {
+		// The integer equality columns that are used by the runtime filter
+		// are already checked for NULL values by the runtimeFilterOp.
+		var nullableKeyCols []uint32
+		for _, colIdx := range spec.Left.EqCols {
+			if !hj.runtimeFilter.filtersProbeCol(int(colIdx)) {
+				nullableKeyCols = append(nullableKeyCols, colIdx)
+			}
+		}
+		if len(nullableKeyCols) > 0 {
+			// The probe tuples with NULL keys are discarded before the probe
+			// filter since the latter might be more expensive to evaluate.
+			hj.probeInput = newNullKeysFilterOp(hj.probeInput, nullableKeyCols)
+		}
+	}
+	if canDiscardNullBuildKeys(spec.JoinType) {
+		hj.buildInput = newNullKeysFilterOp(hj.buildInput, spec.Right.EqCols)
+	}
+	if spec.ProbeFilter != nil {
+		switch spec.JoinType {
+		case descpb.InnerJoin, descpb.LeftOuterJoin, descpb.LeftSemiJoin, descpb.LeftAntiJoin:
+			// The probe tuples that don't pass the filter would be removed
+			// from the output anyway, and whether the other probe tuples are
+			// emitted doesn't depend on them, so we can discard such tuples
+			// before probing.
+			hj.probeInput = spec.ProbeFilter(hj.probeInput)
+		case descpb.RightOuterJoin, descpb.FullOuterJoin:
+			// The probe tuples that don't pass the filter can still mark the
+			// build tuples as matched, and the filter must be evaluated on the
+			// build tuples that are NULL-padded on the left, so we have to
+			// apply the filter to the output.
+			hj.outputFilterInput = newProbeFilterFeeder(outputUnlimitedAllocator, spec.Left.SourceTypes)
+			hj.outputFilter = spec.ProbeFilter(hj.outputFilterInput)
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf(
+				"probe filter is not supported with %s join", spec.JoinType,
+			))
+		}
+	}
+	if hj.runtimeFilter != nil {
+		// Note that the inputs are still exposed as the children of the hash
+		// joiner (and are used in ExportBuffered) so that the runtime filter
+		// (as well as the probe and NULL keys filters) is transparent to the
+		// rest of the flow.
+		hj.probeInput = newRuntimeFilterOp(hj.probeInput, hj.runtimeFilter)
+	}
+	return hj
+}
+
+// NewProbeFilterOp returns an operator that applies spec.ProbeFilter to the
+// left columns of the batches returned by input which must have the output
+// schema of the join described by spec. It is meant to be used on top of the
+// join operators that don't support the probe filter natively (for example,
+// the merge joiner in the fallback strategy of the external hash joiner).
+func NewProbeFilterOp(
+	allocator *colmem.Allocator, input colexecop.Operator, spec HashJoinerSpec,
+) colexecop.ResettableOperator {
+	feeder := newProbeFilterFeeder(allocator, spec.Left.SourceTypes)
+	return &probeFilterOp{
+		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
+		feeder:                   feeder,
+		filter:                   spec.ProbeFilter(feeder),
+	}
+}
+
+type probeFilterOp struct {
+	colexecop.OneInputInitCloserHelper
+
+	feeder *probeFilterFeeder
+	filter colexecop.Operator
+}
+
+var _ colexecop.ResettableOperator = &probeFilterOp{}
+var _ colexecop.ClosableOperator = &probeFilterOp{}
+
+func (p *probeFilterOp) Init(ctx context.Context) {
+	if !p.InitHelper.Init(ctx) {
+		return
+	}
+	p.Input.Init(p.Ctx)
+	p.filter.Init(p.Ctx)
+}
+
+func (p *probeFilterOp) Next() coldata.Batch {
+	for {
+		batch := p.Input.Next()
+		if batch.Length() == 0 || p.feeder.apply(p.filter, batch) > 0 {
+			return batch
+		}
+	}
+}
+
+func (p *probeFilterOp) Reset(ctx context.Context) {
+	if r, ok := p.Input.(colexecop.Resetter); ok {
+		r.Reset(ctx)
+	}
+}
+
+// probeFilterFeeder is a helper operator that returns the left columns of the
+// join output as a batch on the first call to Next and a zero batch on all
+// consequent calls (until it is reset). Using a separate batch allows the
+// probe filter to append the columns it needs for the evaluation without
+// clobbering the right columns of the output.
+type probeFilterFeeder struct {
+	colexecop.ZeroInputNode
+	colexecop.NonExplainable
+
+	nexted bool
+	batch  coldata.Batch
+	// numLeftCols is the number of the left columns. Note that the width of
+	// batch can be larger if the filter appends columns to it.
+	numLeftCols int
+}
+
+var _ colexecop.Operator = &probeFilterFeeder{}
+
+func newProbeFilterFeeder(allocator *colmem.Allocator, leftTypes []*types.T) *probeFilterFeeder {
+	return &probeFilterFeeder{
+		batch:       allocator.NewMemBatchNoCols(leftTypes, coldata.BatchSize()),
+		numLeftCols: len(leftTypes),
+	}
+}
+
+func (f *probeFilterFeeder) Init(context.Context) {}
+
+func (f *probeFilterFeeder) Next() coldata.Batch {
+	if f.nexted {
+		return coldata.ZeroBatch
+	}
+	f.nexted = true
+	return f.batch
+}
+
+func (f *probeFilterFeeder) reset(output coldata.Batch) {
+	f.nexted = false
+	for i := 0; i < f.numLeftCols; i++ {
+		f.batch.ReplaceCol(output.ColVec(i), i)
+	}
+	n := output.Length()
+	f.batch.SetLength(n)
+	if sel := output.Selection(); sel != nil {
+		f.batch.SetSelection(true)
+		copy(f.batch.Selection()[:n], sel[:n])
+	} else {
+		f.batch.SetSelection(false)
+	}
+}
+
+// apply evaluates filter, which must be fed by f, on the left columns of
+// output and removes the tuples that didn't pass it from output. The number of
+// the remaining tuples is returned.
+func (f *probeFilterFeeder) apply(filter colexecop.Operator, output coldata.Batch) int {
+	f.reset(output)
+	filtered := filter.Next()
+	n := filtered.Length()
+	if sel := filtered.Selection(); n > 0 && sel != nil {
+		output.SetSelection(true)
+		copy(output.Selection()[:n], sel[:n])
+	}
+	output.SetLength(n)
+	return n
 }
@@ -0,0 +1,408 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashJoinerDistinctBuild verifies that the LEFT SEMI and LEFT ANTI hash
+// joins that store only the build tuples with distinct keys in the hash table
+// produce the same output as when all build tuples are stored.
+func TestHashJoinerDistinctBuild(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	for _, joinType := range []descpb.JoinType{descpb.LeftSemiJoin, descpb.LeftAntiJoin} {
+		for _, keyTypes := range [][]*types.T{
+			{types.Int},
+			{types.Bytes, types.Int},
+		} {
+			// Both the left and the right tuples consist of the key columns
+			// followed by the row index. The right tuples have a lot of
+			// duplicate keys.
+			nKeys := len(keyTypes)
+			typs := append(append([]*types.T{}, keyTypes...), types.Int)
+			eqCols := make([]uint32, nKeys)
+			for k := range eqCols {
+				eqCols[k] = uint32(k)
+			}
+			makeTuples := func(numTuples, numKeys int) colexectestutils.Tuples {
+				tuples := make(colexectestutils.Tuples, numTuples)
+				for i := range tuples {
+					tuples[i] = make(colexectestutils.Tuple, nKeys+1)
+					for k, keyType := range keyTypes {
+						var key interface{}
+						if rng.Float64() >= 0.1 {
+							key = rng.Intn(numKeys)
+							if keyType.Family() == types.BytesFamily {
+								key = fmt.Sprintf("%d", key)
+							}
+						}
+						tuples[i][k] = key
+					}
+					tuples[i][nKeys] = i
+				}
+				return tuples
+			}
+			numKeys := 1 + rng.Intn(10)
+			leftTuples := makeTuples(rng.Intn(300), 2*numKeys)
+			rightTuples := makeTuples(rng.Intn(3*coldata.BatchSize()), numKeys)
+			newHashJoiner := func(left, right colexecop.Operator, buildDistinct bool) *hashJoiner {
+				spec := MakeHashJoinerSpec(joinType, eqCols, eqCols, typs, typs, false /* rightDistinct */)
+				spec.buildDistinct = buildDistinct
+				return NewHashJoiner(
+					testAllocator, testAllocator, spec, left, right,
+					HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+				).(*hashJoiner)
+			}
+
+			runHashJoiner := func(buildDistinct bool) (colexectestutils.Tuples, *hashJoiner) {
+				hj := newHashJoiner(
+					colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), leftTuples, typs),
+					colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), rightTuples, typs),
+					buildDistinct,
+				)
+				hj.Init(ctx)
+				var output colexectestutils.Tuples
+				for b := hj.Next(); b.Length() > 0; b = hj.Next() {
+					for i := 0; i < b.Length(); i++ {
+						output = append(output, colexectestutils.GetTupleFromBatch(b, i))
+					}
+				}
+				return output, hj
+			}
+			// Compute the expected output with all build tuples stored in the
+			// hash table.
+			expected, _ := runHashJoiner(false /* buildDistinct */)
+			// Make sure that the hash table only stores a single tuple per
+			// distinct key (the tuples with NULL keys are not stored at all).
+			_, hj := runHashJoiner(true /* buildDistinct */)
+			require.Equal(t, numDistinctKeys(rightTuples, nKeys), hj.ht.Vals.Length())
+
+			log.Infof(ctx, "%s: keyTypes=%s", joinType, keyTypes)
+			// We're omitting all nulls injection test because the expected
+			// output is computed for the original inputs.
+			colexectestutils.RunTestsWithoutAllNullsInjection(
+				t, testAllocator,
+				[]colexectestutils.Tuples{leftTuples, rightTuples},
+				[][]*types.T{typs, typs},
+				expected, colexectestutils.UnorderedVerifier,
+				func(sources []colexecop.Operator) (colexecop.Operator, error) {
+					return newHashJoiner(sources[0], sources[1], true /* buildDistinct */), nil
+				},
+			)
+		}
+	}
+}
+
+// numDistinctKeys returns the number of distinct non-NULL keys among the
+// first nKeys columns of tuples.
+func numDistinctKeys(tuples colexectestutils.Tuples, nKeys int) int {
+	keys := make(map[string]struct{})
+	for _, tuple := range tuples {
+		key := tuple[:nKeys]
+		hasNull := false
+		for _, v := range key {
+			hasNull = hasNull || v == nil
+		}
+		if !hasNull {
+			keys[fmt.Sprint(key)] = struct{}{}
+		}
+	}
+	return len(keys)
+}
+
+func BenchmarkHashJoinerDistinctBuild(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	typs := []*types.T{types.Int, types.Int}
+	for _, joinType := range []descpb.JoinType{descpb.LeftSemiJoin, descpb.LeftAntiJoin} {
+		for _, numDuplicates := range []int{1, 16, 256} {
+			for _, buildDistinct := range []bool{false, true} {
+				// Every key on the build side has numDuplicates tuples, and the
+				// build side has 1 << 16 tuples in total.
+				const numBuildTuples = 1 << 16
+				b.Run(fmt.Sprintf("%s/numDuplicates=%d/buildDistinct=%t", joinType, numDuplicates, buildDistinct), func(b *testing.B) {
+					batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+					for i := 0; i < coldata.BatchSize(); i++ {
+						batch.ColVec(0).Int64()[i] = int64(i)
+						batch.ColVec(1).Int64()[i] = int64(i)
+					}
+					batch.SetLength(coldata.BatchSize())
+					numBuildBatches := numBuildTuples / coldata.BatchSize()
+					numDistinctBatches := numBuildBatches / numDuplicates
+					if numDistinctBatches == 0 {
+						numDistinctBatches = 1
+					}
+					b.SetBytes(int64(8 * numBuildTuples * len(typs)))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, numDistinctBatches)
+						// The right source emits the same numDistinctBatches
+						// batches over and over with increasing keys.
+						var batchIdx int
+						rightBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+						rightSource := &colexecop.CallbackOperator{
+							NextCb: func() coldata.Batch {
+								if batchIdx == numBuildBatches {
+									return coldata.ZeroBatch
+								}
+								offset := int64((batchIdx % numDistinctBatches) * coldata.BatchSize())
+								keys, vals := rightBatch.ColVec(0).Int64(), rightBatch.ColVec(1).Int64()
+								for j := 0; j < coldata.BatchSize(); j++ {
+									keys[j] = offset + int64(j)
+									vals[j] = int64(batchIdx)
+								}
+								// The selection vector might have been set by the
+								// hash joiner on the previous batch.
+								rightBatch.SetSelection(false)
+								rightBatch.SetLength(coldata.BatchSize())
+								batchIdx++
+								return rightBatch
+							},
+						}
+						spec := MakeHashJoinerSpec(joinType, []uint32{0}, []uint32{0}, typs, typs, false /* rightDistinct */)
+						spec.buildDistinct = buildDistinct
+						hj := NewHashJoiner(
+							testAllocator, testAllocator, spec, leftSource, rightSource,
+							HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+						)
+						hj.Init(ctx)
+						for hj.Next().Length() > 0 {
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestContiguousRuns verifies that copying the values run by run produces the
+// same result as gathering them one at a time.
+func TestContiguousRuns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const srcLength = 1024
+	src := testAllocator.NewMemColumn(types.Int, srcLength)
+	for i := 0; i < srcLength; i++ {
+		src.Int64()[i] = int64(i)
+		if rng.Float64() < 0.1 {
+			src.Nulls().SetNull(i)
+		}
+	}
+	for _, maxRunLength := range []int{1, 2, minAvgRunLengthForBulkCopy, 4 * minAvgRunLengthForBulkCopy} {
+		// Generate the indices consisting of runs of random lengths.
+		numIndices := 1 + rng.Intn(srcLength)
+		idx := make([]int, 0, numIndices)
+		for len(idx) < numIndices {
+			runLength := 1 + rng.Intn(maxRunLength)
+			start := rng.Intn(srcLength - runLength + 1)
+			for i := 0; i < runLength && len(idx) < numIndices; i++ {
+				idx = append(idx, start+i)
+			}
+		}
+		var runs contiguousRuns
+		runs.init(idx)
+		if maxRunLength == 1 {
+			require.False(t, runs.useRuns)
+		}
+		dst := testAllocator.NewMemColumn(types.Int, numIndices)
+		runs.copy(dst, src, idx)
+		for i, srcIdx := range idx {
+			require.Equal(t, src.Nulls().NullAt(srcIdx), dst.Nulls().NullAt(i))
+			if !src.Nulls().NullAt(srcIdx) {
+				require.Equal(t, src.Int64()[srcIdx], dst.Int64()[i])
+			}
+		}
+	}
+
+	// A single run is always copied in bulk.
+	var runs contiguousRuns
+	idx := []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	runs.init(idx)
+	require.True(t, runs.useRuns)
+	require.Equal(t, []int{0, len(idx)}, runs.starts)
+}
+
+// TestHashJoinerHighFanout verifies the hash joiner on inputs where every key
+// of one side matches a contiguous run of the rows of the other side, in which
+// case the matched rows are copied into the output in bulk.
+func TestHashJoinerHighFanout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	// Both the left and the right tuples consist of the key column followed
+	// by the row index.
+	typs := []*types.T{types.Int, types.Int}
+	makeTuples := func(numKeys, fanout int) colexectestutils.Tuples {
+		tuples := make(colexectestutils.Tuples, 0, numKeys*fanout)
+		for key := 0; key < numKeys; key++ {
+			for j := 0; j < fanout; j++ {
+				tuples = append(tuples, colexectestutils.Tuple{key, len(tuples)})
+			}
+		}
+		return tuples
+	}
+	for _, joinType := range []descpb.JoinType{descpb.InnerJoin, descpb.LeftOuterJoin, descpb.FullOuterJoin} {
+		for _, fanout := range [][2]int{{1, 1 + rng.Intn(64)}, {1 + rng.Intn(64), 1}} {
+			leftFanout, rightFanout := fanout[0], fanout[1]
+			// The left side has more keys than the right one, so some of the
+			// left rows don't have a match.
+			numRightKeys := 1 + rng.Intn(64)
+			leftTuples := makeTuples(numRightKeys+rng.Intn(8), leftFanout)
+			rightTuples := makeTuples(numRightKeys, rightFanout)
+			var expected colexectestutils.Tuples
+			for _, l := range leftTuples {
+				matched := false
+				for _, r := range rightTuples {
+					if l[0] == r[0] {
+						expected = append(expected, colexectestutils.Tuple{l[0], l[1], r[0], r[1]})
+						matched = true
+					}
+				}
+				if !matched && joinType != descpb.InnerJoin {
+					expected = append(expected, colexectestutils.Tuple{l[0], l[1], nil, nil})
+				}
+			}
+			log.Infof(ctx, "%s: leftFanout=%d rightFanout=%d", joinType, leftFanout, rightFanout)
+			// We're omitting all nulls injection test because the expected
+			// output is computed for the original inputs.
+			colexectestutils.RunTestsWithoutAllNullsInjection(
+				t, testAllocator,
+				[]colexectestutils.Tuples{leftTuples, rightTuples},
+				[][]*types.T{typs, typs},
+				expected, colexectestutils.UnorderedVerifier,
+				func(sources []colexecop.Operator) (colexecop.Operator, error) {
+					spec := MakeHashJoinerSpec(
+						joinType, []uint32{0}, []uint32{0}, typs, typs, false, /* rightDistinct */
+					)
+					return NewHashJoiner(
+						testAllocator, testAllocator, spec, sources[0], sources[1],
+						HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					), nil
+				},
+			)
+		}
+	}
+
+	t.Run("build order", func(t *testing.T) {
+		// A single left row matches all of the right rows, which are emitted in
+		// the order of the right input and are copied in bulk.
+		rightTuples := makeTuples(1 /* numKeys */, 3*coldata.BatchSize()+1)
+		spec := MakeHashJoinerSpec(
+			descpb.InnerJoin, []uint32{0}, []uint32{0}, typs, typs, false, /* rightDistinct */
+		)
+		hj := NewHashJoiner(
+			testAllocator, testAllocator, spec,
+			colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), makeTuples(1 /* numKeys */, 1 /* fanout */), typs),
+			colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), rightTuples, typs),
+			HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+		).(*hashJoiner)
+		hj.Init(ctx)
+		var rightIdx int64
+		for b := hj.Next(); b.Length() > 0; b = hj.Next() {
+			if b.Length() >= minAvgRunLengthForBulkCopy {
+				require.True(t, hj.probeState.buildRuns.useRuns)
+			}
+			for i := 0; i < b.Length(); i++ {
+				require.Equal(t, rightIdx, b.ColVec(3).Int64()[i])
+				rightIdx++
+			}
+		}
+		require.Equal(t, int64(len(rightTuples)), rightIdx)
+	})
+}
+
+func BenchmarkHashJoinerHighFanout(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	typs := []*types.T{types.Int, types.Int}
+	// clusteredSource returns an operator that emits numKeys keys in
+	// increasing order with every key repeated fanout times in a row.
+	clusteredSource := func(numKeys, fanout int) colexecop.Operator {
+		var numEmitted int
+		numTuples := numKeys * fanout
+		batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+		return &colexecop.CallbackOperator{
+			NextCb: func() coldata.Batch {
+				if numEmitted == numTuples {
+					return coldata.ZeroBatch
+				}
+				n := coldata.BatchSize()
+				if numTuples-numEmitted < n {
+					n = numTuples - numEmitted
+				}
+				keys, vals := batch.ColVec(0).Int64(), batch.ColVec(1).Int64()
+				for j := 0; j < n; j++ {
+					keys[j] = int64((numEmitted + j) / fanout)
+					vals[j] = int64(numEmitted + j)
+				}
+				// The selection vector might have been set by the hash joiner
+				// on the previous batch.
+				batch.SetSelection(false)
+				batch.SetLength(n)
+				numEmitted += n
+				return batch
+			},
+		}
+	}
+	for _, fanout := range []int{1, 16, 256} {
+		for _, fanoutOnBuildSide := range []bool{true, false} {
+			// Every key matches fanout rows of one side and a single row of
+			// the other side, and the output has 1 << 16 tuples in total.
+			const numOutputTuples = 1 << 16
+			numKeys := numOutputTuples / fanout
+			leftFanout, rightFanout := fanout, 1
+			if fanoutOnBuildSide {
+				leftFanout, rightFanout = 1, fanout
+			}
+			b.Run(fmt.Sprintf("fanout=%d/buildSide=%t", fanout, fanoutOnBuildSide), func(b *testing.B) {
+				b.SetBytes(int64(8 * numOutputTuples * 2 * len(typs)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					spec := MakeHashJoinerSpec(
+						descpb.InnerJoin, []uint32{0}, []uint32{0}, typs, typs, false, /* rightDistinct */
+					)
+					hj := NewHashJoiner(
+						testAllocator, testAllocator, spec,
+						clusteredSource(numKeys, leftFanout), clusteredSource(numKeys, rightFanout),
+						HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					)
+					hj.Init(ctx)
+					for hj.Next().Length() > 0 {
+					}
+				}
+			})
+		}
+	}
+}
@@ -183,7 +183,7 @@ func collectRightSemiAnti(hj *hashJoiner, batchSize int) {
 		//gcassert:bce
 		currentID := HeadIDs[i]
 		for currentID != 0 {
-			hj.probeState.buildRowMatched[currentID-1] = true
+			hj.probeState.buildRowMatched.set(int(currentID - 1))
 			currentID = hj.ht.Same[currentID]
 		}
 	}
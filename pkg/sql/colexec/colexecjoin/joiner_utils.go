@@ -13,6 +13,7 @@ package colexecjoin
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
@@ -56,3 +57,106 @@ func (h *joinHelper) Child(nth int, verbose bool) execinfra.OpNode {
 	// This code is unreachable, but the compiler cannot infer that.
 	return nil
 }
+
+// matchedBitmap is a bitmap that tracks which tuples (of the build side of a
+// join) have had a match.
+type matchedBitmap []uint64
+
+// reset prepares the bitmap to track n tuples none of which have had a match.
+func (b *matchedBitmap) reset(n int) {
+	numWords := (n + 63) >> 6
+	if cap(*b) < numWords {
+		*b = make(matchedBitmap, numWords)
+		return
+	}
+	*b = (*b)[:numWords]
+	for i := range *b {
+		(*b)[i] = 0
+	}
+}
+
+// set marks the tuple at position i as matched.
+func (b matchedBitmap) set(i int) {
+	b[i>>6] |= 1 << uint(i&63)
+}
+
+// isSet returns whether the tuple at position i has had a match.
+func (b matchedBitmap) isSet(i int) bool {
+	return b[i>>6]&(1<<uint(i&63)) != 0
+}
+
+// word returns the 64 bits of the bitmap for the tuples starting at position
+// i, which must be a multiple of 64.
+func (b matchedBitmap) word(i int) uint64 {
+	return b[i>>6]
+}
+
+// minAvgRunLengthForBulkCopy is the minimum average length of the runs of
+// consecutive indices for which copying the values run by run is faster than
+// gathering them one at a time.
+const minAvgRunLengthForBulkCopy = 8
+
+// contiguousRuns splits a slice of indices into the runs of consecutive values
+// (i.e. idx[i+1] = idx[i]+1) in order to copy the values at those indices in
+// bulk, one run at a time. This is beneficial for high-fanout joins where a
+// single row on one side matches a contiguous range of rows on the other
+// side.
+type contiguousRuns struct {
+	// starts contains the position at which each of the runs begins followed
+	// by the total number of indices.
+	starts []int
+	// useRuns indicates whether the runs are long enough on average for the
+	// bulk copy to be used.
+	useRuns bool
+}
+
+// init finds the runs of consecutive values in idx. It gives up as soon as it
+// becomes clear that the runs are too short on average for the bulk copy to
+// pay off.
+func (r *contiguousRuns) init(idx []int) {
+	r.starts = r.starts[:0]
+	r.useRuns = false
+	maxNumRuns := len(idx) / minAvgRunLengthForBulkCopy
+	for i := range idx {
+		if i == 0 || idx[i] != idx[i-1]+1 {
+			if len(r.starts) == maxNumRuns {
+				return
+			}
+			r.starts = append(r.starts, i)
+		}
+	}
+	r.starts = append(r.starts, len(idx))
+	r.useRuns = true
+}
+
+// copy copies src[idx[i]] into dst[i] for all i in [0, len(idx)) where idx
+// must be the same slice that r has been initialized with. If the runs are
+// long enough, each of them is copied in a single operation; otherwise, the
+// values are gathered using idx as the selection vector.
+func (r *contiguousRuns) copy(dst, src coldata.Vec, idx []int) {
+	if !r.useRuns {
+		dst.Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:       src,
+					Sel:       idx,
+					SrcEndIdx: len(idx),
+				},
+			},
+		)
+		return
+	}
+	for i := 0; i < len(r.starts)-1; i++ {
+		start, end := r.starts[i], r.starts[i+1]
+		dst.Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         src,
+					DestIdx:     start,
+					SrcStartIdx: idx[start],
+					SrcEndIdx:   idx[start] + end - start,
+				},
+			},
+		)
+	}
+}
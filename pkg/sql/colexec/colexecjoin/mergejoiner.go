@@ -224,6 +224,31 @@ func NewMergeJoinOp(
 	leftOrdering []execinfrapb.Ordering_Column,
 	rightOrdering []execinfrapb.Ordering_Column,
 	diskAcc *mon.BoundAccount,
+) (colexecop.ResettableOperator, error) {
+	return NewMergeJoinOpWithLimit(
+		unlimitedAllocator, memoryLimit, diskQueueCfg, fdSemaphore, joinType, left, right,
+		leftTypes, rightTypes, leftOrdering, rightOrdering, diskAcc, 0, /* limit */
+	)
+}
+
+// NewMergeJoinOpWithLimit is the same as NewMergeJoinOp but also takes the
+// maximum number of tuples that the merge joiner needs to emit (zero indicates
+// no limit). Once the limit is reached, the merge joiner stops pulling from its
+// inputs and releases its buffered group right away.
+func NewMergeJoinOpWithLimit(
+	unlimitedAllocator *colmem.Allocator,
+	memoryLimit int64,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	fdSemaphore semaphore.Semaphore,
+	joinType descpb.JoinType,
+	left colexecop.Operator,
+	right colexecop.Operator,
+	leftTypes []*types.T,
+	rightTypes []*types.T,
+	leftOrdering []execinfrapb.Ordering_Column,
+	rightOrdering []execinfrapb.Ordering_Column,
+	diskAcc *mon.BoundAccount,
+	limit uint64,
 ) (colexecop.ResettableOperator, error) {
 	// Merge joiner only supports the case when the physical types in the
 	// equality columns in both inputs are the same. We, however, also need to
@@ -253,24 +278,12 @@ func NewMergeJoinOp(
 		rightType := rightTypes[rightColIdx]
 		if !leftType.Identical(rightType) && leftType.IsNumeric() && rightType.IsNumeric() {
 			// The types are different and both are numeric, so we need to plan
-			// a cast. There is a hierarchy of valid casts:
-			//   INT2 -> INT4 -> INT8 -> FLOAT -> DECIMAL
-			// and the cast is valid if 'fromType' is mentioned before 'toType'
-			// in this chain.
-			castLeftToRight := false
-			switch leftType.Family() {
-			case types.IntFamily:
-				switch leftType.Width() {
-				case 16:
-					castLeftToRight = true
-				case 32:
-					castLeftToRight = !rightType.Identical(types.Int2)
-				default:
-					castLeftToRight = rightType.Family() != types.IntFamily
-				}
-			case types.FloatFamily:
-				castLeftToRight = rightType.Family() == types.DecimalFamily
+			// a cast of the narrower type to the common supertype.
+			commonType := colexecbase.CommonSupertype(leftType, rightType)
+			if commonType == nil {
+				return nil, errors.AssertionFailedf("cannot merge join on %s and %s", leftType, rightType)
 			}
+			castLeftToRight := commonType.Identical(rightType)
 			if castLeftToRight {
 				castColumnIdx := len(actualLeftTypes)
 				left, err = colexecbase.GetCastOperator(unlimitedAllocator, left, int(leftColIdx), castColumnIdx, leftType, rightType)
@@ -298,6 +311,7 @@ func NewMergeJoinOp(
 	if err != nil {
 		return nil, err
 	}
+	base.limit = limit
 	var mergeJoinerOp colexecop.ResettableOperator
 	switch joinType {
 	case descpb.InnerJoin:
@@ -336,14 +350,12 @@ func NewMergeJoinOp(
 	numLeftTypes := len(leftTypes)
 	numRightTypes := len(rightTypes)
 	numActualLeftTypes := len(actualLeftTypes)
-	numActualRightTypes := len(actualRightTypes)
 	if !joinType.ShouldIncludeLeftColsInOutput() {
 		numLeftTypes = 0
 		numActualLeftTypes = 0
 	}
 	if !joinType.ShouldIncludeRightColsInOutput() {
 		numRightTypes = 0
-		numActualRightTypes = 0
 	}
 	projection := make([]uint32, 0, numLeftTypes+numRightTypes)
 	for i := 0; i < numLeftTypes; i++ {
@@ -356,7 +368,7 @@ func NewMergeJoinOp(
 		projection = append(projection, uint32(numActualLeftTypes+i))
 	}
 	return colexecbase.NewSimpleProjectOp(
-		mergeJoinerOp, numActualLeftTypes+numActualRightTypes, projection,
+		mergeJoinerOp, joinType.MakeOutputTypes(actualLeftTypes, actualRightTypes), projection,
 	).(colexecop.ResettableOperator), nil
 }
 
@@ -485,6 +497,13 @@ type mergeJoinBase struct {
 	builderState  mjBuilderState
 
 	diskAcc *mon.BoundAccount
+
+	// limit, if non-zero, is the maximum number of tuples that the merge joiner
+	// needs to emit.
+	limit uint64
+	// numEmitted is the number of tuples emitted so far. It is only maintained
+	// if limit is non-zero.
+	numEmitted uint64
 }
 
 var _ colexecop.Resetter = &mergeJoinBase{}
@@ -504,6 +523,7 @@ func (o *mergeJoinBase) Reset(ctx context.Context) {
 	o.proberState.lBatch = nil
 	o.proberState.rBatch = nil
 	o.resetBuilderCrossProductState()
+	o.numEmitted = 0
 }
 
 func (o *mergeJoinBase) Init(ctx context.Context) {
@@ -752,6 +772,28 @@ func (o *mergeJoinBase) completeBufferedGroup(
 	return batch, rowIdx, batchLength
 }
 
+// maybeApplyLimit truncates the output batch that is about to be emitted so
+// that no more than limit tuples are emitted in total. Once the limit is
+// reached, the merge joiner transitions into the finished state, so it won't
+// pull from its inputs anymore. The buffered group is reset right away (which
+// closes the disk queues if there are any) since the consumer might not call
+// Next again.
+func (o *mergeJoinBase) maybeApplyLimit() {
+	if o.limit == 0 {
+		return
+	}
+	length := uint64(o.output.Length())
+	if o.numEmitted+length < o.limit {
+		o.numEmitted += length
+		return
+	}
+	o.output.SetLength(int(o.limit - o.numEmitted))
+	o.numEmitted = o.limit
+	o.state = mjDone
+	o.bufferedGroup.helper.Reset(o.Ctx)
+	o.bufferedGroup.needToReset = false
+}
+
 // finishProbe completes the buffered groups on both sides of the input.
 func (o *mergeJoinBase) finishProbe() {
 	o.proberState.lBatch, o.proberState.lIdx, o.proberState.lLength = o.completeBufferedGroup(
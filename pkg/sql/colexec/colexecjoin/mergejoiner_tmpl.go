@@ -1260,6 +1260,7 @@ func (o *mergeJoin_JOIN_TYPE_STRINGOp) Next() coldata.Batch {
 				// Reset builder out count.
 				o.builderState.outCount = 0
 				o.outputReady = false
+				o.maybeApplyLimit()
 				return o.output
 			}
 		case mjDone:
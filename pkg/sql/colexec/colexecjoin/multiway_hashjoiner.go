@@ -0,0 +1,562 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/errors"
+)
+
+// MultiwayHashJoinerBuildSpec is the specification of a single build input of
+// the multi-way hash joiner.
+type MultiwayHashJoinerBuildSpec struct {
+	// ProbeEqCols specify the indices of the equality columns of the probe
+	// input that are joined with the equality columns of this build input.
+	ProbeEqCols []uint32
+	// EqCols specify the indices of the equality columns of this build input.
+	EqCols []uint32
+	// SourceTypes specify the types of the columns of this build input.
+	SourceTypes []*types.T
+	// Distinct indicates whether the equality columns of this build input
+	// form a key. If they do, the probing can be optimized.
+	Distinct bool
+}
+
+// multiwayHashJoinerState represents the state of the multi-way hash joiner.
+type multiwayHashJoinerState int
+
+const (
+	// mhjBuilding represents the state the multiwayHashJoiner is in when it
+	// is building the hash tables from all of the build inputs.
+	mhjBuilding multiwayHashJoinerState = iota
+	// mhjProbing represents the state the multiwayHashJoiner is in when it is
+	// probing all of the hash tables with the batches from the probe input.
+	mhjProbing
+	// mhjDone represents the state the multiwayHashJoiner is in when it has
+	// finished emitting all output tuples.
+	mhjDone
+)
+
+// multiwayHashJoiner performs an inner join of a single probe input with
+// multiple build inputs where each of the build inputs is joined with the
+// probe input on its own equality columns. This is the shape of the
+// star-schema queries that join a fact table (the probe input) against several
+// dimension tables (the build inputs):
+//
+//   SELECT * FROM fact
+//     JOIN dim1 ON fact.a = dim1.a
+//     JOIN dim2 ON fact.b = dim2.b
+//     JOIN dim3 ON fact.c = dim3.c
+//
+// Such a query is otherwise executed by a chain of hash joiners where each
+// joiner materializes the intermediate result (the fact columns together with
+// the columns of the dimensions joined so far) only for the next joiner to
+// copy it once again. Instead, the multi-way hash joiner builds all of the hash
+// tables once and then probes all of them in a single pass over each probe
+// batch. The probe tuples that don't have a match in one hash table are not
+// probed against the following ones, and the output tuples are populated at
+// once from the probe batch and the hash tables.
+//
+// The output consists of the probe columns followed by the columns of each
+// of the build inputs in order. The tuples with NULL values in any of the
+// equality columns are discarded since they cannot have a match. Note that
+// the multi-way hash joiner doesn't support spilling to disk, so all of the
+// build inputs must fit within the memory limit of buildSideAllocator.
+type multiwayHashJoiner struct {
+	colexecop.InitHelper
+
+	buildSideAllocator       *colmem.Allocator
+	outputUnlimitedAllocator *colmem.Allocator
+	memoryLimit              int64
+
+	// probeSource and buildSources are the inputs of the joiner. probeInput
+	// and buildInputs are the same inputs wrapped with the nullKeysFilterOps.
+	probeSource  colexecop.Operator
+	buildSources []colexecop.Operator
+	probeInput   colexecop.Operator
+	buildInputs  []colexecop.Operator
+
+	probeTypes  []*types.T
+	buildSpecs  []MultiwayHashJoinerBuildSpec
+	outputTypes []*types.T
+
+	// allDistinct indicates whether all of the build inputs are distinct, so
+	// every probe tuple is joined with at most one tuple from each of them.
+	allDistinct bool
+
+	state multiwayHashJoinerState
+	// hts contains the hash table for each of the build inputs.
+	hts []*colexechash.HashTable
+
+	probeState struct {
+		// batch is the current probe batch, and numRows is the number of its
+		// tuples that have a match in all of the hash tables. sel contains
+		// the indices of those tuples, and matchIDs[i] contains the keyIDs of
+		// their first matches in the i-th hash table. useSel indicates
+		// whether sel must be used when probing (it is false if the probe
+		// batch doesn't have a selection vector and all of its tuples have
+		// had a match so far).
+		batch    coldata.Batch
+		numRows  int
+		sel      []int
+		useSel   bool
+		matchIDs [][]uint64
+		buckets  []uint64
+
+		// resumeIdx is the position in sel of the tuple to resume emitting
+		// the output from. If resuming is true, then the tuple has been
+		// partially emitted, and curIDs contains the keyIDs of the
+		// combination of the matches to emit next (this is only possible
+		// when some of the build inputs are not distinct).
+		resumeIdx int
+		curIDs    []uint64
+		resuming  bool
+
+		// probeIdx and buildIdxs are the selection vectors into the probe
+		// batch and the hash tables, respectively, that are used to populate
+		// the output batch.
+		probeIdx  []int
+		buildIdxs [][]int
+	}
+
+	output coldata.Batch
+}
+
+var _ colexecop.Operator = &multiwayHashJoiner{}
+
+// NewMultiwayHashJoiner creates a new multi-way inner hash join operator that
+// joins probeSource with each of buildSources according to the corresponding
+// buildSpecs. See multiwayHashJoiner for more details.
+//
+// buildSideAllocator should use a limited memory account and will be used for
+// the hash tables whereas outputUnlimitedAllocator should use an unlimited
+// memory account and will only be used when populating the output. memoryLimit
+// will limit the size of the batches produced by the joiner.
+func NewMultiwayHashJoiner(
+	buildSideAllocator, outputUnlimitedAllocator *colmem.Allocator,
+	probeSource colexecop.Operator,
+	probeTypes []*types.T,
+	buildSources []colexecop.Operator,
+	buildSpecs []MultiwayHashJoinerBuildSpec,
+	memoryLimit int64,
+) (colexecop.Operator, error) {
+	if len(buildSources) != len(buildSpecs) {
+		return nil, errors.AssertionFailedf(
+			"mismatched number of build inputs %d and specs %d", len(buildSources), len(buildSpecs),
+		)
+	}
+	outputTypes := append([]*types.T{}, probeTypes...)
+	allDistinct := true
+	var probeKeyCols util.FastIntSet
+	buildInputs := make([]colexecop.Operator, len(buildSources))
+	for i, spec := range buildSpecs {
+		if len(spec.ProbeEqCols) == 0 || len(spec.ProbeEqCols) != len(spec.EqCols) {
+			return nil, errors.AssertionFailedf(
+				"invalid number of equality columns %d and %d of build input %d",
+				len(spec.ProbeEqCols), len(spec.EqCols), i,
+			)
+		}
+		for _, colIdx := range spec.ProbeEqCols {
+			probeKeyCols.Add(int(colIdx))
+		}
+		buildInputs[i] = newNullKeysFilterOp(buildSources[i], spec.EqCols)
+		outputTypes = append(outputTypes, spec.SourceTypes...)
+		allDistinct = allDistinct && spec.Distinct
+	}
+	probeEqCols := make([]uint32, 0, probeKeyCols.Len())
+	probeKeyCols.ForEach(func(colIdx int) {
+		probeEqCols = append(probeEqCols, uint32(colIdx))
+	})
+	return &multiwayHashJoiner{
+		buildSideAllocator:       buildSideAllocator,
+		outputUnlimitedAllocator: outputUnlimitedAllocator,
+		memoryLimit:              memoryLimit,
+		probeSource:              probeSource,
+		buildSources:             buildSources,
+		probeInput:               newNullKeysFilterOp(probeSource, probeEqCols),
+		buildInputs:              buildInputs,
+		probeTypes:               probeTypes,
+		buildSpecs:               buildSpecs,
+		outputTypes:              outputTypes,
+		allDistinct:              allDistinct,
+	}, nil
+}
+
+// ChildCount implements the execinfra.OpNode interface.
+func (mhj *multiwayHashJoiner) ChildCount(verbose bool) int {
+	return 1 + len(mhj.buildSources)
+}
+
+// Child implements the execinfra.OpNode interface.
+func (mhj *multiwayHashJoiner) Child(nth int, verbose bool) execinfra.OpNode {
+	if nth == 0 {
+		return mhj.probeSource
+	}
+	return mhj.buildSources[nth-1]
+}
+
+func (mhj *multiwayHashJoiner) Init(ctx context.Context) {
+	if !mhj.InitHelper.Init(ctx) {
+		return
+	}
+	mhj.probeInput.Init(mhj.Ctx)
+	for _, input := range mhj.buildInputs {
+		input.Init(mhj.Ctx)
+	}
+	// This number was chosen to match the one used by the hash joiner.
+	const hashTableLoadFactor = 1.0
+	numBuilds := len(mhj.buildSpecs)
+	mhj.hts = make([]*colexechash.HashTable, numBuilds)
+	for i, spec := range mhj.buildSpecs {
+		mhj.hts[i] = colexechash.NewHashTable(
+			mhj.Ctx,
+			mhj.buildSideAllocator,
+			hashTableLoadFactor,
+			HashJoinerInitialNumBuckets,
+			spec.SourceTypes,
+			spec.EqCols,
+			false, /* allowNullEquality */
+			colexechash.HashTableFullBuildMode,
+			colexechash.HashTableDefaultProbeMode,
+		)
+	}
+	mhj.probeState.matchIDs = make([][]uint64, numBuilds)
+	mhj.probeState.curIDs = make([]uint64, numBuilds)
+	mhj.probeState.buildIdxs = make([][]int, numBuilds)
+	mhj.state = mhjBuilding
+}
+
+func (mhj *multiwayHashJoiner) Next() coldata.Batch {
+	for {
+		switch mhj.state {
+		case mhjBuilding:
+			mhj.build()
+			continue
+		case mhjProbing:
+			if mhj.probeState.resumeIdx == mhj.probeState.numRows {
+				if !mhj.probeNextBatch() {
+					mhj.state = mhjDone
+				}
+				continue
+			}
+			mhj.populateOutput()
+			return mhj.output
+		case mhjDone:
+			return coldata.ZeroBatch
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("multi-way hash joiner in unhandled state"))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	}
+}
+
+// build fully consumes all of the build inputs and builds the hash tables. If
+// any of the build inputs is empty, then the output is empty, and the
+// remaining build inputs as well as the probe input are not consumed.
+func (mhj *multiwayHashJoiner) build() {
+	for i, ht := range mhj.hts {
+		ht.FullBuild(mhj.buildInputs[i])
+		if ht.Vals.Length() == 0 {
+			mhj.state = mhjDone
+			return
+		}
+		if !mhj.buildSpecs[i].Distinct {
+			// We might have duplicates in the hash table, so we need to set
+			// up same and visited slices for the prober.
+			ht.Same = colexecutils.MaybeAllocateUint64Array(ht.Same, ht.Vals.Length()+1)
+			ht.Visited = colexecutils.MaybeAllocateBoolArray(ht.Visited, ht.Vals.Length()+1)
+			// Since keyID = 0 is reserved for end of list, it can be marked
+			// as visited at the beginning.
+			ht.Visited[0] = true
+		}
+	}
+	mhj.state = mhjProbing
+}
+
+// probeNextBatch reads the next batch from the probe input and probes it
+// against all of the hash tables until a batch with at least one tuple that
+// has a match in all of them is found. false is returned if the probe input
+// has been exhausted.
+func (mhj *multiwayHashJoiner) probeNextBatch() bool {
+	ps := &mhj.probeState
+	for {
+		batch := mhj.probeInput.Next()
+		n := batch.Length()
+		if n == 0 {
+			return false
+		}
+		// The tuples are probed using our own selection vector which is
+		// shrunk after probing each hash table to include only the tuples
+		// that had a match. The selection vector is not used until some
+		// tuples are removed if the batch doesn't have one.
+		if cap(ps.sel) < n {
+			ps.sel = make([]int, n)
+		} else {
+			ps.sel = ps.sel[:n]
+		}
+		ps.useSel = false
+		if sel := batch.Selection(); sel != nil {
+			copy(ps.sel, sel[:n])
+			ps.useSel = true
+		}
+		for i := range mhj.hts {
+			if n = mhj.probeHashTable(batch, i, n); n == 0 {
+				break
+			}
+		}
+		if n > 0 {
+			ps.batch = batch
+			ps.numRows = n
+			ps.resumeIdx = 0
+			ps.resuming = false
+			return true
+		}
+	}
+}
+
+// probeHashTable probes the first n tuples of the probe batch from the
+// selection vector against the hash table with the given index. The tuples
+// that don't have a match are removed from the selection vector (as well as
+// the keyIDs of their matches in the previously probed hash tables), and the
+// new number of tuples is returned.
+func (mhj *multiwayHashJoiner) probeHashTable(batch coldata.Batch, htIdx int, n int) int {
+	ps := &mhj.probeState
+	ht := mhj.hts[htIdx]
+	spec := &mhj.buildSpecs[htIdx]
+	for i, colIdx := range spec.ProbeEqCols {
+		ht.Keys[i] = batch.ColVec(int(colIdx))
+	}
+	var sel []int
+	if ps.useSel {
+		sel = ps.sel[:n]
+	}
+	// First, we compute the hash values for all tuples.
+	if cap(ps.buckets) < n {
+		ps.buckets = make([]uint64, n)
+	} else {
+		ps.buckets = ps.buckets[:n]
+	}
+	ht.ComputeBuckets(ps.buckets, ht.Keys, n, sel)
+
+	// Then, we initialize GroupID with the initial hash buckets and ToCheck
+	// with all tuples.
+	ht.ProbeScratch.SetupLimitedSlices(n, ht.BuildMode)
+	groupIDs := ht.ProbeScratch.GroupID
+	for i, bucket := range ps.buckets {
+		groupIDs[i] = ht.BuildScratch.First[bucket]
+	}
+	copy(ht.ProbeScratch.ToCheck, colexechash.HashTableInitialToCheck[:n])
+	nToCheck := uint64(n)
+	// When the build input is distinct, the keyID of the match ends up in
+	// GroupID; otherwise, the keyID of the first match ends up in HeadID,
+	// and the keyIDs of the other matches are chained in ht.Same.
+	matchIDs := groupIDs
+	if spec.Distinct {
+		for nToCheck > 0 {
+			nToCheck = ht.DistinctCheck(nToCheck, sel)
+			ht.FindNext(ht.BuildScratch.Next, nToCheck)
+		}
+	} else {
+		for nToCheck > 0 {
+			nToCheck = ht.Check(ht.Keys, nToCheck, sel)
+			ht.FindNext(ht.BuildScratch.Next, nToCheck)
+		}
+		matchIDs = ht.ProbeScratch.HeadID
+	}
+
+	// Now we remove the tuples without a match.
+	// Note that we don't need to zero out the slice if it has enough capacity
+	// because the correct values are always set below.
+	if cap(ps.matchIDs[htIdx]) < n {
+		ps.matchIDs[htIdx] = make([]uint64, n)
+	} else {
+		ps.matchIDs[htIdx] = ps.matchIDs[htIdx][:n]
+	}
+	copy(ps.matchIDs[htIdx], matchIDs[:n])
+	ids := ps.matchIDs[htIdx]
+	// The tuples before the first one without a match stay in place, and it
+	// is common for all tuples to have a match (e.g. when joining on a
+	// foreign key), in which case there is nothing to remove.
+	newN := 0
+	for newN < n && ids[newN] != 0 {
+		newN++
+	}
+	if newN == n {
+		return n
+	}
+	if !ps.useSel {
+		for i := range ps.sel[:n] {
+			ps.sel[i] = i
+		}
+		ps.useSel = true
+	}
+	matchIDsSoFar := ps.matchIDs[:htIdx+1]
+	for i := newN + 1; i < n; i++ {
+		if ids[i] == 0 {
+			continue
+		}
+		ps.sel[newN] = ps.sel[i]
+		for _, prevIDs := range matchIDsSoFar {
+			prevIDs[newN] = prevIDs[i]
+		}
+		newN++
+	}
+	return newN
+}
+
+// populateOutput populates the output batch with the joined tuples starting
+// from the current position in the probe batch.
+func (mhj *multiwayHashJoiner) populateOutput() {
+	ps := &mhj.probeState
+	// We're resetting the output meaning that we have already fully built the
+	// hash tables and now are only populating output one batch at a time. If
+	// we were to use a limited allocator, we could hit the limit here, and we
+	// would have to error out since we might have already emitted partial
+	// output.
+	mhj.output, _ = mhj.outputUnlimitedAllocator.ResetMaybeReallocate(
+		mhj.outputTypes, mhj.output, ps.numRows-ps.resumeIdx, mhj.memoryLimit,
+	)
+	capacity := mhj.output.Capacity()
+	if cap(ps.probeIdx) < capacity {
+		ps.probeIdx = make([]int, capacity)
+		for i := range ps.buildIdxs {
+			ps.buildIdxs[i] = make([]int, capacity)
+		}
+	}
+
+	// Collect the indices of the joined tuples. probeSliceArgs are set up to
+	// copy either the contiguous range of the probe tuples or the ones
+	// selected by ps.probeIdx.
+	var nResults int
+	var probeSliceArgs coldata.SliceArgs
+	if mhj.allDistinct {
+		// Every probe tuple has exactly one match in each hash table.
+		nResults = ps.numRows - ps.resumeIdx
+		if nResults > capacity {
+			nResults = capacity
+		}
+		if ps.useSel {
+			copy(ps.probeIdx[:nResults], ps.sel[ps.resumeIdx:])
+			probeSliceArgs = coldata.SliceArgs{Sel: ps.probeIdx, SrcEndIdx: nResults}
+		} else {
+			// All tuples of the probe batch have a match, so they are
+			// emitted in order.
+			probeSliceArgs = coldata.SliceArgs{SrcStartIdx: ps.resumeIdx, SrcEndIdx: ps.resumeIdx + nResults}
+		}
+		for htIdx, buildIdx := range ps.buildIdxs {
+			buildIdx = buildIdx[:nResults]
+			for i, id := range ps.matchIDs[htIdx][ps.resumeIdx : ps.resumeIdx+nResults] {
+				// Index of the tuples in the hash table is calculated as
+				// ID - 1.
+				buildIdx[i] = int(id - 1)
+			}
+		}
+		ps.resumeIdx += nResults
+	} else {
+		nResults = mhj.collectCombinations(capacity)
+		probeSliceArgs = coldata.SliceArgs{Sel: ps.probeIdx, SrcEndIdx: nResults}
+	}
+
+	mhj.outputUnlimitedAllocator.PerformOperation(mhj.output.ColVecs(), func() {
+		outCols := mhj.output.ColVecs()
+		for i := range mhj.probeTypes {
+			probeSliceArgs.Src = ps.batch.ColVec(i)
+			outCols[i].Copy(coldata.CopySliceArgs{SliceArgs: probeSliceArgs})
+		}
+		colOffset := len(mhj.probeTypes)
+		for htIdx, ht := range mhj.hts {
+			for i := range mhj.buildSpecs[htIdx].SourceTypes {
+				outCols[colOffset+i].Copy(
+					coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							Src:       ht.Vals.ColVec(i),
+							Sel:       ps.buildIdxs[htIdx],
+							SrcEndIdx: nResults,
+						},
+					},
+				)
+			}
+			colOffset += len(mhj.buildSpecs[htIdx].SourceTypes)
+		}
+		mhj.output.SetLength(nResults)
+	})
+}
+
+// collectCombinations collects the indices of at most capacity joined tuples
+// into ps.probeIdx and ps.buildIdxs and returns the number of the tuples.
+// Every probe tuple is joined with all combinations of its matches in the hash
+// tables which are enumerated by advancing the keyIDs in ps.curIDs along the
+// chains of the matches (starting from the last hash table) like an odometer.
+func (mhj *multiwayHashJoiner) collectCombinations(capacity int) int {
+	ps := &mhj.probeState
+	nResults := 0
+	for nResults < capacity && ps.resumeIdx < ps.numRows {
+		rowIdx := ps.resumeIdx
+		if !ps.resuming {
+			for i := range ps.curIDs {
+				ps.curIDs[i] = ps.matchIDs[i][rowIdx]
+			}
+			ps.resuming = true
+		}
+		probeIdx := rowIdx
+		if ps.useSel {
+			probeIdx = ps.sel[rowIdx]
+		}
+		for nResults < capacity {
+			ps.probeIdx[nResults] = probeIdx
+			for i, id := range ps.curIDs {
+				// Index of the tuples in the hash table is calculated as
+				// ID - 1.
+				ps.buildIdxs[i][nResults] = int(id - 1)
+			}
+			nResults++
+			if !mhj.advanceMatches(rowIdx) {
+				// All combinations for this probe tuple have been emitted.
+				ps.resumeIdx++
+				ps.resuming = false
+				break
+			}
+		}
+	}
+	return nResults
+}
+
+// advanceMatches advances ps.curIDs to the next combination of the matches of
+// the probe tuple at the given position. false is returned if all
+// combinations have been enumerated.
+func (mhj *multiwayHashJoiner) advanceMatches(rowIdx int) bool {
+	ps := &mhj.probeState
+	for i := len(mhj.hts) - 1; i >= 0; i-- {
+		if mhj.buildSpecs[i].Distinct {
+			// There is always a single match in the distinct hash table.
+			continue
+		}
+		if next := mhj.hts[i].Same[ps.curIDs[i]]; next != 0 {
+			ps.curIDs[i] = next
+			return true
+		}
+		// The matches in this hash table have been exhausted, so we start
+		// over from the first match and advance the previous hash table.
+		ps.curIDs[i] = ps.matchIDs[i][rowIdx]
+	}
+	return false
+}
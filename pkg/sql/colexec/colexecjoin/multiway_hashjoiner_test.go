@@ -0,0 +1,322 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMultiwayHashJoiner verifies that the multi-way hash joiner produces the
+// same output as the naive nested loop inner join of the probe input with all
+// of the build inputs.
+func TestMultiwayHashJoiner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	// The probe tuples are (a, b, c, d, rowIdx) and are joined with three
+	// build inputs:
+	// - (a) with the distinct INT keys,
+	// - (b) with the INT keys that have duplicates (unless all build inputs
+	//   are distinct),
+	// - (c, d) with the distinct (BYTES, INT) keys.
+	probeTypes := []*types.T{types.Int, types.Int, types.Bytes, types.Int, types.Int}
+	buildSpecs := []MultiwayHashJoinerBuildSpec{
+		{
+			ProbeEqCols: []uint32{0},
+			EqCols:      []uint32{0},
+			SourceTypes: []*types.T{types.Int, types.Int},
+			Distinct:    true,
+		},
+		{
+			ProbeEqCols: []uint32{1},
+			EqCols:      []uint32{1},
+			SourceTypes: []*types.T{types.Int, types.Int},
+		},
+		{
+			ProbeEqCols: []uint32{2, 3},
+			EqCols:      []uint32{0, 1},
+			SourceTypes: []*types.T{types.Bytes, types.Int, types.Int},
+			Distinct:    true,
+		},
+	}
+	// maybeNull returns nil with a small probability and val otherwise.
+	maybeNull := func(val interface{}) interface{} {
+		if rng.Float64() < 0.05 {
+			return nil
+		}
+		return val
+	}
+	for run := 0; run < 10; run++ {
+		allDistinct := run%2 == 0
+		buildSpecs[1].Distinct = allDistinct
+		numKeys := 1 + rng.Intn(20)
+		probeTuples := make(colexectestutils.Tuples, rng.Intn(500))
+		for i := range probeTuples {
+			// Some of the keys don't have a match since the build inputs
+			// contain fewer distinct keys.
+			probeTuples[i] = colexectestutils.Tuple{
+				maybeNull(rng.Intn(numKeys + 2)),
+				maybeNull(rng.Intn(numKeys + 2)),
+				maybeNull(fmt.Sprintf("%d", rng.Intn(2))),
+				maybeNull(rng.Intn(numKeys + 2)),
+				i,
+			}
+		}
+		buildTuples := make([]colexectestutils.Tuples, len(buildSpecs))
+		for k := rng.Perm(numKeys)[:rng.Intn(numKeys+1)]; len(k) > 0; k = k[1:] {
+			buildTuples[0] = append(buildTuples[0], colexectestutils.Tuple{k[0], len(buildTuples[0])})
+		}
+		if allDistinct {
+			for _, k := range rng.Perm(numKeys)[:rng.Intn(numKeys+1)] {
+				buildTuples[1] = append(buildTuples[1], colexectestutils.Tuple{len(buildTuples[1]), k})
+			}
+		} else {
+			for i := rng.Intn(3 * numKeys); i > 0; i-- {
+				buildTuples[1] = append(buildTuples[1], colexectestutils.Tuple{len(buildTuples[1]), maybeNull(rng.Intn(numKeys))})
+			}
+		}
+		for _, k := range rng.Perm(2 * numKeys) {
+			if rng.Float64() < 0.5 {
+				buildTuples[2] = append(buildTuples[2], colexectestutils.Tuple{
+					fmt.Sprintf("%d", k%2), k / 2, len(buildTuples[2]),
+				})
+			}
+		}
+		// Add a tuple with a NULL key into the distinct build inputs (it
+		// doesn't violate the distinctness because NULL keys are never
+		// matched).
+		buildTuples[0] = append(buildTuples[0], colexectestutils.Tuple{nil, -1})
+		buildTuples[2] = append(buildTuples[2], colexectestutils.Tuple{"0", nil, -1})
+
+		// Compute the expected output with the naive nested loop join.
+		var expected colexectestutils.Tuples
+		for _, probeTuple := range probeTuples {
+			joined := colexectestutils.Tuples{probeTuple}
+			for i, spec := range buildSpecs {
+				var newJoined colexectestutils.Tuples
+				for _, prefix := range joined {
+					for _, buildTuple := range buildTuples[i] {
+						match := true
+						for k := range spec.EqCols {
+							l, r := probeTuple[spec.ProbeEqCols[k]], buildTuple[spec.EqCols[k]]
+							match = match && l != nil && r != nil && l == r
+						}
+						if match {
+							newJoined = append(newJoined, append(append(colexectestutils.Tuple{}, prefix...), buildTuple...))
+						}
+					}
+				}
+				joined = newJoined
+			}
+			expected = append(expected, joined...)
+		}
+
+		inputs := append([]colexectestutils.Tuples{probeTuples}, buildTuples...)
+		typs := [][]*types.T{probeTypes}
+		for _, spec := range buildSpecs {
+			typs = append(typs, spec.SourceTypes)
+		}
+		log.Infof(context.Background(), "allDistinct=%t numKeys=%d numProbeTuples=%d numExpected=%d", allDistinct, numKeys, len(probeTuples), len(expected))
+		// We're omitting all nulls injection test because the tuples with
+		// NULL keys are discarded, so the output would be empty.
+		colexectestutils.RunTestsWithoutAllNullsInjection(
+			t, testAllocator, inputs, typs, expected, colexectestutils.UnorderedVerifier,
+			func(sources []colexecop.Operator) (colexecop.Operator, error) {
+				return NewMultiwayHashJoiner(
+					testAllocator, testAllocator, sources[0], probeTypes, sources[1:], buildSpecs,
+					execinfra.DefaultMemoryLimit,
+				)
+			},
+		)
+	}
+}
+
+// TestMultiwayHashJoinerAllMatch verifies the multi-way hash joiner on a star
+// join where every probe tuple has a match in each of the distinct build
+// inputs, in which case the probe tuples are emitted in order.
+func TestMultiwayHashJoinerAllMatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	probeTypes := []*types.T{types.Int, types.Int, types.Int}
+	dimTypes := []*types.T{types.Int, types.Bytes}
+	buildSpecs := []MultiwayHashJoinerBuildSpec{
+		{ProbeEqCols: []uint32{0}, EqCols: []uint32{0}, SourceTypes: dimTypes, Distinct: true},
+		{ProbeEqCols: []uint32{1}, EqCols: []uint32{0}, SourceTypes: dimTypes, Distinct: true},
+	}
+	dims := []colexectestutils.Tuples{
+		{{2, "c"}, {0, "a"}, {1, "b"}},
+		{{1, "odd"}, {0, "even"}},
+	}
+	var probeTuples, expected colexectestutils.Tuples
+	for i := 0; i < 20; i++ {
+		probeTuples = append(probeTuples, colexectestutils.Tuple{i % 3, i % 2, i})
+		expected = append(expected, colexectestutils.Tuple{
+			i % 3, i % 2, i, i % 3, []string{"a", "b", "c"}[i%3], i % 2, []string{"even", "odd"}[i%2],
+		})
+	}
+	colexectestutils.RunTestsWithTyps(
+		t, testAllocator,
+		[]colexectestutils.Tuples{probeTuples, dims[0], dims[1]},
+		[][]*types.T{probeTypes, dimTypes, dimTypes},
+		expected, colexectestutils.OrderedVerifier,
+		func(sources []colexecop.Operator) (colexecop.Operator, error) {
+			return NewMultiwayHashJoiner(
+				testAllocator, testAllocator, sources[0], probeTypes, sources[1:], buildSpecs,
+				execinfra.DefaultMemoryLimit,
+			)
+		},
+	)
+}
+
+// TestMultiwayHashJoinerEmptyBuild verifies that the multi-way hash joiner
+// doesn't consume the probe input and the remaining build inputs if one of
+// the build inputs is empty.
+func TestMultiwayHashJoinerEmptyBuild(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	typs := []*types.T{types.Int}
+	spec := MultiwayHashJoinerBuildSpec{
+		ProbeEqCols: []uint32{0},
+		EqCols:      []uint32{0},
+		SourceTypes: typs,
+	}
+	mustNotBeCalled := &colexecop.CallbackOperator{
+		NextCb: func() coldata.Batch {
+			t.Fatal("unexpectedly the input was consumed")
+			return nil
+		},
+	}
+	nonEmpty := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), colexectestutils.Tuples{{1}}, typs)
+	empty := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), colexectestutils.Tuples{}, typs)
+	op, err := NewMultiwayHashJoiner(
+		testAllocator, testAllocator, mustNotBeCalled, typs,
+		[]colexecop.Operator{nonEmpty, empty, mustNotBeCalled},
+		[]MultiwayHashJoinerBuildSpec{spec, spec, spec}, execinfra.DefaultMemoryLimit,
+	)
+	require.NoError(t, err)
+	op.Init(context.Background())
+	require.Equal(t, 0, op.Next().Length())
+}
+
+func BenchmarkMultiwayHashJoiner(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	// The probe (fact) tuples are (a, b, c, v), and each of the keys is
+	// joined with a separate build (dimension) input that consists of
+	// (key, v) tuples with distinct keys.
+	const numDims = 3
+	probeTypes := []*types.T{types.Int, types.Int, types.Int, types.Int}
+	dimTypes := []*types.T{types.Int, types.Int}
+	for _, numDimTuples := range []int{1 << 8, 1 << 14} {
+		for _, numProbeBatches := range []int{16, 256} {
+			probeBatch := testAllocator.NewMemBatchWithMaxCapacity(probeTypes)
+			for i := 0; i < coldata.BatchSize(); i++ {
+				for j := 0; j < len(probeTypes); j++ {
+					probeBatch.ColVec(j).Int64()[i] = int64((i * (j + 1)) % numDimTuples)
+				}
+			}
+			probeBatch.SetLength(coldata.BatchSize())
+			dimBatch := testAllocator.NewMemBatchWithMaxCapacity(dimTypes)
+			newDimSource := func() colexecop.Operator {
+				var offset int
+				return &colexecop.CallbackOperator{
+					NextCb: func() coldata.Batch {
+						if offset == numDimTuples {
+							return coldata.ZeroBatch
+						}
+						n := numDimTuples - offset
+						if n > coldata.BatchSize() {
+							n = coldata.BatchSize()
+						}
+						keys, vals := dimBatch.ColVec(0).Int64(), dimBatch.ColVec(1).Int64()
+						for i := 0; i < n; i++ {
+							keys[i] = int64(offset + i)
+							vals[i] = int64(offset + i)
+						}
+						dimBatch.SetSelection(false)
+						dimBatch.SetLength(n)
+						offset += n
+						return dimBatch
+					},
+				}
+			}
+			for _, multiway := range []bool{false, true} {
+				name := fmt.Sprintf("dimRows=%d/probeRows=%d/multiway=%t", numDimTuples, numProbeBatches*coldata.BatchSize(), multiway)
+				b.Run(name, func(b *testing.B) {
+					b.SetBytes(int64(8 * numProbeBatches * coldata.BatchSize() * len(probeTypes)))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						var op colexecop.Operator = colexectestutils.NewFiniteBatchSource(
+							testAllocator, probeBatch, probeTypes, numProbeBatches,
+						)
+						if multiway {
+							buildSources := make([]colexecop.Operator, numDims)
+							buildSpecs := make([]MultiwayHashJoinerBuildSpec, numDims)
+							for d := range buildSpecs {
+								buildSources[d] = newDimSource()
+								buildSpecs[d] = MultiwayHashJoinerBuildSpec{
+									ProbeEqCols: []uint32{uint32(d)},
+									EqCols:      []uint32{0},
+									SourceTypes: dimTypes,
+									Distinct:    true,
+								}
+							}
+							var err error
+							op, err = NewMultiwayHashJoiner(
+								testAllocator, testAllocator, op, probeTypes, buildSources, buildSpecs,
+								execinfra.DefaultMemoryLimit,
+							)
+							if err != nil {
+								b.Fatal(err)
+							}
+						} else {
+							// Plan the chain of the hash joiners where each
+							// joiner appends the columns of the next
+							// dimension.
+							leftTypes := probeTypes
+							for d := 0; d < numDims; d++ {
+								spec := MakeHashJoinerSpec(
+									descpb.InnerJoin, []uint32{uint32(d)}, []uint32{0},
+									leftTypes, dimTypes, true, /* rightDistinct */
+								)
+								op = NewHashJoiner(
+									testAllocator, testAllocator, spec, op, newDimSource(),
+									HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+								)
+								leftTypes = append(append([]*types.T{}, leftTypes...), dimTypes...)
+							}
+						}
+						op.Init(ctx)
+						for op.Next().Length() > 0 {
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+)
+
+// canDiscardNullProbeKeys returns whether the probe tuples that have a NULL
+// value in any of the equality columns can be discarded before probing. This
+// is the case for the join types that never emit the probe tuples without a
+// match since such tuples cannot have a match.
+func canDiscardNullProbeKeys(joinType descpb.JoinType) bool {
+	switch joinType {
+	case descpb.InnerJoin, descpb.LeftSemiJoin, descpb.RightOuterJoin,
+		descpb.RightSemiJoin, descpb.RightAntiJoin:
+		return true
+	default:
+		// LEFT OUTER, FULL OUTER, and LEFT ANTI joins emit the probe tuples
+		// with NULL keys (NULL-padded on the right in case of the outer
+		// joins), and the set-operation joins consider NULL values equal.
+		return false
+	}
+}
+
+// canDiscardNullBuildKeys returns whether the build tuples that have a NULL
+// value in any of the equality columns can be discarded before building the
+// hash table. This is the case for the join types that never emit the build
+// tuples without a match since such tuples cannot have a match.
+func canDiscardNullBuildKeys(joinType descpb.JoinType) bool {
+	switch joinType {
+	case descpb.InnerJoin, descpb.LeftOuterJoin, descpb.LeftSemiJoin,
+		descpb.LeftAntiJoin, descpb.RightSemiJoin:
+		return true
+	default:
+		// RIGHT OUTER, FULL OUTER, and RIGHT ANTI joins emit the build tuples
+		// with NULL keys (NULL-padded on the left in case of the outer
+		// joins), and the set-operation joins consider NULL values equal.
+		return false
+	}
+}
+
+// nullKeysFilterOp is an operator that is planned on the inputs of the hash
+// joiner. It discards all tuples that have a NULL value in any of the key
+// columns so that the hash joiner doesn't spend any effort on hashing the
+// tuples that cannot have a match.
+type nullKeysFilterOp struct {
+	colexecop.OneInputHelper
+	keyCols []uint32
+}
+
+var _ colexecop.Operator = &nullKeysFilterOp{}
+
+func newNullKeysFilterOp(input colexecop.Operator, keyCols []uint32) *nullKeysFilterOp {
+	return &nullKeysFilterOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		keyCols:        keyCols,
+	}
+}
+
+func (f *nullKeysFilterOp) Next() coldata.Batch {
+	for {
+		batch := f.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		maybeHasNulls := false
+		for _, colIdx := range f.keyCols {
+			if batch.ColVec(int(colIdx)).Nulls().MaybeHasNulls() {
+				maybeHasNulls = true
+				break
+			}
+		}
+		if !maybeHasNulls {
+			return batch
+		}
+		sel := batch.Selection()
+		hadSel := sel != nil
+		if !hadSel {
+			batch.SetSelection(true)
+			sel = batch.Selection()[:n]
+			for i := range sel {
+				sel[i] = i
+			}
+		} else {
+			sel = sel[:n]
+		}
+		for _, colIdx := range f.keyCols {
+			nulls := batch.ColVec(int(colIdx)).Nulls()
+			if !nulls.MaybeHasNulls() {
+				continue
+			}
+			idx := 0
+			for _, i := range sel {
+				if !nulls.NullAt(i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+			sel = sel[:idx]
+		}
+		if !hadSel && len(sel) == n {
+			// No tuples have been filtered out, so we remove the selection
+			// vector since the hash joiner is faster without it.
+			batch.SetSelection(false)
+			return batch
+		}
+		if len(sel) > 0 {
+			batch.SetLength(len(sel))
+			return batch
+		}
+	}
+}
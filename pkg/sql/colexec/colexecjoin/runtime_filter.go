@@ -0,0 +1,219 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecjoin
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// runtimeFilter is a min/max filter over the integer equality columns of the
+// build side of the hash join. It is populated once the hash table has been
+// fully built and is then used by the runtimeFilterOp to discard the probe
+// tuples that cannot possibly have a match before they reach the hash table.
+type runtimeFilter struct {
+	cols []runtimeFilterCol
+	// empty indicates that there is no build tuple with non-NULL values in
+	// all of the filtered columns, so no probe tuple can have a match.
+	empty bool
+}
+
+// runtimeFilterCol describes the range of values of a single build side
+// equality column that is used to filter the corresponding probe column.
+type runtimeFilterCol struct {
+	buildColIdx int
+	buildWidth  int32
+	probeColIdx int
+	probeWidth  int32
+	min, max    int64
+}
+
+// newRuntimeFilter returns a runtimeFilter for the hash join described by
+// spec, or nil if such filter cannot be used. The filter is only created
+// for the join types that never emit the probe tuples without a match and
+// only on the equality columns of the integer type family on both sides.
+func newRuntimeFilter(spec HashJoinerSpec) *runtimeFilter {
+	if !canDiscardNullProbeKeys(spec.JoinType) {
+		return nil
+	}
+	var f runtimeFilter
+	for i := range spec.Left.EqCols {
+		probeColIdx, buildColIdx := int(spec.Left.EqCols[i]), int(spec.Right.EqCols[i])
+		probeType := spec.Left.SourceTypes[probeColIdx]
+		buildType := spec.Right.SourceTypes[buildColIdx]
+		if typeconv.TypeFamilyToCanonicalTypeFamily(probeType.Family()) != types.IntFamily ||
+			typeconv.TypeFamilyToCanonicalTypeFamily(buildType.Family()) != types.IntFamily {
+			continue
+		}
+		f.cols = append(f.cols, runtimeFilterCol{
+			buildColIdx: buildColIdx,
+			buildWidth:  buildType.Width(),
+			probeColIdx: probeColIdx,
+			probeWidth:  probeType.Width(),
+		})
+	}
+	if len(f.cols) == 0 {
+		return nil
+	}
+	return &f
+}
+
+// filtersProbeCol returns whether the probe column with the given index is
+// filtered by f. It is safe to call on a nil filter.
+func (f *runtimeFilter) filtersProbeCol(colIdx int) bool {
+	if f == nil {
+		return false
+	}
+	for i := range f.cols {
+		if f.cols[i].probeColIdx == colIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// build computes the ranges of values in the filtered columns of the fully
+// built hash table. NULL values are ignored since they never match.
+func (f *runtimeFilter) build(vals *colexecutils.AppendOnlyBufferedBatch) {
+	n := vals.Length()
+	f.empty = true
+	for i := range f.cols {
+		c := &f.cols[i]
+		vec := vals.ColVec(c.buildColIdx)
+		nulls := vec.Nulls()
+		hasNulls := nulls.MaybeHasNulls()
+		first := true
+		for j := 0; j < n; j++ {
+			if hasNulls && nulls.NullAt(j) {
+				continue
+			}
+			var v int64
+			switch c.buildWidth {
+			case 16:
+				v = int64(vec.Int16().Get(j))
+			case 32:
+				v = int64(vec.Int32().Get(j))
+			default:
+				v = vec.Int64().Get(j)
+			}
+			if first {
+				c.min, c.max = v, v
+				first = false
+			} else if v < c.min {
+				c.min = v
+			} else if v > c.max {
+				c.max = v
+			}
+		}
+		if first {
+			// All values in this column are NULL.
+			return
+		}
+	}
+	f.empty = false
+}
+
+// runtimeFilterOp is an operator that is planned on the probe side of the
+// hash join. It discards all tuples that have a NULL or a value outside of
+// the range of the build side values in any of the filtered columns.
+type runtimeFilterOp struct {
+	colexecop.OneInputHelper
+	filter *runtimeFilter
+}
+
+var _ colexecop.Operator = &runtimeFilterOp{}
+
+func newRuntimeFilterOp(input colexecop.Operator, filter *runtimeFilter) *runtimeFilterOp {
+	return &runtimeFilterOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		filter:         filter,
+	}
+}
+
+func (r *runtimeFilterOp) Next() coldata.Batch {
+	if r.filter.empty {
+		return coldata.ZeroBatch
+	}
+	for {
+		batch := r.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		sel := batch.Selection()
+		hadSel := sel != nil
+		if !hadSel {
+			batch.SetSelection(true)
+			sel = batch.Selection()[:n]
+			for i := range sel {
+				sel[i] = i
+			}
+		} else {
+			sel = sel[:n]
+		}
+		for i := range r.filter.cols {
+			c := &r.filter.cols[i]
+			vec := batch.ColVec(c.probeColIdx)
+			nulls := vec.Nulls()
+			hasNulls := nulls.MaybeHasNulls()
+			idx := 0
+			switch c.probeWidth {
+			case 16:
+				col := vec.Int16()
+				for _, j := range sel {
+					if hasNulls && nulls.NullAt(j) {
+						continue
+					}
+					if v := int64(col.Get(j)); v >= c.min && v <= c.max {
+						sel[idx] = j
+						idx++
+					}
+				}
+			case 32:
+				col := vec.Int32()
+				for _, j := range sel {
+					if hasNulls && nulls.NullAt(j) {
+						continue
+					}
+					if v := int64(col.Get(j)); v >= c.min && v <= c.max {
+						sel[idx] = j
+						idx++
+					}
+				}
+			default:
+				col := vec.Int64()
+				for _, j := range sel {
+					if hasNulls && nulls.NullAt(j) {
+						continue
+					}
+					if v := col.Get(j); v >= c.min && v <= c.max {
+						sel[idx] = j
+						idx++
+					}
+				}
+			}
+			sel = sel[:idx]
+		}
+		if !hadSel && len(sel) == n {
+			// No tuples have been filtered out, so we remove the selection
+			// vector since the hash joiner is faster without it.
+			batch.SetSelection(false)
+			return batch
+		}
+		if len(sel) > 0 {
+			batch.SetLength(len(sel))
+			return batch
+		}
+	}
+}
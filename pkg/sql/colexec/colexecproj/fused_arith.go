@@ -0,0 +1,458 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecproj
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// FusedArithExpr is a node of the tree of binary arithmetic operations that is
+// evaluated by the fused arithmetic operator. A node is either an internal one
+// with an operator and two arguments or a leaf that refers to an input column
+// or to a constant.
+type FusedArithExpr struct {
+	// Op is the operator of an internal node. Only tree.Plus, tree.Minus, and
+	// tree.Mult are supported.
+	Op tree.BinaryOperator
+	// Left and Right are the arguments of an internal node. They are nil for
+	// the leaves.
+	Left, Right *FusedArithExpr
+	// ColIdx is the index of the input column of a leaf. It is -1 for the
+	// constant leaves.
+	ColIdx int
+	// Const is the value of a constant leaf. It must be int64 or float64
+	// depending on the type of the expression.
+	Const interface{}
+}
+
+// NewFusedArithmeticOp returns an operator that evaluates the tree of binary
+// arithmetic operations over the columns and constants of type typ (only Int
+// and Float are supported) and projects the result into outputIdx. Unlike the
+// plan with a separate projection operator for every operation, the
+// intermediate results are not materialized as the columns of the batch but
+// are computed into the scratch vectors reused across batches.
+func NewFusedArithmeticOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	typ *types.T,
+	expr *FusedArithExpr,
+	outputIdx int,
+) (colexecop.Operator, error) {
+	if expr.Left == nil || expr.Right == nil {
+		return nil, errors.AssertionFailedf("fused arithmetic expression must have an operator")
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, typ, outputIdx)
+	base := fusedArithOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		outputIdx:      outputIdx,
+	}
+	switch {
+	case typ.Identical(types.Int):
+		root, err := newFusedArithInt64Node(expr)
+		if err != nil {
+			return nil, err
+		}
+		return &fusedArithInt64Op{fusedArithOpBase: base, root: root}, nil
+	case typ.Identical(types.Float):
+		root, err := newFusedArithFloat64Node(expr)
+		if err != nil {
+			return nil, err
+		}
+		return &fusedArithFloat64Op{fusedArithOpBase: base, root: root}, nil
+	}
+	return nil, errors.Errorf("unsupported fused arithmetic type %s", typ)
+}
+
+type fusedArithOpBase struct {
+	colexecop.OneInputHelper
+
+	allocator *colmem.Allocator
+	outputIdx int
+	// identity is the selection vector that is used for the batches without
+	// one on the paths that don't special-case the dense batches.
+	identity []int
+}
+
+// getRows returns the positions of the tuples of the batch to be evaluated.
+func (b *fusedArithOpBase) getRows(batch coldata.Batch, n int) []int {
+	if sel := batch.Selection(); sel != nil {
+		return sel[:n]
+	}
+	if len(b.identity) < n {
+		b.identity = make([]int, batch.Capacity())
+		for i := range b.identity {
+			b.identity[i] = i
+		}
+	}
+	return b.identity[:n]
+}
+
+// unionNulls returns the union of the nulls of both arguments of a node
+// written into scratch, or nil if neither argument can have nulls. length is
+// the number of the tuples in the nulls that need to be considered.
+func unionNulls(scratch, left, right *coldata.Nulls, length int) *coldata.Nulls {
+	if left == nil {
+		left, right = right, nil
+	}
+	if left == nil {
+		return nil
+	}
+	scratch.Copy(left)
+	if right != nil {
+		scratch.SetNullsFrom(right, length)
+	}
+	return scratch
+}
+
+// fusedArithInt64Node is the Int version of FusedArithExpr which also stores
+// the buffers used during the evaluation.
+type fusedArithInt64Node struct {
+	op          tree.BinaryOperator
+	left, right *fusedArithInt64Node
+	colIdx      int
+	constArg    int64
+	// scratch contains the result of an internal node (other than the root)
+	// or the repeated value of a constant leaf.
+	scratch []int64
+	// nulls contains the nulls of the result of an internal node.
+	nulls coldata.Nulls
+}
+
+func newFusedArithInt64Node(expr *FusedArithExpr) (*fusedArithInt64Node, error) {
+	if expr.Left == nil {
+		n := &fusedArithInt64Node{colIdx: expr.ColIdx}
+		if expr.ColIdx < 0 {
+			c, ok := expr.Const.(int64)
+			if !ok {
+				return nil, errors.AssertionFailedf("unexpected constant %v in the Int expression", expr.Const)
+			}
+			n.constArg = c
+		}
+		return n, nil
+	}
+	switch expr.Op {
+	case tree.Plus, tree.Minus, tree.Mult:
+	default:
+		return nil, errors.Errorf("unsupported fused arithmetic operator %s", expr.Op)
+	}
+	left, err := newFusedArithInt64Node(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := newFusedArithInt64Node(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+	return &fusedArithInt64Node{op: expr.Op, left: left, right: right}, nil
+}
+
+// eval evaluates the node on the tuples at positions rows of the batch. The
+// result of an internal node is written into dest if it is non-nil and into
+// the scratch vector otherwise. The returned nulls are nil if the result
+// cannot have nulls.
+func (n *fusedArithInt64Node) eval(
+	batch coldata.Batch, rows []int, dest []int64,
+) ([]int64, *coldata.Nulls) {
+	if n.left == nil {
+		if n.colIdx >= 0 {
+			vec := batch.ColVec(n.colIdx)
+			if vec.MaybeHasNulls() {
+				return vec.Int64(), vec.Nulls()
+			}
+			return vec.Int64(), nil
+		}
+		if len(n.scratch) < batch.Capacity() {
+			n.scratch = make([]int64, batch.Capacity())
+			for i := range n.scratch {
+				n.scratch[i] = n.constArg
+			}
+		}
+		return n.scratch, nil
+	}
+	if dest == nil {
+		if len(n.scratch) < batch.Capacity() {
+			n.scratch = make([]int64, batch.Capacity())
+		}
+		dest = n.scratch
+	}
+	l, leftNulls := n.left.eval(batch, rows, nil /* dest */)
+	r, rightNulls := n.right.eval(batch, rows, nil /* dest */)
+	nulls := unionNulls(&n.nulls, leftNulls, rightNulls, rows[len(rows)-1]+1)
+	if nulls == nil && batch.Selection() == nil {
+		// Fast path for the dense batches without NULLs which allows for the
+		// bounds check elimination.
+		denseL := l[:len(rows)]
+		denseR := r[:len(denseL)]
+		denseDest := dest[:len(denseL)]
+		switch n.op {
+		case tree.Plus:
+			for i := range denseL {
+				result := denseL[i] + denseR[i]
+				if (result < denseL[i]) != (denseR[i] < 0) {
+					colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+				}
+				denseDest[i] = result
+			}
+		case tree.Minus:
+			for i := range denseL {
+				result := denseL[i] - denseR[i]
+				if (result < denseL[i]) != (denseR[i] > 0) {
+					colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+				}
+				denseDest[i] = result
+			}
+		case tree.Mult:
+			for i := range denseL {
+				left, right := denseL[i], denseR[i]
+				result := left * right
+				if left > math.MaxInt32 || left < math.MinInt32 || right > math.MaxInt32 || right < math.MinInt32 {
+					checkMulInt64Overflow(left, right, result)
+				}
+				denseDest[i] = result
+			}
+		}
+		return dest, nil
+	}
+	// Note that we must not evaluate the operations on the tuples with NULLs
+	// since the garbage values might result in an overflow error.
+	switch n.op {
+	case tree.Plus:
+		for _, i := range rows {
+			if nulls != nil && nulls.NullAt(i) {
+				continue
+			}
+			result := l[i] + r[i]
+			if (result < l[i]) != (r[i] < 0) {
+				colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+			}
+			dest[i] = result
+		}
+	case tree.Minus:
+		for _, i := range rows {
+			if nulls != nil && nulls.NullAt(i) {
+				continue
+			}
+			result := l[i] - r[i]
+			if (result < l[i]) != (r[i] > 0) {
+				colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+			}
+			dest[i] = result
+		}
+	case tree.Mult:
+		for _, i := range rows {
+			if nulls != nil && nulls.NullAt(i) {
+				continue
+			}
+			left, right := l[i], r[i]
+			result := left * right
+			if left > math.MaxInt32 || left < math.MinInt32 || right > math.MaxInt32 || right < math.MinInt32 {
+				checkMulInt64Overflow(left, right, result)
+			}
+			dest[i] = result
+		}
+	}
+	return dest, nulls
+}
+
+// checkMulInt64Overflow panics with tree.ErrIntOutOfRange if result, which is
+// the product of left and right, has overflown. It is the same check as in
+// the multiplication projection operators, and it only needs to be performed
+// if either argument doesn't fit into int32.
+func checkMulInt64Overflow(left, right, result int64) {
+	if left != 0 && right != 0 {
+		sameSign := (left < 0) == (right < 0)
+		if (result < 0) == sameSign {
+			colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+		} else if result/right != left {
+			colexecerror.ExpectedError(tree.ErrIntOutOfRange)
+		}
+	}
+}
+
+type fusedArithInt64Op struct {
+	fusedArithOpBase
+	root *fusedArithInt64Node
+}
+
+var _ colexecop.Operator = &fusedArithInt64Op{}
+
+func (o *fusedArithInt64Op) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	rows := o.getRows(batch, n)
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if projVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in the
+			// output vector.
+			projVec.Nulls().UnsetNulls()
+		}
+		if _, nulls := o.root.eval(batch, rows, projVec.Int64()); nulls != nil {
+			projVec.Nulls().SetNullsFrom(nulls, rows[n-1]+1)
+		}
+	})
+	return batch
+}
+
+// fusedArithFloat64Node is the Float version of FusedArithExpr which also
+// stores the buffers used during the evaluation.
+type fusedArithFloat64Node struct {
+	op          tree.BinaryOperator
+	left, right *fusedArithFloat64Node
+	colIdx      int
+	constArg    float64
+	// scratch contains the result of an internal node (other than the root)
+	// or the repeated value of a constant leaf.
+	scratch []float64
+	// nulls contains the nulls of the result of an internal node.
+	nulls coldata.Nulls
+}
+
+func newFusedArithFloat64Node(expr *FusedArithExpr) (*fusedArithFloat64Node, error) {
+	if expr.Left == nil {
+		n := &fusedArithFloat64Node{colIdx: expr.ColIdx}
+		if expr.ColIdx < 0 {
+			c, ok := expr.Const.(float64)
+			if !ok {
+				return nil, errors.AssertionFailedf("unexpected constant %v in the Float expression", expr.Const)
+			}
+			n.constArg = c
+		}
+		return n, nil
+	}
+	switch expr.Op {
+	case tree.Plus, tree.Minus, tree.Mult:
+	default:
+		return nil, errors.Errorf("unsupported fused arithmetic operator %s", expr.Op)
+	}
+	left, err := newFusedArithFloat64Node(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := newFusedArithFloat64Node(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+	return &fusedArithFloat64Node{op: expr.Op, left: left, right: right}, nil
+}
+
+// eval evaluates the node on the tuples at positions rows of the batch. The
+// result of an internal node is written into dest if it is non-nil and into
+// the scratch vector otherwise. The returned nulls are nil if the result
+// cannot have nulls.
+func (n *fusedArithFloat64Node) eval(
+	batch coldata.Batch, rows []int, dest []float64,
+) ([]float64, *coldata.Nulls) {
+	if n.left == nil {
+		if n.colIdx >= 0 {
+			vec := batch.ColVec(n.colIdx)
+			if vec.MaybeHasNulls() {
+				return vec.Float64(), vec.Nulls()
+			}
+			return vec.Float64(), nil
+		}
+		if len(n.scratch) < batch.Capacity() {
+			n.scratch = make([]float64, batch.Capacity())
+			for i := range n.scratch {
+				n.scratch[i] = n.constArg
+			}
+		}
+		return n.scratch, nil
+	}
+	if dest == nil {
+		if len(n.scratch) < batch.Capacity() {
+			n.scratch = make([]float64, batch.Capacity())
+		}
+		dest = n.scratch
+	}
+	l, leftNulls := n.left.eval(batch, rows, nil /* dest */)
+	r, rightNulls := n.right.eval(batch, rows, nil /* dest */)
+	// The Float operations cannot result in an error, so we evaluate them on
+	// all tuples, including the ones with NULLs.
+	if batch.Selection() == nil {
+		// Fast path for the dense batches which allows for the bounds check
+		// elimination.
+		denseL := l[:len(rows)]
+		denseR := r[:len(denseL)]
+		denseDest := dest[:len(denseL)]
+		switch n.op {
+		case tree.Plus:
+			for i := range denseL {
+				denseDest[i] = denseL[i] + denseR[i]
+			}
+		case tree.Minus:
+			for i := range denseL {
+				denseDest[i] = denseL[i] - denseR[i]
+			}
+		case tree.Mult:
+			for i := range denseL {
+				denseDest[i] = denseL[i] * denseR[i]
+			}
+		}
+		return dest, unionNulls(&n.nulls, leftNulls, rightNulls, len(rows))
+	}
+	switch n.op {
+	case tree.Plus:
+		for _, i := range rows {
+			dest[i] = l[i] + r[i]
+		}
+	case tree.Minus:
+		for _, i := range rows {
+			dest[i] = l[i] - r[i]
+		}
+	case tree.Mult:
+		for _, i := range rows {
+			dest[i] = l[i] * r[i]
+		}
+	}
+	return dest, unionNulls(&n.nulls, leftNulls, rightNulls, rows[len(rows)-1]+1)
+}
+
+type fusedArithFloat64Op struct {
+	fusedArithOpBase
+	root *fusedArithFloat64Node
+}
+
+var _ colexecop.Operator = &fusedArithFloat64Op{}
+
+func (o *fusedArithFloat64Op) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	rows := o.getRows(batch, n)
+	projVec := batch.ColVec(o.outputIdx)
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if projVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in the
+			// output vector.
+			projVec.Nulls().UnsetNulls()
+		}
+		if _, nulls := o.root.eval(batch, rows, projVec.Float64()); nulls != nil {
+			projVec.Nulls().SetNullsFrom(nulls, rows[n-1]+1)
+		}
+	})
+	return batch
+}
@@ -119,3 +119,39 @@ func GetLikeProjectionOperator(
 		return nil, errors.AssertionFailedf("unsupported like op type %d", likeOpType)
 	}
 }
+
+// GetSimilarToProjectionOperator returns a projection operator which projects
+// the result of the specified SIMILAR TO pattern, or NOT SIMILAR TO if the
+// negate argument is true. The pattern is translated into an equivalent
+// regular expression only once.
+func GetSimilarToProjectionOperator(
+	allocator *colmem.Allocator,
+	ctx *tree.EvalContext,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	pattern string,
+	negate bool,
+) (colexecop.Operator, error) {
+	re, err := tree.ConvertSimilarToRegexp(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	base := projConstOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      resultIdx,
+	}
+	if negate {
+		return &projNotRegexpBytesBytesConstOp{
+			projConstOpBase: base,
+			constArg:        re,
+		}, nil
+	}
+	return &projRegexpBytesBytesConstOp{
+		projConstOpBase: base,
+		constArg:        re,
+	}, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecproj
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// maybeNewProjModPowerOfTwoConstOp returns an operator that computes the
+// modulo of the Int column by the constant power of two using a bit mask
+// instead of the integer division. nil is returned if the fast path cannot be
+// used, in which case the general operator should be planned.
+func maybeNewProjModPowerOfTwoConstOp(
+	projConstOpBase projConstOpBase, leftType, rightType, outputType *types.T, constArg interface{},
+) colexecop.Operator {
+	if !leftType.Identical(types.Int) || !rightType.Identical(types.Int) || !outputType.Identical(types.Int) {
+		return nil
+	}
+	c := constArg.(int64)
+	if c == math.MinInt64 {
+		// The absolute value of the divisor doesn't fit into int64.
+		return nil
+	}
+	if c < 0 {
+		// The sign of the result only depends on the sign of the dividend, so
+		// x % c == x % -c.
+		c = -c
+	}
+	if c == 0 || bits.OnesCount64(uint64(c)) != 1 {
+		return nil
+	}
+	return &projModInt64PowerOfTwoConstOp{
+		projConstOpBase: projConstOpBase,
+		divisor:         c,
+		mask:            c - 1,
+	}
+}
+
+// projModInt64PowerOfTwoConstOp computes the modulo of the Int column by a
+// constant power of two. The result has the same sign as the dividend (the same
+// as in the row engine).
+type projModInt64PowerOfTwoConstOp struct {
+	projConstOpBase
+	divisor int64
+	mask    int64
+}
+
+var _ colexecop.Operator = &projModInt64PowerOfTwoConstOp{}
+
+// modPowerOfTwo returns x % divisor where divisor is a positive power of two
+// and mask is divisor-1.
+func modPowerOfTwo(x, divisor, mask int64) int64 {
+	r := x & mask
+	if x < 0 && r != 0 {
+		// For negative x, x & mask is the non-negative remainder, so we need
+		// to shift it to have the sign of the dividend.
+		r -= divisor
+	}
+	return r
+}
+
+func (p *projModInt64PowerOfTwoConstOp) Next() coldata.Batch {
+	batch := p.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(p.colIdx)
+	col := vec.Int64()
+	projVec := batch.ColVec(p.outputIdx)
+	p.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		if projVec.MaybeHasNulls() {
+			// We need to make sure that there are no left over null values in the
+			// output vector.
+			projVec.Nulls().UnsetNulls()
+		}
+		projCol := projVec.Int64()
+		// The modulo cannot result in an error, so we compute it for all rows,
+		// including the ones with NULL values, and then union the nulls.
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				projCol[i] = modPowerOfTwo(col[i], p.divisor, p.mask)
+			}
+		} else {
+			col = col[:n]
+			projCol = projCol[:len(col)]
+			for i := range col {
+				projCol[i] = modPowerOfTwo(col[i], p.divisor, p.mask)
+			}
+		}
+		if vec.Nulls().MaybeHasNulls() {
+			projVec.SetNulls(projVec.Nulls().Or(vec.Nulls()))
+		}
+	})
+	return batch
+}
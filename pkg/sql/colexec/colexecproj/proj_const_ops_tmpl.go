@@ -273,6 +273,11 @@ func GetProjection_CONST_SIDEConstOperator(
 	leftType, rightType := constType, inputTypes[colIdx]
 	// {{else}}
 	leftType, rightType := inputTypes[colIdx], constType
+	if op == tree.Mod {
+		if modOp := maybeNewProjModPowerOfTwoConstOp(projConstOpBase, leftType, rightType, outputType, c); modOp != nil {
+			return modOp, nil
+		}
+	}
 	// {{end}}
 	switch op.(type) {
 	case tree.BinaryOperator:
@@ -13,6 +13,7 @@ package colexecproj
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
@@ -22,15 +23,18 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/apd/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -133,6 +137,402 @@ func TestGetProjectionConstOperator(t *testing.T) {
 	}
 }
 
+func TestProjModInt64PowerOfTwoConstOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	dividends := []int64{0, 1, -1, 7, -7, 8, -8, 9, -9, 100, -100, math.MaxInt64, math.MinInt64, math.MinInt64 + 1}
+	for _, divisor := range []int64{1, -1, 2, 8, -8, 1024, 1 << 62, 3, -6, 7, 100, math.MaxInt64, math.MinInt64} {
+		// The fast path must only be used with the power of two divisors.
+		isPowerOfTwo := divisor != math.MinInt64 && divisor != 0 &&
+			(divisor&(divisor-1) == 0 || -divisor&(-divisor-1) == 0)
+		op, err := GetProjectionRConstOperator(
+			testAllocator, []*types.T{types.Int}, types.Int, types.Int, tree.Mod, colexecop.NewRepeatableBatchSource(
+				testAllocator, testAllocator.NewMemBatchWithMaxCapacity([]*types.T{types.Int}), []*types.T{types.Int},
+			), 0 /* colIdx */, tree.NewDInt(tree.DInt(divisor)), 1 /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+		)
+		require.NoError(t, err)
+		_, usesFastPath := op.(*projModInt64PowerOfTwoConstOp)
+		require.Equal(t, isPowerOfTwo, usesFastPath, "divisor %d", divisor)
+
+		// The results must match the row engine which has the same semantics
+		// as Go's modulo (the sign of the result is the sign of the dividend).
+		input := colexectestutils.Tuples{{nil}}
+		expected := colexectestutils.Tuples{{nil, nil}}
+		for _, dividend := range dividends {
+			input = append(input, colexectestutils.Tuple{dividend})
+			expected = append(expected, colexectestutils.Tuple{dividend, dividend % divisor})
+		}
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{input}, expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], []*types.T{types.Int},
+					fmt.Sprintf("@1 %% (%d)::INT8", divisor), false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+// TestFusedArithmeticOp verifies that the results of the fused operator for
+// the nested arithmetic expressions, including NULLs and the overflow errors,
+// are the same as with the separate projection operators.
+func TestFusedArithmeticOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		inputTypes []*types.T
+		expr       string
+		input      colexectestutils.Tuples
+		expected   colexectestutils.Tuples
+	}{
+		{
+			inputTypes: []*types.T{types.Int, types.Int, types.Int, types.Int},
+			expr:       "(@1 + @2) * (@3 - @4)",
+			input:      colexectestutils.Tuples{{1, 2, 3, 4}, {nil, 2, 3, 4}, {5, 6, nil, 1}, {-7, 3, 10, 2}},
+			expected: colexectestutils.Tuples{
+				{1, 2, 3, 4, -3}, {nil, 2, 3, 4, nil}, {5, 6, nil, 1, nil}, {-7, 3, 10, 2, -32},
+			},
+		},
+		{
+			inputTypes: []*types.T{types.Int, types.Int},
+			expr:       "@1 * 2 - (@2 + 3)",
+			input:      colexectestutils.Tuples{{1, 2}, {nil, 2}, {5, 6}, {-7, 3}},
+			expected:   colexectestutils.Tuples{{1, 2, -3}, {nil, 2, nil}, {5, 6, 1}, {-7, 3, -20}},
+		},
+		{
+			inputTypes: []*types.T{types.Float, types.Float},
+			expr:       "(@1 + @2) * (@1 - 0.5)",
+			input:      colexectestutils.Tuples{{1.5, 2.0}, {nil, 2.0}, {0.5, nil}, {-1.5, 0.25}},
+			expected:   colexectestutils.Tuples{{1.5, 2.0, 3.5}, {nil, 2.0, nil}, {0.5, nil, nil}, {-1.5, 0.25, 2.5}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.input}, [][]*types.T{tc.inputTypes}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The overflow in an intermediate result must result in an error, even if
+	// the other argument of the outer operation is NULL (same as with the
+	// separate projection operators).
+	typs := []*types.T{types.Int, types.Int, types.Int}
+	for _, tc := range []struct {
+		expr  string
+		input colexectestutils.Tuple
+	}{
+		{expr: "(@1 + @2) * @3", input: colexectestutils.Tuple{int64(math.MaxInt64), 1, nil}},
+		{expr: "(@1 - @2) + @3", input: colexectestutils.Tuple{int64(math.MinInt64), 1, nil}},
+		{expr: "(@1 * @2) - @3", input: colexectestutils.Tuple{int64(math.MaxInt64), 2, nil}},
+		{expr: "(@1 + @2) * @3", input: colexectestutils.Tuple{int64(math.MaxInt64 / 2), 1, 3}},
+	} {
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{tc.input}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tree.ErrIntOutOfRange.Error(), "%s", tc.expr)
+	}
+}
+
+// TestProjModSignSemantics verifies that the sign of the result of the modulo
+// operator follows the sign of the dividend (same as in Postgres and in the row
+// engine) for all sign combinations of integer and decimal arguments.
+func TestProjModSignSemantics(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// Every pair of the values below covers all four sign combinations of the
+	// dividend and the divisor, including the cases when the divisor doesn't
+	// divide the dividend evenly.
+	values := map[*types.T][]tree.Datum{
+		types.Int: {
+			tree.NewDInt(7), tree.NewDInt(-7), tree.NewDInt(3), tree.NewDInt(-3),
+			tree.NewDInt(6), tree.NewDInt(-6),
+		},
+		types.Decimal: {
+			&tree.DDecimal{Decimal: *apd.New(75, -1)}, &tree.DDecimal{Decimal: *apd.New(-75, -1)},
+			&tree.DDecimal{Decimal: *apd.New(2, 0)}, &tree.DDecimal{Decimal: *apd.New(-2, 0)},
+			&tree.DDecimal{Decimal: *apd.New(25, -1)}, &tree.DDecimal{Decimal: *apd.New(-25, -1)},
+		},
+	}
+	for _, argTypes := range [][2]*types.T{
+		{types.Int, types.Int},
+		{types.Decimal, types.Decimal},
+		{types.Int, types.Decimal},
+		{types.Decimal, types.Int},
+	} {
+		leftType, rightType := argTypes[0], argTypes[1]
+		outputType := types.Int
+		if leftType.Family() == types.DecimalFamily || rightType.Family() == types.DecimalFamily {
+			outputType = types.Decimal
+		}
+		inputTypes := []*types.T{leftType, rightType}
+		var input, expected colexectestutils.Tuples
+		for _, dividend := range values[leftType] {
+			for _, divisor := range values[rightType] {
+				res, err := tree.NewTypedBinaryExpr(tree.Mod, dividend, divisor, outputType).Eval(&evalCtx)
+				require.NoError(t, err)
+				// Sanity check the row engine as well.
+				if res.Compare(&evalCtx, tree.DZero) != 0 {
+					require.Equal(t, dividend.Compare(&evalCtx, tree.DZero), res.Compare(&evalCtx, tree.DZero),
+						"%s %% %s = %s", dividend, divisor, res)
+				}
+				leftVal := colconv.GetDatumToPhysicalFn(leftType)(dividend)
+				rightVal := colconv.GetDatumToPhysicalFn(rightType)(divisor)
+				resVal := colconv.GetDatumToPhysicalFn(outputType)(res)
+				if d, ok := res.(*tree.DDecimal); ok {
+					// Decimals are compared by their value when the expected
+					// value is a float, which isn't the case otherwise (the
+					// zero coefficient can have different representations).
+					resVal, err = d.Float64()
+					require.NoError(t, err)
+				}
+				input = append(input, colexectestutils.Tuple{leftVal, rightVal})
+				expected = append(expected, colexectestutils.Tuple{leftVal, rightVal, resVal})
+			}
+		}
+		log.Infof(ctx, "%s %% %s", leftType, rightType)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{inputTypes}, expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], inputTypes, "@1 % @2", false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+// TestProjPowFloatSpecialValues verifies that raising floats to the power of
+// floats follows the IEEE 754 semantics of math.Pow (same as the row engine)
+// for the special values (NaN, infinities, signed zeroes) as well as for the
+// negative bases and the results that overflow to infinity. All three kinds of
+// the projection operators (with the constant on either side and without the
+// constants) are checked.
+func TestProjPowFloatSpecialValues(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	values := []float64{
+		math.NaN(), math.Inf(1), math.Inf(-1), 0, math.Copysign(0, -1), 1, -1,
+		0.5, -0.5, 2, -2, 3, -3, 2.5, 1e300, -1e300,
+	}
+	pow := func(x, y float64) float64 {
+		res, err := tree.NewTypedBinaryExpr(
+			tree.Pow, tree.NewDFloat(tree.DFloat(x)), tree.NewDFloat(tree.DFloat(y)), types.Float,
+		).Eval(&evalCtx)
+		require.NoError(t, err)
+		return float64(*res.(*tree.DFloat))
+	}
+	// Sanity check the row engine on some of the special cases.
+	require.Equal(t, 1.0, pow(0, 0))
+	require.Equal(t, 1.0, pow(math.NaN(), 0))
+	require.Equal(t, 1.0, pow(1, math.NaN()))
+	require.True(t, math.IsNaN(pow(-2, 0.5)))
+	require.Equal(t, -8.0, pow(-2, 3))
+	require.Equal(t, math.Inf(1), pow(1e300, 2))
+	require.Equal(t, math.Inf(-1), pow(-1e300, 3))
+	require.Equal(t, math.Inf(-1), pow(math.Copysign(0, -1), -1))
+
+	inputTypes := []*types.T{types.Float, types.Float}
+	input := colexectestutils.Tuples{{nil, 2.0}, {2.0, nil}, {nil, nil}}
+	expected := colexectestutils.Tuples{{nil, 2.0, nil}, {2.0, nil, nil}, {nil, nil, nil}}
+	for _, x := range values {
+		for _, y := range values {
+			input = append(input, colexectestutils.Tuple{x, y})
+			expected = append(expected, colexectestutils.Tuple{x, y, pow(x, y)})
+		}
+	}
+	colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{inputTypes}, expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			op, err := GetProjectionOperator(
+				testAllocator, inputTypes, types.Float, tree.Pow, input[0], 0 /* col1Idx */, 1, /* col2Idx */
+				2 /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+			)
+			if err == nil {
+				_, ok := op.(*projPowFloat64Float64Op)
+				require.True(t, ok, "unexpectedly planned %T", op)
+			}
+			return op, err
+		})
+
+	for _, c := range values {
+		constArg := tree.NewDFloat(tree.DFloat(c))
+		input := colexectestutils.Tuples{{nil}}
+		constRightExpected := colexectestutils.Tuples{{nil, nil}}
+		constLeftExpected := colexectestutils.Tuples{{nil, nil}}
+		for _, x := range values {
+			input = append(input, colexectestutils.Tuple{x})
+			constRightExpected = append(constRightExpected, colexectestutils.Tuple{x, pow(x, c)})
+			constLeftExpected = append(constLeftExpected, colexectestutils.Tuple{x, pow(c, x)})
+		}
+		log.Infof(ctx, "@1 ^ %v", c)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{{types.Float}}, constRightExpected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetProjectionRConstOperator(
+					testAllocator, []*types.T{types.Float}, types.Float, types.Float, tree.Pow, input[0],
+					0 /* colIdx */, constArg, 1 /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+				)
+			})
+		log.Infof(ctx, "%v ^ @1", c)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{{types.Float}}, constLeftExpected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetProjectionLConstOperator(
+					testAllocator, []*types.T{types.Float}, types.Float, types.Float, tree.Pow, input[0],
+					0 /* colIdx */, constArg, 1 /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+				)
+			})
+	}
+}
+
+// TestProjIntervalNumericOps verifies that multiplying and dividing intervals
+// by numeric factors produces the same results as the row engine, including
+// fractional factors and factors that overflow the interval components.
+func TestProjIntervalNumericOps(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	intervals := []duration.Duration{
+		duration.MakeDuration(0 /* nanos */, 1 /* days */, 0 /* months */),
+		duration.MakeDuration(12*3600e9, 15, 1),
+		duration.MakeDuration(-1000, 3, -24),
+		// Fractional factors cascade the months into days and the days into
+		// nanos, and large factors overflow the components.
+		duration.MakeDuration(1, 1, 1),
+		duration.MakeDuration(math.MaxInt64/2, math.MaxInt64/3, math.MaxInt64/4),
+		duration.MakeDuration(math.MinInt64/2, math.MinInt64/3, math.MinInt64/4),
+	}
+	factors := map[*types.T][]tree.Datum{
+		types.Int: {
+			tree.NewDInt(0), tree.NewDInt(1), tree.NewDInt(-1), tree.NewDInt(3),
+			tree.NewDInt(7), tree.NewDInt(math.MaxInt32),
+		},
+		types.Float: {
+			tree.NewDFloat(0), tree.NewDFloat(0.5), tree.NewDFloat(-0.25),
+			tree.NewDFloat(1.0 / 3), tree.NewDFloat(2.5), tree.NewDFloat(1e10),
+		},
+		types.Decimal: {
+			&tree.DDecimal{Decimal: *apd.New(0, 0)}, &tree.DDecimal{Decimal: *apd.New(15, -1)},
+			&tree.DDecimal{Decimal: *apd.New(-125, -2)}, &tree.DDecimal{Decimal: *apd.New(1, -6)},
+		},
+	}
+	for factorType, factorDatums := range factors {
+		for _, binOp := range []tree.BinaryOperator{tree.Mult, tree.Div} {
+			if binOp == tree.Div && factorType.Family() == types.DecimalFamily {
+				// The row engine doesn't support dividing intervals by decimals.
+				continue
+			}
+			inputTypes := []*types.T{types.Interval, factorType}
+			var input, expected colexectestutils.Tuples
+			for _, d := range intervals {
+				for i, factor := range factorDatums {
+					if binOp == tree.Div && i == 0 {
+						// The first factor is zero, and division by zero is
+						// checked below.
+						continue
+					}
+					res, err := tree.NewTypedBinaryExpr(
+						binOp, &tree.DInterval{Duration: d}, factor, types.Interval,
+					).Eval(&evalCtx)
+					require.NoError(t, err)
+					factorVal := colconv.GetDatumToPhysicalFn(factorType)(factor)
+					input = append(input, colexectestutils.Tuple{d, factorVal})
+					expected = append(expected, colexectestutils.Tuple{d, factorVal, res.(*tree.DInterval).Duration})
+				}
+			}
+			input = append(input, colexectestutils.Tuple{nil, colconv.GetDatumToPhysicalFn(factorType)(factorDatums[1])})
+			expected = append(expected, colexectestutils.Tuple{nil, colconv.GetDatumToPhysicalFn(factorType)(factorDatums[1]), nil})
+			exprs := []string{fmt.Sprintf("@1 %s @2", binOp)}
+			if binOp == tree.Mult {
+				// The factor can also be the left argument of the
+				// multiplication.
+				exprs = append(exprs, "@2 * @1")
+			}
+			for _, expr := range exprs {
+				log.Infof(ctx, "%s with %s factor", expr, factorType)
+				colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{inputTypes}, expected, colexectestutils.OrderedVerifier,
+					func(input []colexecop.Operator) (colexecop.Operator, error) {
+						return colexectestutils.CreateTestProjectingOperator(
+							ctx, flowCtx, input[0], inputTypes, expr, false /* canFallbackToRowexec */, testMemAcc,
+						)
+					})
+			}
+		}
+	}
+
+	// Dividing an interval by zero results in an error, same as in the row
+	// engine, both when the divisor is a column and a constant.
+	for _, tc := range []struct {
+		factorType *types.T
+		zero       interface{}
+	}{
+		{factorType: types.Int, zero: int64(0)},
+		{factorType: types.Float, zero: 0.0},
+	} {
+		typs := []*types.T{types.Interval, tc.factorType}
+		for _, expr := range []string{"@1 / @2", fmt.Sprintf("@1 / 0:::%s", tc.factorType.SQLString())} {
+			input := colexectestutils.NewOpTestInput(
+				testAllocator, 1, colexectestutils.Tuples{{intervals[0], tc.zero}}, typs,
+			)
+			op, err := colexectestutils.CreateTestProjectingOperator(
+				ctx, flowCtx, input, typs, expr, false /* canFallbackToRowexec */, testMemAcc,
+			)
+			require.NoError(t, err)
+			op.Init(ctx)
+			err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+			require.EqualError(t, err, tree.ErrDivByZero.Error())
+		}
+	}
+}
+
 func TestGetProjectionConstMixedTypeOperator(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -260,6 +660,104 @@ func TestRandomComparisons(t *testing.T) {
 	}
 }
 
+// TestMixedNumericComparisons verifies that the comparison projections between
+// the columns of different numeric types (which don't use an intermediate cast
+// operator) produce the same results as the row engine, including the values
+// at which the conversion from int to float loses precision.
+func TestMixedNumericComparisons(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// 1<<53+1 is the smallest positive integer that can't be represented
+	// exactly as a float.
+	ints := []interface{}{
+		int64(0), int64(1 << 53), int64(1<<53 + 1), int64(-(1<<53 + 1)),
+		int64(math.MaxInt64), int64(math.MinInt64),
+	}
+	floats := []interface{}{
+		0.5, float64(1 << 53), float64(1<<53 + 2), float64(-(1 << 53)),
+		float64(math.MaxInt64), float64(math.MinInt64), math.NaN(), math.Inf(1), math.Inf(-1),
+	}
+	var decimals []interface{}
+	for _, s := range []string{
+		"0.5", "9007199254740992", "9007199254740993", "-9007199254740993",
+		"9223372036854775807", "9223372036854775808", "-9223372036854775809",
+	} {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		decimals = append(decimals, *d)
+	}
+	toDatum := func(v interface{}) tree.Datum {
+		switch v := v.(type) {
+		case int64:
+			return tree.NewDInt(tree.DInt(v))
+		case float64:
+			return tree.NewDFloat(tree.DFloat(v))
+		case apd.Decimal:
+			return &tree.DDecimal{Decimal: v}
+		}
+		t.Fatalf("unexpected value %v", v)
+		return nil
+	}
+
+	for _, tc := range []struct {
+		typs        []*types.T
+		left, right []interface{}
+	}{
+		{typs: []*types.T{types.Int, types.Float}, left: ints, right: floats},
+		{typs: []*types.T{types.Float, types.Int}, left: floats, right: ints},
+		{typs: []*types.T{types.Int, types.Decimal}, left: ints, right: decimals},
+		{typs: []*types.T{types.Decimal, types.Int}, left: decimals, right: ints},
+		{typs: []*types.T{types.Float, types.Decimal}, left: floats, right: decimals},
+		{typs: []*types.T{types.Decimal, types.Float}, left: decimals, right: floats},
+	} {
+		for _, cmpOp := range []tree.ComparisonOperator{tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE} {
+			log.Infof(ctx, "%s %s %s", tc.typs[0], cmpOp, tc.typs[1])
+			input := colexectestutils.Tuples{{nil, tc.right[0]}, {tc.left[0], nil}, {nil, nil}}
+			expected := colexectestutils.Tuples{{nil, tc.right[0], nil}, {tc.left[0], nil, nil}, {nil, nil, nil}}
+			for _, l := range tc.left {
+				for _, r := range tc.right {
+					cmp := toDatum(l).Compare(&evalCtx, toDatum(r))
+					var b bool
+					switch cmpOp {
+					case tree.EQ:
+						b = cmp == 0
+					case tree.NE:
+						b = cmp != 0
+					case tree.LT:
+						b = cmp < 0
+					case tree.LE:
+						b = cmp <= 0
+					case tree.GT:
+						b = cmp > 0
+					case tree.GE:
+						b = cmp >= 0
+					}
+					input = append(input, colexectestutils.Tuple{l, r})
+					expected = append(expected, colexectestutils.Tuple{l, r, b})
+				}
+			}
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{tc.typs}, expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], tc.typs,
+						fmt.Sprintf("@1 %s @2", cmpOp), false /* canFallbackToRowexec */, testMemAcc,
+					)
+				})
+		}
+	}
+}
+
 func TestGetProjectionOperator(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -394,3 +892,85 @@ func BenchmarkProjOp(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkProjModInt64ConstOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTypes := []*types.T{types.Int}
+	// 8 is a power of two, so the bit mask is used, whereas 7 uses the
+	// general path with the integer division.
+	for _, divisor := range []int{8, 7} {
+		for _, useSel := range []bool{false, true} {
+			for _, hasNulls := range []bool{false, true} {
+				name := fmt.Sprintf("divisor=%d/useSel=%t/hasNulls=%t", divisor, useSel, hasNulls)
+				benchmarkProjOp(b, name, func(source *colexecop.RepeatableBatchSource) (colexecop.Operator, error) {
+					return colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, source, inputTypes, fmt.Sprintf("@1 %% %d", divisor),
+						false /* canFallbackToRowexec */, testMemAcc,
+					)
+				}, inputTypes, useSel, hasNulls)
+			}
+		}
+	}
+}
+
+// BenchmarkFusedArithmeticOp compares the fused evaluation of a two-level
+// arithmetic expression against the plan in which every operation is
+// evaluated by a separate projection operator that materializes its result.
+func BenchmarkFusedArithmeticOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	// The expression is (@1 + @2) * (@3 + @4).
+	inputTypes := []*types.T{types.Int, types.Int, types.Int, types.Int}
+	for _, fused := range []bool{false, true} {
+		for _, useSel := range []bool{false, true} {
+			for _, hasNulls := range []bool{false, true} {
+				name := fmt.Sprintf("fused=%t/useSel=%t/hasNulls=%t", fused, useSel, hasNulls)
+				benchmarkProjOp(b, name, func(source *colexecop.RepeatableBatchSource) (colexecop.Operator, error) {
+					if fused {
+						return NewFusedArithmeticOp(testAllocator, source, types.Int, &FusedArithExpr{
+							Op:    tree.Mult,
+							Left:  &FusedArithExpr{Op: tree.Plus, Left: &FusedArithExpr{ColIdx: 0}, Right: &FusedArithExpr{ColIdx: 1}},
+							Right: &FusedArithExpr{Op: tree.Plus, Left: &FusedArithExpr{ColIdx: 2}, Right: &FusedArithExpr{ColIdx: 3}},
+						}, 4 /* outputIdx */)
+					}
+					typs := inputTypes
+					var op colexecop.Operator = source
+					for _, step := range []struct {
+						binOp            tree.BinaryOperator
+						col1Idx, col2Idx int
+					}{
+						{binOp: tree.Plus, col1Idx: 0, col2Idx: 1},
+						{binOp: tree.Plus, col1Idx: 2, col2Idx: 3},
+						{binOp: tree.Mult, col1Idx: 4, col2Idx: 5},
+					} {
+						var err error
+						op, err = GetProjectionOperator(
+							testAllocator, typs, types.Int, step.binOp, op, step.col1Idx, step.col2Idx,
+							len(typs) /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+						)
+						if err != nil {
+							return nil, err
+						}
+						typs = append(typs[:len(typs):len(typs)], types.Int)
+					}
+					return op, nil
+				}, inputTypes, useSel, hasNulls)
+			}
+		}
+	}
+}
@@ -91,6 +91,11 @@ func (d *defaultCmp_KINDSelOp) Next() coldata.Batch {
 				idx++
 			}
 		}
+		if !hasSel && idx == n {
+			// All tuples of the dense batch have been selected, so the
+			// selection vector can be dropped.
+			batch.SetSelection(false)
+		}
 		if idx > 0 {
 			batch.SetLength(idx)
 			return batch
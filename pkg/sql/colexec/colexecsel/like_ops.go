@@ -107,3 +107,30 @@ func GetLikeOperator(
 		return nil, errors.AssertionFailedf("unsupported like op type %d", likeOpType)
 	}
 }
+
+// GetSimilarToOperator returns a selection operator which applies the
+// specified SIMILAR TO pattern, or NOT SIMILAR TO if the negate argument is
+// true. The pattern is translated into an equivalent regular expression only
+// once.
+func GetSimilarToOperator(
+	ctx *tree.EvalContext, input colexecop.Operator, colIdx int, pattern string, negate bool,
+) (colexecop.Operator, error) {
+	re, err := tree.ConvertSimilarToRegexp(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	base := selConstOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		colIdx:         colIdx,
+	}
+	if negate {
+		return &selNotRegexpBytesBytesConstOp{
+			selConstOpBase: base,
+			constArg:       re,
+		}, nil
+	}
+	return &selRegexpBytesBytesConstOp{
+		selConstOpBase: base,
+		constArg:       re,
+	}, nil
+}
@@ -101,6 +101,77 @@ func TestLikeOperators(t *testing.T) {
 	}
 }
 
+func TestSimilarToOperators(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tups := colexectestutils.Tuples{
+		{"abc"}, {"a.c"}, {"ac"}, {"abbc"}, {"a%c"}, {"^abc$"}, {"xabcx"}, {"a\nc"},
+	}
+	for _, tc := range []struct {
+		pattern  string
+		negate   bool
+		expected colexectestutils.Tuples
+	}{
+		{
+			// The pattern must match the whole string.
+			pattern:  "abc",
+			expected: colexectestutils.Tuples{{"abc"}},
+		},
+		{
+			pattern:  "abc",
+			negate:   true,
+			expected: colexectestutils.Tuples{{"a.c"}, {"ac"}, {"abbc"}, {"a%c"}, {"^abc$"}, {"xabcx"}, {"a\nc"}},
+		},
+		{
+			// '_' matches any single character (including the new line) while
+			// '.' is not a metacharacter.
+			pattern:  "a_c",
+			expected: colexectestutils.Tuples{{"abc"}, {"a.c"}, {"a%c"}, {"a\nc"}},
+		},
+		{
+			pattern:  "a.c",
+			expected: colexectestutils.Tuples{{"a.c"}},
+		},
+		{
+			// '%' matches any sequence of characters.
+			pattern:  "%abc%",
+			expected: colexectestutils.Tuples{{"abc"}, {"^abc$"}, {"xabcx"}},
+		},
+		{
+			// '^' and '$' are not metacharacters.
+			pattern:  "^abc$",
+			expected: colexectestutils.Tuples{{"^abc$"}},
+		},
+		{
+			// The repetition, alternation and grouping are the same as in
+			// POSIX regular expressions.
+			pattern:  "ab*c|x(abc)+x",
+			expected: colexectestutils.Tuples{{"abc"}, {"ac"}, {"abbc"}, {"xabcx"}},
+		},
+		{
+			pattern:  "a[b.]{1,2}c",
+			expected: colexectestutils.Tuples{{"abc"}, {"a.c"}, {"abbc"}},
+		},
+		{
+			// The escaped '%' is matched literally.
+			pattern:  "a\\%c",
+			expected: colexectestutils.Tuples{{"a%c"}},
+		},
+		{
+			pattern:  "a\\%c",
+			negate:   true,
+			expected: colexectestutils.Tuples{{"abc"}, {"a.c"}, {"ac"}, {"abbc"}, {"^abc$"}, {"xabcx"}, {"a\nc"}},
+		},
+	} {
+		colexectestutils.RunTests(
+			t, testAllocator, []colexectestutils.Tuples{tups}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				ctx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+				return GetSimilarToOperator(&ctx, input[0], 0, tc.pattern, tc.negate)
+			})
+	}
+}
+
 func BenchmarkLikeOps(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	rng, _ := randutil.NewPseudoRand()
@@ -0,0 +1,205 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for sel_range.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecsel
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ coldataext.Datum
+	_ duration.Duration
+	_ json.JSON
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// _GOTYPE is the template variable.
+type _GOTYPE interface{}
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+// _COMPARE is the template comparison function for assigning the first input
+// to the result of comparing the second input to the third input which
+// returns an int that is negative, zero, or positive depending on whether the
+// second input is less than, equal to, or greater than the third input.
+func _COMPARE(_, _, _, _, _ string) int {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// GetSelectionRangeOperator returns an operator that selects the tuples for
+// which the column of type t at index colIdx is within the range given by the
+// lower and the upper bounds. The bounds must be non-NULL constants of type t,
+// and each of them can be either inclusive or exclusive. This is equivalent
+// to two selection operators with a constant (like x > lower and x < upper)
+// but evaluates both bounds in a single pass over the batch.
+func GetSelectionRangeOperator(
+	input colexecop.Operator,
+	t *types.T,
+	colIdx int,
+	lower tree.Datum,
+	lowerInclusive bool,
+	upper tree.Datum,
+	upperInclusive bool,
+) (colexecop.Operator, error) {
+	conv := colconv.GetDatumToPhysicalFn(t)
+	base := selRangeOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		colIdx:         colIdx,
+		lowerInclusive: lowerInclusive,
+		upperInclusive: upperInclusive,
+	}
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			return &selRange_TYPEOp{
+				selRangeOpBase: base,
+				lower:          conv(lower).(_GOTYPE),
+				upper:          conv(upper).(_GOTYPE),
+			}, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported range selection type %s", t.Name())
+}
+
+// selRangeOpBase contains all of the fields for range selections, except for
+// the bounds themselves.
+type selRangeOpBase struct {
+	colexecop.OneInputHelper
+	colIdx         int
+	lowerInclusive bool
+	upperInclusive bool
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+// selRange_TYPEOp selects the tuples which are within the range between the
+// lower and the upper bounds.
+type selRange_TYPEOp struct {
+	selRangeOpBase
+	lower _GOTYPE
+	upper _GOTYPE
+}
+
+var _ colexecop.Operator = &selRange_TYPEOp{}
+
+func (p *selRange_TYPEOp) Next() coldata.Batch {
+	// The inclusive bound is satisfied when the result of the comparison with
+	// the bound is zero, whereas the exclusive bound requires the result of
+	// the comparison to be strictly positive (for the lower bound) or strictly
+	// negative (for the upper bound).
+	minLowerCmp, maxUpperCmp := 1, -1
+	if p.lowerInclusive {
+		minLowerCmp = 0
+	}
+	if p.upperInclusive {
+		maxUpperCmp = 0
+	}
+	for {
+		batch := p.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+
+		vec := batch.ColVec(p.colIdx)
+		col := vec.TemplateType()
+		nulls := vec.Nulls()
+		hasNulls := nulls.MaybeHasNulls()
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				arg := col.Get(i)
+				var cmp int
+				_COMPARE(cmp, arg, p.lower, col, _)
+				if cmp >= minLowerCmp {
+					_COMPARE(cmp, arg, p.upper, col, _)
+					if cmp <= maxUpperCmp {
+						sel[idx] = i
+						idx++
+					}
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				arg := col.Get(i)
+				var cmp int
+				_COMPARE(cmp, arg, p.lower, col, _)
+				if cmp >= minLowerCmp {
+					_COMPARE(cmp, arg, p.upper, col, _)
+					if cmp <= maxUpperCmp {
+						sel[idx] = i
+						idx++
+					}
+				}
+			}
+			if idx == n {
+				// All tuples of the dense batch have been selected, so the
+				// selection vector is an identity and can be dropped
+				// altogether.
+				batch.SetSelection(false)
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+// {{end}}
+// {{end}}
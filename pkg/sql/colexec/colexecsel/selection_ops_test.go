@@ -13,11 +13,14 @@ package colexecsel
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -72,6 +75,92 @@ func TestSelLTInt64Int64(t *testing.T) {
 	})
 }
 
+// TestSelDropsFullSelection verifies that the selection operators drop the
+// selection vector of a dense batch when all of its tuples are selected and
+// keep it otherwise.
+func TestSelDropsFullSelection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	const n = 10
+	typs := []*types.T{types.Int, types.Int}
+	for _, tc := range []struct {
+		desc        string
+		constArg    int64
+		expectedLen int
+	}{
+		{desc: "all selected", constArg: n, expectedLen: n},
+		{desc: "some selected", constArg: n / 2, expectedLen: n / 2},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, withConst := range []bool{false, true} {
+				batch := testAllocator.NewMemBatchWithFixedCapacity(typs, n)
+				col1, col2 := batch.ColVec(0).Int64(), batch.ColVec(1).Int64()
+				for i := 0; i < n; i++ {
+					col1[i] = int64(i)
+					col2[i] = tc.constArg
+				}
+				batch.SetLength(n)
+				input := colexecop.NewFeedOperator()
+				input.SetBatch(batch)
+				var op colexecop.Operator
+				var err error
+				if withConst {
+					op, err = GetSelectionConstOperator(
+						tree.LT, input, typs, 0 /* colIdx */, tree.NewDInt(tree.DInt(tc.constArg)),
+						nil /* evalCtx */, nil, /* cmpExpr */
+					)
+				} else {
+					op, err = GetSelectionOperator(
+						tree.LT, input, typs, 0 /* col1Idx */, 1, /* col2Idx */
+						nil /* evalCtx */, nil, /* cmpExpr */
+					)
+				}
+				require.NoError(t, err)
+				op.Init(context.Background())
+				out := op.Next()
+				require.Equal(t, tc.expectedLen, out.Length())
+				if tc.expectedLen == n {
+					require.Nil(t, out.Selection())
+				} else {
+					require.NotNil(t, out.Selection())
+				}
+			}
+		})
+	}
+}
+
+// TestSelLTMixedIntFloat verifies the selection between an int and a float
+// column at the values at which the conversion from int to float loses
+// precision. The row engine converts the int to float before comparing, so
+// the vectorized operator must do the same.
+func TestSelLTMixedIntFloat(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	tups := colexectestutils.Tuples{
+		// 1<<53+1 is converted to 1<<53 which is not less than itself.
+		{1<<53 + 1, float64(1 << 53)},
+		{1 << 53, float64(1<<53 + 2)},
+		{1<<53 + 1, float64(1<<53 + 2)},
+		// math.MaxInt64 is converted to 1<<63.
+		{math.MaxInt64, float64(math.MaxInt64)},
+		{math.MinInt64, float64(math.MinInt64)},
+		{math.MinInt64, math.Inf(-1)},
+		{math.MaxInt64, math.Inf(1)},
+		// NaN is smaller than any other float.
+		{0, math.NaN()},
+		{nil, 1.5},
+		{1, nil},
+	}
+	colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tups}, [][]*types.T{{types.Int, types.Float}},
+		colexectestutils.Tuples{{1 << 53, float64(1<<53 + 2)}, {1<<53 + 1, float64(1<<53 + 2)}, {math.MaxInt64, math.Inf(1)}},
+		colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return GetSelectionOperator(
+				tree.LT, input[0], []*types.T{types.Int, types.Float}, 0 /* col1Idx */, 1, /* col2Idx */
+				nil /* evalCtx */, nil, /* cmpExpr */
+			)
+		})
+}
+
 func TestGetSelectionConstOperator(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -244,3 +333,196 @@ func BenchmarkSelLTBytesBytesOp(b *testing.B) {
 		inputTypes,
 	)
 }
+
+// TestCollatedStringSelOperators verifies that the comparisons of the collated
+// strings are performed according to the collation (which can differ from the
+// byte-wise order and between the locales) and return the same results as the
+// row engine.
+func TestCollatedStringSelOperators(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	strs := []string{"", "a", "A", "ä", "o", "ö", "ss", "ß", "z", "Z"}
+	cmpOps := []tree.ComparisonOperator{tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE}
+	var env tree.CollationEnvironment
+	// In German "ä" sorts right after "a" whereas in Swedish it sorts after
+	// "z".
+	for _, locale := range []string{"de", "sv"} {
+		typ := types.MakeCollatedString(types.String, locale)
+		datums := make([]tree.Datum, len(strs))
+		for i, s := range strs {
+			d, err := tree.NewDCollatedString(s, locale, &env)
+			require.NoError(t, err)
+			datums[i] = d
+		}
+		aUmlaut, z := datums[3], datums[8]
+		require.Equal(t, locale == "sv", aUmlaut.Compare(&evalCtx, z) > 0)
+		for _, cmpOp := range cmpOps {
+			// Comparisons against a constant.
+			typs := []*types.T{typ}
+			for _, constArg := range datums {
+				tuples := colexectestutils.Tuples{{nil}}
+				var expected colexectestutils.Tuples
+				for _, d := range datums {
+					tuples = append(tuples, colexectestutils.Tuple{d})
+					res, err := tree.NewTypedComparisonExpr(cmpOp, d, constArg).Eval(&evalCtx)
+					require.NoError(t, err)
+					if res == tree.DBoolTrue {
+						expected = append(expected, colexectestutils.Tuple{d})
+					}
+				}
+				log.Infof(ctx, "%s: @1 %s %s", locale, cmpOp, constArg)
+				runTests := colexectestutils.RunTestsWithTyps
+				if len(expected) == 0 {
+					// The all nulls injection cannot change the output when
+					// nothing is selected.
+					runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+				}
+				runTests(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+					func(input []colexecop.Operator) (colexecop.Operator, error) {
+						return GetSelectionConstOperator(
+							cmpOp, input[0], typs, 0 /* colIdx */, constArg, &evalCtx,
+							tree.NewTypedComparisonExpr(cmpOp, datums[0], constArg),
+						)
+					})
+			}
+
+			// Comparisons of two columns.
+			typs = []*types.T{typ, typ}
+			tuples := colexectestutils.Tuples{{nil, datums[0]}, {datums[0], nil}}
+			var expected colexectestutils.Tuples
+			for _, left := range datums {
+				for _, right := range datums {
+					tuples = append(tuples, colexectestutils.Tuple{left, right})
+					res, err := tree.NewTypedComparisonExpr(cmpOp, left, right).Eval(&evalCtx)
+					require.NoError(t, err)
+					if res == tree.DBoolTrue {
+						expected = append(expected, colexectestutils.Tuple{left, right})
+					}
+				}
+			}
+			log.Infof(ctx, "%s: @1 %s @2", locale, cmpOp)
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return GetSelectionOperator(
+						cmpOp, input[0], typs, 0 /* col1Idx */, 1 /* col2Idx */, &evalCtx,
+						tree.NewTypedComparisonExpr(cmpOp, datums[0], datums[0]),
+					)
+				})
+		}
+	}
+}
+
+// TestGetSelectionRangeOperator verifies that the range selection operators
+// select the same tuples as the two comparisons with the bounds in the row
+// engine.
+func TestGetSelectionRangeOperator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	var env tree.CollationEnvironment
+	collatedString := func(s string) tree.Datum {
+		d, err := tree.NewDCollatedString(s, "de", &env)
+		require.NoError(t, err)
+		return d
+	}
+	decimal := func(s string) tree.Datum {
+		d, err := tree.ParseDDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+	for _, tc := range []struct {
+		typ    *types.T
+		datums []tree.Datum
+	}{
+		{
+			typ: types.Int,
+			datums: []tree.Datum{
+				tree.NewDInt(math.MinInt64), tree.NewDInt(-1), tree.NewDInt(0),
+				tree.NewDInt(3), tree.NewDInt(7), tree.NewDInt(math.MaxInt64),
+			},
+		},
+		{
+			typ: types.Float,
+			datums: []tree.Datum{
+				tree.NewDFloat(tree.DFloat(math.NaN())), tree.NewDFloat(tree.DFloat(math.Inf(-1))),
+				tree.NewDFloat(-0.5), tree.NewDFloat(0), tree.NewDFloat(2.5),
+				tree.NewDFloat(tree.DFloat(math.Inf(1))),
+			},
+		},
+		{
+			typ: types.Decimal,
+			datums: []tree.Datum{
+				decimal("-1.5"), decimal("0"), decimal("1.00"), decimal("1.01"), decimal("10"),
+			},
+		},
+		{
+			typ: types.String,
+			datums: []tree.Datum{
+				tree.NewDString(""), tree.NewDString("a"), tree.NewDString("ab"),
+				tree.NewDString("b"), tree.NewDString("z"),
+			},
+		},
+		{
+			// Collated strings are compared according to the collation.
+			typ: types.MakeCollatedString(types.String, "de"),
+			datums: []tree.Datum{
+				collatedString("a"), collatedString("ä"), collatedString("b"),
+				collatedString("z"), collatedString("Z"),
+			},
+		},
+	} {
+		typs := []*types.T{tc.typ}
+		conv := colconv.GetDatumToPhysicalFn(tc.typ)
+		tuples := colexectestutils.Tuples{{nil}}
+		for _, d := range tc.datums {
+			tuples = append(tuples, colexectestutils.Tuple{conv(d)})
+		}
+		for _, lower := range tc.datums {
+			for _, upper := range tc.datums {
+				for _, lowerInclusive := range []bool{false, true} {
+					for _, upperInclusive := range []bool{false, true} {
+						lowerOp, upperOp := tree.GT, tree.LT
+						if lowerInclusive {
+							lowerOp = tree.GE
+						}
+						if upperInclusive {
+							upperOp = tree.LE
+						}
+						var expected colexectestutils.Tuples
+						for i, d := range tc.datums {
+							lowerRes, err := tree.NewTypedComparisonExpr(lowerOp, d, lower).Eval(&evalCtx)
+							require.NoError(t, err)
+							upperRes, err := tree.NewTypedComparisonExpr(upperOp, d, upper).Eval(&evalCtx)
+							require.NoError(t, err)
+							if lowerRes == tree.DBoolTrue && upperRes == tree.DBoolTrue {
+								expected = append(expected, tuples[i+1])
+							}
+						}
+						log.Infof(ctx, "@1 %s %s AND @1 %s %s", lowerOp, lower, upperOp, upper)
+						runTests := colexectestutils.RunTestsWithTyps
+						if len(expected) == 0 {
+							// The all nulls injection cannot change the output
+							// when nothing is selected.
+							runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+						}
+						runTests(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+							func(input []colexecop.Operator) (colexecop.Operator, error) {
+								return GetSelectionRangeOperator(
+									input[0], tc.typ, 0 /* colIdx */, lower, lowerInclusive, upper, upperInclusive,
+								)
+							})
+					}
+				}
+			}
+		}
+	}
+}
@@ -110,6 +110,11 @@ func _SEL_CONST_LOOP(_HAS_NULLS bool) { // */}}
 				idx++
 			}
 		}
+		if idx == n {
+			// All tuples of the dense batch have been selected, so the
+			// selection vector is an identity and can be dropped altogether.
+			batch.SetSelection(false)
+		}
 	}
 	// {{end}}
 	// {{end}}
@@ -164,6 +169,11 @@ func _SEL_LOOP(_HAS_NULLS bool) { // */}}
 				idx++
 			}
 		}
+		if idx == n {
+			// All tuples of the dense batch have been selected, so the
+			// selection vector is an identity and can be dropped altogether.
+			batch.SetSelection(false)
+		}
 	}
 	// {{end}}
 	// {{end}}
@@ -296,6 +306,9 @@ func GetSelectionConstOperator(
 		OneInputHelper: colexecop.MakeOneInputHelper(input),
 		colIdx:         colIdx,
 	}
+	if op := getMixedTimestampSelConstOperator(cmpOp, selConstOpBase, leftType, constArg, evalCtx); op != nil {
+		return op, nil
+	}
 	if leftType.Family() != types.TupleFamily && constType.Family() != types.TupleFamily {
 		// Tuple comparison has special null-handling semantics, so we will
 		// fallback to the default comparison operator if either of the
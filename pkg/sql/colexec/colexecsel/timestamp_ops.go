@@ -0,0 +1,257 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecsel
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// getMixedTimestampSelConstOperator returns a selection operator for the
+// comparison of a DATE, TIMESTAMP, or TIMESTAMPTZ column against a constant of
+// a different type among those. Such comparisons (that commonly arise from
+// the filters like d > now() - INTERVAL '1 day' after the constant folding)
+// depend on the session time zone, so the operators for the same canonical
+// type family cannot be used as is. nil is returned if the comparison is not
+// of such kind and the general operator should be planned.
+func getMixedTimestampSelConstOperator(
+	cmpOp tree.ComparisonOperator,
+	selConstOpBase selConstOpBase,
+	leftType *types.T,
+	constArg tree.Datum,
+	evalCtx *tree.EvalContext,
+) colexecop.Operator {
+	leftFamily, constFamily := leftType.Family(), constArg.ResolvedType().Family()
+	if leftFamily == constFamily {
+		return nil
+	}
+	var c time.Time
+	switch d := constArg.(type) {
+	case *tree.DTimestampTZ:
+		c = d.Time
+	case *tree.DTimestamp:
+		c = d.Time
+	default:
+		return nil
+	}
+	loc := evalCtx.GetLocation()
+	switch leftFamily {
+	case types.DateFamily:
+		if constFamily == types.TimestampFamily {
+			c = timestampInLocation(c, loc)
+		}
+		return getDateTimestampTZSelConstOperator(cmpOp, selConstOpBase, c, loc)
+	case types.TimestampFamily:
+		if constFamily != types.TimestampTZFamily {
+			return nil
+		}
+		switch cmpOp {
+		case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+			return &selTimestampInLocationTimestampTZConstOp{
+				selConstOpBase: selConstOpBase,
+				cmpOp:          cmpOp,
+				constArg:       c,
+				loc:            loc,
+			}
+		}
+	case types.TimestampTZFamily:
+		if constFamily != types.TimestampFamily {
+			return nil
+		}
+		// The TIMESTAMPTZ values are compared as is, so we only need to
+		// convert the constant once.
+		return getTimestampSelConstOperator(cmpOp, selConstOpBase, timestampInLocation(c, loc))
+	}
+	return nil
+}
+
+// timestampInLocation returns the TIMESTAMPTZ value that the TIMESTAMP value t
+// is equal to when interpreted in the location loc. It matches the conversion
+// performed by the row engine when comparing these types.
+func timestampInLocation(t time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	return t.In(loc).Add(-time.Duration(offset) * time.Second)
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// dateInLocation returns the TIMESTAMPTZ value that the finite DATE value
+// (represented by the number of days since the Unix epoch) is equal to when
+// interpreted in the location loc. It matches tree.MakeDTimestampTZFromDate.
+func dateInLocation(days int64, loc *time.Location) time.Time {
+	return timestampInLocation(time.Unix(days*secondsPerDay, 0).UTC(), loc)
+}
+
+// getDateTimestampTZSelConstOperator returns a selection operator for the
+// comparison of a DATE column against the TIMESTAMPTZ constant c (when the
+// dates are interpreted in the location loc). The comparison is reduced to
+// the comparison of the dates against the date of the constant, so no
+// conversion needs to be performed per row. nil is returned if no such
+// reduction is possible.
+func getDateTimestampTZSelConstOperator(
+	cmpOp tree.ComparisonOperator, selConstOpBase selConstOpBase, c time.Time, loc *time.Location,
+) colexecop.Operator {
+	// Find the date at which midnight the constant falls into the same day,
+	// i.e. the date such that dateInLocation(date) <= c < dateInLocation(date+1).
+	// Since the conversion is monotonic, the date in the location is either the
+	// desired one or very close to it.
+	year, month, day := c.In(loc).Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+	for i := 0; i < 2 && dateInLocation(date, loc).After(c); i++ {
+		date--
+	}
+	for i := 0; i < 2 && !dateInLocation(date+1, loc).After(c); i++ {
+		date++
+	}
+	start := dateInLocation(date, loc)
+	if start.After(c) || !dateInLocation(date+1, loc).After(c) {
+		return nil
+	}
+	// If the constant is exactly at midnight of the date, then the date is
+	// equal to the constant, otherwise the date is less than the constant and
+	// the following date is greater than the constant. Note that infinite dates
+	// are represented by the largest and smallest int64 values, so they compare
+	// correctly against the finite date too.
+	atMidnight := start.Equal(c)
+	switch cmpOp {
+	case tree.EQ:
+		if atMidnight {
+			return &selEQInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+		}
+	case tree.NE:
+		if atMidnight {
+			return &selNEInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+		}
+	case tree.LT:
+		if atMidnight {
+			return &selLTInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+		}
+		return &selLEInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+	case tree.LE:
+		return &selLEInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+	case tree.GT:
+		return &selGTInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+	case tree.GE:
+		if atMidnight {
+			return &selGEInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+		}
+		return &selGTInt64Int64ConstOp{selConstOpBase: selConstOpBase, constArg: date}
+	}
+	return nil
+}
+
+// getTimestampSelConstOperator returns a selection operator for the comparison
+// of a TIMESTAMPTZ column against the constant c, or nil if the comparison is
+// not supported.
+func getTimestampSelConstOperator(
+	cmpOp tree.ComparisonOperator, selConstOpBase selConstOpBase, c time.Time,
+) colexecop.Operator {
+	switch cmpOp {
+	case tree.EQ:
+		return &selEQTimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	case tree.NE:
+		return &selNETimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	case tree.LT:
+		return &selLTTimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	case tree.LE:
+		return &selLETimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	case tree.GT:
+		return &selGTTimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	case tree.GE:
+		return &selGETimestampTimestampConstOp{selConstOpBase: selConstOpBase, constArg: c}
+	}
+	return nil
+}
+
+// selTimestampInLocationTimestampTZConstOp selects the tuples for which the
+// comparison of the TIMESTAMP column against the TIMESTAMPTZ constant is true.
+// Every TIMESTAMP value is interpreted in the session location before being
+// compared.
+type selTimestampInLocationTimestampTZConstOp struct {
+	selConstOpBase
+	cmpOp    tree.ComparisonOperator
+	constArg time.Time
+	loc      *time.Location
+}
+
+var _ colexecop.Operator = &selTimestampInLocationTimestampTZConstOp{}
+
+// matches returns whether the TIMESTAMP value t satisfies the comparison.
+func (p *selTimestampInLocationTimestampTZConstOp) matches(t time.Time) bool {
+	t = timestampInLocation(t, p.loc)
+	var cmpResult int
+	if t.Before(p.constArg) {
+		cmpResult = -1
+	} else if p.constArg.Before(t) {
+		cmpResult = 1
+	}
+	switch p.cmpOp {
+	case tree.EQ:
+		return cmpResult == 0
+	case tree.NE:
+		return cmpResult != 0
+	case tree.LT:
+		return cmpResult < 0
+	case tree.LE:
+		return cmpResult <= 0
+	case tree.GT:
+		return cmpResult > 0
+	default:
+		return cmpResult >= 0
+	}
+}
+
+func (p *selTimestampInLocationTimestampTZConstOp) Next() coldata.Batch {
+	for {
+		batch := p.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(p.colIdx)
+		col := vec.Timestamp()
+		nulls := vec.Nulls()
+		hasNulls := nulls.MaybeHasNulls()
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				if p.matches(col.Get(i)) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				if p.matches(col.Get(i)) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecsel
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMixedTimestampSelConstOperator verifies that the comparisons of DATE,
+// TIMESTAMP, and TIMESTAMPTZ columns against a constant of a different type
+// among those return the same results as the row engine in different
+// session time zones.
+func TestMixedTimestampSelConstOperator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	constTimes := []time.Time{
+		// Exactly at midnight in UTC.
+		time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC),
+		// Microseconds around midnight.
+		time.Date(2021, 6, 15, 23, 59, 59, 999999000, time.UTC),
+		time.Date(2021, 6, 16, 0, 0, 0, 1000, time.UTC),
+		// Around DST transitions in America/New_York.
+		time.Date(2021, 3, 14, 7, 30, 0, 0, time.UTC),
+		time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC),
+		// Midnight doesn't exist on this date in America/Sao_Paulo.
+		time.Date(2018, 11, 4, 3, 0, 0, 0, time.UTC),
+		// Before the Unix epoch.
+		time.Date(1969, 12, 31, 19, 0, 0, 0, time.UTC),
+	}
+	locationNames := []string{"UTC", "America/New_York", "America/Sao_Paulo", "Asia/Kolkata", "Pacific/Kiritimati"}
+	cmpOps := []tree.ComparisonOperator{tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE}
+	deltas := []time.Duration{-24 * time.Hour, -time.Hour, -time.Microsecond, 0, time.Microsecond, time.Hour, 24 * time.Hour}
+
+	for _, locationName := range locationNames {
+		loc, err := timeutil.LoadLocation(locationName)
+		require.NoError(t, err)
+		evalCtx.SessionData.Location = loc
+		for _, constTime := range constTimes {
+			// The constant is exactly at midnight in the session time zone.
+			year, month, day := constTime.In(loc).Date()
+			localMidnight := time.Date(year, month, day, 0, 0, 0, 0, loc)
+			for _, c := range []time.Time{constTime, localMidnight} {
+				// The wall clock time of the constant in the session time zone
+				// as TIMESTAMP.
+				wall := time.Date(
+					c.In(loc).Year(), c.In(loc).Month(), c.In(loc).Day(),
+					c.In(loc).Hour(), c.In(loc).Minute(), c.In(loc).Second(), c.In(loc).Nanosecond(), time.UTC,
+				)
+				var timestamps []time.Time
+				for _, base := range []time.Time{c.UTC(), wall} {
+					for _, delta := range deltas {
+						timestamps = append(timestamps, base.Add(delta))
+					}
+				}
+				unixDays := c.Unix() / secondsPerDay
+				var dates []int64
+				for d := unixDays - 2; d <= unixDays+2; d++ {
+					dates = append(dates, d)
+				}
+				dates = append(dates, math.MinInt64, math.MaxInt64)
+
+				for _, tc := range []struct {
+					colType  *types.T
+					constArg tree.Datum
+				}{
+					{colType: types.Date, constArg: tree.MustMakeDTimestampTZ(c, time.Microsecond)},
+					{colType: types.Date, constArg: tree.MustMakeDTimestamp(c, time.Microsecond)},
+					{colType: types.Timestamp, constArg: tree.MustMakeDTimestampTZ(c, time.Microsecond)},
+					{colType: types.TimestampTZ, constArg: tree.MustMakeDTimestamp(c, time.Microsecond)},
+				} {
+					// Prepare the input along with the datums to compute the
+					// expected results by the row engine.
+					var tuples colexectestutils.Tuples
+					var datums []tree.Datum
+					if tc.colType.Family() == types.DateFamily {
+						for _, d := range dates {
+							tuples = append(tuples, colexectestutils.Tuple{d})
+							datums = append(datums, tree.NewDDate(pgdate.MakeCompatibleDateFromDisk(d)))
+						}
+					} else {
+						for _, ts := range timestamps {
+							tuples = append(tuples, colexectestutils.Tuple{ts})
+							if tc.colType.Family() == types.TimestampFamily {
+								datums = append(datums, tree.MustMakeDTimestamp(ts, time.Microsecond))
+							} else {
+								datums = append(datums, tree.MustMakeDTimestampTZ(ts, time.Microsecond))
+							}
+						}
+					}
+					tuples = append(tuples, colexectestutils.Tuple{nil})
+					datums = append(datums, tree.DNull)
+
+					for _, cmpOp := range cmpOps {
+						var expected colexectestutils.Tuples
+						for i, d := range datums {
+							if d == tree.DNull {
+								continue
+							}
+							cmpExpr := tree.NewTypedComparisonExpr(cmpOp, d, tc.constArg)
+							res, err := cmpExpr.Eval(&evalCtx)
+							require.NoError(t, err)
+							if res == tree.DBoolTrue {
+								expected = append(expected, tuples[i])
+							}
+						}
+						name := fmt.Sprintf("%s/%s %s %s", locationName, tc.colType, cmpOp, tc.constArg)
+						log.Infof(ctx, "%s", name)
+						typs := []*types.T{tc.colType}
+						cmpExpr := tree.NewTypedComparisonExpr(cmpOp, datums[0], tc.constArg)
+						runTests := colexectestutils.RunTestsWithTyps
+						if len(expected) == 0 {
+							// The all nulls injection cannot change the output
+							// when nothing is selected.
+							runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+						}
+						runTests(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+							func(input []colexecop.Operator) (colexecop.Operator, error) {
+								op, err := GetSelectionConstOperator(cmpOp, input[0], typs, 0 /* colIdx */, tc.constArg, &evalCtx, cmpExpr)
+								if err != nil {
+									return nil, err
+								}
+								if cmpOp != tree.EQ && cmpOp != tree.NE {
+									// Only the equality comparisons of DATE
+									// values against the constants not at
+									// midnight might use the default operator.
+									_, isDefault := op.(*defaultCmpConstSelOp)
+									require.False(t, isDefault, name)
+								}
+								return op, nil
+							})
+					}
+				}
+			}
+		}
+	}
+}
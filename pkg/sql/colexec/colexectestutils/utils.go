@@ -30,19 +30,24 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/enum"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
 	"github.com/pmezard/go-difflib/difflib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -932,6 +937,8 @@ func (s *opTestInput) Next() coldata.Batch {
 						setColVal(vec, outputIdx, newBytes, s.evalCtx)
 					case types.IntervalFamily:
 						setColVal(vec, outputIdx, duration.MakeDuration(rng.Int63(), rng.Int63(), rng.Int63()), s.evalCtx)
+					case types.TimestampTZFamily:
+						setColVal(vec, outputIdx, timeutil.Unix(rng.Int63n(1<<32), rng.Int63n(int64(time.Second))), s.evalCtx)
 					case types.JsonFamily:
 						j, err := json.Random(20, rng)
 						if err != nil {
@@ -949,10 +956,25 @@ func (s *opTestInput) Next() coldata.Batch {
 								colexecerror.InternalError(err)
 							}
 							setColVal(vec, outputIdx, d, s.evalCtx)
+						case types.TimeFamily:
+							setColVal(vec, outputIdx, tree.MakeDTime(timeofday.FromInt(rng.Int63())), s.evalCtx)
 						case types.TimeTZFamily:
 							setColVal(vec, outputIdx, tree.NewDTimeTZFromOffset(timeofday.FromInt(rng.Int63()), rng.Int31()), s.evalCtx)
 						case types.TupleFamily:
 							setColVal(vec, outputIdx, stringToDatum("(NULL)", vec.Type(), s.evalCtx), s.evalCtx)
+						case types.ArrayFamily:
+							setColVal(vec, outputIdx, tree.NewDArray(vec.Type().ArrayContents()), s.evalCtx)
+						case types.BitFamily:
+							setColVal(vec, outputIdx, &tree.DBitArray{BitArray: bitarray.Rand(rng, uint(rng.Intn(130)))}, s.evalCtx)
+						case types.INetFamily:
+							setColVal(vec, outputIdx, tree.NewDIPAddr(tree.DIPAddr{IPAddr: ipaddr.RandIPAddr(rng)}), s.evalCtx)
+						case types.EnumFamily:
+							if members := tree.MakeAllDEnumsInType(vec.Type()); len(members) > 0 {
+								setColVal(vec, outputIdx, members[rng.Intn(len(members))], s.evalCtx)
+							}
+						case types.UnknownFamily:
+							// The only value of the unknown type is NULL, so there
+							// is no garbage to set.
 						default:
 							colexecerror.InternalError(errors.AssertionFailedf("unexpected datum-backed type: %s", vec.Type()))
 						}
@@ -1303,6 +1325,16 @@ func tupleEquals(expected Tuple, actual Tuple, evalCtx *tree.EvalContext) bool {
 				}
 				return false
 			}
+			// Special case for bytes-like types since an empty value might be
+			// represented by a nil byte slice.
+			if b1, ok := actual[i].([]byte); ok {
+				if s2, ok := expected[i].(string); ok {
+					if string(b1) == s2 {
+						continue
+					}
+					return false
+				}
+			}
 			// Default case.
 			if !reflect.DeepEqual(
 				reflect.ValueOf(actual[i]).Convert(reflect.TypeOf(expected[i])).Interface(),
@@ -1543,6 +1575,25 @@ func (c *chunkingBatchSource) Reset(context.Context) {
 	c.curIdx = 0
 }
 
+// MakeTestEnumType returns a hydrated enum type with the given members in the
+// declared order. The members can be created with
+// tree.MakeDEnumFromLogicalRepresentation.
+func MakeTestEnumType(members ...string) *types.T {
+	typ := types.MakeEnum(oid.Oid(100500), oid.Oid(100501))
+	typ.TypeMeta = types.UserDefinedTypeMetadata{
+		Name: &types.UserDefinedTypeName{
+			Schema: "public",
+			Name:   "test_enum",
+		},
+		EnumData: &types.EnumMetadata{
+			LogicalRepresentations:  members,
+			PhysicalRepresentations: enum.GenerateNEvenlySpacedBytes(len(members)),
+			IsMemberReadOnly:        make([]bool, len(members)),
+		},
+	}
+	return typ
+}
+
 // MinBatchSize is the minimum acceptable size of batches for tests in colexec*
 // packages.
 const MinBatchSize = 3
@@ -37,6 +37,24 @@ func BoolOrUnknownToSelOp(
 	}
 }
 
+// NotBoolOrUnknownToSelOp is similar to BoolOrUnknownToSelOp but selects the
+// tuples for which the column is false. Note that, same as with the
+// non-negated form, the tuples with NULL values are never selected.
+func NotBoolOrUnknownToSelOp(
+	input colexecop.Operator, typs []*types.T, vecIdx int,
+) (colexecop.Operator, error) {
+	switch typs[vecIdx].Family() {
+	case types.BoolFamily:
+		return NewNotBoolVecToSelOp(input, vecIdx), nil
+	case types.UnknownFamily:
+		// NOT NULL is NULL, so, similar to BoolOrUnknownToSelOp, the selection
+		// vector will always be empty.
+		return NewZeroOp(input), nil
+	default:
+		return nil, errors.Errorf("unexpectedly %s is neither bool nor unknown", typs[vecIdx])
+	}
+}
+
 // BoolVecToSelOp transforms a boolean column into a selection vector by adding
 // an index to the selection for each true value in the boolean column.
 type BoolVecToSelOp struct {
@@ -93,6 +111,11 @@ func (p *BoolVecToSelOp) Next() coldata.Batch {
 				sel[idx] = i
 				idx += inc
 			}
+			if idx == n {
+				// All tuples of the dense batch have been selected, so the
+				// selection vector can be dropped.
+				batch.SetSelection(false)
+			}
 		}
 
 		if idx == 0 {
@@ -127,6 +150,20 @@ func NewBoolVecToSelOp(input colexecop.Operator, colIdx int) colexecop.Operator
 	return ret
 }
 
+// NewNotBoolVecToSelOp is the negated form of NewBoolVecToSelOp. It filters
+// its input batch by the false values of the boolean column specified by
+// colIdx, and, same as NewBoolVecToSelOp, it never selects the tuples with
+// NULL values in that column.
+//
+// NOTE: if the column can be of a type other than boolean,
+// NotBoolOrUnknownToSelOp *must* be used instead.
+func NewNotBoolVecToSelOp(input colexecop.Operator, colIdx int) colexecop.Operator {
+	d := &selBoolOp{OneInputHelper: colexecop.MakeOneInputHelper(input), colIdx: colIdx, negate: true}
+	ret := &BoolVecToSelOp{OneInputHelper: colexecop.MakeOneInputHelper(d)}
+	d.boolVecToSelOp = ret
+	return ret
+}
+
 // selBoolOp is a small helper operator that transforms a BoolVecToSelOp into
 // an operator that can see the inside of its input batch for NewBoolVecToSelOp.
 type selBoolOp struct {
@@ -134,6 +171,11 @@ type selBoolOp struct {
 	colexecop.NonExplainable
 	boolVecToSelOp *BoolVecToSelOp
 	colIdx         int
+	// negate, if true, indicates that the false values should be selected. In
+	// such case the negated values are written into negated since we cannot
+	// modify the input column in place.
+	negate  bool
+	negated []bool
 }
 
 func (d *selBoolOp) Next() coldata.Batch {
@@ -143,6 +185,10 @@ func (d *selBoolOp) Next() coldata.Batch {
 		return batch
 	}
 	inputCol := batch.ColVec(d.colIdx)
+	if d.negate {
+		d.setNegatedOutputCol(batch, inputCol)
+		return batch
+	}
 	d.boolVecToSelOp.OutputCol = inputCol.Bool()
 	if inputCol.MaybeHasNulls() {
 		// If the input column has null values, we need to explicitly set the
@@ -173,3 +219,26 @@ func (d *selBoolOp) Next() coldata.Batch {
 	}
 	return batch
 }
+
+// setNegatedOutputCol sets the output column of boolVecToSelOp to contain true
+// values only for the tuples for which inputCol is false and non-NULL.
+func (d *selBoolOp) setNegatedOutputCol(batch coldata.Batch, inputCol coldata.Vec) {
+	n := batch.Length()
+	col := inputCol.Bool()
+	if cap(d.negated) < len(col) {
+		d.negated = make([]bool, len(col))
+	}
+	outputCol := d.negated[:len(col)]
+	d.boolVecToSelOp.OutputCol = outputCol
+	nulls := inputCol.Nulls()
+	hasNulls := inputCol.MaybeHasNulls()
+	if sel := batch.Selection(); sel != nil {
+		for _, i := range sel[:n] {
+			outputCol[i] = !col[i] && !(hasNulls && nulls.NullAt(i))
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			outputCol[i] = !col[i] && !(hasNulls && nulls.NullAt(i))
+		}
+	}
+}
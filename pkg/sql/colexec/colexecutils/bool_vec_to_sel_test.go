@@ -32,6 +32,11 @@ func TestBoolVecToSelOp(t *testing.T) {
 			tuples:   colexectestutils.Tuples{{true}, {false}, {true}},
 			expected: colexectestutils.Tuples{{true}, {true}},
 		},
+		{
+			boolCol:  0,
+			tuples:   colexectestutils.Tuples{{true}, {true}, {true}},
+			expected: colexectestutils.Tuples{{true}, {true}, {true}},
+		},
 	}
 	for _, tc := range tcs {
 		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
@@ -39,3 +44,14 @@ func TestBoolVecToSelOp(t *testing.T) {
 		})
 	}
 }
+
+func TestNotBoolVecToSelOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	// The tuples with NULL values must not be selected by the negation.
+	tuples := colexectestutils.Tuples{{true}, {false}, {nil}, {false}, {true}, {nil}}
+	expected := colexectestutils.Tuples{{false}, {false}}
+	colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tuples}, expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
+		return NewNotBoolVecToSelOp(input[0], 0), nil
+	})
+}
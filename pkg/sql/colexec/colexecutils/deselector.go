@@ -23,11 +23,18 @@ import (
 // selection vector, it coalesces them (meaning that tuples will be reordered
 // or omitted according to the selection vector). If the batches come with no
 // selection vector, it is a noop.
+//
+// The deselector should only be planned when the downstream operator requires
+// (or strongly benefits from) dense batches, e.g. the outbox which doesn't
+// serialize the selection vectors. For the cheap operators like projections
+// carrying the selection vector is usually faster than the compaction (see
+// BenchmarkDeselectorBeforeProjections).
 type deselectorOp struct {
 	colexecop.OneInputHelper
 	colexecop.NonExplainable
 	allocator  *colmem.Allocator
 	inputTypes []*types.T
+	copiers    []VecCopier
 
 	output coldata.Batch
 }
@@ -43,6 +50,7 @@ func NewDeselectorOp(
 		OneInputHelper: colexecop.MakeOneInputHelper(input),
 		allocator:      allocator,
 		inputTypes:     typs,
+		copiers:        NewVecCopiers(typs),
 	}
 }
 
@@ -69,17 +77,10 @@ func (p *deselectorOp) Next() coldata.Batch {
 	)
 	sel := batch.Selection()
 	p.allocator.PerformOperation(p.output.ColVecs(), func() {
-		for i := range p.inputTypes {
-			toCol := p.output.ColVec(i)
-			fromCol := batch.ColVec(i)
-			toCol.Copy(
-				coldata.CopySliceArgs{
-					SliceArgs: coldata.SliceArgs{
-						Src:       fromCol,
-						Sel:       sel,
-						SrcEndIdx: batch.Length(),
-					},
-				},
+		for i, copier := range p.copiers {
+			copier.Copy(
+				p.output.ColVec(i), batch.ColVec(i), sel,
+				0 /* destIdx */, 0 /* srcStartIdx */, batch.Length(), /* srcEndIdx */
 			)
 		}
 	})
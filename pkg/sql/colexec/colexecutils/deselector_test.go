@@ -126,3 +126,73 @@ func BenchmarkDeselector(b *testing.B) {
 		}
 	}
 }
+
+// incrementOp is a test operator that increments the values in an Int64
+// column, paying attention to the selection vector. It models a projection
+// operator placed after the deselector.
+type incrementOp struct {
+	colexecop.OneInputHelper
+	colIdx int
+}
+
+var _ colexecop.Operator = &incrementOp{}
+
+func (o *incrementOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return batch
+	}
+	col := batch.ColVec(o.colIdx).Int64()
+	if sel := batch.Selection(); sel != nil {
+		for _, i := range sel[:n] {
+			col[i]++
+		}
+	} else {
+		_ = col[n-1]
+		for i := 0; i < n; i++ {
+			col[i]++
+		}
+	}
+	return batch
+}
+
+// BenchmarkDeselectorBeforeProjections compares carrying the selection vector
+// through a chain of projections against compacting the batches with the
+// deselector before the projections. The compaction pays off when the batches
+// are sparse and many operators process them downstream.
+func BenchmarkDeselectorBeforeProjections(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	rng, _ := randutil.NewPseudoRand()
+	ctx := context.Background()
+
+	typs := []*types.T{types.Int}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for _, probOfOmitting := range []float64{0.1, 0.5, 0.9} {
+		sel := coldatatestutils.RandomSel(rng, coldata.BatchSize(), probOfOmitting)
+		for _, numProjections := range []int{1, 4, 16} {
+			for _, deselect := range []bool{false, true} {
+				b.Run(fmt.Sprintf("selectivity=%.1f/projections=%d/deselect=%t", 1-probOfOmitting, numProjections, deselect), func(b *testing.B) {
+					batch.SetSelection(true)
+					copy(batch.Selection(), sel)
+					batch.SetLength(len(sel))
+					input := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+					var op colexecop.Operator = input
+					if deselect {
+						op = NewDeselectorOp(testAllocator, op, typs)
+					}
+					for i := 0; i < numProjections; i++ {
+						op = &incrementOp{OneInputHelper: colexecop.MakeOneInputHelper(op)}
+					}
+					op.Init(ctx)
+					// We're measuring the amount of data that is not selected out.
+					b.SetBytes(int64(8 * len(sel)))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						op.Next()
+					}
+				})
+			}
+		}
+	}
+}
@@ -175,7 +175,8 @@ var _ colexecop.Operator = &BatchSchemaSubsetEnforcer{}
 // NewBatchSchemaSubsetEnforcer creates a new BatchSchemaSubsetEnforcer.
 // - subsetStartIdx and subsetEndIdx define the boundaries of the range of
 // columns that the projecting operator and its internal projecting operators
-// own.
+// own. The range can be empty if the internal operators don't append any
+// columns.
 func NewBatchSchemaSubsetEnforcer(
 	allocator *colmem.Allocator,
 	input colexecop.Operator,
@@ -193,8 +194,8 @@ func NewBatchSchemaSubsetEnforcer(
 
 // Init implements the colexecop.Operator interface.
 func (e *BatchSchemaSubsetEnforcer) Init(ctx context.Context) {
-	if e.subsetStartIdx >= e.subsetEndIdx {
-		colexecerror.InternalError(errors.AssertionFailedf("unexpectedly subsetStartIdx is not less than subsetEndIdx"))
+	if e.subsetStartIdx > e.subsetEndIdx {
+		colexecerror.InternalError(errors.AssertionFailedf("unexpectedly subsetStartIdx is greater than subsetEndIdx"))
 	}
 	e.OneInputInitCloserHelper.Init(ctx)
 }
@@ -0,0 +1,199 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecutils
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// sizeOfOffset is the size of a single offset of the variable-width values
+	// in the serialized representation.
+	sizeOfOffset = 4
+	// sizeOfInterval is the size of a serialized interval (nanos, months, and
+	// days encoded as int64s).
+	sizeOfInterval = 24
+	// sizeOfTimestamp is the size of a timestamp serialized with
+	// time.MarshalBinary when the offset of its location is a whole number of
+	// minutes (otherwise, one more byte is used).
+	sizeOfTimestamp = 15
+)
+
+// RowSizeEstimator estimates the size of the rows of batches when serialized
+// in the Arrow format used by the vectorized engine to send the batches over
+// the network and to spill them to disk (see colserde.ArrowBatchConverter).
+//
+// The estimate includes the values of all columns and the offsets of the
+// variable-width values, but it doesn't include the null bitmaps, the
+// padding, or the metadata which are amortized across the whole batch. Note
+// that the booleans are estimated to take up one byte each although they are
+// serialized as a bitmap.
+type RowSizeEstimator struct {
+	typs []*types.T
+	// scratch is reused for marshaling the values (decimals and the
+	// datum-backed values) whose serialized size is not known otherwise.
+	scratch []byte
+}
+
+// NewRowSizeEstimator returns a new RowSizeEstimator for the batches with the
+// schema typs. Only the first len(typs) columns of the batches are considered.
+func NewRowSizeEstimator(typs []*types.T) *RowSizeEstimator {
+	return &RowSizeEstimator{typs: typs}
+}
+
+// RowSize returns the estimated serialized size of the row at position rowIdx
+// of the batch. Note that rowIdx is the position in the vectors, so the
+// selection vector must be applied by the caller.
+func (e *RowSizeEstimator) RowSize(batch coldata.Batch, rowIdx int) int64 {
+	var size int64
+	for colIdx := range e.typs {
+		size += e.valueSize(batch.ColVec(colIdx), rowIdx)
+	}
+	return size
+}
+
+// BatchSize returns the estimated serialized size of all rows of the batch
+// that are selected (i.e. the sum of RowSize over all rows).
+func (e *RowSizeEstimator) BatchSize(batch coldata.Batch) int64 {
+	n := batch.Length()
+	var size int64
+	if sel := batch.Selection(); sel != nil {
+		for _, rowIdx := range sel[:n] {
+			size += e.RowSize(batch, rowIdx)
+		}
+	} else {
+		for rowIdx := 0; rowIdx < n; rowIdx++ {
+			size += e.RowSize(batch, rowIdx)
+		}
+	}
+	return size
+}
+
+// valueSize returns the estimated serialized size of the value at position
+// rowIdx of vec.
+func (e *RowSizeEstimator) valueSize(vec coldata.Vec, rowIdx int) int64 {
+	switch vec.CanonicalTypeFamily() {
+	case types.BoolFamily:
+		return 1
+	case types.IntFamily:
+		switch vec.Type().Width() {
+		case 16:
+			return 2
+		case 32:
+			return 4
+		default:
+			return 8
+		}
+	case types.FloatFamily:
+		return 8
+	case types.BytesFamily:
+		// Note that the NULL values are serialized as they are stored in the
+		// vector (which is usually empty).
+		return sizeOfOffset + int64(len(vec.Bytes().Get(rowIdx)))
+	case types.JsonFamily:
+		return sizeOfOffset + int64(len(vec.JSON().Bytes.Get(rowIdx)))
+	}
+	// All other types are variable-width and their NULL values are not
+	// serialized.
+	if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(rowIdx) {
+		return sizeOfOffset
+	}
+	switch vec.CanonicalTypeFamily() {
+	case types.DecimalFamily:
+		d := &vec.Decimal()[rowIdx]
+		e.scratch = d.Append(e.scratch[:0], 'G')
+		return sizeOfOffset + int64(len(e.scratch))
+	case types.IntervalFamily:
+		return sizeOfOffset + sizeOfInterval
+	case types.TimestampTZFamily:
+		return sizeOfOffset + timestampSize(vec.Timestamp()[rowIdx])
+	case typeconv.DatumVecCanonicalTypeFamily:
+		var err error
+		e.scratch, err = vec.Datum().MarshalAt(e.scratch[:0], rowIdx)
+		if err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		return sizeOfOffset + int64(len(e.scratch))
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unhandled type %s", vec.Type()))
+	// This code is unreachable, but the compiler cannot infer that.
+	return 0
+}
+
+// timestampSize returns the size of t serialized with time.MarshalBinary.
+func timestampSize(t time.Time) int64 {
+	if t.Location() == time.UTC {
+		return sizeOfTimestamp
+	}
+	if _, offset := t.Zone(); offset%60 != 0 {
+		return sizeOfTimestamp + 1
+	}
+	return sizeOfTimestamp
+}
+
+// NewRowSizeOp returns an operator that projects the estimated serialized size
+// of each row of the input (computed over the columns with the schema typs)
+// into the Int column at position outputIdx. See RowSizeEstimator for the
+// details of the estimate.
+func NewRowSizeOp(
+	allocator *colmem.Allocator, input colexecop.Operator, typs []*types.T, outputIdx int,
+) colexecop.Operator {
+	input = NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+	return &rowSizeOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		estimator:      NewRowSizeEstimator(typs),
+		outputIdx:      outputIdx,
+	}
+}
+
+type rowSizeOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	estimator *RowSizeEstimator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &rowSizeOp{}
+
+func (r *rowSizeOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	outputVec := batch.ColVec(r.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Int64()
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			outputCol[rowIdx] = r.estimator.RowSize(batch, rowIdx)
+		}
+	})
+	return batch
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/col/colserde"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRowSizeEstimator verifies that the estimated size of the rows matches
+// the size of the values and the offsets in the Arrow serialization.
+func TestRowSizeEstimator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{
+		types.Bool, types.Int2, types.Int4, types.Int, types.Float, types.Decimal,
+		types.String, types.Bytes, types.Jsonb, types.Uuid, types.Date,
+		types.Interval, types.Timestamp, types.TimestampTZ, types.INet,
+		types.MakeArray(types.Int),
+	}
+	for i := 0; i < 10; i++ {
+		typs = append(typs, randgen.RandType(rng))
+	}
+	for _, typ := range typs {
+		t.Run(typ.String(), func(t *testing.T) {
+			capacity := 1 + rng.Intn(coldata.BatchSize())
+			batch := coldatatestutils.RandomBatch(
+				testAllocator, rng, []*types.T{typ}, capacity, 0 /* length */, rng.Float64(),
+			)
+			n := batch.Length()
+			estimator := NewRowSizeEstimator([]*types.T{typ})
+			var estimate int64
+			for rowIdx := 0; rowIdx < n; rowIdx++ {
+				estimate += estimator.RowSize(batch, rowIdx)
+			}
+			require.Equal(t, estimate, estimator.BatchSize(batch))
+
+			c, err := colserde.NewArrowBatchConverter([]*types.T{typ})
+			require.NoError(t, err)
+			data, err := c.BatchToArrow(batch)
+			require.NoError(t, err)
+			var actual int64
+			if typ.Family() == types.BoolFamily {
+				// The booleans are serialized as a bitmap, but we estimate
+				// them to take up one byte each.
+				actual = int64(n)
+			} else {
+				// The first buffer is the null bitmap which isn't included in
+				// the estimate.
+				buffers := data[0].Buffers()
+				for _, buf := range buffers[1:] {
+					actual += int64(buf.Len())
+				}
+				if len(buffers) == 3 {
+					// The variable-width values have one more offset than
+					// the number of values.
+					actual -= sizeOfOffset
+				}
+			}
+			require.Equal(t, actual, estimate)
+
+			// Only the selected rows are included in the batch-level total.
+			sel := coldatatestutils.RandomSel(rng, n, rng.Float64())
+			var selEstimate int64
+			for _, rowIdx := range sel {
+				selEstimate += estimator.RowSize(batch, rowIdx)
+			}
+			batch.SetSelection(true)
+			copy(batch.Selection(), sel)
+			batch.SetLength(len(sel))
+			require.Equal(t, selEstimate, estimator.BatchSize(batch))
+		})
+	}
+}
+
+func TestRowSizeEstimatorTimestampOffset(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The locations with the offsets that are not a whole number of minutes
+	// take up one more byte when serialized.
+	ts := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, loc := range []*time.Location{
+		time.UTC, time.FixedZone("", 3600), time.FixedZone("", -5400), time.FixedZone("", 3601),
+	} {
+		marshaled, err := ts.In(loc).MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, int64(len(marshaled)), timestampSize(ts.In(loc)))
+	}
+}
+
+func TestRowSizeOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	typs := []*types.T{types.Int, types.String, types.Decimal}
+	tuples := colexectestutils.Tuples{
+		{1, "", 1.5},
+		{2, "abc", nil},
+		{nil, "xy", -12345.678},
+		{nil, "abcdefgh", 0.0},
+	}
+	// Ints take up 8 bytes (even if NULL), strings take up the offset and
+	// their length, and decimals take up the offset and the length of their
+	// text representation (NULLs take up only the offset). Note that the
+	// decimals are created from their %f representation in the tests, so they
+	// have six digits after the point.
+	expected := colexectestutils.Tuples{
+		{1, "", 1.5, 8 + 4 + 4 + len("1.500000")},
+		{2, "abc", nil, 8 + 4 + 3 + 4},
+		{nil, "xy", -12345.678, 8 + 4 + 2 + 4 + len("-12345.678000")},
+		{nil, "abcdefgh", 0.0, 8 + 4 + 8 + 4 + len("0.000000")},
+	}
+	colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return NewRowSizeOp(testAllocator, input[0], typs, len(typs)), nil
+		})
+}
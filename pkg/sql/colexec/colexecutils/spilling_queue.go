@@ -35,6 +35,7 @@ type SpillingQueue struct {
 	maxMemoryLimit     int64
 
 	typs             []*types.T
+	copiers          []VecCopier
 	items            []coldata.Batch
 	curHeadIdx       int
 	curTailIdx       int
@@ -115,6 +116,7 @@ func NewSpillingQueue(args *NewSpillingQueueArgs) *SpillingQueue {
 		unlimitedAllocator: args.UnlimitedAllocator,
 		maxMemoryLimit:     args.MemoryLimit,
 		typs:               args.Types,
+		copiers:            NewVecCopiers(args.Types),
 		items:              items,
 		diskQueueCfg:       args.DiskQueueCfg,
 		fdSemaphore:        args.FDSemaphore,
@@ -188,15 +190,10 @@ func (q *SpillingQueue) Enqueue(ctx context.Context, batch coldata.Batch) {
 				q.typs, q.diskQueueDeselectionScratch, n, maxBatchMemSize,
 			)
 			q.unlimitedAllocator.PerformOperation(q.diskQueueDeselectionScratch.ColVecs(), func() {
-				for i := range q.typs {
-					q.diskQueueDeselectionScratch.ColVec(i).Copy(
-						coldata.CopySliceArgs{
-							SliceArgs: coldata.SliceArgs{
-								Src:       batch.ColVec(i),
-								Sel:       sel,
-								SrcEndIdx: n,
-							},
-						},
+				for i, copier := range q.copiers {
+					copier.Copy(
+						q.diskQueueDeselectionScratch.ColVec(i), batch.ColVec(i), sel,
+						0 /* destIdx */, 0 /* srcStartIdx */, n, /* srcEndIdx */
 					)
 				}
 				q.diskQueueDeselectionScratch.SetLength(n)
@@ -243,17 +240,10 @@ func (q *SpillingQueue) Enqueue(ctx context.Context, batch coldata.Batch) {
 				alreadyCopied = n
 			}
 			q.unlimitedAllocator.PerformOperation(tailBatch.ColVecs(), func() {
-				for i := range q.typs {
-					tailBatch.ColVec(i).Copy(
-						coldata.CopySliceArgs{
-							SliceArgs: coldata.SliceArgs{
-								Src:         batch.ColVec(i),
-								Sel:         batch.Selection(),
-								DestIdx:     l,
-								SrcStartIdx: 0,
-								SrcEndIdx:   alreadyCopied,
-							},
-						},
+				for i, copier := range q.copiers {
+					copier.Copy(
+						tailBatch.ColVec(i), batch.ColVec(i), batch.Selection(),
+						l /* destIdx */, 0 /* srcStartIdx */, alreadyCopied, /* srcEndIdx */
 					)
 				}
 				tailBatch.SetLength(l + alreadyCopied)
@@ -294,16 +284,10 @@ func (q *SpillingQueue) Enqueue(ctx context.Context, batch coldata.Batch) {
 		math.MaxInt64, /* maxBatchMemSize */
 	)
 	q.unlimitedAllocator.PerformOperation(newBatch.ColVecs(), func() {
-		for i := range q.typs {
-			newBatch.ColVec(i).Copy(
-				coldata.CopySliceArgs{
-					SliceArgs: coldata.SliceArgs{
-						Src:         batch.ColVec(i),
-						Sel:         batch.Selection(),
-						SrcStartIdx: alreadyCopied,
-						SrcEndIdx:   n,
-					},
-				},
+		for i, copier := range q.copiers {
+			copier.Copy(
+				newBatch.ColVec(i), batch.ColVec(i), batch.Selection(),
+				0 /* destIdx */, alreadyCopied /* srcStartIdx */, n, /* srcEndIdx */
 			)
 		}
 		newBatch.SetLength(n - alreadyCopied)
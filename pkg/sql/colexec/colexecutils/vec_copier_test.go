@@ -0,0 +1,113 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecutils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// TestVecCopier verifies that the specialized VecCopier produces the same
+// results as the generic coldata.Vec.Copy.
+func TestVecCopier(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const numRuns = 10
+	for run := 0; run < numRuns; run++ {
+		typs := randgen.RandColumnTypes(rng, 1+rng.Intn(4))
+		// Always include Int and Bytes which exercise the sliceable and the
+		// flat bytes code paths.
+		typs = append(typs, types.Int, types.Bytes)
+		copiers := NewVecCopiers(typs)
+		n := 1 + rng.Intn(coldata.BatchSize())
+		nullProbability := rng.Float64()
+		src := coldatatestutils.RandomBatch(testAllocator, rng, typs, n, 0 /* length */, nullProbability)
+		var sel []int
+		if rng.Float64() < 0.5 {
+			sel = coldatatestutils.RandomSel(rng, n, rng.Float64())
+			if len(sel) == 0 {
+				sel = []int{0}
+			}
+		}
+		numSrcTuples := n
+		if sel != nil {
+			numSrcTuples = len(sel)
+		}
+		srcStartIdx := rng.Intn(numSrcTuples)
+		srcEndIdx := srcStartIdx + 1 + rng.Intn(numSrcTuples-srcStartIdx)
+		destIdx := rng.Intn(coldata.BatchSize())
+		outputLen := destIdx + srcEndIdx - srcStartIdx
+		expected := testAllocator.NewMemBatchWithFixedCapacity(typs, outputLen)
+		actual := testAllocator.NewMemBatchWithFixedCapacity(typs, outputLen)
+		for i := range typs {
+			expected.ColVec(i).Copy(coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         src.ColVec(i),
+					Sel:         sel,
+					DestIdx:     destIdx,
+					SrcStartIdx: srcStartIdx,
+					SrcEndIdx:   srcEndIdx,
+				},
+			})
+			copiers[i].Copy(actual.ColVec(i), src.ColVec(i), sel, destIdx, srcStartIdx, srcEndIdx)
+		}
+		expected.SetLength(outputLen)
+		actual.SetLength(outputLen)
+		coldata.AssertEquivalentBatches(t, expected, actual)
+	}
+}
+
+func BenchmarkVecCopier(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	rng, _ := randutil.NewPseudoRand()
+
+	for _, typ := range []*types.T{types.Int, types.Bytes} {
+		src := coldatatestutils.RandomBatch(
+			testAllocator, rng, []*types.T{typ}, coldata.BatchSize(), 0 /* length */, 0.1, /* nullProbability */
+		)
+		srcVec := src.ColVec(0)
+		sel := coldatatestutils.RandomSel(rng, coldata.BatchSize(), 0.5 /* probOfOmitting */)
+		dstBatch := testAllocator.NewMemBatchWithMaxCapacity([]*types.T{typ})
+		dst := dstBatch.ColVec(0)
+		copier := NewVecCopier(typ)
+		for _, specialized := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/specialized=%t", typ, specialized), func(b *testing.B) {
+				b.SetBytes(int64(len(sel) * 8))
+				for i := 0; i < b.N; i++ {
+					// Flat bytes don't allow for overwriting the values, so we
+					// have to reset the output on every iteration.
+					dstBatch.ResetInternalBatch()
+					if specialized {
+						copier.Copy(dst, srcVec, sel, 0 /* destIdx */, 0 /* srcStartIdx */, len(sel) /* srcEndIdx */)
+					} else {
+						dst.Copy(coldata.CopySliceArgs{
+							SliceArgs: coldata.SliceArgs{
+								Src:       srcVec,
+								Sel:       sel,
+								SrcEndIdx: len(sel),
+							},
+						})
+					}
+				}
+			})
+		}
+	}
+}
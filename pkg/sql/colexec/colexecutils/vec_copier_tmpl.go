@@ -0,0 +1,165 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for vec_copier.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecutils
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ json.JSON
+)
+
+// {{/*
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+// */}}
+
+// VecCopier copies tuples from one vector into another. Every implementation
+// is specialized for a single type, so the type switch is performed only once
+// when the copier is created rather than on every call, and the copying loop
+// is monomorphic.
+type VecCopier interface {
+	// Copy copies the tuples with indices in range [srcStartIdx, srcEndIdx)
+	// from src into dst starting at position destIdx. If sel is non-nil, it
+	// is applied to src, and the selected tuples are written into dst densely.
+	// The nulls of the overwritten range of dst are updated accordingly.
+	// dst must have enough capacity for all of the copied tuples, and for
+	// Bytes-like types, the tuples must be copied in the increasing order of
+	// destIdx (which is required by the flat bytes invariant).
+	// NOTE: this does *not* perform memory accounting.
+	Copy(dst, src coldata.Vec, sel []int, destIdx, srcStartIdx, srcEndIdx int)
+}
+
+// NewVecCopier returns a VecCopier specialized for the type t.
+func NewVecCopier(t *types.T) VecCopier {
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			return vecCopier_TYPE{}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported type %s for VecCopier", t))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+// NewVecCopiers returns a VecCopier for each of the types in typs.
+func NewVecCopiers(typs []*types.T) []VecCopier {
+	copiers := make([]VecCopier, len(typs))
+	for i, t := range typs {
+		copiers[i] = NewVecCopier(t)
+	}
+	return copiers
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+type vecCopier_TYPE struct{}
+
+var _ VecCopier = vecCopier_TYPE{}
+
+func (vecCopier_TYPE) Copy(dst, src coldata.Vec, sel []int, destIdx, srcStartIdx, srcEndIdx int) {
+	if srcStartIdx == srcEndIdx {
+		// Nothing to copy, so return early.
+		return
+	}
+	if sel == nil {
+		// Without a selection vector the copy of the values as well as of the
+		// null bitmap boils down to copying contiguous ranges, which the
+		// vector already does efficiently.
+		dst.Copy(coldata.CopySliceArgs{
+			SliceArgs: coldata.SliceArgs{
+				Src:         src,
+				DestIdx:     destIdx,
+				SrcStartIdx: srcStartIdx,
+				SrcEndIdx:   srcEndIdx,
+			},
+		})
+		return
+	}
+	sel = sel[srcStartIdx:srcEndIdx]
+	n := len(sel)
+	dstNulls := dst.Nulls()
+	// We're about to overwrite this entire range, so unset all the nulls.
+	dstNulls.UnsetNullRange(destIdx, destIdx+n)
+	fromCol := src.TemplateType()
+	toCol := dst.TemplateType()
+	// {{if .Sliceable}}
+	toCol = toCol[destIdx:]
+	_ = toCol[n-1]
+	// {{end}}
+	if src.MaybeHasNulls() {
+		srcNulls := src.Nulls()
+		for i := 0; i < n; i++ {
+			//gcassert:bce
+			selIdx := sel[i]
+			if srcNulls.NullAt(selIdx) {
+				dstNulls.SetNull(i + destIdx)
+				continue
+			}
+			v := fromCol.Get(selIdx)
+			// {{if .Sliceable}}
+			//gcassert:bce
+			execgen.SET(toCol, i, v)
+			// {{else}}
+			execgen.SET(toCol, i+destIdx, v)
+			// {{end}}
+		}
+		return
+	}
+	for i := 0; i < n; i++ {
+		//gcassert:bce
+		selIdx := sel[i]
+		v := fromCol.Get(selIdx)
+		// {{if .Sliceable}}
+		//gcassert:bce
+		execgen.SET(toCol, i, v)
+		// {{else}}
+		execgen.SET(toCol, i+destIdx, v)
+		// {{end}}
+	}
+}
+
+// {{end}}
+// {{end}}
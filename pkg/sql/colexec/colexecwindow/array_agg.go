@@ -0,0 +1,295 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/marusama/semaphore"
+)
+
+// NewArrayAggOperator creates a new Operator that computes ARRAY_AGG aggregate
+// function used as a window function over the frame that spans the whole
+// partition. This is how the optimizer plans ARRAY_AGG with an ORDER BY clause
+// within the aggregate, so the input must already be sorted on the columns
+// from the PARTITION BY clause followed by the columns from the ORDER BY
+// clause. argIdx specifies the column that is aggregated, and outputColIdx
+// specifies in which coldata.Vec the operator should put its output (if there
+// is no such column, a new column is appended).
+func NewArrayAggOperator(
+	unlimitedAllocator *colmem.Allocator,
+	memoryLimit int64,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	fdSemaphore semaphore.Semaphore,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	argIdx int,
+	outputColIdx int,
+	partitionColIdx int,
+	diskAcc *mon.BoundAccount,
+) colexecop.Operator {
+	return &arrayAggOp{
+		OneInputHelper:  colexecop.MakeOneInputHelper(input),
+		allocator:       unlimitedAllocator,
+		memoryLimit:     memoryLimit,
+		diskQueueCfg:    diskQueueCfg,
+		fdSemaphore:     fdSemaphore,
+		inputTypes:      inputTypes,
+		argIdx:          argIdx,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+		diskAcc:         diskAcc,
+	}
+}
+
+type arrayAggState int
+
+const (
+	// arrayAggBuffering is the state in which arrayAggOp buffers the tuples
+	// of the current partition using SpillingQueue and appends the values of
+	// the argument column to the array. Once the first tuple of the next
+	// partition is seen or a zero-length batch is received, the operator
+	// transitions to arrayAggEmitting state.
+	arrayAggBuffering arrayAggState = iota
+	// arrayAggEmitting is the state in which arrayAggOp emits the buffered
+	// tuples of the current partition with the array in the output column.
+	// Once all of them have been emitted, the operator transitions back to
+	// arrayAggBuffering state (or to arrayAggFinished state if the input has
+	// been exhausted).
+	arrayAggEmitting
+	// arrayAggFinished is the state in which arrayAggOp closes any non-closed
+	// disk resources and emits the zero-length batch.
+	arrayAggFinished
+)
+
+type arrayAggOp struct {
+	colexecop.OneInputHelper
+	colexecop.CloserHelper
+
+	allocator       *colmem.Allocator
+	memoryLimit     int64
+	diskQueueCfg    colcontainer.DiskQueueCfg
+	fdSemaphore     semaphore.Semaphore
+	inputTypes      []*types.T
+	argIdx          int
+	outputColIdx    int
+	partitionColIdx int
+	diskAcc         *mon.BoundAccount
+
+	state arrayAggState
+	// batch is the last batch read from the input, and batchIdx is the
+	// position (before applying the selection vector) of the first tuple in
+	// it that hasn't been buffered yet.
+	batch     coldata.Batch
+	batchIdx  int
+	inputDone bool
+
+	// array is the result for the current partition, and arrayMemUsage is the
+	// amount of memory that has been registered with the allocator for its
+	// elements.
+	array         *tree.DArray
+	arrayMemUsage int64
+	datums        tree.Datums
+	da            rowenc.DatumAlloc
+
+	bufferedTuples *colexecutils.SpillingQueue
+	scratch        coldata.Batch
+	output         coldata.Batch
+}
+
+var _ colexecop.ClosableOperator = &arrayAggOp{}
+
+func (a *arrayAggOp) Init(ctx context.Context) {
+	if !a.InitHelper.Init(ctx) {
+		return
+	}
+	a.Input.Init(a.Ctx)
+	a.state = arrayAggBuffering
+	a.bufferedTuples = colexecutils.NewSpillingQueue(
+		&colexecutils.NewSpillingQueueArgs{
+			UnlimitedAllocator: a.allocator,
+			Types:              a.inputTypes,
+			MemoryLimit:        a.memoryLimit,
+			DiskQueueCfg:       a.diskQueueCfg,
+			FDSemaphore:        a.fdSemaphore,
+			DiskAcc:            a.diskAcc,
+		},
+	)
+	argType := a.inputTypes[a.argIdx]
+	a.array = tree.NewDArray(argType)
+	a.scratch = a.allocator.NewMemBatchWithFixedCapacity(a.inputTypes, coldata.BatchSize())
+	outputTypes := make([]*types.T, len(a.inputTypes)+1)
+	copy(outputTypes, a.inputTypes)
+	outputTypes[len(a.inputTypes)] = types.MakeArray(argType)
+	a.output = a.allocator.NewMemBatchWithFixedCapacity(outputTypes, coldata.BatchSize())
+}
+
+func (a *arrayAggOp) Next() coldata.Batch {
+	for {
+		switch a.state {
+		case arrayAggBuffering:
+			if a.batch == nil || a.batchIdx == a.batch.Length() {
+				a.batch = a.Input.Next()
+				a.batchIdx = 0
+				if a.batch.Length() == 0 {
+					a.inputDone = true
+					if a.array.Len() == 0 {
+						// The input is empty.
+						a.state = arrayAggFinished
+						continue
+					}
+					a.bufferedTuples.Enqueue(a.Ctx, coldata.ZeroBatch)
+					a.state = arrayAggEmitting
+					continue
+				}
+			}
+			n := a.batch.Length()
+			partitionEndIdx := n
+			if a.partitionColIdx != tree.NoColumnIdx {
+				partitionCol := a.batch.ColVec(a.partitionColIdx).Bool()
+				sel := a.batch.Selection()
+				for i := a.batchIdx; i < n; i++ {
+					tupleIdx := i
+					if sel != nil {
+						tupleIdx = sel[i]
+					}
+					// The first tuple of the current partition doesn't end
+					// it.
+					if partitionCol[tupleIdx] && (i > a.batchIdx || a.array.Len() > 0) {
+						partitionEndIdx = i
+						break
+					}
+				}
+			}
+			a.bufferTuples(a.batchIdx, partitionEndIdx)
+			a.batchIdx = partitionEndIdx
+			if partitionEndIdx < n {
+				// The current partition has ended within the batch.
+				a.bufferedTuples.Enqueue(a.Ctx, coldata.ZeroBatch)
+				a.state = arrayAggEmitting
+			}
+			continue
+
+		case arrayAggEmitting:
+			batch, err := a.bufferedTuples.Dequeue(a.Ctx)
+			if err != nil {
+				colexecerror.InternalError(err)
+			}
+			n := batch.Length()
+			if n == 0 {
+				// All tuples of the current partition have been emitted, so
+				// we move onto the next one.
+				a.bufferedTuples.Reset(a.Ctx)
+				a.allocator.ReleaseMemory(a.arrayMemUsage)
+				a.arrayMemUsage = 0
+				a.array = tree.NewDArray(a.inputTypes[a.argIdx])
+				a.state = arrayAggBuffering
+				if a.inputDone {
+					a.state = arrayAggFinished
+				}
+				continue
+			}
+			a.output.ResetInternalBatch()
+			a.allocator.PerformOperation(a.output.ColVecs(), func() {
+				for colIdx, vec := range a.output.ColVecs()[:len(a.inputTypes)] {
+					vec.Copy(
+						coldata.CopySliceArgs{
+							SliceArgs: coldata.SliceArgs{
+								Src:       batch.ColVec(colIdx),
+								SrcEndIdx: n,
+							},
+						},
+					)
+				}
+				// All tuples of the partition share the same array.
+				outputCol := a.output.ColVec(a.outputColIdx).Datum()
+				for i := 0; i < n; i++ {
+					outputCol.Set(i, a.array)
+				}
+			})
+			a.output.SetLength(n)
+			return a.output
+
+		case arrayAggFinished:
+			if err := a.Close(a.Ctx); err != nil {
+				colexecerror.InternalError(err)
+			}
+			return coldata.ZeroBatch
+
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("array_agg operator in unhandled state"))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	}
+}
+
+// bufferTuples buffers the tuples of the current input batch in range
+// [startIdx, endIdx) (before applying the selection vector), all of which
+// belong to the current partition, and appends their values of the argument
+// column to the array.
+func (a *arrayAggOp) bufferTuples(startIdx, endIdx int) {
+	n := endIdx - startIdx
+	if n == 0 {
+		return
+	}
+	a.scratch.ResetInternalBatch()
+	a.allocator.PerformOperation(a.scratch.ColVecs(), func() {
+		for colIdx, vec := range a.scratch.ColVecs() {
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         a.batch.ColVec(colIdx),
+						Sel:         a.batch.Selection(),
+						SrcStartIdx: startIdx,
+						SrcEndIdx:   endIdx,
+					},
+				},
+			)
+		}
+	})
+	a.scratch.SetLength(n)
+	a.bufferedTuples.Enqueue(a.Ctx, a.scratch)
+
+	if cap(a.datums) < n {
+		a.datums = make(tree.Datums, n)
+	}
+	a.datums = a.datums[:n]
+	colconv.ColVecToDatum(a.datums, a.scratch.ColVec(a.argIdx), n, nil /* sel */, &a.da)
+	var memUsage int64
+	for _, d := range a.datums {
+		if err := a.array.Append(d); err != nil {
+			colexecerror.InternalError(err)
+		}
+		memUsage += int64(d.Size())
+	}
+	a.allocator.AdjustMemoryUsage(memUsage)
+	a.arrayMemUsage += memUsage
+}
+
+func (a *arrayAggOp) Close(ctx context.Context) error {
+	if !a.CloserHelper.Close() || a.bufferedTuples == nil {
+		return nil
+	}
+	return a.bufferedTuples.Close(ctx)
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/marusama/semaphore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayAgg(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	// The first column of the input tuples is the partition column which is
+	// true for the first tuple of each partition, and the second column is
+	// the argument of array_agg. The input is expected to be already sorted
+	// according to the ORDER BY clause within the aggregate, so the elements
+	// of the arrays must follow the input order.
+	for _, tc := range []struct {
+		desc        string
+		noPartition bool
+		tuples      colexectestutils.Tuples
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc: "partition boundaries",
+			tuples: colexectestutils.Tuples{
+				{true, 3}, {false, 1}, {false, 2},
+				{true, 5},
+				{true, 2}, {false, 4},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 3, "ARRAY[3,1,2]"}, {false, 1, "ARRAY[3,1,2]"}, {false, 2, "ARRAY[3,1,2]"},
+				{true, 5, "ARRAY[5]"},
+				{true, 2, "ARRAY[2,4]"}, {false, 4, "ARRAY[2,4]"},
+			},
+		},
+		{
+			desc: "nulls",
+			tuples: colexectestutils.Tuples{
+				{true, nil}, {false, 1}, {false, nil},
+				{true, nil},
+				{true, 2}, {false, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, nil, "ARRAY[NULL,1,NULL]"}, {false, 1, "ARRAY[NULL,1,NULL]"}, {false, nil, "ARRAY[NULL,1,NULL]"},
+				{true, nil, "ARRAY[NULL]"},
+				{true, 2, "ARRAY[2,NULL]"}, {false, nil, "ARRAY[2,NULL]"},
+			},
+		},
+		{
+			desc:        "no partition",
+			noPartition: true,
+			tuples: colexectestutils.Tuples{
+				{true, 4}, {false, nil}, {true, 1}, {false, 7},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 4, "ARRAY[4,NULL,1,7]"}, {false, nil, "ARRAY[4,NULL,1,7]"}, {true, 1, "ARRAY[4,NULL,1,7]"}, {false, 7, "ARRAY[4,NULL,1,7]"},
+			},
+		},
+	} {
+		for _, spillForced := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/spillForced=%t", tc.desc, spillForced), func(t *testing.T) {
+				typs := []*types.T{types.Bool, types.Int}
+				partitionColIdx := 0
+				if tc.noPartition {
+					partitionColIdx = tree.NoColumnIdx
+				}
+				memoryLimit := int64(1 << 20)
+				if spillForced {
+					memoryLimit = 1
+				}
+				var semsToCheck []semaphore.Semaphore
+				colexectestutils.RunTestsWithTyps(
+					t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs},
+					tc.expected, colexectestutils.OrderedVerifier,
+					func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+						// The spilling queue uses separate FDs for reading and writing.
+						sem := colexecop.NewTestingSemaphore(2)
+						semsToCheck = append(semsToCheck, sem)
+						return NewArrayAggOperator(
+							testAllocator, memoryLimit, queueCfg, sem, inputs[0], typs,
+							1 /* argIdx */, 2 /* outputColIdx */, partitionColIdx, testDiskAcc,
+						), nil
+					})
+				for i, sem := range semsToCheck {
+					require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
+				}
+			})
+		}
+	}
+}
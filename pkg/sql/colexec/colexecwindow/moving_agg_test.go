@@ -0,0 +1,525 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovingAvg(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	days := func(d int64) duration.Duration {
+		return duration.MakeDuration(0 /* nanos */, d, 0 /* months */)
+	}
+	// The first column of the input tuples is the partition column which is
+	// true for the first tuple of each partition, and the second column is
+	// the argument of the moving average.
+	for _, tc := range []struct {
+		desc        string
+		argType     *types.T
+		offset      uint64
+		noPartition bool
+		tuples      colexectestutils.Tuples
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc:    "partition boundaries",
+			argType: types.Int,
+			offset:  2,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 2}, {false, 3}, {false, 4},
+				{true, 5},
+				{true, 6}, {false, 7},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1.0}, {false, 2, 1.5}, {false, 3, 2.0}, {false, 4, 3.0},
+				{true, 5, 5.0},
+				{true, 6, 6.0}, {false, 7, 6.5},
+			},
+		},
+		{
+			desc:    "nulls",
+			argType: types.Int,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, nil}, {false, 1}, {false, nil}, {false, nil}, {false, 4},
+				{true, 3}, {false, nil},
+				{true, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, nil, nil}, {false, 1, 1.0}, {false, nil, 1.0}, {false, nil, nil}, {false, 4, 4.0},
+				{true, 3, 3.0}, {false, nil, 3.0},
+				{true, nil, nil},
+			},
+		},
+		{
+			desc:    "zero offset",
+			argType: types.Float,
+			offset:  0,
+			tuples: colexectestutils.Tuples{
+				{true, 1.5}, {false, nil}, {false, -2.5}, {true, 0.25},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.5, 1.5}, {false, nil, nil}, {false, -2.5, -2.5}, {true, 0.25, 0.25},
+			},
+		},
+		{
+			desc:    "frame larger than partition",
+			argType: types.Decimal,
+			offset:  100,
+			tuples: colexectestutils.Tuples{
+				{true, 1.0}, {false, 2.0}, {false, nil}, {false, 4.5},
+				{true, 0.5},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.0, 1.0}, {false, 2.0, 1.5}, {false, nil, 1.5}, {false, 4.5, 2.5},
+				{true, 0.5, 0.5},
+			},
+		},
+		{
+			desc:    "int2",
+			argType: types.Int2,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 2}, {false, 4}, {true, -3},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1.0}, {false, 2, 1.5}, {false, 4, 3.0}, {true, -3, -3.0},
+			},
+		},
+		{
+			desc:    "intervals",
+			argType: types.Interval,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, days(1)}, {false, days(3)}, {false, days(5)}, {true, days(2)},
+			},
+			expected: colexectestutils.Tuples{
+				{true, days(1), days(1)}, {false, days(3), days(2)}, {false, days(5), days(4)}, {true, days(2), days(2)},
+			},
+		},
+		{
+			desc:        "no partition",
+			argType:     types.Int,
+			offset:      1,
+			noPartition: true,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 3}, {true, 5}, {false, nil}, {true, 2},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1.0}, {false, 3, 2.0}, {true, 5, 4.0}, {false, nil, 5.0}, {true, 2, 2.0},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			typs := []*types.T{types.Bool, tc.argType}
+			partitionColIdx := 0
+			if tc.noPartition {
+				partitionColIdx = tree.NoColumnIdx
+			}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewMovingAggOperator(
+						testAllocator, inputs[0], typs, execinfrapb.Avg, 1 /* argColIdx */, 2, /* outputColIdx */
+						partitionColIdx, tree.NoColumnIdx /* peersColIdx */, tc.offset,
+						execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+					)
+				})
+		})
+	}
+}
+
+// TestMovingAvgRandomized verifies the moving average operator against the
+// naive operator that recomputes the average over the whole window frame for
+// every row.
+func TestMovingAvgRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const numRows = 500
+	typs := []*types.T{types.Bool, types.Float}
+	for _, offset := range []uint64{0, 1, 7, 100, numRows} {
+		for _, avgPartitionSize := range []int{1, 10, numRows} {
+			tuples := make(colexectestutils.Tuples, numRows)
+			for i := range tuples {
+				// Small integers are summed up exactly, so the incremental and
+				// the naive computations must produce the same results.
+				var arg interface{}
+				if rng.Float64() >= 0.2 {
+					arg = float64(rng.Intn(100) - 50)
+				}
+				tuples[i] = colexectestutils.Tuple{i == 0 || rng.Intn(avgPartitionSize) == 0, arg}
+			}
+			expected := make(colexectestutils.Tuples, numRows)
+			naive := naiveMovingAvg{frameSize: int(offset) + 1}
+			for i, tup := range tuples {
+				avg := naive.next(tup[0].(bool), tup[1])
+				expected[i] = colexectestutils.Tuple{tup[0], tup[1], avg}
+			}
+			t.Run(fmt.Sprintf("offset=%d/partitionSize=%d", offset, avgPartitionSize), func(t *testing.T) {
+				colexectestutils.RunTestsWithTyps(
+					t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+					expected, colexectestutils.OrderedVerifier,
+					func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+						return NewMovingAggOperator(
+							testAllocator, inputs[0], typs, execinfrapb.Avg, 1 /* argColIdx */, 2, /* outputColIdx */
+							0 /* partitionColIdx */, tree.NoColumnIdx /* peersColIdx */, offset,
+							execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+						)
+					})
+			})
+		}
+	}
+}
+
+// naiveMovingAvg computes the moving average of floats by keeping all values
+// of the current partition and summing up the whole window frame for every
+// row.
+type naiveMovingAvg struct {
+	frameSize int
+	values    []interface{}
+}
+
+func (n *naiveMovingAvg) next(newPartition bool, arg interface{}) interface{} {
+	if newPartition {
+		n.values = n.values[:0]
+	}
+	n.values = append(n.values, arg)
+	start := len(n.values) - n.frameSize
+	if start < 0 {
+		start = 0
+	}
+	var sum float64
+	var count int
+	for _, v := range n.values[start:] {
+		if v != nil {
+			sum += v.(float64)
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return sum / float64(count)
+}
+
+func TestMovingAggExclusion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The first column of the input tuples is the partition column, the
+	// second one is the peers column which is true for the first tuple of each
+	// peer group, and the third one is the argument of the aggregate. There are
+	// three peer groups (the first two tuples, the next three tuples and the
+	// last tuple).
+	tuples := colexectestutils.Tuples{
+		{true, true, 1}, {false, false, 2},
+		{false, true, 3}, {false, false, nil}, {false, false, 5},
+		{false, true, 6},
+	}
+	withOutput := func(outputs ...interface{}) colexectestutils.Tuples {
+		res := make(colexectestutils.Tuples, len(tuples))
+		for i := range tuples {
+			res[i] = append(colexectestutils.Tuple{}, tuples[i]...)
+			res[i] = append(res[i], outputs[i])
+		}
+		return res
+	}
+	// The window frame consists of the current row and three preceding rows.
+	const offset = 3
+	for _, tc := range []struct {
+		aggFn     execinfrapb.AggregatorSpec_Func
+		exclusion execinfrapb.WindowerSpec_Frame_Exclusion
+		expected  colexectestutils.Tuples
+	}{
+		{
+			aggFn:     execinfrapb.Sum,
+			exclusion: execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+			expected:  withOutput(1.0, 3.0, 6.0, 6.0, 10.0, 14.0),
+		},
+		{
+			aggFn:     execinfrapb.Sum,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_CURRENT_ROW,
+			expected:  withOutput(nil, 1.0, 3.0, 6.0, 5.0, 8.0),
+		},
+		{
+			aggFn:     execinfrapb.Sum,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP,
+			expected:  withOutput(nil, nil, 3.0, 3.0, 2.0, 8.0),
+		},
+		{
+			aggFn:     execinfrapb.Sum,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES,
+			expected:  withOutput(1.0, 2.0, 6.0, 3.0, 7.0, 14.0),
+		},
+		{
+			aggFn:     execinfrapb.Count,
+			exclusion: execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+			expected:  withOutput(1, 2, 3, 3, 3, 3),
+		},
+		{
+			aggFn:     execinfrapb.Count,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP,
+			expected:  withOutput(0, 0, 2, 2, 1, 2),
+		},
+		{
+			aggFn:     execinfrapb.Count,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES,
+			expected:  withOutput(1, 1, 3, 2, 2, 3),
+		},
+		{
+			aggFn:     execinfrapb.Avg,
+			exclusion: execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP,
+			expected:  withOutput(nil, nil, 1.5, 1.5, 2.0, 4.0),
+		},
+	} {
+		t.Run(fmt.Sprintf("%s/%s", tc.aggFn, tc.exclusion), func(t *testing.T) {
+			typs := []*types.T{types.Bool, types.Bool, types.Int}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewMovingAggOperator(
+						testAllocator, inputs[0], typs, tc.aggFn, 2 /* argColIdx */, 3, /* outputColIdx */
+						0 /* partitionColIdx */, 1 /* peersColIdx */, offset, tc.exclusion,
+					)
+				})
+		})
+	}
+}
+
+// TestMovingAggExclusionRandomized verifies the moving aggregate operators
+// with all frame exclusions against the naive computation that aggregates the
+// whole window frame for every row.
+func TestMovingAggExclusionRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const numRows = 300
+	typs := []*types.T{types.Bool, types.Bool, types.Float}
+	for _, offset := range []uint64{0, 1, 7, numRows} {
+		// The partitions and the peer groups are of 10 and 3 rows on average,
+		// respectively.
+		tuples := make(colexectestutils.Tuples, numRows)
+		for i := range tuples {
+			// Small integers are summed up exactly, so the incremental and the
+			// naive computations must produce the same results.
+			var arg interface{}
+			if rng.Float64() >= 0.2 {
+				arg = float64(rng.Intn(100) - 50)
+			}
+			newPartition := i == 0 || rng.Intn(10) == 0
+			newPeerGroup := newPartition || rng.Intn(3) == 0
+			tuples[i] = colexectestutils.Tuple{newPartition, newPeerGroup, arg}
+		}
+		for _, aggFn := range []execinfrapb.AggregatorSpec_Func{execinfrapb.Avg, execinfrapb.Sum, execinfrapb.Count} {
+			for _, exclusion := range []execinfrapb.WindowerSpec_Frame_Exclusion{
+				execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+				execinfrapb.WindowerSpec_Frame_EXCLUDE_CURRENT_ROW,
+				execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP,
+				execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES,
+			} {
+				expected := naiveMovingAgg(tuples, aggFn, int(offset), exclusion)
+				t.Run(fmt.Sprintf("offset=%d/%s/%s", offset, aggFn, exclusion), func(t *testing.T) {
+					colexectestutils.RunTestsWithTyps(
+						t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+						expected, colexectestutils.OrderedVerifier,
+						func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+							return NewMovingAggOperator(
+								testAllocator, inputs[0], typs, aggFn, 2 /* argColIdx */, 3, /* outputColIdx */
+								0 /* partitionColIdx */, 1 /* peersColIdx */, offset, exclusion,
+							)
+						})
+				})
+			}
+		}
+	}
+}
+
+// naiveMovingAgg returns the tuples extended with the result of the aggregate
+// function of floats over the ROWS BETWEEN offset PRECEDING AND CURRENT ROW
+// window frame with the given exclusion. The tuples are expected to contain
+// the partition column, the peers column and the argument column.
+func naiveMovingAgg(
+	tuples colexectestutils.Tuples,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	offset int,
+	exclusion execinfrapb.WindowerSpec_Frame_Exclusion,
+) colexectestutils.Tuples {
+	res := make(colexectestutils.Tuples, len(tuples))
+	var partitionStart, peerGroupStart int
+	for i, tup := range tuples {
+		if tup[0].(bool) {
+			partitionStart = i
+		}
+		if tup[1].(bool) {
+			peerGroupStart = i
+		}
+		frameStart := i - offset
+		if frameStart < partitionStart {
+			frameStart = partitionStart
+		}
+		var sum float64
+		var count int64
+		for j := frameStart; j <= i; j++ {
+			isPeer := j >= peerGroupStart
+			switch exclusion {
+			case execinfrapb.WindowerSpec_Frame_EXCLUDE_CURRENT_ROW:
+				if j == i {
+					continue
+				}
+			case execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP:
+				if isPeer {
+					continue
+				}
+			case execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES:
+				if isPeer && j != i {
+					continue
+				}
+			}
+			if v := tuples[j][2]; v != nil {
+				sum += v.(float64)
+				count++
+			}
+		}
+		var output interface{}
+		switch {
+		case aggFn == execinfrapb.Count:
+			output = count
+		case count == 0:
+		case aggFn == execinfrapb.Sum:
+			output = sum
+		default:
+			output = sum / float64(count)
+		}
+		res[i] = colexectestutils.Tuple{tup[0], tup[1], tup[2], output}
+	}
+	return res
+}
+
+// naiveMovingAvgOp is an operator that computes the moving average of floats
+// by recomputing the average over the whole window frame for every row. It is
+// used as the baseline in the benchmarks.
+type naiveMovingAvgOp struct {
+	colexecop.OneInputHelper
+	frameSize int
+	values    []float64
+	nulls     []bool
+}
+
+var _ colexecop.Operator = &naiveMovingAvgOp{}
+
+func (o *naiveMovingAvgOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	partitionCol := batch.ColVec(0).Bool()
+	argVec := batch.ColVec(1)
+	argCol, argNulls := argVec.Float64(), argVec.Nulls()
+	outputVec := batch.ColVec(2)
+	outputVec.Nulls().UnsetNulls()
+	outputCol := outputVec.Float64()
+	for i := 0; i < n; i++ {
+		if partitionCol[i] {
+			o.values, o.nulls = o.values[:0], o.nulls[:0]
+		}
+		o.values = append(o.values, argCol[i])
+		o.nulls = append(o.nulls, argNulls.NullAt(i))
+		start := len(o.values) - o.frameSize
+		if start < 0 {
+			start = 0
+		}
+		var sum float64
+		var count int
+		for j := start; j < len(o.values); j++ {
+			if !o.nulls[j] {
+				sum += o.values[j]
+				count++
+			}
+		}
+		if count == 0 {
+			outputVec.Nulls().SetNull(i)
+		} else {
+			outputCol[i] = sum / float64(count)
+		}
+	}
+	return batch
+}
+
+func BenchmarkMovingAvg(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+
+	const partitionSize = 4096
+	typs := []*types.T{types.Bool, types.Float, types.Float}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	partitionCol := batch.ColVec(0).Bool()
+	argCol := batch.ColVec(1).Float64()
+	for i := 0; i < coldata.BatchSize(); i++ {
+		partitionCol[i] = i%partitionSize == 0
+		argCol[i] = rng.Float64()
+		if rng.Float64() < 0.1 {
+			batch.ColVec(1).Nulls().SetNull(i)
+		}
+	}
+	batch.SetLength(coldata.BatchSize())
+	for _, offset := range []uint64{1, 16, 256} {
+		for _, naive := range []bool{false, true} {
+			b.Run(fmt.Sprintf("offset=%d/naive=%t", offset, naive), func(b *testing.B) {
+				source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+				var op colexecop.Operator
+				if naive {
+					op = &naiveMovingAvgOp{
+						OneInputHelper: colexecop.MakeOneInputHelper(source),
+						frameSize:      int(offset) + 1,
+					}
+				} else {
+					var err error
+					op, err = NewMovingAggOperator(
+						testAllocator, source, typs, execinfrapb.Avg, 1 /* argColIdx */, 2, /* outputColIdx */
+						0 /* partitionColIdx */, tree.NoColumnIdx /* peersColIdx */, offset,
+						execinfrapb.WindowerSpec_Frame_NO_EXCLUSION,
+					)
+					require.NoError(b, err)
+				}
+				op.Init(ctx)
+				b.SetBytes(int64(8 * coldata.BatchSize()))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					op.Next()
+				}
+			})
+		}
+	}
+}
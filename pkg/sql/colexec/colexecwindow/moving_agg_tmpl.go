@@ -0,0 +1,447 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for moving_agg.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecwindow
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ tree.AggType
+	_ = colexecerror.InternalError
+)
+
+// {{/*
+// Declarations to make the template compile properly
+
+// _ASSIGN_ADD is the template function for adding the second input to the
+// running sum in the first input. The third input is a scratch decimal.
+func _ASSIGN_ADD(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _ASSIGN_SUB is the template function for subtracting the second input from
+// the running sum in the first input. The third input is a scratch decimal.
+func _ASSIGN_SUB(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _ASSIGN_SUB_SUMS is the template function for assigning the first input to
+// the result of the subtraction of the third input (a running sum) from the
+// second input (another running sum).
+func _ASSIGN_SUB_SUMS(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _ASSIGN_DIV_INT64 is the template division function for assigning the first
+// input to the result of the second input / the third input, where the third
+// input is an int64.
+func _ASSIGN_DIV_INT64(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _COPY_SUM is the template function for copying the running sum in the
+// second input into the first input.
+func _COPY_SUM(_, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewMovingAggOperator creates a new Operator that computes the AVG, SUM or
+// COUNT aggregate function used as a window function over the ROWS BETWEEN
+// offset PRECEDING AND CURRENT ROW window frame. The aggregate is maintained
+// incrementally: the value of the row entering the frame is added to the
+// running sum while the value of the row leaving the frame is subtracted from
+// it. NULL values don't contribute to the aggregate, and if there are no
+// non-NULL values in the frame, the result of AVG and SUM is NULL while the
+// result of COUNT is zero.
+//
+// exclusion specifies which rows of the frame are excluded from the aggregate
+// (see EXCLUDE clause). EXCLUDE GROUP and EXCLUDE TIES require the peers
+// column at position peersColIdx which is true for the first tuple of each
+// peer group. outputColIdx specifies in which coldata.Vec the operator should
+// put its output (if there is no such column, a new column is appended).
+func NewMovingAggOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	argColIdx int,
+	outputColIdx int,
+	partitionColIdx int,
+	peersColIdx int,
+	offset uint64,
+	exclusion execinfrapb.WindowerSpec_Frame_Exclusion,
+) (colexecop.Operator, error) {
+	argType := inputTypes[argColIdx]
+	var outputType *types.T
+	switch aggFn {
+	case execinfrapb.Avg, execinfrapb.Sum:
+		outputType = argType
+		if argType.Family() == types.IntFamily {
+			// Average and sum of integers are decimals.
+			outputType = types.Decimal
+		}
+	case execinfrapb.Count:
+		outputType = types.Int
+	default:
+		return nil, errors.Errorf("unsupported moving aggregate function %s", aggFn)
+	}
+	switch exclusion {
+	case execinfrapb.WindowerSpec_Frame_NO_EXCLUSION, execinfrapb.WindowerSpec_Frame_EXCLUDE_CURRENT_ROW:
+		// The peers information is not needed. Note that for EXCLUDE CURRENT
+		// ROW every row is treated as if it were its own peer group.
+		peersColIdx = tree.NoColumnIdx
+	case execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP, execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES:
+		if peersColIdx == tree.NoColumnIdx {
+			return nil, errors.AssertionFailedf("peers column is required for %s", exclusion)
+		}
+	default:
+		return nil, errors.Errorf("unsupported frame exclusion %s", exclusion)
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputColIdx)
+	// The frame consists of the current row and offset preceding rows. Note
+	// that a frame of MaxInt64 rows can never be full, so we cap the frame
+	// size in order to not overflow.
+	frameSize := int64(math.MaxInt64)
+	if offset < math.MaxInt64 {
+		frameSize = int64(offset) + 1
+	}
+	base := movingAggBase{
+		OneInputHelper:  colexecop.MakeOneInputHelper(input),
+		allocator:       allocator,
+		aggFn:           aggFn,
+		exclusion:       exclusion,
+		argColIdx:       argColIdx,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+		peersColIdx:     peersColIdx,
+		frameSize:       int(frameSize),
+	}
+	switch argType.Family() {
+	// {{range .}}
+	case _TYPE_FAMILY:
+		switch argType.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			// {{with .Overload}}
+			return &movingAgg_TYPEOp{movingAggBase: base}, nil
+			// {{end}}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported moving aggregate type %s", argType)
+}
+
+// minMovingAggBufferSize is the initial size of the ring buffer of the moving
+// aggregate operators (unless the window frame is smaller).
+const minMovingAggBufferSize = 16
+
+// movingAggBase extracts common fields and common methods of the moving
+// aggregate operators. Note that it is not an operator itself and should not
+// be used directly.
+type movingAggBase struct {
+	colexecop.OneInputHelper
+	allocator       *colmem.Allocator
+	aggFn           execinfrapb.AggregatorSpec_Func
+	exclusion       execinfrapb.WindowerSpec_Frame_Exclusion
+	argColIdx       int
+	outputColIdx    int
+	partitionColIdx int
+	peersColIdx     int
+
+	// frameSize is the maximum number of rows in the window frame.
+	frameSize int
+	// head is the position of the oldest row of the window frame in the ring
+	// buffer, and numRows is the number of rows in the window frame.
+	head, numRows int
+	// bufferNulls tracks which rows in the ring buffer have NULL values.
+	bufferNulls []bool
+	// count is the number of non-NULL values in the window frame.
+	count int64
+	// peerRows is the number of rows in the window frame that belong to the
+	// peer group of the current row, and peerCount is the number of non-NULL
+	// values among them. Since the frame ends with the current row, these are
+	// always the newest peerRows rows of the frame. These are only maintained
+	// when some rows are excluded from the frame.
+	peerRows  int
+	peerCount int64
+}
+
+// newBufferSize returns the size of the ring buffer after it grows. The buffer
+// is doubled in size but never exceeds the size of the window frame.
+func (r *movingAggBase) newBufferSize() int {
+	newSize := 2 * len(r.bufferNulls)
+	if newSize < minMovingAggBufferSize {
+		newSize = minMovingAggBufferSize
+	}
+	if newSize > r.frameSize {
+		newSize = r.frameSize
+	}
+	return newSize
+}
+
+func (r *movingAggBase) growNulls(newSize int) {
+	r.allocator.AdjustMemoryUsage(int64(newSize - len(r.bufferNulls)))
+	newNulls := make([]bool, newSize)
+	copy(newNulls, r.bufferNulls)
+	r.bufferNulls = newNulls
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+// {{with .Overload}}
+
+type movingAgg_TYPEOp struct {
+	movingAggBase
+	// buffer is a ring buffer that contains the values of all rows in the
+	// current window frame. Its element at position i is only valid when
+	// bufferNulls[i] is false.
+	buffer []_GOTYPE
+	// sum is the running sum of all non-NULL values in the current window
+	// frame.
+	sum _RET_GOTYPE
+	// peerSum is the running sum of all non-NULL values of the rows in the
+	// window frame that belong to the peer group of the current row. It is
+	// only maintained when some rows are excluded from the frame.
+	peerSum _RET_GOTYPE
+	// result is the scratch space for the sum of the values that are not
+	// excluded from the window frame.
+	result _RET_GOTYPE
+	// {{if .NeedsScratch}}
+	// {{/*
+	// scratch is only needed to convert integers to decimals when adding them
+	// to or subtracting them from the running sum.
+	// */}}
+	scratch apd.Decimal
+	// {{end}}
+}
+
+var _ colexecop.Operator = &movingAgg_TYPEOp{}
+
+func (r *movingAgg_TYPEOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	var partitionCol, peersCol []bool
+	if r.partitionColIdx != tree.NoColumnIdx {
+		partitionCol = batch.ColVec(r.partitionColIdx).Bool()
+	}
+	if r.peersColIdx != tree.NoColumnIdx {
+		peersCol = batch.ColVec(r.peersColIdx).Bool()
+	}
+	argVec := batch.ColVec(r.argColIdx)
+	argCol, argNulls := argVec._TYPE(), argVec.Nulls()
+	outputVec := batch.ColVec(r.outputColIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		var outputCol []_RET_GOTYPE
+		var countCol []int64
+		if r.aggFn == execinfrapb.Count {
+			countCol = outputVec.Int64()
+		} else {
+			outputCol = outputVec._RET_TYPE()
+		}
+		sel := batch.Selection()
+		if argNulls.MaybeHasNulls() {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_MOVING_AGG(true)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_MOVING_AGG(true)
+				}
+			}
+		} else {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_MOVING_AGG(false)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_MOVING_AGG(false)
+				}
+			}
+		}
+	})
+	return batch
+}
+
+// grow increases the size of the ring buffer. It must only be called when the
+// buffer is full and hasn't wrapped around yet (i.e. head is zero).
+func (r *movingAgg_TYPEOp) grow() {
+	newSize := r.newBufferSize()
+	r.allocator.AdjustMemoryUsage(int64(newSize-len(r.buffer)) * int64(unsafe.Sizeof(r.buffer[0])))
+	newBuffer := make([]_GOTYPE, newSize)
+	copy(newBuffer, r.buffer)
+	r.buffer = newBuffer
+	r.growNulls(newSize)
+}
+
+// reset empties the window frame when a new partition begins.
+func (r *movingAgg_TYPEOp) reset() {
+	r.head, r.numRows, r.count = 0, 0, 0
+	var zero _RET_GOTYPE
+	r.sum = zero
+	r.resetPeerGroup()
+}
+
+// resetPeerGroup is called when a new peer group begins.
+func (r *movingAgg_TYPEOp) resetPeerGroup() {
+	r.peerRows, r.peerCount = 0, 0
+	var zero _RET_GOTYPE
+	r.peerSum = zero
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _COMPUTE_MOVING_AGG is a code snippet that slides the window frame forward
+// by the tuple at index i and computes the aggregate over the new frame.
+func _COMPUTE_MOVING_AGG(_HAS_NULLS bool) { // */}}
+	// {{define "computeMovingAgg" -}}
+	if partitionCol != nil && partitionCol[i] {
+		r.reset()
+	}
+	excluding := r.exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION
+	if excluding && (peersCol == nil || peersCol[i]) {
+		// Either a new peer group begins or each row is its own peer group
+		// (for EXCLUDE CURRENT ROW).
+		r.resetPeerGroup()
+	}
+	if r.numRows == r.frameSize {
+		// The oldest row leaves the window frame.
+		inPeerGroup := excluding && r.peerRows == r.numRows
+		if !r.bufferNulls[r.head] {
+			_ASSIGN_SUB(r.sum, r.buffer[r.head], r.scratch)
+			r.count--
+			if inPeerGroup {
+				_ASSIGN_SUB(r.peerSum, r.buffer[r.head], r.scratch)
+				r.peerCount--
+			}
+		}
+		if inPeerGroup {
+			r.peerRows--
+		}
+		r.head++
+		if r.head == len(r.buffer) {
+			r.head = 0
+		}
+		r.numRows--
+	}
+	if r.numRows == len(r.buffer) {
+		r.grow()
+	}
+	idx := r.head + r.numRows
+	if idx >= len(r.buffer) {
+		idx -= len(r.buffer)
+	}
+	// {{if .HasNulls}}
+	r.bufferNulls[idx] = argNulls.NullAt(i)
+	// {{else}}
+	r.bufferNulls[idx] = false
+	// {{end}}
+	if !r.bufferNulls[idx] {
+		v := argCol.Get(i)
+		execgen.COPYVAL(r.buffer[idx], v)
+		_ASSIGN_ADD(r.sum, v, r.scratch)
+		r.count++
+		if excluding {
+			_ASSIGN_ADD(r.peerSum, v, r.scratch)
+			r.peerCount++
+		}
+	}
+	r.numRows++
+	if excluding {
+		r.peerRows++
+		// The rows of the peer group of the current row are excluded from the
+		// window frame, but EXCLUDE TIES keeps the current row itself.
+		_ASSIGN_SUB_SUMS(r.result, r.sum, r.peerSum)
+		count := r.count - r.peerCount
+		if r.exclusion == execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES && !r.bufferNulls[idx] {
+			_ASSIGN_ADD(r.result, r.buffer[idx], r.scratch)
+			count++
+		}
+		_SET_MOVING_AGG_OUTPUT(true)
+	} else {
+		count := r.count
+		_SET_MOVING_AGG_OUTPUT(false)
+	}
+	// {{end}}
+	// {{/*
+} // */}}
+
+// {{/*
+// _SET_MOVING_AGG_OUTPUT is a code snippet that sets the output at index i to
+// the result of the aggregate function over count non-NULL values which sum up
+// to either r.result (if some rows are excluded) or r.sum.
+func _SET_MOVING_AGG_OUTPUT(_EXCLUDING bool) { // */}}
+	// {{define "setMovingAggOutput" -}}
+	if countCol != nil {
+		countCol[i] = count
+	} else if count == 0 {
+		// There are no non-NULL values in the window frame.
+		outputNulls.SetNull(i)
+	} else if r.aggFn == execinfrapb.Sum {
+		// {{if .Excluding}}
+		_COPY_SUM(outputCol[i], r.result)
+		// {{else}}
+		_COPY_SUM(outputCol[i], r.sum)
+		// {{end}}
+	} else {
+		// {{if .Excluding}}
+		_ASSIGN_DIV_INT64(outputCol[i], r.result, count)
+		// {{else}}
+		_ASSIGN_DIV_INT64(outputCol[i], r.sum, count)
+		// {{end}}
+	}
+	// {{end}}
+	// {{/*
+} // */}}
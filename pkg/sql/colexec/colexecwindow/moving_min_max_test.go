@@ -0,0 +1,433 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMovingMinMax(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(sec int64) time.Time {
+		return time.Unix(sec, 0).UTC()
+	}
+	// The first column of the input tuples is the partition column which is
+	// true for the first tuple of each partition, and the second column is
+	// the argument of the aggregate.
+	for _, tc := range []struct {
+		desc        string
+		aggFn       execinfrapb.AggregatorSpec_Func
+		argType     *types.T
+		offset      uint64
+		noPartition bool
+		tuples      colexectestutils.Tuples
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc:    "partition boundaries",
+			aggFn:   execinfrapb.Min,
+			argType: types.Int,
+			offset:  2,
+			tuples: colexectestutils.Tuples{
+				{true, 3}, {false, 1}, {false, 4}, {false, 5}, {false, 6},
+				{true, 9},
+				{true, 8}, {false, 7},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 3, 3}, {false, 1, 1}, {false, 4, 1}, {false, 5, 1}, {false, 6, 4},
+				{true, 9, 9},
+				{true, 8, 8}, {false, 7, 7},
+			},
+		},
+		{
+			// The minimum of the previous partition must not be the result
+			// for the next one even if it would still be in the frame.
+			desc:    "partition boundary with smaller value",
+			aggFn:   execinfrapb.Min,
+			argType: types.Int,
+			offset:  5,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 2}, {true, 5}, {false, 4}, {true, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1}, {false, 2, 1}, {true, 5, 5}, {false, 4, 4}, {true, nil, nil},
+			},
+		},
+		{
+			desc:    "nulls",
+			aggFn:   execinfrapb.Max,
+			argType: types.Int,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, nil}, {false, 1}, {false, nil}, {false, nil}, {false, 4},
+				{true, 3}, {false, nil},
+				{true, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, nil, nil}, {false, 1, 1}, {false, nil, 1}, {false, nil, nil}, {false, 4, 4},
+				{true, 3, 3}, {false, nil, 3},
+				{true, nil, nil},
+			},
+		},
+		{
+			desc:    "zero offset",
+			aggFn:   execinfrapb.Max,
+			argType: types.Float,
+			offset:  0,
+			tuples: colexectestutils.Tuples{
+				{true, 1.5}, {false, nil}, {false, -2.5}, {true, 0.25},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.5, 1.5}, {false, nil, nil}, {false, -2.5, -2.5}, {true, 0.25, 0.25},
+			},
+		},
+		{
+			// The equal values are kept in the deque so that the newer one
+			// is still the result after the older one leaves the frame.
+			desc:    "duplicates",
+			aggFn:   execinfrapb.Max,
+			argType: types.Int,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, 5}, {false, 5}, {false, 1}, {false, 1}, {false, 2},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 5, 5}, {false, 5, 5}, {false, 1, 5}, {false, 1, 1}, {false, 2, 2},
+			},
+		},
+		{
+			desc:    "frame larger than partition",
+			aggFn:   execinfrapb.Min,
+			argType: types.Decimal,
+			offset:  100,
+			tuples: colexectestutils.Tuples{
+				{true, 1.5}, {false, 2.0}, {false, nil}, {false, 0.5},
+				{true, 4.5},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.5, 1.5}, {false, 2.0, 1.5}, {false, nil, 1.5}, {false, 0.5, 0.5},
+				{true, 4.5, 4.5},
+			},
+		},
+		{
+			// NaN is smaller than all other floats.
+			desc:    "NaN",
+			aggFn:   execinfrapb.Min,
+			argType: types.Float,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, 1.0}, {false, math.NaN()}, {false, -1.0}, {false, 2.0},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.0, 1.0}, {false, math.NaN(), math.NaN()}, {false, -1.0, math.NaN()}, {false, 2.0, -1.0},
+			},
+		},
+		{
+			desc:    "bools",
+			aggFn:   execinfrapb.Min,
+			argType: types.Bool,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, true}, {false, false}, {false, true}, {false, true},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, true}, {false, false, false}, {false, true, false}, {false, true, true},
+			},
+		},
+		{
+			desc:    "timestamps",
+			aggFn:   execinfrapb.Max,
+			argType: types.TimestampTZ,
+			offset:  1,
+			tuples: colexectestutils.Tuples{
+				{true, ts(3)}, {false, ts(1)}, {false, ts(2)}, {true, ts(0)},
+			},
+			expected: colexectestutils.Tuples{
+				{true, ts(3), ts(3)}, {false, ts(1), ts(3)}, {false, ts(2), ts(2)}, {true, ts(0), ts(0)},
+			},
+		},
+		{
+			desc:        "no partition",
+			aggFn:       execinfrapb.Min,
+			argType:     types.Int2,
+			offset:      1,
+			noPartition: true,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 3}, {true, 5}, {false, nil}, {true, 2},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1}, {false, 3, 1}, {true, 5, 3}, {false, nil, 5}, {true, 2, 2},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			typs := []*types.T{types.Bool, tc.argType}
+			partitionColIdx := 0
+			if tc.noPartition {
+				partitionColIdx = tree.NoColumnIdx
+			}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewMovingMinMaxOperator(
+						testAllocator, inputs[0], typs, tc.aggFn, 1 /* argColIdx */, 2, /* outputColIdx */
+						partitionColIdx, tc.offset,
+					)
+				})
+		})
+	}
+}
+
+// TestMovingMinMaxRandomized verifies the moving MIN and MAX operators against
+// the naive computation that scans the whole window frame for every row.
+func TestMovingMinMaxRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const numRows = 500
+	typs := []*types.T{types.Bool, types.Int}
+	for _, aggFn := range []execinfrapb.AggregatorSpec_Func{execinfrapb.Min, execinfrapb.Max} {
+		for _, offset := range []uint64{0, 1, 7, 100, numRows} {
+			for _, avgPartitionSize := range []int{1, 10, numRows} {
+				tuples := make(colexectestutils.Tuples, numRows)
+				for i := range tuples {
+					// A small range of values makes the duplicates common.
+					var arg interface{}
+					if rng.Float64() >= 0.2 {
+						arg = int64(rng.Intn(20))
+					}
+					tuples[i] = colexectestutils.Tuple{i == 0 || rng.Intn(avgPartitionSize) == 0, arg}
+				}
+				expected := naiveMovingMinMax(tuples, aggFn, int(offset))
+				t.Run(fmt.Sprintf("%s/offset=%d/partitionSize=%d", aggFn, offset, avgPartitionSize), func(t *testing.T) {
+					colexectestutils.RunTestsWithTyps(
+						t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+						expected, colexectestutils.OrderedVerifier,
+						func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+							return NewMovingMinMaxOperator(
+								testAllocator, inputs[0], typs, aggFn, 1 /* argColIdx */, 2, /* outputColIdx */
+								0 /* partitionColIdx */, offset,
+							)
+						})
+				})
+			}
+		}
+	}
+}
+
+// TestMovingMinMaxBufferSize verifies that the ring buffer of the deque grows
+// when needed (including when the deque wraps around the end of the buffer)
+// but never becomes larger than the window frame.
+func TestMovingMinMaxBufferSize(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	typs := []*types.T{types.Bool, types.Int}
+	for _, offset := range []uint64{3, 20, 100} {
+		frameSize := int(offset) + 1
+		// The deque for MIN contains the first row and the last of the equal
+		// values until the first row leaves the window frame, so the front of
+		// the deque moves away from the beginning of the ring buffer. The
+		// increasing values after that are never removed from the back of the
+		// deque, so it wraps around the ring buffer and grows until it
+		// contains the whole window frame.
+		tuples := colexectestutils.Tuples{{true, 0}}
+		for i := 1; i < frameSize; i++ {
+			tuples = append(tuples, colexectestutils.Tuple{false, 100})
+		}
+		for i := 0; i < 2*frameSize; i++ {
+			tuples = append(tuples, colexectestutils.Tuple{false, 101 + i})
+		}
+		expected := naiveMovingMinMax(tuples, execinfrapb.Min, int(offset))
+		colexectestutils.RunTestsWithTyps(
+			t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+			expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				return NewMovingMinMaxOperator(
+					testAllocator, inputs[0], typs, execinfrapb.Min, 1 /* argColIdx */, 2, /* outputColIdx */
+					0 /* partitionColIdx */, offset,
+				)
+			})
+
+		op, err := NewMovingMinMaxOperator(
+			testAllocator, colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), tuples, typs),
+			typs, execinfrapb.Min, 1 /* argColIdx */, 2 /* outputColIdx */, 0 /* partitionColIdx */, offset,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		for b := op.Next(); b.Length() > 0; b = op.Next() {
+		}
+		minOp := op.(*movingMinInt64Op)
+		require.Equal(t, frameSize, len(minOp.values))
+		require.Equal(t, frameSize, len(minOp.positions))
+	}
+}
+
+// naiveMovingMinMax returns the tuples extended with the result of MIN or MAX
+// over the ROWS BETWEEN offset PRECEDING AND CURRENT ROW window frame which is
+// computed by scanning the whole window frame for every row. The first column
+// of the tuples is the partition column, and the second is the int argument.
+func naiveMovingMinMax(
+	tuples colexectestutils.Tuples, aggFn execinfrapb.AggregatorSpec_Func, offset int,
+) colexectestutils.Tuples {
+	res := make(colexectestutils.Tuples, len(tuples))
+	partitionStart := 0
+	for i, tup := range tuples {
+		if tup[0].(bool) {
+			partitionStart = i
+		}
+		start := i - offset
+		if start < partitionStart {
+			start = partitionStart
+		}
+		var result interface{}
+		for _, frameTup := range tuples[start : i+1] {
+			if frameTup[1] == nil {
+				continue
+			}
+			v := toInt64(frameTup[1])
+			if result == nil ||
+				(aggFn == execinfrapb.Min && v < result.(int64)) ||
+				(aggFn == execinfrapb.Max && v > result.(int64)) {
+				result = v
+			}
+		}
+		res[i] = colexectestutils.Tuple{tup[0], tup[1], result}
+	}
+	return res
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	}
+	panic(fmt.Sprintf("unexpected type %T", v))
+}
+
+// naiveMovingMinOp is an operator that computes the moving minimum of floats
+// by scanning the whole window frame for every row. It is used as the baseline
+// in the benchmarks.
+type naiveMovingMinOp struct {
+	colexecop.OneInputHelper
+	frameSize int
+	values    []float64
+	nulls     []bool
+}
+
+var _ colexecop.Operator = &naiveMovingMinOp{}
+
+func (o *naiveMovingMinOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	partitionCol := batch.ColVec(0).Bool()
+	argVec := batch.ColVec(1)
+	argCol, argNulls := argVec.Float64(), argVec.Nulls()
+	outputVec := batch.ColVec(2)
+	outputVec.Nulls().UnsetNulls()
+	outputCol := outputVec.Float64()
+	for i := 0; i < n; i++ {
+		if partitionCol[i] {
+			o.values, o.nulls = o.values[:0], o.nulls[:0]
+		}
+		o.values = append(o.values, argCol[i])
+		o.nulls = append(o.nulls, argNulls.NullAt(i))
+		start := len(o.values) - o.frameSize
+		if start < 0 {
+			start = 0
+		}
+		found := false
+		var min float64
+		for j := start; j < len(o.values); j++ {
+			if !o.nulls[j] && (!found || o.values[j] < min) {
+				min, found = o.values[j], true
+			}
+		}
+		if !found {
+			outputVec.Nulls().SetNull(i)
+		} else {
+			outputCol[i] = min
+		}
+	}
+	return batch
+}
+
+func BenchmarkMovingMin(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+
+	const partitionSize = 4096
+	typs := []*types.T{types.Bool, types.Float, types.Float}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	partitionCol := batch.ColVec(0).Bool()
+	argCol := batch.ColVec(1).Float64()
+	for i := 0; i < coldata.BatchSize(); i++ {
+		partitionCol[i] = i%partitionSize == 0
+		argCol[i] = rng.Float64()
+		if rng.Float64() < 0.1 {
+			batch.ColVec(1).Nulls().SetNull(i)
+		}
+	}
+	batch.SetLength(coldata.BatchSize())
+	for _, offset := range []uint64{1, 16, 256} {
+		for _, naive := range []bool{false, true} {
+			b.Run(fmt.Sprintf("offset=%d/naive=%t", offset, naive), func(b *testing.B) {
+				source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+				var op colexecop.Operator
+				if naive {
+					op = &naiveMovingMinOp{
+						OneInputHelper: colexecop.MakeOneInputHelper(source),
+						frameSize:      int(offset) + 1,
+					}
+				} else {
+					var err error
+					op, err = NewMovingMinMaxOperator(
+						testAllocator, source, typs, execinfrapb.Min, 1 /* argColIdx */, 2, /* outputColIdx */
+						0 /* partitionColIdx */, offset,
+					)
+					require.NoError(b, err)
+				}
+				op.Init(ctx)
+				b.SetBytes(int64(8 * coldata.BatchSize()))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					op.Next()
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for moving_min_max.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexecwindow
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ tree.AggType
+	_ = colexecerror.InternalError
+)
+
+// {{/*
+// Declarations to make the template compile properly.
+
+// _ASSIGN_CMP is the template function for assigning true to the first input
+// if the second input compares successfully to the third input. The comparison
+// operator is tree.LT for MIN and is tree.GT for MAX.
+func _ASSIGN_CMP(_, _, _, _, _, _ string) bool {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewMovingMinMaxOperator creates a new Operator that computes the MIN or MAX
+// aggregate function used as a window function over the ROWS BETWEEN offset
+// PRECEDING AND CURRENT ROW window frame.
+//
+// Unlike a sum, the minimum can't be updated when a row leaves the window
+// frame, so the operator maintains a monotonic deque of the candidate values
+// instead: every value in the deque is smaller (larger for MAX) than all
+// values that entered the window frame before it and are still in the deque.
+// The front of the deque is the result for the current row, and the value of
+// every row is pushed into and popped from the deque at most once, so the
+// amortized cost per row is O(1) regardless of the size of the window frame.
+// NULL values are ignored, and if there are no non-NULL values in the frame,
+// the result is NULL. outputColIdx specifies in which coldata.Vec the operator
+// should put its output (if there is no such column, a new column is
+// appended).
+func NewMovingMinMaxOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	argColIdx int,
+	outputColIdx int,
+	partitionColIdx int,
+	offset uint64,
+) (colexecop.Operator, error) {
+	argType := inputTypes[argColIdx]
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, argType, outputColIdx)
+	// The frame consists of the current row and offset preceding rows. Note
+	// that a frame of MaxInt64 rows can never be full, so we cap the frame
+	// size in order to not overflow.
+	frameSize := int64(math.MaxInt64)
+	if offset < math.MaxInt64 {
+		frameSize = int64(offset) + 1
+	}
+	base := movingMinMaxBase{
+		OneInputHelper:  colexecop.MakeOneInputHelper(input),
+		allocator:       allocator,
+		argColIdx:       argColIdx,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+		frameSize:       int(frameSize),
+	}
+	switch aggFn {
+	// {{range .}}
+	// {{$aggTitle := .AggTitle}}
+	case execinfrapb._AGG_TITLE:
+		switch typeconv.TypeFamilyToCanonicalTypeFamily(argType.Family()) {
+		// {{range .Overloads}}
+		case _CANONICAL_TYPE_FAMILY:
+			switch argType.Width() {
+			// {{range .WidthOverloads}}
+			case _TYPE_WIDTH:
+				return &moving_AGG_TITLE_TYPEOp{movingMinMaxBase: base}, nil
+				// {{end}}
+			}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported moving %s aggregate on type %s", aggFn, argType)
+}
+
+// movingMinMaxBase extracts common fields and common methods of the moving MIN
+// and MAX operators. Note that it is not an operator itself and should not be
+// used directly.
+type movingMinMaxBase struct {
+	colexecop.OneInputHelper
+	allocator       *colmem.Allocator
+	argColIdx       int
+	outputColIdx    int
+	partitionColIdx int
+
+	// frameSize is the maximum number of rows in the window frame.
+	frameSize int
+	// rowIdx is the position of the current row within its partition.
+	rowIdx int
+	// The deque is stored in a ring buffer: head is the position of its front
+	// in the ring buffer, and numEntries is the number of values in it. Since
+	// all values in the deque belong to the rows of the window frame, the ring
+	// buffer never needs to be larger than the window frame.
+	head, numEntries int
+	// positions contains the positions within the partition of the rows whose
+	// values are in the deque. They increase from the front to the back of the
+	// deque.
+	positions []int
+}
+
+// newBufferSize returns the size of the ring buffer after it grows. The buffer
+// is doubled in size but never exceeds the size of the window frame.
+func (r *movingMinMaxBase) newBufferSize() int {
+	newSize := 2 * len(r.positions)
+	if newSize < minMovingAggBufferSize {
+		newSize = minMovingAggBufferSize
+	}
+	if newSize > r.frameSize {
+		newSize = r.frameSize
+	}
+	return newSize
+}
+
+// growPositions increases the size of the positions ring buffer and moves the
+// front of the deque to the beginning of the new buffer. It must only be
+// called when the buffer is full.
+func (r *movingMinMaxBase) growPositions(newSize int) {
+	r.allocator.AdjustMemoryUsage(int64(newSize-len(r.positions)) * int64(unsafe.Sizeof(int(0))))
+	newPositions := make([]int, newSize)
+	n := copy(newPositions, r.positions[r.head:])
+	copy(newPositions[n:], r.positions[:r.head])
+	r.positions = newPositions
+}
+
+// reset empties the window frame when a new partition begins.
+func (r *movingMinMaxBase) reset() {
+	r.rowIdx, r.head, r.numEntries = 0, 0, 0
+}
+
+// {{range .}}
+// {{$aggTitle := .AggTitle}}
+// {{range .Overloads}}
+// {{range .WidthOverloads}}
+
+type moving_AGG_TITLE_TYPEOp struct {
+	movingMinMaxBase
+	// values is the ring buffer with the values of the deque. Its element at
+	// position i is the value of the row at positions[i].
+	values []_GOTYPE
+}
+
+var _ colexecop.Operator = &moving_AGG_TITLE_TYPEOp{}
+
+func (r *moving_AGG_TITLE_TYPEOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	var partitionCol []bool
+	if r.partitionColIdx != tree.NoColumnIdx {
+		partitionCol = batch.ColVec(r.partitionColIdx).Bool()
+	}
+	argVec := batch.ColVec(r.argColIdx)
+	argCol, argNulls := argVec._TYPE(), argVec.Nulls()
+	outputVec := batch.ColVec(r.outputColIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol, outputNulls := outputVec._TYPE(), outputVec.Nulls()
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		sel := batch.Selection()
+		if argNulls.MaybeHasNulls() {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_MOVING_MIN_MAX(true)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_MOVING_MIN_MAX(true)
+				}
+			}
+		} else {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_MOVING_MIN_MAX(false)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_MOVING_MIN_MAX(false)
+				}
+			}
+		}
+	})
+	return batch
+}
+
+// grow increases the size of the ring buffer. It must only be called when the
+// buffer is full.
+func (r *moving_AGG_TITLE_TYPEOp) grow() {
+	newSize := r.newBufferSize()
+	r.allocator.AdjustMemoryUsage(int64(newSize-len(r.values)) * int64(unsafe.Sizeof(r.values[0])))
+	newValues := make([]_GOTYPE, newSize)
+	n := copy(newValues, r.values[r.head:])
+	copy(newValues[n:], r.values[:r.head])
+	r.values = newValues
+	r.growPositions(newSize)
+	r.head = 0
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _COMPUTE_MOVING_MIN_MAX is a code snippet that slides the window frame
+// forward by the tuple at index i and sets the output at index i to the
+// minimum (or maximum) over the new frame.
+func _COMPUTE_MOVING_MIN_MAX(_HAS_NULLS bool) { // */}}
+	// {{define "computeMovingMinMax" -}}
+	if partitionCol != nil && partitionCol[i] {
+		r.reset()
+	}
+	// The row at position rowIdx-frameSize leaves the window frame. Since the
+	// positions in the deque are increasing, only the front can belong to it.
+	if r.numEntries > 0 && r.positions[r.head] <= r.rowIdx-r.frameSize {
+		r.head++
+		if r.head == len(r.positions) {
+			r.head = 0
+		}
+		r.numEntries--
+	}
+	// {{if .HasNulls}}
+	if !argNulls.NullAt(i) {
+		// {{end}}
+		v := argCol.Get(i)
+		// The values at the back of the deque that are not smaller (larger for
+		// MAX) than v can never be the result since they leave the window frame
+		// before v does, so we remove them.
+		for r.numEntries > 0 {
+			back := r.head + r.numEntries - 1
+			if back >= len(r.positions) {
+				back -= len(r.positions)
+			}
+			var keep bool
+			// {{with .Global}}
+			_ASSIGN_CMP(keep, r.values[back], v, _, argCol, _)
+			// {{end}}
+			if keep {
+				break
+			}
+			r.numEntries--
+		}
+		if r.numEntries == len(r.positions) {
+			r.grow()
+		}
+		idx := r.head + r.numEntries
+		if idx >= len(r.positions) {
+			idx -= len(r.positions)
+		}
+		// {{with .Global}}
+		execgen.COPYVAL(r.values[idx], v)
+		// {{end}}
+		r.positions[idx] = r.rowIdx
+		r.numEntries++
+		// {{if .HasNulls}}
+	}
+	// {{end}}
+	r.rowIdx++
+	if r.numEntries == 0 {
+		// There are no non-NULL values in the window frame.
+		outputNulls.SetNull(i)
+	} else {
+		// {{with .Global}}
+		execgen.SET(outputCol, i, r.values[r.head])
+		// {{end}}
+	}
+	// {{end}}
+	// {{/*
+} // */}}
@@ -21,29 +21,33 @@ import (
 )
 
 // NewWindowSortingPartitioner creates a new colexecop.Operator that orders
-// input first based on the partitionIdxs columns and second on ordCols (i.e. it
-// handles both PARTITION BY and ORDER BY clauses of a window function) and puts
-// true in partitionColIdx'th column (which is appended if needed) for every
-// tuple that is the first within its partition.
+// input first based on the partitionOrdering columns and second on ordCols
+// (i.e. it handles both PARTITION BY and ORDER BY clauses of a window function)
+// and puts true in partitionColIdx'th column (which is appended if needed) for
+// every tuple that is the first within its partition. The partitions only need
+// to be contiguous, so the order of the partitionOrdering columns as well as
+// their directions can be arbitrary.
 func NewWindowSortingPartitioner(
 	allocator *colmem.Allocator,
 	input colexecop.Operator,
 	inputTyps []*types.T,
-	partitionIdxs []uint32,
+	partitionOrdering []execinfrapb.Ordering_Column,
 	ordCols []execinfrapb.Ordering_Column,
 	partitionColIdx int,
 	createDiskBackedSorter func(input colexecop.Operator, inputTypes []*types.T, orderingCols []execinfrapb.Ordering_Column) (colexecop.Operator, error),
 ) (op colexecop.Operator, err error) {
-	partitionAndOrderingCols := make([]execinfrapb.Ordering_Column, len(partitionIdxs)+len(ordCols))
-	for i, idx := range partitionIdxs {
-		partitionAndOrderingCols[i] = execinfrapb.Ordering_Column{ColIdx: idx}
-	}
-	copy(partitionAndOrderingCols[len(partitionIdxs):], ordCols)
+	partitionAndOrderingCols := make([]execinfrapb.Ordering_Column, 0, len(partitionOrdering)+len(ordCols))
+	partitionAndOrderingCols = append(partitionAndOrderingCols, partitionOrdering...)
+	partitionAndOrderingCols = append(partitionAndOrderingCols, ordCols...)
 	input, err = createDiskBackedSorter(input, inputTyps, partitionAndOrderingCols)
 	if err != nil {
 		return nil, err
 	}
 
+	partitionIdxs := make([]uint32, len(partitionOrdering))
+	for i, ord := range partitionOrdering {
+		partitionIdxs[i] = ord.ColIdx
+	}
 	var distinctCol []bool
 	input, distinctCol, err = colexecbase.OrderedDistinctColsToOperators(input, partitionIdxs, inputTyps)
 	if err != nil {
@@ -0,0 +1,275 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/marusama/semaphore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeMinMax(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ts := func(sec int64) time.Time {
+		return time.Unix(sec, 0).UTC()
+	}
+	// The first two columns of the input tuples are the partition and the
+	// peers columns which are true for the first tuple of each partition and
+	// of each peer group, respectively. The third column is the ordering
+	// column, and the fourth one is the argument of the aggregate.
+	for _, tc := range []struct {
+		desc       string
+		aggFn      execinfrapb.AggregatorSpec_Func
+		argType    *types.T
+		offset     time.Duration
+		descending bool
+		tuples     colexectestutils.Tuples
+		expected   colexectestutils.Tuples
+	}{
+		{
+			// The peers share the window frame, and the rows with the
+			// ordering value equal to the boundary of the frame are in it.
+			desc:    "duplicate timestamps",
+			aggFn:   execinfrapb.Min,
+			argType: types.Int,
+			offset:  5 * time.Second,
+			tuples: colexectestutils.Tuples{
+				{true, true, ts(0), 4}, {false, false, ts(0), 2}, {false, true, ts(3), 5},
+				{false, true, ts(5), 7}, {false, false, ts(5), nil}, {false, true, ts(6), 9},
+				{false, true, ts(11), 3}, {false, false, ts(11), 8}, {false, true, ts(17), 6},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, ts(0), 4, 2}, {false, false, ts(0), 2, 2}, {false, true, ts(3), 5, 2},
+				{false, true, ts(5), 7, 2}, {false, false, ts(5), nil, 2}, {false, true, ts(6), 9, 5},
+				{false, true, ts(11), 3, 3}, {false, false, ts(11), 8, 3}, {false, true, ts(17), 6, 6},
+			},
+		},
+		{
+			desc:    "partition boundaries",
+			aggFn:   execinfrapb.Max,
+			argType: types.Int,
+			offset:  10 * time.Second,
+			tuples: colexectestutils.Tuples{
+				{true, true, ts(0), 1}, {false, true, ts(5), 3}, {false, false, ts(5), 2},
+				{true, true, ts(1), 0}, {false, true, ts(20), nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, ts(0), 1, 1}, {false, true, ts(5), 3, 3}, {false, false, ts(5), 2, 3},
+				{true, true, ts(1), 0, 0}, {false, true, ts(20), nil, nil},
+			},
+		},
+		{
+			// The window frame of the rows with NULL timestamps consists of
+			// their peers only.
+			desc:    "null timestamps",
+			aggFn:   execinfrapb.Min,
+			argType: types.Decimal,
+			offset:  time.Second,
+			tuples: colexectestutils.Tuples{
+				{true, true, nil, 5.5}, {false, false, nil, 3.5}, {false, true, ts(0), 4.5},
+				{false, true, ts(1), nil}, {false, true, ts(3), 7.5},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, nil, 5.5, 3.5}, {false, false, nil, 3.5, 3.5}, {false, true, ts(0), 4.5, 4.5},
+				{false, true, ts(1), nil, 4.5}, {false, true, ts(3), 7.5, 7.5},
+			},
+		},
+		{
+			desc:       "descending",
+			aggFn:      execinfrapb.Max,
+			argType:    types.Int,
+			offset:     2 * time.Second,
+			descending: true,
+			tuples: colexectestutils.Tuples{
+				{true, true, ts(10), 1}, {false, true, ts(9), 5}, {false, false, ts(9), 2},
+				{false, true, ts(7), 4}, {false, true, ts(4), 3}, {false, true, nil, 6},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, ts(10), 1, 1}, {false, true, ts(9), 5, 5}, {false, false, ts(9), 2, 5},
+				{false, true, ts(7), 4, 5}, {false, true, ts(4), 3, 3}, {false, true, nil, 6, 6},
+			},
+		},
+		{
+			// The peer group spans multiple batches when the batch size is
+			// small.
+			desc:    "single peer group",
+			aggFn:   execinfrapb.Min,
+			argType: types.Float,
+			offset:  0,
+			tuples: colexectestutils.Tuples{
+				{true, true, ts(7), 3.0}, {false, false, ts(7), 1.0}, {false, false, ts(7), nil},
+				{false, false, ts(7), 2.0}, {false, false, ts(7), 4.0},
+			},
+			expected: colexectestutils.Tuples{
+				{true, true, ts(7), 3.0, 1.0}, {false, false, ts(7), 1.0, 1.0}, {false, false, ts(7), nil, 1.0},
+				{false, false, ts(7), 2.0, 1.0}, {false, false, ts(7), 4.0, 1.0},
+			},
+		},
+	} {
+		for _, spillForced := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/spillForced=%t", tc.desc, spillForced), func(t *testing.T) {
+				typs := []*types.T{types.Bool, types.Bool, types.Timestamp, tc.argType}
+				runRangeMinMaxTest(t, typs, tc.tuples, tc.expected, tc.aggFn, tc.offset, tc.descending, spillForced)
+			})
+		}
+	}
+}
+
+// TestRangeMinMaxRandomized verifies the range MIN and MAX operators against
+// the naive computation that scans the whole partition for every row.
+func TestRangeMinMaxRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const numTuples = 200
+	for _, aggFn := range []execinfrapb.AggregatorSpec_Func{execinfrapb.Min, execinfrapb.Max} {
+		for _, offsetSec := range []int64{0, 1, 3, 10} {
+			for _, spillForced := range []bool{false, true} {
+				tuples := make(colexectestutils.Tuples, numTuples)
+				var prevTS interface{}
+				for i := range tuples {
+					partitionStart := i == 0 || rng.Intn(50) == 0
+					var orderVal interface{}
+					if partitionStart {
+						// Only the first tuples of a partition can have NULL
+						// timestamps.
+						if rng.Float64() >= 0.3 {
+							orderVal = time.Unix(int64(rng.Intn(5)), 0).UTC()
+						}
+					} else if prevTS == nil && rng.Float64() < 0.5 {
+						orderVal = nil
+					} else {
+						sec := int64(0)
+						if prevTS != nil {
+							sec = prevTS.(time.Time).Unix()
+						}
+						orderVal = time.Unix(sec+int64(rng.Intn(3)), 0).UTC()
+					}
+					var arg interface{}
+					if rng.Float64() >= 0.2 {
+						arg = int64(rng.Intn(20))
+					}
+					peerGroupStart := partitionStart || (orderVal == nil) != (prevTS == nil) ||
+						(orderVal != nil && !orderVal.(time.Time).Equal(prevTS.(time.Time)))
+					tuples[i] = colexectestutils.Tuple{partitionStart, peerGroupStart, orderVal, arg}
+					prevTS = orderVal
+				}
+				expected := naiveRangeMinMax(tuples, aggFn, offsetSec)
+				t.Run(fmt.Sprintf("%s/offset=%ds/spillForced=%t", aggFn, offsetSec, spillForced), func(t *testing.T) {
+					typs := []*types.T{types.Bool, types.Bool, types.Timestamp, types.Int}
+					runRangeMinMaxTest(
+						t, typs, tuples, expected, aggFn, time.Duration(offsetSec)*time.Second,
+						false /* descending */, spillForced,
+					)
+				})
+			}
+		}
+	}
+}
+
+func runRangeMinMaxTest(
+	t *testing.T,
+	typs []*types.T,
+	tuples, expected colexectestutils.Tuples,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	offset time.Duration,
+	descending bool,
+	spillForced bool,
+) {
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+	memoryLimit := int64(1 << 20)
+	if spillForced {
+		memoryLimit = 1
+	}
+	orderingCol := execinfrapb.Ordering_Column{ColIdx: 2, Direction: execinfrapb.Ordering_Column_ASC}
+	if descending {
+		orderingCol.Direction = execinfrapb.Ordering_Column_DESC
+	}
+	var semsToCheck []semaphore.Semaphore
+	colexectestutils.RunTestsWithTyps(
+		t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+		expected, colexectestutils.OrderedVerifier,
+		func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+			// The spilling queue uses separate FDs for reading and writing.
+			sem := colexecop.NewTestingSemaphore(2)
+			semsToCheck = append(semsToCheck, sem)
+			return NewRangeMinMaxOperator(
+				testAllocator, memoryLimit, queueCfg, sem, inputs[0], typs, aggFn,
+				3 /* argColIdx */, orderingCol, duration.MakeDuration(offset.Nanoseconds(), 0, 0),
+				nil /* location */, 4 /* outputColIdx */, 0 /* partitionColIdx */, 1, /* peersColIdx */
+				testDiskAcc,
+			)
+		})
+	for i, sem := range semsToCheck {
+		require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
+	}
+}
+
+// naiveRangeMinMax returns the tuples extended with the result of MIN or MAX
+// over the RANGE BETWEEN offsetSec seconds PRECEDING AND CURRENT ROW window
+// frame. The tuples are expected to be in the format described in
+// TestRangeMinMax and to be sorted in ascending order.
+func naiveRangeMinMax(
+	tuples colexectestutils.Tuples, aggFn execinfrapb.AggregatorSpec_Func, offsetSec int64,
+) colexectestutils.Tuples {
+	expected := make(colexectestutils.Tuples, len(tuples))
+	partitionStartIdx := 0
+	for i, tuple := range tuples {
+		if tuple[0].(bool) {
+			partitionStartIdx = i
+		}
+		var res interface{}
+		for j := partitionStartIdx; j < len(tuples); j++ {
+			if j > partitionStartIdx && tuples[j][0].(bool) {
+				break
+			}
+			if tuple[2] == nil {
+				if tuples[j][2] != nil {
+					continue
+				}
+			} else {
+				if tuples[j][2] == nil {
+					continue
+				}
+				cur, other := tuple[2].(time.Time).Unix(), tuples[j][2].(time.Time).Unix()
+				if other < cur-offsetSec || other > cur {
+					continue
+				}
+			}
+			if tuples[j][3] == nil {
+				continue
+			}
+			v := tuples[j][3].(int64)
+			if res == nil || (aggFn == execinfrapb.Min && v < res.(int64)) ||
+				(aggFn == execinfrapb.Max && v > res.(int64)) {
+				res = v
+			}
+		}
+		expected[i] = append(append(colexectestutils.Tuple{}, tuple...), res)
+	}
+	return expected
+}
@@ -0,0 +1,713 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for range_min_max.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexecwindow
+
+import (
+	"context"
+	"time"
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/errors"
+	"github.com/marusama/semaphore"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ tree.AggType
+	_ = colexecerror.InternalError
+)
+
+// {{/*
+// Declarations to make the template compile properly.
+
+// _ASSIGN_CMP is the template function for assigning true to the first input
+// if the second input compares successfully to the third input. The comparison
+// operator is tree.LT for MIN and is tree.GT for MAX.
+func _ASSIGN_CMP(_, _, _, _, _, _ string) bool {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewRangeMinMaxOperator creates a new Operator that computes the MIN or MAX
+// aggregate function used as a window function over the RANGE BETWEEN offset
+// PRECEDING AND CURRENT ROW window frame where the rows are ordered by a
+// single TIMESTAMP or TIMESTAMPTZ column and the offset is an interval. The
+// input must already be sorted on the columns from the PARTITION BY clause
+// followed by the ordering column.
+//
+// Same as the moving MIN and MAX operators, the operator maintains a monotonic
+// deque of the candidate values, but the values leave the window frame based
+// on the value of the ordering column rather than on the position of the row.
+// In RANGE mode the window frame of a row ends with its last peer, so all
+// peers share the window frame and the result, and the result for a peer group
+// is only known once the group has ended. Because of that, the tuples of the
+// last peer group of each batch are buffered (possibly spilling to disk), and
+// the output is emitted in batches separate from the input ones.
+//
+// NULL values of the argument are ignored, and if there are no non-NULL values
+// in the frame, the result is NULL. The window frame of a row with NULL value
+// in the ordering column consists of its peers only. outputColIdx specifies in
+// which coldata.Vec the operator should put its output (if there is no such
+// column, a new column is appended).
+func NewRangeMinMaxOperator(
+	unlimitedAllocator *colmem.Allocator,
+	memoryLimit int64,
+	diskQueueCfg colcontainer.DiskQueueCfg,
+	fdSemaphore semaphore.Semaphore,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	aggFn execinfrapb.AggregatorSpec_Func,
+	argColIdx int,
+	orderingCol execinfrapb.Ordering_Column,
+	offset duration.Duration,
+	location *time.Location,
+	outputColIdx int,
+	partitionColIdx int,
+	peersColIdx int,
+	diskAcc *mon.BoundAccount,
+) (colexecop.Operator, error) {
+	argType := inputTypes[argColIdx]
+	input = colexecutils.NewVectorTypeEnforcer(unlimitedAllocator, input, argType, outputColIdx)
+	// The rows with the ordering value smaller than the value of the current
+	// row minus the offset (larger than the value plus the offset if the rows
+	// are in descending order) leave the window frame.
+	descending := orderingCol.Direction == execinfrapb.Ordering_Column_DESC
+	if !descending {
+		offset = offset.Mul(-1)
+	}
+	base := rangeMinMaxBase{
+		OneInputHelper:  colexecop.MakeOneInputHelper(input),
+		allocator:       unlimitedAllocator,
+		memoryLimit:     memoryLimit,
+		diskQueueCfg:    diskQueueCfg,
+		fdSemaphore:     fdSemaphore,
+		inputTypes:      inputTypes,
+		argColIdx:       argColIdx,
+		orderColIdx:     int(orderingCol.ColIdx),
+		descending:      descending,
+		offset:          offset,
+		location:        location,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+		peersColIdx:     peersColIdx,
+		diskAcc:         diskAcc,
+	}
+	switch aggFn {
+	// {{range .}}
+	// {{$aggTitle := .AggTitle}}
+	case execinfrapb._AGG_TITLE:
+		switch typeconv.TypeFamilyToCanonicalTypeFamily(argType.Family()) {
+		// {{range .Overloads}}
+		case _CANONICAL_TYPE_FAMILY:
+			switch argType.Width() {
+			// {{range .WidthOverloads}}
+			case _TYPE_WIDTH:
+				return &range_AGG_TITLE_TYPEOp{rangeMinMaxBase: base}, nil
+				// {{end}}
+			}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported range %s aggregate on type %s", aggFn, argType)
+}
+
+type rangeMinMaxState int
+
+const (
+	// rangeMinMaxReading is the state in which the range MIN and MAX operators
+	// read the next batch from the input and compute the results for all peer
+	// groups that end within it. If there are tuples with known results (either
+	// buffered or in the batch), the operators transition to
+	// rangeMinMaxEmitting state; otherwise, the batch is buffered.
+	rangeMinMaxReading rangeMinMaxState = iota
+	// rangeMinMaxEmitting is the state in which the range MIN and MAX operators
+	// emit the buffered tuples followed by the tuples of the current batch
+	// with known results. Once all of them have been emitted, the tuples of the
+	// last peer group of the batch are buffered, and the operators transition
+	// back to rangeMinMaxReading state (or to rangeMinMaxFinished state if the
+	// input has been exhausted).
+	rangeMinMaxEmitting
+	// rangeMinMaxFinished is the state in which the range MIN and MAX
+	// operators close any non-closed disk resources and emit the zero-length
+	// batch.
+	rangeMinMaxFinished
+)
+
+// rangeMinMaxBase extracts common fields and common methods of the range MIN
+// and MAX operators. Note that it is not an operator itself and should not be
+// used directly.
+type rangeMinMaxBase struct {
+	colexecop.OneInputHelper
+	colexecop.CloserHelper
+
+	allocator       *colmem.Allocator
+	memoryLimit     int64
+	diskQueueCfg    colcontainer.DiskQueueCfg
+	fdSemaphore     semaphore.Semaphore
+	inputTypes      []*types.T
+	argColIdx       int
+	orderColIdx     int
+	descending      bool
+	outputColIdx    int
+	partitionColIdx int
+	peersColIdx     int
+	diskAcc         *mon.BoundAccount
+	// offset is added to the value of the ordering column of the current peer
+	// group in order to get the boundary of the window frame. It has already
+	// been negated if the rows are in ascending order.
+	offset duration.Duration
+	// location, if non-nil, is the location in which the offset is added to
+	// the TIMESTAMPTZ values.
+	location *time.Location
+
+	state rangeMinMaxState
+	// batch is the last batch read from the input. The results of the tuples
+	// at positions [batchIdx, resolvedEndIdx) (before applying the selection
+	// vector) are known but the tuples haven't been emitted yet.
+	batch          coldata.Batch
+	batchIdx       int
+	resolvedEndIdx int
+	inputDone      bool
+
+	// peerGroupStarted indicates whether the first peer group has begun.
+	peerGroupStarted bool
+	// peerGroupStartIdx is the position (before applying the selection vector)
+	// of the first tuple of the current peer group in the current batch (zero
+	// if the group began in one of the previous batches).
+	peerGroupStartIdx int
+	// peerGroupOrderVal is the value of the ordering column of the current
+	// peer group, valid only if peerGroupOrderIsNull is false.
+	peerGroupOrderVal    time.Time
+	peerGroupOrderIsNull bool
+	// peerGroupBuffered indicates whether some tuples of the current peer
+	// group have been buffered.
+	peerGroupBuffered bool
+
+	// The deque is stored in a ring buffer: head is the position of its front
+	// in the ring buffer, and numEntries is the number of values in it.
+	head, numEntries int
+	// orderVals contains the values of the ordering column of the rows whose
+	// values are in the deque. They are sorted in the order of the rows from
+	// the front to the back of the deque.
+	orderVals []time.Time
+
+	// bufferedTuples contains the tuples of the last peer group of the
+	// previous batches, and numBuffered is the number of such tuples.
+	bufferedTuples *colexecutils.SpillingQueue
+	numBuffered    int
+	// bufferedGroupEnded indicates whether the peer group of the buffered
+	// tuples has ended. draining indicates whether the buffered tuples are
+	// being emitted, and dequeued is the last batch dequeued from
+	// bufferedTuples, with the tuples at positions [0, dequeuedIdx) already
+	// emitted.
+	bufferedGroupEnded bool
+	draining           bool
+	dequeued           coldata.Batch
+	dequeuedIdx        int
+
+	scratch coldata.Batch
+	output  coldata.Batch
+}
+
+func (r *rangeMinMaxBase) Init(ctx context.Context) {
+	if !r.InitHelper.Init(ctx) {
+		return
+	}
+	r.Input.Init(r.Ctx)
+	r.state = rangeMinMaxReading
+	r.bufferedTuples = colexecutils.NewSpillingQueue(
+		&colexecutils.NewSpillingQueueArgs{
+			UnlimitedAllocator: r.allocator,
+			Types:              r.inputTypes,
+			MemoryLimit:        r.memoryLimit,
+			DiskQueueCfg:       r.diskQueueCfg,
+			FDSemaphore:        r.fdSemaphore,
+			DiskAcc:            r.diskAcc,
+		},
+	)
+	r.scratch = r.allocator.NewMemBatchWithFixedCapacity(r.inputTypes, coldata.BatchSize())
+	outputTypes := make([]*types.T, len(r.inputTypes)+1)
+	copy(outputTypes, r.inputTypes)
+	outputTypes[len(r.inputTypes)] = r.inputTypes[r.argColIdx]
+	r.output = r.allocator.NewMemBatchWithFixedCapacity(outputTypes, coldata.BatchSize())
+}
+
+// newBufferSize returns the size of the ring buffer of the deque after it
+// grows.
+func (r *rangeMinMaxBase) newBufferSize() int {
+	newSize := 2 * len(r.orderVals)
+	if newSize < minMovingAggBufferSize {
+		newSize = minMovingAggBufferSize
+	}
+	return newSize
+}
+
+// growOrderVals increases the size of the orderVals ring buffer and moves the
+// front of the deque to the beginning of the new buffer. It must only be
+// called when the buffer is full.
+func (r *rangeMinMaxBase) growOrderVals(newSize int) {
+	r.allocator.AdjustMemoryUsage(int64(newSize-len(r.orderVals)) * int64(unsafe.Sizeof(time.Time{})))
+	newOrderVals := make([]time.Time, newSize)
+	n := copy(newOrderVals, r.orderVals[r.head:])
+	copy(newOrderVals[n:], r.orderVals[:r.head])
+	r.orderVals = newOrderVals
+}
+
+// startPeerGroup removes the values of the rows that are not in the window
+// frame of the peer group that begins with the tuple at position idx (after
+// applying the selection vector) from the deque.
+func (r *rangeMinMaxBase) startPeerGroup(orderCol coldata.Times, orderNulls *coldata.Nulls, idx int) {
+	isNull := orderNulls.MaybeHasNulls() && orderNulls.NullAt(idx)
+	if isNull || r.peerGroupOrderIsNull {
+		// The window frame of a row with NULL ordering value consists of its
+		// peers only, and such rows aren't in the window frame of any other
+		// row, so the deque is emptied.
+		r.head, r.numEntries = 0, 0
+	} else {
+		bound := r.frameBound(orderCol.Get(idx))
+		// Since the rows are sorted by the ordering column, the rows that leave
+		// the window frame are at the front of the deque.
+		for r.numEntries > 0 {
+			orderVal := r.orderVals[r.head]
+			if r.descending && !orderVal.After(bound) || !r.descending && !orderVal.Before(bound) {
+				break
+			}
+			r.head++
+			if r.head == len(r.orderVals) {
+				r.head = 0
+			}
+			r.numEntries--
+		}
+	}
+	if !isNull {
+		r.peerGroupOrderVal = orderCol.Get(idx)
+	}
+	r.peerGroupStarted = true
+	r.peerGroupOrderIsNull = isNull
+}
+
+// frameBound returns the boundary of the window frame of the peer group with
+// the given value of the ordering column. The computation matches the one
+// performed by the binary operators of the row-by-row engine.
+func (r *rangeMinMaxBase) frameBound(orderVal time.Time) time.Time {
+	if r.location != nil {
+		orderVal = orderVal.In(r.location)
+	}
+	bound := duration.Add(orderVal, r.offset).Round(time.Microsecond)
+	if bound.After(tree.MaxSupportedTime) || bound.Before(tree.MinSupportedTime) {
+		colexecerror.ExpectedError(errors.Newf(
+			"timestamp %q exceeds supported timestamp bounds", bound.Format(time.RFC3339),
+		))
+	}
+	return bound
+}
+
+// reset empties the window frame when a new partition begins.
+func (r *rangeMinMaxBase) reset() {
+	r.head, r.numEntries = 0, 0
+	r.peerGroupOrderIsNull = false
+}
+
+// bufferTuples buffers the tuples of the current batch in range [startIdx,
+// endIdx) (before applying the selection vector), all of which belong to the
+// current peer group.
+func (r *rangeMinMaxBase) bufferTuples(startIdx, endIdx int) {
+	n := endIdx - startIdx
+	if n == 0 {
+		return
+	}
+	r.scratch.ResetInternalBatch()
+	r.allocator.PerformOperation(r.scratch.ColVecs(), func() {
+		for colIdx, vec := range r.scratch.ColVecs() {
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         r.batch.ColVec(colIdx),
+						Sel:         r.batch.Selection(),
+						SrcStartIdx: startIdx,
+						SrcEndIdx:   endIdx,
+					},
+				},
+			)
+		}
+	})
+	r.scratch.SetLength(n)
+	r.bufferedTuples.Enqueue(r.Ctx, r.scratch)
+	r.numBuffered += n
+	r.peerGroupBuffered = true
+}
+
+// startEmitting transitions the operator to rangeMinMaxEmitting state.
+func (r *rangeMinMaxBase) startEmitting() {
+	if r.bufferedGroupEnded {
+		r.bufferedTuples.Enqueue(r.Ctx, coldata.ZeroBatch)
+		r.draining = true
+		r.bufferedGroupEnded = false
+	}
+	r.batchIdx = 0
+	r.state = rangeMinMaxEmitting
+}
+
+// copyToOutput copies n tuples of src starting at position srcStartIdx
+// (before applying sel) into the first numCols columns of the output batch
+// starting at position destIdx.
+func (r *rangeMinMaxBase) copyToOutput(
+	src coldata.Batch, sel []int, srcStartIdx, n, destIdx, numCols int,
+) {
+	vecs := r.output.ColVecs()[:numCols]
+	r.allocator.PerformOperation(vecs, func() {
+		for colIdx, vec := range vecs {
+			vec.Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         src.ColVec(colIdx),
+						Sel:         sel,
+						DestIdx:     destIdx,
+						SrcStartIdx: srcStartIdx,
+						SrcEndIdx:   srcStartIdx + n,
+					},
+				},
+			)
+		}
+	})
+}
+
+func (r *rangeMinMaxBase) Close(ctx context.Context) error {
+	if !r.CloserHelper.Close() || r.bufferedTuples == nil {
+		return nil
+	}
+	return r.bufferedTuples.Close(ctx)
+}
+
+// {{range .}}
+// {{$aggTitle := .AggTitle}}
+// {{range .Overloads}}
+// {{range .WidthOverloads}}
+
+type range_AGG_TITLE_TYPEOp struct {
+	rangeMinMaxBase
+	// values is the ring buffer with the values of the deque. Its element at
+	// position i is the value of the row with the ordering value orderVals[i].
+	values []_GOTYPE
+	// result is the result for the last peer group that has ended, and
+	// bufferedResult is the result for the buffered tuples.
+	result               _GOTYPE
+	resultIsNull         bool
+	bufferedResult       _GOTYPE
+	bufferedResultIsNull bool
+}
+
+var _ colexecop.ClosableOperator = &range_AGG_TITLE_TYPEOp{}
+
+func (r *range_AGG_TITLE_TYPEOp) Next() coldata.Batch {
+	for {
+		switch r.state {
+		case rangeMinMaxReading:
+			r.batch = r.Input.Next()
+			n := r.batch.Length()
+			if n == 0 {
+				r.inputDone = true
+				r.batch = nil
+				if !r.peerGroupStarted {
+					// The input is empty.
+					r.state = rangeMinMaxFinished
+					continue
+				}
+				// The last peer group has ended, and all of its tuples have
+				// been buffered.
+				r.finishPeerGroup()
+				r.startEmitting()
+				continue
+			}
+			r.processBatch()
+			if r.bufferedGroupEnded || r.resolvedEndIdx > 0 {
+				r.startEmitting()
+			} else {
+				// All tuples of the batch belong to the peer group that began
+				// in one of the previous batches.
+				r.bufferTuples(0 /* startIdx */, n)
+			}
+
+		case rangeMinMaxEmitting:
+			r.output.ResetInternalBatch()
+			outputLen := 0
+			for outputLen < r.output.Capacity() {
+				if r.draining {
+					if r.dequeued == nil || r.dequeuedIdx == r.dequeued.Length() {
+						var err error
+						r.dequeued, err = r.bufferedTuples.Dequeue(r.Ctx)
+						if err != nil {
+							colexecerror.InternalError(err)
+						}
+						r.dequeuedIdx = 0
+						if r.dequeued.Length() == 0 {
+							// All buffered tuples have been emitted.
+							r.bufferedTuples.Reset(r.Ctx)
+							r.numBuffered = 0
+							r.draining = false
+							r.dequeued = nil
+							continue
+						}
+					}
+					toCopy := r.dequeued.Length() - r.dequeuedIdx
+					if remaining := r.output.Capacity() - outputLen; toCopy > remaining {
+						toCopy = remaining
+					}
+					r.copyToOutput(r.dequeued, nil /* sel */, r.dequeuedIdx, toCopy, outputLen, len(r.inputTypes))
+					r.setBufferedResult(outputLen, outputLen+toCopy)
+					r.dequeuedIdx += toCopy
+					outputLen += toCopy
+					continue
+				}
+				if r.batch == nil || r.batchIdx == r.resolvedEndIdx {
+					break
+				}
+				toCopy := r.resolvedEndIdx - r.batchIdx
+				if remaining := r.output.Capacity() - outputLen; toCopy > remaining {
+					toCopy = remaining
+				}
+				// The results of these tuples have been written into the
+				// output column of the batch.
+				r.copyToOutput(r.batch, r.batch.Selection(), r.batchIdx, toCopy, outputLen, r.output.Width())
+				r.batchIdx += toCopy
+				outputLen += toCopy
+			}
+			if !r.draining && (r.batch == nil || r.batchIdx == r.resolvedEndIdx) {
+				// All tuples with known results have been emitted, so we buffer
+				// the tuples of the last peer group of the batch.
+				if r.batch != nil {
+					r.bufferTuples(r.resolvedEndIdx, r.batch.Length())
+				}
+				r.state = rangeMinMaxReading
+				if r.inputDone {
+					r.state = rangeMinMaxFinished
+				}
+			}
+			if outputLen > 0 {
+				r.output.SetLength(outputLen)
+				return r.output
+			}
+
+		case rangeMinMaxFinished:
+			if err := r.Close(r.Ctx); err != nil {
+				colexecerror.InternalError(err)
+			}
+			return coldata.ZeroBatch
+
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("range min max operator in unhandled state"))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	}
+}
+
+// processBatch pushes the values of the current batch into the deque and
+// writes the results for the tuples of all peer groups that end within the
+// batch into the output column. The tuples of the last peer group of the batch
+// are at positions [resolvedEndIdx, n) (before applying the selection vector).
+func (r *range_AGG_TITLE_TYPEOp) processBatch() {
+	batch := r.batch
+	n := batch.Length()
+	var partitionCol []bool
+	if r.partitionColIdx != tree.NoColumnIdx {
+		partitionCol = batch.ColVec(r.partitionColIdx).Bool()
+	}
+	peersCol := batch.ColVec(r.peersColIdx).Bool()
+	orderVec := batch.ColVec(r.orderColIdx)
+	orderCol, orderNulls := orderVec.Timestamp(), orderVec.Nulls()
+	argVec := batch.ColVec(r.argColIdx)
+	argCol, argNulls := argVec._TYPE(), argVec.Nulls()
+	outputVec := batch.ColVec(r.outputColIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol, outputNulls := outputVec._TYPE(), outputVec.Nulls()
+	// The last peer group of the previous batch might continue in this one.
+	r.peerGroupStartIdx = 0
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		sel := batch.Selection()
+		if argNulls.MaybeHasNulls() {
+			if sel != nil {
+				for i, idx := range sel[:n] {
+					_PROCESS_TUPLE(true)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					idx := i
+					_PROCESS_TUPLE(true)
+				}
+			}
+		} else {
+			if sel != nil {
+				for i, idx := range sel[:n] {
+					_PROCESS_TUPLE(false)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					idx := i
+					_PROCESS_TUPLE(false)
+				}
+			}
+		}
+	})
+	r.resolvedEndIdx = r.peerGroupStartIdx
+}
+
+// finishPeerGroup computes the result for the current peer group once it has
+// ended.
+func (r *range_AGG_TITLE_TYPEOp) finishPeerGroup() {
+	r.resultIsNull = r.numEntries == 0
+	if !r.resultIsNull {
+		execgen.COPYVAL(r.result, r.values[r.head])
+	}
+	if r.peerGroupBuffered {
+		r.bufferedResultIsNull = r.resultIsNull
+		execgen.COPYVAL(r.bufferedResult, r.result)
+		r.bufferedGroupEnded = true
+		r.peerGroupBuffered = false
+	}
+}
+
+// setBufferedResult sets the output values of the tuples of the output batch
+// in range [startIdx, endIdx), all of which have been buffered, to the
+// result for the buffered peer group.
+func (r *range_AGG_TITLE_TYPEOp) setBufferedResult(startIdx, endIdx int) {
+	outputVec := r.output.ColVec(r.outputColIdx)
+	if r.bufferedResultIsNull {
+		outputVec.Nulls().SetNullRange(startIdx, endIdx)
+		return
+	}
+	outputCol := outputVec._TYPE()
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := startIdx; i < endIdx; i++ {
+			execgen.SET(outputCol, i, r.bufferedResult)
+		}
+	})
+}
+
+// grow increases the size of the ring buffer. It must only be called when the
+// buffer is full.
+func (r *range_AGG_TITLE_TYPEOp) grow() {
+	newSize := r.newBufferSize()
+	r.allocator.AdjustMemoryUsage(int64(newSize-len(r.values)) * int64(unsafe.Sizeof(r.result)))
+	newValues := make([]_GOTYPE, newSize)
+	n := copy(newValues, r.values[r.head:])
+	copy(newValues[n:], r.values[:r.head])
+	r.values = newValues
+	r.growOrderVals(newSize)
+	r.head = 0
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _PROCESS_TUPLE is a code snippet that processes the tuple at position i
+// (idx after applying the selection vector): if the tuple begins a new peer
+// group, the results for the tuples of the current group in the batch are set
+// and the window frame is moved forward, and then the value of the tuple is
+// pushed into the deque.
+func _PROCESS_TUPLE(_HAS_NULLS bool) { // */}}
+	// {{define "processTuple" -}}
+	if peersCol[idx] {
+		// The tuple begins a new peer group, so the current one has ended.
+		if r.peerGroupStarted {
+			r.finishPeerGroup()
+			for j := r.peerGroupStartIdx; j < i; j++ {
+				outputIdx := j
+				if sel != nil {
+					outputIdx = sel[j]
+				}
+				if r.resultIsNull {
+					outputNulls.SetNull(outputIdx)
+				} else {
+					// {{with .Global}}
+					execgen.SET(outputCol, outputIdx, r.result)
+					// {{end}}
+				}
+			}
+		}
+		if partitionCol != nil && partitionCol[idx] {
+			r.reset()
+		}
+		r.startPeerGroup(orderCol, orderNulls, idx)
+		r.peerGroupStartIdx = i
+	}
+	// {{if .HasNulls}}
+	if !argNulls.NullAt(idx) {
+		// {{end}}
+		v := argCol.Get(idx)
+		// The values at the back of the deque that are not smaller (larger for
+		// MAX) than v can never be the result since they leave the window frame
+		// no later than v does, so we remove them.
+		for r.numEntries > 0 {
+			back := r.head + r.numEntries - 1
+			if back >= len(r.values) {
+				back -= len(r.values)
+			}
+			var keep bool
+			// {{with .Global}}
+			_ASSIGN_CMP(keep, r.values[back], v, _, argCol, _)
+			// {{end}}
+			if keep {
+				break
+			}
+			r.numEntries--
+		}
+		if r.numEntries == len(r.values) {
+			r.grow()
+		}
+		backIdx := r.head + r.numEntries
+		if backIdx >= len(r.values) {
+			backIdx -= len(r.values)
+		}
+		// {{with .Global}}
+		execgen.COPYVAL(r.values[backIdx], v)
+		// {{end}}
+		r.orderVals[backIdx] = r.peerGroupOrderVal
+		r.numEntries++
+		// {{if .HasNulls}}
+	}
+	// {{end}}
+	// {{end}}
+	// {{/*
+} // */}}
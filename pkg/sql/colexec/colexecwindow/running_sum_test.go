@@ -0,0 +1,245 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexecwindow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningSum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	days := func(d int64) duration.Duration {
+		return duration.MakeDuration(0 /* nanos */, d, 0 /* months */)
+	}
+	// The first column of the input tuples is the partition column which is
+	// true for the first tuple of each partition, and the second column is
+	// the argument of the running sum.
+	for _, tc := range []struct {
+		desc        string
+		argType     *types.T
+		noPartition bool
+		tuples      colexectestutils.Tuples
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc:        "no partition",
+			argType:     types.Int,
+			noPartition: true,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 2}, {true, 3}, {false, -4}, {true, 5},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1.0}, {false, 2, 3.0}, {true, 3, 6.0}, {false, -4, 2.0}, {true, 5, 7.0},
+			},
+		},
+		{
+			desc:    "partition boundaries",
+			argType: types.Int,
+			tuples: colexectestutils.Tuples{
+				{true, 1}, {false, 2}, {false, 3},
+				{true, 5},
+				{true, 6}, {false, 7},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1, 1.0}, {false, 2, 3.0}, {false, 3, 6.0},
+				{true, 5, 5.0},
+				{true, 6, 6.0}, {false, 7, 13.0},
+			},
+		},
+		{
+			desc:        "nulls",
+			argType:     types.Int,
+			noPartition: true,
+			tuples: colexectestutils.Tuples{
+				{true, nil}, {false, nil}, {false, 1}, {false, nil}, {false, 4}, {false, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{true, nil, nil}, {false, nil, nil}, {false, 1, 1.0}, {false, nil, 1.0}, {false, 4, 5.0}, {false, nil, 5.0},
+			},
+		},
+		{
+			desc:    "nulls across partitions",
+			argType: types.Float,
+			tuples: colexectestutils.Tuples{
+				{true, 1.5}, {false, nil},
+				{true, nil}, {false, -2.5}, {false, 0.25},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.5, 1.5}, {false, nil, 1.5},
+				{true, nil, nil}, {false, -2.5, -2.5}, {false, 0.25, -2.25},
+			},
+		},
+		{
+			desc:    "decimals",
+			argType: types.Decimal,
+			tuples: colexectestutils.Tuples{
+				{true, 1.25}, {false, nil}, {false, 2.5}, {true, 0.5},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 1.25, 1.25}, {false, nil, 1.25}, {false, 2.5, 3.75}, {true, 0.5, 0.5},
+			},
+		},
+		{
+			desc:    "int2",
+			argType: types.Int2,
+			tuples: colexectestutils.Tuples{
+				{true, 32767}, {false, 32767}, {true, -3},
+			},
+			expected: colexectestutils.Tuples{
+				{true, 32767, 32767.0}, {false, 32767, 65534.0}, {true, -3, -3.0},
+			},
+		},
+		{
+			desc:    "intervals",
+			argType: types.Interval,
+			tuples: colexectestutils.Tuples{
+				{true, days(1)}, {false, nil}, {false, days(3)}, {true, days(2)},
+			},
+			expected: colexectestutils.Tuples{
+				{true, days(1), days(1)}, {false, nil, days(1)}, {false, days(3), days(4)}, {true, days(2), days(2)},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			typs := []*types.T{types.Bool, tc.argType}
+			partitionColIdx := 0
+			if tc.noPartition {
+				partitionColIdx = tree.NoColumnIdx
+			}
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs},
+				tc.expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewRunningSumOperator(
+						testAllocator, inputs[0], typs, 1 /* argColIdx */, 2, /* outputColIdx */
+						partitionColIdx,
+					)
+				})
+		})
+	}
+}
+
+// TestRunningSumRandomized verifies the running sum operator on the inputs
+// that span many batches with NULLs interspersed.
+func TestRunningSumRandomized(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	numRows := 3*coldata.BatchSize() + 1
+	typs := []*types.T{types.Bool, types.Float}
+	for _, avgPartitionSize := range []int{1, 10, numRows} {
+		tuples := make(colexectestutils.Tuples, numRows)
+		expected := make(colexectestutils.Tuples, numRows)
+		var sum interface{}
+		for i := range tuples {
+			newPartition := i == 0 || rng.Intn(avgPartitionSize) == 0
+			if newPartition {
+				sum = nil
+			}
+			// Small integers are summed up exactly, so the order of the
+			// additions doesn't matter.
+			var arg interface{}
+			if rng.Float64() >= 0.2 {
+				v := float64(rng.Intn(100) - 50)
+				arg = v
+				if sum == nil {
+					sum = v
+				} else {
+					sum = sum.(float64) + v
+				}
+			}
+			tuples[i] = colexectestutils.Tuple{newPartition, arg}
+			expected[i] = colexectestutils.Tuple{newPartition, arg, sum}
+		}
+		t.Run(fmt.Sprintf("partitionSize=%d", avgPartitionSize), func(t *testing.T) {
+			colexectestutils.RunTestsWithTyps(
+				t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+				expected, colexectestutils.OrderedVerifier,
+				func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+					return NewRunningSumOperator(
+						testAllocator, inputs[0], typs, 1 /* argColIdx */, 2, /* outputColIdx */
+						0, /* partitionColIdx */
+					)
+				})
+		})
+	}
+}
+
+func TestIsRunningSum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	sum, avg := execinfrapb.Sum, execinfrapb.Avg
+	bound := func(typ execinfrapb.WindowerSpec_Frame_BoundType) *execinfrapb.WindowerSpec_Frame_Bound {
+		return &execinfrapb.WindowerSpec_Frame_Bound{BoundType: typ}
+	}
+	frame := func(
+		mode execinfrapb.WindowerSpec_Frame_Mode,
+		start execinfrapb.WindowerSpec_Frame_BoundType,
+		end *execinfrapb.WindowerSpec_Frame_Bound,
+		exclusion execinfrapb.WindowerSpec_Frame_Exclusion,
+	) *execinfrapb.WindowerSpec_Frame {
+		return &execinfrapb.WindowerSpec_Frame{
+			Mode:      mode,
+			Bounds:    execinfrapb.WindowerSpec_Frame_Bounds{Start: *bound(start), End: end},
+			Exclusion: exclusion,
+		}
+	}
+	const (
+		rows        = execinfrapb.WindowerSpec_Frame_ROWS
+		rangeMode   = execinfrapb.WindowerSpec_Frame_RANGE
+		unbounded   = execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING
+		offset      = execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING
+		currentRow  = execinfrapb.WindowerSpec_Frame_CURRENT_ROW
+		noExclusion = execinfrapb.WindowerSpec_Frame_NO_EXCLUSION
+	)
+	inputTypes := []*types.T{types.Int, types.String}
+	for _, tc := range []struct {
+		aggFn    execinfrapb.AggregatorSpec_Func
+		argIdx   uint32
+		frame    *execinfrapb.WindowerSpec_Frame
+		expected bool
+	}{
+		{aggFn: sum, frame: frame(rows, unbounded, nil, noExclusion), expected: true},
+		{aggFn: sum, frame: frame(rows, unbounded, bound(currentRow), noExclusion), expected: true},
+		{aggFn: avg, frame: frame(rows, unbounded, nil, noExclusion)},
+		{aggFn: sum, argIdx: 1, frame: frame(rows, unbounded, nil, noExclusion)},
+		{aggFn: sum, frame: nil},
+		{aggFn: sum, frame: frame(rangeMode, unbounded, nil, noExclusion)},
+		{aggFn: sum, frame: frame(rows, offset, nil, noExclusion)},
+		{aggFn: sum, frame: frame(rows, unbounded, bound(execinfrapb.WindowerSpec_Frame_UNBOUNDED_FOLLOWING), noExclusion)},
+		{aggFn: sum, frame: frame(rows, unbounded, nil, execinfrapb.WindowerSpec_Frame_EXCLUDE_CURRENT_ROW)},
+	} {
+		aggFn := tc.aggFn
+		wf := &execinfrapb.WindowerSpec_WindowFn{
+			Func:     execinfrapb.WindowerSpec_Func{AggregateFunc: &aggFn},
+			ArgsIdxs: []uint32{tc.argIdx},
+			Frame:    tc.frame,
+		}
+		require.Equal(t, tc.expected, IsRunningSum(wf, inputTypes), wf.String())
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for running_sum.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexecwindow
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ tree.AggType
+	_ = colexecerror.InternalError
+)
+
+// {{/*
+// Declarations to make the template compile properly
+
+// _ASSIGN_ADD is the template function for adding the second input to the
+// running sum in the first input. The third input is a scratch decimal.
+func _ASSIGN_ADD(_, _, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _COPY_SUM is the template function for copying the running sum in the
+// second input into the first input.
+func _COPY_SUM(_, _ string) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewRunningSumOperator creates a new Operator that computes the SUM aggregate
+// function used as a window function over the ROWS BETWEEN UNBOUNDED PRECEDING
+// AND CURRENT ROW window frame, i.e. the running total of the argument column
+// in the order in which the tuples arrive. Unlike the moving aggregate
+// operator, it doesn't need to remember the values in the window frame, so
+// only the running sum is maintained across batches. NULL values don't
+// contribute to the sum, and the result is NULL until the first non-NULL
+// value of the partition is seen. outputColIdx specifies in which coldata.Vec
+// the operator should put its output (if there is no such column, a new
+// column is appended).
+func NewRunningSumOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	argColIdx int,
+	outputColIdx int,
+	partitionColIdx int,
+) (colexecop.Operator, error) {
+	argType := inputTypes[argColIdx]
+	outputType := argType
+	if argType.Family() == types.IntFamily {
+		// The sum of integers is a decimal.
+		outputType = types.Decimal
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputColIdx)
+	base := runningSumBase{
+		OneInputHelper:  colexecop.MakeOneInputHelper(input),
+		allocator:       allocator,
+		argColIdx:       argColIdx,
+		outputColIdx:    outputColIdx,
+		partitionColIdx: partitionColIdx,
+	}
+	switch argType.Family() {
+	// {{range .}}
+	case _TYPE_FAMILY:
+		switch argType.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			// {{with .Overload}}
+			return &runningSum_TYPEOp{runningSumBase: base}, nil
+			// {{end}}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported running sum type %s", argType)
+}
+
+// runningSumBase extracts common fields of the running sum operators. Note
+// that it is not an operator itself and should not be used directly.
+type runningSumBase struct {
+	colexecop.OneInputHelper
+	allocator       *colmem.Allocator
+	argColIdx       int
+	outputColIdx    int
+	partitionColIdx int
+
+	// foundNonNull indicates whether a non-NULL value has been seen in the
+	// current partition.
+	foundNonNull bool
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+// {{with .Overload}}
+
+type runningSum_TYPEOp struct {
+	runningSumBase
+	// sum is the running sum of all non-NULL values seen so far in the
+	// current partition.
+	sum _RET_GOTYPE
+	// {{if .NeedsScratch}}
+	// {{/*
+	// scratch is only needed to convert integers to decimals when adding them
+	// to the running sum.
+	// */}}
+	scratch apd.Decimal
+	// {{end}}
+}
+
+var _ colexecop.Operator = &runningSum_TYPEOp{}
+
+func (r *runningSum_TYPEOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	var partitionCol []bool
+	if r.partitionColIdx != tree.NoColumnIdx {
+		partitionCol = batch.ColVec(r.partitionColIdx).Bool()
+	}
+	argVec := batch.ColVec(r.argColIdx)
+	argCol, argNulls := argVec._TYPE(), argVec.Nulls()
+	outputVec := batch.ColVec(r.outputColIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	r.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		outputCol := outputVec._RET_TYPE()
+		sel := batch.Selection()
+		if argNulls.MaybeHasNulls() {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_RUNNING_SUM(true)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_RUNNING_SUM(true)
+				}
+			}
+		} else {
+			if sel != nil {
+				for _, i := range sel[:n] {
+					_COMPUTE_RUNNING_SUM(false)
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					_COMPUTE_RUNNING_SUM(false)
+				}
+			}
+		}
+	})
+	return batch
+}
+
+// reset resets the running sum when a new partition begins.
+func (r *runningSum_TYPEOp) reset() {
+	var zero _RET_GOTYPE
+	r.sum = zero
+	r.foundNonNull = false
+}
+
+// {{end}}
+// {{end}}
+// {{end}}
+
+// {{/*
+// _COMPUTE_RUNNING_SUM is a code snippet that adds the value of the tuple at
+// index i to the running sum and sets the output of that tuple.
+func _COMPUTE_RUNNING_SUM(_HAS_NULLS bool) { // */}}
+	// {{define "computeRunningSum" -}}
+	if partitionCol != nil && partitionCol[i] {
+		r.reset()
+	}
+	// {{if .HasNulls}}
+	if !argNulls.NullAt(i) {
+		// {{end}}
+		v := argCol.Get(i)
+		_ASSIGN_ADD(r.sum, v, r.scratch)
+		r.foundNonNull = true
+		// {{if .HasNulls}}
+	}
+	// {{end}}
+	if r.foundNonNull {
+		_COPY_SUM(outputCol[i], r.sum)
+	} else {
+		outputNulls.SetNull(i)
+	}
+	// {{end}}
+	// {{/*
+} // */}}
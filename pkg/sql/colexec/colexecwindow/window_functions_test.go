@@ -243,6 +243,49 @@ func TestWindowFunctions(t *testing.T) {
 					},
 				},
 			},
+			// Peer groups are determined by all of the ORDER BY columns.
+			{
+				tuples: colexectestutils.Tuples{
+					{1, 2, 1}, {2, 1, 1}, {1, 1, 1}, {nil, 1, 2}, {1, 3, nil}, {2, 2, 2},
+					{1, 1, 2}, {2, 1, 1}, {1, 1, 1}, {nil, 1, 1}, {1, 2, 1}, {2, 1, 1},
+				},
+				expected: colexectestutils.Tuples{
+					{nil, 1, 1, 1}, {nil, 1, 2, 2},
+					{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 2, 3}, {1, 2, 1, 4}, {1, 2, 1, 4}, {1, 3, nil, 6},
+					{2, 1, 1, 1}, {2, 1, 1, 1}, {2, 1, 1, 1}, {2, 2, 2, 4},
+				},
+				windowerSpec: execinfrapb.WindowerSpec{
+					PartitionBy: []uint32{0},
+					WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+						{
+							Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &rankFn},
+							Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}, {ColIdx: 2}}},
+							OutputColIdx: 3,
+						},
+					},
+				},
+			},
+			{
+				tuples: colexectestutils.Tuples{
+					{1, 2, 1}, {2, 1, 1}, {1, 1, 1}, {nil, 1, 2}, {1, 3, nil}, {2, 2, 2},
+					{1, 1, 2}, {2, 1, 1}, {1, 1, 1}, {nil, 1, 1}, {1, 2, 1}, {2, 1, 1},
+				},
+				expected: colexectestutils.Tuples{
+					{nil, 1, 1, 1}, {nil, 1, 2, 2},
+					{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 2, 2}, {1, 2, 1, 3}, {1, 2, 1, 3}, {1, 3, nil, 4},
+					{2, 1, 1, 1}, {2, 1, 1, 1}, {2, 1, 1, 1}, {2, 2, 2, 2},
+				},
+				windowerSpec: execinfrapb.WindowerSpec{
+					PartitionBy: []uint32{0},
+					WindowFns: []execinfrapb.WindowerSpec_WindowFn{
+						{
+							Func:         execinfrapb.WindowerSpec_Func{WindowFunc: &denseRankFn},
+							Ordering:     execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 1}, {ColIdx: 2}}},
+							OutputColIdx: 3,
+						},
+					},
+				},
+			},
 			{
 				tuples:   colexectestutils.Tuples{{nil, 2}, {3, 2}, {1, nil}, {2, 1}, {nil, nil}, {1, 2}, {nil, 1}, {1, 3}, {nil, nil}, {3, 1}},
 				expected: colexectestutils.Tuples{{nil, nil, 0}, {nil, nil, 0}, {nil, 1, 2.0 / 3}, {nil, 2, 1}, {1, nil, 0}, {1, 2, 1.0 / 2}, {1, 3, 1}, {2, 1, 0}, {3, 1, 0}, {3, 2, 1}},
@@ -11,8 +11,13 @@
 package colexecwindow
 
 import (
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/errors"
 )
 
@@ -49,3 +54,175 @@ func WindowFnNeedsPeersInfo(windowFn execinfrapb.WindowerSpec_WindowFunc) bool {
 		return false
 	}
 }
+
+// MovingAggOffset returns the offset of the window frame if the given window
+// function is the AVG, SUM or COUNT aggregate function over the ROWS BETWEEN
+// offset PRECEDING AND CURRENT ROW window frame (with any frame exclusion)
+// that can be computed by the moving aggregate operator, or the MIN or MAX
+// aggregate function over such a window frame (without frame exclusion) that
+// can be computed by the moving MIN and MAX operator.
+func MovingAggOffset(
+	wf *execinfrapb.WindowerSpec_WindowFn, inputTypes []*types.T,
+) (offset uint64, ok bool) {
+	if wf.Func.AggregateFunc == nil {
+		return 0, false
+	}
+	if len(wf.ArgsIdxs) != 1 || int(wf.ArgsIdxs[0]) >= len(inputTypes) {
+		return 0, false
+	}
+	frame := wf.Frame
+	if frame == nil || frame.Mode != execinfrapb.WindowerSpec_Frame_ROWS {
+		return 0, false
+	}
+	argType := inputTypes[wf.ArgsIdxs[0]]
+	switch *wf.Func.AggregateFunc {
+	case execinfrapb.Avg, execinfrapb.Sum, execinfrapb.Count:
+		switch argType.Family() {
+		case types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily:
+		default:
+			return 0, false
+		}
+	case execinfrapb.Min, execinfrapb.Max:
+		if frame.Exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION {
+			return 0, false
+		}
+		switch typeconv.TypeFamilyToCanonicalTypeFamily(argType.Family()) {
+		case types.BoolFamily, types.IntFamily, types.FloatFamily, types.DecimalFamily,
+			types.TimestampTZFamily, types.IntervalFamily:
+		default:
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if frame.Bounds.Start.BoundType != execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING {
+		return 0, false
+	}
+	if end := frame.Bounds.End; end != nil && end.BoundType != execinfrapb.WindowerSpec_Frame_CURRENT_ROW {
+		return 0, false
+	}
+	return frame.Bounds.Start.IntOffset, true
+}
+
+// RangeMinMaxOffset returns the offset of the window frame if the given window
+// function is the MIN or MAX aggregate function over the RANGE BETWEEN offset
+// PRECEDING AND CURRENT ROW window frame (without frame exclusion) where the
+// rows are ordered by a single TIMESTAMP or TIMESTAMPTZ column that can be
+// computed by the range MIN and MAX operator.
+func RangeMinMaxOffset(
+	wf *execinfrapb.WindowerSpec_WindowFn, inputTypes []*types.T,
+) (offset duration.Duration, ok bool) {
+	if wf.Func.AggregateFunc == nil {
+		return duration.Duration{}, false
+	}
+	switch *wf.Func.AggregateFunc {
+	case execinfrapb.Min, execinfrapb.Max:
+	default:
+		return duration.Duration{}, false
+	}
+	if len(wf.ArgsIdxs) != 1 || int(wf.ArgsIdxs[0]) >= len(inputTypes) {
+		return duration.Duration{}, false
+	}
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(inputTypes[wf.ArgsIdxs[0]].Family()) {
+	case types.BoolFamily, types.IntFamily, types.FloatFamily, types.DecimalFamily,
+		types.TimestampTZFamily, types.IntervalFamily:
+	default:
+		return duration.Duration{}, false
+	}
+	if len(wf.Ordering.Columns) != 1 || int(wf.Ordering.Columns[0].ColIdx) >= len(inputTypes) {
+		return duration.Duration{}, false
+	}
+	switch inputTypes[wf.Ordering.Columns[0].ColIdx].Family() {
+	case types.TimestampFamily, types.TimestampTZFamily:
+	default:
+		return duration.Duration{}, false
+	}
+	frame := wf.Frame
+	if frame == nil || frame.Mode != execinfrapb.WindowerSpec_Frame_RANGE ||
+		frame.Exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION {
+		return duration.Duration{}, false
+	}
+	start := frame.Bounds.Start
+	if start.BoundType != execinfrapb.WindowerSpec_Frame_OFFSET_PRECEDING {
+		return duration.Duration{}, false
+	}
+	if end := frame.Bounds.End; end != nil && end.BoundType != execinfrapb.WindowerSpec_Frame_CURRENT_ROW {
+		return duration.Duration{}, false
+	}
+	if start.OffsetType.Type == nil || start.OffsetType.Type.Family() != types.IntervalFamily {
+		return duration.Duration{}, false
+	}
+	var da rowenc.DatumAlloc
+	datum, rem, err := rowenc.DecodeTableValue(&da, start.OffsetType.Type, start.TypedOffset)
+	if err != nil || len(rem) != 0 {
+		return duration.Duration{}, false
+	}
+	interval, isInterval := datum.(*tree.DInterval)
+	if !isInterval {
+		return duration.Duration{}, false
+	}
+	return interval.Duration, true
+}
+
+// MovingAggNeedsPeersInfo returns whether the moving aggregate operator needs
+// the information about the peer groups to compute the given window function.
+// This is the case when the peers of the current row are excluded from the
+// window frame.
+func MovingAggNeedsPeersInfo(wf *execinfrapb.WindowerSpec_WindowFn) bool {
+	if wf.Frame == nil {
+		return false
+	}
+	switch wf.Frame.Exclusion {
+	case execinfrapb.WindowerSpec_Frame_EXCLUDE_GROUP, execinfrapb.WindowerSpec_Frame_EXCLUDE_TIES:
+		return true
+	}
+	return false
+}
+
+// IsRunningSum returns whether the given window function is the SUM aggregate
+// function over the ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW window
+// frame (without frame exclusion) that can be computed by the running sum
+// operator.
+func IsRunningSum(wf *execinfrapb.WindowerSpec_WindowFn, inputTypes []*types.T) bool {
+	if wf.Func.AggregateFunc == nil || *wf.Func.AggregateFunc != execinfrapb.Sum {
+		return false
+	}
+	if len(wf.ArgsIdxs) != 1 || int(wf.ArgsIdxs[0]) >= len(inputTypes) {
+		return false
+	}
+	switch inputTypes[wf.ArgsIdxs[0]].Family() {
+	case types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily:
+	default:
+		return false
+	}
+	frame := wf.Frame
+	if frame == nil || frame.Mode != execinfrapb.WindowerSpec_Frame_ROWS ||
+		frame.Exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION {
+		return false
+	}
+	if frame.Bounds.Start.BoundType != execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING {
+		return false
+	}
+	end := frame.Bounds.End
+	return end == nil || end.BoundType == execinfrapb.WindowerSpec_Frame_CURRENT_ROW
+}
+
+// IsWholePartitionArrayAgg returns whether the given window function is the
+// ARRAY_AGG aggregate function over the window frame that spans the whole
+// partition (without frame exclusion) that can be computed by the array_agg
+// operator.
+func IsWholePartitionArrayAgg(wf *execinfrapb.WindowerSpec_WindowFn) bool {
+	if wf.Func.AggregateFunc == nil || *wf.Func.AggregateFunc != execinfrapb.ArrayAgg {
+		return false
+	}
+	if len(wf.ArgsIdxs) != 1 {
+		return false
+	}
+	frame := wf.Frame
+	if frame == nil || frame.Exclusion != execinfrapb.WindowerSpec_Frame_NO_EXCLUSION {
+		return false
+	}
+	return frame.Bounds.Start.BoundType == execinfrapb.WindowerSpec_Frame_UNBOUNDED_PRECEDING &&
+		frame.Bounds.End != nil &&
+		frame.Bounds.End.BoundType == execinfrapb.WindowerSpec_Frame_UNBOUNDED_FOLLOWING
+}
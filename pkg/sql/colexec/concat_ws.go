@@ -0,0 +1,126 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+)
+
+// newConcatWSOperator returns an operator that evaluates concat_ws() builtin.
+// The separator is expected at position argumentCols[0] unless sep is non-nil,
+// in which case the separator is constant. The remaining argumentCols are the
+// Bytes columns that are concatenated.
+func newConcatWSOperator(
+	allocator *colmem.Allocator,
+	sep *string,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &concatWSOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		sepIdx:         argumentCols[0],
+		argumentCols:   argumentCols[1:],
+		outputIdx:      outputIdx,
+	}
+	if sep != nil {
+		op.constSep = []byte(*sep)
+		op.sepIsConst = true
+	}
+	return op
+}
+
+// concatWSOp is an operator that concatenates the non-NULL values of several
+// Bytes columns separated by the separator. Unlike the concatenation via ||,
+// the NULL values are skipped, so a row in which all values are NULL results
+// in an empty string. The result is NULL only when the separator is NULL.
+type concatWSOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	// sepIsConst, if set, indicates that constSep is used as the separator.
+	// Otherwise, the separator is read from the column at position sepIdx.
+	sepIsConst   bool
+	constSep     []byte
+	sepIdx       int
+	argumentCols []int
+	outputIdx    int
+	// scratch is the buffer the results are written into before being set
+	// into the output vector. It is reused across rows and batches.
+	scratch []byte
+}
+
+var _ colexecop.Operator = &concatWSOp{}
+
+func (c *concatWSOp) Next() coldata.Batch {
+	batch := c.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	var sepCol *coldata.Bytes
+	var sepNulls *coldata.Nulls
+	if !c.sepIsConst {
+		sepVec := batch.ColVec(c.sepIdx)
+		sepCol = sepVec.Bytes()
+		if sepVec.MaybeHasNulls() {
+			sepNulls = sepVec.Nulls()
+		}
+	}
+	outputVec := batch.ColVec(c.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	outputNulls := outputVec.Nulls()
+	c.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				sep := c.constSep
+				if !c.sepIsConst {
+					if sepNulls != nil && sepNulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					sep = sepCol.Get(rowIdx)
+				}
+				c.scratch = c.scratch[:0]
+				first := true
+				for _, colIdx := range c.argumentCols {
+					vec := batch.ColVec(colIdx)
+					if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(rowIdx) {
+						continue
+					}
+					if !first {
+						c.scratch = append(c.scratch, sep...)
+					}
+					c.scratch = append(c.scratch, vec.Bytes().Get(rowIdx)...)
+					first = false
+				}
+				outputCol.Set(rowIdx, c.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestConcatWS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "constant separator",
+			expr: "concat_ws(', ', @1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"a", "b", "c"},
+				{nil, "b", "c"},
+				{"a", nil, "c"},
+				{"a", "b", nil},
+				{nil, "b", nil},
+				{nil, nil, nil},
+				{"", "", ""},
+			},
+			inputTypes: []*types.T{types.String, types.String, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"a", "b", "c", "a, b, c"},
+				{nil, "b", "c", "b, c"},
+				{"a", nil, "c", "a, c"},
+				{"a", "b", nil, "a, b"},
+				{nil, "b", nil, "b"},
+				{nil, nil, nil, ""},
+				{"", "", "", ", , "},
+			},
+		},
+		{
+			desc: "column separator",
+			expr: "concat_ws(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"-", "a", "b"},
+				{"", "a", "b"},
+				{"日本", "a", "b"},
+				{nil, "a", "b"},
+				{"-", nil, "b"},
+				{nil, nil, nil},
+			},
+			inputTypes: []*types.T{types.String, types.String, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"-", "a", "b", "a-b"},
+				{"", "a", "b", "ab"},
+				{"日本", "a", "b", "a日本b"},
+				{nil, "a", "b", nil},
+				{"-", nil, "b", "b"},
+				{nil, nil, nil, nil},
+			},
+		},
+		{
+			desc:         "constant arguments",
+			expr:         "concat_ws('-', @1, 'x', NULL, @1)",
+			inputTuples:  colexectestutils.Tuples{{"a"}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"a", "a-x-a"}, {nil, "x"}},
+		},
+		{
+			desc:         "separator only",
+			expr:         "concat_ws(@1)",
+			inputTuples:  colexectestutils.Tuples{{"a"}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"a", ""}, {nil, nil}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,570 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timetz"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/errors"
+)
+
+// dateTimeValue is an argument or the result of the date and time arithmetic
+// in the physical representation of the vectorized engine. Only the field
+// corresponding to the type of the value is used.
+type dateTimeValue struct {
+	// i is used by the Int values, the Date values (the number of days since
+	// the Unix epoch), and the Time values (the number of microseconds since
+	// midnight).
+	i int64
+	// t is used by the Timestamp and TimestampTZ values.
+	t time.Time
+	// d is used by the Interval values.
+	d duration.Duration
+	// ttz is used by the TimeTZ values.
+	ttz timetz.TimeTZ
+}
+
+// dateTimeArithFn computes the result of the date and time arithmetic on the
+// arguments l and r into res. loc is the session time zone.
+type dateTimeArithFn func(loc *time.Location, l, r, res *dateTimeValue) error
+
+type dateTimeArithSignature struct {
+	op          tree.BinaryOperator
+	left, right types.Family
+}
+
+type dateTimeArithImpl struct {
+	fn           dateTimeArithFn
+	outputFamily types.Family
+}
+
+// dateTimeArithImpls contains all of the date and time arithmetic operations
+// of the row engine on the arguments of different types (as well as the
+// subtraction of two times). Each of them performs the same computation as
+// the corresponding tree.BinOp, including the range checks and the usage of
+// the session time zone.
+//
+// The subtraction of two dates as well as the operations on the timestamps
+// and the intervals of the same type are handled by other operators.
+var dateTimeArithImpls = map[dateTimeArithSignature]dateTimeArithImpl{
+	{tree.Plus, types.DateFamily, types.IntFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.i, err = addDays(l.i, r.i)
+			return err
+		},
+		outputFamily: types.DateFamily,
+	},
+	{tree.Plus, types.IntFamily, types.DateFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.i, err = addDays(r.i, l.i)
+			return err
+		},
+		outputFamily: types.DateFamily,
+	},
+	{tree.Minus, types.DateFamily, types.IntFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			d, err := pgdate.MakeCompatibleDateFromDisk(l.i).SubDays(r.i)
+			res.i = d.UnixEpochDaysWithOrig()
+			return err
+		},
+		outputFamily: types.DateFamily,
+	},
+	{tree.Plus, types.DateFamily, types.TimeFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addToDate(l.i, time.Duration(r.i)*time.Microsecond)
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Plus, types.TimeFamily, types.DateFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addToDate(r.i, time.Duration(l.i)*time.Microsecond)
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Minus, types.DateFamily, types.TimeFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addToDate(l.i, -1*time.Duration(r.i)*time.Microsecond)
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Plus, types.DateFamily, types.TimeTZFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addToDate(l.i, r.ttz.ToDuration())
+			return err
+		},
+		outputFamily: types.TimestampTZFamily,
+	},
+	{tree.Plus, types.TimeTZFamily, types.DateFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addToDate(r.i, l.ttz.ToDuration())
+			return err
+		},
+		outputFamily: types.TimestampTZFamily,
+	},
+	{tree.Plus, types.DateFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addIntervalToDate(l.i, r.d)
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Plus, types.IntervalFamily, types.DateFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addIntervalToDate(r.i, l.d)
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Minus, types.DateFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = addIntervalToDate(l.i, r.d.Mul(-1))
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Plus, types.TimeFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.i = int64(timeofday.TimeOfDay(l.i).Add(r.d))
+			return nil
+		},
+		outputFamily: types.TimeFamily,
+	},
+	{tree.Plus, types.IntervalFamily, types.TimeFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.i = int64(timeofday.TimeOfDay(r.i).Add(l.d))
+			return nil
+		},
+		outputFamily: types.TimeFamily,
+	},
+	{tree.Minus, types.TimeFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.i = int64(timeofday.TimeOfDay(l.i).Add(r.d.Mul(-1)))
+			return nil
+		},
+		outputFamily: types.TimeFamily,
+	},
+	{tree.Minus, types.TimeFamily, types.TimeFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.d = timeofday.Difference(timeofday.TimeOfDay(l.i), timeofday.TimeOfDay(r.i))
+			return nil
+		},
+		outputFamily: types.IntervalFamily,
+	},
+	{tree.Plus, types.TimeTZFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.ttz = timetz.MakeTimeTZ(l.ttz.Add(r.d), l.ttz.OffsetSecs)
+			return nil
+		},
+		outputFamily: types.TimeTZFamily,
+	},
+	{tree.Plus, types.IntervalFamily, types.TimeTZFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.ttz = timetz.MakeTimeTZ(r.ttz.Add(l.d), r.ttz.OffsetSecs)
+			return nil
+		},
+		outputFamily: types.TimeTZFamily,
+	},
+	{tree.Minus, types.TimeTZFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) error {
+			res.ttz = timetz.MakeTimeTZ(l.ttz.Add(r.d.Mul(-1)), l.ttz.OffsetSecs)
+			return nil
+		},
+		outputFamily: types.TimeTZFamily,
+	},
+	{tree.Plus, types.TimestampFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(l.t, r.d))
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Plus, types.IntervalFamily, types.TimestampFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(r.t, l.d))
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	{tree.Minus, types.TimestampFamily, types.IntervalFamily}: {
+		fn: func(_ *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(l.t, r.d.Mul(-1)))
+			return err
+		},
+		outputFamily: types.TimestampFamily,
+	},
+	// The timestamps with time zones are converted into the session time
+	// zone before adding the intervals so that the days and the months are
+	// added according to the local calendar (e.g. adding one day across a
+	// DST transition doesn't always add 24 hours).
+	{tree.Plus, types.TimestampTZFamily, types.IntervalFamily}: {
+		fn: func(loc *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(l.t.In(loc), r.d))
+			return err
+		},
+		outputFamily: types.TimestampTZFamily,
+	},
+	{tree.Plus, types.IntervalFamily, types.TimestampTZFamily}: {
+		fn: func(loc *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(r.t.In(loc), l.d))
+			return err
+		},
+		outputFamily: types.TimestampTZFamily,
+	},
+	{tree.Minus, types.TimestampTZFamily, types.IntervalFamily}: {
+		fn: func(loc *time.Location, l, r, res *dateTimeValue) (err error) {
+			res.t, err = makeTimestamp(duration.Add(l.t.In(loc), r.d.Mul(-1)))
+			return err
+		},
+		outputFamily: types.TimestampTZFamily,
+	},
+	{tree.Minus, types.TimestampFamily, types.TimestampTZFamily}: {
+		fn: func(loc *time.Location, l, r, res *dateTimeValue) error {
+			stripped, err := stripTimeZone(r.t, loc)
+			if err != nil {
+				return err
+			}
+			res.d = duration.MakeDurationJustifyHours(l.t.Sub(stripped).Nanoseconds(), 0, 0)
+			return nil
+		},
+		outputFamily: types.IntervalFamily,
+	},
+	{tree.Minus, types.TimestampTZFamily, types.TimestampFamily}: {
+		fn: func(loc *time.Location, l, r, res *dateTimeValue) error {
+			stripped, err := stripTimeZone(l.t, loc)
+			if err != nil {
+				return err
+			}
+			res.d = duration.MakeDurationJustifyHours(stripped.Sub(r.t).Nanoseconds(), 0, 0)
+			return nil
+		},
+		outputFamily: types.IntervalFamily,
+	},
+}
+
+// addDays returns the date which is n days after the date represented by the
+// number of days since the Unix epoch.
+func addDays(days int64, n int64) (int64, error) {
+	d, err := pgdate.MakeCompatibleDateFromDisk(days).AddDays(n)
+	return d.UnixEpochDaysWithOrig(), err
+}
+
+// addToDate returns the timestamp which is d after the midnight of the date
+// represented by the number of days since the Unix epoch.
+func addToDate(days int64, d time.Duration) (time.Time, error) {
+	t, err := pgdate.MakeCompatibleDateFromDisk(days).ToTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return makeTimestamp(t.Add(d))
+}
+
+// addIntervalToDate returns the timestamp which is the interval d after the
+// midnight of the date represented by the number of days since the Unix
+// epoch.
+func addIntervalToDate(days int64, d duration.Duration) (time.Time, error) {
+	t, err := pgdate.MakeCompatibleDateFromDisk(days).ToTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return makeTimestamp(duration.Add(t, d))
+}
+
+// makeTimestamp rounds the timestamp to microseconds and checks that it is
+// within the supported bounds, same as tree.MakeDTimestamp.
+func makeTimestamp(t time.Time) (time.Time, error) {
+	ret := t.Round(time.Microsecond)
+	if ret.After(tree.MaxSupportedTime) || ret.Before(tree.MinSupportedTime) {
+		return time.Time{}, errors.Newf(
+			"timestamp %q exceeds supported timestamp bounds", ret.Format(time.RFC3339),
+		)
+	}
+	return ret, nil
+}
+
+// stripTimeZone returns the timestamp without a time zone that has the wall
+// clock time of the timestamp with a time zone t in the location loc. See
+// tree.DTimestampTZ.EvalAtTimeZone.
+func stripTimeZone(t time.Time, loc *time.Location) (time.Time, error) {
+	_, locOffset := t.In(loc).Zone()
+	return makeTimestamp(t.UTC().Add(time.Duration(locOffset) * time.Second).UTC())
+}
+
+// IsDateTimeArith returns whether the binary operator op on the arguments of
+// the given types is the date and time arithmetic supported by the operator
+// returned by GetDateTimeArithProjectionOperator.
+func IsDateTimeArith(op tree.Operator, leftType, rightType *types.T) bool {
+	binOp, ok := op.(tree.BinaryOperator)
+	if !ok {
+		return false
+	}
+	_, ok = dateTimeArithImpls[dateTimeArithSignature{binOp, leftType.Family(), rightType.Family()}]
+	return ok
+}
+
+// GetDateTimeArithProjectionOperator returns an operator that projects the
+// result of the date and time arithmetic (tree.Plus or tree.Minus) on the
+// arguments of types leftType and rightType into the column at position
+// resultIdx. The left argument is the constant constLeft if it is non-nil or
+// the column at position leftIdx, and similarly for the right argument.
+//
+// Unlike the generic projection operators which only see the physical
+// representation of the arguments (e.g. a date is represented as an integer),
+// this operator performs the same computation as the row engine, including
+// the range checks of the results and the usage of the session time zone.
+func GetDateTimeArithProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	op tree.Operator,
+	leftType, rightType, outputType *types.T,
+	leftIdx, rightIdx int,
+	constLeft, constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	var impl dateTimeArithImpl
+	binOp, ok := op.(tree.BinaryOperator)
+	if ok {
+		impl, ok = dateTimeArithImpls[dateTimeArithSignature{binOp, leftType.Family(), rightType.Family()}]
+	}
+	if !ok || impl.outputFamily != outputType.Family() {
+		return nil, errors.Errorf(
+			"unsupported date and time arithmetic %s %s %s into %s", leftType, op, rightType, outputType,
+		)
+	}
+	left, err := makeDateTimeArithArg(leftType, leftIdx, constLeft)
+	if err != nil {
+		return nil, err
+	}
+	right, err := makeDateTimeArithArg(rightType, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, resultIdx)
+	return &dateTimeArithProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		evalCtx:        evalCtx,
+		fn:             impl.fn,
+		left:           left,
+		right:          right,
+		outputFamily:   outputType.Family(),
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// dateTimeArithArg is one of the arguments of the date and time arithmetic.
+type dateTimeArithArg struct {
+	typ     *types.T
+	isConst bool
+	// constVal and constIsNull are only used if isConst is true, and colIdx
+	// otherwise.
+	constVal    dateTimeValue
+	constIsNull bool
+	colIdx      int
+	nulls       *coldata.Nulls
+	// Only one of the following is set for each batch depending on the type
+	// of the argument.
+	int16s    coldata.Int16s
+	int32s    coldata.Int32s
+	int64s    coldata.Int64s
+	times     coldata.Times
+	durations coldata.Durations
+	datums    coldata.DatumVec
+}
+
+func makeDateTimeArithArg(
+	typ *types.T, colIdx int, constArg tree.Datum,
+) (dateTimeArithArg, error) {
+	if constArg == nil {
+		return dateTimeArithArg{typ: typ, colIdx: colIdx}, nil
+	}
+	a := dateTimeArithArg{typ: typ, isConst: true}
+	if constArg == tree.DNull {
+		a.constIsNull = true
+		return a, nil
+	}
+	if constArg.ResolvedType().Family() != typ.Family() {
+		return dateTimeArithArg{}, errors.Errorf("unsupported date and time arithmetic argument %s", constArg)
+	}
+	switch d := constArg.(type) {
+	case *tree.DInt:
+		a.constVal.i = int64(*d)
+	case *tree.DDate:
+		a.constVal.i = d.UnixEpochDaysWithOrig()
+	case *tree.DTime:
+		a.constVal.i = int64(*d)
+	case *tree.DTimestamp:
+		a.constVal.t = d.Time
+	case *tree.DTimestampTZ:
+		a.constVal.t = d.Time
+	case *tree.DInterval:
+		a.constVal.d = d.Duration
+	case *tree.DTimeTZ:
+		a.constVal.ttz = d.TimeTZ
+	default:
+		return dateTimeArithArg{}, errors.Errorf("unsupported date and time arithmetic argument %s", constArg)
+	}
+	return a, nil
+}
+
+// init prepares the argument for the current batch. It must be called before
+// get.
+func (a *dateTimeArithArg) init(batch coldata.Batch) {
+	if a.isConst {
+		return
+	}
+	vec := batch.ColVec(a.colIdx)
+	a.nulls = vec.Nulls()
+	switch a.typ.Family() {
+	case types.IntFamily:
+		switch a.typ.Width() {
+		case 16:
+			a.int16s = vec.Int16()
+		case 32:
+			a.int32s = vec.Int32()
+		default:
+			a.int64s = vec.Int64()
+		}
+	case types.DateFamily:
+		a.int64s = vec.Int64()
+	case types.TimestampFamily, types.TimestampTZFamily:
+		a.times = vec.Timestamp()
+	case types.IntervalFamily:
+		a.durations = vec.Interval()
+	case types.TimeFamily, types.TimeTZFamily:
+		a.datums = vec.Datum()
+	}
+}
+
+// get reads the argument at position rowIdx into v and returns whether it is
+// non-NULL.
+func (a *dateTimeArithArg) get(rowIdx int, v *dateTimeValue) bool {
+	if a.isConst {
+		*v = a.constVal
+		return !a.constIsNull
+	}
+	if a.nulls.NullAt(rowIdx) {
+		return false
+	}
+	switch a.typ.Family() {
+	case types.IntFamily:
+		switch a.typ.Width() {
+		case 16:
+			v.i = int64(a.int16s[rowIdx])
+		case 32:
+			v.i = int64(a.int32s[rowIdx])
+		default:
+			v.i = a.int64s[rowIdx]
+		}
+	case types.DateFamily:
+		v.i = a.int64s[rowIdx]
+	case types.TimestampFamily, types.TimestampTZFamily:
+		v.t = a.times[rowIdx]
+	case types.IntervalFamily:
+		v.d = a.durations[rowIdx]
+	case types.TimeFamily:
+		v.i = int64(*a.datums.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DTime))
+	case types.TimeTZFamily:
+		v.ttz = a.datums.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DTimeTZ).TimeTZ
+	}
+	return true
+}
+
+// dateTimeArithProjOp is an operator that projects the result of the date
+// and time arithmetic. The result is NULL if either of the arguments is NULL.
+type dateTimeArithProjOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	evalCtx      *tree.EvalContext
+	fn           dateTimeArithFn
+	left, right  dateTimeArithArg
+	outputFamily types.Family
+	outputIdx    int
+}
+
+var _ colexecop.Operator = &dateTimeArithProjOp{}
+
+func (o *dateTimeArithProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	o.left.init(batch)
+	o.right.init(batch)
+	loc := o.evalCtx.GetLocation()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	var outputInt64s coldata.Int64s
+	var outputTimes coldata.Times
+	var outputDurations coldata.Durations
+	var outputDatums coldata.DatumVec
+	switch o.outputFamily {
+	case types.DateFamily:
+		outputInt64s = outputVec.Int64()
+	case types.TimestampFamily, types.TimestampTZFamily:
+		outputTimes = outputVec.Timestamp()
+	case types.IntervalFamily:
+		outputDurations = outputVec.Interval()
+	case types.TimeFamily, types.TimeTZFamily:
+		outputDatums = outputVec.Datum()
+	}
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		var l, r, res dateTimeValue
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if !o.left.get(rowIdx, &l) || !o.right.get(rowIdx, &r) {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			if err := o.fn(loc, &l, &r, &res); err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			switch o.outputFamily {
+			case types.DateFamily:
+				outputInt64s[rowIdx] = res.i
+			case types.TimestampFamily, types.TimestampTZFamily:
+				outputTimes[rowIdx] = res.t
+			case types.IntervalFamily:
+				outputDurations[rowIdx] = res.d
+			case types.TimeFamily:
+				outputDatums.Set(rowIdx, tree.MakeDTime(timeofday.TimeOfDay(res.i)))
+			case types.TimeTZFamily:
+				outputDatums.Set(rowIdx, &tree.DTimeTZ{TimeTZ: res.ttz})
+			}
+		}
+	})
+	return batch
+}
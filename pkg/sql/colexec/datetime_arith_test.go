@@ -0,0 +1,267 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timetz"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDateTimeArith verifies that all of the date and time arithmetic
+// operations of the row engine on the arguments of different types are
+// supported by the vectorized engine and return the same results (including
+// the errors) as the row engine in different session time zones.
+func TestDateTimeArith(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	date := func(days int64) tree.Datum {
+		return tree.NewDDate(pgdate.MakeCompatibleDateFromDisk(days))
+	}
+	// The first value of each type is used as the constant argument.
+	valuesByFamily := map[types.Family][]tree.Datum{
+		types.IntFamily: {
+			tree.NewDInt(3), tree.NewDInt(0), tree.NewDInt(-1), tree.NewDInt(1000000),
+			tree.NewDInt(math.MaxInt32), tree.NewDInt(math.MinInt32),
+		},
+		types.DateFamily: {
+			// 2021-03-14 and 2021-11-07 are the days of the DST transitions in
+			// America/New_York.
+			date(18700), date(18938), date(0), date(-1),
+			date(tree.MaxSupportedTime.Unix() / (24 * 60 * 60)),
+			date(pgdate.PosInfDate.UnixEpochDays()), date(pgdate.NegInfDate.UnixEpochDays()),
+		},
+		types.TimeFamily: {
+			tree.MakeDTime(timeofday.New(12, 30, 15, 123456)), tree.MakeDTime(timeofday.Min),
+			tree.MakeDTime(timeofday.New(23, 59, 59, 999999)), tree.MakeDTime(timeofday.Time2400),
+		},
+		types.TimeTZFamily: {
+			tree.NewDTimeTZFromOffset(timeofday.New(12, 30, 0, 0), 5*60*60),
+			tree.NewDTimeTZFromOffset(timeofday.Min, 0),
+			tree.NewDTimeTZFromOffset(timeofday.New(23, 59, 59, 999999), -8*60*60),
+			&tree.DTimeTZ{TimeTZ: timetz.MakeTimeTZ(timeofday.Time2400, timetz.MinTimeTZOffsetSecs)},
+		},
+		types.TimestampFamily: {
+			tree.MustMakeDTimestamp(time.Date(2021, 3, 14, 6, 30, 0, 0, time.UTC), time.Microsecond),
+			tree.MustMakeDTimestamp(time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC), time.Microsecond),
+			tree.MustMakeDTimestamp(time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC), time.Microsecond),
+			tree.MustMakeDTimestamp(tree.MinSupportedTime, time.Microsecond),
+			tree.MustMakeDTimestamp(tree.MaxSupportedTime, time.Microsecond),
+		},
+		types.IntervalFamily: {
+			tree.NewDInterval(duration.MakeDuration(0, 1, 0), types.DefaultIntervalTypeMetadata),
+			tree.NewDInterval(duration.MakeDuration(0, 0, 1), types.DefaultIntervalTypeMetadata),
+			tree.NewDInterval(duration.MakeDuration(-time.Hour.Nanoseconds(), -1, 0), types.DefaultIntervalTypeMetadata),
+			tree.NewDInterval(duration.MakeDuration(25*time.Hour.Nanoseconds(), 0, 0), types.DefaultIntervalTypeMetadata),
+			tree.NewDInterval(duration.MakeDuration(time.Microsecond.Nanoseconds(), 0, 0), types.DefaultIntervalTypeMetadata),
+			tree.NewDInterval(duration.MakeDuration(0, 0, 12*300000), types.DefaultIntervalTypeMetadata),
+		},
+	}
+	for _, d := range valuesByFamily[types.TimestampFamily] {
+		valuesByFamily[types.TimestampTZFamily] = append(
+			valuesByFamily[types.TimestampTZFamily],
+			tree.MustMakeDTimestampTZ(d.(*tree.DTimestamp).Time, time.Microsecond),
+		)
+	}
+	// The integers of all widths are supported.
+	typesByFamily := map[types.Family][]*types.T{
+		types.IntFamily:         {types.Int, types.Int4, types.Int2},
+		types.DateFamily:        {types.Date},
+		types.TimeFamily:        {types.Time},
+		types.TimeTZFamily:      {types.TimeTZ},
+		types.TimestampFamily:   {types.Timestamp},
+		types.TimestampTZFamily: {types.TimestampTZ},
+		types.IntervalFamily:    {types.Interval},
+	}
+
+	// valuesOfType returns the values of the given type.
+	valuesOfType := func(typ *types.T) []tree.Datum {
+		var res []tree.Datum
+		for _, d := range valuesByFamily[typ.Family()] {
+			if i, ok := d.(*tree.DInt); ok {
+				// Skip the integers which don't fit into the width of the type.
+				if width := typ.Width(); int64(*i) < -(1<<(width-1)) || int64(*i) > 1<<(width-1)-1 {
+					continue
+				}
+			}
+			res = append(res, d)
+		}
+		return res
+	}
+
+	var numOverloads int
+	for _, op := range []tree.BinaryOperator{tree.Plus, tree.Minus} {
+		for _, o := range tree.BinOps[op] {
+			overload := o.(*tree.BinOp)
+			leftFamily, rightFamily := overload.LeftType.Family(), overload.RightType.Family()
+			if valuesByFamily[leftFamily] == nil || valuesByFamily[rightFamily] == nil ||
+				(leftFamily == types.IntFamily && rightFamily != types.DateFamily) ||
+				(rightFamily == types.IntFamily && leftFamily != types.DateFamily) ||
+				(leftFamily == rightFamily && leftFamily != types.TimeFamily) {
+				// The operation on the arguments of the same type (other than
+				// the times) is handled by other operators.
+				continue
+			}
+			numOverloads++
+			for _, leftType := range typesByFamily[leftFamily] {
+				for _, rightType := range typesByFamily[rightFamily] {
+					require.True(t, IsDateTimeArith(op, leftType, rightType), "%s %s %s", leftType, op, rightType)
+					for _, locationName := range []string{"UTC", "America/New_York"} {
+						loc, err := timeutil.LoadLocation(locationName)
+						require.NoError(t, err)
+						evalCtx.SessionData.Location = loc
+						name := fmt.Sprintf("%s/%s %s %s", locationName, leftType, op, rightType)
+						log.Infof(ctx, "%s", name)
+						testDateTimeArith(
+							t, ctx, flowCtx, overload, op, leftType, rightType,
+							valuesOfType(leftType), valuesOfType(rightType),
+						)
+					}
+				}
+			}
+		}
+	}
+	// All of the date and time arithmetic operations are covered.
+	require.Equal(t, len(dateTimeArithImpls), numOverloads)
+}
+
+// testDateTimeArith runs the date and time arithmetic with the columns and the
+// constants of the given values and compares the results against the row
+// engine.
+func testDateTimeArith(
+	t *testing.T,
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	overload *tree.BinOp,
+	op tree.BinaryOperator,
+	leftType, rightType *types.T,
+	leftValues, rightValues []tree.Datum,
+) {
+	evalCtx := flowCtx.EvalCtx
+	leftConv, rightConv := colconv.GetDatumToPhysicalFn(leftType), colconv.GetDatumToPhysicalFn(rightType)
+	resultConv := colconv.GetDatumToPhysicalFn(overload.ReturnType)
+	// eval returns the result of the row engine as well as the error.
+	eval := func(l, r tree.Datum) (interface{}, error) {
+		if l == tree.DNull || r == tree.DNull {
+			return nil, nil
+		}
+		res, err := overload.Fn(evalCtx, l, r)
+		if err != nil {
+			return nil, err
+		}
+		return resultConv(res), nil
+	}
+	conv := func(d tree.Datum, conv func(tree.Datum) interface{}) interface{} {
+		if d == tree.DNull {
+			return nil
+		}
+		return conv(d)
+	}
+	leftValues = append(leftValues, tree.DNull)
+	rightValues = append(rightValues, tree.DNull)
+	runTest := func(inputTypes []*types.T, input, expected colexectestutils.Tuples, expr string) {
+		colexectestutils.RunTestsWithTyps(
+			t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{inputTypes},
+			expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], inputTypes, expr,
+					false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+	constExpr := func(d tree.Datum) string {
+		return tree.AsStringWithFlags(d, tree.FmtParsable)
+	}
+
+	// Both arguments are columns.
+	inputTypes := []*types.T{leftType, rightType}
+	var input, expected colexectestutils.Tuples
+	for _, l := range leftValues {
+		for _, r := range rightValues {
+			tuple := colexectestutils.Tuple{conv(l, leftConv), conv(r, rightConv)}
+			res, err := eval(l, r)
+			if err != nil {
+				// The same error must be returned by the vectorized engine.
+				errInput := colexectestutils.NewOpTestInput(
+					testAllocator, 1, colexectestutils.Tuples{tuple}, inputTypes,
+				)
+				proj, err2 := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, errInput, inputTypes, fmt.Sprintf("@1 %s @2", op),
+					false /* canFallbackToRowexec */, testMemAcc,
+				)
+				require.NoError(t, err2)
+				proj.Init(ctx)
+				err2 = colexecerror.CatchVectorizedRuntimeError(func() { proj.Next() })
+				require.EqualError(t, err2, err.Error(), "%s %s %s", l, op, r)
+				continue
+			}
+			input = append(input, tuple)
+			expected = append(expected, append(tuple, res))
+		}
+	}
+	runTest(inputTypes, input, expected, fmt.Sprintf("@1 %s @2", op))
+
+	// One of the arguments is constant.
+	for _, constLeft := range []bool{false, true} {
+		colType, constArg, values, colConv := leftType, rightValues[0], leftValues, leftConv
+		expr := fmt.Sprintf("@1 %s %s", op, constExpr(constArg))
+		if constLeft {
+			colType, constArg, values, colConv = rightType, leftValues[0], rightValues, rightConv
+			expr = fmt.Sprintf("%s %s @1", constExpr(constArg), op)
+		}
+		input, expected = nil, nil
+		for _, v := range values {
+			l, r := v, constArg
+			if constLeft {
+				l, r = constArg, v
+			}
+			res, err := eval(l, r)
+			if err != nil {
+				continue
+			}
+			tuple := colexectestutils.Tuple{conv(v, colConv)}
+			input = append(input, tuple)
+			expected = append(expected, append(tuple, res))
+		}
+		runTest([]*types.T{colType}, input, expected, expr)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/errors"
+)
+
+// errSubtractInfiniteDates matches the error returned by the row engine.
+var errSubtractInfiniteDates = pgerror.New(pgcode.DatetimeFieldOverflow, "cannot subtract infinite dates")
+
+// GetDateMinusProjectionOperator returns an operator that projects the number
+// of days between two dates (the result of the subtraction of the dates) into
+// the Int column at position resultIdx. The left argument is the constant
+// constLeft if it is non-nil or the Date column at position leftIdx, and
+// similarly for the right argument.
+//
+// The dates are stored as the number of days since the Unix epoch, so the
+// subtraction of the integers gives the correct result, but, unlike the
+// generic integer subtraction, the operator returns an error if any of the
+// dates is infinite, same as the row engine.
+func GetDateMinusProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	leftIdx, rightIdx int,
+	constLeft, constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	left, err := makeDateMinusArg(leftIdx, constLeft)
+	if err != nil {
+		return nil, err
+	}
+	right, err := makeDateMinusArg(rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, resultIdx)
+	return &dateMinusProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		left:           left,
+		right:          right,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// dateMinusArg is one of the arguments of the date subtraction.
+type dateMinusArg struct {
+	isConst bool
+	// constDays is only used if isConst is true, and colIdx otherwise.
+	constDays int64
+	colIdx    int
+}
+
+func makeDateMinusArg(colIdx int, constArg tree.Datum) (dateMinusArg, error) {
+	if constArg == nil {
+		return dateMinusArg{colIdx: colIdx}, nil
+	}
+	d, ok := constArg.(*tree.DDate)
+	if !ok {
+		return dateMinusArg{}, errors.Errorf("unsupported date subtraction argument %s", constArg)
+	}
+	return dateMinusArg{isConst: true, constDays: d.UnixEpochDays()}, nil
+}
+
+// dateMinusProjOp projects the result of the subtraction of two dates.
+type dateMinusProjOp struct {
+	colexecop.OneInputHelper
+	allocator   *colmem.Allocator
+	left, right dateMinusArg
+	outputIdx   int
+}
+
+var _ colexecop.Operator = &dateMinusProjOp{}
+
+// isInfiniteDate returns whether the number of days since the Unix epoch
+// represents an infinite date.
+func isInfiniteDate(days int64) bool {
+	return days == pgdate.PosInfDate.UnixEpochDays() || days == pgdate.NegInfDate.UnixEpochDays()
+}
+
+func (d *dateMinusProjOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	var leftNulls, rightNulls *coldata.Nulls
+	var leftCol, rightCol []int64
+	if !d.left.isConst {
+		vec := batch.ColVec(d.left.colIdx)
+		leftNulls, leftCol = vec.Nulls(), vec.Int64()
+	}
+	if !d.right.isConst {
+		vec := batch.ColVec(d.right.colIdx)
+		rightNulls, rightCol = vec.Nulls(), vec.Int64()
+	}
+	outputVec := batch.ColVec(d.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				l, r := d.left.constDays, d.right.constDays
+				if !d.left.isConst {
+					if leftNulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					l = leftCol[rowIdx]
+				}
+				if !d.right.isConst {
+					if rightNulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					r = rightCol[rowIdx]
+				}
+				if isInfiniteDate(l) || isInfiniteDate(r) {
+					colexecerror.ExpectedError(errSubtractInfiniteDates)
+				}
+				// This can't overflow because the finite dates are within the
+				// range of int32.
+				outputCol[rowIdx] = l - r
+			}
+		},
+	)
+	return batch
+}
+
+// newAgeOperator returns an operator that evaluates the two-argument age()
+// builtin on the TimestampTZ columns at positions endIdx and beginIdx.
+func newAgeOperator(
+	allocator *colmem.Allocator, endIdx, beginIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &ageOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		endIdx:         endIdx,
+		beginIdx:       beginIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// ageOp is an operator that returns the interval between two timestamps,
+// normalized into years, months and days. See duration.Age for more details.
+type ageOp struct {
+	colexecop.OneInputHelper
+	allocator        *colmem.Allocator
+	endIdx, beginIdx int
+	outputIdx        int
+}
+
+var _ colexecop.Operator = &ageOp{}
+
+func (a *ageOp) Next() coldata.Batch {
+	batch := a.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	endVec, beginVec := batch.ColVec(a.endIdx), batch.ColVec(a.beginIdx)
+	endNulls, endCol := endVec.Nulls(), endVec.Timestamp()
+	beginNulls, beginCol := beginVec.Nulls(), beginVec.Timestamp()
+	outputVec := batch.ColVec(a.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Interval()
+	a.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if endNulls.NullAt(rowIdx) || beginNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol[rowIdx] = duration.Age(endCol[rowIdx], beginCol[rowIdx])
+			}
+		},
+	)
+	return batch
+}
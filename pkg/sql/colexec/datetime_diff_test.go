@@ -0,0 +1,143 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateMinusAndAge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The dates are represented as the number of days since the Unix epoch.
+	const jan1st2021, mar1st2021 = 18628, 18687
+	ts := func(s string) time.Time {
+		res, err := time.Parse("2006-01-02 15:04:05.999999", s)
+		require.NoError(t, err)
+		return res
+	}
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "date - date",
+			expr: "@1 - @2",
+			inputTuples: colexectestutils.Tuples{
+				{mar1st2021, jan1st2021}, {jan1st2021, mar1st2021}, {jan1st2021, jan1st2021},
+				{jan1st2021, nil}, {nil, jan1st2021},
+			},
+			inputTypes: []*types.T{types.Date, types.Date},
+			outputTuples: colexectestutils.Tuples{
+				{mar1st2021, jan1st2021, 59}, {jan1st2021, mar1st2021, -59}, {jan1st2021, jan1st2021, 0},
+				{jan1st2021, nil, nil}, {nil, jan1st2021, nil},
+			},
+		},
+		{
+			desc: "date - constant date",
+			expr: "@1 - '2021-01-01'::DATE",
+			inputTuples: colexectestutils.Tuples{
+				{mar1st2021}, {nil},
+			},
+			inputTypes: []*types.T{types.Date},
+			outputTuples: colexectestutils.Tuples{
+				{mar1st2021, 59}, {nil, nil},
+			},
+		},
+		{
+			desc: "age",
+			expr: "age(@1, @2)",
+			// The cases around the month and year boundaries (including the
+			// leap years) where the days are borrowed from the months.
+			inputTuples: colexectestutils.Tuples{
+				{ts("2021-03-01 00:00:00"), ts("2021-01-31 00:00:00")},
+				{ts("2021-03-31 00:00:00"), ts("2021-02-28 00:00:00")},
+				{ts("2020-03-01 00:00:00"), ts("2020-02-28 12:00:00")},
+				{ts("2021-01-01 00:00:00"), ts("2020-12-31 23:59:59.999999")},
+				{ts("2021-01-31 00:00:00"), ts("2021-03-01 00:00:00")},
+				{ts("2024-02-29 00:00:00"), ts("2021-02-28 00:00:00")},
+				{ts("2021-01-01 00:00:00"), nil},
+				{nil, ts("2021-01-01 00:00:00")},
+			},
+			inputTypes: []*types.T{types.TimestampTZ, types.TimestampTZ},
+			outputTuples: colexectestutils.Tuples{
+				{ts("2021-03-01 00:00:00"), ts("2021-01-31 00:00:00"), duration.MakeDuration(0, 1, 1)},
+				{ts("2021-03-31 00:00:00"), ts("2021-02-28 00:00:00"), duration.MakeDuration(0, 3, 1)},
+				{ts("2020-03-01 00:00:00"), ts("2020-02-28 12:00:00"), duration.MakeDuration(12*time.Hour.Nanoseconds(), 1, 0)},
+				{ts("2021-01-01 00:00:00"), ts("2020-12-31 23:59:59.999999"), duration.MakeDuration(time.Microsecond.Nanoseconds(), 0, 0)},
+				{ts("2021-01-31 00:00:00"), ts("2021-03-01 00:00:00"), duration.MakeDuration(0, -1, -1)},
+				{ts("2024-02-29 00:00:00"), ts("2021-02-28 00:00:00"), duration.MakeDuration(0, 1, 36)},
+				{ts("2021-01-01 00:00:00"), nil, nil},
+				{nil, ts("2021-01-01 00:00:00"), nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The subtraction of infinite dates results in the same error as in the
+	// row engine.
+	typs := []*types.T{types.Date, types.Date}
+	for _, tc := range []colexectestutils.Tuple{
+		{pgdate.PosInfDate.UnixEpochDays(), jan1st2021},
+		{jan1st2021, pgdate.NegInfDate.UnixEpochDays()},
+		{pgdate.PosInfDate.UnixEpochDays(), pgdate.PosInfDate.UnixEpochDays()},
+	} {
+		input := colexectestutils.NewOpTestInput(
+			testAllocator, 1, colexectestutils.Tuples{tc}, typs,
+		)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, "@1 - @2", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, "cannot subtract infinite dates")
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// newDecimalRoundOperator returns an operator that evaluates round() or
+// trunc() builtin on the decimal column at position argumentCols[0]. The
+// optional second argument is the Int64 column with the number of digits to
+// keep after the decimal point, and only round() supports it.
+//
+// All computations are performed with the decimal context ctx which must be
+// the same as the one used by the row engine for the builtin, so that the
+// results have exactly the same coefficients and exponents.
+func newDecimalRoundOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	ctx *apd.Context,
+	trunc bool,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	scaleIdx := -1
+	if len(argumentCols) > 1 {
+		scaleIdx = argumentCols[1]
+	}
+	return &decimalRoundOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		ctx:            ctx,
+		trunc:          trunc,
+		inputIdx:       argumentCols[0],
+		scaleIdx:       scaleIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// decimalRoundOp is an operator that rounds (half away from zero) or truncates
+// decimals to the given number of digits after the decimal point (zero if not
+// specified).
+type decimalRoundOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	ctx       *apd.Context
+	trunc     bool
+	inputIdx  int
+	// scaleIdx is the index of the column with the scale to round to, -1 if
+	// the scale is zero.
+	scaleIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &decimalRoundOp{}
+
+func (d *decimalRoundOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(d.inputIdx)
+	inputNulls, inputCol := inputVec.Nulls(), inputVec.Decimal()
+	var scaleNulls *coldata.Nulls
+	var scaleCol coldata.Int64s
+	if d.scaleIdx >= 0 {
+		scaleVec := batch.ColVec(d.scaleIdx)
+		scaleNulls, scaleCol = scaleVec.Nulls(), scaleVec.Int64()
+	}
+	outputVec := batch.ColVec(d.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Decimal()
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if inputNulls.NullAt(rowIdx) || (scaleNulls != nil && scaleNulls.NullAt(rowIdx)) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				if d.trunc {
+					inputCol[rowIdx].Modf(&outputCol[rowIdx], nil /* frac */)
+					continue
+				}
+				var scale int32
+				if scaleCol != nil {
+					// Same as the row engine, the results are undefined if the
+					// scale doesn't fit into int32.
+					scale = int32(scaleCol.Get(rowIdx))
+				}
+				if _, err := d.ctx.Quantize(&outputCol[rowIdx], &inputCol[rowIdx], -scale); err != nil {
+					colexecerror.ExpectedError(d.funcExpr.MaybeWrapError(err))
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// newDecimalModOperator returns an operator that evaluates mod() builtin on
+// the decimal columns at positions argumentCols. The remainder is computed with
+// the decimal context ctx which must be the same as the one used by the row
+// engine.
+func newDecimalModOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	ctx *apd.Context,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &decimalModOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		ctx:            ctx,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+}
+
+// decimalModOp is an operator that computes the remainder of the division of
+// two decimals. Same as in the row engine, the division by zero results in an
+// error.
+type decimalModOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	funcExpr     *tree.FuncExpr
+	ctx          *apd.Context
+	argumentCols []int
+	outputIdx    int
+}
+
+var _ colexecop.Operator = &decimalModOp{}
+
+func (d *decimalModOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	xVec, yVec := batch.ColVec(d.argumentCols[0]), batch.ColVec(d.argumentCols[1])
+	xNulls, xCol := xVec.Nulls(), xVec.Decimal()
+	yNulls, yCol := yVec.Nulls(), yVec.Decimal()
+	outputVec := batch.ColVec(d.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Decimal()
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if xNulls.NullAt(rowIdx) || yNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				y := &yCol[rowIdx]
+				if y.Sign() == 0 {
+					colexecerror.ExpectedError(d.funcExpr.MaybeWrapError(tree.ErrDivByZero))
+				}
+				if _, err := d.ctx.Rem(&outputCol[rowIdx], &xCol[rowIdx], y); err != nil {
+					colexecerror.ExpectedError(d.funcExpr.MaybeWrapError(err))
+				}
+			}
+		},
+	)
+	return batch
+}
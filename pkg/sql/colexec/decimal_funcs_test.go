@@ -0,0 +1,242 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	dec := func(s string) apd.Decimal {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		return *d
+	}
+	decimal52 := types.MakeDecimal(5, 2)
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "round",
+			expr: "round(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{dec("2.49")}, {dec("2.50")}, {dec("-2.50")}, {dec("0.00")}, {dec("123.45")}, {nil},
+			},
+			inputTypes: []*types.T{decimal52},
+			outputTuples: colexectestutils.Tuples{
+				{dec("2.49"), dec("2")}, {dec("2.50"), dec("3")}, {dec("-2.50"), dec("-3")},
+				{dec("0.00"), dec("0")}, {dec("123.45"), dec("123")}, {nil, nil},
+			},
+		},
+		{
+			// The results have exactly the requested number of digits after the
+			// decimal point, regardless of the scale of the input.
+			desc: "round with scale",
+			expr: "round(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{dec("1.25"), 1}, {dec("-1.25"), 1}, {dec("1.25"), 4}, {dec("123.45"), -2},
+				{dec("1.25"), 0}, {nil, 1}, {dec("1.25"), nil},
+			},
+			inputTypes: []*types.T{decimal52, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{dec("1.25"), 1, dec("1.3")}, {dec("-1.25"), 1, dec("-1.3")}, {dec("1.25"), 4, dec("1.2500")},
+				{dec("123.45"), -2, dec("1E+2")}, {dec("1.25"), 0, dec("1")}, {nil, 1, nil}, {dec("1.25"), nil, nil},
+			},
+		},
+		{
+			desc: "trunc",
+			expr: "trunc(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{dec("2.99")}, {dec("-2.99")}, {dec("0.50")}, {dec("1E+2")}, {nil},
+			},
+			inputTypes: []*types.T{decimal52},
+			outputTuples: colexectestutils.Tuples{
+				{dec("2.99"), dec("2")}, {dec("-2.99"), dec("-2")}, {dec("0.50"), dec("0")},
+				{dec("1E+2"), dec("1E+2")}, {nil, nil},
+			},
+		},
+		{
+			// The sign of the result is the same as the sign of the dividend.
+			desc: "mod",
+			expr: "mod(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{dec("10.50"), dec("3.00")}, {dec("-10.50"), dec("3.00")}, {dec("10.50"), dec("-3.00")},
+				{dec("0.01"), dec("3.00")}, {nil, dec("1.00")}, {dec("1.00"), nil},
+			},
+			inputTypes: []*types.T{decimal52, decimal52},
+			outputTuples: colexectestutils.Tuples{
+				{dec("10.50"), dec("3.00"), dec("1.50")}, {dec("-10.50"), dec("3.00"), dec("-1.50")},
+				{dec("10.50"), dec("-3.00"), dec("1.50")}, {dec("0.01"), dec("3.00"), dec("0.01")},
+				{nil, dec("1.00"), nil}, {dec("1.00"), nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	t.Run("mod by zero", func(t *testing.T) {
+		input := colexectestutils.Tuples{{dec("1.00"), dec("0.00")}}
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, input, []*types.T{decimal52, decimal52}),
+			[]*types.T{decimal52, decimal52}, "mod(@1, @2)", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "division by zero")
+	})
+}
+
+// TestDecimalFuncsAgainstRowEngine verifies that the vectorized round(),
+// trunc() and mod() produce exactly the same decimals (including the number of
+// trailing zeroes) as the row engine on the columns of different precisions
+// and scales.
+func TestDecimalFuncsAgainstRowEngine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+	rng, _ := randutil.NewPseudoRand()
+
+	// randDecimal returns a random non-zero decimal that fits into a column
+	// of typ.
+	randDecimal := func(typ *types.T) apd.Decimal {
+		var d apd.Decimal
+		coeff := rng.Int63n(pow10(typ.Precision())-1) + 1
+		if rng.Intn(2) == 0 {
+			coeff = -coeff
+		}
+		d.SetFinite(coeff, -typ.Scale())
+		return d
+	}
+
+	const numRows = 100
+	for _, typ := range []*types.T{
+		types.MakeDecimal(5, 2), types.MakeDecimal(10, 0), types.MakeDecimal(18, 9), types.MakeDecimal(18, 18),
+	} {
+		for _, expr := range []string{"round(@1)", "round(@1, @2)", "trunc(@1)", "mod(@1, @3)"} {
+			t.Run(fmt.Sprintf("%s/%s", typ.SQLString(), expr), func(t *testing.T) {
+				inputTypes := []*types.T{typ, types.Int, typ}
+				parsed, err := parser.ParseExpr(expr)
+				require.NoError(t, err)
+				semaCtx := tree.MakeSemaContext()
+				semaCtx.IVarContainer = &colexectestutils.MockTypeContext{Typs: inputTypes}
+				typedExpr, err := tree.TypeCheck(ctx, parsed, &semaCtx, types.Any)
+				require.NoError(t, err)
+				funcExpr := typedExpr.(*tree.FuncExpr)
+				argIdxs := make([]int, len(funcExpr.Exprs))
+				for i, e := range funcExpr.Exprs {
+					argIdxs[i] = e.(*tree.IndexedVar).Idx
+				}
+
+				input := make(colexectestutils.Tuples, numRows)
+				expected := make([]string, numRows)
+				for i := range input {
+					x, y := randDecimal(typ), randDecimal(typ)
+					scale := rng.Intn(int(2*typ.Scale())+6) - 3
+					input[i] = colexectestutils.Tuple{x, scale, y}
+					datums := tree.Datums{&tree.DDecimal{Decimal: x}, tree.NewDInt(tree.DInt(scale)), &tree.DDecimal{Decimal: y}}
+					args := make(tree.Datums, len(argIdxs))
+					for j, idx := range argIdxs {
+						args[j] = datums[idx]
+					}
+					res, err := funcExpr.ResolvedOverload().Fn(&evalCtx, args)
+					require.NoError(t, err)
+					expected[i] = res.String()
+				}
+
+				op, err := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), input, inputTypes),
+					inputTypes, expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+				require.NoError(t, err)
+				op.Init(ctx)
+				// The decimals are compared by their string representations
+				// which include the trailing zeroes and the sign of zero.
+				var rowIdx int
+				for b := op.Next(); b.Length() > 0; b = op.Next() {
+					outputCol := b.ColVec(len(inputTypes)).Decimal()
+					for i := 0; i < b.Length(); i++ {
+						j := i
+						if sel := b.Selection(); sel != nil {
+							j = sel[i]
+						}
+						actual := outputCol.Get(j)
+						require.Equal(t, expected[rowIdx], actual.String(), "input: %s", input[rowIdx])
+						rowIdx++
+					}
+				}
+				require.Equal(t, numRows, rowIdx)
+			})
+		}
+	}
+}
+
+func pow10(n int32) int64 {
+	res := int64(1)
+	for i := int32(0); i < n; i++ {
+		res *= 10
+	}
+	return res
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestDefaultOnNullOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		tuples     colexectestutils.Tuples
+		inputTypes []*types.T
+		typ        *types.T
+		// colIdx is the index of the column to apply the default to, -1 if the
+		// column is missing.
+		colIdx int
+		// defaultExpr, if set, is the default expression that is planned as a
+		// sub-projection. Otherwise, defaultVal is the constant default.
+		defaultExpr string
+		defaultVal  interface{}
+		expected    colexectestutils.Tuples
+	}{
+		{
+			desc:       "constant int default",
+			tuples:     colexectestutils.Tuples{{1}, {nil}, {3}, {nil}},
+			inputTypes: []*types.T{types.Int},
+			typ:        types.Int,
+			colIdx:     0,
+			defaultVal: int64(42),
+			expected:   colexectestutils.Tuples{{1}, {42}, {3}, {42}},
+		},
+		{
+			desc:       "constant string default",
+			tuples:     colexectestutils.Tuples{{nil}, {"a"}, {nil}, {"bc"}},
+			inputTypes: []*types.T{types.String},
+			typ:        types.String,
+			colIdx:     0,
+			defaultVal: []byte("default"),
+			expected:   colexectestutils.Tuples{{"default"}, {"a"}, {"default"}, {"bc"}},
+		},
+		{
+			desc:       "constant decimal default",
+			tuples:     colexectestutils.Tuples{{nil}, {*apd.New(15, -1)}},
+			inputTypes: []*types.T{types.Decimal},
+			typ:        types.Decimal,
+			colIdx:     0,
+			defaultVal: *apd.New(0, 0),
+			expected:   colexectestutils.Tuples{{*apd.New(0, 0)}, {*apd.New(15, -1)}},
+		},
+		{
+			desc:       "missing column with constant default",
+			tuples:     colexectestutils.Tuples{{1}, {nil}},
+			inputTypes: []*types.T{types.Int},
+			typ:        types.String,
+			colIdx:     -1,
+			defaultVal: []byte("default"),
+			expected:   colexectestutils.Tuples{{"default"}, {"default"}},
+		},
+		{
+			desc:        "default expression",
+			tuples:      colexectestutils.Tuples{{1, 10}, {nil, 20}, {3, nil}, {nil, nil}},
+			inputTypes:  []*types.T{types.Int, types.Int},
+			typ:         types.Int,
+			colIdx:      0,
+			defaultExpr: "@2 * 2",
+			expected:    colexectestutils.Tuples{{1}, {40}, {3}, {nil}},
+		},
+		{
+			desc:        "missing column with default expression",
+			tuples:      colexectestutils.Tuples{{1, "a"}, {nil, nil}, {3, "c"}},
+			inputTypes:  []*types.T{types.Int, types.String},
+			typ:         types.String,
+			colIdx:      -1,
+			defaultExpr: "@2 || 'x'",
+			expected:    colexectestutils.Tuples{{"ax"}, {nil}, {"cx"}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		// The output doesn't depend on the input when the column is missing
+		// and the default is constant, so we skip the all nulls injection.
+		colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{tc.inputTypes}, tc.expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				input, defaultIdx, outputIdx := inputs[0], -1, len(tc.inputTypes)
+				typs := tc.inputTypes[:len(tc.inputTypes):len(tc.inputTypes)]
+				if tc.defaultExpr != "" {
+					var err error
+					input, err = colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input, tc.inputTypes, tc.defaultExpr,
+						false /* canFallbackToRowexec */, testMemAcc,
+					)
+					if err != nil {
+						return nil, err
+					}
+					defaultIdx, outputIdx = outputIdx, outputIdx+1
+					// The default expression is of the same type as the
+					// column.
+					typs = append(typs, tc.typ)
+				}
+				op, err := NewDefaultOnNullOp(
+					testAllocator, input, tc.typ, tc.colIdx, defaultIdx, tc.defaultVal, outputIdx,
+				)
+				if err != nil {
+					return nil, err
+				}
+				// We will project out all other columns in order to have test
+				// cases be less verbose.
+				return colexecbase.NewSimpleProjectOp(op, append(typs, tc.typ), []uint32{uint32(outputIdx)}), nil
+			})
+	}
+}
+
+func TestCoalesceWithConstantDefault(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		tuples     colexectestutils.Tuples
+		renderExpr string
+		inputTypes []*types.T
+		expected   colexectestutils.Tuples
+	}{
+		{
+			tuples:     colexectestutils.Tuples{{1}, {nil}, {3}},
+			renderExpr: "COALESCE(@1, 0)",
+			inputTypes: []*types.T{types.Int},
+			expected:   colexectestutils.Tuples{{1}, {0}, {3}},
+		},
+		{
+			tuples:     colexectestutils.Tuples{{1, 2}, {nil, 3}, {nil, nil}},
+			renderExpr: "COALESCE(@1 + @2, -1)",
+			inputTypes: []*types.T{types.Int, types.Int},
+			expected:   colexectestutils.Tuples{{3}, {-1}, {-1}},
+		},
+		{
+			tuples:     colexectestutils.Tuples{{"a"}, {nil}},
+			renderExpr: "IFNULL(@1, 'b')",
+			inputTypes: []*types.T{types.String},
+			expected:   colexectestutils.Tuples{{"a"}, {"b"}},
+		},
+	} {
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				op, err := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, inputs[0], tc.inputTypes, tc.renderExpr,
+					false /* canFallbackToRowexec */, testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				// COALESCE and IFNULL are of the type of their arguments.
+				outputTypes := append(tc.inputTypes[:len(tc.inputTypes):len(tc.inputTypes)], tc.inputTypes[0])
+				return colexecbase.NewSimpleProjectOp(op, outputTypes, []uint32{uint32(len(tc.inputTypes))}), nil
+			})
+	}
+}
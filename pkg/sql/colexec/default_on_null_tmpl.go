@@ -0,0 +1,226 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for default_on_null.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ json.JSON
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// _GOTYPE is the template variable.
+type _GOTYPE interface{}
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+// */}}
+
+// NewDefaultOnNullOp creates a new operator that projects the column of type t
+// at index colIdx into outputIdx, filling in the default value on the rows
+// where the column is NULL. This is used for applying the column defaults.
+//
+// If defaultIdx is -1, then the default is the constant value defaultVal.
+// Otherwise, the default values are read from the column at index defaultIdx
+// which is expected to have been populated by a sub-projection of the default
+// expression.
+//
+// colIdx can be -1 which indicates that the column is missing, in which case
+// the default is used for all rows.
+func NewDefaultOnNullOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	t *types.T,
+	colIdx int,
+	defaultIdx int,
+	defaultVal interface{},
+	outputIdx int,
+) (colexecop.Operator, error) {
+	if colIdx < 0 {
+		if defaultIdx < 0 {
+			return colexecbase.NewConstOp(allocator, input, t, defaultVal, outputIdx)
+		}
+		// The default column is used as is for all rows, and since NULL
+		// values in it are propagated to the output, we can use it as the
+		// "input" column too.
+		colIdx = defaultIdx
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, t, outputIdx)
+	base := defaultOnNullOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			if defaultIdx < 0 {
+				return &defaultOnNull_TYPEConstOp{
+					defaultOnNullOpBase: base,
+					defaultVal:          defaultVal.(_GOTYPE),
+				}, nil
+			}
+			return &defaultOnNull_TYPEOp{
+				defaultOnNullOpBase: base,
+				defaultIdx:          defaultIdx,
+			}, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported default on null type %s", t.Name())
+}
+
+type defaultOnNullOpBase struct {
+	colexecop.OneInputHelper
+
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+// defaultOnNull_TYPEConstOp fills in the constant default value on the rows
+// where the input column is NULL.
+type defaultOnNull_TYPEConstOp struct {
+	defaultOnNullOpBase
+	defaultVal _GOTYPE
+}
+
+var _ colexecop.Operator = &defaultOnNull_TYPEConstOp{}
+
+func (d *defaultOnNull_TYPEConstOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(d.colIdx)
+	nulls, col := vec.Nulls(), vec.TemplateType()
+	outputVec := batch.ColVec(d.outputIdx)
+	outputCol := outputVec.TemplateType()
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					execgen.SET(outputCol, rowIdx, d.defaultVal)
+				} else {
+					v := col.Get(rowIdx)
+					execgen.SET(outputCol, rowIdx, v)
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// defaultOnNull_TYPEOp fills in the default value read from the default column
+// on the rows where the input column is NULL.
+type defaultOnNull_TYPEOp struct {
+	defaultOnNullOpBase
+	defaultIdx int
+}
+
+var _ colexecop.Operator = &defaultOnNull_TYPEOp{}
+
+func (d *defaultOnNull_TYPEOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(d.colIdx)
+	nulls, col := vec.Nulls(), vec.TemplateType()
+	defaultVec := batch.ColVec(d.defaultIdx)
+	defaultNulls, defaultCol := defaultVec.Nulls(), defaultVec.TemplateType()
+	outputVec := batch.ColVec(d.outputIdx)
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.TemplateType()
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputNulls.UnsetNulls()
+	}
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if !nulls.NullAt(rowIdx) {
+					v := col.Get(rowIdx)
+					execgen.SET(outputCol, rowIdx, v)
+				} else if !defaultNulls.NullAt(rowIdx) {
+					v := defaultCol.Get(rowIdx)
+					execgen.SET(outputCol, rowIdx, v)
+				} else {
+					outputNulls.SetNull(rowIdx)
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
+// {{end}}
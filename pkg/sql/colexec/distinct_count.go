@@ -0,0 +1,152 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// NewDistinctCountOp returns an operator that counts the number of distinct
+// tuples of the input on the distinct columns and outputs a batch with a
+// single integer column containing a single integer, the count. It is
+// equivalent to the distinct operator followed by the count operator, but the
+// deduplicated batches are never emitted.
+//
+// If the input is ordered on all of the distinct columns, the boundaries
+// between the groups of identical tuples are counted. Otherwise, the distinct
+// tuples are accumulated in a hash table, and its size is the result. Note
+// that in the latter case the operator doesn't spill to disk.
+func NewDistinctCountOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	distinctCols []uint32,
+	orderedCols []uint32,
+	typs []*types.T,
+) (colexecop.Operator, error) {
+	internalBatch := allocator.NewMemBatchWithFixedCapacity(
+		[]*types.T{types.Int}, 1, /* capacity */
+	)
+	if len(orderedCols) == len(distinctCols) {
+		op, distinctCol, err := colexecbase.OrderedDistinctColsToOperators(input, distinctCols, typs)
+		if err != nil {
+			return nil, err
+		}
+		return &orderedDistinctCountOp{
+			OneInputHelper: colexecop.MakeOneInputHelper(op),
+			distinctCol:    distinctCol,
+			internalBatch:  internalBatch,
+		}, nil
+	}
+	return &unorderedDistinctCountOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		distinctCols:   distinctCols,
+		typs:           typs,
+		internalBatch:  internalBatch,
+	}, nil
+}
+
+// orderedDistinctCountOp counts the number of tuples that start a new group
+// of identical tuples according to the chain of the ordered distinct
+// operators.
+type orderedDistinctCountOp struct {
+	colexecop.OneInputHelper
+
+	// distinctCol is the output column of the chain of the ordered distinct
+	// operators which indicates whether the tuple is the first one in its
+	// group.
+	distinctCol   []bool
+	internalBatch coldata.Batch
+	done          bool
+}
+
+var _ colexecop.Operator = &orderedDistinctCountOp{}
+
+func (c *orderedDistinctCountOp) Next() coldata.Batch {
+	if c.done {
+		return coldata.ZeroBatch
+	}
+	var count int64
+	for {
+		batch := c.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			break
+		}
+		if sel := batch.Selection(); sel != nil {
+			for _, i := range sel[:n] {
+				if c.distinctCol[i] {
+					count++
+				}
+			}
+		} else {
+			for _, distinct := range c.distinctCol[:n] {
+				if distinct {
+					count++
+				}
+			}
+		}
+	}
+	c.done = true
+	c.internalBatch.ResetInternalBatch()
+	c.internalBatch.ColVec(0).Int64()[0] = count
+	c.internalBatch.SetLength(1)
+	return c.internalBatch
+}
+
+// unorderedDistinctCountOp appends the distinct tuples of the input into a
+// hash table (same as the unordered distinct does) and emits the number of
+// tuples in it once the input is exhausted.
+type unorderedDistinctCountOp struct {
+	colexecop.OneInputHelper
+
+	allocator     *colmem.Allocator
+	distinctCols  []uint32
+	typs          []*types.T
+	ht            *colexechash.HashTable
+	internalBatch coldata.Batch
+	done          bool
+}
+
+var _ colexecop.Operator = &unorderedDistinctCountOp{}
+
+func (c *unorderedDistinctCountOp) Init(ctx context.Context) {
+	if !c.InitHelper.Init(ctx) {
+		return
+	}
+	c.Input.Init(c.Ctx)
+	c.ht = newUnorderedDistinctHashTable(c.Ctx, c.allocator, c.typs, c.distinctCols)
+}
+
+func (c *unorderedDistinctCountOp) Next() coldata.Batch {
+	if c.done {
+		return coldata.ZeroBatch
+	}
+	for {
+		batch := c.Input.Next()
+		if batch.Length() == 0 {
+			break
+		}
+		c.ht.DistinctBuild(batch)
+	}
+	c.done = true
+	c.internalBatch.ResetInternalBatch()
+	c.internalBatch.ColVec(0).Int64()[0] = int64(c.ht.Vals.Length())
+	c.internalBatch.SetLength(1)
+	return c.internalBatch
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// newDistinctThenCount returns the plan that the distinct count operator
+// replaces: the distinct operator followed by the count operator.
+func newDistinctThenCount(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	distinctCols []uint32,
+	orderedCols []uint32,
+	typs []*types.T,
+) (colexecop.Operator, error) {
+	var distinct colexecop.Operator
+	if len(orderedCols) == len(distinctCols) {
+		var err error
+		distinct, err = colexecbase.NewOrderedDistinct(input, distinctCols, typs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		distinct = NewUnorderedDistinct(allocator, input, distinctCols, typs)
+	}
+	return NewCountOp(allocator, distinct), nil
+}
+
+// runDistinctCountTest verifies that the distinct count operator produces the
+// same count as the distinct operator followed by the count operator.
+func runDistinctCountTest(
+	t *testing.T,
+	tups colexectestutils.Tuples,
+	typs []*types.T,
+	distinctCols []uint32,
+	orderedCols []uint32,
+	expectedCount int,
+) {
+	ctx := context.Background()
+	input := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), tups, typs)
+	separate, err := newDistinctThenCount(testAllocator, input, distinctCols, orderedCols, typs)
+	require.NoError(t, err)
+	separate.Init(ctx)
+	expected := colexectestutils.Tuples{colexectestutils.GetTupleFromBatch(separate.Next(), 0)}
+	require.Equal(t, colexectestutils.Tuples{{expectedCount}}.String(), expected.String())
+
+	runTests := colexectestutils.RunTestsWithTyps
+	if expectedCount == 1 {
+		// The input with all NULLs also has a single distinct tuple.
+		runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+	}
+	runTests(t, testAllocator, []colexectestutils.Tuples{tups}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return NewDistinctCountOp(testAllocator, input[0], distinctCols, orderedCols, typs)
+		})
+}
+
+func TestDistinctCount(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	for _, tc := range distinctTestCases {
+		log.Infof(context.Background(), "unordered")
+		runDistinctCountTest(t, tc.tuples, tc.typs, tc.distinctCols, nil /* orderedCols */, len(tc.expected))
+		if tc.isOrderedOnDistinctCols {
+			log.Info(context.Background(), "ordered")
+			runDistinctCountTest(t, tc.tuples, tc.typs, tc.distinctCols, tc.distinctCols, len(tc.expected))
+		}
+	}
+}
+
+func TestDistinctCountRandom(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	rng, _ := randutil.NewPseudoRand()
+	nCols := 1 + rng.Intn(3)
+	typs := make([]*types.T, nCols)
+	distinctCols := make([]uint32, nCols)
+	for i := range typs {
+		typs[i] = types.Int
+		distinctCols[i] = uint32(i)
+	}
+	nTuples := 1 + rng.Intn(4*coldata.BatchSize())
+	tups, expected := generateRandomDataForUnorderedDistinct(rng, nTuples, nCols, rng.Float64())
+	log.Infof(context.Background(), "unordered")
+	runDistinctCountTest(t, tups, typs, distinctCols, nil /* orderedCols */, len(expected))
+	// The generated tuples are shuffled, so we need to sort them for the
+	// ordered variant.
+	sort.Slice(tups, func(i, j int) bool {
+		for k := range tups[i] {
+			if tups[i][k].(int) != tups[j][k].(int) {
+				return tups[i][k].(int) < tups[j][k].(int)
+			}
+		}
+		return false
+	})
+	log.Info(context.Background(), "ordered")
+	runDistinctCountTest(t, tups, typs, distinctCols, distinctCols, len(expected))
+}
+
+func BenchmarkDistinctCount(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	for _, fused := range []bool{false, true} {
+		newOp := newDistinctThenCount
+		namePrefix := "DistinctThenCount"
+		if fused {
+			newOp = NewDistinctCountOp
+			namePrefix = "DistinctCount"
+		}
+		for _, ordered := range []bool{false, true} {
+			name := namePrefix + "/Unordered"
+			if ordered {
+				name = namePrefix + "/Ordered"
+			}
+			runDistinctBenchmarks(
+				ctx,
+				b,
+				func(allocator *colmem.Allocator, input colexecop.Operator, distinctCols []uint32, numOrderedCols int, typs []*types.T) (colexecop.Operator, error) {
+					return newOp(allocator, input, distinctCols, distinctCols[:numOrderedCols], typs)
+				},
+				func(nCols int) int {
+					if ordered {
+						return nCols
+					}
+					return 0
+				},
+				name,
+				false, /* isExternal */
+			)
+		}
+	}
+}
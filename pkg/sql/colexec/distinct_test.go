@@ -17,11 +17,15 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecagg"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -420,3 +424,61 @@ func BenchmarkDistinct(b *testing.B) {
 		)
 	}
 }
+
+// BenchmarkDistinctVsHashGroupBy compares the unordered distinct against the
+// hash aggregator that only outputs the grouping columns (i.e. GROUP BY without
+// any aggregates) on the same input. The vectorized engine plans the latter as
+// the former.
+func BenchmarkDistinctVsHashGroupBy(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+
+	runDistinctBenchmarks(
+		ctx,
+		b,
+		func(allocator *colmem.Allocator, input colexecop.Operator, distinctCols []uint32, _ int, typs []*types.T) (colexecop.Operator, error) {
+			return NewUnorderedDistinct(allocator, input, distinctCols, typs), nil
+		},
+		func(int) int { return 0 },
+		"Distinct",
+		false, /* isExternal */
+	)
+	runDistinctBenchmarks(
+		ctx,
+		b,
+		func(allocator *colmem.Allocator, input colexecop.Operator, distinctCols []uint32, _ int, typs []*types.T) (colexecop.Operator, error) {
+			spec := &execinfrapb.AggregatorSpec{
+				Type:         execinfrapb.AggregatorSpec_NON_SCALAR,
+				GroupCols:    distinctCols,
+				Aggregations: make([]execinfrapb.AggregatorSpec_Aggregation, len(distinctCols)),
+			}
+			for i, col := range distinctCols {
+				spec.Aggregations[i] = execinfrapb.AggregatorSpec_Aggregation{
+					Func: execinfrapb.AnyNotNull, ColIdx: []uint32{col},
+				}
+			}
+			constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+				&evalCtx, nil /* semaCtx */, spec.Aggregations, typs,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return NewHashAggregator(&colexecagg.NewAggregatorArgs{
+				Allocator:      allocator,
+				MemAccount:     testMemAcc,
+				Input:          input,
+				InputTypes:     typs,
+				Spec:           spec,
+				EvalCtx:        &evalCtx,
+				Constructors:   constructors,
+				ConstArguments: constArguments,
+				OutputTypes:    outputTypes,
+			}, nil /* newSpillingQueueArgs */)
+		},
+		func(int) int { return 0 },
+		"HashGroupBy",
+		false, /* isExternal */
+	)
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// enumCmpBase evaluates the comparison operator (one of =, <>, <, <=, > and
+// >=) on the enum column at position leftIdx and either the enum column at
+// position rightIdx or the constant enum constRight.
+type enumCmpBase struct {
+	op      tree.ComparisonOperator
+	leftIdx int
+	// rightIdx is only used if constRight is nil.
+	rightIdx   int
+	constRight []byte
+}
+
+func makeEnumCmpBase(
+	op tree.ComparisonOperator, leftIdx, rightIdx int, constRight tree.Datum,
+) (enumCmpBase, error) {
+	switch op {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+	default:
+		return enumCmpBase{}, errors.AssertionFailedf("unexpected enum comparison operator %s", op)
+	}
+	b := enumCmpBase{
+		op:       op,
+		leftIdx:  leftIdx,
+		rightIdx: rightIdx,
+	}
+	if constRight != nil {
+		d, ok := constRight.(*tree.DEnum)
+		if !ok {
+			return enumCmpBase{}, errors.Errorf("unsupported enum comparison argument %s", constRight)
+		}
+		b.constRight = d.PhysicalRep
+	}
+	return b, nil
+}
+
+// eval returns the result of the comparison on the row at position rowIdx.
+// The result is NULL if either of the arguments is NULL.
+func (b *enumCmpBase) eval(leftVec, rightVec coldata.Vec, rowIdx int) (res bool, isNull bool) {
+	if leftVec.Nulls().NullAt(rowIdx) {
+		return false, true
+	}
+	left := leftVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DEnum).PhysicalRep
+	right := b.constRight
+	if right == nil {
+		if rightVec.Nulls().NullAt(rowIdx) {
+			return false, true
+		}
+		right = rightVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DEnum).PhysicalRep
+	}
+	// The physical representations of the enum members are ordered the same
+	// way as the members were declared, so comparing them directly gives the
+	// same result as the row engine without looking up the enum metadata.
+	cmp := bytes.Compare(left, right)
+	switch b.op {
+	case tree.EQ:
+		return cmp == 0, false
+	case tree.NE:
+		return cmp != 0, false
+	case tree.LT:
+		return cmp < 0, false
+	case tree.LE:
+		return cmp <= 0, false
+	case tree.GT:
+		return cmp > 0, false
+	default:
+		return cmp >= 0, false
+	}
+}
+
+// vecs returns the vectors of the arguments. The right vector is nil if the
+// right argument is constant.
+func (b *enumCmpBase) vecs(batch coldata.Batch) (leftVec, rightVec coldata.Vec) {
+	leftVec = batch.ColVec(b.leftIdx)
+	if b.constRight == nil {
+		rightVec = batch.ColVec(b.rightIdx)
+	}
+	return leftVec, rightVec
+}
+
+// GetEnumCmpProjectionOperator returns an operator that projects the result
+// of the comparison operator op (one of =, <>, <, <=, > and >=) into the Bool
+// column at position resultIdx. The left argument is the enum column at
+// position leftIdx, and the right argument is either the constant constRight
+// if it is non-nil or the enum column at position rightIdx.
+func GetEnumCmpProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	op tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeEnumCmpBase(op, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &enumCmpProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		enumCmpBase:    base,
+		allocator:      allocator,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// GetEnumCmpOperator returns an operator that selects the tuples for which the
+// comparison operator op (one of =, <>, <, <=, > and >=) evaluates to true.
+// The arguments are the same as in GetEnumCmpProjectionOperator.
+func GetEnumCmpOperator(
+	input colexecop.Operator,
+	op tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeEnumCmpBase(op, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	return &enumCmpSelOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		enumCmpBase:    base,
+	}, nil
+}
+
+type enumCmpProjOp struct {
+	colexecop.OneInputHelper
+	enumCmpBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &enumCmpProjOp{}
+
+func (o *enumCmpProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	leftVec, rightVec := o.vecs(batch)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(leftVec, rightVec, rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type enumCmpSelOp struct {
+	colexecop.OneInputHelper
+	enumCmpBase
+}
+
+var _ colexecop.Operator = &enumCmpSelOp{}
+
+func (o *enumCmpSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		leftVec, rightVec := o.vecs(batch)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumCmp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The declared order of the members differs from the order of their
+	// labels, so the comparisons must not be performed on the labels.
+	enumType := colexectestutils.MakeTestEnumType("small", "medium", "large")
+	mustMake := func(label string) *tree.DEnum {
+		d, err := tree.MakeDEnumFromLogicalRepresentation(enumType, label)
+		require.NoError(t, err)
+		return d
+	}
+	small, medium, large := mustMake("small"), mustMake("medium"), mustMake("large")
+
+	typs := []*types.T{enumType, enumType}
+	inputTuples := colexectestutils.Tuples{
+		{small, small},
+		{small, medium},
+		{medium, small},
+		{small, large},
+		{large, medium},
+		{nil, small},
+		{large, nil},
+	}
+	withResults := func(results ...interface{}) colexectestutils.Tuples {
+		var tuples colexectestutils.Tuples
+		for i, tuple := range inputTuples {
+			tuples = append(tuples, colexectestutils.Tuple{tuple[0], tuple[1], results[i]})
+		}
+		return tuples
+	}
+
+	for _, tc := range []struct {
+		expr         string
+		outputTuples colexectestutils.Tuples
+	}{
+		{expr: "@1 = @2", outputTuples: withResults(true, false, false, false, false, nil, nil)},
+		{expr: "@1 != @2", outputTuples: withResults(false, true, true, true, true, nil, nil)},
+		{expr: "@1 < @2", outputTuples: withResults(false, true, false, true, false, nil, nil)},
+		{expr: "@1 <= @2", outputTuples: withResults(true, true, false, true, false, nil, nil)},
+		{expr: "@1 > @2", outputTuples: withResults(false, false, true, false, true, nil, nil)},
+		{expr: "@1 >= @2", outputTuples: withResults(true, false, true, false, true, nil, nil)},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The enums can't be spelled out in the expressions, so the operators
+	// with the constant are created directly.
+	constInput := colexectestutils.Tuples{{small}, {medium}, {large}, {nil}}
+	for _, tc := range []struct {
+		op           tree.ComparisonOperator
+		outputTuples colexectestutils.Tuples
+	}{
+		{op: tree.EQ, outputTuples: colexectestutils.Tuples{{small, false}, {medium, true}, {large, false}, {nil, nil}}},
+		{op: tree.LT, outputTuples: colexectestutils.Tuples{{small, true}, {medium, false}, {large, false}, {nil, nil}}},
+		{op: tree.GE, outputTuples: colexectestutils.Tuples{{small, false}, {medium, true}, {large, true}, {nil, nil}}},
+	} {
+		log.Infof(ctx, "%s medium", tc.op)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{constInput}, [][]*types.T{{enumType}}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetEnumCmpProjectionOperator(
+					testAllocator, input[0], tc.op, 0 /* leftIdx */, -1 /* rightIdx */, medium, 1, /* resultIdx */
+				)
+			})
+	}
+}
+
+func TestEnumCmpSel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	enumType := colexectestutils.MakeTestEnumType("small", "medium", "large")
+	mustMake := func(label string) *tree.DEnum {
+		d, err := tree.MakeDEnumFromLogicalRepresentation(enumType, label)
+		require.NoError(t, err)
+		return d
+	}
+	small, medium, large := mustMake("small"), mustMake("medium"), mustMake("large")
+
+	typs := []*types.T{enumType, enumType}
+	inputTuples := colexectestutils.Tuples{
+		{small, medium},
+		{large, medium},
+		{medium, medium},
+		{large, small},
+		{nil, small},
+		{small, nil},
+	}
+	for _, tc := range []struct {
+		op           tree.ComparisonOperator
+		constRight   tree.Datum
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			op:           tree.EQ,
+			outputTuples: colexectestutils.Tuples{{medium, medium}},
+		},
+		{
+			op:           tree.GT,
+			outputTuples: colexectestutils.Tuples{{large, medium}, {large, small}},
+		},
+		{
+			op:           tree.LE,
+			constRight:   medium,
+			outputTuples: colexectestutils.Tuples{{small, medium}, {medium, medium}, {small, nil}},
+		},
+		{
+			op:           tree.NE,
+			constRight:   large,
+			outputTuples: colexectestutils.Tuples{{small, medium}, {medium, medium}, {small, nil}},
+		},
+	} {
+		log.Infof(ctx, "%s/const=%t", tc.op, tc.constRight != nil)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetEnumCmpOperator(
+					input[0], tc.op, 0 /* leftIdx */, 1 /* rightIdx */, tc.constRight,
+				)
+			})
+	}
+}
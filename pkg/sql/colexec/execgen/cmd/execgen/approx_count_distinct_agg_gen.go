@@ -0,0 +1,33 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"text/template"
+)
+
+const approxCountDistinctAggTmpl = "pkg/sql/colexec/colexecagg/approx_count_distinct_agg_tmpl.go"
+
+func genApproxCountDistinctAgg(inputFileContents string, wr io.Writer) error {
+	accumulateRe := makeFunctionRegex("_ACCUMULATE_APPROX_COUNT_DISTINCT", 6)
+	s := accumulateRe.ReplaceAllString(inputFileContents, `{{template "accumulateApproxCountDistinct" buildDict "HasNulls" $5 "HasSel" $6}}`)
+
+	tmpl, err := template.New("approx_count_distinct_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, nil)
+}
+
+func init() {
+	registerAggGenerator(genApproxCountDistinctAgg, "approx_count_distinct_agg.eg.go", approxCountDistinctAggTmpl)
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"text/template"
+)
+
+const approxPercentileAggTmpl = "pkg/sql/colexec/colexecagg/approx_percentile_agg_tmpl.go"
+
+func genApproxPercentileAgg(inputFileContents string, wr io.Writer) error {
+	accumulateRe := makeFunctionRegex("_ACCUMULATE_APPROX_PERCENTILE", 8)
+	s := accumulateRe.ReplaceAllString(inputFileContents, `{{template "accumulateApproxPercentile" buildDict "HasNulls" $7 "HasSel" $8}}`)
+
+	tmpl, err := template.New("approx_percentile_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, nil)
+}
+
+func init() {
+	registerAggGenerator(genApproxPercentileAgg, "approx_percentile_agg.eg.go", approxPercentileAggTmpl)
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+const arrayLengthTmpl = "pkg/sql/colexec/array_length_tmpl.go"
+
+func genArrayLength(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_DIM_WIDTH", fmt.Sprintf("{{.}}{{if eq . %d}}: default{{end}}", anyWidth),
+		"_DimType", fmt.Sprintf("Int{{if eq . %d}}64{{else}}{{.}}{{end}}", anyWidth),
+	)
+	s := r.Replace(inputFileContents)
+
+	tmpl, err := template.New("array_length").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(wr, supportedWidthsByCanonicalTypeFamily[types.IntFamily])
+}
+
+func init() {
+	registerGenerator(genArrayLength, "array_length.eg.go", arrayLengthTmpl)
+}
@@ -92,8 +92,13 @@ type avgAggWidthTmplInfo struct {
 
 const avgAggTmpl = "pkg/sql/colexec/colexecagg/avg_agg_tmpl.go"
 
-func genAvgAgg(inputFileContents string, wr io.Writer) error {
+func genAvgAgg(inputFileContents string, wr io.Writer, isFinal bool) error {
+	var avgKind string
+	if isFinal {
+		avgKind = "Final"
+	}
 	r := strings.NewReplacer(
+		"_AVGKIND", avgKind,
 		"_TYPE_FAMILY", "{{.TypeFamily}}",
 		"_TYPE_WIDTH", typeWidthReplacement,
 		"_RET_GOTYPE", `{{.RetGoType}}`,
@@ -108,6 +113,9 @@ func genAvgAgg(inputFileContents string, wr io.Writer) error {
 	assignAddRe := makeFunctionRegex("_ASSIGN_ADD", 6)
 	s = assignAddRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignAdd", 6))
 
+	finalizeCount := makeFunctionRegex("_FINALIZE_COUNT", 1)
+	s = finalizeCount.ReplaceAllString(s, `{{template "finalizeCount"}}`)
+
 	accumulateAvg := makeFunctionRegex("_ACCUMULATE_AVG", 5)
 	s = accumulateAvg.ReplaceAllString(s, `{{template "accumulateAvg" buildDict "Global" . "HasNulls" $4 "HasSel" $5}}`)
 
@@ -126,7 +134,15 @@ func genAvgAgg(inputFileContents string, wr io.Writer) error {
 	// Note that all types on which we support avg aggregate function are the
 	// canonical representatives, so we can operate with their type family
 	// directly.
-	for _, inputTypeFamily := range []types.Family{types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily} {
+	//
+	// The final avg aggregate function combines the partial sums and counts
+	// computed by the local avg aggregations in the distributed plans, so it
+	// takes two arguments, and the first one is already of the result type.
+	supportedTypeFamilies := []types.Family{types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily}
+	if isFinal {
+		supportedTypeFamilies = []types.Family{types.DecimalFamily, types.FloatFamily, types.IntervalFamily}
+	}
+	for _, inputTypeFamily := range supportedTypeFamilies {
 		tmplInfo := avgAggTypeTmplInfo{TypeFamily: toString(inputTypeFamily)}
 		for _, inputTypeWidth := range supportedWidthsByCanonicalTypeFamily[inputTypeFamily] {
 			needsHelper := false
@@ -158,5 +174,11 @@ func genAvgAgg(inputFileContents string, wr io.Writer) error {
 }
 
 func init() {
-	registerAggGenerator(genAvgAgg, "avg_agg.eg.go", avgAggTmpl)
+	avgAggGenerator := func(isFinal bool) generator {
+		return func(inputFileContents string, wr io.Writer) error {
+			return genAvgAgg(inputFileContents, wr, isFinal)
+		}
+	}
+	registerAggGenerator(avgAggGenerator(false /* isFinal */), "avg_agg.eg.go", avgAggTmpl)
+	registerAggGenerator(avgAggGenerator(true /* isFinal */), "final_avg_agg.eg.go", avgAggTmpl)
 }
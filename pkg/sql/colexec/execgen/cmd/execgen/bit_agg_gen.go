@@ -0,0 +1,116 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+type bitAggTmplInfo struct {
+	aggTmplInfoBase
+	OpType         string
+	InputVecMethod string
+}
+
+// AssignBitOp returns the statement that folds r into l using the bitwise
+// operation of the aggregate. The result is always an int64 since that is the
+// return type of bit_and, bit_or and bit_xor for all integer widths.
+func (b bitAggTmplInfo) AssignBitOp(target, l, r string) string {
+	switch b.OpType {
+	case "And":
+		return fmt.Sprintf("%s = %s & int64(%s)", target, l, r)
+	case "Or":
+		return fmt.Sprintf("%s = %s | int64(%s)", target, l, r)
+	case "Xor":
+		return fmt.Sprintf("%s = %s ^ int64(%s)", target, l, r)
+	default:
+		colexecerror.InternalError(errors.AssertionFailedf("unsupported bit agg type %s", b.OpType))
+		// This code is unreachable, but the compiler cannot infer that.
+		return ""
+	}
+}
+
+// DefaultVal returns the identity value of the bitwise operation.
+func (b bitAggTmplInfo) DefaultVal() string {
+	if b.OpType == "And" {
+		return "^int64(0)"
+	}
+	return "0"
+}
+
+// Avoid unused warnings. These methods are used in the template.
+var (
+	_ = bitAggTmplInfo{}.AssignBitOp
+	_ = bitAggTmplInfo{}.DefaultVal
+)
+
+type bitAggWidthTmplInfo struct {
+	Width    int32
+	Overload bitAggTmplInfo
+}
+
+type bitAggOpTmplInfo struct {
+	OpType         string
+	WidthOverloads []bitAggWidthTmplInfo
+}
+
+const bitAggTmpl = "pkg/sql/colexec/colexecagg/bit_agg_tmpl.go"
+
+func genBitAgg(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_TYPE", "{{.OpType}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_TYPE", "{{.InputVecMethod}}",
+		"_DEFAULT_VAL", "{{.DefaultVal}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	accumulateBitwise := makeFunctionRegex("_ACCUMULATE_BITWISE", 5)
+	s = accumulateBitwise.ReplaceAllString(s, `{{template "accumulateBitwise" buildDict "Global" . "HasNulls" $4 "HasSel" $5}}`)
+
+	assignBitRe := makeFunctionRegex("_ASSIGN_BIT_OP", 3)
+	s = assignBitRe.ReplaceAllString(s, makeTemplateFunctionCall(`AssignBitOp`, 3))
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("bit_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	var tmplInfos []bitAggOpTmplInfo
+	for _, opType := range []string{"And", "Or", "Xor"} {
+		tmplInfo := bitAggOpTmplInfo{OpType: opType}
+		for _, width := range supportedWidthsByCanonicalTypeFamily[types.IntFamily] {
+			tmplInfo.WidthOverloads = append(tmplInfo.WidthOverloads, bitAggWidthTmplInfo{
+				Width: width,
+				Overload: bitAggTmplInfo{
+					aggTmplInfoBase: aggTmplInfoBase{canonicalTypeFamily: types.IntFamily},
+					OpType:          opType,
+					InputVecMethod:  toVecMethod(types.IntFamily, width),
+				},
+			})
+		}
+		tmplInfos = append(tmplInfos, tmplInfo)
+	}
+	return tmpl.Execute(wr, tmplInfos)
+}
+
+func init() {
+	registerAggGenerator(genBitAgg, "bit_agg.eg.go", bitAggTmpl)
+}
@@ -53,11 +53,22 @@ func (b booleanAggTmplInfo) DefaultVal() string {
 	return "false"
 }
 
+// ResultDetermined returns the condition that is true when the current
+// aggregate value can no longer change (i.e. false has been seen for bool_and
+// or true has been seen for bool_or).
+func (b booleanAggTmplInfo) ResultDetermined() string {
+	if b.IsAnd {
+		return "!a.curAgg"
+	}
+	return "a.curAgg"
+}
+
 // Avoid unused warnings. These methods are used in the template.
 var (
 	_ = booleanAggTmplInfo{}.AssignBoolOp
 	_ = booleanAggTmplInfo{}.OpType
 	_ = booleanAggTmplInfo{}.DefaultVal
+	_ = booleanAggTmplInfo{}.ResultDetermined
 )
 
 const boolAggTmpl = "pkg/sql/colexec/colexecagg/bool_and_or_agg_tmpl.go"
@@ -66,6 +77,7 @@ func genBooleanAgg(inputFileContents string, wr io.Writer) error {
 	r := strings.NewReplacer(
 		"_OP_TYPE", "{{.OpType}}",
 		"_DEFAULT_VAL", "{{.DefaultVal}}",
+		"_RESULT_DETERMINED", "{{.ResultDetermined}}",
 	)
 	s := r.Replace(inputFileContents)
 
@@ -0,0 +1,53 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+const caseConversionTmpl = "pkg/sql/colexec/case_conversion_tmpl.go"
+
+type caseConversionOverload struct {
+	// OpName is the name of the builtin the operator evaluates.
+	OpName string
+	// ConvertFn is the function that appends the case-converted []byte to
+	// another []byte.
+	ConvertFn string
+}
+
+var caseConversionOverloads = []caseConversionOverload{
+	{OpName: "initcap", ConvertFn: "appendInitcap"},
+	{OpName: "lower", ConvertFn: "appendLower"},
+	{OpName: "upper", ConvertFn: "appendUpper"},
+}
+
+func genCaseConversion(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_NAME", "{{.OpName}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	convertRe := makeFunctionRegex("_CONVERT", 2)
+	s = convertRe.ReplaceAllString(s, `{{.ConvertFn}}($1, $2)`)
+
+	tmpl, err := template.New("case_conversion").Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, caseConversionOverloads)
+}
+
+func init() {
+	registerGenerator(genCaseConversion, "case_conversion.eg.go", caseConversionTmpl)
+}
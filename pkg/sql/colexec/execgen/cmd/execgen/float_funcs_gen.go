@@ -0,0 +1,74 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+const floatFuncsTmpl = "pkg/sql/colexec/float_funcs_tmpl.go"
+
+type floatFuncOverload struct {
+	// OpName is the prefix of the name of the operator.
+	OpName string
+	// BuiltinName is the name of the builtin the operator evaluates.
+	BuiltinName string
+	// Builtin is the name of the tree.SpecializedVectorizedBuiltin of the
+	// overload.
+	Builtin string
+	// ComputeFn is the function that computes the result on the arguments.
+	ComputeFn string
+	// TwoArgs is true if the builtin takes two arguments.
+	TwoArgs bool
+	// CanError is true if ComputeFn returns an error along with the result.
+	CanError bool
+}
+
+var floatFuncOverloads = []floatFuncOverload{
+	{OpName: "acos", BuiltinName: "acos", Builtin: "AcosFloat", ComputeFn: "math.Acos"},
+	{OpName: "asin", BuiltinName: "asin", Builtin: "AsinFloat", ComputeFn: "math.Asin"},
+	{OpName: "atan", BuiltinName: "atan", Builtin: "AtanFloat", ComputeFn: "math.Atan"},
+	{OpName: "atan2", BuiltinName: "atan2", Builtin: "Atan2FloatFloat", ComputeFn: "math.Atan2", TwoArgs: true},
+	{OpName: "cos", BuiltinName: "cos", Builtin: "CosFloat", ComputeFn: "math.Cos"},
+	{OpName: "exp", BuiltinName: "exp", Builtin: "ExpFloat", ComputeFn: "math.Exp"},
+	{OpName: "ln", BuiltinName: "ln", Builtin: "LnFloat", ComputeFn: "math.Log"},
+	{OpName: "log", BuiltinName: "log", Builtin: "LogFloat", ComputeFn: "math.Log10"},
+	{OpName: "logBase", BuiltinName: "log", Builtin: "LogFloatFloat", ComputeFn: "floatLogBase", TwoArgs: true, CanError: true},
+	{OpName: "sin", BuiltinName: "sin", Builtin: "SinFloat", ComputeFn: "math.Sin"},
+	{OpName: "sqrt", BuiltinName: "sqrt", Builtin: "SqrtFloat", ComputeFn: "floatSqrt", CanError: true},
+	{OpName: "tan", BuiltinName: "tan", Builtin: "TanFloat", ComputeFn: "math.Tan"},
+}
+
+func genFloatFuncs(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_NAME", "{{.OpName}}",
+		"_BUILTIN_NAME", "{{.BuiltinName}}",
+		"_BUILTIN", "{{.Builtin}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	computeWithErrorRe := makeFunctionRegex("_COMPUTE_WITH_ERROR", 2)
+	s = computeWithErrorRe.ReplaceAllString(s, `{{.ComputeFn}}($1{{if .TwoArgs}}, $2{{end}})`)
+	computeRe := makeFunctionRegex("_COMPUTE", 2)
+	s = computeRe.ReplaceAllString(s, `{{.ComputeFn}}($1{{if .TwoArgs}}, $2{{end}})`)
+
+	tmpl, err := template.New("float_funcs").Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, floatFuncOverloads)
+}
+
+func init() {
+	registerGenerator(genFloatFuncs, "float_funcs.eg.go", floatFuncsTmpl)
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+type jsonAggTmplInfo struct {
+	aggTmplInfoBase
+	// IsObject is true for json_object_agg and false for json_agg.
+	IsObject bool
+}
+
+// JSONKind returns the kind of the JSON value built by the aggregate.
+func (j jsonAggTmplInfo) JSONKind() string {
+	if j.IsObject {
+		return "Object"
+	}
+	return "Array"
+}
+
+// Avoid unused warnings. These methods are used in the template.
+var (
+	_ = jsonAggTmplInfo{}.JSONKind
+)
+
+const jsonAggTmpl = "pkg/sql/colexec/colexecagg/json_agg_tmpl.go"
+
+func genJSONAgg(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_BUILDER_TYPE", "json.{{.JSONKind}}BuilderWithCounter",
+		"_NEW_BUILDER()", "json.New{{.JSONKind}}BuilderWithCounter()",
+		"_JSON_KIND", "{{.JSONKind}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	accumulateJSONRe := makeFunctionRegex("_ACCUMULATE_JSON", 4)
+	s = accumulateJSONRe.ReplaceAllString(s, `{{template "accumulateJSON" buildDict "Global" . "HasSel" $4}}`)
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("json_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(wr, []jsonAggTmplInfo{
+		{
+			aggTmplInfoBase: aggTmplInfoBase{canonicalTypeFamily: types.JsonFamily},
+			IsObject:        false,
+		},
+		{
+			aggTmplInfoBase: aggTmplInfoBase{canonicalTypeFamily: types.JsonFamily},
+			IsObject:        true,
+		},
+	})
+}
+
+func init() {
+	registerAggGenerator(genJSONAgg, "json_agg.eg.go", jsonAggTmpl)
+}
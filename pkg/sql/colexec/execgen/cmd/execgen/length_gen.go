@@ -0,0 +1,64 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+const lengthTmpl = "pkg/sql/colexec/length_tmpl.go"
+
+type lengthOverload struct {
+	// OpName is the prefix of the operator name.
+	OpName string
+	// Description describes the computed length.
+	Description string
+	// LengthFn is the function that computes the length of a []byte.
+	LengthFn string
+}
+
+var lengthOverloads = []lengthOverload{
+	{
+		OpName:      "charLength",
+		Description: "the number of characters",
+		// Note that utf8.RuneCount counts each invalid UTF-8 byte as a single
+		// character, the same as utf8.RuneCountInString used by the row engine.
+		LengthFn: "utf8.RuneCount",
+	},
+	{
+		OpName:      "octetLength",
+		Description: "the number of bytes",
+		LengthFn:    "len",
+	},
+}
+
+func genLength(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_NAME", "{{.OpName}}",
+		"_DESCRIPTION", "{{.Description}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	lengthRe := makeFunctionRegex("_LENGTH", 1)
+	s = lengthRe.ReplaceAllString(s, `{{.LengthFn}}($1)`)
+
+	tmpl, err := template.New("length").Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, lengthOverloads)
+}
+
+func init() {
+	registerGenerator(genLength, "length.eg.go", lengthTmpl)
+}
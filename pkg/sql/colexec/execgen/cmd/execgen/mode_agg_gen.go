@@ -0,0 +1,134 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+const modeAggTmpl = "pkg/sql/colexec/colexecagg/mode_agg_tmpl.go"
+
+// modeKeyType returns the type of the keys in the map of the mode aggregate
+// function over the values of the given overload. The values of the types
+// without a natively comparable representation are counted using their
+// fingerprints.
+func modeKeyType(overload *lastArgWidthOverload) string {
+	switch overload.lastArgTypeOverload.CanonicalTypeFamily {
+	case types.BoolFamily:
+		return "bool"
+	case types.IntFamily:
+		return "int64"
+	case types.FloatFamily:
+		return "uint64"
+	case types.TimestampTZFamily:
+		return "[2]int64"
+	case types.IntervalFamily:
+		return "duration.Duration"
+	}
+	return "string"
+}
+
+// usesFingerprint returns whether the mode aggregate function over the
+// values of the given overload uses the fingerprints as keys.
+func usesFingerprint(overload *lastArgWidthOverload) bool {
+	switch overload.lastArgTypeOverload.CanonicalTypeFamily {
+	case types.BoolFamily, types.BytesFamily, types.IntFamily, types.FloatFamily,
+		types.TimestampTZFamily, types.IntervalFamily:
+		return false
+	}
+	return true
+}
+
+// modePrepareKey returns the statement that has to be executed before the
+// key of the value at position idx of vec is used.
+func modePrepareKey(overload *lastArgWidthOverload, agg, vec, idx string) string {
+	if !usesFingerprint(overload) {
+		return ""
+	}
+	return fmt.Sprintf(
+		"%[1]s.buf, %[1]s.scratch = aggFingerprint(%[1]s.buf, %[1]s.scratch, %[2]s, %[3]s, %[1]s.inputType)",
+		agg, vec, idx,
+	)
+}
+
+// modeKey returns the expression for the key of the value val. Note that for
+// the fingerprints the key is only valid after modePrepareKey.
+func modeKey(overload *lastArgWidthOverload, agg, val string) string {
+	switch overload.lastArgTypeOverload.CanonicalTypeFamily {
+	case types.BoolFamily, types.IntervalFamily:
+		return val
+	case types.BytesFamily:
+		return fmt.Sprintf("string(%s)", val)
+	case types.IntFamily:
+		return fmt.Sprintf("int64(%s)", val)
+	case types.FloatFamily:
+		return fmt.Sprintf("modeFloatKey(float64(%s))", val)
+	case types.TimestampTZFamily:
+		return fmt.Sprintf("[2]int64{%[1]s.Unix(), int64(%[1]s.Nanosecond())}", val)
+	}
+	return fmt.Sprintf("string(%s.buf)", agg)
+}
+
+// modeKeySize returns the expression for the memory footprint of the key.
+func modeKeySize(overload *lastArgWidthOverload, key string) string {
+	if modeKeyType(overload) == "string" {
+		return fmt.Sprintf("int64(unsafe.Sizeof(%[1]s)) + int64(len(%[1]s))", key)
+	}
+	return fmt.Sprintf("int64(unsafe.Sizeof(%s))", key)
+}
+
+func genModeAgg(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_CANONICAL_TYPE_FAMILY", "{{.CanonicalTypeFamilyStr}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_GOTYPESLICE", "{{.GoTypeSliceName}}",
+		"_GOTYPE", "{{.GoType}}",
+		"_KEYTYPE", "{{modeKeyType .}}",
+		"_TYPE", "{{.VecMethod}}",
+		"TemplateType", "{{.VecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	prepareKeyRe := makeFunctionRegex("_MODE_PREPARE_KEY", 3)
+	s = prepareKeyRe.ReplaceAllString(s, `{{modePrepareKey . "$1" "$2" "$3"}}`)
+	keyRe := makeFunctionRegex("_MODE_KEY", 2)
+	s = keyRe.ReplaceAllString(s, `{{modeKey . "$1" "$2"}}`)
+	keySizeRe := makeFunctionRegex("_MODE_KEY_SIZE", 1)
+	s = keySizeRe.ReplaceAllString(s, `{{modeKeySize . "$1"}}`)
+
+	accumulateMode := makeFunctionRegex("_ACCUMULATE_MODE", 6)
+	s = accumulateMode.ReplaceAllString(s, `{{template "accumulateMode" buildDict "Global" . "HasNulls" $5 "HasSel" $6}}`)
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("mode_agg").Funcs(template.FuncMap{
+		"buildDict":      buildDict,
+		"modeKeyType":    modeKeyType,
+		"modePrepareKey": modePrepareKey,
+		"modeKey":        modeKey,
+		"modeKeySize":    modeKeySize,
+	}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(wr, sameTypeComparisonOpToOverloads[tree.EQ])
+}
+
+func init() {
+	registerAggGenerator(genModeAgg, "mode_agg.eg.go", modeAggTmpl)
+}
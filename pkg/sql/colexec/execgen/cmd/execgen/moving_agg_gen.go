@@ -0,0 +1,208 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+type movingAggTmplInfo struct {
+	inputTypeFamily types.Family
+	retTypeFamily   types.Family
+	// NeedsScratch is true when the running sum of integers is stored as a
+	// decimal, so a scratch decimal is needed to convert the integers.
+	NeedsScratch   bool
+	InputVecMethod string
+	InputGoType    string
+	RetVecMethod   string
+	RetGoType      string
+}
+
+// AssignAdd returns the statement that adds v to the running sum.
+func (m movingAggTmplInfo) AssignAdd(sum, v, scratch string) string {
+	return m.assignAddOrSub(sum, v, scratch, "Add")
+}
+
+// AssignSub returns the statement that subtracts v from the running sum.
+func (m movingAggTmplInfo) AssignSub(sum, v, scratch string) string {
+	return m.assignAddOrSub(sum, v, scratch, "Sub")
+}
+
+func (m movingAggTmplInfo) assignAddOrSub(sum, v, scratch, op string) string {
+	switch m.inputTypeFamily {
+	case types.IntFamily:
+		return fmt.Sprintf(`
+			%[3]s.SetInt64(int64(%[2]s))
+			if _, err := tree.ExactCtx.%[4]s(&%[1]s, &%[1]s, &%[3]s); err != nil {
+				colexecerror.ExpectedError(err)
+			}`, sum, v, scratch, op)
+	case types.DecimalFamily:
+		return fmt.Sprintf(`
+			if _, err := tree.ExactCtx.%[3]s(&%[1]s, &%[1]s, &%[2]s); err != nil {
+				colexecerror.ExpectedError(err)
+			}`, sum, v, op)
+	case types.FloatFamily:
+		if op == "Add" {
+			return fmt.Sprintf("%s += %s", sum, v)
+		}
+		return fmt.Sprintf("%s -= %s", sum, v)
+	case types.IntervalFamily:
+		return fmt.Sprintf("%[1]s = %[1]s.%[3]s(%[2]s)", sum, v, op)
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported moving aggregate type %s", m.inputTypeFamily))
+	// This code is unreachable, but the compiler cannot infer that.
+	return ""
+}
+
+// AssignSubSums returns the statement that assigns target to the difference
+// of two running sums.
+func (m movingAggTmplInfo) AssignSubSums(target, left, right string) string {
+	switch m.retTypeFamily {
+	case types.DecimalFamily:
+		return fmt.Sprintf(`
+			if _, err := tree.ExactCtx.Sub(&%[1]s, &%[2]s, &%[3]s); err != nil {
+				colexecerror.ExpectedError(err)
+			}`, target, left, right)
+	case types.FloatFamily:
+		return fmt.Sprintf("%s = %s - %s", target, left, right)
+	case types.IntervalFamily:
+		return fmt.Sprintf("%s = %s.Sub(%s)", target, left, right)
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported moving aggregate type %s", m.retTypeFamily))
+	// This code is unreachable, but the compiler cannot infer that.
+	return ""
+}
+
+// AssignDivInt64 returns the statement that computes the average from the
+// running sum and the number of non-NULL values.
+func (m movingAggTmplInfo) AssignDivInt64(target, sum, count string) string {
+	switch m.inputTypeFamily {
+	case types.IntFamily, types.DecimalFamily:
+		// Note that the running sum of integers is stored as a decimal, so
+		// ints and decimals share the division code.
+		return fmt.Sprintf(`
+			%[1]s.SetInt64(%[3]s)
+			if _, err := tree.DecimalCtx.Quo(&%[1]s, &%[2]s, &%[1]s); err != nil {
+				colexecerror.ExpectedError(err)
+			}`, target, sum, count)
+	case types.FloatFamily:
+		return fmt.Sprintf("%s = %s / float64(%s)", target, sum, count)
+	case types.IntervalFamily:
+		return fmt.Sprintf("%s = %s.Div(%s)", target, sum, count)
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported moving aggregate type %s", m.inputTypeFamily))
+	// This code is unreachable, but the compiler cannot infer that.
+	return ""
+}
+
+// CopyVal is a function that should only be used in templates.
+func (m movingAggTmplInfo) CopyVal(dest, src string) string {
+	return copyVal(m.inputTypeFamily, dest, src)
+}
+
+// CopySum returns the statement that copies the running sum src into dest.
+func (m movingAggTmplInfo) CopySum(dest, src string) string {
+	return copyVal(m.retTypeFamily, dest, src)
+}
+
+// Avoid unused warnings. These methods are used in the template.
+var (
+	_ = movingAggTmplInfo{}.AssignAdd
+	_ = movingAggTmplInfo{}.AssignSub
+	_ = movingAggTmplInfo{}.AssignSubSums
+	_ = movingAggTmplInfo{}.AssignDivInt64
+	_ = movingAggTmplInfo{}.CopyVal
+	_ = movingAggTmplInfo{}.CopySum
+)
+
+type movingAggWidthTmplInfo struct {
+	Width    int32
+	Overload movingAggTmplInfo
+}
+
+type movingAggTypeTmplInfo struct {
+	TypeFamily     string
+	WidthOverloads []movingAggWidthTmplInfo
+}
+
+const movingAggTmpl = "pkg/sql/colexec/colexecwindow/moving_agg_tmpl.go"
+
+func genMovingAggOps(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_TYPE_FAMILY", "{{.TypeFamily}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_RET_GOTYPE", "{{.RetGoType}}",
+		"_RET_TYPE", "{{.RetVecMethod}}",
+		"_GOTYPE", "{{.InputGoType}}",
+		"_TYPE", "{{.InputVecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	assignAddRe := makeFunctionRegex("_ASSIGN_ADD", 3)
+	s = assignAddRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignAdd", 3))
+	assignSubRe := makeFunctionRegex("_ASSIGN_SUB", 3)
+	s = assignSubRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignSub", 3))
+	assignSubSumsRe := makeFunctionRegex("_ASSIGN_SUB_SUMS", 3)
+	s = assignSubSumsRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignSubSums", 3))
+	assignDivRe := makeFunctionRegex("_ASSIGN_DIV_INT64", 3)
+	s = assignDivRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignDivInt64", 3))
+	copySumRe := makeFunctionRegex("_COPY_SUM", 2)
+	s = copySumRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.CopySum", 2))
+
+	computeMovingAggRe := makeFunctionRegex("_COMPUTE_MOVING_AGG", 1)
+	s = computeMovingAggRe.ReplaceAllString(s, `{{template "computeMovingAgg" buildDict "Global" . "HasNulls" $1}}`)
+	setMovingAggOutputRe := makeFunctionRegex("_SET_MOVING_AGG_OUTPUT", 1)
+	s = setMovingAggOutputRe.ReplaceAllString(s, `{{template "setMovingAggOutput" buildDict "Global" .Global "Excluding" $1}}`)
+
+	s = replaceManipulationFuncsAmbiguous(".Global", s)
+
+	tmpl, err := template.New("moving_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	var tmplInfos []movingAggTypeTmplInfo
+	for _, inputTypeFamily := range []types.Family{types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily} {
+		tmplInfo := movingAggTypeTmplInfo{TypeFamily: toString(inputTypeFamily)}
+		for _, inputTypeWidth := range supportedWidthsByCanonicalTypeFamily[inputTypeFamily] {
+			retTypeFamily, retTypeWidth := inputTypeFamily, inputTypeWidth
+			if inputTypeFamily == types.IntFamily {
+				// Average and sum of integers are decimals.
+				retTypeFamily, retTypeWidth = types.DecimalFamily, anyWidth
+			}
+			tmplInfo.WidthOverloads = append(tmplInfo.WidthOverloads, movingAggWidthTmplInfo{
+				Width: inputTypeWidth,
+				Overload: movingAggTmplInfo{
+					inputTypeFamily: inputTypeFamily,
+					retTypeFamily:   retTypeFamily,
+					NeedsScratch:    inputTypeFamily == types.IntFamily,
+					InputVecMethod:  toVecMethod(inputTypeFamily, inputTypeWidth),
+					InputGoType:     toPhysicalRepresentation(inputTypeFamily, inputTypeWidth),
+					RetVecMethod:    toVecMethod(retTypeFamily, retTypeWidth),
+					RetGoType:       toPhysicalRepresentation(retTypeFamily, retTypeWidth),
+				},
+			})
+		}
+		tmplInfos = append(tmplInfos, tmplInfo)
+	}
+	return tmpl.Execute(wr, tmplInfos)
+}
+
+func init() {
+	registerGenerator(genMovingAggOps, "moving_agg.eg.go", movingAggTmpl)
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+const negAbsTmpl = "pkg/sql/colexec/neg_abs_tmpl.go"
+
+type negAbsOverload struct {
+	// OpName is the name of the operator.
+	OpName string
+	// OpDescription describes the operation performed by the operator.
+	OpDescription string
+	// TypeName is the name of the vector type of the output.
+	TypeName string
+	// ArgTypeName is the name of the vector type of the argument if it differs
+	// from TypeName.
+	ArgTypeName string
+	// AssignFmt is the format string of the assignment of the result of the
+	// operation on the element of the second argument to the element of the
+	// first argument, both at the position given by the third argument.
+	AssignFmt string
+}
+
+// Assign is used to replace _ASSIGN in the template.
+func (o negAbsOverload) Assign(target, arg, idx string) string {
+	return fmt.Sprintf(o.AssignFmt, target, arg, idx)
+}
+
+// GetArgTypeName is used to replace _ARG_TYPE_NAME in the template.
+func (o negAbsOverload) GetArgTypeName() string {
+	if o.ArgTypeName != "" {
+		return o.ArgTypeName
+	}
+	return o.TypeName
+}
+
+var (
+	_ = negAbsOverload{}.Assign
+	_ = negAbsOverload{}.GetArgTypeName
+)
+
+var negAbsOverloads = []negAbsOverload{
+	{
+		OpName:        "negDecimal",
+		OpDescription: "the unary minus on decimals",
+		TypeName:      "Decimal",
+		AssignFmt:     "%[1]s[%[3]s].Neg(&%[2]s[%[3]s])",
+	},
+	{
+		OpName:        "negInterval",
+		OpDescription: "the unary minus on intervals",
+		TypeName:      "Interval",
+		AssignFmt:     "%[1]s[%[3]s] = negInterval(%[2]s[%[3]s])",
+	},
+	{
+		OpName:        "absDecimal",
+		OpDescription: "abs() builtin on decimals",
+		TypeName:      "Decimal",
+		AssignFmt:     "%[1]s[%[3]s].Abs(&%[2]s[%[3]s])",
+	},
+	{
+		OpName:        "complementInt16",
+		OpDescription: "the bitwise NOT on int16s",
+		TypeName:      "Int64",
+		ArgTypeName:   "Int16",
+		AssignFmt:     "%[1]s[%[3]s] = ^int64(%[2]s[%[3]s])",
+	},
+	{
+		OpName:        "complementInt32",
+		OpDescription: "the bitwise NOT on int32s",
+		TypeName:      "Int64",
+		ArgTypeName:   "Int32",
+		AssignFmt:     "%[1]s[%[3]s] = ^int64(%[2]s[%[3]s])",
+	},
+	{
+		OpName:        "complementInt64",
+		OpDescription: "the bitwise NOT on int64s",
+		TypeName:      "Int64",
+		AssignFmt:     "%[1]s[%[3]s] = ^%[2]s[%[3]s]",
+	},
+	{
+		OpName:        "complementBits",
+		OpDescription: "the bitwise NOT on bit strings",
+		TypeName:      "Datum",
+		AssignFmt:     "%[1]s.Set(%[3]s, complementBits(%[2]s.Get(%[3]s)))",
+	},
+}
+
+func genNegAbs(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_NAME", "{{.OpName}}",
+		"_OP_DESCRIPTION", "{{.OpDescription}}",
+		"_ARG_TYPE_NAME", "{{.GetArgTypeName}}",
+		"_TYPE_NAME", "{{.TypeName}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	assignRe := makeFunctionRegex("_ASSIGN", 3)
+	s = assignRe.ReplaceAllString(s, makeTemplateFunctionCall("Assign", 3))
+	negAbsLoopRe := makeFunctionRegex("_NEG_ABS_LOOP", 1)
+	s = negAbsLoopRe.ReplaceAllString(s, `{{template "negAbsLoop" buildDict "Global" . "HasNulls" $1}}`)
+
+	tmpl, err := template.New("neg_abs").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, negAbsOverloads)
+}
+
+func init() {
+	registerGenerator(genNegAbs, "neg_abs.eg.go", negAbsTmpl)
+}
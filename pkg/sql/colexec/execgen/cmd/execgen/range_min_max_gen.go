@@ -0,0 +1,74 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+const rangeMinMaxTmpl = "pkg/sql/colexec/colexecwindow/range_min_max_tmpl.go"
+
+func genRangeMinMaxOps(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_CANONICAL_TYPE_FAMILY", "{{.CanonicalTypeFamilyStr}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_AGG_TITLE", "{{$aggTitle}}",
+		"_GOTYPE", "{{.GoType}}",
+		"_TYPE", "{{.VecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	assignCmpRe := makeFunctionRegex("_ASSIGN_CMP", 6)
+	s = assignCmpRe.ReplaceAllString(s, makeTemplateFunctionCall("Assign", 6))
+
+	processTupleRe := makeFunctionRegex("_PROCESS_TUPLE", 1)
+	s = processTupleRe.ReplaceAllString(s, `{{template "processTuple" buildDict "Global" . "HasNulls" $1}}`)
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("range_min_max").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	// The RANGE mode operators support the same argument types as the moving
+	// MIN and MAX operators in ROWS mode.
+	filterOverloads := func(overloads []*oneArgOverload) []*oneArgOverload {
+		var res []*oneArgOverload
+		for _, o := range overloads {
+			if _, ok := movingMinMaxTypeFamilies[o.CanonicalTypeFamily]; ok {
+				res = append(res, o)
+			}
+		}
+		return res
+	}
+	return tmpl.Execute(wr, []struct {
+		AggTitle  string
+		Overloads []*oneArgOverload
+	}{
+		{
+			AggTitle:  "Min",
+			Overloads: filterOverloads(sameTypeComparisonOpToOverloads[tree.LT]),
+		},
+		{
+			AggTitle:  "Max",
+			Overloads: filterOverloads(sameTypeComparisonOpToOverloads[tree.GT]),
+		},
+	})
+}
+
+func init() {
+	registerGenerator(genRangeMinMaxOps, "range_min_max.eg.go", rangeMinMaxTmpl)
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+type regressionAggXTmplInfo struct {
+	XTypeFamily string
+	XVecMethod  string
+	YVecMethod  string
+}
+
+type regressionAggYTmplInfo struct {
+	YTypeFamily string
+	XOverloads  []regressionAggXTmplInfo
+}
+
+const regressionAggTmpl = "pkg/sql/colexec/colexecagg/regression_agg_tmpl.go"
+
+func genRegressionAgg(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_Y_TYPE_FAMILY", "{{.YTypeFamily}}",
+		"_X_TYPE_FAMILY", "{{.XTypeFamily}}",
+		"_Y_TYPE", "{{.YVecMethod}}",
+		"_X_TYPE", "{{.XVecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	accumulateRe := makeFunctionRegex("_ACCUMULATE_REGRESSION", 8)
+	s = accumulateRe.ReplaceAllString(s, `{{template "accumulateRegression" buildDict "HasNulls" $7 "HasSel" $8}}`)
+
+	tmpl, err := template.New("regression_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	// The statistical aggregate functions are computed natively only for
+	// floats and 64-bit integers (in any combination of the arguments). The
+	// values are converted to floats for the computation, and the result is
+	// always a float.
+	typeFamilies := []types.Family{types.FloatFamily, types.IntFamily}
+	var tmplInfos []regressionAggYTmplInfo
+	for _, yTypeFamily := range typeFamilies {
+		tmplInfo := regressionAggYTmplInfo{YTypeFamily: toString(yTypeFamily)}
+		for _, xTypeFamily := range typeFamilies {
+			tmplInfo.XOverloads = append(tmplInfo.XOverloads, regressionAggXTmplInfo{
+				XTypeFamily: toString(xTypeFamily),
+				XVecMethod:  toVecMethod(xTypeFamily, 64),
+				YVecMethod:  toVecMethod(yTypeFamily, 64),
+			})
+		}
+		tmplInfos = append(tmplInfos, tmplInfo)
+	}
+	return tmpl.Execute(wr, tmplInfos)
+}
+
+func init() {
+	registerAggGenerator(genRegressionAgg, "regression_agg.eg.go", regressionAggTmpl)
+}
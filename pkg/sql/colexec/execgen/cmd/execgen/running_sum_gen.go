@@ -0,0 +1,77 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+const runningSumTmpl = "pkg/sql/colexec/colexecwindow/running_sum_tmpl.go"
+
+func genRunningSumOps(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_TYPE_FAMILY", "{{.TypeFamily}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_RET_GOTYPE", "{{.RetGoType}}",
+		"_RET_TYPE", "{{.RetVecMethod}}",
+		"_TYPE", "{{.InputVecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	assignAddRe := makeFunctionRegex("_ASSIGN_ADD", 3)
+	s = assignAddRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.AssignAdd", 3))
+	copySumRe := makeFunctionRegex("_COPY_SUM", 2)
+	s = copySumRe.ReplaceAllString(s, makeTemplateFunctionCall("Global.CopySum", 2))
+
+	computeRunningSumRe := makeFunctionRegex("_COMPUTE_RUNNING_SUM", 1)
+	s = computeRunningSumRe.ReplaceAllString(s, `{{template "computeRunningSum" buildDict "Global" . "HasNulls" $1}}`)
+
+	tmpl, err := template.New("running_sum").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	// The running sum shares the overloads with the moving aggregate
+	// operators.
+	var tmplInfos []movingAggTypeTmplInfo
+	for _, inputTypeFamily := range []types.Family{types.IntFamily, types.DecimalFamily, types.FloatFamily, types.IntervalFamily} {
+		tmplInfo := movingAggTypeTmplInfo{TypeFamily: toString(inputTypeFamily)}
+		for _, inputTypeWidth := range supportedWidthsByCanonicalTypeFamily[inputTypeFamily] {
+			retTypeFamily, retTypeWidth := inputTypeFamily, inputTypeWidth
+			if inputTypeFamily == types.IntFamily {
+				// The sum of integers is a decimal.
+				retTypeFamily, retTypeWidth = types.DecimalFamily, anyWidth
+			}
+			tmplInfo.WidthOverloads = append(tmplInfo.WidthOverloads, movingAggWidthTmplInfo{
+				Width: inputTypeWidth,
+				Overload: movingAggTmplInfo{
+					inputTypeFamily: inputTypeFamily,
+					retTypeFamily:   retTypeFamily,
+					NeedsScratch:    inputTypeFamily == types.IntFamily,
+					InputVecMethod:  toVecMethod(inputTypeFamily, inputTypeWidth),
+					InputGoType:     toPhysicalRepresentation(inputTypeFamily, inputTypeWidth),
+					RetVecMethod:    toVecMethod(retTypeFamily, retTypeWidth),
+					RetGoType:       toPhysicalRepresentation(retTypeFamily, retTypeWidth),
+				},
+			})
+		}
+		tmplInfos = append(tmplInfos, tmplInfo)
+	}
+	return tmpl.Execute(wr, tmplInfos)
+}
+
+func init() {
+	registerGenerator(genRunningSumOps, "running_sum.eg.go", runningSumTmpl)
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+const selRangeTmpl = "pkg/sql/colexec/colexecsel/sel_range_tmpl.go"
+
+func genSelRangeOps(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_CANONICAL_TYPE_FAMILY", "{{.CanonicalTypeFamilyStr}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_GOTYPE", "{{.GoType}}",
+		"_TYPE", "{{.VecMethod}}",
+		"TemplateType", "{{.VecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	compareRe := makeFunctionRegex("_COMPARE", 5)
+	s = compareRe.ReplaceAllString(s, makeTemplateFunctionCall("Compare", 5))
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("sel_range").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(wr, sameTypeComparisonOpToOverloads[tree.LT])
+}
+
+func init() {
+	registerGenerator(genSelRangeOps, "sel_range.eg.go", selRangeTmpl)
+}
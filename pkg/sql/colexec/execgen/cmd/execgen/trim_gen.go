@@ -0,0 +1,71 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+const trimTmpl = "pkg/sql/colexec/trim_tmpl.go"
+
+type trimOverload struct {
+	// OpName is the name of the builtin the operator evaluates.
+	OpName string
+	// TrimSpaceFn is the function that trims the whitespace from a []byte.
+	TrimSpaceFn string
+	// TrimCutsetFn is the function that trims all characters included into the
+	// cutset from a []byte.
+	TrimCutsetFn string
+}
+
+var trimOverloads = []trimOverload{
+	{
+		OpName:       "trim",
+		TrimSpaceFn:  "bytes.TrimSpace",
+		TrimCutsetFn: "bytes.Trim",
+	},
+	{
+		OpName:       "ltrim",
+		TrimSpaceFn:  "trimLeftSpace",
+		TrimCutsetFn: "bytes.TrimLeft",
+	},
+	{
+		OpName:       "rtrim",
+		TrimSpaceFn:  "trimRightSpace",
+		TrimCutsetFn: "bytes.TrimRight",
+	},
+}
+
+func genTrim(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_OP_NAME", "{{.OpName}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	trimSpaceRe := makeFunctionRegex("_TRIM_SPACE", 1)
+	s = trimSpaceRe.ReplaceAllString(s, `{{.Global.TrimSpaceFn}}($1)`)
+	trimCutsetRe := makeFunctionRegex("_TRIM_CUTSET", 2)
+	s = trimCutsetRe.ReplaceAllString(s, `{{.Global.TrimCutsetFn}}($1, $2)`)
+	trimLoopRe := makeFunctionRegex("_TRIM_LOOP", 2)
+	s = trimLoopRe.ReplaceAllString(s, `{{template "trimLoop" buildDict "Global" . "HasNulls" $1 "TrimWhitespace" $2}}`)
+
+	tmpl, err := template.New("trim").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(wr, trimOverloads)
+}
+
+func init() {
+	registerGenerator(genTrim, "trim.eg.go", trimTmpl)
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+type varianceTmplInfo struct {
+	aggTmplInfoBase
+	IsDecimal      bool
+	InputVecMethod string
+	GoType         string
+}
+
+// AssignWelfordUpdate returns the statements that update the running count,
+// mean and sum of squared differences from the mean of the aggregate function
+// a with the value v. The updates are performed in exactly the same way as in
+// the row engine so that the results are the same.
+func (v varianceTmplInfo) AssignWelfordUpdate(a, val string) string {
+	if v.IsDecimal {
+		return fmt.Sprintf(`
+			%[1]s.curCount++
+			%[1]s.count.SetInt64(%[1]s.curCount)
+			%[1]s.ed.Sub(&%[1]s.delta, &%[2]s, &%[1]s.curMean)
+			%[1]s.ed.Quo(&%[1]s.tmp, &%[1]s.delta, &%[1]s.count)
+			%[1]s.ed.Add(&%[1]s.curMean, &%[1]s.curMean, &%[1]s.tmp)
+			%[1]s.ed.Sub(&%[1]s.tmp, &%[2]s, &%[1]s.curMean)
+			%[1]s.ed.Add(&%[1]s.curSqrDiff, &%[1]s.curSqrDiff, %[1]s.ed.Mul(&%[1]s.delta, &%[1]s.delta, &%[1]s.tmp))
+			if err := %[1]s.ed.Err(); err != nil {
+				colexecerror.ExpectedError(err)
+			}`, a, val)
+	}
+	return fmt.Sprintf(`
+		%[1]s.curCount++
+		delta := %[2]s - %[1]s.curMean
+		%[1]s.curMean += delta / float64(%[1]s.curCount)
+		%[1]s.curSqrDiff += delta * (%[2]s - %[1]s.curMean)`, a, val)
+}
+
+// AssignVariance returns the statements that assign target to the variance
+// (or the standard deviation) computed from the running state of the
+// aggregate function a.
+func (v varianceTmplInfo) AssignVariance(target, a string) string {
+	if v.IsDecimal {
+		// Note that the trailing zeros are removed, same as in the row engine,
+		// so that the results are the same regardless of the order in which
+		// the values are processed.
+		return fmt.Sprintf(`
+			if %[2]s.pop {
+				%[2]s.tmp.SetInt64(%[2]s.curCount)
+			} else {
+				%[2]s.tmp.SetInt64(%[2]s.curCount - 1)
+			}
+			%[2]s.delta.Reduce(&%[2]s.curSqrDiff)
+			if _, err := tree.DecimalCtx.Quo(&%[1]s, &%[2]s.delta, &%[2]s.tmp); err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			%[1]s.Reduce(&%[1]s)
+			if %[2]s.sqrt {
+				if _, err := tree.DecimalCtx.Sqrt(&%[1]s, &%[1]s); err != nil {
+					colexecerror.ExpectedError(err)
+				}
+			}`, target, a)
+	}
+	return fmt.Sprintf(`
+		denom := float64(%[2]s.curCount)
+		if !%[2]s.pop {
+			denom--
+		}
+		%[1]s = %[2]s.curSqrDiff / denom
+		if %[2]s.sqrt {
+			%[1]s = math.Sqrt(%[1]s)
+		}`, target, a)
+}
+
+// Avoid unused warnings. These methods are used in the template.
+var (
+	_ = varianceTmplInfo{}.AssignWelfordUpdate
+	_ = varianceTmplInfo{}.AssignVariance
+)
+
+type varianceAggWidthTmplInfo struct {
+	Width    int32
+	Overload varianceTmplInfo
+}
+
+type varianceAggTypeTmplInfo struct {
+	TypeFamily     string
+	WidthOverloads []varianceAggWidthTmplInfo
+}
+
+const varianceAggTmpl = "pkg/sql/colexec/colexecagg/variance_agg_tmpl.go"
+
+func genVarianceAgg(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_TYPE_FAMILY", "{{.TypeFamily}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_GOTYPE", "{{.GoType}}",
+		"_TYPE", "{{.InputVecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	assignWelfordRe := makeFunctionRegex("_ASSIGN_WELFORD_UPDATE", 2)
+	s = assignWelfordRe.ReplaceAllString(s, makeTemplateFunctionCall("AssignWelfordUpdate", 2))
+	assignVarianceRe := makeFunctionRegex("_ASSIGN_VARIANCE", 2)
+	s = assignVarianceRe.ReplaceAllString(s, makeTemplateFunctionCall("AssignVariance", 2))
+
+	accumulateVariance := makeFunctionRegex("_ACCUMULATE_VARIANCE", 5)
+	s = accumulateVariance.ReplaceAllString(s, `{{template "accumulateVariance" buildDict "Global" . "HasNulls" $4 "HasSel" $5}}`)
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("variance_agg").Funcs(template.FuncMap{"buildDict": buildDict}).Parse(s)
+	if err != nil {
+		return err
+	}
+
+	// The variance is computed natively only for floats and decimals. The
+	// variance of integers is a decimal, and such aggregations are handled by
+	// the default aggregate function.
+	var tmplInfos []varianceAggTypeTmplInfo
+	for _, typeFamily := range []types.Family{types.DecimalFamily, types.FloatFamily} {
+		tmplInfo := varianceAggTypeTmplInfo{TypeFamily: toString(typeFamily)}
+		for _, width := range supportedWidthsByCanonicalTypeFamily[typeFamily] {
+			tmplInfo.WidthOverloads = append(tmplInfo.WidthOverloads, varianceAggWidthTmplInfo{
+				Width: width,
+				Overload: varianceTmplInfo{
+					aggTmplInfoBase: aggTmplInfoBase{canonicalTypeFamily: typeFamily},
+					IsDecimal:       typeFamily == types.DecimalFamily,
+					InputVecMethod:  toVecMethod(typeFamily, width),
+					GoType:          toPhysicalRepresentation(typeFamily, width),
+				},
+			})
+		}
+		tmplInfos = append(tmplInfos, tmplInfo)
+	}
+	return tmpl.Execute(wr, tmplInfos)
+}
+
+func init() {
+	registerAggGenerator(genVarianceAgg, "variance_agg.eg.go", varianceAggTmpl)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+const vecCopierTmpl = "pkg/sql/colexec/colexecutils/vec_copier_tmpl.go"
+
+func genVecCopier(inputFileContents string, wr io.Writer) error {
+	r := strings.NewReplacer(
+		"_CANONICAL_TYPE_FAMILY", "{{.CanonicalTypeFamilyStr}}",
+		"_TYPE_WIDTH", typeWidthReplacement,
+		"_TYPE", "{{.VecMethod}}",
+		"TemplateType", "{{.VecMethod}}",
+	)
+	s := r.Replace(inputFileContents)
+
+	s = replaceManipulationFuncs(s)
+
+	tmpl, err := template.New("vec_copier").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	// It doesn't matter that we're passing in all overloads of Equality
+	// comparison operator - we simply need to iterate over all supported
+	// types.
+	return tmpl.Execute(wr, sameTypeComparisonOpToOverloads[tree.EQ])
+}
+
+func init() {
+	registerGenerator(genVecCopier, "vec_copier.eg.go", vecCopierTmpl)
+}
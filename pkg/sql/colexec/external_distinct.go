@@ -25,19 +25,21 @@ import (
 
 // NewExternalDistinct returns a new disk-backed unordered distinct operator. It
 // uses the in-memory unordered distinct as the "main" strategy for the external
-// operator and the external sort + ordered distinct as the "fallback".
+// operator and the external sort + ordered distinct as the "fallback". If
+// outputOrdering is non-empty, the output of the operator is sorted
+// accordingly.
 func NewExternalDistinct(
 	unlimitedAllocator *colmem.Allocator,
 	flowCtx *execinfra.FlowCtx,
 	args *colexecargs.NewColOperatorArgs,
 	input colexecop.Operator,
 	inputTypes []*types.T,
+	distinctCols []uint32,
+	outputOrdering execinfrapb.Ordering,
 	createDiskBackedSorter DiskBackedSorterConstructor,
 	inMemUnorderedDistinct colexecop.Operator,
 	diskAcc *mon.BoundAccount,
 ) colexecop.Operator {
-	distinctSpec := args.Spec.Core.Distinct
-	distinctCols := distinctSpec.DistinctColumns
 	inMemMainOpConstructor := func(partitionedInputs []*partitionerToOperator) colexecop.ResettableOperator {
 		// Note that the hash-based partitioner will make sure that partitions
 		// to process using the in-memory unordered distinct fit under the
@@ -54,26 +56,19 @@ func NewExternalDistinct(
 	) colexecop.ResettableOperator {
 		// The distinct operator *must* keep the first tuple from the input
 		// among all that are identical on distinctCols. In order to guarantee
-		// such behavior in the fallback, we append an ordinality column to
-		// every tuple before the disk-backed sorter and include that column in
-		// the desired ordering. We then project out that temporary column
+		// such behavior in the fallback, we use the stable disk-backed sorter
 		// before feeding the tuples into the ordered distinct.
-		ordinalityOp := colexecbase.NewOrdinalityOp(unlimitedAllocator, partitionedInputs[0], len(inputTypes))
-		orderingCols := make([]execinfrapb.Ordering_Column, len(distinctCols)+1)
+		orderingCols := make([]execinfrapb.Ordering_Column, len(distinctCols))
 		for i := range distinctCols {
 			orderingCols[i].ColIdx = distinctCols[i]
 		}
-		orderingCols[len(distinctCols)].ColIdx = uint32(len(inputTypes))
-		sortTypes := make([]*types.T, 0, len(inputTypes)+1)
-		sortTypes = append(sortTypes, inputTypes...)
-		sortTypes = append(sortTypes, types.Int)
-		diskBackedSorter := createDiskBackedSorter(ordinalityOp, sortTypes, orderingCols, maxNumberActivePartitions)
-		projection := make([]uint32, len(inputTypes))
-		for i := range projection {
-			projection[i] = uint32(i)
-		}
-		diskBackedWithoutOrdinality := colexecbase.NewSimpleProjectOp(diskBackedSorter, len(sortTypes), projection)
-		diskBackedFallbackOp, err := colexecbase.NewOrderedDistinct(diskBackedWithoutOrdinality, distinctCols, inputTypes)
+		diskBackedSorter := NewStableSorter(
+			unlimitedAllocator, partitionedInputs[0], inputTypes, orderingCols,
+			func(input colexecop.Operator, sortTypes []*types.T, sortOrderingCols []execinfrapb.Ordering_Column) colexecop.Operator {
+				return createDiskBackedSorter(input, sortTypes, sortOrderingCols, maxNumberActivePartitions)
+			},
+		)
+		diskBackedFallbackOp, err := colexecbase.NewOrderedDistinct(diskBackedSorter, distinctCols, inputTypes)
 		if err != nil {
 			colexecerror.InternalError(err)
 		}
@@ -107,7 +102,6 @@ func NewExternalDistinct(
 	// the ordering of tuples. However, that is not that the case with the
 	// hash-based partitioner, so we might need to plan an external sort on top
 	// of it.
-	outputOrdering := args.Spec.Core.Distinct.OutputOrdering
 	if len(outputOrdering.Columns) == 0 {
 		// No particular output ordering is required.
 		return ed
@@ -69,15 +69,16 @@ func TestExternalDistinct(t *testing.T) {
 			var semsToCheck []semaphore.Semaphore
 			var outputOrdering execinfrapb.Ordering
 			verifier := colexectestutils.UnorderedVerifier
-			// Check that the external distinct and the disk-backed sort
-			// were added as Closers.
-			numExpectedClosers := 2
+			// Check that the external distinct as well as the disk spiller
+			// and the external sorter of the disk-backed sort were added as
+			// Closers.
+			numExpectedClosers := 3
 			if tc.isOrderedOnDistinctCols {
 				outputOrdering = convertDistinctColsToOrdering(tc.distinctCols)
 				verifier = colexectestutils.OrderedVerifier
 				// The final disk-backed sort must also be added as a
-				// Closer.
-				numExpectedClosers++
+				// Closer (both its disk spiller and its external sorter).
+				numExpectedClosers += 2
 			}
 			colexectestutils.RunTestsWithTyps(
 				t,
@@ -209,9 +210,10 @@ func TestExternalDistinctSpilling(t *testing.T) {
 				sem, func() { numSpills++ }, numForcedRepartitions,
 			)
 			require.NoError(t, err)
-			// Check that the external distinct and the disk-backed sort
-			// were added as Closers.
-			numExpectedClosers := 2
+			// Check that the external distinct as well as the disk spiller
+			// and the external sorter of the disk-backed sort were added as
+			// Closers.
+			numExpectedClosers := 3
 			require.Equal(t, numExpectedClosers, len(closers))
 			accounts = append(accounts, newAccounts...)
 			monitors = append(monitors, newMonitors...)
@@ -234,6 +236,119 @@ func TestExternalDistinctSpilling(t *testing.T) {
 	}
 }
 
+// TestExternalDistinctWideSchemaWithNulls verifies that the external distinct
+// over all columns of a wide schema with NULL values spills to disk when given
+// a tiny memory limit and still correctly treats NULLs as equal to each other.
+func TestExternalDistinctWideSchemaWithNulls(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+		DiskMonitor: testDiskMonitor,
+	}
+	flowCtx.Cfg.TestingKnobs.MemoryLimitBytes = mon.DefaultPoolAllocationSize
+
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	var (
+		accounts []*mon.BoundAccount
+		monitors []*mon.BytesMonitor
+	)
+
+	rng, _ := randutil.NewPseudoRand()
+	const nCols = 12
+	const nullProbability = 0.2
+	typs := make([]*types.T, nCols)
+	distinctCols := make([]uint32, nCols)
+	for i := range typs {
+		typs[i] = []*types.T{types.Int, types.Bytes, types.Bool, types.Int4}[i%4]
+		distinctCols[i] = uint32(i)
+	}
+	randValue := func(typ *types.T) interface{} {
+		if rng.Float64() < nullProbability {
+			return nil
+		}
+		switch typ.Family() {
+		case types.BytesFamily:
+			return []string{"a", "b", "c"}[rng.Intn(3)]
+		case types.BoolFamily:
+			return rng.Intn(2) == 0
+		default:
+			return rng.Intn(3)
+		}
+	}
+
+	// Generate the distinct tuples first, and then use each of them at least
+	// once (in a random order) as the input.
+	var expected colexectestutils.Tuples
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		tup := make(colexectestutils.Tuple, nCols)
+		for j := range tup {
+			tup[j] = randValue(typs[j])
+		}
+		key := fmt.Sprint(tup)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		expected = append(expected, tup)
+	}
+	tups := make(colexectestutils.Tuples, 0, 3*len(expected))
+	tups = append(tups, expected...)
+	for len(tups) < cap(tups) {
+		tups = append(tups, expected[rng.Intn(len(expected))])
+	}
+	rand.Shuffle(len(tups), func(i, j int) { tups[i], tups[j] = tups[j], tups[i] })
+
+	var numRuns, numSpills int
+	var semsToCheck []semaphore.Semaphore
+	colexectestutils.RunTestsWithoutAllNullsInjection(
+		t,
+		testAllocator,
+		[]colexectestutils.Tuples{tups},
+		[][]*types.T{typs},
+		expected,
+		colexectestutils.UnorderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			// Since we're giving very low memory limit to the operator, in
+			// order to make the test run faster, we'll use an unlimited number
+			// of file descriptors.
+			sem := colexecop.NewTestingSemaphore(0 /* limit */)
+			semsToCheck = append(semsToCheck, sem)
+			var outputOrdering execinfrapb.Ordering
+			distinct, newAccounts, newMonitors, _, err := createExternalDistinct(
+				ctx, flowCtx, input, typs, distinctCols, outputOrdering, queueCfg,
+				sem, func() { numSpills++ }, 0, /* numForcedRepartitions */
+			)
+			require.NoError(t, err)
+			accounts = append(accounts, newAccounts...)
+			monitors = append(monitors, newMonitors...)
+			numRuns++
+			return distinct, nil
+		},
+	)
+	for i, sem := range semsToCheck {
+		require.Equal(t, 0, sem.GetCount(), "sem still reports open FDs at index %d", i)
+	}
+	require.Equal(t, numRuns, numSpills, "the spilling didn't occur in all cases")
+
+	for _, acc := range accounts {
+		acc.Close(ctx)
+	}
+	for _, mon := range monitors {
+		mon.Stop(ctx)
+	}
+}
+
 // generateRandomDataForDistinct is a utility function that generates data to be
 // used in randomized unit test of an unordered distinct operation. Note that
 // tups and expected can be in an arbitrary order (meaning the former is
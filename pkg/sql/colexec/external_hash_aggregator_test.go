@@ -91,14 +91,16 @@ func TestExternalHashAggregator(t *testing.T) {
 				}
 				var numExpectedClosers int
 				if diskSpillingEnabled {
-					// The external sorter and the disk spiller should be added
-					// as Closers (the latter is responsible for closing the
-					// in-memory hash aggregator as well as the external one).
-					numExpectedClosers = 2
+					// The disk spiller and the external sorter of the
+					// disk-backed sort as well as the disk spiller of the hash
+					// aggregator should be added as Closers (the latter is
+					// responsible for closing the in-memory hash aggregator as
+					// well as the external one).
+					numExpectedClosers = 3
 					if len(tc.spec.OutputOrdering.Columns) > 0 {
 						// When the output ordering is required, we also plan
-						// another external sort.
-						numExpectedClosers++
+						// another disk-backed sort.
+						numExpectedClosers += 2
 					}
 				} else {
 					// Only the in-memory hash aggregator should be added.
@@ -138,6 +138,11 @@ func NewExternalHashJoiner(
 		if err != nil {
 			colexecerror.InternalError(err)
 		}
+		if spec.ProbeFilter != nil {
+			// The merge joiner doesn't support the probe filter, so we apply
+			// it to the output of the merge joiner.
+			return colexecjoin.NewProbeFilterOp(unlimitedAllocator, diskBackedSortMerge, spec)
+		}
 		return diskBackedSortMerge
 	}
 	return newHashBasedPartitioner(
@@ -92,11 +92,12 @@ func TestExternalHashJoiner(t *testing.T) {
 						ctx, flowCtx, spec, sources, func() {}, queueCfg,
 						numForcedRepartitions, delegateFDAcquisitions, sem,
 					)
-					// Expect three closers. These are the external hash joiner, and
-					// one external sorter for each input.
+					// Expect six closers. These are the disk spiller, the external
+					// hash joiner, and one disk spiller with an external sorter for
+					// each input.
 					// TODO(asubiotto): Explicitly Close when testing.T is passed into
 					//  this constructor and we do a substring match.
-					require.Equal(t, 3, len(closers))
+					require.Equal(t, 6, len(closers))
 					accounts = append(accounts, newAccounts...)
 					monitors = append(monitors, newMonitors...)
 					return hjOp, err
@@ -119,7 +120,9 @@ func TestExternalHashJoiner(t *testing.T) {
 // TestExternalHashJoinerFallbackToSortMergeJoin tests that the external hash
 // joiner falls back to using sort + merge join when repartitioning doesn't
 // decrease the size of the partition. We instantiate two sources that contain
-// the same tuple many times.
+// the same tuple many times. The test also verifies that the conjuncts of the
+// ON expression that are evaluated on the probe input by the hash joiner are
+// respected by the fallback strategy.
 func TestExternalHashJoinerFallbackToSortMergeJoin(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -137,59 +140,79 @@ func TestExternalHashJoinerFallbackToSortMergeJoin(t *testing.T) {
 		},
 		DiskMonitor: testDiskMonitor,
 	}
-	sourceTypes := []*types.T{types.Int}
+	sourceTypes := []*types.T{types.Int, types.Int}
 	batch := testAllocator.NewMemBatchWithMaxCapacity(sourceTypes)
-	// We don't need to set the data since zero values in the columns work.
+	// We don't need to set the data of the first column since zero values
+	// work. The second column alternates between 0 and 1.
+	for i := 0; i < coldata.BatchSize(); i++ {
+		batch.ColVec(1).Int64()[i] = int64(i % 2)
+	}
 	batch.SetLength(coldata.BatchSize())
 	nBatches := 2
-	leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, batch, sourceTypes, nBatches)
-	rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, batch, sourceTypes, nBatches)
-	tc := &joinTestCase{
-		joinType:     descpb.InnerJoin,
-		leftTypes:    sourceTypes,
-		leftOutCols:  []uint32{0},
-		leftEqCols:   []uint32{0},
-		rightTypes:   sourceTypes,
-		rightOutCols: []uint32{0},
-		rightEqCols:  []uint32{0},
-	}
-	tc.init()
-	spec := createSpecForHashJoiner(tc)
-	var spilled bool
-	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
-	defer cleanup()
-	sem := colexecop.NewTestingSemaphore(externalHJMinPartitions)
-	// Ignore closers since the sorter should close itself when it is drained of
-	// all tuples. We assert this by checking that the semaphore reports a count
-	// of 0.
-	hj, accounts, monitors, _, err := createDiskBackedHashJoiner(
-		ctx, flowCtx, spec, []colexecop.Operator{leftSource, rightSource},
-		func() { spilled = true }, queueCfg,
-		// Force a repartition so that the recursive repartitioning always
-		// occurs.
-		1, /* numForcedRepartitions */
-		true /* delegateFDAcquisitions */, sem,
-	)
-	defer func() {
-		for _, acc := range accounts {
-			acc.Close(ctx)
-		}
-		for _, mon := range monitors {
-			mon.Stop(ctx)
-		}
-	}()
-	require.NoError(t, err)
-	hj.Init(ctx)
-	// We have a full cross-product, so we should get the number of tuples
-	// squared in the output.
-	expectedTuplesCount := nBatches * nBatches * coldata.BatchSize() * coldata.BatchSize()
-	actualTuplesCount := 0
-	for b := hj.Next(); b.Length() > 0; b = hj.Next() {
-		actualTuplesCount += b.Length()
+	numOnes := nBatches * (coldata.BatchSize() / 2)
+	for _, tc := range []struct {
+		onExpr execinfrapb.Expression
+		// We have a full cross-product, so we should get the number of
+		// tuples squared in the output (unless the left tuples are filtered).
+		expectedTuplesCount int
+	}{
+		{
+			expectedTuplesCount: nBatches * nBatches * coldata.BatchSize() * coldata.BatchSize(),
+		},
+		{
+			onExpr:              execinfrapb.Expression{Expr: "@2 = 1"},
+			expectedTuplesCount: numOnes * nBatches * coldata.BatchSize(),
+		},
+	} {
+		t.Run(fmt.Sprintf("onExpr=%s", tc.onExpr), func(t *testing.T) {
+			leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, batch, sourceTypes, nBatches)
+			rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, batch, sourceTypes, nBatches)
+			jtc := &joinTestCase{
+				joinType:     descpb.InnerJoin,
+				leftTypes:    sourceTypes,
+				leftOutCols:  []uint32{0},
+				leftEqCols:   []uint32{0},
+				rightTypes:   sourceTypes,
+				rightOutCols: []uint32{0},
+				rightEqCols:  []uint32{0},
+				onExpr:       tc.onExpr,
+			}
+			jtc.init()
+			spec := createSpecForHashJoiner(jtc)
+			var spilled bool
+			queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+			defer cleanup()
+			sem := colexecop.NewTestingSemaphore(externalHJMinPartitions)
+			// Ignore closers since the sorter should close itself when it is
+			// drained of all tuples. We assert this by checking that the
+			// semaphore reports a count of 0.
+			hj, accounts, monitors, _, err := createDiskBackedHashJoiner(
+				ctx, flowCtx, spec, []colexecop.Operator{leftSource, rightSource},
+				func() { spilled = true }, queueCfg,
+				// Force a repartition so that the recursive repartitioning
+				// always occurs.
+				1, /* numForcedRepartitions */
+				true /* delegateFDAcquisitions */, sem,
+			)
+			defer func() {
+				for _, acc := range accounts {
+					acc.Close(ctx)
+				}
+				for _, mon := range monitors {
+					mon.Stop(ctx)
+				}
+			}()
+			require.NoError(t, err)
+			hj.Init(ctx)
+			actualTuplesCount := 0
+			for b := hj.Next(); b.Length() > 0; b = hj.Next() {
+				actualTuplesCount += b.Length()
+			}
+			require.True(t, spilled)
+			require.Equal(t, tc.expectedTuplesCount, actualTuplesCount)
+			require.Equal(t, 0, sem.GetCount())
+		})
 	}
-	require.True(t, spilled)
-	require.Equal(t, expectedTuplesCount, actualTuplesCount)
-	require.Equal(t, 0, sem.GetCount())
 }
 
 // newIntColumns returns nCols columns of types.Int with increasing values
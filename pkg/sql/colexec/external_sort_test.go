@@ -77,7 +77,12 @@ func TestExternalSort(t *testing.T) {
 			for _, tc := range tcs {
 				log.Infof(context.Background(), "spillForced=%t/numRepartitions=%d/%s", spillForced, numForcedRepartitions, tc.description)
 				var semsToCheck []semaphore.Semaphore
-				colexectestutils.RunTestsWithTyps(
+				runTests := colexectestutils.RunTestsWithTyps
+				if len(tc.expected) == 0 {
+					// The output is empty regardless of the input values.
+					runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+				}
+				runTests(
 					t,
 					testAllocator,
 					[]colexectestutils.Tuples{tc.tuples},
@@ -94,7 +99,7 @@ func TestExternalSort(t *testing.T) {
 						// flow this will happen in a downstream materializer/outbox,
 						// since there is no way to tell an operator that Next won't be
 						// called again.
-						if tc.k == 0 || tc.k >= uint64(len(tc.tuples)) {
+						if tc.k == 0 || tc.k+tc.offset >= uint64(len(tc.tuples)) {
 							semsToCheck = append(semsToCheck, sem)
 						}
 						// TODO(asubiotto): Pass in the testing.T of the caller to this
@@ -103,13 +108,14 @@ func TestExternalSort(t *testing.T) {
 						//  result.ToClose) in cases where it is know the sorter will not
 						//  be drained.
 						sorter, newAccounts, newMonitors, closers, err := createDiskBackedSorter(
-							ctx, flowCtx, input, tc.typs, tc.ordCols, tc.matchLen, tc.k, func() {},
+							ctx, flowCtx, input, tc.typs, tc.ordCols, tc.matchLen, tc.k, tc.offset, func() {},
 							numForcedRepartitions, false /* delegateFDAcquisition */, queueCfg, sem,
 						)
-						// Check that the sort was added as a Closer.
+						// Check that the disk spiller and the external sorter were
+						// added as Closers.
 						// TODO(asubiotto): Explicitly Close when testing.T is passed into
 						//  this constructor and we do a substring match.
-						require.Equal(t, 1, len(closers))
+						require.Equal(t, 2, len(closers))
 						accounts = append(accounts, newAccounts...)
 						monitors = append(monitors, newMonitors...)
 						return sorter, err
@@ -208,11 +214,11 @@ func TestExternalSortRandomized(t *testing.T) {
 						semsToCheck = append(semsToCheck, sem)
 						sorter, newAccounts, newMonitors, closers, err := createDiskBackedSorter(
 							ctx, flowCtx, input, typs[:nCols], ordCols,
-							0 /* matchLen */, 0 /* k */, func() {},
+							0 /* matchLen */, 0 /* k */, 0 /* offset */, func() {},
 							numForcedRepartitions, delegateFDAcquisition, queueCfg, sem)
 						// TODO(asubiotto): Explicitly Close when testing.T is passed into
 						//  this constructor and we do a substring match.
-						require.Equal(t, 1, len(closers))
+						require.Equal(t, 2, len(closers))
 						accounts = append(accounts, newAccounts...)
 						monitors = append(monitors, newMonitors...)
 						return sorter, err
@@ -231,6 +237,101 @@ func TestExternalSortRandomized(t *testing.T) {
 	}
 }
 
+// TestStableSorter verifies that the stable sorter preserves the input order
+// among the tuples with equal sort keys, both when sorting in memory and when
+// spilling to disk. The sort key has very low cardinality so that every group
+// of equal tuples spans multiple batches.
+func TestStableSorter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+		DiskMonitor: testDiskMonitor,
+	}
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+
+	// The first column is the sort key and the second column is the position
+	// of the tuple in the input.
+	const numKeys = 3
+	nTups := coldata.BatchSize()*4 + 1
+	typs := []*types.T{types.Int, types.Int}
+	tups := make(colexectestutils.Tuples, nTups)
+	for i := range tups {
+		tups[i] = colexectestutils.Tuple{(nTups - i) % numKeys, i}
+	}
+	expected := make(colexectestutils.Tuples, 0, nTups)
+	for key := 0; key < numKeys; key++ {
+		for _, tup := range tups {
+			if tup[0] == key {
+				expected = append(expected, tup)
+			}
+		}
+	}
+	ordCols := []execinfrapb.Ordering_Column{{ColIdx: 0}}
+
+	var (
+		accounts []*mon.BoundAccount
+		monitors []*mon.BytesMonitor
+	)
+	for _, tc := range []struct {
+		desc           string
+		diskBacked     bool
+		forceDiskSpill bool
+	}{
+		{desc: "in-memory"},
+		{desc: "disk-backed", diskBacked: true},
+		{desc: "disk-backed/forceDiskSpill", diskBacked: true, forceDiskSpill: true},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		flowCtx.Cfg.TestingKnobs.ForceDiskSpill = tc.forceDiskSpill
+		colexectestutils.RunTestsWithTyps(
+			t,
+			testAllocator,
+			[]colexectestutils.Tuples{tups},
+			[][]*types.T{typs},
+			expected,
+			colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				var err error
+				sorter := NewStableSorter(
+					testAllocator, input[0], typs, ordCols,
+					func(input colexecop.Operator, sortTypes []*types.T, sortOrderingCols []execinfrapb.Ordering_Column) colexecop.Operator {
+						if !tc.diskBacked {
+							var sorter colexecop.Operator
+							sorter, err = NewSorter(testAllocator, input, sortTypes, sortOrderingCols)
+							return sorter
+						}
+						sem := colexecop.NewTestingSemaphore(colexecop.ExternalSorterMinPartitions)
+						sorter, newAccounts, newMonitors, _, createErr := createDiskBackedSorter(
+							ctx, flowCtx, []colexecop.Operator{input}, sortTypes, sortOrderingCols,
+							0 /* matchLen */, 0 /* k */, 0 /* offset */, func() {},
+							0 /* numForcedRepartitions */, false /* delegateFDAcquisition */, queueCfg, sem,
+						)
+						err = createErr
+						accounts = append(accounts, newAccounts...)
+						monitors = append(monitors, newMonitors...)
+						return sorter
+					},
+				)
+				return sorter, err
+			})
+	}
+	for _, acc := range accounts {
+		acc.Close(ctx)
+	}
+	for _, m := range monitors {
+		m.Stop(ctx)
+	}
+}
+
 // TestExternalSortMemoryAccounting is a sanity check for the memory accounting
 // done throughout the external sort operation. At the moment there are a lot of
 // known problems with the memory accounting, so the test is not very strict.
@@ -296,12 +397,12 @@ func TestExternalSortMemoryAccounting(t *testing.T) {
 	sem := colexecop.NewTestingSemaphore(numFDs)
 	sorter, accounts, monitors, closers, err := createDiskBackedSorter(
 		ctx, flowCtx, []colexecop.Operator{input}, typs, ordCols,
-		0 /* matchLen */, 0 /* k */, func() { spilled = true },
+		0 /* matchLen */, 0 /* k */, 0 /* offset */, func() { spilled = true },
 		0 /* numForcedRepartitions */, false, /* delegateFDAcquisition */
 		queueCfg, sem,
 	)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(closers))
+	require.Equal(t, 2, len(closers))
 
 	sorter.Init(ctx)
 	for b := sorter.Next(); b.Length() > 0; b = sorter.Next() {
@@ -420,7 +521,7 @@ func BenchmarkExternalSort(b *testing.B) {
 						var spilled bool
 						sorter, accounts, monitors, _, err := createDiskBackedSorter(
 							ctx, flowCtx, []colexecop.Operator{source}, typs, ordCols,
-							0 /* matchLen */, 0 /* k */, func() { spilled = true },
+							0 /* matchLen */, 0 /* k */, 0 /* offset */, func() { spilled = true },
 							0 /* numForcedRepartitions */, false /* delegateFDAcquisitions */, queueCfg, &colexecop.TestingSemaphore{},
 						)
 						memAccounts = append(memAccounts, accounts...)
@@ -460,6 +561,7 @@ func createDiskBackedSorter(
 	ordCols []execinfrapb.Ordering_Column,
 	matchLen int,
 	k uint64,
+	offset uint64,
 	spillingCallbackFn func(),
 	numForcedRepartitions int,
 	delegateFDAcquisitions bool,
@@ -476,7 +578,8 @@ func createDiskBackedSorter(
 			Sorter: sorterSpec,
 		},
 		Post: execinfrapb.PostProcessSpec{
-			Limit: k,
+			Limit:  k,
+			Offset: offset,
 		},
 		ResultTypes: typs,
 	}
@@ -0,0 +1,234 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"math"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// FingerprintAlgorithm specifies the hash function used by the fingerprint
+// operator.
+type FingerprintAlgorithm int
+
+const (
+	// FingerprintFNV64 uses 64-bit FNV-1a hash function.
+	FingerprintFNV64 FingerprintAlgorithm = iota
+	// FingerprintCRC32 uses CRC-32 checksum with the Castagnoli polynomial.
+	FingerprintCRC32
+)
+
+var crc32CastagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// NewFingerprintOp returns an operator that projects into the Int column at
+// position outputIdx a per-row fingerprint of the values in the columns at
+// positions colIdxs (which are of inputTypes types). The fingerprint is
+// computed using the given hash algorithm.
+//
+// The fingerprint of a row depends only on the values of the row (in the
+// order of colIdxs) and not on the way the rows are split into batches or on
+// the selection vectors. The values are canonicalized before hashing, so the
+// values that are considered equal by SQL (like integers of different widths
+// or the decimals with different number of trailing zeroes) have the same
+// fingerprint.
+func NewFingerprintOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	colIdxs []int,
+	algorithm FingerprintAlgorithm,
+	outputIdx int,
+) (colexecop.Operator, error) {
+	var hasher hash.Hash64
+	switch algorithm {
+	case FingerprintFNV64:
+		hasher = fnv.New64a()
+	case FingerprintCRC32:
+	default:
+		return nil, errors.AssertionFailedf("unexpected fingerprint algorithm %d", algorithm)
+	}
+	for _, colIdx := range colIdxs {
+		if colIdx < 0 || colIdx >= len(inputTypes) {
+			return nil, errors.AssertionFailedf("invalid column index %d", colIdx)
+		}
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+	return &fingerprintOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputTypes:     inputTypes,
+		colIdxs:        colIdxs,
+		algorithm:      algorithm,
+		hasher:         hasher,
+		outputIdx:      outputIdx,
+	}, nil
+}
+
+// fingerprintOp is an operator that computes a deterministic per-row digest
+// of several columns. Every row is first encoded into a canonical byte
+// representation which is then hashed.
+type fingerprintOp struct {
+	colexecop.OneInputHelper
+	allocator  *colmem.Allocator
+	inputTypes []*types.T
+	colIdxs    []int
+	algorithm  FingerprintAlgorithm
+	// hasher is only used with FingerprintFNV64 algorithm.
+	hasher    hash.Hash64
+	outputIdx int
+	// scratch is the buffer the canonical encoding of a row is written into.
+	// It is reused across rows and batches.
+	scratch []byte
+	// decimalScratch is used to canonicalize the decimals.
+	decimalScratch apd.Decimal
+}
+
+var _ colexecop.Operator = &fingerprintOp{}
+
+func (f *fingerprintOp) Next() coldata.Batch {
+	batch := f.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	outputVec := batch.ColVec(f.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Int64()
+	// The output vector is of fixed-width type, so we don't need to update
+	// the memory account.
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		f.scratch = f.scratch[:0]
+		for _, colIdx := range f.colIdxs {
+			f.scratch = f.appendCanonicalValue(f.scratch, batch.ColVec(colIdx), f.inputTypes[colIdx], rowIdx)
+		}
+		switch f.algorithm {
+		case FingerprintFNV64:
+			f.hasher.Reset()
+			// Write on hash.Hash never returns an error.
+			_, _ = f.hasher.Write(f.scratch)
+			outputCol[rowIdx] = int64(f.hasher.Sum64())
+		case FingerprintCRC32:
+			outputCol[rowIdx] = int64(crc32.Checksum(f.scratch, crc32CastagnoliTable))
+		}
+	}
+	return batch
+}
+
+const (
+	fingerprintNullMarker    = 0
+	fingerprintNotNullMarker = 1
+)
+
+// appendCanonicalValue appends the canonical encoding of the value at position
+// rowIdx in vec to buf. Every value is prefixed with a marker of whether it is
+// NULL, and the variable-width values are prefixed with their length, so that
+// the encoding of the row is unambiguous.
+func (f *fingerprintOp) appendCanonicalValue(
+	buf []byte, vec coldata.Vec, t *types.T, rowIdx int,
+) []byte {
+	if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(rowIdx) {
+		return append(buf, fingerprintNullMarker)
+	}
+	buf = append(buf, fingerprintNotNullMarker)
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	case types.BoolFamily:
+		if vec.Bool()[rowIdx] {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	case types.BytesFamily:
+		return appendLengthPrefixed(buf, vec.Bytes().Get(rowIdx))
+	case types.IntFamily:
+		var v int64
+		switch t.Width() {
+		case 16:
+			v = int64(vec.Int16()[rowIdx])
+		case 32:
+			v = int64(vec.Int32()[rowIdx])
+		default:
+			v = vec.Int64()[rowIdx]
+		}
+		return appendUint64(buf, uint64(v))
+	case types.FloatFamily:
+		v := vec.Float64()[rowIdx]
+		if math.IsNaN(v) {
+			// All NaN values are considered equal.
+			v = math.NaN()
+		} else if v == 0 {
+			// Negative zero is equal to positive zero.
+			v = 0
+		}
+		return appendUint64(buf, math.Float64bits(v))
+	case types.DecimalFamily:
+		// Decimals that differ only in the number of trailing zeroes are
+		// equal, so we remove those.
+		d := &vec.Decimal()[rowIdx]
+		f.decimalScratch.Reduce(d)
+		if f.decimalScratch.IsZero() {
+			// Negative zero is equal to positive zero.
+			f.decimalScratch.Negative = false
+		}
+		return appendLengthPrefixed(buf, []byte(f.decimalScratch.String()))
+	case types.TimestampTZFamily:
+		v := vec.Timestamp()[rowIdx]
+		buf = appendUint64(buf, uint64(v.Unix()))
+		return appendUint64(buf, uint64(v.Nanosecond()))
+	case types.IntervalFamily:
+		v := vec.Interval()[rowIdx]
+		buf = appendUint64(buf, uint64(v.Months))
+		buf = appendUint64(buf, uint64(v.Days))
+		return appendUint64(buf, uint64(v.Nanos()))
+	case types.JsonFamily:
+		return appendLengthPrefixed(buf, []byte(vec.JSON().Get(rowIdx).String()))
+	case typeconv.DatumVecCanonicalTypeFamily:
+		d := vec.Datum().Get(rowIdx).(*coldataext.Datum).Datum
+		return appendLengthPrefixed(buf, []byte(tree.AsString(d)))
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported fingerprint type %s", t))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], v)
+	return append(buf, scratch[:]...)
+}
+
+func appendLengthPrefixed(buf []byte, v []byte) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	buf = append(buf, scratch[:binary.PutUvarint(scratch[:], uint64(len(v)))]...)
+	return append(buf, v...)
+}
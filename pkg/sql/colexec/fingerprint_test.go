@@ -0,0 +1,147 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// runFingerprintOp returns the fingerprints of the tuples when they are split
+// into the batches of batchSize length.
+func runFingerprintOp(
+	t *testing.T,
+	tuples colexectestutils.Tuples,
+	typs []*types.T,
+	colIdxs []int,
+	algorithm FingerprintAlgorithm,
+	batchSize int,
+) []int64 {
+	input := colexectestutils.NewOpTestInput(testAllocator, batchSize, tuples, typs)
+	op, err := NewFingerprintOp(testAllocator, input, typs, colIdxs, algorithm, len(typs))
+	require.NoError(t, err)
+	op.Init(context.Background())
+	var res []int64
+	for b := op.Next(); b.Length() > 0; b = op.Next() {
+		outputCol := b.ColVec(len(typs)).Int64()
+		for i := 0; i < b.Length(); i++ {
+			rowIdx := i
+			if sel := b.Selection(); sel != nil {
+				rowIdx = sel[i]
+			}
+			res = append(res, outputCol[rowIdx])
+		}
+	}
+	return res
+}
+
+func TestFingerprintOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	typs := []*types.T{types.Int, types.String, types.Decimal, types.Float, types.Jsonb}
+	tuples := colexectestutils.Tuples{
+		{1, "a", *apd.New(1, 0), 1.5, `{"a": 1}`},
+		{nil, "b", *apd.New(15, -1), nil, `[]`},
+		{3, nil, nil, -2.0, nil},
+		{nil, nil, nil, nil, nil},
+		{-4, "", *apd.New(0, 0), 0.0, `"x"`},
+		{5, "ab", *apd.New(-7, 3), 1e10, `{}`},
+		{6, "longer string", *apd.New(3, -5), math.Inf(1), `{"b": [1, 2]}`},
+	}
+	colIdxs := []int{4, 0, 2, 1, 3}
+
+	for _, algorithm := range []FingerprintAlgorithm{FingerprintFNV64, FingerprintCRC32} {
+		t.Run(fmt.Sprintf("algorithm=%d", algorithm), func(t *testing.T) {
+			// The fingerprints must not depend on how the rows are split into
+			// batches.
+			expected := runFingerprintOp(t, tuples, typs, colIdxs, algorithm, coldata.BatchSize())
+			require.Len(t, expected, len(tuples))
+			for batchSize := 1; batchSize < len(tuples) && batchSize < coldata.BatchSize(); batchSize++ {
+				require.Equal(t, expected, runFingerprintOp(t, tuples, typs, colIdxs, algorithm, batchSize))
+			}
+			// All rows are distinct, so their fingerprints must be distinct
+			// too.
+			seen := make(map[int64]struct{})
+			for _, fingerprint := range expected {
+				seen[fingerprint] = struct{}{}
+			}
+			require.Len(t, seen, len(expected))
+
+			// The fingerprints also must not depend on the selection vectors.
+			expectedTuples := make(colexectestutils.Tuples, len(expected))
+			for i := range expected {
+				expectedTuples[i] = colexectestutils.Tuple{expected[i]}
+			}
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, expectedTuples, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					op, err := NewFingerprintOp(testAllocator, input[0], typs, colIdxs, algorithm, len(typs))
+					if err != nil {
+						return nil, err
+					}
+					return colexecbase.NewSimpleProjectOp(op, append(typs[:len(typs):len(typs)], types.Int), []uint32{uint32(len(typs))}), nil
+				})
+		})
+	}
+
+	t.Run("canonical", func(t *testing.T) {
+		fingerprint := func(tuple colexectestutils.Tuple, typs []*types.T) int64 {
+			colIdxs := make([]int, len(typs))
+			for i := range colIdxs {
+				colIdxs[i] = i
+			}
+			res := runFingerprintOp(t, colexectestutils.Tuples{tuple}, typs, colIdxs, FingerprintFNV64, 1 /* batchSize */)
+			require.Len(t, res, 1)
+			return res[0]
+		}
+		// Equal values of different representations have the same
+		// fingerprints.
+		require.Equal(t,
+			fingerprint(colexectestutils.Tuple{2}, []*types.T{types.Int2}),
+			fingerprint(colexectestutils.Tuple{2}, []*types.T{types.Int}),
+		)
+		require.Equal(t,
+			fingerprint(colexectestutils.Tuple{*apd.New(1, 0)}, []*types.T{types.Decimal}),
+			fingerprint(colexectestutils.Tuple{*apd.New(100, -2)}, []*types.T{types.Decimal}),
+		)
+		require.Equal(t,
+			fingerprint(colexectestutils.Tuple{0.0}, []*types.T{types.Float}),
+			fingerprint(colexectestutils.Tuple{math.Copysign(0, -1)}, []*types.T{types.Float}),
+		)
+		// NULL and empty values must be distinguished, as well as the values
+		// that have the same concatenated representations.
+		require.NotEqual(t,
+			fingerprint(colexectestutils.Tuple{nil}, []*types.T{types.String}),
+			fingerprint(colexectestutils.Tuple{""}, []*types.T{types.String}),
+		)
+		require.NotEqual(t,
+			fingerprint(colexectestutils.Tuple{"ab", "c"}, []*types.T{types.String, types.String}),
+			fingerprint(colexectestutils.Tuple{"a", "bc"}, []*types.T{types.String, types.String}),
+		)
+		// The order of the columns matters.
+		require.NotEqual(t,
+			fingerprint(colexectestutils.Tuple{"a", "b"}, []*types.T{types.String, types.String}),
+			fingerprint(colexectestutils.Tuple{"b", "a"}, []*types.T{types.String, types.String}),
+		)
+	})
+}
@@ -0,0 +1,236 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloatFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "sqrt",
+			expr: "sqrt(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{0.0}, {1.0}, {2.25}, {16.0}, {nil},
+			},
+			inputTypes: []*types.T{types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{0.0, 0.0}, {1.0, 1.0}, {2.25, 1.5}, {16.0, 4.0}, {nil, nil},
+			},
+		},
+		{
+			desc: "exp and ln",
+			expr: "ln(exp(@1))",
+			inputTuples: colexectestutils.Tuples{
+				{0.0}, {1.0}, {-2.0}, {nil},
+			},
+			inputTypes: []*types.T{types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{0.0, 0.0}, {1.0, 1.0}, {-2.0, -2.0}, {nil, nil},
+			},
+		},
+		{
+			desc: "log",
+			expr: "log(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{1.0}, {10.0}, {1000.0}, {nil},
+			},
+			inputTypes: []*types.T{types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{1.0, 0.0}, {10.0, 1.0}, {1000.0, 3.0}, {nil, nil},
+			},
+		},
+		{
+			desc: "log with base",
+			expr: "log(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{2.0, 8.0}, {10.0, 100.0}, {nil, 1.0}, {2.0, nil},
+			},
+			inputTypes: []*types.T{types.Float, types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{2.0, 8.0, 3.0}, {10.0, 100.0, 2.0}, {nil, 1.0, nil}, {2.0, nil, nil},
+			},
+		},
+		{
+			desc: "atan2",
+			expr: "atan2(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{0.0, 1.0}, {1.0, 0.0}, {0.0, -1.0}, {nil, 1.0}, {1.0, nil},
+			},
+			inputTypes: []*types.T{types.Float, types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{0.0, 1.0, 0.0}, {1.0, 0.0, math.Pi / 2}, {0.0, -1.0, math.Pi}, {nil, 1.0, nil}, {1.0, nil, nil},
+			},
+		},
+		{
+			desc: "trigonometric",
+			expr: "sin(@1) + cos(@1) + tan(@1) + asin(@1) + acos(@1) + atan(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{0.0}, {nil},
+			},
+			inputTypes: []*types.T{types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{0.0, 1 + math.Pi/2}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+// TestFloatFuncsAgainstRowEngine verifies that the vectorized math builtins
+// produce exactly the same results (including NaNs, infinities and the sign
+// of zero) and the same errors as the row engine on the special values and
+// at the boundaries of the domains.
+func TestFloatFuncsAgainstRowEngine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	specialValues := []float64{
+		0, math.Copysign(0, -1), 1, -1, 0.5, -0.5, 1 + 1e-15, -1 - 1e-15, 10, -10,
+		math.Pi / 2, 710, -746, math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64,
+		math.Inf(1), math.Inf(-1), math.NaN(),
+	}
+	inputTypes := []*types.T{types.Float, types.Float}
+	for _, expr := range []string{
+		"acos(@1)", "asin(@1)", "atan(@1)", "atan2(@1, @2)", "cos(@1)", "exp(@1)",
+		"ln(@1)", "log(@1)", "log(@1, @2)", "sin(@1)", "sqrt(@1)", "tan(@1)",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			parsed, err := parser.ParseExpr(expr)
+			require.NoError(t, err)
+			semaCtx := tree.MakeSemaContext()
+			semaCtx.IVarContainer = &colexectestutils.MockTypeContext{Typs: inputTypes}
+			typedExpr, err := tree.TypeCheck(ctx, parsed, &semaCtx, types.Any)
+			require.NoError(t, err)
+			funcExpr := typedExpr.(*tree.FuncExpr)
+
+			// The inputs on which the row engine returns an error are checked
+			// one at a time.
+			var input colexectestutils.Tuples
+			var expected []float64
+			var errInput colexectestutils.Tuples
+			var expectedErrs []error
+			// The second argument is only varied for the builtins that use it.
+			ys := specialValues[:1]
+			if len(funcExpr.Exprs) > 1 {
+				ys = specialValues
+			}
+			for _, x := range specialValues {
+				for _, y := range ys {
+					args := tree.Datums{tree.NewDFloat(tree.DFloat(x))}
+					if len(funcExpr.Exprs) > 1 {
+						args = append(args, tree.NewDFloat(tree.DFloat(y)))
+					}
+					res, err := funcExpr.ResolvedOverload().Fn(&evalCtx, args)
+					if err != nil {
+						errInput = append(errInput, colexectestutils.Tuple{x, y})
+						expectedErrs = append(expectedErrs, err)
+						continue
+					}
+					input = append(input, colexectestutils.Tuple{x, y})
+					expected = append(expected, float64(*res.(*tree.DFloat)))
+				}
+			}
+
+			op, err := colexectestutils.CreateTestProjectingOperator(
+				ctx, flowCtx, colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), input, inputTypes),
+				inputTypes, expr, false /* canFallbackToRowexec */, testMemAcc,
+			)
+			require.NoError(t, err)
+			op.Init(ctx)
+			var rowIdx int
+			for b := op.Next(); b.Length() > 0; b = op.Next() {
+				outputCol := b.ColVec(len(inputTypes)).Float64()
+				for i := 0; i < b.Length(); i++ {
+					actual := outputCol[i]
+					if math.IsNaN(expected[rowIdx]) {
+						require.True(t, math.IsNaN(actual), "input: %s, actual: %v", input[rowIdx], actual)
+					} else {
+						require.Equal(t, math.Float64bits(expected[rowIdx]), math.Float64bits(actual),
+							"input: %s, expected: %v, actual: %v", input[rowIdx], expected[rowIdx], actual)
+					}
+					rowIdx++
+				}
+			}
+			require.Equal(t, len(input), rowIdx)
+
+			for i, tup := range errInput {
+				t.Run(fmt.Sprintf("%v", tup), func(t *testing.T) {
+					op, err := colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, colexectestutils.Tuples{tup}, inputTypes),
+						inputTypes, expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+					require.NoError(t, err)
+					op.Init(ctx)
+					err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+					require.Error(t, err)
+					require.Contains(t, err.Error(), expectedErrs[i].Error())
+				})
+			}
+		})
+	}
+}
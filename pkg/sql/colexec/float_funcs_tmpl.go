@@ -0,0 +1,184 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for float_funcs.eg.go. It's formatted in
+// a special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _COMPUTE is the template function for computing the result of the builtin
+// on the arguments. The second argument is omitted for the builtins with a
+// single argument.
+func _COMPUTE(_, _ float64) float64 {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _COMPUTE_WITH_ERROR is the same as _COMPUTE for the builtins that can
+// return an error.
+func _COMPUTE_WITH_ERROR(_, _ float64) (float64, error) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// newFloatFuncOperator returns an operator that evaluates one of the math
+// builtins on the Float columns at positions argumentCols and writes the
+// result into the column at position outputIdx.
+func newFloatFuncOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	builtin tree.SpecializedVectorizedBuiltin,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := floatFuncOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+	switch builtin {
+	// {{range .}}
+	case tree._BUILTIN:
+		return &_OP_NAMEOp{floatFuncOpBase: base}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported float builtin %d", builtin))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type floatFuncOpBase struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	funcExpr     *tree.FuncExpr
+	argumentCols []int
+	outputIdx    int
+}
+
+// The functions below compute the results of the builtins that can return an
+// error. They perform exactly the same checks of the domain as the row
+// engine, and the results outside of the domain that don't result in an error
+// are NaNs or infinities as returned by the math package.
+
+// floatSqrt returns the square root of x. It matches tree.Sqrt.
+func floatSqrt(x float64) (float64, error) {
+	if x < 0 {
+		return 0, tree.ErrSqrtOfNegNumber
+	}
+	return math.Sqrt(x), nil
+}
+
+// floatLogBase returns the base b logarithm of x. It matches the float
+// overload of log() with two arguments.
+func floatLogBase(b, x float64) (float64, error) {
+	switch {
+	case x < 0:
+		return 0, tree.ErrLogOfNegNumber
+	case x == 0:
+		return 0, tree.ErrLogOfZero
+	}
+	switch {
+	case b < 0:
+		return 0, tree.ErrLogOfNegNumber
+	case b == 0:
+		return 0, tree.ErrLogOfZero
+	}
+	return math.Log10(x) / math.Log10(b), nil
+}
+
+// {{range .}}
+
+// _OP_NAMEOp is an operator that evaluates _BUILTIN_NAME() builtin on the
+// Float {{if .TwoArgs}}columns{{else}}column{{end}}.
+type _OP_NAMEOp struct {
+	floatFuncOpBase
+}
+
+var _ colexecop.Operator = &_OP_NAMEOp{}
+
+func (f *_OP_NAMEOp) Next() coldata.Batch {
+	batch := f.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	xVec := batch.ColVec(f.argumentCols[0])
+	xNulls, xCol := xVec.Nulls(), xVec.Float64()
+	// {{if .TwoArgs}}
+	yVec := batch.ColVec(f.argumentCols[1])
+	yNulls, yCol := yVec.Nulls(), yVec.Float64()
+	// {{end}}
+	outputVec := batch.ColVec(f.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Float64()
+	f.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				// {{if .TwoArgs}}
+				if xNulls.NullAt(rowIdx) || yNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				x, y := xCol[rowIdx], yCol[rowIdx]
+				// {{else}}
+				if xNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				x := xCol[rowIdx]
+				// {{end}}
+				// {{if .CanError}}
+				res, err := _COMPUTE_WITH_ERROR(x, y)
+				if err != nil {
+					colexecerror.ExpectedError(f.funcExpr.MaybeWrapError(err))
+				}
+				// {{else}}
+				res := _COMPUTE(x, y)
+				// {{end}}
+				outputCol[rowIdx] = res
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
@@ -0,0 +1,213 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewGenerateSubscriptsOp returns an operator that evaluates the
+// generate_subscripts set-returning function. argumentCols contains the
+// positions of the arguments of the function: the array column and,
+// optionally, the Int64 column with the dimension and the Bool column
+// indicating whether the subscripts are generated in reverse order. Each input
+// tuple is repeated once for each subscript of its array with the subscript
+// appended after the first numInputCols columns of the input (the remaining
+// columns of the input, if any, are not emitted).
+//
+// Same as in the row engine, only one-dimensional arrays are supported, so the
+// tuples with the dimension other than 1 don't produce any output. Neither do
+// the tuples with empty arrays or NULL arguments.
+func NewGenerateSubscriptsOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	numInputCols int,
+	argumentCols []int,
+	maxOutputBatchMemSize int64,
+) (colexecop.Operator, error) {
+	// Only the array, the Int64 dimension and the Bool reverse arguments are
+	// supported (for example, an argument might be an untyped NULL).
+	if typ := inputTypes[argumentCols[0]]; typ.Family() != types.ArrayFamily {
+		return nil, errors.Newf("unsupported array argument type %s", typ)
+	}
+	if len(argumentCols) > 1 {
+		typ := inputTypes[argumentCols[1]]
+		if typ.Family() != types.IntFamily || (typ.Width() != 0 && typ.Width() != 64) {
+			return nil, errors.Newf("unsupported dimension argument type %s", typ)
+		}
+	}
+	if len(argumentCols) > 2 {
+		if typ := inputTypes[argumentCols[2]]; typ.Family() != types.BoolFamily {
+			return nil, errors.Newf("unsupported reverse argument type %s", typ)
+		}
+	}
+	outputTypes := make([]*types.T, numInputCols, numInputCols+1)
+	copy(outputTypes, inputTypes[:numInputCols])
+	return &generateSubscriptsOp{
+		OneInputHelper:        colexecop.MakeOneInputHelper(input),
+		allocator:             allocator,
+		outputTypes:           append(outputTypes, types.Int),
+		numInputCols:          numInputCols,
+		argumentCols:          argumentCols,
+		maxOutputBatchMemSize: maxOutputBatchMemSize,
+	}, nil
+}
+
+// generateSubscriptsOp expands each input tuple into as many output tuples as
+// there are subscripts in its array. Since a single input tuple can produce an
+// arbitrary number of output tuples, the operator keeps track of the input
+// tuple being currently expanded across the calls to Next.
+type generateSubscriptsOp struct {
+	colexecop.OneInputHelper
+
+	allocator             *colmem.Allocator
+	outputTypes           []*types.T
+	numInputCols          int
+	argumentCols          []int
+	maxOutputBatchMemSize int64
+
+	// batch is the current input batch, and nextIdx is the position of the
+	// next tuple in it (before applying the selection vector) to be expanded.
+	batch   coldata.Batch
+	nextIdx int
+	// expanding indicates whether the tuple at position rowIdx in batch is
+	// currently being expanded. In such case, next is the subscript to be
+	// emitted next, last is the last subscript to be emitted for the tuple,
+	// and step is either 1 or -1 depending on the order of the subscripts.
+	expanding bool
+	rowIdx    int
+	next      int64
+	last      int64
+	step      int64
+
+	output coldata.Batch
+	// srcIdxs contains the position of the input tuple in batch for each of
+	// the output tuples.
+	srcIdxs []int
+}
+
+var _ colexecop.Operator = &generateSubscriptsOp{}
+
+func (o *generateSubscriptsOp) Next() coldata.Batch {
+	if o.batch == nil {
+		o.batch = o.Input.Next()
+	}
+	if o.batch.Length() == 0 {
+		return coldata.ZeroBatch
+	}
+	// Most commonly, every input tuple expands into a few output ones, so we
+	// use the length of the input batch as the estimate of the output size.
+	o.output, _ = o.allocator.ResetMaybeReallocate(
+		o.outputTypes, o.output, o.batch.Length(), o.maxOutputBatchMemSize,
+	)
+	if cap(o.srcIdxs) < o.output.Capacity() {
+		o.srcIdxs = make([]int, o.output.Capacity())
+	}
+	o.srcIdxs = o.srcIdxs[:o.output.Capacity()]
+	subscripts := o.output.ColVec(o.numInputCols).Int64()
+	var outputIdx int
+	o.allocator.PerformOperation(o.output.ColVecs(), func() {
+		// batchStartIdx is the position of the first output tuple that was
+		// generated from the current input batch.
+		batchStartIdx := 0
+		for outputIdx < o.output.Capacity() {
+			if !o.expanding && !o.startNextTuple() {
+				// The current input batch has been fully consumed, so we
+				// copy the input columns for the output tuples generated
+				// from it before moving onto the next batch.
+				copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+				batchStartIdx = outputIdx
+				o.batch = o.Input.Next()
+				o.nextIdx = 0
+				if o.batch.Length() == 0 {
+					break
+				}
+				continue
+			}
+			// Emit as many subscripts of the current tuple as fit into the
+			// output batch.
+			for ; o.expanding && outputIdx < o.output.Capacity(); outputIdx++ {
+				subscripts[outputIdx] = o.next
+				o.srcIdxs[outputIdx] = o.rowIdx
+				if o.next == o.last {
+					o.expanding = false
+				} else {
+					o.next += o.step
+				}
+			}
+		}
+		copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+		o.output.SetLength(outputIdx)
+	})
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	return o.output
+}
+
+// startNextTuple finds the next tuple in the current input batch that has at
+// least one subscript to be generated and prepares it for the expansion. false
+// is returned if the batch has been fully consumed.
+func (o *generateSubscriptsOp) startNextTuple() bool {
+	n := o.batch.Length()
+	sel := o.batch.Selection()
+	arrayVec := o.batch.ColVec(o.argumentCols[0])
+	arrayNulls, arrayCol := arrayVec.Nulls(), arrayVec.Datum()
+	var dimNulls, reverseNulls *coldata.Nulls
+	var dimCol coldata.Int64s
+	var reverseCol coldata.Bools
+	if len(o.argumentCols) > 1 {
+		dimVec := o.batch.ColVec(o.argumentCols[1])
+		dimNulls, dimCol = dimVec.Nulls(), dimVec.Int64()
+	}
+	if len(o.argumentCols) > 2 {
+		reverseVec := o.batch.ColVec(o.argumentCols[2])
+		reverseNulls, reverseCol = reverseVec.Nulls(), reverseVec.Bool()
+	}
+	for ; o.nextIdx < n; o.nextIdx++ {
+		rowIdx := o.nextIdx
+		if sel != nil {
+			rowIdx = sel[o.nextIdx]
+		}
+		if arrayNulls.NullAt(rowIdx) ||
+			(dimNulls != nil && dimNulls.NullAt(rowIdx)) ||
+			(reverseNulls != nil && reverseNulls.NullAt(rowIdx)) {
+			continue
+		}
+		if dimCol != nil && dimCol.Get(rowIdx) != 1 {
+			continue
+		}
+		arr := tree.MustBeDArray(arrayCol.Get(rowIdx).(*coldataext.Datum).Datum)
+		if arr.Len() == 0 {
+			continue
+		}
+		// Most arrays are 1-indexed, but the special Postgres vector types
+		// are 0-indexed.
+		first := int64(arr.FirstIndex())
+		last := first + int64(arr.Len()) - 1
+		o.next, o.last, o.step = first, last, 1
+		if reverseCol != nil && reverseCol.Get(rowIdx) {
+			o.next, o.last, o.step = last, first, -1
+		}
+		o.rowIdx = rowIdx
+		o.nextIdx++
+		o.expanding = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestGenerateSubscripts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	// longArray expands into several output batches on its own.
+	longArrayLen := 2*coldata.BatchSize() + 3
+	var longArray strings.Builder
+	longArray.WriteString("ARRAY[")
+	longArrayExpected := make(colexectestutils.Tuples, 0, longArrayLen)
+	longArrayReversedExpected := make(colexectestutils.Tuples, 0, longArrayLen)
+	for i := 0; i < longArrayLen; i++ {
+		if i > 0 {
+			longArray.WriteString(", ")
+		}
+		fmt.Fprintf(&longArray, "%d", i)
+		longArrayExpected = append(longArrayExpected, colexectestutils.Tuple{3, i + 1})
+		longArrayReversedExpected = append(longArrayReversedExpected, colexectestutils.Tuple{3, longArrayLen - i})
+	}
+	longArray.WriteString("]")
+
+	// The input columns are the emitted column, the array, the dimension and
+	// whether the subscripts are reversed.
+	typs := []*types.T{types.Int, types.IntArray, types.Int, types.Bool}
+	for _, tc := range []struct {
+		desc         string
+		argumentCols []int
+		tuples       colexectestutils.Tuples
+		expected     colexectestutils.Tuples
+	}{
+		{
+			desc:         "array only",
+			argumentCols: []int{1},
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY[5, 6, 7]", 1, false}, {1, "ARRAY[]:::INT[]", 1, false}, {2, nil, 1, false},
+				{nil, "ARRAY[NULL]:::INT[]", 1, false}, {4, "ARRAY[8, NULL]", 1, false},
+			},
+			expected: colexectestutils.Tuples{
+				{0, 1}, {0, 2}, {0, 3}, {nil, 1}, {4, 1}, {4, 2},
+			},
+		},
+		{
+			// Only one-dimensional arrays are supported, so the other dimensions
+			// don't have any subscripts.
+			desc:         "with dimension",
+			argumentCols: []int{1, 2},
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY[5, 6]", 1, false}, {1, "ARRAY[5, 6]", 2, false}, {2, "ARRAY[5, 6]", 0, false},
+				{3, "ARRAY[5, 6]", -1, false}, {4, "ARRAY[5, 6]", nil, false}, {5, "ARRAY[7]", 1, false},
+			},
+			expected: colexectestutils.Tuples{
+				{0, 1}, {0, 2}, {5, 1},
+			},
+		},
+		{
+			desc:         "reverse",
+			argumentCols: []int{1, 2, 3},
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY[5, 6, 7]", 1, true}, {1, "ARRAY[5, 6, 7]", 1, false}, {2, "ARRAY[5, 6]", 1, nil},
+				{3, "ARRAY[]:::INT[]", 1, true}, {4, "ARRAY[5]", 1, true},
+			},
+			expected: colexectestutils.Tuples{
+				{0, 3}, {0, 2}, {0, 1}, {1, 1}, {1, 2}, {1, 3}, {4, 1},
+			},
+		},
+		{
+			desc:         "long array",
+			argumentCols: []int{1},
+			tuples: colexectestutils.Tuples{
+				{1, "ARRAY[]:::INT[]", 1, false}, {3, longArray.String(), 1, false}, {5, nil, 1, false},
+			},
+			expected: longArrayExpected,
+		},
+		{
+			desc:         "long array reversed",
+			argumentCols: []int{1, 2, 3},
+			tuples: colexectestutils.Tuples{
+				{3, longArray.String(), 1, true}, {5, "ARRAY[1]", 2, true},
+			},
+			expected: longArrayReversedExpected,
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				// Only the first input column is emitted.
+				return NewGenerateSubscriptsOp(
+					testAllocator, input[0], typs, 1 /* numInputCols */, tc.argumentCols, math.MaxInt64, /* maxOutputBatchMemSize */
+				)
+			})
+	}
+}
@@ -453,7 +453,9 @@ func (op *hashAggregator) onlineAgg(b coldata.Batch) {
 
 	// Step 4: now we go over all equality chains and check whether there are
 	// any that haven't been processed yet (they will be of non-zero length).
-	// If we find any, we'll create a new bucket for each.
+	// If we find any, we'll create a new bucket for each. Before doing so, we
+	// check that the new groups will fit under the memory limit.
+	op.checkMemoryForNewGroups(b, eqChainsCount)
 	newGroupsHeadsSel := op.scratch.anotherIntSlice[:0]
 	newGroupCount := 0
 	for eqChainSlot, eqChain := range op.scratch.eqChains[:eqChainsCount] {
@@ -502,6 +504,39 @@ func (op *hashAggregator) onlineAgg(b coldata.Batch) {
 	}
 }
 
+// checkMemoryForNewGroups estimates the memory needed for creating the new
+// aggregation groups for the equality chains that haven't been processed yet
+// and makes sure that the memory limit won't be exceeded. If it would be, a
+// memory error is thrown before any of the new groups are created so that the
+// disk-backed fallback could take over without the memory usage of the
+// in-memory hash aggregator overshooting the limit.
+//
+// Note that the memory of the aggregate functions is registered with the
+// allocator before those are allocated, so it isn't included into the estimate,
+// and the memory used by the aggregate functions to store their intermediate
+// results isn't known upfront.
+func (op *hashAggregator) checkMemoryForNewGroups(b coldata.Batch, eqChainsCount int) {
+	numNewGroups := 0
+	for _, eqChain := range op.scratch.eqChains[:eqChainsCount] {
+		if len(eqChain) > 0 {
+			numNewGroups++
+		}
+	}
+	if numNewGroups == 0 {
+		return
+	}
+	estimate := op.ht.EstimateAppendAllDistinctMemSize(b, numNewGroups)
+	if numReused := op.numPreviouslyCreatedBuckets - len(op.buckets); numReused > 0 {
+		estimate += op.hashAlloc.estimateMemSize(numNewGroups - numReused)
+	} else {
+		estimate += op.hashAlloc.estimateMemSize(numNewGroups)
+	}
+	// The actual allocations are registered once they are performed, so we
+	// only need to check whether the estimate fits under the limit.
+	op.allocator.AdjustMemoryUsage(estimate)
+	op.allocator.ReleaseMemory(estimate)
+}
+
 func (op *hashAggregator) ExportBuffered(input colexecop.Operator) coldata.Batch {
 	if !op.inputTrackingState.zeroBatchEnqueued {
 		// Per the contract of the spilling queue, we need to append a
@@ -550,5 +585,9 @@ func (op *hashAggregator) Close(ctx context.Context) error {
 	if err := op.toClose.Close(ctx); err != nil {
 		retErr = err
 	}
+	// The output batch is no longer needed, so we give it back to the
+	// allocator in order for the batch to be reused by other operators.
+	op.allocator.ReleaseBatch(op.output)
+	op.output = nil
 	return retErr
 }
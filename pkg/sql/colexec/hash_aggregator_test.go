@@ -12,6 +12,9 @@ package colexec
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
@@ -26,11 +29,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlerrors"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/colcontainerutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -147,6 +152,85 @@ func TestHashAggregator(t *testing.T) {
 	}
 }
 
+// TestHashAggregatorFallsBackBeforeInsertingGroups verifies that the memory
+// error is thrown before the new aggregation groups are inserted when their
+// estimated memory usage exceeds the limit, so that the disk spiller can take
+// over without the in-memory hash aggregator overshooting the budget.
+func TestHashAggregatorFallsBackBeforeInsertingGroups(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	// All tuples of the input are in the first batch, and each of them forms
+	// its own group with a large grouping value.
+	const valueSize = 32 << 10
+	typs := []*types.T{types.Int, types.Bytes}
+	numTuples := coldata.BatchSize()
+	tuples := make(colexectestutils.Tuples, numTuples)
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{i, fmt.Sprintf("%d%s", i, strings.Repeat("a", valueSize))}
+	}
+	spec := &execinfrapb.AggregatorSpec{
+		GroupCols: []uint32{0, 1},
+		Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+			{Func: execinfrapb.CountRows},
+		},
+	}
+	constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+		&evalCtx, nil /* semaCtx */, spec.Aggregations, typs,
+	)
+	require.NoError(t, err)
+
+	// Find out how much memory is needed to buffer the input batch.
+	input := colexectestutils.NewOpTestInput(testAllocator, numTuples, tuples, typs)
+	input.Init(ctx)
+	bufferingAcc := testMemMonitor.MakeBoundAccount()
+	defer bufferingAcc.Close(ctx)
+	bufferingAllocator := colmem.NewAllocator(ctx, &bufferingAcc, testColumnFactory)
+	buffered := colexecutils.NewAppendOnlyBufferedBatch(bufferingAllocator, typs, nil /* colsToStore */)
+	batch := input.Next()
+	bufferingAllocator.PerformOperation(buffered.ColVecs(), func() {
+		buffered.AppendTuples(batch, 0 /* startIdx */, batch.Length())
+	})
+
+	// The limit allows for buffering the input but not for storing the
+	// grouping values in the hash table.
+	const monitorName = "hash-aggregator-limited"
+	limit := bufferingAllocator.Used() + int64(numTuples*valueSize/2)
+	memMon := mon.NewMonitor(monitorName, mon.MemoryResource, nil, nil, 0, math.MaxInt64, st)
+	memMon.Start(ctx, nil, mon.MakeStandaloneBudget(limit))
+	defer memMon.Stop(ctx)
+	acc := memMon.MakeBoundAccount()
+	defer acc.Close(ctx)
+	op, err := NewHashAggregator(&colexecagg.NewAggregatorArgs{
+		Allocator:      colmem.NewAllocator(ctx, &acc, testColumnFactory),
+		MemAccount:     &acc,
+		Input:          colexectestutils.NewOpTestInput(testAllocator, numTuples, tuples, typs),
+		InputTypes:     typs,
+		Spec:           spec,
+		EvalCtx:        &evalCtx,
+		Constructors:   constructors,
+		ConstArguments: constArguments,
+		OutputTypes:    outputTypes,
+	},
+		nil, /* newSpillingQueueArgs */
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+	// This is the error that makes the disk spiller fall back to the
+	// disk-backed operator.
+	require.True(t, sqlerrors.IsOutOfMemoryError(err), "unexpected error %v", err)
+	require.Contains(t, err.Error(), monitorName)
+	hashAgg := op.(*hashAggregator)
+	require.Zero(t, hashAgg.ht.Vals.Length(), "groups were inserted before the memory error")
+	require.Zero(t, len(hashAgg.buckets), "groups were created before the memory error")
+	require.LessOrEqual(t, acc.Used(), limit)
+}
+
 func BenchmarkHashAggregatorInputTuplesTracking(b *testing.B) {
 	defer leaktest.AfterTest(b)()
 	defer log.Scope(b).Close(b)
@@ -205,3 +289,92 @@ func BenchmarkHashAggregatorInputTuplesTracking(b *testing.B) {
 		account.Close(ctx)
 	}
 }
+
+// BenchmarkHashAggregatorMultipleFilteredAggregates benchmarks the hash
+// aggregator computing five aggregate functions that either all have the same
+// FILTER clause or don't have one at all.
+func BenchmarkHashAggregatorMultipleFilteredAggregates(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	rng, _ := randutil.NewPseudoRand()
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+
+	numInputRows := 32 * coldata.BatchSize()
+	typs := []*types.T{types.Int, types.Int, types.Int, types.Bool}
+	cols := make([]coldata.Vec, len(typs))
+	for i := range typs {
+		cols[i] = testAllocator.NewMemColumn(typs[i], numInputRows)
+	}
+	for _, col := range cols[1:3] {
+		vals := col.Int64()
+		for i := range vals {
+			// Restrict the range of values to go around the overflow of the
+			// integer summation.
+			vals[i] = int64(rng.Intn(1024))
+		}
+	}
+	filter := cols[3].Bool()
+	for i := range filter {
+		filter[i] = rng.Float64() < 0.5
+	}
+	source := colexectestutils.NewChunkingBatchSource(testAllocator, typs, cols, numInputRows)
+
+	for _, groupSize := range []int{1, 32, coldata.BatchSize()} {
+		numGroups := numInputRows / groupSize
+		groups := cols[0].Int64()
+		for i := range groups {
+			groups[i] = int64(rng.Intn(numGroups))
+		}
+		for _, hasFilter := range []bool{false, true} {
+			tc := aggregatorTestCase{
+				typs:      typs,
+				groupCols: []uint32{0},
+				aggCols:   [][]uint32{{1}, {2}, {}, {1}, {2}},
+				aggFns: []execinfrapb.AggregatorSpec_Func{
+					execinfrapb.SumInt,
+					execinfrapb.Avg,
+					execinfrapb.CountRows,
+					execinfrapb.Min,
+					execinfrapb.Max,
+				},
+			}
+			if hasFilter {
+				tc.aggFilter = []int{3, 3, 3, 3, 3}
+			}
+			require.NoError(b, tc.init())
+			constructors, constArguments, outputTypes, err := colexecagg.ProcessAggregations(
+				&evalCtx, nil /* semaCtx */, tc.spec.Aggregations, tc.typs,
+			)
+			require.NoError(b, err)
+			b.Run(fmt.Sprintf("groupSize=%d/filter=%t", groupSize, hasFilter), func(b *testing.B) {
+				// Only count the aggregation columns.
+				b.SetBytes(int64(8 * 2 * numInputRows))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					a, err := NewHashAggregator(&colexecagg.NewAggregatorArgs{
+						Allocator:      testAllocator,
+						MemAccount:     testMemAcc,
+						Input:          source,
+						InputTypes:     tc.typs,
+						Spec:           tc.spec,
+						EvalCtx:        &evalCtx,
+						Constructors:   constructors,
+						ConstArguments: constArguments,
+						OutputTypes:    outputTypes,
+					}, nil /* newSpillingQueueArgs */)
+					if err != nil {
+						b.Fatal(err)
+					}
+					a.Init(ctx)
+					for b := a.Next(); b.Length() != 0; b = a.Next() {
+					}
+					if err = a.(colexecop.Closer).Close(ctx); err != nil {
+						b.Fatal(err)
+					}
+					source.Reset(ctx)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// newHashFuncOperator returns an operator that evaluates one of the hashing
+// builtins (md5(), sha1(), sha224(), sha256(), sha384(), sha512(), fnv32(),
+// fnv32a(), fnv64(), fnv64a(), crc32ieee(), and crc32c()) on the Bytes columns
+// at positions argumentCols. The cryptographic hashes produce the hex-encoded
+// digest whereas the other hashes produce an Int.
+func newHashFuncOperator(
+	allocator *colmem.Allocator,
+	specializedBuiltin tree.SpecializedVectorizedBuiltin,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &hashFuncOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+	switch specializedBuiltin {
+	case tree.MD5:
+		op.h = md5.New()
+	case tree.SHA1:
+		op.h = sha1.New()
+	case tree.SHA224:
+		op.h = sha256.New224()
+	case tree.SHA256:
+		op.h = sha256.New()
+	case tree.SHA384:
+		op.h = sha512.New384()
+	case tree.SHA512:
+		op.h = sha512.New()
+	case tree.FNV32:
+		op.h32 = fnv.New32()
+	case tree.FNV32a:
+		op.h32 = fnv.New32a()
+	case tree.CRC32IEEE:
+		op.h32 = crc32.New(crc32.IEEETable)
+	case tree.CRC32C:
+		op.h32 = crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case tree.FNV64:
+		op.h64 = fnv.New64()
+	case tree.FNV64a:
+		op.h64 = fnv.New64a()
+	default:
+		colexecerror.InternalError(errors.AssertionFailedf("unsupported hash builtin %d", specializedBuiltin))
+	}
+	// Exactly one of the hashers is set, so we keep a reference to it as
+	// hash.Hash in order to feed it the same way in all cases.
+	switch {
+	case op.h32 != nil:
+		op.h = op.h32
+	case op.h64 != nil:
+		op.h = op.h64
+	}
+	return op
+}
+
+// hashFuncOp is an operator that hashes the concatenation of the non-NULL
+// values of several Bytes columns. The result is NULL only when all values in
+// the row are NULL. The hasher is reused across rows and batches.
+type hashFuncOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	outputIdx    int
+
+	// h is the hasher that is always set. Additionally, exactly one of h32 and
+	// h64 is set if the result is the 32-bit or the 64-bit sum, respectively.
+	// Otherwise, the result is the hex-encoded digest.
+	h   hash.Hash
+	h32 hash.Hash32
+	h64 hash.Hash64
+
+	// sum and hexSum are the buffers the digests are written into. They are
+	// reused across rows and batches.
+	sum    []byte
+	hexSum []byte
+}
+
+var _ colexecop.Operator = &hashFuncOp{}
+
+func (o *hashFuncOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				o.h.Reset()
+				var nonNullSeen bool
+				for _, colIdx := range o.argumentCols {
+					vec := batch.ColVec(colIdx)
+					if vec.Nulls().MaybeHasNulls() && vec.Nulls().NullAt(rowIdx) {
+						continue
+					}
+					nonNullSeen = true
+					// Write never returns an error.
+					_, _ = o.h.Write(vec.Bytes().Get(rowIdx))
+				}
+				if !nonNullSeen {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				switch {
+				case o.h32 != nil:
+					outputVec.Int64()[rowIdx] = int64(o.h32.Sum32())
+				case o.h64 != nil:
+					outputVec.Int64()[rowIdx] = int64(o.h64.Sum64())
+				default:
+					o.sum = o.h.Sum(o.sum[:0])
+					if hexLen := hex.EncodedLen(len(o.sum)); cap(o.hexSum) < hexLen {
+						o.hexSum = make([]byte, hexLen)
+					} else {
+						o.hexSum = o.hexSum[:hexLen]
+					}
+					hex.Encode(o.hexSum, o.sum)
+					outputVec.Bytes().Set(rowIdx, o.hexSum)
+				}
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
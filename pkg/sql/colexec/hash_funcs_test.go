@@ -0,0 +1,158 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestHashFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The expected results are the well-known test vectors of the hashes of
+	// 'abc', '', and '123456789'.
+	testCases := []struct {
+		name   string
+		abc    interface{}
+		empty  interface{}
+		digits interface{}
+	}{
+		{
+			name:   "md5",
+			abc:    "900150983cd24fb0d6963f7d28e17f72",
+			empty:  "d41d8cd98f00b204e9800998ecf8427e",
+			digits: "25f9e794323b453885f5181f1b624d0b",
+		},
+		{
+			name:   "sha1",
+			abc:    "a9993e364706816aba3e25717850c26c9cd0d89d",
+			empty:  "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			digits: "f7c3bc1d808e04732adf679965ccc34ca7ae3441",
+		},
+		{
+			name:   "sha224",
+			abc:    "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+			empty:  "d14a028c2a3a2bc9476102bb288234c415a2b01f828ea62ac5b3e42f",
+			digits: "9b3e61bf29f17c75572fae2e86e17809a4513d07c8a18152acf34521",
+		},
+		{
+			name:   "sha256",
+			abc:    "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+			empty:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			digits: "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225",
+		},
+		{
+			name:   "sha384",
+			abc:    "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+			empty:  "38b060a751ac96384cd9327eb1b1e36a21fdb71114be07434c0cc7bf63f6e1da274edebfe76f65fbd51ad2f14898b95b",
+			digits: "eb455d56d2c1a69de64e832011f3393d45f3fa31d6842f21af92d2fe469c499da5e3179847334a18479c8d1dedea1be3",
+		},
+		{
+			name:   "sha512",
+			abc:    "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+			empty:  "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+			digits: "d9e6762dd1c8eaf6d61b3c6192fc408d4d6d5f1176d0c29169bc24e71c3f274ad27fcd5811b313d681f7e55ec02d73d499c95455b6b5bb503acf574fba8ffe85",
+		},
+		{
+			name:   "fnv32",
+			abc:    1134309195,
+			empty:  2166136261,
+			digits: 605325334,
+		},
+		{
+			name:   "fnv32a",
+			abc:    440920331,
+			empty:  2166136261,
+			digits: 3146166556,
+		},
+		{
+			name:   "fnv64",
+			abc:    -2820157060406071861,
+			empty:  -3750763034362895579,
+			digits: -6399619235874007338,
+		},
+		{
+			name:   "fnv64a",
+			abc:    -1792535898324117685,
+			empty:  -3750763034362895579,
+			digits: 492395637191921148,
+		},
+		{
+			name:   "crc32ieee",
+			abc:    891568578,
+			empty:  0,
+			digits: 3421780262,
+		},
+		{
+			name:   "crc32c",
+			abc:    910901175,
+			empty:  0,
+			digits: 3808858755,
+		},
+	}
+	for _, typ := range []*types.T{types.String, types.Bytes} {
+		typs := []*types.T{typ, typ}
+		for _, tc := range testCases {
+			// The NULL values are skipped and the non-NULL values are hashed
+			// as if they were concatenated, so the result is NULL only if all
+			// values are NULL.
+			inputTuples := colexectestutils.Tuples{
+				{"abc", nil},
+				{"ab", "c"},
+				{nil, "abc"},
+				{"", nil},
+				{"", ""},
+				{"1234", "56789"},
+				{nil, nil},
+			}
+			outputTuples := colexectestutils.Tuples{
+				{"abc", nil, tc.abc},
+				{"ab", "c", tc.abc},
+				{nil, "abc", tc.abc},
+				{"", nil, tc.empty},
+				{"", "", tc.empty},
+				{"1234", "56789", tc.digits},
+				{nil, nil, nil},
+			}
+			expr := fmt.Sprintf("%s(@1, @2)", tc.name)
+			log.Infof(ctx, "%s/%s", typ, expr)
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], typs,
+						expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+				})
+		}
+	}
+}
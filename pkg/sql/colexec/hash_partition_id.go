@@ -0,0 +1,97 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// hashPartitionIDOp is an operator that computes the hash of the tuples on
+// hashCols modulo numPartitions and writes it as the partition ID into the Int
+// column at position outputIdx. It can be used to split the stream of tuples
+// into numPartitions sub-streams, for example, in order to aggregate each of
+// them by a separate worker since the tuples from the same group always end up
+// in the same partition (NULLs are hashed consistently too).
+//
+// The partition IDs are computed with colexechash.TupleHashDistributor using
+// colexechash.DefaultInitHashValue, so they are the same as the indices of the
+// outputs to which the tuples are routed by the hash router with the same
+// number of outputs.
+type hashPartitionIDOp struct {
+	colexecop.OneInputHelper
+
+	hashCols    []uint32
+	outputIdx   int
+	distributor *colexechash.TupleHashDistributor
+}
+
+var _ colexecop.Operator = &hashPartitionIDOp{}
+
+// NewHashPartitionIDOp returns a new operator that appends the partition ID in
+// the range [0, numPartitions) computed from the hash of hashCols to each
+// tuple.
+func NewHashPartitionIDOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	hashCols []uint32,
+	numPartitions int,
+	outputIdx int,
+) colexecop.Operator {
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Int, outputIdx)
+	return &hashPartitionIDOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		hashCols:       hashCols,
+		outputIdx:      outputIdx,
+		distributor:    colexechash.NewTupleHashDistributor(colexechash.DefaultInitHashValue, numPartitions),
+	}
+}
+
+// Init implements the colexecop.Operator interface.
+func (p *hashPartitionIDOp) Init(ctx context.Context) {
+	if !p.InitHelper.Init(ctx) {
+		return
+	}
+	p.Input.Init(p.Ctx)
+	p.distributor.Init(p.Ctx)
+}
+
+func (p *hashPartitionIDOp) Next() coldata.Batch {
+	batch := p.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	partitionIDs := p.distributor.ComputeOutputIdxs(batch, p.hashCols)
+	outputVec := batch.ColVec(p.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	col := outputVec.Int64()
+	if sel := batch.Selection(); sel != nil {
+		for i, selIdx := range sel[:n] {
+			col[selIdx] = int64(partitionIDs[i])
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			col[i] = int64(partitionIDs[i])
+		}
+	}
+	return batch
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHashPartitionID verifies that the partition IDs are deterministic (they
+// don't depend on the batch boundaries or the selection vectors), the same as
+// the outputs chosen by the TupleHashDistributor, and roughly balanced.
+func TestHashPartitionID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	const numTuples = 1000
+	typs := []*types.T{types.Int, types.String}
+	hashCols := []uint32{0, 1}
+	// Every tuple is a separate group, and some groups have NULLs.
+	input := make(colexectestutils.Tuples, numTuples)
+	for i := range input {
+		input[i] = colexectestutils.Tuple{i / 2, fmt.Sprintf("%d", i%2)}
+		if i%10 == 0 {
+			input[i][1] = nil
+		}
+	}
+	for i := 1; i < numTuples; i += 100 {
+		input[i][0] = nil
+	}
+	// Also include the duplicates of some of the groups.
+	input = append(input, input[:numTuples/10]...)
+
+	for _, numPartitions := range []int{1, 2, 7, 16} {
+		// Compute the expected partition IDs by distributing all tuples at
+		// once.
+		batch := testAllocator.NewMemBatchWithFixedCapacity(typs, len(input))
+		for i, tup := range input {
+			for j, v := range tup {
+				if v == nil {
+					batch.ColVec(j).Nulls().SetNull(i)
+				} else if j == 0 {
+					batch.ColVec(j).Int64()[i] = int64(v.(int))
+				} else {
+					batch.ColVec(j).Bytes().Set(i, []byte(v.(string)))
+				}
+			}
+		}
+		batch.SetLength(len(input))
+		distributor := colexechash.NewTupleHashDistributor(colexechash.DefaultInitHashValue, numPartitions)
+		distributor.Init(ctx)
+		expected := make(colexectestutils.Tuples, len(input))
+		partitionSizes := make([]int, numPartitions)
+		for partitionID, sel := range distributor.Distribute(batch, hashCols) {
+			partitionSizes[partitionID] += len(sel)
+			for _, i := range sel {
+				expected[i] = append(colexectestutils.Tuple{}, input[i]...)
+				expected[i] = append(expected[i], partitionID)
+			}
+		}
+		// The duplicates must be in the same partition as the originals.
+		for i := numTuples; i < len(input); i++ {
+			require.Equal(t, expected[i-numTuples], expected[i])
+		}
+		// We expect that every partition has about numTuples/numPartitions
+		// tuples, so if the actual number deviates by more than a factor of 2,
+		// we fail the test.
+		for partitionID, size := range partitionSizes {
+			expectedSize := len(input) / numPartitions
+			require.True(
+				t, size > expectedSize/2 && size < expectedSize*2,
+				"partition %d has %d tuples, expected about %d", partitionID, size, expectedSize,
+			)
+		}
+
+		log.Infof(ctx, "numPartitions=%d", numPartitions)
+		colexectestutils.RunTestsWithoutAllNullsInjection(
+			t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs}, expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return NewHashPartitionIDOp(testAllocator, input[0], hashCols, numPartitions, len(typs)), nil
+			},
+		)
+	}
+}
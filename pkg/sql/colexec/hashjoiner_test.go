@@ -20,7 +20,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecjoin"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecproj"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecsel"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
@@ -30,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -924,6 +928,167 @@ func getHJTestCases() []*joinTestCase {
 			leftOutCols: []uint32{0},
 			expected:    colexectestutils.Tuples{{1}, {2}, {2}},
 		},
+		{
+			description: "27",
+			leftTypes:   []*types.T{types.Int},
+			rightTypes:  []*types.T{types.Int},
+
+			// Test duplicate keys on both sides of a full outer join. Every
+			// unmatched tuple must be emitted exactly once.
+			leftTuples: colexectestutils.Tuples{
+				{0},
+				{0},
+				{1},
+				{nil},
+				{3},
+				{3},
+			},
+			rightTuples: colexectestutils.Tuples{
+				{0},
+				{2},
+				{0},
+				{nil},
+				{2},
+				{3},
+				{0},
+			},
+
+			leftEqCols:   []uint32{0},
+			rightEqCols:  []uint32{0},
+			leftOutCols:  []uint32{0},
+			rightOutCols: []uint32{0},
+
+			joinType: descpb.FullOuterJoin,
+
+			expected: colexectestutils.Tuples{
+				{0, 0},
+				{0, 0},
+				{0, 0},
+				{0, 0},
+				{0, 0},
+				{0, 0},
+				{1, nil},
+				{nil, nil},
+				{3, 3},
+				{3, 3},
+				{nil, 2},
+				{nil, 2},
+				{nil, nil},
+			},
+		},
+		{
+			description: "28",
+			leftTypes:   []*types.T{types.Int},
+			rightTypes:  []*types.T{types.Int},
+
+			// Test a full outer join in which none of the tuples have a match.
+			leftTuples: colexectestutils.Tuples{
+				{1},
+				{1},
+				{nil},
+				{3},
+			},
+			rightTuples: colexectestutils.Tuples{
+				{2},
+				{4},
+				{nil},
+				{2},
+				{4},
+			},
+
+			leftEqCols:   []uint32{0},
+			rightEqCols:  []uint32{0},
+			leftOutCols:  []uint32{0},
+			rightOutCols: []uint32{0},
+
+			joinType: descpb.FullOuterJoin,
+
+			expected: colexectestutils.Tuples{
+				{1, nil},
+				{1, nil},
+				{nil, nil},
+				{3, nil},
+				{nil, 2},
+				{nil, 4},
+				{nil, nil},
+				{nil, 2},
+				{nil, 4},
+			},
+		},
+		{
+			description: "29",
+			leftTypes:   []*types.T{types.Int, types.Int},
+			rightTypes:  []*types.T{types.Int, types.Int},
+
+			// Test ON expression with a conjunct that only references the
+			// left columns (which is evaluated on the probe input) and a
+			// conjunct that references both sides.
+			leftTuples: colexectestutils.Tuples{
+				{1, 1},
+				{2, 2},
+				{3, nil},
+				{4, 4},
+				{5, 5},
+			},
+			rightTuples: colexectestutils.Tuples{
+				{1, 1},
+				{2, 2},
+				{3, 3},
+				{4, 4},
+				{5, 9},
+			},
+
+			leftEqCols:   []uint32{0},
+			rightEqCols:  []uint32{0},
+			leftOutCols:  []uint32{1},
+			rightOutCols: []uint32{1},
+
+			leftEqColsAreKey:  true,
+			rightEqColsAreKey: true,
+
+			onExpr: execinfrapb.Expression{Expr: "@2 > 1 AND @1 + @4 < 10"},
+			expected: colexectestutils.Tuples{
+				{2, 2},
+				{4, 4},
+			},
+		},
+		{
+			description: "30",
+			leftTypes:   []*types.T{types.Int, types.Int},
+			rightTypes:  []*types.T{types.Int, types.Int},
+
+			// Test ON expression that only references the left columns and
+			// needs projections to be evaluated.
+			leftTuples: colexectestutils.Tuples{
+				{1, 1},
+				{2, 2},
+				{3, nil},
+				{4, 4},
+				{5, 5},
+			},
+			rightTuples: colexectestutils.Tuples{
+				{1, 1},
+				{2, 2},
+				{3, 3},
+				{4, 4},
+				{5, 9},
+			},
+
+			leftEqCols:   []uint32{0},
+			rightEqCols:  []uint32{0},
+			leftOutCols:  []uint32{1},
+			rightOutCols: []uint32{1},
+
+			leftEqColsAreKey:  true,
+			rightEqColsAreKey: true,
+
+			onExpr: execinfrapb.Expression{Expr: "@1 + @2 >= 6 OR @2 IS NULL"},
+			expected: colexectestutils.Tuples{
+				{nil, 3},
+				{4, 4},
+				{5, 9},
+			},
+		},
 	}
 	return withMirrors(hjTestCases)
 }
@@ -1031,6 +1196,443 @@ func TestHashJoiner(t *testing.T) {
 	}
 }
 
+// TestHashJoinerRuntimeFilter verifies that the runtime filter pushed from the
+// build side to the probe side of the hash joiner never drops the probe tuples
+// that have a match.
+func TestHashJoinerRuntimeFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const nullProbability = 0.1
+	randKey := func(lo, hi int) interface{} {
+		if rng.Float64() < nullProbability {
+			return nil
+		}
+		return lo + rng.Intn(hi-lo)
+	}
+
+	for _, joinType := range []descpb.JoinType{
+		descpb.InnerJoin, descpb.LeftSemiJoin, descpb.RightOuterJoin,
+		descpb.RightSemiJoin, descpb.RightAntiJoin,
+		// The runtime filter is not used for the following join types, but we
+		// include them to make sure that the unmatched probe tuples are still
+		// emitted.
+		descpb.LeftOuterJoin, descpb.LeftAntiJoin,
+	} {
+		for _, keyType := range []*types.T{types.Int2, types.Int4, types.Int} {
+			for nKeys := 1; nKeys <= 2; nKeys++ {
+				// The left tuples consist of nKeys key columns followed by the row
+				// index, the right tuples consist only of the key columns. The
+				// right keys come from a narrower range so that only some of the
+				// left tuples have a match.
+				leftTypes := make([]*types.T, nKeys+1)
+				rightTypes := make([]*types.T, nKeys)
+				eqCols := make([]uint32, nKeys)
+				for k := 0; k < nKeys; k++ {
+					leftTypes[k], rightTypes[k], eqCols[k] = keyType, keyType, uint32(k)
+				}
+				leftTypes[nKeys] = types.Int
+				leftTuples := make(colexectestutils.Tuples, 100+rng.Intn(400))
+				for i := range leftTuples {
+					leftTuples[i] = make(colexectestutils.Tuple, nKeys+1)
+					for k := 0; k < nKeys; k++ {
+						leftTuples[i][k] = randKey(-50, 50)
+					}
+					leftTuples[i][nKeys] = i
+				}
+				rightTuples := make(colexectestutils.Tuples, rng.Intn(50))
+				for i := range rightTuples {
+					rightTuples[i] = make(colexectestutils.Tuple, nKeys)
+					for k := 0; k < nKeys; k++ {
+						rightTuples[i][k] = randKey(-10, 20)
+					}
+				}
+
+				expected := naiveEqualityJoin(
+					joinType, leftTuples, rightTuples, len(leftTypes), len(rightTypes), nKeys,
+				)
+
+				log.Infof(context.Background(), "%s: keyType=%s nKeys=%d", joinType, keyType, nKeys)
+				// We're omitting all nulls injection test because the expected
+				// output is computed for the original inputs.
+				colexectestutils.RunTestsWithoutAllNullsInjection(
+					t, testAllocator,
+					[]colexectestutils.Tuples{leftTuples, rightTuples},
+					[][]*types.T{leftTypes, rightTypes},
+					expected, colexectestutils.UnorderedVerifier,
+					func(sources []colexecop.Operator) (colexecop.Operator, error) {
+						spec := colexecjoin.MakeHashJoinerSpec(
+							joinType, eqCols, eqCols, leftTypes, rightTypes,
+							false, /* rightDistinct */
+						)
+						return colexecjoin.NewHashJoiner(
+							testAllocator, testAllocator, spec, sources[0], sources[1],
+							colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+						), nil
+					},
+				)
+			}
+		}
+	}
+}
+
+// TestHashJoinerNullKeys verifies that the hash joiner correctly handles the
+// tuples with NULL keys on both sides (which are discarded before hashing for
+// some join types).
+func TestHashJoinerNullKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	const nullProbability = 0.4
+	randKey := func(keyType *types.T, numKeys int) interface{} {
+		if rng.Float64() < nullProbability {
+			return nil
+		}
+		k := rng.Intn(numKeys)
+		if keyType.Family() == types.BytesFamily {
+			return fmt.Sprintf("%d", k)
+		}
+		return k
+	}
+
+	for _, joinType := range []descpb.JoinType{
+		descpb.InnerJoin, descpb.LeftOuterJoin, descpb.RightOuterJoin,
+		descpb.FullOuterJoin, descpb.LeftSemiJoin, descpb.LeftAntiJoin,
+		descpb.RightSemiJoin, descpb.RightAntiJoin,
+	} {
+		// The integer equality columns are also filtered by the runtime
+		// filter on the probe side, so we include them to make sure that the
+		// filters work together.
+		for _, keyTypes := range [][]*types.T{
+			{types.Bytes},
+			{types.Int, types.Bytes},
+		} {
+			// Both the left and the right tuples consist of the key columns
+			// followed by the row index.
+			nKeys := len(keyTypes)
+			typs := append(append([]*types.T{}, keyTypes...), types.Int)
+			eqCols := make([]uint32, nKeys)
+			for k := range eqCols {
+				eqCols[k] = uint32(k)
+			}
+			makeTuples := func(numTuples int) colexectestutils.Tuples {
+				tuples := make(colexectestutils.Tuples, numTuples)
+				for i := range tuples {
+					tuples[i] = make(colexectestutils.Tuple, nKeys+1)
+					for k, keyType := range keyTypes {
+						tuples[i][k] = randKey(keyType, 5)
+					}
+					tuples[i][nKeys] = i
+				}
+				return tuples
+			}
+			leftTuples := makeTuples(rng.Intn(300))
+			rightTuples := makeTuples(rng.Intn(100))
+			expected := naiveEqualityJoin(joinType, leftTuples, rightTuples, len(typs), len(typs), nKeys)
+
+			log.Infof(context.Background(), "%s: keyTypes=%s", joinType, keyTypes)
+			// We're omitting all nulls injection test because the expected
+			// output is computed for the original inputs.
+			colexectestutils.RunTestsWithoutAllNullsInjection(
+				t, testAllocator,
+				[]colexectestutils.Tuples{leftTuples, rightTuples},
+				[][]*types.T{typs, typs},
+				expected, colexectestutils.UnorderedVerifier,
+				func(sources []colexecop.Operator) (colexecop.Operator, error) {
+					spec := colexecjoin.MakeHashJoinerSpec(
+						joinType, eqCols, eqCols, typs, typs, false, /* rightDistinct */
+					)
+					return colexecjoin.NewHashJoiner(
+						testAllocator, testAllocator, spec, sources[0], sources[1],
+						colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					), nil
+				},
+			)
+		}
+	}
+}
+
+// naiveEqualityJoin returns the expected output of the equality join of the
+// given type on the first nKeys columns of the left and the right tuples
+// which have leftWidth and rightWidth columns, respectively. NULL keys never
+// match.
+func naiveEqualityJoin(
+	joinType descpb.JoinType,
+	leftTuples, rightTuples colexectestutils.Tuples,
+	leftWidth, rightWidth, nKeys int,
+) colexectestutils.Tuples {
+	keysMatch := func(l, r colexectestutils.Tuple) bool {
+		for k := 0; k < nKeys; k++ {
+			if l[k] == nil || r[k] == nil || l[k] != r[k] {
+				return false
+			}
+		}
+		return true
+	}
+	concat := func(l, r colexectestutils.Tuple) colexectestutils.Tuple {
+		return append(append(colexectestutils.Tuple{}, l...), r...)
+	}
+	var expected colexectestutils.Tuples
+	leftMatched := make([]bool, len(leftTuples))
+	rightMatched := make([]bool, len(rightTuples))
+	for i, l := range leftTuples {
+		for j, r := range rightTuples {
+			if keysMatch(l, r) {
+				leftMatched[i], rightMatched[j] = true, true
+				switch joinType {
+				case descpb.InnerJoin, descpb.LeftOuterJoin, descpb.RightOuterJoin, descpb.FullOuterJoin:
+					expected = append(expected, concat(l, r))
+				}
+			}
+		}
+	}
+	for i, l := range leftTuples {
+		switch {
+		case joinType == descpb.LeftSemiJoin && leftMatched[i],
+			joinType == descpb.LeftAntiJoin && !leftMatched[i]:
+			expected = append(expected, l)
+		case joinType.IsLeftOuterOrFullOuter() && !leftMatched[i]:
+			expected = append(expected, concat(l, make(colexectestutils.Tuple, rightWidth)))
+		}
+	}
+	for j, r := range rightTuples {
+		switch {
+		case joinType == descpb.RightSemiJoin && rightMatched[j],
+			joinType == descpb.RightAntiJoin && !rightMatched[j]:
+			expected = append(expected, r)
+		case (joinType == descpb.RightOuterJoin || joinType == descpb.FullOuterJoin) && !rightMatched[j]:
+			expected = append(expected, concat(make(colexectestutils.Tuple, leftWidth), r))
+		}
+	}
+	return expected
+}
+
+// TestHashJoinerProbeFilter verifies that the probe filter passed into the hash
+// joiner has the same semantics as the filter on the left columns of the join
+// output.
+func TestHashJoinerProbeFilter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+	rng, _ := randutil.NewPseudoRand()
+	randInt := func(n int) interface{} {
+		if rng.Float64() < 0.1 {
+			return nil
+		}
+		return rng.Intn(n)
+	}
+
+	// Both inputs have the schema (key, value), and the filter is on the value
+	// of the left input.
+	typs := []*types.T{types.Int, types.Int}
+	for _, tc := range []struct {
+		name     string
+		pred     func(x interface{}) bool
+		makeProj func(input colexecop.Operator) colexecop.Operator
+	}{
+		{
+			name: "x > 5",
+			pred: func(x interface{}) bool { return x != nil && x.(int) > 5 },
+			makeProj: func(input colexecop.Operator) colexecop.Operator {
+				op, err := colexecsel.GetSelectionConstOperator(
+					tree.GT, input, typs, 1 /* colIdx */, tree.NewDInt(5), &evalCtx, nil, /* cmpExpr */
+				)
+				require.NoError(t, err)
+				return op
+			},
+		},
+		{
+			// This filter passes the tuples NULL-padded on the left.
+			name: "x IS NULL",
+			pred: func(x interface{}) bool { return x == nil },
+			makeProj: func(input colexecop.Operator) colexecop.Operator {
+				return NewIsNullSelOp(input, 1 /* colIdx */, false /* negate */, false /* isTupleNull */)
+			},
+		},
+		{
+			// This filter appends a column to its input.
+			name: "x + x > 10",
+			pred: func(x interface{}) bool { return x != nil && x.(int)*2 > 10 },
+			makeProj: func(input colexecop.Operator) colexecop.Operator {
+				proj, err := colexecproj.GetProjectionOperator(
+					testAllocator, typs, types.Int, tree.Plus, input, 1 /* col1Idx */, 1, /* col2Idx */
+					2 /* outputIdx */, &evalCtx, nil /* binFn */, nil, /* cmpExpr */
+				)
+				require.NoError(t, err)
+				op, err := colexecsel.GetSelectionConstOperator(
+					tree.GT, proj, []*types.T{types.Int, types.Int, types.Int}, 2, /* colIdx */
+					tree.NewDInt(10), &evalCtx, nil, /* cmpExpr */
+				)
+				require.NoError(t, err)
+				return op
+			},
+		},
+	} {
+		for _, joinType := range []descpb.JoinType{
+			descpb.InnerJoin, descpb.LeftOuterJoin, descpb.LeftSemiJoin,
+			descpb.LeftAntiJoin, descpb.RightOuterJoin, descpb.FullOuterJoin,
+		} {
+			leftTuples := make(colexectestutils.Tuples, 100+rng.Intn(200))
+			for i := range leftTuples {
+				leftTuples[i] = colexectestutils.Tuple{randInt(50), randInt(10)}
+			}
+			rightTuples := make(colexectestutils.Tuples, rng.Intn(100))
+			for i := range rightTuples {
+				rightTuples[i] = colexectestutils.Tuple{randInt(50), randInt(10)}
+			}
+
+			var expected colexectestutils.Tuples
+			maybeEmit := func(l, r colexectestutils.Tuple) {
+				if tc.pred(l[1]) {
+					expected = append(expected, append(append(colexectestutils.Tuple{}, l...), r...))
+				}
+			}
+			nulls := colexectestutils.Tuple{nil, nil}
+			rightMatched := make([]bool, len(rightTuples))
+			for _, l := range leftTuples {
+				leftMatched := false
+				for j, r := range rightTuples {
+					if l[0] != nil && r[0] != nil && l[0] == r[0] {
+						leftMatched, rightMatched[j] = true, true
+						switch joinType {
+						case descpb.InnerJoin, descpb.LeftOuterJoin,
+							descpb.RightOuterJoin, descpb.FullOuterJoin:
+							maybeEmit(l, r)
+						}
+					}
+				}
+				switch {
+				case joinType == descpb.LeftSemiJoin && leftMatched,
+					joinType == descpb.LeftAntiJoin && !leftMatched:
+					maybeEmit(l, nil /* r */)
+				case (joinType == descpb.LeftOuterJoin || joinType == descpb.FullOuterJoin) && !leftMatched:
+					maybeEmit(l, nulls)
+				}
+			}
+			if joinType == descpb.RightOuterJoin || joinType == descpb.FullOuterJoin {
+				for j, r := range rightTuples {
+					if !rightMatched[j] {
+						maybeEmit(nulls, r)
+					}
+				}
+			}
+
+			log.Infof(ctx, "%s/%s", tc.name, joinType)
+			// We're omitting all nulls injection test because the expected
+			// output is computed for the original inputs.
+			colexectestutils.RunTestsWithoutAllNullsInjection(
+				t, testAllocator,
+				[]colexectestutils.Tuples{leftTuples, rightTuples},
+				[][]*types.T{typs, typs},
+				expected, colexectestutils.UnorderedVerifier,
+				func(sources []colexecop.Operator) (colexecop.Operator, error) {
+					spec := colexecjoin.MakeHashJoinerSpec(
+						joinType, []uint32{0}, []uint32{0}, typs, typs,
+						false, /* rightDistinct */
+					)
+					spec.ProbeFilter = tc.makeProj
+					return colexecjoin.NewHashJoiner(
+						testAllocator, testAllocator, spec, sources[0], sources[1],
+						colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					), nil
+				},
+			)
+		}
+	}
+}
+
+// TestHashJoinerFullOuterDuplicates verifies that the full outer hash joiner
+// emits every unmatched tuple from both inputs exactly once when the build
+// side contains many duplicate keys and spans multiple batches.
+func TestHashJoinerFullOuterDuplicates(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Int}
+	for _, tc := range []struct {
+		name string
+		// leftKeys and rightKeys determine the range of the keys on each side,
+		// keys outside of [0, numMatching) never have a match.
+		leftKeys, rightKeys, numMatching int
+	}{
+		{name: "some matched", leftKeys: 20, rightKeys: 40, numMatching: 10},
+		{name: "all matched", leftKeys: 5, rightKeys: 5, numMatching: 5},
+		{name: "none matched", leftKeys: 10, rightKeys: 10, numMatching: 0},
+	} {
+		randKey := func(numKeys int, left bool) interface{} {
+			if rng.Float64() < 0.05 {
+				return nil
+			}
+			k := rng.Intn(numKeys)
+			if k >= tc.numMatching {
+				// Make the non-matching keys differ between the inputs.
+				k = k*2 + 1
+				if left {
+					k++
+				}
+			}
+			return k
+		}
+		leftTuples := make(colexectestutils.Tuples, 1+rng.Intn(300))
+		for i := range leftTuples {
+			leftTuples[i] = colexectestutils.Tuple{randKey(tc.leftKeys, true /* left */), i}
+		}
+		// Make sure that the build side takes up multiple batches.
+		rightTuples := make(colexectestutils.Tuples, coldata.BatchSize()+rng.Intn(3*coldata.BatchSize()))
+		for i := range rightTuples {
+			rightTuples[i] = colexectestutils.Tuple{randKey(tc.rightKeys, false /* left */), i}
+		}
+
+		var expected colexectestutils.Tuples
+		nulls := colexectestutils.Tuple{nil, nil}
+		rightMatched := make([]bool, len(rightTuples))
+		for _, l := range leftTuples {
+			leftMatched := false
+			for j, r := range rightTuples {
+				if l[0] != nil && r[0] != nil && l[0] == r[0] {
+					leftMatched, rightMatched[j] = true, true
+					expected = append(expected, append(append(colexectestutils.Tuple{}, l...), r...))
+				}
+			}
+			if !leftMatched {
+				expected = append(expected, append(append(colexectestutils.Tuple{}, l...), nulls...))
+			}
+		}
+		for j, r := range rightTuples {
+			if !rightMatched[j] {
+				expected = append(expected, append(append(colexectestutils.Tuple{}, nulls...), r...))
+			}
+		}
+
+		log.Infof(ctx, "%s", tc.name)
+		// We're omitting all nulls injection test because the expected output
+		// is computed for the original inputs.
+		colexectestutils.RunTestsWithoutAllNullsInjection(
+			t, testAllocator,
+			[]colexectestutils.Tuples{leftTuples, rightTuples},
+			[][]*types.T{typs, typs},
+			expected, colexectestutils.UnorderedVerifier,
+			func(sources []colexecop.Operator) (colexecop.Operator, error) {
+				spec := colexecjoin.MakeHashJoinerSpec(
+					descpb.FullOuterJoin, []uint32{0}, []uint32{0}, typs, typs,
+					false, /* rightDistinct */
+				)
+				return colexecjoin.NewHashJoiner(
+					testAllocator, testAllocator, spec, sources[0], sources[1],
+					colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+				), nil
+			},
+		)
+	}
+}
+
 func BenchmarkHashJoiner(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	ctx := context.Background()
@@ -1113,6 +1715,231 @@ func BenchmarkHashJoiner(b *testing.B) {
 	}
 }
 
+// BenchmarkHashJoinerLeftOuterUnmatched benchmarks the left outer hash join
+// where most of the left rows don't have a match, so most of the output rows
+// are padded with NULLs in the right columns. In the allUnmatched case none of
+// the left rows have a match.
+func BenchmarkHashJoinerLeftOuterUnmatched(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	// Only one out of matchEvery left rows has a match.
+	const matchEvery = 16
+
+	for _, allUnmatched := range []bool{false, true} {
+		for _, nRightCols := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("allUnmatched=%t/rightCols=%d", allUnmatched, nRightCols), func(b *testing.B) {
+				leftTypes := []*types.T{types.Int, types.Int}
+				rightTypes := make([]*types.T, nRightCols)
+				for i := range rightTypes {
+					rightTypes[i] = types.Int
+				}
+				leftBatch := testAllocator.NewMemBatchWithMaxCapacity(leftTypes)
+				rightBatch := testAllocator.NewMemBatchWithMaxCapacity(rightTypes)
+				for i := 0; i < coldata.BatchSize(); i++ {
+					leftBatch.ColVec(0).Int64()[i] = int64(i * matchEvery)
+					if allUnmatched {
+						leftBatch.ColVec(0).Int64()[i] = int64(coldata.BatchSize() + i)
+					}
+					leftBatch.ColVec(1).Int64()[i] = int64(i)
+					for colIdx := range rightTypes {
+						rightBatch.ColVec(colIdx).Int64()[i] = int64(i)
+					}
+				}
+				leftBatch.SetLength(coldata.BatchSize())
+				rightBatch.SetLength(coldata.BatchSize())
+
+				const nBatches = 1 << 8
+				// 8 (bytes / int64) * nBatches (number of batches) * col.BatchSize()
+				// (rows / batch) * number of columns in both sources.
+				b.SetBytes(int64(8 * nBatches * coldata.BatchSize() * (len(leftTypes) + nRightCols)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, leftBatch, leftTypes, nBatches)
+					rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, rightBatch, rightTypes, 1 /* usableCount */)
+					hjSpec := colexecjoin.MakeHashJoinerSpec(
+						descpb.LeftOuterJoin,
+						[]uint32{0}, []uint32{0},
+						leftTypes, rightTypes,
+						true, /* rightDistinct */
+					)
+					hj := colexecjoin.NewHashJoiner(
+						testAllocator, testAllocator, hjSpec,
+						leftSource, rightSource,
+						colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					)
+					hj.Init(ctx)
+					for hj.Next().Length() > 0 {
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkHashJoinerRuntimeFilter(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	for _, matchEvery := range []int{1, 16, 256} {
+		// Only one out of matchEvery left rows has a match, and all of the
+		// left rows without a match are outside of the range of the right
+		// values.
+		b.Run(fmt.Sprintf("matchEvery=%d", matchEvery), func(b *testing.B) {
+			typs := []*types.T{types.Int, types.Int}
+			leftBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+			rightBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+			for i := 0; i < coldata.BatchSize(); i++ {
+				leftBatch.ColVec(0).Int64()[i] = int64(i * matchEvery)
+				leftBatch.ColVec(1).Int64()[i] = int64(i)
+				rightBatch.ColVec(0).Int64()[i] = int64(i)
+				rightBatch.ColVec(1).Int64()[i] = int64(i)
+			}
+			leftBatch.SetLength(coldata.BatchSize())
+			rightBatch.SetLength(coldata.BatchSize())
+
+			const nBatches = 1 << 8
+			// 8 (bytes / int64) * nBatches (number of batches) * col.BatchSize()
+			// (rows / batch) * number of columns in the left source.
+			b.SetBytes(int64(8 * nBatches * coldata.BatchSize() * len(typs)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, leftBatch, typs, nBatches)
+				rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, rightBatch, typs, 1 /* usableCount */)
+				hjSpec := colexecjoin.MakeHashJoinerSpec(
+					descpb.InnerJoin,
+					[]uint32{0}, []uint32{0},
+					typs, typs,
+					true, /* rightDistinct */
+				)
+				hj := colexecjoin.NewHashJoiner(
+					testAllocator, testAllocator, hjSpec,
+					leftSource, rightSource,
+					colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+				)
+				hj.Init(ctx)
+				for hj.Next().Length() > 0 {
+				}
+			}
+		})
+	}
+}
+func BenchmarkHashJoinerNullKeys(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+
+	for _, joinType := range []descpb.JoinType{descpb.InnerJoin, descpb.LeftOuterJoin} {
+		for _, nullFraction := range []float64{0, 0.5, 0.9} {
+			// The keys are of the bytes type so that the runtime filter is not
+			// used. Both sides have the same fraction of NULL keys, and all
+			// non-NULL keys have a match.
+			b.Run(fmt.Sprintf("%s/nullFraction=%.1f", joinType, nullFraction), func(b *testing.B) {
+				typs := []*types.T{types.Bytes, types.Int}
+				leftBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+				rightBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+				numNulls := int(float64(coldata.BatchSize()) * nullFraction)
+				for _, batch := range []coldata.Batch{leftBatch, rightBatch} {
+					keys := batch.ColVec(0)
+					for i := 0; i < coldata.BatchSize(); i++ {
+						if i < numNulls {
+							keys.Nulls().SetNull(i)
+						} else {
+							keys.Bytes().Set(i, []byte(fmt.Sprintf("%d", i)))
+						}
+						batch.ColVec(1).Int64()[i] = int64(i)
+					}
+					batch.SetLength(coldata.BatchSize())
+				}
+
+				const nBatches = 1 << 8
+				b.SetBytes(int64(8 * nBatches * coldata.BatchSize() * len(typs)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, leftBatch, typs, nBatches)
+					rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, rightBatch, typs, 1 /* usableCount */)
+					hjSpec := colexecjoin.MakeHashJoinerSpec(
+						joinType,
+						[]uint32{0}, []uint32{0},
+						typs, typs,
+						false, /* rightDistinct */
+					)
+					hj := colexecjoin.NewHashJoiner(
+						testAllocator, testAllocator, hjSpec,
+						leftSource, rightSource,
+						colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					)
+					hj.Init(ctx)
+					for hj.Next().Length() > 0 {
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkHashJoinerProbeFilter(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+
+	typs := []*types.T{types.Int, types.Int}
+	leftBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	rightBatch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for i := 0; i < coldata.BatchSize(); i++ {
+		leftBatch.ColVec(0).Int64()[i] = int64(i)
+		leftBatch.ColVec(1).Int64()[i] = int64(i)
+		rightBatch.ColVec(0).Int64()[i] = int64(i)
+		rightBatch.ColVec(1).Int64()[i] = int64(i)
+	}
+	leftBatch.SetLength(coldata.BatchSize())
+	rightBatch.SetLength(coldata.BatchSize())
+	// Only one out of 16 left rows passes the filter '@2 < selectivity'.
+	selectivity := tree.NewDInt(tree.DInt(coldata.BatchSize() / 16))
+	makeFilter := func(input colexecop.Operator) colexecop.Operator {
+		op, err := colexecsel.GetSelectionConstOperator(
+			tree.LT, input, typs, 1 /* colIdx */, selectivity, &evalCtx, nil, /* cmpExpr */
+		)
+		require.NoError(b, err)
+		return op
+	}
+
+	for _, joinType := range []descpb.JoinType{descpb.InnerJoin, descpb.RightOuterJoin} {
+		for _, pushed := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/pushed=%t", joinType, pushed), func(b *testing.B) {
+				const nBatches = 1 << 8
+				// 8 (bytes / int64) * nBatches (number of batches) * col.BatchSize()
+				// (rows / batch) * number of columns in the left source.
+				b.SetBytes(int64(8 * nBatches * coldata.BatchSize() * len(typs)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					leftSource := colexectestutils.NewFiniteBatchSource(testAllocator, leftBatch, typs, nBatches)
+					rightSource := colexectestutils.NewFiniteBatchSource(testAllocator, rightBatch, typs, 1 /* usableCount */)
+					hjSpec := colexecjoin.MakeHashJoinerSpec(
+						joinType,
+						[]uint32{0}, []uint32{0},
+						typs, typs,
+						false, /* rightDistinct */
+					)
+					if pushed {
+						hjSpec.ProbeFilter = makeFilter
+					}
+					var op colexecop.Operator = colexecjoin.NewHashJoiner(
+						testAllocator, testAllocator, hjSpec,
+						leftSource, rightSource,
+						colexecjoin.HashJoinerInitialNumBuckets, execinfra.DefaultMemoryLimit,
+					)
+					if !pushed {
+						op = makeFilter(op)
+					}
+					op.Init(ctx)
+					for op.Next().Length() > 0 {
+					}
+				}
+			})
+		}
+	}
+}
+
 // TestHashJoinerProjection tests that planning of hash joiner correctly
 // handles the "post-joiner" projection. The test uses different types with a
 // projection in which output columns from both sides are intertwined so that
@@ -1183,3 +2010,107 @@ func TestHashJoinerProjection(t *testing.T) {
 		b.ColVec(5).Bytes()
 	}
 }
+
+// TestHashJoinerLeftOuterProjectionOntoRightCols tests the left outer hash
+// join where most of the left rows don't have a match followed by a
+// projection onto the right columns, so most of the output rows consist
+// only of NULLs.
+func TestHashJoinerLeftOuterProjectionOntoRightCols(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	leftTypes := []*types.T{types.Int, types.Int}
+	rightTypes := []*types.T{types.Int, types.Float, types.Bytes}
+	const numLeftRows = 20
+	// Only the left rows with keys 3 and 17 have a match.
+	rightTuples := colexectestutils.Tuples{{3, 0.3, "three"}, {17, 1.7, "seventeen"}}
+	var leftTuples, expected colexectestutils.Tuples
+	for i := 0; i < numLeftRows; i++ {
+		leftTuples = append(leftTuples, colexectestutils.Tuple{i, i * 10})
+		switch i {
+		case 3:
+			expected = append(expected, colexectestutils.Tuple{0.3, "three"})
+		case 17:
+			expected = append(expected, colexectestutils.Tuple{1.7, "seventeen"})
+		default:
+			expected = append(expected, colexectestutils.Tuple{nil, nil})
+		}
+	}
+
+	spec := &execinfrapb.ProcessorSpec{
+		Core: execinfrapb.ProcessorCoreUnion{
+			HashJoiner: &execinfrapb.HashJoinerSpec{
+				LeftEqColumns:        []uint32{0},
+				RightEqColumns:       []uint32{0},
+				RightEqColumnsAreKey: true,
+				Type:                 descpb.LeftOuterJoin,
+			},
+		},
+		Input: []execinfrapb.InputSyncSpec{
+			{ColumnTypes: leftTypes},
+			{ColumnTypes: rightTypes},
+		},
+		Post: execinfrapb.PostProcessSpec{
+			Projection:    true,
+			OutputColumns: []uint32{3, 4},
+		},
+		ResultTypes: []*types.T{types.Float, types.Bytes},
+	}
+	constructor := func(sources []colexecop.Operator) (colexecop.Operator, error) {
+		args := &colexecargs.NewColOperatorArgs{
+			Spec:                spec,
+			Inputs:              colexectestutils.MakeInputs(sources),
+			StreamingMemAccount: testMemAcc,
+		}
+		args.TestingKnobs.UseStreamingMemAccountForBuffering = true
+		args.TestingKnobs.DiskSpillingDisabled = true
+		result, err := colexecargs.TestNewColOperator(ctx, flowCtx, args)
+		if err != nil {
+			return nil, err
+		}
+		return result.Root, nil
+	}
+
+	colexectestutils.RunTestsWithTyps(
+		t, testAllocator, []colexectestutils.Tuples{leftTuples, rightTuples},
+		[][]*types.T{leftTypes, rightTypes}, expected, colexectestutils.UnorderedVerifier, constructor,
+	)
+
+	// Additionally, check that the batches returned by the projection
+	// correctly expose the NULLs of the unmatched rows.
+	for _, batchSize := range []int{1, 3, coldata.BatchSize()} {
+		t.Run(fmt.Sprintf("batchSize=%d", batchSize), func(t *testing.T) {
+			leftSource := colexectestutils.NewOpTestInput(testAllocator, batchSize, leftTuples, leftTypes)
+			rightSource := colexectestutils.NewOpTestInput(testAllocator, batchSize, rightTuples, rightTypes)
+			op, err := constructor([]colexecop.Operator{leftSource, rightSource})
+			require.NoError(t, err)
+			op.Init(ctx)
+			numRows, numNulls := 0, 0
+			for b := op.Next(); b.Length() > 0; b = op.Next() {
+				require.Equal(t, 2, b.Width())
+				floats, bytes := b.ColVec(0), b.ColVec(1)
+				for i := 0; i < b.Length(); i++ {
+					require.Equal(t, floats.Nulls().NullAt(i), bytes.Nulls().NullAt(i))
+					if floats.Nulls().NullAt(i) {
+						numNulls++
+						require.True(t, colexecbase.MaybeHasNulls(b))
+					}
+				}
+				numRows += b.Length()
+			}
+			require.Equal(t, numLeftRows, numRows)
+			require.Equal(t, numLeftRows-len(rightTuples), numNulls)
+		})
+	}
+}
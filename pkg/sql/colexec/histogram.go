@@ -0,0 +1,338 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colencoding"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/errors"
+)
+
+// histogramSketchSize is the upper bound on the memory used by a single
+// HyperLogLog sketch of precision 14 which is used to estimate the number of
+// distinct values within a bucket.
+const histogramSketchSize = int64(1) << 14
+
+// HistogramOutputTypes returns the types of the columns emitted by the
+// operator created with NewHistogramOp for a column of the given type.
+func HistogramOutputTypes(typ *types.T) []*types.T {
+	return []*types.T{typ, types.Int, types.Int, types.Float}
+}
+
+// NewHistogramOp returns a new operator that consumes its whole input and
+// computes the histogram of the column at position colIdx (of type typ) with
+// the buckets defined by upperBounds which must be non-NULL and strictly
+// increasing.
+//
+// The histogram is emitted with the schema of HistogramOutputTypes(typ), i.e.
+// the columns are (upper_bound, num_eq, num_range, distinct_range) with the
+// same meaning as in stats.HistogramData_Bucket:
+// - the first row always has NULL upper bound, and its num_eq is the number
+// of NULL values (the other two columns are zero)
+// - then each bucket is emitted. num_eq is the number of values equal to the
+// upper bound, num_range is the number of values that are greater than the
+// previous upper bound and less than the upper bound of the bucket, and
+// distinct_range is the estimate of the number of distinct values among the
+// latter
+// - if some of the values are greater than the last upper bound, then one
+// more bucket is emitted with the maximum value as its upper bound.
+//
+// The values are compared using their key encoding, and the number of distinct
+// values is estimated with a HyperLogLog sketch for each of the buckets. The
+// sketches are created lazily, once the first value falls into the range of
+// the bucket, and are accounted for with the allocator.
+func NewHistogramOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	typ *types.T,
+	colIdx int,
+	upperBounds tree.Datums,
+) (colexecop.Operator, error) {
+	if !colinfo.ColumnTypeIsIndexable(typ) {
+		return nil, errors.AssertionFailedf("histograms are not supported on type %s", typ.SQLString())
+	}
+	encodedBounds := make([][]byte, len(upperBounds))
+	for i, bound := range upperBounds {
+		if bound == tree.DNull {
+			return nil, errors.AssertionFailedf("histogram upper bound cannot be NULL")
+		}
+		if !bound.ResolvedType().Equivalent(typ) {
+			return nil, errors.AssertionFailedf(
+				"histogram upper bound %s is not of type %s", bound, typ.SQLString(),
+			)
+		}
+		var err error
+		encodedBounds[i], err = rowenc.EncodeTableKey(nil /* b */, bound, encoding.Ascending)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && bytes.Compare(encodedBounds[i-1], encodedBounds[i]) >= 0 {
+			return nil, errors.AssertionFailedf("histogram upper bounds are not strictly increasing")
+		}
+	}
+	return &histogramOp{
+		OneInputNode:  colexecop.NewOneInputNode(input),
+		allocator:     allocator,
+		typ:           typ,
+		colIdx:        colIdx,
+		upperBounds:   upperBounds,
+		encodedBounds: encodedBounds,
+		buckets:       make([]histogramBucket, len(upperBounds)),
+	}, nil
+}
+
+// histogramBucket contains the counts of a single bucket of the histogram.
+type histogramBucket struct {
+	numEq    int64
+	numRange int64
+	// sketch is nil until the first value falls into the range of the bucket.
+	sketch *hyperloglog.Sketch
+}
+
+// histogramState represents the state of the histogram operator.
+type histogramState int
+
+const (
+	// histogramBuilding is the initial state of the operator, where it
+	// consumes its input while updating the buckets.
+	histogramBuilding histogramState = iota
+	// histogramEmitting is the second state of the operator, indicating that
+	// each call to Next will return another batch of the histogram.
+	histogramEmitting
+	// histogramDone is the final state of the operator, where it always
+	// returns a zero batch.
+	histogramDone
+)
+
+// histogramOp computes the histogram of a single column in one pass over the
+// input. See the comment on NewHistogramOp for more details.
+type histogramOp struct {
+	colexecop.OneInputNode
+	colexecop.InitHelper
+
+	allocator     *colmem.Allocator
+	typ           *types.T
+	colIdx        int
+	upperBounds   tree.Datums
+	encodedBounds [][]byte
+
+	state     histogramState
+	nullCount int64
+	buckets   []histogramBucket
+	// maxBucket contains the counts of the values greater than the last upper
+	// bound. The values equal to the maximum value (which is stored in maxVec
+	// and maxKey) are counted in numEq, and the remaining ones in numRange.
+	maxBucket histogramBucket
+	maxVec    coldata.Vec
+	maxKey    []byte
+	// buf is reused when computing the key encoding of the values.
+	buf []byte
+	// emitted is the number of rows of the histogram which have been emitted
+	// so far.
+	emitted int
+	output  coldata.Batch
+}
+
+var _ colexecop.Operator = &histogramOp{}
+
+func (h *histogramOp) Init(ctx context.Context) {
+	if !h.InitHelper.Init(ctx) {
+		return
+	}
+	h.Input.Init(h.Ctx)
+}
+
+func (h *histogramOp) Next() coldata.Batch {
+	for {
+		switch h.state {
+		case histogramBuilding:
+			h.build()
+			h.state = histogramEmitting
+		case histogramEmitting:
+			output := h.emit()
+			if output.Length() == 0 {
+				h.state = histogramDone
+				continue
+			}
+			return output
+		case histogramDone:
+			return coldata.ZeroBatch
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("invalid histogram state %v", h.state))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	}
+}
+
+// insertIntoSketch inserts the key into the sketch of the bucket, creating the
+// sketch if necessary.
+func (h *histogramOp) insertIntoSketch(b *histogramBucket, key []byte) {
+	if b.sketch == nil {
+		h.allocator.AdjustMemoryUsage(histogramSketchSize)
+		b.sketch = hyperloglog.New14()
+	}
+	b.sketch.Insert(key)
+}
+
+// build reads in the entire input updating the buckets.
+func (h *histogramOp) build() {
+	for batch := h.Input.Next(); batch.Length() > 0; batch = h.Input.Next() {
+		n := batch.Length()
+		sel := batch.Selection()
+		vec := batch.ColVec(h.colIdx)
+		nulls := vec.Nulls()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if nulls.NullAt(rowIdx) {
+				h.nullCount++
+				continue
+			}
+			var err error
+			h.buf, err = colencoding.EncodeTableKeyFromCol(h.buf[:0], vec, rowIdx, h.typ, encoding.Ascending)
+			if err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			key := h.buf
+			// Find the first bucket with the upper bound that is not less than
+			// the value.
+			bucketIdx := sort.Search(len(h.encodedBounds), func(j int) bool {
+				return bytes.Compare(h.encodedBounds[j], key) >= 0
+			})
+			if bucketIdx < len(h.buckets) {
+				b := &h.buckets[bucketIdx]
+				if bytes.Equal(h.encodedBounds[bucketIdx], key) {
+					b.numEq++
+				} else {
+					b.numRange++
+					h.insertIntoSketch(b, key)
+				}
+				continue
+			}
+			// The value is greater than the last upper bound.
+			if h.maxVec == nil {
+				h.maxVec = h.allocator.NewMemColumn(h.typ, 1 /* capacity */)
+			}
+			switch cmp := bytes.Compare(key, h.maxKey); {
+			case h.maxBucket.numEq == 0 || cmp > 0:
+				if h.maxBucket.numEq > 0 {
+					// The previous maximum is now in the range of the bucket.
+					h.maxBucket.numRange += h.maxBucket.numEq
+					h.insertIntoSketch(&h.maxBucket, h.maxKey)
+				}
+				h.maxBucket.numEq = 1
+				h.allocator.AdjustMemoryUsage(int64(len(key) - len(h.maxKey)))
+				h.maxKey = append(h.maxKey[:0], key...)
+				h.allocator.PerformOperation([]coldata.Vec{h.maxVec}, func() {
+					h.maxVec.Copy(coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							Src:         vec,
+							SrcStartIdx: rowIdx,
+							SrcEndIdx:   rowIdx + 1,
+						},
+					})
+				})
+			case cmp == 0:
+				h.maxBucket.numEq++
+			default:
+				h.maxBucket.numRange++
+				h.insertIntoSketch(&h.maxBucket, key)
+			}
+		}
+	}
+}
+
+// estimateDistinct returns the estimate of the number of distinct values in
+// the range of the bucket.
+func (b *histogramBucket) estimateDistinct() float64 {
+	if b.sketch == nil {
+		return 0
+	}
+	return float64(b.sketch.Estimate())
+}
+
+func (h *histogramOp) emit() coldata.Batch {
+	// The histogram consists of the NULL row, the buckets, and, possibly, the
+	// bucket with the maximum value.
+	numRows := 1 + len(h.buckets)
+	if h.maxBucket.numEq > 0 {
+		numRows++
+	}
+	toEmit := numRows - h.emitted
+	if toEmit == 0 {
+		// We're done.
+		return coldata.ZeroBatch
+	}
+	if toEmit > coldata.BatchSize() {
+		toEmit = coldata.BatchSize()
+	}
+	// The size of the output is determined by the number of buckets, so we
+	// don't enforce any footprint-based limit.
+	const maxBatchMemSize = math.MaxInt64
+	typs := HistogramOutputTypes(h.typ)
+	h.output, _ = h.allocator.ResetMaybeReallocate(typs, h.output, toEmit, maxBatchMemSize)
+	boundVec := h.output.ColVec(0)
+	numEqCol := h.output.ColVec(1).Int64()
+	numRangeCol := h.output.ColVec(2).Int64()
+	distinctRangeCol := h.output.ColVec(3).Float64()
+	converter := colconv.GetDatumToPhysicalFn(h.typ)
+	h.allocator.PerformOperation(h.output.ColVecs(), func() {
+		for outputIdx := 0; outputIdx < toEmit; outputIdx++ {
+			rowIdx := h.emitted + outputIdx
+			var b *histogramBucket
+			switch bucketIdx := rowIdx - 1; {
+			case rowIdx == 0:
+				boundVec.Nulls().SetNull(outputIdx)
+				numEqCol[outputIdx] = h.nullCount
+				numRangeCol[outputIdx] = 0
+				distinctRangeCol[outputIdx] = 0
+				continue
+			case bucketIdx < len(h.buckets):
+				b = &h.buckets[bucketIdx]
+				coldata.SetValueAt(boundVec, converter(h.upperBounds[bucketIdx]), outputIdx)
+			default:
+				b = &h.maxBucket
+				boundVec.Copy(coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         h.maxVec,
+						DestIdx:     outputIdx,
+						SrcStartIdx: 0,
+						SrcEndIdx:   1,
+					},
+				})
+			}
+			numEqCol[outputIdx] = b.numEq
+			numRangeCol[outputIdx] = b.numRange
+			distinctRangeCol[outputIdx] = b.estimateDistinct()
+		}
+		h.output.SetLength(toEmit)
+	})
+	h.emitted += toEmit
+	return h.output
+}
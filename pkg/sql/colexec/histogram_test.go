@@ -0,0 +1,208 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/stretchr/testify/require"
+)
+
+// makeHistogramInput returns the shuffled tuples in which each of the values
+// in [0, numValues) occurs numRepeats times, followed by numNulls NULLs.
+func makeHistogramInput(numValues, numRepeats, numNulls int) colexectestutils.Tuples {
+	var tuples colexectestutils.Tuples
+	for i := 0; i < numValues*numRepeats; i++ {
+		tuples = append(tuples, colexectestutils.Tuple{i % numValues})
+	}
+	for i := 0; i < numNulls; i++ {
+		tuples = append(tuples, colexectestutils.Tuple{nil})
+	}
+	rng := rand.New(rand.NewSource(0))
+	rng.Shuffle(len(tuples), func(i, j int) {
+		tuples[i], tuples[j] = tuples[j], tuples[i]
+	})
+	return tuples
+}
+
+func TestHistogram(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Each of the values in [0, 100) occurs 10 times, and the upper bounds are
+	// 9, 19, ..., 89, so every bucket has 10 values equal to the upper bound
+	// and 90 values (9 distinct ones) in its range. The values greater than
+	// 89 form the last bucket with 99 as its upper bound.
+	var bounds tree.Datums
+	expected := colexectestutils.Tuples{{nil, 50, 0, 0.0}}
+	for i := 9; i < 100; i += 10 {
+		if i < 90 {
+			bounds = append(bounds, tree.NewDInt(tree.DInt(i)))
+		}
+		expected = append(expected, colexectestutils.Tuple{i, 10, 90, 9.0})
+	}
+
+	testCases := []struct {
+		desc     string
+		typ      *types.T
+		bounds   tree.Datums
+		input    colexectestutils.Tuples
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc:     "uniform",
+			typ:      types.Int,
+			bounds:   bounds,
+			input:    makeHistogramInput(100 /* numValues */, 10 /* numRepeats */, 50 /* numNulls */),
+			expected: expected,
+		},
+		{
+			desc:   "strings",
+			typ:    types.String,
+			bounds: tree.Datums{tree.NewDString("b"), tree.NewDString("d")},
+			input: colexectestutils.Tuples{
+				{"d"}, {"a"}, {"b"}, {"e"}, {nil}, {"b"}, {"c"}, {"d"}, {"ee"}, {"ee"}, {"dd"},
+			},
+			expected: colexectestutils.Tuples{
+				{nil, 1, 0, 0.0},
+				{"b", 2, 1, 1.0},
+				{"d", 2, 1, 1.0},
+				{"ee", 2, 2, 2.0},
+			},
+		},
+		{
+			desc:   "no values greater than the last bound",
+			typ:    types.Int,
+			bounds: tree.Datums{tree.NewDInt(1), tree.NewDInt(5)},
+			input:  colexectestutils.Tuples{{1}, {3}, {5}, {4}, {4}},
+			expected: colexectestutils.Tuples{
+				{nil, 0, 0, 0.0},
+				{1, 1, 0, 0.0},
+				{5, 1, 3, 2.0},
+			},
+		},
+		{
+			desc:   "empty input",
+			typ:    types.Int,
+			bounds: tree.Datums{tree.NewDInt(1)},
+			input:  colexectestutils.Tuples{},
+			expected: colexectestutils.Tuples{
+				{nil, 0, 0, 0.0},
+				{1, 0, 0, 0.0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(context.Background(), "%s", tc.desc)
+		colexectestutils.RunTestsWithoutAllNullsInjection(
+			t, testAllocator, []colexectestutils.Tuples{tc.input}, [][]*types.T{{tc.typ}}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return NewHistogramOp(testAllocator, input[0], tc.typ, 0 /* colIdx */, tc.bounds)
+			},
+		)
+	}
+}
+
+func TestHistogramInvalidBounds(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, tc := range []struct {
+		typ         *types.T
+		bounds      tree.Datums
+		expectedErr string
+	}{
+		{
+			typ:         types.Jsonb,
+			expectedErr: "histograms are not supported on type JSONB",
+		},
+		{
+			typ:         types.Int,
+			bounds:      tree.Datums{tree.NewDInt(1), tree.DNull},
+			expectedErr: "histogram upper bound cannot be NULL",
+		},
+		{
+			typ:         types.Int,
+			bounds:      tree.Datums{tree.NewDString("a")},
+			expectedErr: "histogram upper bound 'a' is not of type INT8",
+		},
+		{
+			typ:         types.Int,
+			bounds:      tree.Datums{tree.NewDInt(2), tree.NewDInt(2)},
+			expectedErr: "histogram upper bounds are not strictly increasing",
+		},
+	} {
+		_, err := NewHistogramOp(testAllocator, colexecop.NewRepeatableBatchSource(
+			testAllocator, testAllocator.NewMemBatchWithMaxCapacity([]*types.T{tc.typ}), []*types.T{tc.typ},
+		), tc.typ, 0 /* colIdx */, tc.bounds)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.expectedErr)
+	}
+}
+
+func TestHistogramMemoryAccounting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	typs := []*types.T{types.Int}
+	// Only the first numNonEmpty buckets have values in their ranges, so only
+	// their sketches must be created.
+	const numBuckets, numNonEmpty = 100, 10
+	bounds := make(tree.Datums, numBuckets)
+	for i := range bounds {
+		bounds[i] = tree.NewDInt(tree.DInt(2 * i))
+	}
+	tuples := makeHistogramInput(2*numNonEmpty /* numValues */, 3 /* numRepeats */, 0 /* numNulls */)
+	for _, limited := range []bool{false, true} {
+		memMon := mon.NewMonitor("histogram", mon.MemoryResource, nil, nil, 0, math.MaxInt64, st)
+		if limited {
+			memMon.Start(ctx, nil, mon.MakeStandaloneBudget(histogramSketchSize))
+		} else {
+			memMon.Start(ctx, nil, mon.MakeStandaloneBudget(math.MaxInt64))
+		}
+		acc := memMon.MakeBoundAccount()
+		allocator := colmem.NewAllocator(ctx, &acc, testColumnFactory)
+		input := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), tuples, typs)
+		op, err := NewHistogramOp(allocator, input, types.Int, 0 /* colIdx */, bounds)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() {
+			for b := op.Next(); b.Length() > 0; b = op.Next() {
+			}
+		})
+		if limited {
+			require.Error(t, err, "expected memory error")
+		} else {
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, acc.Used(), numNonEmpty*histogramSketchSize)
+			require.Less(t, acc.Used(), numBuckets*histogramSketchSize)
+		}
+		acc.Close(ctx)
+		memMon.Stop(ctx)
+	}
+}
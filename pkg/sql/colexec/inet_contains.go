@@ -0,0 +1,217 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ipaddr"
+	"github.com/cockroachdb/errors"
+)
+
+// inetContainsBase evaluates the INet containment operator (one of <<, >> and
+// &&) on the INet column at position leftIdx and either the INet column at
+// position rightIdx or the constant INet constRight.
+type inetContainsBase struct {
+	op      tree.Operator
+	leftIdx int
+	// rightIdx is only used if constRight is nil.
+	rightIdx   int
+	constRight *ipaddr.IPAddr
+}
+
+func makeINetContainsBase(
+	op tree.Operator, leftIdx, rightIdx int, constRight tree.Datum,
+) (inetContainsBase, error) {
+	if op != tree.LShift && op != tree.RShift && op != tree.Overlaps {
+		return inetContainsBase{}, errors.AssertionFailedf("unexpected INet containment operator %s", op)
+	}
+	b := inetContainsBase{
+		op:       op,
+		leftIdx:  leftIdx,
+		rightIdx: rightIdx,
+	}
+	if constRight != nil {
+		d, ok := constRight.(*tree.DIPAddr)
+		if !ok {
+			return inetContainsBase{}, errors.Errorf("unsupported INet containment argument %s", constRight)
+		}
+		b.constRight = &d.IPAddr
+	}
+	return b, nil
+}
+
+// eval returns the result of the INet containment on the row at position
+// rowIdx. The result is NULL if either of the arguments is NULL.
+func (b *inetContainsBase) eval(leftVec, rightVec coldata.Vec, rowIdx int) (res bool, isNull bool) {
+	if leftVec.Nulls().NullAt(rowIdx) {
+		return false, true
+	}
+	left := &leftVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DIPAddr).IPAddr
+	right := b.constRight
+	if right == nil {
+		if rightVec.Nulls().NullAt(rowIdx) {
+			return false, true
+		}
+		right = &rightVec.Datum().Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DIPAddr).IPAddr
+	}
+	// Same as in the row engine, << and >> test for the strict containment,
+	// so a network neither contains nor is contained by itself, whereas &&
+	// is true if either of the networks contains the other one.
+	switch b.op {
+	case tree.LShift:
+		return left.ContainedBy(right), false
+	case tree.RShift:
+		return left.Contains(right), false
+	default:
+		return left.ContainsOrContainedBy(right), false
+	}
+}
+
+// vecs returns the vectors of the arguments. The right vector is nil if the
+// right argument is constant.
+func (b *inetContainsBase) vecs(batch coldata.Batch) (leftVec, rightVec coldata.Vec) {
+	leftVec = batch.ColVec(b.leftIdx)
+	if b.constRight == nil {
+		rightVec = batch.ColVec(b.rightIdx)
+	}
+	return leftVec, rightVec
+}
+
+// GetINetContainsProjectionOperator returns an operator that projects the
+// result of the INet containment operator op (one of <<, >> and &&) into the
+// Bool column at position resultIdx. The left argument is the INet column at
+// position leftIdx, and the right argument is either the constant constRight
+// if it is non-nil or the INet column at position rightIdx.
+func GetINetContainsProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	op tree.Operator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeINetContainsBase(op, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &inetContainsProjOp{
+		OneInputHelper:   colexecop.MakeOneInputHelper(input),
+		inetContainsBase: base,
+		allocator:        allocator,
+		outputIdx:        resultIdx,
+	}, nil
+}
+
+// GetINetContainsOperator returns an operator that selects the tuples for
+// which the INet containment operator op (one of <<, >> and &&) evaluates to
+// true. The arguments are the same as in GetINetContainsProjectionOperator.
+func GetINetContainsOperator(
+	input colexecop.Operator, op tree.Operator, leftIdx, rightIdx int, constRight tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeINetContainsBase(op, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	return &inetContainsSelOp{
+		OneInputHelper:   colexecop.MakeOneInputHelper(input),
+		inetContainsBase: base,
+	}, nil
+}
+
+type inetContainsProjOp struct {
+	colexecop.OneInputHelper
+	inetContainsBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &inetContainsProjOp{}
+
+func (o *inetContainsProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	leftVec, rightVec := o.vecs(batch)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(leftVec, rightVec, rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type inetContainsSelOp struct {
+	colexecop.OneInputHelper
+	inetContainsBase
+}
+
+var _ colexecop.Operator = &inetContainsSelOp{}
+
+func (o *inetContainsSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		leftVec, rightVec := o.vecs(batch)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestINetContains(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	typs := []*types.T{types.INet, types.INet}
+	inputTuples := colexectestutils.Tuples{
+		{"'192.168.1.5'", "'192.168.1.0/24'"},
+		{"'10.1.0.0/16'", "'10.0.0.0/8'"},
+		{"'11.0.0.1'", "'10.0.0.0/8'"},
+		{"'10.0.0.0/8'", "'10.0.0.0/8'"},
+		{"'10.0.0.1'", "'10.0.0.1'"},
+		{"'2001:db8::1'", "'2001:db8::/32'"},
+		{"'2001:db8::/32'", "'2001:db8::/32'"},
+		{"'2001:db9::/32'", "'2001:db8::/31'"},
+		{"'2001:db8::1'", "'0.0.0.0/0'"},
+		{"'10.0.0.1'", "'::/0'"},
+		{nil, "'10.0.0.0/8'"},
+		{"'10.0.0.1'", nil},
+	}
+	withResults := func(results ...interface{}) colexectestutils.Tuples {
+		var tuples colexectestutils.Tuples
+		for i, tuple := range inputTuples {
+			tuples = append(tuples, colexectestutils.Tuple{tuple[0], tuple[1], results[i]})
+		}
+		return tuples
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			// Note that the containment is strict, so a network is not
+			// contained by itself, and that the networks of different
+			// families never contain one another.
+			desc:        "contained by",
+			expr:        "@1 << @2",
+			inputTuples: inputTuples,
+			inputTypes:  typs,
+			outputTuples: withResults(
+				true, true, false, false, false, true, false, true, false, false, nil, nil,
+			),
+		},
+		{
+			desc:        "contains",
+			expr:        "@2 >> @1",
+			inputTuples: inputTuples,
+			inputTypes:  typs,
+			outputTuples: withResults(
+				true, true, false, false, false, true, false, true, false, false, nil, nil,
+			),
+		},
+		{
+			desc:        "reversed contains",
+			expr:        "@1 >> @2",
+			inputTuples: inputTuples,
+			inputTypes:  typs,
+			outputTuples: withResults(
+				false, false, false, false, false, false, false, false, false, false, nil, nil,
+			),
+		},
+		{
+			// Unlike << and >>, && is true for the equal networks.
+			desc:        "overlaps",
+			expr:        "@1 && @2",
+			inputTuples: inputTuples,
+			inputTypes:  typs,
+			outputTuples: withResults(
+				true, true, false, true, true, true, true, true, false, false, nil, nil,
+			),
+		},
+		{
+			desc: "contained by constant",
+			expr: "@1 << '10.0.0.0/8'",
+			inputTuples: colexectestutils.Tuples{
+				{"'10.255.255.255'"}, {"'10.0.0.0/8'"}, {"'10.0.0.0/7'"}, {"'::a00:1'"}, {nil},
+			},
+			inputTypes: []*types.T{types.INet},
+			outputTuples: colexectestutils.Tuples{
+				{"'10.255.255.255'", true}, {"'10.0.0.0/8'", false}, {"'10.0.0.0/7'", false},
+				{"'::a00:1'", false}, {nil, nil},
+			},
+		},
+		{
+			// The constant on the left is supported by swapping the
+			// arguments.
+			desc: "constant contains",
+			expr: "'10.0.0.0/8' >> @1",
+			inputTuples: colexectestutils.Tuples{
+				{"'10.255.255.255'"}, {"'10.0.0.0/8'"}, {"'11.0.0.1'"}, {nil},
+			},
+			inputTypes: []*types.T{types.INet},
+			outputTuples: colexectestutils.Tuples{
+				{"'10.255.255.255'", true}, {"'10.0.0.0/8'", false}, {"'11.0.0.1'", false}, {nil, nil},
+			},
+		},
+		{
+			desc: "overlaps constant",
+			expr: "@1 && '2001:db8::/32'",
+			inputTuples: colexectestutils.Tuples{
+				{"'2001:db8:1::/48'"}, {"'2001::/16'"}, {"'2001:db8::/32'"}, {"'2002::1'"}, {"'32.1.13.184'"}, {nil},
+			},
+			inputTypes: []*types.T{types.INet},
+			outputTuples: colexectestutils.Tuples{
+				{"'2001:db8:1::/48'", true}, {"'2001::/16'", true}, {"'2001:db8::/32'", true},
+				{"'2002::1'", false}, {"'32.1.13.184'", false}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+func TestINetContainsSel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	typs := []*types.T{types.INet, types.INet}
+	inputTuples := colexectestutils.Tuples{
+		{"'10.1.2.3'", "'10.0.0.0/8'"},
+		{"'10.0.0.0/8'", "'10.1.2.3'"},
+		{"'10.0.0.0/8'", "'10.0.0.0/8'"},
+		{"'2001:db8::/32'", "'2001:db8::1'"},
+		{"'10.0.0.0/8'", "'11.0.0.0/8'"},
+		{nil, "'10.0.0.0/8'"},
+		{"'10.0.0.1'", nil},
+	}
+	constRight, err := tree.ParseDIPAddrFromINetString("10.0.0.0/8")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		op           tree.Operator
+		constRight   tree.Datum
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			op: tree.Overlaps,
+			outputTuples: colexectestutils.Tuples{
+				{"'10.1.2.3'", "'10.0.0.0/8'"},
+				{"'10.0.0.0/8'", "'10.1.2.3'"},
+				{"'10.0.0.0/8'", "'10.0.0.0/8'"},
+				{"'2001:db8::/32'", "'2001:db8::1'"},
+			},
+		},
+		{
+			op: tree.RShift,
+			outputTuples: colexectestutils.Tuples{
+				{"'10.0.0.0/8'", "'10.1.2.3'"},
+				{"'2001:db8::/32'", "'2001:db8::1'"},
+			},
+		},
+		{
+			op:         tree.LShift,
+			constRight: constRight,
+			outputTuples: colexectestutils.Tuples{
+				{"'10.1.2.3'", "'10.0.0.0/8'"},
+				{"'10.0.0.1'", nil},
+			},
+		},
+	} {
+		log.Infof(ctx, "%s/const=%t", tc.op, tc.constRight != nil)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetINetContainsOperator(
+					input[0], tc.op, 0 /* leftIdx */, 1 /* rightIdx */, tc.constRight,
+				)
+			})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// newINetFuncOperator returns an operator that evaluates either host() (if
+// isHost is true) or masklen() builtin on the INet column at position
+// inputIdx.
+func newINetFuncOperator(
+	allocator *colmem.Allocator, isHost bool, inputIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &inetFuncOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		isHost:         isHost,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// inetFuncOp is an operator that extracts either the address part as text or
+// the prefix length of the INet values. Same as in the row engine, the address
+// part is formatted without the prefix length for both IPv4 and IPv6 values.
+type inetFuncOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	isHost    bool
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &inetFuncOp{}
+
+func (o *inetFuncOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.inputIdx)
+	nulls, col := vec.Nulls(), vec.Datum()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if o.isHost {
+				outputCol := outputVec.Bytes()
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					if nulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					s := col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DIPAddr).IPAddr.String()
+					if slashIdx := strings.IndexByte(s, '/'); slashIdx != -1 {
+						s = s[:slashIdx]
+					}
+					outputCol.Set(rowIdx, []byte(s))
+				}
+				return
+			}
+			outputCol := outputVec.Int64()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol[rowIdx] = int64(col.Get(rowIdx).(*coldataext.Datum).Datum.(*tree.DIPAddr).Mask)
+			}
+		},
+	)
+	return batch
+}
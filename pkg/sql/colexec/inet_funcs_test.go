@@ -0,0 +1,78 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestINetFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTuples := colexectestutils.Tuples{
+		{"'192.168.1.2/16'"},
+		{"'10.0.0.1'"},
+		{"'0.0.0.0/0'"},
+		{"'2001:db8::1/64'"},
+		{"'::1'"},
+		{"'::ffff:1.2.3.4/120'"},
+		{nil},
+	}
+	for _, tc := range []struct {
+		expr    string
+		results []interface{}
+	}{
+		{
+			// The address part is formatted without the prefix length even if
+			// it isn't the maximum one.
+			expr:    "host(@1)",
+			results: []interface{}{"192.168.1.2", "10.0.0.1", "0.0.0.0", "2001:db8::1", "::1", "::ffff:1.2.3.4", nil},
+		},
+		{
+			expr:    "masklen(@1)",
+			results: []interface{}{16, 32, 0, 64, 128, 120, nil},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		var outputTuples colexectestutils.Tuples
+		for i, tuple := range inputTuples {
+			outputTuples = append(outputTuples, colexectestutils.Tuple{tuple[0], tc.results[i]})
+		}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{{types.INet}}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], []*types.T{types.INet},
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/arith"
+)
+
+// newIntGCDOperator returns an operator that evaluates gcd() or lcm() builtin
+// on the Int64 columns at positions argumentCols.
+func newIntGCDOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	lcm bool,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &intGCDOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		lcm:            lcm,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+}
+
+// intGCDOp is an operator that computes the greatest common divisor or the
+// least common multiple of two integers. The results are computed by the same
+// functions as in the row engine, so they are never negative, and the results
+// that don't fit into int64 (e.g. the GCD of math.MinInt64 and zero) result in
+// an error.
+type intGCDOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	funcExpr     *tree.FuncExpr
+	lcm          bool
+	argumentCols []int
+	outputIdx    int
+}
+
+var _ colexecop.Operator = &intGCDOp{}
+
+func (d *intGCDOp) Next() coldata.Batch {
+	batch := d.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	xVec, yVec := batch.ColVec(d.argumentCols[0]), batch.ColVec(d.argumentCols[1])
+	xNulls, xCol := xVec.Nulls(), xVec.Int64()
+	yNulls, yCol := yVec.Nulls(), yVec.Int64()
+	outputVec := batch.ColVec(d.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Int64()
+	d.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if xNulls.NullAt(rowIdx) || yNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				var res int64
+				var ok bool
+				if d.lcm {
+					res, ok = arith.LCMWithOverflow(xCol[rowIdx], yCol[rowIdx])
+				} else {
+					res, ok = arith.GCDWithOverflow(xCol[rowIdx], yCol[rowIdx])
+				}
+				if !ok {
+					colexecerror.ExpectedError(d.funcExpr.MaybeWrapError(tree.ErrIntOutOfRange))
+				}
+				outputCol[rowIdx] = res
+			}
+		},
+	)
+	return batch
+}
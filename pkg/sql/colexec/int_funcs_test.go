@@ -0,0 +1,130 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntFuncs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			// The result is never negative, and the GCD with zero is the
+			// absolute value of the other argument.
+			desc: "gcd",
+			expr: "gcd(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{12, 18}, {-12, 18}, {12, -18}, {-12, -18}, {0, 5}, {-5, 0}, {0, 0},
+				{7, 13}, {math.MinInt64, 2}, {math.MaxInt64, 1}, {nil, 1}, {1, nil},
+			},
+			inputTypes: []*types.T{types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{12, 18, 6}, {-12, 18, 6}, {12, -18, 6}, {-12, -18, 6}, {0, 5, 5}, {-5, 0, 5}, {0, 0, 0},
+				{7, 13, 1}, {math.MinInt64, 2, 2}, {math.MaxInt64, 1, 1}, {nil, 1, nil}, {1, nil, nil},
+			},
+		},
+		{
+			// The result is never negative, and the LCM with zero is zero.
+			desc: "lcm",
+			expr: "lcm(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{12, 18}, {-12, 18}, {12, -18}, {-12, -18}, {0, 5}, {-5, 0}, {0, 0},
+				{7, 13}, {math.MinInt64, 0}, {math.MaxInt64, -1}, {nil, 1}, {1, nil},
+			},
+			inputTypes: []*types.T{types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{12, 18, 36}, {-12, 18, 36}, {12, -18, 36}, {-12, -18, 36}, {0, 5, 0}, {-5, 0, 0}, {0, 0, 0},
+				{7, 13, 91}, {math.MinInt64, 0, 0}, {math.MaxInt64, -1, math.MaxInt64}, {nil, 1, nil}, {1, nil, nil},
+			},
+		},
+		{
+			// INT4 is handled by the default builtin operator, but the results
+			// must be the same.
+			desc: "gcd INT4",
+			expr: "gcd(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{int32(-12), int32(18)}, {int32(0), int32(0)}, {int32(math.MinInt32), int32(0)}, {nil, int32(1)},
+			},
+			inputTypes: []*types.T{types.Int4, types.Int4},
+			outputTuples: colexectestutils.Tuples{
+				{int32(-12), int32(18), 6}, {int32(0), int32(0), 0}, {int32(math.MinInt32), int32(0), -math.MinInt32}, {nil, int32(1), nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The results that don't fit into INT8 result in an error.
+	for _, tc := range []struct {
+		expr  string
+		input colexectestutils.Tuple
+	}{
+		{expr: "gcd(@1, @2)", input: colexectestutils.Tuple{math.MinInt64, 0}},
+		{expr: "gcd(@1, @2)", input: colexectestutils.Tuple{math.MinInt64, math.MinInt64}},
+		{expr: "lcm(@1, @2)", input: colexectestutils.Tuple{math.MinInt64, 1}},
+		{expr: "lcm(@1, @2)", input: colexectestutils.Tuple{math.MaxInt64, 2}},
+		{expr: "lcm(@1, @2)", input: colexectestutils.Tuple{4294967296, 4294967297}},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			typs := []*types.T{types.Int, types.Int}
+			op, err := colexectestutils.CreateTestProjectingOperator(
+				ctx, flowCtx, colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, colexectestutils.Tuples{tc.input}, typs),
+				typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+			)
+			require.NoError(t, err)
+			op.Init(ctx)
+			err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "integer out of range")
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
@@ -25,6 +26,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsNullProjOp(t *testing.T) {
@@ -254,3 +257,61 @@ func TestIsNullSelOp(t *testing.T) {
 		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
 	}
 }
+
+// TestIsNullSelOpNullBitmap verifies that the IS [NOT] NULL selection operator
+// correctly reads the null bitmap of the batches of different lengths, both
+// with and without the nulls flag set.
+func TestIsNullSelOpNullBitmap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int}
+	for _, negate := range []bool{false, true} {
+		for _, nullProbability := range []float64{0, 0.1, 0.5, 0.9, 1} {
+			for _, hasNullsFlag := range []bool{false, true} {
+				if nullProbability > 0 && !hasNullsFlag {
+					// The nulls flag is always set when there are NULLs.
+					continue
+				}
+				for n := 1; n <= 3*8+5; n++ {
+					batch := testAllocator.NewMemBatchWithFixedCapacity(typs, n)
+					nulls := batch.ColVec(0).Nulls()
+					if hasNullsFlag {
+						// Set the flag, possibly without having any NULLs.
+						nulls.SetNull(0)
+						nulls.UnsetNull(0)
+					}
+					var expected []int
+					for i := 0; i < n; i++ {
+						isNull := rng.Float64() < nullProbability
+						if isNull {
+							nulls.SetNull(i)
+						}
+						if isNull != negate {
+							expected = append(expected, i)
+						}
+					}
+					batch.SetLength(n)
+					input := colexecop.NewBatchBuffer()
+					input.Add(batch, typs)
+					input.Add(coldata.ZeroBatch, typs)
+					op := NewIsNullSelOp(input, 0 /* colIdx */, negate, false /* isTupleNull */)
+					op.Init(context.Background())
+					out := op.Next()
+					var actual []int
+					if out.Length() > 0 {
+						if sel := out.Selection(); sel != nil {
+							actual = append(actual, sel[:out.Length()]...)
+						} else {
+							for i := 0; i < out.Length(); i++ {
+								actual = append(actual, i)
+							}
+						}
+					}
+					require.Equal(t, expected, actual, "negate=%t n=%d", negate, n)
+				}
+			}
+		}
+	}
+}
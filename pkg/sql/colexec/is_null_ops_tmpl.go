@@ -213,9 +213,32 @@ func (o *is_KINDNullSelOp) Next() coldata.Batch {
 			} else {
 				batch.SetSelection(true)
 				sel := batch.Selection()[:n]
+				// {{if .IsTuple}}
 				for i := range sel {
 					_MAYBE_SELECT(o, nulls, i, idx, sel, _IS_TUPLE)
 				}
+				// {{else}}
+				// We read the null bitmap directly one byte at a time, which
+				// allows us to skip eight tuples at once when none of them can
+				// be selected.
+				skipByte := byte(0xFF)
+				if o.negate {
+					skipByte = 0
+				}
+				bitmap := nulls.NullBitmap()
+				for start := 0; start < n; start += 8 {
+					if bitmap[start>>3] == skipByte {
+						continue
+					}
+					end := start + 8
+					if end > n {
+						end = n
+					}
+					for i := start; i < end; i++ {
+						_MAYBE_SELECT(o, nulls, i, idx, sel, _IS_TUPLE)
+					}
+				}
+				// {{end}}
 			}
 			if idx > 0 {
 				batch.SetLength(idx)
@@ -0,0 +1,260 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+)
+
+// jsonBuildObjectSupported returns whether json_build_object() builtin with
+// the given arguments can be evaluated by the vectorized operator. Only the
+// keys that are either constant non-NULL strings or String columns are
+// supported, and the number of arguments must be even (in the other cases the
+// row engine either formats the keys in a special way or returns an error).
+func jsonBuildObjectSupported(funcExpr *tree.FuncExpr, columnTypes []*types.T, argumentCols []int) bool {
+	if len(argumentCols)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(argumentCols); i += 2 {
+		if s, ok := constStringOrNull(funcExpr.Exprs[i]); ok {
+			if s == nil {
+				return false
+			}
+		} else if columnTypes[argumentCols[i]].Family() != types.StringFamily {
+			return false
+		}
+	}
+	return true
+}
+
+// newJSONBuildOperator returns an operator that evaluates either
+// json_build_object() (if isObject is true) or json_build_array() builtin
+// (and their JSONB counterparts). For the former, argumentCols contain the
+// alternating keys and values (see jsonBuildObjectSupported for the
+// requirements on the keys), for the latter all argumentCols are values.
+func newJSONBuildOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	funcExpr *tree.FuncExpr,
+	columnTypes []*types.T,
+	argumentCols []int,
+	isObject bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &jsonBuildOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		evalCtx:        evalCtx,
+		funcExpr:       funcExpr,
+		isObject:       isObject,
+		outputIdx:      outputIdx,
+	}
+	valueStart, valueStep := 0, 1
+	if isObject {
+		valueStart, valueStep = 1, 2
+		op.keys = make([]jsonBuildKey, len(argumentCols)/2)
+		for i := range op.keys {
+			op.keys[i].colIdx = argumentCols[2*i]
+			if s, ok := constStringOrNull(funcExpr.Exprs[2*i]); ok {
+				op.keys[i].constKey = s
+			}
+		}
+	}
+	op.values = make([]jsonBuildValue, 0, len(argumentCols)/valueStep)
+	for i := valueStart; i < len(argumentCols); i += valueStep {
+		v := jsonBuildValue{typ: columnTypes[argumentCols[i]]}
+		v.datums.colIdx = argumentCols[i]
+		if d, ok := funcExpr.Exprs[i].(tree.Datum); ok {
+			// The constant values are converted to JSON only once. If the
+			// conversion fails, the value is read from the column so that the
+			// error is returned only if there are any rows, same as in the row
+			// engine.
+			if j, err := tree.AsJSON(d, evalCtx.GetLocation()); err == nil {
+				v.constJSON = j
+			}
+		}
+		op.values = append(op.values, v)
+	}
+	return op
+}
+
+// jsonBuildKey is a key of the object built by json_build_object().
+type jsonBuildKey struct {
+	// constKey is the constant key. If it is nil, the key is read from the
+	// String column at position colIdx.
+	constKey *string
+	colIdx   int
+}
+
+// jsonBuildValue is a value of the object or an element of the array built by
+// json_build_object() or json_build_array(), respectively.
+type jsonBuildValue struct {
+	// constJSON is the JSON representation of the constant value. If it is
+	// nil, the value is read from the column of type typ.
+	constJSON json.JSON
+	typ       *types.T
+	// datums is used to read the values of the types that are not converted
+	// to JSON natively. Its colIdx is the index of the column with the value.
+	datums arrayConcatArg
+}
+
+// nativelyConverted returns whether the values of the column are converted to
+// JSON without the datum representation.
+func (v *jsonBuildValue) nativelyConverted() bool {
+	switch v.typ.Family() {
+	case types.BoolFamily, types.IntFamily, types.FloatFamily, types.DecimalFamily,
+		types.StringFamily, types.JsonFamily:
+		return true
+	}
+	return false
+}
+
+// get returns the JSON representation of the value at position rowIdx the
+// same way as the row engine does.
+func (v *jsonBuildValue) get(batch coldata.Batch, rowIdx int, evalCtx *tree.EvalContext) (json.JSON, error) {
+	if v.constJSON != nil {
+		return v.constJSON, nil
+	}
+	vec := batch.ColVec(v.datums.colIdx)
+	if vec.Nulls().NullAt(rowIdx) {
+		return json.NullJSONValue, nil
+	}
+	switch v.typ.Family() {
+	case types.BoolFamily:
+		return json.FromBool(vec.Bool()[rowIdx]), nil
+	case types.IntFamily:
+		switch v.typ.Width() {
+		case 16:
+			return json.FromInt64(int64(vec.Int16()[rowIdx])), nil
+		case 32:
+			return json.FromInt64(int64(vec.Int32()[rowIdx])), nil
+		default:
+			return json.FromInt64(vec.Int64()[rowIdx]), nil
+		}
+	case types.FloatFamily:
+		return json.FromFloat64(vec.Float64()[rowIdx])
+	case types.DecimalFamily:
+		return json.FromDecimal(vec.Decimal()[rowIdx]), nil
+	case types.StringFamily:
+		return json.FromString(string(vec.Bytes().Get(rowIdx))), nil
+	case types.JsonFamily:
+		return vec.JSON().Get(rowIdx), nil
+	}
+	return tree.AsJSON(v.datums.get(rowIdx), evalCtx.GetLocation())
+}
+
+// jsonBuildOp is an operator that builds a JSON object out of the key/value
+// pairs or a JSON array out of the elements for every row. The NULL values
+// are represented as JSON nulls, and a NULL key results in an error, same as
+// in the row engine, so the result is never NULL.
+type jsonBuildOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	evalCtx   *tree.EvalContext
+	funcExpr  *tree.FuncExpr
+	isObject  bool
+	// keys are the keys of the object, set only if isObject is true. There is
+	// a key for each of the values.
+	keys      []jsonBuildKey
+	values    []jsonBuildValue
+	outputIdx int
+	da        rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &jsonBuildOp{}
+
+func (o *jsonBuildOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	for i := range o.values {
+		if v := &o.values[i]; v.constJSON == nil && !v.nativelyConverted() {
+			v.datums.convert(batch, n, &o.da)
+		}
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.JSON()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			var j json.JSON
+			if o.isObject {
+				j = o.buildObject(batch, rowIdx)
+			} else {
+				j = o.buildArray(batch, rowIdx)
+			}
+			// Note that the result is encoded directly into the flat buffer of
+			// the output vector.
+			outputCol.Set(rowIdx, j)
+		}
+	})
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+func (o *jsonBuildOp) buildObject(batch coldata.Batch, rowIdx int) json.JSON {
+	builder := json.NewObjectBuilder(len(o.keys))
+	for i := range o.keys {
+		var key string
+		if k := &o.keys[i]; k.constKey != nil {
+			key = *k.constKey
+		} else {
+			keyVec := batch.ColVec(k.colIdx)
+			if keyVec.Nulls().NullAt(rowIdx) {
+				colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(pgerror.Newf(
+					pgcode.InvalidParameterValue, "argument %d cannot be null", 2*i+1,
+				)))
+			}
+			key = string(keyVec.Bytes().Get(rowIdx))
+		}
+		val, err := o.values[i].get(batch, rowIdx, o.evalCtx)
+		if err != nil {
+			colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+		}
+		builder.Add(key, val)
+	}
+	return builder.Build()
+}
+
+func (o *jsonBuildOp) buildArray(batch coldata.Batch, rowIdx int) json.JSON {
+	builder := json.NewArrayBuilder(len(o.values))
+	for i := range o.values {
+		val, err := o.values[i].get(batch, rowIdx, o.evalCtx)
+		if err != nil {
+			colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+		}
+		builder.Add(val)
+	}
+	return builder.Build()
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONBuild(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	ts := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "array of columns",
+			expr: "jsonb_build_array(@1, @2, @3, @4, @5)",
+			inputTuples: colexectestutils.Tuples{
+				{1, "a", true, 1.5, `{"b": [1, null]}`},
+				{nil, nil, nil, nil, nil},
+				{-2, `"q"`, false, 0.0, `null`},
+			},
+			inputTypes: []*types.T{types.Int, types.String, types.Bool, types.Float, types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{1, "a", true, 1.5, `{"b": [1, null]}`, mustParseJSON(`[1, "a", true, 1.5, {"b": [1, null]}]`)},
+				{nil, nil, nil, nil, nil, mustParseJSON(`[null, null, null, null, null]`)},
+				{-2, `"q"`, false, 0.0, `null`, mustParseJSON(`[-2, "\"q\"", false, 0, null]`)},
+			},
+		},
+		{
+			desc: "array of constants and other types",
+			expr: "json_build_array(@1, @2, 'c', NULL, 3.0)",
+			inputTuples: colexectestutils.Tuples{
+				{int16(7), ts}, {nil, nil},
+			},
+			inputTypes: []*types.T{types.Int2, types.Timestamp},
+			outputTuples: colexectestutils.Tuples{
+				{int16(7), ts, mustParseJSON(`[7, "2021-06-01T12:30:00", "c", null, 3.0]`)},
+				{nil, nil, mustParseJSON(`[null, null, "c", null, 3.0]`)},
+			},
+		},
+		{
+			desc:         "empty array",
+			expr:         "json_build_array()",
+			inputTuples:  colexectestutils.Tuples{{1}},
+			inputTypes:   []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{{1, mustParseJSON(`[]`)}},
+		},
+		{
+			// The keys are sorted, and the last value wins for the duplicate
+			// keys.
+			desc: "object with constant keys",
+			expr: "jsonb_build_object('k', @1, 'a', @2, 'k', @3)",
+			inputTuples: colexectestutils.Tuples{
+				{1, "x", 2}, {nil, nil, 3}, {4, "y", nil},
+			},
+			inputTypes: []*types.T{types.Int, types.String, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{1, "x", 2, mustParseJSON(`{"a": "x", "k": 2}`)},
+				{nil, nil, 3, mustParseJSON(`{"a": null, "k": 3}`)},
+				{4, "y", nil, mustParseJSON(`{"a": "y", "k": null}`)},
+			},
+		},
+		{
+			desc: "nested construction",
+			expr: "json_build_object('x', json_build_array(@2, json_build_object('v', @1)), 'd', @3::DECIMAL)",
+			inputTuples: colexectestutils.Tuples{
+				{"v", 1, 1.25}, {"", nil, nil},
+			},
+			inputTypes: []*types.T{types.String, types.Int, types.Float},
+			outputTuples: colexectestutils.Tuples{
+				{"v", 1, 1.25, mustParseJSON(`{"x": [1, {"v": "v"}], "d": 1.25}`)},
+				{"", nil, nil, mustParseJSON(`{"x": [null, {"v": ""}], "d": null}`)},
+			},
+		},
+		{
+			desc:         "empty object",
+			expr:         "jsonb_build_object()",
+			inputTuples:  colexectestutils.Tuples{{1}},
+			inputTypes:   []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{{1, mustParseJSON(`{}`)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The keys read from a column are tested separately since the NULL keys
+	// (that are injected by the test harness) result in an error.
+	typs := []*types.T{types.String, types.Int}
+	input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"a", 1}, {"k", 2}, {"", nil}}, typs)
+	op, err := colexectestutils.CreateTestProjectingOperator(
+		ctx, flowCtx, input, typs, "json_build_object('k', @2, @1, json_build_array(@1, @2))", false /* canFallbackToRowexec */, testMemAcc,
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	require.NoError(t, colexectestutils.NewOpTestOutput(op, colexectestutils.Tuples{
+		{"a", 1, mustParseJSON(`{"a": ["a", 1], "k": 1}`)},
+		{"k", 2, mustParseJSON(`{"k": ["k", 2]}`)},
+		{"", nil, mustParseJSON(`{"": ["", null], "k": null}`)},
+	}).Verify())
+
+	// A NULL key results in the same error as in the row engine.
+	input = colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"a", 1}, {nil, 2}}, typs)
+	op, err = colexectestutils.CreateTestProjectingOperator(
+		ctx, flowCtx, input, typs, "json_build_object('k', @2, @1, @2)", false /* canFallbackToRowexec */, testMemAcc,
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() {
+		for b := op.Next(); b.Length() > 0; b = op.Next() {
+		}
+	})
+	require.EqualError(t, err, "json_build_object(): argument 3 cannot be null")
+}
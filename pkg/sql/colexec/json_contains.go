@@ -0,0 +1,223 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// jsonContainsBase evaluates the JSON containment (either @> or <@) of the
+// JSON column at position leftIdx and either the JSON column at position
+// rightIdx or the constant JSON constRight.
+type jsonContainsBase struct {
+	leftIdx int
+	// rightIdx is only used if constRight is nil.
+	rightIdx   int
+	constRight json.JSON
+	// leftIsHaystack indicates whether the left argument is the document that
+	// must contain the right argument (i.e. @> is used).
+	leftIsHaystack bool
+}
+
+func makeJSONContainsBase(
+	cmpOp tree.ComparisonOperator, leftIdx, rightIdx int, constRight tree.Datum,
+) (jsonContainsBase, error) {
+	if cmpOp != tree.Contains && cmpOp != tree.ContainedBy {
+		return jsonContainsBase{}, errors.AssertionFailedf("unexpected JSON containment operator %s", cmpOp)
+	}
+	b := jsonContainsBase{
+		leftIdx:        leftIdx,
+		rightIdx:       rightIdx,
+		leftIsHaystack: cmpOp == tree.Contains,
+	}
+	if constRight != nil {
+		j, ok := constRight.(*tree.DJSON)
+		if !ok {
+			return jsonContainsBase{}, errors.Errorf("unsupported JSON containment argument %s", constRight)
+		}
+		// The constant is already decoded, so it is not decoded again for
+		// every row.
+		b.constRight = j.JSON
+	}
+	return b, nil
+}
+
+// eval returns the result of the JSON containment on the row at position
+// rowIdx. The result is NULL if either of the arguments is NULL (note that
+// the JSON null is a regular value).
+func (b *jsonContainsBase) eval(leftVec, rightVec coldata.Vec, rowIdx int) (res bool, isNull bool) {
+	if leftVec.Nulls().NullAt(rowIdx) {
+		return false, true
+	}
+	left := leftVec.JSON().Get(rowIdx)
+	right := b.constRight
+	if right == nil {
+		if rightVec.Nulls().NullAt(rowIdx) {
+			return false, true
+		}
+		right = rightVec.JSON().Get(rowIdx)
+	}
+	haystack, needle := left, right
+	if !b.leftIsHaystack {
+		haystack, needle = right, left
+	}
+	contains, err := json.Contains(haystack, needle)
+	if err != nil {
+		colexecerror.ExpectedError(err)
+	}
+	return contains, false
+}
+
+// vecs returns the vectors of the arguments. The right vector is nil if the
+// right argument is constant.
+func (b *jsonContainsBase) vecs(batch coldata.Batch) (leftVec, rightVec coldata.Vec) {
+	leftVec = batch.ColVec(b.leftIdx)
+	if b.constRight == nil {
+		rightVec = batch.ColVec(b.rightIdx)
+	}
+	return leftVec, rightVec
+}
+
+// GetJSONContainsProjectionOperator returns an operator that projects the
+// result of the JSON containment operator cmpOp (either @> or <@) into the
+// Bool column at position resultIdx. The left argument is the JSON column at
+// position leftIdx, and the right argument is either the constant constRight
+// if it is non-nil or the JSON column at position rightIdx.
+func GetJSONContainsProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	cmpOp tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeJSONContainsBase(cmpOp, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &jsonContainsProjOp{
+		OneInputHelper:   colexecop.MakeOneInputHelper(input),
+		jsonContainsBase: base,
+		allocator:        allocator,
+		outputIdx:        resultIdx,
+	}, nil
+}
+
+// GetJSONContainsOperator returns an operator that selects the tuples for
+// which the JSON containment operator cmpOp (either @> or <@) evaluates to
+// true. The arguments are the same as in GetJSONContainsProjectionOperator.
+func GetJSONContainsOperator(
+	input colexecop.Operator,
+	cmpOp tree.ComparisonOperator,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeJSONContainsBase(cmpOp, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonContainsSelOp{
+		OneInputHelper:   colexecop.MakeOneInputHelper(input),
+		jsonContainsBase: base,
+	}, nil
+}
+
+type jsonContainsProjOp struct {
+	colexecop.OneInputHelper
+	jsonContainsBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &jsonContainsProjOp{}
+
+func (o *jsonContainsProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	leftVec, rightVec := o.vecs(batch)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(leftVec, rightVec, rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type jsonContainsSelOp struct {
+	colexecop.OneInputHelper
+	jsonContainsBase
+}
+
+var _ colexecop.Operator = &jsonContainsSelOp{}
+
+func (o *jsonContainsSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		leftVec, rightVec := o.vecs(batch)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(leftVec, rightVec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
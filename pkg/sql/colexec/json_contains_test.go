@@ -0,0 +1,205 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONContains(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "contains column",
+			expr: "@1 @> @2",
+			inputTuples: colexectestutils.Tuples{
+				{`{"a": 1, "b": 2}`, `{"a": 1}`},
+				{`{"a": 1}`, `{"a": 1, "b": 2}`},
+				{`{"a": {"b": [1, 2], "c": 3}}`, `{"a": {"b": [2]}}`},
+				{`{"a": {"b": [1, 2]}}`, `{"a": [1]}`},
+				{`[1, [2, 3], {"a": 1}]`, `[[3], {}]`},
+				{`[1, 2]`, `[2, 2, 1]`},
+				{`[1, 2]`, `1`},
+				{`1`, `[1]`},
+				{`{"a": null}`, `{"a": null}`},
+				{`null`, `null`},
+				{nil, `{}`},
+				{`{}`, nil},
+			},
+			inputTypes: []*types.T{types.Jsonb, types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": 1, "b": 2}`, `{"a": 1}`, true},
+				{`{"a": 1}`, `{"a": 1, "b": 2}`, false},
+				{`{"a": {"b": [1, 2], "c": 3}}`, `{"a": {"b": [2]}}`, true},
+				{`{"a": {"b": [1, 2]}}`, `{"a": [1]}`, false},
+				{`[1, [2, 3], {"a": 1}]`, `[[3], {}]`, true},
+				{`[1, 2]`, `[2, 2, 1]`, true},
+				// A top-level array contains a scalar that is its element, but
+				// not the other way around.
+				{`[1, 2]`, `1`, true},
+				{`1`, `[1]`, false},
+				// The JSON null is a regular value.
+				{`{"a": null}`, `{"a": null}`, true},
+				{`null`, `null`, true},
+				{nil, `{}`, nil},
+				{`{}`, nil, nil},
+			},
+		},
+		{
+			desc: "contained by column",
+			expr: "@1 <@ @2",
+			inputTuples: colexectestutils.Tuples{
+				{`{"a": 1}`, `{"a": 1, "b": 2}`},
+				{`{"a": 1, "b": 2}`, `{"a": 1}`},
+				{`1`, `[1, 2]`},
+				{nil, nil},
+			},
+			inputTypes: []*types.T{types.Jsonb, types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": 1}`, `{"a": 1, "b": 2}`, true},
+				{`{"a": 1, "b": 2}`, `{"a": 1}`, false},
+				{`1`, `[1, 2]`, true},
+				{nil, nil, nil},
+			},
+		},
+		{
+			desc: "contains constant",
+			expr: `@1 @> '{"k": 1}'`,
+			inputTuples: colexectestutils.Tuples{
+				{`{"k": 1, "j": 2}`}, {`{"k": 2}`}, {`{"j": {"k": 1}}`}, {`[{"k": 1}]`}, {`{}`}, {nil},
+			},
+			inputTypes: []*types.T{types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{`{"k": 1, "j": 2}`, true}, {`{"k": 2}`, false}, {`{"j": {"k": 1}}`, false},
+				{`[{"k": 1}]`, false}, {`{}`, false}, {nil, nil},
+			},
+		},
+		{
+			desc: "contains constant array",
+			expr: `@1 @> '[1, "a"]'`,
+			inputTuples: colexectestutils.Tuples{
+				{`["a", 2, 1]`}, {`[1]`}, {`[[1, "a"]]`}, {`{"a": 1}`}, {nil},
+			},
+			inputTypes: []*types.T{types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{`["a", 2, 1]`, true}, {`[1]`, false}, {`[[1, "a"]]`, false}, {`{"a": 1}`, false}, {nil, nil},
+			},
+		},
+		{
+			desc: "contained by constant",
+			expr: `@1 <@ '{"a": [1, 2], "b": true}'`,
+			inputTuples: colexectestutils.Tuples{
+				{`{"a": [2]}`}, {`{"b": true}`}, {`{"b": false}`}, {`{}`}, {`[]`}, {nil},
+			},
+			inputTypes: []*types.T{types.Jsonb},
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": [2]}`, true}, {`{"b": true}`, true}, {`{"b": false}`, false},
+				{`{}`, true}, {`[]`, false}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+func TestJSONContainsSel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	typs := []*types.T{types.Jsonb, types.Jsonb}
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": [1, 2]}`, `{"a": [1]}`},
+		{`{"a": [1]}`, `{"a": [1, 2]}`},
+		{`[1]`, `[]`},
+		{`null`, `null`},
+		{nil, `{}`},
+		{`{}`, nil},
+	}
+	constRight, err := tree.ParseDJSON(`{"a": [1, 2], "b": 3}`)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		cmpOp        tree.ComparisonOperator
+		constRight   tree.Datum
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			cmpOp: tree.Contains,
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": [1, 2]}`, `{"a": [1]}`},
+				{`[1]`, `[]`},
+				{`null`, `null`},
+			},
+		},
+		{
+			cmpOp: tree.ContainedBy,
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": [1]}`, `{"a": [1, 2]}`},
+				{`null`, `null`},
+			},
+		},
+		{
+			cmpOp:      tree.ContainedBy,
+			constRight: constRight,
+			outputTuples: colexectestutils.Tuples{
+				{`{"a": [1, 2]}`, `{"a": [1]}`},
+				{`{"a": [1]}`, `{"a": [1, 2]}`},
+				{`{}`, nil},
+			},
+		},
+	} {
+		log.Infof(ctx, "%s/const=%t", tc.cmpOp, tc.constRight != nil)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetJSONContainsOperator(
+					input[0], tc.cmpOp, 0 /* leftIdx */, 1 /* rightIdx */, tc.constRight,
+				)
+			})
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// jsonExistsBase evaluates the JSON key existence operators (?, ?|, and ?&)
+// of the JSON column at position colIdx and the constant keys.
+type jsonExistsBase struct {
+	colIdx int
+	// keys contains the keys to look up. NULL keys are omitted since they
+	// never exist.
+	keys []string
+	// all indicates whether all of the keys must exist (?&) rather than any
+	// of them (? and ?|).
+	all bool
+}
+
+func makeJSONExistsBase(
+	cmpOp tree.ComparisonOperator, colIdx int, constArg tree.Datum,
+) (jsonExistsBase, error) {
+	b := jsonExistsBase{colIdx: colIdx, all: cmpOp == tree.JSONAllExists}
+	switch cmpOp {
+	case tree.JSONExists:
+		key, ok := tree.AsDString(constArg)
+		if !ok {
+			return jsonExistsBase{}, errors.Errorf("unsupported JSON key existence argument %s", constArg)
+		}
+		b.keys = []string{string(key)}
+	case tree.JSONSomeExists, tree.JSONAllExists:
+		arr, ok := constArg.(*tree.DArray)
+		if !ok {
+			return jsonExistsBase{}, errors.Errorf("unsupported JSON key existence argument %s", constArg)
+		}
+		b.keys = make([]string, 0, len(arr.Array))
+		for _, d := range arr.Array {
+			if d == tree.DNull {
+				continue
+			}
+			b.keys = append(b.keys, string(tree.MustBeDString(d)))
+		}
+	default:
+		return jsonExistsBase{}, errors.AssertionFailedf("unexpected JSON key existence operator %s", cmpOp)
+	}
+	return b, nil
+}
+
+// eval returns the result of the key existence operator on the row at
+// position rowIdx. The JSON values are not decoded: the keys are looked up
+// directly in the encoded representation.
+func (b *jsonExistsBase) eval(col *coldata.JSONs, rowIdx int) bool {
+	j := col.Get(rowIdx)
+	for _, key := range b.keys {
+		exists, err := j.Exists(key)
+		if err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		if exists != b.all {
+			// This is either the first key that exists (for the "any" case)
+			// or the first key that doesn't exist (for the "all" case), so
+			// the result is known.
+			return exists
+		}
+	}
+	// Either all of the keys exist (for the "all" case) or none of them do
+	// (for the "any" case).
+	return b.all
+}
+
+// GetJSONExistsProjectionOperator returns an operator that projects the result
+// of the JSON key existence operator cmpOp (one of ?, ?|, and ?&) into the
+// Bool column at position resultIdx. The left argument is the JSON column at
+// position colIdx, and the right argument is the constant constArg which must
+// be either a string or an array of strings.
+func GetJSONExistsProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	cmpOp tree.ComparisonOperator,
+	colIdx int,
+	constArg tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeJSONExistsBase(cmpOp, colIdx, constArg)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &jsonExistsProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		jsonExistsBase: base,
+		allocator:      allocator,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// GetJSONExistsOperator returns an operator that selects the tuples for which
+// the JSON key existence operator cmpOp (one of ?, ?|, and ?&) evaluates to
+// true. The arguments are the same as in GetJSONExistsProjectionOperator.
+func GetJSONExistsOperator(
+	input colexecop.Operator, cmpOp tree.ComparisonOperator, colIdx int, constArg tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeJSONExistsBase(cmpOp, colIdx, constArg)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonExistsSelOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		jsonExistsBase: base,
+	}, nil
+}
+
+type jsonExistsProjOp struct {
+	colexecop.OneInputHelper
+	jsonExistsBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &jsonExistsProjOp{}
+
+func (o *jsonExistsProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(o.colIdx)
+	nulls, col := vec.Nulls(), vec.JSON()
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if nulls.NullAt(rowIdx) {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = o.eval(col, rowIdx)
+		}
+	})
+	return batch
+}
+
+type jsonExistsSelOp struct {
+	colexecop.OneInputHelper
+	jsonExistsBase
+}
+
+var _ colexecop.Operator = &jsonExistsSelOp{}
+
+func (o *jsonExistsSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(o.colIdx)
+		nulls, col := vec.Nulls(), vec.JSON()
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if !nulls.NullAt(i) && o.eval(col, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if !nulls.NullAt(i) && o.eval(col, i) {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
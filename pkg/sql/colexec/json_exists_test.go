@@ -0,0 +1,148 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestJSONExists(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// Note that only the top-level keys of the objects and the top-level
+	// string elements of the arrays are considered.
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": 1, "b": null}`},
+		{`{"c": {"a": 1}}`},
+		{`["a", "c", 1]`},
+		{`[["a"], "b"]`},
+		{`"a"`},
+		{`1`},
+		{`null`},
+		{nil},
+	}
+	typs := []*types.T{types.Jsonb}
+	for _, tc := range []struct {
+		expr    string
+		results []interface{}
+	}{
+		{
+			expr:    `@1 ? 'a'`,
+			results: []interface{}{true, false, true, false, true, false, false, nil},
+		},
+		{
+			expr:    `@1 ? 'b'`,
+			results: []interface{}{true, false, false, true, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?| '{b,c}'::STRING[]`,
+			results: []interface{}{true, true, true, true, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?| '{x,NULL}'::STRING[]`,
+			results: []interface{}{false, false, false, false, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?| '{}'::STRING[]`,
+			results: []interface{}{false, false, false, false, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?& '{a,b}'::STRING[]`,
+			results: []interface{}{true, false, false, false, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?& '{a,c,NULL}'::STRING[]`,
+			results: []interface{}{false, false, true, false, false, false, false, nil},
+		},
+		{
+			expr:    `@1 ?& '{}'::STRING[]`,
+			results: []interface{}{true, true, true, true, true, true, true, nil},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		outputTuples := make(colexectestutils.Tuples, len(inputTuples))
+		for i := range inputTuples {
+			outputTuples[i] = colexectestutils.Tuple{inputTuples[i][0], tc.results[i]}
+		}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+func TestJSONExistsSel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	typs := []*types.T{types.Jsonb}
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": 1, "b": 2}`}, {`{"b": 2}`}, {`["a"]`}, {`"b"`}, {`{}`}, {nil},
+	}
+	for _, tc := range []struct {
+		cmpOp        tree.ComparisonOperator
+		constArg     tree.Datum
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			cmpOp:        tree.JSONExists,
+			constArg:     tree.NewDString("a"),
+			outputTuples: colexectestutils.Tuples{{`{"a": 1, "b": 2}`}, {`["a"]`}},
+		},
+		{
+			cmpOp: tree.JSONSomeExists,
+			constArg: &tree.DArray{
+				ParamTyp: types.String,
+				Array:    tree.Datums{tree.NewDString("a"), tree.NewDString("b")},
+			},
+			outputTuples: colexectestutils.Tuples{{`{"a": 1, "b": 2}`}, {`{"b": 2}`}, {`["a"]`}, {`"b"`}},
+		},
+		{
+			cmpOp: tree.JSONAllExists,
+			constArg: &tree.DArray{
+				ParamTyp: types.String,
+				Array:    tree.Datums{tree.NewDString("a"), tree.NewDString("b")},
+			},
+			outputTuples: colexectestutils.Tuples{{`{"a": 1, "b": 2}`}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.cmpOp)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetJSONExistsOperator(input[0], tc.cmpOp, 0 /* colIdx */, tc.constArg)
+			})
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// JSONExpandFunc specifies the set-returning function that the JSON expand
+// operator evaluates.
+type JSONExpandFunc int
+
+const (
+	// JSONArrayElements expands a JSON array into its elements
+	// (json_array_elements and jsonb_array_elements).
+	JSONArrayElements JSONExpandFunc = iota
+	// JSONArrayElementsText expands a JSON array into the text representations
+	// of its elements (json_array_elements_text and jsonb_array_elements_text).
+	JSONArrayElementsText
+	// JSONEach expands a JSON object into its key/value pairs (json_each and
+	// jsonb_each).
+	JSONEach
+	// JSONEachText expands a JSON object into its keys and the text
+	// representations of its values (json_each_text and jsonb_each_text).
+	JSONEachText
+	// JSONObjectKeys expands a JSON object into its keys in sorted order
+	// (json_object_keys and jsonb_object_keys).
+	JSONObjectKeys
+)
+
+// These errors match the ones returned by the row-by-row implementations of
+// the corresponding builtins.
+var (
+	errJSONCallOnNonArray            = pgerror.New(pgcode.InvalidParameterValue, "cannot be called on a non-array")
+	errJSONDeconstructArrayAsObject  = pgerror.New(pgcode.InvalidParameterValue, "cannot deconstruct an array as an object")
+	errJSONDeconstructScalarAsObject = pgerror.Newf(pgcode.InvalidParameterValue, "cannot deconstruct a scalar")
+	errJSONObjectKeysOnArray         = pgerror.New(pgcode.InvalidParameterValue, "cannot call json_object_keys on an array")
+	errJSONObjectKeysOnScalar        = pgerror.Newf(pgcode.InvalidParameterValue, "cannot call json_object_keys on a scalar")
+)
+
+// NewJSONExpandOp returns an operator that evaluates the set-returning
+// function fn on the JSON column at position jsonColIdx. Each input tuple is
+// repeated once for each value generated from it with the generated columns
+// appended after the first numInputCols columns of the input (the remaining
+// columns of the input, if any, are not emitted). The tuples with NULL in the
+// JSON column as well as the tuples with empty JSON arrays or objects don't
+// produce any output.
+func NewJSONExpandOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	numInputCols int,
+	jsonColIdx int,
+	fn JSONExpandFunc,
+	maxOutputBatchMemSize int64,
+) (colexecop.Operator, error) {
+	outputTypes := make([]*types.T, numInputCols, numInputCols+2)
+	copy(outputTypes, inputTypes[:numInputCols])
+	switch fn {
+	case JSONArrayElements:
+		outputTypes = append(outputTypes, types.Jsonb)
+	case JSONArrayElementsText:
+		outputTypes = append(outputTypes, types.String)
+	case JSONEach:
+		outputTypes = append(outputTypes, types.String, types.Jsonb)
+	case JSONEachText:
+		outputTypes = append(outputTypes, types.String, types.String)
+	case JSONObjectKeys:
+		outputTypes = append(outputTypes, types.String)
+	default:
+		return nil, errors.AssertionFailedf("unexpected json expand function %d", fn)
+	}
+	return &jsonExpandOp{
+		OneInputHelper:        colexecop.MakeOneInputHelper(input),
+		allocator:             allocator,
+		outputTypes:           outputTypes,
+		numInputCols:          numInputCols,
+		jsonColIdx:            jsonColIdx,
+		fn:                    fn,
+		maxOutputBatchMemSize: maxOutputBatchMemSize,
+	}, nil
+}
+
+// jsonExpandOp expands the JSON values of its input into several output
+// tuples. Since a single input tuple can produce an arbitrary number of output
+// tuples, the operator keeps track of the input tuple being currently expanded
+// across the calls to Next.
+type jsonExpandOp struct {
+	colexecop.OneInputHelper
+
+	allocator             *colmem.Allocator
+	outputTypes           []*types.T
+	numInputCols          int
+	jsonColIdx            int
+	fn                    JSONExpandFunc
+	maxOutputBatchMemSize int64
+
+	// batch is the current input batch, and nextIdx is the position of the
+	// next tuple in it (before applying the selection vector) to be expanded.
+	batch   coldata.Batch
+	nextIdx int
+	// expanding indicates whether the tuple at position rowIdx in batch is
+	// currently being expanded. In such case either the array or the iterator
+	// over the object is set depending on the function.
+	expanding bool
+	rowIdx    int
+	array     json.JSON
+	arrayIdx  int
+	iter      *json.ObjectIterator
+
+	output coldata.Batch
+	// srcIdxs contains the position of the input tuple in batch for each of
+	// the output tuples.
+	srcIdxs []int
+}
+
+var _ colexecop.Operator = &jsonExpandOp{}
+
+func (o *jsonExpandOp) Next() coldata.Batch {
+	if o.batch == nil {
+		o.batch = o.Input.Next()
+	}
+	if o.batch.Length() == 0 {
+		return coldata.ZeroBatch
+	}
+	// Most commonly, every input tuple expands into a few output ones, so we
+	// use the length of the input batch as the estimate of the output size.
+	o.output, _ = o.allocator.ResetMaybeReallocate(
+		o.outputTypes, o.output, o.batch.Length(), o.maxOutputBatchMemSize,
+	)
+	if cap(o.srcIdxs) < o.output.Capacity() {
+		o.srcIdxs = make([]int, o.output.Capacity())
+	}
+	o.srcIdxs = o.srcIdxs[:o.output.Capacity()]
+	var outputIdx int
+	o.allocator.PerformOperation(o.output.ColVecs(), func() {
+		// batchStartIdx is the position of the first output tuple that was
+		// generated from the current input batch.
+		batchStartIdx := 0
+		for outputIdx < o.output.Capacity() {
+			if !o.expanding && !o.startNextTuple() {
+				// The current input batch has been fully consumed, so we
+				// copy the input columns for the output tuples generated
+				// from it before moving onto the next batch.
+				copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+				batchStartIdx = outputIdx
+				o.batch = o.Input.Next()
+				o.nextIdx = 0
+				if o.batch.Length() == 0 {
+					break
+				}
+				continue
+			}
+			if o.generate(outputIdx) {
+				o.srcIdxs[outputIdx] = o.rowIdx
+				outputIdx++
+			} else {
+				o.expanding = false
+			}
+		}
+		copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+		o.output.SetLength(outputIdx)
+	})
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	return o.output
+}
+
+// startNextTuple finds the next tuple in the current input batch that is not
+// NULL in the JSON column and prepares it for the expansion. false is returned
+// if the batch has been fully consumed.
+func (o *jsonExpandOp) startNextTuple() bool {
+	n := o.batch.Length()
+	sel := o.batch.Selection()
+	vec := o.batch.ColVec(o.jsonColIdx)
+	nulls := vec.Nulls()
+	col := vec.JSON()
+	for ; o.nextIdx < n; o.nextIdx++ {
+		rowIdx := o.nextIdx
+		if sel != nil {
+			rowIdx = sel[o.nextIdx]
+		}
+		if nulls.MaybeHasNulls() && nulls.NullAt(rowIdx) {
+			continue
+		}
+		j := col.Get(rowIdx)
+		switch o.fn {
+		case JSONArrayElements, JSONArrayElementsText:
+			if j.Type() != json.ArrayJSONType {
+				colexecerror.ExpectedError(errJSONCallOnNonArray)
+			}
+			o.array, o.arrayIdx = j, 0
+		default:
+			iter, err := j.ObjectIter()
+			if err != nil {
+				colexecerror.ExpectedError(err)
+			}
+			if iter == nil {
+				isArray := j.Type() == json.ArrayJSONType
+				switch {
+				case o.fn == JSONObjectKeys && isArray:
+					colexecerror.ExpectedError(errJSONObjectKeysOnArray)
+				case o.fn == JSONObjectKeys:
+					colexecerror.ExpectedError(errJSONObjectKeysOnScalar)
+				case isArray:
+					colexecerror.ExpectedError(errJSONDeconstructArrayAsObject)
+				}
+				colexecerror.ExpectedError(errJSONDeconstructScalarAsObject)
+			}
+			o.iter = iter
+		}
+		o.rowIdx = rowIdx
+		o.nextIdx++
+		o.expanding = true
+		return true
+	}
+	return false
+}
+
+// generate writes the next value generated from the tuple being currently
+// expanded into the output tuple at position outputIdx. false is returned if
+// there are no more values to generate.
+func (o *jsonExpandOp) generate(outputIdx int) bool {
+	var key string
+	var value json.JSON
+	switch o.fn {
+	case JSONArrayElements, JSONArrayElementsText:
+		var err error
+		value, err = o.array.FetchValIdx(o.arrayIdx)
+		if err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		if value == nil {
+			return false
+		}
+		o.arrayIdx++
+	default:
+		if !o.iter.Next() {
+			return false
+		}
+		key, value = o.iter.Key(), o.iter.Value()
+		o.output.ColVec(o.numInputCols).Bytes().Set(outputIdx, []byte(key))
+	}
+	valueVec := o.output.ColVec(len(o.outputTypes) - 1)
+	switch o.fn {
+	case JSONArrayElements, JSONEach:
+		valueVec.JSON().Set(outputIdx, value)
+	case JSONObjectKeys:
+		// Only the keys are emitted.
+	default:
+		text, err := value.AsText()
+		if err != nil {
+			colexecerror.ExpectedError(err)
+		}
+		if text == nil {
+			// JSON null is converted to SQL NULL.
+			valueVec.Nulls().SetNull(outputIdx)
+			// We still need to set a value in order to maintain the
+			// invariants of the flat bytes.
+			valueVec.Bytes().Set(outputIdx, nil)
+		} else {
+			valueVec.Bytes().Set(outputIdx, []byte(*text))
+		}
+	}
+	return true
+}
+
+// copyExpandedInputColumns copies the first numInputCols columns from the
+// input batch into the output tuples in range [startIdx, endIdx) of the output
+// batch, where srcIdxs contains the position of the input tuple for each of the
+// output tuples.
+func copyExpandedInputColumns(
+	output, batch coldata.Batch, numInputCols int, srcIdxs []int, startIdx, endIdx int,
+) {
+	if startIdx == endIdx {
+		return
+	}
+	for i := 0; i < numInputCols; i++ {
+		output.ColVec(i).Copy(
+			coldata.CopySliceArgs{
+				SliceArgs: coldata.SliceArgs{
+					Src:         batch.ColVec(i),
+					Sel:         srcIdxs,
+					DestIdx:     startIdx,
+					SrcStartIdx: startIdx,
+					SrcEndIdx:   endIdx,
+				},
+			},
+		)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONExpand(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	// longArray expands into several output batches on its own.
+	longArrayLen := 2*coldata.BatchSize() + 3
+	var longArray strings.Builder
+	longArray.WriteString("[")
+	longArrayExpected := make(colexectestutils.Tuples, 0, longArrayLen)
+	for i := 0; i < longArrayLen; i++ {
+		if i > 0 {
+			longArray.WriteString(", ")
+		}
+		fmt.Fprintf(&longArray, "%d", i)
+		longArrayExpected = append(longArrayExpected, colexectestutils.Tuple{3, mustParseJSON(fmt.Sprintf("%d", i))})
+	}
+	longArray.WriteString("]")
+	// longObject expands into several output batches on its own too. Its keys
+	// are emitted in sorted order.
+	longObjectLen := 2*coldata.BatchSize() + 3
+	var longObject strings.Builder
+	longObject.WriteString("{")
+	longObjectExpected := make(colexectestutils.Tuples, 0, longObjectLen+1)
+	for i := longObjectLen - 1; i >= 0; i-- {
+		if i < longObjectLen-1 {
+			longObject.WriteString(", ")
+		}
+		fmt.Fprintf(&longObject, `"k%05d": %d`, i, i)
+	}
+	for i := 0; i < longObjectLen; i++ {
+		longObjectExpected = append(longObjectExpected, colexectestutils.Tuple{3, fmt.Sprintf("k%05d", i)})
+	}
+	longObject.WriteString("}")
+	longObjectExpected = append(longObjectExpected, colexectestutils.Tuple{4, "a"})
+
+	typs := []*types.T{types.Int, types.Jsonb}
+	for _, tc := range []struct {
+		desc     string
+		fn       JSONExpandFunc
+		tuples   colexectestutils.Tuples
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc: "array elements",
+			fn:   JSONArrayElements,
+			tuples: colexectestutils.Tuples{
+				{0, `[1, "a", null]`}, {1, `[]`}, {2, nil}, {nil, `[{"b": [2]}]`}, {4, `[true, false]`},
+			},
+			expected: colexectestutils.Tuples{
+				{0, mustParseJSON(`1`)}, {0, mustParseJSON(`"a"`)}, {0, mustParseJSON(`null`)},
+				{nil, mustParseJSON(`{"b": [2]}`)},
+				{4, mustParseJSON(`true`)}, {4, mustParseJSON(`false`)},
+			},
+		},
+		{
+			desc:     "long array",
+			fn:       JSONArrayElements,
+			tuples:   colexectestutils.Tuples{{1, `[]`}, {3, longArray.String()}, {5, nil}},
+			expected: longArrayExpected,
+		},
+		{
+			desc: "array elements as text",
+			fn:   JSONArrayElementsText,
+			tuples: colexectestutils.Tuples{
+				{0, `[1, "a", null]`}, {1, `[]`}, {2, nil}, {3, `[{"b": [2]}, "c"]`},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "1"}, {0, "a"}, {0, nil},
+				{3, `{"b": [2]}`}, {3, "c"},
+			},
+		},
+		{
+			desc: "each",
+			fn:   JSONEach,
+			tuples: colexectestutils.Tuples{
+				{0, `{"b": 1, "a": [2]}`}, {1, `{}`}, {2, nil}, {nil, `{"c": null}`},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "a", mustParseJSON(`[2]`)}, {0, "b", mustParseJSON(`1`)},
+				{nil, "c", mustParseJSON(`null`)},
+			},
+		},
+		{
+			desc: "each as text",
+			fn:   JSONEachText,
+			tuples: colexectestutils.Tuples{
+				{0, `{"b": 1, "a": "x"}`}, {1, `{}`}, {2, nil}, {3, `{"c": null, "d": {"e": true}}`},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "a", "x"}, {0, "b", "1"},
+				{3, "c", nil}, {3, "d", `{"e": true}`},
+			},
+		},
+		{
+			desc: "object keys",
+			fn:   JSONObjectKeys,
+			tuples: colexectestutils.Tuples{
+				{0, `{"b": 1, "a": [2], "": null}`}, {1, `{}`}, {2, nil}, {nil, `{"c": {"d": 3}}`},
+			},
+			expected: colexectestutils.Tuples{
+				{0, ""}, {0, "a"}, {0, "b"},
+				{nil, "c"},
+			},
+		},
+		{
+			desc:     "long object keys",
+			fn:       JSONObjectKeys,
+			tuples:   colexectestutils.Tuples{{1, `{}`}, {3, longObject.String()}, {4, `{"a": 1}`}, {5, nil}},
+			expected: longObjectExpected,
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				// Only the first input column is emitted.
+				return NewJSONExpandOp(
+					testAllocator, input[0], typs, 1 /* numInputCols */, 1 /* jsonColIdx */, tc.fn, math.MaxInt64, /* maxOutputBatchMemSize */
+				)
+			})
+	}
+
+	// Expanding the JSON values of an unexpected type results in an error,
+	// same as in the row engine.
+	for _, tc := range []struct {
+		fn          JSONExpandFunc
+		json        string
+		expectedErr string
+	}{
+		{fn: JSONArrayElements, json: `{"a": 1}`, expectedErr: "cannot be called on a non-array"},
+		{fn: JSONArrayElementsText, json: `1`, expectedErr: "cannot be called on a non-array"},
+		{fn: JSONEach, json: `[1]`, expectedErr: "cannot deconstruct an array as an object"},
+		{fn: JSONEachText, json: `"a"`, expectedErr: "cannot deconstruct a scalar"},
+		{fn: JSONObjectKeys, json: `[1]`, expectedErr: "cannot call json_object_keys on an array"},
+		{fn: JSONObjectKeys, json: `null`, expectedErr: "cannot call json_object_keys on a scalar"},
+	} {
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{0, tc.json}}, typs)
+		op, err := NewJSONExpandOp(
+			testAllocator, input, typs, len(typs), 1 /* jsonColIdx */, tc.fn, math.MaxInt64, /* maxOutputBatchMemSize */
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.expectedErr)
+	}
+}
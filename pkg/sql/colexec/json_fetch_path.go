@@ -0,0 +1,146 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// GetJSONFetchPathProjectionOperator returns an operator that projects the
+// result of the JSON path extraction operator binOp (either #> or #>>) into
+// the column at position resultIdx. The left argument is the JSON column at
+// position colIdx, and the right argument is the constant path constPath
+// which must be an array of strings. Unlike the default operators, the path
+// is converted only once rather than for every row.
+func GetJSONFetchPathProjectionOperator(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	binOp tree.BinaryOperator,
+	colIdx int,
+	constPath tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	if binOp != tree.JSONFetchValPath && binOp != tree.JSONFetchTextPath {
+		return nil, errors.AssertionFailedf("unexpected JSON path extraction operator %s", binOp)
+	}
+	arr, ok := constPath.(*tree.DArray)
+	if !ok {
+		return nil, errors.Errorf("unsupported JSON path %s", constPath)
+	}
+	op := &jsonFetchPathOp{
+		allocator: allocator,
+		colIdx:    colIdx,
+		outputIdx: resultIdx,
+		asText:    binOp == tree.JSONFetchTextPath,
+		path:      make([]string, len(arr.Array)),
+	}
+	for i, d := range arr.Array {
+		if d == tree.DNull {
+			// The path containing NULL never exists.
+			op.pathHasNull = true
+			break
+		}
+		op.path[i] = string(tree.MustBeDString(d))
+	}
+	outputType := types.Jsonb
+	if op.asText {
+		outputType = types.String
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, resultIdx)
+	op.OneInputHelper = colexecop.MakeOneInputHelper(input)
+	return op, nil
+}
+
+// jsonFetchPathOp is an operator that extracts the JSON value at the constant
+// path either as JSON or as text. The result is NULL if the path doesn't
+// exist. The lookups are performed directly on the encoded JSON values, so
+// only the extracted values are decoded.
+type jsonFetchPathOp struct {
+	colexecop.OneInputHelper
+	allocator   *colmem.Allocator
+	colIdx      int
+	outputIdx   int
+	asText      bool
+	path        []string
+	pathHasNull bool
+}
+
+var _ colexecop.Operator = &jsonFetchPathOp{}
+
+func (o *jsonFetchPathOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.colIdx)
+	nulls, col := vec.Nulls(), vec.JSON()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls := outputVec.Nulls()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if o.pathHasNull || nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				res, err := json.FetchPath(col.Get(rowIdx), o.path)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				if res == nil {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				if !o.asText {
+					outputVec.JSON().Set(rowIdx, res)
+					continue
+				}
+				text, err := res.AsText()
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				if text == nil {
+					// The JSON null results in NULL when extracted as text.
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				// Set copies the value, so it is safe to not allocate a new
+				// byte slice.
+				outputVec.Bytes().Set(rowIdx, encoding.UnsafeConvertStringToBytes(*text))
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
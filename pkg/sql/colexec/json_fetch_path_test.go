@@ -0,0 +1,123 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestJSONFetchPath(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": {"b": {"c": [1, "x", {"d": true}]}}}`},
+		{`{"a": {"b": "str"}}`},
+		{`{"a": [{"b": 1}, {"b": null}]}`},
+		{`[{"a": 1}, [2, 3]]`},
+		{`{"b": 1}`},
+		{`"a"`},
+		{nil},
+	}
+	typs := []*types.T{types.Jsonb}
+	for _, tc := range []struct {
+		expr    string
+		results []interface{}
+	}{
+		{
+			expr:    `@1 #> '{a,b}'::STRING[]`,
+			results: []interface{}{`{"c": [1, "x", {"d": true}]}`, `"str"`, nil, nil, nil, nil, nil},
+		},
+		{
+			expr:    `@1 #>> '{a,b}'::STRING[]`,
+			results: []interface{}{`{"c": [1, "x", {"d": true}]}`, "str", nil, nil, nil, nil, nil},
+		},
+		{
+			// A deep path with an array index step.
+			expr:    `@1 #> '{a,b,c,2,d}'::STRING[]`,
+			results: []interface{}{`true`, nil, nil, nil, nil, nil, nil},
+		},
+		{
+			expr:    `@1 #>> '{a,b,c,1}'::STRING[]`,
+			results: []interface{}{"x", nil, nil, nil, nil, nil, nil},
+		},
+		{
+			// The JSON null is extracted as JSON but results in NULL when
+			// extracted as text.
+			expr:    `@1 #> '{a,-1,b}'::STRING[]`,
+			results: []interface{}{nil, nil, `null`, nil, nil, nil, nil},
+		},
+		{
+			expr:    `@1 #>> '{a,-1,b}'::STRING[]`,
+			results: []interface{}{nil, nil, nil, nil, nil, nil, nil},
+		},
+		{
+			// Array index steps on the top-level array, and steps that are
+			// not valid indices.
+			expr:    `@1 #> '{1,0}'::STRING[]`,
+			results: []interface{}{nil, nil, nil, `2`, nil, nil, nil},
+		},
+		{
+			expr:    `@1 #> '{x,0}'::STRING[]`,
+			results: []interface{}{nil, nil, nil, nil, nil, nil, nil},
+		},
+		{
+			// The empty path extracts the whole document.
+			expr: `@1 #> '{}'::STRING[]`,
+			results: []interface{}{
+				`{"a": {"b": {"c": [1, "x", {"d": true}]}}}`, `{"a": {"b": "str"}}`,
+				`{"a": [{"b": 1}, {"b": null}]}`, `[{"a": 1}, [2, 3]]`, `{"b": 1}`, `"a"`, nil,
+			},
+		},
+		{
+			expr:    `@1 #>> '{}'::STRING[]`,
+			results: []interface{}{`{"a": {"b": {"c": [1, "x", {"d": true}]}}}`, `{"a": {"b": "str"}}`, `{"a": [{"b": 1}, {"b": null}]}`, `[{"a": 1}, [2, 3]]`, `{"b": 1}`, "a", nil},
+		},
+		{
+			// The path containing NULL never exists.
+			expr:    `@1 #> '{b,NULL}'::STRING[]`,
+			results: []interface{}{nil, nil, nil, nil, nil, nil, nil},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		outputTuples := make(colexectestutils.Tuples, len(inputTuples))
+		for i := range inputTuples {
+			outputTuples[i] = colexectestutils.Tuple{inputTuples[i][0], tc.results[i]}
+		}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+)
+
+// newJSONSetOperator returns an operator that evaluates jsonb_set() builtin
+// (if insert is false) or jsonb_insert() builtin (if insert is true). The path
+// must be a constant array of strings, and it is converted only once rather
+// than for every row. The JSON target and the new value are at positions
+// argumentCols[0] and argumentCols[2]. If there are four arguments, the
+// create_missing (for jsonb_set) or insert_after (for jsonb_insert) flag is
+// at position argumentCols[3]; otherwise, the defaults of true and false,
+// respectively, are used.
+func newJSONSetOperator(
+	allocator *colmem.Allocator,
+	path *tree.DArray,
+	insert bool,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &jsonSetOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		insert:         insert,
+		targetIdx:      argumentCols[0],
+		newValIdx:      argumentCols[2],
+		flagIdx:        -1,
+		defaultFlag:    !insert,
+		outputIdx:      outputIdx,
+		path:           make([]string, len(path.Array)),
+	}
+	if len(argumentCols) == 4 {
+		op.flagIdx = argumentCols[3]
+	}
+	for i, d := range path.Array {
+		if d == tree.DNull {
+			// Same as in the row engine, the error is only returned for the
+			// rows that don't have NULL arguments.
+			op.pathErr = pgerror.Newf(pgcode.NullValueNotAllowed, "path element at position %d is null", i+1)
+			break
+		}
+		op.path[i] = string(tree.MustBeDString(d))
+	}
+	return op
+}
+
+// jsonSetOp is an operator that replaces (jsonb_set) or inserts (jsonb_insert)
+// the new JSON value at the constant path within the target JSON value. The
+// result is NULL if any of the arguments is NULL.
+//
+// The results are encoded into the scratch buffer that is reused across rows.
+type jsonSetOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	insert    bool
+	targetIdx int
+	newValIdx int
+	// flagIdx is the position of the create_missing or the insert_after
+	// argument, or -1 if the argument is omitted, in which case defaultFlag
+	// is used.
+	flagIdx     int
+	defaultFlag bool
+	outputIdx   int
+	path        []string
+	// pathErr, if non-nil, is the error about a NULL element of the path.
+	pathErr error
+	scratch []byte
+}
+
+var _ colexecop.Operator = &jsonSetOp{}
+
+func (j *jsonSetOp) Next() coldata.Batch {
+	batch := j.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	targetVec, newValVec := batch.ColVec(j.targetIdx), batch.ColVec(j.newValIdx)
+	targetNulls, targets := targetVec.Nulls(), targetVec.JSON()
+	newValNulls, newVals := newValVec.Nulls(), newValVec.JSON()
+	var flagNulls *coldata.Nulls
+	var flags coldata.Bools
+	if j.flagIdx != -1 {
+		flagVec := batch.ColVec(j.flagIdx)
+		flagNulls, flags = flagVec.Nulls(), flagVec.Bool()
+	}
+	outputVec := batch.ColVec(j.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.JSON()
+	j.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if targetNulls.NullAt(rowIdx) || newValNulls.NullAt(rowIdx) ||
+					(flagNulls != nil && flagNulls.NullAt(rowIdx)) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				if j.pathErr != nil {
+					colexecerror.ExpectedError(j.pathErr)
+				}
+				flag := j.defaultFlag
+				if flagNulls != nil {
+					flag = flags[rowIdx]
+				}
+				var res json.JSON
+				var err error
+				if j.insert {
+					res, err = json.DeepInsert(targets.Get(rowIdx), j.path, newVals.Get(rowIdx), flag)
+				} else {
+					res, err = json.DeepSet(targets.Get(rowIdx), j.path, newVals.Get(rowIdx), flag)
+				}
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				j.scratch, err = json.EncodeJSON(j.scratch[:0], res)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				outputCol.Bytes.Set(rowIdx, j.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
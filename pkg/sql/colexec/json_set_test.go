@@ -0,0 +1,162 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The columns are the target, the new value, and the create_missing or the
+	// insert_after flag.
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": 1, "b": [1, 2]}`, `"x"`, true},
+		{`{"b": [], "c": {"d": 1}}`, `[3]`, false},
+		{nil, `1`, true},
+		{`{"a": 2, "b": [0]}`, nil, true},
+		{`{"a": 3}`, `2`, nil},
+	}
+	typs := []*types.T{types.Jsonb, types.Jsonb, types.Bool}
+	for _, tc := range []struct {
+		expr    string
+		results []interface{}
+	}{
+		{
+			// Setting an existing key and creating a missing one.
+			expr: `jsonb_set(@1, '{a}', @2)`,
+			results: []interface{}{
+				`{"a": "x", "b": [1, 2]}`, `{"a": [3], "b": [], "c": {"d": 1}}`, nil, nil, `{"a": 2}`,
+			},
+		},
+		{
+			expr: `jsonb_set(@1, '{a}', @2, false)`,
+			results: []interface{}{
+				`{"a": "x", "b": [1, 2]}`, `{"b": [], "c": {"d": 1}}`, nil, nil, `{"a": 2}`,
+			},
+		},
+		{
+			// The create_missing flag comes from a column.
+			expr: `jsonb_set(@1, '{a}', @2, @3)`,
+			results: []interface{}{
+				`{"a": "x", "b": [1, 2]}`, `{"b": [], "c": {"d": 1}}`, nil, nil, nil,
+			},
+		},
+		{
+			// Array index paths. The missing elements are appended to the
+			// array, and the paths that don't exist leave the target intact.
+			expr: `jsonb_set(@1, '{b,0}', @2)`,
+			results: []interface{}{
+				`{"a": 1, "b": ["x", 2]}`, `{"b": [[3]], "c": {"d": 1}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			expr: `jsonb_set(@1, '{b,-1}', @2, false)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, "x"]}`, `{"b": [], "c": {"d": 1}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			expr: `jsonb_set(@1, '{b,5}', @2)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, 2, "x"]}`, `{"b": [[3]], "c": {"d": 1}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			expr: `jsonb_set(@1, '{c,d}', @2)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, 2]}`, `{"b": [], "c": {"d": [3]}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			expr: `jsonb_insert(@1, '{b,0}', @2)`,
+			results: []interface{}{
+				`{"a": 1, "b": ["x", 1, 2]}`, `{"b": [[3]], "c": {"d": 1}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			expr: `jsonb_insert(@1, '{b,0}', @2, true)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, "x", 2]}`, `{"b": [[3]], "c": {"d": 1}}`, nil, nil, `{"a": 3}`,
+			},
+		},
+		{
+			// The insert_after flag comes from a column.
+			expr: `jsonb_insert(@1, '{b,-1}', @2, @3)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, 2, "x"]}`, `{"b": [[3]], "c": {"d": 1}}`, nil, nil, nil,
+			},
+		},
+		{
+			expr: `jsonb_insert(@1, '{e}', @2)`,
+			results: []interface{}{
+				`{"a": 1, "b": [1, 2], "e": "x"}`, `{"b": [], "c": {"d": 1}, "e": [3]}`, nil, nil, `{"a": 3, "e": 2}`,
+			},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.expr)
+		outputTuples := make(colexectestutils.Tuples, len(inputTuples))
+		for i := range inputTuples {
+			outputTuples[i] = append(append(colexectestutils.Tuple{}, inputTuples[i]...), tc.results[i])
+		}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	for _, tc := range []struct {
+		target string
+		expr   string
+		err    string
+	}{
+		{target: `{"a": 1}`, expr: `jsonb_insert(@1, '{a}', '2')`, err: "cannot replace existing key"},
+		{target: `"s"`, expr: `jsonb_set(@1, '{a}', '2')`, err: "cannot set path in scalar"},
+		{target: `{"a": 1}`, expr: `jsonb_set(@1, '{a,NULL}', '2')`, err: "path element at position 2 is null"},
+	} {
+		typs := []*types.T{types.Jsonb}
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{tc.target}}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+)
+
+// newJSONStripNullsOperator returns an operator that evaluates
+// json_strip_nulls() and jsonb_strip_nulls() builtins on the JSON column at
+// position inputIdx.
+func newJSONStripNullsOperator(
+	allocator *colmem.Allocator, inputIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &jsonStripNullsOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// jsonStripNullsOp is an operator that recursively removes all object fields
+// that have JSON null values. Same as in Postgres, the null elements of arrays
+// are left untouched (although the objects within arrays are stripped). The
+// NULL input results in NULL.
+//
+// The values that don't contain any object fields to remove are copied to the
+// output in their encoded form, and the values that do are re-encoded into the
+// scratch buffer that is reused across rows.
+type jsonStripNullsOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	inputIdx  int
+	outputIdx int
+	scratch   []byte
+}
+
+var _ colexecop.Operator = &jsonStripNullsOp{}
+
+func (j *jsonStripNullsOp) Next() coldata.Batch {
+	batch := j.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(j.inputIdx)
+	nulls, col := vec.Nulls(), vec.JSON()
+	outputVec := batch.ColVec(j.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.JSON()
+	j.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				res, stripped, err := col.Get(rowIdx).StripNulls()
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				if !stripped {
+					// Set copies the value, so it is safe to pass the encoded
+					// input value directly.
+					outputCol.Bytes.Set(rowIdx, col.Bytes.Get(rowIdx))
+					continue
+				}
+				j.scratch, err = json.EncodeJSON(j.scratch[:0], res)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				outputCol.Bytes.Set(rowIdx, j.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
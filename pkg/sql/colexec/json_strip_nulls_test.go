@@ -0,0 +1,87 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestJSONStripNulls(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// Note that the null elements of arrays are not stripped while the objects
+	// within the arrays are.
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": 1, "b": null}`},
+		{`{"a": {"b": null, "c": {"d": null, "e": 2}}, "f": null}`},
+		{`{"a": null}`},
+		{`{"a": [null, {"b": null, "c": 1}], "d": "e"}`},
+		{`[null, 1, {"a": null}, [{"b": null}, null]]`},
+		{`[null, 1, "a"]`},
+		{`{"a": 1, "b": [2, 3]}`},
+		{`{}`},
+		{`"str"`},
+		{`1.5`},
+		{`true`},
+		{`null`},
+		{nil},
+	}
+	outputTuples := colexectestutils.Tuples{
+		{`{"a": 1}`},
+		{`{"a": {"c": {"e": 2}}}`},
+		{`{}`},
+		{`{"a": [null, {"c": 1}], "d": "e"}`},
+		{`[null, 1, {}, [{}, null]]`},
+		{`[null, 1, "a"]`},
+		{`{"a": 1, "b": [2, 3]}`},
+		{`{}`},
+		{`"str"`},
+		{`1.5`},
+		{`true`},
+		{`null`},
+		{nil},
+	}
+	for i := range outputTuples {
+		outputTuples[i] = append(colexectestutils.Tuple{inputTuples[i][0]}, outputTuples[i]...)
+	}
+	typs := []*types.T{types.Jsonb}
+	for _, expr := range []string{"json_strip_nulls(@1)", "jsonb_strip_nulls(@1)"} {
+		log.Infof(ctx, "%s", expr)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
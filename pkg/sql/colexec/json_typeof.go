@@ -0,0 +1,115 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// newJSONTypeOfOperator returns an operator that evaluates json_typeof() and
+// jsonb_typeof() builtins on the JSON column at position inputIdx.
+func newJSONTypeOfOperator(
+	allocator *colmem.Allocator, inputIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &jsonTypeOfOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+var (
+	jsonNullTypeName    = []byte("null")
+	jsonStringTypeName  = []byte("string")
+	jsonNumberTypeName  = []byte("number")
+	jsonBooleanTypeName = []byte("boolean")
+	jsonArrayTypeName   = []byte("array")
+	jsonObjectTypeName  = []byte("object")
+)
+
+// jsonTypeOfOp is an operator that returns the name of the type of the
+// outermost JSON value. Note that the result is NULL only for the NULL input
+// while the JSON null results in 'null'. Only the header of the encoded JSON
+// is inspected, so the values are never fully decoded.
+type jsonTypeOfOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &jsonTypeOfOp{}
+
+func (j *jsonTypeOfOp) Next() coldata.Batch {
+	batch := j.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(j.inputIdx)
+	nulls, col := vec.Nulls(), vec.JSON()
+	outputVec := batch.ColVec(j.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	j.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol.Set(rowIdx, jsonTypeName(col.Get(rowIdx).Type()))
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// jsonTypeName returns the name of the JSON type t the same way as the row
+// engine does.
+func jsonTypeName(t json.Type) []byte {
+	switch t {
+	case json.NullJSONType:
+		return jsonNullTypeName
+	case json.StringJSONType:
+		return jsonStringTypeName
+	case json.NumberJSONType:
+		return jsonNumberTypeName
+	case json.FalseJSONType, json.TrueJSONType:
+		return jsonBooleanTypeName
+	case json.ArrayJSONType:
+		return jsonArrayTypeName
+	case json.ObjectJSONType:
+		return jsonObjectTypeName
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unexpected JSON type %d", t))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestJSONTypeOf(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// Note that the JSON null results in 'null' whereas the NULL input results
+	// in NULL.
+	inputTuples := colexectestutils.Tuples{
+		{`{"a": [1, 2]}`}, {`{}`}, {`[1, "a", null]`}, {`[]`}, {`"str"`}, {`""`},
+		{`1.5`}, {`-3`}, {`true`}, {`false`}, {`null`}, {nil},
+	}
+	outputTuples := colexectestutils.Tuples{
+		{`{"a": [1, 2]}`, "object"}, {`{}`, "object"}, {`[1, "a", null]`, "array"},
+		{`[]`, "array"}, {`"str"`, "string"}, {`""`, "string"}, {`1.5`, "number"},
+		{`-3`, "number"}, {`true`, "boolean"}, {`false`, "boolean"}, {`null`, "null"},
+		{nil, nil},
+	}
+	typs := []*types.T{types.Jsonb}
+	for _, expr := range []string{"json_typeof(@1)", "jsonb_typeof(@1)"} {
+		log.Infof(ctx, "%s", expr)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+)
+
+// newLeftRightOperator returns an operator that evaluates left() (if left is
+// true) or right() builtin on the Bytes column at position argumentCols[0]
+// with the Int64 column at position argumentCols[1] as the number of the
+// characters (or bytes if onBytes is true) to return.
+func newLeftRightOperator(
+	allocator *colmem.Allocator,
+	argumentCols []int,
+	left bool,
+	onBytes bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &leftRightOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		left:           left,
+		onBytes:        onBytes,
+		outputIdx:      outputIdx,
+	}
+}
+
+// leftRightOp is an operator that returns the first (if left is true) or the
+// last n characters of each string. Same as in the row engine, a negative n
+// means all but the last (or the first) |n| characters.
+type leftRightOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	left         bool
+	// onBytes indicates whether the arguments are the bytes rather than the
+	// strings, in which case the bytes are counted instead of the characters.
+	onBytes   bool
+	outputIdx int
+	// scratch is the buffer the results are written into when they can't be
+	// sliced from the input (because of invalid UTF-8 bytes which are replaced
+	// with utf8.RuneError). It is reused across rows and batches.
+	scratch []byte
+}
+
+var _ colexecop.Operator = &leftRightOp{}
+
+// resolveLeftRightCount returns the number of the elements out of total that
+// are returned by left() and right() builtins with the count argument n.
+func resolveLeftRightCount(n int64, total int) int {
+	if n < 0 {
+		n += int64(total)
+		if n < 0 {
+			return 0
+		}
+	}
+	if n > int64(total) {
+		return total
+	}
+	return int(n)
+}
+
+// leftRight returns the result of left() or right() builtin for s.
+func (o *leftRightOp) leftRight(s []byte, n int64) []byte {
+	if o.onBytes {
+		count := resolveLeftRightCount(n, len(s))
+		if o.left {
+			return s[:count]
+		}
+		return s[len(s)-count:]
+	}
+	numRunes := utf8.RuneCount(s)
+	count := resolveLeftRightCount(n, numRunes)
+	start, end := 0, count
+	if !o.left {
+		start, end = numRunes-count, numRunes
+	}
+	if !utf8.Valid(s) {
+		o.scratch = appendRuneRange(o.scratch[:0], s, start, end)
+		return o.scratch
+	}
+	// All characters are valid, so we can slice the input directly. Find the
+	// byte offsets of the characters at positions start and end (note that the
+	// conversion to string doesn't allocate here).
+	startOffset, endOffset := len(s), len(s)
+	runeIdx := 0
+	for i := range string(s) {
+		if runeIdx == start {
+			startOffset = i
+		}
+		if runeIdx == end {
+			endOffset = i
+			break
+		}
+		runeIdx++
+	}
+	return s[startOffset:endOffset]
+}
+
+func (o *leftRightOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec, countVec := batch.ColVec(o.argumentCols[0]), batch.ColVec(o.argumentCols[1])
+	inputNulls, inputCol := inputVec.Nulls(), inputVec.Bytes()
+	countNulls, countCol := countVec.Nulls(), countVec.Int64()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if inputNulls.NullAt(rowIdx) || countNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				outputCol.Set(rowIdx, o.leftRight(inputCol.Get(rowIdx), countCol[rowIdx]))
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
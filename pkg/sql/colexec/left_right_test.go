@@ -0,0 +1,134 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestLeftRight(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The counts cover zero, positive and negative values as well as the
+	// values larger than the length of the input.
+	input := colexectestutils.Tuples{
+		{"abcde", 0}, {"abcde", 2}, {"abcde", 5}, {"abcde", 10}, {"abcde", -2},
+		{"abcde", -5}, {"abcde", -10}, {"", 1}, {"", -1}, {"aé禅😀b", 3},
+		{"aé禅😀b", -1}, {"aé禅😀b", 10}, {"a\xffb", 2}, {"a\xffb", -2},
+		{nil, 1}, {"abcde", nil},
+	}
+	typs := []*types.T{types.String, types.Int}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:       "left",
+			expr:       "left(@1, @2)",
+			inputTypes: typs,
+			outputTuples: colexectestutils.Tuples{
+				{"abcde", 0, ""}, {"abcde", 2, "ab"}, {"abcde", 5, "abcde"}, {"abcde", 10, "abcde"},
+				{"abcde", -2, "abc"}, {"abcde", -5, ""}, {"abcde", -10, ""}, {"", 1, ""}, {"", -1, ""},
+				{"aé禅😀b", 3, "aé禅"}, {"aé禅😀b", -1, "aé禅😀"}, {"aé禅😀b", 10, "aé禅😀b"},
+				// The invalid UTF-8 bytes are replaced with utf8.RuneError.
+				{"a\xffb", 2, "a�"}, {"a\xffb", -2, "a"},
+				{nil, 1, nil}, {"abcde", nil, nil},
+			},
+		},
+		{
+			desc:       "right",
+			expr:       "right(@1, @2)",
+			inputTypes: typs,
+			outputTuples: colexectestutils.Tuples{
+				{"abcde", 0, ""}, {"abcde", 2, "de"}, {"abcde", 5, "abcde"}, {"abcde", 10, "abcde"},
+				{"abcde", -2, "cde"}, {"abcde", -5, ""}, {"abcde", -10, ""}, {"", 1, ""}, {"", -1, ""},
+				{"aé禅😀b", 3, "禅😀b"}, {"aé禅😀b", -1, "é禅😀b"}, {"aé禅😀b", 10, "aé禅😀b"},
+				{"a\xffb", 2, "�b"}, {"a\xffb", -2, "b"},
+				{nil, 1, nil}, {"abcde", nil, nil},
+			},
+		},
+		{
+			// On bytes, the count is the number of bytes rather than
+			// characters ("aé禅😀b" has 11 bytes).
+			desc:       "left on bytes",
+			expr:       "left(@1, @2)",
+			inputTypes: []*types.T{types.Bytes, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"abcde", 0, ""}, {"abcde", 2, "ab"}, {"abcde", 5, "abcde"}, {"abcde", 10, "abcde"},
+				{"abcde", -2, "abc"}, {"abcde", -5, ""}, {"abcde", -10, ""}, {"", 1, ""}, {"", -1, ""},
+				{"aé禅😀b", 3, "a\xc3\xa9"}, {"aé禅😀b", -1, "aé禅😀"}, {"aé禅😀b", 10, "aé禅😀"},
+				{"a\xffb", 2, "a\xff"}, {"a\xffb", -2, "a"},
+				{nil, 1, nil}, {"abcde", nil, nil},
+			},
+		},
+		{
+			desc:       "right on bytes",
+			expr:       "right(@1, @2)",
+			inputTypes: []*types.T{types.Bytes, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"abcde", 0, ""}, {"abcde", 2, "de"}, {"abcde", 5, "abcde"}, {"abcde", 10, "abcde"},
+				{"abcde", -2, "cde"}, {"abcde", -5, ""}, {"abcde", -10, ""}, {"", 1, ""}, {"", -1, ""},
+				{"aé禅😀b", 3, "\x98\x80b"}, {"aé禅😀b", -1, "é禅😀b"}, {"aé禅😀b", 10, "é禅😀b"},
+				{"a\xffb", 2, "\xffb"}, {"a\xffb", -2, "b"},
+				{nil, 1, nil}, {"abcde", nil, nil},
+			},
+		},
+		{
+			// INT4 count is handled by the default builtin operator, but the
+			// results must be the same.
+			desc:       "left with INT4",
+			expr:       "left(@1, @2)",
+			inputTypes: []*types.T{types.String, types.Int4},
+			outputTuples: colexectestutils.Tuples{
+				{"abcde", 0, ""}, {"abcde", 2, "ab"}, {"abcde", 5, "abcde"}, {"abcde", 10, "abcde"},
+				{"abcde", -2, "abc"}, {"abcde", -5, ""}, {"abcde", -10, ""}, {"", 1, ""}, {"", -1, ""},
+				{"aé禅😀b", 3, "aé禅"}, {"aé禅😀b", -1, "aé禅😀"}, {"aé禅😀b", 10, "aé禅😀b"},
+				{"a\xffb", 2, "a�"}, {"a\xffb", -2, "a"},
+				{nil, 1, nil}, {"abcde", nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
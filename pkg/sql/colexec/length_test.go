@@ -0,0 +1,104 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestLength(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	stringTuples := colexectestutils.Tuples{{"abc"}, {""}, {"日本語"}, {"café"}, {nil}}
+	testCases := []struct {
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			expr:         "length(@1)",
+			inputTuples:  stringTuples,
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc", 3}, {"", 0}, {"日本語", 3}, {"café", 4}, {nil, nil}},
+		},
+		{
+			expr:         "char_length(@1)",
+			inputTuples:  stringTuples,
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc", 3}, {"", 0}, {"日本語", 3}, {"café", 4}, {nil, nil}},
+		},
+		{
+			expr:         "character_length(@1)",
+			inputTuples:  stringTuples,
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc", 3}, {"", 0}, {"日本語", 3}, {"café", 4}, {nil, nil}},
+		},
+		{
+			expr:         "octet_length(@1)",
+			inputTuples:  stringTuples,
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc", 3}, {"", 0}, {"日本語", 9}, {"café", 5}, {nil, nil}},
+		},
+		{
+			// Each invalid UTF-8 byte is counted as a single character, the
+			// same as in the row engine.
+			expr:         "length(@1)",
+			inputTuples:  colexectestutils.Tuples{{"a\xffb"}, {"\xe6\x97"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"a\xffb", 3}, {"\xe6\x97", 2}},
+		},
+		{
+			expr:         "length(@1)",
+			inputTuples:  colexectestutils.Tuples{{"日本語"}, {""}, {nil}},
+			inputTypes:   []*types.T{types.Bytes},
+			outputTuples: colexectestutils.Tuples{{"日本語", 9}, {"", 0}, {nil, nil}},
+		},
+		{
+			expr:         "octet_length(@1)",
+			inputTuples:  colexectestutils.Tuples{{"日本語"}, {""}, {nil}},
+			inputTypes:   []*types.T{types.Bytes},
+			outputTuples: colexectestutils.Tuples{{"日本語", 9}, {"", 0}, {nil, nil}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s on %s", tc.expr, tc.inputTypes[0])
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
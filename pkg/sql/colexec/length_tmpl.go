@@ -0,0 +1,135 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for length.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _LENGTH is the template function for computing the length of the argument.
+func _LENGTH(_ []byte) int {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// newLengthOperator returns an operator that computes the length of the
+// values in the Bytes column at position colIdx according to the length
+// builtin and writes the result into the Int64 column at position outputIdx.
+func newLengthOperator(
+	allocator *colmem.Allocator,
+	builtin tree.SpecializedVectorizedBuiltin,
+	colIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := lengthOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	switch builtin {
+	case tree.CharLengthString:
+		return &charLengthOp{lengthOpBase: base}
+	case tree.OctetLengthBytes, tree.OctetLengthString:
+		return &octetLengthOp{lengthOpBase: base}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported length builtin %d", builtin))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type lengthOpBase struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+}
+
+// {{range .}}
+
+// _OP_NAMEOp computes _DESCRIPTION in each value of a Bytes
+// column.
+type _OP_NAMEOp struct {
+	lengthOpBase
+}
+
+var _ colexecop.Operator = &_OP_NAMEOp{}
+
+func (l *_OP_NAMEOp) Next() coldata.Batch {
+	batch := l.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(l.colIdx)
+	inputCol := inputVec.Bytes()
+	outputVec := batch.ColVec(l.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Int64()
+	l.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if inputVec.MaybeHasNulls() {
+				inputNulls := inputVec.Nulls()
+				outputNulls := outputVec.Nulls()
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					if inputNulls.NullAt(rowIdx) {
+						outputNulls.SetNull(rowIdx)
+						continue
+					}
+					v := inputCol.Get(rowIdx)
+					outputCol[rowIdx] = int64(_LENGTH(v))
+				}
+			} else {
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					v := inputCol.Get(rowIdx)
+					outputCol[rowIdx] = int64(_LENGTH(v))
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
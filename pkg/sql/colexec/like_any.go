@@ -0,0 +1,315 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexeccmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// likeAnyPattern is a single LIKE pattern compiled for matching.
+type likeAnyPattern struct {
+	// likeOpType is one of LikeConstant, LikePrefix, LikeSuffix,
+	// LikeContains, LikeAlwaysMatch and LikeRegexp.
+	likeOpType colexeccmp.LikeOpType
+	// pattern is the argument of the non-regexp match. It is upper-cased if
+	// the match is case-insensitive.
+	pattern []byte
+	re      *regexp.Regexp
+	// matchesEmpty indicates whether the pattern matches the empty string.
+	// Same as in the row engine, the empty string only matches the patterns
+	// that consist of '%' only.
+	matchesEmpty bool
+}
+
+// match returns whether s matches the pattern. upper must be the upper-cased
+// s if the match is case-insensitive and is ignored otherwise.
+func (p *likeAnyPattern) match(s, upper []byte) bool {
+	if len(s) == 0 {
+		return p.matchesEmpty
+	}
+	switch p.likeOpType {
+	case colexeccmp.LikeConstant:
+		return bytes.Equal(upper, p.pattern)
+	case colexeccmp.LikePrefix:
+		return bytes.HasPrefix(upper, p.pattern)
+	case colexeccmp.LikeSuffix:
+		return bytes.HasSuffix(upper, p.pattern)
+	case colexeccmp.LikeContains:
+		return bytes.Contains(upper, p.pattern)
+	case colexeccmp.LikeAlwaysMatch:
+		return true
+	default:
+		return p.re.Match(s)
+	}
+}
+
+// likeAnyBase evaluates `col LIKE ANY (patterns)` as well as ALL, NOT LIKE
+// and ILIKE variants of it for the String column at position colIdx and the
+// constant list of patterns. Each pattern is compiled only once.
+type likeAnyBase struct {
+	colIdx   int
+	patterns []likeAnyPattern
+	// hasNulls indicates whether there are NULL patterns.
+	hasNulls bool
+	// all indicates whether all patterns (rather than any) must match.
+	all             bool
+	negate          bool
+	caseInsensitive bool
+}
+
+func makeLikeAnyBase(
+	evalCtx *tree.EvalContext,
+	colIdx int,
+	typ *types.T,
+	cmpOp, subOp tree.ComparisonOperator,
+	constArg tree.Datum,
+) (likeAnyBase, error) {
+	if cmpOp != tree.Any && cmpOp != tree.Some && cmpOp != tree.All {
+		return likeAnyBase{}, errors.AssertionFailedf("unexpected operator %s", cmpOp)
+	}
+	b := likeAnyBase{colIdx: colIdx, all: cmpOp == tree.All}
+	switch subOp {
+	case tree.Like:
+	case tree.NotLike:
+		b.negate = true
+	case tree.ILike:
+		b.caseInsensitive = true
+	case tree.NotILike:
+		b.negate, b.caseInsensitive = true, true
+	default:
+		return likeAnyBase{}, errors.AssertionFailedf("unexpected sub-operator %s", subOp)
+	}
+	// The collated strings are not supported.
+	if typ.Family() != types.StringFamily {
+		return likeAnyBase{}, errors.Errorf("unsupported %s type %s", subOp, typ.Name())
+	}
+	var datums tree.Datums
+	if tuple, ok := tree.AsDTuple(constArg); ok {
+		datums = tuple.D
+	} else if array, ok := tree.AsDArray(constArg); ok {
+		datums = array.Array
+	} else {
+		return likeAnyBase{}, errors.Errorf("unsupported %s argument %s", subOp, constArg)
+	}
+	b.patterns = make([]likeAnyPattern, 0, len(datums))
+	for _, d := range datums {
+		if d == tree.DNull {
+			b.hasNulls = true
+			continue
+		}
+		s, ok := tree.AsDString(d)
+		if !ok {
+			return likeAnyBase{}, errors.Errorf("unsupported %s pattern %s", subOp, d)
+		}
+		p, err := compileLikeAnyPattern(evalCtx, string(s), b.caseInsensitive)
+		if err != nil {
+			return likeAnyBase{}, err
+		}
+		b.patterns = append(b.patterns, p)
+	}
+	return b, nil
+}
+
+func compileLikeAnyPattern(
+	evalCtx *tree.EvalContext, pattern string, caseInsensitive bool,
+) (likeAnyPattern, error) {
+	p := likeAnyPattern{matchesEmpty: strings.Trim(pattern, "%") == ""}
+	likeOpType, pat, err := colexeccmp.GetLikeOperatorType(pattern, false /* negate */)
+	if err != nil {
+		return likeAnyPattern{}, err
+	}
+	if strings.ContainsRune(pattern, '\\') {
+		// The fast paths don't handle the escape character.
+		likeOpType = colexeccmp.LikeRegexp
+	}
+	p.likeOpType = likeOpType
+	if likeOpType == colexeccmp.LikeRegexp {
+		p.re, err = tree.ConvertLikeToRegexp(evalCtx, pattern, caseInsensitive, '\\')
+		return p, err
+	}
+	if caseInsensitive {
+		pat = strings.ToUpper(pat)
+	}
+	p.pattern = []byte(pat)
+	return p, nil
+}
+
+// eval returns the result of the comparison on the row at position rowIdx.
+func (b *likeAnyBase) eval(vec coldata.Vec, rowIdx int) (res bool, isNull bool) {
+	if len(b.patterns) == 0 && !b.hasNulls {
+		// The comparison with the empty list is constant even if the value is
+		// NULL, same as in the row engine.
+		return b.all, false
+	}
+	if vec.Nulls().NullAt(rowIdx) {
+		return false, true
+	}
+	s := vec.Bytes().Get(rowIdx)
+	upper := s
+	if b.caseInsensitive {
+		upper = bytes.ToUpper(s)
+	}
+	for i := range b.patterns {
+		if matched := b.patterns[i].match(s, upper) != b.negate; matched != b.all {
+			// We have found a pattern that either matches when ANY is used
+			// or doesn't match when ALL is used.
+			return matched, false
+		}
+	}
+	if b.hasNulls {
+		return false, true
+	}
+	return b.all, false
+}
+
+// GetLikeAnyProjectionOperator returns an operator that projects the result of
+// the comparison of the String column at position colIdx with the constant
+// list of patterns (either a tuple or an array) into the Bool column at
+// position resultIdx. cmpOp must be either ANY (or SOME) or ALL, and subOp
+// must be one of LIKE, NOT LIKE, ILIKE or NOT ILIKE.
+func GetLikeAnyProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	colIdx int,
+	typ *types.T,
+	cmpOp, subOp tree.ComparisonOperator,
+	constArg tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeLikeAnyBase(evalCtx, colIdx, typ, cmpOp, subOp, constArg)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &likeAnyProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		likeAnyBase:    base,
+		allocator:      allocator,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// GetLikeAnyOperator returns an operator that selects the tuples for which the
+// comparison of the String column with the constant list of patterns
+// evaluates to true. The arguments are the same as in
+// GetLikeAnyProjectionOperator.
+func GetLikeAnyOperator(
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	colIdx int,
+	typ *types.T,
+	cmpOp, subOp tree.ComparisonOperator,
+	constArg tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeLikeAnyBase(evalCtx, colIdx, typ, cmpOp, subOp, constArg)
+	if err != nil {
+		return nil, err
+	}
+	return &likeAnySelOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		likeAnyBase:    base,
+	}, nil
+}
+
+type likeAnyProjOp struct {
+	colexecop.OneInputHelper
+	likeAnyBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &likeAnyProjOp{}
+
+func (o *likeAnyProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(o.colIdx)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(vec, rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type likeAnySelOp struct {
+	colexecop.OneInputHelper
+	likeAnyBase
+}
+
+var _ colexecop.Operator = &likeAnySelOp{}
+
+func (o *likeAnySelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		vec := batch.ColVec(o.colIdx)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(vec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(vec, i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLikeAny(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	inputTuples := colexectestutils.Tuples{
+		{"abc"}, {"xbz"}, {"ABC"}, {"bcd"}, {"a_c"}, {""}, {nil},
+	}
+	testCases := []struct {
+		expr         string
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			// Prefix, suffix and constant patterns.
+			expr: "@1 LIKE ANY '{a%,%z,bcd}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", true}, {"ABC", false}, {"bcd", true}, {"a_c", true}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			// Contains and regexp patterns.
+			expr: `@1 LIKE ANY '{%b%,"_\\_c"}'::STRING[]`,
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", true}, {"ABC", false}, {"bcd", true}, {"a_c", true}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			expr: "@1 LIKE ALL '{%b%,%c}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", false}, {"ABC", false}, {"bcd", false}, {"a_c", false}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			expr: "@1 NOT LIKE ANY '{a%,%c}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", false}, {"xbz", true}, {"ABC", true}, {"bcd", true}, {"a_c", false}, {"", true}, {nil, nil},
+			},
+		},
+		{
+			expr: "@1 NOT LIKE ALL '{a%,%c}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", false}, {"xbz", true}, {"ABC", true}, {"bcd", true}, {"a_c", false}, {"", true}, {nil, nil},
+			},
+		},
+		{
+			expr: "@1 ILIKE ANY '{A%,%Z,B_D}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", true}, {"ABC", true}, {"bcd", true}, {"a_c", true}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			expr: "@1 NOT ILIKE ALL '{abc,%%}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", false}, {"xbz", false}, {"ABC", false}, {"bcd", false}, {"a_c", false}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			// A NULL pattern results in NULL unless there is a matching
+			// pattern for ANY.
+			expr: "@1 LIKE ANY '{a%,NULL}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", nil}, {"ABC", nil}, {"bcd", nil}, {"a_c", true}, {"", nil}, {nil, nil},
+			},
+		},
+		{
+			// A NULL pattern results in NULL unless there is a non-matching
+			// pattern for ALL.
+			expr: "@1 LIKE ALL '{a%,NULL}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", nil}, {"xbz", false}, {"ABC", false}, {"bcd", false}, {"a_c", nil}, {"", false}, {nil, nil},
+			},
+		},
+		{
+			// The comparison with the empty array doesn't depend on the value.
+			expr: "@1 LIKE ANY '{}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", false}, {"xbz", false}, {"ABC", false}, {"bcd", false}, {"a_c", false}, {"", false}, {nil, false},
+			},
+		},
+		{
+			expr: "@1 LIKE ALL '{}'::STRING[]",
+			outputTuples: colexectestutils.Tuples{
+				{"abc", true}, {"xbz", true}, {"ABC", true}, {"bcd", true}, {"a_c", true}, {"", true}, {nil, true},
+			},
+		},
+	}
+
+	typs := []*types.T{types.String}
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.expr)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+func TestLikeAnySel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	typs := []*types.T{types.String}
+	inputTuples := colexectestutils.Tuples{
+		{"abc"}, {"xbz"}, {"ABC"}, {"bcd"}, {""}, {nil},
+	}
+	patterns := tree.NewDArray(types.String)
+	for _, d := range []tree.Datum{tree.NewDString("a%"), tree.NewDString("%Z")} {
+		require.NoError(t, patterns.Append(d))
+	}
+	for _, tc := range []struct {
+		cmpOp, subOp tree.ComparisonOperator
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			cmpOp:        tree.Any,
+			subOp:        tree.Like,
+			outputTuples: colexectestutils.Tuples{{"abc"}},
+		},
+		{
+			cmpOp:        tree.Any,
+			subOp:        tree.ILike,
+			outputTuples: colexectestutils.Tuples{{"abc"}, {"xbz"}, {"ABC"}},
+		},
+		{
+			cmpOp:        tree.All,
+			subOp:        tree.NotLike,
+			outputTuples: colexectestutils.Tuples{{"xbz"}, {"ABC"}, {"bcd"}, {""}},
+		},
+		{
+			cmpOp:        tree.Any,
+			subOp:        tree.NotILike,
+			outputTuples: colexectestutils.Tuples{{"abc"}, {"xbz"}, {"ABC"}, {"bcd"}, {""}},
+		},
+	} {
+		log.Infof(ctx, "%s %s", tc.subOp, tc.cmpOp)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return GetLikeAnyOperator(
+					&evalCtx, input[0], 0 /* colIdx */, typs[0], tc.cmpOp, tc.subOp, patterns,
+				)
+			})
+	}
+}
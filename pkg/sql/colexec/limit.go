@@ -31,11 +31,15 @@ type limitOp struct {
 var _ colexecop.Operator = &limitOp{}
 var _ colexecop.ClosableOperator = &limitOp{}
 
-// NewLimitOp returns a new limit operator with the given limit.
+// NewLimitOp returns a new limit operator with the given limit. If the limit is
+// zero, the input is still initialized and closed, but it is never read from.
 func NewLimitOp(input colexecop.Operator, limit uint64) colexecop.Operator {
 	c := &limitOp{
 		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
 		limit:                    limit,
+		// There is nothing to emit with the zero limit, so we short-circuit
+		// the execution of the input tree altogether.
+		done: limit == 0,
 	}
 	return c
 }
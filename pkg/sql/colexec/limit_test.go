@@ -11,12 +11,17 @@
 package colexec
 
 import (
+	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLimit(t *testing.T) {
@@ -72,3 +77,35 @@ func TestLimit(t *testing.T) {
 		})
 	}
 }
+
+// TestLimitZero verifies that the limit operator with the zero limit never
+// reads from its input, yet still initializes and closes it.
+func TestLimitZero(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	var initialized, closed bool
+	input := &colexecop.CallbackOperator{
+		InitCb: func(context.Context) {
+			initialized = true
+		},
+		NextCb: func() coldata.Batch {
+			colexecerror.InternalError(errors.AssertionFailedf("unexpectedly Next was called"))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		},
+		CloseCb: func(context.Context) error {
+			closed = true
+			return nil
+		},
+	}
+	op := NewLimitOp(input, 0 /* limit */)
+	op.Init(ctx)
+	require.True(t, initialized)
+	for i := 0; i < 2; i++ {
+		require.Zero(t, op.Next().Length())
+	}
+	require.NoError(t, op.(colexecop.Closer).Close(ctx))
+	require.True(t, closed)
+}
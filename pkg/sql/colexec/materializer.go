@@ -17,12 +17,14 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/errors"
 )
@@ -48,6 +50,11 @@ type Materializer struct {
 	// if the batch had a selection vector on top of it, the converted vectors
 	// will be "dense" and contain only tuples that were selected.
 	converter *colconv.VecToDatumConverter
+	// projection, if non-nil, contains the positions of the output columns in
+	// the batches underlying the ones returned by the input, which is a simple
+	// project operator. In such case the converter operates on the underlying
+	// batches directly.
+	projection []int
 
 	// row is the memory used for the output row.
 	row rowenc.EncDatumRow
@@ -196,12 +203,14 @@ func NewMaterializer(
 	cancelFlow func() context.CancelFunc,
 ) (*Materializer, error) {
 	m := materializerPool.Get().(*Materializer)
+	converter, projection := newMaterializerConverter(input.Root, len(typs))
 	*m = Materializer{
 		ProcessorBase: m.ProcessorBase,
 		input:         input.Root,
 		typs:          typs,
 		drainHelper:   newDrainHelper(input.StatsCollectors, input.MetadataSources),
-		converter:     colconv.NewAllVecToDatumConverter(len(typs)),
+		converter:     converter,
+		projection:    projection,
 		row:           make(rowenc.EncDatumRow, len(typs)),
 		closers:       input.ToClose,
 	}
@@ -237,6 +246,45 @@ func NewMaterializer(
 	return m, nil
 }
 
+// newMaterializerConverter returns the converter for the batches returned by
+// input. If input is a simple project operator, the converter operates on the
+// batches underlying the projecting ones in order to not go through the
+// projecting batch for each column, and the positions of the output columns
+// in those underlying batches are returned as well.
+func newMaterializerConverter(
+	input colexecop.Operator, numCols int,
+) (_ *colconv.VecToDatumConverter, projection []int) {
+	_, inputTypes, simpleProjection, ok := colexecbase.UnwrapSimpleProjectOp(input)
+	if !ok || len(simpleProjection) != numCols {
+		return colconv.NewAllVecToDatumConverter(numCols), nil
+	}
+	projection = make([]int, numCols)
+	if colexecbase.IsPermutation(len(inputTypes), simpleProjection) {
+		// All columns of the underlying batches are converted, and the
+		// output columns are simply picked in the permuted order.
+		for i, vecIdx := range simpleProjection {
+			projection[i] = int(vecIdx)
+		}
+		return colconv.NewAllVecToDatumConverter(numCols), projection
+	}
+	// The same column might be projected several times, but it is converted
+	// only once.
+	var vecIdxsToConvert util.FastIntSet
+	// The underlying batches might have more columns, but the converter only
+	// needs to handle the ones up to the last projected column.
+	var batchWidth int
+	for i, vecIdx := range simpleProjection {
+		projection[i] = int(vecIdx)
+		vecIdxsToConvert.Add(int(vecIdx))
+		if int(vecIdx) >= batchWidth {
+			batchWidth = int(vecIdx) + 1
+		}
+	}
+	return colconv.NewVecToDatumConverter(
+		batchWidth, vecIdxsToConvert.Ordered(), true, /* willRelease */
+	), projection
+}
+
 var _ execinfra.OpNode = &Materializer{}
 var _ execinfra.Processor = &Materializer{}
 var _ execinfra.Releasable = &Materializer{}
@@ -286,14 +334,22 @@ func (m *Materializer) next() rowenc.EncDatumRow {
 			return nil
 		}
 		m.curIdx = 0
-		m.converter.ConvertBatchAndDeselect(m.batch)
+		if m.projection != nil {
+			m.converter.ConvertBatchAndDeselect(colexecbase.UnwrapProjectingBatch(m.batch))
+		} else {
+			m.converter.ConvertBatchAndDeselect(m.batch)
+		}
 	}
 
 	for colIdx := range m.typs {
+		vecIdx := colIdx
+		if m.projection != nil {
+			vecIdx = m.projection[colIdx]
+		}
 		// Note that we don't need to apply the selection vector of the
 		// batch to index m.curIdx because vecToDatumConverter returns a
 		// "dense" datum column.
-		m.row[colIdx].Datum = m.converter.GetDatumColumn(colIdx)[m.curIdx]
+		m.row[colIdx].Datum = m.converter.GetDatumColumn(vecIdx)[m.curIdx]
 	}
 	m.curIdx++
 	// Note that there is no post-processing to be done in the
@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
@@ -180,6 +181,184 @@ func BenchmarkMaterializer(b *testing.B) {
 	}
 }
 
+// BenchmarkMaterializerWideProjection benchmarks the materializer on top of
+// a simple project operator which projects a half of the columns of a wide
+// input in the reversed order. The materializer either reads the columns of
+// the underlying batches directly or goes through the projecting batches.
+func BenchmarkMaterializerWideProjection(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+
+	rng, _ := randutil.NewPseudoRand()
+	const numInputCols = 64
+	typs := make([]*types.T, numInputCols)
+	for i := range typs {
+		typs[i] = types.Int
+	}
+	projection := make([]uint32, numInputCols/2)
+	for i := range projection {
+		projection[i] = uint32(numInputCols - 1 - 2*i)
+	}
+	projectedTypes := typs[:len(projection)]
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for _, colVec := range batch.ColVecs() {
+		coldatatestutils.RandomVec(coldatatestutils.RandomVecArgs{
+			Rand: rng,
+			Vec:  colVec,
+			N:    coldata.BatchSize(),
+		})
+	}
+	batch.SetLength(coldata.BatchSize())
+	nBatches := 10
+	nRows := nBatches * coldata.BatchSize()
+	for _, hideProjection := range []bool{false, true} {
+		b.Run(fmt.Sprintf("hideProjection=%t", hideProjection), func(b *testing.B) {
+			source := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
+			var input colexecop.Operator = colexecbase.NewSimpleProjectOp(source, typs, projection)
+			if hideProjection {
+				input = colexecop.NewNoop(input)
+			}
+			b.SetBytes(int64(nRows * len(projection) * int(unsafe.Sizeof(int64(0)))))
+			for i := 0; i < b.N; i++ {
+				m, err := NewMaterializer(
+					flowCtx,
+					0, /* processorID */
+					colexecargs.OpWithMetaInfo{Root: input},
+					projectedTypes,
+					nil, /* output */
+					nil, /* cancelFlow */
+				)
+				if err != nil {
+					b.Fatal(err)
+				}
+				m.Start(ctx)
+
+				foundRows := 0
+				for {
+					row, meta := m.Next()
+					if meta != nil {
+						b.Fatalf("unexpected metadata %v", meta)
+					}
+					if row == nil {
+						break
+					}
+					foundRows++
+				}
+				if foundRows != nRows {
+					b.Fatalf("expected %d rows, found %d", nRows, foundRows)
+				}
+				source.Reset(nBatches)
+			}
+		})
+	}
+}
+
+// TestMaterializerSimpleProject verifies that the materializer on top of a
+// simple project operator, which reads the columns of the batches underlying
+// the projecting ones directly, produces the same rows as when the projecting
+// batches are used. Both the arbitrary projections and the permutations of
+// all columns (for which all underlying columns are converted) are covered.
+func TestMaterializerSimpleProject(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+		EvalCtx: &evalCtx,
+	}
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Bytes, types.Decimal, types.Float, types.Bool}
+	nBatches := 3
+	for _, projection := range [][]uint32{
+		// The columns are reordered, some of them are projected several
+		// times, and some of them aren't projected at all.
+		{3, 0, 3, 1},
+		// The permutations of all columns.
+		{4, 3, 2, 1, 0},
+		{2, 0, 4, 1, 3},
+	} {
+		projectedTypes := make([]*types.T, len(projection))
+		for i, colIdx := range projection {
+			projectedTypes[i] = typs[colIdx]
+		}
+		for _, useSel := range []bool{false, true} {
+			batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+			for _, colVec := range batch.ColVecs() {
+				coldatatestutils.RandomVec(coldatatestutils.RandomVecArgs{
+					Rand:            rng,
+					Vec:             colVec,
+					N:               coldata.BatchSize(),
+					NullProbability: nullProbability,
+				})
+			}
+			batch.SetLength(coldata.BatchSize())
+			if useSel {
+				batch.SetSelection(true)
+				sel := batch.Selection()
+				n := 0
+				for i := 0; i < coldata.BatchSize(); i += 2 {
+					sel[n] = i
+					n++
+				}
+				batch.SetLength(n)
+			}
+			// makeMaterializer returns the materializer on top of the simple
+			// project operator. If hideProjection is true, the simple project
+			// operator is wrapped so that the projecting batches are used.
+			makeMaterializer := func(hideProjection bool) *Materializer {
+				var input colexecop.Operator = colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
+				input = colexecbase.NewSimpleProjectOp(input, typs, projection)
+				if hideProjection {
+					input = colexecop.NewNoop(input)
+				}
+				m, err := NewMaterializer(
+					flowCtx,
+					1, /* processorID */
+					colexecargs.OpWithMetaInfo{Root: input},
+					projectedTypes,
+					nil, /* output */
+					nil, /* cancelFlow */
+				)
+				require.NoError(t, err)
+				require.Equal(t, !hideProjection, m.projection != nil)
+				m.Start(ctx)
+				return m
+			}
+			expected, actual := makeMaterializer(true /* hideProjection */), makeMaterializer(false /* hideProjection */)
+			numRows := 0
+			for {
+				expectedRow, meta := expected.Next()
+				require.Nil(t, meta)
+				actualRow, meta := actual.Next()
+				require.Nil(t, meta)
+				if expectedRow == nil {
+					require.Nil(t, actualRow)
+					break
+				}
+				require.NotNil(t, actualRow)
+				for i := range expectedRow {
+					require.Equal(t, 0, expectedRow[i].Datum.Compare(&evalCtx, actualRow[i].Datum), "%s != %s", expectedRow, actualRow)
+				}
+				numRows++
+			}
+			require.Equal(t, nBatches*batch.Length(), numRows)
+			expected.Release()
+			actual.Release()
+		}
+	}
+}
+
 func TestMaterializerNextErrorAfterConsumerDone(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -224,6 +403,109 @@ func TestMaterializerNextErrorAfterConsumerDone(t *testing.T) {
 	)
 }
 
+// TestMaterializerDrainsMetadataFromOperatorTree verifies that the metadata
+// produced at different levels of a tree of operators is propagated as a side
+// channel to the materializer at the top of the tree, and that the data stream
+// isn't affected.
+func TestMaterializerDrainsMetadataFromOperatorTree(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+	}
+
+	typs := []*types.T{types.Int, types.Int}
+	// numDrained tracks how many times every metadata source was drained.
+	numDrained := make(map[int]int)
+	// newMetadataSource returns a metadata source that emits the given
+	// metrics, as a scan would do.
+	newMetadataSource := func(id int, rowsRead, bytesRead int64) colexecop.MetadataSource {
+		return colexectestutils.CallbackMetadataSource{DrainMetaCb: func() []execinfrapb.ProducerMetadata {
+			numDrained[id]++
+			meta := execinfrapb.GetProducerMeta()
+			meta.Metrics = execinfrapb.GetMetricsMeta()
+			meta.Metrics.RowsRead = rowsRead
+			meta.Metrics.BytesRead = bytesRead
+			return []execinfrapb.ProducerMetadata{*meta}
+		}}
+	}
+	newLeaf := func(id int, tuples colexectestutils.Tuples) colexecargs.OpWithMetaInfo {
+		return colexecargs.OpWithMetaInfo{
+			Root: colexectestutils.NewOpTestInput(testAllocator, 1 /* batchSize */, tuples, typs),
+			MetadataSources: colexecop.MetadataSources{
+				newMetadataSource(id, int64(len(tuples)), int64(16*len(tuples))),
+			},
+		}
+	}
+
+	// The tree has three levels of the operators, and the metadata is produced
+	// on all of them:
+	//
+	//   simple project (+ metadata source 4)
+	//            |
+	//    outer synchronizer
+	//        /         \
+	//  leaf 3     inner synchronizer
+	//                 /        \
+	//             leaf 1      leaf 2
+	inner := NewSerialUnorderedSynchronizer([]colexecargs.OpWithMetaInfo{
+		newLeaf(1, colexectestutils.Tuples{{1, 1}, {2, 2}}),
+		newLeaf(2, colexectestutils.Tuples{{3, 3}}),
+	})
+	outer := NewSerialUnorderedSynchronizer([]colexecargs.OpWithMetaInfo{
+		{Root: inner, MetadataSources: colexecop.MetadataSources{inner}},
+		newLeaf(3, colexectestutils.Tuples{{4, 4}, {5, 5}, {6, 6}}),
+	})
+	m, err := NewMaterializer(
+		flowCtx,
+		0, /* processorID */
+		colexecargs.OpWithMetaInfo{
+			Root: colexecbase.NewSimpleProjectOp(outer, typs, []uint32{1}),
+			MetadataSources: colexecop.MetadataSources{
+				outer, newMetadataSource(4 /* id */, 0 /* rowsRead */, 100 /* bytesRead */),
+			},
+		},
+		[]*types.T{types.Int},
+		nil, /* output */
+		nil, /* cancelFlow */
+	)
+	require.NoError(t, err)
+
+	m.Start(ctx)
+	var numRows int
+	var metrics []execinfrapb.RemoteProducerMetadata_Metrics
+	for {
+		row, meta := m.Next()
+		if meta != nil {
+			require.Nil(t, meta.Err)
+			if meta.Metrics != nil {
+				metrics = append(metrics, *meta.Metrics)
+			}
+			continue
+		}
+		if row == nil {
+			break
+		}
+		require.Len(t, row, 1)
+		numRows++
+	}
+	require.Equal(t, 6, numRows)
+	require.Equal(t, map[int]int{1: 1, 2: 1, 3: 1, 4: 1}, numDrained)
+	require.Len(t, metrics, 4)
+	var rowsRead, bytesRead int64
+	for _, metric := range metrics {
+		rowsRead += metric.RowsRead
+		bytesRead += metric.BytesRead
+	}
+	require.Equal(t, int64(6), rowsRead)
+	require.Equal(t, int64(6*16+100), bytesRead)
+}
+
 func BenchmarkColumnarizeMaterialize(b *testing.B) {
 	defer log.Scope(b).Close(b)
 	types := []*types.T{types.Int, types.Int}
@@ -32,6 +32,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
 )
 
 func createSpecForMergeJoiner(tc *joinTestCase) *execinfrapb.ProcessorSpec {
@@ -1823,6 +1824,88 @@ func TestMergeJoinerMultiBatch(t *testing.T) {
 	}
 }
 
+// TestMergeJoinerLimit verifies that the merge joiner with a limit emits
+// exactly the limit number of tuples and stops pulling from its inputs once
+// the limit is reached.
+func TestMergeJoinerLimit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	queueCfg, cleanup := colcontainerutils.NewTestingDiskQueueCfg(t, true /* inMem */)
+	defer cleanup()
+	const numInputBatches = 16
+	nTuples := coldata.BatchSize() * numInputBatches
+	for _, largeGroup := range []bool{false, true} {
+		for _, limit := range []int{1, 5, coldata.BatchSize() + 3} {
+			t.Run(fmt.Sprintf("largeGroup=%t/limit=%d", largeGroup, limit), func(t *testing.T) {
+				typs := []*types.T{types.Int}
+				cols := []coldata.Vec{testAllocator.NewMemColumn(typs[0], nTuples)}
+				keys := cols[0].Int64()
+				// If largeGroup is true, then the first two batches form a
+				// single group which needs to be buffered.
+				groupSize := 1
+				if largeGroup {
+					groupSize = 2 * coldata.BatchSize()
+				}
+				for i := range keys {
+					if i >= groupSize {
+						keys[i] = int64(i - groupSize + 1)
+					}
+				}
+				var numNextCalls [2]int
+				var inputs [2]colexecop.Operator
+				for i := range inputs {
+					source := colexectestutils.NewChunkingBatchSource(testAllocator, typs, cols, nTuples)
+					i := i
+					inputs[i] = &colexecop.CallbackOperator{
+						InitCb: source.Init,
+						NextCb: func() coldata.Batch {
+							numNextCalls[i]++
+							return source.Next()
+						},
+					}
+				}
+				mj, err := colexecjoin.NewMergeJoinOpWithLimit(
+					testAllocator, execinfra.DefaultMemoryLimit,
+					queueCfg, colexecop.NewTestingSemaphore(mjFDLimit), descpb.InnerJoin,
+					inputs[0], inputs[1], typs, typs,
+					[]execinfrapb.Ordering_Column{{ColIdx: 0, Direction: execinfrapb.Ordering_Column_ASC}},
+					[]execinfrapb.Ordering_Column{{ColIdx: 0, Direction: execinfrapb.Ordering_Column_ASC}},
+					testDiskAcc, uint64(limit),
+				)
+				require.NoError(t, err)
+				mj.Init(ctx)
+				// The first groupSize^2 output tuples have zero key, and the
+				// following tuples have the increasing keys.
+				count := 0
+				for b := mj.Next(); b.Length() != 0; b = mj.Next() {
+					outCol := b.ColVec(0).Int64()
+					for j := 0; j < b.Length(); j++ {
+						expected := int64(0)
+						if count >= groupSize*groupSize {
+							expected = int64(count - groupSize*groupSize + 1)
+						}
+						require.Equal(t, expected, outCol[j])
+						count++
+					}
+				}
+				require.Equal(t, limit, count)
+				// Make sure that the merge joiner doesn't pull from its inputs
+				// after having emitted the limit number of tuples.
+				numNextCallsAtLimit := numNextCalls
+				require.Equal(t, 0, mj.Next().Length())
+				require.Equal(t, numNextCallsAtLimit, numNextCalls)
+				// Only the batches needed to produce the limit number of tuples
+				// (plus one batch of lookahead) should have been read.
+				for _, n := range numNextCalls {
+					require.Less(t, n, numInputBatches/2)
+				}
+				require.NoError(t, mj.(colexecop.Closer).Close(ctx))
+			})
+		}
+	}
+}
+
 // TestMergeJoinerMultiBatchRuns creates one long input of a n:n join, and
 // keeps track of the expected count to make sure the join output is batched
 // correctly.
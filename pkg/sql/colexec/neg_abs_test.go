@@ -0,0 +1,249 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegAbs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	dec := func(s string) apd.Decimal {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		return *d
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "negate decimals",
+			expr: "-@1",
+			inputTuples: colexectestutils.Tuples{
+				{dec("1.50")}, {dec("-0.001")}, {dec("0")}, {dec("NaN")}, {dec("Infinity")}, {dec("-Infinity")}, {nil},
+			},
+			inputTypes: []*types.T{types.Decimal},
+			outputTuples: colexectestutils.Tuples{
+				{dec("1.50"), dec("-1.50")},
+				{dec("-0.001"), dec("0.001")},
+				{dec("0"), dec("0")},
+				{dec("NaN"), dec("-NaN")},
+				{dec("Infinity"), dec("-Infinity")},
+				{dec("-Infinity"), dec("Infinity")},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "abs decimals",
+			expr: "abs(@1)",
+			inputTuples: colexectestutils.Tuples{
+				{dec("1.50")}, {dec("-0.001")}, {dec("-1E+400")}, {dec("-Infinity")}, {nil},
+			},
+			inputTypes: []*types.T{types.Decimal},
+			outputTuples: colexectestutils.Tuples{
+				{dec("1.50"), dec("1.50")},
+				{dec("-0.001"), dec("0.001")},
+				{dec("-1E+400"), dec("1E+400")},
+				{dec("-Infinity"), dec("Infinity")},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "negate intervals",
+			expr: "-@1",
+			inputTuples: colexectestutils.Tuples{
+				{duration.MakeDuration(3600e9, 2, 1)},
+				{duration.MakeDuration(-1, 0, -14)},
+				{duration.MakeDuration(0, math.MaxInt64, math.MaxInt64)},
+				{duration.MakeDuration(0, math.MinInt64+1, math.MinInt64+1)},
+				{nil},
+			},
+			inputTypes: []*types.T{types.Interval},
+			outputTuples: colexectestutils.Tuples{
+				{duration.MakeDuration(3600e9, 2, 1), duration.MakeDuration(-3600e9, -2, -1)},
+				{duration.MakeDuration(-1, 0, -14), duration.MakeDuration(1, 0, 14)},
+				{duration.MakeDuration(0, math.MaxInt64, math.MaxInt64), duration.MakeDuration(0, math.MinInt64+1, math.MinInt64+1)},
+				{duration.MakeDuration(0, math.MinInt64+1, math.MinInt64+1), duration.MakeDuration(0, math.MaxInt64, math.MaxInt64)},
+				{nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// Negating an interval with a component that cannot be negated results in
+	// an error, same as in the row engine.
+	for _, d := range []duration.Duration{
+		duration.MakeDuration(0, 0, math.MinInt64),
+		duration.MakeDuration(0, math.MinInt64, 0),
+	} {
+		typs := []*types.T{types.Interval}
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{d}}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, "-@1", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, "interval out of range")
+
+		_, err = tree.NewTypedUnaryExpr(tree.UnaryMinus, &tree.DInterval{Duration: d}, types.Interval).Eval(&evalCtx)
+		require.EqualError(t, err, "interval out of range")
+	}
+}
+
+func TestComplement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// The bit strings of various lengths (including the empty ones and the
+	// ones that span several words) are complemented by the row engine to get
+	// the expected results.
+	rng, _ := randutil.NewPseudoRand()
+	var bitsInput, bitsOutput colexectestutils.Tuples
+	for _, bitLen := range []uint{0, 1, 7, 63, 64, 65, 128, 130} {
+		for i := 0; i < 3; i++ {
+			d := &tree.DBitArray{BitArray: bitarray.Rand(rng, bitLen)}
+			res, err := tree.NewTypedUnaryExpr(tree.UnaryComplement, d, types.VarBit).Eval(&evalCtx)
+			require.NoError(t, err)
+			bitsInput = append(bitsInput, colexectestutils.Tuple{d})
+			bitsOutput = append(bitsOutput, colexectestutils.Tuple{d, res})
+		}
+	}
+	bitsInput = append(bitsInput, colexectestutils.Tuple{nil})
+	bitsOutput = append(bitsOutput, colexectestutils.Tuple{nil, nil})
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "int64",
+			expr: "~@1",
+			inputTuples: colexectestutils.Tuples{
+				{0}, {-1}, {1}, {math.MaxInt64}, {math.MinInt64}, {nil},
+			},
+			inputTypes: []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{0, -1}, {-1, 0}, {1, -2}, {math.MaxInt64, math.MinInt64}, {math.MinInt64, math.MaxInt64}, {nil, nil},
+			},
+		},
+		{
+			// The result is INT8, so the complement of the boundary values
+			// doesn't wrap around.
+			desc: "int16",
+			expr: "~@1",
+			inputTuples: colexectestutils.Tuples{
+				{int16(0)}, {int16(math.MaxInt16)}, {int16(math.MinInt16)}, {nil},
+			},
+			inputTypes: []*types.T{types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{int16(0), -1}, {int16(math.MaxInt16), math.MinInt16}, {int16(math.MinInt16), math.MaxInt16}, {nil, nil},
+			},
+		},
+		{
+			desc: "int32",
+			expr: "~@1",
+			inputTuples: colexectestutils.Tuples{
+				{int32(5)}, {int32(math.MaxInt32)}, {int32(math.MinInt32)}, {nil},
+			},
+			inputTypes: []*types.T{types.Int4},
+			outputTuples: colexectestutils.Tuples{
+				{int32(5), -6}, {int32(math.MaxInt32), math.MinInt32}, {int32(math.MinInt32), math.MaxInt32}, {nil, nil},
+			},
+		},
+		{
+			desc: "bit",
+			expr: "~@1",
+			inputTuples: colexectestutils.Tuples{
+				{"B'0000'"}, {"B'1010'"}, {"B'1111'"}, {nil},
+			},
+			inputTypes: []*types.T{types.MakeBit(4)},
+			outputTuples: colexectestutils.Tuples{
+				{"B'0000'", "B'1111'"}, {"B'1010'", "B'0101'"}, {"B'1111'", "B'0000'"}, {nil, nil},
+			},
+		},
+		{
+			desc:         "varbit",
+			expr:         "~@1",
+			inputTuples:  bitsInput,
+			inputTypes:   []*types.T{types.VarBit},
+			outputTuples: bitsOutput,
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
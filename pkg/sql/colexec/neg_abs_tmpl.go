@@ -0,0 +1,211 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for neg_abs.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _ASSIGN is the template function for assigning the result of the operation
+// on the element of the second argument at the position given by the third
+// argument into the element of the first argument at the same position.
+func _ASSIGN(_, _, _ interface{}) {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewNegOperator returns an operator that projects the unary minus of the
+// column at position colIdx into the column at position outputIdx. Only the
+// decimal and the interval types are supported.
+func NewNegOperator(
+	allocator *colmem.Allocator, typ *types.T, colIdx int, outputIdx int, input colexecop.Operator,
+) (colexecop.Operator, error) {
+	family := typeconv.TypeFamilyToCanonicalTypeFamily(typ.Family())
+	if family != types.DecimalFamily && family != types.IntervalFamily {
+		return nil, errors.Errorf("unsupported unary minus argument type %s", typ)
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, typ, outputIdx)
+	base := negAbsOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	if family == types.DecimalFamily {
+		return &negDecimalOp{negAbsOpBase: base}, nil
+	}
+	return &negIntervalOp{negAbsOpBase: base}, nil
+}
+
+// NewComplementOperator returns an operator that projects the bitwise NOT of
+// the column at position colIdx into the column at position outputIdx. Only
+// the integer and the bit string types are supported. Same as in the row
+// engine, the result is always INT8 for the integer arguments.
+func NewComplementOperator(
+	allocator *colmem.Allocator, typ *types.T, colIdx int, outputIdx int, input colexecop.Operator,
+) (colexecop.Operator, error) {
+	outputType := types.Int
+	if typ.Family() == types.BitFamily {
+		outputType = types.VarBit
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, outputType, outputIdx)
+	base := negAbsOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	switch typ.Family() {
+	case types.IntFamily:
+		switch typ.Width() {
+		case 16:
+			return &complementInt16Op{negAbsOpBase: base}, nil
+		case 32:
+			return &complementInt32Op{negAbsOpBase: base}, nil
+		default:
+			return &complementInt64Op{negAbsOpBase: base}, nil
+		}
+	case types.BitFamily:
+		return &complementBitsOp{negAbsOpBase: base}, nil
+	}
+	return nil, errors.Errorf("unsupported bitwise NOT argument type %s", typ)
+}
+
+// newAbsOperator returns an operator that evaluates abs() builtin on the
+// decimal column at position colIdx and writes the result into the column at
+// position outputIdx.
+func newAbsOperator(
+	allocator *colmem.Allocator, colIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &absDecimalOp{
+		negAbsOpBase: negAbsOpBase{
+			OneInputHelper: colexecop.MakeOneInputHelper(input),
+			allocator:      allocator,
+			colIdx:         colIdx,
+			outputIdx:      outputIdx,
+		},
+	}
+}
+
+type negAbsOpBase struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+}
+
+// negInterval returns the negation of d. Similar to Postgres, an error is
+// raised if any of the components of d cannot be negated.
+func negInterval(d duration.Duration) duration.Duration {
+	if d.Months == math.MinInt64 || d.Days == math.MinInt64 || d.Nanos() == math.MinInt64 {
+		colexecerror.ExpectedError(tree.ErrIntervalOutOfRange)
+	}
+	d.SetNanos(-d.Nanos())
+	d.Days = -d.Days
+	d.Months = -d.Months
+	return d
+}
+
+// complementBits returns the bitwise NOT of the bit string d.
+func complementBits(d coldata.Datum) *tree.DBitArray {
+	return &tree.DBitArray{BitArray: bitarray.Not(d.(*coldataext.Datum).Datum.(*tree.DBitArray).BitArray)}
+}
+
+// {{range .}}
+
+// _OP_NAMEOp is an operator that evaluates _OP_DESCRIPTION.
+type _OP_NAMEOp struct {
+	negAbsOpBase
+}
+
+var _ colexecop.Operator = &_OP_NAMEOp{}
+
+func (o *_OP_NAMEOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(o.colIdx)
+	inputCol := inputVec._ARG_TYPE_NAME()
+	inputNulls := inputVec.Nulls()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec._TYPE_NAME()
+	outputNulls := outputVec.Nulls()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if inputVec.MaybeHasNulls() {
+				_NEG_ABS_LOOP(true)
+			} else {
+				_NEG_ABS_LOOP(false)
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
+
+// {{/*
+func _NEG_ABS_LOOP(_HAS_NULLS bool) { // */}}
+	// {{define "negAbsLoop" -}}
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		// {{if .HasNulls}}
+		if inputNulls.NullAt(rowIdx) {
+			outputNulls.SetNull(rowIdx)
+			continue
+		}
+		// {{end}}
+		// {{with .Global}}
+		_ASSIGN(outputCol, inputCol, rowIdx)
+		// {{end}}
+	}
+	// {{end}}
+	// {{/*
+}
+
+// */}}
@@ -0,0 +1,138 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/errors"
+)
+
+// notSelOp is an operator that selects the tuples for which the predicate is
+// not true. The predicate is represented by a chain of selection operators that
+// reads from the buffer, and the result is the complement of the tuples
+// selected by the predicate within the input batch.
+//
+// Note that the complement is only the correct negation of the predicate when
+// the predicate is never NULL (otherwise the tuples for which the predicate is
+// NULL would be incorrectly selected), so it is the caller's responsibility to
+// use this operator only for such predicates.
+type notSelOp struct {
+	colexecop.InitHelper
+
+	allocator *colmem.Allocator
+	buffer    *bufferOp
+	innerOp   colexecop.Operator
+
+	// origSel is a buffer used to keep track of the original selection vector
+	// of the input batch since the predicate modifies the selection vector of
+	// the batch.
+	origSel []int
+	// innerSel contains the tuples selected by the predicate.
+	innerSel []int
+}
+
+var _ colexecop.Operator = &notSelOp{}
+
+// NewNotSelOp returns an operator that selects the tuples that don't satisfy
+// the predicate which must never evaluate to NULL.
+// - buffer is a bufferOp that will return the input batch repeatedly.
+// - innerOp is the chain of selection operators (connected to buffer) that
+// evaluates the predicate.
+func NewNotSelOp(
+	allocator *colmem.Allocator, buffer colexecop.Operator, innerOp colexecop.Operator,
+) colexecop.Operator {
+	// We internally use two selection vectors.
+	allocator.AdjustMemoryUsage(int64(2 * colmem.SizeOfBatchSizeSelVector))
+	return &notSelOp{
+		allocator: allocator,
+		buffer:    buffer.(*bufferOp),
+		innerOp:   innerOp,
+	}
+}
+
+func (n *notSelOp) ChildCount(verbose bool) int {
+	return 2
+}
+
+func (n *notSelOp) Child(nth int, verbose bool) execinfra.OpNode {
+	switch nth {
+	case 0:
+		return n.buffer
+	case 1:
+		return n.innerOp
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("invalid idx %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (n *notSelOp) Init(ctx context.Context) {
+	if !n.InitHelper.Init(ctx) {
+		return
+	}
+	n.innerOp.Init(n.Ctx)
+}
+
+func (n *notSelOp) Next() coldata.Batch {
+	for {
+		n.buffer.advance()
+		batch := n.buffer.batch
+		origLen := batch.Length()
+		if origLen == 0 {
+			return coldata.ZeroBatch
+		}
+		origHasSel := batch.Selection() != nil
+		if origHasSel {
+			n.origSel = colexecutils.EnsureSelectionVectorLength(n.origSel, origLen)
+			copy(n.origSel, batch.Selection())
+		}
+
+		innerBatch := n.innerOp.Next()
+		innerLen := innerBatch.Length()
+		if innerLen == origLen {
+			// All tuples have been selected by the predicate, so none of them
+			// are selected by its negation.
+			continue
+		}
+		n.innerSel = colexecutils.EnsureSelectionVectorLength(n.innerSel, innerLen)
+		if innerLen > 0 {
+			copy(n.innerSel, innerBatch.Selection()[:innerLen])
+		}
+
+		// Set the batch up to contain only the tuples that haven't been
+		// selected by the predicate. Note that we rely on the assumption that
+		// the selection vectors are increasing sequences.
+		batch.SetSelection(true)
+		sel := batch.Selection()
+		var resultLen, innerIdx int
+		for i := 0; i < origLen; i++ {
+			rowIdx := i
+			if origHasSel {
+				rowIdx = n.origSel[i]
+			}
+			if innerIdx < innerLen && n.innerSel[innerIdx] == rowIdx {
+				innerIdx++
+				continue
+			}
+			sel[resultLen] = rowIdx
+			resultLen++
+		}
+		batch.SetLength(resultLen)
+		return batch
+	}
+}
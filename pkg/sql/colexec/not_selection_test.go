@@ -0,0 +1,100 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestNotSelection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	typs := []*types.T{types.Int, types.Bool}
+	tuples := colexectestutils.Tuples{
+		{0, true}, {6, false}, {nil, true}, {3, nil}, {nil, nil}, {10, false}, {-1, true},
+	}
+	for _, tc := range []struct {
+		filter   string
+		expected colexectestutils.Tuples
+	}{
+		{
+			// The tuples for which the predicate is NULL must not be selected.
+			filter:   "NOT (@1 > 5)",
+			expected: colexectestutils.Tuples{{0, true}, {3, nil}, {-1, true}},
+		},
+		{
+			filter:   "NOT @2",
+			expected: colexectestutils.Tuples{{6, false}, {10, false}},
+		},
+		{
+			// false AND NULL is false, so the negation is true.
+			filter:   "NOT (@1 > 5 AND @2)",
+			expected: colexectestutils.Tuples{{0, true}, {6, false}, {3, nil}, {10, false}, {-1, true}},
+		},
+		{
+			filter:   "NOT (@1 > 5 AND @2 IS NULL)",
+			expected: colexectestutils.Tuples{{0, true}, {6, false}, {nil, true}, {3, nil}, {10, false}, {-1, true}},
+		},
+		{
+			// The predicates below are never NULL, so their negations are
+			// evaluated as the complements of the selected tuples.
+			filter:   "NOT (@1 IS NULL)",
+			expected: colexectestutils.Tuples{{0, true}, {6, false}, {3, nil}, {10, false}, {-1, true}},
+		},
+		{
+			filter:   "NOT (@1 IS NULL OR @2 IS NULL)",
+			expected: colexectestutils.Tuples{{0, true}, {6, false}, {10, false}, {-1, true}},
+		},
+		{
+			filter:   "NOT (@2 IS DISTINCT FROM false)",
+			expected: colexectestutils.Tuples{{6, false}, {10, false}},
+		},
+		{
+			filter:   "NOT (@1 IS NULL OR @2 IS NOT NULL)",
+			expected: colexectestutils.Tuples{{3, nil}},
+		},
+		{
+			filter:   "NOT (@1 < 5) AND NOT (@2 IS NULL)",
+			expected: colexectestutils.Tuples{{6, false}, {10, false}},
+		},
+		{
+			filter:   "NOT (@1 < 5) OR NOT (@2 IS NOT NULL)",
+			expected: colexectestutils.Tuples{{6, false}, {3, nil}, {nil, nil}, {10, false}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.filter)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return createTestFilterer(ctx, flowCtx, input[0], typs, tc.filter)
+			})
+	}
+}
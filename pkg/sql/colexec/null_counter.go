@@ -0,0 +1,85 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+)
+
+// NullCounter is an Operator that passes all batches from its input through
+// unchanged while counting the number of NULL values in each of the columns
+// across the whole stream (this is needed in order to compute the fraction of
+// NULL values when collecting table statistics). The counts, together with the
+// number of rows, are emitted as SamplerProgress metadata when the operator is
+// drained.
+//
+// Note that the metadata of the input is not propagated, so the input has to
+// be drained separately if it is a MetadataSource.
+type NullCounter struct {
+	colexecop.OneInputHelper
+
+	numRows    uint64
+	nullCounts []int64
+}
+
+var _ colexecop.DrainableOperator = &NullCounter{}
+
+// NewNullCounter returns a new NullCounter that counts the NULL values in the
+// first numCols columns of the input.
+func NewNullCounter(input colexecop.Operator, numCols int) *NullCounter {
+	return &NullCounter{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		nullCounts:     make([]int64, numCols),
+	}
+}
+
+// Next implements the colexecop.Operator interface.
+func (c *NullCounter) Next() coldata.Batch {
+	b := c.Input.Next()
+	n := b.Length()
+	if n == 0 {
+		return b
+	}
+	c.numRows += uint64(n)
+	sel := b.Selection()
+	for colIdx := range c.nullCounts {
+		nulls := b.ColVec(colIdx).Nulls()
+		if !nulls.MaybeHasNulls() {
+			// There are definitely no NULL values in this column, so we can
+			// skip the counting.
+			continue
+		}
+		if sel != nil {
+			var numNulls int64
+			for _, i := range sel[:n] {
+				if nulls.NullAt(i) {
+					numNulls++
+				}
+			}
+			c.nullCounts[colIdx] += numNulls
+		} else {
+			c.nullCounts[colIdx] += int64(nulls.NullCount(n))
+		}
+	}
+	return b
+}
+
+// DrainMeta implements the colexecop.MetadataSource interface.
+func (c *NullCounter) DrainMeta() []execinfrapb.ProducerMetadata {
+	return []execinfrapb.ProducerMetadata{{
+		SamplerProgress: &execinfrapb.RemoteProducerMetadata_SamplerProgress{
+			RowsProcessed: c.numRows,
+			NullCounts:    c.nullCounts,
+		},
+	}}
+}
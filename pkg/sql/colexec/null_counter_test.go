@@ -0,0 +1,73 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullCounter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	// The columns are an all-NULL column, a column without NULLs, and a
+	// column with random NULLs.
+	typs := []*types.T{types.Int, types.Bytes, types.Int}
+	tuples := make(colexectestutils.Tuples, 1+rng.Intn(3*coldata.BatchSize()))
+	expected := []int64{int64(len(tuples)), 0, 0}
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{nil, "a", i}
+		if rng.Float64() < nullProbability {
+			tuples[i][2] = nil
+			expected[2]++
+		}
+	}
+
+	var counters []*NullCounter
+	// We're omitting all nulls injection test because the counts would be
+	// different.
+	colexectestutils.RunTestsWithoutAllNullsInjection(
+		t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs},
+		tuples, colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			c := NewNullCounter(input[0], len(typs))
+			counters = append(counters, c)
+			return c, nil
+		},
+	)
+	// The last operator is created for the random nulls injection test in
+	// which the input tuples are modified.
+	counters = counters[:len(counters)-1]
+	numChecked := 0
+	for _, c := range counters {
+		meta := c.DrainMeta()
+		require.Len(t, meta, 1)
+		progress := meta[0].SamplerProgress
+		require.NotNil(t, progress)
+		if progress.RowsProcessed != uint64(len(tuples)) {
+			// Some of the test runs don't consume the whole input.
+			continue
+		}
+		require.Equal(t, expected, progress.NullCounts)
+		numChecked++
+	}
+	require.NotZero(t, numChecked)
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestNullIfOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		desc     string
+		tuples   colexectestutils.Tuples
+		typ      *types.T
+		sentinel interface{}
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc:     "int sentinel present",
+			tuples:   colexectestutils.Tuples{{1}, {-1}, {nil}, {3}, {-1}},
+			typ:      types.Int,
+			sentinel: int64(-1),
+			expected: colexectestutils.Tuples{{1}, {nil}, {nil}, {3}, {nil}},
+		},
+		{
+			desc:     "int sentinel absent",
+			tuples:   colexectestutils.Tuples{{1}, {2}, {nil}, {3}},
+			typ:      types.Int,
+			sentinel: int64(-1),
+			expected: colexectestutils.Tuples{{1}, {2}, {nil}, {3}},
+		},
+		{
+			desc:     "empty string sentinel present",
+			tuples:   colexectestutils.Tuples{{""}, {"a"}, {nil}, {""}, {"bc"}},
+			typ:      types.String,
+			sentinel: []byte(""),
+			expected: colexectestutils.Tuples{{nil}, {"a"}, {nil}, {nil}, {"bc"}},
+		},
+		{
+			desc:     "string sentinel absent",
+			tuples:   colexectestutils.Tuples{{"a"}, {nil}, {"NA "}},
+			typ:      types.String,
+			sentinel: []byte("NA"),
+			expected: colexectestutils.Tuples{{"a"}, {nil}, {"NA "}},
+		},
+		{
+			// The decimals are compared by value rather than by their
+			// representation.
+			desc:     "decimal sentinel present",
+			tuples:   colexectestutils.Tuples{{*apd.New(10, -1)}, {*apd.New(100, -2)}, {*apd.New(15, -1)}},
+			typ:      types.Decimal,
+			sentinel: *apd.New(1, 0),
+			expected: colexectestutils.Tuples{{nil}, {nil}, {*apd.New(15, -1)}},
+		},
+		{
+			// Same as in the row engine, NaN is equal to itself.
+			desc:     "float NaN sentinel present",
+			tuples:   colexectestutils.Tuples{{math.NaN()}, {1.5}, {nil}},
+			typ:      types.Float,
+			sentinel: math.NaN(),
+			expected: colexectestutils.Tuples{{nil}, {1.5}, {nil}},
+		},
+		{
+			desc:     "bool sentinel present",
+			tuples:   colexectestutils.Tuples{{true}, {false}, {nil}},
+			typ:      types.Bool,
+			sentinel: false,
+			expected: colexectestutils.Tuples{{true}, {nil}, {nil}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				op, err := NewNullIfOp(testAllocator, inputs[0], tc.typ, 0 /* colIdx */, tc.sentinel, 1 /* outputIdx */)
+				if err != nil {
+					return nil, err
+				}
+				// We will project out the input column in order to have test
+				// cases be less verbose.
+				return colexecbase.NewSimpleProjectOp(op, []*types.T{tc.typ, tc.typ}, []uint32{1}), nil
+			})
+	}
+}
+
+func TestNullIfWithConstantSentinel(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	for _, tc := range []struct {
+		tuples     colexectestutils.Tuples
+		renderExpr string
+		inputTypes []*types.T
+		expected   colexectestutils.Tuples
+	}{
+		{
+			tuples:     colexectestutils.Tuples{{1}, {0}, {nil}, {3}},
+			renderExpr: "NULLIF(@1, 0)",
+			inputTypes: []*types.T{types.Int},
+			expected:   colexectestutils.Tuples{{1}, {nil}, {nil}, {3}},
+		},
+		{
+			tuples:     colexectestutils.Tuples{{1, 2}, {1, 1}, {nil, 3}},
+			renderExpr: "NULLIF(@1 + @2, 2)",
+			inputTypes: []*types.T{types.Int, types.Int},
+			expected:   colexectestutils.Tuples{{3}, {nil}, {nil}},
+		},
+		{
+			tuples:     colexectestutils.Tuples{{"a"}, {""}, {nil}},
+			renderExpr: "NULLIF(@1, '')",
+			inputTypes: []*types.T{types.String},
+			expected:   colexectestutils.Tuples{{"a"}, {nil}, {nil}},
+		},
+	} {
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier,
+			func(inputs []colexecop.Operator) (colexecop.Operator, error) {
+				op, err := colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, inputs[0], tc.inputTypes, tc.renderExpr,
+					false /* canFallbackToRowexec */, testMemAcc,
+				)
+				if err != nil {
+					return nil, err
+				}
+				// NULLIF is of the type of its first argument.
+				outputTypes := append(tc.inputTypes[:len(tc.inputTypes):len(tc.inputTypes)], tc.inputTypes[0])
+				return colexecbase.NewSimpleProjectOp(op, outputTypes, []uint32{uint32(len(tc.inputTypes))}), nil
+			})
+	}
+}
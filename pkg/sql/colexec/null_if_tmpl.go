@@ -0,0 +1,171 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for null_if.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ coldataext.Datum
+	_ duration.Duration
+	_ json.JSON
+	_ tree.AggType
+)
+
+// {{/*
+
+// Declarations to make the template compile properly.
+
+// _GOTYPE is the template variable.
+type _GOTYPE interface{}
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+// _ASSIGN_EQ is the template equality function for assigning the first input
+// to the result of the second input == the third input.
+func _ASSIGN_EQ(_, _, _, _, _, _ string) bool {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// NewNullIfOp creates a new operator that projects the column of type t at
+// index colIdx into outputIdx, replacing the values equal to the constant
+// sentinel with NULL. This is NULLIF(x, <sentinel>) which is used, for
+// example, to convert the sentinel values (like an empty string) of the
+// imported data into NULLs.
+func NewNullIfOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	t *types.T,
+	colIdx int,
+	sentinel interface{},
+	outputIdx int,
+) (colexecop.Operator, error) {
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, t, outputIdx)
+	base := nullIfOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			return &nullIf_TYPEOp{
+				nullIfOpBase: base,
+				sentinel:     sentinel.(_GOTYPE),
+			}, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unsupported NULLIF type %s", t.Name())
+}
+
+type nullIfOpBase struct {
+	colexecop.OneInputHelper
+
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+// nullIf_TYPEOp sets NULL on the rows where the input column is equal to the
+// constant sentinel and copies the value otherwise.
+type nullIf_TYPEOp struct {
+	nullIfOpBase
+	sentinel _GOTYPE
+}
+
+var _ colexecop.Operator = &nullIf_TYPEOp{}
+
+func (o *nullIf_TYPEOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	vec := batch.ColVec(o.colIdx)
+	nulls, col := vec.Nulls(), vec.TemplateType()
+	hasNulls := nulls.MaybeHasNulls()
+	outputVec := batch.ColVec(o.outputIdx)
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.TemplateType()
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputNulls.UnsetNulls()
+	}
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if hasNulls && nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				v := col.Get(rowIdx)
+				var isSentinel bool
+				_ASSIGN_EQ(isSentinel, v, o.sentinel, _, col, _)
+				if isSentinel {
+					outputNulls.SetNull(rowIdx)
+				} else {
+					execgen.SET(outputCol, rowIdx, v)
+				}
+			}
+		},
+	)
+	return batch
+}
+
+// {{end}}
+// {{end}}
@@ -18,7 +18,7 @@ import (
 // offsetOp is an operator that implements offset, returning everything
 // after the first n tuples in its input.
 type offsetOp struct {
-	colexecop.OneInputHelper
+	colexecop.OneInputInitCloserHelper
 
 	offset uint64
 
@@ -27,12 +27,13 @@ type offsetOp struct {
 }
 
 var _ colexecop.Operator = &offsetOp{}
+var _ colexecop.ClosableOperator = &offsetOp{}
 
 // NewOffsetOp returns a new offset operator with the given offset.
 func NewOffsetOp(input colexecop.Operator, offset uint64) colexecop.Operator {
 	return &offsetOp{
-		OneInputHelper: colexecop.MakeOneInputHelper(input),
-		offset:         offset,
+		OneInputInitCloserHelper: colexecop.MakeOneInputInitCloserHelper(input),
+		offset:                   offset,
 	}
 }
 
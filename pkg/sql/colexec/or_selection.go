@@ -0,0 +1,185 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/errors"
+)
+
+// orSelOp is an operator that selects the tuples for which at least one of the
+// two predicates is true. Each predicate is represented by a chain of
+// selection operators that reads from the buffer.
+//
+// First, the left predicate is evaluated on all tuples of the input batch.
+// Then, the right predicate is evaluated only on the tuples that weren't
+// selected by the left one (this also provides the short-circuiting behavior,
+// the same as in the row engine). Finally, the union of the selected tuples is
+// computed by merging two selection vectors (which are disjoint and sorted in
+// increasing order).
+type orSelOp struct {
+	colexecop.InitHelper
+
+	allocator *colmem.Allocator
+	buffer    *bufferOp
+	leftOp    colexecop.Operator
+	rightOp   colexecop.Operator
+
+	// origSel is a buffer used to keep track of the original selection vector
+	// of the input batch since the predicates modify the selection vector of
+	// the batch.
+	origSel []int
+	// leftSel contains the tuples selected by the left predicate.
+	leftSel []int
+	// rightSel contains the tuples selected by the right predicate.
+	rightSel []int
+}
+
+var _ colexecop.Operator = &orSelOp{}
+
+// NewOrSelOp returns an operator that selects the tuples that satisfy at least
+// one of the two predicates.
+// - buffer is a bufferOp that will return the input batch repeatedly.
+// - leftOp and rightOp are the chains of selection operators (connected to
+// buffer) that evaluate the left and the right predicates, respectively.
+func NewOrSelOp(
+	allocator *colmem.Allocator, buffer colexecop.Operator, leftOp, rightOp colexecop.Operator,
+) colexecop.Operator {
+	// We internally use three selection vectors.
+	allocator.AdjustMemoryUsage(int64(3 * colmem.SizeOfBatchSizeSelVector))
+	return &orSelOp{
+		allocator: allocator,
+		buffer:    buffer.(*bufferOp),
+		leftOp:    leftOp,
+		rightOp:   rightOp,
+	}
+}
+
+func (o *orSelOp) ChildCount(verbose bool) int {
+	return 3
+}
+
+func (o *orSelOp) Child(nth int, verbose bool) execinfra.OpNode {
+	switch nth {
+	case 0:
+		return o.buffer
+	case 1:
+		return o.leftOp
+	case 2:
+		return o.rightOp
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("invalid idx %d", nth))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+func (o *orSelOp) Init(ctx context.Context) {
+	if !o.InitHelper.Init(ctx) {
+		return
+	}
+	o.leftOp.Init(o.Ctx)
+	o.rightOp.Init(o.Ctx)
+}
+
+func (o *orSelOp) Next() coldata.Batch {
+	for {
+		o.buffer.advance()
+		batch := o.buffer.batch
+		origLen := batch.Length()
+		if origLen == 0 {
+			return coldata.ZeroBatch
+		}
+		origHasSel := batch.Selection() != nil
+		if origHasSel {
+			o.origSel = colexecutils.EnsureSelectionVectorLength(o.origSel, origLen)
+			copy(o.origSel, batch.Selection())
+		}
+
+		leftBatch := o.leftOp.Next()
+		leftLen := leftBatch.Length()
+		if leftLen == origLen {
+			// All tuples have been selected by the left predicate, so there is
+			// no need to evaluate the right one.
+			return leftBatch
+		}
+		o.leftSel = colexecutils.EnsureSelectionVectorLength(o.leftSel, leftLen)
+		if leftLen > 0 {
+			copy(o.leftSel, leftBatch.Selection()[:leftLen])
+		}
+
+		// Set the batch up to contain only the tuples that haven't been
+		// selected by the left predicate. Note that we rely on the assumption
+		// that the selection vectors are increasing sequences.
+		batch.SetSelection(true)
+		sel := batch.Selection()
+		var remainingLen, leftIdx int
+		for i := 0; i < origLen; i++ {
+			rowIdx := i
+			if origHasSel {
+				rowIdx = o.origSel[i]
+			}
+			if leftIdx < leftLen && o.leftSel[leftIdx] == rowIdx {
+				leftIdx++
+				continue
+			}
+			sel[remainingLen] = rowIdx
+			remainingLen++
+		}
+		batch.SetLength(remainingLen)
+		o.buffer.rewind()
+		rightBatch := o.rightOp.Next()
+		rightLen := rightBatch.Length()
+		if rightLen == remainingLen {
+			// All remaining tuples have been selected by the right predicate,
+			// so we need to restore the original state of the batch.
+			batch.SetSelection(origHasSel)
+			if origHasSel {
+				copy(batch.Selection(), o.origSel)
+			}
+			batch.SetLength(origLen)
+			return batch
+		}
+		o.rightSel = colexecutils.EnsureSelectionVectorLength(o.rightSel, rightLen)
+		if rightLen > 0 {
+			copy(o.rightSel, rightBatch.Selection()[:rightLen])
+		}
+
+		// Merge two disjoint increasing sequences into the selection vector of
+		// the batch.
+		batch.SetSelection(true)
+		sel = batch.Selection()
+		var rightIdx, resultLen int
+		leftIdx = 0
+		for leftIdx < leftLen && rightIdx < rightLen {
+			if o.leftSel[leftIdx] < o.rightSel[rightIdx] {
+				sel[resultLen] = o.leftSel[leftIdx]
+				leftIdx++
+			} else {
+				sel[resultLen] = o.rightSel[rightIdx]
+				rightIdx++
+			}
+			resultLen++
+		}
+		resultLen += copy(sel[resultLen:], o.leftSel[leftIdx:leftLen])
+		resultLen += copy(sel[resultLen:], o.rightSel[rightIdx:rightLen])
+		if resultLen > 0 {
+			batch.SetLength(resultLen)
+			return batch
+		}
+	}
+}
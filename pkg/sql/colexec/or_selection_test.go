@@ -0,0 +1,178 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestFilterer plans the vectorized filterer with the given filter on
+// top of input.
+func createTestFilterer(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	input colexecop.Operator,
+	typs []*types.T,
+	filter string,
+) (colexecop.Operator, error) {
+	spec := &execinfrapb.ProcessorSpec{
+		Input: []execinfrapb.InputSyncSpec{{ColumnTypes: typs}},
+		Core: execinfrapb.ProcessorCoreUnion{
+			Filterer: &execinfrapb.FiltererSpec{
+				Filter: execinfrapb.Expression{Expr: filter},
+			},
+		},
+		ResultTypes: typs,
+	}
+	args := &colexecargs.NewColOperatorArgs{
+		Spec:                spec,
+		Inputs:              []colexecargs.OpWithMetaInfo{{Root: input}},
+		StreamingMemAccount: testMemAcc,
+	}
+	result, err := colexecargs.TestNewColOperator(ctx, flowCtx, args)
+	if err != nil {
+		return nil, err
+	}
+	return result.Root, nil
+}
+
+func TestOrSelOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	typs := []*types.T{types.Int, types.Int}
+	tuples := colexectestutils.Tuples{
+		{0, 0}, {1, 5}, {6, 1}, {7, 9}, {nil, 2}, {3, nil}, {nil, nil}, {10, 10}, {-1, 4},
+	}
+	for _, tc := range []struct {
+		filter   string
+		expected colexectestutils.Tuples
+	}{
+		{
+			filter:   "@1 > 5 OR @2 < 3",
+			expected: colexectestutils.Tuples{{0, 0}, {6, 1}, {7, 9}, {nil, 2}, {10, 10}},
+		},
+		{
+			// The right side must not be evaluated on the tuples for which
+			// the left side is true, so there is no division by zero.
+			filter:   "@1 = 0 OR 10 / @1 > 2",
+			expected: colexectestutils.Tuples{{0, 0}, {1, 5}, {3, nil}},
+		},
+		{
+			// Neither side appends any columns to the batch.
+			filter:   "@1 IS NULL OR @2 IS NULL",
+			expected: colexectestutils.Tuples{{nil, 2}, {3, nil}, {nil, nil}},
+		},
+		{
+			// The left side selects all tuples with non-NULL first column.
+			filter:   "@1 > -100 OR @2 < 3",
+			expected: colexectestutils.Tuples{{0, 0}, {1, 5}, {6, 1}, {7, 9}, {nil, 2}, {3, nil}, {10, 10}, {-1, 4}},
+		},
+		{
+			// The right side selects all remaining tuples with non-NULL
+			// second column.
+			filter:   "@2 < 100 OR @1 IS NULL",
+			expected: colexectestutils.Tuples{{0, 0}, {1, 5}, {6, 1}, {7, 9}, {nil, 2}, {nil, nil}, {10, 10}, {-1, 4}},
+		},
+		{
+			filter:   "@1 = 1 OR @1 = 7 OR @2 = 0",
+			expected: colexectestutils.Tuples{{0, 0}, {1, 5}, {7, 9}},
+		},
+		{
+			filter:   "(@1 > 5 OR @2 < 3) AND @1 < 10",
+			expected: colexectestutils.Tuples{{0, 0}, {6, 1}, {7, 9}},
+		},
+		{
+			filter:   "@1 > 5 AND (@2 < 3 OR @2 > 9)",
+			expected: colexectestutils.Tuples{{6, 1}, {10, 10}},
+		},
+		{
+			filter:   "(@1 > 5 AND @2 < 5) OR (@1 < 2 AND @2 > 2)",
+			expected: colexectestutils.Tuples{{1, 5}, {6, 1}, {-1, 4}},
+		},
+	} {
+		log.Infof(ctx, "%s", tc.filter)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return createTestFilterer(ctx, flowCtx, input[0], typs, tc.filter)
+			})
+	}
+}
+
+func BenchmarkOrSelOp(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	rng, _ := randutil.NewPseudoRand()
+	typs := []*types.T{types.Int, types.Int}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	for _, vec := range batch.ColVecs() {
+		col := vec.Int64()
+		for i := 0; i < coldata.BatchSize(); i++ {
+			col[i] = rng.Int63n(1000)
+		}
+	}
+	batch.SetLength(coldata.BatchSize())
+	for _, tc := range []struct {
+		name   string
+		filter string
+	}{
+		{name: "highlySelective", filter: "@1 < 10 OR @2 < 10"},
+		{name: "lowlySelective", filter: "@1 < 900 OR @2 < 900"},
+		{name: "mixed", filter: "@1 < 10 OR @2 < 900"},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+			op, err := createTestFilterer(ctx, flowCtx, source, typs, tc.filter)
+			require.NoError(b, err)
+			op.Init(ctx)
+			b.SetBytes(int64(len(typs) * 8 * coldata.BatchSize()))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				op.Next()
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestOverlay(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:         "constant start",
+			expr:         "overlay(@1, 'CAT', 2)",
+			inputTuples:  colexectestutils.Tuples{{"doggie"}, {"d"}, {""}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"doggie", "dCATie"}, {"d", "dCAT"}, {"", "CAT"}, {nil, nil}},
+		},
+		{
+			desc:         "insert at the start",
+			expr:         "overlay(@1 PLACING 'CAT' FROM 1)",
+			inputTuples:  colexectestutils.Tuples{{"doggie"}, {"do"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"doggie", "CATgie"}, {"do", "CAT"}},
+		},
+		{
+			desc:         "past the end",
+			expr:         "overlay(@1 PLACING 'CAT' FROM 10 FOR 2)",
+			inputTuples:  colexectestutils.Tuples{{"doggie"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"doggie", "doggieCAT"}},
+		},
+		{
+			desc:         "zero and negative length",
+			expr:         "overlay(@1 PLACING 'XX' FROM 3 FOR 0) || ' ' || overlay(@1 PLACING 'XX' FROM 3 FOR -1)",
+			inputTuples:  colexectestutils.Tuples{{"doggie"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"doggie", "doXXggie doXXoggie"}},
+		},
+		{
+			desc: "column arguments",
+			expr: "overlay(@1, @2, @3, @4)",
+			inputTuples: colexectestutils.Tuples{
+				{"doggie", "CAT", 2, 3},
+				{"doggie", "", 1, 6},
+				{"doggie", "CAT", 2, 9223372036854775807},
+				{nil, "CAT", 2, 3},
+				{"doggie", nil, 2, 3},
+				{"doggie", "CAT", nil, 3},
+				{"doggie", "CAT", 2, nil},
+			},
+			inputTypes: []*types.T{types.String, types.String, types.Int, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"doggie", "CAT", 2, 3, "dCATie"},
+				{"doggie", "", 1, 6, ""},
+				// The end position overflows, so it is treated as the start
+				// of the string.
+				{"doggie", "CAT", 2, 9223372036854775807, "dCATdoggie"},
+				{nil, "CAT", 2, 3, nil},
+				{"doggie", nil, 2, 3, nil},
+				{"doggie", "CAT", nil, 3, nil},
+				{"doggie", "CAT", 2, nil, nil},
+			},
+		},
+		{
+			desc:         "narrow int arguments",
+			expr:         "overlay(@1, 'CAT', @2) || overlay(@1, 'CAT', @2, @3)",
+			inputTuples:  colexectestutils.Tuples{{"doggie", 2, 1}},
+			inputTypes:   []*types.T{types.String, types.Int2, types.Int4},
+			outputTuples: colexectestutils.Tuples{{"doggie", 2, 1, "dCATiedCATggie"}},
+		},
+		{
+			desc:         "multibyte",
+			expr:         "overlay(@1, 'ÉÉ', 2, 1)",
+			inputTuples:  colexectestutils.Tuples{{"héllo"}, {"日本語"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"héllo", "hÉÉllo"}, {"日本語", "日ÉÉ語"}},
+		},
+		{
+			// Invalid UTF-8 bytes are replaced with utf8.RuneError, same as in
+			// the row engine.
+			desc:         "invalid utf-8",
+			expr:         "overlay(@1, 'X', 3)",
+			inputTuples:  colexectestutils.Tuples{{"a\xffbc"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"a\xffbc", "a�Xc"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for overlay.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _START_WIDTH is the template variable.
+const _START_WIDTH = 0
+
+// _LENGTH_WIDTH is the template variable.
+const _LENGTH_WIDTH = 0
+
+// */}}
+
+// newOverlayOperator returns an operator that evaluates overlay() builtin. The
+// arguments are expected at positions argumentCols: the input and the overlay
+// value Bytes columns, the start position Int column and, optionally, the
+// length Int column. If the length is omitted, the number of characters in the
+// overlay value is used.
+func newOverlayOperator(
+	allocator *colmem.Allocator,
+	typs []*types.T,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := overlayOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+	startType := typs[argumentCols[2]]
+	if startType.Family() != types.IntFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("non-int start argument type %s", startType))
+	}
+	if len(argumentCols) == 3 {
+		switch startType.Width() {
+		// {{range $startWidth, $lengthWidths := .}}
+		case _START_WIDTH:
+			return &overlay_StartTypeOp{overlayOpBase: base}
+			// {{end}}
+		}
+		colexecerror.InternalError(errors.AssertionFailedf("unsupported overlay argument type: %s", startType))
+	}
+	lengthType := typs[argumentCols[3]]
+	if lengthType.Family() != types.IntFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("non-int length argument type %s", lengthType))
+	}
+	switch startType.Width() {
+	// {{range $startWidth, $lengthWidths := .}}
+	case _START_WIDTH:
+		switch lengthType.Width() {
+		// {{range $lengthWidth := $lengthWidths}}
+		case _LENGTH_WIDTH:
+			return &overlay_StartType_LengthTypeOp{overlayOpBase: base}
+			// {{end}}
+		}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported overlay argument types: %s %s", startType, lengthType))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type overlayOpBase struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	outputIdx    int
+	// scratch is the buffer the results are written into before being set
+	// into the output vector. It is reused across rows and batches.
+	scratch []byte
+}
+
+// appendOverlay appends to dst the result of replacing size characters of s,
+// starting at the character with (1-based) index pos, with to. It matches the
+// overlay() builtin of the row engine, including the replacement of invalid
+// UTF-8 bytes of s with utf8.RuneError.
+func appendOverlay(dst, s, to []byte, pos, size int) []byte {
+	if pos < 1 {
+		colexecerror.ExpectedError(pgerror.Newf(
+			pgcode.InvalidParameterValue, "non-positive substring length not allowed: %d", pos,
+		))
+	}
+	pos--
+	numRunes := utf8.RuneCount(s)
+	if pos > numRunes {
+		pos = numRunes
+	}
+	after := pos + size
+	if after < 0 {
+		after = 0
+	} else if after > numRunes {
+		after = numRunes
+	}
+	dst = appendRuneRange(dst, s, 0 /* start */, pos)
+	dst = append(dst, to...)
+	return appendRuneRange(dst, s, after, numRunes)
+}
+
+// appendRuneRange appends the characters of s with indices in [start, end)
+// to dst.
+func appendRuneRange(dst, s []byte, start, end int) []byte {
+	for i, runeIdx := 0, 0; i < len(s) && runeIdx < end; runeIdx++ {
+		if c := s[i]; c < utf8.RuneSelf {
+			if runeIdx >= start {
+				dst = append(dst, c)
+			}
+			i++
+			continue
+		}
+		r, width := utf8.DecodeRune(s[i:])
+		if runeIdx >= start {
+			dst = appendRune(dst, r)
+		}
+		i += width
+	}
+	return dst
+}
+
+// {{range $startWidth, $lengthWidths := .}}
+
+// overlay_StartTypeOp is an operator that evaluates overlay() builtin without
+// the length argument.
+type overlay_StartTypeOp struct {
+	overlayOpBase
+}
+
+var _ colexecop.Operator = &overlay_StartTypeOp{}
+
+func (o *overlay_StartTypeOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputCol := batch.ColVec(o.argumentCols[0]).Bytes()
+	toCol := batch.ColVec(o.argumentCols[1]).Bytes()
+	startCol := batch.ColVec(o.argumentCols[2])._StartType()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if o.hasNullArgument(batch, rowIdx) {
+					outputVec.Nulls().SetNull(rowIdx)
+					continue
+				}
+				to := toCol.Get(rowIdx)
+				o.scratch = appendOverlay(
+					o.scratch[:0], inputCol.Get(rowIdx), to, int(startCol[rowIdx]), utf8.RuneCount(to),
+				)
+				outputCol.Set(rowIdx, o.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{range $lengthWidth := $lengthWidths}}
+
+// overlay_StartType_LengthTypeOp is an operator that evaluates overlay()
+// builtin with the length argument.
+type overlay_StartType_LengthTypeOp struct {
+	overlayOpBase
+}
+
+var _ colexecop.Operator = &overlay_StartType_LengthTypeOp{}
+
+func (o *overlay_StartType_LengthTypeOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputCol := batch.ColVec(o.argumentCols[0]).Bytes()
+	toCol := batch.ColVec(o.argumentCols[1]).Bytes()
+	startCol := batch.ColVec(o.argumentCols[2])._StartType()
+	lengthCol := batch.ColVec(o.argumentCols[3])._LengthType()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if o.hasNullArgument(batch, rowIdx) {
+					outputVec.Nulls().SetNull(rowIdx)
+					continue
+				}
+				o.scratch = appendOverlay(
+					o.scratch[:0], inputCol.Get(rowIdx), toCol.Get(rowIdx),
+					int(startCol[rowIdx]), int(lengthCol[rowIdx]),
+				)
+				outputCol.Set(rowIdx, o.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{end}}
+// {{end}}
+
+// hasNullArgument returns whether any of the arguments is NULL at position
+// rowIdx, in which case the result is NULL.
+func (o *overlayOpBase) hasNullArgument(batch coldata.Batch, rowIdx int) bool {
+	for _, col := range o.argumentCols {
+		if batch.ColVec(col).Nulls().NullAt(rowIdx) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPad(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "constant arguments",
+			expr: "lpad(@1, 5, 'xy') || '|' || rpad(@1, 5, 'xy')",
+			inputTuples: colexectestutils.Tuples{
+				{""},
+				{"ab"},
+				{"abcde"},
+				{"abcdefg"},
+				{nil},
+			},
+			inputTypes: []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"", "xyxyx|xyxyx"},
+				{"ab", "xyxab|abxyx"},
+				{"abcde", "abcde|abcde"},
+				{"abcdefg", "abcde|abcde"},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "default fill",
+			expr: "lpad(@1, @2) || '|' || rpad(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"ab", 4},
+				{"ab", 2},
+				{"ab", 1},
+				{"ab", 0},
+				{"ab", -1},
+				{"ab", nil},
+			},
+			inputTypes: []*types.T{types.String, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"ab", 4, "  ab|ab  "},
+				{"ab", 2, "ab|ab"},
+				{"ab", 1, "a|a"},
+				{"ab", 0, "|"},
+				{"ab", -1, "|"},
+				{"ab", nil, nil},
+			},
+		},
+		{
+			desc: "column arguments",
+			expr: "lpad(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"abc", 6, "12"},
+				{"abc", 3, "12"},
+				{"abc", 2, "12"},
+				// An empty fill leaves the input unchanged unless it needs
+				// to be truncated.
+				{"abc", 6, ""},
+				{"abc", 1, ""},
+				{nil, 6, "12"},
+				{"abc", nil, "12"},
+				{"abc", 6, nil},
+			},
+			inputTypes: []*types.T{types.String, types.Int, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"abc", 6, "12", "121abc"},
+				{"abc", 3, "12", "abc"},
+				{"abc", 2, "12", "ab"},
+				{"abc", 6, "", "abc"},
+				{"abc", 1, "", "a"},
+				{nil, 6, "12", nil},
+				{"abc", nil, "12", nil},
+				{"abc", 6, nil, nil},
+			},
+		},
+		{
+			desc: "multibyte characters",
+			expr: "lpad(@1, @2, @3) || '|' || rpad(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"日本", 5, "語ü"},
+				{"日本語", 2, "x"},
+				{"ü", 3, "日"},
+			},
+			inputTypes: []*types.T{types.String, types.Int, types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"日本", 5, "語ü", "語ü語日本|日本語ü語"},
+				{"日本語", 2, "x", "日本|日本"},
+				{"ü", 3, "日", "日日ü|ü日日"},
+			},
+		},
+		{
+			desc:         "narrow int argument",
+			expr:         "lpad(@1, @2, '.') || rpad(@1, @3, '.')",
+			inputTuples:  colexectestutils.Tuples{{"a", 2, 3}},
+			inputTypes:   []*types.T{types.String, types.Int2, types.Int4},
+			outputTuples: colexectestutils.Tuples{{"a", 2, 3, ".aa.."}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// Too large length results in an error, same as in the row engine.
+	typs := []*types.T{types.String, types.Int}
+	input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"a", 1 << 30}}, typs)
+	op, err := colexectestutils.CreateTestProjectingOperator(
+		ctx, flowCtx, input, typs, "rpad(@1, @2)", false /* canFallbackToRowexec */, testMemAcc,
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+	require.EqualError(t, err, "requested length too large, exceeds 128 MiB")
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for pad.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _LENGTH_WIDTH is the template variable.
+const _LENGTH_WIDTH = 0
+
+// */}}
+
+// maxAllocatedStringSize is the maximum length of the result of the string
+// builtins like lpad(), rpad() and replace(). It matches the limit of the row
+// engine.
+const maxAllocatedStringSize = 128 * 1024 * 1024
+
+var errStringTooLarge = pgerror.Newf(
+	pgcode.ProgramLimitExceeded, "requested length too large, exceeds %s", humanizeutil.IBytes(maxAllocatedStringSize),
+)
+
+// defaultPadFill is used by lpad() and rpad() when the fill argument is
+// omitted.
+var defaultPadFill = []byte(" ")
+
+// newPadOperator returns an operator that evaluates lpad() (if left is true)
+// or rpad() builtin. The arguments are expected at positions argumentCols: the
+// input Bytes column, the length Int column and, optionally, the fill Bytes
+// column. If the fill is omitted, a single space is used.
+func newPadOperator(
+	allocator *colmem.Allocator,
+	typs []*types.T,
+	argumentCols []int,
+	left bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := padOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		left:           left,
+		outputIdx:      outputIdx,
+	}
+	lengthType := typs[argumentCols[1]]
+	if lengthType.Family() != types.IntFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("non-int length argument type %s", lengthType))
+	}
+	switch lengthType.Width() {
+	// {{range .}}
+	case _LENGTH_WIDTH:
+		return &pad_LengthTypeOp{padOpBase: base}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported pad argument type: %s", lengthType))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type padOpBase struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	left         bool
+	outputIdx    int
+	// scratch is the buffer the results are written into before being set
+	// into the output vector. It is reused across rows and batches.
+	scratch []byte
+}
+
+// appendPad appends to dst the result of padding s to length characters by
+// adding fill to the left (if left is true) or to the right of s, truncating s
+// if it is longer than length. It matches lpad() and rpad() builtins of the
+// row engine, so s is left unchanged if fill is empty, and the invalid UTF-8
+// bytes of the truncated s and of fill are replaced with utf8.RuneError.
+func appendPad(dst, s, fill []byte, length int, left bool) []byte {
+	if length > maxAllocatedStringSize {
+		colexecerror.ExpectedError(errStringTooLarge)
+	}
+	if length < 0 {
+		length = 0
+	}
+	numRunes := utf8.RuneCount(s)
+	if length == numRunes {
+		return append(dst, s...)
+	}
+	if length < numRunes {
+		return appendRuneRange(dst, s, 0 /* start */, length)
+	}
+	if len(fill) == 0 {
+		return append(dst, s...)
+	}
+	if !left {
+		dst = append(dst, s...)
+	}
+	for toFill := length - numRunes; toFill > 0; {
+		for i := 0; i < len(fill) && toFill > 0; toFill-- {
+			if c := fill[i]; c < utf8.RuneSelf {
+				dst = append(dst, c)
+				i++
+				continue
+			}
+			r, width := utf8.DecodeRune(fill[i:])
+			dst = appendRune(dst, r)
+			i += width
+		}
+	}
+	if left {
+		dst = append(dst, s...)
+	}
+	return dst
+}
+
+// {{range .}}
+
+// pad_LengthTypeOp is an operator that evaluates lpad() or rpad() builtin.
+type pad_LengthTypeOp struct {
+	padOpBase
+}
+
+var _ colexecop.Operator = &pad_LengthTypeOp{}
+
+func (p *pad_LengthTypeOp) Next() coldata.Batch {
+	batch := p.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputCol := batch.ColVec(p.argumentCols[0]).Bytes()
+	lengthCol := batch.ColVec(p.argumentCols[1])._LengthType()
+	var fillCol *coldata.Bytes
+	if len(p.argumentCols) > 2 {
+		fillCol = batch.ColVec(p.argumentCols[2]).Bytes()
+	}
+	outputVec := batch.ColVec(p.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	p.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			fill := defaultPadFill
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if p.hasNullArgument(batch, rowIdx) {
+					outputVec.Nulls().SetNull(rowIdx)
+					continue
+				}
+				if fillCol != nil {
+					fill = fillCol.Get(rowIdx)
+				}
+				p.scratch = appendPad(
+					p.scratch[:0], inputCol.Get(rowIdx), fill, int(lengthCol[rowIdx]), p.left,
+				)
+				outputCol.Set(rowIdx, p.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{end}}
+
+// hasNullArgument returns whether any of the arguments is NULL at position
+// rowIdx, in which case the result is NULL.
+func (p *padOpBase) hasNullArgument(batch coldata.Batch, rowIdx int) bool {
+	for _, col := range p.argumentCols {
+		if batch.ColVec(col).Nulls().NullAt(rowIdx) {
+			return true
+		}
+	}
+	return false
+}
@@ -59,7 +59,10 @@ const (
 )
 
 // ParallelUnorderedSynchronizer is an Operator that combines multiple Operator streams
-// into one.
+// into one. Similar to SerialUnorderedSynchronizer, the batches are returned as
+// they are produced by the inputs, so they might or might not have a selection
+// vector set unless the inputs are compacted with
+// CompactUnorderedSynchronizerInputs.
 type ParallelUnorderedSynchronizer struct {
 	colexecop.InitHelper
 
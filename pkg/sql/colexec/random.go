@@ -0,0 +1,101 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// newRandomOperator returns an operator that evaluates either random() builtin
+// (if isUUID is false) or one of the builtins that generate a random UUID
+// (like gen_random_uuid()). A new value is generated for every row using the
+// source of randomness derived from seed, so two operators with the same seed
+// produce the same values.
+func newRandomOperator(
+	allocator *colmem.Allocator, isUUID bool, seed int64, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &randomOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		rng:            rand.New(rand.NewSource(seed)),
+		isUUID:         isUUID,
+		outputIdx:      outputIdx,
+	}
+}
+
+// randomOp is an operator that generates either a random float in [0, 1) or
+// a random version 4 UUID for every row. Note that unlike the row engine, the
+// random UUIDs are generated using a fast but not cryptographically secure
+// source of randomness which is seeded once per operator. The result is never
+// NULL.
+type randomOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	rng       *rand.Rand
+	isUUID    bool
+	outputIdx int
+}
+
+var _ colexecop.Operator = &randomOp{}
+
+func (r *randomOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	outputVec := batch.ColVec(r.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	r.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if r.isUUID {
+				outputCol := outputVec.Bytes()
+				var u uuid.UUID
+				for i := 0; i < n; i++ {
+					rowIdx := i
+					if sel != nil {
+						rowIdx = sel[i]
+					}
+					// Read always fills in the whole slice and never returns an
+					// error.
+					_, _ = r.rng.Read(u[:])
+					u.SetVersion(uuid.V4)
+					u.SetVariant(uuid.VariantRFC4122)
+					outputCol.Set(rowIdx, u.GetBytes())
+				}
+				return
+			}
+			outputCol := outputVec.Float64()
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				outputCol[rowIdx] = r.rng.Float64()
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// runRandomOp returns the values generated by the random operator for numRows
+// rows. If useSel is true, only every other row is selected by the input. The
+// UUIDs are returned in their string representation.
+func runRandomOp(t *testing.T, isUUID bool, seed int64, numRows int, useSel bool) []interface{} {
+	typs := []*types.T{types.Bool}
+	tuples := make(colexectestutils.Tuples, numRows)
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{!useSel || i%2 == 0}
+	}
+	input := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), tuples, typs)
+	if useSel {
+		input = colexecutils.NewBoolVecToSelOp(input, 0 /* colIdx */)
+	}
+	outputType := types.Float
+	if isUUID {
+		outputType = types.Uuid
+	}
+	input = colexecutils.NewVectorTypeEnforcer(testAllocator, input, outputType, 1 /* outputIdx */)
+	op := newRandomOperator(testAllocator, isUUID, seed, 1 /* outputIdx */, input)
+	op.Init(context.Background())
+	var res []interface{}
+	for b := op.Next(); b.Length() > 0; b = op.Next() {
+		vec, sel := b.ColVec(1), b.Selection()
+		require.False(t, vec.MaybeHasNulls())
+		for i := 0; i < b.Length(); i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if isUUID {
+				u, err := uuid.FromBytes(vec.Bytes().Get(rowIdx))
+				require.NoError(t, err)
+				require.Equal(t, uuid.V4, u.Version())
+				require.Equal(t, uuid.VariantRFC4122, u.Variant())
+				res = append(res, u.String())
+			} else {
+				f := vec.Float64()[rowIdx]
+				require.True(t, f >= 0 && f < 1, "unexpected random value %f", f)
+				res = append(res, f)
+			}
+		}
+	}
+	return res
+}
+
+func TestRandom(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	for _, isUUID := range []bool{false, true} {
+		for _, useSel := range []bool{false, true} {
+			seed := rng.Int63()
+			numRows := 3*coldata.BatchSize() + 1
+			t.Run(fmt.Sprintf("uuid=%t/useSel=%t/seed=%d", isUUID, useSel, seed), func(t *testing.T) {
+				res := runRandomOp(t, isUUID, seed, numRows, useSel)
+				expectedNumRows := numRows
+				if useSel {
+					expectedNumRows = (numRows + 1) / 2
+				}
+				require.Len(t, res, expectedNumRows)
+
+				// The values must be independent across the rows and the
+				// batches, so we expect all of them to be distinct.
+				seen := make(map[interface{}]struct{}, len(res))
+				for _, v := range res {
+					_, ok := seen[v]
+					require.False(t, ok, "duplicate value %v", v)
+					seen[v] = struct{}{}
+				}
+
+				// The same seed must produce the same values while a different
+				// one must not.
+				require.Equal(t, res, runRandomOp(t, isUUID, seed, numRows, useSel))
+				require.NotEqual(t, res, runRandomOp(t, isUUID, seed+1, numRows, useSel))
+			})
+		}
+	}
+}
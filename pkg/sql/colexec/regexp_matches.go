@@ -0,0 +1,197 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"regexp"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewRegexpMatchesOp returns an operator that evaluates the regexp_matches
+// set-returning function with the constant pattern and flags on the String
+// column at position strColIdx. Each input tuple is repeated once for each
+// match of the pattern in its string with the array of the captured substrings
+// appended after the first numInputCols columns of the input (the remaining
+// columns of the input, if any, are not emitted). Only the first match is
+// emitted unless the flags contain 'g'.
+//
+// The tuples with NULL strings or without any matches don't produce any
+// output. Same as in the row engine, an invalid pattern or invalid flags
+// result in an error only if there is a non-NULL string to be matched.
+func NewRegexpMatchesOp(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	numInputCols int,
+	strColIdx int,
+	pattern string,
+	flags string,
+	maxOutputBatchMemSize int64,
+) (colexecop.Operator, error) {
+	if typ := inputTypes[strColIdx]; typ.Family() != types.StringFamily {
+		return nil, errors.Newf("unsupported string argument type %s", typ)
+	}
+	outputTypes := make([]*types.T, numInputCols, numInputCols+1)
+	copy(outputTypes, inputTypes[:numInputCols])
+	outputTypes = append(outputTypes, types.StringArray)
+	// The pattern is compiled only once for all input tuples.
+	re, err := builtins.GetRegexpWithFlags(evalCtx, pattern, flags)
+	return &regexpMatchesOp{
+		OneInputHelper:        colexecop.MakeOneInputHelper(input),
+		allocator:             allocator,
+		outputTypes:           outputTypes,
+		numInputCols:          numInputCols,
+		strColIdx:             strColIdx,
+		maxOutputBatchMemSize: maxOutputBatchMemSize,
+		re:                    re,
+		reErr:                 err,
+		flags:                 flags,
+	}, nil
+}
+
+// regexpMatchesOp expands each input tuple into as many output tuples as there
+// are matches of the regular expression in its string. Since a single input
+// tuple can produce an arbitrary number of output tuples, the operator keeps
+// track of the input tuple being currently expanded across the calls to Next.
+type regexpMatchesOp struct {
+	colexecop.OneInputHelper
+
+	allocator             *colmem.Allocator
+	outputTypes           []*types.T
+	numInputCols          int
+	strColIdx             int
+	maxOutputBatchMemSize int64
+	re                    *regexp.Regexp
+	// reErr is the error that occurred when compiling the regular expression.
+	// It is returned on the first non-NULL string.
+	reErr error
+	flags string
+
+	// batch is the current input batch, and nextIdx is the position of the
+	// next tuple in it (before applying the selection vector) to be expanded.
+	batch   coldata.Batch
+	nextIdx int
+	// s is the string of the tuple at position rowIdx in batch which is
+	// currently being expanded, matches are the matches in s that haven't
+	// been fully emitted yet (nil if there is no such tuple), and matchIdx is
+	// the position of the match to be emitted next.
+	s        string
+	matches  [][]int
+	rowIdx   int
+	matchIdx int
+
+	output coldata.Batch
+	// srcIdxs contains the position of the input tuple in batch for each of
+	// the output tuples.
+	srcIdxs []int
+}
+
+var _ colexecop.Operator = &regexpMatchesOp{}
+
+func (o *regexpMatchesOp) Next() coldata.Batch {
+	if o.batch == nil {
+		o.batch = o.Input.Next()
+	}
+	if o.batch.Length() == 0 {
+		return coldata.ZeroBatch
+	}
+	// Most commonly, every input tuple has a few matches, so we use the length
+	// of the input batch as the estimate of the output size.
+	o.output, _ = o.allocator.ResetMaybeReallocate(
+		o.outputTypes, o.output, o.batch.Length(), o.maxOutputBatchMemSize,
+	)
+	if cap(o.srcIdxs) < o.output.Capacity() {
+		o.srcIdxs = make([]int, o.output.Capacity())
+	}
+	o.srcIdxs = o.srcIdxs[:o.output.Capacity()]
+	matchesCol := o.output.ColVec(o.numInputCols).Datum()
+	var outputIdx int
+	o.allocator.PerformOperation(o.output.ColVecs(), func() {
+		// batchStartIdx is the position of the first output tuple that was
+		// generated from the current input batch.
+		batchStartIdx := 0
+		for outputIdx < o.output.Capacity() {
+			if o.matches == nil && !o.startNextTuple() {
+				// The current input batch has been fully consumed, so we
+				// copy the input columns for the output tuples generated
+				// from it before moving onto the next batch.
+				copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+				batchStartIdx = outputIdx
+				o.batch = o.Input.Next()
+				o.nextIdx = 0
+				if o.batch.Length() == 0 {
+					break
+				}
+				continue
+			}
+			// Emit as many matches of the current tuple as fit into the
+			// output batch.
+			for ; o.matches != nil && outputIdx < o.output.Capacity(); outputIdx++ {
+				arr, err := builtins.RegexpMatchToArray(o.s, o.matches[o.matchIdx])
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+				matchesCol.Set(outputIdx, arr)
+				o.srcIdxs[outputIdx] = o.rowIdx
+				o.matchIdx++
+				if o.matchIdx == len(o.matches) {
+					o.matches = nil
+				}
+			}
+		}
+		copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+		o.output.SetLength(outputIdx)
+	})
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	return o.output
+}
+
+// startNextTuple finds the next tuple in the current input batch that has at
+// least one match and prepares it for the expansion. false is returned if the
+// batch has been fully consumed.
+func (o *regexpMatchesOp) startNextTuple() bool {
+	n := o.batch.Length()
+	sel := o.batch.Selection()
+	strVec := o.batch.ColVec(o.strColIdx)
+	strNulls, strCol := strVec.Nulls(), strVec.Bytes()
+	for ; o.nextIdx < n; o.nextIdx++ {
+		rowIdx := o.nextIdx
+		if sel != nil {
+			rowIdx = sel[o.nextIdx]
+		}
+		if strNulls.NullAt(rowIdx) {
+			continue
+		}
+		if o.reErr != nil {
+			colexecerror.ExpectedError(o.reErr)
+		}
+		s := string(strCol.Get(rowIdx))
+		matches := builtins.RegexpMatches(o.re, s, o.flags)
+		if len(matches) == 0 {
+			continue
+		}
+		o.s, o.matches, o.rowIdx, o.matchIdx = s, matches, rowIdx, 0
+		o.nextIdx++
+		return true
+	}
+	return false
+}
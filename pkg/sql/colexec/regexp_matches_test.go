@@ -0,0 +1,160 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexpMatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	// longString has so many matches that they span several output batches
+	// on their own.
+	numLongMatches := 2*coldata.BatchSize() + 3
+	longString := strings.Repeat("xab", numLongMatches)
+	var longExpected colexectestutils.Tuples
+	for i := 0; i < numLongMatches; i++ {
+		longExpected = append(longExpected, colexectestutils.Tuple{3, "ARRAY['b']"})
+	}
+	longExpected = append(longExpected, colexectestutils.Tuple{4, "ARRAY['b']"})
+
+	for _, tc := range []struct {
+		desc     string
+		pattern  string
+		flags    string
+		tuples   colexectestutils.Tuples
+		expected colexectestutils.Tuples
+	}{
+		{
+			desc:    "first match",
+			pattern: "a(b+)",
+			tuples: colexectestutils.Tuples{
+				{0, "abbxab"}, {1, "xyz"}, {2, nil}, {nil, "ab"}, {4, ""},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "ARRAY['bb']"}, {nil, "ARRAY['b']"},
+			},
+		},
+		{
+			desc:    "global",
+			pattern: "a(b+)",
+			flags:   "g",
+			tuples: colexectestutils.Tuples{
+				{0, "abbxab"}, {1, "xyz"}, {2, nil}, {nil, "ab"}, {4, ""},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "ARRAY['bb']"}, {0, "ARRAY['b']"}, {nil, "ARRAY['b']"},
+			},
+		},
+		{
+			// Without the capture groups, the whole match is returned.
+			desc:    "no capture groups",
+			pattern: "[0-9]+",
+			flags:   "g",
+			tuples: colexectestutils.Tuples{
+				{0, "a12b3"}, {1, "abc"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "ARRAY['12']"}, {0, "ARRAY['3']"},
+			},
+		},
+		{
+			// The groups that don't participate in the match are NULL.
+			desc:    "unmatched capture groups",
+			pattern: "(a)|(b)",
+			flags:   "g",
+			tuples: colexectestutils.Tuples{
+				{0, "ab"}, {1, "c"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "ARRAY['a',NULL]"}, {0, "ARRAY[NULL,'b']"},
+			},
+		},
+		{
+			desc:    "case-insensitive",
+			pattern: "(a)(b)",
+			flags:   "gi",
+			tuples: colexectestutils.Tuples{
+				{0, "ABxab"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "ARRAY['A','B']"}, {0, "ARRAY['a','b']"},
+			},
+		},
+		{
+			desc:    "many matches",
+			pattern: "a(b)",
+			flags:   "g",
+			tuples: colexectestutils.Tuples{
+				{1, "xyz"}, {3, longString}, {4, "ab"}, {5, nil},
+			},
+			expected: longExpected,
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		typs := []*types.T{types.Int, types.String}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				// Only the first input column is emitted.
+				return NewRegexpMatchesOp(
+					testAllocator, &evalCtx, input[0], typs, 1 /* numInputCols */, 1, /* strColIdx */
+					tc.pattern, tc.flags, math.MaxInt64, /* maxOutputBatchMemSize */
+				)
+			})
+	}
+
+	// An invalid pattern results in an error only if there is a non-NULL
+	// string to be matched, same as in the row engine.
+	for _, tc := range []struct {
+		input       interface{}
+		expectedErr string
+	}{
+		{input: nil},
+		{input: "a", expectedErr: "error parsing regexp: missing closing ): `(?s:(a)`"},
+	} {
+		typs := []*types.T{types.String}
+		input := colexectestutils.NewOpTestInput(
+			testAllocator, 1, colexectestutils.Tuples{{tc.input}}, typs,
+		)
+		op, err := NewRegexpMatchesOp(
+			testAllocator, &evalCtx, input, typs, 1 /* numInputCols */, 0, /* strColIdx */
+			"(a", "" /* flags */, math.MaxInt64, /* maxOutputBatchMemSize */
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		if tc.expectedErr == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expectedErr)
+		}
+	}
+}
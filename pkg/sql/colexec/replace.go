@@ -0,0 +1,121 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+)
+
+// newReplaceOperator returns an operator that evaluates replace() builtin.
+// The arguments are expected at positions argumentCols: the input, the string
+// to find, and its replacement, all of them Bytes columns.
+func newReplaceOperator(
+	allocator *colmem.Allocator, argumentCols []int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &replaceOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+}
+
+// replaceOp is an operator that replaces all non-overlapping occurrences of a
+// string with another string.
+type replaceOp struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	outputIdx    int
+	// scratch is the buffer the results are written into before being set
+	// into the output vector. It is reused across rows and batches.
+	scratch []byte
+}
+
+var _ colexecop.Operator = &replaceOp{}
+
+// appendReplace appends to dst the result of replacing all non-overlapping
+// occurrences of from in s with to. Same as in Postgres, s is left unchanged
+// if from is empty.
+func appendReplace(dst, s, from, to []byte) []byte {
+	// Reserve the largest possible result upfront, same as the row engine.
+	maxResultLen := len(s)
+	if len(from) > 0 && len(from) < len(to) {
+		// The largest result is if s is from repeated over and over.
+		maxResultLen = len(s) / len(from) * len(to)
+	}
+	if maxResultLen > maxAllocatedStringSize {
+		colexecerror.ExpectedError(errStringTooLarge)
+	}
+	if len(from) == 0 {
+		return append(dst, s...)
+	}
+	for {
+		idx := bytes.Index(s, from)
+		if idx < 0 {
+			break
+		}
+		dst = append(dst, s[:idx]...)
+		dst = append(dst, to...)
+		s = s[idx+len(from):]
+	}
+	return append(dst, s...)
+}
+
+func (r *replaceOp) Next() coldata.Batch {
+	batch := r.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(r.argumentCols[0])
+	fromVec := batch.ColVec(r.argumentCols[1])
+	toVec := batch.ColVec(r.argumentCols[2])
+	inputNulls, inputCol := inputVec.Nulls(), inputVec.Bytes()
+	fromNulls, fromCol := fromVec.Nulls(), fromVec.Bytes()
+	toNulls, toCol := toVec.Nulls(), toVec.Bytes()
+	outputVec := batch.ColVec(r.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	r.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if inputNulls.NullAt(rowIdx) || fromNulls.NullAt(rowIdx) || toNulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				r.scratch = appendReplace(
+					r.scratch[:0], inputCol.Get(rowIdx), fromCol.Get(rowIdx), toCol.Get(rowIdx),
+				)
+				outputCol.Set(rowIdx, r.scratch)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestReplace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	typs := []*types.T{types.String, types.String, types.String}
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "constant arguments",
+			expr: "replace(@1, 'ab', 'x')",
+			inputTuples: colexectestutils.Tuples{
+				{""}, {"cde"}, {"ab"}, {"abcab"}, {"aabbab"}, {nil},
+			},
+			inputTypes: []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"", ""}, {"cde", "cde"}, {"ab", "x"}, {"abcab", "xcx"}, {"aabbab", "axbx"}, {nil, nil},
+			},
+		},
+		{
+			desc: "column arguments",
+			expr: "replace(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				// The result grows.
+				{"a-b-c", "-", "--"},
+				// The result shrinks.
+				{"a--b--c", "--", ""},
+				// The occurrences don't overlap.
+				{"aaaa", "aa", "b"},
+				{"aaa", "aa", "b"},
+				// Same as in Postgres, the empty string is never replaced.
+				{"abc", "", "x"},
+				{"", "", "x"},
+				{"abc", "abcd", "x"},
+				{"日本語日本", "日本", "ü"},
+				{nil, "a", "b"},
+				{"abc", nil, "b"},
+				{"abc", "a", nil},
+			},
+			inputTypes: typs,
+			outputTuples: colexectestutils.Tuples{
+				{"a-b-c", "-", "--", "a--b--c"},
+				{"a--b--c", "--", "", "abc"},
+				{"aaaa", "aa", "b", "bb"},
+				{"aaa", "aa", "b", "ba"},
+				{"abc", "", "x", "abc"},
+				{"", "", "x", ""},
+				{"abc", "abcd", "x", "abc"},
+				{"日本語日本", "日本", "ü", "ü語ü"},
+				{nil, "a", "b", nil},
+				{"abc", nil, "b", nil},
+				{"abc", "a", nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewReservoirSampleOp returns a new operator that samples k rows out of its
+// input uniformly at random. The sample is emitted once the whole input has
+// been consumed, and the order of the emitted rows is unspecified. If the input
+// has no more than k rows, then all of them are emitted.
+//
+// The randomness is derived from seed, so two runs over the same input with the
+// same seed produce the same sample.
+func NewReservoirSampleOp(
+	allocator *colmem.Allocator, input colexecop.Operator, inputTypes []*types.T, k uint64, seed int64,
+) colexecop.Operator {
+	return &reservoirSampleOp{
+		OneInputNode: colexecop.NewOneInputNode(input),
+		allocator:    allocator,
+		inputTypes:   inputTypes,
+		k:            k,
+		rng:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// reservoirSampleState represents the state of the reservoir sample operator.
+type reservoirSampleState int
+
+const (
+	// reservoirSampleSpooling is the initial state of the operator, where it
+	// consumes its input while maintaining the sample.
+	reservoirSampleSpooling reservoirSampleState = iota
+	// reservoirSampleEmitting is the second state of the operator, indicating
+	// that each call to Next will return another batch of the sample.
+	reservoirSampleEmitting
+	// reservoirSampleDone is the final state of the operator, where it always
+	// returns a zero batch.
+	reservoirSampleDone
+)
+
+// reservoirSampleOp implements the reservoir sampling (the "Algorithm R"):
+// the first k rows are added to the reservoir, and then the nth row (1-based)
+// replaces a random row of the reservoir with probability k/n. Once the input
+// is exhausted, each of the rows has been retained with the same probability.
+type reservoirSampleOp struct {
+	colexecop.OneInputNode
+	colexecop.InitHelper
+
+	allocator  *colmem.Allocator
+	inputTypes []*types.T
+	k          uint64
+	rng        *rand.Rand
+
+	state reservoirSampleState
+	// sample stores the rows that are currently retained.
+	sample *colexecutils.AppendOnlyBufferedBatch
+	// numSeen is the number of rows that have been read from the input so far.
+	numSeen uint64
+	// emitted is the number of rows from the sample which have been emitted so
+	// far.
+	emitted int
+	output  coldata.Batch
+}
+
+var _ colexecop.Operator = &reservoirSampleOp{}
+
+func (r *reservoirSampleOp) Init(ctx context.Context) {
+	if !r.InitHelper.Init(ctx) {
+		return
+	}
+	r.Input.Init(r.Ctx)
+	r.sample = colexecutils.NewAppendOnlyBufferedBatch(r.allocator, r.inputTypes, nil /* colsToStore */)
+}
+
+func (r *reservoirSampleOp) Next() coldata.Batch {
+	for {
+		switch r.state {
+		case reservoirSampleSpooling:
+			r.spool()
+			r.state = reservoirSampleEmitting
+		case reservoirSampleEmitting:
+			output := r.emit()
+			if output.Length() == 0 {
+				r.state = reservoirSampleDone
+				continue
+			}
+			return output
+		case reservoirSampleDone:
+			return coldata.ZeroBatch
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("invalid reservoir sample state %v", r.state))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	}
+}
+
+// spool reads in the entire input, maintaining the sample of the rows seen so
+// far in r.sample.
+func (r *reservoirSampleOp) spool() {
+	for batch := r.Input.Next(); batch.Length() > 0; batch = r.Input.Next() {
+		n := batch.Length()
+		startIdx := 0
+		if r.numSeen < r.k {
+			// The reservoir is not full yet, so we simply append the tuples
+			// until it is.
+			toAppend := n
+			if remaining := r.k - r.numSeen; remaining < uint64(n) {
+				toAppend = int(remaining)
+			}
+			r.allocator.PerformOperation(r.sample.ColVecs(), func() {
+				r.sample.AppendTuples(batch, 0 /* startIdx */, toAppend)
+			})
+			r.numSeen += uint64(toAppend)
+			startIdx = toAppend
+		}
+		if startIdx == n {
+			continue
+		}
+		sel := batch.Selection()
+		r.allocator.PerformOperation(r.sample.ColVecs(), func() {
+			for i := startIdx; i < n; i++ {
+				r.numSeen++
+				// The current row is retained with probability k/numSeen, in
+				// which case it replaces the row at position j.
+				j := uint64(r.rng.Int63n(int64(r.numSeen)))
+				if j >= r.k {
+					continue
+				}
+				srcIdx := i
+				if sel != nil {
+					srcIdx = sel[i]
+				}
+				for colIdx := range r.inputTypes {
+					// Note that in case of the flat bytes the data might need
+					// to be moved around since we're overwriting a value in
+					// the middle of the vector.
+					r.sample.ColVec(colIdx).Copy(coldata.CopySliceArgs{
+						SliceArgs: coldata.SliceArgs{
+							Src:         batch.ColVec(colIdx),
+							DestIdx:     int(j),
+							SrcStartIdx: srcIdx,
+							SrcEndIdx:   srcIdx + 1,
+						},
+					})
+				}
+			}
+		})
+	}
+}
+
+func (r *reservoirSampleOp) emit() coldata.Batch {
+	toEmit := r.sample.Length() - r.emitted
+	if toEmit == 0 {
+		// We're done.
+		return coldata.ZeroBatch
+	}
+	if toEmit > coldata.BatchSize() {
+		toEmit = coldata.BatchSize()
+	}
+	// The output batch is limited by the size of the sample which has already
+	// been accounted for, so we don't enforce any footprint-based limit.
+	const maxBatchMemSize = math.MaxInt64
+	r.output, _ = r.allocator.ResetMaybeReallocate(r.inputTypes, r.output, toEmit, maxBatchMemSize)
+	r.allocator.PerformOperation(r.output.ColVecs(), func() {
+		for i := range r.inputTypes {
+			r.output.ColVec(i).Copy(
+				coldata.CopySliceArgs{
+					SliceArgs: coldata.SliceArgs{
+						Src:         r.sample.ColVec(i),
+						SrcStartIdx: r.emitted,
+						SrcEndIdx:   r.emitted + toEmit,
+					},
+				},
+			)
+		}
+		r.output.SetLength(toEmit)
+	})
+	r.emitted += toEmit
+	return r.output
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+var reservoirSampleTestTypes = []*types.T{types.Int, types.Bool, types.Bytes}
+
+// makeReservoirSampleInput returns an input of numRows tuples of the form
+// (i, eligible, "i") where eligible indicates whether the tuple is expected to
+// be considered for the sample. If useSel is true, only the eligible tuples
+// (every other tuple) are selected by the input.
+func makeReservoirSampleInput(
+	allocator *colmem.Allocator, numRows int, useSel bool,
+) colexecop.Operator {
+	tuples := make(colexectestutils.Tuples, numRows)
+	for i := range tuples {
+		tuples[i] = colexectestutils.Tuple{i, !useSel || i%2 == 0, fmt.Sprint(i)}
+	}
+	input := colexectestutils.NewOpTestInput(allocator, coldata.BatchSize(), tuples, reservoirSampleTestTypes)
+	if useSel {
+		input = colexecutils.NewBoolVecToSelOp(input, 1 /* colIdx */)
+	}
+	return input
+}
+
+func runReservoirSample(
+	allocator *colmem.Allocator, numRows int, useSel bool, k uint64, seed int64,
+) colexectestutils.Tuples {
+	op := NewReservoirSampleOp(
+		allocator, makeReservoirSampleInput(allocator, numRows, useSel), reservoirSampleTestTypes, k, seed,
+	)
+	op.Init(context.Background())
+	var sample colexectestutils.Tuples
+	for b := op.Next(); b.Length() > 0; b = op.Next() {
+		for i := 0; i < b.Length(); i++ {
+			sample = append(sample, colexectestutils.GetTupleFromBatch(b, i))
+		}
+	}
+	return sample
+}
+
+func TestReservoirSample(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rng, _ := randutil.NewPseudoRand()
+	for _, numRows := range []int{0, 3, 50, 2 * coldata.BatchSize()} {
+		for _, k := range []uint64{0, 1, 5, 100} {
+			for _, useSel := range []bool{false, true} {
+				seed := rng.Int63()
+				log.Infof(context.Background(), "numRows=%d/k=%d/useSel=%t/seed=%d", numRows, k, useSel, seed)
+				sample := runReservoirSample(testAllocator, numRows, useSel, k, seed)
+				numEligible := numRows
+				if useSel {
+					numEligible = (numRows + 1) / 2
+				}
+				expectedLen := int(k)
+				if numEligible < expectedLen {
+					expectedLen = numEligible
+				}
+				require.Equal(t, expectedLen, len(sample))
+				seen := make(map[int64]struct{})
+				for _, tuple := range sample {
+					i := tuple[0].(int64)
+					require.True(t, i >= 0 && i < int64(numRows), "unexpected tuple %v", tuple)
+					require.Equal(t, true, tuple[1], "sampled tuple %v is not selected", tuple)
+					require.Equal(t, fmt.Sprint(i), string(tuple[2].([]byte)), "corrupted tuple %v", tuple)
+					_, ok := seen[i]
+					require.False(t, ok, "tuple %v is sampled more than once", tuple)
+					seen[i] = struct{}{}
+				}
+				// A run with the same seed must produce exactly the same sample.
+				require.Equal(t, sample, runReservoirSample(testAllocator, numRows, useSel, k, seed))
+			}
+		}
+	}
+}
+
+// TestReservoirSampleUniform verifies that each of the rows is retained with
+// roughly the same probability.
+func TestReservoirSampleUniform(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const (
+		numRows = 10
+		k       = 3
+		numRuns = 3000
+	)
+	counts := make([]int, numRows)
+	for seed := int64(0); seed < numRuns; seed++ {
+		for _, tuple := range runReservoirSample(testAllocator, numRows, false /* useSel */, k, seed) {
+			counts[tuple[0].(int64)]++
+		}
+	}
+	// Each row is expected to be sampled numRuns*k/numRows = 900 times with
+	// the standard deviation of about 25, so the bounds below are generous
+	// enough to never fail given that the seeds are fixed.
+	const expected = numRuns * k / numRows
+	for i, count := range counts {
+		require.True(t, count > expected*8/10 && count < expected*12/10, "row %d sampled %d times", i, count)
+	}
+}
+
+// TestReservoirSampleMemoryAccounting verifies that the memory used by the
+// operator is proportional to the size of the sample, not to the size of the
+// input, and that the memory limit is respected.
+func TestReservoirSampleMemoryAccounting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	const numRows = 4000
+	for _, limited := range []bool{false, true} {
+		memMon := mon.NewMonitor("reservoir-sample", mon.MemoryResource, nil, nil, 0, math.MaxInt64, st)
+		if limited {
+			memMon.Start(ctx, nil, mon.MakeStandaloneBudget(1))
+		} else {
+			memMon.Start(ctx, nil, mon.MakeStandaloneBudget(math.MaxInt64))
+		}
+		acc := memMon.MakeBoundAccount()
+		allocator := colmem.NewAllocator(ctx, &acc, testColumnFactory)
+		var smallSampleMem, largeSampleMem int64
+		err := colexecerror.CatchVectorizedRuntimeError(func() {
+			for _, k := range []uint64{10, 1000} {
+				acc.Clear(ctx)
+				require.Equal(t, int(k), len(runReservoirSample(allocator, numRows, false /* useSel */, k, int64(k))))
+				if k == 10 {
+					smallSampleMem = acc.Used()
+				} else {
+					largeSampleMem = acc.Used()
+				}
+			}
+		})
+		if limited {
+			require.Error(t, err, "expected memory error")
+		} else {
+			require.NoError(t, err)
+			require.Less(t, smallSampleMem, largeSampleMem)
+		}
+		acc.Close(ctx)
+		memMon.Stop(ctx)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// GetInArrayOperator returns an operator that selects the tuples for which the
+// value in the column at colIdx is equal to any of the elements of the
+// constant array (i.e. col = ANY(array)).
+//
+// The elements of the array are sorted and deduplicated when the operator is
+// constructed. If the hash set of the elements can be used, then the operator
+// returned by GetInHashOperator is used since probing the hash set is faster
+// than the binary search even for large arrays; otherwise, the operator
+// returned by GetInOperator performs a binary search over the sorted elements
+// for each value.
+func GetInArrayOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	t *types.T,
+	input colexecop.Operator,
+	colIdx int,
+	array *tree.DArray,
+) (colexecop.Operator, error) {
+	if array.Len() == 0 {
+		// No value (including NULL) is equal to any of the elements of an
+		// empty array.
+		return colexecutils.NewZeroOp(input), nil
+	}
+	datumTuple, err := sortedDistinctArrayElements(evalCtx, t, array)
+	if err != nil {
+		return nil, err
+	}
+	if CanUseInHashSet(t, datumTuple) {
+		return GetInHashOperator(allocator, t, input, colIdx, datumTuple, false /* negate */)
+	}
+	return GetInOperator(t, input, colIdx, datumTuple, false /* negate */)
+}
+
+// GetInArrayProjectionOperator returns an operator that projects the result of
+// col = ANY(array) for the values in the column at colIdx and the constant
+// array into the column at resultIdx. See GetInArrayOperator for more details.
+func GetInArrayProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	t *types.T,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	array *tree.DArray,
+) (colexecop.Operator, error) {
+	if array.Len() == 0 {
+		// The result is false for all values (including NULL) if the array
+		// is empty.
+		return colexecbase.NewConstOp(allocator, input, types.Bool, false, resultIdx)
+	}
+	datumTuple, err := sortedDistinctArrayElements(evalCtx, t, array)
+	if err != nil {
+		return nil, err
+	}
+	if CanUseInHashSet(t, datumTuple) {
+		return GetInHashProjectionOperator(
+			allocator, t, input, colIdx, resultIdx, datumTuple, false, /* negate */
+		)
+	}
+	return GetInProjectionOperator(
+		allocator, t, input, colIdx, resultIdx, datumTuple, false, /* negate */
+	)
+}
+
+// sortedDistinctArrayElements returns a tuple of the distinct elements of the
+// array in sorted order. An error is returned if the elements can't be
+// converted into the physical representation of t without changing their
+// values.
+func sortedDistinctArrayElements(
+	evalCtx *tree.EvalContext, t *types.T, array *tree.DArray,
+) (*tree.DTuple, error) {
+	elemTyp := array.ParamTyp
+	if !elemTyp.Equivalent(t) || (t.Family() == types.IntFamily && elemTyp.Width() != t.Width()) {
+		return nil, errors.Errorf("unsupported = ANY comparison of %s and %s", t, array.ResolvedType())
+	}
+	tupleContents := make([]*types.T, array.Len())
+	for i := range tupleContents {
+		tupleContents[i] = elemTyp
+	}
+	elems := make(tree.Datums, array.Len())
+	copy(elems, array.Array)
+	datumTuple := tree.NewDTuple(types.MakeTuple(tupleContents), elems...)
+	datumTuple.Normalize(evalCtx)
+	return datumTuple, nil
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+func makeInArrayTestArray(typ *types.T, datums ...tree.Datum) *tree.DArray {
+	array := tree.NewDArray(typ)
+	for _, d := range datums {
+		if err := array.Append(d); err != nil {
+			panic(err)
+		}
+	}
+	return array
+}
+
+// makeLargeInArrayTestCase returns an unsorted array with duplicates that
+// contains the multiples of 3 in [0, 3*numElems) as well as the input tuples
+// in [0, 3*numElems+10) and the tuples selected by = ANY.
+func makeLargeInArrayTestCase(
+	numElems int,
+) (array []tree.Datum, input, output colexectestutils.Tuples) {
+	for i := numElems - 1; i >= 0; i-- {
+		array = append(array, tree.NewDInt(tree.DInt(3*i)))
+		if i%5 == 0 {
+			array = append(array, tree.NewDInt(tree.DInt(3*i)))
+		}
+	}
+	for i := 0; i < 3*numElems+10; i++ {
+		input = append(input, colexectestutils.Tuple{i})
+		if i%3 == 0 && i < 3*numElems {
+			output = append(output, colexectestutils.Tuple{i})
+		}
+	}
+	return array, input, output
+}
+
+func TestSelectInArray(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	largeArray, largeInput, largeOutput := makeLargeInArrayTestCase(4 * coldata.BatchSize())
+
+	testCases := []struct {
+		desc string
+		// typ is the type of the values, types.Int is used if it is nil.
+		typ          *types.T
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+		array        []tree.Datum
+		// skipAllNullsInjection is set when replacing all input values with
+		// NULLs doesn't change the output.
+		skipAllNullsInjection bool
+	}{
+		{
+			desc:         "unsorted with duplicates",
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {2}, {3}},
+			outputTuples: colexectestutils.Tuples{{1}, {3}},
+			array:        []tree.Datum{tree.NewDInt(3), tree.NewDInt(1), tree.NewDInt(3)},
+		},
+		{
+			desc:         "NULL in array",
+			inputTuples:  colexectestutils.Tuples{{nil}, {1}, {2}},
+			outputTuples: colexectestutils.Tuples{{1}},
+			array:        []tree.Datum{tree.NewDInt(1), tree.DNull},
+		},
+		{
+			desc:                  "only NULL in array",
+			inputTuples:           colexectestutils.Tuples{{nil}, {1}},
+			outputTuples:          colexectestutils.Tuples{},
+			array:                 []tree.Datum{tree.DNull},
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:                  "empty array",
+			inputTuples:           colexectestutils.Tuples{{nil}, {1}},
+			outputTuples:          colexectestutils.Tuples{},
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:         "large array",
+			inputTuples:  largeInput,
+			outputTuples: largeOutput,
+			array:        largeArray,
+		},
+		{
+			// The intervals can't be stored in the hash set, so the binary
+			// search is used.
+			desc: "intervals",
+			typ:  types.Interval,
+			inputTuples: colexectestutils.Tuples{
+				{duration.MakeDuration(0, 0, 3)}, {duration.MakeDuration(0, 0, 2)}, {duration.MakeDuration(0, 0, 1)},
+			},
+			outputTuples: colexectestutils.Tuples{{duration.MakeDuration(0, 0, 3)}, {duration.MakeDuration(0, 0, 1)}},
+			array: []tree.Datum{
+				&tree.DInterval{Duration: duration.MakeDuration(0, 0, 3)},
+				&tree.DInterval{Duration: duration.MakeDuration(0, 0, 1)},
+				tree.DNull,
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		log.Infof(context.Background(), "%s", c.desc)
+		typ := c.typ
+		if typ == nil {
+			typ = types.Int
+		}
+		opConstructor := func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return GetInArrayOperator(
+				testAllocator, &evalCtx, typ, input[0], 0 /* colIdx */, makeInArrayTestArray(typ, c.array...),
+			)
+		}
+		typs := [][]*types.T{{typ}}
+		if c.skipAllNullsInjection {
+			colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		} else {
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		}
+	}
+}
+
+func TestProjectInArray(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	largeStrings := make([]tree.Datum, 100)
+	for i := range largeStrings {
+		largeStrings[i] = tree.NewDString(fmt.Sprintf("%03d", len(largeStrings)-i))
+	}
+
+	testCases := []struct {
+		desc         string
+		typ          *types.T
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+		array        []tree.Datum
+		// skipAllNullsInjection is set when replacing all input values with
+		// NULLs doesn't change the output.
+		skipAllNullsInjection bool
+	}{
+		{
+			desc:         "ints",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, false}, {1, true}, {nil, nil}},
+			array:        []tree.Datum{tree.NewDInt(3), tree.NewDInt(1), tree.NewDInt(3)},
+		},
+		{
+			// If there is no match and the array contains NULL, the result is
+			// NULL rather than false.
+			desc:         "NULL in array",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, nil}, {1, true}, {nil, nil}},
+			array:        []tree.Datum{tree.DNull, tree.NewDInt(1)},
+		},
+		{
+			// The result is false even for NULL values when the array is
+			// empty.
+			desc:                  "empty array",
+			typ:                   types.Int,
+			inputTuples:           colexectestutils.Tuples{{0}, {nil}},
+			outputTuples:          colexectestutils.Tuples{{0, false}, {nil, false}},
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:         "large array of strings",
+			typ:          types.String,
+			inputTuples:  colexectestutils.Tuples{{"001"}, {"1"}, {"032"}, {"101"}},
+			outputTuples: colexectestutils.Tuples{{"001", true}, {"1", false}, {"032", true}, {"101", false}},
+			array:        largeStrings,
+		},
+		{
+			// The intervals can't be stored in the hash set, so the binary
+			// search is used. Note that '1 day' is equal to '24 hours'.
+			desc:        "intervals",
+			typ:         types.Interval,
+			inputTuples: colexectestutils.Tuples{{duration.MakeDuration(0, 1, 0)}, {duration.MakeDuration(0, 2, 0)}},
+			outputTuples: colexectestutils.Tuples{
+				{duration.MakeDuration(0, 1, 0), true}, {duration.MakeDuration(0, 2, 0), false},
+			},
+			array: []tree.Datum{
+				&tree.DInterval{Duration: duration.MakeDuration(24*60*60*1e9, 0, 0)},
+				&tree.DInterval{Duration: duration.MakeDuration(0, 0, 1)},
+			},
+		},
+	}
+
+	for _, c := range testCases {
+		log.Infof(context.Background(), "%s", c.desc)
+		opConstructor := func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return GetInArrayProjectionOperator(
+				testAllocator, &evalCtx, c.typ, input[0], 0 /* colIdx */, 1 /* resultIdx */, makeInArrayTestArray(c.typ, c.array...),
+			)
+		}
+		typs := [][]*types.T{{c.typ}}
+		if c.skipAllNullsInjection {
+			colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		} else {
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		}
+	}
+
+	// The elements of the array must have the same physical representation
+	// as the column.
+	_, err := GetInArrayProjectionOperator(
+		testAllocator, &evalCtx, types.Int2, colexecop.NewRepeatableBatchSource(testAllocator, testAllocator.NewMemBatchWithMaxCapacity([]*types.T{types.Int2}), []*types.T{types.Int2}),
+		0 /* colIdx */, 1 /* resultIdx */, makeInArrayTestArray(types.Int, tree.NewDInt(1)),
+	)
+	require.Error(t, err)
+}
+
+// BenchmarkSelectInArray compares the hash set and the binary search
+// approaches of the = ANY operator over the arrays of various sizes. Note
+// that the hash set is faster for all sizes.
+func BenchmarkSelectInArray(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+	typs := []*types.T{types.Int}
+
+	for _, arrayLen := range []int{4, 16, 64, 256, 1024, 16384, 1 << 18} {
+		array := make([]tree.Datum, arrayLen)
+		for i := range array {
+			array[i] = tree.NewDInt(tree.DInt(rng.Intn(4 * arrayLen)))
+		}
+		datumTuple, err := sortedDistinctArrayElements(&evalCtx, types.Int, makeInArrayTestArray(types.Int, array...))
+		if err != nil {
+			b.Fatal(err)
+		}
+		// Roughly a quarter of the values have a match.
+		batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+		col := batch.ColVec(0).Int64()
+		for i := 0; i < coldata.BatchSize(); i++ {
+			col[i] = int64(rng.Intn(4 * arrayLen))
+		}
+		batch.SetLength(coldata.BatchSize())
+		for _, useHash := range []bool{false, true} {
+			b.Run(fmt.Sprintf("arrayLen=%d/hash=%t", arrayLen, useHash), func(b *testing.B) {
+				source := colexecop.NewRepeatableBatchSource(testAllocator, batch, typs)
+				var op colexecop.Operator
+				if useHash {
+					op, err = GetInHashOperator(testAllocator, types.Int, source, 0 /* colIdx */, datumTuple, false /* negate */)
+				} else {
+					op, err = GetInOperator(types.Int, source, 0 /* colIdx */, datumTuple, false /* negate */)
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+				op.Init(ctx)
+				b.SetBytes(int64(8 * coldata.BatchSize()))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					op.Next()
+				}
+			})
+		}
+	}
+}
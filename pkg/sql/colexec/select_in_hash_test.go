@@ -0,0 +1,356 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func makeInHashTestTuple(typ *types.T, datums ...tree.Datum) *tree.DTuple {
+	return tree.NewDTuple(types.MakeTuple([]*types.T{typ}), datums...)
+}
+
+func TestSelectInHash(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The large set contains the even numbers in [0, 4000), so it is built
+	// from multiple batches.
+	const largeSetSize = 2000
+	largeSet := make([]tree.Datum, largeSetSize)
+	var largeInput, largeOutput, largeNegatedOutput colexectestutils.Tuples
+	for i := range largeSet {
+		largeSet[i] = tree.NewDInt(tree.DInt(2 * i))
+	}
+	for i := 0; i < 2*largeSetSize+10; i++ {
+		largeInput = append(largeInput, colexectestutils.Tuple{i})
+		if i%2 == 0 && i < 2*largeSetSize {
+			largeOutput = append(largeOutput, colexectestutils.Tuple{i})
+		} else {
+			largeNegatedOutput = append(largeNegatedOutput, colexectestutils.Tuple{i})
+		}
+	}
+	// The small set is probed by the large input which consists of multiple
+	// batches.
+	smallSet := []tree.Datum{tree.NewDInt(3), tree.NewDInt(1000)}
+	var smallSetOutput, smallSetNegatedOutput colexectestutils.Tuples
+	for _, tup := range largeInput {
+		if i := tup[0].(int); i == 3 || i == 1000 {
+			smallSetOutput = append(smallSetOutput, tup)
+		} else {
+			smallSetNegatedOutput = append(smallSetNegatedOutput, tup)
+		}
+	}
+
+	testCases := []struct {
+		desc         string
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+		set          []tree.Datum
+		negate       bool
+		// skipAllNullsInjection is set when replacing all input values with
+		// NULLs doesn't change the output.
+		skipAllNullsInjection bool
+	}{
+		{
+			desc:         "simple in",
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {2}},
+			outputTuples: colexectestutils.Tuples{{0}, {1}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.NewDInt(0)},
+		},
+		{
+			desc:         "simple not in",
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {2}},
+			outputTuples: colexectestutils.Tuples{{2}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.NewDInt(0)},
+			negate:       true,
+		},
+		{
+			desc:         "in with NULL in set",
+			inputTuples:  colexectestutils.Tuples{{nil}, {1}, {2}},
+			outputTuples: colexectestutils.Tuples{{1}},
+			set:          []tree.Datum{tree.DNull, tree.NewDInt(1)},
+		},
+		{
+			// 2 NOT IN (NULL, 1) is NULL, so no tuples are selected.
+			desc:                  "not in with NULL in set",
+			inputTuples:           colexectestutils.Tuples{{nil}, {1}, {2}},
+			outputTuples:          colexectestutils.Tuples{},
+			set:                   []tree.Datum{tree.DNull, tree.NewDInt(1)},
+			negate:                true,
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:         "not in without NULL in set",
+			inputTuples:  colexectestutils.Tuples{{nil}, {1}, {2}},
+			outputTuples: colexectestutils.Tuples{{2}},
+			set:          []tree.Datum{tree.NewDInt(1)},
+			negate:       true,
+		},
+		{
+			desc:                  "in empty set",
+			inputTuples:           colexectestutils.Tuples{{nil}, {1}},
+			outputTuples:          colexectestutils.Tuples{},
+			skipAllNullsInjection: true,
+		},
+		{
+			// Every value, including NULL, is NOT IN an empty set.
+			desc:                  "not in empty set",
+			inputTuples:           colexectestutils.Tuples{{nil}, {1}},
+			outputTuples:          colexectestutils.Tuples{{nil}, {1}},
+			negate:                true,
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:         "in large set",
+			inputTuples:  largeInput,
+			outputTuples: largeOutput,
+			set:          largeSet,
+		},
+		{
+			desc:         "not in large set",
+			inputTuples:  largeInput,
+			outputTuples: largeNegatedOutput,
+			set:          largeSet,
+			negate:       true,
+		},
+		{
+			desc:         "in small set with large input",
+			inputTuples:  largeInput,
+			outputTuples: smallSetOutput,
+			set:          smallSet,
+		},
+		{
+			desc:         "not in small set with large input",
+			inputTuples:  largeInput,
+			outputTuples: smallSetNegatedOutput,
+			set:          smallSet,
+			negate:       true,
+		},
+	}
+
+	for _, c := range testCases {
+		log.Infof(context.Background(), "%s", c.desc)
+		opConstructor := func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return GetInHashOperator(
+				testAllocator, types.Int, input[0], 0 /* colIdx */, makeInHashTestTuple(types.Int, c.set...), c.negate,
+			)
+		}
+		if c.skipAllNullsInjection {
+			colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, nil, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		} else {
+			colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		}
+	}
+}
+
+func TestProjectInHash(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	negativeZero := math.Copysign(0, -1)
+	testCases := []struct {
+		desc         string
+		typ          *types.T
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+		set          []tree.Datum
+		negate       bool
+		// skipAllNullsInjection is set when replacing all input values with
+		// NULLs doesn't change the output.
+		skipAllNullsInjection bool
+	}{
+		{
+			desc:         "in",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, false}, {1, true}, {nil, nil}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.NewDInt(3)},
+		},
+		{
+			desc:         "not in",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, true}, {1, false}, {nil, nil}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.NewDInt(3)},
+			negate:       true,
+		},
+		{
+			// If there is no match and the set contains NULL, the result is
+			// NULL rather than false.
+			desc:         "in with NULL in set",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, nil}, {1, true}, {nil, nil}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.DNull},
+		},
+		{
+			desc:         "not in with NULL in set",
+			typ:          types.Int,
+			inputTuples:  colexectestutils.Tuples{{0}, {1}, {nil}},
+			outputTuples: colexectestutils.Tuples{{0, nil}, {1, false}, {nil, nil}},
+			set:          []tree.Datum{tree.NewDInt(1), tree.DNull},
+			negate:       true,
+		},
+		{
+			desc:                  "in set with only NULL",
+			typ:                   types.Int,
+			inputTuples:           colexectestutils.Tuples{{0}, {nil}},
+			outputTuples:          colexectestutils.Tuples{{0, nil}, {nil, nil}},
+			set:                   []tree.Datum{tree.DNull},
+			skipAllNullsInjection: true,
+		},
+		{
+			// The result is false even for NULL values when the set is empty.
+			desc:                  "in empty set",
+			typ:                   types.Int,
+			inputTuples:           colexectestutils.Tuples{{0}, {nil}},
+			outputTuples:          colexectestutils.Tuples{{0, false}, {nil, false}},
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:                  "not in empty set",
+			typ:                   types.Int,
+			inputTuples:           colexectestutils.Tuples{{0}, {nil}},
+			outputTuples:          colexectestutils.Tuples{{0, true}, {nil, true}},
+			negate:                true,
+			skipAllNullsInjection: true,
+		},
+		{
+			desc:         "floats",
+			typ:          types.Float,
+			inputTuples:  colexectestutils.Tuples{{negativeZero}, {1.5}, {2.5}},
+			outputTuples: colexectestutils.Tuples{{negativeZero, true}, {1.5, true}, {2.5, false}},
+			set:          []tree.Datum{tree.NewDFloat(0), tree.NewDFloat(1.5)},
+		},
+		{
+			// The decimals with different number of trailing zeroes are equal.
+			desc:         "decimals",
+			typ:          types.Decimal,
+			inputTuples:  colexectestutils.Tuples{{1.0}, {2.5}, {3.0}},
+			outputTuples: colexectestutils.Tuples{{1.0, true}, {2.5, true}, {3.0, false}},
+			set: []tree.Datum{
+				&tree.DDecimal{Decimal: *apd.New(1, 0)}, &tree.DDecimal{Decimal: *apd.New(250, -2)},
+			},
+		},
+		{
+			desc:         "strings",
+			typ:          types.String,
+			inputTuples:  colexectestutils.Tuples{{"a"}, {""}, {"abc"}},
+			outputTuples: colexectestutils.Tuples{{"a", true}, {"", true}, {"abc", false}},
+			set:          []tree.Datum{tree.NewDString(""), tree.NewDString("a"), tree.NewDString("ab")},
+		},
+	}
+
+	for _, c := range testCases {
+		log.Infof(context.Background(), "%s", c.desc)
+		opConstructor := func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return GetInHashProjectionOperator(
+				testAllocator, c.typ, input[0], 0 /* colIdx */, 1 /* resultIdx */, makeInHashTestTuple(c.typ, c.set...), c.negate,
+			)
+		}
+		typs := [][]*types.T{{c.typ}}
+		if c.skipAllNullsInjection {
+			colexectestutils.RunTestsWithoutAllNullsInjection(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		} else {
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{c.inputTuples}, typs, c.outputTuples, colexectestutils.OrderedVerifier, opConstructor)
+		}
+	}
+}
+
+// TestInHashSetBuiltOnce verifies that the hash set of the IN operators is
+// built lazily when the first non-empty batch is read and that it is reused
+// for all of the following batches.
+func TestInHashSetBuiltOnce(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	typs := []*types.T{types.Int}
+	var largeInput colexectestutils.Tuples
+	for i := 0; i < 10*coldata.BatchSize()+1; i++ {
+		largeInput = append(largeInput, colexectestutils.Tuple{i % 8})
+	}
+	smallSet := makeInHashTestTuple(types.Int, tree.NewDInt(1), tree.DNull, tree.NewDInt(5))
+	for _, tc := range []struct {
+		desc        string
+		inputTuples colexectestutils.Tuples
+		set         *tree.DTuple
+		// expectBuilt indicates whether the set must be built once all
+		// batches have been read.
+		expectBuilt bool
+	}{
+		{
+			desc:        "large input",
+			inputTuples: largeInput,
+			set:         smallSet,
+			expectBuilt: true,
+		},
+		{
+			desc:        "empty input",
+			inputTuples: colexectestutils.Tuples{},
+			set:         smallSet,
+		},
+		{
+			// IN an empty subquery is false for every value, so the set is
+			// never probed.
+			desc:        "empty set",
+			inputTuples: largeInput,
+			set:         makeInHashTestTuple(types.Int),
+		},
+	} {
+		for _, project := range []bool{false, true} {
+			input := colexectestutils.NewOpTestInput(testAllocator, coldata.BatchSize(), tc.inputTuples, typs)
+			var op colexecop.Operator
+			var set *inHashSet
+			if project {
+				var err error
+				op, err = GetInHashProjectionOperator(
+					testAllocator, types.Int, input, 0 /* colIdx */, 1 /* resultIdx */, tc.set, false, /* negate */
+				)
+				require.NoError(t, err)
+				set = &op.(*projectInHashOpInt64).set
+			} else {
+				var err error
+				op, err = GetInHashOperator(testAllocator, types.Int, input, 0 /* colIdx */, tc.set, false /* negate */)
+				require.NoError(t, err)
+				set = &op.(*selectInHashOpInt64).set
+			}
+			op.Init(ctx)
+			require.False(t, set.built, tc.desc)
+			var first []uint64
+			for batch := op.Next(); batch.Length() > 0; batch = op.Next() {
+				if !tc.expectBuilt {
+					continue
+				}
+				require.True(t, set.built, tc.desc)
+				if first == nil {
+					first = set.first
+				} else {
+					require.True(t, &first[0] == &set.first[0], "%s: the set was rebuilt", tc.desc)
+				}
+			}
+			require.Equal(t, tc.expectBuilt, set.built, tc.desc)
+		}
+	}
+}
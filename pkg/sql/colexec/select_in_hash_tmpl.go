@@ -0,0 +1,479 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for select_in_hash.eg.go. It's formatted
+// in a special way, so it's both valid Go and a valid text/template input.
+// This permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"context"
+	"unsafe"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexechash"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/execgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// Workaround for bazel auto-generated code. goimports does not automatically
+// pick up the right packages when run within the bazel sandbox.
+var (
+	_ apd.Context
+	_ duration.Duration
+	_ coldataext.Datum
+	_ json.JSON
+)
+
+// Remove unused warnings.
+var (
+	_ = colexecerror.InternalError
+)
+
+// {{/*
+
+type _GOTYPESLICE interface{}
+type _GOTYPE interface{}
+type _TYPE interface{}
+
+// _CANONICAL_TYPE_FAMILY is the template variable.
+const _CANONICAL_TYPE_FAMILY = types.UnknownFamily
+
+// _TYPE_WIDTH is the template variable.
+const _TYPE_WIDTH = 0
+
+func _COMPARE(_, _, _, _, _ string) bool {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// GetInHashProjectionOperator returns an operator that projects the result of
+// comparing the values in the column at colIdx against the elements of
+// datumTuple with IN (or NOT IN if negate is true) into the column at
+// resultIdx.
+//
+// Unlike the operator returned by GetInProjectionOperator, which performs a
+// binary search over the sorted elements, this operator probes a hash set of
+// the elements, so it is meant for large tuples, e.g. the buffered results of
+// IN subqueries. The hash set is built lazily when the first non-empty batch
+// is read from the input and is reused for all of the following batches. The
+// elements of datumTuple must have the same physical representation as t, and
+// the caller must ensure that the equal values of type t have equal hashes,
+// which doesn't hold for some types (e.g. intervals).
+func GetInHashProjectionOperator(
+	allocator *colmem.Allocator,
+	t *types.T,
+	input colexecop.Operator,
+	colIdx int,
+	resultIdx int,
+	datumTuple *tree.DTuple,
+	negate bool,
+) (colexecop.Operator, error) {
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			obj := &projectInHashOp_TYPE{
+				OneInputHelper: colexecop.MakeOneInputHelper(input),
+				colIdx:         colIdx,
+				outputIdx:      resultIdx,
+				negate:         negate,
+			}
+			obj.set.init(allocator, t, datumTuple)
+			return obj, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unhandled type: %s", t.Name())
+}
+
+// GetInHashOperator returns an operator that selects the tuples for which the
+// value in the column at colIdx is IN (or NOT IN if negate is true) the
+// elements of datumTuple. See GetInHashProjectionOperator for more details.
+func GetInHashOperator(
+	allocator *colmem.Allocator,
+	t *types.T,
+	input colexecop.Operator,
+	colIdx int,
+	datumTuple *tree.DTuple,
+	negate bool,
+) (colexecop.Operator, error) {
+	switch typeconv.TypeFamilyToCanonicalTypeFamily(t.Family()) {
+	// {{range .}}
+	case _CANONICAL_TYPE_FAMILY:
+		switch t.Width() {
+		// {{range .WidthOverloads}}
+		case _TYPE_WIDTH:
+			obj := &selectInHashOp_TYPE{
+				OneInputHelper: colexecop.MakeOneInputHelper(input),
+				colIdx:         colIdx,
+				negate:         negate,
+			}
+			obj.set.init(allocator, t, datumTuple)
+			return obj, nil
+			// {{end}}
+		}
+		// {{end}}
+	}
+	return nil, errors.Errorf("unhandled type: %s", t.Name())
+}
+
+// CanUseInHashSet returns whether the elements of datumTuple can be stored in
+// the hash set of the IN operators that probe the values of type typ. The
+// elements are converted into the physical representation of typ, so they
+// must be of an equivalent type, and the integers must not be narrowed.
+func CanUseInHashSet(typ *types.T, datumTuple *tree.DTuple) bool {
+	switch typ.Family() {
+	case types.IntervalFamily, types.JsonFamily, types.ArrayFamily, types.TupleFamily:
+		// The values of these types that are equal might not have the same
+		// hash (e.g. '1 day' and '24 hours' intervals).
+		return false
+	case types.IntFamily:
+		if typ.Width() != 64 {
+			return false
+		}
+	}
+	for _, d := range datumTuple.D {
+		if d != tree.DNull && !d.ResolvedType().Equivalent(typ) {
+			return false
+		}
+	}
+	return true
+}
+
+// inHashSet is a hash set of the elements of a tuple that is probed by the
+// IN operators.
+type inHashSet struct {
+	allocator *colmem.Allocator
+	hasher    colexechash.VecHasher
+	typ       *types.T
+	// datumTuple contains the elements of the set which are converted into
+	// vals when the set is built.
+	datumTuple *tree.DTuple
+	// built indicates whether the set has already been built.
+	built bool
+	// vals stores the non-NULL elements of the tuple. The ID of the element
+	// at index i is i+1.
+	vals coldata.Vec
+	// first stores the ID of the first element in each hash bucket, 0 means
+	// that the bucket is empty.
+	first []uint64
+	// next stores the ID of the next element in the same hash bucket for the
+	// element with the corresponding ID, 0 marks the end of the chain.
+	next       []uint64
+	numBuckets uint64
+	// hasNulls indicates whether the tuple contains NULLs.
+	hasNulls bool
+	// isEmpty indicates whether the tuple is empty in which case the result
+	// of IN is false even for NULL values.
+	isEmpty bool
+}
+
+const sizeOfUint64 = int64(unsafe.Sizeof(uint64(0)))
+
+// init initializes the set with the elements of datumTuple. The set itself is
+// built only once the operator needs to probe it, see buildInHashSet.
+func (s *inHashSet) init(allocator *colmem.Allocator, t *types.T, datumTuple *tree.DTuple) {
+	s.allocator = allocator
+	s.typ = t
+	s.datumTuple = datumTuple
+	s.isEmpty = len(datumTuple.D) == 0
+}
+
+// allocVals allocates the vector that will store the non-NULL elements of the
+// tuple. The vector must be populated by the caller before the hash chains are
+// built.
+func (s *inHashSet) allocVals() {
+	var numVals int
+	for _, d := range s.datumTuple.D {
+		if d != tree.DNull {
+			numVals++
+		}
+	}
+	s.vals = s.allocator.NewMemColumn(s.typ, numVals)
+	s.hasNulls = numVals < len(s.datumTuple.D)
+}
+
+// buildChains builds the hash chains over the elements stored in s.vals.
+func (s *inHashSet) buildChains(ctx context.Context) {
+	s.hasher.Init(ctx)
+	numVals := s.vals.Length()
+	s.numBuckets = 1
+	for s.numBuckets < uint64(numVals) {
+		s.numBuckets *= 2
+	}
+	s.first = make([]uint64, s.numBuckets)
+	s.next = make([]uint64, numVals+1)
+	s.allocator.AdjustMemoryUsage(sizeOfUint64 * int64(len(s.first)+len(s.next)))
+	for start := 0; start < numVals; start += coldata.BatchSize() {
+		end := start + coldata.BatchSize()
+		if end > numVals {
+			end = numVals
+		}
+		buckets := s.hasher.ComputeBuckets(s.vals.Window(start, end), end-start, nil /* sel */, s.numBuckets)
+		for i, bucket := range buckets {
+			id := uint64(start + i + 1)
+			s.next[id] = s.first[bucket]
+			s.first[bucket] = id
+		}
+	}
+}
+
+// computeBuckets returns the hash buckets of the first n values of vec
+// according to sel.
+func (s *inHashSet) computeBuckets(vec coldata.Vec, n int, sel []int) []uint64 {
+	return s.hasher.ComputeBuckets(vec, n, sel, s.numBuckets)
+}
+
+// {{range .}}
+// {{range .WidthOverloads}}
+
+type selectInHashOp_TYPE struct {
+	colexecop.OneInputHelper
+	colIdx int
+	set    inHashSet
+	vals   _GOTYPESLICE
+	negate bool
+}
+
+var _ colexecop.Operator = &selectInHashOp_TYPE{}
+
+type projectInHashOp_TYPE struct {
+	colexecop.OneInputHelper
+	colIdx    int
+	outputIdx int
+	set       inHashSet
+	vals      _GOTYPESLICE
+	negate    bool
+}
+
+var _ colexecop.Operator = &projectInHashOp_TYPE{}
+
+// buildInHashSet_TYPE populates s with the non-NULL elements of its tuple
+// and builds the hash chains over them. It returns the elements of the set.
+func buildInHashSet_TYPE(ctx context.Context, s *inHashSet) _GOTYPESLICE {
+	s.allocVals()
+	conv := colconv.GetDatumToPhysicalFn(s.typ)
+	vals := s.vals.TemplateType()
+	s.allocator.PerformOperation([]coldata.Vec{s.vals}, func() {
+		var idx int
+		for _, d := range s.datumTuple.D {
+			if d != tree.DNull {
+				v := conv(d).(_GOTYPE)
+				execgen.SET(vals, idx, v)
+				idx++
+			}
+		}
+	})
+	s.buildChains(ctx)
+	s.built = true
+	return vals
+}
+
+// cmpInHash_TYPE checks whether targetElem, which is assigned to the given
+// hash bucket, is present in the set.
+func cmpInHash_TYPE(
+	targetElem _GOTYPE, targetCol _GOTYPESLICE, s *inHashSet, vals _GOTYPESLICE, bucket uint64,
+) comparisonResult {
+	for id := s.first[bucket]; id != 0; id = s.next[id] {
+		setElem := vals.Get(int(id - 1))
+		var cmpResult int
+		_COMPARE(cmpResult, targetElem, setElem, targetCol, _)
+		if cmpResult == 0 {
+			return siTrue
+		}
+	}
+	if s.hasNulls {
+		return siNull
+	}
+	return siFalse
+}
+
+func (si *selectInHashOp_TYPE) Init(ctx context.Context) {
+	if !si.InitHelper.Init(ctx) {
+		return
+	}
+	si.Input.Init(si.Ctx)
+}
+
+func (si *selectInHashOp_TYPE) Next() coldata.Batch {
+	for {
+		batch := si.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return coldata.ZeroBatch
+		}
+		if si.set.isEmpty {
+			// Every value (including NULL) is NOT IN an empty tuple.
+			if si.negate {
+				return batch
+			}
+			continue
+		}
+		if !si.set.built {
+			si.vals = buildInHashSet_TYPE(si.Ctx, &si.set)
+		}
+
+		vec := batch.ColVec(si.colIdx)
+		col := vec.TemplateType()
+		nulls := vec.Nulls()
+		hasNulls := vec.MaybeHasNulls()
+		buckets := si.set.computeBuckets(vec, n, batch.Selection())
+
+		compVal := siTrue
+		if si.negate {
+			compVal = siFalse
+		}
+
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for j, i := range sel {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				v := col.Get(i)
+				if cmpInHash_TYPE(v, col, &si.set, si.vals, buckets[j]) == compVal {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()
+			for i := 0; i < n; i++ {
+				if hasNulls && nulls.NullAt(i) {
+					continue
+				}
+				v := col.Get(i)
+				if cmpInHash_TYPE(v, col, &si.set, si.vals, buckets[i]) == compVal {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+func (pi *projectInHashOp_TYPE) Init(ctx context.Context) {
+	if !pi.InitHelper.Init(ctx) {
+		return
+	}
+	pi.Input.Init(pi.Ctx)
+}
+
+func (pi *projectInHashOp_TYPE) Next() coldata.Batch {
+	batch := pi.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+
+	vec := batch.ColVec(pi.colIdx)
+	col := vec.TemplateType()
+	nulls := vec.Nulls()
+	hasNulls := vec.MaybeHasNulls()
+
+	projVec := batch.ColVec(pi.outputIdx)
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projNulls.UnsetNulls()
+	}
+
+	cmpVal := siTrue
+	if pi.negate {
+		cmpVal = siFalse
+	}
+
+	sel := batch.Selection()
+	if pi.set.isEmpty {
+		// Every value (including NULL) is NOT IN an empty tuple.
+		if sel != nil {
+			for _, i := range sel[:n] {
+				projCol[i] = pi.negate
+			}
+		} else {
+			for i := 0; i < n; i++ {
+				projCol[i] = pi.negate
+			}
+		}
+		return batch
+	}
+	if !pi.set.built {
+		pi.vals = buildInHashSet_TYPE(pi.Ctx, &pi.set)
+	}
+
+	buckets := pi.set.computeBuckets(vec, n, sel)
+	if sel != nil {
+		sel = sel[:n]
+		for j, i := range sel {
+			if hasNulls && nulls.NullAt(i) {
+				projNulls.SetNull(i)
+				continue
+			}
+			v := col.Get(i)
+			cmpRes := cmpInHash_TYPE(v, col, &pi.set, pi.vals, buckets[j])
+			if cmpRes == siNull {
+				projNulls.SetNull(i)
+			} else {
+				projCol[i] = cmpRes == cmpVal
+			}
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			if hasNulls && nulls.NullAt(i) {
+				projNulls.SetNull(i)
+				continue
+			}
+			v := col.Get(i)
+			cmpRes := cmpInHash_TYPE(v, col, &pi.set, pi.vals, buckets[i])
+			if cmpRes == siNull {
+				projNulls.SetNull(i)
+			} else {
+				projCol[i] = cmpRes == cmpVal
+			}
+		}
+	}
+	return batch
+}
+
+// {{end}}
+// {{end}}
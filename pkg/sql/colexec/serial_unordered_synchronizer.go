@@ -15,9 +15,12 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
 
@@ -27,6 +30,13 @@ import (
 // for a parallel implementation. The serial one is used when concurrency is
 // undesirable - for example when the whole query is planned on the gateway and
 // we want to run it in the RootTxn.
+//
+// The batches are returned exactly as they are produced by the inputs, so it
+// is possible that some of them have a selection vector set while others
+// don't, even when coming from the different inputs of the same UNION ALL. As
+// with any other operator, the consumers must respect the selection vector. If
+// the consumer requires dense batches, the inputs should be compacted with
+// CompactUnorderedSynchronizerInputs before creating the synchronizer.
 type SerialUnorderedSynchronizer struct {
 	colexecop.InitHelper
 	span *tracing.Span
@@ -61,6 +71,23 @@ func NewSerialUnorderedSynchronizer(
 	}
 }
 
+// CompactUnorderedSynchronizerInputs returns the inputs with the root of each
+// one wrapped into a deselector, so that an unordered synchronizer (either
+// serial or parallel) created on top of them emits only the batches without a
+// selection vector. allocators must contain a separate allocator for each
+// input since the ParallelUnorderedSynchronizer consumes its inputs
+// concurrently.
+func CompactUnorderedSynchronizerInputs(
+	allocators []*colmem.Allocator, inputs []colexecargs.OpWithMetaInfo, typs []*types.T,
+) []colexecargs.OpWithMetaInfo {
+	compacted := make([]colexecargs.OpWithMetaInfo, len(inputs))
+	for i := range inputs {
+		compacted[i] = inputs[i]
+		compacted[i].Root = colexecutils.NewDeselectorOp(allocators[i], inputs[i].Root, typs)
+	}
+	return compacted
+}
+
 // Init is part of the colexecop.Operator interface.
 func (s *SerialUnorderedSynchronizer) Init(ctx context.Context) {
 	if !s.InitHelper.Init(ctx) {
@@ -12,14 +12,18 @@ package colexec
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -66,3 +70,71 @@ func TestSerialUnorderedSynchronizer(t *testing.T) {
 	}
 	require.Equal(t, numInputs*numBatches, resultBatches)
 }
+
+// TestUnorderedSynchronizersMixedSelection verifies that both unordered
+// synchronizers correctly handle the inputs of which only some use a selection
+// vector and that only the batches without a selection vector are emitted when
+// the inputs are compacted.
+func TestUnorderedSynchronizersMixedSelection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(ctx)
+	typs := []*types.T{types.Int}
+	expected := colexectestutils.Tuples{{0}, {2}, {5}, {nil}, {6}}
+	for _, parallel := range []bool{false, true} {
+		for _, compact := range []bool{false, true} {
+			// The parallel synchronizer consumes its inputs concurrently, so
+			// each input gets its own allocator.
+			allocators := make([]*colmem.Allocator, 2)
+			for i := range allocators {
+				acc := testMemMonitor.MakeBoundAccount()
+				defer acc.Close(ctx)
+				allocators[i] = colmem.NewAllocator(ctx, &acc, testColumnFactory)
+			}
+			inputs := []colexecargs.OpWithMetaInfo{
+				{
+					// Note that all tuples must fit into a single batch, so we
+					// use no more than colexectestutils.MinBatchSize of them.
+					Root: colexectestutils.NewOpFixedSelTestInput(
+						allocators[0], []int{0, 2}, coldata.BatchSize(),
+						colexectestutils.Tuples{{0}, {1}, {2}}, typs,
+					),
+				},
+				{
+					Root: colexectestutils.NewOpTestInput(
+						allocators[1], coldata.BatchSize(), colexectestutils.Tuples{{5}, {nil}, {6}}, typs,
+					),
+				},
+			}
+			if compact {
+				inputs = CompactUnorderedSynchronizerInputs(allocators, inputs, typs)
+			}
+			var op colexecop.Operator
+			var wg sync.WaitGroup
+			if parallel {
+				op = NewParallelUnorderedSynchronizer(inputs, &wg)
+			} else {
+				op = NewSerialUnorderedSynchronizer(inputs)
+			}
+			op.Init(ctx)
+			var actual colexectestutils.Tuples
+			sawSelection := false
+			for b := op.Next(); b.Length() > 0; b = op.Next() {
+				if b.Selection() != nil {
+					sawSelection = true
+				}
+				for i := 0; i < b.Length(); i++ {
+					actual = append(actual, colexectestutils.GetTupleFromBatch(b, i))
+				}
+			}
+			wg.Wait()
+			require.NoError(t, colexectestutils.AssertTuplesSetsEqual(expected, actual, evalCtx))
+			// Without the compaction, the batches from the first input must
+			// keep their selection vector.
+			require.Equal(t, !compact, sawSelection)
+		}
+	}
+}
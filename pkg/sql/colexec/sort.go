@@ -15,6 +15,7 @@ import (
 	"math"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
@@ -37,6 +38,84 @@ func NewSorter(
 	return newSorter(allocator, newAllSpooler(allocator, input, inputTypes), inputTypes, orderingCols)
 }
 
+// NewStableSorter returns a new operator that sorts its input on the columns
+// given in orderingCols while preserving the input order among the tuples that
+// are equal on orderingCols.
+//
+// The sort itself is performed by the operator returned by createSorter (which
+// can be the in-memory sorter, the external sorter, or the disk-backed
+// combination of the two). The input to that operator has an ordinality column
+// appended, and orderingCols are extended with that column as the last
+// tie-breaker. The column is projected out from the output. Since the ordinals
+// are assigned before the tuples reach the sorter, they remain correct across
+// the batch boundaries and when the sorter spills to disk.
+func NewStableSorter(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	orderingCols []execinfrapb.Ordering_Column,
+	createSorter func(colexecop.Operator, []*types.T, []execinfrapb.Ordering_Column) colexecop.Operator,
+) colexecop.Operator {
+	ordinalityIdx := len(inputTypes)
+	ordinalityOp := colexecbase.NewOrdinalityOp(allocator, input, ordinalityIdx)
+	sortTypes := make([]*types.T, 0, len(inputTypes)+1)
+	sortTypes = append(sortTypes, inputTypes...)
+	sortTypes = append(sortTypes, types.Int)
+	sortOrderingCols := make([]execinfrapb.Ordering_Column, 0, len(orderingCols)+1)
+	sortOrderingCols = append(sortOrderingCols, orderingCols...)
+	sortOrderingCols = append(sortOrderingCols, execinfrapb.Ordering_Column{
+		ColIdx:    uint32(ordinalityIdx),
+		Direction: execinfrapb.Ordering_Column_ASC,
+	})
+	sorter := createSorter(ordinalityOp, sortTypes, sortOrderingCols)
+	projection := make([]uint32, len(inputTypes))
+	for i := range projection {
+		projection[i] = uint32(i)
+	}
+	return colexecbase.NewSimpleProjectOp(sorter, sortTypes, projection)
+}
+
+// SortKeyProjection describes a column that is computed from the input of the
+// sorter only in order to be used as a sort key.
+type SortKeyProjection struct {
+	// Type is the type of the computed column.
+	Type *types.T
+	// Project returns an operator that computes the sort key from the columns
+	// of its input and writes it into the column at outputIdx.
+	Project func(input colexecop.Operator, outputIdx int) (colexecop.Operator, error)
+}
+
+// NewSorterWithComputedKeys returns a new sort operator, which sorts its input
+// on the columns given in orderingCols. The first len(inputTypes) columns refer
+// to the columns of the input, and the following ones refer to the sort keys
+// computed by keyProjections (in the same order). The computed columns are only
+// used for ordering and are not included into the output, so the output schema
+// is the same as the schema of the input.
+func NewSorterWithComputedKeys(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	keyProjections []SortKeyProjection,
+	orderingCols []execinfrapb.Ordering_Column,
+) (colexecop.Operator, error) {
+	sortTypes := make([]*types.T, 0, len(inputTypes)+len(keyProjections))
+	sortTypes = append(sortTypes, inputTypes...)
+	for _, key := range keyProjections {
+		var err error
+		input, err = key.Project(input, len(sortTypes))
+		if err != nil {
+			return nil, err
+		}
+		sortTypes = append(sortTypes, key.Type)
+	}
+	sorter, err := newSorter(allocator, newAllSpooler(allocator, input, sortTypes), sortTypes, orderingCols)
+	if err != nil {
+		return nil, err
+	}
+	sorter.(*sortOp).outputTypes = inputTypes
+	return sorter, nil
+}
+
 func newSorter(
 	allocator *colmem.Allocator,
 	input spooler,
@@ -62,6 +141,7 @@ func newSorter(
 		allocator:    allocator,
 		input:        input,
 		inputTypes:   inputTypes,
+		outputTypes:  inputTypes,
 		sorters:      make([]colSorter, len(orderingCols)),
 		partitioners: partitioners,
 		orderingCols: orderingCols,
@@ -185,12 +265,17 @@ func (p *allSpooler) Reset(ctx context.Context) {
 
 type sortOp struct {
 	colexecop.InitHelper
+	colexecop.CloserHelper
 
 	allocator *colmem.Allocator
 	input     spooler
 
 	// inputTypes contains the types of all of the columns from input.
 	inputTypes []*types.T
+	// outputTypes contains the types of the columns that are emitted. These
+	// are always a prefix of inputTypes: the remaining columns, if any, are
+	// only used as the sort keys.
+	outputTypes []*types.T
 	// orderingCols is the ordered list of column orderings that the sorter should
 	// sort on.
 	orderingCols []execinfrapb.Ordering_Column
@@ -202,6 +287,11 @@ type sortOp struct {
 	// partitioners contains one partitioner per sort column except for the last,
 	// which doesn't need to be partitioned.
 	partitioners []partitioner
+	// mergeSortedRuns, if true, indicates that the input consists of the runs
+	// already sorted on orderingCols which are delimited by the last input
+	// column, so the runs can be merged instead of being sorted from scratch
+	// (see NewSorterOverSortedRuns).
+	mergeSortedRuns bool
 
 	// order maintains the order of tuples in the batch, after sorting. The value
 	// at index i in order is the ordinal value of the tuple in the input that
@@ -220,6 +310,7 @@ type sortOp struct {
 
 var _ colexecop.BufferingInMemoryOperator = &sortOp{}
 var _ colexecop.Resetter = &sortOp{}
+var _ colexecop.Closer = &sortOp{}
 
 // colSorter is a single-column sorter, specialized on a particular type.
 type colSorter interface {
@@ -280,9 +371,9 @@ func (p *sortOp) Next() coldata.Batch {
 			// For now, we don't enforce any footprint-based memory limit.
 			// TODO(yuzefovich): refactor this.
 			const maxBatchMemSize = math.MaxInt64
-			p.output, _ = p.allocator.ResetMaybeReallocate(p.inputTypes, p.output, toEmit, maxBatchMemSize)
+			p.output, _ = p.allocator.ResetMaybeReallocate(p.outputTypes, p.output, toEmit, maxBatchMemSize)
 			newEmitted := p.emitted + toEmit
-			for j := 0; j < len(p.inputTypes); j++ {
+			for j := 0; j < len(p.outputTypes); j++ {
 				// At this point, we have already fully sorted the input. It is ok to do
 				// this Copy outside of the allocator - the work has been done, but
 				// theoretically it is possible to hit the limit here (mainly with
@@ -332,6 +423,10 @@ func (p *sortOp) sort() {
 		p.order[i] = i
 	}
 
+	if p.mergeSortedRuns && p.mergeRuns() {
+		return
+	}
+
 	for i := range p.orderingCols {
 		inputVec := p.input.getValues(int(p.orderingCols[i].ColIdx))
 		p.sorters[i] = newSingleSorter(p.inputTypes[p.orderingCols[i].ColIdx], p.orderingCols[i].Direction, inputVec.MaybeHasNulls())
@@ -418,6 +513,18 @@ func (p *sortOp) Reset(ctx context.Context) {
 	p.state = sortSpooling
 }
 
+// Close implements the colexecop.Closer interface.
+func (p *sortOp) Close(context.Context) error {
+	if !p.CloserHelper.Close() {
+		return nil
+	}
+	// The output batch is no longer needed, so we give it back to the
+	// allocator in order for the batch to be reused by other operators.
+	p.allocator.ReleaseBatch(p.output)
+	p.output = nil
+	return nil
+}
+
 func (p *sortOp) ChildCount(verbose bool) int {
 	return 1
 }
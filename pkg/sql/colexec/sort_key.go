@@ -0,0 +1,123 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colencoding"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/errors"
+)
+
+// NewSortKeyOp returns an operator that projects into the Bytes column at
+// position outputIdx a per-row sort key of the columns in ordering (which are
+// of inputTypes types).
+//
+// The sort keys are byte-comparable: comparing the keys of two rows with
+// bytes.Compare gives the same result as comparing the rows according to
+// ordering, including the direction of every column and the placement of
+// NULLs (which sort first when ascending and last when descending). The key
+// of every column is encoded with the same encoding that is used for the index
+// keys, so the values can be decoded back with
+// colencoding.DecodeKeyValsToCols.
+func NewSortKeyOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	ordering []execinfrapb.Ordering_Column,
+	outputIdx int,
+) (colexecop.Operator, error) {
+	directions := make([]encoding.Direction, len(ordering))
+	for i, col := range ordering {
+		if int(col.ColIdx) >= len(inputTypes) {
+			return nil, errors.AssertionFailedf("invalid column index %d", col.ColIdx)
+		}
+		if inputTypes[col.ColIdx].Family() == types.JsonFamily {
+			return nil, unimplemented.NewWithIssue(35706, "unable to encode JSON as a table key")
+		}
+		switch col.Direction {
+		case execinfrapb.Ordering_Column_ASC:
+			directions[i] = encoding.Ascending
+		case execinfrapb.Ordering_Column_DESC:
+			directions[i] = encoding.Descending
+		default:
+			return nil, errors.AssertionFailedf("unexpected direction %s", col.Direction)
+		}
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bytes, outputIdx)
+	return &sortKeyOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputTypes:     inputTypes,
+		ordering:       ordering,
+		directions:     directions,
+		outputIdx:      outputIdx,
+	}, nil
+}
+
+// sortKeyOp is an operator that encodes the ordering columns of every row
+// into a single order-preserving key.
+type sortKeyOp struct {
+	colexecop.OneInputHelper
+	allocator  *colmem.Allocator
+	inputTypes []*types.T
+	ordering   []execinfrapb.Ordering_Column
+	directions []encoding.Direction
+	outputIdx  int
+	// scratch is the buffer the key of a row is written into. It is reused
+	// across rows and batches.
+	scratch []byte
+}
+
+var _ colexecop.Operator = &sortKeyOp{}
+
+func (s *sortKeyOp) Next() coldata.Batch {
+	batch := s.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	outputVec := batch.ColVec(s.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	s.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			s.scratch = s.scratch[:0]
+			for j, col := range s.ordering {
+				var err error
+				s.scratch, err = colencoding.EncodeTableKeyFromCol(
+					s.scratch, batch.ColVec(int(col.ColIdx)), rowIdx, s.inputTypes[col.ColIdx], s.directions[j],
+				)
+				if err != nil {
+					colexecerror.ExpectedError(err)
+				}
+			}
+			outputCol.Set(rowIdx, s.scratch)
+		}
+	})
+	return batch
+}
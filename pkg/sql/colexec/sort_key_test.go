@@ -0,0 +1,194 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldatatestutils"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colencoding"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSortKeyOp verifies that the order of the sort keys produced by the sort
+// key operator matches the order of the rows according to the ordering and
+// that the keys can be decoded back into the original values.
+func TestSortKeyOp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	rng, _ := randutil.NewPseudoRand()
+
+	for run := 0; run < 10; run++ {
+		numCols := 1 + rng.Intn(3)
+		typs := make([]*types.T, numCols)
+		for i := range typs {
+			typs[i] = randgen.RandSortingType(rng)
+			for typs[i].Family() == types.JsonFamily || isSpatialType(typs[i]) {
+				// The key encoding of the spatial types follows the
+				// space-filling curve, so it is not consistent with their
+				// comparison.
+				typs[i] = randgen.RandSortingType(rng)
+			}
+		}
+		ordering := make([]execinfrapb.Ordering_Column, numCols)
+		for i, colIdx := range rng.Perm(numCols) {
+			ordering[i].ColIdx = uint32(colIdx)
+			if rng.Float64() < 0.5 {
+				ordering[i].Direction = execinfrapb.Ordering_Column_DESC
+			}
+		}
+		t.Run(fmt.Sprintf("types=%s/ordering=%v", typs, ordering), func(t *testing.T) {
+			nRows := 1 + rng.Intn(3*coldata.BatchSize())
+			cols := make([]coldata.Vec, numCols)
+			for i := range cols {
+				cols[i] = testAllocator.NewMemColumn(typs[i], nRows)
+				var bytesFixedLength int
+				if typs[i].Family() == types.UuidFamily {
+					bytesFixedLength = 16
+				}
+				coldatatestutils.RandomVec(coldatatestutils.RandomVecArgs{
+					Rand:             rng,
+					Vec:              cols[i],
+					N:                nRows,
+					NullProbability:  0.2,
+					BytesFixedLength: bytesFixedLength,
+					// Use a small range in order to get some duplicates.
+					IntRange: 4,
+				})
+			}
+			source := colexectestutils.NewChunkingBatchSource(testAllocator, typs, cols, nRows)
+			op, err := NewSortKeyOp(testAllocator, source, typs, ordering, numCols /* outputIdx */)
+			require.NoError(t, err)
+			op.Init(ctx)
+			keys := make([][]byte, 0, nRows)
+			for b := op.Next(); b.Length() > 0; b = op.Next() {
+				outputCol := b.ColVec(numCols).Bytes()
+				for i := 0; i < b.Length(); i++ {
+					keys = append(keys, append([]byte(nil), outputCol.Get(i)...))
+				}
+			}
+			require.Len(t, keys, nRows)
+
+			converter := colconv.NewAllVecToDatumConverter(numCols)
+			converter.ConvertVecs(cols, nRows, nil /* sel */)
+			compareRows := func(i, j int) int {
+				for _, col := range ordering {
+					datums := converter.GetDatumColumn(int(col.ColIdx))
+					cmp := datums[i].Compare(&evalCtx, datums[j])
+					if col.Direction == execinfrapb.Ordering_Column_DESC {
+						cmp = -cmp
+					}
+					if cmp != 0 {
+						return cmp
+					}
+				}
+				return 0
+			}
+			// Sort the rows by their keys and make sure that they are sorted
+			// according to the ordering.
+			order := make([]int, nRows)
+			for i := range order {
+				order[i] = i
+			}
+			sort.Slice(order, func(i, j int) bool {
+				return bytes.Compare(keys[order[i]], keys[order[j]]) < 0
+			})
+			for i := 1; i < nRows; i++ {
+				prev, cur := order[i-1], order[i]
+				cmp := compareRows(prev, cur)
+				require.LessOrEqualf(t, cmp, 0, "rows %d and %d are out of order", prev, cur)
+				if bytes.Equal(keys[prev], keys[cur]) {
+					require.Equalf(t, 0, cmp, "rows %d and %d have the same keys", prev, cur)
+				} else {
+					require.Equalf(t, -1, cmp, "rows %d and %d have different keys", prev, cur)
+				}
+			}
+
+			// Decode the keys and make sure that the original values are
+			// returned. The decoding of some datum-backed types (like the
+			// collated strings) is not supported, so we skip those.
+			indexColIdx := make([]int, numCols)
+			keyTypes := make([]*types.T, numCols)
+			directions := make([]descpb.IndexDescriptor_Direction, numCols)
+			for i, col := range ordering {
+				if typeconv.TypeFamilyToCanonicalTypeFamily(typs[col.ColIdx].Family()) == typeconv.DatumVecCanonicalTypeFamily {
+					return
+				}
+				indexColIdx[i] = int(col.ColIdx)
+				keyTypes[i] = typs[col.ColIdx]
+				if col.Direction == execinfrapb.Ordering_Column_DESC {
+					directions[i] = descpb.IndexDescriptor_DESC
+				}
+			}
+			decoded := make([]coldata.Vec, numCols)
+			for i := range decoded {
+				decoded[i] = testAllocator.NewMemColumn(typs[i], nRows)
+			}
+			var da rowenc.DatumAlloc
+			for i, key := range keys {
+				rest, _, err := colencoding.DecodeKeyValsToCols(
+					&da, decoded, i, indexColIdx, keyTypes, directions,
+					nil /* unseen */, key, -1, /* invertedColIdx */
+				)
+				require.NoError(t, err)
+				require.Empty(t, rest)
+			}
+			decodedConverter := colconv.NewAllVecToDatumConverter(numCols)
+			decodedConverter.ConvertVecs(decoded, nRows, nil /* sel */)
+			for colIdx := 0; colIdx < numCols; colIdx++ {
+				expected := converter.GetDatumColumn(colIdx)
+				actual := decodedConverter.GetDatumColumn(colIdx)
+				for i := 0; i < nRows; i++ {
+					require.Equalf(t, 0, expected[i].Compare(&evalCtx, actual[i]), "expected %s, got %s", expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func isSpatialType(t *types.T) bool {
+	switch t.Family() {
+	case types.GeometryFamily, types.GeographyFamily, types.Box2DFamily:
+		return true
+	case types.ArrayFamily:
+		return isSpatialType(t.ArrayContents())
+	case types.TupleFamily:
+		for _, contents := range t.TupleContents() {
+			if isSpatialType(contents) {
+				return true
+			}
+		}
+	}
+	return false
+}
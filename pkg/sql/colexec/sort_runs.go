@@ -0,0 +1,199 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"container/heap"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewSorterOverSortedRuns returns a new sort operator, which sorts its input
+// on the columns given in orderingCols. The input must consist of several
+// concatenated runs, each of which is already sorted on orderingCols (for
+// example, the outputs of several index reads), and the last column of the
+// input must be a boolean column that is true for the first tuple of each run.
+// Instead of sorting all of the spooled tuples from scratch, the sorter
+// performs a k-way merge of the runs unless there are too many of them.
+//
+// The run boundaries column is not included into the output, so the output
+// schema is inputTypes without the last column.
+func NewSorterOverSortedRuns(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	orderingCols []execinfrapb.Ordering_Column,
+) (colexecop.Operator, error) {
+	runStartsColIdx := len(inputTypes) - 1
+	if runStartsColIdx < 0 || inputTypes[runStartsColIdx].Family() != types.BoolFamily {
+		return nil, errors.AssertionFailedf("the last input column must be a boolean column with run boundaries")
+	}
+	for _, ord := range orderingCols {
+		if int(ord.ColIdx) == runStartsColIdx {
+			return nil, errors.AssertionFailedf("cannot sort on the run boundaries column")
+		}
+	}
+	sorter, err := newSorter(allocator, newAllSpooler(allocator, input, inputTypes), inputTypes, orderingCols)
+	if err != nil {
+		return nil, err
+	}
+	s := sorter.(*sortOp)
+	s.outputTypes = inputTypes[:runStartsColIdx]
+	s.mergeSortedRuns = true
+	return s, nil
+}
+
+// maxSortedRunsToMerge is the maximum number of the sorted runs that are
+// merged. The merge performs O(log k) comparisons via vecComparators per
+// tuple, and with many runs the specialized sort from scratch becomes faster.
+const maxSortedRunsToMerge = 8
+
+// mergeRuns populates p.order by merging the sorted runs of the spooled
+// tuples, and it returns false if there are too many runs to be merged, in
+// which case the tuples need to be sorted from scratch. The runs are delimited
+// by the last column of the input, and p.order must have already been
+// initialized to the ordinal positions.
+func (p *sortOp) mergeRuns() bool {
+	spooledTuples := p.input.getNumTuples()
+	runStartsVec := p.input.getValues(len(p.inputTypes) - 1)
+	runStarts, nulls := runStartsVec.Bool(), runStartsVec.Nulls()
+	m := &sortedRunsMerger{orderingCols: p.orderingCols}
+	runStartIdx := 0
+	for i := 1; i < spooledTuples; i++ {
+		if runStarts[i] && !nulls.NullAt(i) {
+			if len(m.runs) == maxSortedRunsToMerge-1 {
+				return false
+			}
+			m.runs = append(m.runs, sortedRun{next: runStartIdx, end: i})
+			runStartIdx = i
+		}
+	}
+	if len(m.runs) == 0 {
+		// All spooled tuples form a single sorted run, so the ordinal positions
+		// are already in the sorted order.
+		return true
+	}
+	m.runs = append(m.runs, sortedRun{next: runStartIdx, end: spooledTuples})
+	m.comparators = make([]vecComparator, len(p.orderingCols))
+	for i, ord := range p.orderingCols {
+		m.comparators[i] = GetVecComparator(p.inputTypes[ord.ColIdx], 1 /* numVecs */)
+		m.comparators[i].setVec(0, p.input.getValues(int(ord.ColIdx)))
+	}
+	heap.Init(m)
+	for i := range p.order {
+		run := &m.runs[0]
+		p.order[i] = run.next
+		run.next++
+		if run.next == run.end {
+			heap.Pop(m)
+		} else {
+			m.siftDown()
+		}
+	}
+	return true
+}
+
+// sortedRun describes the remaining tuples of a single sorted run within the
+// spooled tuples.
+type sortedRun struct {
+	// next is the index of the next tuple of the run to be merged.
+	next int
+	// end is the index of the first tuple after the run.
+	end int
+}
+
+// sortedRunsMerger is a min heap of the sorted runs ordered by their next
+// tuples.
+type sortedRunsMerger struct {
+	// comparators contains one vecComparator per ordering column which has
+	// the spooled vector of that column set at index 0.
+	comparators  []vecComparator
+	orderingCols []execinfrapb.Ordering_Column
+	runs         []sortedRun
+}
+
+var _ heap.Interface = &sortedRunsMerger{}
+
+func (m *sortedRunsMerger) compareRow(rowIdx1, rowIdx2 int) int {
+	for i, info := range m.orderingCols {
+		res := m.comparators[i].compare(0 /* vecIdx1 */, 0 /* vecIdx2 */, rowIdx1, rowIdx2)
+		if res != 0 {
+			switch d := info.Direction; d {
+			case execinfrapb.Ordering_Column_ASC:
+				return res
+			case execinfrapb.Ordering_Column_DESC:
+				return -res
+			default:
+				colexecerror.InternalError(errors.AssertionFailedf("unexpected direction value %d", d))
+			}
+		}
+	}
+	return 0
+}
+
+// siftDown restores the heap invariant after the next tuple of the run at the
+// root of the heap has been advanced. It is equivalent to heap.Fix(m, 0) but
+// avoids the overhead of the calls through heap.Interface.
+func (m *sortedRunsMerger) siftDown() {
+	n, i := len(m.runs), 0
+	for {
+		j := 2*i + 1
+		if j >= n {
+			return
+		}
+		if j+1 < n && m.Less(j+1, j) {
+			j++
+		}
+		if !m.Less(j, i) {
+			return
+		}
+		m.runs[i], m.runs[j] = m.runs[j], m.runs[i]
+		i = j
+	}
+}
+
+// Len is part of heap.Interface and is only meant to be used internally.
+func (m *sortedRunsMerger) Len() int {
+	return len(m.runs)
+}
+
+// Less is part of heap.Interface and is only meant to be used internally.
+func (m *sortedRunsMerger) Less(i, j int) bool {
+	next1, next2 := m.runs[i].next, m.runs[j].next
+	if res := m.compareRow(next1, next2); res != 0 {
+		return res < 0
+	}
+	// The ties are broken in favor of the earlier runs so that the merge is
+	// stable.
+	return next1 < next2
+}
+
+// Swap is part of heap.Interface and is only meant to be used internally.
+func (m *sortedRunsMerger) Swap(i, j int) {
+	m.runs[i], m.runs[j] = m.runs[j], m.runs[i]
+}
+
+// Push is part of heap.Interface and is only meant to be used internally.
+func (m *sortedRunsMerger) Push(x interface{}) {
+	m.runs = append(m.runs, x.(sortedRun))
+}
+
+// Pop is part of heap.Interface and is only meant to be used internally.
+func (m *sortedRunsMerger) Pop() interface{} {
+	x := m.runs[len(m.runs)-1]
+	m.runs = m.runs[:len(m.runs)-1]
+	return x
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSortOverSortedRuns verifies that the sorter over the input that consists
+// of the already sorted runs merges the runs instead of sorting the input from
+// scratch (unless there are too many runs) and that the output is correct.
+func TestSortOverSortedRuns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	const maxCols = 3
+	for nCols := 1; nCols <= maxCols; nCols++ {
+		for nOrderingCols := 1; nOrderingCols <= nCols; nOrderingCols++ {
+			for _, nRuns := range []int{1, 2, 1 + rng.Intn(maxSortedRunsToMerge), maxSortedRunsToMerge + 1} {
+				ordCols := generateColumnOrdering(rng, nCols, nOrderingCols)
+				// The input tuples consist of nCols columns followed by the
+				// boolean column which is true for the first tuple of each run.
+				var tups colexectestutils.Tuples
+				for run := 0; run < nRuns; run++ {
+					runTups := make(colexectestutils.Tuples, 1+rng.Intn(coldata.BatchSize()))
+					for i := range runTups {
+						runTups[i] = make(colexectestutils.Tuple, nCols, nCols+1)
+						for j := range runTups[i] {
+							if rng.Float64() < nullProbability {
+								runTups[i][j] = nil
+							} else {
+								// Small range so that the runs are interleaved
+								// on the first ordering columns.
+								runTups[i][j] = int64(rng.Intn(8))
+							}
+						}
+						// Enforce that the last ordering column is always
+						// unique (while the runs are still interleaved on it).
+						// Otherwise there would be multiple valid sort orders.
+						runTups[i][ordCols[nOrderingCols-1].ColIdx] = int64(i*nRuns + run)
+					}
+					sort.Slice(runTups, less(runTups, ordCols))
+					for i := range runTups {
+						runTups[i] = append(runTups[i], i == 0)
+					}
+					tups = append(tups, runTups...)
+				}
+				expected := make(colexectestutils.Tuples, len(tups))
+				for i := range tups {
+					expected[i] = tups[i][:nCols]
+				}
+				sort.Slice(expected, less(expected, ordCols))
+
+				typs := make([]*types.T, nCols+1)
+				for i := 0; i < nCols; i++ {
+					typs[i] = types.Int
+				}
+				typs[nCols] = types.Bool
+				log.Infof(ctx, "nCols=%d/nOrderingCols=%d/nRuns=%d", nCols, nOrderingCols, nRuns)
+				var sorters []*sortOp
+				// We're omitting all nulls injection test because the NULL
+				// values in the run boundaries column are not considered the
+				// run starts, so the output would be different.
+				colexectestutils.RunTestsWithoutAllNullsInjection(
+					t, testAllocator, []colexectestutils.Tuples{tups}, [][]*types.T{typs},
+					expected, colexectestutils.OrderedVerifier,
+					func(input []colexecop.Operator) (colexecop.Operator, error) {
+						sorter, err := NewSorterOverSortedRuns(testAllocator, input[0], typs, ordCols)
+						if err == nil {
+							sorters = append(sorters, sorter.(*sortOp))
+						}
+						return sorter, err
+					},
+				)
+				// The single-column sorters are only created when the spooled
+				// tuples are sorted from scratch.
+				sortedFromScratch := false
+				for _, sorter := range sorters {
+					sortedFromScratch = sortedFromScratch || sorter.sorters[0] != nil
+				}
+				require.Equal(t, nRuns > maxSortedRunsToMerge, sortedFromScratch)
+			}
+		}
+	}
+}
+
+func BenchmarkSortOverSortedRuns(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	rng, _ := randutil.NewPseudoRand()
+	const nTuples = 1 << 16
+	typs := []*types.T{types.Int, types.Bool}
+	ordCols := []execinfrapb.Ordering_Column{{ColIdx: 0}}
+	for _, nRuns := range []int{1, 4, 8, 64} {
+		for _, mergeRuns := range []bool{false, true} {
+			b.Run(fmt.Sprintf("nRuns=%d/mergeRuns=%t", nRuns, mergeRuns), func(b *testing.B) {
+				// Every run consists of increasing values with random gaps.
+				runLength := nTuples / nRuns
+				var cur int64
+				batches := make([]coldata.Batch, nTuples/coldata.BatchSize())
+				for i := range batches {
+					batches[i] = testAllocator.NewMemBatchWithMaxCapacity(typs)
+					vals, runStarts := batches[i].ColVec(0).Int64(), batches[i].ColVec(1).Bool()
+					for j := 0; j < coldata.BatchSize(); j++ {
+						idx := i*coldata.BatchSize() + j
+						runStarts[j] = idx%runLength == 0
+						if runStarts[j] {
+							cur = 0
+						}
+						cur += rng.Int63n(nTuples / int64(runLength) * 2)
+						vals[j] = cur
+					}
+					batches[i].SetLength(coldata.BatchSize())
+				}
+				b.SetBytes(int64(8 * nTuples))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var batchIdx int
+					source := &colexecop.CallbackOperator{
+						NextCb: func() coldata.Batch {
+							if batchIdx == len(batches) {
+								return coldata.ZeroBatch
+							}
+							batchIdx++
+							return batches[batchIdx-1]
+						},
+					}
+					var sorter colexecop.Operator
+					var err error
+					if mergeRuns {
+						sorter, err = NewSorterOverSortedRuns(testAllocator, source, typs, ordCols)
+					} else {
+						sorter, err = NewSorter(testAllocator, source, typs, ordCols)
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+					sorter.Init(ctx)
+					for sorter.Next().Length() > 0 {
+					}
+				}
+			})
+		}
+	}
+}
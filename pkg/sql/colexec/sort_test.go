@@ -19,10 +19,12 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecproj"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -149,6 +151,42 @@ func TestSort(t *testing.T) {
 	}
 }
 
+// TestSortWithComputedKeys verifies that the sorter can sort on an expression
+// computed from its input and that the computed column isn't included into the
+// output.
+func TestSortWithComputedKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	typs := []*types.T{types.Int, types.Int}
+	// ORDER BY a + b, a DESC
+	plus := SortKeyProjection{
+		Type: types.Int,
+		Project: func(input colexecop.Operator, outputIdx int) (colexecop.Operator, error) {
+			return colexecproj.GetProjectionOperator(
+				testAllocator, typs, types.Int, tree.Plus, input, 0 /* col1Idx */, 1, /* col2Idx */
+				outputIdx, nil /* evalCtx */, nil /* binFn */, nil, /* cmpExpr */
+			)
+		},
+	}
+	ordCols := []execinfrapb.Ordering_Column{
+		{ColIdx: 2},
+		{ColIdx: 0, Direction: execinfrapb.Ordering_Column_DESC},
+	}
+	colexectestutils.RunTestsWithTyps(
+		t,
+		testAllocator,
+		[]colexectestutils.Tuples{{{1, 5}, {3, 1}, {nil, 2}, {2, 2}, {0, 0}, {-1, 4}}},
+		[][]*types.T{typs},
+		colexectestutils.Tuples{{nil, 2}, {0, 0}, {-1, 4}, {3, 1}, {2, 2}, {1, 5}},
+		colexectestutils.OrderedVerifier,
+		func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return NewSorterWithComputedKeys(
+				testAllocator, input[0], typs, []SortKeyProjection{plus}, ordCols,
+			)
+		},
+	)
+}
+
 func TestSortRandomized(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -172,7 +210,7 @@ func TestSortRandomized(t *testing.T) {
 				}
 				colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tups}, expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
 					if topK {
-						return NewTopKSorter(testAllocator, input[0], typs[:nCols], ordCols, uint64(k)), nil
+						return NewTopKSorter(testAllocator, input[0], typs[:nCols], ordCols, uint64(k), 0 /* offset */), nil
 					}
 					return NewSorter(testAllocator, input[0], typs[:nCols], ordCols)
 				})
@@ -314,7 +352,7 @@ func BenchmarkSort(b *testing.B) {
 						source := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
 						var sorter colexecop.Operator
 						if topK {
-							sorter = NewTopKSorter(testAllocator, source, typs, ordCols, k)
+							sorter = NewTopKSorter(testAllocator, source, typs, ordCols, k, 0 /* offset */)
 						} else {
 							var err error
 							sorter, err = NewSorter(testAllocator, source, typs, ordCols)
@@ -24,4 +24,5 @@ type sortTestCase struct {
 	ordCols     []execinfrapb.Ordering_Column
 	matchLen    int
 	k           uint64
+	offset      uint64
 }
@@ -31,7 +31,8 @@ const (
 )
 
 // NewTopKSorter returns a new sort operator, which sorts its input on the
-// columns given in orderingCols and returns the first K rows. The inputTypes
+// columns given in orderingCols and returns the first K rows after skipping
+// the first offset rows. Only the top K+offset rows are stored. The inputTypes
 // must correspond 1-1 with the columns in the input operator.
 func NewTopKSorter(
 	allocator *colmem.Allocator,
@@ -39,13 +40,15 @@ func NewTopKSorter(
 	inputTypes []*types.T,
 	orderingCols []execinfrapb.Ordering_Column,
 	k uint64,
+	offset uint64,
 ) colexecop.Operator {
 	return &topKSorter{
 		allocator:    allocator,
 		OneInputNode: colexecop.NewOneInputNode(input),
 		inputTypes:   inputTypes,
 		orderingCols: orderingCols,
-		k:            k,
+		k:            k + offset,
+		offset:       offset,
 	}
 }
 
@@ -73,7 +76,10 @@ type topKSorter struct {
 	allocator    *colmem.Allocator
 	orderingCols []execinfrapb.Ordering_Column
 	inputTypes   []*types.T
-	k            uint64
+	// k is the number of rows that are stored by the sorter, including the
+	// first offset rows that are skipped when emitting.
+	k      uint64
+	offset uint64
 
 	// state is the current state of the sort.
 	state topKSortState
@@ -211,6 +217,13 @@ func (t *topKSorter) spool() {
 	for i := 0; i < t.topK.Length(); i++ {
 		t.sel[len(t.sel)-i-1] = heap.Pop(t).(int)
 	}
+	// Skip the first offset rows (possibly all of them if the input had fewer
+	// rows than that).
+	if t.offset < uint64(len(t.sel)) {
+		t.emitted = int(t.offset)
+	} else {
+		t.emitted = len(t.sel)
+	}
 }
 
 func (t *topKSorter) emit() coldata.Batch {
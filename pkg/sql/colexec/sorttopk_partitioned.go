@@ -0,0 +1,310 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"container/heap"
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewTopKPerPartitionSorter returns a new operator that returns at most K
+// rows of each partition of its input, in the order given by orderingCols
+// within each partition. This is the same as filtering the result of the
+// ROW_NUMBER window function (with the same partitioning and ordering) by
+// 'row_number <= K', but only K rows of a single partition are ever buffered
+// (instead of the whole input being sorted).
+//
+// The input must be ordered on partitionCols (in any direction), so that all
+// rows of a single partition are contiguous. The partitions are emitted in the
+// order in which they are seen. The unordered input is not supported, so it
+// has to be sorted on partitionCols first. The inputTypes must correspond 1-1
+// with the columns in the input operator.
+func NewTopKPerPartitionSorter(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	partitionCols []uint32,
+	orderingCols []execinfrapb.Ordering_Column,
+	k uint64,
+) colexecop.Operator {
+	return &topKPerPartitionSorter{
+		OneInputNode:  colexecop.NewOneInputNode(input),
+		allocator:     allocator,
+		inputTypes:    inputTypes,
+		partitionCols: partitionCols,
+		orderingCols:  orderingCols,
+		k:             k,
+	}
+}
+
+// topKPerPartitionSortState represents the state of the top K per partition
+// sort operator.
+type topKPerPartitionSortState int
+
+const (
+	// topKPerPartitionSpooling is the initial state of the operator, where it
+	// spools the current partition of its input, keeping only the top K rows.
+	topKPerPartitionSpooling topKPerPartitionSortState = iota
+	// topKPerPartitionEmitting indicates that the operator is copying the
+	// sorted top K rows of the last spooled partition into the output.
+	topKPerPartitionEmitting
+	// topKPerPartitionDone is the final state of the operator, where the input
+	// has been fully consumed and all of the partitions have been emitted.
+	topKPerPartitionDone
+)
+
+type topKPerPartitionSorter struct {
+	colexecop.OneInputNode
+	colexecop.InitHelper
+
+	allocator     *colmem.Allocator
+	inputTypes    []*types.T
+	partitionCols []uint32
+	orderingCols  []execinfrapb.Ordering_Column
+	k             uint64
+
+	state topKPerPartitionSortState
+	// inputBatch is the last read batch from the input.
+	inputBatch coldata.Batch
+	// firstUnprocessedTupleIdx indicates the index of the first tuple in
+	// inputBatch that hasn't been processed yet.
+	firstUnprocessedTupleIdx int
+	// comparators stores one comparator per input column.
+	comparators []vecComparator
+	// topK stores the top K rows of the current partition. It is not sorted
+	// internally.
+	topK *colexecutils.AppendOnlyBufferedBatch
+	// heap is a max heap which stores indices into topK.
+	heap []int
+	// sel is a selection vector which specifies an ordering on topK once the
+	// current partition has been fully spooled.
+	sel []int
+	// emitted is the number of rows of the current partition that have been
+	// copied into the output so far.
+	emitted int
+	output  coldata.Batch
+}
+
+var _ colexecop.Operator = &topKPerPartitionSorter{}
+
+func (t *topKPerPartitionSorter) Init(ctx context.Context) {
+	if !t.InitHelper.Init(ctx) {
+		return
+	}
+	t.Input.Init(t.Ctx)
+	t.topK = colexecutils.NewAppendOnlyBufferedBatch(t.allocator, t.inputTypes, nil /* colsToStore */)
+	t.comparators = make([]vecComparator, len(t.inputTypes))
+	for i, typ := range t.inputTypes {
+		t.comparators[i] = GetVecComparator(typ, 2)
+	}
+}
+
+func (t *topKPerPartitionSorter) Next() coldata.Batch {
+	if t.state == topKPerPartitionDone || t.k == 0 {
+		return coldata.ZeroBatch
+	}
+	// The output only contains the rows that have already been buffered in
+	// topK, so we don't enforce any footprint-based limit.
+	const maxBatchMemSize = math.MaxInt64
+	t.output, _ = t.allocator.ResetMaybeReallocate(t.inputTypes, t.output, coldata.BatchSize(), maxBatchMemSize)
+	outputIdx := 0
+	// The rows of multiple partitions are accumulated into the same output
+	// batch until it is full.
+	for outputIdx < t.output.Capacity() && t.state != topKPerPartitionDone {
+		switch t.state {
+		case topKPerPartitionSpooling:
+			t.spoolPartition()
+			if t.topK.Length() == 0 {
+				// The input has been fully consumed.
+				t.state = topKPerPartitionDone
+			} else {
+				t.state = topKPerPartitionEmitting
+			}
+		case topKPerPartitionEmitting:
+			toEmit := len(t.sel) - t.emitted
+			if remaining := t.output.Capacity() - outputIdx; toEmit > remaining {
+				toEmit = remaining
+			}
+			t.allocator.PerformOperation(t.output.ColVecs(), func() {
+				for i := range t.inputTypes {
+					t.output.ColVec(i).Copy(
+						coldata.CopySliceArgs{
+							SliceArgs: coldata.SliceArgs{
+								Src:         t.topK.ColVec(i),
+								Sel:         t.sel,
+								DestIdx:     outputIdx,
+								SrcStartIdx: t.emitted,
+								SrcEndIdx:   t.emitted + toEmit,
+							},
+						},
+					)
+				}
+			})
+			outputIdx += toEmit
+			t.emitted += toEmit
+			if t.emitted == len(t.sel) {
+				// The current partition has been fully emitted, so we reset
+				// the state in order to spool the next one.
+				t.topK.ResetInternalBatch()
+				t.emitted = 0
+				t.state = topKPerPartitionSpooling
+			}
+		default:
+			colexecerror.InternalError(errors.AssertionFailedf("invalid top K per partition sort state %v", t.state))
+		}
+	}
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	t.output.SetLength(outputIdx)
+	return t.output
+}
+
+// spoolPartition reads in the rows of the current partition, always storing
+// the top K rows it has seen so far in t.topK (the same way as topKSorter
+// does). The spooling stops either when the input is exhausted or when the
+// first row of the next partition is encountered (that row stays unprocessed
+// in t.inputBatch).
+//
+// Once the partition has been spooled, everything is popped off the heap to
+// determine the output ordering of the partition.
+func (t *topKPerPartitionSorter) spoolPartition() {
+	t.heap = t.heap[:0]
+spooling:
+	for {
+		if t.inputBatch != nil && t.inputBatch.Length() == 0 {
+			// The input has already been exhausted.
+			break
+		}
+		if t.inputBatch == nil || t.firstUnprocessedTupleIdx == t.inputBatch.Length() {
+			t.inputBatch = t.Input.Next()
+			t.firstUnprocessedTupleIdx = 0
+			if t.inputBatch.Length() == 0 {
+				break
+			}
+		}
+		t.updateComparators(inputVecIdx, t.inputBatch)
+		sel := t.inputBatch.Selection()
+		for ; t.firstUnprocessedTupleIdx < t.inputBatch.Length(); t.firstUnprocessedTupleIdx++ {
+			idx := t.firstUnprocessedTupleIdx
+			if sel != nil {
+				idx = sel[idx]
+			}
+			// All rows in t.topK belong to the current partition, so we can
+			// compare against any one of them.
+			if t.topK.Length() > 0 && !t.samePartition(idx) {
+				break spooling
+			}
+			if uint64(t.topK.Length()) < t.k {
+				t.allocator.PerformOperation(t.topK.ColVecs(), func() {
+					t.topK.AppendTuples(t.inputBatch, t.firstUnprocessedTupleIdx, t.firstUnprocessedTupleIdx+1)
+				})
+				// Appending might have reallocated the vectors of t.topK.
+				t.updateComparators(topKVecIdx, t.topK)
+				heap.Push(t, t.topK.Length()-1)
+				continue
+			}
+			// Whenever a row is less than the heap max, swap it in.
+			maxIdx := t.heap[0]
+			if t.compareRow(inputVecIdx, topKVecIdx, idx, maxIdx) < 0 {
+				t.allocator.PerformOperation(t.topK.ColVecs(), func() {
+					for j := range t.inputTypes {
+						t.comparators[j].set(inputVecIdx, topKVecIdx, idx, maxIdx)
+					}
+				})
+				heap.Fix(t, 0)
+			}
+		}
+	}
+
+	// t.topK now contains the top K rows of the partition unsorted. Note that
+	// it's a max heap so we need to fill the selection vector in reverse.
+	if cap(t.sel) < t.topK.Length() {
+		t.sel = make([]int, t.topK.Length())
+	} else {
+		t.sel = t.sel[:t.topK.Length()]
+	}
+	for i := range t.sel {
+		t.sel[len(t.sel)-i-1] = heap.Pop(t).(int)
+	}
+}
+
+// samePartition returns whether the row at index idx of the input batch
+// belongs to the partition currently stored in t.topK.
+func (t *topKPerPartitionSorter) samePartition(idx int) bool {
+	for _, colIdx := range t.partitionCols {
+		if t.comparators[colIdx].compare(inputVecIdx, topKVecIdx, idx, 0 /* valIdx2 */) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *topKPerPartitionSorter) compareRow(vecIdx1, vecIdx2 int, rowIdx1, rowIdx2 int) int {
+	for i := range t.orderingCols {
+		info := t.orderingCols[i]
+		res := t.comparators[info.ColIdx].compare(vecIdx1, vecIdx2, rowIdx1, rowIdx2)
+		if res != 0 {
+			switch d := info.Direction; d {
+			case execinfrapb.Ordering_Column_ASC:
+				return res
+			case execinfrapb.Ordering_Column_DESC:
+				return -res
+			default:
+				colexecerror.InternalError(errors.AssertionFailedf("unexpected direction value %d", d))
+			}
+		}
+	}
+	return 0
+}
+
+func (t *topKPerPartitionSorter) updateComparators(vecIdx int, batch coldata.Batch) {
+	for i := range t.inputTypes {
+		t.comparators[i].setVec(vecIdx, batch.ColVec(i))
+	}
+}
+
+// Len is part of heap.Interface and is only meant to be used internally.
+func (t *topKPerPartitionSorter) Len() int {
+	return len(t.heap)
+}
+
+// Less is part of heap.Interface and is only meant to be used internally.
+func (t *topKPerPartitionSorter) Less(i, j int) bool {
+	return t.compareRow(topKVecIdx, topKVecIdx, t.heap[i], t.heap[j]) > 0
+}
+
+// Swap is part of heap.Interface and is only meant to be used internally.
+func (t *topKPerPartitionSorter) Swap(i, j int) {
+	t.heap[i], t.heap[j] = t.heap[j], t.heap[i]
+}
+
+// Push is part of heap.Interface and is only meant to be used internally.
+func (t *topKPerPartitionSorter) Push(x interface{}) {
+	t.heap = append(t.heap, x.(int))
+}
+
+// Pop is part of heap.Interface and is only meant to be used internally.
+func (t *topKPerPartitionSorter) Pop() interface{} {
+	x := t.heap[len(t.heap)-1]
+	t.heap = t.heap[:len(t.heap)-1]
+	return x
+}
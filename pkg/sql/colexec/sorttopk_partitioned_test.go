@@ -0,0 +1,134 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestTopKPerPartitionSorter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The first column of the input tuples is the partition column, and the
+	// input is always ordered on it.
+	for _, tc := range []struct {
+		desc     string
+		tuples   colexectestutils.Tuples
+		expected colexectestutils.Tuples
+		typs     []*types.T
+		ordCols  []execinfrapb.Ordering_Column
+		k        uint64
+	}{
+		{
+			desc: "partitions smaller than k",
+			tuples: colexectestutils.Tuples{
+				{1, 3}, {1, 1},
+				{2, 5},
+				{3, 2}, {3, 1},
+			},
+			expected: colexectestutils.Tuples{
+				{1, 1}, {1, 3},
+				{2, 5},
+				{3, 1}, {3, 2},
+			},
+			typs:    []*types.T{types.Int, types.Int},
+			ordCols: []execinfrapb.Ordering_Column{{ColIdx: 1}},
+			k:       3,
+		},
+		{
+			desc: "partitions larger than k",
+			tuples: colexectestutils.Tuples{
+				{1, 5}, {1, 2}, {1, 7}, {1, 1}, {1, 4},
+				{2, 9}, {2, 8}, {2, 6}, {2, 10},
+			},
+			expected: colexectestutils.Tuples{
+				{1, 1}, {1, 2},
+				{2, 6}, {2, 8},
+			},
+			typs:    []*types.T{types.Int, types.Int},
+			ordCols: []execinfrapb.Ordering_Column{{ColIdx: 1}},
+			k:       2,
+		},
+		{
+			desc: "mixed partition sizes",
+			tuples: colexectestutils.Tuples{
+				{1, 4}, {1, 3}, {1, 2}, {1, 1},
+				{2, 1},
+				{3, 2}, {3, 3}, {3, 1},
+				{4, 5}, {4, 6},
+			},
+			expected: colexectestutils.Tuples{
+				{1, 1}, {1, 2}, {1, 3},
+				{2, 1},
+				{3, 1}, {3, 2}, {3, 3},
+				{4, 5}, {4, 6},
+			},
+			typs:    []*types.T{types.Int, types.Int},
+			ordCols: []execinfrapb.Ordering_Column{{ColIdx: 1}},
+			k:       3,
+		},
+		{
+			desc: "nulls",
+			tuples: colexectestutils.Tuples{
+				{nil, 2}, {nil, nil}, {nil, 1},
+				{1, 3}, {1, nil},
+			},
+			expected: colexectestutils.Tuples{
+				{nil, nil}, {nil, 1},
+				{1, nil}, {1, 3},
+			},
+			typs:    []*types.T{types.Int, types.Int},
+			ordCols: []execinfrapb.Ordering_Column{{ColIdx: 1}},
+			k:       2,
+		},
+		{
+			desc: "descending on multiple columns",
+			tuples: colexectestutils.Tuples{
+				{"a", 1, 1}, {"a", 2, 1}, {"a", 2, 2}, {"a", 1, 2},
+				{"b", 3, 3}, {"b", 3, 4}, {"b", 2, 5},
+			},
+			expected: colexectestutils.Tuples{
+				{"a", 2, 1}, {"a", 2, 2}, {"a", 1, 1},
+				{"b", 3, 3}, {"b", 3, 4}, {"b", 2, 5},
+			},
+			typs: []*types.T{types.String, types.Int, types.Int},
+			ordCols: []execinfrapb.Ordering_Column{
+				{ColIdx: 1, Direction: execinfrapb.Ordering_Column_DESC},
+				{ColIdx: 2, Direction: execinfrapb.Ordering_Column_ASC},
+			},
+			k: 3,
+		},
+		{
+			desc: "k = 1",
+			tuples: colexectestutils.Tuples{
+				{1, 2}, {1, 1}, {2, 3}, {2, 4}, {3, 5},
+			},
+			expected: colexectestutils.Tuples{{1, 1}, {2, 3}, {3, 5}},
+			typs:     []*types.T{types.Int, types.Int},
+			ordCols:  []execinfrapb.Ordering_Column{{ColIdx: 1}},
+			k:        1,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return NewTopKPerPartitionSorter(testAllocator, input[0], tc.typs, []uint32{0}, tc.ordCols, tc.k), nil
+			})
+		})
+	}
+}
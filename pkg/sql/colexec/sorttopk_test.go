@@ -12,14 +12,17 @@ package colexec
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
 
 var topKSortTestCases []sortTestCase
@@ -61,6 +64,42 @@ func init() {
 			},
 			k: 3,
 		},
+		{
+			description: "offset >= input length",
+			tuples:      colexectestutils.Tuples{{5}, {3}, {1}, {4}, {2}},
+			expected:    colexectestutils.Tuples{},
+			typs:        []*types.T{types.Int},
+			ordCols:     []execinfrapb.Ordering_Column{{ColIdx: 0}},
+			k:           3,
+			offset:      5,
+		},
+		{
+			description: "offset < input length < k + offset",
+			tuples:      colexectestutils.Tuples{{5}, {3}, {1}, {4}, {2}},
+			expected:    colexectestutils.Tuples{{4}, {5}},
+			typs:        []*types.T{types.Int},
+			ordCols:     []execinfrapb.Ordering_Column{{ColIdx: 0}},
+			k:           3,
+			offset:      3,
+		},
+		{
+			description: "k + offset < input length",
+			tuples:      colexectestutils.Tuples{{7}, {5}, {3}, {1}, {6}, {4}, {2}},
+			expected:    colexectestutils.Tuples{{3}, {4}, {5}},
+			typs:        []*types.T{types.Int},
+			ordCols:     []execinfrapb.Ordering_Column{{ColIdx: 0}},
+			k:           3,
+			offset:      2,
+		},
+		{
+			description: "offset with nulls",
+			tuples:      colexectestutils.Tuples{{1}, {2}, {nil}, {3}, {4}, {5}, {6}, {7}, {nil}},
+			expected:    colexectestutils.Tuples{{1}, {2}},
+			typs:        []*types.T{types.Int},
+			ordCols:     []execinfrapb.Ordering_Column{{ColIdx: 0}},
+			k:           2,
+			offset:      2,
+		},
 	}
 }
 
@@ -70,8 +109,55 @@ func TestTopKSorter(t *testing.T) {
 
 	for _, tc := range topKSortTestCases {
 		log.Infof(context.Background(), "%s", tc.description)
-		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, tc.expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
-			return NewTopKSorter(testAllocator, input[0], tc.typs, tc.ordCols, tc.k), nil
+		runTests := colexectestutils.RunTestsWithTyps
+		if len(tc.expected) == 0 {
+			// The output is empty regardless of the input values.
+			runTests = colexectestutils.RunTestsWithoutAllNullsInjection
+		}
+		runTests(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{tc.typs}, tc.expected, colexectestutils.OrderedVerifier, func(input []colexecop.Operator) (colexecop.Operator, error) {
+			return NewTopKSorter(testAllocator, input[0], tc.typs, tc.ordCols, tc.k, tc.offset), nil
 		})
 	}
 }
+
+func BenchmarkTopKSortWithOffset(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	rng, _ := randutil.NewPseudoRand()
+	ctx := context.Background()
+	const nBatches = 1 << 6
+	typs := []*types.T{types.Int}
+	ordCols := []execinfrapb.Ordering_Column{{ColIdx: 0}}
+	batch := testAllocator.NewMemBatchWithMaxCapacity(typs)
+	batch.SetLength(coldata.BatchSize())
+	col := batch.ColVec(0).Int64()
+	for i := 0; i < coldata.BatchSize(); i++ {
+		col[i] = rng.Int63()
+	}
+
+	for _, k := range []uint64{16, 1024} {
+		for _, offset := range []uint64{16, 1024} {
+			for _, topK := range []bool{false, true} {
+				name := fmt.Sprintf("k=%d/offset=%d/topK=%t", k, offset, topK)
+				b.Run(name, func(b *testing.B) {
+					b.SetBytes(int64(8 * nBatches * coldata.BatchSize()))
+					for n := 0; n < b.N; n++ {
+						source := colexectestutils.NewFiniteBatchSource(testAllocator, batch, typs, nBatches)
+						var op colexecop.Operator
+						if topK {
+							op = NewTopKSorter(testAllocator, source, typs, ordCols, k, offset)
+						} else {
+							sorter, err := NewSorter(testAllocator, source, typs, ordCols)
+							if err != nil {
+								b.Fatal(err)
+							}
+							op = NewLimitOp(NewOffsetOp(sorter, offset), k)
+						}
+						op.Init(ctx)
+						for out := op.Next(); out.Length() != 0; out = op.Next() {
+						}
+					}
+				})
+			}
+		}
+	}
+}
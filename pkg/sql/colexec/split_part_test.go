@@ -0,0 +1,139 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPart(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "constant arguments",
+			expr: "split_part(@1, '.', 3)",
+			inputTuples: colexectestutils.Tuples{
+				{"123.456.789.0"},
+				{"123.456.789"},
+				{"123.456"},
+				{"123.456."},
+				{"123.456.."},
+				{".."},
+				{""},
+				{nil},
+			},
+			inputTypes: []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{
+				{"123.456.789.0", "789"},
+				{"123.456.789", "789"},
+				{"123.456", ""},
+				{"123.456.", ""},
+				{"123.456..", ""},
+				{"..", ""},
+				{"", ""},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "column arguments",
+			expr: "split_part(@1, @2, @3)",
+			inputTuples: colexectestutils.Tuples{
+				{"a--b--c", "--", 2},
+				{"a--b--c--", "--", 4},
+				{"a--b--c", "--", 5},
+				{"a--b--c", ",", 1},
+				{"a--b--c", ",", 2},
+				{"日本語", "本", 2},
+				{"abc", "", 2},
+				{"", "", 1},
+				{nil, "-", 1},
+				{"a-b", nil, 1},
+				{"a-b", "-", nil},
+			},
+			inputTypes: []*types.T{types.String, types.String, types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{"a--b--c", "--", 2, "b"},
+				{"a--b--c--", "--", 4, ""},
+				{"a--b--c", "--", 5, ""},
+				{"a--b--c", ",", 1, "a--b--c"},
+				{"a--b--c", ",", 2, ""},
+				{"日本語", "本", 2, "語"},
+				{"abc", "", 2, "b"},
+				{"", "", 1, ""},
+				{nil, "-", 1, nil},
+				{"a-b", nil, 1, nil},
+				{"a-b", "-", nil, nil},
+			},
+		},
+		{
+			desc:         "narrow int argument",
+			expr:         "split_part(@1, ',', @2) || split_part(@1, ',', @3)",
+			inputTuples:  colexectestutils.Tuples{{"a,b,c", 1, 3}},
+			inputTypes:   []*types.T{types.String, types.Int2, types.Int4},
+			outputTuples: colexectestutils.Tuples{{"a,b,c", 1, 3, "ac"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// Non-positive field position results in an error.
+	for _, field := range []int{0, -1} {
+		typs := []*types.T{types.String, types.Int}
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"a,b", field}}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, "split_part(@1, ',', @2)", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, fmt.Sprintf("field position %d must be greater than zero", field))
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for split_part.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _FIELD_WIDTH is the template variable.
+const _FIELD_WIDTH = 0
+
+// */}}
+
+// newSplitPartOperator returns an operator that evaluates split_part()
+// builtin. The arguments are expected at positions argumentCols: the input and
+// the delimiter Bytes columns and the field position Int column.
+func newSplitPartOperator(
+	allocator *colmem.Allocator,
+	typs []*types.T,
+	argumentCols []int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := splitPartOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		argumentCols:   argumentCols,
+		outputIdx:      outputIdx,
+	}
+	fieldType := typs[argumentCols[2]]
+	if fieldType.Family() != types.IntFamily {
+		colexecerror.InternalError(errors.AssertionFailedf("non-int field argument type %s", fieldType))
+	}
+	switch fieldType.Width() {
+	// {{range .}}
+	case _FIELD_WIDTH:
+		return &splitPart_FieldTypeOp{splitPartOpBase: base}
+		// {{end}}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported split_part argument type: %s", fieldType))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type splitPartOpBase struct {
+	colexecop.OneInputHelper
+	allocator    *colmem.Allocator
+	argumentCols []int
+	outputIdx    int
+}
+
+// splitPart returns the field with (1-based) index field of s split on sep or
+// an empty slice if there are fewer fields. It matches the split_part() builtin
+// of the row engine, so an empty sep splits s into separate characters.
+func splitPart(s, sep []byte, field int) []byte {
+	if field <= 0 {
+		colexecerror.ExpectedError(pgerror.Newf(
+			pgcode.InvalidParameterValue, "field position %d must be greater than zero", field,
+		))
+	}
+	if len(sep) == 0 {
+		for i := 0; i < len(s); field-- {
+			_, width := utf8.DecodeRune(s[i:])
+			if field == 1 {
+				return s[i : i+width]
+			}
+			i += width
+		}
+		return nil
+	}
+	for ; field > 1; field-- {
+		idx := bytes.Index(s, sep)
+		if idx < 0 {
+			return nil
+		}
+		s = s[idx+len(sep):]
+	}
+	if idx := bytes.Index(s, sep); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+// {{range .}}
+
+// splitPart_FieldTypeOp is an operator that evaluates split_part() builtin.
+type splitPart_FieldTypeOp struct {
+	splitPartOpBase
+}
+
+var _ colexecop.Operator = &splitPart_FieldTypeOp{}
+
+func (s *splitPart_FieldTypeOp) Next() coldata.Batch {
+	batch := s.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputCol := batch.ColVec(s.argumentCols[0]).Bytes()
+	sepCol := batch.ColVec(s.argumentCols[1]).Bytes()
+	fieldCol := batch.ColVec(s.argumentCols[2])._FieldType()
+	outputVec := batch.ColVec(s.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	s.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if s.hasNullArgument(batch, rowIdx) {
+					outputVec.Nulls().SetNull(rowIdx)
+					continue
+				}
+				outputCol.Set(rowIdx, splitPart(inputCol.Get(rowIdx), sepCol.Get(rowIdx), int(fieldCol[rowIdx])))
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{end}}
+
+// hasNullArgument returns whether any of the arguments is NULL at position
+// rowIdx, in which case the result is NULL.
+func (s *splitPartOpBase) hasNullArgument(batch coldata.Batch, rowIdx int) bool {
+	for _, col := range s.argumentCols {
+		if batch.ColVec(col).Nulls().NullAt(rowIdx) {
+			return true
+		}
+	}
+	return false
+}
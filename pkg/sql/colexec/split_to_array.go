@@ -0,0 +1,181 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+)
+
+// constStringOrNull returns the value of expr if it is either a constant
+// string or NULL (in which case nil is returned). ok is false if expr is not a
+// constant.
+func constStringOrNull(expr tree.Expr) (s *string, ok bool) {
+	switch d := expr.(type) {
+	case *tree.DString:
+		return (*string)(d), true
+	case tree.Datum:
+		return nil, d == tree.DNull
+	}
+	return nil, false
+}
+
+// newStringToArrayOperator returns an operator that evaluates string_to_array()
+// builtin on the String column at position inputIdx. The delimiter and the
+// string to be considered NULL are constants, and each of them can be NULL.
+func newStringToArrayOperator(
+	allocator *colmem.Allocator,
+	delim *string,
+	nullStr *string,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	var split func(string) []string
+	switch {
+	case delim == nil:
+		// When given a NULL delimiter, string_to_array splits into each
+		// character.
+		split = func(s string) []string {
+			res := make([]string, 0, utf8.RuneCountInString(s))
+			for _, c := range s {
+				res = append(res, string(c))
+			}
+			return res
+		}
+	case *delim == "":
+		split = func(s string) []string {
+			if s == "" {
+				return nil
+			}
+			return []string{s}
+		}
+	default:
+		split = func(s string) []string {
+			if s == "" {
+				return nil
+			}
+			return strings.Split(s, *delim)
+		}
+	}
+	return &splitToArrayOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		split:          split,
+		nullStr:        nullStr,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// newRegexpSplitToArrayOperator returns an operator that evaluates
+// regexp_split_to_array() builtin with the constant pattern and flags on the
+// String column at position inputIdx. Same as in the row engine, an invalid
+// pattern results in an error only if there is a non-NULL value to be split.
+func newRegexpSplitToArrayOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	funcExpr *tree.FuncExpr,
+	pattern string,
+	flags string,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	var split func(string) []string
+	if re, err := builtins.GetRegexpWithFlags(evalCtx, pattern, flags); err != nil {
+		split = func(string) []string {
+			colexecerror.ExpectedError(funcExpr.MaybeWrapError(err))
+			// This code is unreachable, but the compiler cannot infer that.
+			return nil
+		}
+	} else {
+		split = func(s string) []string {
+			return re.Split(s, -1 /* n */)
+		}
+	}
+	return &splitToArrayOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		split:          split,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// splitToArrayOp is an operator that splits the strings into arrays of
+// strings using split function. If nullStr is non-nil, the elements equal to
+// it are replaced with NULLs.
+type splitToArrayOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	split     func(string) []string
+	nullStr   *string
+	inputIdx  int
+	outputIdx int
+	da        rowenc.DatumAlloc
+}
+
+var _ colexecop.Operator = &splitToArrayOp{}
+
+func (o *splitToArrayOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.inputIdx)
+	nulls, col := vec.Nulls(), vec.Bytes()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Datum()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				res := tree.NewDArray(types.String)
+				for _, s := range o.split(string(col.Get(rowIdx))) {
+					var elem tree.Datum = tree.DNull
+					if o.nullStr == nil || s != *o.nullStr {
+						elem = o.da.NewDString(tree.DString(s))
+					}
+					if err := res.Append(elem); err != nil {
+						colexecerror.ExpectedError(err)
+					}
+				}
+				outputCol.Set(rowIdx, res)
+			}
+		},
+	)
+	return batch
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitToArray(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "string_to_array",
+			expr: "string_to_array(@1, ',')",
+			inputTuples: colexectestutils.Tuples{
+				{"a,b"}, {"a,,b,"}, {",,"}, {"abc"}, {""}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,b", "ARRAY['a','b']"},
+				{"a,,b,", "ARRAY['a','','b','']"},
+				{",,", "ARRAY['','','']"},
+				{"abc", "ARRAY['abc']"},
+				{"", "ARRAY[]:::STRING[]"},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "string_to_array multi-character delimiter",
+			expr: "string_to_array(@1, '~^~')",
+			inputTuples: colexectestutils.Tuples{
+				{"xx~^~yy~^~~^~zz"}, {"~^"},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"xx~^~yy~^~~^~zz", "ARRAY['xx','yy','','zz']"},
+				{"~^", "ARRAY['~^']"},
+			},
+		},
+		{
+			desc: "string_to_array empty delimiter",
+			expr: "string_to_array(@1, '')",
+			inputTuples: colexectestutils.Tuples{
+				{"a,b"}, {""}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,b", "ARRAY['a,b']"}, {"", "ARRAY[]:::STRING[]"}, {nil, nil},
+			},
+		},
+		{
+			// A NULL delimiter splits the string into characters.
+			desc: "string_to_array NULL delimiter",
+			expr: "string_to_array(@1, NULL)",
+			inputTuples: colexectestutils.Tuples{
+				{"ab"}, {"aé禅"}, {""}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"ab", "ARRAY['a','b']"},
+				{"aé禅", "ARRAY['a','é','禅']"},
+				{"", "ARRAY[]:::STRING[]"},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "string_to_array null string",
+			expr: "string_to_array(@1, ',', 'x')",
+			inputTuples: colexectestutils.Tuples{
+				{"x,y,,x"}, {"xx"}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"x,y,,x", "ARRAY[NULL,'y','',NULL]"},
+				{"xx", "ARRAY['xx']"},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "string_to_array empty null string",
+			expr: "string_to_array(@1, ',', '')",
+			inputTuples: colexectestutils.Tuples{
+				{"a,,b"},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,,b", "ARRAY['a',NULL,'b']"},
+			},
+		},
+		{
+			desc: "string_to_array NULL null string",
+			expr: "string_to_array(@1, ',', NULL)",
+			inputTuples: colexectestutils.Tuples{
+				{"a,,b"},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,,b", "ARRAY['a','','b']"},
+			},
+		},
+		{
+			desc: "regexp_split_to_array",
+			expr: "regexp_split_to_array(@1, ',')",
+			inputTuples: colexectestutils.Tuples{
+				{"a,,b,"}, {"abc"}, {""}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,,b,", "ARRAY['a','','b','']"},
+				{"abc", "ARRAY['abc']"},
+				{"", "ARRAY['']"},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "regexp_split_to_array repeated delimiter",
+			expr: "regexp_split_to_array(@1, ',+\\s*')",
+			inputTuples: colexectestutils.Tuples{
+				{"a,, b,c"},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"a,, b,c", "ARRAY['a','b','c']"},
+			},
+		},
+		{
+			desc: "regexp_split_to_array with flags",
+			expr: "regexp_split_to_array(@1, 'b', 'i')",
+			inputTuples: colexectestutils.Tuples{
+				{"abAbBc"}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{"abAbBc", "ARRAY['a','A','','c']"}, {nil, nil},
+			},
+		},
+	}
+
+	typs := []*types.T{types.String}
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// An invalid pattern results in an error only if there is a non-NULL
+	// value to be split, same as in the row engine.
+	for _, tc := range []struct {
+		input       interface{}
+		expectedErr string
+	}{
+		{input: nil},
+		{input: "a", expectedErr: "regexp_split_to_array(): error parsing regexp: missing closing ): `(?s:(a)`"},
+	} {
+		input := colexectestutils.NewOpTestInput(
+			testAllocator, 1, colexectestutils.Tuples{{tc.input}}, typs,
+		)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, "regexp_split_to_array(@1, '(a')", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		if tc.expectedErr == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, tc.expectedErr)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/knz/strtime"
+)
+
+// newStrftimeOperator returns an operator that evaluates experimental_strftime()
+// builtin with the constant format on the column at position inputIdx. The
+// column is either a Date column (if isDate is true) or a Timestamp or a
+// TimestampTZ column.
+func newStrftimeOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	format string,
+	isDate bool,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &strftimeOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		format:         format,
+		isDate:         isDate,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// strftimeOp is an operator that formats the date or the timestamp values
+// according to the constant format using strftime notation. It matches the
+// row engine, so an invalid format results in an error only if there is a
+// non-NULL value to be formatted.
+type strftimeOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	format    string
+	isDate    bool
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &strftimeOp{}
+
+func (s *strftimeOp) Next() coldata.Batch {
+	batch := s.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(s.inputIdx)
+	nulls := vec.Nulls()
+	var dateCol coldata.Int64s
+	var timestampCol coldata.Times
+	if s.isDate {
+		dateCol = vec.Int64()
+	} else {
+		timestampCol = vec.Timestamp()
+	}
+	outputVec := batch.ColVec(s.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	s.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				var t time.Time
+				if s.isDate {
+					var err error
+					t, err = pgdate.MakeCompatibleDateFromDisk(dateCol[rowIdx]).ToTime()
+					if err != nil {
+						colexecerror.ExpectedError(s.funcExpr.MaybeWrapError(err))
+					}
+				} else {
+					t = timestampCol[rowIdx]
+				}
+				res, err := strtime.Strftime(t, s.format)
+				if err != nil {
+					colexecerror.ExpectedError(s.funcExpr.MaybeWrapError(err))
+				}
+				outputCol.Set(rowIdx, []byte(res))
+			}
+		},
+	)
+	return batch
+}
+
+// newStrptimeOperator returns an operator that evaluates experimental_strptime()
+// builtin with the constant format on the String column at position inputIdx.
+func newStrptimeOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	format string,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &strptimeOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		format:         format,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// strptimeOp is an operator that parses the strings into the TimestampTZ
+// values according to the constant format using strptime notation.
+type strptimeOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	format    string
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &strptimeOp{}
+
+func (s *strptimeOp) Next() coldata.Batch {
+	batch := s.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(s.inputIdx)
+	nulls, col := vec.Nulls(), vec.Bytes()
+	outputVec := batch.ColVec(s.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Timestamp()
+	s.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				t, err := strtime.Strptime(string(col.Get(rowIdx)), s.format)
+				if err != nil {
+					colexecerror.ExpectedError(s.funcExpr.MaybeWrapError(err))
+				}
+				// MakeDTimestampTZ performs the same rounding and range check as
+				// the row engine.
+				d, err := tree.MakeDTimestampTZ(t.UTC(), time.Microsecond)
+				if err != nil {
+					colexecerror.ExpectedError(s.funcExpr.MaybeWrapError(err))
+				}
+				outputCol[rowIdx] = d.Time
+			}
+		},
+	)
+	return batch
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrftimeStrptime(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	// 2021-01-01 as the number of days since the Unix epoch.
+	const date = 18628
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:         "strftime timestamp",
+			expr:         "experimental_strftime(@1, '%Y-%m-%d %H:%M:%S')",
+			inputTuples:  colexectestutils.Tuples{{ts}, {nil}},
+			inputTypes:   []*types.T{types.Timestamp},
+			outputTuples: colexectestutils.Tuples{{ts, "2021-03-04 05:06:07"}, {nil, nil}},
+		},
+		{
+			desc:         "strftime timestamptz",
+			expr:         "experimental_strftime(@1, '%A, %B %d %Y %I%p')",
+			inputTuples:  colexectestutils.Tuples{{ts}},
+			inputTypes:   []*types.T{types.TimestampTZ},
+			outputTuples: colexectestutils.Tuples{{ts, "Thursday, March 04 2021 05AM"}},
+		},
+		{
+			desc:         "strftime date",
+			expr:         "experimental_strftime(@1, '%d/%m/%y %j')",
+			inputTuples:  colexectestutils.Tuples{{date}, {nil}},
+			inputTypes:   []*types.T{types.Date},
+			outputTuples: colexectestutils.Tuples{{date, "01/01/21 001"}, {nil, nil}},
+		},
+		{
+			desc:         "strftime invalid format on NULL",
+			expr:         "experimental_strftime(@1, '%Q')",
+			inputTuples:  colexectestutils.Tuples{{nil}},
+			inputTypes:   []*types.T{types.Timestamp},
+			outputTuples: colexectestutils.Tuples{{nil, nil}},
+		},
+		{
+			desc:         "strptime",
+			expr:         "experimental_strptime(@1, '%Y-%m-%d %H:%M:%S')",
+			inputTuples:  colexectestutils.Tuples{{"2021-03-04 05:06:07"}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"2021-03-04 05:06:07", ts}, {nil, nil}},
+		},
+		{
+			// Malformed input is parsed as the zero time, same as in the row
+			// engine.
+			desc:         "strptime malformed input",
+			expr:         "experimental_strptime(@1, '%Y-%m-%d')",
+			inputTuples:  colexectestutils.Tuples{{"garbage"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"garbage", time.Time{}}},
+		},
+		{
+			desc:         "strptime with time zone offset",
+			expr:         "experimental_strptime(@1, '%d/%m/%Y %H:%M %z')",
+			inputTuples:  colexectestutils.Tuples{{"04/03/2021 07:06 +0200"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"04/03/2021 07:06 +0200", ts.Add(-7 * time.Second)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// Invalid format and infinite dates result in the same errors as in the
+	// row engine.
+	_, infDateErr := pgdate.PosInfDate.ToTime()
+	require.Error(t, infDateErr)
+	for _, tc := range []struct {
+		expr        string
+		inputTuple  colexectestutils.Tuple
+		inputType   *types.T
+		expectedErr string
+	}{
+		{
+			expr:        "experimental_strftime(@1, '%Y %Q')",
+			inputTuple:  colexectestutils.Tuple{ts},
+			inputType:   types.Timestamp,
+			expectedErr: "experimental_strftime(): invalid format code: Q",
+		},
+		{
+			expr:        "experimental_strftime(@1, '%Y')",
+			inputTuple:  colexectestutils.Tuple{math.MaxInt64},
+			inputType:   types.Date,
+			expectedErr: "experimental_strftime(): " + infDateErr.Error(),
+		},
+	} {
+		typs := []*types.T{tc.inputType}
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{tc.inputTuple}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.expectedErr)
+	}
+}
@@ -0,0 +1,476 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/errors"
+)
+
+// IsTimeCmp returns whether op is one of the comparison operators (=, <>, <,
+// <=, > and >=) on the arguments of the given types which are either TIME or
+// TIMETZ values, in which case it is supported by the operators returned by
+// GetTimeCmpProjectionOperator and GetTimeCmpOperator.
+func IsTimeCmp(op tree.Operator, leftType, rightType *types.T) bool {
+	switch op {
+	case tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE:
+	default:
+		return false
+	}
+	isTime := func(t *types.T) bool {
+		return t.Family() == types.TimeFamily || t.Family() == types.TimeTZFamily
+	}
+	return isTime(leftType) && isTime(rightType)
+}
+
+// timeCmpKey is the representation of a TIME or a TIMETZ value that is used
+// to compare them. The values are ordered by the instants in UTC on the first
+// day of the Unix epoch and then by the time zone offsets.
+type timeCmpKey struct {
+	micros     int64
+	offsetSecs int32
+}
+
+// timeCmpArg is one of the arguments of the comparison of the times.
+type timeCmpArg struct {
+	isTimeTZ bool
+	// colIdx is only used if isConst is false, and constVal otherwise.
+	colIdx   int
+	isConst  bool
+	constVal tree.Datum
+	vec      coldata.Vec
+}
+
+// key returns the timeCmpKey of the argument on the row at position rowIdx
+// and whether it is non-NULL. loc is the session time zone, and offsetSecs is
+// the offset that is used for the TIME values.
+func (a *timeCmpArg) key(
+	rowIdx int, loc *time.Location, offsetSecs int32,
+) (_ timeCmpKey, ok bool) {
+	d := a.constVal
+	if !a.isConst {
+		if a.vec.Nulls().NullAt(rowIdx) {
+			return timeCmpKey{}, false
+		}
+		d = a.vec.Datum().Get(rowIdx).(*coldataext.Datum).Datum
+	}
+	if a.isTimeTZ {
+		t := d.(*tree.DTimeTZ)
+		// This is the same instant as the one returned by ToTime.
+		return timeCmpKey{
+			micros:     int64(t.TimeOfDay) + int64(t.OffsetSecs)*duration.MicrosPerMilli*duration.MillisPerSec,
+			offsetSecs: t.OffsetSecs,
+		}, true
+	}
+	// The TIME values are normalized to the session time zone, same as in
+	// tree.compareTimestamps.
+	t := int64(*d.(*tree.DTime))
+	_, zoneOffsetSecs := timeofday.TimeOfDay(t).ToTime().In(loc).Zone()
+	return timeCmpKey{
+		micros:     t - int64(zoneOffsetSecs)*duration.MicrosPerMilli*duration.MillisPerSec,
+		offsetSecs: offsetSecs,
+	}, true
+}
+
+// timeCmpBase evaluates the comparison operator (one of =, <>, <, <=, > and
+// >=) on the TIME or TIMETZ column at position leftIdx and either the TIME or
+// TIMETZ column at position rightIdx or the constant constRight. The result
+// is the same as of the row engine which takes the session time zone into
+// account when comparing the TIME values with the TIMETZ ones.
+type timeCmpBase struct {
+	evalCtx     *tree.EvalContext
+	op          tree.ComparisonOperator
+	left, right timeCmpArg
+	// loc and offsetSecs are the session time zone and the offset of the
+	// TIME values which are computed for each batch.
+	loc        *time.Location
+	offsetSecs int32
+}
+
+func makeTimeCmpBase(
+	evalCtx *tree.EvalContext,
+	op tree.ComparisonOperator,
+	leftType, rightType *types.T,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (timeCmpBase, error) {
+	if !IsTimeCmp(op, leftType, rightType) {
+		return timeCmpBase{}, errors.AssertionFailedf(
+			"unexpected time comparison %s %s %s", leftType, op, rightType,
+		)
+	}
+	b := timeCmpBase{
+		evalCtx: evalCtx,
+		op:      op,
+		left:    timeCmpArg{isTimeTZ: leftType.Family() == types.TimeTZFamily, colIdx: leftIdx},
+		right:   timeCmpArg{isTimeTZ: rightType.Family() == types.TimeTZFamily, colIdx: rightIdx},
+	}
+	if constRight != nil {
+		switch constRight.(type) {
+		case *tree.DTime:
+			b.right.isTimeTZ = false
+		case *tree.DTimeTZ:
+			b.right.isTimeTZ = true
+		default:
+			return timeCmpBase{}, errors.Errorf("unsupported time comparison argument %s", constRight)
+		}
+		b.right.isConst, b.right.constVal = true, constRight
+	}
+	return b, nil
+}
+
+// prepare prepares the arguments for the batch. It must be called before
+// eval.
+func (b *timeCmpBase) prepare(batch coldata.Batch) {
+	b.left.vec = batch.ColVec(b.left.colIdx)
+	if !b.right.isConst {
+		b.right.vec = batch.ColVec(b.right.colIdx)
+	}
+	b.loc = b.evalCtx.GetLocation()
+	_, zoneOffsetSecs := b.evalCtx.GetRelativeParseTime().Zone()
+	b.offsetSecs = int32(-zoneOffsetSecs)
+}
+
+// eval returns the result of the comparison on the row at position rowIdx.
+// The result is NULL if either of the arguments is NULL.
+func (b *timeCmpBase) eval(rowIdx int) (res bool, isNull bool) {
+	left, ok := b.left.key(rowIdx, b.loc, b.offsetSecs)
+	if !ok {
+		return false, true
+	}
+	right, ok := b.right.key(rowIdx, b.loc, b.offsetSecs)
+	if !ok {
+		return false, true
+	}
+	var cmp int
+	switch {
+	case left.micros < right.micros:
+		cmp = -1
+	case left.micros > right.micros:
+		cmp = 1
+	case left.offsetSecs < right.offsetSecs:
+		// The instants are the same, so the offsets are compared. Note that
+		// when both arguments are TIME values, the offsets are the same.
+		cmp = -1
+	case left.offsetSecs > right.offsetSecs:
+		cmp = 1
+	}
+	switch b.op {
+	case tree.EQ:
+		return cmp == 0, false
+	case tree.NE:
+		return cmp != 0, false
+	case tree.LT:
+		return cmp < 0, false
+	case tree.LE:
+		return cmp <= 0, false
+	case tree.GT:
+		return cmp > 0, false
+	default:
+		return cmp >= 0, false
+	}
+}
+
+// GetTimeCmpProjectionOperator returns an operator that projects the result
+// of the comparison operator op (one of =, <>, <, <=, > and >=) into the Bool
+// column at position resultIdx. The left argument is the TIME or TIMETZ
+// column at position leftIdx, and the right argument is either the constant
+// constRight if it is non-nil or the TIME or TIMETZ column at position
+// rightIdx.
+func GetTimeCmpProjectionOperator(
+	allocator *colmem.Allocator,
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	op tree.ComparisonOperator,
+	leftType, rightType *types.T,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+	resultIdx int,
+) (colexecop.Operator, error) {
+	base, err := makeTimeCmpBase(evalCtx, op, leftType, rightType, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	input = colexecutils.NewVectorTypeEnforcer(allocator, input, types.Bool, resultIdx)
+	return &timeCmpProjOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		timeCmpBase:    base,
+		allocator:      allocator,
+		outputIdx:      resultIdx,
+	}, nil
+}
+
+// GetTimeCmpOperator returns an operator that selects the tuples for which the
+// comparison operator op (one of =, <>, <, <=, > and >=) evaluates to true.
+// The arguments are the same as in GetTimeCmpProjectionOperator.
+func GetTimeCmpOperator(
+	evalCtx *tree.EvalContext,
+	input colexecop.Operator,
+	op tree.ComparisonOperator,
+	leftType, rightType *types.T,
+	leftIdx, rightIdx int,
+	constRight tree.Datum,
+) (colexecop.Operator, error) {
+	base, err := makeTimeCmpBase(evalCtx, op, leftType, rightType, leftIdx, rightIdx, constRight)
+	if err != nil {
+		return nil, err
+	}
+	return &timeCmpSelOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		timeCmpBase:    base,
+	}, nil
+}
+
+type timeCmpProjOp struct {
+	colexecop.OneInputHelper
+	timeCmpBase
+	allocator *colmem.Allocator
+	outputIdx int
+}
+
+var _ colexecop.Operator = &timeCmpProjOp{}
+
+func (o *timeCmpProjOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	o.prepare(batch)
+	projVec := batch.ColVec(o.outputIdx)
+	if projVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		projVec.Nulls().UnsetNulls()
+	}
+	projCol := projVec.Bool()
+	projNulls := projVec.Nulls()
+	o.allocator.PerformOperation([]coldata.Vec{projVec}, func() {
+		sel := batch.Selection()
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			res, isNull := o.eval(rowIdx)
+			if isNull {
+				projNulls.SetNull(rowIdx)
+				continue
+			}
+			projCol[rowIdx] = res
+		}
+	})
+	return batch
+}
+
+type timeCmpSelOp struct {
+	colexecop.OneInputHelper
+	timeCmpBase
+}
+
+var _ colexecop.Operator = &timeCmpSelOp{}
+
+func (o *timeCmpSelOp) Next() coldata.Batch {
+	for {
+		batch := o.Input.Next()
+		n := batch.Length()
+		if n == 0 {
+			return batch
+		}
+		o.prepare(batch)
+		var idx int
+		if sel := batch.Selection(); sel != nil {
+			sel = sel[:n]
+			for _, i := range sel {
+				if res, isNull := o.eval(i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		} else {
+			batch.SetSelection(true)
+			sel := batch.Selection()[:n]
+			for i := range sel {
+				if res, isNull := o.eval(i); res && !isNull {
+					sel[idx] = i
+					idx++
+				}
+			}
+		}
+		if idx > 0 {
+			batch.SetLength(idx)
+			return batch
+		}
+	}
+}
+
+// timeExtractField is one of the fields of the TIME and TIMETZ values that
+// can be extracted by the timeExtractOp.
+type timeExtractField int
+
+const (
+	timeExtractHour timeExtractField = iota
+	timeExtractMinute
+	timeExtractSecond
+	timeExtractMillisecond
+	timeExtractMicrosecond
+	timeExtractEpoch
+	// The following fields are only supported for the TIMETZ values.
+	timeExtractTimezone
+	timeExtractTimezoneHour
+	timeExtractTimezoneMinute
+)
+
+// getTimeExtractField returns the field named by timeSpan (in any case) if
+// it can be extracted from the TIME (or TIMETZ if isTimeTZ is true) values.
+func getTimeExtractField(timeSpan string, isTimeTZ bool) (timeExtractField, bool) {
+	switch strings.ToLower(timeSpan) {
+	case "hour", "hours":
+		return timeExtractHour, true
+	case "minute", "minutes":
+		return timeExtractMinute, true
+	case "second", "seconds":
+		return timeExtractSecond, true
+	case "millisecond", "milliseconds":
+		return timeExtractMillisecond, true
+	case "microsecond", "microseconds":
+		return timeExtractMicrosecond, true
+	case "epoch":
+		return timeExtractEpoch, true
+	case "timezone":
+		return timeExtractTimezone, isTimeTZ
+	case "timezone_hour", "timezone_hours":
+		return timeExtractTimezoneHour, isTimeTZ
+	case "timezone_minute", "timezone_minutes":
+		return timeExtractTimezoneMinute, isTimeTZ
+	}
+	return 0, false
+}
+
+// newTimeExtractOperator returns an operator that evaluates extract() builtin
+// with the constant field on the TIME (or TIMETZ if isTimeTZ is true) column
+// at position inputIdx. ok is false if the field is not supported (the row
+// engine returns an error in such case if there is a non-NULL value).
+func newTimeExtractOperator(
+	allocator *colmem.Allocator,
+	timeSpan string,
+	isTimeTZ bool,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) (_ colexecop.Operator, ok bool) {
+	field, ok := getTimeExtractField(timeSpan, isTimeTZ)
+	if !ok {
+		return nil, false
+	}
+	return &timeExtractOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		field:          field,
+		isTimeTZ:       isTimeTZ,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}, true
+}
+
+// timeExtractOp is an operator that extracts the field of the TIME or TIMETZ
+// values into a Float column. The results are computed the same way as by the
+// row engine.
+type timeExtractOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	field     timeExtractField
+	isTimeTZ  bool
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &timeExtractOp{}
+
+// extract returns the field of the time of day t. offsetSecs is only used for
+// the TIMETZ values.
+func (o *timeExtractOp) extract(t timeofday.TimeOfDay, offsetSecs int32) float64 {
+	switch o.field {
+	case timeExtractHour:
+		return float64(t.Hour())
+	case timeExtractMinute:
+		return float64(t.Minute())
+	case timeExtractSecond:
+		return float64(t.Second()) + float64(t.Microsecond())/(duration.MicrosPerMilli*duration.MillisPerSec)
+	case timeExtractMillisecond:
+		return float64(t.Second()*duration.MillisPerSec) + float64(t.Microsecond())/duration.MicrosPerMilli
+	case timeExtractMicrosecond:
+		return float64((t.Second() * duration.MillisPerSec * duration.MicrosPerMilli) + t.Microsecond())
+	case timeExtractEpoch:
+		seconds := float64(time.Duration(t)) * float64(time.Microsecond) / float64(time.Second)
+		if o.isTimeTZ {
+			// Epoch additionally includes the zone offset.
+			seconds += float64(offsetSecs)
+		}
+		return seconds
+	case timeExtractTimezone:
+		return float64(-offsetSecs)
+	case timeExtractTimezoneHour:
+		return float64(-offsetSecs / duration.SecsPerHour)
+	default:
+		return float64((-offsetSecs / duration.SecsPerMinute) % 60)
+	}
+}
+
+func (o *timeExtractOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.inputIdx)
+	nulls := vec.Nulls()
+	datums := vec.Datum()
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Float64()
+	o.allocator.PerformOperation([]coldata.Vec{outputVec}, func() {
+		for i := 0; i < n; i++ {
+			rowIdx := i
+			if sel != nil {
+				rowIdx = sel[i]
+			}
+			if nulls.NullAt(rowIdx) {
+				outputNulls.SetNull(rowIdx)
+				continue
+			}
+			d := datums.Get(rowIdx).(*coldataext.Datum).Datum
+			if o.isTimeTZ {
+				t := d.(*tree.DTimeTZ)
+				outputCol[rowIdx] = o.extract(t.TimeOfDay, t.OffsetSecs)
+			} else {
+				outputCol[rowIdx] = o.extract(timeofday.TimeOfDay(*d.(*tree.DTime)), 0 /* offsetSecs */)
+			}
+		}
+	})
+	return batch
+}
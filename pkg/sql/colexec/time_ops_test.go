@@ -0,0 +1,363 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/cockroach/pkg/util/timetz"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+// timeOpsTestValues returns the TIME and TIMETZ values used by the tests. The
+// TIMETZ values include the ones that represent the same instant with
+// different offsets as well as the ones that cross midnight in UTC.
+func timeOpsTestValues() map[types.Family][]tree.Datum {
+	return map[types.Family][]tree.Datum{
+		types.TimeFamily: {
+			tree.MakeDTime(timeofday.New(12, 30, 15, 123456)),
+			tree.MakeDTime(timeofday.Min),
+			tree.MakeDTime(timeofday.New(5, 0, 0, 0)),
+			tree.MakeDTime(timeofday.New(7, 30, 15, 123456)),
+			tree.MakeDTime(timeofday.New(23, 59, 59, 999999)),
+			tree.MakeDTime(timeofday.Time2400),
+		},
+		types.TimeTZFamily: {
+			// 12:30:15.123456+05 and 07:30:15.123456+00 are the same instant.
+			tree.NewDTimeTZFromOffset(timeofday.New(12, 30, 15, 123456), -5*60*60),
+			tree.NewDTimeTZFromOffset(timeofday.New(7, 30, 15, 123456), 0),
+			tree.NewDTimeTZFromOffset(timeofday.Min, 0),
+			// 23:00:00-01 is midnight of the next day in UTC.
+			tree.NewDTimeTZFromOffset(timeofday.New(23, 0, 0, 0), 60*60),
+			tree.NewDTimeTZFromOffset(timeofday.New(0, 0, 0, 0), 5*60*60),
+			&tree.DTimeTZ{TimeTZ: timetz.MakeTimeTZ(timeofday.Time2400, timetz.MinTimeTZOffsetSecs)},
+		},
+	}
+}
+
+// TestTimeCmp verifies that the comparisons of the TIME and TIMETZ values
+// return the same results as the row engine in different session time zones.
+func TestTimeCmp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	valuesByFamily := timeOpsTestValues()
+	timeTypes := []*types.T{types.Time, types.TimeTZ}
+	ops := []tree.ComparisonOperator{tree.EQ, tree.NE, tree.LT, tree.LE, tree.GT, tree.GE}
+	// eval returns the result of the comparison of the row engine.
+	eval := func(op tree.ComparisonOperator, l, r tree.Datum) interface{} {
+		if l == tree.DNull || r == tree.DNull {
+			return nil
+		}
+		cmp := l.Compare(&evalCtx, r)
+		switch op {
+		case tree.EQ:
+			return cmp == 0
+		case tree.NE:
+			return cmp != 0
+		case tree.LT:
+			return cmp < 0
+		case tree.LE:
+			return cmp <= 0
+		case tree.GT:
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}
+	// toTuple returns the tuple of the test harness with the datums.
+	toTuple := func(datums ...tree.Datum) colexectestutils.Tuple {
+		tuple := make(colexectestutils.Tuple, len(datums))
+		for i, d := range datums {
+			if d != tree.DNull {
+				tuple[i] = d
+			}
+		}
+		return tuple
+	}
+	for _, locationName := range []string{"UTC", "America/New_York", "Asia/Kolkata"} {
+		loc, err := timeutil.LoadLocation(locationName)
+		require.NoError(t, err)
+		evalCtx.SessionData.Location = loc
+		for _, leftType := range timeTypes {
+			for _, rightType := range timeTypes {
+				leftValues := append(valuesByFamily[leftType.Family()], tree.DNull)
+				rightValues := append(valuesByFamily[rightType.Family()], tree.DNull)
+				constArg := rightValues[0]
+				constExpr := tree.AsStringWithFlags(constArg, tree.FmtParsable)
+				typs := []*types.T{leftType, rightType}
+				for _, op := range ops {
+					var input, expected, constInput, constExpected colexectestutils.Tuples
+					for _, l := range leftValues {
+						for _, r := range rightValues {
+							input = append(input, toTuple(l, r))
+							expected = append(expected, append(toTuple(l, r), eval(op, l, r)))
+						}
+						constInput = append(constInput, toTuple(l))
+						constExpected = append(constExpected, append(toTuple(l), eval(op, l, constArg)))
+					}
+					for _, tc := range []struct {
+						expr     string
+						typs     []*types.T
+						input    colexectestutils.Tuples
+						expected colexectestutils.Tuples
+					}{
+						{expr: fmt.Sprintf("@1 %s @2", op), typs: typs, input: input, expected: expected},
+						{expr: fmt.Sprintf("@1 %s %s", op, constExpr), typs: []*types.T{leftType}, input: constInput, expected: constExpected},
+					} {
+						log.Infof(ctx, "%s/%s %s", locationName, tc.expr, tc.typs)
+						colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.input}, [][]*types.T{tc.typs}, tc.expected, colexectestutils.OrderedVerifier,
+							func(input []colexecop.Operator) (colexecop.Operator, error) {
+								op, err := colexectestutils.CreateTestProjectingOperator(
+									ctx, flowCtx, input[0], tc.typs,
+									tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+								)
+								require.IsType(t, &timeCmpProjOp{}, op, tc.expr)
+								return op, err
+							})
+					}
+
+					// The comparison with the constant on the left is
+					// commuted.
+					lConstExpected := make(colexectestutils.Tuples, 0, len(rightValues))
+					for _, r := range rightValues {
+						lConstExpected = append(lConstExpected, append(toTuple(r), eval(op, leftValues[0], r)))
+					}
+					lConstExpr := fmt.Sprintf("%s %s @1", tree.AsStringWithFlags(leftValues[0], tree.FmtParsable), op)
+					log.Infof(ctx, "%s/%s %s", locationName, lConstExpr, rightType)
+					colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{firstColumn(lConstExpected)}, [][]*types.T{{rightType}}, lConstExpected, colexectestutils.OrderedVerifier,
+						func(input []colexecop.Operator) (colexecop.Operator, error) {
+							return colexectestutils.CreateTestProjectingOperator(
+								ctx, flowCtx, input[0], []*types.T{rightType},
+								lConstExpr, false /* canFallbackToRowexec */, testMemAcc,
+							)
+						})
+
+					// The selection returns only the tuples for which the
+					// comparison is true.
+					var selected colexectestutils.Tuples
+					for _, tuple := range expected {
+						if tuple[2] == true {
+							selected = append(selected, tuple[:2])
+						}
+					}
+					log.Infof(ctx, "%s/%s %s %s selection", locationName, leftType, op, rightType)
+					runner := colexectestutils.RunTestsWithTyps
+					if len(selected) == 0 {
+						// The all nulls injection doesn't change the empty
+						// output.
+						runner = colexectestutils.RunTestsWithoutAllNullsInjection
+					}
+					runner(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{typs}, selected, colexectestutils.OrderedVerifier,
+						func(input []colexecop.Operator) (colexecop.Operator, error) {
+							return GetTimeCmpOperator(
+								&evalCtx, input[0], op, leftType, rightType, 0 /* leftIdx */, 1 /* rightIdx */, nil, /* constRight */
+							)
+						})
+				}
+			}
+		}
+	}
+}
+
+// TestTimeExtract verifies that extract() on the TIME and TIMETZ values
+// returns the same results as the row engine.
+func TestTimeExtract(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	_, overloads := builtins.GetBuiltinProperties("extract")
+	valuesByFamily := timeOpsTestValues()
+	for _, typ := range []*types.T{types.Time, types.TimeTZ} {
+		var overload *tree.Overload
+		for i := range overloads {
+			if overloads[i].Types.MatchAt(typ, 1 /* i */) {
+				overload = &overloads[i]
+			}
+		}
+		require.NotNil(t, overload)
+		for _, field := range []string{
+			"hour", "minute", "second", "millisecond", "microsecond", "epoch", "SECONDS",
+			"timezone", "timezone_hour", "timezone_minute",
+		} {
+			_, supported := getTimeExtractField(field, typ.Family() == types.TimeTZFamily)
+			var input, expected colexectestutils.Tuples
+			for _, d := range valuesByFamily[typ.Family()] {
+				res, err := overload.Fn(&evalCtx, tree.Datums{tree.NewDString(field), d})
+				if err != nil {
+					// The field is not supported for the type.
+					require.False(t, supported, "%s %s", field, typ)
+					continue
+				}
+				input = append(input, colexectestutils.Tuple{d})
+				expected = append(expected, colexectestutils.Tuple{d, float64(*res.(*tree.DFloat))})
+			}
+			if !supported {
+				continue
+			}
+			input = append(input, colexectestutils.Tuple{nil})
+			expected = append(expected, colexectestutils.Tuple{nil, nil})
+			expr := fmt.Sprintf("extract('%s', @1)", field)
+			log.Infof(ctx, "%s %s", expr, typ)
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{{typ}}, expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					op, err := colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], []*types.T{typ},
+						expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+					// The constant field argument is projected out after the
+					// extract operator.
+					extractOp, _, _, ok := colexecbase.UnwrapSimpleProjectOp(op)
+					require.True(t, ok, expr)
+					require.IsType(t, &timeExtractOp{}, extractOp, expr)
+					return op, err
+				})
+		}
+	}
+}
+
+// TestTimeArithMidnightWrap verifies that adding the intervals to and
+// subtracting them from the TIME and TIMETZ values wraps around midnight
+// (the days and the months of the intervals are ignored) and that the offsets
+// of the TIMETZ values are preserved.
+func TestTimeArithMidnightWrap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	tod := func(h, m int) timeofday.TimeOfDay {
+		return timeofday.New(h, m, 0, 0)
+	}
+	timeTuples := func(pairs ...timeofday.TimeOfDay) colexectestutils.Tuples {
+		var res colexectestutils.Tuples
+		for i := 0; i < len(pairs); i += 2 {
+			res = append(res, colexectestutils.Tuple{tree.MakeDTime(pairs[i]), tree.MakeDTime(pairs[i+1])})
+		}
+		return append(res, colexectestutils.Tuple{nil, nil})
+	}
+	const offsetSecs = 5 * 60 * 60
+	timeTZTuples := func(pairs ...timeofday.TimeOfDay) colexectestutils.Tuples {
+		var res colexectestutils.Tuples
+		for i := 0; i < len(pairs); i += 2 {
+			res = append(res, colexectestutils.Tuple{
+				tree.NewDTimeTZFromOffset(pairs[i], offsetSecs), tree.NewDTimeTZFromOffset(pairs[i+1], offsetSecs),
+			})
+		}
+		return append(res, colexectestutils.Tuple{nil, nil})
+	}
+	for _, tc := range []struct {
+		typ    *types.T
+		expr   string
+		tuples colexectestutils.Tuples
+	}{
+		{
+			typ:    types.Time,
+			expr:   "@1 + '1 hour'::INTERVAL",
+			tuples: timeTuples(tod(23, 30), tod(0, 30), tod(12, 0), tod(13, 0), tod(23, 0), tod(0, 0)),
+		},
+		{
+			typ:    types.Time,
+			expr:   "@1 - '1 hour'::INTERVAL",
+			tuples: timeTuples(tod(0, 30), tod(23, 30), tod(0, 0), tod(23, 0), tod(13, 0), tod(12, 0)),
+		},
+		{
+			// Several days are wrapped around.
+			typ:    types.Time,
+			expr:   "'49 hours'::INTERVAL + @1",
+			tuples: timeTuples(tod(23, 30), tod(0, 30), tod(1, 0), tod(2, 0)),
+		},
+		{
+			// The days and the months are ignored.
+			typ:    types.Time,
+			expr:   "@1 + '1 month 1 day 1 hour'::INTERVAL",
+			tuples: timeTuples(tod(23, 30), tod(0, 30), tod(12, 0), tod(13, 0)),
+		},
+		{
+			typ:    types.Time,
+			expr:   "@1 - '-25 hours'::INTERVAL",
+			tuples: timeTuples(tod(23, 30), tod(0, 30), tod(0, 0), tod(1, 0)),
+		},
+		{
+			// The offsets of the TIMETZ values are kept, and the times wrap
+			// around midnight in their own time zone.
+			typ:    types.TimeTZ,
+			expr:   "@1 + '1 hour'::INTERVAL",
+			tuples: timeTZTuples(tod(23, 30), tod(0, 30), tod(12, 0), tod(13, 0)),
+		},
+		{
+			typ:    types.TimeTZ,
+			expr:   "@1 - '90 minutes'::INTERVAL",
+			tuples: timeTZTuples(tod(0, 30), tod(23, 0), tod(13, 0), tod(11, 30)),
+		},
+	} {
+		log.Infof(ctx, "%s %s", tc.expr, tc.typ)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{firstColumn(tc.tuples)}, [][]*types.T{{tc.typ}}, tc.tuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], []*types.T{tc.typ},
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
+
+// firstColumn returns the tuples that contain only the first column of the
+// given tuples.
+func firstColumn(tuples colexectestutils.Tuples) colexectestutils.Tuples {
+	res := make(colexectestutils.Tuples, len(tuples))
+	for i, tuple := range tuples {
+		res[i] = colexectestutils.Tuple{tuple[0]}
+	}
+	return res
+}
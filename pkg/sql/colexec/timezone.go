@@ -0,0 +1,166 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// newTimezoneOperator returns an operator that evaluates timezone() builtin
+// (i.e. the AT TIME ZONE operator) on the Timestamp (if toTimestampTZ is true)
+// or the TimestampTZ column at position argumentCols[1]. If zone is non-nil,
+// then it is the constant time zone; otherwise, the time zone is read from
+// the String column at position argumentCols[0].
+func newTimezoneOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	zone *string,
+	argumentCols []int,
+	toTimestampTZ bool,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	op := &timezoneOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		zoneIdx:        -1,
+		inputIdx:       argumentCols[1],
+		outputIdx:      outputIdx,
+		toTimestampTZ:  toTimestampTZ,
+	}
+	if zone != nil {
+		op.setZone([]byte(*zone))
+	} else {
+		op.zoneIdx = argumentCols[0]
+	}
+	return op
+}
+
+// timezoneOp is an operator that converts the Timestamp values to the
+// TimestampTZ values treating them as located in the given time zone, or the
+// TimestampTZ values to the Timestamp values of the local time in the given
+// time zone. It matches the row engine, so an invalid time zone results in an
+// error only if there is a non-NULL value to be converted.
+type timezoneOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	// zoneIdx is the index of the column with the time zone, -1 if the time
+	// zone is constant.
+	zoneIdx       int
+	inputIdx      int
+	outputIdx     int
+	toTimestampTZ bool
+
+	// loc is the location of the time zone (or locErr is the error of parsing
+	// the time zone) which is set in the constructor if the time zone is
+	// constant. Otherwise, it is updated only when the time zone differs from
+	// the one of the previous row (stored in lastZone, valid only if zoneSet is
+	// true).
+	loc      *time.Location
+	locErr   error
+	lastZone []byte
+	zoneSet  bool
+}
+
+var _ colexecop.Operator = &timezoneOp{}
+
+// setZone parses the time zone the same way as the row engine does.
+func (o *timezoneOp) setZone(zone []byte) {
+	o.lastZone = append(o.lastZone[:0], zone...)
+	o.zoneSet = true
+	o.loc, o.locErr = timeutil.TimeZoneStringToLocation(
+		string(zone), timeutil.TimeZoneStringToLocationPOSIXStandard,
+	)
+}
+
+func (o *timezoneOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(o.inputIdx)
+	inputNulls, inputCol := inputVec.Nulls(), inputVec.Timestamp()
+	var zoneNulls *coldata.Nulls
+	var zoneCol *coldata.Bytes
+	if o.zoneIdx >= 0 {
+		zoneVec := batch.ColVec(o.zoneIdx)
+		zoneNulls, zoneCol = zoneVec.Nulls(), zoneVec.Bytes()
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Timestamp()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if inputNulls.NullAt(rowIdx) || (zoneNulls != nil && zoneNulls.NullAt(rowIdx)) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				if zoneCol != nil {
+					if zone := zoneCol.Get(rowIdx); !o.zoneSet || !bytes.Equal(zone, o.lastZone) {
+						o.setZone(zone)
+					}
+				}
+				if o.locErr != nil {
+					colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(o.locErr))
+				}
+				t := inputCol[rowIdx]
+				if o.toTimestampTZ {
+					// Note that the offset of the time zone is determined at the
+					// instant of the timestamp taken as UTC, same as in the row
+					// engine.
+					_, beforeOffsetSecs := t.Zone()
+					_, afterOffsetSecs := t.In(o.loc).Zone()
+					t = t.Add(time.Duration(beforeOffsetSecs-afterOffsetSecs) * time.Second)
+					// MakeDTimestampTZ performs the same rounding and range check
+					// as the row engine.
+					d, err := tree.MakeDTimestampTZ(t, time.Microsecond)
+					if err != nil {
+						colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+					}
+					outputCol[rowIdx] = d.Time
+				} else {
+					_, locOffsetSecs := t.In(o.loc).Zone()
+					t = t.UTC().Add(time.Duration(locOffsetSecs) * time.Second).UTC()
+					// MakeDTimestamp performs the same rounding and range check as
+					// the row engine.
+					d, err := tree.MakeDTimestamp(t, time.Microsecond)
+					if err != nil {
+						colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+					}
+					outputCol[rowIdx] = d.Time
+				}
+			}
+		},
+	)
+	return batch
+}
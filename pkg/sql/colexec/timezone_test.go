@@ -0,0 +1,247 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimezone(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	utc := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04:05", s)
+		require.NoError(t, err)
+		return ts
+	}
+	// In America/New_York, the clocks were turned forward from 02:00 EST to
+	// 03:00 EDT on 2021-03-14 (at 07:00 UTC) and turned back from 02:00 EDT
+	// to 01:00 EST on 2021-11-07 (at 06:00 UTC).
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "timestamptz across DST boundaries",
+			expr: "@1 AT TIME ZONE 'America/New_York'",
+			inputTuples: colexectestutils.Tuples{
+				{utc("2021-03-14 06:59:59")}, {utc("2021-03-14 07:00:00")},
+				{utc("2021-11-07 05:30:00")}, {utc("2021-11-07 06:30:00")}, {nil},
+			},
+			inputTypes: []*types.T{types.TimestampTZ},
+			// Both 05:30 and 06:30 UTC on 2021-11-07 result in the ambiguous
+			// local time 01:30.
+			outputTuples: colexectestutils.Tuples{
+				{utc("2021-03-14 06:59:59"), utc("2021-03-14 01:59:59")},
+				{utc("2021-03-14 07:00:00"), utc("2021-03-14 03:00:00")},
+				{utc("2021-11-07 05:30:00"), utc("2021-11-07 01:30:00")},
+				{utc("2021-11-07 06:30:00"), utc("2021-11-07 01:30:00")},
+				{nil, nil},
+			},
+		},
+		{
+			// The offset of the time zone is determined at the instant of the
+			// timestamp taken as UTC, so the non-existent local time 02:30 on
+			// 2021-03-14 and the ambiguous local time 01:30 on 2021-11-07 both
+			// use the offset before the transition, and the offset changes at
+			// 07:00 and 06:00 local time respectively, same as in the row
+			// engine.
+			desc: "timestamp across DST boundaries",
+			expr: "@1 AT TIME ZONE 'America/New_York'",
+			inputTuples: colexectestutils.Tuples{
+				{utc("2021-03-14 02:30:00")}, {utc("2021-03-14 06:30:00")}, {utc("2021-03-14 07:30:00")},
+				{utc("2021-11-07 01:30:00")}, {utc("2021-11-07 06:30:00")}, {nil},
+			},
+			inputTypes: []*types.T{types.Timestamp},
+			outputTuples: colexectestutils.Tuples{
+				{utc("2021-03-14 02:30:00"), utc("2021-03-14 07:30:00")},
+				{utc("2021-03-14 06:30:00"), utc("2021-03-14 11:30:00")},
+				{utc("2021-03-14 07:30:00"), utc("2021-03-14 11:30:00")},
+				{utc("2021-11-07 01:30:00"), utc("2021-11-07 05:30:00")},
+				{utc("2021-11-07 06:30:00"), utc("2021-11-07 11:30:00")},
+				{nil, nil},
+			},
+		},
+		{
+			desc: "time zone column",
+			expr: "timezone(@1, @2)",
+			inputTuples: colexectestutils.Tuples{
+				{"UTC", utc("2021-06-01 12:00:00")},
+				{"Europe/London", utc("2021-06-01 12:00:00")},
+				{"Europe/London", utc("2021-01-01 12:00:00")},
+				{"+05:30", utc("2021-06-01 12:00:00")},
+				{"3", utc("2021-06-01 12:00:00")},
+				{nil, utc("2021-06-01 12:00:00")},
+				{"asia/tokyo", nil},
+				{"asia/tokyo", utc("2021-06-01 12:00:00")},
+			},
+			inputTypes: []*types.T{types.String, types.TimestampTZ},
+			// Note that the offsets follow the POSIX convention, so both '3' and
+			// '+05:30' are west of UTC.
+			outputTuples: colexectestutils.Tuples{
+				{"UTC", utc("2021-06-01 12:00:00"), utc("2021-06-01 12:00:00")},
+				{"Europe/London", utc("2021-06-01 12:00:00"), utc("2021-06-01 13:00:00")},
+				{"Europe/London", utc("2021-01-01 12:00:00"), utc("2021-01-01 12:00:00")},
+				{"+05:30", utc("2021-06-01 12:00:00"), utc("2021-06-01 06:30:00")},
+				{"3", utc("2021-06-01 12:00:00"), utc("2021-06-01 09:00:00")},
+				{nil, utc("2021-06-01 12:00:00"), nil},
+				{"asia/tokyo", nil, nil},
+				{"asia/tokyo", utc("2021-06-01 12:00:00"), utc("2021-06-01 21:00:00")},
+			},
+		},
+		{
+			desc:         "invalid time zone on NULL",
+			expr:         "timezone(@1, @2)",
+			inputTuples:  colexectestutils.Tuples{{"Mars/Olympus_Mons", nil}},
+			inputTypes:   []*types.T{types.String, types.Timestamp},
+			outputTuples: colexectestutils.Tuples{{"Mars/Olympus_Mons", nil, nil}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// The invalid time zone results in the same error as in the row engine.
+	typs := []*types.T{types.String, types.Timestamp}
+	input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{{"Mars/Olympus_Mons", utc("2021-06-01 12:00:00")}}, typs)
+	op, err := colexectestutils.CreateTestProjectingOperator(
+		ctx, flowCtx, input, typs, "timezone(@1, @2)", false /* canFallbackToRowexec */, testMemAcc,
+	)
+	require.NoError(t, err)
+	op.Init(ctx)
+	err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+	require.EqualError(t, err, `timezone(): could not parse "Mars/Olympus_Mons" as time zone`)
+}
+
+// TestTimezoneAgainstRowEngine verifies that the vectorized timezone() produces
+// the same results as the row engine around the DST transitions in the time
+// zones with different rules.
+func TestTimezoneAgainstRowEngine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	// Australia/Lord_Howe has the DST offset of 30 minutes, and the clocks in
+	// Australia/Sydney are turned forward in October.
+	zones := []string{
+		"America/New_York", "Europe/London", "Australia/Lord_Howe", "Australia/Sydney", "UTC", "-02:30",
+	}
+	transitions := []time.Time{
+		time.Date(2021, 3, 14, 7, 0, 0, 0, time.UTC),
+		time.Date(2021, 11, 7, 6, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 28, 1, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 31, 1, 0, 0, 0, time.UTC),
+		time.Date(2021, 4, 3, 15, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 2, 15, 30, 0, 0, time.UTC),
+		time.Date(2021, 10, 2, 16, 0, 0, 0, time.UTC),
+	}
+	var input colexectestutils.Tuples
+	var datums []tree.Datums
+	for _, zone := range zones {
+		for _, transition := range transitions {
+			for offset := -15 * time.Hour; offset <= 15*time.Hour; offset += 15 * time.Minute {
+				ts := transition.Add(offset)
+				input = append(input, colexectestutils.Tuple{zone, ts})
+				datums = append(datums, tree.Datums{
+					tree.NewDString(zone), tree.MustMakeDTimestamp(ts, time.Microsecond), tree.MustMakeDTimestampTZ(ts, time.Microsecond),
+				})
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		expr       string
+		inputTypes []*types.T
+	}{
+		{expr: "timezone(@1, @2)", inputTypes: []*types.T{types.String, types.Timestamp}},
+		{expr: "timezone(@1, @2)", inputTypes: []*types.T{types.String, types.TimestampTZ}},
+	} {
+		t.Run(tc.inputTypes[1].String(), func(t *testing.T) {
+			parsed, err := parser.ParseExpr(tc.expr)
+			require.NoError(t, err)
+			semaCtx := tree.MakeSemaContext()
+			semaCtx.IVarContainer = &colexectestutils.MockTypeContext{Typs: tc.inputTypes}
+			typedExpr, err := tree.TypeCheck(ctx, parsed, &semaCtx, types.Any)
+			require.NoError(t, err)
+			funcExpr := typedExpr.(*tree.FuncExpr)
+
+			expected := make(colexectestutils.Tuples, len(input))
+			for i := range input {
+				tsDatum := datums[i][1]
+				if tc.inputTypes[1].Family() == types.TimestampTZFamily {
+					tsDatum = datums[i][2]
+				}
+				res, err := funcExpr.ResolvedOverload().Fn(&evalCtx, tree.Datums{datums[i][0], tsDatum})
+				require.NoError(t, err)
+				var resTime time.Time
+				switch d := res.(type) {
+				case *tree.DTimestamp:
+					resTime = d.Time
+				case *tree.DTimestampTZ:
+					resTime = d.Time
+				}
+				expected[i] = colexectestutils.Tuple{input[i][0], input[i][1], resTime}
+			}
+			colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{input}, [][]*types.T{tc.inputTypes}, expected, colexectestutils.OrderedVerifier,
+				func(input []colexecop.Operator) (colexecop.Operator, error) {
+					return colexectestutils.CreateTestProjectingOperator(
+						ctx, flowCtx, input[0], tc.inputTypes,
+						tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+					)
+				})
+		})
+	}
+}
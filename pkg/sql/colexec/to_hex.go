@@ -0,0 +1,90 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"strconv"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+)
+
+// newToHexOperator returns an operator that evaluates to_hex() builtin on the
+// Int column at position inputIdx.
+func newToHexOperator(
+	allocator *colmem.Allocator, inputIdx int, outputIdx int, input colexecop.Operator,
+) colexecop.Operator {
+	return &toHexOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// toHexOp is an operator that returns the hexadecimal representation of the
+// integers. Same as in the row engine, the integers are always treated as
+// 64-bit unsigned ones, so the negative values are represented in two's
+// complement (e.g. -1 is converted to 'ffffffffffffffff').
+type toHexOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	inputIdx  int
+	outputIdx int
+	// buf is the scratch space for the hexadecimal representation of a single
+	// value. It is large enough to fit the representation of any uint64.
+	buf []byte
+}
+
+var _ colexecop.Operator = &toHexOp{}
+
+func (t *toHexOp) Next() coldata.Batch {
+	batch := t.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(t.inputIdx)
+	nulls, col := vec.Nulls(), vec.Int64()
+	outputVec := batch.ColVec(t.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Bytes()
+	if t.buf == nil {
+		t.buf = make([]byte, 0, 16)
+	}
+	t.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				t.buf = strconv.AppendUint(t.buf[:0], uint64(col[rowIdx]), 16)
+				outputCol.Set(rowIdx, t.buf)
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
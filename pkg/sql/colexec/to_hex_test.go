@@ -0,0 +1,88 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestToHex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc: "INT8",
+			// The negative values are represented in two's complement as
+			// 64-bit integers.
+			inputTuples: colexectestutils.Tuples{
+				{0}, {1}, {10}, {255}, {2147483647}, {-1}, {-256},
+				{math.MaxInt64}, {math.MinInt64}, {nil},
+			},
+			inputTypes: []*types.T{types.Int},
+			outputTuples: colexectestutils.Tuples{
+				{0, "0"}, {1, "1"}, {10, "a"}, {255, "ff"}, {2147483647, "7fffffff"},
+				{-1, "ffffffffffffffff"}, {-256, "ffffffffffffff00"},
+				{math.MaxInt64, "7fffffffffffffff"}, {math.MinInt64, "8000000000000000"},
+				{nil, nil},
+			},
+		},
+		{
+			// INT2 is handled by the default builtin operator, but the results
+			// must be the same.
+			desc: "INT2",
+			inputTuples: colexectestutils.Tuples{
+				{0}, {-1}, {math.MaxInt16}, {nil},
+			},
+			inputTypes: []*types.T{types.Int2},
+			outputTuples: colexectestutils.Tuples{
+				{0, "0"}, {-1, "ffffffffffffffff"}, {math.MaxInt16, "7fff"}, {nil, nil},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{tc.inputTypes}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					"to_hex(@1)", false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
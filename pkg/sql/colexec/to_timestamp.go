@@ -0,0 +1,110 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// newToTimestampOperator returns an operator that evaluates to_timestamp()
+// builtin on the column at position inputIdx. The column is either a Decimal
+// column (if isDecimal is true) or a Float column.
+func newToTimestampOperator(
+	allocator *colmem.Allocator,
+	funcExpr *tree.FuncExpr,
+	isDecimal bool,
+	inputIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	return &toTimestampOp{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		funcExpr:       funcExpr,
+		isDecimal:      isDecimal,
+		inputIdx:       inputIdx,
+		outputIdx:      outputIdx,
+	}
+}
+
+// toTimestampOp is an operator that converts the number of seconds since the
+// Unix epoch to the TimestampTZ values. The conversion is shared with the row
+// engine, so the fractional seconds are rounded the same way, and the same
+// errors are returned for NaN and for the values that exceed the supported
+// timestamp bounds.
+type toTimestampOp struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	funcExpr  *tree.FuncExpr
+	isDecimal bool
+	inputIdx  int
+	outputIdx int
+}
+
+var _ colexecop.Operator = &toTimestampOp{}
+
+func (o *toTimestampOp) Next() coldata.Batch {
+	batch := o.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	vec := batch.ColVec(o.inputIdx)
+	nulls := vec.Nulls()
+	var floatCol coldata.Float64s
+	var decimalCol coldata.Decimals
+	if o.isDecimal {
+		decimalCol = vec.Decimal()
+	} else {
+		floatCol = vec.Float64()
+	}
+	outputVec := batch.ColVec(o.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputNulls, outputCol := outputVec.Nulls(), outputVec.Timestamp()
+	o.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			for i := 0; i < n; i++ {
+				rowIdx := i
+				if sel != nil {
+					rowIdx = sel[i]
+				}
+				if nulls.NullAt(rowIdx) {
+					outputNulls.SetNull(rowIdx)
+					continue
+				}
+				var t time.Time
+				var err error
+				if o.isDecimal {
+					t, err = tree.TimeFromEpochDecimal(&decimalCol[rowIdx])
+				} else {
+					t, err = tree.TimeFromEpochFloat(floatCol[rowIdx])
+				}
+				if err != nil {
+					colexecerror.ExpectedError(o.funcExpr.MaybeWrapError(err))
+				}
+				outputCol[rowIdx] = t
+			}
+		},
+	)
+	return batch
+}
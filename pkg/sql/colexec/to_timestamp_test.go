@@ -0,0 +1,187 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/apd/v2"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil/pgdate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	minSecs, maxSecs := tree.MinSupportedTime.Unix(), tree.MaxSupportedTime.Unix()
+	mustParseDecimal := func(s string) apd.Decimal {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		return *d
+	}
+	testCases := []struct {
+		desc         string
+		inputType    *types.T
+		inputTuples  colexectestutils.Tuples
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:      "float",
+			inputType: types.Float,
+			inputTuples: colexectestutils.Tuples{
+				{0.0}, {1.5}, {-1.5}, {1617235200.25}, {1e-7}, {0.9999999}, {nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{0.0, timeutil.Unix(0, 0)},
+				{1.5, timeutil.Unix(1, 5e8)},
+				{-1.5, timeutil.Unix(-2, 5e8)},
+				{1617235200.25, timeutil.Unix(1617235200, 25e7)},
+				// The fractional seconds are rounded to microseconds.
+				{1e-7, timeutil.Unix(0, 0)},
+				{0.9999999, timeutil.Unix(1, 0)},
+				{nil, nil},
+			},
+		},
+		{
+			desc:      "float boundaries",
+			inputType: types.Float,
+			inputTuples: colexectestutils.Tuples{
+				{float64(minSecs)}, {float64(maxSecs)}, {math.Inf(1)}, {math.Inf(-1)},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{float64(minSecs), tree.MinSupportedTime},
+				{float64(maxSecs), timeutil.Unix(maxSecs, 0)},
+				{math.Inf(1), pgdate.TimeInfinity},
+				{math.Inf(-1), pgdate.TimeNegativeInfinity},
+			},
+		},
+		{
+			desc:      "decimal",
+			inputType: types.Decimal,
+			inputTuples: colexectestutils.Tuples{
+				{mustParseDecimal("1.5")}, {mustParseDecimal("-1.5")},
+				{mustParseDecimal("0.0000005")}, {mustParseDecimal("0.0000015")},
+				{mustParseDecimal("-0.0000005")}, {mustParseDecimal("1617235200.1234567")},
+				{nil},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{mustParseDecimal("1.5"), timeutil.Unix(1, 5e8)},
+				{mustParseDecimal("-1.5"), timeutil.Unix(-2, 5e8)},
+				// The fractional seconds are rounded to microseconds with ties
+				// to even.
+				{mustParseDecimal("0.0000005"), timeutil.Unix(0, 0)},
+				{mustParseDecimal("0.0000015"), timeutil.Unix(0, 2000)},
+				{mustParseDecimal("-0.0000005"), timeutil.Unix(0, 0)},
+				{mustParseDecimal("1617235200.1234567"), timeutil.Unix(1617235200, 123457000)},
+				{nil, nil},
+			},
+		},
+		{
+			desc:      "decimal boundaries",
+			inputType: types.Decimal,
+			inputTuples: colexectestutils.Tuples{
+				{mustParseDecimal("-210866803200")}, {mustParseDecimal("9224318015999.9999994")},
+				{mustParseDecimal("Infinity")}, {mustParseDecimal("-Infinity")},
+			},
+			outputTuples: colexectestutils.Tuples{
+				{mustParseDecimal("-210866803200"), tree.MinSupportedTime},
+				{mustParseDecimal("9224318015999.9999994"), tree.MaxSupportedTime},
+				{mustParseDecimal("Infinity"), pgdate.TimeInfinity},
+				{mustParseDecimal("-Infinity"), pgdate.TimeNegativeInfinity},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		typs := []*types.T{tc.inputType}
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, [][]*types.T{typs}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], typs,
+					"to_timestamp(@1)", false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+
+	// NaN and the values that exceed the supported timestamp bounds result in
+	// the same errors as in the row engine.
+	for _, tc := range []struct {
+		inputTuple  colexectestutils.Tuple
+		inputType   *types.T
+		expectedErr string
+	}{
+		{
+			inputTuple:  colexectestutils.Tuple{math.NaN()},
+			inputType:   types.Float,
+			expectedErr: "to_timestamp(): timestamp cannot be NaN",
+		},
+		{
+			inputTuple:  colexectestutils.Tuple{float64(minSecs - 1)},
+			inputType:   types.Float,
+			expectedErr: `to_timestamp(): timestamp out of range: "-2.10866803201e+11"`,
+		},
+		{
+			inputTuple:  colexectestutils.Tuple{1e20},
+			inputType:   types.Float,
+			expectedErr: `to_timestamp(): timestamp out of range: "1e+20"`,
+		},
+		{
+			inputTuple:  colexectestutils.Tuple{mustParseDecimal("NaN")},
+			inputType:   types.Decimal,
+			expectedErr: "to_timestamp(): timestamp cannot be NaN",
+		},
+		{
+			// The value is rounded up beyond the maximum supported timestamp.
+			inputTuple:  colexectestutils.Tuple{mustParseDecimal("9224318015999.9999995")},
+			inputType:   types.Decimal,
+			expectedErr: `to_timestamp(): timestamp out of range: "9224318015999.9999995"`,
+		},
+		{
+			inputTuple:  colexectestutils.Tuple{mustParseDecimal("-210866803200.000001")},
+			inputType:   types.Decimal,
+			expectedErr: `to_timestamp(): timestamp out of range: "-210866803200.000001"`,
+		},
+	} {
+		typs := []*types.T{tc.inputType}
+		input := colexectestutils.NewOpTestInput(testAllocator, 1, colexectestutils.Tuples{tc.inputTuple}, typs)
+		op, err := colexectestutils.CreateTestProjectingOperator(
+			ctx, flowCtx, input, typs, "to_timestamp(@1)", false /* canFallbackToRowexec */, testMemAcc,
+		)
+		require.NoError(t, err)
+		op.Init(ctx)
+		err = colexecerror.CatchVectorizedRuntimeError(func() { op.Next() })
+		require.EqualError(t, err, tc.expectedErr)
+	}
+}
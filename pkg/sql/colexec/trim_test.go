@@ -0,0 +1,123 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestTrim(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expr         string
+		inputTuples  colexectestutils.Tuples
+		inputTypes   []*types.T
+		outputTuples colexectestutils.Tuples
+	}{
+		{
+			desc:         "btrim whitespace",
+			expr:         "btrim(@1)",
+			inputTuples:  colexectestutils.Tuples{{"  abc \t"}, {"abc"}, {"   "}, {nil}, {""}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"  abc \t", "abc"}, {"abc", "abc"}, {"   ", ""}, {nil, nil}, {"", ""}},
+		},
+		{
+			desc:         "ltrim whitespace",
+			expr:         "ltrim(@1)",
+			inputTuples:  colexectestutils.Tuples{{"  abc \t"}, {"abc"}, {" 　abc"}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"  abc \t", "abc \t"}, {"abc", "abc"}, {" 　abc", "abc"}, {nil, nil}},
+		},
+		{
+			desc:         "rtrim whitespace",
+			expr:         "rtrim(@1)",
+			inputTuples:  colexectestutils.Tuples{{"  abc \t"}, {"abc"}, {"abc "}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"  abc \t", "  abc"}, {"abc", "abc"}, {"abc ", "abc"}, {nil, nil}},
+		},
+		{
+			desc:         "btrim everything",
+			expr:         "btrim(@1, 'xy')",
+			inputTuples:  colexectestutils.Tuples{{"xyyx"}, {"x"}, {nil}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"xyyx", ""}, {"x", ""}, {nil, nil}},
+		},
+		{
+			desc:         "btrim nothing",
+			expr:         "btrim(@1, 'xy')",
+			inputTuples:  colexectestutils.Tuples{{"abc"}, {"axyb"}, {""}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc", "abc"}, {"axyb", "axyb"}, {"", ""}},
+		},
+		{
+			desc:         "ltrim and rtrim custom",
+			expr:         "ltrim(@1, 'xy') || rtrim(@1, 'xy')",
+			inputTuples:  colexectestutils.Tuples{{"xyaxy"}, {"b"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"xyaxy", "axyxya"}, {"b", "bb"}},
+		},
+		{
+			desc:         "multibyte",
+			expr:         "btrim(@1, 'éß')",
+			inputTuples:  colexectestutils.Tuples{{"ßéxßé"}, {"éé"}, {"ée"}, {"日本ß"}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"ßéxßé", "x"}, {"éé", ""}, {"ée", "e"}, {"日本ß", "日本"}},
+		},
+		{
+			desc:         "empty trim characters",
+			expr:         "rtrim(@1, '')",
+			inputTuples:  colexectestutils.Tuples{{"abc  "}},
+			inputTypes:   []*types.T{types.String},
+			outputTuples: colexectestutils.Tuples{{"abc  ", "abc  "}},
+		},
+		{
+			desc:         "non-constant trim characters",
+			expr:         "btrim(@1, @2)",
+			inputTuples:  colexectestutils.Tuples{{"xyaxy", "xy"}, {"abc", "c"}, {"abc", nil}},
+			inputTypes:   []*types.T{types.String, types.String},
+			outputTuples: colexectestutils.Tuples{{"xyaxy", "xy", "a"}, {"abc", "c", "ab"}, {"abc", nil, nil}},
+		},
+	}
+
+	for _, tc := range testCases {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTests(t, testAllocator, []colexectestutils.Tuples{tc.inputTuples}, tc.outputTuples, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				return colexectestutils.CreateTestProjectingOperator(
+					ctx, flowCtx, input[0], tc.inputTypes,
+					tc.expr, false /* canFallbackToRowexec */, testMemAcc,
+				)
+			})
+	}
+}
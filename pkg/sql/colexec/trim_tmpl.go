@@ -0,0 +1,189 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// {{/*
+// +build execgen_template
+//
+// This file is the execgen template for trim.eg.go. It's formatted in a
+// special way, so it's both valid Go and a valid text/template input. This
+// permits editing this file with editor support.
+//
+// */}}
+
+package colexec
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+)
+
+// {{/*
+
+// _TRIM_SPACE is the template function for trimming the whitespace from the
+// first argument.
+func _TRIM_SPACE(_ []byte) []byte {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// _TRIM_CUTSET is the template function for trimming all characters included
+// into the second argument from the first argument.
+func _TRIM_CUTSET(_ []byte, _ string) []byte {
+	colexecerror.InternalError(errors.AssertionFailedf(""))
+}
+
+// */}}
+
+// newTrimOperator returns an operator that trims the Bytes column at position
+// colIdx according to the trimming builtin and writes the result into the
+// column at position outputIdx. If trimChars is nil, then the whitespace is
+// trimmed.
+func newTrimOperator(
+	allocator *colmem.Allocator,
+	builtin tree.SpecializedVectorizedBuiltin,
+	trimChars *string,
+	colIdx int,
+	outputIdx int,
+	input colexecop.Operator,
+) colexecop.Operator {
+	base := trimOpBase{
+		OneInputHelper: colexecop.MakeOneInputHelper(input),
+		allocator:      allocator,
+		colIdx:         colIdx,
+		outputIdx:      outputIdx,
+	}
+	if trimChars != nil {
+		base.cutset = *trimChars
+	} else {
+		base.trimWhitespace = true
+	}
+	switch builtin {
+	case tree.BTrimString:
+		return &trimOp{trimOpBase: base}
+	case tree.LTrimString, tree.LTrimStringString:
+		return &ltrimOp{trimOpBase: base}
+	case tree.RTrimString, tree.RTrimStringString:
+		return &rtrimOp{trimOpBase: base}
+	}
+	colexecerror.InternalError(errors.AssertionFailedf("unsupported trim builtin %d", builtin))
+	// This code is unreachable, but the compiler cannot infer that.
+	return nil
+}
+
+type trimOpBase struct {
+	colexecop.OneInputHelper
+	allocator *colmem.Allocator
+	colIdx    int
+	outputIdx int
+	// trimWhitespace, if set, indicates that the whitespace (as defined by
+	// unicode.IsSpace) is trimmed. Otherwise, all characters included into
+	// cutset are trimmed.
+	trimWhitespace bool
+	cutset         string
+}
+
+// trimLeftSpace removes the leading whitespace from b.
+func trimLeftSpace(b []byte) []byte {
+	return bytes.TrimLeftFunc(b, unicode.IsSpace)
+}
+
+// trimRightSpace removes the trailing whitespace from b.
+func trimRightSpace(b []byte) []byte {
+	return bytes.TrimRightFunc(b, unicode.IsSpace)
+}
+
+// {{range .}}
+
+// _OP_NAMEOp is an operator that evaluates _OP_NAME() builtin on a Bytes
+// column.
+type _OP_NAMEOp struct {
+	trimOpBase
+}
+
+var _ colexecop.Operator = &_OP_NAMEOp{}
+
+func (t *_OP_NAMEOp) Next() coldata.Batch {
+	batch := t.Input.Next()
+	n := batch.Length()
+	if n == 0 {
+		return coldata.ZeroBatch
+	}
+	sel := batch.Selection()
+	inputVec := batch.ColVec(t.colIdx)
+	inputCol := inputVec.Bytes()
+	inputNulls := inputVec.Nulls()
+	outputVec := batch.ColVec(t.outputIdx)
+	if outputVec.MaybeHasNulls() {
+		// We need to make sure that there are no left over null values in the
+		// output vector.
+		outputVec.Nulls().UnsetNulls()
+	}
+	outputCol := outputVec.Bytes()
+	outputNulls := outputVec.Nulls()
+	t.allocator.PerformOperation(
+		[]coldata.Vec{outputVec},
+		func() {
+			if inputVec.MaybeHasNulls() {
+				if t.trimWhitespace {
+					_TRIM_LOOP(true, true)
+				} else {
+					_TRIM_LOOP(true, false)
+				}
+			} else {
+				if t.trimWhitespace {
+					_TRIM_LOOP(false, true)
+				} else {
+					_TRIM_LOOP(false, false)
+				}
+			}
+		},
+	)
+	// Although we didn't change the length of the batch, it is necessary to set
+	// the length anyway (this helps maintaining the invariant of flat bytes).
+	batch.SetLength(n)
+	return batch
+}
+
+// {{end}}
+
+// {{/*
+func _TRIM_LOOP(_HAS_NULLS bool, _TRIM_WHITESPACE bool) { // */}}
+	// {{define "trimLoop" -}}
+	for i := 0; i < n; i++ {
+		rowIdx := i
+		if sel != nil {
+			rowIdx = sel[i]
+		}
+		// {{if .HasNulls}}
+		if inputNulls.NullAt(rowIdx) {
+			outputNulls.SetNull(rowIdx)
+			continue
+		}
+		// {{end}}
+		// Note that trimming only reslices the input value, so the only copy
+		// happens when the result is set into the output vector.
+		v := inputCol.Get(rowIdx)
+		// {{if .TrimWhitespace}}
+		outputCol.Set(rowIdx, _TRIM_SPACE(v))
+		// {{else}}
+		outputCol.Set(rowIdx, _TRIM_CUTSET(v, t.cutset))
+		// {{end}}
+	}
+	// {{end}}
+	// {{/*
+}
+
+// */}}
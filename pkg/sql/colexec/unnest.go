@@ -0,0 +1,196 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/sql/colconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+)
+
+// NewUnnestOp returns an operator that evaluates the unnest set-returning
+// function on the array column at position arrayColIdx. Each input tuple is
+// repeated once for each element of its array with the element appended after
+// the first numInputCols columns of the input (the remaining columns of the
+// input, if any, are not emitted). If withOrdinality is true, then the 1-based
+// position of the element within its array is appended as well (this is
+// equivalent to unnest WITH ORDINALITY applied to each input tuple separately,
+// as well as to information_schema._pg_expandarray).
+//
+// The tuples with NULL or empty arrays don't produce any output.
+func NewUnnestOp(
+	allocator *colmem.Allocator,
+	input colexecop.Operator,
+	inputTypes []*types.T,
+	numInputCols int,
+	arrayColIdx int,
+	withOrdinality bool,
+	maxOutputBatchMemSize int64,
+) (colexecop.Operator, error) {
+	// Only the array argument is supported (for example, the argument might
+	// be an untyped NULL).
+	arrayType := inputTypes[arrayColIdx]
+	if arrayType.Family() != types.ArrayFamily {
+		return nil, errors.Newf("unsupported array argument type %s", arrayType)
+	}
+	elemType := arrayType.ArrayContents()
+	outputTypes := make([]*types.T, numInputCols, numInputCols+2)
+	copy(outputTypes, inputTypes[:numInputCols])
+	outputTypes = append(outputTypes, elemType)
+	if withOrdinality {
+		outputTypes = append(outputTypes, types.Int)
+	}
+	return &unnestOp{
+		OneInputHelper:        colexecop.MakeOneInputHelper(input),
+		allocator:             allocator,
+		outputTypes:           outputTypes,
+		numInputCols:          numInputCols,
+		arrayColIdx:           arrayColIdx,
+		withOrdinality:        withOrdinality,
+		maxOutputBatchMemSize: maxOutputBatchMemSize,
+		toPhysical:            colconv.GetDatumToPhysicalFn(elemType),
+	}, nil
+}
+
+// unnestOp expands each input tuple into as many output tuples as there are
+// elements in its array. Since a single input tuple can produce an arbitrary
+// number of output tuples, the operator keeps track of the input tuple being
+// currently expanded across the calls to Next.
+type unnestOp struct {
+	colexecop.OneInputHelper
+
+	allocator             *colmem.Allocator
+	outputTypes           []*types.T
+	numInputCols          int
+	arrayColIdx           int
+	withOrdinality        bool
+	maxOutputBatchMemSize int64
+	toPhysical            func(tree.Datum) interface{}
+
+	// batch is the current input batch, and nextIdx is the position of the
+	// next tuple in it (before applying the selection vector) to be expanded.
+	batch   coldata.Batch
+	nextIdx int
+	// array is the array of the tuple at position rowIdx in batch which is
+	// currently being expanded (nil if there is no such tuple), and elemIdx is
+	// the position of the element of the array to be emitted next.
+	array   *tree.DArray
+	rowIdx  int
+	elemIdx int
+
+	output coldata.Batch
+	// srcIdxs contains the position of the input tuple in batch for each of
+	// the output tuples.
+	srcIdxs []int
+}
+
+var _ colexecop.Operator = &unnestOp{}
+
+func (o *unnestOp) Next() coldata.Batch {
+	if o.batch == nil {
+		o.batch = o.Input.Next()
+	}
+	if o.batch.Length() == 0 {
+		return coldata.ZeroBatch
+	}
+	// Most commonly, every input tuple expands into a few output ones, so we
+	// use the length of the input batch as the estimate of the output size.
+	o.output, _ = o.allocator.ResetMaybeReallocate(
+		o.outputTypes, o.output, o.batch.Length(), o.maxOutputBatchMemSize,
+	)
+	if cap(o.srcIdxs) < o.output.Capacity() {
+		o.srcIdxs = make([]int, o.output.Capacity())
+	}
+	o.srcIdxs = o.srcIdxs[:o.output.Capacity()]
+	elemVec := o.output.ColVec(o.numInputCols)
+	var ordinalities coldata.Int64s
+	if o.withOrdinality {
+		ordinalities = o.output.ColVec(o.numInputCols + 1).Int64()
+	}
+	var outputIdx int
+	o.allocator.PerformOperation(o.output.ColVecs(), func() {
+		// batchStartIdx is the position of the first output tuple that was
+		// generated from the current input batch.
+		batchStartIdx := 0
+		for outputIdx < o.output.Capacity() {
+			if o.array == nil && !o.startNextTuple() {
+				// The current input batch has been fully consumed, so we
+				// copy the input columns for the output tuples generated
+				// from it before moving onto the next batch.
+				copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+				batchStartIdx = outputIdx
+				o.batch = o.Input.Next()
+				o.nextIdx = 0
+				if o.batch.Length() == 0 {
+					break
+				}
+				continue
+			}
+			// Emit as many elements of the current tuple as fit into the
+			// output batch.
+			for ; o.array != nil && outputIdx < o.output.Capacity(); outputIdx++ {
+				if elem := o.array.Array[o.elemIdx]; elem == tree.DNull {
+					elemVec.Nulls().SetNull(outputIdx)
+				} else {
+					coldata.SetValueAt(elemVec, o.toPhysical(elem), outputIdx)
+				}
+				if o.withOrdinality {
+					// The ordinality is 1-based and is reset for each input
+					// tuple.
+					ordinalities[outputIdx] = int64(o.elemIdx + 1)
+				}
+				o.srcIdxs[outputIdx] = o.rowIdx
+				o.elemIdx++
+				if o.elemIdx == o.array.Len() {
+					o.array = nil
+				}
+			}
+		}
+		copyExpandedInputColumns(o.output, o.batch, o.numInputCols, o.srcIdxs, batchStartIdx, outputIdx)
+		o.output.SetLength(outputIdx)
+	})
+	if outputIdx == 0 {
+		return coldata.ZeroBatch
+	}
+	return o.output
+}
+
+// startNextTuple finds the next tuple in the current input batch that has a
+// non-empty array and prepares it for the expansion. false is returned if the
+// batch has been fully consumed.
+func (o *unnestOp) startNextTuple() bool {
+	n := o.batch.Length()
+	sel := o.batch.Selection()
+	arrayVec := o.batch.ColVec(o.arrayColIdx)
+	arrayNulls, arrayCol := arrayVec.Nulls(), arrayVec.Datum()
+	for ; o.nextIdx < n; o.nextIdx++ {
+		rowIdx := o.nextIdx
+		if sel != nil {
+			rowIdx = sel[o.nextIdx]
+		}
+		if arrayNulls.NullAt(rowIdx) {
+			continue
+		}
+		arr := tree.MustBeDArray(arrayCol.Get(rowIdx).(*coldataext.Datum).Datum)
+		if arr.Len() == 0 {
+			continue
+		}
+		o.array, o.rowIdx, o.elemIdx = arr, rowIdx, 0
+		o.nextIdx++
+		return true
+	}
+	return false
+}
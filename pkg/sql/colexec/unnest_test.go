@@ -0,0 +1,121 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colexec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+func TestUnnest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	// longArray expands into several output batches on its own.
+	longArrayLen := 2*coldata.BatchSize() + 3
+	var longArray strings.Builder
+	longArray.WriteString("ARRAY[")
+	var longArrayExpected, longArrayOrdinalityExpected colexectestutils.Tuples
+	for i := 0; i < longArrayLen; i++ {
+		if i > 0 {
+			longArray.WriteString(", ")
+		}
+		fmt.Fprintf(&longArray, "%d", 10*i)
+		longArrayExpected = append(longArrayExpected, colexectestutils.Tuple{3, 10 * i})
+		longArrayOrdinalityExpected = append(longArrayOrdinalityExpected, colexectestutils.Tuple{3, 10 * i, i + 1})
+	}
+	longArray.WriteString("]")
+	// The ordinality is reset for the tuples following the long array.
+	longArrayExpected = append(longArrayExpected, colexectestutils.Tuple{4, 7}, colexectestutils.Tuple{4, 8})
+	longArrayOrdinalityExpected = append(
+		longArrayOrdinalityExpected, colexectestutils.Tuple{4, 7, 1}, colexectestutils.Tuple{4, 8, 2},
+	)
+
+	for _, tc := range []struct {
+		desc           string
+		typs           []*types.T
+		withOrdinality bool
+		tuples         colexectestutils.Tuples
+		expected       colexectestutils.Tuples
+	}{
+		{
+			desc: "ints",
+			typs: []*types.T{types.Int, types.IntArray},
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY[5, 6, 7]"}, {1, "ARRAY[]:::INT[]"}, {2, nil},
+				{nil, "ARRAY[NULL]:::INT[]"}, {4, "ARRAY[8, NULL]"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, 5}, {0, 6}, {0, 7}, {nil, nil}, {4, 8}, {4, nil},
+			},
+		},
+		{
+			desc:           "ints with ordinality",
+			typs:           []*types.T{types.Int, types.IntArray},
+			withOrdinality: true,
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY[5, 6, 7]"}, {1, "ARRAY[]:::INT[]"}, {2, nil},
+				{nil, "ARRAY[NULL]:::INT[]"}, {4, "ARRAY[8, NULL]"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, 5, 1}, {0, 6, 2}, {0, 7, 3}, {nil, nil, 1}, {4, 8, 1}, {4, nil, 2},
+			},
+		},
+		{
+			desc:           "strings with ordinality",
+			typs:           []*types.T{types.Int, types.StringArray},
+			withOrdinality: true,
+			tuples: colexectestutils.Tuples{
+				{0, "ARRAY['a', NULL, '']"}, {1, "ARRAY['bc']"},
+			},
+			expected: colexectestutils.Tuples{
+				{0, "a", 1}, {0, nil, 2}, {0, "", 3}, {1, "bc", 1},
+			},
+		},
+		{
+			desc: "long array",
+			typs: []*types.T{types.Int, types.IntArray},
+			tuples: colexectestutils.Tuples{
+				{1, "ARRAY[]:::INT[]"}, {3, longArray.String()}, {4, "ARRAY[7, 8]"}, {5, nil},
+			},
+			expected: longArrayExpected,
+		},
+		{
+			desc:           "long array with ordinality",
+			typs:           []*types.T{types.Int, types.IntArray},
+			withOrdinality: true,
+			tuples: colexectestutils.Tuples{
+				{1, "ARRAY[]:::INT[]"}, {3, longArray.String()}, {4, "ARRAY[7, 8]"}, {5, nil},
+			},
+			expected: longArrayOrdinalityExpected,
+		},
+	} {
+		log.Infof(ctx, "%s", tc.desc)
+		colexectestutils.RunTestsWithTyps(t, testAllocator, []colexectestutils.Tuples{tc.tuples}, [][]*types.T{tc.typs}, tc.expected, colexectestutils.OrderedVerifier,
+			func(input []colexecop.Operator) (colexecop.Operator, error) {
+				// Only the first input column is emitted.
+				return NewUnnestOp(
+					testAllocator, input[0], tc.typs, 1 /* numInputCols */, 1 /* arrayColIdx */, tc.withOrdinality, math.MaxInt64, /* maxOutputBatchMemSize */
+				)
+			})
+	}
+}
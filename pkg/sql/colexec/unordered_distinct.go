@@ -60,16 +60,24 @@ func (op *unorderedDistinct) Init(ctx context.Context) {
 		return
 	}
 	op.Input.Init(op.Ctx)
+	op.ht = newUnorderedDistinctHashTable(op.Ctx, op.allocator, op.typs, op.distinctCols)
+}
+
+// newUnorderedDistinctHashTable returns a new hash table that is used to
+// perform the unordered DISTINCT operation on distinctCols.
+func newUnorderedDistinctHashTable(
+	ctx context.Context, allocator *colmem.Allocator, typs []*types.T, distinctCols []uint32,
+) *colexechash.HashTable {
 	// These numbers were chosen after running the micro-benchmarks.
 	const hashTableLoadFactor = 2.0
 	const hashTableNumBuckets = 128
-	op.ht = colexechash.NewHashTable(
-		op.Ctx,
-		op.allocator,
+	return colexechash.NewHashTable(
+		ctx,
+		allocator,
 		hashTableLoadFactor,
 		hashTableNumBuckets,
-		op.typs,
-		op.distinctCols,
+		typs,
+		distinctCols,
 		true, /* allowNullEquality */
 		colexechash.HashTableDistinctBuildMode,
 		colexechash.HashTableDefaultProbeMode,
@@ -53,6 +53,15 @@ type ColBatchScan struct {
 	rf          *cFetcher
 	limitHint   int64
 	parallelize bool
+	// runtimeSpans, if set, is consulted right before the scan is started in
+	// order to narrow down the spans from the spec. In such case starting the
+	// scan is postponed until the first call to Next.
+	runtimeSpans RuntimeSpansProvider
+	// scanStarted indicates whether StartScan has been called on the cFetcher.
+	scanStarted bool
+	// done is set when the runtime spans don't intersect with the spans from
+	// the spec, so nothing needs to be read.
+	done bool
 	// tracingSpan is created when the stats should be collected for the query
 	// execution, and it will be finished when closing the operator.
 	tracingSpan *tracing.Span
@@ -68,6 +77,13 @@ type ColBatchScan struct {
 	ResultTypes []*types.T
 }
 
+// RuntimeSpansProvider returns the key spans that are only known at runtime
+// (for example, the ones derived from the result of a subquery or from the
+// build side of a hash join) and which constrain the spans of the scan. If ok
+// is false, then the scan is not constrained at runtime. The returned spans
+// can be unsorted and overlapping.
+type RuntimeSpansProvider func() (spans roachpb.Spans, ok bool)
+
 var _ colexecop.KVReader = &ColBatchScan{}
 var _ execinfra.Releasable = &ColBatchScan{}
 var _ colexecop.Closer = &ColBatchScan{}
@@ -83,6 +99,34 @@ func (s *ColBatchScan) Init(ctx context.Context) {
 	// cFetcher. Note that ProcessorSpan method itself will check whether
 	// tracing is enabled.
 	s.Ctx, s.tracingSpan = execinfra.ProcessorSpan(s.Ctx, "colbatchscan")
+	if s.runtimeSpans == nil {
+		s.startScan()
+	}
+}
+
+// SetRuntimeSpansProvider sets the provider of the spans that will constrain
+// the scan at runtime. It must be called before Init.
+func (s *ColBatchScan) SetRuntimeSpansProvider(provider RuntimeSpansProvider) {
+	s.runtimeSpans = provider
+}
+
+// startScan starts the scan of s.spans, possibly narrowed down by the runtime
+// spans.
+func (s *ColBatchScan) startScan() {
+	s.scanStarted = true
+	if s.runtimeSpans != nil {
+		if runtimeSpans, ok := s.runtimeSpans(); ok {
+			// Note that we update s.spans so that the misplanned ranges are
+			// computed for the spans that were actually read. The intersection
+			// is copied into the existing slice since it is pooled and reused
+			// by the next ColBatchScan.
+			s.spans = append(s.spans[:0], intersectSpans(s.spans, runtimeSpans)...)
+			if len(s.spans) == 0 {
+				s.done = true
+				return
+			}
+		}
+	}
 	limitBatches := !s.parallelize
 	if err := s.rf.StartScan(
 		s.flowCtx.Txn, s.spans, limitBatches, s.limitHint, s.flowCtx.TraceKV,
@@ -92,8 +136,52 @@ func (s *ColBatchScan) Init(ctx context.Context) {
 	}
 }
 
+// intersectSpans returns the intersection of spans, which must be sorted and
+// non-overlapping, with runtimeSpans, which can be arbitrary. The result is
+// sorted and non-overlapping as well.
+func intersectSpans(spans, runtimeSpans roachpb.Spans) roachpb.Spans {
+	// Make a copy of the runtime spans since MergeSpans modifies its argument.
+	runtimeSpans, _ = roachpb.MergeSpans(append(roachpb.Spans(nil), runtimeSpans...))
+	var result roachpb.Spans
+	// Since both slices are sorted, the intersections are produced in the
+	// sorted order too.
+	for _, sp := range spans {
+		for _, rsp := range runtimeSpans {
+			if !sp.Overlaps(rsp) {
+				continue
+			}
+			switch {
+			case len(sp.EndKey) == 0:
+				// The span is a single key contained in the runtime span.
+				result = append(result, sp)
+			case len(rsp.EndKey) == 0:
+				// The runtime span is a single key contained in the span. We
+				// don't use a single key span here since GetRequests cannot be
+				// mixed with ReverseScanRequests in a limited batch.
+				result = append(result, roachpb.Span{Key: rsp.Key, EndKey: rsp.Key.Next()})
+			default:
+				intersection := sp
+				if rsp.Key.Compare(intersection.Key) > 0 {
+					intersection.Key = rsp.Key
+				}
+				if rsp.EndKey.Compare(intersection.EndKey) < 0 {
+					intersection.EndKey = rsp.EndKey
+				}
+				result = append(result, intersection)
+			}
+		}
+	}
+	return result
+}
+
 // Next is part of the Operator interface.
 func (s *ColBatchScan) Next() coldata.Batch {
+	if !s.scanStarted {
+		s.startScan()
+	}
+	if s.done {
+		return coldata.ZeroBatch
+	}
 	bat, err := s.rf.NextBatch(s.Ctx)
 	if err != nil {
 		colexecerror.InternalError(err)
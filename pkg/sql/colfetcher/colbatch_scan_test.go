@@ -0,0 +1,202 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colfetcher_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkv"
+	"github.com/cockroachdb/cockroach/pkg/sql/colfetcher"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestColBatchScanRuntimeSpans verifies that the spans supplied at runtime
+// constrain the spans of the ColBatchScan so that only the rows within both
+// sets of spans are read.
+func TestColBatchScanRuntimeSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 100
+	sqlutils.CreateTable(
+		t, sqlDB, "t",
+		"k INT PRIMARY KEY",
+		numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn),
+	)
+	desc := catalogkv.TestingGetTableDescriptor(kvDB, keys.SystemSQLCodec, "test", "t")
+
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg: &execinfra.ServerConfig{
+			Settings: st,
+		},
+		Txn:    kv.NewTxn(ctx, s.DB(), s.NodeID()),
+		NodeID: evalCtx.NodeID,
+		Local:  true,
+	}
+	memAcc := evalCtx.Mon.MakeBoundAccount()
+	defer memAcc.Close(ctx)
+	allocator := colmem.NewAllocator(ctx, &memAcc, coldata.StandardColumnFactory)
+
+	key := func(k int) roachpb.Key {
+		res, err := randgen.TestingMakePrimaryIndexKey(desc, k)
+		require.NoError(t, err)
+		return res
+	}
+	// rowKey returns the key of the only column family of the row k.
+	rowKey := func(k int) roachpb.Key {
+		return keys.MakeFamilyKey(key(k), 0 /* famID */)
+	}
+	// span returns the span of the rows in [start, end).
+	span := func(start, end int) roachpb.Span {
+		return roachpb.Span{Key: key(start), EndKey: key(end)}
+	}
+	// rowsInRange returns the rows in [start, end).
+	rowsInRange := func(start, end int) []int64 {
+		var res []int64
+		for i := start; i < end; i++ {
+			res = append(res, int64(i))
+		}
+		return res
+	}
+	concat := func(rows ...[]int64) []int64 {
+		var res []int64
+		for _, r := range rows {
+			res = append(res, r...)
+		}
+		return res
+	}
+
+	for i, tc := range []struct {
+		specSpans    roachpb.Spans
+		runtimeSpans roachpb.Spans
+		// unconstrained, if set, indicates that the provider doesn't constrain
+		// the scan.
+		unconstrained bool
+		// forwardOnly, if set, indicates that the test case is not run with
+		// the reverse scan because the spec contains single key spans.
+		forwardOnly bool
+		expected    []int64
+	}{
+		{
+			specSpans:     roachpb.Spans{desc.PrimaryIndexSpan(keys.SystemSQLCodec)},
+			unconstrained: true,
+			expected:      rowsInRange(1, numRows+1),
+		},
+		{
+			// The runtime spans are unsorted and overlapping, and they
+			// include a single key and a span extending beyond the table.
+			specSpans: roachpb.Spans{desc.PrimaryIndexSpan(keys.SystemSQLCodec)},
+			runtimeSpans: roachpb.Spans{
+				span(90, 200), span(15, 25), {Key: rowKey(50)}, span(10, 20),
+			},
+			expected: concat(rowsInRange(10, 25), []int64{50}, rowsInRange(90, numRows+1)),
+		},
+		{
+			// Multiple spec spans, one of which is a single key.
+			specSpans: roachpb.Spans{span(1, 20), {Key: rowKey(30)}, {Key: rowKey(35)}, span(40, 60)},
+			runtimeSpans: roachpb.Spans{
+				span(5, 10), span(15, 45), span(50, 51), span(55, 80),
+			},
+			expected: concat(
+				rowsInRange(5, 10), rowsInRange(15, 20), []int64{30, 35},
+				rowsInRange(40, 45), []int64{50}, rowsInRange(55, 60),
+			),
+			forwardOnly: true,
+		},
+		{
+			// No intersection with the spec spans.
+			specSpans:    roachpb.Spans{span(1, 20)},
+			runtimeSpans: roachpb.Spans{span(20, 30), {Key: rowKey(40)}},
+		},
+		{
+			// No runtime spans at all.
+			specSpans:    roachpb.Spans{desc.PrimaryIndexSpan(keys.SystemSQLCodec)},
+			runtimeSpans: roachpb.Spans{},
+		},
+	} {
+		for _, reverse := range []bool{false, true} {
+			if reverse && tc.forwardOnly {
+				continue
+			}
+			t.Run(fmt.Sprintf("%d/reverse=%t", i, reverse), func(t *testing.T) {
+				spec := execinfrapb.TableReaderSpec{
+					Table:         *desc.TableDesc(),
+					NeededColumns: []uint32{0},
+					Reverse:       reverse,
+				}
+				for _, sp := range tc.specSpans {
+					spec.Spans = append(spec.Spans, execinfrapb.TableReaderSpan{Span: sp})
+				}
+				scan, err := colfetcher.NewColBatchScan(
+					ctx, allocator, flowCtx, &evalCtx, &spec,
+					&execinfrapb.PostProcessSpec{}, 0, /* estimatedRowCount */
+				)
+				require.NoError(t, err)
+				defer scan.Release()
+				var providerCalled bool
+				scan.SetRuntimeSpansProvider(func() (roachpb.Spans, bool) {
+					providerCalled = true
+					return tc.runtimeSpans, !tc.unconstrained
+				})
+				scan.Init(ctx)
+				// The provider is only consulted when the first batch is
+				// requested.
+				require.False(t, providerCalled)
+
+				var actual []int64
+				for {
+					b := scan.Next()
+					if b.Length() == 0 {
+						break
+					}
+					actual = append(actual, b.ColVec(0).Int64()[:b.Length()]...)
+				}
+				require.True(t, providerCalled)
+				expected := append([]int64(nil), tc.expected...)
+				if reverse {
+					for l, r := 0, len(expected)-1; l < r; l, r = l+1, r-1 {
+						expected[l], expected[r] = expected[r], expected[l]
+					}
+				}
+				require.Equal(t, expected, actual)
+				// Only the rows within the runtime spans must have been read.
+				require.Equal(t, int64(len(expected)), scan.GetRowsRead())
+				require.NoError(t, scan.Close(ctx))
+			})
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colbuilder"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
@@ -206,6 +207,11 @@ func (f *vectorizedFlow) Setup(
 		f.countingSemaphore,
 		flowCtx.TypeResolverFactory.NewTypeResolver(flowCtx.EvalCtx.Txn),
 	)
+	// The batches retained by the pool are accounted for against the flow's
+	// memory monitor, and their total size is limited by the workmem limit.
+	f.creator.batchPool = colmem.NewBatchPool(
+		f.creator.newStreamingMemAccount(flowCtx), execinfra.GetWorkMemLimit(flowCtx),
+	)
 	if f.testingKnobs.onSetupFlow != nil {
 		f.testingKnobs.onSetupFlow(f.creator)
 	}
@@ -512,6 +518,9 @@ type vectorizedFlowCreator struct {
 
 	diskQueueCfg colcontainer.DiskQueueCfg
 	fdSemaphore  semaphore.Semaphore
+	// batchPool is the pool of batches shared by the buffering operators of
+	// the flow. It can be nil. It is closed during the flow cleanup.
+	batchPool *colmem.BatchPool
 
 	// numClosers and numClosed are used to assert during testing that the
 	// expected number of components are closed.
@@ -570,6 +579,8 @@ func newVectorizedFlowCreator(
 }
 
 func (s *vectorizedFlowCreator) cleanup(ctx context.Context) {
+	// The batch pool must be closed before its memory account.
+	s.batchPool.Close(ctx)
 	for _, acc := range s.accounts {
 		acc.Close(ctx)
 	}
@@ -805,6 +816,72 @@ func (s *vectorizedFlowCreator) setupRouter(
 	return nil
 }
 
+// pushProjectionIntoInputStreams checks whether the projection in the
+// post-processing of pspec can be pushed below the synchronizer into each of
+// the input streams. This is possible when the processor is a no-op that merges
+// multiple streams (which is how UNION ALL is planned) and its post-processing
+// consists only of a projection, so the synchronizer can concatenate already
+// projected batches. If the projection can be pushed, a copy of pspec without
+// the projection and with the input schema and ordering updated accordingly is
+// returned along with the projection that must be applied to each of the input
+// streams. Otherwise, pspec is returned unchanged and the projection is nil.
+func pushProjectionIntoInputStreams(
+	pspec *execinfrapb.ProcessorSpec,
+) (_ *execinfrapb.ProcessorSpec, streamProjection []uint32) {
+	post := &pspec.Post
+	if pspec.Core.Noop == nil || len(pspec.Input) != 1 || len(pspec.Input[0].Streams) < 2 {
+		return pspec, nil
+	}
+	if !post.Projection || len(post.RenderExprs) > 0 || post.Offset != 0 || post.Limit != 0 {
+		return pspec, nil
+	}
+	input := pspec.Input[0]
+	if len(post.OutputColumns) != len(pspec.ResultTypes) {
+		return pspec, nil
+	}
+	projectedTypes := make([]*types.T, len(post.OutputColumns))
+	for i, col := range post.OutputColumns {
+		if int(col) >= len(input.ColumnTypes) {
+			return pspec, nil
+		}
+		projectedTypes[i] = input.ColumnTypes[col]
+		// All input streams share the schema of the input sync spec, so they
+		// have the same schema after the projection as well, but that schema
+		// must also be exactly the output schema of the processor.
+		if !projectedTypes[i].Identical(pspec.ResultTypes[i]) {
+			return pspec, nil
+		}
+	}
+	var projectedOrdering execinfrapb.Ordering
+	if input.Type == execinfrapb.InputSyncSpec_ORDERED {
+		// The ordered synchronizer needs all of the ordering columns, so we
+		// can only push the projection if it doesn't remove any of them.
+		projectedOrdering.Columns = make([]execinfrapb.Ordering_Column, len(input.Ordering.Columns))
+		for i, orderingCol := range input.Ordering.Columns {
+			found := false
+			for j, col := range post.OutputColumns {
+				if col == orderingCol.ColIdx {
+					projectedOrdering.Columns[i] = execinfrapb.Ordering_Column{
+						ColIdx:    uint32(j),
+						Direction: orderingCol.Direction,
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return pspec, nil
+			}
+		}
+	}
+	newSpec := *pspec
+	newSpec.Input = []execinfrapb.InputSyncSpec{input}
+	newSpec.Input[0].ColumnTypes = projectedTypes
+	newSpec.Input[0].Ordering = projectedOrdering
+	newSpec.Post = execinfrapb.PostProcessSpec{}
+	return &newSpec, post.OutputColumns
+}
+
 // setupInput sets up one or more input operators (local or remote) and a
 // synchronizer to expose these separate streams as one exec.Operator which is
 // returned. If s.recordingStats is true, these inputs and synchronizer are
@@ -812,10 +889,16 @@ func (s *vectorizedFlowCreator) setupRouter(
 // exposed as of yet. Inboxes that are created are also returned as
 // []colexecop.MetadataSource so that any remote metadata can be read through
 // calling DrainMeta.
+// streamTypes are the types of the input streams which differ from
+// input.ColumnTypes only if streamProjection is non-nil, in which case the
+// projection is applied to each of the input streams before they are
+// synchronized.
 func (s *vectorizedFlowCreator) setupInput(
 	ctx context.Context,
 	flowCtx *execinfra.FlowCtx,
 	input execinfrapb.InputSyncSpec,
+	streamTypes []*types.T,
+	streamProjection []uint32,
 	opt flowinfra.FuseOpt,
 	factory coldata.ColumnFactory,
 ) (colexecargs.OpWithMetaInfo, error) {
@@ -823,8 +906,8 @@ func (s *vectorizedFlowCreator) setupInput(
 	// Before we can safely use types we received over the wire in the
 	// operators, we need to make sure they are hydrated. In row execution
 	// engine it is done during the processor initialization, but operators
-	// don't do that.
-	if err := s.typeResolver.HydrateTypeSlice(ctx, input.ColumnTypes); err != nil {
+	// don't do that. Note that the types of input are a subset of streamTypes.
+	if err := s.typeResolver.HydrateTypeSlice(ctx, streamTypes); err != nil {
 		return colexecargs.OpWithMetaInfo{}, err
 	}
 
@@ -853,7 +936,7 @@ func (s *vectorizedFlowCreator) setupInput(
 				}
 			}
 
-			inbox, err := s.remoteComponentCreator.newInbox(colmem.NewAllocator(ctx, s.newStreamingMemAccount(flowCtx), factory), input.ColumnTypes, inputStream.StreamID)
+			inbox, err := s.remoteComponentCreator.newInbox(colmem.NewAllocator(ctx, s.newStreamingMemAccount(flowCtx), factory), streamTypes, inputStream.StreamID)
 
 			if err != nil {
 				return colexecargs.OpWithMetaInfo{}, err
@@ -881,6 +964,10 @@ func (s *vectorizedFlowCreator) setupInput(
 		default:
 			return colexecargs.OpWithMetaInfo{}, errors.Errorf("unsupported input stream type %s", inputStream.Type)
 		}
+		if streamProjection != nil {
+			in := &inputStreamOps[len(inputStreamOps)-1]
+			in.Root = colexecbase.NewSimpleProjectOp(in.Root, streamTypes, streamProjection)
+		}
 	}
 	opWithMetaInfo := inputStreamOps[0]
 	if len(inputStreamOps) > 1 {
@@ -1056,9 +1143,16 @@ func (s *vectorizedFlowCreator) setupFlow(
 				return
 			}
 
+			// Note that the types of the input streams must be captured before
+			// the projection is pushed into them.
+			inputSpecs := pspec.Input
+			var streamProjection []uint32
+			pspec, streamProjection = pushProjectionIntoInputStreams(pspec)
 			var inputs []colexecargs.OpWithMetaInfo
 			for i := range pspec.Input {
-				input, localErr := s.setupInput(ctx, flowCtx, pspec.Input[i], opt, factory)
+				input, localErr := s.setupInput(
+					ctx, flowCtx, pspec.Input[i], inputSpecs[i].ColumnTypes, streamProjection, opt, factory,
+				)
 				if localErr != nil {
 					err = localErr
 					return
@@ -1082,6 +1176,7 @@ func (s *vectorizedFlowCreator) setupFlow(
 				FDSemaphore:          s.fdSemaphore,
 				ExprHelper:           s.exprHelper,
 				Factory:              factory,
+				BatchPool:            s.batchPool,
 			}
 			args.TestingKnobs.PlanInvariantsCheckers = util.CrdbTestBuild
 			var result *colexecargs.NewColOperatorResult
@@ -21,6 +21,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
 	"github.com/cockroachdb/cockroach/pkg/sql/colcontainer"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexec"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colbuilder"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexecargs"
+	"github.com/cockroachdb/cockroach/pkg/sql/colexec/colexectestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/colexecop"
 	"github.com/cockroachdb/cockroach/pkg/sql/colflow/colrpc"
 	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
@@ -346,3 +349,200 @@ func TestVectorizedFlowTempDirectory(t *testing.T) {
 		checkDirs(t, 0)
 	})
 }
+
+func TestPushProjectionIntoInputStreams(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	inputTypes := []*types.T{types.Int, types.Bytes, types.Float}
+	makeSpec := func(
+		numStreams int, ordering execinfrapb.Ordering, post execinfrapb.PostProcessSpec, resultTypes []*types.T,
+	) execinfrapb.ProcessorSpec {
+		input := execinfrapb.InputSyncSpec{Ordering: ordering, ColumnTypes: inputTypes}
+		if len(ordering.Columns) > 0 {
+			input.Type = execinfrapb.InputSyncSpec_ORDERED
+		}
+		for i := 0; i < numStreams; i++ {
+			input.Streams = append(input.Streams, execinfrapb.StreamEndpointSpec{
+				Type: execinfrapb.StreamEndpointSpec_LOCAL, StreamID: execinfrapb.StreamID(i),
+			})
+		}
+		return execinfrapb.ProcessorSpec{
+			Input:       []execinfrapb.InputSyncSpec{input},
+			Core:        execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+			Post:        post,
+			ResultTypes: resultTypes,
+		}
+	}
+	projection := execinfrapb.PostProcessSpec{Projection: true, OutputColumns: []uint32{2, 0}}
+	projectedTypes := []*types.T{types.Float, types.Int}
+	ordering := func(colIdx uint32) execinfrapb.Ordering {
+		return execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{
+			{ColIdx: colIdx, Direction: execinfrapb.Ordering_Column_DESC},
+		}}
+	}
+
+	for _, tc := range []struct {
+		desc             string
+		spec             execinfrapb.ProcessorSpec
+		expectedOrdering execinfrapb.Ordering
+		pushed           bool
+	}{
+		{
+			desc:   "unordered",
+			spec:   makeSpec(2 /* numStreams */, execinfrapb.Ordering{}, projection, projectedTypes),
+			pushed: true,
+		},
+		{
+			desc:             "ordered",
+			spec:             makeSpec(3 /* numStreams */, ordering(0), projection, projectedTypes),
+			expectedOrdering: ordering(1),
+			pushed:           true,
+		},
+		{
+			desc: "ordering column projected out",
+			spec: makeSpec(2 /* numStreams */, ordering(1), projection, projectedTypes),
+		},
+		{
+			desc: "single stream",
+			spec: makeSpec(1 /* numStreams */, execinfrapb.Ordering{}, projection, projectedTypes),
+		},
+		{
+			desc: "limit",
+			spec: makeSpec(2 /* numStreams */, execinfrapb.Ordering{}, execinfrapb.PostProcessSpec{
+				Projection: true, OutputColumns: []uint32{2, 0}, Limit: 1,
+			}, projectedTypes),
+		},
+		{
+			desc: "render",
+			spec: makeSpec(2 /* numStreams */, execinfrapb.Ordering{}, execinfrapb.PostProcessSpec{
+				RenderExprs: []execinfrapb.Expression{{Expr: "@3"}, {Expr: "@1"}},
+			}, projectedTypes),
+		},
+		{
+			desc: "incompatible schema",
+			spec: makeSpec(2 /* numStreams */, execinfrapb.Ordering{}, projection, []*types.T{types.Float, types.Float}),
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			origSpec := tc.spec
+			newSpec, streamProjection := pushProjectionIntoInputStreams(&tc.spec)
+			// The original spec must never be modified.
+			require.Equal(t, origSpec, tc.spec)
+			if !tc.pushed {
+				require.Nil(t, streamProjection)
+				require.Equal(t, &tc.spec, newSpec)
+				return
+			}
+			require.Equal(t, projection.OutputColumns, streamProjection)
+			require.Equal(t, execinfrapb.PostProcessSpec{}, newSpec.Post)
+			require.Equal(t, projectedTypes, newSpec.Input[0].ColumnTypes)
+			require.Equal(t, tc.expectedOrdering, newSpec.Input[0].Ordering)
+			require.Equal(t, tc.spec.Input[0].Streams, newSpec.Input[0].Streams)
+		})
+	}
+}
+
+// TestPushProjectionIntoInputStreamsResults verifies that pushing the
+// projection of a processor merging multiple input streams into those streams
+// doesn't change the output of the processor.
+func TestPushProjectionIntoInputStreamsResults(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	flowCtx := &execinfra.FlowCtx{
+		EvalCtx: &evalCtx,
+		Cfg:     &execinfra.ServerConfig{Settings: st},
+	}
+	tu := newTestUtils(ctx)
+	defer tu.cleanup(ctx)
+
+	typs := []*types.T{types.Int, types.Int, types.Int}
+	// Each of the streams is ordered by the first column.
+	streams := []colexectestutils.Tuples{
+		{{0, 10, 100}, {2, 12, 102}, {4, 14, 104}, {6, 16, 106}, {8, 18, 108}},
+		{{1, 11, 101}, {3, 13, 103}, {5, 15, 105}},
+		{{7, 17, 107}},
+	}
+	input := execinfrapb.InputSyncSpec{ColumnTypes: typs}
+	for i := range streams {
+		input.Streams = append(input.Streams, execinfrapb.StreamEndpointSpec{
+			Type: execinfrapb.StreamEndpointSpec_LOCAL, StreamID: execinfrapb.StreamID(i),
+		})
+	}
+	orderedInput := input
+	orderedInput.Type = execinfrapb.InputSyncSpec_ORDERED
+	orderedInput.Ordering = execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}}
+
+	for _, tc := range []struct {
+		desc  string
+		input execinfrapb.InputSyncSpec
+	}{
+		{desc: "unordered", input: input},
+		{desc: "ordered", input: orderedInput},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			spec := execinfrapb.ProcessorSpec{
+				Input: []execinfrapb.InputSyncSpec{tc.input},
+				Core:  execinfrapb.ProcessorCoreUnion{Noop: &execinfrapb.NoopCoreSpec{}},
+				Post: execinfrapb.PostProcessSpec{
+					Projection: true, OutputColumns: []uint32{2, 0, 2},
+				},
+				ResultTypes: []*types.T{types.Int, types.Int, types.Int},
+			}
+			run := func(pushProjection bool) [][]int64 {
+				var wg sync.WaitGroup
+				vfc := newVectorizedFlowCreator(
+					&vectorizedFlowCreatorHelper{f: &flowinfra.FlowBase{}}, nil /* componentCreator */, false, false,
+					&wg, &execinfra.RowChannel{}, nil /* nodeDialer */, execinfrapb.FlowID{},
+					colcontainer.DiskQueueCfg{}, nil /* fdSemaphore */, descs.DistSQLTypeResolver{},
+				)
+				defer vfc.cleanup(ctx)
+				for i := range streams {
+					vfc.streamIDToInputOp[execinfrapb.StreamID(i)] = colexecargs.OpWithMetaInfo{
+						Root: colexectestutils.NewOpTestInput(tu.testAllocator, 2 /* batchSize */, streams[i], typs),
+					}
+				}
+				newSpec, streamProjection := &spec, []uint32(nil)
+				if pushProjection {
+					newSpec, streamProjection = pushProjectionIntoInputStreams(&spec)
+					require.NotNil(t, streamProjection)
+				}
+				in, err := vfc.setupInput(
+					ctx, flowCtx, newSpec.Input[0], typs, streamProjection,
+					flowinfra.FuseAggressively, tu.testColumnFactory,
+				)
+				require.NoError(t, err)
+				r, err := colbuilder.NewColOperator(ctx, flowCtx, &colexecargs.NewColOperatorArgs{
+					Spec:                newSpec,
+					Inputs:              []colexecargs.OpWithMetaInfo{in},
+					StreamingMemAccount: tu.testMemAcc,
+				})
+				require.NoError(t, err)
+				defer r.Release()
+				var rows [][]int64
+				r.Root.Init(ctx)
+				for b := r.Root.Next(); b.Length() > 0; b = r.Root.Next() {
+					sel := b.Selection()
+					for i := 0; i < b.Length(); i++ {
+						rowIdx := i
+						if sel != nil {
+							rowIdx = sel[i]
+						}
+						row := make([]int64, b.Width())
+						for j := range row {
+							row[j] = b.ColVec(j).Int64()[rowIdx]
+						}
+						rows = append(rows, row)
+					}
+				}
+				return rows
+			}
+			expected := run(false /* pushProjection */)
+			require.Len(t, expected, 9)
+			require.Equal(t, expected, run(true /* pushProjection */))
+		})
+	}
+}
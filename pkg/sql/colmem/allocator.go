@@ -34,11 +34,13 @@ import (
 // new batches (and appends to existing ones) within a fixed memory budget. If
 // the budget is exceeded, it will panic with an error.
 //
-// In the future this can also be used to pool coldata.Vec allocations.
+// The Allocator can optionally be backed by a BatchPool in which case the
+// batches are drawn from (and returned to) the pool whenever possible.
 type Allocator struct {
 	ctx     context.Context
 	acc     *mon.BoundAccount
 	factory coldata.ColumnFactory
+	pool    *BatchPool
 }
 
 func selVectorSize(capacity int) int64 {
@@ -121,11 +123,33 @@ func NewAllocator(
 	}
 }
 
+// NewAllocatorWithBatchPool constructs a new Allocator instance that draws the
+// batches from the given pool and returns the batches that are no longer
+// needed to it.
+// NOTE: the Allocator returns the old batch to the pool in
+// ResetMaybeReallocate, so it should only be used by the components that don't
+// keep any references to the old batch after the reallocation.
+func NewAllocatorWithBatchPool(
+	ctx context.Context, acc *mon.BoundAccount, factory coldata.ColumnFactory, pool *BatchPool,
+) *Allocator {
+	a := NewAllocator(ctx, acc, factory)
+	a.pool = pool
+	return a
+}
+
 // NewMemBatchWithFixedCapacity allocates a new in-memory coldata.Batch with the
 // given vector capacity.
 // Note: consider whether you want the dynamic batch size behavior (in which
 // case you should be using ResetMaybeReallocate).
 func (a *Allocator) NewMemBatchWithFixedCapacity(typs []*types.T, capacity int) coldata.Batch {
+	if b := a.pool.get(a.ctx, typs, capacity); b != nil {
+		if err := a.acc.Grow(a.ctx, GetBatchMemSize(b)); err != nil {
+			// Return the batch to the pool since we cannot use it.
+			a.pool.put(a.ctx, b)
+			colexecerror.InternalError(err)
+		}
+		return b
+	}
 	estimatedMemoryUsage := selVectorSize(capacity) + int64(EstimateBatchSizeBytes(typs, capacity))
 	if err := a.acc.Grow(a.ctx, estimatedMemoryUsage); err != nil {
 		colexecerror.InternalError(err)
@@ -157,8 +181,8 @@ func (a *Allocator) NewMemBatchNoCols(typs []*types.T, capacity int) coldata.Bat
 // exponentially (possibly incurring a reallocation), until the batch reaches
 // coldata.BatchSize() in capacity or maxBatchMemSize in the memory footprint.
 // NOTE: if the reallocation occurs, then the memory under the old batch is
-// released, so it is expected that the caller will lose the references to the
-// old batch.
+// released (and the old batch might be returned to the BatchPool), so it is
+// expected that the caller will lose the references to the old batch.
 // Note: the method assumes that minCapacity is at least 0 and will clamp
 // minCapacity to be between 1 and coldata.BatchSize() inclusive.
 // TODO(yuzefovich): change the contract so that maxBatchMemSize takes priority
@@ -194,6 +218,12 @@ func (a *Allocator) ResetMaybeReallocate(
 			newBatch = oldBatch
 		} else {
 			a.ReleaseMemory(oldBatchMemSize)
+			if oldBatch.Width() == len(typs) {
+				// Only return the old batch to the pool if it hasn't been
+				// modified by the other components (like the vector type
+				// enforcers).
+				a.pool.put(a.ctx, oldBatch)
+			}
 			newCapacity := oldBatch.Capacity() * 2
 			if newCapacity < minCapacity {
 				newCapacity = minCapacity
@@ -207,6 +237,17 @@ func (a *Allocator) ResetMaybeReallocate(
 	return newBatch, reallocated
 }
 
+// ReleaseBatch releases the memory of the batch and returns it to the
+// BatchPool (if the Allocator has one). The caller must lose all references to
+// the batch.
+func (a *Allocator) ReleaseBatch(b coldata.Batch) {
+	if b == nil || b == coldata.ZeroBatch {
+		return
+	}
+	a.ReleaseMemory(GetBatchMemSize(b))
+	a.pool.put(a.ctx, b)
+}
+
 // NewMemColumn returns a new coldata.Vec of the desired capacity.
 // NOTE: consider whether you should be using MaybeAppendColumn,
 // NewMemBatchWith*, or ResetMaybeReallocate methods.
@@ -0,0 +1,168 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colmem
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/typeconv"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// BatchPool is a pool of coldata.Batches that is shared between the
+// Allocators of a single flow in order to reduce the number of allocations
+// (and, thus, the GC pressure) performed by the operators that frequently
+// reallocate their output batches. The pool is closed when the flow is cleaned
+// up.
+//
+// The memory of the batches retained by the pool is registered with the pool's
+// own memory account, and the total size of the retained batches is limited by
+// maxSize. Once a batch is handed out by the pool, its memory is registered
+// with the memory account of the Allocator that requested the batch.
+//
+// A nil *BatchPool is valid and represents a pool that never retains any
+// batches.
+type BatchPool struct {
+	maxSize int64
+	mu      struct {
+		syncutil.Mutex
+		acc *mon.BoundAccount
+		// batches contains the retained batches keyed by their schema and
+		// capacity.
+		batches map[batchPoolKey][]coldata.Batch
+		// size is the total memory footprint of the retained batches.
+		size int64
+	}
+}
+
+// batchPoolKey identifies the batches that can be used interchangeably.
+type batchPoolKey struct {
+	// schema is the comma-separated list of the SQL strings of the types of
+	// the vectors.
+	schema   string
+	capacity int
+}
+
+func makeBatchPoolKey(typs []*types.T, capacity int) batchPoolKey {
+	var sb strings.Builder
+	for i, typ := range typs {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(typ.SQLString())
+	}
+	return batchPoolKey{schema: sb.String(), capacity: capacity}
+}
+
+// NewBatchPool creates a new BatchPool that uses acc to account for the memory
+// of the retained batches, the total size of which will not exceed maxSize.
+func NewBatchPool(acc *mon.BoundAccount, maxSize int64) *BatchPool {
+	p := &BatchPool{maxSize: maxSize}
+	p.mu.acc = acc
+	p.mu.batches = make(map[batchPoolKey][]coldata.Batch)
+	return p
+}
+
+// get returns a batch from the pool that has the given schema and capacity if
+// such is present, nil is returned otherwise. The memory footprint of the
+// returned batch is released from the pool's account.
+func (p *BatchPool) get(ctx context.Context, typs []*types.T, capacity int) coldata.Batch {
+	if p == nil {
+		return nil
+	}
+	key := makeBatchPoolKey(typs, capacity)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	batches := p.mu.batches[key]
+	// The SQL strings of the types that aren't identical can be the same, so
+	// we still need to check the schema of the batch, but normally the last
+	// batch in the list is returned right away.
+	for i := len(batches) - 1; i >= 0; i-- {
+		b := batches[i]
+		if !batchHasSchema(b, typs) {
+			continue
+		}
+		lastIdx := len(batches) - 1
+		batches[i] = batches[lastIdx]
+		batches[lastIdx] = nil
+		p.mu.batches[key] = batches[:lastIdx]
+		memSize := GetBatchMemSize(b)
+		p.mu.size -= memSize
+		p.mu.acc.Shrink(ctx, memSize)
+		return b
+	}
+	return nil
+}
+
+// put adds the batch to the pool if the pool has enough space for it. It
+// returns whether the batch has been retained, and if it has, then the caller
+// must lose all references to the batch.
+func (p *BatchPool) put(ctx context.Context, b coldata.Batch) bool {
+	if p == nil {
+		return false
+	}
+	if _, ok := b.(*coldata.MemBatch); !ok || b.Capacity() == 0 {
+		return false
+	}
+	typs := make([]*types.T, b.Width())
+	for i, vec := range b.ColVecs() {
+		typs[i] = vec.Type()
+		// Datum-backed vectors hold a reference to the eval context of the
+		// component that created them, so they are never pooled.
+		if vec.CanonicalTypeFamily() == typeconv.DatumVecCanonicalTypeFamily {
+			return false
+		}
+	}
+	b.ResetInternalBatch()
+	memSize := GetBatchMemSize(b)
+	key := makeBatchPoolKey(typs, b.Capacity())
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mu.size+memSize > p.maxSize {
+		return false
+	}
+	if err := p.mu.acc.Grow(ctx, memSize); err != nil {
+		return false
+	}
+	p.mu.size += memSize
+	p.mu.batches[key] = append(p.mu.batches[key], b)
+	return true
+}
+
+// Close releases all batches retained by the pool.
+func (p *BatchPool) Close(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mu.batches = make(map[batchPoolKey][]coldata.Batch)
+	p.mu.size = 0
+	p.mu.acc.Clear(ctx)
+}
+
+// batchHasSchema returns whether the vectors of b are of exactly the given
+// types.
+func batchHasSchema(b coldata.Batch, typs []*types.T) bool {
+	if b.Width() != len(typs) {
+		return false
+	}
+	for i, vec := range b.ColVecs() {
+		if !vec.Type().Identical(typs[i]) {
+			return false
+		}
+	}
+	return true
+}
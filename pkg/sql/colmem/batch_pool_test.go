@@ -0,0 +1,212 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package colmem_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/col/coldata"
+	"github.com/cockroachdb/cockroach/pkg/col/coldataext"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/colmem"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	defer testMemMonitor.Stop(ctx)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	testColumnFactory := coldataext.NewExtendedColumnFactory(&evalCtx)
+	typs := []*types.T{types.Int, types.Bytes}
+
+	newPool := func(maxSize int64) (*colmem.BatchPool, *colmem.Allocator, func()) {
+		poolAcc := testMemMonitor.MakeBoundAccount()
+		memAcc := testMemMonitor.MakeBoundAccount()
+		pool := colmem.NewBatchPool(&poolAcc, maxSize)
+		allocator := colmem.NewAllocatorWithBatchPool(ctx, &memAcc, testColumnFactory, pool)
+		return pool, allocator, func() {
+			pool.Close(ctx)
+			require.Zero(t, poolAcc.Used())
+			poolAcc.Close(ctx)
+			memAcc.Close(ctx)
+		}
+	}
+
+	t.Run("Reuse", func(t *testing.T) {
+		_, allocator, cleanup := newPool(math.MaxInt64)
+		defer cleanup()
+		b := allocator.NewMemBatchWithFixedCapacity(typs, 4 /* capacity */)
+		b.ColVec(0).Int64()[0] = 1
+		b.ColVec(0).Nulls().SetNull(1)
+		b.ColVec(1).Bytes().Set(0, []byte("foo"))
+		b.SetLength(2)
+		b.SetSelection(true)
+		before := allocator.Used()
+		allocator.ReleaseBatch(b)
+		require.Zero(t, allocator.Used())
+
+		// A batch of a different capacity or of a different schema is not
+		// served from the pool.
+		other := allocator.NewMemBatchWithFixedCapacity(typs, 8 /* capacity */)
+		require.True(t, other != b)
+		other = allocator.NewMemBatchWithFixedCapacity([]*types.T{types.Int, types.String}, 4 /* capacity */)
+		require.True(t, other != b)
+		other = allocator.NewMemBatchWithFixedCapacity(typs[:1], 4 /* capacity */)
+		require.True(t, other != b)
+
+		used := allocator.Used()
+		reused := allocator.NewMemBatchWithFixedCapacity(typs, 4 /* capacity */)
+		require.True(t, reused == b)
+		// The batch's memory is registered with the allocator again.
+		require.Equal(t, before, allocator.Used()-used)
+		// The reused batch must be in the "reset" state.
+		require.Zero(t, reused.Length())
+		require.Nil(t, reused.Selection())
+		require.False(t, reused.ColVec(0).MaybeHasNulls())
+		reused.ColVec(1).Bytes().Set(0, []byte("bar"))
+	})
+
+	t.Run("ResetMaybeReallocate", func(t *testing.T) {
+		_, allocator, cleanup := newPool(math.MaxInt64)
+		defer cleanup()
+		b, _ := allocator.ResetMaybeReallocate(typs, nil /* oldBatch */, 1 /* minCapacity */, math.MaxInt64)
+		// The old batch is returned to the pool on reallocation.
+		newBatch, reallocated := allocator.ResetMaybeReallocate(typs, b, 1 /* minCapacity */, math.MaxInt64)
+		require.True(t, reallocated)
+		require.True(t, newBatch != b)
+		require.True(t, allocator.NewMemBatchWithFixedCapacity(typs, b.Capacity()) == b)
+		// The old batch that had columns appended to it is not returned to the
+		// pool.
+		b = allocator.NewMemBatchWithFixedCapacity(typs, 1 /* capacity */)
+		b.SetLength(1)
+		allocator.MaybeAppendColumn(b, types.Int, len(typs))
+		_, reallocated = allocator.ResetMaybeReallocate(typs, b, 1 /* minCapacity */, math.MaxInt64)
+		require.True(t, reallocated)
+		require.True(t, allocator.NewMemBatchWithFixedCapacity([]*types.T{types.Int, types.Bytes, types.Int}, 1 /* capacity */) != b)
+	})
+
+	t.Run("ManySchemas", func(t *testing.T) {
+		_, allocator, cleanup := newPool(math.MaxInt64)
+		defer cleanup()
+		// The batches of the same capacity but of different schemas are
+		// retained together, and each is handed out only for its own schema.
+		schemas := [][]*types.T{
+			{types.Int}, {types.Int2}, {types.Bytes}, {types.String}, {types.MakeVarChar(10)},
+			{types.Int, types.Bytes}, {types.Bytes, types.Int},
+		}
+		batches := make([]coldata.Batch, len(schemas))
+		for i, schema := range schemas {
+			batches[i] = allocator.NewMemBatchWithFixedCapacity(schema, 2 /* capacity */)
+		}
+		for _, b := range batches {
+			allocator.ReleaseBatch(b)
+		}
+		for i := len(schemas) - 1; i >= 0; i-- {
+			require.True(t, allocator.NewMemBatchWithFixedCapacity(schemas[i], 2 /* capacity */) == batches[i])
+		}
+	})
+
+	t.Run("DatumBacked", func(t *testing.T) {
+		_, allocator, cleanup := newPool(math.MaxInt64)
+		defer cleanup()
+		datumTyps := []*types.T{types.Interval, types.Jsonb, types.Int4, types.MakeArray(types.Int)}
+		b := allocator.NewMemBatchWithFixedCapacity(datumTyps, 2 /* capacity */)
+		allocator.ReleaseBatch(b)
+		require.True(t, allocator.NewMemBatchWithFixedCapacity(datumTyps, 2 /* capacity */) != b)
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		_, allocator, cleanup := newPool(1 /* maxSize */)
+		defer cleanup()
+		b := allocator.NewMemBatchWithFixedCapacity(typs, 2 /* capacity */)
+		allocator.ReleaseBatch(b)
+		require.True(t, allocator.NewMemBatchWithFixedCapacity(typs, 2 /* capacity */) != b)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		memAcc := testMemMonitor.MakeBoundAccount()
+		defer memAcc.Close(ctx)
+		allocator := colmem.NewAllocatorWithBatchPool(ctx, &memAcc, testColumnFactory, nil /* pool */)
+		b := allocator.NewMemBatchWithFixedCapacity(typs, 2 /* capacity */)
+		allocator.ReleaseBatch(b)
+		require.Zero(t, allocator.Used())
+		require.True(t, allocator.NewMemBatchWithFixedCapacity(typs, 2 /* capacity */) != b)
+	})
+}
+
+func BenchmarkBatchPool(b *testing.B) {
+	defer log.Scope(b).Close(b)
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	testMemMonitor := execinfra.NewTestMemMonitor(ctx, st)
+	defer testMemMonitor.Stop(ctx)
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+	testColumnFactory := coldataext.NewExtendedColumnFactory(&evalCtx)
+	typs := []*types.T{types.Int, types.Bytes, types.Decimal}
+	const numConcurrentOperators = 8
+
+	for _, usePool := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pool=%t", usePool), func(b *testing.B) {
+			poolAcc := testMemMonitor.MakeBoundAccount()
+			defer poolAcc.Close(ctx)
+			var pool *colmem.BatchPool
+			if usePool {
+				pool = colmem.NewBatchPool(&poolAcc, math.MaxInt64)
+				defer pool.Close(ctx)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			wg.Add(numConcurrentOperators)
+			for op := 0; op < numConcurrentOperators; op++ {
+				go func() {
+					defer wg.Done()
+					memAcc := testMemMonitor.MakeBoundAccount()
+					defer memAcc.Close(ctx)
+					allocator := colmem.NewAllocatorWithBatchPool(ctx, &memAcc, testColumnFactory, pool)
+					// Simulate an operator that grows its output batch
+					// dynamically up to coldata.BatchSize() in capacity
+					// and then releases it when it is done.
+					for i := 0; i < b.N/numConcurrentOperators+1; i++ {
+						var batch coldata.Batch
+						for {
+							var reallocated bool
+							batch, reallocated = allocator.ResetMaybeReallocate(
+								typs, batch, 1 /* minCapacity */, math.MaxInt64,
+							)
+							if !reallocated {
+								break
+							}
+						}
+						allocator.ReleaseBatch(batch)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
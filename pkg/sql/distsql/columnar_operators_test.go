@@ -38,51 +38,55 @@ const nullProbability = 0.2
 const randTypesProbability = 0.5
 
 var aggregateFuncToNumArguments = map[execinfrapb.AggregatorSpec_Func]int{
-	execinfrapb.AnyNotNull:         1,
-	execinfrapb.Avg:                1,
-	execinfrapb.BoolAnd:            1,
-	execinfrapb.BoolOr:             1,
-	execinfrapb.ConcatAgg:          1,
-	execinfrapb.Count:              1,
-	execinfrapb.Max:                1,
-	execinfrapb.Min:                1,
-	execinfrapb.Stddev:             1,
-	execinfrapb.Sum:                1,
-	execinfrapb.SumInt:             1,
-	execinfrapb.Variance:           1,
-	execinfrapb.XorAgg:             1,
-	execinfrapb.CountRows:          0,
-	execinfrapb.Sqrdiff:            1,
-	execinfrapb.FinalVariance:      3,
-	execinfrapb.FinalStddev:        3,
-	execinfrapb.ArrayAgg:           1,
-	execinfrapb.JSONAgg:            1,
-	execinfrapb.JSONBAgg:           1,
-	execinfrapb.StringAgg:          2,
-	execinfrapb.BitAnd:             1,
-	execinfrapb.BitOr:              1,
-	execinfrapb.Corr:               2,
-	execinfrapb.PercentileDiscImpl: 2,
-	execinfrapb.PercentileContImpl: 2,
-	execinfrapb.JSONObjectAgg:      2,
-	execinfrapb.JSONBObjectAgg:     2,
-	execinfrapb.VarPop:             1,
-	execinfrapb.StddevPop:          1,
-	execinfrapb.StMakeline:         1,
-	execinfrapb.StExtent:           1,
-	execinfrapb.StUnion:            1,
-	execinfrapb.StCollect:          1,
-	execinfrapb.CovarPop:           2,
-	execinfrapb.CovarSamp:          2,
-	execinfrapb.RegrIntercept:      2,
-	execinfrapb.RegrR2:             2,
-	execinfrapb.RegrSlope:          2,
-	execinfrapb.RegrSxx:            2,
-	execinfrapb.RegrSxy:            2,
-	execinfrapb.RegrSyy:            2,
-	execinfrapb.RegrCount:          2,
-	execinfrapb.RegrAvgx:           2,
-	execinfrapb.RegrAvgy:           2,
+	execinfrapb.AnyNotNull:          1,
+	execinfrapb.Avg:                 1,
+	execinfrapb.BoolAnd:             1,
+	execinfrapb.BoolOr:              1,
+	execinfrapb.ConcatAgg:           1,
+	execinfrapb.Count:               1,
+	execinfrapb.Max:                 1,
+	execinfrapb.Min:                 1,
+	execinfrapb.Stddev:              1,
+	execinfrapb.Sum:                 1,
+	execinfrapb.SumInt:              1,
+	execinfrapb.Variance:            1,
+	execinfrapb.XorAgg:              1,
+	execinfrapb.CountRows:           0,
+	execinfrapb.Sqrdiff:             1,
+	execinfrapb.FinalVariance:       3,
+	execinfrapb.FinalStddev:         3,
+	execinfrapb.ArrayAgg:            1,
+	execinfrapb.JSONAgg:             1,
+	execinfrapb.JSONBAgg:            1,
+	execinfrapb.StringAgg:           2,
+	execinfrapb.BitAnd:              1,
+	execinfrapb.BitOr:               1,
+	execinfrapb.Corr:                2,
+	execinfrapb.PercentileDiscImpl:  2,
+	execinfrapb.PercentileContImpl:  2,
+	execinfrapb.JSONObjectAgg:       2,
+	execinfrapb.JSONBObjectAgg:      2,
+	execinfrapb.VarPop:              1,
+	execinfrapb.StddevPop:           1,
+	execinfrapb.StMakeline:          1,
+	execinfrapb.StExtent:            1,
+	execinfrapb.StUnion:             1,
+	execinfrapb.StCollect:           1,
+	execinfrapb.CovarPop:            2,
+	execinfrapb.CovarSamp:           2,
+	execinfrapb.RegrIntercept:       2,
+	execinfrapb.RegrR2:              2,
+	execinfrapb.RegrSlope:           2,
+	execinfrapb.RegrSxx:             2,
+	execinfrapb.RegrSxy:             2,
+	execinfrapb.RegrSyy:             2,
+	execinfrapb.RegrCount:           2,
+	execinfrapb.RegrAvgx:            2,
+	execinfrapb.RegrAvgy:            2,
+	execinfrapb.ApproxCountDistinct: 1,
+	execinfrapb.BitXor:              1,
+	execinfrapb.ApproxPercentile:    2,
+	execinfrapb.ModeImpl:            1,
 }
 
 // TestAggregateFuncToNumArguments ensures that all aggregate functions are
@@ -139,6 +143,13 @@ func TestAggregatorAgainstProcessor(t *testing.T) {
 				execinfrapb.PercentileContImpl:
 				// We skip percentile functions because those can only be
 				// planned as window functions.
+			case execinfrapb.ModeImpl:
+				// We skip MODE because it can only be planned as a window
+				// function too, and it breaks the ties based on the order of
+				// the input which isn't deterministic here.
+			case execinfrapb.ApproxPercentile:
+				// We skip APPROX_PERCENTILE because its fraction argument
+				// must be in [0, 1] which random inputs rarely satisfy.
 			default:
 				found = true
 			}
@@ -336,6 +347,282 @@ func TestAggregatorAgainstProcessor(t *testing.T) {
 	}
 }
 
+// TestFinalAvgAgainstProcessor verifies that the vectorized engine, which folds
+// the final stage of the distributed AVG (SUM and SUM_INT aggregations followed
+// by the division rendering) into a single aggregate function, produces the
+// same results as the row-by-row engine.
+func TestFinalAvgAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(context.Background())
+
+	rng, seed := randutil.NewPseudoRand()
+	nRuns := 10
+	nRows := 100
+	const (
+		nextGroupProb = 0.2
+		maxCount      = 5
+	)
+	var da rowenc.DatumAlloc
+	orderedCols := execinfrapb.ConvertToColumnOrdering(
+		execinfrapb.Ordering{Columns: []execinfrapb.Ordering_Column{{ColIdx: 0}}},
+	)
+	for _, sumType := range []*types.T{types.Decimal, types.Float, types.Interval} {
+		inputTypes := []*types.T{types.Int, sumType, types.Int}
+		outputTypes := []*types.T{types.Int, sumType}
+		renderExpr := "@2 / @3"
+		if sumType.Family() == types.FloatFamily {
+			renderExpr = "@2 / @3::FLOAT8"
+		}
+		for _, spillForced := range []bool{false, true} {
+			for _, hashAgg := range []bool{false, true} {
+				if !hashAgg && spillForced {
+					// There is no point in making the ordered aggregation spill to
+					// disk.
+					continue
+				}
+				for run := 0; run < nRuns; run++ {
+					rows := make(rowenc.EncDatumRows, nRows)
+					groupIdx := 0
+					for i := range rows {
+						// The counts are mostly positive, but we also include
+						// zeroes and NULLs in order to exercise the error and the
+						// NULL propagation paths.
+						count := rowenc.EncDatum{Datum: tree.DNull}
+						if rng.Float64() >= nullProbability {
+							count = rowenc.EncDatum{Datum: tree.NewDInt(tree.DInt(rng.Intn(maxCount)))}
+						}
+						rows[i] = rowenc.EncDatumRow{
+							{Datum: tree.NewDInt(tree.DInt(groupIdx))},
+							{Datum: randgen.RandDatum(rng, sumType, true /* nullOk */)},
+							count,
+						}
+						if rng.Float64() < nextGroupProb {
+							groupIdx++
+						}
+					}
+					aggregatorSpec := &execinfrapb.AggregatorSpec{
+						Type:      execinfrapb.AggregatorSpec_NON_SCALAR,
+						GroupCols: []uint32{0},
+						Aggregations: []execinfrapb.AggregatorSpec_Aggregation{
+							{Func: execinfrapb.AnyNotNull, ColIdx: []uint32{0}},
+							{Func: execinfrapb.Sum, ColIdx: []uint32{1}},
+							{Func: execinfrapb.SumInt, ColIdx: []uint32{2}},
+						},
+					}
+					if hashAgg {
+						rand.Shuffle(nRows, func(i, j int) {
+							rows[i], rows[j] = rows[j], rows[i]
+						})
+					} else {
+						aggregatorSpec.OrderedGroupCols = []uint32{0}
+						sort.Slice(rows, func(i, j int) bool {
+							cmp, err := rows[i].Compare(inputTypes, &da, orderedCols, &evalCtx, rows[j])
+							if err != nil {
+								t.Fatal(err)
+							}
+							return cmp < 0
+						})
+					}
+					pspec := &execinfrapb.ProcessorSpec{
+						Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+						Core:  execinfrapb.ProcessorCoreUnion{Aggregator: aggregatorSpec},
+						Post: execinfrapb.PostProcessSpec{
+							RenderExprs: []execinfrapb.Expression{{Expr: "@1"}, {Expr: renderExpr}},
+						},
+						ResultTypes: outputTypes,
+					}
+					args := verifyColOperatorArgs{
+						anyOrder:       hashAgg,
+						inputTypes:     [][]*types.T{inputTypes},
+						inputs:         []rowenc.EncDatumRows{rows},
+						pspec:          pspec,
+						forceDiskSpill: spillForced,
+					}
+					if err := verifyColOperator(t, args); err != nil {
+						fmt.Printf("--- seed = %d run = %d type = %s hash = %t ---\n",
+							seed, run, sumType, hashAgg)
+						prettyPrintTypes(inputTypes, "t" /* tableName */)
+						prettyPrintInput(rows, inputTypes, "t" /* tableName */)
+						t.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestGroupByWithoutAggregatesAgainstProcessor verifies that the aggregations
+// that only output the grouping columns, which are planned as the distinct by
+// the vectorized engine, produce the same output as both the row-by-row
+// aggregator and the row-by-row distinct.
+func TestGroupByWithoutAggregatesAgainstProcessor(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	var da rowenc.DatumAlloc
+	evalCtx := tree.MakeTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+
+	rng, seed := randutil.NewPseudoRand()
+	nRuns := 10
+	nRows := 20
+	maxCols := 3
+	maxNum := 3
+	intTyps := make([]*types.T, maxCols)
+	for i := range intTyps {
+		intTyps[i] = types.Int
+	}
+
+	for _, spillForced := range []bool{false, true} {
+		for run := 0; run < nRuns; run++ {
+			for nCols := 1; nCols <= maxCols; nCols++ {
+				for nGroupCols := 1; nGroupCols <= nCols; nGroupCols++ {
+					for nOrderedCols := 0; nOrderedCols <= nGroupCols; nOrderedCols++ {
+						if spillForced && nOrderedCols == nGroupCols {
+							// The ordered distinct doesn't spill to disk.
+							continue
+						}
+						var (
+							rows       rowenc.EncDatumRows
+							inputTypes []*types.T
+						)
+						if rng.Float64() < randTypesProbability {
+							inputTypes = generateRandomSupportedTypesWithUnencodable(rng, nCols, !spillForced)
+							rows = randgen.RandEncDatumRowsOfTypes(rng, nRows, inputTypes)
+						} else {
+							inputTypes = intTyps[:nCols]
+							rows = randgen.MakeRandIntRowsInRange(rng, nRows, nCols, maxNum, nullProbability)
+						}
+						groupCols := make([]uint32, nGroupCols)
+						for i, groupCol := range rng.Perm(nCols)[:nGroupCols] {
+							groupCols[i] = uint32(groupCol)
+						}
+						orderedCols := groupCols[:nOrderedCols]
+						// If we're spilling, we sometimes require the output
+						// ordering on all grouping columns in order to exercise
+						// the sort after the hash-based partitioner.
+						requireOutputOrdering := spillForced && rng.Float64() < 0.5
+						ordCols := make([]execinfrapb.Ordering_Column, nOrderedCols)
+						for i, col := range orderedCols {
+							ordCols[i] = execinfrapb.Ordering_Column{ColIdx: col}
+						}
+						if requireOutputOrdering {
+							for _, col := range groupCols[nOrderedCols:] {
+								ordCols = append(ordCols, execinfrapb.Ordering_Column{ColIdx: col})
+							}
+						}
+						sort.Slice(rows, func(i, j int) bool {
+							cmp, err := rows[i].Compare(
+								inputTypes, &da,
+								execinfrapb.ConvertToColumnOrdering(execinfrapb.Ordering{Columns: ordCols}),
+								&evalCtx, rows[j],
+							)
+							if err != nil {
+								t.Fatal(err)
+							}
+							return cmp < 0
+						})
+						// The aggregations output the grouping columns in a
+						// random order, possibly with some of them omitted or
+						// repeated (unless the output ordering is required, in
+						// which case each grouping column is output once).
+						nAggregations := 1 + rng.Intn(nGroupCols+1)
+						if requireOutputOrdering {
+							nAggregations = nGroupCols
+						}
+						aggregations := make([]execinfrapb.AggregatorSpec_Aggregation, nAggregations)
+						outputCols := make([]uint32, nAggregations)
+						outputTypes := make([]*types.T, nAggregations)
+						outputIdxs := rng.Perm(nGroupCols)
+						for i := range aggregations {
+							col := groupCols[rng.Intn(nGroupCols)]
+							if requireOutputOrdering {
+								col = groupCols[outputIdxs[i]]
+							}
+							aggregations[i] = execinfrapb.AggregatorSpec_Aggregation{
+								Func: execinfrapb.AnyNotNull, ColIdx: []uint32{col},
+							}
+							outputCols[i] = col
+							outputTypes[i] = inputTypes[col]
+						}
+						var aggOutputOrdering, distinctOutputOrdering execinfrapb.Ordering
+						if requireOutputOrdering {
+							distinctOutputOrdering.Columns = ordCols
+							for _, ordCol := range ordCols {
+								for outputIdx, col := range outputCols {
+									if col == ordCol.ColIdx {
+										aggOutputOrdering.Columns = append(
+											aggOutputOrdering.Columns,
+											execinfrapb.Ordering_Column{ColIdx: uint32(outputIdx)},
+										)
+									}
+								}
+							}
+						}
+						// The output order is deterministic only if the input
+						// is ordered on all grouping columns. The row-by-row
+						// distinct also respects the required output ordering,
+						// but the row-by-row hash aggregator doesn't.
+						for _, tc := range []struct {
+							pspec    *execinfrapb.ProcessorSpec
+							anyOrder bool
+						}{
+							{
+								anyOrder: nOrderedCols < nGroupCols,
+								pspec: &execinfrapb.ProcessorSpec{
+									Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+									Core: execinfrapb.ProcessorCoreUnion{Aggregator: &execinfrapb.AggregatorSpec{
+										Type:             execinfrapb.AggregatorSpec_NON_SCALAR,
+										GroupCols:        groupCols,
+										OrderedGroupCols: orderedCols,
+										Aggregations:     aggregations,
+										OutputOrdering:   aggOutputOrdering,
+									}},
+									ResultTypes: outputTypes,
+								},
+							},
+							{
+								anyOrder: nOrderedCols < nGroupCols && !requireOutputOrdering,
+								pspec: &execinfrapb.ProcessorSpec{
+									Input: []execinfrapb.InputSyncSpec{{ColumnTypes: inputTypes}},
+									Core: execinfrapb.ProcessorCoreUnion{Distinct: &execinfrapb.DistinctSpec{
+										DistinctColumns: groupCols,
+										OrderedColumns:  orderedCols,
+										OutputOrdering:  distinctOutputOrdering,
+									}},
+									Post: execinfrapb.PostProcessSpec{
+										Projection:    true,
+										OutputColumns: outputCols,
+									},
+									ResultTypes: outputTypes,
+								},
+							},
+						} {
+							args := verifyColOperatorArgs{
+								anyOrder:       tc.anyOrder,
+								inputTypes:     [][]*types.T{inputTypes},
+								inputs:         []rowenc.EncDatumRows{rows},
+								pspec:          tc.pspec,
+								forceDiskSpill: spillForced,
+							}
+							if err := verifyColOperator(t, args); err != nil {
+								fmt.Printf("--- seed = %d run = %d nCols = %d group cols = %v ordered cols = %v output cols = %v spilled = %t ---\n",
+									seed, run, nCols, groupCols, orderedCols, outputCols, spillForced)
+								prettyPrintTypes(inputTypes, "t" /* tableName */)
+								prettyPrintInput(rows, inputTypes, "t" /* tableName */)
+								t.Fatal(err)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
 func TestDistinctAgainstProcessor(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
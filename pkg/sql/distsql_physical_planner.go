@@ -3514,6 +3514,11 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 		return nil, err
 	}
 
+	// The input might already be ordered (e.g. if it is a top K sort), in which
+	// case we communicate its ordering to the windowers of the first stage so
+	// that they could avoid the redundant sorts. The windowers don't preserve
+	// the ordering, so the later stages can't rely on it.
+	inputOrdering := dsp.convertOrdering(planReqOrdering(n.plan), plan.PlanToStreamColMap)
 	numWindowFuncProcessed := 0
 	windowPlanState := createWindowPlanState(n, planCtx, plan)
 	// Each iteration of this loop adds a new stage of windowers. The steps taken:
@@ -3566,6 +3571,11 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 			if len(nodes) == 1 {
 				node = nodes[0]
 			}
+			if len(plan.ResultRouters) == 1 {
+				// There is a single stream, so its ordering is still
+				// maintained, yet SetMergeOrdering would have dropped it.
+				plan.MergeOrdering = inputOrdering
+			}
 			plan.AddSingleGroupStage(
 				node,
 				execinfrapb.ProcessorCoreUnion{Windower: &windowerSpec},
@@ -3625,6 +3635,7 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 				plan.ResultRouters = append(plan.ResultRouters, pIdx)
 			}
 		}
+		inputOrdering = execinfrapb.Ordering{}
 	}
 
 	// We definitely added columns throughout all the stages of windowers, so we
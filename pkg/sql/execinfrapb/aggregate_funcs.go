@@ -58,4 +58,11 @@ const (
 	RegrCount          = AggregatorSpec_REGR_COUNT
 	RegrAvgx           = AggregatorSpec_REGR_AVGX
 	RegrAvgy           = AggregatorSpec_REGR_AVGY
+	// ApproxCountDistinct estimates the number of distinct values using a
+	// HyperLogLog sketch.
+	ApproxCountDistinct = AggregatorSpec_APPROX_COUNT_DISTINCT
+	BitXor              = AggregatorSpec_BIT_XOR
+	// ApproxPercentile estimates a percentile using a t-digest.
+	ApproxPercentile = AggregatorSpec_APPROX_PERCENTILE
+	ModeImpl         = AggregatorSpec_MODE_IMPL
 )
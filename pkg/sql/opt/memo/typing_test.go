@@ -183,7 +183,8 @@ func TestTypingAggregateAssumptions(t *testing.T) {
 	for _, name := range builtins.AllAggregateBuiltinNames {
 		if name == builtins.AnyNotNull ||
 			name == "percentile_disc" ||
-			name == "percentile_cont" {
+			name == "percentile_cont" ||
+			name == "mode" {
 			// These are treated as special cases.
 			continue
 		}
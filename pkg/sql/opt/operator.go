@@ -179,10 +179,13 @@ var UnaryOpReverseMap = map[Operator]tree.UnaryOperator{
 // AggregateOpReverseMap maps from an optimizer operator type to the name of an
 // aggregation function.
 var AggregateOpReverseMap = map[Operator]string{
+	ApproxCountDistinctOp: "approx_count_distinct",
+	ApproxPercentileOp:    "approx_percentile",
 	ArrayAggOp:            "array_agg",
 	AvgOp:                 "avg",
 	BitAndAggOp:           "bit_and",
 	BitOrAggOp:            "bit_or",
+	BitXorAggOp:           "bit_xor",
 	BoolAndOp:             "bool_and",
 	BoolOrOp:              "bool_or",
 	ConcatAggOp:           "concat_agg",
@@ -218,6 +221,7 @@ var AggregateOpReverseMap = map[Operator]string{
 	AnyNotNullAggOp:       "any_not_null",
 	PercentileDiscOp:      "percentile_disc_impl",
 	PercentileContOp:      "percentile_cont_impl",
+	ModeOp:                "mode_impl",
 	VarPopOp:              "var_pop",
 	StdDevPopOp:           "stddev_pop",
 	STMakeLineOp:          "st_makeline",
@@ -316,10 +320,10 @@ func BoolOperatorRequiresNotNullArgs(op Operator) bool {
 func AggregateIgnoresNulls(op Operator) bool {
 	switch op {
 
-	case AnyNotNullAggOp, AvgOp, BitAndAggOp, BitOrAggOp, BoolAndOp, BoolOrOp,
-		ConstNotNullAggOp, CorrOp, CountOp, MaxOp, MinOp, SqrDiffOp, StdDevOp,
+	case AnyNotNullAggOp, ApproxCountDistinctOp, ApproxPercentileOp, AvgOp, BitAndAggOp,
+		BitOrAggOp, BitXorAggOp, BoolAndOp, BoolOrOp, ConstNotNullAggOp, CorrOp, CountOp, MaxOp, MinOp, SqrDiffOp, StdDevOp,
 		StringAggOp, SumOp, SumIntOp, VarianceOp, XorAggOp, PercentileDiscOp,
-		PercentileContOp, STMakeLineOp, STCollectOp, STExtentOp, STUnionOp, StdDevPopOp,
+		PercentileContOp, ModeOp, STMakeLineOp, STCollectOp, STExtentOp, STUnionOp, StdDevPopOp,
 		VarPopOp, CovarPopOp, CovarSampOp, RegressionAvgXOp, RegressionAvgYOp,
 		RegressionInterceptOp, RegressionR2Op, RegressionSlopeOp, RegressionSXXOp,
 		RegressionSXYOp, RegressionSYYOp, RegressionCountOp:
@@ -340,18 +344,18 @@ func AggregateIgnoresNulls(op Operator) bool {
 func AggregateIsNullOnEmpty(op Operator) bool {
 	switch op {
 
-	case AnyNotNullAggOp, ArrayAggOp, AvgOp, BitAndAggOp,
-		BitOrAggOp, BoolAndOp, BoolOrOp, ConcatAggOp, ConstAggOp,
+	case AnyNotNullAggOp, ApproxPercentileOp, ArrayAggOp, AvgOp, BitAndAggOp,
+		BitOrAggOp, BitXorAggOp, BoolAndOp, BoolOrOp, ConcatAggOp, ConstAggOp,
 		ConstNotNullAggOp, CorrOp, FirstAggOp, JsonAggOp, JsonbAggOp,
 		MaxOp, MinOp, SqrDiffOp, StdDevOp, STMakeLineOp, StringAggOp, SumOp, SumIntOp,
-		VarianceOp, XorAggOp, PercentileDiscOp, PercentileContOp,
+		VarianceOp, XorAggOp, PercentileDiscOp, PercentileContOp, ModeOp,
 		JsonObjectAggOp, JsonbObjectAggOp, StdDevPopOp, STCollectOp, STExtentOp, STUnionOp,
 		VarPopOp, CovarPopOp, CovarSampOp, RegressionAvgXOp, RegressionAvgYOp,
 		RegressionInterceptOp, RegressionR2Op, RegressionSlopeOp, RegressionSXXOp,
 		RegressionSXYOp, RegressionSYYOp:
 		return true
 
-	case CountOp, CountRowsOp, RegressionCountOp:
+	case ApproxCountDistinctOp, CountOp, CountRowsOp, RegressionCountOp:
 		return false
 
 	default:
@@ -369,11 +373,11 @@ func AggregateIsNullOnEmpty(op Operator) bool {
 func AggregateIsNeverNullOnNonNullInput(op Operator) bool {
 	switch op {
 
-	case AnyNotNullAggOp, ArrayAggOp, AvgOp, BitAndAggOp,
-		BitOrAggOp, BoolAndOp, BoolOrOp, ConcatAggOp, ConstAggOp,
+	case AnyNotNullAggOp, ApproxCountDistinctOp, ArrayAggOp, AvgOp, BitAndAggOp,
+		BitOrAggOp, BitXorAggOp, BoolAndOp, BoolOrOp, ConcatAggOp, ConstAggOp,
 		ConstNotNullAggOp, CountOp, CountRowsOp, FirstAggOp,
 		JsonAggOp, JsonbAggOp, MaxOp, MinOp, SqrDiffOp, STMakeLineOp,
-		StringAggOp, SumOp, SumIntOp, XorAggOp, PercentileDiscOp, PercentileContOp,
+		StringAggOp, SumOp, SumIntOp, XorAggOp, PercentileDiscOp, PercentileContOp, ModeOp,
 		JsonObjectAggOp, JsonbObjectAggOp, StdDevPopOp, STCollectOp, STExtentOp, STUnionOp,
 		VarPopOp, CovarPopOp, RegressionAvgXOp, RegressionAvgYOp, RegressionSXXOp,
 		RegressionSXYOp, RegressionSYYOp, RegressionCountOp:
@@ -384,6 +388,11 @@ func AggregateIsNeverNullOnNonNullInput(op Operator) bool {
 		// These aggregations return NULL if they are given a single not-NULL input.
 		return false
 
+	case ApproxPercentileOp:
+		// This aggregation ignores the rows in which either input is NULL, so it
+		// returns NULL if the non-NULL inputs are on different rows.
+		return false
+
 	default:
 		panic(errors.AssertionFailedf("unhandled op %s", log.Safe(op)))
 	}
@@ -393,7 +402,7 @@ func AggregateIsNeverNullOnNonNullInput(op Operator) bool {
 // returns NULL, even if the input is empty, or one more more inputs are NULL.
 func AggregateIsNeverNull(op Operator) bool {
 	switch op {
-	case CountOp, CountRowsOp, RegressionCountOp:
+	case ApproxCountDistinctOp, CountOp, CountRowsOp, RegressionCountOp:
 		return true
 	}
 	return false
@@ -416,7 +425,7 @@ func AggregateIsNeverNull(op Operator) bool {
 func AggregatesCanMerge(inner, outer Operator) bool {
 	switch inner {
 
-	case AnyNotNullAggOp, BitAndAggOp, BitOrAggOp, BoolAndOp,
+	case AnyNotNullAggOp, BitAndAggOp, BitOrAggOp, BitXorAggOp, BoolAndOp,
 		BoolOrOp, ConstAggOp, ConstNotNullAggOp, FirstAggOp,
 		MaxOp, MinOp, STMakeLineOp, STExtentOp, STUnionOp, SumOp, SumIntOp, XorAggOp:
 		return inner == outer
@@ -426,8 +435,8 @@ func AggregatesCanMerge(inner, outer Operator) bool {
 		// while CountOp and CountRowsOp both output int values.
 		return outer == SumIntOp
 
-	case ArrayAggOp, AvgOp, ConcatAggOp, CorrOp, JsonAggOp, JsonbAggOp,
-		JsonObjectAggOp, JsonbObjectAggOp, PercentileContOp, PercentileDiscOp,
+	case ApproxCountDistinctOp, ApproxPercentileOp, ArrayAggOp, AvgOp, ConcatAggOp, CorrOp, JsonAggOp,
+		JsonbAggOp, JsonObjectAggOp, JsonbObjectAggOp, ModeOp, PercentileContOp, PercentileDiscOp,
 		SqrDiffOp, STCollectOp, StdDevOp, StringAggOp, VarianceOp, StdDevPopOp,
 		VarPopOp, CovarPopOp, CovarSampOp, RegressionAvgXOp, RegressionAvgYOp,
 		RegressionInterceptOp, RegressionR2Op, RegressionSlopeOp, RegressionSXXOp,
@@ -443,13 +452,14 @@ func AggregatesCanMerge(inner, outer Operator) bool {
 // operator does not change when duplicate rows are added to the input.
 func AggregateIgnoresDuplicates(op Operator) bool {
 	switch op {
-	case AnyNotNullAggOp, BitAndAggOp, BitOrAggOp, BoolAndOp, BoolOrOp,
-		ConstAggOp, ConstNotNullAggOp, FirstAggOp, MaxOp, MinOp, STExtentOp, STUnionOp:
+	case AnyNotNullAggOp, ApproxCountDistinctOp, BitAndAggOp, BitOrAggOp, BoolAndOp,
+		BoolOrOp, ConstAggOp, ConstNotNullAggOp, FirstAggOp, MaxOp, MinOp, STExtentOp,
+		STUnionOp:
 		return true
 
-	case ArrayAggOp, AvgOp, ConcatAggOp, CountOp, CorrOp, CountRowsOp, SumIntOp,
+	case ApproxPercentileOp, ArrayAggOp, AvgOp, BitXorAggOp, ConcatAggOp, CountOp, CorrOp, CountRowsOp, SumIntOp,
 		SumOp, SqrDiffOp, VarianceOp, StdDevOp, XorAggOp, JsonAggOp, JsonbAggOp,
-		StringAggOp, PercentileDiscOp, PercentileContOp, StdDevPopOp, STMakeLineOp,
+		StringAggOp, PercentileDiscOp, PercentileContOp, ModeOp, StdDevPopOp, STMakeLineOp,
 		VarPopOp, JsonObjectAggOp, JsonbObjectAggOp, STCollectOp, CovarPopOp,
 		CovarSampOp, RegressionAvgXOp, RegressionAvgYOp, RegressionInterceptOp,
 		RegressionR2Op, RegressionSlopeOp, RegressionSXXOp, RegressionSXYOp,
@@ -237,7 +237,7 @@ func (a *aggregateInfo) TypeCheck(
 // ordered-set aggregate.
 func (a aggregateInfo) isOrderedSetAggregate() bool {
 	switch a.def.Name {
-	case "percentile_disc_impl", "percentile_cont_impl":
+	case "percentile_disc_impl", "percentile_cont_impl", "mode_impl":
 		return true
 	default:
 		return false
@@ -665,6 +665,8 @@ func translateAggName(name string) string {
 		return "percentile_disc"
 	case "percentile_cont_impl":
 		return "percentile_cont"
+	case "mode_impl":
+		return "mode"
 	}
 	return name
 }
@@ -786,6 +788,10 @@ func (b *Builder) constructWindowFn(name string, args []opt.ScalarExpr) opt.Scal
 
 func (b *Builder) constructAggregate(name string, args []opt.ScalarExpr) opt.ScalarExpr {
 	switch name {
+	case "approx_count_distinct":
+		return b.factory.ConstructApproxCountDistinct(args[0])
+	case "approx_percentile":
+		return b.factory.ConstructApproxPercentile(args[0], args[1])
 	case "array_agg":
 		return b.factory.ConstructArrayAgg(args[0])
 	case "avg":
@@ -794,6 +800,8 @@ func (b *Builder) constructAggregate(name string, args []opt.ScalarExpr) opt.Sca
 		return b.factory.ConstructBitAndAgg(args[0])
 	case "bit_or":
 		return b.factory.ConstructBitOrAgg(args[0])
+	case "bit_xor":
+		return b.factory.ConstructBitXorAgg(args[0])
 	case "bool_and", "every":
 		return b.factory.ConstructBoolAnd(args[0])
 	case "bool_or":
@@ -866,6 +874,8 @@ func (b *Builder) constructAggregate(name string, args []opt.ScalarExpr) opt.Sca
 		return b.factory.ConstructPercentileDisc(args[0], args[1])
 	case "percentile_cont_impl":
 		return b.factory.ConstructPercentileCont(args[0], args[1])
+	case "mode_impl":
+		return b.factory.ConstructMode(args[0])
 	case "json_object_agg":
 		return b.factory.ConstructJsonObjectAgg(args[0], args[1])
 	case "jsonb_object_agg":
@@ -1066,6 +1066,10 @@ func isOrderedSetAggregate(def *tree.FunctionDefinition) (*tree.FunctionDefiniti
 		newDef := *tree.FunDefs["percentile_cont_impl"]
 		newDef.Private = false
 		return &newDef, true
+	case tree.FunDefs["mode"]:
+		newDef := *tree.FunDefs["mode_impl"]
+		newDef.Private = false
+		return &newDef, true
 	}
 	return def, false
 }
@@ -300,4 +300,14 @@ var DistAggregationTable = map[execinfrapb.AggregatorSpec_Func]DistAggregationIn
 			},
 		},
 	},
+
+	execinfrapb.BitXor: {
+		LocalStage: []execinfrapb.AggregatorSpec_Func{execinfrapb.BitXor},
+		FinalStage: []FinalStageInfo{
+			{
+				Fn:        execinfrapb.BitXor,
+				LocalIdxs: passThroughLocalIdxs,
+			},
+		},
+	},
 }
@@ -354,6 +354,12 @@ func (p *PhysicalPlan) MergeResultStreams(
 	}
 	if useUnorderedSync {
 		proc.Spec.Input[destInput].Type = execinfrapb.InputSyncSpec_UNORDERED
+		if len(resultRouters) == 1 {
+			// The single stream is still ordered, so we communicate its
+			// ordering to the processor which might take advantage of it
+			// (e.g. to avoid redundant sorts).
+			proc.Spec.Input[destInput].Ordering = ordering
+		}
 	} else {
 		proc.Spec.Input[destInput].Type = execinfrapb.InputSyncSpec_ORDERED
 		proc.Spec.Input[destInput].Ordering = ordering
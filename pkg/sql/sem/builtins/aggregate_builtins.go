@@ -18,19 +18,24 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/axiomhq/hyperloglog"
 	"github.com/cockroachdb/apd/v2"
 	"github.com/cockroachdb/cockroach/pkg/geo"
 	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
 	"github.com/cockroachdb/cockroach/pkg/geo/geos"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/arith"
 	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/tdigest"
 	"github.com/cockroachdb/errors"
 	"github.com/twpayne/go-geom"
 )
@@ -104,6 +109,20 @@ var allMaxMinAggregateTypes = append(
 // These functions are also identified with Class == tree.AggregateClass.
 // The properties are reachable via tree.FunctionDefinition.
 var aggregates = map[string]builtinDefinition{
+	"approx_count_distinct": makeBuiltin(aggPropsNullableArgs(),
+		makeAggOverload([]*types.T{types.Any}, types.Int, newApproxCountDistinctAggregate,
+			"Estimates the number of distinct non-NULL selected elements using a HyperLogLog sketch.",
+			tree.VolatilityImmutable),
+	),
+
+	"approx_percentile": makeBuiltin(aggProps(),
+		makeAggOverload([]*types.T{types.Float, types.Float}, types.Float, newApproxPercentileAggregate,
+			"Estimates the percentile of the selected values (the first argument) using a t-digest. "+
+				"The second argument is the fraction of the percentile; it is read from the first row "+
+				"and must be between 0 and 1. The rows in which either argument is NULL are ignored.",
+			tree.VolatilityImmutable),
+	),
+
 	"array_agg": setProps(aggPropsNullableArgs(),
 		arrayBuiltin(func(t *types.T) tree.Overload {
 			return makeAggOverloadWithReturnType(
@@ -147,6 +166,13 @@ var aggregates = map[string]builtinDefinition{
 			"Calculates the bitwise OR of all non-null input values, or null if none.", tree.VolatilityImmutable),
 	),
 
+	"bit_xor": makeBuiltin(aggProps(),
+		makeAggOverload([]*types.T{types.Int}, types.Int, newIntBitXorAggregate,
+			"Calculates the bitwise XOR of all non-null input values, or null if none.", tree.VolatilityImmutable),
+		makeAggOverload([]*types.T{types.VarBit}, types.VarBit, newBitBitXorAggregate,
+			"Calculates the bitwise XOR of all non-null input values, or null if none.", tree.VolatilityImmutable),
+	),
+
 	"bool_and": makeBuiltin(aggProps(),
 		makeAggOverload([]*types.T{types.Bool}, types.Bool, newBoolAndAggregate,
 			"Calculates the boolean value of `AND`ing all selected values.", tree.VolatilityImmutable),
@@ -548,6 +574,22 @@ var aggregates = map[string]builtinDefinition{
 			"Implementation of percentile_cont.",
 			tree.VolatilityImmutable),
 	)),
+	"mode": makeBuiltin(aggProps(),
+		makeAggOverloadWithReturnType(
+			[]*types.T{},
+			func(args []tree.TypedExpr) *types.T { return tree.UnknownReturnType },
+			builtinMustNotRun,
+			"Returns the most frequent input value, choosing the first one in the ordering if "+
+				"there are multiple equally-frequent values.",
+			tree.VolatilityImmutable),
+	),
+	"mode_impl": makePrivate(collectOverloads(aggProps(), types.Scalar,
+		func(t *types.T) tree.Overload {
+			return makeAggOverload([]*types.T{t}, t, newModeAggregate,
+				"Implementation of mode.",
+				tree.VolatilityImmutable)
+		},
+	)),
 }
 
 // AnyNotNull is the name of the aggregate returned by NewAnyNotNullAggregate.
@@ -1047,6 +1089,8 @@ func builtinMustNotRun(_ []*types.T, _ *tree.EvalContext, _ tree.Datums) tree.Ag
 	panic("builtin must be overridden and cannot be run directly")
 }
 
+var _ tree.AggregateFunc = &approxCountDistinctAggregate{}
+var _ tree.AggregateFunc = &approxPercentileAggregate{}
 var _ tree.AggregateFunc = &arrayAggregate{}
 var _ tree.AggregateFunc = &avgAggregate{}
 var _ tree.AggregateFunc = &corrAggregate{}
@@ -1079,8 +1123,11 @@ var _ tree.AggregateFunc = &intBitAndAggregate{}
 var _ tree.AggregateFunc = &bitBitAndAggregate{}
 var _ tree.AggregateFunc = &intBitOrAggregate{}
 var _ tree.AggregateFunc = &bitBitOrAggregate{}
+var _ tree.AggregateFunc = &intBitXorAggregate{}
+var _ tree.AggregateFunc = &bitBitXorAggregate{}
 var _ tree.AggregateFunc = &percentileDiscAggregate{}
 var _ tree.AggregateFunc = &percentileContAggregate{}
+var _ tree.AggregateFunc = &modeAggregate{}
 var _ tree.AggregateFunc = &stMakeLineAgg{}
 var _ tree.AggregateFunc = &stUnionAgg{}
 var _ tree.AggregateFunc = &stExtentAgg{}
@@ -1096,6 +1143,8 @@ var _ tree.AggregateFunc = &regressionCountAggregate{}
 var _ tree.AggregateFunc = &regressionAvgXAggregate{}
 var _ tree.AggregateFunc = &regressionAvgYAggregate{}
 
+const sizeOfApproxCountDistinctAggregate = int64(unsafe.Sizeof(approxCountDistinctAggregate{}))
+const sizeOfApproxPercentileAggregate = int64(unsafe.Sizeof(approxPercentileAggregate{}))
 const sizeOfArrayAggregate = int64(unsafe.Sizeof(arrayAggregate{}))
 const sizeOfAvgAggregate = int64(unsafe.Sizeof(avgAggregate{}))
 const sizeOfRegressionAccumulatorBase = int64(unsafe.Sizeof(regressionAccumulatorBase{}))
@@ -1132,8 +1181,12 @@ const sizeOfIntBitAndAggregate = int64(unsafe.Sizeof(intBitAndAggregate{}))
 const sizeOfBitBitAndAggregate = int64(unsafe.Sizeof(bitBitAndAggregate{}))
 const sizeOfIntBitOrAggregate = int64(unsafe.Sizeof(intBitOrAggregate{}))
 const sizeOfBitBitOrAggregate = int64(unsafe.Sizeof(bitBitOrAggregate{}))
+const sizeOfIntBitXorAggregate = int64(unsafe.Sizeof(intBitXorAggregate{}))
+const sizeOfBitBitXorAggregate = int64(unsafe.Sizeof(bitBitXorAggregate{}))
 const sizeOfPercentileDiscAggregate = int64(unsafe.Sizeof(percentileDiscAggregate{}))
 const sizeOfPercentileContAggregate = int64(unsafe.Sizeof(percentileContAggregate{}))
+const sizeOfModeAggregate = int64(unsafe.Sizeof(modeAggregate{}))
+const sizeOfModeEntry = int64(unsafe.Sizeof(modeEntry{}))
 const sizeOfSTMakeLineAggregate = int64(unsafe.Sizeof(stMakeLineAgg{}))
 const sizeOfSTUnionAggregate = int64(unsafe.Sizeof(stUnionAgg{}))
 const sizeOfSTCollectAggregate = int64(unsafe.Sizeof(stCollectAgg{}))
@@ -1739,6 +1792,104 @@ func (a *bitBitOrAggregate) Size() int64 {
 	return sizeOfBitBitOrAggregate
 }
 
+type intBitXorAggregate struct {
+	sawNonNull bool
+	result     int64
+}
+
+func newIntBitXorAggregate(_ []*types.T, _ *tree.EvalContext, _ tree.Datums) tree.AggregateFunc {
+	return &intBitXorAggregate{}
+}
+
+// Add inserts one value into the running bitwise XOR.
+func (a *intBitXorAggregate) Add(_ context.Context, datum tree.Datum, _ ...tree.Datum) error {
+	if datum == tree.DNull {
+		return nil
+	}
+	// XOR with zero is the identity, so we don't need to special case the
+	// first non-null datum.
+	a.result = a.result ^ int64(tree.MustBeDInt(datum))
+	a.sawNonNull = true
+	return nil
+}
+
+// Result returns the bitwise XOR.
+func (a *intBitXorAggregate) Result() (tree.Datum, error) {
+	if !a.sawNonNull {
+		return tree.DNull, nil
+	}
+	return tree.NewDInt(tree.DInt(a.result)), nil
+}
+
+// Reset implements tree.AggregateFunc interface.
+func (a *intBitXorAggregate) Reset(context.Context) {
+	a.sawNonNull = false
+	a.result = 0
+}
+
+// Close is part of the tree.AggregateFunc interface.
+func (a *intBitXorAggregate) Close(context.Context) {}
+
+// Size is part of the tree.AggregateFunc interface.
+func (a *intBitXorAggregate) Size() int64 {
+	return sizeOfIntBitXorAggregate
+}
+
+type bitBitXorAggregate struct {
+	sawNonNull bool
+	result     bitarray.BitArray
+}
+
+func newBitBitXorAggregate(_ []*types.T, _ *tree.EvalContext, _ tree.Datums) tree.AggregateFunc {
+	return &bitBitXorAggregate{}
+}
+
+// Add inserts one value into the running bitwise XOR.
+func (a *bitBitXorAggregate) Add(_ context.Context, datum tree.Datum, _ ...tree.Datum) error {
+	if datum == tree.DNull {
+		return nil
+	}
+	bits := &tree.MustBeDBitArray(datum).BitArray
+	if !a.sawNonNull {
+		// This is the first non-null datum, so we simply store
+		// the provided value for the aggregation.
+		a.result = *bits
+		a.sawNonNull = true
+		return nil
+	}
+	// If the length of the current bit array is different from that of the
+	// stored value, we return an error.
+	if a.result.BitLen() != bits.BitLen() {
+		return tree.NewCannotMixBitArraySizesError("XOR")
+	}
+	// This is not the first non-null datum, so we actually XOR it with the
+	// aggregate so far.
+	a.result = bitarray.Xor(a.result, *bits)
+	return nil
+}
+
+// Result returns the bitwise XOR.
+func (a *bitBitXorAggregate) Result() (tree.Datum, error) {
+	if !a.sawNonNull {
+		return tree.DNull, nil
+	}
+	return &tree.DBitArray{BitArray: a.result}, nil
+}
+
+// Reset implements tree.AggregateFunc interface.
+func (a *bitBitXorAggregate) Reset(context.Context) {
+	a.sawNonNull = false
+	a.result = bitarray.BitArray{}
+}
+
+// Close is part of the tree.AggregateFunc interface.
+func (a *bitBitXorAggregate) Close(context.Context) {}
+
+// Size is part of the tree.AggregateFunc interface.
+func (a *bitBitXorAggregate) Size() int64 {
+	return sizeOfBitBitXorAggregate
+}
+
 type boolAndAggregate struct {
 	sawNonNull bool
 	result     bool
@@ -2230,6 +2381,141 @@ func (a *regressionCountAggregate) Size() int64 {
 	return sizeOfRegressionCountAggregate
 }
 
+// approxCountDistinctSketchSize is the upper bound on the memory used by the
+// HyperLogLog sketch of precision 14.
+const approxCountDistinctSketchSize = 1 << 14
+
+// approxCountDistinctAggregate estimates the number of distinct non-NULL values
+// passed to Add. The values are inserted into the HyperLogLog sketch using
+// their fingerprints (the ascending key encoding or, for JSON, the value
+// encoding), so the values that are equal in SQL are counted once.
+type approxCountDistinctAggregate struct {
+	sketch *hyperloglog.Sketch
+	buf    []byte
+}
+
+func newApproxCountDistinctAggregate(
+	_ []*types.T, _ *tree.EvalContext, _ tree.Datums,
+) tree.AggregateFunc {
+	return &approxCountDistinctAggregate{sketch: hyperloglog.New14()}
+}
+
+// Add implements tree.AggregateFunc interface.
+func (a *approxCountDistinctAggregate) Add(
+	_ context.Context, datum tree.Datum, _ ...tree.Datum,
+) error {
+	if datum == tree.DNull {
+		return nil
+	}
+	var err error
+	a.buf, err = encodeAggFingerprint(a.buf[:0], datum)
+	if err != nil {
+		return err
+	}
+	a.sketch.Insert(a.buf)
+	return nil
+}
+
+// encodeAggFingerprint appends to buf the fingerprint of the non-NULL datum:
+// the ascending key encoding or, for JSON, the value encoding. The datums that
+// are equal in SQL have the same fingerprints.
+func encodeAggFingerprint(buf []byte, datum tree.Datum) ([]byte, error) {
+	if j, ok := datum.(*tree.DJSON); ok {
+		return rowenc.EncodeTableValue(buf, descpb.ColumnID(encoding.NoColumnID), j, nil /* scratch */)
+	}
+	return rowenc.EncodeTableKey(buf, datum, encoding.Ascending)
+}
+
+// Result implements tree.AggregateFunc interface.
+func (a *approxCountDistinctAggregate) Result() (tree.Datum, error) {
+	return tree.NewDInt(tree.DInt(a.sketch.Estimate())), nil
+}
+
+// Reset implements tree.AggregateFunc interface.
+func (a *approxCountDistinctAggregate) Reset(context.Context) {
+	a.sketch = hyperloglog.New14()
+}
+
+// Close is part of the tree.AggregateFunc interface.
+func (a *approxCountDistinctAggregate) Close(context.Context) {}
+
+// Size is part of the tree.AggregateFunc interface.
+func (a *approxCountDistinctAggregate) Size() int64 {
+	return sizeOfApproxCountDistinctAggregate + approxCountDistinctSketchSize
+}
+
+// approxPercentileAggregate estimates the percentile of the values passed to
+// Add using a t-digest. The fraction of the percentile is read from the first
+// row in which neither argument is NULL.
+type approxPercentileAggregate struct {
+	digest      *tdigest.TDigest
+	fraction    float64
+	hasFraction bool
+	// acc accounts for the memory of the digest which grows lazily.
+	acc       mon.BoundAccount
+	accounted int64
+}
+
+func newApproxPercentileAggregate(
+	_ []*types.T, evalCtx *tree.EvalContext, _ tree.Datums,
+) tree.AggregateFunc {
+	return &approxPercentileAggregate{
+		digest: tdigest.New(tdigest.DefaultCompression),
+		acc:    evalCtx.Mon.MakeBoundAccount(),
+	}
+}
+
+// Add implements tree.AggregateFunc interface.
+func (a *approxPercentileAggregate) Add(
+	ctx context.Context, datum tree.Datum, others ...tree.Datum,
+) error {
+	if datum == tree.DNull || others[0] == tree.DNull {
+		return nil
+	}
+	if !a.hasFraction {
+		fractions, _, err := validateInputFractions(others[0])
+		if err != nil {
+			return err
+		}
+		a.fraction = fractions[0]
+		a.hasFraction = true
+	}
+	a.digest.Add(float64(tree.MustBeDFloat(datum)))
+	if memUsage := a.digest.MemUsage(); memUsage > a.accounted {
+		if err := a.acc.Grow(ctx, memUsage-a.accounted); err != nil {
+			return err
+		}
+		a.accounted = memUsage
+	}
+	return nil
+}
+
+// Result implements tree.AggregateFunc interface.
+func (a *approxPercentileAggregate) Result() (tree.Datum, error) {
+	if a.digest.Count() == 0 {
+		return tree.DNull, nil
+	}
+	return tree.NewDFloat(tree.DFloat(a.digest.Quantile(a.fraction))), nil
+}
+
+// Reset implements tree.AggregateFunc interface.
+func (a *approxPercentileAggregate) Reset(context.Context) {
+	// Note that the memory of the digest is kept for reuse, so we don't shrink
+	// the account.
+	a.digest.Reset()
+	a.hasFraction = false
+}
+
+// Close is part of the tree.AggregateFunc interface.
+func (a *approxPercentileAggregate) Close(ctx context.Context) {
+	a.acc.Close(ctx)
+}
+
+// Size is part of the tree.AggregateFunc interface.
+func (a *approxPercentileAggregate) Size() int64 {
+	return sizeOfApproxPercentileAggregate
+}
+
 type countAggregate struct {
 	count int
 }
@@ -3947,6 +4233,93 @@ func (a *percentileContAggregate) Size() int64 {
 	return sizeOfPercentileContAggregate
 }
 
+// modeAggregate returns the most frequent non-NULL value passed to Add. The
+// values are counted using their fingerprints (see encodeAggFingerprint). If
+// there are multiple equally-frequent values, the one seen first wins, and
+// since the input of mode is sorted according to its WITHIN GROUP clause, it
+// is the first one in the ordering.
+type modeAggregate struct {
+	// counts maps the fingerprint of each value to its entry.
+	counts map[string]*modeEntry
+	// best is the most frequent value so far, and bestEntry is its entry in
+	// counts. bestEntry is nil if there were no non-NULL values.
+	best      tree.Datum
+	bestEntry *modeEntry
+	buf       []byte
+	acc       mon.BoundAccount
+}
+
+// modeEntry describes a distinct value passed to modeAggregate.
+type modeEntry struct {
+	// count is the number of times the value has been seen.
+	count int
+	// ordinal is the position of the value among the distinct values in the
+	// order in which they have been first seen.
+	ordinal int
+}
+
+func newModeAggregate(
+	_ []*types.T, evalCtx *tree.EvalContext, _ tree.Datums,
+) tree.AggregateFunc {
+	return &modeAggregate{
+		counts: make(map[string]*modeEntry),
+		acc:    evalCtx.Mon.MakeBoundAccount(),
+	}
+}
+
+// Add implements tree.AggregateFunc interface.
+func (a *modeAggregate) Add(ctx context.Context, datum tree.Datum, _ ...tree.Datum) error {
+	if datum == tree.DNull {
+		return nil
+	}
+	var err error
+	a.buf, err = encodeAggFingerprint(a.buf[:0], datum)
+	if err != nil {
+		return err
+	}
+	e := a.counts[string(a.buf)]
+	if e == nil {
+		if err := a.acc.Grow(ctx, int64(len(a.buf))+sizeOfModeEntry+mapEntryOverhead); err != nil {
+			return err
+		}
+		e = &modeEntry{ordinal: len(a.counts)}
+		a.counts[string(a.buf)] = e
+	}
+	e.count++
+	// The value that is the most frequent one already is kept as is, so the
+	// result is the occurrence that made its value the most frequent one.
+	if e != a.bestEntry && (a.bestEntry == nil || e.count > a.bestEntry.count ||
+		(e.count == a.bestEntry.count && e.ordinal < a.bestEntry.ordinal)) {
+		a.best, a.bestEntry = datum, e
+	}
+	return nil
+}
+
+// Result implements tree.AggregateFunc interface.
+func (a *modeAggregate) Result() (tree.Datum, error) {
+	if a.bestEntry == nil {
+		return tree.DNull, nil
+	}
+	return a.best, nil
+}
+
+// Reset implements tree.AggregateFunc interface.
+func (a *modeAggregate) Reset(ctx context.Context) {
+	a.counts = make(map[string]*modeEntry)
+	a.best, a.bestEntry = nil, nil
+	a.acc.Empty(ctx)
+}
+
+// Close is part of the tree.AggregateFunc interface.
+func (a *modeAggregate) Close(ctx context.Context) {
+	a.acc.Close(ctx)
+}
+
+// Size is part of the tree.AggregateFunc interface.
+func (a *modeAggregate) Size() int64 {
+	return sizeOfModeAggregate
+}
+
 type jsonObjectAggregate struct {
 	singleDatumAggregateBase
 
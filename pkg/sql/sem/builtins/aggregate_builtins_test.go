@@ -87,6 +87,20 @@ func flattenArgs(args ...[]tree.Datum) [][]tree.Datum {
 	return res
 }
 
+func TestApproxCountDistinctResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testAggregateResultDeepCopy(t, newApproxCountDistinctAggregate, makeIntTestDatum(10))
+}
+
+func TestApproxPercentileResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	fractions := make([]tree.Datum, 10)
+	for i := range fractions {
+		fractions[i] = tree.NewDFloat(0.5)
+	}
+	testAggregateResultDeepCopy(t, newApproxPercentileAggregate, makeFloatTestDatum(10), fractions)
+}
+
 func TestAvgIntResultDeepCopy(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	testAggregateResultDeepCopy(t, newIntAvgAggregate, makeIntTestDatum(10))
@@ -161,6 +175,32 @@ func TestBitOrBitResultDeepCopy(t *testing.T) {
 	})
 }
 
+func TestBitXorIntResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	t.Run("all null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newIntBitXorAggregate, makeNullTestDatum(10))
+	})
+	t.Run("with null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newIntBitXorAggregate, makeTestWithNullDatum(10, makeIntTestDatum))
+	})
+	t.Run("without null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newIntBitXorAggregate, makeIntTestDatum(10))
+	})
+}
+
+func TestBitXorBitResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	t.Run("all null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newBitBitXorAggregate, makeNullTestDatum(10))
+	})
+	t.Run("with null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newBitBitXorAggregate, makeTestWithNullDatum(10, makeBitTestDatum))
+	})
+	t.Run("without null", func(t *testing.T) {
+		testAggregateResultDeepCopy(t, newBitBitXorAggregate, makeBitTestDatum(10))
+	})
+}
+
 func TestBoolAndResultDeepCopy(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	testAggregateResultDeepCopy(t, newBoolAndAggregate, makeBoolTestDatum(10))
@@ -216,6 +256,16 @@ func TestMinBoolResultDeepCopy(t *testing.T) {
 	testAggregateResultDeepCopy(t, newMinAggregate, makeBoolTestDatum(10))
 }
 
+func TestModeIntResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testAggregateResultDeepCopy(t, newModeAggregate, makeIntTestDatum(10))
+}
+
+func TestModeDecimalResultDeepCopy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testAggregateResultDeepCopy(t, newModeAggregate, makeDecimalTestDatum(10))
+}
+
 func TestSumSmallIntResultDeepCopy(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	testAggregateResultDeepCopy(t, newSmallIntSumAggregate, makeSmallIntTestDatum(10))
@@ -22,8 +22,10 @@ import (
 	"hash/crc32"
 	"hash/fnv"
 	"math"
+	"math/bits"
 	"math/rand"
 	"net"
+	"regexp"
 	"regexp/syntax"
 	"strconv"
 	"strings"
@@ -223,6 +225,31 @@ var builtins = map[string]builtinDefinition{
 	"char_length":      lengthImpls(false /* includeBitOverload */),
 	"character_length": lengthImpls(false /* includeBitOverload */),
 
+	"bit_count": makeBuiltin(tree.FunctionProperties{Category: categoryString},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"val", types.Int}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				// The integers are treated as 64-bit unsigned ones, so the
+				// negative values are counted in two's complement.
+				val := tree.MustBeDInt(args[0])
+				return tree.NewDInt(tree.DInt(bits.OnesCount64(uint64(val)))), nil
+			},
+			Info: "Calculates the number of bits set to 1 in the two's complement " +
+				"representation of `val`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.BitCountInt,
+		},
+		setSpecializedVecBuiltin(tree.BitCountBits, bitsOverload1(
+			func(_ *tree.EvalContext, s *tree.DBitArray) (tree.Datum, error) {
+				return tree.NewDInt(tree.DInt(s.BitArray.OnesCount())), nil
+			},
+			types.Int,
+			"Calculates the number of bits set to 1 in `val`.",
+			tree.VolatilityImmutable,
+		)),
+	),
+
 	"bit_length": makeBuiltin(tree.FunctionProperties{Category: categoryString},
 		stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
@@ -251,22 +278,22 @@ var builtins = map[string]builtinDefinition{
 	),
 
 	"octet_length": makeBuiltin(tree.FunctionProperties{Category: categoryString},
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.OctetLengthString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDInt(tree.DInt(len(s))), nil
 			},
 			types.Int,
 			"Calculates the number of bytes used to represent `val`.",
 			tree.VolatilityImmutable,
-		),
-		bytesOverload1(
+		)),
+		setSpecializedVecBuiltin(tree.OctetLengthBytes, bytesOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDInt(tree.DInt(len(s))), nil
 			},
 			types.Int,
 			"Calculates the number of bytes used to represent `val`.",
 			tree.VolatilityImmutable,
-		),
+		)),
 		bitsOverload1(
 			func(_ *tree.EvalContext, s *tree.DBitArray) (tree.Datum, error) {
 				return tree.NewDInt(tree.DInt((s.BitArray.BitLen() + 7) / 8)), nil
@@ -280,14 +307,14 @@ var builtins = map[string]builtinDefinition{
 	// TODO(pmattis): What string functions should also support types.Bytes?
 
 	"lower": makeBuiltin(tree.FunctionProperties{Category: categoryString},
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.LowerString, stringOverload1(
 			func(evalCtx *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.ToLower(s)), nil
 			},
 			types.String,
 			"Converts all characters in `val` to their lower-case equivalents.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	"unaccent": makeBuiltin(tree.FunctionProperties{Category: categoryString},
@@ -311,14 +338,14 @@ var builtins = map[string]builtinDefinition{
 	),
 
 	"upper": makeBuiltin(tree.FunctionProperties{Category: categoryString},
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.UpperString, stringOverload1(
 			func(evalCtx *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.ToUpper(s)), nil
 			},
 			types.String,
 			"Converts all characters in `val` to their to their upper-case equivalents.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	"substr":    substringImpls,
@@ -395,7 +422,8 @@ var builtins = map[string]builtinDefinition{
 			Info: "Uses the first argument as a separator between the concatenation of the " +
 				"subsequent arguments. \n\nFor example `concat_ws('!','wow','great')` " +
 				"returns `wow!great`.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ConcatWS,
 			// In Postgres concat_ws can take any arguments, converting them to
 			// their text representation. Since the text representation can
 			// depend on the context (e.g. timezone), the function is Stable. In
@@ -473,8 +501,9 @@ var builtins = map[string]builtinDefinition{
 	// https://www.postgresql.org/docs/9.0/functions-binarystring.html#FUNCTIONS-BINARYSTRING-OTHER
 	"get_bit": makeBuiltin(tree.FunctionProperties{Category: categoryString},
 		tree.Overload{
-			Types:      tree.ArgTypes{{"bit_string", types.VarBit}, {"index", types.Int}},
-			ReturnType: tree.FixedReturnType(types.Int),
+			Types:                 tree.ArgTypes{{"bit_string", types.VarBit}, {"index", types.Int}},
+			ReturnType:            tree.FixedReturnType(types.Int),
+			SpecializedVecBuiltin: tree.GetBitVarBitInt,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				bitString := tree.MustBeDBitArray(args[0])
 				index := int(tree.MustBeDInt(args[1]))
@@ -518,7 +547,8 @@ var builtins = map[string]builtinDefinition{
 				{"index", types.Int},
 				{"to_set", types.Int},
 			},
-			ReturnType: tree.FixedReturnType(types.VarBit),
+			SpecializedVecBuiltin: tree.SetBitVarBitIntInt,
+			ReturnType:            tree.FixedReturnType(types.VarBit),
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				bitString := tree.MustBeDBitArray(args[0])
 				index := int(tree.MustBeDInt(args[1]))
@@ -733,8 +763,9 @@ var builtins = map[string]builtinDefinition{
 
 	"host": makeBuiltin(defProps(),
 		tree.Overload{
-			Types:      tree.ArgTypes{{"val", types.INet}},
-			ReturnType: tree.FixedReturnType(types.String),
+			Types:                 tree.ArgTypes{{"val", types.INet}},
+			ReturnType:            tree.FixedReturnType(types.String),
+			SpecializedVecBuiltin: tree.HostINet,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				dIPAddr := tree.MustBeDIPAddr(args[0])
 				s := dIPAddr.IPAddr.String()
@@ -766,8 +797,9 @@ var builtins = map[string]builtinDefinition{
 
 	"masklen": makeBuiltin(defProps(),
 		tree.Overload{
-			Types:      tree.ArgTypes{{"val", types.INet}},
-			ReturnType: tree.FixedReturnType(types.Int),
+			Types:                 tree.ArgTypes{{"val", types.INet}},
+			ReturnType:            tree.FixedReturnType(types.Int),
+			SpecializedVecBuiltin: tree.MaskLenINet,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				dIPAddr := tree.MustBeDIPAddr(args[0])
 				return tree.NewDInt(tree.DInt(dIPAddr.Mask)), nil
@@ -955,7 +987,8 @@ var builtins = map[string]builtinDefinition{
 			Info: "Splits `input` on `delimiter` and return the value in the `return_index_pos`  " +
 				"position (starting at 1). \n\nFor example, `split_part('123.456.789.0','.',3)`" +
 				"returns `789`.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.SplitPartStringStringInt,
 		},
 	),
 
@@ -1030,7 +1063,7 @@ var builtins = map[string]builtinDefinition{
 	),
 
 	"ascii": makeBuiltin(tree.FunctionProperties{Category: categoryString},
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.ASCIIString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				for _, ch := range s {
 					return tree.NewDInt(tree.DInt(ch)), nil
@@ -1040,7 +1073,7 @@ var builtins = map[string]builtinDefinition{
 			types.Int,
 			"Returns the character code of the first character in `val`. Despite the name, the function supports Unicode too.",
 			tree.VolatilityImmutable,
-		)),
+		))),
 
 	"chr": makeBuiltin(tree.FunctionProperties{Category: categoryString},
 		tree.Overload{
@@ -1059,69 +1092,82 @@ var builtins = map[string]builtinDefinition{
 				}
 				return tree.NewDString(answer), nil
 			},
-			Info:       "Returns the character with the code given in `val`. Inverse function of `ascii()`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns the character with the code given in `val`. Inverse function of `ascii()`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ChrInt,
 		},
 	),
 
 	"md5": hashBuiltin(
 		func() hash.Hash { return md5.New() },
 		"Calculates the MD5 hash value of a set of values.",
+		tree.MD5,
 	),
 
 	"sha1": hashBuiltin(
 		func() hash.Hash { return sha1.New() },
 		"Calculates the SHA1 hash value of a set of values.",
+		tree.SHA1,
 	),
 
 	"sha224": hashBuiltin(
 		func() hash.Hash { return sha256.New224() },
 		"Calculates the SHA224 hash value of a set of values.",
+		tree.SHA224,
 	),
 
 	"sha256": hashBuiltin(
 		func() hash.Hash { return sha256.New() },
 		"Calculates the SHA256 hash value of a set of values.",
+		tree.SHA256,
 	),
 
 	"sha384": hashBuiltin(
 		func() hash.Hash { return sha512.New384() },
 		"Calculates the SHA384 hash value of a set of values.",
+		tree.SHA384,
 	),
 
 	"sha512": hashBuiltin(
 		func() hash.Hash { return sha512.New() },
 		"Calculates the SHA512 hash value of a set of values.",
+		tree.SHA512,
 	),
 
 	"fnv32": hash32Builtin(
 		func() hash.Hash32 { return fnv.New32() },
 		"Calculates the 32-bit FNV-1 hash value of a set of values.",
+		tree.FNV32,
 	),
 
 	"fnv32a": hash32Builtin(
 		func() hash.Hash32 { return fnv.New32a() },
 		"Calculates the 32-bit FNV-1a hash value of a set of values.",
+		tree.FNV32a,
 	),
 
 	"fnv64": hash64Builtin(
 		func() hash.Hash64 { return fnv.New64() },
 		"Calculates the 64-bit FNV-1 hash value of a set of values.",
+		tree.FNV64,
 	),
 
 	"fnv64a": hash64Builtin(
 		func() hash.Hash64 { return fnv.New64a() },
 		"Calculates the 64-bit FNV-1a hash value of a set of values.",
+		tree.FNV64a,
 	),
 
 	"crc32ieee": hash32Builtin(
 		func() hash.Hash32 { return crc32.New(crc32.IEEETable) },
 		"Calculates the CRC-32 hash using the IEEE polynomial.",
+		tree.CRC32IEEE,
 	),
 
 	"crc32c": hash32Builtin(
 		func() hash.Hash32 { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
 		"Calculates the CRC-32 hash using the Castagnoli polynomial.",
+		tree.CRC32C,
 	),
 
 	"to_hex": makeBuiltin(
@@ -1137,8 +1183,9 @@ var builtins = map[string]builtinDefinition{
 				// As such, always assume bigint / uint64.
 				return tree.NewDString(fmt.Sprintf("%x", uint64(val))), nil
 			},
-			Info:       "Converts `val` to its hexadecimal representation.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Converts `val` to its hexadecimal representation.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ToHexInt,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"val", types.Bytes}},
@@ -1250,7 +1297,8 @@ var builtins = map[string]builtinDefinition{
 				{"overlay_val", types.String},
 				{"start_pos", types.Int},
 			},
-			ReturnType: tree.FixedReturnType(types.String),
+			SpecializedVecBuiltin: tree.OverlayStringStringInt,
+			ReturnType:            tree.FixedReturnType(types.String),
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				s := string(tree.MustBeDString(args[0]))
 				to := string(tree.MustBeDString(args[1]))
@@ -1270,7 +1318,8 @@ var builtins = map[string]builtinDefinition{
 				{"start_pos", types.Int},
 				{"end_pos", types.Int},
 			},
-			ReturnType: tree.FixedReturnType(types.String),
+			SpecializedVecBuiltin: tree.OverlayStringStringIntInt,
+			ReturnType:            tree.FixedReturnType(types.String),
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				s := string(tree.MustBeDString(args[0]))
 				to := string(tree.MustBeDString(args[1]))
@@ -1300,7 +1349,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "Pads `string` to `length` by adding ' ' to the left of `string`." +
 				"If `string` is longer than `length` it is truncated.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.LPadStringInt,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"string", types.String}, {"length", types.Int}, {"fill", types.String}},
@@ -1317,7 +1367,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "Pads `string` by adding `fill` to the left of `string` to make it `length`. " +
 				"If `string` is longer than `length` it is truncated.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.LPadStringIntString,
 		},
 	),
 
@@ -1337,7 +1388,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "Pads `string` to `length` by adding ' ' to the right of string. " +
 				"If `string` is longer than `length` it is truncated.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RPadStringInt,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"string", types.String}, {"length", types.Int}, {"fill", types.String}},
@@ -1354,13 +1406,14 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "Pads `string` to `length` by adding `fill` to the right of `string`. " +
 				"If `string` is longer than `length` it is truncated.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RPadStringIntString,
 		},
 	),
 
 	// The SQL parser coerces TRIM(...) and TRIM(BOTH ...) to BTRIM(...).
 	"btrim": makeBuiltin(defProps(),
-		stringOverload2(
+		setSpecializedVecBuiltin(tree.BTrimStringString, stringOverload2(
 			"input",
 			"trim_chars",
 			func(_ *tree.EvalContext, s, chars string) (tree.Datum, error) {
@@ -1371,20 +1424,20 @@ var builtins = map[string]builtinDefinition{
 				" of `input` (applies recursively). \n\nFor example, `btrim('doggie', 'eod')` "+
 				"returns `ggi`.",
 			tree.VolatilityImmutable,
-		),
-		stringOverload1(
+		)),
+		setSpecializedVecBuiltin(tree.BTrimString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.TrimSpace(s)), nil
 			},
 			types.String,
 			"Removes all spaces from the beginning and end of `val`.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	// The SQL parser coerces TRIM(LEADING ...) to LTRIM(...).
 	"ltrim": makeBuiltin(defProps(),
-		stringOverload2(
+		setSpecializedVecBuiltin(tree.LTrimStringString, stringOverload2(
 			"input",
 			"trim_chars",
 			func(_ *tree.EvalContext, s, chars string) (tree.Datum, error) {
@@ -1395,20 +1448,20 @@ var builtins = map[string]builtinDefinition{
 				"(left-hand side) of `input` (applies recursively). \n\nFor example, "+
 				"`ltrim('doggie', 'od')` returns `ggie`.",
 			tree.VolatilityImmutable,
-		),
-		stringOverload1(
+		)),
+		setSpecializedVecBuiltin(tree.LTrimString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.TrimLeftFunc(s, unicode.IsSpace)), nil
 			},
 			types.String,
 			"Removes all spaces from the beginning (left-hand side) of `val`.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	// The SQL parser coerces TRIM(TRAILING ...) to RTRIM(...).
 	"rtrim": makeBuiltin(defProps(),
-		stringOverload2(
+		setSpecializedVecBuiltin(tree.RTrimStringString, stringOverload2(
 			"input",
 			"trim_chars",
 			func(_ *tree.EvalContext, s, chars string) (tree.Datum, error) {
@@ -1419,15 +1472,15 @@ var builtins = map[string]builtinDefinition{
 				"side) of `input` (applies recursively). \n\nFor example, `rtrim('doggie', 'ei')` "+
 				"returns `dogg`.",
 			tree.VolatilityImmutable,
-		),
-		stringOverload1(
+		)),
+		setSpecializedVecBuiltin(tree.RTrimString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.TrimRightFunc(s, unicode.IsSpace)), nil
 			},
 			types.String,
 			"Removes all spaces from the end (right-hand side) of `val`.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	"reverse": makeBuiltin(defProps(),
@@ -1449,17 +1502,14 @@ var builtins = map[string]builtinDefinition{
 	),
 
 	"replace": makeBuiltin(defProps(),
-		stringOverload3(
+		setSpecializedVecBuiltin(tree.ReplaceStringStringString, stringOverload3(
 			"input",
 			"find",
 			"replace",
 			func(evalCtx *tree.EvalContext, input, from, to string) (tree.Datum, error) {
 				// Reserve memory for the largest possible result.
 				var maxResultLen int64
-				if len(from) == 0 {
-					// Replacing the empty string causes len(input)+1 insertions.
-					maxResultLen = int64(len(input) + (len(input)+1)*len(to))
-				} else if len(from) < len(to) {
+				if len(from) > 0 && len(from) < len(to) {
 					// Largest result is if input is [from] repeated over and over.
 					maxResultLen = int64(len(input) / len(from) * len(to))
 				} else {
@@ -1469,13 +1519,17 @@ var builtins = map[string]builtinDefinition{
 				if maxResultLen > maxAllocatedStringSize {
 					return nil, errStringTooLarge
 				}
+				if len(from) == 0 {
+					// Same as in Postgres, the empty string is never replaced.
+					return tree.NewDString(input), nil
+				}
 				result := strings.Replace(input, from, to, -1)
 				return tree.NewDString(result), nil
 			},
 			types.String,
 			"Replaces all occurrences of `find` with `replace` in `input`",
 			tree.VolatilityImmutable,
-		),
+		)),
 	),
 
 	"translate": makeBuiltin(defProps(),
@@ -1585,8 +1639,9 @@ var builtins = map[string]builtinDefinition{
 			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return regexpSplitToArray(ctx, args, false /* hasFlags */)
 			},
-			Info:       "Split string using a POSIX regular expression as the delimiter.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Split string using a POSIX regular expression as the delimiter.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RegexpSplitToArrayStringString,
 		},
 		tree.Overload{
 			Types: tree.ArgTypes{
@@ -1598,8 +1653,9 @@ var builtins = map[string]builtinDefinition{
 			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return regexpSplitToArray(ctx, args, true /* hasFlags */)
 			},
-			Info:       "Split string using a POSIX regular expression as the delimiter with flags." + regexpFlagInfo,
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Split string using a POSIX regular expression as the delimiter with flags." + regexpFlagInfo,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RegexpSplitToArrayStringStringString,
 		},
 	),
 
@@ -1686,14 +1742,14 @@ var builtins = map[string]builtinDefinition{
 		)),
 
 	"initcap": makeBuiltin(defProps(),
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.InitcapString, stringOverload1(
 			func(evalCtx *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDString(strings.Title(strings.ToLower(s))), nil
 			},
 			types.String,
 			"Capitalizes the first letter of `val`.",
 			tree.VolatilityImmutable,
-		)),
+		))),
 
 	"quote_ident": makeBuiltin(defProps(),
 		stringOverload1(
@@ -1795,8 +1851,9 @@ var builtins = map[string]builtinDefinition{
 				}
 				return tree.NewDBytes(tree.DBytes(bytes[:n])), nil
 			},
-			Info:       "Returns the first `return_set` bytes from `input`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns the first `return_set` bytes from `input`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.LeftBytesInt,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.String}, {"return_set", types.Int}},
@@ -1814,8 +1871,9 @@ var builtins = map[string]builtinDefinition{
 				}
 				return tree.NewDString(string(runes[:n])), nil
 			},
-			Info:       "Returns the first `return_set` characters from `input`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns the first `return_set` characters from `input`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.LeftStringInt,
 		},
 	),
 
@@ -1836,8 +1894,9 @@ var builtins = map[string]builtinDefinition{
 				}
 				return tree.NewDBytes(tree.DBytes(bytes[len(bytes)-n:])), nil
 			},
-			Info:       "Returns the last `return_set` bytes from `input`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns the last `return_set` bytes from `input`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RightBytesInt,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.String}, {"return_set", types.Int}},
@@ -1855,8 +1914,9 @@ var builtins = map[string]builtinDefinition{
 				}
 				return tree.NewDString(string(runes[len(runes)-n:])), nil
 			},
-			Info:       "Returns the last `return_set` characters from `input`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns the last `return_set` characters from `input`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RightStringInt,
 		},
 	),
 
@@ -1868,8 +1928,9 @@ var builtins = map[string]builtinDefinition{
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return tree.NewDFloat(tree.DFloat(rand.Float64())), nil
 			},
-			Info:       "Returns a random float between 0 and 1.",
-			Volatility: tree.VolatilityVolatile,
+			Info:                  "Returns a random float between 0 and 1.",
+			Volatility:            tree.VolatilityVolatile,
+			SpecializedVecBuiltin: tree.Random,
 		},
 	),
 
@@ -2169,7 +2230,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "From `input`, extracts and formats the time as identified in `extract_format` " +
 				"using standard `strftime` notation (though not all formatting is supported).",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StrftimeTimestamp,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.Date}, {"extract_format", types.String}},
@@ -2188,7 +2250,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "From `input`, extracts and formats the time as identified in `extract_format` " +
 				"using standard `strftime` notation (though not all formatting is supported).",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StrftimeDate,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.TimestampTZ}, {"extract_format", types.String}},
@@ -2204,7 +2267,8 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "From `input`, extracts and formats the time as identified in `extract_format` " +
 				"using standard `strftime` notation (though not all formatting is supported).",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StrftimeTimestampTZ,
 		},
 	),
 
@@ -2226,8 +2290,60 @@ var builtins = map[string]builtinDefinition{
 			},
 			Info: "Returns `input` as a timestamptz using `format` (which uses standard " +
 				"`strptime` formatting).",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StrptimeStringString,
+		},
+	),
+
+	// https://www.postgresql.org/docs/10/static/functions-datetime.html
+	"to_timestamp": makeBuiltin(
+		tree.FunctionProperties{
+			Category: categoryDateAndTime,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"epoch", types.Int}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				t, err := tree.TimeFromEpochFloat(float64(tree.MustBeDInt(args[0])))
+				if err != nil {
+					return nil, err
+				}
+				return &tree.DTimestampTZ{Time: t}, nil
+			},
+			Info:       "Converts `epoch`, the number of seconds since the Unix epoch, to a timestamptz.",
 			Volatility: tree.VolatilityImmutable,
 		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"epoch", types.Float}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				t, err := tree.TimeFromEpochFloat(float64(tree.MustBeDFloat(args[0])))
+				if err != nil {
+					return nil, err
+				}
+				return &tree.DTimestampTZ{Time: t}, nil
+			},
+			Info: "Converts `epoch`, the number of seconds since the Unix epoch, to a " +
+				"timestamptz. The fractional seconds are rounded to microseconds.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ToTimestampFloat,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"epoch", types.Decimal}},
+			ReturnType: tree.FixedReturnType(types.TimestampTZ),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				d := tree.MustBeDDecimal(args[0])
+				t, err := tree.TimeFromEpochDecimal(&d.Decimal)
+				if err != nil {
+					return nil, err
+				}
+				return &tree.DTimestampTZ{Time: t}, nil
+			},
+			Info: "Converts `epoch`, the number of seconds since the Unix epoch, to a " +
+				"timestamptz. The fractional seconds are rounded to microseconds.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ToTimestampDecimal,
+		},
 	),
 
 	// https://www.postgresql.org/docs/10/static/functions-datetime.html
@@ -2267,7 +2383,8 @@ months and years, use ` + "`now() - timestamptz`" + `.`,
 Note this may not be an accurate time span since years and months are normalized
 from days, and years and months are out of context. To avoid normalizing days into
 months and years, use the timestamptz subtraction operator.`,
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.AgeTimestampTZTimestampTZ,
 		},
 	),
 
@@ -2479,7 +2596,8 @@ may increase either contention or retry errors, or both.`,
 			},
 			Info: "Extracts `element` from `input`.\n\n" +
 				"Compatible elements: hour, minute, second, millisecond, microsecond, epoch",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ExtractStringTime,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"element", types.String}, {"input", types.TimeTZ}},
@@ -2492,7 +2610,8 @@ may increase either contention or retry errors, or both.`,
 			Info: "Extracts `element` from `input`.\n\n" +
 				"Compatible elements: hour, minute, second, millisecond, microsecond, epoch,\n" +
 				"timezone, timezone_hour, timezone_minute",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ExtractStringTimeTZ,
 		},
 	),
 
@@ -2719,8 +2838,9 @@ may increase either contention or retry errors, or both.`,
 				durationDelta := time.Duration(beforeOffsetSecs-afterOffsetSecs) * time.Second
 				return tree.MakeDTimestampTZ(ts.Time.Add(durationDelta), time.Microsecond)
 			},
-			Info:       "Treat given time stamp without time zone as located in the specified time zone.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Treat given time stamp without time zone as located in the specified time zone.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.TimezoneStringTimestamp,
 		},
 		tree.Overload{
 			Types: tree.ArgTypes{
@@ -2740,8 +2860,9 @@ may increase either contention or retry errors, or both.`,
 				}
 				return ts.EvalAtTimeZone(ctx, loc)
 			},
-			Info:       "Convert given time stamp with time zone to the new time zone, with no time zone designation.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Convert given time stamp with time zone to the new time zone, with no time zone designation.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.TimezoneStringTimestampTZ,
 		},
 		tree.Overload{
 			Types: tree.ArgTypes{
@@ -2837,8 +2958,9 @@ may increase either contention or retry errors, or both.`,
 				delimOrNil := stringOrNil(args[1])
 				return stringToArray(str, delimOrNil, nil)
 			},
-			Info:       "Split a string into components on a delimiter.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Split a string into components on a delimiter.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StringToArrayStringString,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"str", types.String}, {"delimiter", types.String}, {"null", types.String}},
@@ -2852,8 +2974,9 @@ may increase either contention or retry errors, or both.`,
 				nullStr := stringOrNil(args[2])
 				return stringToArray(str, delimOrNil, nullStr)
 			},
-			Info:       "Split a string into components on a delimiter with a specified string to consider NULL.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Split a string into components on a delimiter with a specified string to consider NULL.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.StringToArrayStringStringString,
 		},
 	),
 
@@ -2869,8 +2992,9 @@ may increase either contention or retry errors, or both.`,
 				delim := string(tree.MustBeDString(args[1]))
 				return arrayToString(arr, delim, nil)
 			},
-			Info:       "Join an array into a string with a delimiter.",
-			Volatility: tree.VolatilityStable,
+			Info:                  "Join an array into a string with a delimiter.",
+			Volatility:            tree.VolatilityStable,
+			SpecializedVecBuiltin: tree.ArrayToStringString,
 		},
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.AnyArray}, {"delimiter", types.String}, {"null", types.String}},
@@ -2884,8 +3008,9 @@ may increase either contention or retry errors, or both.`,
 				nullStr := stringOrNil(args[2])
 				return arrayToString(arr, delim, nullStr)
 			},
-			Info:       "Join an array into a string with a delimiter, replacing NULLs with a null string.",
-			Volatility: tree.VolatilityStable,
+			Info:                  "Join an array into a string with a delimiter, replacing NULLs with a null string.",
+			Volatility:            tree.VolatilityStable,
+			SpecializedVecBuiltin: tree.ArrayToStringStringString,
 		},
 	),
 
@@ -2901,7 +3026,22 @@ may increase either contention or retry errors, or both.`,
 			Info: "Calculates the length of `input` on the provided `array_dimension`. However, " +
 				"because CockroachDB doesn't yet support multi-dimensional arrays, the only supported" +
 				" `array_dimension` is **1**.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayLength,
+		},
+	),
+
+	"cardinality": makeBuiltin(arrayProps(),
+		tree.Overload{
+			Types:      tree.ArgTypes{{"input", types.AnyArray}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				arr := tree.MustBeDArray(args[0])
+				return cardinality(arr), nil
+			},
+			Info:                  "Calculates the number of elements contained in `input`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.Cardinality,
 		},
 	),
 
@@ -2944,8 +3084,9 @@ may increase either contention or retry errors, or both.`,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return tree.AppendToMaybeNullArray(typ, args[0], args[1])
 			},
-			Info:       "Appends `elem` to `array`, returning the result.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Appends `elem` to `array`, returning the result.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayAppend,
 		}
 	})),
 
@@ -2956,8 +3097,9 @@ may increase either contention or retry errors, or both.`,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return tree.PrependToMaybeNullArray(typ, args[0], args[1])
 			},
-			Info:       "Prepends `elem` to `array`, returning the result.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Prepends `elem` to `array`, returning the result.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayPrepend,
 		}
 	})),
 
@@ -2968,8 +3110,9 @@ may increase either contention or retry errors, or both.`,
 			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 				return tree.ConcatArrays(typ, args[0], args[1])
 			},
-			Info:       "Appends two arrays.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Appends two arrays.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayCat,
 		}
 	})),
 
@@ -2991,8 +3134,9 @@ may increase either contention or retry errors, or both.`,
 				}
 				return result, nil
 			},
-			Info:       "Remove from `array` all elements equal to `elem`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Remove from `array` all elements equal to `elem`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayRemove,
 		}
 	})),
 
@@ -3018,8 +3162,9 @@ may increase either contention or retry errors, or both.`,
 				}
 				return result, nil
 			},
-			Info:       "Replace all occurrences of `toreplace` in `array` with `replacewith`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Replace all occurrences of `toreplace` in `array` with `replacewith`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayReplace,
 		}
 	})),
 
@@ -3038,8 +3183,9 @@ may increase either contention or retry errors, or both.`,
 				}
 				return tree.DNull, nil
 			},
-			Info:       "Return the index of the first occurrence of `elem` in `array`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Return the index of the first occurrence of `elem` in `array`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayPosition,
 		}
 	})),
 
@@ -3061,8 +3207,9 @@ may increase either contention or retry errors, or both.`,
 				}
 				return result, nil
 			},
-			Info:       "Returns and array of indexes of all occurrences of `elem` in `array`.",
-			Volatility: tree.VolatilityImmutable,
+			Info:                  "Returns and array of indexes of all occurrences of `elem` in `array`.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.ArrayPositions,
 		}
 	})),
 
@@ -5242,22 +5389,22 @@ table's zone configuration this will return NULL.`,
 
 var lengthImpls = func(incBitOverload bool) builtinDefinition {
 	b := makeBuiltin(tree.FunctionProperties{Category: categoryString},
-		stringOverload1(
+		setSpecializedVecBuiltin(tree.CharLengthString, stringOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDInt(tree.DInt(utf8.RuneCountInString(s))), nil
 			},
 			types.Int,
 			"Calculates the number of characters in `val`.",
 			tree.VolatilityImmutable,
-		),
-		bytesOverload1(
+		)),
+		setSpecializedVecBuiltin(tree.OctetLengthBytes, bytesOverload1(
 			func(_ *tree.EvalContext, s string) (tree.Datum, error) {
 				return tree.NewDInt(tree.DInt(len(s))), nil
 			},
 			types.Int,
 			"Calculates the number of bytes in `val`.",
 			tree.VolatilityImmutable,
-		),
+		)),
 	)
 	if incBitOverload {
 		b.overloads = append(
@@ -5346,7 +5493,8 @@ var substringImpls = makeBuiltin(tree.FunctionProperties{Category: categoryStrin
 			{"input", types.VarBit},
 			{"start_pos", types.Int},
 		},
-		ReturnType: tree.FixedReturnType(types.VarBit),
+		SpecializedVecBuiltin: tree.SubstringVarBitInt,
+		ReturnType:            tree.FixedReturnType(types.VarBit),
 		Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 			bitString := tree.MustBeDBitArray(args[0])
 			start := int(tree.MustBeDInt(args[1]))
@@ -5362,7 +5510,8 @@ var substringImpls = makeBuiltin(tree.FunctionProperties{Category: categoryStrin
 			{"start_pos", types.Int},
 			{"length", types.Int},
 		},
-		ReturnType: tree.FixedReturnType(types.VarBit),
+		SpecializedVecBuiltin: tree.SubstringVarBitIntInt,
+		ReturnType:            tree.FixedReturnType(types.VarBit),
 		Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 			bitString := tree.MustBeDBitArray(args[0])
 			start := int(tree.MustBeDInt(args[1]))
@@ -5517,8 +5666,9 @@ var generateRandomUUIDImpl = makeBuiltin(
 			uv := uuid.MakeV4()
 			return tree.NewDUuid(tree.DUuid{UUID: uv}), nil
 		},
-		Info:       "Generates a random UUID and returns it as a value of UUID type.",
-		Volatility: tree.VolatilityVolatile,
+		Info:                  "Generates a random UUID and returns it as a value of UUID type.",
+		Volatility:            tree.VolatilityVolatile,
+		SpecializedVecBuiltin: tree.GenRandomUUID,
 	},
 )
 
@@ -5532,8 +5682,9 @@ var uuidV4Impl = makeBuiltin(
 		Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 			return tree.NewDBytes(tree.DBytes(uuid.MakeV4().GetBytes())), nil
 		},
-		Info:       "Returns a UUID.",
-		Volatility: tree.VolatilityVolatile,
+		Info:                  "Returns a UUID.",
+		Volatility:            tree.VolatilityVolatile,
+		SpecializedVecBuiltin: tree.UUIDV4,
 	},
 )
 
@@ -5828,8 +5979,9 @@ var jsonSetImpl = tree.Overload{
 	Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 		return jsonDatumSet(args[0], args[1], args[2], tree.DBoolTrue)
 	},
-	Info:       "Returns the JSON value pointed to by the variadic arguments.",
-	Volatility: tree.VolatilityImmutable,
+	Info:                  "Returns the JSON value pointed to by the variadic arguments.",
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONSet,
 }
 
 var jsonSetWithCreateMissingImpl = tree.Overload{
@@ -5846,7 +5998,8 @@ var jsonSetWithCreateMissingImpl = tree.Overload{
 	Info: "Returns the JSON value pointed to by the variadic arguments. " +
 		"If `create_missing` is false, new keys will not be inserted to objects " +
 		"and values will not be prepended or appended to arrays.",
-	Volatility: tree.VolatilityImmutable,
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONSetWithCreateMissing,
 }
 
 func jsonDatumSet(
@@ -5879,8 +6032,9 @@ var jsonInsertImpl = tree.Overload{
 	Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 		return insertToJSONDatum(args[0], args[1], args[2], tree.DBoolFalse)
 	},
-	Info:       "Returns the JSON value pointed to by the variadic arguments. `new_val` will be inserted before path target.",
-	Volatility: tree.VolatilityImmutable,
+	Info:                  "Returns the JSON value pointed to by the variadic arguments. `new_val` will be inserted before path target.",
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONInsert,
 }
 
 var jsonInsertWithInsertAfterImpl = tree.Overload{
@@ -5896,7 +6050,8 @@ var jsonInsertWithInsertAfterImpl = tree.Overload{
 	},
 	Info: "Returns the JSON value pointed to by the variadic arguments. " +
 		"If `insert_after` is true (default is false), `new_val` will be inserted after path target.",
-	Volatility: tree.VolatilityImmutable,
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONInsertWithInsertAfter,
 }
 
 func insertToJSONDatum(
@@ -5941,8 +6096,9 @@ var jsonTypeOfImpl = tree.Overload{
 		}
 		return nil, errors.AssertionFailedf("unexpected JSON type %d", t)
 	},
-	Info:       "Returns the type of the outermost JSON value as a text string.",
-	Volatility: tree.VolatilityImmutable,
+	Info:                  "Returns the type of the outermost JSON value as a text string.",
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONTypeOf,
 }
 
 func jsonProps() tree.FunctionProperties {
@@ -5958,8 +6114,9 @@ func jsonPropsNullableArgs() tree.FunctionProperties {
 }
 
 var jsonBuildObjectImpl = tree.Overload{
-	Types:      tree.VariadicType{VarType: types.Any},
-	ReturnType: tree.FixedReturnType(types.Jsonb),
+	Types:                 tree.VariadicType{VarType: types.Any},
+	ReturnType:            tree.FixedReturnType(types.Jsonb),
+	SpecializedVecBuiltin: tree.JSONBuildObject,
 	Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 		if len(args)%2 != 0 {
 			return nil, pgerror.New(pgcode.InvalidParameterValue,
@@ -6028,8 +6185,9 @@ var arrayToJSONImpls = makeBuiltin(jsonProps(),
 )
 
 var jsonBuildArrayImpl = tree.Overload{
-	Types:      tree.VariadicType{VarType: types.Any},
-	ReturnType: tree.FixedReturnType(types.Jsonb),
+	Types:                 tree.VariadicType{VarType: types.Any},
+	ReturnType:            tree.FixedReturnType(types.Jsonb),
+	SpecializedVecBuiltin: tree.JSONBuildArray,
 	Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
 		builder := json.NewArrayBuilder(len(args))
 		for _, arg := range args {
@@ -6117,8 +6275,9 @@ var jsonStripNullsImpl = tree.Overload{
 		j, _, err := tree.MustBeDJSON(args[0]).StripNulls()
 		return tree.NewDJSON(j), err
 	},
-	Info:       "Returns from_json with all object fields that have null values omitted. Other null values are untouched.",
-	Volatility: tree.VolatilityImmutable,
+	Info:                  "Returns from_json with all object fields that have null values omitted. Other null values are untouched.",
+	Volatility:            tree.VolatilityImmutable,
+	SpecializedVecBuiltin: tree.JSONStripNulls,
 }
 
 var jsonArrayLengthImpl = tree.Overload{
@@ -6159,6 +6318,13 @@ func setProps(props tree.FunctionProperties, d builtinDefinition) builtinDefinit
 	return d
 }
 
+func setSpecializedVecBuiltin(
+	b tree.SpecializedVectorizedBuiltin, o tree.Overload,
+) tree.Overload {
+	o.SpecializedVecBuiltin = b
+	return o
+}
+
 func jsonOverload1(
 	f func(*tree.EvalContext, json.JSON) (tree.Datum, error),
 	returnType *types.T,
@@ -6323,7 +6489,9 @@ func feedHash(h hash.Hash, args tree.Datums) (bool, error) {
 	return nonNullSeen, nil
 }
 
-func hashBuiltin(newHash func() hash.Hash, info string) builtinDefinition {
+func hashBuiltin(
+	newHash func() hash.Hash, info string, specializedVecBuiltin tree.SpecializedVectorizedBuiltin,
+) builtinDefinition {
 	return makeBuiltin(tree.FunctionProperties{NullableArgs: true},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.String},
@@ -6335,8 +6503,9 @@ func hashBuiltin(newHash func() hash.Hash, info string) builtinDefinition {
 				}
 				return tree.NewDString(fmt.Sprintf("%x", h.Sum(nil))), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.Bytes},
@@ -6348,13 +6517,16 @@ func hashBuiltin(newHash func() hash.Hash, info string) builtinDefinition {
 				}
 				return tree.NewDString(fmt.Sprintf("%x", h.Sum(nil))), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 	)
 }
 
-func hash32Builtin(newHash func() hash.Hash32, info string) builtinDefinition {
+func hash32Builtin(
+	newHash func() hash.Hash32, info string, specializedVecBuiltin tree.SpecializedVectorizedBuiltin,
+) builtinDefinition {
 	return makeBuiltin(tree.FunctionProperties{NullableArgs: true},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.String},
@@ -6366,8 +6538,9 @@ func hash32Builtin(newHash func() hash.Hash32, info string) builtinDefinition {
 				}
 				return tree.NewDInt(tree.DInt(h.Sum32())), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.Bytes},
@@ -6379,13 +6552,16 @@ func hash32Builtin(newHash func() hash.Hash32, info string) builtinDefinition {
 				}
 				return tree.NewDInt(tree.DInt(h.Sum32())), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 	)
 }
 
-func hash64Builtin(newHash func() hash.Hash64, info string) builtinDefinition {
+func hash64Builtin(
+	newHash func() hash.Hash64, info string, specializedVecBuiltin tree.SpecializedVectorizedBuiltin,
+) builtinDefinition {
 	return makeBuiltin(tree.FunctionProperties{NullableArgs: true},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.String},
@@ -6397,8 +6573,9 @@ func hash64Builtin(newHash func() hash.Hash64, info string) builtinDefinition {
 				}
 				return tree.NewDInt(tree.DInt(h.Sum64())), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 		tree.Overload{
 			Types:      tree.VariadicType{VarType: types.Bytes},
@@ -6410,8 +6587,9 @@ func hash64Builtin(newHash func() hash.Hash64, info string) builtinDefinition {
 				}
 				return tree.NewDInt(tree.DInt(h.Sum64())), nil
 			},
-			Info:       info,
-			Volatility: tree.VolatilityLeakProof,
+			Info:                  info,
+			Volatility:            tree.VolatilityLeakProof,
+			SpecializedVecBuiltin: specializedVecBuiltin,
 		},
 	)
 }
@@ -6465,13 +6643,20 @@ func regexpSplit(ctx *tree.EvalContext, args tree.Datums, hasFlags bool) ([]stri
 	if hasFlags {
 		sqlFlags = string(tree.MustBeDString(args[2]))
 	}
-	patternRe, err := ctx.ReCache.GetRegexp(regexpFlagKey{pattern, sqlFlags})
+	patternRe, err := GetRegexpWithFlags(ctx, pattern, sqlFlags)
 	if err != nil {
 		return nil, err
 	}
 	return patternRe.Split(s, -1), nil
 }
 
+// GetRegexpWithFlags returns the compiled regular expression for the pattern
+// adjusted according to the provided Postgres regexp flags. The result is
+// cached in the regexp cache of ctx.
+func GetRegexpWithFlags(ctx *tree.EvalContext, pattern, sqlFlags string) (*regexp.Regexp, error) {
+	return ctx.ReCache.GetRegexp(regexpFlagKey{pattern, sqlFlags})
+}
+
 func regexpSplitToArray(
 	ctx *tree.EvalContext, args tree.Datums, hasFlags bool,
 ) (tree.Datum, error) {
@@ -6713,6 +6898,21 @@ func arrayLength(arr *tree.DArray, dim int64) tree.Datum {
 	return arrayLength(a, dim-1)
 }
 
+// cardinality returns the total number of elements in arr. Unlike
+// arrayLength, it returns zero for an empty array.
+func cardinality(arr *tree.DArray) tree.Datum {
+	if arr.ParamTyp.Family() != types.ArrayFamily {
+		return tree.NewDInt(tree.DInt(arr.Len()))
+	}
+	var n tree.DInt
+	for _, e := range arr.Array {
+		if a, ok := tree.AsDArray(e); ok {
+			n += tree.MustBeDInt(cardinality(a))
+		}
+	}
+	return tree.NewDInt(n)
+}
+
 var intOne = tree.NewDInt(tree.DInt(1))
 
 func arrayLower(arr *tree.DArray, dim int64) tree.Datum {
@@ -7018,9 +7218,9 @@ func stringToArray(str string, delimPtr *string, nullStr *string) (tree.Datum, e
 		}
 	} else {
 		// When given a NULL delimiter, string_to_array splits into each character.
-		split = make([]string, len(str))
-		for i, c := range str {
-			split[i] = string(c)
+		split = make([]string, 0, utf8.RuneCountInString(str))
+		for _, c := range str {
+			split = append(split, string(c))
 		}
 	}
 
@@ -13,6 +13,7 @@ package builtins
 import (
 	"bytes"
 	"context"
+	"regexp"
 	"strings"
 	"time"
 
@@ -173,6 +174,36 @@ var generators = map[string]builtinDefinition{
 		),
 	),
 
+	"regexp_matches": makeBuiltin(
+		genProps(),
+		// See https://www.postgresql.org/docs/current/functions-matching.html
+		withSpecializedVecBuiltin(makeGeneratorOverload(
+			tree.ArgTypes{
+				{"string", types.String},
+				{"pattern", types.String},
+			},
+			types.StringArray,
+			makeRegexpMatchesGeneratorFactory(false /* hasFlags */),
+			"Returns the substrings captured by the first match of the POSIX regular expression "+
+				"`pattern` in `string` as an array. If the pattern has no capture groups, the array "+
+				"contains the whole match. No rows are returned if there is no match.",
+			tree.VolatilityImmutable,
+		), tree.RegexpMatchesStringString),
+		withSpecializedVecBuiltin(makeGeneratorOverload(
+			tree.ArgTypes{
+				{"string", types.String},
+				{"pattern", types.String},
+				{"flags", types.String},
+			},
+			types.StringArray,
+			makeRegexpMatchesGeneratorFactory(true /* hasFlags */),
+			"Returns the substrings captured by the matches of the POSIX regular expression "+
+				"`pattern` in `string` using `flags`, one array per match. Without the `g` flag, "+
+				"only the first match is returned."+regexpFlagInfo,
+			tree.VolatilityImmutable,
+		), tree.RegexpMatchesStringStringString),
+	),
+
 	"regexp_split_to_table": makeBuiltin(
 		genProps(),
 		makeGeneratorOverload(
@@ -198,9 +229,33 @@ var generators = map[string]builtinDefinition{
 		),
 	),
 
+	"string_to_table": makeBuiltin(
+		tree.FunctionProperties{
+			Class:    tree.GeneratorClass,
+			Category: categoryGenerator,
+			// The NULL delimiter splits the string into characters.
+			NullableArgs: true,
+		},
+		makeGeneratorOverload(
+			tree.ArgTypes{{"str", types.String}, {"delimiter", types.String}},
+			types.String,
+			makeStringToTableGenerator,
+			"Split a string into components on a delimiter and return them as a set of rows.",
+			tree.VolatilityImmutable,
+		),
+		makeGeneratorOverload(
+			tree.ArgTypes{{"str", types.String}, {"delimiter", types.String}, {"null", types.String}},
+			types.String,
+			makeStringToTableGenerator,
+			"Split a string into components on a delimiter with a specified string to consider "+
+				"NULL and return them as a set of rows.",
+			tree.VolatilityImmutable,
+		),
+	),
+
 	"unnest": makeBuiltin(genProps(),
 		// See https://www.postgresql.org/docs/current/static/functions-array.html
-		makeGeneratorOverloadWithReturnType(
+		withSpecializedVecBuiltin(makeGeneratorOverloadWithReturnType(
 			tree.ArgTypes{{"input", types.AnyArray}},
 			func(args []tree.TypedExpr) *types.T {
 				if len(args) == 0 || args[0].ResolvedType().Family() == types.UnknownFamily {
@@ -211,7 +266,7 @@ var generators = map[string]builtinDefinition{
 			makeArrayGenerator,
 			"Returns the input array as a set of rows",
 			tree.VolatilityImmutable,
-		),
+		), tree.Unnest),
 		makeGeneratorOverloadWithReturnType(
 			tree.VariadicType{
 				FixedTypes: []*types.T{types.AnyArray, types.AnyArray},
@@ -238,7 +293,7 @@ var generators = map[string]builtinDefinition{
 	),
 
 	"information_schema._pg_expandarray": makeBuiltin(genProps(),
-		makeGeneratorOverloadWithReturnType(
+		withSpecializedVecBuiltin(makeGeneratorOverloadWithReturnType(
 			tree.ArgTypes{{"input", types.AnyArray}},
 			func(args []tree.TypedExpr) *types.T {
 				if len(args) == 0 || args[0].ResolvedType().Family() == types.UnknownFamily {
@@ -250,7 +305,7 @@ var generators = map[string]builtinDefinition{
 			makeExpandArrayGenerator,
 			"Returns the input array as a set of rows with an index",
 			tree.VolatilityImmutable,
-		),
+		), tree.PGExpandArray),
 	),
 
 	"crdb_internal.unary_table": makeBuiltin(genProps(),
@@ -266,28 +321,28 @@ var generators = map[string]builtinDefinition{
 
 	"generate_subscripts": makeBuiltin(genProps(),
 		// See https://www.postgresql.org/docs/current/static/functions-srf.html#FUNCTIONS-SRF-SUBSCRIPTS
-		makeGeneratorOverload(
+		withSpecializedVecBuiltin(makeGeneratorOverload(
 			tree.ArgTypes{{"array", types.AnyArray}},
 			subscriptsValueGeneratorType,
 			makeGenerateSubscriptsGenerator,
 			"Returns a series comprising the given array's subscripts.",
 			tree.VolatilityImmutable,
-		),
-		makeGeneratorOverload(
+		), tree.GenerateSubscripts),
+		withSpecializedVecBuiltin(makeGeneratorOverload(
 			tree.ArgTypes{{"array", types.AnyArray}, {"dim", types.Int}},
 			subscriptsValueGeneratorType,
 			makeGenerateSubscriptsGenerator,
 			"Returns a series comprising the given array's subscripts.",
 			tree.VolatilityImmutable,
-		),
-		makeGeneratorOverload(
+		), tree.GenerateSubscripts),
+		withSpecializedVecBuiltin(makeGeneratorOverload(
 			tree.ArgTypes{{"array", types.AnyArray}, {"dim", types.Int}, {"reverse", types.Bool}},
 			subscriptsValueGeneratorType,
 			makeGenerateSubscriptsGenerator,
 			"Returns a series comprising the given array's subscripts.\n\n"+
 				"When reverse is true, the series is returned in reverse order.",
 			tree.VolatilityImmutable,
-		),
+		), tree.GenerateSubscripts),
 	),
 
 	"json_array_elements":       makeBuiltin(genPropsWithLabels(jsonArrayGeneratorLabels), jsonArrayElementsImpl),
@@ -424,6 +479,13 @@ func makeGeneratorOverloadWithReturnType(
 	}
 }
 
+// withSpecializedVecBuiltin returns the overload o that is marked to have the
+// specialized vectorized operator b.
+func withSpecializedVecBuiltin(o tree.Overload, b tree.SpecializedVectorizedBuiltin) tree.Overload {
+	o.SpecializedVecBuiltin = b
+	return o
+}
+
 // regexpSplitToTableGenerator supports regexp_split_to_table.
 type regexpSplitToTableGenerator struct {
 	words []string
@@ -468,6 +530,110 @@ func (g *regexpSplitToTableGenerator) Values() (tree.Datums, error) {
 	return tree.Datums{tree.NewDString(g.words[g.curr])}, nil
 }
 
+// regexpMatchesGenerator supports regexp_matches.
+type regexpMatchesGenerator struct {
+	s       string
+	matches [][]int
+	curr    int
+}
+
+func makeRegexpMatchesGeneratorFactory(hasFlags bool) tree.GeneratorFactory {
+	return func(
+		ctx *tree.EvalContext, args tree.Datums,
+	) (tree.ValueGenerator, error) {
+		s := string(tree.MustBeDString(args[0]))
+		pattern := string(tree.MustBeDString(args[1]))
+		sqlFlags := ""
+		if hasFlags {
+			sqlFlags = string(tree.MustBeDString(args[2]))
+		}
+		patternRe, err := GetRegexpWithFlags(ctx, pattern, sqlFlags)
+		if err != nil {
+			return nil, err
+		}
+		return &regexpMatchesGenerator{
+			s:       s,
+			matches: RegexpMatches(patternRe, s, sqlFlags),
+			curr:    -1,
+		}, nil
+	}
+}
+
+// ResolvedType implements the tree.ValueGenerator interface.
+func (*regexpMatchesGenerator) ResolvedType() *types.T { return types.StringArray }
+
+// Close implements the tree.ValueGenerator interface.
+func (*regexpMatchesGenerator) Close(_ context.Context) {}
+
+// Start implements the tree.ValueGenerator interface.
+func (g *regexpMatchesGenerator) Start(_ context.Context, _ *kv.Txn) error {
+	g.curr = -1
+	return nil
+}
+
+// Next implements the tree.ValueGenerator interface.
+func (g *regexpMatchesGenerator) Next(_ context.Context) (bool, error) {
+	g.curr++
+	return g.curr < len(g.matches), nil
+}
+
+// Values implements the tree.ValueGenerator interface.
+func (g *regexpMatchesGenerator) Values() (tree.Datums, error) {
+	arr, err := RegexpMatchToArray(g.s, g.matches[g.curr])
+	if err != nil {
+		return nil, err
+	}
+	return tree.Datums{arr}, nil
+}
+
+// RegexpMatches returns the positions of the matches of patternRe in s (in
+// the format of regexp.FindAllStringSubmatchIndex) for which regexp_matches
+// produces the rows. All matches are returned if sqlFlags contain the global
+// flag 'g', and only the first one otherwise.
+func RegexpMatches(patternRe *regexp.Regexp, s string, sqlFlags string) [][]int {
+	n := 1
+	if strings.ContainsRune(sqlFlags, 'g') {
+		n = -1
+	}
+	return patternRe.FindAllStringSubmatchIndex(s, n)
+}
+
+// RegexpMatchToArray returns the array that regexp_matches produces for the
+// match of a regular expression in s at positions match. The array contains
+// the captured substrings (NULL for the groups that didn't participate in the
+// match), or the whole match if the regular expression has no capture groups.
+func RegexpMatchToArray(s string, match []int) (*tree.DArray, error) {
+	result := tree.NewDArray(types.String)
+	if len(match) == 2 {
+		return result, result.Append(tree.NewDString(s[match[0]:match[1]]))
+	}
+	for i := 2; i < len(match); i += 2 {
+		var elem tree.Datum = tree.DNull
+		if match[i] >= 0 {
+			elem = tree.NewDString(s[match[i]:match[i+1]])
+		}
+		if err := result.Append(elem); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func makeStringToTableGenerator(_ *tree.EvalContext, args tree.Datums) (tree.ValueGenerator, error) {
+	if args[0] == tree.DNull {
+		return &arrayValueGenerator{array: tree.NewDArray(types.String)}, nil
+	}
+	var nullStr *string
+	if len(args) > 2 {
+		nullStr = stringOrNil(args[2])
+	}
+	arr, err := stringToArray(string(tree.MustBeDString(args[0])), stringOrNil(args[1]), nullStr)
+	if err != nil {
+		return nil, err
+	}
+	return &arrayValueGenerator{array: tree.MustBeDArray(arr)}, nil
+}
+
 // keywordsValueGenerator supports the execution of pg_get_keywords().
 type keywordsValueGenerator struct {
 	curKeyword int
@@ -968,21 +1134,21 @@ var (
 	errJSONDeconstructScalarAsObject = pgerror.Newf(pgcode.InvalidParameterValue, "cannot deconstruct a scalar")
 )
 
-var jsonArrayElementsImpl = makeGeneratorOverload(
+var jsonArrayElementsImpl = withSpecializedVecBuiltin(makeGeneratorOverload(
 	tree.ArgTypes{{"input", types.Jsonb}},
 	jsonArrayGeneratorType,
 	makeJSONArrayAsJSONGenerator,
 	"Expands a JSON array to a set of JSON values.",
 	tree.VolatilityImmutable,
-)
+), tree.JSONArrayElements)
 
-var jsonArrayElementsTextImpl = makeGeneratorOverload(
+var jsonArrayElementsTextImpl = withSpecializedVecBuiltin(makeGeneratorOverload(
 	tree.ArgTypes{{"input", types.Jsonb}},
 	jsonArrayTextGeneratorType,
 	makeJSONArrayAsTextGenerator,
 	"Expands a JSON array to a set of text values.",
 	tree.VolatilityImmutable,
-)
+), tree.JSONArrayElementsText)
 
 var jsonArrayGeneratorLabels = []string{"value"}
 var jsonArrayGeneratorType = types.Jsonb
@@ -1064,13 +1230,13 @@ func (g *jsonArrayGenerator) Values() (tree.Datums, error) {
 }
 
 // jsonObjectKeysImpl is a key generator of a JSON object.
-var jsonObjectKeysImpl = makeGeneratorOverload(
+var jsonObjectKeysImpl = withSpecializedVecBuiltin(makeGeneratorOverload(
 	tree.ArgTypes{{"input", types.Jsonb}},
 	jsonObjectKeysGeneratorType,
 	makeJSONObjectKeysGenerator,
 	"Returns sorted set of keys in the outermost JSON object.",
 	tree.VolatilityImmutable,
-)
+), tree.JSONObjectKeys)
 
 var jsonObjectKeysGeneratorType = types.String
 
@@ -1120,22 +1286,22 @@ func (g *jsonObjectKeysGenerator) Values() (tree.Datums, error) {
 	return tree.Datums{tree.NewDString(g.iter.Key())}, nil
 }
 
-var jsonEachImpl = makeGeneratorOverload(
+var jsonEachImpl = withSpecializedVecBuiltin(makeGeneratorOverload(
 	tree.ArgTypes{{"input", types.Jsonb}},
 	jsonEachGeneratorType,
 	makeJSONEachImplGenerator,
 	"Expands the outermost JSON or JSONB object into a set of key/value pairs.",
 	tree.VolatilityImmutable,
-)
+), tree.JSONEach)
 
-var jsonEachTextImpl = makeGeneratorOverload(
+var jsonEachTextImpl = withSpecializedVecBuiltin(makeGeneratorOverload(
 	tree.ArgTypes{{"input", types.Jsonb}},
 	jsonEachTextGeneratorType,
 	makeJSONEachTextImplGenerator,
 	"Expands the outermost JSON or JSONB object into a set of key/value pairs. "+
 		"The returned values will be of type text.",
 	tree.VolatilityImmutable,
-)
+), tree.JSONEachText)
 
 var jsonEachGeneratorLabels = []string{"key", "value"}
 
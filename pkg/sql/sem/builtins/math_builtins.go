@@ -19,6 +19,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/arith"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/errors"
 )
@@ -34,9 +35,7 @@ func initMathBuiltins() {
 }
 
 var (
-	errAbsOfMinInt64  = pgerror.New(pgcode.NumericValueOutOfRange, "abs of min integer value (-9223372036854775808) not defined")
-	errLogOfNegNumber = pgerror.New(pgcode.InvalidArgumentForLogarithm, "cannot take logarithm of a negative number")
-	errLogOfZero      = pgerror.New(pgcode.InvalidArgumentForLogarithm, "cannot take logarithm of zero")
+	errAbsOfMinInt64 = pgerror.New(pgcode.NumericValueOutOfRange, "abs of min integer value (-9223372036854775808) not defined")
 )
 
 const (
@@ -52,11 +51,11 @@ var mathBuiltins = map[string]builtinDefinition{
 		floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Abs(x))), nil
 		}, "Calculates the absolute value of `val`.", tree.VolatilityImmutable),
-		decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.AbsDecimal, decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 			dd := &tree.DDecimal{}
 			dd.Abs(x)
 			return dd, nil
-		}, "Calculates the absolute value of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the absolute value of `val`.", tree.VolatilityImmutable)),
 		tree.Overload{
 			Types:      tree.ArgTypes{{"val", types.Int}},
 			ReturnType: tree.FixedReturnType(types.Int),
@@ -76,9 +75,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"acos": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.AcosFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Acos(x))), nil
-		}, "Calculates the inverse cosine of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the inverse cosine of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"acosd": makeBuiltin(defProps(),
@@ -94,9 +93,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"asin": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.AsinFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Asin(x))), nil
-		}, "Calculates the inverse sine of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the inverse sine of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"asind": makeBuiltin(defProps(),
@@ -112,9 +111,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"atan": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.AtanFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Atan(x))), nil
-		}, "Calculates the inverse tangent of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the inverse tangent of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"atand": makeBuiltin(defProps(),
@@ -130,9 +129,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"atan2": makeBuiltin(defProps(),
-		floatOverload2("x", "y", func(x, y float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.Atan2FloatFloat, floatOverload2("x", "y", func(x, y float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Atan2(x, y))), nil
-		}, "Calculates the inverse tangent of `x`/`y`.", tree.VolatilityImmutable),
+		}, "Calculates the inverse tangent of `x`/`y`.", tree.VolatilityImmutable)),
 	),
 
 	"atan2d": makeBuiltin(defProps(),
@@ -154,9 +153,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	"ceiling": ceilImpl,
 
 	"cos": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.CosFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Cos(x))), nil
-		}, "Calculates the cosine of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the cosine of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"cosd": makeBuiltin(defProps(),
@@ -218,9 +217,9 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"exp": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.ExpFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Exp(x))), nil
-		}, "Calculates *e* ^ `val`.", tree.VolatilityImmutable),
+		}, "Calculates *e* ^ `val`.", tree.VolatilityImmutable)),
 		decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 			dd := &tree.DDecimal{}
 			_, err := tree.DecimalCtx.Exp(&dd.Decimal, x)
@@ -248,6 +247,25 @@ var mathBuiltins = map[string]builtinDefinition{
 		},
 	),
 
+	"gcd": makeBuiltin(defProps(),
+		tree.Overload{
+			Types:      tree.ArgTypes{{"x", types.Int}, {"y", types.Int}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				x, y := tree.MustBeDInt(args[0]), tree.MustBeDInt(args[1])
+				r, ok := arith.GCDWithOverflow(int64(x), int64(y))
+				if !ok {
+					return nil, tree.ErrIntOutOfRange
+				}
+				return tree.NewDInt(tree.DInt(r)), nil
+			},
+			Info: "Calculates the greatest common divisor of `x` and `y`. The result " +
+				"is never negative, and it is zero if both `x` and `y` are zero.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.GCDIntInt,
+		},
+	),
+
 	"isnan": makeBuiltin(defProps(),
 		tree.Overload{
 			// Can't use floatBuiltin1 here because this one returns
@@ -272,45 +290,64 @@ var mathBuiltins = map[string]builtinDefinition{
 		},
 	),
 
+	"lcm": makeBuiltin(defProps(),
+		tree.Overload{
+			Types:      tree.ArgTypes{{"x", types.Int}, {"y", types.Int}},
+			ReturnType: tree.FixedReturnType(types.Int),
+			Fn: func(_ *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				x, y := tree.MustBeDInt(args[0]), tree.MustBeDInt(args[1])
+				r, ok := arith.LCMWithOverflow(int64(x), int64(y))
+				if !ok {
+					return nil, tree.ErrIntOutOfRange
+				}
+				return tree.NewDInt(tree.DInt(r)), nil
+			},
+			Info: "Calculates the least common multiple of `x` and `y`. The result " +
+				"is never negative, and it is zero if either `x` or `y` is zero.",
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.LCMIntInt,
+		},
+	),
+
 	"ln": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.LnFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Log(x))), nil
-		}, "Calculates the natural log of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the natural log of `val`.", tree.VolatilityImmutable)),
 		decimalLogFn(tree.DecimalCtx.Ln, "Calculates the natural log of `val`.", tree.VolatilityImmutable),
 	),
 
 	"log": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.LogFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Log10(x))), nil
-		}, "Calculates the base 10 log of `val`.", tree.VolatilityImmutable),
-		floatOverload2("b", "x", func(b, x float64) (tree.Datum, error) {
+		}, "Calculates the base 10 log of `val`.", tree.VolatilityImmutable)),
+		setSpecializedVecBuiltin(tree.LogFloatFloat, floatOverload2("b", "x", func(b, x float64) (tree.Datum, error) {
 			switch {
 			case x < 0.0:
-				return nil, errLogOfNegNumber
+				return nil, tree.ErrLogOfNegNumber
 			case x == 0.0:
-				return nil, errLogOfZero
+				return nil, tree.ErrLogOfZero
 			}
 			switch {
 			case b < 0.0:
-				return nil, errLogOfNegNumber
+				return nil, tree.ErrLogOfNegNumber
 			case b == 0.0:
-				return nil, errLogOfZero
+				return nil, tree.ErrLogOfZero
 			}
 			return tree.NewDFloat(tree.DFloat(math.Log10(x) / math.Log10(b))), nil
-		}, "Calculates the base `b` log of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the base `b` log of `val`.", tree.VolatilityImmutable)),
 		decimalLogFn(tree.DecimalCtx.Log10, "Calculates the base 10 log of `val`.", tree.VolatilityImmutable),
 		decimalOverload2("b", "x", func(b, x *apd.Decimal) (tree.Datum, error) {
 			switch x.Sign() {
 			case -1:
-				return nil, errLogOfNegNumber
+				return nil, tree.ErrLogOfNegNumber
 			case 0:
-				return nil, errLogOfZero
+				return nil, tree.ErrLogOfZero
 			}
 			switch b.Sign() {
 			case -1:
-				return nil, errLogOfNegNumber
+				return nil, tree.ErrLogOfNegNumber
 			case 0:
-				return nil, errLogOfZero
+				return nil, tree.ErrLogOfZero
 			}
 
 			top := new(apd.Decimal)
@@ -332,14 +369,14 @@ var mathBuiltins = map[string]builtinDefinition{
 		floatOverload2("x", "y", func(x, y float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Mod(x, y))), nil
 		}, "Calculates `x`%`y`.", tree.VolatilityImmutable),
-		decimalOverload2("x", "y", func(x, y *apd.Decimal) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.ModDecimalDecimal, decimalOverload2("x", "y", func(x, y *apd.Decimal) (tree.Datum, error) {
 			if y.Sign() == 0 {
 				return nil, tree.ErrDivByZero
 			}
 			dd := &tree.DDecimal{}
 			_, err := tree.HighPrecisionCtx.Rem(&dd.Decimal, x, y)
 			return dd, err
-		}, "Calculates `x`%`y`.", tree.VolatilityImmutable),
+		}, "Calculates `x`%`y`.", tree.VolatilityImmutable)),
 		tree.Overload{
 			Types:      tree.ArgTypes{{"x", types.Int}, {"y", types.Int}},
 			ReturnType: tree.FixedReturnType(types.Int),
@@ -381,10 +418,10 @@ var mathBuiltins = map[string]builtinDefinition{
 		floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.RoundToEven(x))), nil
 		}, "Rounds `val` to the nearest integer using half to even (banker's) rounding.", tree.VolatilityImmutable),
-		decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.RoundDecimal, decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 			return roundDecimal(x, 0)
 		}, "Rounds `val` to the nearest integer, half away from zero: "+
-			"round(+/-2.4) = +/-2, round(+/-2.5) = +/-3.", tree.VolatilityImmutable),
+			"round(+/-2.4) = +/-2, round(+/-2.5) = +/-3.", tree.VolatilityImmutable)),
 		tree.Overload{
 			Types:      tree.ArgTypes{{"input", types.Float}, {"decimal_accuracy", types.Int}},
 			ReturnType: tree.FixedReturnType(types.Float),
@@ -428,14 +465,15 @@ var mathBuiltins = map[string]builtinDefinition{
 			Info: "Keeps `decimal_accuracy` number of figures to the right of the zero position " +
 				"in `input` using half away from zero rounding. If `decimal_accuracy` " +
 				"is not in the range -2^31...(2^31-1), the results are undefined.",
-			Volatility: tree.VolatilityImmutable,
+			Volatility:            tree.VolatilityImmutable,
+			SpecializedVecBuiltin: tree.RoundDecimalInt,
 		},
 	),
 
 	"sin": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.SinFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Sin(x))), nil
-		}, "Calculates the sine of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the sine of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"sind": makeBuiltin(defProps(),
@@ -487,18 +525,18 @@ var mathBuiltins = map[string]builtinDefinition{
 	),
 
 	"sqrt": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.SqrtFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.Sqrt(x)
-		}, "Calculates the square root of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the square root of `val`.", tree.VolatilityImmutable)),
 		decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 			return tree.DecimalSqrt(x)
 		}, "Calculates the square root of `val`.", tree.VolatilityImmutable),
 	),
 
 	"tan": makeBuiltin(defProps(),
-		floatOverload1(func(x float64) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.TanFloat, floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Tan(x))), nil
-		}, "Calculates the tangent of `val`.", tree.VolatilityImmutable),
+		}, "Calculates the tangent of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"tand": makeBuiltin(defProps(),
@@ -517,11 +555,11 @@ var mathBuiltins = map[string]builtinDefinition{
 		floatOverload1(func(x float64) (tree.Datum, error) {
 			return tree.NewDFloat(tree.DFloat(math.Trunc(x))), nil
 		}, "Truncates the decimal values of `val`.", tree.VolatilityImmutable),
-		decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
+		setSpecializedVecBuiltin(tree.TruncDecimal, decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 			dd := &tree.DDecimal{}
 			x.Modf(&dd.Decimal, nil)
 			return dd, nil
-		}, "Truncates the decimal values of `val`.", tree.VolatilityImmutable),
+		}, "Truncates the decimal values of `val`.", tree.VolatilityImmutable)),
 	),
 
 	"width_bucket": makeBuiltin(defProps(),
@@ -636,9 +674,9 @@ func decimalLogFn(
 	return decimalOverload1(func(x *apd.Decimal) (tree.Datum, error) {
 		switch x.Sign() {
 		case -1:
-			return nil, errLogOfNegNumber
+			return nil, tree.ErrLogOfNegNumber
 		case 0:
-			return nil, errLogOfZero
+			return nil, tree.ErrLogOfZero
 		}
 		dd := &tree.DDecimal{}
 		_, err := logFn(&dd.Decimal, x)
@@ -69,10 +69,7 @@ func (sw *slidingWindow) add(iv *indexedValue) {
 // indices smaller than given 'idx'. This operation corresponds to shifting the
 // start of the frame up to 'idx'.
 func (sw *slidingWindow) removeAllBefore(idx int) {
-	for i := 0; i < sw.values.Len() && i < idx; i++ {
-		if sw.values.Get(i).(*indexedValue).idx >= idx {
-			break
-		}
+	for sw.values.Len() > 0 && sw.values.Get(0).(*indexedValue).idx < idx {
 		sw.values.RemoveFirst()
 	}
 }
@@ -254,3 +254,24 @@ func TestSlidingWindow(t *testing.T) {
 		testSlidingWindow(t, count)
 	}
 }
+
+// TestSlidingWindowRemoveAllBefore verifies that all values that are no longer
+// in the frame are removed when the start of the frame moves forward by
+// several rows at once (which is the case with RANGE mode and peers).
+func TestSlidingWindowRemoveAllBefore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	sw := makeSlidingWindow(evalCtx, func(evalCtx *tree.EvalContext, a, b tree.Datum) int {
+		return -a.Compare(evalCtx, b)
+	})
+	// With the increasing values, none of them is ever removed from the end of
+	// the deque by the min sliding window.
+	for idx := 0; idx < 4; idx++ {
+		sw.add(&indexedValue{value: tree.NewDInt(tree.DInt(idx)), idx: idx})
+	}
+	sw.removeAllBefore(3)
+	if sw.values.Len() != 1 || sw.values.Get(0).(*indexedValue).idx != 3 {
+		t.Fatalf("unexpected sliding window after removing all values before 3:\n%s", sw.string())
+	}
+}
@@ -2444,6 +2444,98 @@ func MustMakeDTimestampTZ(t time.Time, precision time.Duration) *DTimestampTZ {
 	return ret
 }
 
+var (
+	errTimestampNaN = pgerror.New(pgcode.DatetimeFieldOverflow, "timestamp cannot be NaN")
+
+	minSupportedEpochSecs = MinSupportedTime.Unix()
+	maxSupportedEpochSecs = MaxSupportedTime.Unix()
+	minSupportedEpochDec  = apd.New(minSupportedEpochSecs-1, 0)
+	maxSupportedEpochDec  = apd.New(maxSupportedEpochSecs+1, 0)
+)
+
+// TimeFromEpochFloat returns the time that is secs seconds after the Unix
+// epoch the same way as to_timestamp() in Postgres: the fractional seconds are
+// rounded to microseconds (with ties to even), and the infinities result in
+// the infinite timestamps. An error is returned for NaN and for the times that
+// exceed the supported timestamp bounds.
+func TimeFromEpochFloat(secs float64) (time.Time, error) {
+	switch {
+	case math.IsNaN(secs):
+		return time.Time{}, errTimestampNaN
+	case math.IsInf(secs, 1):
+		return pgdate.TimeInfinity, nil
+	case math.IsInf(secs, -1):
+		return pgdate.TimeNegativeInfinity, nil
+	}
+	// Check the bounds loosely first so that the conversion to integers below
+	// cannot overflow.
+	if secs >= float64(minSupportedEpochSecs-1) && secs <= float64(maxSupportedEpochSecs+1) {
+		whole := math.Floor(secs)
+		micros := math.RoundToEven((secs - whole) * 1e6)
+		if t, ok := timeFromEpoch(int64(whole), int64(micros)); ok {
+			return t, nil
+		}
+	}
+	return time.Time{}, pgerror.Newf(
+		pgcode.DatetimeFieldOverflow, "timestamp out of range: %q", strconv.FormatFloat(secs, 'g', -1, 64),
+	)
+}
+
+// TimeFromEpochDecimal is the same as TimeFromEpochFloat but for the decimal
+// number of seconds. The fractional seconds are rounded to microseconds
+// exactly.
+func TimeFromEpochDecimal(secs *apd.Decimal) (time.Time, error) {
+	switch secs.Form {
+	case apd.NaN, apd.NaNSignaling:
+		return time.Time{}, errTimestampNaN
+	case apd.Infinite:
+		if secs.Negative {
+			return pgdate.TimeNegativeInfinity, nil
+		}
+		return pgdate.TimeInfinity, nil
+	}
+	// Check the bounds loosely first so that the conversion to integers below
+	// cannot overflow.
+	if secs.Cmp(minSupportedEpochDec) >= 0 && secs.Cmp(maxSupportedEpochDec) <= 0 {
+		var whole, frac apd.Decimal
+		if _, err := ExactCtx.Floor(&whole, secs); err != nil {
+			return time.Time{}, err
+		}
+		if _, err := ExactCtx.Sub(&frac, secs, &whole); err != nil {
+			return time.Time{}, err
+		}
+		frac.Exponent += 6
+		if _, err := RoundCtx.RoundToIntegralValue(&frac, &frac); err != nil {
+			return time.Time{}, err
+		}
+		wholeSecs, err := whole.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		micros, err := frac.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t, ok := timeFromEpoch(wholeSecs, micros); ok {
+			return t, nil
+		}
+	}
+	return time.Time{}, pgerror.Newf(
+		pgcode.DatetimeFieldOverflow, "timestamp out of range: %q", secs.String(),
+	)
+}
+
+// timeFromEpoch returns the time that is secs seconds and micros microseconds
+// after the Unix epoch. false is returned if the time exceeds the supported
+// timestamp bounds.
+func timeFromEpoch(secs, micros int64) (time.Time, bool) {
+	t := timeutil.Unix(secs, micros*int64(time.Microsecond))
+	if t.After(MaxSupportedTime) || t.Before(MinSupportedTime) {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 // MakeDTimestampTZFromDate creates a DTimestampTZ from a DDate.
 // This will be equivalent to the midnight of the given zone.
 func MakeDTimestampTZFromDate(loc *time.Location, d *DDate) (*DTimestampTZ, error) {
@@ -62,10 +62,19 @@ var (
 	// ErrFloatOutOfRange is reported when float arithmetic overflows.
 	ErrFloatOutOfRange = pgerror.New(pgcode.NumericValueOutOfRange, "float out of range")
 	errDecOutOfRange   = pgerror.New(pgcode.NumericValueOutOfRange, "decimal out of range")
+	// ErrIntervalOutOfRange is reported when interval arithmetic overflows.
+	ErrIntervalOutOfRange = pgerror.New(pgcode.DatetimeFieldOverflow, "interval out of range")
 
 	// ErrDivByZero is reported on a division by zero.
-	ErrDivByZero       = pgerror.New(pgcode.DivisionByZero, "division by zero")
-	errSqrtOfNegNumber = pgerror.New(pgcode.InvalidArgumentForPowerFunction, "cannot take square root of a negative number")
+	ErrDivByZero = pgerror.New(pgcode.DivisionByZero, "division by zero")
+	// ErrSqrtOfNegNumber is reported when taking the square root of a negative
+	// number.
+	ErrSqrtOfNegNumber = pgerror.New(pgcode.InvalidArgumentForPowerFunction, "cannot take square root of a negative number")
+	// ErrLogOfNegNumber is reported when taking the logarithm of a negative
+	// number.
+	ErrLogOfNegNumber = pgerror.New(pgcode.InvalidArgumentForLogarithm, "cannot take logarithm of a negative number")
+	// ErrLogOfZero is reported when taking the logarithm of zero.
+	ErrLogOfZero = pgerror.New(pgcode.InvalidArgumentForLogarithm, "cannot take logarithm of zero")
 
 	// ErrShiftArgOutOfRange is reported when a shift argument is out of range.
 	ErrShiftArgOutOfRange = pgerror.New(pgcode.InvalidParameterValue, "shift argument out of range")
@@ -162,6 +171,9 @@ var UnaryOps = unaryOpFixups(map[UnaryOperator]unaryOpOverload{
 			ReturnType: types.Interval,
 			Fn: func(_ *EvalContext, d Datum) (Datum, error) {
 				i := d.(*DInterval).Duration
+				if i.Months == math.MinInt64 || i.Days == math.MinInt64 || i.Nanos() == math.MinInt64 {
+					return nil, ErrIntervalOutOfRange
+				}
 				i.SetNanos(-i.Nanos())
 				i.Days = -i.Days
 				i.Months = -i.Months
@@ -5250,6 +5262,13 @@ func SimilarToEscape(ctx *EvalContext, unescaped, pattern, escape string) (Datum
 	return matchRegexpWithKey(ctx, NewDString(unescaped), key)
 }
 
+// ConvertSimilarToRegexp compiles the specified SIMILAR TO pattern (with the
+// default escape character) as an equivalent regular expression.
+func ConvertSimilarToRegexp(ctx *EvalContext, pattern string) (*regexp.Regexp, error) {
+	key := similarToKey{s: pattern, escape: '\\'}
+	return ctx.ReCache.GetRegexp(key)
+}
+
 type regexpKey struct {
 	s               string
 	caseInsensitive bool
@@ -5466,7 +5485,7 @@ func (c *CallbackValueGenerator) Close(_ context.Context) {}
 // Sqrt returns the square root of x.
 func Sqrt(x float64) (*DFloat, error) {
 	if x < 0.0 {
-		return nil, errSqrtOfNegNumber
+		return nil, ErrSqrtOfNegNumber
 	}
 	return NewDFloat(DFloat(math.Sqrt(x))), nil
 }
@@ -5474,7 +5493,7 @@ func Sqrt(x float64) (*DFloat, error) {
 // DecimalSqrt returns the square root of x.
 func DecimalSqrt(x *apd.Decimal) (*DDecimal, error) {
 	if x.Sign() < 0 {
-		return nil, errSqrtOfNegNumber
+		return nil, ErrSqrtOfNegNumber
 	}
 	dd := &DDecimal{}
 	_, err := DecimalCtx.Sqrt(&dd.Decimal, x)
@@ -38,7 +38,121 @@ type SpecializedVectorizedBuiltin int
 // Keep this list alphabetized so that it is easy to manage.
 const (
 	_ SpecializedVectorizedBuiltin = iota
+	AbsDecimal
+	AcosFloat
+	AgeTimestampTZTimestampTZ
+	ArrayAppend
+	ArrayCat
+	ArrayLength
+	ArrayPosition
+	ArrayPositions
+	ArrayPrepend
+	ArrayRemove
+	ArrayReplace
+	ArrayToStringString
+	ArrayToStringStringString
+	ASCIIString
+	AsinFloat
+	Atan2FloatFloat
+	AtanFloat
+	BitCountBits
+	BitCountInt
+	BTrimString
+	BTrimStringString
+	Cardinality
+	CharLengthString
+	ChrInt
+	ConcatWS
+	CosFloat
+	CRC32C
+	CRC32IEEE
+	ExpFloat
+	ExtractStringTime
+	ExtractStringTimeTZ
+	FNV32
+	FNV32a
+	FNV64
+	FNV64a
+	GCDIntInt
+	GenerateSubscripts
+	GenRandomUUID
+	GetBitVarBitInt
+	HostINet
+	InitcapString
+	JSONArrayElements
+	JSONArrayElementsText
+	JSONBuildArray
+	JSONBuildObject
+	JSONEach
+	JSONEachText
+	JSONInsert
+	JSONInsertWithInsertAfter
+	JSONObjectKeys
+	JSONSet
+	JSONSetWithCreateMissing
+	JSONStripNulls
+	JSONTypeOf
+	LCMIntInt
+	LeftBytesInt
+	LeftStringInt
+	LnFloat
+	LogFloat
+	LogFloatFloat
+	LowerString
+	LPadStringInt
+	LPadStringIntString
+	LTrimString
+	LTrimStringString
+	MaskLenINet
+	MD5
+	ModDecimalDecimal
+	OctetLengthBytes
+	OctetLengthString
+	OverlayStringStringInt
+	OverlayStringStringIntInt
+	PGExpandArray
+	Random
+	RegexpMatchesStringString
+	RegexpMatchesStringStringString
+	RegexpSplitToArrayStringString
+	RegexpSplitToArrayStringStringString
+	ReplaceStringStringString
+	RightBytesInt
+	RightStringInt
+	RoundDecimal
+	RoundDecimalInt
+	RPadStringInt
+	RPadStringIntString
+	RTrimString
+	RTrimStringString
+	SetBitVarBitIntInt
+	SHA1
+	SHA224
+	SHA256
+	SHA384
+	SHA512
+	SinFloat
+	SplitPartStringStringInt
+	SqrtFloat
+	StrftimeDate
+	StrftimeTimestamp
+	StrftimeTimestampTZ
+	StringToArrayStringString
+	StringToArrayStringStringString
+	StrptimeStringString
 	SubstringStringIntInt
+	SubstringVarBitInt
+	SubstringVarBitIntInt
+	TanFloat
+	TimezoneStringTimestamp
+	TimezoneStringTimestampTZ
+	ToHexInt
+	ToTimestampDecimal
+	ToTimestampFloat
+	TruncDecimal
+	Unnest
+	UpperString
+	UUIDV4
 )
 
 // Overload is one of the overloads of a built-in function.
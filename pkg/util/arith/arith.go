@@ -88,3 +88,48 @@ func MulHalfPositiveWithOverflow(a, b int64) (r int64, ok bool) {
 	}
 	return a * b, true
 }
+
+// GCDWithOverflow returns the greatest common divisor of a and b. The result
+// is never negative, and the GCD of two zeroes is zero. If ok is false, the
+// result (2^63) doesn't fit into int64, which only happens if each of a and b
+// is either zero or math.MinInt64.
+func GCDWithOverflow(a, b int64) (r int64, ok bool) {
+	g := gcdUint64(absUint64(a), absUint64(b))
+	if g > math.MaxInt64 {
+		return 0, false
+	}
+	return int64(g), true
+}
+
+// LCMWithOverflow returns the least common multiple of a and b. The result is
+// never negative, and it is zero if either a or b is zero. If ok is false, the
+// result overflowed.
+func LCMWithOverflow(a, b int64) (r int64, ok bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	ua, ub := absUint64(a), absUint64(b)
+	x := ua / gcdUint64(ua, ub)
+	if x > math.MaxInt64/ub {
+		return 0, false
+	}
+	return int64(x * ub), true
+}
+
+// absUint64 returns the absolute value of a. Unlike with int64, the absolute
+// value of math.MinInt64 is representable.
+func absUint64(a int64) uint64 {
+	if a < 0 {
+		return -uint64(a)
+	}
+	return uint64(a)
+}
+
+// gcdUint64 returns the greatest common divisor of a and b using the
+// Euclidean algorithm.
+func gcdUint64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
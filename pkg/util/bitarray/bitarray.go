@@ -13,6 +13,7 @@ package bitarray
 import (
 	"bytes"
 	"fmt"
+	"math/bits"
 	"math/rand"
 	"unsafe"
 
@@ -153,6 +154,17 @@ func (d BitArray) IsEmpty() bool {
 	return d.lastBitsUsed == 0
 }
 
+// OnesCount returns the number of bits set to 1 in the array.
+func (d BitArray) OnesCount() int {
+	// The trailing unused bits of the last word are always zero, so they don't
+	// affect the count.
+	count := 0
+	for _, w := range d.words {
+		count += bits.OnesCount64(w)
+	}
+	return count
+}
+
 // MakeBitArrayFromInt64 creates a bit array with the specified
 // size. The bits from the integer are written to the right of the bit
 // array and the sign bit is extended.
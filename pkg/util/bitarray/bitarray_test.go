@@ -438,6 +438,37 @@ func TestNot(t *testing.T) {
 	}
 }
 
+func TestOnesCount(t *testing.T) {
+	testData := []struct {
+		val string
+		res int
+	}{
+		{"", 0},
+		{"0", 0},
+		{"1", 1},
+		{"1101", 3},
+		{"00000000000000000000000000000000" + "00000000000000000000000000000000", 0},
+		{"11111111111111111111111111111111" + "11111111111111111111111111111111", 64},
+		{"10111010101111101111101011001110" + "11001010111111101011101010111110" + "101", 46},
+	}
+
+	for _, test := range testData {
+		t.Run(test.val, func(t *testing.T) {
+			ba, err := Parse(test.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res := ba.OnesCount(); res != test.res {
+				t.Fatalf("expected %d, got %d", test.res, res)
+			}
+			// The result must be the same after the bits are flipped twice.
+			if res := Not(Not(ba)).OnesCount(); res != test.res {
+				t.Fatalf("expected %d after double negation, got %d", test.res, res)
+			}
+		})
+	}
+}
+
 func TestNext(t *testing.T) {
 	testData := []struct {
 		val, res string
@@ -0,0 +1,256 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+// Package tdigest implements the merging t-digest, a sketch that estimates the
+// quantiles of a stream of values using bounded memory. See "Computing
+// Extremely Accurate Quantiles Using t-Digests" by Ted Dunning and Otmar Ertl.
+package tdigest
+
+import (
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// DefaultCompression is the compression used by the digests unless specified
+// otherwise. With this compression the digest keeps at most around a hundred
+// centroids, and the error of the estimated quantiles is under one percent of
+// the ranks (the error is much smaller closer to the tails).
+const DefaultCompression = 100
+
+// bufferFactor determines the number of the values (as the multiple of the
+// compression) that are accumulated before they are merged into the
+// centroids.
+const bufferFactor = 5
+
+// centroid summarizes weight number of values with their mean.
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+type centroidsByMean []centroid
+
+func (c centroidsByMean) Len() int           { return len(c) }
+func (c centroidsByMean) Less(i, j int) bool { return c[i].mean < c[j].mean }
+func (c centroidsByMean) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// TDigest estimates the quantiles of the values added to it.
+//
+// The values are accumulated in a buffer and, once the buffer is full, they
+// are merged into the centroids. The number of the values summarized by a
+// single centroid is limited by the k1 scale function, so that the centroids
+// close to the tails are small (and the estimates there are accurate) while
+// the centroids in the middle are large. The number of merged centroids never
+// exceeds compression + 4 which, together with the buffer, bounds the memory
+// used by the digest, no matter how many values are added.
+//
+// NaN values are not added to the centroids; instead, they are counted
+// separately and are considered smaller than all other values (same as in
+// SQL).
+type TDigest struct {
+	compression float64
+	// centroids contains the merged centroids (sorted by their means)
+	// followed by the values that haven't been merged yet. The slice grows
+	// lazily, up to maxCentroids.
+	centroids    []centroid
+	numMerged    int
+	maxCentroids int
+	// weight is the total weight of all centroids (i.e. the number of non-NaN
+	// values added to the digest).
+	weight float64
+	numNaN int64
+	// min and max are the smallest and the largest non-NaN values.
+	min, max float64
+}
+
+// New returns a new empty digest with the given compression.
+func New(compression float64) *TDigest {
+	return &TDigest{
+		compression:  compression,
+		maxCentroids: maxCentroids(compression),
+	}
+}
+
+func maxCentroids(compression float64) int {
+	return bufferFactor * int(math.Ceil(compression)+4)
+}
+
+var (
+	sizeOfTDigest  = int64(unsafe.Sizeof(TDigest{}))
+	sizeOfCentroid = int64(unsafe.Sizeof(centroid{}))
+)
+
+// MaxMemUsage returns the upper bound on the memory used by a digest with the
+// given compression.
+func MaxMemUsage(compression float64) int64 {
+	return sizeOfTDigest + sizeOfCentroid*int64(maxCentroids(compression))
+}
+
+// MemUsage returns the memory currently used by the digest.
+func (t *TDigest) MemUsage() int64 {
+	return sizeOfTDigest + sizeOfCentroid*int64(cap(t.centroids))
+}
+
+// Count returns the number of values added to the digest.
+func (t *TDigest) Count() int64 {
+	return int64(t.weight) + t.numNaN
+}
+
+// Reset empties the digest. The memory used by the digest is kept for reuse.
+func (t *TDigest) Reset() {
+	t.centroids = t.centroids[:0]
+	t.numMerged = 0
+	t.weight = 0
+	t.numNaN = 0
+}
+
+// Add adds a value to the digest.
+func (t *TDigest) Add(x float64) {
+	if math.IsNaN(x) {
+		t.numNaN++
+		return
+	}
+	if len(t.centroids) == cap(t.centroids) {
+		if cap(t.centroids) < t.maxCentroids {
+			newCap := 2 * cap(t.centroids)
+			if newCap < 8 {
+				newCap = 8
+			} else if newCap > t.maxCentroids {
+				newCap = t.maxCentroids
+			}
+			centroids := make([]centroid, len(t.centroids), newCap)
+			copy(centroids, t.centroids)
+			t.centroids = centroids
+		} else {
+			t.compress()
+		}
+	}
+	if t.weight == 0 {
+		t.min, t.max = x, x
+	} else if x < t.min {
+		t.min = x
+	} else if x > t.max {
+		t.max = x
+	}
+	t.centroids = append(t.centroids, centroid{mean: x, weight: 1})
+	t.weight++
+}
+
+// scaleK is the k1 scale function which maps the quantile q to the scale k.
+func (t *TDigest) scaleK(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// scaleQ is the inverse of scaleK.
+func (t *TDigest) scaleQ(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+// compress merges the buffered values into the centroids.
+func (t *TDigest) compress() {
+	if t.numMerged == len(t.centroids) {
+		return
+	}
+	c := t.centroids
+	sort.Sort(centroidsByMean(c))
+	// The centroids are merged greedily, in place: the centroid at position
+	// cur absorbs the following ones as long as its weight doesn't exceed the
+	// limit (which corresponds to the increase of one in the scale). The
+	// first and the last centroids are never merged with others: the scale
+	// function saturates at the tails, so otherwise the extremes could be
+	// absorbed into large centroids, and the estimates close to 0 and 1 would
+	// drift away from the min and the max.
+	var cur int
+	var weightSoFar float64
+	weightLimit := t.weight * t.scaleQ(t.scaleK(0)+1)
+	for i := 1; i < len(c); i++ {
+		// The infinities can only be merged with each other since the mean of
+		// the infinity and a finite value is undefined.
+		canMerge := c[cur].mean == c[i].mean ||
+			(!math.IsInf(c[cur].mean, 0) && !math.IsInf(c[i].mean, 0))
+		canMerge = canMerge && cur > 0 && i < len(c)-1
+		if canMerge && weightSoFar+c[cur].weight+c[i].weight <= weightLimit {
+			c[cur].weight += c[i].weight
+			if c[i].mean != c[cur].mean {
+				c[cur].mean += (c[i].mean - c[cur].mean) * c[i].weight / c[cur].weight
+			}
+			continue
+		}
+		weightSoFar += c[cur].weight
+		weightLimit = t.weight * t.scaleQ(t.scaleK(weightSoFar/t.weight)+1)
+		cur++
+		c[cur] = c[i]
+	}
+	t.centroids = c[:cur+1]
+	t.numMerged = len(t.centroids)
+}
+
+// Quantile returns the estimate of the q-quantile (q must be within [0, 1])
+// of the values added to the digest. The estimate is the linear interpolation
+// between the centroids (the values of a centroid are assumed to be spread
+// evenly around its mean), so if all centroids summarize a single value each,
+// the result is exact and is the same as the continuous percentile. The
+// quantiles 0 and 1 are always exact (unless they fall onto NaNs). NaN is
+// returned if the digest is empty.
+//
+// Note that the buffered values are merged into the centroids, but no memory
+// is allocated.
+func (t *TDigest) Quantile(q float64) float64 {
+	if t.Count() == 0 {
+		return math.NaN()
+	}
+	// rank is the (zero-based, fractional) position of the quantile among
+	// all values sorted in the ascending order.
+	rank := q * float64(t.Count()-1)
+	if rank < float64(t.numNaN) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	} else if q >= 1 {
+		return t.max
+	}
+	rank -= float64(t.numNaN)
+	t.compress()
+	c := t.centroids
+	// center is the rank of the mean of the current centroid.
+	center := (c[0].weight - 1) / 2
+	if rank <= center {
+		return interpolate(t.min, 0, c[0].mean, center, rank)
+	}
+	weightSoFar := c[0].weight
+	for i := 1; i < len(c); i++ {
+		nextCenter := weightSoFar + (c[i].weight-1)/2
+		if rank <= nextCenter {
+			return interpolate(c[i-1].mean, center, c[i].mean, nextCenter, rank)
+		}
+		center = nextCenter
+		weightSoFar += c[i].weight
+	}
+	return interpolate(c[len(c)-1].mean, center, t.max, t.weight-1, rank)
+}
+
+// interpolate returns the value at rank on the line going through the points
+// (fromRank, from) and (toRank, to).
+func interpolate(from, fromRank, to, toRank, rank float64) float64 {
+	// Note that equal from and to are returned as is since the interpolation
+	// could be off by the rounding error (or be NaN for the infinities).
+	if rank <= fromRank || from == to {
+		return from
+	} else if rank >= toRank {
+		return to
+	}
+	f := (rank - fromRank) / (toRank - fromRank)
+	return (1-f)*from + f*to
+}
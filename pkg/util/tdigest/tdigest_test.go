@@ -0,0 +1,149 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package tdigest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/stretchr/testify/require"
+)
+
+// exactQuantile returns the continuous percentile of the sorted values.
+func exactQuantile(sorted []float64, q float64) float64 {
+	rank := q * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(rank)), int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+	f := rank - float64(lo)
+	return (1-f)*sorted[lo] + f*sorted[hi]
+}
+
+var testQuantiles = []float64{0, 0.001, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1}
+
+func TestTDigestSmallInputIsExact(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	for n := 1; n <= 20; n++ {
+		d := New(DefaultCompression)
+		values := make([]float64, n)
+		for i := range values {
+			values[i] = rng.NormFloat64() * 100
+			d.Add(values[i])
+		}
+		sort.Float64s(values)
+		require.Equal(t, int64(n), d.Count())
+		for _, q := range testQuantiles {
+			require.InDelta(t, exactQuantile(values, q), d.Quantile(q), 1e-9, "n=%d q=%f", n, q)
+		}
+	}
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	const n = 200000
+	for _, tc := range []struct {
+		name string
+		gen  func() float64
+	}{
+		{name: "uniform", gen: func() float64 { return rng.Float64() * 1000 }},
+		{name: "normal", gen: func() float64 { return rng.NormFloat64()*10 + 50 }},
+		{name: "exponential", gen: rng.ExpFloat64},
+		{name: "few distinct", gen: func() float64 { return float64(rng.Intn(10)) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New(DefaultCompression)
+			values := make([]float64, n)
+			for i := range values {
+				values[i] = tc.gen()
+				d.Add(values[i])
+			}
+			sort.Float64s(values)
+			for _, q := range testQuantiles {
+				estimate := d.Quantile(q)
+				// The estimate must be between the values at the ranks that
+				// are within the tolerance from the rank of the quantile. The
+				// tolerance is tighter for the tails.
+				tolerance := 0.01
+				if q <= 0.01 || q >= 0.99 {
+					tolerance = 0.001
+				}
+				lo := exactQuantile(values, math.Max(0, q-tolerance))
+				hi := exactQuantile(values, math.Min(1, q+tolerance))
+				require.True(
+					t, lo <= estimate && estimate <= hi,
+					"q=%f: estimate %f is not within [%f, %f]", q, estimate, lo, hi,
+				)
+			}
+			require.Equal(t, values[0], d.Quantile(0))
+			require.Equal(t, values[n-1], d.Quantile(1))
+		})
+	}
+}
+
+func TestTDigestMemoryIsBounded(t *testing.T) {
+	rng, _ := randutil.NewPseudoRand()
+	for _, compression := range []float64{10, DefaultCompression, 500} {
+		t.Run(fmt.Sprintf("compression=%.0f", compression), func(t *testing.T) {
+			d := New(compression)
+			for i := 0; i < 1000000; i++ {
+				d.Add(rng.Float64())
+				if i%1000 == 0 {
+					require.LessOrEqual(t, d.MemUsage(), MaxMemUsage(compression))
+				}
+			}
+			d.Quantile(0.5)
+			require.LessOrEqual(t, len(d.centroids), int(compression)+4)
+			require.LessOrEqual(t, d.MemUsage(), MaxMemUsage(compression))
+
+			// The memory is kept for reuse after the reset.
+			memUsage := d.MemUsage()
+			d.Reset()
+			require.Equal(t, int64(0), d.Count())
+			require.True(t, math.IsNaN(d.Quantile(0.5)))
+			d.Add(1)
+			require.Equal(t, memUsage, d.MemUsage())
+			require.Equal(t, float64(1), d.Quantile(0.5))
+		})
+	}
+}
+
+func TestTDigestSpecialValues(t *testing.T) {
+	d := New(DefaultCompression)
+	require.True(t, math.IsNaN(d.Quantile(0.5)))
+	for _, v := range []float64{math.Inf(1), 3, math.NaN(), 1, math.Inf(-1), math.NaN(), 2} {
+		d.Add(v)
+	}
+	// NaN values are smaller than all other values.
+	require.Equal(t, int64(7), d.Count())
+	require.True(t, math.IsNaN(d.Quantile(0)))
+	require.True(t, math.IsNaN(d.Quantile(1.0/6)))
+	require.True(t, math.IsNaN(d.Quantile(0.2)))
+	require.Equal(t, math.Inf(-1), d.Quantile(2.0/6))
+	require.Equal(t, float64(1), d.Quantile(3.0/6))
+	require.Equal(t, 1.5, d.Quantile(3.5/6))
+	require.Equal(t, float64(3), d.Quantile(5.0/6))
+	require.Equal(t, math.Inf(1), d.Quantile(1))
+
+	// The infinities are never merged with the finite values.
+	d = New(10)
+	for i := 0; i < 10000; i++ {
+		d.Add(math.Inf(-1))
+		d.Add(float64(i))
+		d.Add(math.Inf(1))
+	}
+	require.Equal(t, math.Inf(-1), d.Quantile(0.3))
+	require.False(t, math.IsInf(d.Quantile(0.5), 0))
+	require.Equal(t, math.Inf(1), d.Quantile(0.7))
+}